@@ -0,0 +1,238 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ==========================================
+// DOCX
+// ==========================================
+
+// streamEntry 流式标识在 ZIP 容器中的条目，以 Store 方式保存以保持原始字节
+const streamEntry = "customXml/stream.bin"
+
+// escapeXML 转义 XML 文本
+func escapeXML(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// zipFile ZIP 容器中的一个条目
+type zipFile struct {
+	name   string
+	data   []byte
+	stored bool
+}
+
+// writeZip 按顺序写出 ZIP 条目
+func writeZip(w io.Writer, files []zipFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		method := zip.Deflate
+		if f.stored {
+			method = zip.Store
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: method})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeDOCX 生成 DOCX，红头使用 w:color FF0000，密级可同时写入自定义属性 SecretLevel
+func writeDOCX(w io.Writer, s *docSpec) error {
+	var doc strings.Builder
+	doc.WriteString(xml.Header)
+	doc.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for _, l := range s.lines() {
+		doc.WriteString(`<w:p><w:r><w:rPr><w:rFonts w:eastAsia="宋体"/>`)
+		if l.Red {
+			doc.WriteString(`<w:color w:val="FF0000"/>`)
+		}
+		// w:sz 以半磅为单位
+		fmt.Fprintf(&doc, `<w:sz w:val="%d"/></w:rPr><w:t>%s</w:t></w:r></w:p>`, l.Size*2, escapeXML(l.Text))
+	}
+	doc.WriteString(`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/></w:sectPr></w:body></w:document>`)
+
+	meta := s.Meta && s.Level != ""
+	var types, rels strings.Builder
+	types.WriteString(xml.Header)
+	types.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="bin" ContentType="application/octet-stream"/>` +
+		`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>`)
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>`)
+	if meta {
+		types.WriteString(`<Override PartName="/docProps/custom.xml" ContentType="application/vnd.openxmlformats-officedocument.custom-properties+xml"/>`)
+		rels.WriteString(`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties" Target="docProps/custom.xml"/>`)
+	}
+	types.WriteString(`</Types>`)
+	rels.WriteString(`</Relationships>`)
+
+	files := []zipFile{
+		{name: "[Content_Types].xml", data: []byte(types.String())},
+		{name: "_rels/.rels", data: []byte(rels.String())},
+		{name: "word/document.xml", data: []byte(doc.String())},
+	}
+	if meta {
+		custom := xml.Header +
+			`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" ` +
+			`xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">` +
+			`<property fmtid="{D5CDD505-2E9C-101B-9397-08002B2CF9AE}" pid="2" name="SecretLevel">` +
+			`<vt:lpwstr>` + escapeXML(s.marking()) + `</vt:lpwstr></property></Properties>`
+		files = append(files, zipFile{name: "docProps/custom.xml", data: []byte(custom)})
+	}
+	if len(s.Stream) > 0 {
+		files = append(files, zipFile{name: streamEntry, data: s.Stream, stored: true})
+	}
+	return writeZip(w, files)
+}
+
+// ==========================================
+// PDF
+// ==========================================
+
+// pdfText 将文本编码为 UCS-2 十六进制串，配合 UniGB-UCS2-H 编码的 Type0 字体
+// 不在基本多文种平面的字符以 "?" 代替
+func pdfText(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('<')
+	for _, r := range s {
+		if r > 0xFFFF {
+			r = '?'
+		}
+		fmt.Fprintf(&sb, "%04X", r)
+	}
+	sb.WriteByte('>')
+	return sb.String()
+}
+
+// writePDF 生成单页 PDF，使用内置的 STSong-Light 字体，不嵌入字体文件
+// 红头以 "1 0 0 rg" 着色；流式标识保存为未被引用的流对象，阅读器会忽略它
+func writePDF(w io.Writer, s *docSpec) error {
+	var content strings.Builder
+	content.WriteString("BT\n72 780 Td\n")
+	for i, l := range s.lines() {
+		if i > 0 {
+			// 按本行字号下移
+			fmt.Fprintf(&content, "0 -%d Td\n", l.Size*3/2)
+		}
+		if l.Red {
+			content.WriteString("1 0 0 rg\n")
+		} else {
+			content.WriteString("0 0 0 rg\n")
+		}
+		fmt.Fprintf(&content, "/F1 %d Tf\n%s Tj\n", l.Size, pdfText(l.Text))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 4 0 R >> >> /Contents 6 0 R >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [5 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor 7 0 R >>",
+		pdfStream([]byte(content.String())),
+		"<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>",
+	}
+	if len(s.Stream) > 0 {
+		objects = append(objects, pdfStream(s.Stream))
+	}
+
+	var buf bytes.Buffer
+	// 第二行的高位字节提示传输工具按二进制处理
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	info := fmt.Sprintf("/Title %s", pdfText(s.Title))
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info << %s >> >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, info, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfStream 不压缩的流对象
+func pdfStream(data []byte) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+// ==========================================
+// OFD
+// ==========================================
+
+const ofdNS = `xmlns:ofd="http://www.ofdspec.org/2016"`
+
+// writeOFD 生成单页 OFD: OFD.xml -> Doc_0/Document.xml -> Doc_0/Pages/Page_0/Content.xml
+// 红头以 FillColor "255 0 0" 着色，密级可同时写入 DocInfo 的 CustomData
+func writeOFD(w io.Writer, s *docSpec) error {
+	var custom string
+	if s.Meta && s.Level != "" {
+		custom = `<ofd:CustomDatas><ofd:CustomData Name="SecretLevel">` + escapeXML(s.marking()) + `</ofd:CustomData></ofd:CustomDatas>`
+	}
+	ofd := xml.Header +
+		`<ofd:OFD ` + ofdNS + ` Version="1.0" DocType="OFD"><ofd:DocBody>` +
+		`<ofd:DocInfo><ofd:DocID>corpusgen</ofd:DocID><ofd:Title>` + escapeXML(s.Title) + `</ofd:Title>` +
+		`<ofd:Creator>` + toolName + `</ofd:Creator>` + custom + `</ofd:DocInfo>` +
+		`<ofd:DocRoot>Doc_0/Document.xml</ofd:DocRoot></ofd:DocBody></ofd:OFD>`
+
+	document := xml.Header +
+		`<ofd:Document ` + ofdNS + `><ofd:CommonData><ofd:MaxUnitID>100</ofd:MaxUnitID>` +
+		`<ofd:PageArea><ofd:PhysicalBox>0 0 210 297</ofd:PhysicalBox></ofd:PageArea>` +
+		`<ofd:PublicRes>PublicRes.xml</ofd:PublicRes></ofd:CommonData>` +
+		`<ofd:Pages><ofd:Page ID="1" BaseLoc="Pages/Page_0/Content.xml"/></ofd:Pages></ofd:Document>`
+
+	res := xml.Header +
+		`<ofd:Res ` + ofdNS + ` BaseLoc="Res"><ofd:Fonts>` +
+		`<ofd:Font ID="2" FontName="宋体" FamilyName="宋体"/></ofd:Fonts></ofd:Res>`
+
+	var page strings.Builder
+	page.WriteString(xml.Header)
+	page.WriteString(`<ofd:Page ` + ofdNS + `><ofd:Content><ofd:Layer ID="3">`)
+	// 坐标以毫米为单位，1 磅约 0.353 毫米
+	y := 25.0
+	for i, l := range s.lines() {
+		h := float64(l.Size) * 0.353
+		color := "0 0 0"
+		if l.Red {
+			color = "255 0 0"
+		}
+		fmt.Fprintf(&page, `<ofd:TextObject ID="%d" Boundary="25 %.1f 160 %.1f" Font="2" Size="%.1f">`+
+			`<ofd:FillColor Value="%s"/><ofd:TextCode X="0" Y="%.1f">%s</ofd:TextCode></ofd:TextObject>`,
+			10+i, y, h*1.5, h, color, h, escapeXML(l.Text))
+		y += h * 1.5
+	}
+	page.WriteString(`</ofd:Layer></ofd:Content></ofd:Page>`)
+
+	files := []zipFile{
+		{name: "OFD.xml", data: []byte(ofd)},
+		{name: "Doc_0/Document.xml", data: []byte(document)},
+		{name: "Doc_0/PublicRes.xml", data: []byte(res)},
+		{name: "Doc_0/Pages/Page_0/Content.xml", data: []byte(page.String())},
+	}
+	if len(s.Stream) > 0 {
+		files = append(files, zipFile{name: "Doc_0/Res/stream.bin", data: s.Stream, stored: true})
+	}
+	return writeZip(w, files)
+}
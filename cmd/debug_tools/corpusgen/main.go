@@ -0,0 +1,443 @@
+// Package main 测试语料生成工具
+// 按指定的密级标志、红头、发文字号、流式标识与压缩包嵌套生成 DOCX/PDF/OFD 样本，
+// 用于在没有真实涉密文件的情况下验证检测模块的改动
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ==========================================
+// 命令行参数
+// ==========================================
+
+var (
+	outputDir string // 输出目录
+	formats   string // 生成的格式
+	baseName  string // 文件名（不含扩展名）
+	matrix    bool   // 生成全部特征组合
+
+	// 文档内容
+	level     string // 密级
+	term      string // 保密期限
+	redHeader bool   // 红头
+	issuer    string // 发文机关标志
+	docNumber string // 发文字号
+	title     string // 标题
+	body      string // 正文
+	withMeta  bool   // 密级写入文档属性
+
+	// 流式标识
+	streamHex    string // 流式标识（十六进制）
+	streamBase64 string // 流式标识（Base64）
+	streamRules  string // 流式标识规则文件
+	streamRuleID int64  // 从规则文件中选取的规则 ID
+
+	nestDepth int  // 压缩包嵌套层数
+	showHelp  bool // 显示帮助
+)
+
+const (
+	toolName    = "corpusgen"
+	toolVersion = "1.0.0"
+)
+
+func init() {
+	flag.StringVar(&outputDir, "o", "corpus", "输出目录")
+	flag.StringVar(&formats, "formats", "docx,pdf,ofd", "生成的格式：docx, pdf, ofd（逗号分隔）")
+	flag.StringVar(&baseName, "name", "sample", "文件名（不含扩展名），-matrix 时忽略")
+	flag.BoolVar(&matrix, "matrix", false, "生成密级、红头、发文字号、流式标识的全部组合")
+
+	flag.StringVar(&level, "level", "机密", "密级：绝密, 机密, 秘密, none")
+	flag.StringVar(&term, "term", "10年", "保密期限，为空时只标注密级")
+	flag.BoolVar(&redHeader, "red", true, "生成红色发文机关标志（红头）")
+	flag.StringVar(&issuer, "issuer", "某某市人民政府文件", "发文机关标志")
+	flag.StringVar(&docNumber, "docno", "某政发〔2024〕1号", "发文字号，为空时不生成")
+	flag.StringVar(&title, "title", "关于开展专项检查工作的通知", "标题")
+	flag.StringVar(&body, "body", "各区县人民政府，市政府各部门：\n现就有关事项通知如下。", "正文，段落以 \\n 分隔")
+	flag.BoolVar(&withMeta, "meta", false, "同时将密级写入文档属性（DOCX 自定义属性 / OFD CustomData）")
+
+	flag.StringVar(&streamHex, "stream-hex", "", "嵌入的流式标识（十六进制）")
+	flag.StringVar(&streamBase64, "stream-base64", "", "嵌入的流式标识（Base64）")
+	flag.StringVar(&streamRules, "stream-rules", "", "从流式标识规则文件（JSON）中选取标识")
+	flag.Int64Var(&streamRuleID, "stream-id", 0, "选取的规则 ID（0=第一条）")
+
+	flag.IntVar(&nestDepth, "nest", 0, "将每个样本嵌套打包到 N 层 ZIP 中")
+	flag.BoolVar(&showHelp, "help", false, "显示帮助信息")
+	flag.BoolVar(&showHelp, "h", false, "显示帮助信息（简写）")
+}
+
+// ==========================================
+// 数据结构
+// ==========================================
+
+// docSpec 一个样本的特征
+type docSpec struct {
+	Level     string
+	Term      string
+	RedHeader bool
+	Issuer    string
+	DocNumber string
+	Title     string
+	Body      []string
+	Meta      bool
+	Stream    []byte
+}
+
+// marking 密级标志，如 "机密★10年"
+func (s *docSpec) marking() string {
+	if s.Level == "" {
+		return ""
+	}
+	if s.Term == "" {
+		return s.Level
+	}
+	return s.Level + "★" + s.Term
+}
+
+// docLine 文档中的一行
+type docLine struct {
+	Text string
+	Red  bool
+	Size int // 字号（磅）
+}
+
+// lines 版头到正文的各行，各格式的生成器共用
+func (s *docSpec) lines() []docLine {
+	var out []docLine
+	if m := s.marking(); m != "" {
+		out = append(out, docLine{Text: m, Size: 16})
+	}
+	if s.Issuer != "" {
+		out = append(out, docLine{Text: s.Issuer, Red: s.RedHeader, Size: 26})
+	}
+	if s.DocNumber != "" {
+		out = append(out, docLine{Text: s.DocNumber, Size: 16})
+	}
+	out = append(out, docLine{Text: s.Title, Size: 22})
+	for _, p := range s.Body {
+		out = append(out, docLine{Text: p, Size: 16})
+	}
+	return out
+}
+
+// manifestEntry 生成的样本及其特征，供编写期望结果与标注文件参考
+type manifestEntry struct {
+	File         string `json:"file"`
+	Format       string `json:"format"`
+	SecretLevel  string `json:"secret_level,omitempty"`
+	RedHeader    bool   `json:"red_header"`
+	DocNumber    bool   `json:"doc_number"`
+	Meta         bool   `json:"meta,omitempty"`
+	StreamMarker bool   `json:"stream_marker"`
+	NestDepth    int    `json:"nest_depth,omitempty"`
+}
+
+// streamRule 流式标识规则文件中的一条规则，rule_content 为 Base64
+type streamRule struct {
+	RuleID      int64  `json:"rule_id"`
+	RuleContent []byte `json:"rule_content"`
+	RuleDesc    string `json:"rule_desc"`
+}
+
+// generators 按格式生成样本
+var generators = map[string]func(io.Writer, *docSpec) error{
+	"docx": writeDOCX,
+	"pdf":  writePDF,
+	"ofd":  writeOFD,
+}
+
+// ==========================================
+// 主函数
+// ==========================================
+
+func main() {
+	flag.Usage = printUsage
+	flag.Parse()
+	if showHelp {
+		printUsage()
+		return
+	}
+
+	selected, err := parseFormats(formats)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(2)
+	}
+	lvl, err := parseLevel(level)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(2)
+	}
+	stream, err := loadStream()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(2)
+	}
+	if nestDepth < 0 {
+		fmt.Fprintln(os.Stderr, "错误: -nest 不能为负数")
+		os.Exit(2)
+	}
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 创建输出目录失败: %v\n", err)
+		os.Exit(2)
+	}
+
+	base := docSpec{
+		Level:     lvl,
+		Term:      term,
+		RedHeader: redHeader,
+		Issuer:    issuer,
+		DocNumber: docNumber,
+		Title:     title,
+		Body:      strings.Split(strings.ReplaceAll(body, `\n`, "\n"), "\n"),
+		Meta:      withMeta,
+		Stream:    stream,
+	}
+	specs := map[string]docSpec{baseName: base}
+	if matrix {
+		specs = buildMatrix(base)
+	}
+
+	var manifest []manifestEntry
+	for name, spec := range specs {
+		spec := spec
+		for _, ext := range selected {
+			file, err := generate(name, ext, &spec)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: 生成 %s.%s 失败: %v\n", name, ext, err)
+				os.Exit(2)
+			}
+			manifest = append(manifest, manifestEntry{
+				File:         file,
+				Format:       ext,
+				SecretLevel:  spec.Level,
+				RedHeader:    spec.RedHeader && spec.Issuer != "",
+				DocNumber:    spec.DocNumber != "",
+				Meta:         spec.Meta && spec.Level != "",
+				StreamMarker: len(spec.Stream) > 0,
+				NestDepth:    nestDepth,
+			})
+		}
+	}
+	sort.Slice(manifest, func(i, j int) bool { return manifest[i].File < manifest[j].File })
+
+	if err := writeManifest(manifest); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Printf("%s %s: 已在 %s 生成 %d 个样本 (manifest.json, labels.csv)\n", toolName, toolVersion, outputDir, len(manifest))
+}
+
+// parseFormats 解析格式列表
+func parseFormats(s string) ([]string, error) {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if _, ok := generators[f]; !ok {
+			return nil, fmt.Errorf("未知格式: %s，可选: docx, pdf, ofd", f)
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// parseLevel 解析密级，none 或空表示不标注
+func parseLevel(s string) (string, error) {
+	switch s {
+	case "", "none":
+		return "", nil
+	case "绝密", "机密", "秘密":
+		return s, nil
+	}
+	return "", fmt.Errorf("无效的密级 %q，可选: 绝密, 机密, 秘密, none", s)
+}
+
+// loadStream 读取要嵌入的流式标识，未指定时返回 nil
+func loadStream() ([]byte, error) {
+	switch {
+	case streamHex != "":
+		b, err := hex.DecodeString(strings.ReplaceAll(streamHex, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("解析 -stream-hex 失败: %w", err)
+		}
+		return b, nil
+	case streamBase64 != "":
+		b, err := base64.StdEncoding.DecodeString(streamBase64)
+		if err != nil {
+			return nil, fmt.Errorf("解析 -stream-base64 失败: %w", err)
+		}
+		return b, nil
+	case streamRules != "":
+		data, err := os.ReadFile(streamRules)
+		if err != nil {
+			return nil, err
+		}
+		// 兼容 {"rules": [...]} 与规则数组两种格式
+		var file struct {
+			Rules []streamRule `json:"rules"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil || file.Rules == nil {
+			if err := json.Unmarshal(data, &file.Rules); err != nil {
+				return nil, fmt.Errorf("解析规则文件失败: %w", err)
+			}
+		}
+		for _, r := range file.Rules {
+			if streamRuleID == 0 || r.RuleID == streamRuleID {
+				if len(r.RuleContent) == 0 {
+					return nil, fmt.Errorf("规则 %d 内容为空", r.RuleID)
+				}
+				return r.RuleContent, nil
+			}
+		}
+		return nil, fmt.Errorf("规则文件中没有 ID 为 %d 的规则", streamRuleID)
+	}
+	return nil, nil
+}
+
+// buildMatrix 密级 × 红头 × 发文字号 × 流式标识的全部组合，未指定流式标识时不含该维度
+// 文件名依次标注各特征，如 secret-red-docno-stream，没有任何特征的为 plain
+func buildMatrix(base docSpec) map[string]docSpec {
+	levels := []struct{ name, level string }{
+		{"", ""}, {"top-secret", "绝密"}, {"secret", "机密"}, {"confidential", "秘密"},
+	}
+	streams := [][]byte{nil}
+	if len(base.Stream) > 0 {
+		streams = append(streams, base.Stream)
+	}
+	specs := make(map[string]docSpec)
+	for _, l := range levels {
+		for _, red := range []bool{false, true} {
+			for _, docno := range []bool{false, true} {
+				for _, stream := range streams {
+					s := base
+					s.Level, s.RedHeader, s.Stream = l.level, red, stream
+					var parts []string
+					if l.name != "" {
+						parts = append(parts, l.name)
+					}
+					if red {
+						parts = append(parts, "red")
+					}
+					if docno {
+						parts = append(parts, "docno")
+					} else {
+						s.DocNumber = ""
+					}
+					if stream != nil {
+						parts = append(parts, "stream")
+					}
+					name := "plain"
+					if len(parts) > 0 {
+						name = strings.Join(parts, "-")
+					}
+					specs[name] = s
+				}
+			}
+		}
+	}
+	return specs
+}
+
+// generate 生成一个样本，按 -nest 嵌套打包，返回相对于输出目录的文件名
+func generate(name, ext string, spec *docSpec) (string, error) {
+	inner := name + "." + ext
+	var buf bytes.Buffer
+	if err := generators[ext](&buf, spec); err != nil {
+		return "", err
+	}
+	data := buf.Bytes()
+	for i := 1; i <= nestDepth; i++ {
+		var err error
+		if data, err = wrapZip(inner, data); err != nil {
+			return "", err
+		}
+		inner = fmt.Sprintf("%s_%s_nest%d.zip", name, ext, i)
+	}
+	return inner, os.WriteFile(filepath.Join(outputDir, inner), data, 0644)
+}
+
+// wrapZip 将文件打包为只含该文件的 ZIP
+func wrapZip(name string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	return buf.Bytes(), err
+}
+
+// writeManifest 写出样本清单与 govcheck -labels 可用的标注文件
+// 同时具备红头与发文字号的样本标注为公文
+func writeManifest(entries []manifestEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "manifest.json"), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入 manifest.json 失败: %w", err)
+	}
+
+	f, err := os.Create(filepath.Join(outputDir, "labels.csv"))
+	if err != nil {
+		return fmt.Errorf("写入 labels.csv 失败: %w", err)
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "# 由 %s 生成: 同时具备红头与发文字号的样本标注为公文\n", toolName)
+	w := csv.NewWriter(f)
+	for _, e := range entries {
+		label := "0"
+		if e.RedHeader && e.DocNumber {
+			label = "1"
+		}
+		w.Write([]string{e.File, label})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printUsage() {
+	fmt.Printf(`%s %s - 测试语料生成工具
+
+用法:
+  %s [选项]
+
+选项:
+`, toolName, toolVersion, toolName)
+	flag.PrintDefaults()
+	fmt.Printf(`
+说明:
+  DOCX 与 OFD 中的流式标识以不压缩的 ZIP 条目保存，PDF 中以未被引用的流对象保存，
+  均保持原始字节，可被按字节匹配的流式标识检测发现。
+  输出目录中同时生成 manifest.json (样本特征) 与 labels.csv (govcheck -labels 标注文件)。
+
+示例:
+  # 生成一组机密、红头、带发文字号的样本
+  %s -o corpus
+
+  # 生成全部特征组合，嵌入规则文件中的流式标识，并嵌套两层 ZIP
+  %s -o corpus -matrix -stream-rules ../stream_marker/rules.json -nest 2
+
+  # 生成不带密级与红头的 PDF
+  %s -o corpus -formats pdf -level none -red=false -docno "" -name clean
+
+  # 配合 detector-debug 生成回归期望结果
+  %s -o corpus -matrix && detector-debug -p corpus --regress corpus.expected.json --regress-update
+`, toolName, toolName, toolName, toolName)
+}
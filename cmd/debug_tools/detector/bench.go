@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"linuxFileWatcher/cmd/debug_tools/internal/bench"
+)
+
+// ==========================================
+// 性能基准 (--bench)
+// ==========================================
+//
+// 按当前的模块开关与规则重复扫描 -p 指定的文件，输出吞吐、延迟分位数与内存分配，
+// --format json 的结果可保存下来与其他版本对比。
+
+// runBench 执行性能基准，返回退出码: 0 完成，2 参数或文件错误
+func runBench() int {
+	// 只输出基准结果
+	quiet = true
+	mgr := initDetectorManager()
+	applyRules(mgr, preloadRules())
+
+	files, err := targetFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "错误: 没有找到待测试的文件")
+		return 2
+	}
+
+	result := bench.Run(toolName, toolVersion, files, bench.Options{
+		Warmup:  benchWarmup,
+		Rounds:  benchRounds,
+		Workers: workers,
+	}, func(path string) error {
+		if r := scanFile(mgr, path); r.Error != "" {
+			return errors.New(r.Error)
+		}
+		return nil
+	})
+
+	if err := result.Write(outputFormat, outputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 输出基准结果失败: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ==========================================
+// 结果比对 (diff 子命令)
+// ==========================================
+//
+// 比对两次扫描的 JSON 结果 (--format json -o 保存)，按文件路径逐个比较:
+//   新增命中   旧结果未命中，新结果命中
+//   命中消除   旧结果命中，新结果未命中
+//   命中变化   两次都命中，但告警类型、规则或密级不同
+//   新增失败   旧结果正常，新结果检测失败
+// 只出现在一侧的文件单独列出，不参与以上比较。用于规则更新下发前确认影响范围。
+
+// diffItem 一个结果不同的文件
+type diffItem struct {
+	File     string          `json:"file"`
+	Old      *expectedResult `json:"old,omitempty"`
+	New      *expectedResult `json:"new,omitempty"`
+	RuleDesc string          `json:"rule_desc,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// diffReport 比对报告
+type diffReport struct {
+	Old           string     `json:"old"`
+	New           string     `json:"new"`
+	OldFiles      int        `json:"old_files"`
+	NewFiles      int        `json:"new_files"`
+	Unchanged     int        `json:"unchanged"`
+	Differences   int        `json:"differences"`
+	NewDetections []diffItem `json:"new_detections"`
+	Resolved      []diffItem `json:"resolved"`
+	Changed       []diffItem `json:"changed"`
+	NewErrors     []diffItem `json:"new_errors"`
+	OnlyInOld     []string   `json:"only_in_old"`
+	OnlyInNew     []string   `json:"only_in_new"`
+}
+
+// runDiff 执行 diff 子命令，返回退出码: 0 结果相同，1 有差异，2 参数或文件错误
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet(toolName+" diff", flag.ContinueOnError)
+	fs.StringVar(&outputFile, "output", "", "输出文件路径")
+	fs.StringVar(&outputFile, "o", "", "输出文件路径（简写）")
+	fs.StringVar(&outputFormat, "format", "text", "输出格式: text, json")
+	fs.Usage = func() {
+		fmt.Printf(`用法:
+  %s diff [选项] <旧结果.json> <新结果.json>
+
+比对两次扫描的 JSON 结果，报告新增命中、命中消除、命中变化与新增失败。
+
+选项:
+  -o, --output <文件>    输出文件路径
+      --format <格式>    输出格式: text, json (默认: text)
+
+退出码:
+  0    两次结果相同
+  1    有差异
+  2    参数或文件错误
+`, toolName)
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "错误: diff 需要指定旧结果与新结果两个 JSON 文件")
+		fs.Usage()
+		return 2
+	}
+
+	oldSummary, err := loadScanSummary(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	newSummary, err := loadScanSummary(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+
+	report := diffSummaries(oldSummary, newSummary)
+	report.Old, report.New = fs.Arg(0), fs.Arg(1)
+	if err := writeDiffReport(report); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	if report.Differences > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadScanSummary 读取 --format json 保存的扫描结果
+func loadScanSummary(path string) (*ScanSummary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s ScanSummary
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析扫描结果 %s 失败 (需要 --format json 保存的结果): %v", path, err)
+	}
+	return &s, nil
+}
+
+// diffSummaries 按文件路径比对两次扫描结果
+func diffSummaries(oldSummary, newSummary *ScanSummary) *diffReport {
+	report := &diffReport{
+		// 空列表输出为 []，便于脚本处理
+		NewDetections: []diffItem{},
+		Resolved:      []diffItem{},
+		Changed:       []diffItem{},
+		NewErrors:     []diffItem{},
+		OnlyInOld:     []string{},
+		OnlyInNew:     []string{},
+	}
+	oldByPath := make(map[string]ScanResult, len(oldSummary.Results))
+	for _, r := range oldSummary.Results {
+		oldByPath[r.FilePath] = r
+	}
+	newByPath := make(map[string]ScanResult, len(newSummary.Results))
+	for _, r := range newSummary.Results {
+		newByPath[r.FilePath] = r
+	}
+	report.OldFiles, report.NewFiles = len(oldByPath), len(newByPath)
+
+	for path, n := range newByPath {
+		o, ok := oldByPath[path]
+		if !ok {
+			report.OnlyInNew = append(report.OnlyInNew, path)
+			continue
+		}
+		before, after := toExpected(o), toExpected(n)
+		item := diffItem{File: path, Old: &before, New: &after, RuleDesc: n.RuleDesc}
+		switch {
+		case n.Error != "" && o.Error == "":
+			item.New, item.Error = nil, n.Error
+			report.NewErrors = append(report.NewErrors, item)
+		case n.Error != "" || o.Error != "":
+			// 两次都失败或失败已恢复，命中结果不可比，不计入差异
+			report.Unchanged++
+		case !before.Detected && after.Detected:
+			report.NewDetections = append(report.NewDetections, item)
+		case before.Detected && !after.Detected:
+			item.RuleDesc = o.RuleDesc
+			report.Resolved = append(report.Resolved, item)
+		case before != after:
+			report.Changed = append(report.Changed, item)
+		default:
+			report.Unchanged++
+		}
+	}
+	for path := range oldByPath {
+		if _, ok := newByPath[path]; !ok {
+			report.OnlyInOld = append(report.OnlyInOld, path)
+		}
+	}
+
+	byFile := func(items []diffItem) {
+		sort.Slice(items, func(i, j int) bool { return items[i].File < items[j].File })
+	}
+	byFile(report.NewDetections)
+	byFile(report.Resolved)
+	byFile(report.Changed)
+	byFile(report.NewErrors)
+	sort.Strings(report.OnlyInOld)
+	sort.Strings(report.OnlyInNew)
+	report.Differences = len(report.NewDetections) + len(report.Resolved) + len(report.Changed) + len(report.NewErrors)
+	return report
+}
+
+// writeDiffReport 按 --format 输出比对报告，指定 -o 时写入文件
+func writeDiffReport(report *diffReport) error {
+	var data []byte
+	if outputFormat == "json" {
+		var err error
+		if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(formatDiffReport(report))
+	}
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("结果比对: %d 项差异，报告已保存: %s\n", report.Differences, outputFile)
+		return nil
+	}
+	_, err := os.Stdout.Write(data)
+	return err
+}
+
+// describeVerdict 命中结果与密级
+func describeVerdict(r *expectedResult) string {
+	s := describeExpected(r)
+	if r != nil && r.Detected && r.SecretLevel != 0 {
+		s += " " + getSecretLevelStr(r.SecretLevel)
+	}
+	return s
+}
+
+func formatDiffReport(report *diffReport) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("=", 70) + "\n")
+	sb.WriteString(fmt.Sprintf("结果比对: %s → %s\n", report.Old, report.New))
+	sb.WriteString(strings.Repeat("=", 70) + "\n")
+
+	section := func(title string, items []diffItem, detail func(diffItem) string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(items)))
+		for _, it := range items {
+			sb.WriteString(fmt.Sprintf("  %s\n      %s\n", it.File, detail(it)))
+		}
+	}
+	section("+ 新增命中", report.NewDetections, func(it diffItem) string {
+		return fmt.Sprintf("命中: %s [%s]", describeVerdict(it.New), truncate(it.RuleDesc, 40))
+	})
+	section("- 命中消除", report.Resolved, func(it diffItem) string {
+		return fmt.Sprintf("原命中: %s [%s]", describeVerdict(it.Old), truncate(it.RuleDesc, 40))
+	})
+	section("~ 命中变化", report.Changed, func(it diffItem) string {
+		return fmt.Sprintf("%s → %s", describeVerdict(it.Old), describeVerdict(it.New))
+	})
+	section("✗ 新增失败", report.NewErrors, func(it diffItem) string {
+		return "错误: " + it.Error
+	})
+	list := func(title string, files []string) {
+		if len(files) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(files)))
+		for _, f := range files {
+			sb.WriteString("  " + f + "\n")
+		}
+	}
+	list("仅在旧结果中", report.OnlyInOld)
+	list("仅在新结果中", report.OnlyInNew)
+
+	sb.WriteString("\n" + strings.Repeat("-", 70) + "\n")
+	sb.WriteString(fmt.Sprintf("旧结果 %d 个文件，新结果 %d 个文件: 相同 %d，新增命中 %d，命中消除 %d，命中变化 %d，新增失败 %d\n",
+		report.OldFiles, report.NewFiles, report.Unchanged, len(report.NewDetections), len(report.Resolved),
+		len(report.Changed), len(report.NewErrors)))
+	if report.Differences == 0 {
+		sb.WriteString("结果: 相同\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("结果: %d 项差异\n", report.Differences))
+	}
+	return sb.String()
+}
@@ -6,16 +6,29 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"linuxFileWatcher/cmd/debug_tools/internal/checkpoint"
+	"linuxFileWatcher/cmd/debug_tools/internal/extstats"
+	"linuxFileWatcher/cmd/debug_tools/internal/filelist"
+	"linuxFileWatcher/cmd/debug_tools/internal/progress"
+	"linuxFileWatcher/cmd/debug_tools/internal/scanflag"
 	"linuxFileWatcher/internal/detector"
+	"linuxFileWatcher/internal/detector/keyword"
 	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/safewalk"
+	"linuxFileWatcher/internal/service/detectapi"
+	"linuxFileWatcher/internal/watcher"
 )
 
 // ==========================================
@@ -25,6 +38,18 @@ import (
 var (
 	targetPath string
 	recursive  bool
+	// 不进入其他文件系统的挂载点
+	oneFS bool
+	// 从文件或标准输入读取待扫描路径 (配合 find/fd)
+	filesFrom string
+	filesNul  bool
+	// 断点续扫的检查点文件
+	checkpointFile string
+	// 打开的检查点，未指定 --checkpoint 时为 nil
+	scanCheckpoint *checkpoint.File[ScanResult]
+	// 定向扫描: 目录深度与文件大小、修改时间条件 (大小上限为 maxFileSize)
+	maxDepth  int
+	scanAttrs pathfilter.Attrs
 
 	// 模块开关 - 注意这些变量会被 flag 和 resolveModuleFlags 修改
 	enableSecretMarker bool
@@ -35,12 +60,39 @@ var (
 	enableAll          bool
 	disableAll         bool
 
-	hashRulesFile   string
-	streamRulesFile string
+	hashRulesFile    string
+	streamRulesFile  string
+	keywordRulesFile string
+	lintRulesFile    string
+
+	// 回归测试
+	regressFile   string
+	regressUpdate bool
+	// 扫描时不输出单个文件的结果 (回归测试只输出比对报告)
+	scanSilent bool
+
+	// 性能基准
+	benchMode   bool
+	benchRounds int
+	benchWarmup int
+
+	// 持续监控: 文件静默多久后检测
+	watchSettle time.Duration
+
+	// 规则统计 (通过守护进程本机接口)
+	apiSocket    string
+	ruleStats    bool
+	markFP       string
+	unmarkFP     string
+	resetRuleArg string
+	listEvidence bool
+	getEvidence  string
+	logLevels    bool
+	setLogLevel  string
 
 	workers     int
 	timeout     int
-	maxFileSize int64
+	maxFileSize int64 // 字节
 
 	outputFile   string
 	outputFormat string
@@ -63,6 +115,15 @@ func init() {
 	flag.StringVar(&targetPath, "p", "", "扫描目标路径（简写）")
 	flag.BoolVar(&recursive, "recursive", true, "递归扫描")
 	flag.BoolVar(&recursive, "r", true, "递归扫描（简写）")
+	flag.BoolVar(&oneFS, "one-file-system", false, "不进入其他文件系统的挂载点 (同 find -xdev)")
+	flag.StringVar(&filesFrom, "files-from", "", "从文件读取待扫描路径列表，- 表示标准输入")
+	flag.BoolVar(&filesNul, "null", false, "--files-from 的列表以 NUL 分隔 (配合 find -print0)")
+	flag.BoolVar(&filesNul, "0", false, "--files-from 的列表以 NUL 分隔（简写）")
+	flag.StringVar(&checkpointFile, "checkpoint", "", "断点续扫: 记录已扫描的文件，中断后重新运行时跳过")
+	flag.IntVar(&maxDepth, "max-depth", 0, "目录遍历的最大深度，0 表示不限")
+	flag.Var((*scanflag.Size)(&scanAttrs.MinSize), "min-size", "跳过小于该大小的文件，如 4K")
+	flag.Var((*scanflag.Time)(&scanAttrs.NewerThan), "newer-than", "只扫描该时间之后修改的文件，如 48h、7d、2024-05-01")
+	flag.Var((*scanflag.Time)(&scanAttrs.OlderThan), "older-than", "只扫描该时间之前修改的文件")
 
 	// 模块开关 - 默认全部 false
 	flag.BoolVar(&enableSecretMarker, "secret-marker", false, "启用密级标志检测")
@@ -75,11 +136,33 @@ func init() {
 
 	flag.StringVar(&hashRulesFile, "hash-rules", "", "哈希检测规则文件")
 	flag.StringVar(&streamRulesFile, "stream-rules", "", "流式标识规则文件")
+	flag.StringVar(&keywordRulesFile, "keyword-rules", "", "关键词检测规则文件 (模式与正则规则)")
+	flag.StringVar(&lintRulesFile, "lint-rules", "", "检查关键词规则文件中的正则规则后退出")
+
+	flag.StringVar(&regressFile, "regress", "", "回归测试: 扫描 -p 指定的语料目录并与期望结果文件比对")
+	flag.BoolVar(&regressUpdate, "regress-update", false, "以本次扫描结果重新生成 --regress 指定的期望结果文件")
+
+	flag.BoolVar(&benchMode, "bench", false, "性能基准: 重复扫描 -p 指定的文件，输出吞吐、延迟与内存分配")
+	flag.IntVar(&benchRounds, "bench-rounds", 3, "性能基准的测量轮数")
+	flag.IntVar(&benchWarmup, "bench-warmup", 1, "性能基准的预热轮数（不计入统计）")
+
+	flag.DurationVar(&watchSettle, "settle", watcher.DefaultSettle, "watch: 文件最后一次写入后等待多久再检测")
+
+	flag.StringVar(&apiSocket, "socket", "/run/linuxFileWatcher/detect.sock", "守护进程本机接口 socket (api.socket)")
+	flag.BoolVar(&ruleStats, "rule-stats", false, "查看守护进程的规则命中统计")
+	flag.StringVar(&markFP, "mark-fp", "", "将告警标记为误报")
+	flag.StringVar(&unmarkFP, "unmark-fp", "", "取消告警的误报标记")
+	flag.StringVar(&resetRuleArg, "reset-rule", "", "重置规则统计 (模块:规则ID)")
+	flag.BoolVar(&listEvidence, "list-evidence", false, "查看守护进程取证库中的证据")
+	flag.StringVar(&getEvidence, "get-evidence", "", "按告警 ID 取回证据 (写入 --output 指定的文件)")
+	flag.BoolVar(&logLevels, "log-levels", false, "查看守护进程的日志级别")
+	flag.StringVar(&setLogLevel, "set-log-level", "", "调整守护进程日志级别 (级别 或 模块=级别，模块= 取消覆盖)")
 
 	flag.IntVar(&workers, "workers", 0, "并发工作数")
 	flag.IntVar(&workers, "w", 0, "并发工作数（简写）")
 	flag.IntVar(&timeout, "timeout", 30, "单文件超时（秒）")
-	flag.Int64Var(&maxFileSize, "max-size", 100, "最大文件大小（MB）")
+	maxFileSize = 100 << 20
+	flag.Var((*scanflag.Size)(&maxFileSize), "max-size", "最大文件大小，无单位时按 MB 计")
 
 	flag.StringVar(&outputFile, "output", "", "输出文件路径")
 	flag.StringVar(&outputFile, "o", "", "输出文件路径（简写）")
@@ -114,17 +197,25 @@ type ScanResult struct {
 	Error       string        `json:"error,omitempty"`
 }
 
+// ResultPath 实现 checkpoint.Result
+func (r ScanResult) ResultPath() string { return r.FilePath }
+
+// Failed 实现 checkpoint.Result：检测失败的文件不记录，续扫时重试
+func (r ScanResult) Failed() bool { return r.Error != "" }
+
 type ScanSummary struct {
-	StartTime     time.Time       `json:"start_time"`
-	EndTime       time.Time       `json:"end_time"`
-	Duration      time.Duration   `json:"duration_ns"`
-	TotalFiles    int64           `json:"total_files"`
-	ScannedFiles  int64           `json:"scanned_files"`
-	DetectedFiles int64           `json:"detected_files"`
-	ErrorFiles    int64           `json:"error_files"`
-	TotalSize     int64           `json:"total_size_bytes"`
-	ModulesConfig map[string]bool `json:"modules_config"`
-	Results       []ScanResult    `json:"results,omitempty"`
+	StartTime     time.Time           `json:"start_time"`
+	EndTime       time.Time           `json:"end_time"`
+	Duration      time.Duration       `json:"duration_ns"`
+	TotalFiles    int64               `json:"total_files"`
+	ScannedFiles  int64               `json:"scanned_files"`
+	DetectedFiles int64               `json:"detected_files"`
+	ErrorFiles    int64               `json:"error_files"`
+	ResumedFiles  int64               `json:"resumed_files,omitempty"` // 沿用检查点结果、本次未扫描的文件
+	TotalSize     int64               `json:"total_size_bytes"`
+	FileTypes     *extstats.Breakdown `json:"file_types,omitempty"` // 按扩展名与分类的统计
+	ModulesConfig map[string]bool     `json:"modules_config"`
+	Results       []ScanResult        `json:"results,omitempty"`
 }
 
 // ==========================================
@@ -132,6 +223,16 @@ type ScanSummary struct {
 // ==========================================
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		}
+	}
+
 	flag.Parse()
 
 	if showHelp {
@@ -144,6 +245,30 @@ func main() {
 		return
 	}
 
+	if lintRulesFile != "" {
+		os.Exit(lintRules(lintRulesFile))
+	}
+
+	if ruleStats || markFP != "" || unmarkFP != "" || resetRuleArg != "" {
+		os.Exit(runRuleStats())
+	}
+
+	if listEvidence || getEvidence != "" {
+		os.Exit(runEvidence())
+	}
+
+	if logLevels || setLogLevel != "" {
+		os.Exit(runLogLevels())
+	}
+
+	if regressFile != "" {
+		os.Exit(runRegress())
+	}
+	if regressUpdate {
+		fmt.Fprintln(os.Stderr, "错误: --regress-update 需与 --regress 一起使用")
+		os.Exit(2)
+	}
+
 	// 处理模块开关（关键修复点）
 	resolveModuleFlags()
 
@@ -152,17 +277,32 @@ func main() {
 		return
 	}
 
-	if targetPath == "" {
-		fmt.Fprintln(os.Stderr, "错误: 必须指定 -p 参数")
+	if err := applyScanFilters(); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(2)
+	}
+
+	if targetPath == "" && filesFrom == "" {
+		fmt.Fprintln(os.Stderr, "错误: 必须指定 -p 或 --files-from 参数")
 		fmt.Fprintln(os.Stderr, "使用 -h 查看帮助")
 		os.Exit(1)
 	}
-
-	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "错误: 路径不存在: %s\n", targetPath)
+	if targetPath != "" && filesFrom != "" {
+		fmt.Fprintln(os.Stderr, "错误: -p 与 --files-from 不能同时使用")
 		os.Exit(1)
 	}
 
+	if targetPath != "" {
+		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "错误: 路径不存在: %s\n", targetPath)
+			os.Exit(1)
+		}
+	}
+
+	if benchMode {
+		os.Exit(runBench())
+	}
+
 	if !quiet {
 		printBanner()
 	}
@@ -177,7 +317,11 @@ func main() {
 	applyRules(mgr, rulesLoaded)
 
 	// 收集文件
-	files := collectFiles(targetPath)
+	files, err := targetFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
 	if len(files) == 0 {
 		fmt.Println("没有找到待扫描的文件")
 		return
@@ -185,6 +329,14 @@ func main() {
 
 	if !quiet {
 		fmt.Printf("共发现 %d 个文件待扫描\n", len(files))
+		if desc := scanflag.Describe(scanAttrs, maxDepth); desc != "" {
+			fmt.Printf("定向扫描条件: %s\n", desc)
+		}
+	}
+
+	if scanCheckpoint, err = checkpoint.OpenFlag[ScanResult](checkpointFile, toolName, quiet); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
 	}
 
 	// 执行扫描
@@ -192,6 +344,7 @@ func main() {
 
 	// 输出结果
 	outputResults(summary)
+	scanCheckpoint.Finish()
 }
 
 // ==========================================
@@ -239,6 +392,7 @@ func printCurrentConfig() {
 	fmt.Println(strings.Repeat("-", 40))
 	fmt.Printf("  哈希规则文件:   %s\n", hashRulesFile)
 	fmt.Printf("  流式规则文件:   %s\n", streamRulesFile)
+	fmt.Printf("  关键词规则文件: %s\n", keywordRulesFile)
 }
 
 // ==========================================
@@ -246,8 +400,9 @@ func printCurrentConfig() {
 // ==========================================
 
 type LoadedRules struct {
-	HashRules   []model.HashDetectRule
-	StreamRules []model.StreamMarkerDetectRule
+	HashRules    []model.HashDetectRule
+	StreamRules  []model.StreamMarkerDetectRule
+	KeywordRules *model.KeywordDetectConfig
 }
 
 func preloadRules() *LoadedRules {
@@ -287,7 +442,22 @@ func preloadRules() *LoadedRules {
 		}
 	}
 
-	if len(loaded.HashRules) == 0 && len(loaded.StreamRules) == 0 {
+	// 加载关键词规则
+	if keywordRulesFile != "" {
+		rules, err := loadKeywordRules(keywordRulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ⚠ 加载关键词规则失败: %v\n", err)
+		} else {
+			loaded.KeywordRules = rules
+			if !quiet {
+				fmt.Printf("  ✓ 已加载 %d 条关键词规则\n", len(rules.Rules))
+			}
+			// 自动启用关键词检测
+			enableKeywords = true
+		}
+	}
+
+	if len(loaded.HashRules) == 0 && len(loaded.StreamRules) == 0 && loaded.KeywordRules == nil {
 		if !quiet {
 			fmt.Println("  ⚠ 未加载任何规则文件")
 		}
@@ -308,6 +478,12 @@ func applyRules(mgr *detector.Manager, rules *LoadedRules) {
 			fmt.Fprintf(os.Stderr, "  ⚠ 设置流式标识规则失败: %v\n", err)
 		}
 	}
+
+	if rules.KeywordRules != nil {
+		if err := mgr.SetKeywordRules(rules.KeywordRules, keyword.Limits{}); err != nil {
+			fmt.Fprintf(os.Stderr, "  ⚠ 设置关键词规则失败: %v\n", err)
+		}
+	}
 }
 
 func loadHashRules(path string) ([]model.HashDetectRule, error) {
@@ -340,6 +516,184 @@ func loadStreamMarkerRules(path string) ([]model.StreamMarkerDetectRule, error)
 	return config.Rules, nil
 }
 
+func loadKeywordRules(path string) (*model.KeywordDetectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config model.KeywordDetectConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ==========================================
+// 规则检查
+// ==========================================
+
+// lintRules 检查关键词规则文件中的正则规则，有错误返回 1，只有警告返回 0
+func lintRules(path string) int {
+	config, err := loadKeywordRules(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+
+	checked, errCount, warnCount := 0, 0, 0
+	for _, rule := range config.Rules {
+		if rule.RuleType != model.KeywordRuleTypeRegex {
+			continue
+		}
+		checked++
+		for _, issue := range keyword.Lint(rule.RuleContent, keyword.Limits{}) {
+			mark := "⚠"
+			if issue.Severity == keyword.SeverityError {
+				mark = "✗"
+				errCount++
+			} else {
+				warnCount++
+			}
+			fmt.Printf("%s 规则 %d %s: %s\n", mark, rule.RuleID, truncate(rule.RuleContent, 40), issue.Message)
+		}
+	}
+
+	fmt.Printf("检查 %d 条正则规则: %d 个错误, %d 个警告\n", checked, errCount, warnCount)
+	if errCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRuleStats 通过守护进程本机接口标记误报、重置或查看规则命中统计
+func runRuleStats() int {
+	client := detectapi.NewClient(apiSocket)
+	ctx := context.Background()
+
+	var (
+		stats []detectapi.RuleStat
+		err   error
+	)
+	switch {
+	case markFP != "" || unmarkFP != "":
+		alertID, fp := markFP, true
+		if alertID == "" {
+			alertID, fp = unmarkFP, false
+		}
+		var s *detectapi.RuleStat
+		if s, err = client.MarkAlert(ctx, alertID, fp); err == nil {
+			stats = []detectapi.RuleStat{*s}
+		}
+	case resetRuleArg != "":
+		module, id, ok := strings.Cut(resetRuleArg, ":")
+		ruleID, perr := strconv.ParseInt(id, 10, 64)
+		if !ok || module == "" || perr != nil {
+			fmt.Fprintf(os.Stderr, "错误: --reset-rule 格式为 模块:规则ID，例如 keyword_detect:41\n")
+			return 2
+		}
+		stats, err = client.ResetRuleStat(ctx, module, ruleID)
+	default:
+		stats, err = client.ListRuleStats(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("%-22s %-8s %-8s %-8s %-8s %-8s %s\n", "模块", "规则ID", "命中", "误报", "准确率", "状态", "最近命中")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, s := range stats {
+		last := "-"
+		if s.LastHit > 0 {
+			last = time.Unix(s.LastHit, 0).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-22s %-8d %-8d %-8d %-8s %-8s %s\n", s.Module, s.RuleID, s.Hits, s.FalsePositives,
+			fmt.Sprintf("%.1f%%", s.Precision*100), s.Status, last)
+	}
+	fmt.Printf("共 %d 条规则\n", len(stats))
+	return 0
+}
+
+// runEvidence 通过守护进程本机接口查看或取回证据
+func runEvidence() int {
+	client := detectapi.NewClient(apiSocket)
+	ctx := context.Background()
+
+	if getEvidence != "" {
+		if outputFile == "" {
+			fmt.Fprintf(os.Stderr, "错误: --get-evidence 需要通过 --output 指定保存的文件\n")
+			return 2
+		}
+		item, content, err := client.GetEvidence(ctx, getEvidence)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		if err := os.WriteFile(outputFile, content, 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		fmt.Printf("已保存证据: %s (%s, %s, SM3 %s)\n", outputFile, item.Mode, formatSize(item.Size), item.SM3)
+		return 0
+	}
+
+	items, err := client.ListEvidence(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%-22s %-20s %-8s %-10s %s\n", "告警ID", "留存时间", "方式", "大小", "文件")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, e := range items {
+		mode := e.Mode
+		if e.Truncated {
+			mode += "*"
+		}
+		fmt.Printf("%-22s %-20s %-8s %-10s %s\n", e.AlertID, time.Unix(e.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+			mode, formatSize(e.Size), e.FilePath)
+	}
+	fmt.Printf("共 %d 条证据 (* 文件超过大小上限，仅留存命中摘录)\n", len(items))
+	return 0
+}
+
+// runLogLevels 通过守护进程本机接口查看或调整日志级别
+func runLogLevels() int {
+	client := detectapi.NewClient(apiSocket)
+	ctx := context.Background()
+
+	var resp *detectapi.LogLevelsResponse
+	var err error
+	if setLogLevel != "" {
+		module, level := "", setLogLevel
+		if i := strings.IndexByte(setLogLevel, '='); i >= 0 {
+			module, level = setLogLevel[:i], setLogLevel[i+1:]
+			if module == "" {
+				fmt.Fprintf(os.Stderr, "错误: --set-log-level 格式应为 级别 或 模块=级别\n")
+				return 2
+			}
+		}
+		resp, err = client.SetLogLevel(ctx, module, level)
+	} else {
+		resp, err = client.GetLogLevels(ctx)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("全局: %s\n", resp.Global)
+	modules := make([]string, 0, len(resp.Modules))
+	for m := range resp.Modules {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+	for _, m := range modules {
+		fmt.Printf("  %-16s %s\n", m, resp.Modules[m])
+	}
+	return 0
+}
+
 // ==========================================
 // 初始化
 // ==========================================
@@ -366,8 +720,8 @@ func initDetectorManager() *detector.Manager {
 
 		SecretMarkerOCR:         true,
 		LayoutThreshold:         0.8,
-		StreamMarkerMaxFileSize: maxFileSize * 1024 * 1024,
-		HashMaxFileSize:         maxFileSize * 1024 * 1024,
+		StreamMarkerMaxFileSize: maxFileSize,
+		HashMaxFileSize:         maxFileSize,
 
 		CurrentCompany:      "调试模式",
 		CurrentComputerName: getHostname(),
@@ -400,6 +754,35 @@ func initDetectorManager() *detector.Manager {
 // 文件收集
 // ==========================================
 
+// targetFiles 收集待扫描文件: 指定 --files-from 时逐项展开列表中的路径，否则遍历 -p
+func targetFiles() ([]string, error) {
+	if filesFrom == "" {
+		return collectFiles(targetPath), nil
+	}
+	paths, err := filelist.Load(filesFrom, filesNul)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件列表失败: %v", err)
+	}
+	return filelist.Expand(paths, func(p string) ([]string, error) {
+		return collectFiles(p), nil
+	}, func(p string, err error) {
+		fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", p, err)
+	}), nil
+}
+
+// applyScanFilters 检查定向扫描条件，--max-depth 通过全局路径过滤器生效
+func applyScanFilters() error {
+	if maxDepth < 0 {
+		return fmt.Errorf("--max-depth 不能为负数")
+	}
+	attrs := scanAttrs
+	attrs.MaxSize = maxFileSize
+	if err := attrs.Validate(); err != nil {
+		return err
+	}
+	return scanflag.SetFilter(maxDepth)
+}
+
 func collectFiles(path string) []string {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -411,20 +794,34 @@ func collectFiles(path string) []string {
 	}
 
 	var files []string
-	filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			if info != nil && info.IsDir() && !recursive && p != path {
-				return filepath.SkipDir
+	filter := pathfilter.Default()
+	safewalk.Walk(path, safewalk.Options{SameFilesystem: oneFS}, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			if d != nil && d.IsDir() && p != path {
+				if !recursive || filter.SkipDir(p, pathfilter.Depth(path, p)) {
+					return filepath.SkipDir
+				}
 			}
 			return nil
 		}
 
-		if maxFileSize > 0 && info.Size() > maxFileSize*1024*1024 {
+		if filter.SkipFile(p, pathfilter.Depth(path, p)) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if maxFileSize > 0 && info.Size() > maxFileSize {
 			if verbose {
 				fmt.Printf("  跳过大文件: %s\n", p)
 			}
 			return nil
 		}
+		if !scanAttrs.Match(info) {
+			return nil
+		}
 
 		files = append(files, p)
 		return nil
@@ -444,13 +841,20 @@ func runScan(mgr *detector.Manager, files []string) *ScanSummary {
 		Results:       make([]ScanResult, 0),
 		ModulesConfig: mgr.GetAllSubModuleStatus(),
 	}
+	pending := scanCheckpoint.Resume(files, func(r ScanResult) {
+		summary.ResumedFiles++
+		if r.Detected {
+			summary.DetectedFiles++
+		}
+		summary.Results = append(summary.Results, r)
+	})
 
 	numWorkers := workers
 	if numWorkers <= 0 {
 		numWorkers = runtime.NumCPU()
 	}
-	if numWorkers > len(files) {
-		numWorkers = len(files)
+	if numWorkers > len(pending) {
+		numWorkers = len(pending)
 	}
 
 	if !quiet {
@@ -461,7 +865,10 @@ func runScan(mgr *detector.Manager, files []string) *ScanSummary {
 	taskChan := make(chan string, numWorkers*2)
 	resultChan := make(chan ScanResult, numWorkers*2)
 
-	var scanned, detected int64
+	// 命中数包含从检查点恢复的结果
+	var scanned int64
+	detected := summary.DetectedFiles
+	meter := progress.New(len(files), len(files)-len(pending))
 
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
@@ -493,7 +900,12 @@ func runScan(mgr *detector.Manager, files []string) *ScanSummary {
 			mu.Lock()
 			summary.Results = append(summary.Results, result)
 			mu.Unlock()
+			scanCheckpoint.Record(result)
+			meter.Add(result.FileSize)
 
+			if scanSilent {
+				continue
+			}
 			if result.Detected {
 				printDetection(result)
 			} else if result.Error != "" && verbose {
@@ -502,16 +914,13 @@ func runScan(mgr *detector.Manager, files []string) *ScanSummary {
 				fmt.Printf("  [安全] %s (耗时: %v)\n", result.FileName, result.Duration)
 			}
 
-			if showProgress && !quiet {
-				cur := atomic.LoadInt64(&scanned)
-				if cur%20 == 0 || cur == int64(len(files)) {
-					fmt.Printf("\r进度: %d/%d (命中: %d)", cur, len(files), atomic.LoadInt64(&detected))
-				}
+			if showProgress && !quiet && meter.Due() {
+				fmt.Printf("\r进度: %s (命中: %d)   ", meter.Line(), atomic.LoadInt64(&detected))
 			}
 		}
 	}()
 
-	for _, f := range files {
+	for _, f := range pending {
 		taskChan <- f
 	}
 	close(taskChan)
@@ -523,6 +932,7 @@ func runScan(mgr *detector.Manager, files []string) *ScanSummary {
 	summary.EndTime = time.Now()
 	summary.Duration = summary.EndTime.Sub(summary.StartTime)
 	summary.ScannedFiles = scanned
+	summary.FileTypes = fileTypeBreakdown(summary.Results)
 	summary.DetectedFiles = detected
 
 	if showProgress && !quiet {
@@ -628,6 +1038,15 @@ func getAlertTypeStr(t int) string {
 	}
 }
 
+// fileTypeBreakdown 按扩展名与分类汇总检测结果 (含检查点恢复的结果)
+func fileTypeBreakdown(results []ScanResult) *extstats.Breakdown {
+	c := extstats.New()
+	for _, r := range results {
+		c.Add(r.FilePath, r.FileSize, r.Duration, r.Detected, r.Error != "")
+	}
+	return c.Breakdown()
+}
+
 func outputResults(summary *ScanSummary) {
 	if !quiet {
 		fmt.Println()
@@ -637,6 +1056,9 @@ func outputResults(summary *ScanSummary) {
 		fmt.Printf("  扫描耗时:       %v\n", summary.Duration)
 		fmt.Printf("  文件总数:       %d\n", summary.TotalFiles)
 		fmt.Printf("  已扫描:         %d\n", summary.ScannedFiles)
+		if summary.ResumedFiles > 0 {
+			fmt.Printf("  检查点恢复:     %d\n", summary.ResumedFiles)
+		}
 		fmt.Printf("  检测命中:       %d\n", summary.DetectedFiles)
 		fmt.Printf("  错误数:         %d\n", summary.ErrorFiles)
 		fmt.Printf("  扫描总大小:     %s\n", formatSize(summary.TotalSize))
@@ -645,6 +1067,10 @@ func outputResults(summary *ScanSummary) {
 			speed := float64(summary.TotalSize) / summary.Duration.Seconds() / 1024 / 1024
 			fmt.Printf("  扫描速度:       %.2f MB/s\n", speed)
 		}
+		if summary.FileTypes != nil {
+			fmt.Println()
+			fmt.Print(summary.FileTypes.Format("  ", 15))
+		}
 
 		fmt.Println()
 		fmt.Println("  启用的检测模块:")
@@ -675,6 +1101,10 @@ func saveOutput(summary *ScanSummary) {
 		var sb strings.Builder
 		sb.WriteString(fmt.Sprintf("扫描报告 - %s\n\n", summary.EndTime.Format("2006-01-02 15:04:05")))
 		sb.WriteString(fmt.Sprintf("总文件: %d, 命中: %d\n\n", summary.TotalFiles, summary.DetectedFiles))
+		if summary.FileTypes != nil {
+			sb.WriteString(summary.FileTypes.Format("", 0))
+			sb.WriteString("\n")
+		}
 		for _, r := range summary.Results {
 			if r.Detected {
 				sb.WriteString(fmt.Sprintf("[%s] %s\n  规则: %s\n  匹配: %s\n\n",
@@ -750,9 +1180,19 @@ func printHelp() {
 用法:
   %s -p <路径> [选项]
 
-扫描目标:
-  -p, --path <路径>      扫描目标路径 [必需]
+扫描目标（-p 与 --files-from 二选一）:
+  -p, --path <路径>      扫描目标路径
   -r, --recursive        递归扫描 (默认: true)
+      --files-from <文件> 从文件读取待扫描路径，每行一个，- 表示标准输入；列表中的目录按 -r 展开
+  -0, --null             列表以 NUL 分隔 (配合 find -print0 / fd -0)
+      --checkpoint <文件> 断点续扫: 记录已扫描的文件，中断后以同一文件重新运行时跳过未变化的文件，完成后删除
+
+定向扫描（目录遍历时生效，直接指定的文件不受限制）:
+      --max-depth <N>    目录遍历的最大深度，1 表示只扫描目录下的文件 (默认: 0 不限)
+      --min-size <大小>   跳过小于该大小的文件，支持 K/M/G 单位
+      --max-size <大小>   跳过大于该大小的文件，无单位时按 MB 计 (默认: 100M)
+      --newer-than <时间> 只扫描该时间之后修改的文件: 时长 (48h、7d、2w) 或日期 (2024-05-01)
+      --older-than <时间> 只扫描该时间之前修改的文件
 
 模块开关:
       --all              启用所有模块
@@ -767,11 +1207,48 @@ func printHelp() {
 规则文件:
       --hash-rules       哈希规则文件（自动启用哈希检测）
       --stream-rules     流式标识规则文件（自动启用流式检测）
+      --keyword-rules    关键词规则文件（自动启用关键词检测）
+
+规则检查:
+      --lint-rules       检查关键词规则文件中的正则规则（超出安全限制、灾难性回溯写法）后退出
+
+回归测试（发布门禁，出现回归时退出码为 1）:
+      --regress <期望结果> 用全部模块扫描 -p 指定的语料目录，报告新漏报、新误报、检测失败与文件缺失
+      --regress-update   以本次扫描结果重新生成期望结果文件
+
+结果比对（diff 子命令，有差异时退出码为 1）:
+  %s diff <旧结果.json> <新结果.json>
+                         比对两次 --format json 保存的扫描结果，报告新增命中、命中消除、命中变化与新增失败
+
+持续监控（watch 子命令，模块开关与规则文件选项同单次扫描）:
+  %s watch -p <目录> [选项]
+                         监听目录中新建与写入的文件，静默后立即检测并实时输出命中，Ctrl+C 退出
+      --settle           文件最后一次写入后等待多久再检测 (默认: 500ms)
+                         --format json 时每个结果输出一行 JSON，-o 指定的文件追加保存命中结果
+
+性能基准（按当前模块开关与规则重复扫描，--format json 的结果可与其他版本对比）:
+      --bench            输出吞吐（文件/秒、MB/秒）、延迟 p50/p95/p99 与每文件内存分配
+      --bench-rounds     测量轮数 (默认: 3)
+      --bench-warmup     预热轮数，不计入统计 (默认: 1)
+
+规则统计（通过守护进程本机接口）:
+      --socket           本机接口 socket (默认: /run/linuxFileWatcher/detect.sock)
+      --rule-stats       查看规则命中次数、误报次数与准确率
+      --mark-fp <告警ID>  将告警标记为误报
+      --unmark-fp <告警ID> 取消告警的误报标记
+      --reset-rule <模块:规则ID> 规则修正后重置统计（恢复已降级规则的告警）
+
+取证留存（通过守护进程本机接口）:
+      --list-evidence    查看取证库中的证据
+      --get-evidence <告警ID> 取回证据，配合 -o 指定保存的文件
+
+日志级别（通过守护进程本机接口，配置热加载后恢复为配置值）:
+      --log-levels       查看全局与各模块的日志级别
+      --set-log-level <级别|模块=级别> 调整全局或单个模块的级别，如 detector=debug；模块= 取消覆盖
 
 运行配置:
   -w, --workers          并发数 (默认: CPU核心数)
       --timeout          单文件超时秒数 (默认: 30)
-      --max-size         最大文件MB (默认: 100)
 
 输出:
   -o, --output           输出文件
@@ -790,5 +1267,36 @@ func printHelp() {
   # 查看配置
   %s --none --hash --show-config
 
-`, toolName, toolVersion, toolName, toolName, toolName, toolName)
+  # 下发前检查关键词正则规则
+  %s --lint-rules keyword_rules.json
+
+  # 标记误报后查看规则准确率
+  %s --mark-fp 3f2a9c1e-7b4d-4e8a-9c21-5d6e7f8a9b0c
+  %s --rule-stats
+
+  # 生成黄金语料的期望结果，规则或检测逻辑修改后比对
+  %s -p ./corpus --regress expected.json --regress-update --hash-rules rules.json
+  %s -p ./corpus --regress expected.json --hash-rules rules.json
+
+  # 规则更新前后各扫描一次，比对影响范围
+  %s -p ./data --format json -o before.json
+  %s -p ./data --hash-rules new_rules.json --format json -o after.json
+  %s diff before.json after.json
+
+  # 持续监控下载目录，只启用哈希检测
+  %s watch -p ~/Downloads --none --hash-rules rules.json
+
+  # 性能基准，保存 JSON 结果用于版本间对比
+  %s -p ./corpus --bench --bench-rounds 5 --format json -o bench.json
+
+  # 只扫描最近一天修改过的文档
+  find /data -name '*.docx' -mtime -1 -print0 | %s --files-from - -0
+
+  # 事件排查: 扫描最近 48 小时修改过、不小于 1K 的文件
+  %s -p /home --newer-than 48h --min-size 1K
+
+  # 扫描大型共享目录，中断后执行同一命令继续
+  %s -p /mnt/share --checkpoint share.ckpt --format json -o share.json
+
+`, toolName, toolVersion, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName)
 }
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==========================================
+// 回归测试 (--regress)
+// ==========================================
+//
+// 用全部检测模块扫描语料目录 (-p)，与期望结果文件逐个比对:
+//   新漏报   期望命中，本次未命中
+//   新误报   期望未命中，本次命中
+//   检测失败 本次扫描出错
+//   文件缺失 期望结果中有，语料目录中没有
+// 以上任一项非空时退出码为 1，可直接用于发布门禁；命中类型或规则变化只报告，不视为回归。
+// --regress-update 以本次结果重新生成期望结果文件。
+
+// expectedVersion 期望结果文件格式版本
+const expectedVersion = 1
+
+// expectedResult 单个文件的期望结果，AlertType、RuleID 为 0 时不比对
+type expectedResult struct {
+	Detected    bool  `json:"detected"`
+	AlertType   int   `json:"alert_type,omitempty"`
+	RuleID      int64 `json:"rule_id,omitempty"`
+	SecretLevel int   `json:"secret_level,omitempty"`
+}
+
+// expectedFile 期望结果文件，路径相对于语料目录，以 / 分隔
+type expectedFile struct {
+	Version     int                       `json:"version"`
+	GeneratedAt time.Time                 `json:"generated_at"`
+	Tool        string                    `json:"tool,omitempty"`
+	Modules     map[string]bool           `json:"modules,omitempty"`
+	Files       map[string]expectedResult `json:"files"`
+}
+
+// regressItem 一个不一致的文件
+type regressItem struct {
+	File     string          `json:"file"`
+	Expected *expectedResult `json:"expected,omitempty"`
+	Actual   *expectedResult `json:"actual,omitempty"`
+	RuleDesc string          `json:"rule_desc,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// regressReport 比对报告
+type regressReport struct {
+	Corpus            string        `json:"corpus"`
+	Expected          string        `json:"expected"`
+	Total             int           `json:"total"`
+	Passed            int           `json:"passed"`
+	Regressions       int           `json:"regressions"`
+	NewMisses         []regressItem `json:"new_misses"`
+	NewFalsePositives []regressItem `json:"new_false_positives"`
+	Errors            []regressItem `json:"errors"`
+	Missing           []string      `json:"missing"`
+	Changed           []regressItem `json:"changed"`
+	Unlisted          []string      `json:"unlisted"`
+	Duration          string        `json:"duration"`
+}
+
+func toExpected(r ScanResult) expectedResult {
+	if !r.Detected {
+		return expectedResult{}
+	}
+	return expectedResult{Detected: true, AlertType: r.AlertType, RuleID: r.RuleID, SecretLevel: r.SecretLevel}
+}
+
+func loadExpected(path string) (*expectedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f expectedFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析期望结果文件失败: %v", err)
+	}
+	if f.Version != expectedVersion {
+		return nil, fmt.Errorf("不支持的期望结果文件版本 %d", f.Version)
+	}
+	return &f, nil
+}
+
+// corpusKey 文件在语料目录中的相对路径
+func corpusKey(corpus, path string) string {
+	rel, err := filepath.Rel(corpus, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// runRegress 扫描语料目录并与期望结果比对，返回退出码: 0 无回归，1 有回归，2 参数或文件错误
+func runRegress() int {
+	info, err := os.Stat(targetPath)
+	if err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "错误: --regress 需要通过 -p 指定语料目录\n")
+		return 2
+	}
+
+	var expected *expectedFile
+	if !regressUpdate {
+		if expected, err = loadExpected(regressFile); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 2
+		}
+	}
+
+	// 回归测试总是启用全部模块，只输出比对报告
+	enableAll = true
+	resolveModuleFlags()
+	quiet, scanSilent = true, true
+
+	mgr := initDetectorManager()
+	applyRules(mgr, preloadRules())
+	summary := runScan(mgr, collectFiles(targetPath))
+
+	if regressUpdate {
+		return saveExpected(summary)
+	}
+
+	report := compareExpected(expected, summary)
+	report.Duration = summary.Duration.Round(time.Millisecond).String()
+	if err := writeRegressReport(report); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	if report.Regressions > 0 {
+		return 1
+	}
+	return 0
+}
+
+// saveExpected 以本次结果生成期望结果文件
+func saveExpected(summary *ScanSummary) int {
+	f := expectedFile{
+		Version:     expectedVersion,
+		GeneratedAt: time.Now(),
+		Tool:        toolName + " " + toolVersion,
+		Modules:     summary.ModulesConfig,
+		Files:       make(map[string]expectedResult, len(summary.Results)),
+	}
+	failed := 0
+	for _, r := range summary.Results {
+		if r.Error != "" {
+			// 检测失败的文件不写入，避免把错误固化为期望结果
+			fmt.Fprintf(os.Stderr, "  ⚠ 检测失败，未写入: %s: %s\n", r.FilePath, r.Error)
+			failed++
+			continue
+		}
+		f.Files[corpusKey(targetPath, r.FilePath)] = toExpected(r)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err == nil {
+		err = os.WriteFile(regressFile, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 保存期望结果失败: %v\n", err)
+		return 2
+	}
+	fmt.Printf("已生成期望结果: %s (%d 个文件，命中 %d 个)\n", regressFile, len(f.Files), summary.DetectedFiles)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// compareExpected 逐个文件比对期望结果
+func compareExpected(expected *expectedFile, summary *ScanSummary) *regressReport {
+	report := &regressReport{
+		Corpus:   targetPath,
+		Expected: regressFile,
+		Total:    len(expected.Files),
+		// 空列表输出为 []，便于脚本处理
+		NewMisses:         []regressItem{},
+		NewFalsePositives: []regressItem{},
+		Errors:            []regressItem{},
+		Missing:           []string{},
+		Changed:           []regressItem{},
+		Unlisted:          []string{},
+	}
+	seen := make(map[string]bool, len(summary.Results))
+	for _, r := range summary.Results {
+		key := corpusKey(targetPath, r.FilePath)
+		seen[key] = true
+		want, ok := expected.Files[key]
+		if !ok {
+			report.Unlisted = append(report.Unlisted, key)
+			continue
+		}
+		item := regressItem{File: key, Expected: &want}
+		if r.Error != "" {
+			item.Error = r.Error
+			report.Errors = append(report.Errors, item)
+			continue
+		}
+		got := toExpected(r)
+		item.Actual, item.RuleDesc = &got, r.RuleDesc
+		switch {
+		case want.Detected && !got.Detected:
+			report.NewMisses = append(report.NewMisses, item)
+		case !want.Detected && got.Detected:
+			report.NewFalsePositives = append(report.NewFalsePositives, item)
+		case want.Detected && ((want.AlertType != 0 && want.AlertType != got.AlertType) ||
+			(want.RuleID != 0 && want.RuleID != got.RuleID)):
+			report.Changed = append(report.Changed, item)
+			report.Passed++
+		default:
+			report.Passed++
+		}
+	}
+	for key := range expected.Files {
+		if !seen[key] {
+			report.Missing = append(report.Missing, key)
+		}
+	}
+
+	byFile := func(items []regressItem) {
+		sort.Slice(items, func(i, j int) bool { return items[i].File < items[j].File })
+	}
+	byFile(report.NewMisses)
+	byFile(report.NewFalsePositives)
+	byFile(report.Errors)
+	byFile(report.Changed)
+	sort.Strings(report.Missing)
+	sort.Strings(report.Unlisted)
+	report.Regressions = len(report.NewMisses) + len(report.NewFalsePositives) + len(report.Errors) + len(report.Missing)
+	return report
+}
+
+// writeRegressReport 按 --format 输出比对报告，指定 -o 时写入文件
+func writeRegressReport(report *regressReport) error {
+	var data []byte
+	if outputFormat == "json" {
+		var err error
+		if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(formatRegressReport(report))
+	}
+	if outputFile != "" {
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("回归测试: %d 项回归，报告已保存: %s\n", report.Regressions, outputFile)
+		return nil
+	}
+	_, err := os.Stdout.Write(data)
+	return err
+}
+
+func describeExpected(r *expectedResult) string {
+	if r == nil || !r.Detected {
+		return "未命中"
+	}
+	s := getAlertTypeStr(r.AlertType)
+	if r.RuleID != 0 {
+		s += fmt.Sprintf(" 规则 %d", r.RuleID)
+	}
+	return s
+}
+
+func formatRegressReport(report *regressReport) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("=", 70) + "\n")
+	sb.WriteString(fmt.Sprintf("回归测试: %s (期望结果 %s)\n", report.Corpus, report.Expected))
+	sb.WriteString(strings.Repeat("=", 70) + "\n")
+
+	section := func(title string, items []regressItem, detail func(regressItem) string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (%d):\n", title, len(items)))
+		for _, it := range items {
+			sb.WriteString(fmt.Sprintf("  %s\n      %s\n", it.File, detail(it)))
+		}
+	}
+	section("✗ 新漏报", report.NewMisses, func(it regressItem) string {
+		return "期望: " + describeExpected(it.Expected)
+	})
+	section("✗ 新误报", report.NewFalsePositives, func(it regressItem) string {
+		return fmt.Sprintf("命中: %s [%s]", describeExpected(it.Actual), truncate(it.RuleDesc, 40))
+	})
+	section("✗ 检测失败", report.Errors, func(it regressItem) string {
+		return "错误: " + it.Error
+	})
+	if len(report.Missing) > 0 {
+		sb.WriteString(fmt.Sprintf("\n✗ 文件缺失 (%d):\n", len(report.Missing)))
+		for _, f := range report.Missing {
+			sb.WriteString("  " + f + "\n")
+		}
+	}
+	section("⚠ 命中变化", report.Changed, func(it regressItem) string {
+		return fmt.Sprintf("期望: %s → 本次: %s", describeExpected(it.Expected), describeExpected(it.Actual))
+	})
+	if len(report.Unlisted) > 0 {
+		sb.WriteString(fmt.Sprintf("\n⚠ 未登记的文件 (%d，使用 --regress-update 重新生成期望结果):\n", len(report.Unlisted)))
+		for _, f := range report.Unlisted {
+			sb.WriteString("  " + f + "\n")
+		}
+	}
+
+	sb.WriteString("\n" + strings.Repeat("-", 70) + "\n")
+	sb.WriteString(fmt.Sprintf("期望 %d 个文件: 通过 %d，新漏报 %d，新误报 %d，检测失败 %d，文件缺失 %d (耗时 %s)\n",
+		report.Total, report.Passed, len(report.NewMisses), len(report.NewFalsePositives),
+		len(report.Errors), len(report.Missing), report.Duration))
+	if report.Regressions > 0 {
+		sb.WriteString(fmt.Sprintf("结果: 失败，%d 项回归\n", report.Regressions))
+	} else {
+		sb.WriteString("结果: 通过\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/watcher"
+)
+
+// ==========================================
+// 持续监控 (watch 子命令)
+// ==========================================
+//
+// 用 internal/watcher 递归监听 -p 指定的目录，文件新建或写入并静默 --settle 后立即检测，
+// 实时输出命中结果，Ctrl+C 退出时输出统计。模块开关、规则文件等选项与单次扫描相同。
+// --format json 时每个结果输出一行 JSON；指定 -o 时命中结果同时追加到该文件。
+
+// runWatch 执行 watch 子命令，返回退出码: 0 正常退出，1 参数或监控错误
+func runWatch(args []string) int {
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return 1
+	}
+	if showHelp {
+		printHelp()
+		return 0
+	}
+	if targetPath == "" {
+		fmt.Fprintln(os.Stderr, "错误: watch 需要通过 -p 指定监控目录")
+		return 1
+	}
+	if info, err := os.Stat(targetPath); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "错误: 监控目标必须是目录: %s\n", targetPath)
+		return 1
+	}
+
+	resolveModuleFlags()
+	if err := applyScanFilters(); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	// JSON 输出只包含结果行
+	jsonLines := outputFormat == "json"
+	if jsonLines {
+		quiet = true
+	}
+	if !quiet {
+		printBanner()
+	}
+	mgr := initDetectorManager()
+	applyRules(mgr, preloadRules())
+
+	w, err := watcher.New(watcher.Options{Filter: pathfilter.Default(), Settle: watchSettle})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	defer w.Close()
+	if err := w.Add(targetPath); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 监听目录失败: %v\n", err)
+		return 1
+	}
+
+	var hitLog *os.File
+	if outputFile != "" {
+		if hitLog, err = os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 打开输出文件失败: %v\n", err)
+			return 1
+		}
+		defer hitLog.Close()
+	}
+
+	numWorkers := workers
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+	if !quiet {
+		fmt.Printf("\n[3] 开始监控 %s (并发数: %d，静默时间: %v)，按 Ctrl+C 退出\n", targetPath, numWorkers, watchSettle)
+		fmt.Println(strings.Repeat("=", 70))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		scanned, detected, failed int64
+		outMu                     sync.Mutex
+		wg                        sync.WaitGroup
+	)
+	tasks := make(chan string, numWorkers*4)
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range tasks {
+				info, err := os.Stat(path)
+				if err != nil || !info.Mode().IsRegular() {
+					// 检测前已被删除或改名
+					continue
+				}
+				if maxFileSize > 0 && info.Size() > maxFileSize {
+					if verbose {
+						fmt.Printf("  跳过大文件: %s\n", path)
+					}
+					continue
+				}
+
+				r := scanFile(mgr, path)
+				atomic.AddInt64(&scanned, 1)
+				switch {
+				case r.Error != "":
+					atomic.AddInt64(&failed, 1)
+				case r.Detected:
+					atomic.AddInt64(&detected, 1)
+				}
+
+				outMu.Lock()
+				printWatchResult(r, jsonLines)
+				if hitLog != nil && r.Detected {
+					if line, err := json.Marshal(r); err == nil {
+						hitLog.Write(append(line, '\n'))
+					}
+				}
+				outMu.Unlock()
+			}
+		}()
+	}
+
+	start := time.Now()
+	err = w.Run(ctx, func(ev watcher.Event) {
+		select {
+		case tasks <- ev.Path:
+		case <-ctx.Done():
+		}
+	})
+	close(tasks)
+	wg.Wait()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 文件监控异常退出: %v\n", err)
+		return 1
+	}
+
+	if !jsonLines {
+		fmt.Println()
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("监控 %v: 检测 %d 个文件，命中 %d，失败 %d\n",
+			time.Since(start).Round(time.Second), scanned, detected, failed)
+	}
+	return 0
+}
+
+// printWatchResult 实时输出单个文件的检测结果，未命中与失败只在 -v 时输出
+func printWatchResult(r ScanResult, jsonLines bool) {
+	if !r.Detected && !verbose {
+		return
+	}
+	if jsonLines {
+		if line, err := json.Marshal(r); err == nil {
+			fmt.Println(string(line))
+		}
+		return
+	}
+	ts := time.Now().Format("15:04:05")
+	switch {
+	case r.Detected:
+		printDetection(r)
+		if !quiet {
+			fmt.Printf("         时间: %s\n", ts)
+		}
+	case r.Error != "":
+		fmt.Printf("%s [错误] %s: %s\n", ts, r.FilePath, r.Error)
+	default:
+		fmt.Printf("%s [安全] %s (耗时: %v)\n", ts, r.FilePath, r.Duration)
+	}
+}
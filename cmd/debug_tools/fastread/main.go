@@ -0,0 +1,236 @@
+//go:build linux
+
+// Package main 文件读取吞吐基准工具
+// 对比标准库逐个读取、posix_fadvise 预读与 io_uring 批量读取在大量小文件场景下的吞吐，
+// 用于验证 internal/fastread 在目标主机上的收益
+package main
+
+import (
+	"crypto/md5"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/fastread"
+	"linuxFileWatcher/internal/safewalk"
+)
+
+// ==========================================
+// 命令行参数
+// ==========================================
+
+var (
+	targetDir  string // 扫描目录
+	modes      string // 读取方式
+	batchSize  int    // 每批文件数
+	maxSizeKB  int64  // 参与测试的文件大小上限
+	rounds     int    // 每种方式的轮数
+	dropCaches bool   // 每轮前清空页缓存
+	withHash   bool   // 读取后计算 MD5，模拟哈希检测
+	limit      int    // 最多测试的文件数
+)
+
+const (
+	toolName    = "fastread-bench"
+	toolVersion = "1.0.0"
+)
+
+func init() {
+	flag.StringVar(&targetDir, "d", ".", "扫描目录")
+	flag.StringVar(&modes, "mode", "all", "读取方式：std, fadvise, uring, all（逗号分隔）")
+	flag.IntVar(&batchSize, "batch", fastread.DefaultQueueDepth, "每批提交的文件数（io_uring 队列深度）")
+	flag.Int64Var(&maxSizeKB, "max-size", 1024, "参与测试的文件大小上限（KB）")
+	flag.IntVar(&rounds, "rounds", 3, "每种读取方式的测试轮数")
+	flag.BoolVar(&dropCaches, "drop-caches", false, "每轮前清空页缓存，测试冷读（需 root）")
+	flag.BoolVar(&withHash, "hash", false, "读取后计算 MD5")
+	flag.IntVar(&limit, "n", 0, "最多测试的文件数（0=不限）")
+}
+
+// ==========================================
+// 数据结构
+// ==========================================
+
+// roundResult 单轮测试结果
+type roundResult struct {
+	files    int
+	bytes    int64
+	errors   int
+	duration time.Duration
+}
+
+func (r roundResult) filesPerSec() float64 {
+	return float64(r.files) / r.duration.Seconds()
+}
+
+func (r roundResult) mbPerSec() float64 {
+	return float64(r.bytes) / 1024 / 1024 / r.duration.Seconds()
+}
+
+// ==========================================
+// 主函数
+// ==========================================
+
+func main() {
+	flag.Parse()
+
+	selected, err := parseModes(modes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	if dropCaches && os.Geteuid() != 0 {
+		fmt.Fprintln(os.Stderr, "错误: -drop-caches 需要 root 权限")
+		os.Exit(1)
+	}
+
+	files, total, err := collectFiles(targetDir, maxSizeKB*1024)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "收集文件失败: %v\n", err)
+		os.Exit(1)
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "错误: 目录中没有符合条件的文件")
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s %s\n", toolName, toolVersion)
+	fmt.Printf("文件数: %d, 总大小: %.1f MB, 每批: %d, 轮数: %d, 冷读: %v, MD5: %v\n",
+		len(files), float64(total)/1024/1024, batchSize, rounds, dropCaches, withHash)
+	fmt.Println(strings.Repeat("-", 72))
+	fmt.Printf("%-10s %10s %12s %12s %10s %8s\n", "方式", "耗时", "文件/秒", "MB/秒", "错误", "加速比")
+
+	var baseline float64
+	for _, mode := range selected {
+		best, kind, err := bench(mode, files)
+		if err != nil {
+			fmt.Printf("%-10s 不可用: %v\n", mode, err)
+			continue
+		}
+		speedup := "-"
+		if baseline == 0 {
+			baseline = best.filesPerSec()
+		} else {
+			speedup = fmt.Sprintf("%.2fx", best.filesPerSec()/baseline)
+		}
+		fmt.Printf("%-10s %10s %12.0f %12.1f %10d %8s\n",
+			kind, best.duration.Round(time.Millisecond), best.filesPerSec(), best.mbPerSec(), best.errors, speedup)
+	}
+}
+
+// parseModes 解析读取方式列表
+func parseModes(s string) ([]string, error) {
+	if s == "all" {
+		return []string{"std", "fadvise", "uring"}, nil
+	}
+	var out []string
+	for _, m := range strings.Split(s, ",") {
+		m = strings.TrimSpace(m)
+		switch m {
+		case "std", "fadvise", "uring":
+			out = append(out, m)
+		default:
+			return nil, fmt.Errorf("未知读取方式: %s", m)
+		}
+	}
+	return out, nil
+}
+
+// collectFiles 收集目录下不超过 maxSize 的普通文件
+func collectFiles(dir string, maxSize int64) ([]string, int64, error) {
+	var files []string
+	var total int64
+	err := safewalk.Walk(dir, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxSize {
+			return nil
+		}
+		files = append(files, path)
+		total += info.Size()
+		if limit > 0 && len(files) >= limit {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return files, total, err
+}
+
+// bench 以指定方式读取全部文件 rounds 轮，返回最快一轮
+func bench(mode string, files []string) (roundResult, string, error) {
+	var read func(paths []string) []fastread.Result
+	kind := mode
+
+	switch mode {
+	case "std":
+		read = readStd
+	case "fadvise", "uring":
+		r := fastread.NewBatchReader(fastread.Options{
+			DisableURing: mode == "fadvise",
+			QueueDepth:   batchSize,
+			MaxFileSize:  maxSizeKB * 1024,
+		})
+		defer r.Close()
+		if mode == "uring" && r.Kind() != "io_uring" {
+			return roundResult{}, kind, fmt.Errorf("内核不支持或已禁用 io_uring")
+		}
+		read, kind = r.ReadFiles, r.Kind()
+	}
+
+	var best roundResult
+	for i := 0; i < rounds; i++ {
+		if dropCaches {
+			if err := dropPageCache(); err != nil {
+				return roundResult{}, kind, err
+			}
+		}
+
+		var res roundResult
+		start := time.Now()
+		for s := 0; s < len(files); s += batchSize {
+			e := min(s+batchSize, len(files))
+			for _, r := range read(files[s:e]) {
+				if r.Err != nil {
+					res.errors++
+					continue
+				}
+				if withHash {
+					md5.Sum(r.Data)
+				}
+				res.files++
+				res.bytes += int64(len(r.Data))
+			}
+		}
+		res.duration = time.Since(start)
+
+		if best.duration == 0 || res.duration < best.duration {
+			best = res
+		}
+	}
+	return best, kind, nil
+}
+
+// readStd 标准库逐个读取，作为对照
+func readStd(paths []string) []fastread.Result {
+	results := make([]fastread.Result, len(paths))
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		results[i] = fastread.Result{Path: path, Data: data, Err: err}
+	}
+	return results
+}
+
+// dropPageCache 回写脏页并清空页缓存
+func dropPageCache() error {
+	syscall.Sync()
+	return os.WriteFile("/proc/sys/vm/drop_caches", []byte("3"), 0)
+}
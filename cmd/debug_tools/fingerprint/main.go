@@ -0,0 +1,135 @@
+// Package main 内容指纹规则生成与验证工具
+// 对已知涉密文档 (文本格式) 计算 simhash 与分段指纹，输出 fingerprint_detect 策略 JSON；
+// 指定 -check 时用生成的规则检测目标文件，用于调整判定阈值
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/fingerprint"
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 命令行参数
+// ==========================================
+
+var (
+	ruleID         int64   // 首条规则 ID
+	level          int     // 敏感级别
+	ruleDesc       string  // 规则描述，为空时使用文件名
+	ruleTypes      string  // 生成的规则类型
+	checkPath      string  // 待检测文件
+	maxDistance    int     // simhash 汉明距离上限
+	minContainment float64 // 分段指纹出现比例下限
+	minMatches     int     // 分段指纹数下限
+)
+
+func init() {
+	flag.Int64Var(&ruleID, "rule-id", 1, "首条规则 ID，每条规则依次递增")
+	flag.IntVar(&level, "level", 3, "敏感级别 (1-5)")
+	flag.StringVar(&ruleDesc, "desc", "", "规则描述，为空时使用文件名")
+	flag.StringVar(&ruleTypes, "type", "simhash,shingles", "规则类型：simhash, shingles（逗号分隔）")
+	flag.StringVar(&checkPath, "check", "", "用生成的规则检测该文件，不输出策略")
+	flag.IntVar(&maxDistance, "max-distance", fingerprint.DefaultMaxDistance, "simhash 汉明距离上限")
+	flag.Float64Var(&minContainment, "min-containment", fingerprint.DefaultMinContainment, "分段指纹出现比例下限")
+	flag.IntVar(&minMatches, "min-matches", fingerprint.DefaultMinMatches, "分段指纹数下限")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s [选项] 涉密文档...\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := buildRules(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	if checkPath != "" {
+		os.Exit(check(cfg))
+	}
+
+	out, _ := json.MarshalIndent(cfg, "", "  ")
+	fmt.Println(string(out))
+}
+
+// buildRules 为每个文档生成指定类型的规则
+func buildRules(files []string) (*model.FingerprintDetectConfig, error) {
+	types := map[string]int{"simhash": fingerprint.RuleTypeSimHash, "shingles": fingerprint.RuleTypeShingles}
+	var selected []string
+	for _, t := range strings.Split(ruleTypes, ",") {
+		t = strings.TrimSpace(t)
+		if _, ok := types[t]; !ok {
+			return nil, fmt.Errorf("未知的规则类型: %s", t)
+		}
+		selected = append(selected, t)
+	}
+
+	cfg := model.NewFingerprintDetectConfig()
+	id := ruleID
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fp := fingerprint.Compute(string(data))
+		if len(fp.Shingles) == 0 {
+			return nil, fmt.Errorf("%s: 文本过短，无法生成指纹", path)
+		}
+		desc := ruleDesc
+		if desc == "" {
+			desc = filepath.Base(path)
+		}
+		for _, t := range selected {
+			content := fingerprint.EncodeSimHash(fp.SimHash)
+			if types[t] == fingerprint.RuleTypeShingles {
+				content = fingerprint.EncodeShingles(fp.Shingles)
+			}
+			rule := model.NewFingerprintDetectRule(id, types[t], content, level)
+			rule.RuleDesc = desc
+			cfg.Rules = append(cfg.Rules, *rule)
+			id++
+		}
+		fmt.Fprintf(os.Stderr, "%s: simhash %s, 分段指纹 %d 个\n", path, fingerprint.EncodeSimHash(fp.SimHash), len(fp.Shingles))
+	}
+	return cfg, nil
+}
+
+// check 检测目标文件，命中返回 0，未命中返回 1
+func check(cfg *model.FingerprintDetectConfig) int {
+	d, err := fingerprint.New(cfg, fingerprint.Thresholds{
+		MaxDistance:    maxDistance,
+		MinContainment: minContainment,
+		MinMatches:     minMatches,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	res, err := d.DetectFile(context.Background(), checkPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 2
+	}
+	if !res.IsSecret {
+		fmt.Printf("%s: 未命中\n", checkPath)
+		return 1
+	}
+	fmt.Printf("%s: 命中规则 %d (%s)，%s\n", checkPath, res.RuleID, res.RuleDesc, res.MatchedText)
+	fmt.Printf("片段: %s\n", res.ContextText)
+	return 0
+}
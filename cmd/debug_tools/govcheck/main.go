@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -12,9 +13,12 @@ import (
 	"sync/atomic"
 	"time"
 
+	"linuxFileWatcher/cmd/debug_tools/internal/filelist"
 	"linuxFileWatcher/internal/detector/govcheck"
 	"linuxFileWatcher/internal/detector/govcheck/detector"
 	"linuxFileWatcher/internal/detector/govcheck/processor"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/safewalk"
 )
 
 // 版本信息
@@ -27,6 +31,8 @@ const (
 type CliConfig struct {
 	FilePath    string
 	DirPath     string
+	FilesFrom   string // 待检测路径列表文件，- 表示标准输入
+	FilesNul    bool   // 路径列表以 NUL 分隔
 	OutputJSON  bool
 	Verbose     bool
 	Threshold   float64
@@ -39,6 +45,11 @@ type CliConfig struct {
 
 	// 调试选项
 	UseSubDetector bool // 使用 SubDetector 接口（模拟上游调用）
+
+	// 阈值扫描
+	SweepSpec       string    // 起始:结束:步长
+	SweepThresholds []float64 // 由 SweepSpec 解析
+	LabelsPath      string    // 标注文件，用于计算精确率与召回率
 }
 
 func main() {
@@ -60,11 +71,15 @@ func main() {
 	}
 
 	// 验证参数
-	if cliConfig.FilePath == "" && cliConfig.DirPath == "" {
-		fmt.Fprintln(os.Stderr, "错误: 请指定待检测的文件 (-file) 或目录 (-dir)")
+	if cliConfig.FilePath == "" && cliConfig.DirPath == "" && cliConfig.FilesFrom == "" {
+		fmt.Fprintln(os.Stderr, "错误: 请指定待检测的文件 (-file)、目录 (-dir) 或路径列表 (-files-from)")
 		fmt.Fprintln(os.Stderr, "使用 -help 查看帮助信息")
 		os.Exit(1)
 	}
+	if err := checkSweepArgs(cliConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
 
 	// 运行检测
 	if err := run(cliConfig); err != nil {
@@ -82,6 +97,10 @@ func parseArgs() *CliConfig {
 	flag.StringVar(&cfg.DirPath, "dir", "", "待检测的目录路径")
 	flag.StringVar(&cfg.DirPath, "d", "", "待检测的目录路径 (简写)")
 
+	flag.StringVar(&cfg.FilesFrom, "files-from", "", "从文件读取待检测路径列表，- 表示标准输入")
+	flag.BoolVar(&cfg.FilesNul, "null", false, "-files-from 的列表以 NUL 分隔 (配合 find -print0)")
+	flag.BoolVar(&cfg.FilesNul, "0", false, "-files-from 的列表以 NUL 分隔 (简写)")
+
 	flag.BoolVar(&cfg.OutputJSON, "json", false, "以 JSON 格式输出结果")
 	flag.BoolVar(&cfg.Verbose, "verbose", false, "显示详细检测信息")
 	flag.BoolVar(&cfg.Verbose, "v", false, "显示详细检测信息 (简写)")
@@ -102,10 +121,13 @@ func parseArgs() *CliConfig {
 
 	flag.BoolVar(&cfg.UseSubDetector, "sub", false, "使用 SubDetector 接口（模拟上游调用）")
 
+	flag.StringVar(&cfg.SweepSpec, "sweep", "", "阈值扫描: 起始:结束:步长，如 0.3:0.9:0.05")
+	flag.StringVar(&cfg.LabelsPath, "labels", "", "阈值扫描的标注文件 (每行 路径,1/0)")
+
 	flag.Parse()
 
 	// 支持位置参数
-	if cfg.FilePath == "" && cfg.DirPath == "" && flag.NArg() > 0 {
+	if cfg.FilePath == "" && cfg.DirPath == "" && cfg.FilesFrom == "" && flag.NArg() > 0 {
 		cfg.FilePath = flag.Arg(0)
 	}
 
@@ -171,6 +193,9 @@ func runWithInternalDetector(cfg *CliConfig, files []string, startTime time.Time
 	totalTime := time.Since(startTime)
 
 	// 输出结果
+	if cfg.SweepSpec != "" {
+		return outputSweep(cfg, results, totalTime)
+	}
 	if cfg.OutputJSON {
 		return outputJSON(results, totalTime)
 	}
@@ -285,6 +310,25 @@ func runWithSubDetector(cfg *CliConfig, files []string, startTime time.Time) err
 	return nil
 }
 
+// checkSweepArgs 校验阈值扫描参数
+func checkSweepArgs(cfg *CliConfig) error {
+	if cfg.SweepSpec == "" {
+		if cfg.LabelsPath != "" {
+			return fmt.Errorf("-labels 需与 -sweep 一起使用")
+		}
+		return nil
+	}
+	if cfg.UseSubDetector {
+		return fmt.Errorf("-sweep 不支持 -sub 模式 (SubDetector 接口不返回得分)")
+	}
+	thresholds, err := parseSweep(cfg.SweepSpec)
+	if err != nil {
+		return err
+	}
+	cfg.SweepThresholds = thresholds
+	return nil
+}
+
 // registerProcessors 注册所有处理器
 func registerProcessors(det *detector.Detector, cfg *CliConfig) {
 	det.RegisterProcessor(processor.NewTextProcessor())
@@ -332,7 +376,21 @@ func detectBatchParallel(det *detector.Detector, files []string, workers int) []
 func collectFiles(cfg *CliConfig) ([]string, error) {
 	var files []string
 
-	if cfg.FilePath != "" {
+	if cfg.FilesFrom != "" {
+		paths, err := filelist.Load(cfg.FilesFrom, cfg.FilesNul)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件列表失败: %w", err)
+		}
+		// 列表中的目录按 -dir 的规则遍历
+		files = filelist.Expand(paths, func(path string) ([]string, error) {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				return collectFiles(&CliConfig{DirPath: path})
+			}
+			return collectFiles(&CliConfig{FilePath: path})
+		}, func(path string, err error) {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", path, err)
+		})
+	} else if cfg.FilePath != "" {
 		absPath, err := filepath.Abs(cfg.FilePath)
 		if err != nil {
 			return nil, err
@@ -342,19 +400,27 @@ func collectFiles(cfg *CliConfig) ([]string, error) {
 		}
 		files = append(files, absPath)
 	} else if cfg.DirPath != "" {
-		err := filepath.Walk(cfg.DirPath, func(path string, info os.FileInfo, err error) error {
+		// 手动扫描只跳过伪文件系统，网络文件系统照常扫描 (规则不含 glob，不会出错)
+		filter, _ := pathfilter.New(pathfilter.ManualOptions())
+		err := safewalk.Walk(cfg.DirPath, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
-			if info.IsDir() {
+			if d.IsDir() {
 				// 跳过隐藏目录
-				if len(info.Name()) > 0 && info.Name()[0] == '.' {
+				if len(d.Name()) > 0 && d.Name()[0] == '.' {
+					return filepath.SkipDir
+				}
+				if path != cfg.DirPath && filter.SkipDir(path, pathfilter.Depth(cfg.DirPath, path)) {
 					return filepath.SkipDir
 				}
 				return nil
 			}
+			if filter.SkipFile(path, pathfilter.Depth(cfg.DirPath, path)) {
+				return nil
+			}
 			// 跳过隐藏文件
-			if len(info.Name()) > 0 && info.Name()[0] == '.' {
+			if len(d.Name()) > 0 && d.Name()[0] == '.' {
 				return nil
 			}
 			absPath, err := filepath.Abs(path)
@@ -527,10 +593,13 @@ func printHelp() {
   %s [选项] [文件路径]
   %s -file <文件路径>
   %s -dir <目录路径>
+  %s -files-from <列表文件|->
 
 选项:
   -file, -f <路径>      指定待检测的单个文件
   -dir, -d <路径>       指定待检测的目录
+  -files-from <路径>    从文件读取待检测路径，每行一个，- 表示标准输入；列表中的目录按 -dir 遍历
+  -null, -0             -files-from 的列表以 NUL 分隔 (配合 find -print0 / fd -0)
   -threshold, -t <值>   公文判定阈值 (0-1)，默认 0.6
   -workers, -w <数量>   并行处理协程数，默认 4
   -timeout <秒>         单文件处理超时，默认 30
@@ -538,6 +607,8 @@ func printHelp() {
   -verbose, -v          详细输出模式
   -no-ocr               禁用 OCR 功能
   -sub                  使用 SubDetector 接口（模拟上游调用）
+  -sweep <起:止:步长>    阈值扫描: 检测一次，输出每个阈值下的公文/非公文数量
+  -labels <路径>        阈值扫描的标注文件 (每行 路径,1/0)，输出精确率、召回率与 F1
   -status               显示系统状态
   -version              显示版本信息
   -help, -h             显示帮助信息
@@ -559,11 +630,17 @@ func printHelp() {
   # 调整阈值，JSON 输出
   %s -file doc.docx -threshold 0.5 -json
 
+  # 阈值扫描，按标注计算精确率与召回率
+  %s -dir ./samples/ -sweep 0.3:0.9:0.05 -labels labels.csv
+
+  # 检测 find 找到的文件
+  find ./documents -name '*.ofd' -print0 | %s -files-from - -0
+
   # 查看系统状态
   %s -status
 `
-	fmt.Printf(help, ToolName, ToolVersion, ToolName, ToolName, ToolName,
-		ToolName, ToolName, ToolName, ToolName, ToolName)
+	fmt.Printf(help, ToolName, ToolVersion, ToolName, ToolName, ToolName, ToolName,
+		ToolName, ToolName, ToolName, ToolName, ToolName, ToolName, ToolName)
 }
 
 func formatAvailable(available bool) string {
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/detector/govcheck/detector"
+)
+
+// ==========================================
+// 阈值扫描 (-sweep)
+// ==========================================
+//
+// 综合得分与阈值无关，检测只执行一次，之后按 "起始:结束:步长" 依次统计每个阈值下的公文/非公文数量；
+// 指定 -labels 标注文件时同时计算精确率、召回率与 F1，用于按实际样本选择阈值。
+
+// sweepPoint 单个阈值的统计
+type sweepPoint struct {
+	Threshold   float64 `json:"threshold"`
+	Official    int     `json:"official"`
+	NonOfficial int     `json:"non_official"`
+	// 以下字段只在指定标注文件时输出，分母为 0 时不输出
+	TP        *int     `json:"tp,omitempty"`
+	FP        *int     `json:"fp,omitempty"`
+	FN        *int     `json:"fn,omitempty"`
+	TN        *int     `json:"tn,omitempty"`
+	Precision *float64 `json:"precision,omitempty"`
+	Recall    *float64 `json:"recall,omitempty"`
+	F1        *float64 `json:"f1,omitempty"`
+}
+
+// parseSweep 解析 "起始:结束:步长"，返回按步长递增的阈值
+func parseSweep(spec string) ([]float64, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("无效的 -sweep %q，格式为 起始:结束:步长，如 0.3:0.9:0.05", spec)
+	}
+	var v [3]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 -sweep %q: %v", spec, err)
+		}
+		v[i] = f
+	}
+	start, end, step := v[0], v[1], v[2]
+	if start < 0 || end > 1 || start > end {
+		return nil, fmt.Errorf("无效的 -sweep %q，阈值应满足 0 <= 起始 <= 结束 <= 1", spec)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("无效的 -sweep %q，步长应大于 0", spec)
+	}
+	// 按序号计算，避免步长累加的浮点误差漏掉结束值
+	n := int(math.Floor((end-start)/step+1e-9)) + 1
+	thresholds := make([]float64, n)
+	for i := range thresholds {
+		thresholds[i] = math.Round((start+float64(i)*step)*1e6) / 1e6
+	}
+	return thresholds, nil
+}
+
+// loadLabels 读取标注文件，返回 绝对路径 -> 是否为公文
+// 每行 "路径,标注"，标注为 1/0、true/false、official/non_official；# 开头的行为注释，
+// 相对路径相对于标注文件所在目录
+func loadLabels(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[string]bool)
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析标注文件失败: %v", err)
+		}
+		line, _ := r.FieldPos(0)
+		var official bool
+		switch strings.ToLower(strings.TrimSpace(rec[1])) {
+		case "1", "true", "yes", "official":
+			official = true
+		case "0", "false", "no", "non_official":
+		default:
+			return nil, fmt.Errorf("标注文件第 %d 行: 无效的标注 %q，可选: 1/0、true/false、official/non_official", line, rec[1])
+		}
+		p := strings.TrimSpace(rec[0])
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(base, p)
+		}
+		labels[filepath.Clean(p)] = official
+	}
+	if len(labels) == 0 {
+		return nil, fmt.Errorf("标注文件 %s 中没有标注", path)
+	}
+	return labels, nil
+}
+
+// sweep 统计每个阈值下的判定结果，labels 为 nil 时不计算精确率与召回率
+// 检测失败的文件不参与统计
+func sweep(results []*detector.DetectionResult, thresholds []float64, labels map[string]bool) []sweepPoint {
+	points := make([]sweepPoint, len(thresholds))
+	for i, t := range thresholds {
+		p := sweepPoint{Threshold: t}
+		var tp, fp, fn, tn int
+		for _, r := range results {
+			if !r.Success {
+				continue
+			}
+			predicted := r.Confidence >= t
+			if predicted {
+				p.Official++
+			} else {
+				p.NonOfficial++
+			}
+			if labels == nil {
+				continue
+			}
+			actual, ok := labels[filepath.Clean(r.FilePath)]
+			if !ok {
+				continue
+			}
+			switch {
+			case predicted && actual:
+				tp++
+			case predicted && !actual:
+				fp++
+			case !predicted && actual:
+				fn++
+			default:
+				tn++
+			}
+		}
+		if labels != nil {
+			p.TP, p.FP, p.FN, p.TN = &tp, &fp, &fn, &tn
+			p.Precision = ratio(tp, tp+fp)
+			p.Recall = ratio(tp, tp+fn)
+			if p.Precision != nil && p.Recall != nil && *p.Precision+*p.Recall > 0 {
+				f1 := 2 * *p.Precision * *p.Recall / (*p.Precision + *p.Recall)
+				p.F1 = &f1
+			}
+		}
+		points[i] = p
+	}
+	return points
+}
+
+func ratio(n, d int) *float64 {
+	if d == 0 {
+		return nil
+	}
+	v := float64(n) / float64(d)
+	return &v
+}
+
+// bestF1 F1 最高的阈值 (相同时取较低的阈值)，没有可用的 F1 时返回 -1
+func bestF1(points []sweepPoint) int {
+	best := -1
+	for i, p := range points {
+		if p.F1 != nil && (best < 0 || *p.F1 > *points[best].F1) {
+			best = i
+		}
+	}
+	return best
+}
+
+// sweepCounts 标注覆盖情况: 参与统计的文件数、有标注的文件数、失败数
+func sweepCounts(results []*detector.DetectionResult, labels map[string]bool) (scored, labeled, failed int) {
+	for _, r := range results {
+		if !r.Success {
+			failed++
+			continue
+		}
+		scored++
+		if _, ok := labels[filepath.Clean(r.FilePath)]; ok {
+			labeled++
+		}
+	}
+	return scored, labeled, failed
+}
+
+// outputSweep 输出阈值扫描结果
+func outputSweep(cfg *CliConfig, results []*detector.DetectionResult, totalTime time.Duration) error {
+	var labels map[string]bool
+	if cfg.LabelsPath != "" {
+		var err error
+		if labels, err = loadLabels(cfg.LabelsPath); err != nil {
+			return err
+		}
+	}
+	points := sweep(results, cfg.SweepThresholds, labels)
+	scored, labeled, failed := sweepCounts(results, labels)
+
+	if cfg.OutputJSON {
+		output := map[string]interface{}{
+			"mode":       "sweep",
+			"total":      len(results),
+			"scored":     scored,
+			"failed":     failed,
+			"points":     points,
+			"total_time": totalTime.String(),
+		}
+		if labels != nil {
+			output["labeled"] = labeled
+			if i := bestF1(points); i >= 0 {
+				output["best_f1_threshold"] = points[i].Threshold
+			}
+		}
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println("========================================")
+	fmt.Println(" 阈值扫描结果")
+	fmt.Println("========================================")
+	fmt.Printf("文件: %d 个 (参与统计 %d，失败 %d)", len(results), scored, failed)
+	if labels != nil {
+		fmt.Printf("，有标注 %d 个", labeled)
+	}
+	fmt.Println()
+	fmt.Println()
+
+	if labels == nil {
+		// 中文表头按显示宽度 (每字两列) 对齐
+		fmt.Printf("%-6s %6s %5s\n", "阈值", "公文", "非公文")
+		for _, p := range points {
+			fmt.Printf("%-8.2f %8d %8d\n", p.Threshold, p.Official, p.NonOfficial)
+		}
+	} else {
+		fmt.Printf("%-6s %4s %3s %5s %5s %5s %5s %5s %5s %6s\n", "阈值", "公文", "非公文", "TP", "FP", "FN", "TN", "精确率", "召回率", "F1")
+		for _, p := range points {
+			fmt.Printf("%-8.2f %6d %6d %5d %5d %5d %5d %8s %8s %6s\n", p.Threshold, p.Official, p.NonOfficial,
+				*p.TP, *p.FP, *p.FN, *p.TN, formatPercent(p.Precision), formatPercent(p.Recall), formatRatio(p.F1))
+		}
+		if i := bestF1(points); i >= 0 {
+			fmt.Println()
+			fmt.Printf("F1 最高的阈值: %.2f (F1 %.3f)\n", points[i].Threshold, *points[i].F1)
+		}
+		if labeled < scored {
+			fmt.Printf("提示: %d 个文件没有标注，不计入精确率与召回率\n", scored-labeled)
+		}
+	}
+	fmt.Printf("总耗时: %v\n", totalTime)
+	return nil
+}
+
+func formatPercent(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%%", *v*100)
+}
+
+func formatRatio(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f", *v)
+}
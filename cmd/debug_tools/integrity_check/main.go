@@ -14,7 +14,9 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"linuxFileWatcher/internal/security/baseline"
 	"linuxFileWatcher/internal/security/integrity"
+	"linuxFileWatcher/internal/security/pkgverify"
 )
 
 // ==========================================
@@ -27,10 +29,16 @@ var (
 	appName = "integrity-checker"
 
 	// 命令行参数
-	targetFile    string
+	targetFiles   []string
 	checkInterval time.Duration
 	verboseMode   bool
 
+	// 签名基线参数
+	baselineOutput string
+	signingKey     string
+	verifyPubKey   string
+	signatureOnly  bool
+
 	// 颜色输出
 	colorRed     = color.New(color.FgRed, color.Bold)
 	colorGreen   = color.New(color.FgGreen, color.Bold)
@@ -71,7 +79,9 @@ var rootCmd = &cobra.Command{
 用于单独测试和排查文件完整性校验逻辑，支持：
   - 单次校验：对指定文件执行一次 SM3 哈希计算
   - 持续监控：周期性检查文件是否被篡改或删除
-  - 基线生成：生成文件的基线哈希值
+  - 基线生成：生成文件的基线哈希值，可输出 SM2 签名的基线文件
+  - 基线校验：校验基线文件签名后与磁盘文件对比
+  - 软件包校验：按 dpkg / rpm 软件包数据库校验文件
 
 示例:
   # 检查指定文件的完整性
@@ -80,8 +90,18 @@ var rootCmd = &cobra.Command{
   # 启动持续监控模式
   integrity-checker watch --file /usr/bin/myapp --interval 30s
 
+  # 同时监控多个文件、目录与 glob 模式
+  integrity-checker watch -f /usr/bin/myapp -f /etc/myapp/ -f '/etc/ssh/*_config'
+
   # 生成基线哈希
   integrity-checker baseline --file /usr/bin/myapp
+
+  # 生成签名基线文件并离线校验
+  integrity-checker baseline /usr/bin /etc/ssh -o baseline.json -k baseline_sm2.key
+  integrity-checker verify baseline.json --pubkey baseline_sm2.key.pub
+
+  # 按软件包数据库校验
+  integrity-checker pkgverify /usr/bin/ls /usr/sbin/sshd
 `,
 	Version: version,
 }
@@ -154,17 +174,22 @@ func runCheck(cmd *cobra.Command, args []string) error {
 // ==========================================
 
 var baselineCmd = &cobra.Command{
-	Use:   "baseline",
+	Use:   "baseline [path...]",
 	Short: "生成文件的基线哈希值",
 	Long: `计算指定文件的 SM3 哈希值，用于建立完整性校验基线。
 
-输出格式适合保存到配置文件或用于后续对比。`,
+输出格式适合保存到配置文件或用于后续对比。
+指定 --output 时为参数中的文件与目录 (递归) 生成基线文件，并以 --key 指定的 SM2 私钥签名。`,
 	RunE: runBaseline,
 }
 
 func runBaseline(cmd *cobra.Command, args []string) error {
 	printBanner()
 
+	if baselineOutput != "" {
+		return writeSignedBaseline(args)
+	}
+
 	target, err := resolveTargetFile()
 	if err != nil {
 		return err
@@ -203,43 +228,241 @@ integrity_baseline:
 	return nil
 }
 
+// writeSignedBaseline 生成基线文件并签名
+func writeSignedBaseline(paths []string) error {
+	if signingKey == "" {
+		return fmt.Errorf("生成基线文件需要 --key 指定签名私钥")
+	}
+	if len(paths) == 0 {
+		var err error
+		if paths, err = resolveTargetFiles(nil); err != nil {
+			return err
+		}
+	}
+	priv, err := baseline.LoadPrivateKey(signingKey)
+	if err != nil {
+		return err
+	}
+
+	colorYellow.Println("🔄 正在计算 SM3 哈希...")
+	f, err := baseline.Generate(paths)
+	if err != nil {
+		return fmt.Errorf("生成基线失败: %v", err)
+	}
+	if err := f.Sign(priv); err != nil {
+		return fmt.Errorf("签名失败: %v", err)
+	}
+	if err := f.Save(baselineOutput); err != nil {
+		return fmt.Errorf("写入基线文件失败: %v", err)
+	}
+
+	colorGreen.Println("✅ 基线文件已生成并签名")
+	fmt.Printf("基线文件    : %s\n", baselineOutput)
+	fmt.Printf("文件数      : %d\n", len(f.Entries))
+	fmt.Printf("签名公钥    : %s\n", f.Signer)
+	return nil
+}
+
+// ==========================================
+// verify 命令 - 校验签名基线
+// ==========================================
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <baseline.json>",
+	Short: "校验签名基线文件并与磁盘文件对比",
+	Long: `使用可信公钥校验基线文件的 SM2 签名，证明基线生成后未被修改；
+签名有效时再将磁盘上的文件与基线对比。签名无效时不进行对比。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runVerify,
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	if verifyPubKey == "" {
+		return fmt.Errorf("需要 --pubkey 指定可信公钥 (十六进制或公钥文件)")
+	}
+	pub, err := baseline.LoadPublicKey(verifyPubKey)
+	if err != nil {
+		return err
+	}
+
+	colorCyan.Printf("📄 基线文件: %s\n", args[0])
+	printSeparator()
+
+	f, err := baseline.LoadVerified(args[0], pub)
+	if err != nil {
+		colorRed.Printf("❌ 签名校验失败: %v\n", err)
+		return err
+	}
+	colorGreen.Println("✅ 签名有效，基线未被修改")
+	fmt.Printf("生成时间    : %s\n", f.GeneratedAt)
+	if f.Hostname != "" {
+		fmt.Printf("生成主机    : %s\n", f.Hostname)
+	}
+	fmt.Printf("文件数      : %d\n", len(f.Entries))
+	if signatureOnly {
+		return nil
+	}
+	printSeparator()
+
+	failed := 0
+	for _, r := range f.Compare() {
+		switch r.Status {
+		case baseline.StatusOK:
+			if verboseMode {
+				colorGreen.Printf("✅ %s\n", r.Path)
+			}
+			continue
+		case baseline.StatusMetadata:
+			colorYellow.Printf("⚠️  %s  [%s] %s\n", r.Path, r.Status, r.Detail)
+		default:
+			failed++
+			colorRed.Printf("❌ %s  [%s]\n", r.Path, r.Status)
+			if r.Actual != "" {
+				fmt.Printf("    期望摘要: %s\n    实际摘要: %s\n", r.Expected, r.Actual)
+			} else if r.Detail != "" {
+				fmt.Printf("    错误: %s\n", r.Detail)
+			}
+		}
+	}
+
+	printSeparator()
+	if failed > 0 {
+		return fmt.Errorf("%d 个文件与基线不一致", failed)
+	}
+	colorGreen.Println("✅ 全部文件与基线一致")
+	return nil
+}
+
+// ==========================================
+// pkgverify 命令 - 软件包校验
+// ==========================================
+
+var pkgverifyCmd = &cobra.Command{
+	Use:   "pkgverify [file...]",
+	Short: "按软件包数据库校验文件",
+	Long: `从 dpkg / rpm 软件包数据库读取文件摘要，与磁盘上的文件对比。
+
+未指定文件时校验 --file 或当前程序自身。`,
+	RunE: runPkgverify,
+}
+
+func runPkgverify(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	paths := args
+	if len(paths) == 0 {
+		var err error
+		if paths, err = resolveTargetFiles(nil); err != nil {
+			return err
+		}
+	}
+
+	db, err := pkgverify.Open("/")
+	if err != nil {
+		return fmt.Errorf("打开软件包数据库失败: %v", err)
+	}
+	colorCyan.Printf("📦 软件包数据库: %s\n", db.Name())
+	printSeparator()
+
+	failed := 0
+	for _, r := range pkgverify.NewChecker(db, paths).Verify() {
+		switch r.Status {
+		case pkgverify.StatusOK:
+			colorGreen.Printf("✅ %s", r.Path)
+		case pkgverify.StatusModified, pkgverify.StatusMissing:
+			failed++
+			colorRed.Printf("❌ %s", r.Path)
+		default:
+			colorYellow.Printf("⚠️  %s", r.Path)
+		}
+		fmt.Printf("  [%s]", r.Status)
+		if r.Package != "" {
+			fmt.Printf("  软件包: %s", r.Package)
+		}
+		fmt.Println()
+		if verboseMode || r.Status == pkgverify.StatusModified {
+			if r.Expected != "" {
+				fmt.Printf("    期望摘要: %s\n", r.Expected)
+			}
+			if r.Actual != "" {
+				fmt.Printf("    实际摘要: %s\n", r.Actual)
+			}
+		}
+		if r.Err != nil {
+			fmt.Printf("    错误: %v\n", r.Err)
+		}
+	}
+
+	printSeparator()
+	if failed > 0 {
+		return fmt.Errorf("%d 个文件校验失败", failed)
+	}
+	return nil
+}
+
 // ==========================================
 // watch 命令 - 持续监控
 // ==========================================
 
 var watchCmd = &cobra.Command{
-	Use:   "watch",
+	Use:   "watch [path...]",
 	Short: "启动持续监控模式",
 	Long: `启动后台监控，周期性检查文件完整性。
 
-当检测到文件被篡改或删除时，会输出告警信息。
-按 Ctrl+C 停止监控。`,
+监控目标由参数与 --file 指定 (可多次指定)，支持文件、目录 (递归其下的普通文件) 与 glob 模式，
+启动时为每个文件建立基线。文件被篡改、删除或权限变化时输出告警，同一文件状态不变时不重复告警。
+按 Ctrl+C 停止监控，停止时输出各文件的状态汇总表。`,
 	RunE: runWatch,
 }
 
+// fileStatus 单个文件的监控状态
+type fileStatus struct {
+	status baseline.Status
+	since  time.Time // 进入当前状态的时间
+	alerts int
+	detail string
+}
+
 func runWatch(cmd *cobra.Command, args []string) error {
 	printBanner()
 
-	target, err := resolveTargetFile()
+	targets, err := resolveTargetFiles(args)
 	if err != nil {
 		return err
 	}
 
-	colorCyan.Printf("📁 监控目标: %s\n", target)
+	colorCyan.Printf("📁 监控目标: %d 个文件\n", len(targets))
+	if verboseMode || len(targets) <= 10 {
+		for _, t := range targets {
+			fmt.Printf("   • %s\n", t)
+		}
+	}
 	colorCyan.Printf("⏱️  检查间隔: %v\n", checkInterval)
 	printSeparator()
 
 	// 计算初始基线
 	colorYellow.Println("🔄 正在建立基线...")
 
-	baselineHash, err := integrity.ComputeFileSM3(target)
+	base, err := baseline.Generate(targets)
 	if err != nil {
 		return fmt.Errorf("无法建立基线: %v", err)
 	}
 
-	colorGreen.Printf("✅ 基线已建立: %s\n", baselineHash)
+	if len(base.Entries) == 1 {
+		colorGreen.Printf("✅ 基线已建立: %s\n", base.Entries[0].Hash)
+	} else {
+		colorGreen.Printf("✅ 基线已建立: %d 个文件\n", len(base.Entries))
+	}
 	printSeparator()
 
+	now := time.Now()
+	states := make(map[string]*fileStatus, len(base.Entries))
+	for _, e := range base.Entries {
+		states[e.Path] = &fileStatus{status: baseline.StatusOK, since: now}
+	}
+
 	// 创建自定义 Reporter
 	reporter := &DebugReporter{verbose: verboseMode}
 
@@ -265,56 +488,103 @@ func runWatch(cmd *cobra.Command, args []string) error {
 			colorYellow.Println("🛑 收到停止信号，正在退出...")
 			colorWhite.Printf("   总运行时间: %v\n", time.Since(startTime).Round(time.Second))
 			colorWhite.Printf("   检查次数: %d\n", checkCount)
+			printSeparator()
+			printStatusTable(base, states)
 			colorGreen.Println("👋 监控已停止")
 			return nil
 
 		case <-ticker.C:
 			checkCount++
-			performIntegrityCheck(target, baselineHash, reporter, checkCount)
+			performIntegrityCheck(base, states, reporter, checkCount)
 		}
 	}
 }
 
-// performIntegrityCheck 执行一次完整性检查
-func performIntegrityCheck(target, baselineHash string, reporter *DebugReporter, count int) {
+// performIntegrityCheck 对比全部文件与基线，文件状态变化时告警
+func performIntegrityCheck(base *baseline.File, states map[string]*fileStatus, reporter *DebugReporter, count int) {
 	timestamp := time.Now().Format("15:04:05")
 
 	if verboseMode {
 		colorWhite.Printf("[%s] 第 %d 次检查...\n", timestamp, count)
 	}
 
-	// 1. 检查文件是否存在
-	_, err := os.Stat(target)
-	if err != nil {
-		if os.IsNotExist(err) {
-			reporter.Report(integrity.TypeFileDeleted, fmt.Sprintf("文件已删除: %s", target))
-		} else {
-			reporter.Report(integrity.TypeReadError, fmt.Sprintf("无法访问文件: %v", err))
+	counts := make(map[baseline.Status]int)
+	for _, r := range base.Compare() {
+		counts[r.Status]++
+		st := states[r.Path]
+		if r.Status == st.status {
+			continue
 		}
-		return
-	}
-
-	// 2. 计算当前哈希
-	currentHash, err := integrity.ComputeFileSM3(target)
-	if err != nil {
-		reporter.Report(integrity.TypeReadError, fmt.Sprintf("哈希计算失败: %v", err))
-		return
-	}
-
-	// 3. 对比基线
-	if currentHash != baselineHash {
-		reporter.Report(integrity.TypeFileModified, fmt.Sprintf(
-			"文件内容已变更!\n   基线哈希: %s\n   当前哈希: %s",
-			baselineHash, currentHash))
-		return
+		prev := st.status
+		st.status, st.since, st.detail = r.Status, time.Now(), r.Detail
+
+		switch r.Status {
+		case baseline.StatusOK:
+			colorGreen.Printf("[%s] ✓ 已恢复: %s (之前: %s)\n", timestamp, r.Path, prev)
+			continue
+		case baseline.StatusModified:
+			reporter.Report(integrity.TypeFileModified, fmt.Sprintf(
+				"文件内容已变更: %s\n   基线哈希: %s\n   当前哈希: %s",
+				r.Path, r.Expected, r.Actual))
+		case baseline.StatusMetadata:
+			reporter.Report(integrity.TypeFileModified, fmt.Sprintf("文件权限已变化: %s\n   %s", r.Path, r.Detail))
+		default:
+			if _, err := os.Stat(r.Path); os.IsNotExist(err) {
+				reporter.Report(integrity.TypeFileDeleted, fmt.Sprintf("文件已删除: %s", r.Path))
+			} else {
+				reporter.Report(integrity.TypeReadError, fmt.Sprintf("无法访问文件: %s\n   %s", r.Path, r.Detail))
+			}
+		}
+		st.alerts++
 	}
 
 	// 正常
 	if verboseMode {
-		colorGreen.Printf("[%s] ✓ 检查通过 (Hash: %s...)\n", timestamp, currentHash[:16])
+		if len(base.Entries) == 1 && counts[baseline.StatusOK] == 1 {
+			colorGreen.Printf("[%s] ✓ 检查通过 (Hash: %s...)\n", timestamp, base.Entries[0].Hash[:16])
+			return
+		}
+		colorGreen.Printf("[%s] ✓ 正常 %d | 篡改 %d | 权限变化 %d | 删除/无法访问 %d\n", timestamp,
+			counts[baseline.StatusOK], counts[baseline.StatusModified],
+			counts[baseline.StatusMetadata], counts[baseline.StatusMissing])
 	}
 }
 
+// printStatusTable 打印各文件的状态汇总，异常文件排在前面
+func printStatusTable(base *baseline.File, states map[string]*fileStatus) {
+	colorCyan.Println("📊 文件状态汇总:")
+	fmt.Printf("  %-10s %-6s %-20s %s\n", "状态", "告警", "状态开始时间", "文件")
+	fmt.Println("  " + strings.Repeat("-", 90))
+
+	ok := 0
+	for _, abnormal := range []bool{true, false} {
+		for _, e := range base.Entries {
+			st := states[e.Path]
+			if (st.status != baseline.StatusOK) != abnormal {
+				continue
+			}
+			c := colorRed
+			switch st.status {
+			case baseline.StatusOK:
+				ok++
+				// 文件较多时正常且未告警过的文件只计数
+				if len(base.Entries) > 20 && st.alerts == 0 && !verboseMode {
+					continue
+				}
+				c = colorGreen
+			case baseline.StatusMetadata:
+				c = colorYellow
+			}
+			c.Printf("  %-10s %-6d %-20s %s\n", st.status, st.alerts, st.since.Format("2006-01-02 15:04:05"), e.Path)
+			if st.detail != "" && verboseMode {
+				fmt.Printf("             %s\n", st.detail)
+			}
+		}
+	}
+	fmt.Println()
+	colorCyan.Printf("📈 共 %d 个文件 | 正常 %d | 异常 %d\n", len(base.Entries), ok, len(base.Entries)-ok)
+}
+
 // ==========================================
 // 自定义 Reporter 实现
 // ==========================================
@@ -354,11 +624,14 @@ func (r *DebugReporter) Report(vType integrity.ViolationType, msg string) {
 // 辅助函数
 // ==========================================
 
-// resolveTargetFile 解析目标文件路径
+// resolveTargetFile 解析单个目标文件路径，用于只接受一个目标的命令
 func resolveTargetFile() (string, error) {
-	if targetFile != "" {
+	if len(targetFiles) > 1 {
+		return "", fmt.Errorf("该命令只接受一个 --file")
+	}
+	if len(targetFiles) == 1 {
 		// 用户指定了文件
-		absPath, err := filepath.Abs(targetFile)
+		absPath, err := filepath.Abs(targetFiles[0])
 		if err != nil {
 			return "", fmt.Errorf("无法解析路径: %v", err)
 		}
@@ -373,6 +646,27 @@ func resolveTargetFile() (string, error) {
 	return selfPath, nil
 }
 
+// resolveTargetFiles 将参数与 --file 指定的文件、目录与 glob 模式展开为文件列表
+// 均未指定时为当前程序自身
+func resolveTargetFiles(args []string) ([]string, error) {
+	targets := append(append([]string(nil), args...), targetFiles...)
+	if len(targets) == 0 {
+		self, err := resolveTargetFile()
+		if err != nil {
+			return nil, err
+		}
+		return []string{self}, nil
+	}
+	files, err := baseline.Expand(targets)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析监控目标: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("监控目标中没有文件")
+	}
+	return files, nil
+}
+
 // printBanner 打印工具标题
 func printBanner() {
 	fmt.Println()
@@ -429,14 +723,22 @@ func formatFileSize(size int64) string {
 
 func init() {
 	// 全局参数
-	rootCmd.PersistentFlags().StringVarP(&targetFile, "file", "f", "", "要检查的目标文件路径 (默认: 当前程序自身)")
+	rootCmd.PersistentFlags().StringArrayVarP(&targetFiles, "file", "f", nil, "目标文件路径 (默认: 当前程序自身)；watch 等命令可多次指定，支持目录与 glob 模式")
 	rootCmd.PersistentFlags().BoolVarP(&verboseMode, "verbose", "v", false, "启用详细输出模式")
 
 	// watch 命令特有参数
 	watchCmd.Flags().DurationVarP(&checkInterval, "interval", "i", 30*time.Second, "检查间隔时间 (如: 10s, 1m, 5m)")
 
+	// baseline / verify 命令参数
+	baselineCmd.Flags().StringVarP(&baselineOutput, "output", "o", "", "生成签名基线文件的路径")
+	baselineCmd.Flags().StringVarP(&signingKey, "key", "k", "", "SM2 签名私钥文件 (十六进制 d)")
+	verifyCmd.Flags().StringVar(&verifyPubKey, "pubkey", "", "可信 SM2 公钥 (十六进制或公钥文件)")
+	verifyCmd.Flags().BoolVar(&signatureOnly, "signature-only", false, "只校验签名，不与磁盘文件对比")
+
 	// 注册子命令
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(baselineCmd)
 	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(pkgverifyCmd)
 }
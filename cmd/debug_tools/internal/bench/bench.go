@@ -0,0 +1,247 @@
+// Package bench 调试工具共用的检测性能基准
+// 对同一批文件先预热、再重复测量若干轮，统计吞吐、单文件延迟分位数与内存分配，
+// JSON 结果可保存下来与其他版本的结果对比
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options 基准参数
+type Options struct {
+	Warmup  int // 预热轮数，不计入统计
+	Rounds  int // 测量轮数
+	Workers int // 并发数，<= 0 时使用 CPU 核心数
+}
+
+// Latency 单文件检测延迟，单位毫秒
+type Latency struct {
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P95  float64 `json:"p95_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// Result 基准结果
+type Result struct {
+	Tool      string    `json:"tool"`
+	Version   string    `json:"version"`
+	GoVersion string    `json:"go_version"`
+	Platform  string    `json:"platform"`
+	CPUs      int       `json:"cpus"`
+	StartTime time.Time `json:"start_time"`
+
+	Workers int   `json:"workers"`
+	Warmup  int   `json:"warmup"`
+	Rounds  int   `json:"rounds"`
+	Files   int   `json:"files"` // 每轮文件数
+	Bytes   int64 `json:"bytes"` // 每轮字节数
+	Errors  int   `json:"errors"`
+
+	Duration    time.Duration `json:"duration_ns"` // 全部测量轮的耗时
+	FilesPerSec float64       `json:"files_per_sec"`
+	MBPerSec    float64       `json:"mb_per_sec"`
+	// RoundFilesPerSec 各轮吞吐，用于判断结果是否稳定
+	RoundFilesPerSec []float64 `json:"round_files_per_sec"`
+	Latency          Latency   `json:"latency"`
+
+	AllocsPerFile     float64 `json:"allocs_per_file"`
+	AllocBytesPerFile float64 `json:"alloc_bytes_per_file"`
+}
+
+// Run 对 files 执行 Warmup+Rounds 轮 fn，返回测量轮的统计
+// 无法获取大小的文件仍参与测量，只是不计入字节数
+func Run(tool, version string, files []string, opts Options, fn func(path string) error) *Result {
+	if opts.Rounds <= 0 {
+		opts.Rounds = 1
+	}
+	if opts.Warmup < 0 {
+		opts.Warmup = 0
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Workers > len(files) {
+		opts.Workers = len(files)
+	}
+
+	r := &Result{
+		Tool:      tool,
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		StartTime: time.Now(),
+		Workers:   opts.Workers,
+		Warmup:    opts.Warmup,
+		Rounds:    opts.Rounds,
+		Files:     len(files),
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			r.Bytes += info.Size()
+		}
+	}
+	if len(files) == 0 {
+		return r
+	}
+
+	for i := 0; i < opts.Warmup; i++ {
+		runRound(files, opts.Workers, fn, nil)
+	}
+
+	// 内存分配按测量轮前后的累计值计算，包含并发工作协程本身的少量分配
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	latencies := make([]time.Duration, 0, len(files)*opts.Rounds)
+	for i := 0; i < opts.Rounds; i++ {
+		start := time.Now()
+		lat, errs := runRound(files, opts.Workers, fn, latencies)
+		elapsed := time.Since(start)
+		latencies = lat
+		r.Errors += errs
+		r.Duration += elapsed
+		r.RoundFilesPerSec = append(r.RoundFilesPerSec, float64(len(files))/elapsed.Seconds())
+	}
+
+	runtime.ReadMemStats(&after)
+	calls := float64(len(files) * opts.Rounds)
+	r.AllocsPerFile = float64(after.Mallocs-before.Mallocs) / calls
+	r.AllocBytesPerFile = float64(after.TotalAlloc-before.TotalAlloc) / calls
+
+	if secs := r.Duration.Seconds(); secs > 0 {
+		r.FilesPerSec = calls / secs
+		r.MBPerSec = float64(r.Bytes) * float64(opts.Rounds) / 1024 / 1024 / secs
+	}
+	r.Latency = summarize(latencies)
+	return r
+}
+
+// runRound 并发检测一轮，latencies 非 nil 时追加每个文件的耗时
+func runRound(files []string, workers int, fn func(string) error, latencies []time.Duration) ([]time.Duration, int) {
+	tasks := make(chan string)
+	var (
+		mu     sync.Mutex
+		errors int
+		wg     sync.WaitGroup
+	)
+	record := latencies != nil
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range tasks {
+				start := time.Now()
+				err := fn(path)
+				d := time.Since(start)
+				mu.Lock()
+				if err != nil {
+					errors++
+				}
+				if record {
+					latencies = append(latencies, d)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, f := range files {
+		tasks <- f
+	}
+	close(tasks)
+	wg.Wait()
+	return latencies, errors
+}
+
+// summarize 计算延迟分位数 (最近秩法)
+func summarize(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	pct := func(p float64) float64 {
+		i := int(float64(len(latencies))*p+0.999999) - 1
+		if i < 0 {
+			i = 0
+		}
+		return ms(latencies[i])
+	}
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	return Latency{
+		Mean: ms(total / time.Duration(len(latencies))),
+		P50:  pct(0.50),
+		P95:  pct(0.95),
+		P99:  pct(0.99),
+		Max:  ms(latencies[len(latencies)-1]),
+	}
+}
+
+// WriteJSON 输出 JSON 结果
+func (r *Result) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteText 输出文本结果
+func (r *Result) WriteText(w io.Writer) {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("=", 60) + "\n")
+	sb.WriteString(fmt.Sprintf("性能基准: %s %s (%s, %s, %d 核)\n", r.Tool, r.Version, r.GoVersion, r.Platform, r.CPUs))
+	sb.WriteString(strings.Repeat("=", 60) + "\n")
+	sb.WriteString(fmt.Sprintf("文件: %d 个，%.2f MB/轮\n", r.Files, float64(r.Bytes)/1024/1024))
+	sb.WriteString(fmt.Sprintf("并发: %d，预热 %d 轮，测量 %d 轮，耗时 %v\n",
+		r.Workers, r.Warmup, r.Rounds, r.Duration.Round(time.Millisecond)))
+	if r.Errors > 0 {
+		sb.WriteString(fmt.Sprintf("检测失败: %d 次 (仍计入耗时)\n", r.Errors))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("吞吐:     %.1f 文件/秒, %.2f MB/秒\n", r.FilesPerSec, r.MBPerSec))
+	if len(r.RoundFilesPerSec) > 1 {
+		rounds := make([]string, len(r.RoundFilesPerSec))
+		for i, v := range r.RoundFilesPerSec {
+			rounds[i] = fmt.Sprintf("%.1f", v)
+		}
+		sb.WriteString(fmt.Sprintf("各轮:     %s 文件/秒\n", strings.Join(rounds, " / ")))
+	}
+	sb.WriteString(fmt.Sprintf("延迟:     平均 %.2f ms, p50 %.2f ms, p95 %.2f ms, p99 %.2f ms, 最大 %.2f ms\n",
+		r.Latency.Mean, r.Latency.P50, r.Latency.P95, r.Latency.P99, r.Latency.Max))
+	sb.WriteString(fmt.Sprintf("内存分配: %.0f 次/文件, %.1f KB/文件\n", r.AllocsPerFile, r.AllocBytesPerFile/1024))
+	io.WriteString(w, sb.String())
+}
+
+// Write 按格式输出结果，path 非空时写入文件
+func (r *Result) Write(format, path string) error {
+	w := io.Writer(os.Stdout)
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if format == "json" {
+		return r.WriteJSON(w)
+	}
+	r.WriteText(w)
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package checkpoint 调试工具共用的断点续扫
+// 每扫描完一个文件，向检查点文件追加一行 JSON 记录 (路径、大小、修改时间与检测结果)。
+// 扫描中断后以同一个检查点文件重新运行，大小与修改时间未变的文件沿用记录的结果，只扫描剩余文件。
+// 检查点按工具的检测结果类型实例化 (File[ScanResult])，检测失败的结果不记录，续扫时重试
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Result 可记录到检查点的检测结果，由各工具的 ScanResult 实现
+type Result interface {
+	// ResultPath 结果对应的文件路径
+	ResultPath() string
+	// Failed 检测是否失败
+	Failed() bool
+}
+
+// maxLine 单条记录的最大长度
+const maxLine = 1024 * 1024
+
+// header 检查点文件首行，防止不同工具混用同一个检查点
+type header struct {
+	Tool string `json:"tool"`
+}
+
+// entry 一个已扫描文件的记录
+type entry struct {
+	Path    string          `json:"path"`
+	Size    int64           `json:"size"`
+	ModTime int64           `json:"mtime_ns"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// File 打开的检查点文件，R 为工具的检测结果类型；Record 可并发调用
+// 方法均可在 nil 上调用 (未指定检查点时不续扫也不记录)
+type File[R Result] struct {
+	path string
+	done map[string]entry
+
+	mu      sync.Mutex
+	f       *os.File
+	stopped bool
+}
+
+// Open 打开检查点文件并读取已有记录，文件不存在时新建
+// 末尾不完整的记录 (写入时被中断) 会被忽略，对应文件重新扫描
+func Open[R Result](path, tool string) (*File[R], error) {
+	c := &File[R]{path: path, done: make(map[string]entry)}
+
+	rf, err := os.Open(path)
+	var truncated bool
+	switch {
+	case err == nil:
+		truncated, err = c.load(rf, tool)
+		rf.Close()
+		if err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, err
+	}
+
+	if c.f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+	var prefix []byte
+	if info, err := c.f.Stat(); err == nil && info.Size() == 0 {
+		prefix, _ = json.Marshal(header{Tool: tool})
+	}
+	if truncated || prefix != nil {
+		// 补齐被中断记录的换行，后续记录从新行开始
+		if _, err := c.f.Write(append(prefix, '\n')); err != nil {
+			c.f.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// load 读取已有记录，truncated 表示文件末尾缺少换行 (最后一条记录写入时被中断)
+func (c *File[R]) load(f *os.File, tool string) (truncated bool, err error) {
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil {
+			truncated = last[0] != '\n'
+		}
+	}
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLine)
+	if !sc.Scan() {
+		return truncated, sc.Err()
+	}
+	var h header
+	if err := json.Unmarshal(sc.Bytes(), &h); err != nil || h.Tool == "" {
+		return false, fmt.Errorf("%s 不是检查点文件", c.path)
+	}
+	if h.Tool != tool {
+		return false, fmt.Errorf("检查点文件 %s 属于 %s，不能用于 %s", c.path, h.Tool, tool)
+	}
+	for sc.Scan() {
+		var e entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil || e.Path == "" {
+			continue
+		}
+		// 同一文件有多条记录时以最后一条为准
+		c.done[e.Path] = e
+	}
+	return truncated, sc.Err()
+}
+
+// Len 已有记录的文件数
+func (c *File[R]) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.done)
+}
+
+// Lookup 返回 path 已记录的检测结果，文件不存在、大小或修改时间变化时返回 false
+func (c *File[R]) Lookup(path string) (R, bool) {
+	var r R
+	if c == nil {
+		return r, false
+	}
+	e, ok := c.done[path]
+	if !ok {
+		return r, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != e.Size || info.ModTime().UnixNano() != e.ModTime {
+		return r, false
+	}
+	if json.Unmarshal(e.Result, &r) != nil {
+		return r, false
+	}
+	return r, true
+}
+
+// Resume 对已记录且未变化的文件调用 restore (计入统计与输出)，返回仍需扫描的文件
+func (c *File[R]) Resume(files []string, restore func(R)) []string {
+	if c == nil {
+		return files
+	}
+	pending := make([]string, 0, len(files))
+	for _, f := range files {
+		r, ok := c.Lookup(f)
+		if !ok {
+			pending = append(pending, f)
+			continue
+		}
+		restore(r)
+	}
+	return pending
+}
+
+// Record 记录扫描完成的文件，检测失败的结果不记录；写入失败时提示一次并停止记录
+func (c *File[R]) Record(r R) {
+	if c == nil || r.Failed() {
+		return
+	}
+	if err := c.write(r.ResultPath(), r); err != nil {
+		c.mu.Lock()
+		stopped := c.stopped
+		c.stopped = true
+		c.mu.Unlock()
+		if !stopped {
+			fmt.Fprintf(os.Stderr, "\n警告: 写入检查点失败，停止记录: %v\n", err)
+		}
+	}
+}
+
+// write 追加 path 的检测结果，每条记录单独写入，进程被终止时最多丢失正在写入的一条
+func (c *File[R]) write(path string, result R) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry{Path: path, Size: info.Size(), ModTime: info.ModTime().UnixNano(), Result: raw})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		return nil
+	}
+	_, err = c.f.Write(append(line, '\n'))
+	return err
+}
+
+// Close 关闭检查点文件，保留已有记录
+func (c *File[R]) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.f.Close()
+}
+
+// Finish 全部文件扫描完成并输出结果后关闭并删除检查点文件
+func (c *File[R]) Finish() {
+	if c == nil {
+		return
+	}
+	c.f.Close()
+	if err := os.Remove(c.path); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 删除检查点文件失败: %v\n", err)
+	}
+}
+
+// OpenFlag 打开 --checkpoint 指定的检查点文件，path 为空时返回 nil；已有记录且 quiet 为 false 时提示
+func OpenFlag[R Result](path, tool string, quiet bool) (*File[R], error) {
+	if path == "" {
+		return nil, nil
+	}
+	c, err := Open[R](path, tool)
+	if err != nil {
+		return nil, fmt.Errorf("打开检查点文件失败: %v", err)
+	}
+	if !quiet && c.Len() > 0 {
+		fmt.Printf("检查点 %s 已记录 %d 个文件，跳过其中未变化的文件\n", path, c.Len())
+	}
+	return c, nil
+}
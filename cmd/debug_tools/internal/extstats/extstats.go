@@ -0,0 +1,193 @@
+// Package extstats 调试工具共用的按扩展名/文件分类统计
+// 汇总每种扩展名与分类的扫描数、命中数、错误数、字节数和累计检测耗时，
+// 用于判断哪些格式占用了主要扫描时间、哪些格式产生命中
+package extstats
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/filetype"
+)
+
+// NoExt 没有扩展名的文件
+const NoExt = "(无扩展名)"
+
+// Stat 一种扩展名或分类的统计
+type Stat struct {
+	Name     string        `json:"name"`
+	Category string        `json:"category,omitempty"` // 仅按扩展名统计时填写
+	Files    int64         `json:"files"`
+	Detected int64         `json:"detected"`
+	Errors   int64         `json:"errors"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"duration_ns"` // 累计检测耗时 (各文件耗时之和)
+}
+
+// Breakdown 统计结果，均按累计耗时降序排列
+type Breakdown struct {
+	ByExtension []Stat `json:"by_extension"`
+	ByCategory  []Stat `json:"by_category"`
+}
+
+// Collector 统计收集器，不做并发保护
+type Collector struct {
+	ext map[string]*Stat
+	cat map[string]*Stat
+}
+
+// New 创建收集器
+func New() *Collector {
+	return &Collector{ext: make(map[string]*Stat), cat: make(map[string]*Stat)}
+}
+
+// Ext 统计使用的扩展名: 小写、不含点，没有扩展名时为 NoExt
+func Ext(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "" {
+		return NoExt
+	}
+	return ext
+}
+
+// Add 记录一个文件的检测结果，分类按扩展名确定
+func (c *Collector) Add(path string, size int64, d time.Duration, detected, failed bool) {
+	ext := Ext(path)
+	category := string(filetype.CategoryOther)
+	if ext != NoExt {
+		category = string(filetype.GetFileTypeByExtension(ext).Category)
+	}
+
+	es := c.ext[ext]
+	if es == nil {
+		es = &Stat{Name: ext, Category: category}
+		c.ext[ext] = es
+	}
+	cs := c.cat[category]
+	if cs == nil {
+		cs = &Stat{Name: category}
+		c.cat[category] = cs
+	}
+	for _, s := range []*Stat{es, cs} {
+		s.Files++
+		s.Bytes += size
+		s.Duration += d
+		switch {
+		case failed:
+			s.Errors++
+		case detected:
+			s.Detected++
+		}
+	}
+}
+
+// Breakdown 返回统计结果，没有记录时返回 nil
+func (c *Collector) Breakdown() *Breakdown {
+	if len(c.ext) == 0 {
+		return nil
+	}
+	return &Breakdown{ByExtension: sorted(c.ext), ByCategory: sorted(c.cat)}
+}
+
+// sorted 按累计耗时降序，其次按文件数降序、名称升序
+func sorted(m map[string]*Stat) []Stat {
+	list := make([]Stat, 0, len(m))
+	for _, s := range m {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		if a.Files != b.Files {
+			return a.Files > b.Files
+		}
+		return a.Name < b.Name
+	})
+	return list
+}
+
+// Format 文本表格，每行以 indent 开头；按扩展名只列出前 limit 项 (<=0 表示全部)，其余合并为一行
+func (b *Breakdown) Format(indent string, limit int) string {
+	if b == nil {
+		return ""
+	}
+	var total time.Duration
+	for _, s := range b.ByCategory {
+		total += s.Duration
+	}
+
+	var sb strings.Builder
+	writeTable := func(title string, list []Stat) {
+		sb.WriteString(fmt.Sprintf("%s%s (按累计耗时排序):\n", indent, title))
+		// 表头为中文 (每字占两列)，按显示宽度手工对齐
+		sb.WriteString(indent + "  类型              文件   命中   错误       大小       耗时   占比\n")
+		for _, s := range list {
+			sb.WriteString(formatRow(indent, s, total))
+		}
+	}
+
+	writeTable("按分类统计", b.ByCategory)
+	sb.WriteString("\n")
+
+	list := b.ByExtension
+	var rest *Stat
+	if limit > 0 && len(list) > limit {
+		rest = &Stat{Name: fmt.Sprintf("其余 %d 种", len(list)-limit)}
+		for _, s := range list[limit:] {
+			rest.Files += s.Files
+			rest.Detected += s.Detected
+			rest.Errors += s.Errors
+			rest.Bytes += s.Bytes
+			rest.Duration += s.Duration
+		}
+		list = list[:limit]
+	}
+	writeTable("按扩展名统计", list)
+	if rest != nil {
+		sb.WriteString(formatRow(indent, *rest, total))
+	}
+	return sb.String()
+}
+
+func formatRow(indent string, s Stat, total time.Duration) string {
+	share := 0.0
+	if total > 0 {
+		share = float64(s.Duration) * 100 / float64(total)
+	}
+	return fmt.Sprintf("%s  %s %8d %6d %6d %10s %10s %5.1f%%\n",
+		indent, padRight(s.Name, 12), s.Files, s.Detected, s.Errors, formatBytes(s.Bytes), s.Duration.Round(time.Millisecond), share)
+}
+
+// padRight 按显示宽度 (中文等非 ASCII 字符占两列) 右侧补空格
+func padRight(s string, width int) string {
+	w := 0
+	for _, r := range s {
+		if r < 0x80 {
+			w++
+		} else {
+			w += 2
+		}
+	}
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.2f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.2f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.2f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
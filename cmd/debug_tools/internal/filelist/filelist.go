@@ -0,0 +1,89 @@
+// Package filelist 调试工具共用的文件列表输入
+// 从文件或标准输入读取待扫描路径 (--files-from)，每行一个；-0 时以 NUL 分隔，
+// 配合 find -print0 / fd -0 处理包含换行的文件名
+package filelist
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// Stdin --files-from 取此值时从标准输入读取
+const Stdin = "-"
+
+// maxLine 单个路径的最大长度
+const maxLine = 64 * 1024
+
+// Read 从 r 读取路径列表，nul 为 true 时以 NUL 分隔，否则按行分隔 (兼容 \r\n)
+// 空项忽略，重复路径只保留第一次出现
+func Read(r io.Reader, nul bool) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 4096), maxLine)
+	if nul {
+		sc.Split(splitNUL)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for sc.Scan() {
+		p := sc.Text()
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	return paths, sc.Err()
+}
+
+// Load 读取 name 指定的列表文件，name 为 "-" 时读取标准输入
+func Load(name string, nul bool) ([]string, error) {
+	if name == Stdin {
+		return Read(os.Stdin, nul)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, nul)
+}
+
+// Expand 依次展开列表中的路径: 每一项交给 collect 按工具原有规则处理 (目录遍历、文件过滤)，
+// 结果按首次出现去重。不存在或无法展开的路径不中断处理，交给 onError 报告
+// (列表通常由 find 生成，扫描前文件可能已被删除)
+func Expand(paths []string, collect func(path string) ([]string, error), onError func(path string, err error)) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if _, err := os.Stat(p); err != nil {
+			onError(p, err)
+			continue
+		}
+		found, err := collect(p)
+		if err != nil {
+			onError(p, err)
+			continue
+		}
+		for _, f := range found {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// splitNUL bufio.SplitFunc，以 NUL 分隔，末尾没有 NUL 的最后一项同样返回
+func splitNUL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
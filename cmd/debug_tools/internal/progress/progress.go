@@ -0,0 +1,74 @@
+// Package progress 调试工具共用的扫描进度行
+// 输出完成数、百分比、文件与字节速率、已用时间和预计剩余时间；
+// 从检查点恢复的文件计入完成数，但不计入速率与剩余时间的估算
+package progress
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultInterval 进度行的默认刷新间隔
+const DefaultInterval = 500 * time.Millisecond
+
+// Meter 扫描进度，只在收集结果的协程中使用，不做并发保护
+type Meter struct {
+	Interval time.Duration // 刷新间隔，Due 据此限制输出频率
+
+	total   int // 文件总数，包含已恢复的文件
+	resumed int // 从检查点恢复的文件数
+	done    int // 本次已完成的文件数
+	bytes   int64
+	start   time.Time
+	last    time.Time
+}
+
+// New 创建进度，total 为文件总数，resumed 为其中已从检查点恢复、本次无需扫描的文件数
+func New(total, resumed int) *Meter {
+	return &Meter{
+		Interval: DefaultInterval,
+		total:    total,
+		resumed:  resumed,
+		start:    time.Now(),
+	}
+}
+
+// Add 记录完成一个文件，size 为文件字节数
+func (m *Meter) Add(size int64) {
+	m.done++
+	m.bytes += size
+}
+
+// Due 距上次输出超过刷新间隔或全部完成时返回 true，并将本次视为已输出
+func (m *Meter) Due() bool {
+	now := time.Now()
+	if m.resumed+m.done < m.total && now.Sub(m.last) < m.Interval {
+		return false
+	}
+	m.last = now
+	return true
+}
+
+// Line 进度行，如 "1200/50000 (2.4%) | 85.3 文件/秒, 12.40 MB/秒 | 已用 14s, 剩余约 9m32s"
+func (m *Meter) Line() string {
+	finished := m.resumed + m.done
+	pct := 100.0
+	if m.total > 0 {
+		pct = float64(finished) * 100 / float64(m.total)
+	}
+	elapsed := time.Since(m.start)
+	line := fmt.Sprintf("%d/%d (%.1f%%)", finished, m.total, pct)
+
+	secs := elapsed.Seconds()
+	if m.done == 0 || secs <= 0 {
+		return line + fmt.Sprintf(" | 已用 %v", elapsed.Round(time.Second))
+	}
+	rate := float64(m.done) / secs
+	line += fmt.Sprintf(" | %.1f 文件/秒, %.2f MB/秒 | 已用 %v",
+		rate, float64(m.bytes)/1024/1024/secs, elapsed.Round(time.Second))
+	if remaining := m.total - finished; remaining > 0 {
+		eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+		line += fmt.Sprintf(", 剩余约 %v", eta.Round(time.Second))
+	}
+	return line
+}
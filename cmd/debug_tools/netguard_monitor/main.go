@@ -1,8 +1,11 @@
+//go:build linux
+
 // Package main 提供网络连接监控模块的独立调试工具
 // 用于单独测试和排查 internal/security/netguard 子模块的逻辑问题
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
@@ -16,8 +19,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"linuxFileWatcher/internal/security/netguard"
+	"linuxFileWatcher/internal/security/netguard/bandwidth"
+	"linuxFileWatcher/internal/security/netguard/conntrack"
 	"linuxFileWatcher/internal/security/netguard/detector"
 	"linuxFileWatcher/internal/security/netguard/event"
+	"linuxFileWatcher/internal/security/netguard/geoip"
+	"linuxFileWatcher/internal/security/netguard/listener"
+	"linuxFileWatcher/internal/security/netguard/seen"
+	"linuxFileWatcher/internal/service/detectapi"
 )
 
 // ==========================================
@@ -36,6 +45,33 @@ var (
 	quietMode    bool
 	dryRunMode   bool
 	whitelistIPs []string
+	dedupTTL     time.Duration
+
+	// listen 命令参数
+	listenHost       bool
+	listenProcesses  []string
+	listenAllowPorts []string
+	listenAllowProcs []string
+
+	// bandwidth 命令参数
+	bwInterval    time.Duration
+	bwWindow      time.Duration
+	bwThresholdMB int64
+	bwConntrack   bool
+
+	// whitelist 命令参数
+	wlSocket string
+	wlDesc   string
+	wlDaemon bool
+
+	// conntrack 命令参数
+	ctEvents bool
+	ctKill   bool
+
+	// GeoIP 参数
+	geoDatabases []string
+	geoAllow     []string
+	geoAlert     []string
 
 	// 颜色输出
 	colorRed     = color.New(color.FgRed, color.Bold)
@@ -91,8 +127,30 @@ var rootCmd = &cobra.Command{
 
   # 添加白名单并监控
   netguard-monitor watch --whitelist 192.168.1.0/24,10.0.0.1
+
+  # 结合 GeoIP 库，对境外连接告警
+  netguard-monitor watch --geoip-db GeoLite2-City.mmdb --geoip-db GeoLite2-ASN.mmdb --geo-alert '!CN'
+
+  # 查看整机监听端口及白名单匹配情况
+  netguard-monitor listen --host --allow-port 22,tcp/443
+
+  # 统计外发流量，1 分钟内向同一对端发送超过 10MB 时告警
+  netguard-monitor bandwidth --window 1m --threshold-mb 10
+
+  # 查看与指定 IP 相关的连接跟踪记录并断开
+  netguard-monitor conntrack 203.0.113.9 --kill
+
+  # 录制连接快照，离线回放复现告警
+  netguard-monitor record --pid 1234 --out trace.json --duration 10m
+  netguard-monitor replay trace.json --whitelist 203.0.113.0/24
+
+  # 每个告警、扫描状态输出一行 JSON，交给 jq 处理
+  netguard-monitor watch --dry-run --output json | jq 'select(.event == "alert")'
 `,
 	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutput()
+	},
 }
 
 // ==========================================
@@ -115,15 +173,29 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// 确定目标 PID
 	pids := resolveTargetPIDs()
 
-	colorCyan.Printf("🔍 扫描目标 PID: %v\n", pids)
-	printSeparator()
-
 	// 创建扫描器
 	scanner := detector.NewScanner(pids)
+	startTime := time.Now()
+
+	if jsonMode() {
+		connections, err := scanner.Scan()
+		if err != nil {
+			return fmt.Errorf("扫描失败: %v", err)
+		}
+		for _, conn := range connections {
+			emit(newConnectionEvent(conn))
+		}
+		summary := newSummaryEvent(connections)
+		summary.DurationMS = time.Since(startTime).Milliseconds()
+		emit(summary)
+		return nil
+	}
+
+	colorCyan.Printf("🔍 扫描目标 PID: %v\n", pids)
+	printSeparator()
 
 	// 执行扫描
 	colorYellow.Println("🔄 正在扫描网络连接...")
-	startTime := time.Now()
 
 	connections, err := scanner.Scan()
 	if err != nil {
@@ -178,36 +250,49 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	// 确定目标 PID
 	pids := resolveTargetPIDs()
 
-	colorCyan.Printf("🔍 监控目标 PID: %v\n", pids)
-	colorCyan.Printf("⏱️  扫描间隔: %v\n", scanInterval)
-
-	if dryRunMode {
-		colorYellow.Println("🔒 运行模式: 仅检测 (dry-run)")
-	} else {
-		colorRed.Println("🔒 运行模式: 检测并封禁 (需要 root 权限)")
-	}
-
-	if quietMode {
-		colorCyan.Println("🔇 输出模式: 静默模式（仅显示异常）")
-	} else if verboseMode {
-		colorCyan.Println("📢 输出模式: 详细模式")
-	} else {
-		colorCyan.Println("📢 输出模式: 标准模式")
-	}
-
-	printSeparator()
-
 	// 初始化白名单
 	initialWhitelist := []string{"127.0.0.1", "::1"}
 	if len(whitelistIPs) > 0 {
 		initialWhitelist = append(initialWhitelist, whitelistIPs...)
 	}
 
-	colorCyan.Println("📋 白名单规则:")
-	for _, ip := range initialWhitelist {
-		fmt.Printf("   • %s\n", ip)
+	if jsonMode() {
+		emit(startEvent{
+			eventHeader: header("start"),
+			Command:     "watch",
+			PIDs:        pids,
+			Interval:    scanInterval.String(),
+			DedupTTL:    dedupTTL.String(),
+			DryRun:      dryRunMode,
+			Whitelist:   initialWhitelist,
+		})
+	} else {
+		colorCyan.Printf("🔍 监控目标 PID: %v\n", pids)
+		colorCyan.Printf("⏱️  扫描间隔: %v\n", scanInterval)
+		colorCyan.Printf("🔁 告警去重: %v 内同一 IP 只告警一次\n", dedupTTL)
+
+		if dryRunMode {
+			colorYellow.Println("🔒 运行模式: 仅检测 (dry-run)")
+		} else {
+			colorRed.Println("🔒 运行模式: 检测并封禁 (需要 root 权限)")
+		}
+
+		if quietMode {
+			colorCyan.Println("🔇 输出模式: 静默模式（仅显示异常）")
+		} else if verboseMode {
+			colorCyan.Println("📢 输出模式: 详细模式")
+		} else {
+			colorCyan.Println("📢 输出模式: 标准模式")
+		}
+
+		printSeparator()
+
+		colorCyan.Println("📋 白名单规则:")
+		for _, ip := range initialWhitelist {
+			fmt.Printf("   • %s\n", ip)
+		}
+		printSeparator()
 	}
-	printSeparator()
 
 	// 创建白名单管理器
 	whitelistMgr := netguard.NewWhitelistManager(initialWhitelist)
@@ -215,11 +300,31 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	// 创建扫描器
 	scanner := detector.NewScanner(pids)
 
-	// 创建 Reporter
-	reporter := &DebugReporter{dryRun: dryRunMode}
+	// 加载 GeoIP 库
+	geo, err := loadGeo()
+	if err != nil {
+		if !jsonMode() {
+			colorRed.Printf("❌ %v\n", err)
+		}
+		return err
+	}
 
-	colorMagenta.Println("👀 开始持续监控... (按 Ctrl+C 停止)")
-	fmt.Println()
+	// 创建 Reporter，封禁模式下通过连接跟踪断开已建立的连接
+	reporter := &DebugReporter{dryRun: dryRunMode, geo: geo}
+	if !dryRunMode {
+		ct, err := conntrack.Dial()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  连接跟踪不可用，已建立的连接不会断开: %v\n", err)
+		} else {
+			defer ct.Close()
+			reporter.ct = ct
+		}
+	}
+
+	if !jsonMode() {
+		colorMagenta.Println("👀 开始持续监控... (按 Ctrl+C 停止)")
+		fmt.Println()
+	}
 
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
@@ -232,12 +337,23 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	scanCount := 0
 	alertCount := 0
 	totalConnections := 0
-	blockedIPs := make(map[string]bool) // 去重缓存
+	blockedIPs := seen.New(seen.Config{TTL: dedupTTL}) // 去重缓存，过期后再次告警
 	startTime := time.Now()
 
 	for {
 		select {
 		case <-sigChan:
+			if jsonMode() {
+				emit(stopEvent{
+					eventHeader:   header("stop"),
+					UptimeSeconds: int64(time.Since(startTime).Seconds()),
+					Scans:         scanCount,
+					Connections:   totalConnections,
+					Alerts:        alertCount,
+					BlockedIPs:    blockedIPs.Len(),
+				})
+				return nil
+			}
 			fmt.Println()
 			printSeparator()
 			colorYellow.Println("🛑 收到停止信号，正在退出...")
@@ -245,13 +361,13 @@ func runWatch(cmd *cobra.Command, args []string) error {
 			colorWhite.Printf("   扫描次数     : %d\n", scanCount)
 			colorWhite.Printf("   检测连接总数 : %d\n", totalConnections)
 			colorWhite.Printf("   告警次数     : %d\n", alertCount)
-			colorWhite.Printf("   封禁 IP 数   : %d\n", len(blockedIPs))
+			colorWhite.Printf("   封禁 IP 数   : %d\n", blockedIPs.Len())
 			colorGreen.Println("👋 监控已停止")
 			return nil
 
 		case <-ticker.C:
 			scanCount++
-			alerts, connCount := performNetworkScan(scanner, whitelistMgr, reporter, scanCount, blockedIPs)
+			alerts, connCount := performNetworkScan(scanner, whitelistMgr, geo, reporter, scanCount, blockedIPs)
 			alertCount += alerts
 			totalConnections += connCount
 		}
@@ -260,58 +376,66 @@ func runWatch(cmd *cobra.Command, args []string) error {
 
 // performNetworkScan 执行一次网络扫描
 // 返回值: (告警数, 连接数)
-func performNetworkScan(scanner *detector.NetworkScanner, whitelist *netguard.WhitelistManager,
-	reporter *DebugReporter, count int, blockedIPs map[string]bool) (int, int) {
+func performNetworkScan(scanner *detector.NetworkScanner, whitelist *netguard.WhitelistManager, geo *geoFilter,
+	reporter *DebugReporter, count int, blockedIPs *seen.Cache) (int, int) {
 
-	timestamp := time.Now().Format("15:04:05")
+	now := time.Now()
 
 	// 1. 扫描连接
 	connections, err := scanner.Scan()
 	if err != nil {
-		if !quietMode {
-			colorRed.Printf("[%s] ❌ 扫描失败: %v\n", timestamp, err)
-		}
+		printScanError(now, count, err.Error())
 		return 0, 0
 	}
 
-	connCount := len(connections)
+	// 2. 检查每个连接
+	violationCount, alertCount := checkConnections(newTraceConns(connections), whitelist, geo, reporter, blockedIPs, now)
+
+	// 3. 输出状态
+	printScanStatus(now, count, len(connections), violationCount, alertCount)
+
+	return alertCount, len(connections)
+}
+
+// checkConnections 按白名单与 GeoIP 规则检查一组连接，有效期内未告警过的违规 IP 上报告警
+// watch 与 replay 共用，now 为扫描时间 (replay 时为记录时间)
+// 返回值: (违规数, 告警数)
+func checkConnections(connections []traceConn, whitelist *netguard.WhitelistManager, geo *geoFilter,
+	reporter *DebugReporter, blockedIPs *seen.Cache, now time.Time) (int, int) {
+
 	alertCount := 0
 	violationCount := 0
 
-	// 2. 检查每个连接
 	for _, conn := range connections {
 		// 跳过空 IP（可能是 LISTEN 状态的残留）
 		if conn.RemoteIP == "" || conn.RemoteIP == "0.0.0.0" || conn.RemoteIP == "::" {
 			continue
 		}
 
-		// 检查白名单
-		if !whitelist.IsAllowed(conn.RemoteIP) {
+		// 检查白名单: IP 白名单优先，其次为 GeoIP 规则
+		if !whitelist.IsAllowed(conn.RemoteIP) && !geo.allowed(conn.RemoteIP) {
 			violationCount++
 
-			// 去重检查
-			if blockedIPs[conn.RemoteIP] {
+			// 去重检查: 有效期内已告警的 IP 不重复上报
+			if !blockedIPs.Alert(conn.RemoteIP, now) {
 				continue
 			}
-
-			// 记录并上报
-			blockedIPs[conn.RemoteIP] = true
 			alertCount++
 
 			// 构建告警
 			alert := event.NetworkAlert{
-				Timestamp:   time.Now(),
-				AlertTime:   time.Now().Unix(),
-				Direction:   determineDirection(conn),
+				Timestamp:   now,
+				AlertTime:   now.Unix(),
+				Direction:   determineDirection(conn.LocalPort),
 				RemoteIP:    conn.RemoteIP,
-				RemotePort:  uint16(conn.RemotePort),
-				LocalPort:   uint16(conn.LocalPort),
+				RemotePort:  conn.RemotePort,
+				LocalPort:   conn.LocalPort,
 				Protocol:    conn.Protocol,
 				PID:         conn.PID,
 				ActionTaken: "DETECTED",
 			}
 
-			if !dryRunMode {
+			if !reporter.dryRun {
 				alert.ActionTaken = "BLOCKED"
 			}
 
@@ -319,8 +443,34 @@ func performNetworkScan(scanner *detector.NetworkScanner, whitelist *netguard.Wh
 		}
 	}
 
-	// 3. 输出状态
-	if !quietMode {
+	return violationCount, alertCount
+}
+
+// printScanError 输出扫描失败
+func printScanError(now time.Time, count int, msg string) {
+	if jsonMode() {
+		emit(scanEvent{eventHeader: eventHeader{Event: "scan", Time: now}, Scan: count, Error: msg})
+	} else if !quietMode {
+		colorRed.Printf("[%s] ❌ 扫描失败: %v\n", now.Format("15:04:05"), msg)
+	}
+}
+
+// printScanStatus 输出一次扫描的结果
+func printScanStatus(now time.Time, count, connCount, violationCount, alertCount int) {
+	timestamp := now.Format("15:04:05")
+
+	if jsonMode() {
+		// 静默模式只输出有违规的扫描
+		if !quietMode || violationCount > 0 {
+			emit(scanEvent{
+				eventHeader: eventHeader{Event: "scan", Time: now},
+				Scan:        count,
+				Connections: connCount,
+				Violations:  violationCount,
+				NewAlerts:   alertCount,
+			})
+		}
+	} else if !quietMode {
 		if violationCount > 0 {
 			colorYellow.Printf("[%s] 扫描 #%d | 连接数: %d | 违规: %d | 新告警: %d\n",
 				timestamp, count, connCount, violationCount, alertCount)
@@ -332,14 +482,12 @@ func performNetworkScan(scanner *detector.NetworkScanner, whitelist *netguard.Wh
 				timestamp, count, connCount)
 		}
 	}
-
-	return alertCount, connCount
 }
 
 // determineDirection 判断连接方向
-func determineDirection(conn detector.ConnectionInfo) event.TrafficDirection {
+func determineDirection(localPort uint16) event.TrafficDirection {
 	// 简单判断：如果本地端口小于 1024，通常是服务端（被动接收）
-	if conn.LocalPort < 1024 {
+	if localPort < 1024 {
 		return event.DirectionInbound
 	}
 	return event.DirectionOutbound
@@ -353,11 +501,19 @@ var whitelistCmd = &cobra.Command{
 	Use:   "whitelist",
 	Short: "白名单管理和测试",
 	Long: `查看默认白名单规则，或测试 IP 是否匹配白名单。
+add / remove 通过本机接口 (--socket) 修改运行中守护进程的白名单，立即生效且重启后保留。
 
 示例:
   # 查看默认白名单
   netguard-monitor whitelist list
 
+  # 查看守护进程当前的白名单 (配置、策略与运行时添加的规则)
+  netguard-monitor whitelist list --daemon
+
+  # 向守护进程添加、删除白名单规则
+  netguard-monitor whitelist add 203.0.113.0/24 --desc 备份服务器
+  netguard-monitor whitelist remove 203.0.113.0/24
+
   # 测试 IP 是否在白名单中
   netguard-monitor whitelist test 192.168.1.100
 
@@ -371,6 +527,20 @@ var whitelistListCmd = &cobra.Command{
 	RunE:  runWhitelistList,
 }
 
+var whitelistAddCmd = &cobra.Command{
+	Use:   "add [IP|CIDR]",
+	Short: "向守护进程添加白名单规则",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWhitelistAdd,
+}
+
+var whitelistRemoveCmd = &cobra.Command{
+	Use:   "remove [IP|CIDR]",
+	Short: "删除守护进程中运行时添加的白名单规则",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runWhitelistRemove,
+}
+
 var whitelistTestCmd = &cobra.Command{
 	Use:   "test [IP]",
 	Short: "测试 IP 是否匹配白名单",
@@ -381,6 +551,18 @@ var whitelistTestCmd = &cobra.Command{
 func runWhitelistList(cmd *cobra.Command, args []string) error {
 	printBanner()
 
+	if wlDaemon {
+		rules, err := detectapi.NewClient(wlSocket).ListWhitelist(context.Background())
+		if err != nil {
+			colorRed.Printf("❌ 读取守护进程白名单失败: %v\n", err)
+			return err
+		}
+		colorCyan.Printf("📋 守护进程白名单 (%s):\n", wlSocket)
+		printSeparator()
+		printWhitelistRules(rules)
+		return nil
+	}
+
 	colorCyan.Println("📋 默认白名单规则:")
 	printSeparator()
 
@@ -417,6 +599,44 @@ func runWhitelistList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runWhitelistAdd(cmd *cobra.Command, args []string) error {
+	r, err := detectapi.NewClient(wlSocket).AddWhitelist(context.Background(), args[0], wlDesc)
+	if err != nil {
+		colorRed.Printf("❌ 添加白名单失败: %v\n", err)
+		return err
+	}
+	colorGreen.Printf("✅ 已添加白名单规则: %s\n", r.Value)
+	return nil
+}
+
+func runWhitelistRemove(cmd *cobra.Command, args []string) error {
+	rules, err := detectapi.NewClient(wlSocket).RemoveWhitelist(context.Background(), args[0])
+	if err != nil {
+		colorRed.Printf("❌ 删除白名单失败: %v\n", err)
+		return err
+	}
+	colorGreen.Printf("✅ 已删除白名单规则: %s\n", args[0])
+	printSeparator()
+	printWhitelistRules(rules)
+	return nil
+}
+
+// printWhitelistRules 输出守护进程的白名单规则
+func printWhitelistRules(rules []detectapi.WhitelistRule) {
+	fmt.Println()
+	fmt.Printf("  %-25s %-8s %-20s %s\n", "规则", "来源", "添加时间", "说明")
+	fmt.Println("  " + strings.Repeat("-", 70))
+	for _, r := range rules {
+		created := "-"
+		if r.CreatedAt > 0 {
+			created = time.Unix(r.CreatedAt, 0).Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("  %-25s %-8s %-20s %s\n", r.Value, r.Source, created, r.Desc)
+	}
+	fmt.Println()
+	colorCyan.Printf("📊 共 %d 条规则\n", len(rules))
+}
+
 func runWhitelistTest(cmd *cobra.Command, args []string) error {
 	printBanner()
 
@@ -474,8 +694,10 @@ func runConnections(cmd *cobra.Command, args []string) error {
 
 	pids := resolveTargetPIDs()
 
-	colorCyan.Printf("🔍 目标 PID: %v\n", pids)
-	printSeparator()
+	if !jsonMode() {
+		colorCyan.Printf("🔍 目标 PID: %v\n", pids)
+		printSeparator()
+	}
 
 	scanner := detector.NewScanner(pids)
 	connections, err := scanner.Scan()
@@ -483,11 +705,6 @@ func runConnections(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("扫描失败: %v", err)
 	}
 
-	if len(connections) == 0 {
-		colorYellow.Println("📭 未发现活跃的网络连接")
-		return nil
-	}
-
 	// 初始化白名单用于标记
 	initialWhitelist := []string{"127.0.0.1", "::1"}
 	if len(whitelistIPs) > 0 {
@@ -495,6 +712,23 @@ func runConnections(cmd *cobra.Command, args []string) error {
 	}
 	whitelistMgr := netguard.NewWhitelistManager(initialWhitelist)
 
+	if jsonMode() {
+		for _, conn := range connections {
+			ev := newConnectionEvent(conn)
+			if conn.RemoteIP != "" && conn.RemoteIP != "0.0.0.0" && conn.RemoteIP != "::" {
+				ev.Whitelisted = boolPtr(whitelistMgr.IsAllowed(conn.RemoteIP))
+			}
+			emit(ev)
+		}
+		emit(newSummaryEvent(connections))
+		return nil
+	}
+
+	if len(connections) == 0 {
+		colorYellow.Println("📭 未发现活跃的网络连接")
+		return nil
+	}
+
 	colorCyan.Printf("📊 发现 %d 个连接:\n", len(connections))
 	fmt.Println()
 
@@ -506,6 +740,407 @@ func runConnections(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// ==========================================
+// listen 命令 - 监听端口
+// ==========================================
+
+var listenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "显示监听端口及所属程序",
+	Long: `读取 /proc/net 中的监听 socket 并关联所属进程，标记是否在白名单中。
+
+默认只显示自身与 --process 指定进程的监听，--host 显示整机。
+未指定 --allow-port / --allow-process 时守护进程以启动时已存在的监听为白名单。`,
+	RunE: runListen,
+}
+
+func runListen(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	cfg := listener.Config{
+		Host:           listenHost,
+		Processes:      listenProcesses,
+		AllowPorts:     listenAllowPorts,
+		AllowProcesses: listenAllowProcs,
+	}
+	noAllowlist := len(cfg.AllowPorts) == 0 && len(cfg.AllowProcesses) == 0
+	m, err := listener.New(cfg, nil)
+	if err != nil {
+		return err
+	}
+	ls, err := m.Snapshot()
+	if err != nil {
+		return fmt.Errorf("读取监听端口失败: %v", err)
+	}
+
+	if jsonMode() {
+		for _, l := range ls {
+			ev := newListenerEvent(l)
+			if !noAllowlist {
+				ev.Allowed = boolPtr(m.Allowed(l))
+			}
+			emit(ev)
+		}
+		return nil
+	}
+
+	if listenHost {
+		colorCyan.Println("🔍 范围: 整机")
+	} else {
+		colorCyan.Printf("🔍 范围: 自身进程 + %v\n", listenProcesses)
+	}
+	printSeparator()
+
+	if len(ls) == 0 {
+		colorYellow.Println("📭 未发现监听端口")
+		return nil
+	}
+
+	fmt.Printf("  %-6s %-30s %-8s %-16s %-8s %s\n", "协议", "监听地址", "PID", "进程", "白名单", "程序路径")
+	fmt.Println("  " + strings.Repeat("-", 95))
+	violations := 0
+	for _, l := range ls {
+		status := "✅ 是"
+		if noAllowlist {
+			status = "➖ N/A"
+		} else if !m.Allowed(l) {
+			status = "❌ 否"
+			violations++
+		}
+		exe := l.Exe
+		if exe == "" {
+			exe = "-"
+		}
+		fmt.Printf("  %-6s %-30s %-8d %-16s %-8s %s\n",
+			l.Protocol, net.JoinHostPort(l.Address, fmt.Sprint(l.Port)), l.PID, l.Process, status, exe)
+	}
+	fmt.Println()
+	printSeparator()
+	colorCyan.Printf("📈 监听端口: %d", len(ls))
+	if !noAllowlist {
+		colorCyan.Printf(" | 白名单之外: %d", violations)
+	}
+	fmt.Println()
+	return nil
+}
+
+// ==========================================
+// bandwidth 命令 - 外发流量统计
+// ==========================================
+
+var bandwidthCmd = &cobra.Command{
+	Use:   "bandwidth",
+	Short: "按进程与对端统计 TCP 外发流量，超过阈值时告警",
+	Long: `周期读取整机 TCP 连接的已确认发送字节数 (sock_diag)，按 (进程, 对端 IP) 在窗口内累计，
+向白名单 (--whitelist、--geo-allow) 之外的对端发送量超过阈值时输出告警。
+--conntrack 订阅连接跟踪销毁事件，补充两次采样之间关闭的连接 (需要 nf_conntrack_acct 已开启)。
+启动前已发送的数据不计入，按 Ctrl+C 停止。`,
+	RunE: runBandwidth,
+}
+
+func runBandwidth(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	geo, err := loadGeo()
+	if err != nil {
+		return err
+	}
+	cfg := bandwidth.Config{
+		Interval:  bwInterval,
+		Window:    bwWindow,
+		Threshold: uint64(bwThresholdMB) << 20,
+		Whitelist: whitelistIPs,
+	}
+	if geo != nil {
+		cfg.Allow = func(ip net.IP) bool { return geo.allowed(ip.String()) }
+	}
+	m, err := bandwidth.New(cfg, func(f bandwidth.Flow) {
+		if jsonMode() {
+			emit(newBandwidthAlert(f, geo.lookup(f.Remote.String())))
+			return
+		}
+		colorRed.Printf("🚨 [%s] %s 内向 %s 发送 %s (%s)\n", f.Time.Format("15:04:05"), f.Window,
+			net.JoinHostPort(f.Remote.String(), fmt.Sprint(f.RemotePort)), bandwidth.FormatBytes(f.Bytes), f.Owner())
+		if info := geo.lookup(f.Remote.String()); !info.Empty() {
+			colorMagenta.Printf("   归属: %s\n", info)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if !jsonMode() {
+		colorCyan.Printf("🔍 采样周期: %v | 窗口: %v | 阈值: %d MB\n", bwInterval, bwWindow, bwThresholdMB)
+	}
+	var ct *conntrack.Monitor
+	if bwConntrack {
+		ct = conntrack.NewMonitor(conntrack.MonitorConfig{Events: []conntrack.EventType{conntrack.EventDestroy}}, m.ObserveConntrack)
+		if err := ct.Start(); err != nil {
+			return err
+		}
+		if !jsonMode() {
+			colorCyan.Println("🔗 连接跟踪: 已订阅连接销毁事件")
+		}
+	}
+	printSeparator()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	m.Start()
+	<-sigChan
+	m.Stop()
+	if ct != nil {
+		ct.Stop()
+	}
+	if !jsonMode() {
+		fmt.Println()
+		colorGreen.Println("👋 监控已停止")
+	}
+	return nil
+}
+
+// ==========================================
+// conntrack 命令 - 连接跟踪
+// ==========================================
+
+var conntrackCmd = &cobra.Command{
+	Use:   "conntrack [IP...]",
+	Short: "查看、订阅或断开连接跟踪 (nf_conntrack) 记录",
+	Long: `通过 ctnetlink 读取连接跟踪表，指定 IP 时只显示与其相关的连接。
+--kill 删除与指定 IP 相关的连接 (已建立的 TCP 连接随之失效)，
+--events 持续输出连接建立与销毁事件。需要 root 权限与 nf_conntrack 模块。`,
+	RunE: runConntrack,
+}
+
+func runConntrack(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	var ips []net.IP
+	for _, a := range args {
+		ip := net.ParseIP(a)
+		if ip == nil {
+			return fmt.Errorf("无效的 IP: %s", a)
+		}
+		ips = append(ips, ip)
+	}
+	match := func(f conntrack.Flow) bool {
+		if len(ips) == 0 {
+			return true
+		}
+		for _, ip := range ips {
+			if f.Involves(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ctEvents {
+		m := conntrack.NewMonitor(conntrack.MonitorConfig{}, func(ev conntrack.Event) {
+			if !match(ev.Flow) {
+				return
+			}
+			if jsonMode() {
+				emit(newConntrackEvent(ev.Type.String(), ev.Flow, ev.Time))
+				return
+			}
+			c := colorGreen
+			if ev.Type == conntrack.EventDestroy {
+				c = colorYellow
+			}
+			c.Printf("[%s] %-7s %s", ev.Time.Format("15:04:05"), ev.Type, ev.Flow)
+			if ev.Type == conntrack.EventDestroy {
+				fmt.Printf("  ↑%s ↓%s", bandwidth.FormatBytes(ev.Flow.OrigCounters.Bytes), bandwidth.FormatBytes(ev.Flow.ReplyCounters.Bytes))
+			}
+			fmt.Println()
+		})
+		if err := m.Start(); err != nil {
+			return err
+		}
+		if !jsonMode() {
+			colorMagenta.Println("👀 订阅连接跟踪事件... (按 Ctrl+C 停止)")
+		}
+		printSeparator()
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+		m.Stop()
+		if jsonMode() {
+			if n := m.Overruns(); n > 0 {
+				fmt.Fprintf(os.Stderr, "⚠️  缓冲区溢出 %d 次，部分事件丢失\n", n)
+			}
+			return nil
+		}
+		fmt.Println()
+		if n := m.Overruns(); n > 0 {
+			colorYellow.Printf("⚠️  缓冲区溢出 %d 次，部分事件丢失\n", n)
+		}
+		colorGreen.Println("👋 监控已停止")
+		return nil
+	}
+
+	c, err := conntrack.Dial()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ctKill {
+		if len(ips) == 0 {
+			return fmt.Errorf("--kill 需要指定 IP")
+		}
+		for _, ip := range ips {
+			n, err := c.DeleteIP(ip)
+			if err != nil {
+				return err
+			}
+			if jsonMode() {
+				emit(killEvent{eventHeader: header("kill"), IP: ip.String(), Deleted: n})
+				continue
+			}
+			colorYellow.Printf("✂️  %s: 已删除 %d 条连接跟踪记录\n", ip, n)
+		}
+		return nil
+	}
+
+	flows, err := c.Dump()
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, f := range flows {
+		if !match(f) {
+			continue
+		}
+		count++
+		if jsonMode() {
+			emit(newConntrackEvent("dump", f, time.Now()))
+			continue
+		}
+		fmt.Printf("%-60s ↑%-10s ↓%-10s 超时 %ds\n", f.String(),
+			bandwidth.FormatBytes(f.OrigCounters.Bytes), bandwidth.FormatBytes(f.ReplyCounters.Bytes), f.Timeout)
+	}
+	if !jsonMode() {
+		printSeparator()
+		colorCyan.Printf("📊 共 %d 条连接跟踪记录\n", count)
+	}
+	return nil
+}
+
+// ==========================================
+// geoip 命令 - GeoIP 查询
+// ==========================================
+
+var geoipCmd = &cobra.Command{
+	Use:   "geoip [IP...]",
+	Short: "查询 IP 的国家、城市与 ASN 及规则判定",
+	Long: `使用本地 MaxMind 格式库查询 IP 的地理位置与自治系统，并按 --geo-allow / --geo-alert 规则判定。
+
+示例:
+  netguard-monitor geoip 8.8.8.8 114.114.114.114 --geoip-db GeoLite2-City.mmdb --geoip-db GeoLite2-ASN.mmdb --geo-alert '!CN'`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runGeoIP,
+}
+
+func runGeoIP(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	geo, err := loadGeo()
+	if err != nil {
+		return err
+	}
+	if geo == nil {
+		return fmt.Errorf("未指定 GeoIP 库 (--geoip-db)")
+	}
+
+	if jsonMode() {
+		for _, ip := range args {
+			ev := geoipEvent{eventHeader: header("geoip"), IP: ip}
+			info, err := geo.db.LookupString(ip)
+			if err != nil {
+				ev.Error = err.Error()
+				emit(ev)
+				continue
+			}
+			ev.Geo = newGeoJSON(info)
+			switch geo.rules.Evaluate(info) {
+			case geoip.VerdictAllow:
+				ev.Verdict = "allow"
+			case geoip.VerdictAlert:
+				ev.Verdict = "alert"
+			default:
+				ev.Verdict = "none"
+			}
+			emit(ev)
+		}
+		return nil
+	}
+
+	fmt.Printf("  %-40s %-8s %s\n", "IP", "判定", "位置 / ASN")
+	fmt.Println("  " + strings.Repeat("-", 85))
+	for _, ip := range args {
+		info, err := geo.db.LookupString(ip)
+		if err != nil {
+			colorRed.Printf("  %-40s ❌ %v\n", ip, err)
+			continue
+		}
+		verdict := "➖ 无"
+		switch geo.rules.Evaluate(info) {
+		case geoip.VerdictAllow:
+			verdict = "✅ 允许"
+		case geoip.VerdictAlert:
+			verdict = "❌ 告警"
+		}
+		desc := info.String()
+		if desc == "" {
+			desc = "-"
+		}
+		fmt.Printf("  %-40s %-8s %s\n", ip, verdict, desc)
+	}
+	fmt.Println()
+	return nil
+}
+
+// geoFilter GeoIP 库与规则
+type geoFilter struct {
+	db    *geoip.DB
+	rules *geoip.Rules
+}
+
+// loadGeo 按命令行参数加载 GeoIP 库，未指定时返回 nil
+func loadGeo() (*geoFilter, error) {
+	if len(geoDatabases) == 0 {
+		return nil, nil
+	}
+	db, err := geoip.Open(geoDatabases...)
+	if err != nil {
+		return nil, fmt.Errorf("加载 GeoIP 库失败: %v", err)
+	}
+	rules, err := geoip.ParseRules(geoAllow, geoAlert)
+	if err != nil {
+		return nil, err
+	}
+	return &geoFilter{db: db, rules: rules}, nil
+}
+
+// lookup 查询 IP 的地理信息
+func (g *geoFilter) lookup(ip string) geoip.Info {
+	if g == nil {
+		return geoip.Info{}
+	}
+	info, _ := g.db.LookupString(ip)
+	return info
+}
+
+// allowed IP 是否命中 GeoIP 白名单 (未命中告警规则)
+func (g *geoFilter) allowed(ip string) bool {
+	if g == nil {
+		return false
+	}
+	return g.rules.Evaluate(g.lookup(ip)) == geoip.VerdictAllow
+}
+
 // ==========================================
 // 自定义 Reporter 实现
 // ==========================================
@@ -513,10 +1148,27 @@ func runConnections(cmd *cobra.Command, args []string) error {
 // DebugReporter 调试用的告警上报器
 type DebugReporter struct {
 	dryRun bool
+	geo    *geoFilter
+	// 封禁时断开已建立的连接，nil 时不断开
+	ct *conntrack.Conn
 }
 
 // Report 上报网络告警
 func (r *DebugReporter) Report(alert event.NetworkAlert) error {
+	if jsonMode() {
+		ev := newConnectionAlert(alert, r.geo.lookup(alert.RemoteIP))
+		if !r.dryRun && r.ct != nil {
+			n, err := r.ct.DeleteIP(net.ParseIP(alert.RemoteIP))
+			if err != nil {
+				ev.Error = err.Error()
+			} else {
+				ev.Killed = n
+			}
+		}
+		emit(ev)
+		return nil
+	}
+
 	timestamp := alert.Timestamp.Format("2006-01-02 15:04:05")
 
 	fmt.Println()
@@ -539,7 +1191,19 @@ func (r *DebugReporter) Report(alert event.NetworkAlert) error {
 	headerColor.Printf("║  协议     : %-50s ║\n", alert.Protocol)
 	headerColor.Printf("║  方向     : %-50s ║\n", alert.Direction)
 	headerColor.Printf("║  进程 PID : %-50d ║\n", alert.PID)
+	if info := r.geo.lookup(alert.RemoteIP); !info.Empty() {
+		headerColor.Printf("║  归属     : %-50s ║\n", info.String())
+	}
 	headerColor.Println("╚══════════════════════════════════════════════════════════════╝")
+
+	if !r.dryRun && r.ct != nil {
+		n, err := r.ct.DeleteIP(net.ParseIP(alert.RemoteIP))
+		if err != nil {
+			colorRed.Printf("❌ 断开已建立连接失败: %v\n", err)
+		} else {
+			colorYellow.Printf("✂️  已断开 %d 条连接跟踪记录\n", n)
+		}
+	}
 	fmt.Println()
 
 	return nil
@@ -636,24 +1300,29 @@ func printConnectionTableWithStatus(connections []detector.ConnectionInfo, white
 	fmt.Println()
 }
 
-// printConnectionStats 打印连接统计信息
-func printConnectionStats(connections []detector.ConnectionInfo) {
-	// 统计协议分布
-	protoStats := make(map[string]int)
-	statusStats := make(map[string]int)
-	uniqueIPs := make(map[string]bool)
+// summarizeConnections 统计协议、状态分布与唯一远程 IP 数
+func summarizeConnections(connections []detector.ConnectionInfo) (protoStats, statusStats map[string]int, uniqueIPs int) {
+	protoStats = make(map[string]int)
+	statusStats = make(map[string]int)
+	unique := make(map[string]bool)
 
 	for _, conn := range connections {
 		protoStats[conn.Protocol]++
 		statusStats[conn.Status]++
 		if conn.RemoteIP != "" && conn.RemoteIP != "0.0.0.0" && conn.RemoteIP != "::" {
-			uniqueIPs[conn.RemoteIP] = true
+			unique[conn.RemoteIP] = true
 		}
 	}
+	return protoStats, statusStats, len(unique)
+}
+
+// printConnectionStats 打印连接统计信息
+func printConnectionStats(connections []detector.ConnectionInfo) {
+	protoStats, statusStats, uniqueIPs := summarizeConnections(connections)
 
 	colorCyan.Println("📈 统计信息:")
 	fmt.Printf("   总连接数    : %d\n", len(connections))
-	fmt.Printf("   唯一远程 IP : %d\n", len(uniqueIPs))
+	fmt.Printf("   唯一远程 IP : %d\n", uniqueIPs)
 
 	// 协议统计
 	fmt.Print("   协议分布    : ")
@@ -674,8 +1343,11 @@ func printConnectionStats(connections []detector.ConnectionInfo) {
 	fmt.Println(strings.Join(statusList, ", "))
 }
 
-// printBanner 打印工具标题
+// printBanner 打印工具标题，JSON 模式下不输出
 func printBanner() {
+	if jsonMode() {
+		return
+	}
 	fmt.Println()
 	colorMagenta.Println("╔════════════════════════════════════════════════════════════╗")
 	colorMagenta.Println("║          网络连接监控调试工具 (NetGuard Monitor)             ║")
@@ -684,8 +1356,11 @@ func printBanner() {
 	fmt.Println()
 }
 
-// printSeparator 打印分隔线
+// printSeparator 打印分隔线，JSON 模式下不输出
 func printSeparator() {
+	if jsonMode() {
+		return
+	}
 	colorWhite.Println("────────────────────────────────────────────────────────────────")
 }
 
@@ -698,19 +1373,72 @@ func init() {
 	rootCmd.PersistentFlags().IntSliceVarP(&targetPIDs, "pid", "p", nil, "目标进程 PID (可多次指定，默认: 当前进程)")
 	rootCmd.PersistentFlags().BoolVarP(&verboseMode, "verbose", "v", false, "启用详细输出模式")
 	rootCmd.PersistentFlags().StringSliceVarP(&whitelistIPs, "whitelist", "w", nil, "白名单 IP 或 CIDR (可多次指定)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "输出格式: text / json (每个事件一行 JSON，whitelist 命令不支持)")
 
 	// watch 命令参数
 	watchCmd.Flags().DurationVarP(&scanInterval, "interval", "i", 5*time.Second, "扫描间隔时间 (如: 5s, 1m)")
 	watchCmd.Flags().BoolVarP(&quietMode, "quiet", "q", false, "静默模式，仅在异常时输出")
 	watchCmd.Flags().BoolVarP(&dryRunMode, "dry-run", "d", false, "仅检测，不执行封禁")
+	watchCmd.Flags().DurationVar(&dedupTTL, "dedup-ttl", seen.DefaultTTL, "同一 IP 的告警去重时间，过期后再次告警")
+
+	// record / replay 命令参数
+	recordCmd.Flags().StringVar(&recordOut, "out", "", "轨迹文件路径")
+	recordCmd.Flags().DurationVar(&recordDuration, "duration", 0, "录制时长，0 表示直到 Ctrl+C")
+	recordCmd.Flags().DurationVarP(&scanInterval, "interval", "i", 5*time.Second, "扫描间隔时间 (如: 5s, 1m)")
+	recordCmd.Flags().BoolVarP(&quietMode, "quiet", "q", false, "静默模式，不输出每个快照")
+	replayCmd.Flags().BoolVarP(&quietMode, "quiet", "q", false, "静默模式，仅在异常时输出")
+	replayCmd.Flags().DurationVar(&dedupTTL, "dedup-ttl", seen.DefaultTTL, "同一 IP 的告警去重时间，过期后再次告警")
+
+	// GeoIP 参数
+	rootCmd.PersistentFlags().StringSliceVar(&geoDatabases, "geoip-db", nil, "MaxMind 格式 GeoIP 库 (可多次指定，如城市库与 ASN 库)")
+	rootCmd.PersistentFlags().StringSliceVar(&geoAllow, "geo-allow", nil, "GeoIP 白名单，国家代码或 ASN，如 CN、AS4134")
+	rootCmd.PersistentFlags().StringSliceVar(&geoAlert, "geo-alert", nil, "GeoIP 告警规则，\"!\" 前缀取反，如 !CN")
+
+	// listen 命令参数
+	listenCmd.Flags().BoolVar(&listenHost, "host", false, "显示整机的监听端口")
+	listenCmd.Flags().StringSliceVar(&listenProcesses, "process", nil, "受监控的进程名 (可多次指定)")
+	listenCmd.Flags().StringSliceVar(&listenAllowPorts, "allow-port", nil, "允许的端口，如 22、tcp/443、8000-8100")
+	listenCmd.Flags().StringSliceVar(&listenAllowProcs, "allow-process", nil, "允许任意监听的程序路径 (支持 * 通配)")
+
+	// bandwidth 命令参数
+	bandwidthCmd.Flags().DurationVar(&bwInterval, "interval", bandwidth.DefaultInterval, "采样周期")
+	bandwidthCmd.Flags().DurationVar(&bwWindow, "window", bandwidth.DefaultWindow, "统计窗口")
+	bandwidthCmd.Flags().Int64Var(&bwThresholdMB, "threshold-mb", bandwidth.DefaultThreshold>>20, "窗口内发送量阈值 (MB)")
+	bandwidthCmd.Flags().BoolVar(&bwConntrack, "conntrack", false, "订阅连接跟踪销毁事件，统计两次采样之间关闭的连接")
+
+	// whitelist 命令参数
+	whitelistCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := checkOutput(); err != nil {
+			return err
+		}
+		if jsonMode() {
+			return fmt.Errorf("whitelist 命令不支持 --output json")
+		}
+		return nil
+	}
+	whitelistCmd.PersistentFlags().StringVar(&wlSocket, "socket", "/run/linuxFileWatcher/detect.sock", "守护进程本机接口 socket (api.socket)")
+	whitelistListCmd.Flags().BoolVar(&wlDaemon, "daemon", false, "列出运行中守护进程的白名单")
+	whitelistAddCmd.Flags().StringVar(&wlDesc, "desc", "", "规则说明")
+
+	// conntrack 命令参数
+	conntrackCmd.Flags().BoolVar(&ctEvents, "events", false, "持续输出连接建立与销毁事件")
+	conntrackCmd.Flags().BoolVar(&ctKill, "kill", false, "删除与指定 IP 相关的连接")
 
 	// 注册子命令
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(recordCmd)
+	rootCmd.AddCommand(replayCmd)
 	rootCmd.AddCommand(connectionsCmd)
+	rootCmd.AddCommand(listenCmd)
+	rootCmd.AddCommand(geoipCmd)
+	rootCmd.AddCommand(bandwidthCmd)
+	rootCmd.AddCommand(conntrackCmd)
 
 	// whitelist 子命令
 	whitelistCmd.AddCommand(whitelistListCmd)
 	whitelistCmd.AddCommand(whitelistTestCmd)
+	whitelistCmd.AddCommand(whitelistAddCmd)
+	whitelistCmd.AddCommand(whitelistRemoveCmd)
 	rootCmd.AddCommand(whitelistCmd)
 }
@@ -0,0 +1,312 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/security/netguard/bandwidth"
+	"linuxFileWatcher/internal/security/netguard/conntrack"
+	"linuxFileWatcher/internal/security/netguard/detector"
+	"linuxFileWatcher/internal/security/netguard/event"
+	"linuxFileWatcher/internal/security/netguard/geoip"
+	"linuxFileWatcher/internal/security/netguard/listener"
+)
+
+// ==========================================
+// JSON 输出 (--output json)
+// ==========================================
+//
+// 每个事件输出一行 JSON 对象 (NDJSON)，可直接交给 jq 或测试脚本处理，所有事件都带 event 与 time 字段。
+// JSON 模式下标准输出只有事件：横幅、表格与提示不输出，统计改为 summary / stop 事件，错误仍输出到标准错误。
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	// 输出格式: text / json
+	outputFormat string
+
+	emitMu  sync.Mutex
+	emitEnc = newEncoder()
+)
+
+func newEncoder() *json.Encoder {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+// jsonMode 是否按 JSON 输出
+func jsonMode() bool {
+	return outputFormat == outputJSON
+}
+
+// checkOutput 校验 --output 参数
+func checkOutput() error {
+	switch outputFormat {
+	case outputText, outputJSON:
+		return nil
+	}
+	return fmt.Errorf("无效的输出格式 %q，可选: text、json", outputFormat)
+}
+
+// emit 输出一个事件，可在多个 goroutine 中调用
+func emit(v interface{}) {
+	emitMu.Lock()
+	defer emitMu.Unlock()
+	if err := emitEnc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "输出事件失败: %v\n", err)
+	}
+}
+
+// eventHeader 所有事件的公共字段
+type eventHeader struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+}
+
+func header(name string) eventHeader {
+	return eventHeader{Event: name, Time: time.Now()}
+}
+
+// geoJSON GeoIP 查询结果
+type geoJSON struct {
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+	ASN     uint32 `json:"asn,omitempty"`
+	ASOrg   string `json:"as_org,omitempty"`
+}
+
+func newGeoJSON(info geoip.Info) *geoJSON {
+	if info.Empty() {
+		return nil
+	}
+	return &geoJSON{Country: info.Country, City: info.City, ASN: info.ASN, ASOrg: info.ASOrg}
+}
+
+// startEvent watch 启动时的配置
+type startEvent struct {
+	eventHeader
+	Command   string   `json:"command"`
+	PIDs      []int32  `json:"pids,omitempty"`
+	Interval  string   `json:"interval,omitempty"`
+	DedupTTL  string   `json:"dedup_ttl,omitempty"`
+	DryRun    bool     `json:"dry_run"`
+	Whitelist []string `json:"whitelist,omitempty"`
+}
+
+// connectionEvent 一条网络连接
+type connectionEvent struct {
+	eventHeader
+	PID        int    `json:"pid"`
+	Protocol   string `json:"protocol"`
+	LocalPort  int    `json:"local_port"`
+	RemoteIP   string `json:"remote_ip"`
+	RemotePort int    `json:"remote_port"`
+	Status     string `json:"status"`
+	// 是否在白名单中，只有 connections 命令输出
+	Whitelisted *bool `json:"whitelisted,omitempty"`
+}
+
+func newConnectionEvent(conn detector.ConnectionInfo) connectionEvent {
+	return connectionEvent{
+		eventHeader: header("connection"),
+		PID:         int(conn.PID),
+		Protocol:    conn.Protocol,
+		LocalPort:   int(conn.LocalPort),
+		RemoteIP:    conn.RemoteIP,
+		RemotePort:  int(conn.RemotePort),
+		Status:      conn.Status,
+	}
+}
+
+// summaryEvent scan / connections 的连接统计
+type summaryEvent struct {
+	eventHeader
+	Connections     int            `json:"connections"`
+	UniqueRemoteIPs int            `json:"unique_remote_ips"`
+	Protocols       map[string]int `json:"protocols"`
+	States          map[string]int `json:"states"`
+	DurationMS      int64          `json:"duration_ms,omitempty"`
+}
+
+func newSummaryEvent(connections []detector.ConnectionInfo) summaryEvent {
+	protos, states, unique := summarizeConnections(connections)
+	return summaryEvent{
+		eventHeader:     header("summary"),
+		Connections:     len(connections),
+		UniqueRemoteIPs: unique,
+		Protocols:       protos,
+		States:          states,
+	}
+}
+
+// scanEvent watch 每轮扫描的状态
+type scanEvent struct {
+	eventHeader
+	Scan        int    `json:"scan"`
+	Connections int    `json:"connections"`
+	Violations  int    `json:"violations"`
+	NewAlerts   int    `json:"new_alerts"`
+	Error       string `json:"error,omitempty"`
+}
+
+// alertEvent 告警: type 为 connection (白名单外的连接) 或 bandwidth (外发流量超过阈值)
+type alertEvent struct {
+	eventHeader
+	Type       string   `json:"type"`
+	Action     string   `json:"action,omitempty"`
+	Direction  string   `json:"direction,omitempty"`
+	Protocol   string   `json:"protocol,omitempty"`
+	PID        int      `json:"pid"`
+	Process    string   `json:"process,omitempty"`
+	Exe        string   `json:"exe,omitempty"`
+	LocalPort  int      `json:"local_port,omitempty"`
+	RemoteIP   string   `json:"remote_ip"`
+	RemotePort int      `json:"remote_port"`
+	Bytes      uint64   `json:"bytes,omitempty"`
+	Window     string   `json:"window,omitempty"`
+	Geo        *geoJSON `json:"geo,omitempty"`
+	// 封禁时断开的连接跟踪记录数
+	Killed int `json:"killed,omitempty"`
+	// 断开连接失败的原因
+	Error string `json:"error,omitempty"`
+}
+
+func newConnectionAlert(alert event.NetworkAlert, geo geoip.Info) alertEvent {
+	return alertEvent{
+		eventHeader: eventHeader{Event: "alert", Time: alert.Timestamp},
+		Type:        "connection",
+		Action:      alert.ActionTaken,
+		Direction:   fmt.Sprint(alert.Direction),
+		Protocol:    alert.Protocol,
+		PID:         int(alert.PID),
+		LocalPort:   int(alert.LocalPort),
+		RemoteIP:    alert.RemoteIP,
+		RemotePort:  int(alert.RemotePort),
+		Geo:         newGeoJSON(geo),
+	}
+}
+
+func newBandwidthAlert(f bandwidth.Flow, geo geoip.Info) alertEvent {
+	return alertEvent{
+		eventHeader: eventHeader{Event: "alert", Time: f.Time},
+		Type:        "bandwidth",
+		PID:         f.PID,
+		Process:     f.Process,
+		Exe:         f.Exe,
+		RemoteIP:    f.Remote.String(),
+		RemotePort:  f.RemotePort,
+		Bytes:       f.Bytes,
+		Window:      f.Window.String(),
+		Geo:         newGeoJSON(geo),
+	}
+}
+
+// stopEvent watch 停止时的统计
+type stopEvent struct {
+	eventHeader
+	UptimeSeconds int64 `json:"uptime_seconds"`
+	Scans         int   `json:"scans"`
+	Connections   int   `json:"connections"`
+	Alerts        int   `json:"alerts"`
+	BlockedIPs    int   `json:"blocked_ips"`
+}
+
+// listenerEvent 一个监听端口
+type listenerEvent struct {
+	eventHeader
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	PID      int    `json:"pid"`
+	Process  string `json:"process,omitempty"`
+	Exe      string `json:"exe,omitempty"`
+	// 是否在白名单中，未指定白名单时不输出
+	Allowed *bool `json:"allowed,omitempty"`
+}
+
+func newListenerEvent(l listener.Listener) listenerEvent {
+	return listenerEvent{
+		eventHeader: header("listener"),
+		Protocol:    l.Protocol,
+		Address:     l.Address,
+		Port:        l.Port,
+		PID:         l.PID,
+		Process:     l.Process,
+		Exe:         l.Exe,
+	}
+}
+
+// tupleJSON 连接跟踪的一个方向
+type tupleJSON struct {
+	Src     string `json:"src"`
+	Dst     string `json:"dst"`
+	SrcPort uint16 `json:"src_port"`
+	DstPort uint16 `json:"dst_port"`
+	Proto   uint8  `json:"proto"`
+}
+
+func newTupleJSON(t conntrack.Tuple) tupleJSON {
+	return tupleJSON{Src: ipString(t.Src), Dst: ipString(t.Dst), SrcPort: t.SrcPort, DstPort: t.DstPort, Proto: t.Proto}
+}
+
+// conntrackEvent 连接跟踪记录 (type 为 dump) 或事件 (new / update / destroy)
+type conntrackEvent struct {
+	eventHeader
+	Type       string    `json:"type"`
+	ID         uint32    `json:"id"`
+	Orig       tupleJSON `json:"orig"`
+	Reply      tupleJSON `json:"reply"`
+	OrigBytes  uint64    `json:"orig_bytes"`
+	ReplyBytes uint64    `json:"reply_bytes"`
+	Timeout    uint32    `json:"timeout,omitempty"`
+}
+
+func newConntrackEvent(typ string, f conntrack.Flow, t time.Time) conntrackEvent {
+	return conntrackEvent{
+		eventHeader: eventHeader{Event: "conntrack", Time: t},
+		Type:        typ,
+		ID:          f.ID,
+		Orig:        newTupleJSON(f.Orig),
+		Reply:       newTupleJSON(f.Reply),
+		OrigBytes:   f.OrigCounters.Bytes,
+		ReplyBytes:  f.ReplyCounters.Bytes,
+		Timeout:     f.Timeout,
+	}
+}
+
+// killEvent conntrack --kill 的结果
+type killEvent struct {
+	eventHeader
+	IP      string `json:"ip"`
+	Deleted int    `json:"deleted"`
+}
+
+// geoipEvent geoip 命令的查询结果
+type geoipEvent struct {
+	eventHeader
+	IP      string   `json:"ip"`
+	Verdict string   `json:"verdict,omitempty"` // allow / alert / none
+	Geo     *geoJSON `json:"geo,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
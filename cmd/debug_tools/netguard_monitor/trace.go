@@ -0,0 +1,322 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"linuxFileWatcher/internal/security/netguard"
+	"linuxFileWatcher/internal/security/netguard/detector"
+	"linuxFileWatcher/internal/security/netguard/seen"
+)
+
+// ==========================================
+// record / replay 命令 - 连接快照录制与回放
+// ==========================================
+//
+// record 按扫描间隔记录目标进程的连接快照，replay 将记录按原时间顺序交给与 watch 相同的
+// 白名单 / GeoIP / 告警去重逻辑，用于在没有现场主机的情况下复现误报。
+
+// traceVersion 轨迹文件格式版本
+const traceVersion = 1
+
+// trace 连接快照轨迹
+type trace struct {
+	Version    int       `json:"version"`
+	Host       string    `json:"host,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+	PIDs       []int32   `json:"pids"`
+	Interval   string    `json:"interval"`
+	// 录制时生效的白名单，回放时在此基础上追加 --whitelist
+	Whitelist []string        `json:"whitelist"`
+	Snapshots []traceSnapshot `json:"snapshots"`
+}
+
+// traceSnapshot 一次扫描的结果
+type traceSnapshot struct {
+	Time        time.Time   `json:"time"`
+	Error       string      `json:"error,omitempty"`
+	Connections []traceConn `json:"connections"`
+}
+
+// traceConn 一条连接
+type traceConn struct {
+	PID        int32  `json:"pid"`
+	Protocol   string `json:"protocol"`
+	LocalPort  uint16 `json:"local_port"`
+	RemoteIP   string `json:"remote_ip"`
+	RemotePort uint16 `json:"remote_port"`
+	Status     string `json:"status"`
+}
+
+func newTraceConns(connections []detector.ConnectionInfo) []traceConn {
+	out := make([]traceConn, len(connections))
+	for i, conn := range connections {
+		out[i] = traceConn{
+			PID:        int32(conn.PID),
+			Protocol:   conn.Protocol,
+			LocalPort:  uint16(conn.LocalPort),
+			RemoteIP:   conn.RemoteIP,
+			RemotePort: uint16(conn.RemotePort),
+			Status:     conn.Status,
+		}
+	}
+	return out
+}
+
+// save 写入临时文件后重命名，中途退出不会留下不完整的轨迹
+func (t *trace) save(path string) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func loadTrace(path string) (*trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t trace
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("解析轨迹文件失败: %v", err)
+	}
+	if t.Version != traceVersion {
+		return nil, fmt.Errorf("不支持的轨迹文件版本 %d", t.Version)
+	}
+	return &t, nil
+}
+
+var (
+	// record / replay 命令参数
+	recordOut      string
+	recordDuration time.Duration
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "录制连接快照",
+	Long: `按扫描间隔记录目标进程的网络连接快照，写入 --out 指定的轨迹文件。
+
+录制到 --duration 指定的时长或按 Ctrl+C 时停止并保存；轨迹同时记录当前白名单，
+可交给 replay 命令离线复现告警。`,
+	RunE: runRecord,
+}
+
+func runRecord(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	if recordOut == "" {
+		return fmt.Errorf("需要 --out 指定轨迹文件路径")
+	}
+
+	pids := resolveTargetPIDs()
+	whitelist := append([]string{"127.0.0.1", "::1"}, whitelistIPs...)
+	host, _ := os.Hostname()
+	t := &trace{
+		Version:    traceVersion,
+		Host:       host,
+		RecordedAt: time.Now(),
+		PIDs:       pids,
+		Interval:   scanInterval.String(),
+		Whitelist:  whitelist,
+	}
+
+	if jsonMode() {
+		emit(startEvent{
+			eventHeader: header("start"),
+			Command:     "record",
+			PIDs:        pids,
+			Interval:    scanInterval.String(),
+			Whitelist:   whitelist,
+		})
+	} else {
+		colorCyan.Printf("🔍 录制目标 PID: %v\n", pids)
+		colorCyan.Printf("⏱️  扫描间隔: %v\n", scanInterval)
+		if recordDuration > 0 {
+			colorCyan.Printf("⏳ 录制时长: %v\n", recordDuration)
+		}
+		colorCyan.Printf("💾 轨迹文件: %s\n", recordOut)
+		printSeparator()
+		colorMagenta.Println("🎬 开始录制... (按 Ctrl+C 停止并保存)")
+		fmt.Println()
+	}
+
+	scanner := detector.NewScanner(pids)
+	snapshot := func() {
+		now := time.Now()
+		s := traceSnapshot{Time: now}
+		connections, err := scanner.Scan()
+		if err != nil {
+			s.Error = err.Error()
+			printScanError(now, len(t.Snapshots)+1, s.Error)
+		} else {
+			s.Connections = newTraceConns(connections)
+			if jsonMode() {
+				emit(scanEvent{eventHeader: eventHeader{Event: "scan", Time: now}, Scan: len(t.Snapshots) + 1, Connections: len(connections)})
+			} else if !quietMode {
+				colorGreen.Printf("[%s] 📸 快照 #%d | 连接数: %d\n", now.Format("15:04:05"), len(t.Snapshots)+1, len(connections))
+			}
+		}
+		t.Snapshots = append(t.Snapshots, s)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	var deadline <-chan time.Time
+	if recordDuration > 0 {
+		timer := time.NewTimer(recordDuration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	snapshot()
+loop:
+	for {
+		select {
+		case <-sigChan:
+			break loop
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			snapshot()
+		}
+	}
+
+	if err := t.save(recordOut); err != nil {
+		return fmt.Errorf("保存轨迹文件失败: %v", err)
+	}
+
+	connCount := 0
+	for _, s := range t.Snapshots {
+		connCount += len(s.Connections)
+	}
+	if jsonMode() {
+		emit(stopEvent{
+			eventHeader:   header("stop"),
+			UptimeSeconds: int64(time.Since(t.RecordedAt).Seconds()),
+			Scans:         len(t.Snapshots),
+			Connections:   connCount,
+		})
+		return nil
+	}
+	fmt.Println()
+	printSeparator()
+	colorGreen.Printf("💾 已保存 %d 个快照 (%d 条连接) 到 %s\n", len(t.Snapshots), connCount, recordOut)
+	return nil
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <trace.json>",
+	Short: "回放连接快照并重新检测",
+	Long: `按记录的时间顺序，将 record 生成的轨迹交给与 watch 相同的白名单、GeoIP 规则与告警去重逻辑，
+输出在当时会产生的告警。用于离线复现误报、验证白名单调整是否有效。
+
+白名单为录制时的白名单加上 --whitelist 指定的规则；回放只检测，不执行封禁。`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReplay,
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	printBanner()
+
+	t, err := loadTrace(args[0])
+	if err != nil {
+		return err
+	}
+	whitelist := append(append([]string(nil), t.Whitelist...), whitelistIPs...)
+
+	geo, err := loadGeo()
+	if err != nil {
+		return err
+	}
+
+	if jsonMode() {
+		emit(startEvent{
+			eventHeader: header("start"),
+			Command:     "replay",
+			PIDs:        t.PIDs,
+			Interval:    t.Interval,
+			DedupTTL:    dedupTTL.String(),
+			DryRun:      true,
+			Whitelist:   whitelist,
+		})
+	} else {
+		colorCyan.Printf("📼 轨迹文件: %s\n", args[0])
+		colorCyan.Printf("   录制主机: %s | 录制时间: %s | 快照: %d 个\n",
+			t.Host, t.RecordedAt.Format("2006-01-02 15:04:05"), len(t.Snapshots))
+		colorCyan.Printf("   目标 PID: %v | 扫描间隔: %s\n", t.PIDs, t.Interval)
+		colorCyan.Printf("🔁 告警去重: %v 内同一 IP 只告警一次\n", dedupTTL)
+		colorCyan.Println("📋 白名单规则:")
+		for _, ip := range whitelist {
+			fmt.Printf("   • %s\n", ip)
+		}
+		printSeparator()
+	}
+
+	whitelistMgr := netguard.NewWhitelistManager(whitelist)
+	// 去重按记录时间计算，与录制时的告警时机一致
+	blockedIPs := seen.New(seen.Config{TTL: dedupTTL})
+	reporter := &DebugReporter{dryRun: true, geo: geo}
+
+	alertCount, connCount := 0, 0
+	for i, s := range t.Snapshots {
+		if s.Error != "" {
+			printScanError(s.Time, i+1, s.Error)
+			continue
+		}
+		violations, alerts := checkConnections(s.Connections, whitelistMgr, geo, reporter, blockedIPs, s.Time)
+		printScanStatus(s.Time, i+1, len(s.Connections), violations, alerts)
+		alertCount += alerts
+		connCount += len(s.Connections)
+	}
+
+	var span time.Duration
+	if n := len(t.Snapshots); n > 1 {
+		span = t.Snapshots[n-1].Time.Sub(t.Snapshots[0].Time)
+	}
+	if jsonMode() {
+		emit(stopEvent{
+			eventHeader:   header("stop"),
+			UptimeSeconds: int64(span.Seconds()),
+			Scans:         len(t.Snapshots),
+			Connections:   connCount,
+			Alerts:        alertCount,
+		})
+		return nil
+	}
+	fmt.Println()
+	printSeparator()
+	colorCyan.Println("📊 回放统计:")
+	colorWhite.Printf("   轨迹时长     : %v\n", span.Round(time.Second))
+	colorWhite.Printf("   快照数量     : %d\n", len(t.Snapshots))
+	colorWhite.Printf("   检测连接总数 : %d\n", connCount)
+	colorWhite.Printf("   告警次数     : %d\n", alertCount)
+	return nil
+}
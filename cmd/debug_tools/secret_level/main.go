@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -15,6 +16,10 @@ import (
 	"linuxFileWatcher/internal/model"
 	// 引入我们封装好的密级标志检测子模块
 	"linuxFileWatcher/internal/detector/secret_level"
+	"linuxFileWatcher/cmd/debug_tools/internal/filelist"
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/safewalk"
 )
 
 var (
@@ -22,14 +27,20 @@ var (
 	workers   int
 	verbose   bool
 	enableOCR bool
+	filesFrom string // 待扫描路径列表文件，- 表示标准输入
+	filesNul  bool   // 路径列表以 NUL 分隔
 )
 
 func main() {
-	// 1. 参数解析 (复刻之前的 CLI 体验)
-	flag.StringVar(&targetDir, "d", ".", "要扫描的目录路径")
-	flag.IntVar(&workers, "w", runtime.NumCPU(), "并发工作线程数")
-	flag.BoolVar(&verbose, "v", false, "显示详细调试日志")
-	flag.BoolVar(&enableOCR, "ocr", true, "开启 OCR 检测 (默认开启)")
+	// 1. 参数解析 (复刻之前的 CLI 体验)，帮助文本语言取 LANG 环境变量
+	i18n.SetLocale("")
+	flag.StringVar(&targetDir, "d", ".", i18n.T("cli.secret_level.dir"))
+	flag.IntVar(&workers, "w", runtime.NumCPU(), i18n.T("cli.secret_level.workers"))
+	flag.BoolVar(&verbose, "v", false, i18n.T("cli.secret_level.verbose"))
+	flag.BoolVar(&enableOCR, "ocr", true, i18n.T("cli.secret_level.ocr"))
+	flag.StringVar(&filesFrom, "files-from", "", i18n.T("cli.secret_level.files_from"))
+	flag.BoolVar(&filesNul, "null", false, i18n.T("cli.secret_level.null"))
+	flag.BoolVar(&filesNul, "0", false, i18n.T("cli.secret_level.null"))
 	flag.Parse()
 
 	// 2. 初始化配置
@@ -44,15 +55,27 @@ func main() {
 		}
 	}
 
-	// 校验目录
-	stat, err := os.Stat(targetDir)
-	if err != nil {
-		fmt.Printf("Fatal: 无法访问目标目录: %v\n", err)
-		os.Exit(1)
-	}
-	if !stat.IsDir() {
-		fmt.Printf("Fatal: 目标路径不是一个目录: %s\n", targetDir)
-		os.Exit(1)
+	// 指定路径列表时逐项展开，否则校验目录
+	var listed []string
+	if filesFrom != "" {
+		paths, err := filelist.Load(filesFrom, filesNul)
+		if err != nil {
+			fmt.Println(i18n.T("cli.fatal.read_list", err))
+			os.Exit(1)
+		}
+		listed = filelist.Expand(paths, collectFiles, func(path string, err error) {
+			fmt.Println(i18n.T("cli.warn.skip_path", path, err))
+		})
+	} else {
+		stat, err := os.Stat(targetDir)
+		if err != nil {
+			fmt.Println(i18n.T("cli.fatal.access_dir", err))
+			os.Exit(1)
+		}
+		if !stat.IsDir() {
+			fmt.Println(i18n.T("cli.fatal.not_dir", targetDir))
+			os.Exit(1)
+		}
 	}
 
 	// 3. 初始化核心检测服务 (使用最新的 internal 模块)
@@ -69,7 +92,7 @@ func main() {
 	countFound := 0
 	var mu sync.Mutex // 保护 countFound 和输出
 
-	fmt.Printf("[INFO] 开始扫描... 并发数: %d\n", workers)
+	fmt.Println(i18n.T("cli.scan.start", workers))
 	fmt.Println("------------------------------------------------")
 
 	// 启动 Worker 池
@@ -85,32 +108,20 @@ func main() {
 
 	// 遍历目录并分发任务
 	go func() {
-		err := filepath.Walk(targetDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				if verbose {
-					fmt.Printf("[DEBUG] 访问错误: %s\n", err)
-				}
-				return nil
+		defer close(fileChan) // 遍历完关闭通道
+		if filesFrom != "" {
+			for _, path := range listed {
+				fileChan <- path
 			}
-			if info.IsDir() {
-				// 跳过隐藏目录
-				if strings.HasPrefix(info.Name(), ".") && len(info.Name()) > 1 {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-			if strings.HasPrefix(info.Name(), ".") {
-				return nil
-			}
-
+			return
+		}
+		err := walkDir(targetDir, func(path string) {
 			// 发送任务
 			fileChan <- path
-			return nil
 		})
 		if err != nil {
 			fmt.Printf("遍历目录出错: %v\n", err)
 		}
-		close(fileChan) // 遍历完关闭通道
 	}()
 
 	// 等待所有任务完成
@@ -121,6 +132,52 @@ func main() {
 	fmt.Printf("发现涉密文件数: %d\n", countFound)
 }
 
+// walkDir 遍历目录，跳过隐藏文件与路径过滤规则排除的路径，对每个待检测文件调用 emit
+func walkDir(root string, emit func(path string)) error {
+	// 手动扫描只跳过伪文件系统，网络文件系统照常扫描 (规则不含 glob，不会出错)
+	filter, _ := pathfilter.New(pathfilter.ManualOptions())
+	return safewalk.Walk(root, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if verbose {
+				fmt.Printf("[DEBUG] 访问错误: %s\n", err)
+			}
+			return nil
+		}
+		if d.IsDir() {
+			// 跳过隐藏目录
+			if strings.HasPrefix(d.Name(), ".") && len(d.Name()) > 1 {
+				return filepath.SkipDir
+			}
+			if path != root && filter.SkipDir(path, pathfilter.Depth(root, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		if filter.SkipFile(path, pathfilter.Depth(root, path)) {
+			return nil
+		}
+		emit(path)
+		return nil
+	})
+}
+
+// collectFiles 展开 --files-from 列表中的一项: 文件直接检测，目录按 -d 的规则遍历
+func collectFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = walkDir(path, func(p string) { files = append(files, p) })
+	return files, err
+}
+
 // processFile 单个文件处理逻辑
 func processFile(det secret_level.Detector, path string, verbose bool, mu *sync.Mutex, count *int) {
 	// 创建带超时的 Context
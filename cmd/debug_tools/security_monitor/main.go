@@ -1,3 +1,5 @@
+//go:build linux
+
 // Package main 提供集成式安全监控调试工具
 // 整合完整性校验和网络连接监控功能，提供统一的监控界面
 package main
@@ -6,7 +8,6 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,9 +17,11 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
+	"linuxFileWatcher/internal/security/baseline"
 	"linuxFileWatcher/internal/security/integrity"
 	"linuxFileWatcher/internal/security/netguard"
 	"linuxFileWatcher/internal/security/netguard/detector"
+	"linuxFileWatcher/internal/security/netguard/seen"
 )
 
 // ==========================================
@@ -34,14 +37,20 @@ var (
 	enableNetguard  bool
 
 	// 完整性校验参数
-	integrityFile     string
+	integrityFiles    []string
 	integrityInterval time.Duration
+	integrityBaseline string
+	integrityPubKey   string
+
+	// 完整性校验基线，启动前加载签名基线或为目标文件建立
+	integrityBaselineFile *baseline.File
 
 	// 网络监控参数
 	netguardPIDs      []int
 	netguardInterval  time.Duration
 	netguardWhitelist []string
 	netguardDryRun    bool
+	netguardDedupTTL  time.Duration
 
 	// 通用参数
 	verboseMode bool
@@ -143,6 +152,14 @@ var rootCmd = &cobra.Command{
   # 仅启动完整性校验
   security-monitor start --enable-integrity --integrity-file /usr/bin/myapp
 
+  # 校验多个文件、目录与 glob 模式
+  security-monitor start --enable-integrity \
+    --integrity-file /usr/bin/myapp --integrity-file /etc/myapp/ --integrity-file '/etc/ssh/*_config'
+
+  # 按 integrity-checker baseline 生成的签名基线校验 (启动前已被篡改的文件同样告警)
+  security-monitor start --enable-integrity \
+    --integrity-baseline baseline.json --integrity-pubkey baseline_sm2.key.pub
+
   # 仅启动网络监控
   security-monitor start --enable-netguard --netguard-pid 1234
 
@@ -150,6 +167,9 @@ var rootCmd = &cobra.Command{
   security-monitor start \
     --enable-integrity --integrity-file /opt/app/server --integrity-interval 30s \
     --enable-netguard --netguard-pid 1234 --netguard-interval 5s --dry-run
+
+  # 每个告警、状态输出一行 JSON，交给 jq 处理
+  security-monitor start --all --dry-run --output json | jq 'select(.event == "alert")'
 `,
 	Version: version,
 }
@@ -165,13 +185,18 @@ var startCmd = &cobra.Command{
 
 至少需要启用一个模块 (--enable-integrity 或 --enable-netguard)，
 或使用 --all 启用所有模块。`,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return checkOutput()
+	},
 	RunE: runStart,
 }
 
 var enableAll bool
 
 func runStart(cmd *cobra.Command, args []string) error {
-	printBanner()
+	if !jsonMode() {
+		printBanner()
+	}
 
 	// 处理 --all 参数
 	if enableAll {
@@ -181,6 +206,9 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	// 验证至少启用一个模块
 	if !enableIntegrity && !enableNetguard {
+		if jsonMode() {
+			return fmt.Errorf("no module enabled")
+		}
 		colorRed.Println("❌ 错误: 至少需要启用一个监控模块")
 		fmt.Println()
 		colorYellow.Println("使用以下参数启用模块:")
@@ -190,12 +218,26 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no module enabled")
 	}
 
-	// 显示配置摘要
-	printConfig()
+	// 加载签名基线或建立基线: 签名无效或目标无效时不启动
+	if enableIntegrity {
+		f, err := loadIntegrityBaseline()
+		if err != nil {
+			return err
+		}
+		integrityBaselineFile = f
+		initIntegrityStates(f)
+	}
 
 	// 初始化统计
 	stats.StartTime = time.Now()
 
+	// 显示配置摘要
+	if jsonMode() {
+		emit(newStartEvent())
+	} else {
+		printConfig()
+	}
+
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -228,14 +270,18 @@ func runStart(cmd *cobra.Command, args []string) error {
 		go statusPrinter(stopChan)
 	}
 
-	printSeparator()
-	colorMagenta.Println("🚀 安全监控已启动 (按 Ctrl+C 停止)")
-	fmt.Println()
+	if !jsonMode() {
+		printSeparator()
+		colorMagenta.Println("🚀 安全监控已启动 (按 Ctrl+C 停止)")
+		fmt.Println()
+	}
 
 	// 等待停止信号
 	<-sigChan
-	fmt.Println()
-	colorYellow.Println("🛑 收到停止信号，正在关闭...")
+	if !jsonMode() {
+		fmt.Println()
+		colorYellow.Println("🛑 收到停止信号，正在关闭...")
+	}
 
 	// 通知所有 goroutine 停止
 	close(stopChan)
@@ -252,10 +298,14 @@ func runStart(cmd *cobra.Command, args []string) error {
 	case <-done:
 		// 正常结束
 	case <-time.After(5 * time.Second):
-		colorYellow.Println("⚠️  部分模块未能及时停止")
+		fmt.Fprintln(os.Stderr, "⚠️  部分模块未能及时停止")
 	}
 
 	// 打印最终统计
+	if jsonMode() {
+		emit(newStatsEvent("stop", true))
+		return nil
+	}
 	printFinalStats()
 
 	colorGreen.Println("👋 安全监控已停止")
@@ -268,56 +318,22 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 func runIntegrityMonitor(stopChan <-chan struct{}) {
 	moduleName := "Integrity"
-
-	// 解析目标文件
-	targetFile := integrityFile
-	if targetFile == "" {
-		// 默认监控自身
-		var err error
-		targetFile, err = integrity.GetSelfExecutablePath()
-		if err != nil {
-			sendAlert(Alert{
-				Type:      AlertIntegrity,
-				Timestamp: time.Now(),
-				Module:    moduleName,
-				Level:     "CRITICAL",
-				Title:     "模块初始化失败",
-				Details:   map[string]string{"error": err.Error()},
-			})
-			return
-		}
-	} else {
-		var err error
-		targetFile, err = filepath.Abs(targetFile)
-		if err != nil {
-			sendAlert(Alert{
-				Type:      AlertIntegrity,
-				Timestamp: time.Now(),
-				Module:    moduleName,
-				Level:     "CRITICAL",
-				Title:     "无效的文件路径",
-				Details:   map[string]string{"error": err.Error()},
-			})
-			return
+	f := integrityBaselineFile
+
+	if verboseMode && !jsonMode() {
+		if integrityBaseline != "" {
+			colorGreen.Printf("[%s] 已加载签名基线: %s (%d 个文件, 生成于 %s)\n",
+				moduleName, integrityBaseline, len(f.Entries), f.GeneratedAt)
+		} else if len(f.Entries) == 1 {
+			colorGreen.Printf("[%s] 基线已建立: %s\n", moduleName, f.Entries[0].Hash[:32]+"...")
+		} else {
+			colorGreen.Printf("[%s] 基线已建立: %d 个文件\n", moduleName, len(f.Entries))
 		}
 	}
 
-	// 计算基线哈希
-	baselineHash, err := integrity.ComputeFileSM3(targetFile)
-	if err != nil {
-		sendAlert(Alert{
-			Type:      AlertIntegrity,
-			Timestamp: time.Now(),
-			Module:    moduleName,
-			Level:     "CRITICAL",
-			Title:     "无法建立基线",
-			Details:   map[string]string{"file": targetFile, "error": err.Error()},
-		})
-		return
-	}
-
-	if verboseMode {
-		colorGreen.Printf("[%s] 基线已建立: %s\n", moduleName, baselineHash[:32]+"...")
+	// 签名基线在部署时生成，启动时立即对比一次，启动前已被篡改的文件同样告警
+	if integrityBaseline != "" {
+		checkIntegrity(f, moduleName)
 	}
 
 	// 启动监控循环
@@ -329,69 +345,148 @@ func runIntegrityMonitor(stopChan <-chan struct{}) {
 		case <-stopChan:
 			return
 		case <-ticker.C:
-			checkIntegrity(targetFile, baselineHash, moduleName)
+			checkIntegrity(f, moduleName)
 		}
 	}
 }
 
-func checkIntegrity(targetFile, baselineHash, moduleName string) {
-	atomic.AddInt64(&stats.IntegrityChecks, 1)
+// loadIntegrityBaseline 加载签名基线并用可信公钥校验签名；未指定基线时为目标文件建立基线
+func loadIntegrityBaseline() (*baseline.File, error) {
+	if integrityBaseline == "" {
+		targets := integrityFiles
+		if len(targets) == 0 {
+			// 默认监控自身
+			self, err := integrity.GetSelfExecutablePath()
+			if err != nil {
+				return nil, fmt.Errorf("无法获取自身路径: %v", err)
+			}
+			targets = []string{self}
+		}
+		f, err := baseline.Generate(targets)
+		if err != nil {
+			return nil, fmt.Errorf("无法建立完整性基线: %v", err)
+		}
+		if len(f.Entries) == 0 {
+			return nil, fmt.Errorf("完整性校验目标中没有文件")
+		}
+		return f, nil
+	}
 
-	// 检查文件是否存在
-	_, err := os.Stat(targetFile)
+	if len(integrityFiles) > 0 {
+		return nil, fmt.Errorf("--integrity-file 与 --integrity-baseline 不能同时使用")
+	}
+	if integrityPubKey == "" {
+		return nil, fmt.Errorf("使用 --integrity-baseline 需要 --integrity-pubkey 指定可信公钥 (十六进制或公钥文件)")
+	}
+	pub, err := baseline.LoadPublicKey(integrityPubKey)
+	if err != nil {
+		return nil, err
+	}
+	f, err := baseline.LoadVerified(integrityBaseline, pub)
 	if err != nil {
-		atomic.AddInt64(&stats.IntegrityAlerts, 1)
+		return nil, fmt.Errorf("基线文件 %s 校验失败: %v", integrityBaseline, err)
+	}
+	if len(f.Entries) == 0 {
+		return nil, fmt.Errorf("基线文件 %s 不包含任何文件", integrityBaseline)
+	}
+	return f, nil
+}
 
-		alertTitle := "文件访问异常"
-		if os.IsNotExist(err) {
-			alertTitle = "文件已被删除"
-		}
+// fileStatus 单个文件的完整性状态
+type fileStatus struct {
+	Path   string
+	Status baseline.Status
+	Since  time.Time // 进入当前状态的时间
+	Alerts int
+	Detail string
+}
 
-		sendAlert(Alert{
-			Type:      AlertIntegrity,
-			Timestamp: time.Now(),
-			Module:    moduleName,
-			Level:     "CRITICAL",
-			Title:     alertTitle,
-			Details: map[string]string{
-				"file":  targetFile,
-				"error": err.Error(),
-			},
-		})
-		return
+var (
+	integrityMu sync.Mutex
+	// 各文件的最新状态，按基线顺序
+	integrityStates []*fileStatus
+)
+
+func initIntegrityStates(f *baseline.File) {
+	now := time.Now()
+	integrityMu.Lock()
+	defer integrityMu.Unlock()
+	integrityStates = make([]*fileStatus, len(f.Entries))
+	for i, e := range f.Entries {
+		integrityStates[i] = &fileStatus{Path: e.Path, Status: baseline.StatusOK, Since: now}
 	}
+}
 
-	// 计算当前哈希
-	currentHash, err := integrity.ComputeFileSM3(targetFile)
-	if err != nil {
-		atomic.AddInt64(&stats.IntegrityAlerts, 1)
-		sendAlert(Alert{
-			Type:      AlertIntegrity,
-			Timestamp: time.Now(),
-			Module:    moduleName,
-			Level:     "WARN",
-			Title:     "哈希计算失败",
-			Details:   map[string]string{"file": targetFile, "error": err.Error()},
-		})
-		return
+// snapshotIntegrityStates 返回各文件状态的副本
+func snapshotIntegrityStates() []fileStatus {
+	integrityMu.Lock()
+	defer integrityMu.Unlock()
+	out := make([]fileStatus, len(integrityStates))
+	for i, st := range integrityStates {
+		out[i] = *st
 	}
+	return out
+}
+
+// checkIntegrity 对比全部文件与基线，文件状态变化时告警，状态不变时不重复告警
+func checkIntegrity(f *baseline.File, moduleName string) {
+	atomic.AddInt64(&stats.IntegrityChecks, 1)
 
-	// 对比基线
-	if currentHash != baselineHash {
-		atomic.AddInt64(&stats.IntegrityAlerts, 1)
-		sendAlert(Alert{
+	results := f.Compare()
+
+	integrityMu.Lock()
+	var alerts []Alert
+	for i, r := range results {
+		st := integrityStates[i]
+		if r.Status == st.Status {
+			continue
+		}
+		prev := st.Status
+		st.Status, st.Since, st.Detail = r.Status, time.Now(), r.Detail
+
+		level := "CRITICAL"
+		var title string
+		details := map[string]string{"file": r.Path}
+
+		switch r.Status {
+		case baseline.StatusOK:
+			level = "INFO"
+			title = "文件已恢复"
+			details["previous"] = string(prev)
+		case baseline.StatusModified:
+			title = "文件内容已被篡改"
+			details["baselineHash"] = r.Expected
+			details["currentHash"] = r.Actual
+		case baseline.StatusMetadata:
+			level = "WARN"
+			title = "文件权限已变化"
+			details["mode"] = r.Detail
+		default:
+			title = "文件访问异常"
+			if _, err := os.Stat(r.Path); os.IsNotExist(err) {
+				title = "文件已被删除"
+			}
+			details["error"] = r.Detail
+		}
+
+		if r.Status != baseline.StatusOK {
+			st.Alerts++
+			atomic.AddInt64(&stats.IntegrityAlerts, 1)
+		}
+		alerts = append(alerts, Alert{
 			Type:      AlertIntegrity,
 			Timestamp: time.Now(),
 			Module:    moduleName,
-			Level:     "CRITICAL",
-			Title:     "文件内容已被篡改",
-			Details: map[string]string{
-				"file":         targetFile,
-				"baselineHash": baselineHash,
-				"currentHash":  currentHash,
-			},
+			Level:     level,
+			Title:     title,
+			Details:   details,
 		})
 	}
+	integrityMu.Unlock()
+
+	for _, a := range alerts {
+		sendAlert(a)
+	}
 }
 
 // ==========================================
@@ -420,9 +515,9 @@ func runNetguardMonitor(stopChan <-chan struct{}) {
 
 	whitelistMgr := netguard.NewWhitelistManager(initialWhitelist)
 	scanner := detector.NewScanner(pids)
-	blockedIPs := make(map[string]bool)
+	blockedIPs := seen.New(seen.Config{TTL: netguardDedupTTL})
 
-	if verboseMode {
+	if verboseMode && !jsonMode() {
 		colorGreen.Printf("[%s] 监控 PID: %v, 白名单: %v\n", moduleName, pids, initialWhitelist)
 	}
 
@@ -441,13 +536,13 @@ func runNetguardMonitor(stopChan <-chan struct{}) {
 }
 
 func scanNetwork(scanner *detector.NetworkScanner, whitelist *netguard.WhitelistManager,
-	blockedIPs map[string]bool, moduleName string) {
+	blockedIPs *seen.Cache, moduleName string) {
 
 	atomic.AddInt64(&stats.NetguardScans, 1)
 
 	connections, err := scanner.Scan()
 	if err != nil {
-		if verboseMode {
+		if verboseMode && !jsonMode() {
 			colorRed.Printf("[%s] 扫描失败: %v\n", moduleName, err)
 		}
 		return
@@ -463,12 +558,11 @@ func scanNetwork(scanner *detector.NetworkScanner, whitelist *netguard.Whitelist
 
 		// 检查白名单
 		if !whitelist.IsAllowed(conn.RemoteIP) {
-			// 去重检查
-			if blockedIPs[conn.RemoteIP] {
+			// 去重检查: 有效期内已告警的 IP 不重复上报
+			if !blockedIPs.Alert(conn.RemoteIP, time.Now()) {
 				continue
 			}
 
-			blockedIPs[conn.RemoteIP] = true
 			stats.NetguardBlockedIPs.Store(conn.RemoteIP, true)
 			atomic.AddInt64(&stats.NetguardAlerts, 1)
 
@@ -518,6 +612,10 @@ func sendAlert(alert Alert) {
 
 func alertHandler() {
 	for alert := range alertChan {
+		if jsonMode() {
+			emit(newAlertEvent(alert))
+			continue
+		}
 		printAlert(alert)
 	}
 }
@@ -574,7 +672,10 @@ func statusPrinter(stopChan <-chan struct{}) {
 		case <-stopChan:
 			return
 		case <-ticker.C:
-			if !quietMode && verboseMode {
+			// JSON 模式下状态事件不依赖详细模式，便于脚本跟踪运行情况
+			if jsonMode() {
+				emit(newStatsEvent("status", false))
+			} else if !quietMode && verboseMode {
 				printStatus()
 			}
 		}
@@ -594,9 +695,11 @@ func printStatus() {
 	colorBlue.Printf("\n📊 [状态更新] 运行时长: %v\n", elapsed)
 
 	if enableIntegrity {
-		colorWhite.Printf("   Integrity: 检查 %d 次, 告警 %d 次\n",
+		files := snapshotIntegrityStates()
+		colorWhite.Printf("   Integrity: 检查 %d 次, 告警 %d 次, 文件 %d 个, 异常 %d 个\n",
 			atomic.LoadInt64(&stats.IntegrityChecks),
-			atomic.LoadInt64(&stats.IntegrityAlerts))
+			atomic.LoadInt64(&stats.IntegrityAlerts),
+			len(files), countAbnormal(files))
 	}
 
 	if enableNetguard {
@@ -631,6 +734,7 @@ func printFinalStats() {
 		colorWhite.Println("   【完整性校验】")
 		colorWhite.Printf("      检查次数: %d\n", atomic.LoadInt64(&stats.IntegrityChecks))
 		colorWhite.Printf("      告警次数: %d\n", atomic.LoadInt64(&stats.IntegrityAlerts))
+		printIntegrityTable(snapshotIntegrityStates())
 		fmt.Println()
 	}
 
@@ -648,6 +752,53 @@ func printFinalStats() {
 	printSeparator()
 }
 
+// countAbnormal 与基线不一致的文件数
+func countAbnormal(files []fileStatus) int {
+	n := 0
+	for _, st := range files {
+		if st.Status != baseline.StatusOK {
+			n++
+		}
+	}
+	return n
+}
+
+// printIntegrityTable 打印各文件的状态汇总，异常或告警过的文件排在前面；
+// 文件较多时正常且未告警过的文件只计数
+func printIntegrityTable(files []fileStatus) {
+	abnormal := countAbnormal(files)
+	colorWhite.Printf("      文件数量: %d (正常 %d, 异常 %d)\n", len(files), len(files)-abnormal, abnormal)
+
+	var rows []fileStatus
+	for _, notable := range []bool{true, false} {
+		for _, st := range files {
+			if (st.Status != baseline.StatusOK || st.Alerts > 0) != notable {
+				continue
+			}
+			if !notable && len(files) > 20 && !verboseMode {
+				continue
+			}
+			rows = append(rows, st)
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	fmt.Println()
+	colorWhite.Printf("      %-10s %-6s %-20s %s\n", "状态", "告警", "状态开始时间", "文件")
+	for _, st := range rows {
+		c := colorRed
+		switch st.Status {
+		case baseline.StatusOK:
+			c = colorGreen
+		case baseline.StatusMetadata:
+			c = colorYellow
+		}
+		c.Printf("      %-10s %-6d %-20s %s\n", st.Status, st.Alerts, st.Since.Format("2006-01-02 15:04:05"), st.Path)
+	}
+}
+
 // ==========================================
 // config 命令 - 配置管理
 // ==========================================
@@ -671,8 +822,10 @@ func runConfig(cmd *cobra.Command, args []string) error {
   --enable-netguard     启用网络监控模块
 
 【完整性校验参数】
-  --integrity-file      监控的目标文件路径 (默认: 程序自身)
+  --integrity-file      监控的目标文件、目录或 glob 模式，可多次指定 (默认: 程序自身)
   --integrity-interval  检查间隔 (默认: 30s)
+  --integrity-baseline  签名基线文件 (integrity-checker baseline -o 生成)，校验其中全部文件
+  --integrity-pubkey    校验基线签名的可信 SM2 公钥 (十六进制或公钥文件)
 
 【网络监控参数】
   --netguard-pid        监控的目标进程 PID，可多次指定 (默认: 自身)
@@ -683,6 +836,7 @@ func runConfig(cmd *cobra.Command, args []string) error {
 【通用参数】
   --verbose, -v         详细输出模式
   --quiet, -q           静默模式，仅输出告警
+  --output, -o          输出格式: text / json (每个事件一行 JSON)
 `)
 
 	printSeparator()
@@ -753,11 +907,15 @@ func printConfig() {
 	}
 	colorWhite.Printf("   【完整性校验】 %s\n", integrityStatus)
 	if enableIntegrity {
-		targetDisplay := integrityFile
-		if targetDisplay == "" {
-			targetDisplay = "(程序自身)"
+		if f := integrityBaselineFile; f != nil {
+			colorWhite.Printf("      基线文件: %s (%d 个文件, 生成于 %s, 签名有效)\n", integrityBaseline, len(f.Entries), f.GeneratedAt)
+		} else {
+			targetDisplay := strings.Join(integrityFiles, ", ")
+			if len(integrityFiles) == 0 {
+				targetDisplay = "(程序自身)"
+			}
+			colorWhite.Printf("      目标文件: %s (%d 个文件)\n", targetDisplay, len(integrityBaselineFile.Entries))
 		}
-		colorWhite.Printf("      目标文件: %s\n", targetDisplay)
 		colorWhite.Printf("      检查间隔: %v\n", integrityInterval)
 	}
 	fmt.Println()
@@ -775,6 +933,7 @@ func printConfig() {
 		}
 		colorWhite.Printf("      目标 PID: %s\n", pidDisplay)
 		colorWhite.Printf("      扫描间隔: %v\n", netguardInterval)
+		colorWhite.Printf("      告警去重: %v\n", netguardDedupTTL)
 
 		whitelist := append([]string{"127.0.0.1", "::1"}, netguardWhitelist...)
 		colorWhite.Printf("      白名单:   %v\n", whitelist)
@@ -808,18 +967,22 @@ func init() {
 	startCmd.Flags().BoolVar(&enableNetguard, "enable-netguard", false, "启用网络监控模块")
 
 	// 完整性校验参数
-	startCmd.Flags().StringVar(&integrityFile, "integrity-file", "", "完整性校验目标文件 (默认: 程序自身)")
+	startCmd.Flags().StringArrayVar(&integrityFiles, "integrity-file", nil, "完整性校验目标文件、目录或 glob 模式，可多次指定 (默认: 程序自身)")
 	startCmd.Flags().DurationVar(&integrityInterval, "integrity-interval", 30*time.Second, "完整性检查间隔")
+	startCmd.Flags().StringVar(&integrityBaseline, "integrity-baseline", "", "签名基线文件 (integrity-checker baseline -o 生成)，按基线校验其中全部文件")
+	startCmd.Flags().StringVar(&integrityPubKey, "integrity-pubkey", "", "校验基线签名的可信 SM2 公钥 (十六进制或公钥文件)")
 
 	// 网络监控参数
 	startCmd.Flags().IntSliceVar(&netguardPIDs, "netguard-pid", nil, "网络监控目标 PID (可多次指定)")
 	startCmd.Flags().DurationVar(&netguardInterval, "netguard-interval", 5*time.Second, "网络扫描间隔")
 	startCmd.Flags().StringSliceVar(&netguardWhitelist, "netguard-whitelist", nil, "网络白名单 IP/CIDR (可多次指定)")
 	startCmd.Flags().BoolVar(&netguardDryRun, "dry-run", false, "仅检测，不执行封禁")
+	startCmd.Flags().DurationVar(&netguardDedupTTL, "netguard-dedup-ttl", seen.DefaultTTL, "同一 IP 的告警去重时间，过期后再次告警")
 
 	// 通用参数
 	startCmd.Flags().BoolVarP(&verboseMode, "verbose", "v", false, "详细输出模式")
 	startCmd.Flags().BoolVarP(&quietMode, "quiet", "q", false, "静默模式")
+	startCmd.Flags().StringVarP(&outputFormat, "output", "o", outputText, "输出格式: text / json (每个事件一行 JSON)")
 
 	// 注册命令
 	rootCmd.AddCommand(startCmd)
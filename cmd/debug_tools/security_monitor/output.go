@@ -0,0 +1,228 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/security/baseline"
+)
+
+// ==========================================
+// JSON 输出 (--output json)
+// ==========================================
+//
+// 每个事件输出一行 JSON 对象 (NDJSON)，可直接交给 jq 或测试脚本处理，所有事件都带 event 与 time 字段:
+//   start  启动配置
+//   alert  安全告警
+//   status 周期状态 (每 30 秒，静默模式不输出)
+//   stop   最终统计
+// JSON 模式下标准输出只有事件，横幅与提示不输出，错误仍输出到标准错误。
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	// 输出格式: text / json
+	outputFormat string
+
+	emitMu  sync.Mutex
+	emitEnc = newEncoder()
+)
+
+func newEncoder() *json.Encoder {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+// jsonMode 是否按 JSON 输出
+func jsonMode() bool {
+	return outputFormat == outputJSON
+}
+
+// checkOutput 校验 --output 参数
+func checkOutput() error {
+	switch outputFormat {
+	case outputText, outputJSON:
+		return nil
+	}
+	return fmt.Errorf("无效的输出格式 %q，可选: text、json", outputFormat)
+}
+
+// emit 输出一个事件，可在多个 goroutine 中调用
+func emit(v interface{}) {
+	emitMu.Lock()
+	defer emitMu.Unlock()
+	if err := emitEnc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "输出事件失败: %v\n", err)
+	}
+}
+
+// eventHeader 所有事件的公共字段
+type eventHeader struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+}
+
+func header(name string) eventHeader {
+	return eventHeader{Event: name, Time: time.Now()}
+}
+
+// integrityConfig 完整性校验模块配置，未启用时不输出
+type integrityConfig struct {
+	Targets  []string `json:"targets,omitempty"` // 为空且未指定基线时表示程序自身
+	Baseline string   `json:"baseline,omitempty"`
+	Files    int      `json:"files"` // 基线中的文件数
+	Interval string   `json:"interval"`
+}
+
+// netguardConfig 网络监控模块配置，未启用时不输出
+type netguardConfig struct {
+	PIDs      []int    `json:"pids"`
+	Interval  string   `json:"interval"`
+	DedupTTL  string   `json:"dedup_ttl"`
+	Whitelist []string `json:"whitelist"`
+	DryRun    bool     `json:"dry_run"`
+}
+
+// startEvent 启动配置
+type startEvent struct {
+	eventHeader
+	Version   string           `json:"version"`
+	PID       int              `json:"pid"`
+	Integrity *integrityConfig `json:"integrity,omitempty"`
+	Netguard  *netguardConfig  `json:"netguard,omitempty"`
+}
+
+func newStartEvent() startEvent {
+	ev := startEvent{eventHeader: header("start"), Version: version, PID: os.Getpid()}
+	if enableIntegrity {
+		ev.Integrity = &integrityConfig{
+			Targets:  integrityFiles,
+			Baseline: integrityBaseline,
+			Files:    len(integrityBaselineFile.Entries),
+			Interval: integrityInterval.String(),
+		}
+	}
+	if enableNetguard {
+		pids := netguardPIDs
+		if len(pids) == 0 {
+			pids = []int{os.Getpid()}
+		}
+		ev.Netguard = &netguardConfig{
+			PIDs:      pids,
+			Interval:  netguardInterval.String(),
+			DedupTTL:  netguardDedupTTL.String(),
+			Whitelist: append([]string{"127.0.0.1", "::1"}, netguardWhitelist...),
+			DryRun:    netguardDryRun,
+		}
+	}
+	return ev
+}
+
+// alertEvent 安全告警，type 为 INTEGRITY 或 NETWORK
+type alertEvent struct {
+	eventHeader
+	Type    AlertType         `json:"type"`
+	Module  string            `json:"module"`
+	Level   string            `json:"level"`
+	Title   string            `json:"title"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+func newAlertEvent(alert Alert) alertEvent {
+	return alertEvent{
+		eventHeader: eventHeader{Event: "alert", Time: alert.Timestamp},
+		Type:        alert.Type,
+		Module:      alert.Module,
+		Level:       alert.Level,
+		Title:       alert.Title,
+		Details:     alert.Details,
+	}
+}
+
+// integrityStats 完整性校验统计
+type integrityStats struct {
+	Checks   int64      `json:"checks"`
+	Alerts   int64      `json:"alerts"`
+	Files    int        `json:"files"`
+	Abnormal int        `json:"abnormal"`
+	Status   []fileJSON `json:"status,omitempty"` // 只在 stop 事件中输出
+}
+
+// fileJSON 单个文件的完整性状态
+type fileJSON struct {
+	Path   string          `json:"path"`
+	Status baseline.Status `json:"status"`
+	Since  time.Time       `json:"since"`
+	Alerts int             `json:"alerts"`
+	Detail string          `json:"detail,omitempty"`
+}
+
+// netguardStats 网络监控统计
+type netguardStats struct {
+	Scans       int64    `json:"scans"`
+	Connections int64    `json:"connections"`
+	Alerts      int64    `json:"alerts"`
+	BlockedIPs  int      `json:"blocked_ips"`
+	BlockedList []string `json:"blocked_list,omitempty"` // 只在 stop 事件中输出
+}
+
+// statsEvent 周期状态 (status) 与最终统计 (stop)
+type statsEvent struct {
+	eventHeader
+	UptimeSeconds int64           `json:"uptime_seconds"`
+	Integrity     *integrityStats `json:"integrity,omitempty"`
+	Netguard      *netguardStats  `json:"netguard,omitempty"`
+}
+
+// newStatsEvent 汇总当前统计，withList 为 true 时附带各文件状态与封禁 IP 列表
+func newStatsEvent(name string, withList bool) statsEvent {
+	ev := statsEvent{
+		eventHeader:   header(name),
+		UptimeSeconds: int64(time.Since(stats.StartTime).Seconds()),
+	}
+	if enableIntegrity {
+		files := snapshotIntegrityStates()
+		ev.Integrity = &integrityStats{
+			Checks:   atomic.LoadInt64(&stats.IntegrityChecks),
+			Alerts:   atomic.LoadInt64(&stats.IntegrityAlerts),
+			Files:    len(files),
+			Abnormal: countAbnormal(files),
+		}
+		if withList {
+			for _, st := range files {
+				ev.Integrity.Status = append(ev.Integrity.Status, fileJSON{
+					Path: st.Path, Status: st.Status, Since: st.Since, Alerts: st.Alerts, Detail: st.Detail,
+				})
+			}
+		}
+	}
+	if enableNetguard {
+		var blocked []string
+		stats.NetguardBlockedIPs.Range(func(key, _ interface{}) bool {
+			blocked = append(blocked, key.(string))
+			return true
+		})
+		sort.Strings(blocked)
+		ev.Netguard = &netguardStats{
+			Scans:       atomic.LoadInt64(&stats.NetguardScans),
+			Connections: atomic.LoadInt64(&stats.NetguardConnections),
+			Alerts:      atomic.LoadInt64(&stats.NetguardAlerts),
+			BlockedIPs:  len(blocked),
+		}
+		if withList {
+			ev.Netguard.BlockedList = blocked
+		}
+	}
+	return ev
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"linuxFileWatcher/cmd/debug_tools/internal/bench"
+	"linuxFileWatcher/internal/detector/electronic_secret"
+)
+
+// runBench 按已加载的规则重复扫描文件，输出性能基准结果，返回退出码: 0 完成，1 输出失败
+func runBench(detector electronic_secret.DetectorWithRules, files []string) int {
+	result := bench.Run(toolName, toolVersion, files, bench.Options{
+		Warmup:  benchWarmup,
+		Rounds:  benchRounds,
+		Workers: workers,
+	}, func(path string) error {
+		if r := scanFile(detector, path); r.Error != "" {
+			return errors.New(r.Error)
+		}
+		return nil
+	})
+
+	if err := result.Write(outputFormat, outputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "输出基准结果失败: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/electronic_secret"
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 标识注入 (inject 子命令)
+// ==========================================
+//
+// 将规则中的流式标识写入文件，生成可验证的命中样本，用于端到端测试:
+//   append       追加到文件末尾
+//   offset       在 --offset 处插入，原有内容后移
+//   zip-comment  写入 ZIP 注释，压缩包条目不变
+//   ooxml        以不压缩的自定义部件写入 OOXML 文档 (docx/xlsx/pptx)
+// 注入后校验输出文件中包含完整标识，指定 --check 时再用检测器扫描一次。
+
+// markerSize 流式标识的标准长度
+const markerSize = 256
+
+// ooxmlMarkerPart OOXML 中保存标识的部件
+const ooxmlMarkerPart = "customXml/streamMarker.bin"
+
+var (
+	injectMode    string // 注入方式
+	injectOffset  int64  // offset 方式的插入位置
+	injectOutput  string // 输出文件（单个输入时）
+	injectOutDir  string // 输出目录
+	injectInPlace bool   // 直接修改原文件
+	injectCheck   bool   // 注入后用检测器扫描输出文件
+)
+
+// runInject 执行 inject 子命令，返回退出码: 0 全部成功，1 有失败
+func runInject(args []string) int {
+	fs := flag.NewFlagSet(toolName+" inject", flag.ContinueOnError)
+	fs.StringVar(&rulesFile, "rules", "", "规则文件路径（JSON格式）")
+	fs.StringVar(&rulesFile, "f", "", "规则文件路径（简写）")
+	fs.StringVar(&ruleHex, "hex", "", "标识的十六进制内容")
+	fs.StringVar(&ruleBase64, "base64", "", "标识的Base64内容")
+	fs.Int64Var(&ruleID, "rule-id", 1, "规则ID，使用规则文件时选取该规则")
+	fs.BoolVar(&verifyRules, "verify", false, "校验规则文件签名，签名无效时拒绝加载")
+	fs.StringVar(&rulesPubKey, "pubkey", "", "规则签名公钥（十六进制或文件路径）")
+	fs.StringVar(&injectMode, "mode", "append", "注入方式：append, offset, zip-comment, ooxml")
+	fs.Int64Var(&injectOffset, "offset", 0, "offset 方式的插入位置（字节）")
+	fs.StringVar(&injectOutput, "output", "", "输出文件路径（仅单个输入文件）")
+	fs.StringVar(&injectOutput, "o", "", "输出文件路径（简写）")
+	fs.StringVar(&injectOutDir, "out-dir", "", "输出目录")
+	fs.BoolVar(&injectInPlace, "in-place", false, "直接修改原文件")
+	fs.BoolVar(&injectCheck, "check", false, "注入后使用检测器扫描输出文件")
+	fs.BoolVar(&verbose, "verbose", false, "详细输出模式")
+	fs.BoolVar(&verbose, "v", false, "详细输出模式（简写）")
+	fs.Usage = printInjectHelp
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	ruleIDSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "rule-id" {
+			ruleIDSet = true
+		}
+	})
+
+	files := fs.Args()
+	if err := validateInjectArgs(files); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		fmt.Fprintf(os.Stderr, "使用 %s inject -h 查看帮助信息\n", toolName)
+		return 1
+	}
+
+	rule, err := selectInjectRule(ruleIDSet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载规则失败: %v\n", err)
+		return 1
+	}
+	if len(rule.RuleContent) != markerSize {
+		fmt.Fprintf(os.Stderr, "警告: 标识长度为 %d 字节，标准流式标识为 %d 字节\n", len(rule.RuleContent), markerSize)
+	}
+
+	var detector electronic_secret.DetectorWithRules
+	if injectCheck {
+		detector = createDetector()
+		if err := detector.SetRules([]model.StreamMarkerDetectRule{rule}); err != nil {
+			fmt.Fprintf(os.Stderr, "设置规则失败: %v\n", err)
+			return 1
+		}
+	}
+
+	failed := 0
+	for _, src := range files {
+		dst := injectTarget(src)
+		if err := injectFile(src, dst, rule.RuleContent); err != nil {
+			fmt.Printf("✗ %s: %v\n", src, err)
+			failed++
+			continue
+		}
+		if detector != nil {
+			r := scanFile(detector, dst)
+			if r.Error != "" || !r.Detected {
+				fmt.Printf("✗ %s -> %s: 已注入，但检测器未命中 %s\n", src, dst, r.Error)
+				failed++
+				continue
+			}
+		}
+		fmt.Printf("✓ %s -> %s (%s, 规则 %d)\n", src, dst, injectMode, rule.RuleID)
+	}
+
+	if len(files) > 1 {
+		fmt.Printf("\n注入 %d 个文件: 成功 %d，失败 %d\n", len(files), len(files)-failed, failed)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func validateInjectArgs(files []string) error {
+	if len(files) == 0 {
+		return fmt.Errorf("必须指定要注入的文件")
+	}
+	if rulesFile == "" && ruleHex == "" && ruleBase64 == "" {
+		return fmt.Errorf("必须指定标识：使用 -f/--rules 指定规则文件，或使用 --hex/--base64 指定标识内容")
+	}
+	if verifyRules && rulesPubKey == "" {
+		return fmt.Errorf("--verify 需要使用 --pubkey 指定签名公钥")
+	}
+	switch injectMode {
+	case "append", "zip-comment", "ooxml":
+	case "offset":
+		if injectOffset < 0 {
+			return fmt.Errorf("--offset 不能为负数")
+		}
+	default:
+		return fmt.Errorf("不支持的注入方式: %s（支持: append, offset, zip-comment, ooxml）", injectMode)
+	}
+	outputs := 0
+	for _, set := range []bool{injectOutput != "", injectOutDir != "", injectInPlace} {
+		if set {
+			outputs++
+		}
+	}
+	if outputs > 1 {
+		return fmt.Errorf("-o、--out-dir 与 --in-place 只能指定一个")
+	}
+	if injectOutput != "" && len(files) > 1 {
+		return fmt.Errorf("-o 只能用于单个输入文件，多个文件请使用 --out-dir")
+	}
+	return nil
+}
+
+// selectInjectRule 选取要注入的标识
+// 规则文件中有多条规则时按 --rule-id 选取，未指定时使用第一条
+func selectInjectRule(ruleIDSet bool) (model.StreamMarkerDetectRule, error) {
+	rules, err := loadRules()
+	if err != nil {
+		return model.StreamMarkerDetectRule{}, err
+	}
+	if len(rules) == 0 {
+		return model.StreamMarkerDetectRule{}, fmt.Errorf("没有有效的规则")
+	}
+	if !ruleIDSet {
+		return rules[0], nil
+	}
+	for _, r := range rules {
+		if r.RuleID == ruleID {
+			return r, nil
+		}
+	}
+	return model.StreamMarkerDetectRule{}, fmt.Errorf("没有 ID 为 %d 的规则", ruleID)
+}
+
+// injectTarget 输出路径，默认在原文件名的扩展名前加 .marked，如 a.docx -> a.marked.docx
+func injectTarget(src string) string {
+	switch {
+	case injectInPlace:
+		return src
+	case injectOutput != "":
+		return injectOutput
+	}
+	ext := filepath.Ext(src)
+	name := strings.TrimSuffix(filepath.Base(src), ext) + ".marked" + ext
+	if injectOutDir != "" {
+		return filepath.Join(injectOutDir, name)
+	}
+	return filepath.Join(filepath.Dir(src), name)
+}
+
+// injectFile 按 --mode 注入标识并写出，写出前校验结果中包含完整标识
+func injectFile(src, dst string, marker []byte) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("不是普通文件")
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	switch injectMode {
+	case "append":
+		out = append(data, marker...)
+	case "offset":
+		if injectOffset > int64(len(data)) {
+			return fmt.Errorf("插入位置 %d 超出文件大小 %d", injectOffset, len(data))
+		}
+		out = make([]byte, 0, len(data)+len(marker))
+		out = append(out, data[:injectOffset]...)
+		out = append(out, marker...)
+		out = append(out, data[injectOffset:]...)
+	case "zip-comment":
+		out, err = setZipComment(data, marker)
+	case "ooxml":
+		out, err = addOOXMLPart(data, marker)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Contains(out, marker) {
+		return fmt.Errorf("注入后未找到完整标识")
+	}
+
+	if injectOutDir != "" {
+		if err := os.MkdirAll(injectOutDir, 0755); err != nil {
+			return err
+		}
+	}
+	// 先写临时文件再替换，--in-place 失败时不破坏原文件
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".inject-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// eocdSize ZIP 中央目录结束记录的固定长度（不含注释）
+const eocdSize = 22
+
+// setZipComment 替换 ZIP 注释，中央目录与各条目保持原样
+// 注释长度字段为 16 位，标识不能超过 65535 字节
+func setZipComment(data, comment []byte) ([]byte, error) {
+	if len(comment) > 0xFFFF {
+		return nil, fmt.Errorf("标识过长，ZIP 注释最多 65535 字节")
+	}
+	// 结束记录位于文件末尾，之后只有注释，注释最长 65535 字节
+	start := len(data) - eocdSize - 0xFFFF
+	if start < 0 {
+		start = 0
+	}
+	eocd := -1
+	for i := len(data) - eocdSize; i >= start; i-- {
+		if binary.LittleEndian.Uint32(data[i:]) == 0x06054b50 &&
+			i+eocdSize+int(binary.LittleEndian.Uint16(data[i+20:])) == len(data) {
+			eocd = i
+			break
+		}
+	}
+	if eocd < 0 {
+		return nil, fmt.Errorf("不是 ZIP 文件")
+	}
+
+	out := make([]byte, 0, eocd+eocdSize+len(comment))
+	out = append(out, data[:eocd+eocdSize]...)
+	binary.LittleEndian.PutUint16(out[eocd+20:], uint16(len(comment)))
+	return append(out, comment...), nil
+}
+
+// addOOXMLPart 将标识以不压缩的部件写入 OOXML 文档，原有部件原样复制
+// [Content_Types].xml 中补充 bin 扩展名的类型声明，避免 Office 提示文档损坏
+func addOOXMLPart(data, marker []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("不是 OOXML 文档: %w", err)
+	}
+	isOOXML := false
+	for _, f := range zr.File {
+		if f.Name == "[Content_Types].xml" {
+			isOOXML = true
+		}
+		if f.Name == ooxmlMarkerPart {
+			return nil, fmt.Errorf("文档中已存在 %s", ooxmlMarkerPart)
+		}
+	}
+	if !isOOXML {
+		return nil, fmt.Errorf("不是 OOXML 文档: 缺少 [Content_Types].xml")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if f.Name != "[Content_Types].xml" {
+			if err := zw.Copy(f); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		types, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(types, []byte(`Extension="bin"`)) {
+			types = bytes.Replace(types, []byte("</Types>"),
+				[]byte(`<Default Extension="bin" ContentType="application/octet-stream"/></Types>`), 1)
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(types); err != nil {
+			return nil, err
+		}
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: ooxmlMarkerPart, Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(marker); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func printInjectHelp() {
+	fmt.Printf(`%s inject - 将流式标识注入文件，生成端到端测试用的命中样本
+
+用法:
+  %s inject [选项] <文件>...
+
+标识:
+  -f, --rules <文件>         规则文件路径（JSON格式）
+      --hex <十六进制>        标识的十六进制内容
+      --base64 <Base64>      标识的Base64内容
+      --rule-id <ID>         规则文件中有多条规则时选取的规则 (默认: 第一条)
+      --verify               校验规则文件 SM2 签名，无效时拒绝加载
+      --pubkey <公钥>        签名公钥（十六进制 04||X||Y 或文件路径）
+
+注入方式 (--mode):
+  append                     追加到文件末尾 (默认)
+  offset                     在 --offset 指定的字节位置插入，原有内容后移
+  zip-comment                写入 ZIP 注释 (zip/docx/xlsx/pptx/ofd 等)，条目不变
+  ooxml                      以不压缩的部件 %s 写入 docx/xlsx/pptx
+
+输出:
+  -o, --output <文件>        输出文件路径（仅单个输入文件）
+      --out-dir <目录>       输出目录
+      --in-place             直接修改原文件
+                             均未指定时输出到原文件旁，如 a.docx -> a.marked.docx
+
+其他:
+      --check                注入后使用检测器扫描输出文件，未命中视为失败
+  -v, --verbose              详细输出模式
+
+示例:
+  # 将规则 1001 的标识追加到文本文件
+  %s inject -f rules.json --rule-id 1001 report.txt
+
+  # 在文件第 4096 字节处插入标识，输出到指定文件
+  %s inject -f rules.json --mode offset --offset 4096 -o hit.bin clean.bin
+
+  # 将标识写入 docx 自定义部件，并用检测器确认命中
+  %s inject -f rules.json --mode ooxml --check --out-dir samples/ a.docx b.xlsx
+
+退出码:
+  0    全部注入成功
+  1    参数错误或有文件注入失败
+
+`, toolName, toolName, ooxmlMarkerPart, toolName, toolName, toolName)
+}
@@ -11,15 +11,22 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"linuxFileWatcher/cmd/debug_tools/internal/checkpoint"
+	"linuxFileWatcher/cmd/debug_tools/internal/extstats"
+	"linuxFileWatcher/cmd/debug_tools/internal/filelist"
+	"linuxFileWatcher/cmd/debug_tools/internal/progress"
+	"linuxFileWatcher/cmd/debug_tools/internal/scanflag"
 	"linuxFileWatcher/internal/detector/electronic_secret"
+	"linuxFileWatcher/internal/detector/policy"
 	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/safewalk"
 )
 
 // ==========================================
@@ -31,16 +38,30 @@ var (
 	targetPath  string // 扫描目标路径（文件或目录）
 	recursive   bool   // 是否递归扫描子目录
 	followLinks bool   // 是否跟随符号链接
+	oneFS       bool   // 不进入其他文件系统的挂载点
+	filesFrom   string // 待扫描路径列表文件，- 表示标准输入
+	filesNul    bool   // 路径列表以 NUL 分隔
+
+	// 断点续扫
+	checkpointFile string // 检查点文件，记录已扫描的文件
+	// 打开的检查点，未指定 --checkpoint 时为 nil
+	scanCheckpoint *checkpoint.File[ScanResult]
+
+	// 定向扫描
+	maxDepth  int              // 目录遍历的最大深度，0 表示不限
+	scanAttrs pathfilter.Attrs // 文件大小下限与修改时间条件（大小上限为 maxFileSize）
 
 	// 规则配置
-	rulesFile  string // 规则文件路径（JSON格式）
-	ruleHex    string // 单条规则（十六进制格式）
-	ruleBase64 string // 单条规则（Base64格式）
-	ruleID     int64  // 单条规则的ID
-	ruleDesc   string // 单条规则的描述
+	rulesFile   string // 规则文件路径（JSON格式）
+	ruleHex     string // 单条规则（十六进制格式）
+	ruleBase64  string // 单条规则（Base64格式）
+	ruleID      int64  // 单条规则的ID
+	ruleDesc    string // 单条规则的描述
+	verifyRules bool   // 校验规则文件的 SM2 签名，失败拒绝加载
+	rulesPubKey string // 签名公钥（十六进制或文件路径）
 
 	// 检测配置
-	maxFileSize int64 // 最大文件大小（MB）
+	maxFileSize int64 // 最大文件大小（字节）
 	timeout     int   // 单文件超时时间（秒）
 	scanArchive bool  // 是否扫描压缩包内容
 	workers     int   // 并发工作协程数
@@ -52,6 +73,11 @@ var (
 	quiet        bool   // 静默模式（只输出命中结果）
 	showProgress bool   // 显示进度
 
+	// 性能基准
+	benchMode   bool // 性能基准模式
+	benchRounds int  // 测量轮数
+	benchWarmup int  // 预热轮数
+
 	// 其他
 	showHelp    bool // 显示帮助
 	showVersion bool // 显示版本
@@ -69,6 +95,19 @@ func init() {
 	flag.BoolVar(&recursive, "recursive", true, "递归扫描子目录")
 	flag.BoolVar(&recursive, "r", true, "递归扫描子目录（简写）")
 	flag.BoolVar(&followLinks, "follow-links", false, "跟随符号链接")
+	flag.BoolVar(&oneFS, "one-file-system", false, "不进入其他文件系统的挂载点 (同 find -xdev)")
+	flag.StringVar(&filesFrom, "files-from", "", "从文件读取待扫描路径列表，- 表示标准输入")
+	flag.BoolVar(&filesNul, "null", false, "--files-from 的列表以 NUL 分隔 (配合 find -print0)")
+	flag.BoolVar(&filesNul, "0", false, "--files-from 的列表以 NUL 分隔（简写）")
+
+	// 断点续扫
+	flag.StringVar(&checkpointFile, "checkpoint", "", "断点续扫: 记录已扫描的文件，中断后重新运行时跳过")
+
+	// 定向扫描
+	flag.IntVar(&maxDepth, "max-depth", 0, "目录遍历的最大深度，0 表示不限")
+	flag.Var((*scanflag.Size)(&scanAttrs.MinSize), "min-size", "跳过小于该大小的文件，如 4K")
+	flag.Var((*scanflag.Time)(&scanAttrs.NewerThan), "newer-than", "只扫描该时间之后修改的文件，如 48h、7d、2024-05-01")
+	flag.Var((*scanflag.Time)(&scanAttrs.OlderThan), "older-than", "只扫描该时间之前修改的文件")
 
 	// 规则配置
 	flag.StringVar(&rulesFile, "rules", "", "规则文件路径（JSON格式）")
@@ -77,9 +116,12 @@ func init() {
 	flag.StringVar(&ruleBase64, "base64", "", "单条规则的Base64内容")
 	flag.Int64Var(&ruleID, "rule-id", 1, "单条规则的ID")
 	flag.StringVar(&ruleDesc, "rule-desc", "CLI测试规则", "单条规则的描述")
+	flag.BoolVar(&verifyRules, "verify", false, "校验规则文件签名，签名无效时拒绝加载")
+	flag.StringVar(&rulesPubKey, "pubkey", "", "规则签名公钥（十六进制或文件路径）")
 
 	// 检测配置
-	flag.Int64Var(&maxFileSize, "max-size", 500, "最大文件大小（MB）")
+	maxFileSize = 500 << 20
+	flag.Var((*scanflag.Size)(&maxFileSize), "max-size", "最大文件大小，无单位时按 MB 计")
 	flag.IntVar(&timeout, "timeout", 30, "单文件超时时间（秒）")
 	flag.BoolVar(&scanArchive, "scan-archive", true, "扫描压缩包内容")
 	flag.IntVar(&workers, "workers", 0, "并发工作协程数（0=CPU核心数）")
@@ -95,6 +137,11 @@ func init() {
 	flag.BoolVar(&quiet, "q", false, "静默模式（简写）")
 	flag.BoolVar(&showProgress, "progress", true, "显示进度")
 
+	// 性能基准
+	flag.BoolVar(&benchMode, "bench", false, "性能基准：重复扫描并输出吞吐、延迟与内存分配")
+	flag.IntVar(&benchRounds, "bench-rounds", 3, "性能基准的测量轮数")
+	flag.IntVar(&benchWarmup, "bench-warmup", 1, "性能基准的预热轮数（不计入统计）")
+
 	// 其他
 	flag.BoolVar(&showHelp, "help", false, "显示帮助信息")
 	flag.BoolVar(&showHelp, "h", false, "显示帮助信息（简写）")
@@ -119,19 +166,27 @@ type ScanResult struct {
 	Duration    time.Duration `json:"duration_ns"`
 }
 
+// ResultPath 实现 checkpoint.Result
+func (r ScanResult) ResultPath() string { return r.FilePath }
+
+// Failed 实现 checkpoint.Result：检测失败的文件不记录，续扫时重试
+func (r ScanResult) Failed() bool { return r.Error != "" }
+
 // ScanSummary 扫描摘要
 type ScanSummary struct {
-	StartTime     time.Time     `json:"start_time"`
-	EndTime       time.Time     `json:"end_time"`
-	Duration      time.Duration `json:"duration_ns"`
-	TotalFiles    int64         `json:"total_files"`
-	ScannedFiles  int64         `json:"scanned_files"`
-	SkippedFiles  int64         `json:"skipped_files"`
-	ErrorFiles    int64         `json:"error_files"`
-	DetectedFiles int64         `json:"detected_files"`
-	TotalSize     int64         `json:"total_size_bytes"`
-	RulesCount    int           `json:"rules_count"`
-	Results       []ScanResult  `json:"results,omitempty"`
+	StartTime     time.Time           `json:"start_time"`
+	EndTime       time.Time           `json:"end_time"`
+	Duration      time.Duration       `json:"duration_ns"`
+	TotalFiles    int64               `json:"total_files"`
+	ScannedFiles  int64               `json:"scanned_files"`
+	SkippedFiles  int64               `json:"skipped_files"`
+	ErrorFiles    int64               `json:"error_files"`
+	DetectedFiles int64               `json:"detected_files"`
+	ResumedFiles  int64               `json:"resumed_files,omitempty"` // 沿用检查点结果、本次未扫描的文件
+	TotalSize     int64               `json:"total_size_bytes"`
+	FileTypes     *extstats.Breakdown `json:"file_types,omitempty"` // 按扩展名与分类的统计
+	RulesCount    int                 `json:"rules_count"`
+	Results       []ScanResult        `json:"results,omitempty"`
 }
 
 // ==========================================
@@ -139,6 +194,11 @@ type ScanSummary struct {
 // ==========================================
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "inject" {
+		os.Exit(runInject(os.Args[2:]))
+	}
+
 	flag.Parse()
 
 	// 显示帮助
@@ -160,6 +220,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 性能基准只输出基准结果
+	if benchMode {
+		quiet = true
+	}
+
 	// 加载规则
 	rules, err := loadRules()
 	if err != nil {
@@ -186,7 +251,7 @@ func main() {
 	}
 
 	// 收集文件列表
-	files, err := collectFiles(targetPath)
+	files, err := targetFiles()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "收集文件失败: %v\n", err)
 		os.Exit(1)
@@ -197,8 +262,20 @@ func main() {
 		return
 	}
 
+	if benchMode {
+		os.Exit(runBench(detector, files))
+	}
+
 	if !quiet {
 		fmt.Printf("共发现 %d 个���件待扫描\n", len(files))
+		if desc := scanflag.Describe(scanAttrs, maxDepth); desc != "" {
+			fmt.Printf("定向扫描条件: %s\n", desc)
+		}
+	}
+
+	if scanCheckpoint, err = checkpoint.OpenFlag[ScanResult](checkpointFile, toolName, quiet); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
 	}
 
 	// 执行扫描
@@ -206,6 +283,7 @@ func main() {
 
 	// 输出结果
 	outputResults(summary)
+	scanCheckpoint.Finish()
 
 	// 设置退出码
 	if summary.DetectedFiles > 0 {
@@ -221,13 +299,18 @@ func main() {
 // ==========================================
 
 func validateArgs() error {
-	if targetPath == "" {
-		return fmt.Errorf("必须指定扫描目标路径 (-p 或 --path)")
+	if targetPath == "" && filesFrom == "" {
+		return fmt.Errorf("必须指定扫描目标路径 (-p 或 --path) 或路径列表 (--files-from)")
+	}
+	if targetPath != "" && filesFrom != "" {
+		return fmt.Errorf("-p 与 --files-from 不能同时使用")
 	}
 
 	// 检查路径是否存在
-	if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-		return fmt.Errorf("路径不存在: %s", targetPath)
+	if targetPath != "" {
+		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+			return fmt.Errorf("路径不存在: %s", targetPath)
+		}
 	}
 
 	// 检查规则配置
@@ -235,6 +318,14 @@ func validateArgs() error {
 		return fmt.Errorf("必须指定规则：使用 -f/--rules 指定规则文件，或使用 --hex/--base64 指定单条规则")
 	}
 
+	if verifyRules && rulesPubKey == "" {
+		return fmt.Errorf("--verify 需要使用 --pubkey 指定签名公钥")
+	}
+
+	if err := applyScanFilters(); err != nil {
+		return err
+	}
+
 	// 检查输出格式
 	switch outputFormat {
 	case "text", "json", "csv":
@@ -297,6 +388,10 @@ func loadRulesFromFile(path string) ([]model.StreamMarkerDetectRule, error) {
 		return nil, err
 	}
 
+	if err := verifyRulesFile(path, data); err != nil {
+		return nil, err
+	}
+
 	// 尝试解析为规则配置
 	var config model.StreamMarkerDetectConfig
 	if err := json.Unmarshal(data, &config); err != nil {
@@ -311,6 +406,34 @@ func loadRulesFromFile(path string) ([]model.StreamMarkerDetectRule, error) {
 	return config.Rules, nil
 }
 
+// verifyRulesFile 校验规则文件签名
+// --verify 时签名缺失或无效拒绝加载；仅指定 --pubkey 时只输出警告
+func verifyRulesFile(path string, data []byte) error {
+	if rulesPubKey == "" {
+		return nil
+	}
+	mode := policy.VerifyWarn
+	if verifyRules {
+		mode = policy.VerifyEnforce
+	}
+	verifier, err := policy.NewVerifier(mode, rulesPubKey)
+	if err != nil {
+		return fmt.Errorf("加载签名公钥失败: %w", err)
+	}
+
+	if err := policy.VerifyBundle(data, verifier.PublicKey); err != nil {
+		if verifyRules {
+			return fmt.Errorf("规则文件签名校验失败: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "警告: 规则文件 %s 签名校验失败: %v\n", path, err)
+		return nil
+	}
+	if verbose {
+		fmt.Printf("规则文件签名校验通过: %s\n", path)
+	}
+	return nil
+}
+
 // ==========================================
 // 检测器创建
 // ==========================================
@@ -318,7 +441,7 @@ func loadRulesFromFile(path string) ([]model.StreamMarkerDetectRule, error) {
 func createDetector() electronic_secret.DetectorWithRules {
 	cfg := electronic_secret.Config{
 		Enabled:             true,
-		MaxFileSize:         maxFileSize * 1024 * 1024,
+		MaxFileSize:         maxFileSize,
 		Timeout:             time.Duration(timeout) * time.Second,
 		ScanArchiveContent:  scanArchive,
 		MaxArchiveEntrySize: 50 * 1024 * 1024,
@@ -334,6 +457,33 @@ func createDetector() electronic_secret.DetectorWithRules {
 // 文件收集
 // ==========================================
 
+// applyScanFilters 检查定向扫描条件，--max-depth 通过全局路径过滤器生效
+func applyScanFilters() error {
+	if maxDepth < 0 {
+		return fmt.Errorf("--max-depth 不能为负数")
+	}
+	attrs := scanAttrs
+	attrs.MaxSize = maxFileSize
+	if err := attrs.Validate(); err != nil {
+		return err
+	}
+	return scanflag.SetFilter(maxDepth)
+}
+
+// targetFiles 收集待扫描文件: 指定 --files-from 时逐项展开列表中的路径，否则遍历 -p
+func targetFiles() ([]string, error) {
+	if filesFrom == "" {
+		return collectFiles(targetPath)
+	}
+	paths, err := filelist.Load(filesFrom, filesNul)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件列表失败: %v", err)
+	}
+	return filelist.Expand(paths, collectFiles, func(p string, err error) {
+		fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", p, err)
+	}), nil
+}
+
 func collectFiles(path string) ([]string, error) {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -345,9 +495,11 @@ func collectFiles(path string) ([]string, error) {
 		return []string{path}, nil
 	}
 
-	// 遍历目录
+	// 遍历目录 (遵循全局路径过滤规则)
 	var files []string
 	var walkFunc fs.WalkDirFunc
+	root := path
+	filter := pathfilter.Default()
 
 	walkFunc = func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -360,30 +512,37 @@ func collectFiles(path string) ([]string, error) {
 		// 跳过目录
 		if d.IsDir() {
 			// 如果不递归，跳过子目录
-			if !recursive && path != targetPath {
+			if !recursive && path != root {
 				return fs.SkipDir
 			}
+			if path != root && filter.SkipDir(path, pathfilter.Depth(root, path)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if filter.SkipFile(path, pathfilter.Depth(root, path)) {
 			return nil
 		}
 
-		// 处理符号链接
+		// 跟随的符号链接已按目标文件回调，这里只剩不跟随或目标不存在的链接
 		if d.Type()&fs.ModeSymlink != 0 {
-			if !followLinks {
-				return nil // 跳过符号链接
-			}
-			// 解析符号链接
-			realPath, err := filepath.EvalSymlinks(path)
-			if err != nil {
+			return nil
+		}
+
+		// 定向扫描: 大小下限与修改时间
+		if !scanAttrs.IsZero() {
+			info, err := os.Stat(path)
+			if err != nil || !scanAttrs.Match(info) {
 				return nil
 			}
-			path = realPath
 		}
 
 		files = append(files, path)
 		return nil
 	}
 
-	if err := filepath.WalkDir(path, walkFunc); err != nil {
+	if err := safewalk.Walk(path, safewalk.Options{FollowSymlinks: followLinks, SameFilesystem: oneFS}, walkFunc); err != nil {
 		return nil, err
 	}
 
@@ -400,14 +559,21 @@ func runScan(detector electronic_secret.DetectorWithRules, files []string) *Scan
 		TotalFiles: int64(len(files)),
 		Results:    make([]ScanResult, 0),
 	}
+	pending := scanCheckpoint.Resume(files, func(r ScanResult) {
+		summary.ResumedFiles++
+		if r.Detected {
+			summary.DetectedFiles++
+		}
+		summary.Results = append(summary.Results, r)
+	})
 
 	// 确定工作协程数
 	numWorkers := workers
 	if numWorkers <= 0 {
 		numWorkers = runtime.NumCPU()
 	}
-	if numWorkers > len(files) {
-		numWorkers = len(files)
+	if numWorkers > len(pending) {
+		numWorkers = len(pending)
 	}
 
 	if !quiet {
@@ -420,9 +586,10 @@ func runScan(detector electronic_secret.DetectorWithRules, files []string) *Scan
 	taskChan := make(chan string, numWorkers*2)
 	resultChan := make(chan ScanResult, numWorkers*2)
 
-	// 进度统计
+	// 进度统计，命中数包含从检查点恢复的结果
 	var scanned int64
-	var detected int64
+	detected := summary.DetectedFiles
+	meter := progress.New(len(files), len(files)-len(pending))
 
 	// 启动工作协程
 	var wg sync.WaitGroup
@@ -456,6 +623,8 @@ func runScan(detector electronic_secret.DetectorWithRules, files []string) *Scan
 
 			// 保存结果
 			summary.Results = append(summary.Results, result)
+			scanCheckpoint.Record(result)
+			meter.Add(result.FileSize)
 
 			// 输出进度和结果
 			if result.Detected {
@@ -465,17 +634,14 @@ func runScan(detector electronic_secret.DetectorWithRules, files []string) *Scan
 			}
 
 			// 显示进度
-			if showProgress && !quiet {
-				current := atomic.LoadInt64(&scanned)
-				if current%100 == 0 || current == int64(len(files)) {
-					fmt.Printf("\r进度: %d/%d (检测到: %d)", current, len(files), atomic.LoadInt64(&detected))
-				}
+			if showProgress && !quiet && meter.Due() {
+				fmt.Printf("\r进度: %s (检测到: %d)   ", meter.Line(), atomic.LoadInt64(&detected))
 			}
 		}
 	}()
 
 	// 分发任务
-	for _, file := range files {
+	for _, file := range pending {
 		taskChan <- file
 	}
 	close(taskChan)
@@ -491,7 +657,8 @@ func runScan(detector electronic_secret.DetectorWithRules, files []string) *Scan
 	summary.EndTime = time.Now()
 	summary.Duration = summary.EndTime.Sub(summary.StartTime)
 	summary.ScannedFiles = scanned
-	summary.SkippedFiles = summary.TotalFiles - summary.ScannedFiles
+	summary.FileTypes = fileTypeBreakdown(summary.Results)
+	summary.SkippedFiles = summary.TotalFiles - summary.ScannedFiles - summary.ResumedFiles
 
 	if showProgress && !quiet {
 		fmt.Println() // 换行
@@ -562,6 +729,15 @@ func printDetection(result ScanResult) {
 	fmt.Printf("  耗时: %v\n", result.Duration)
 }
 
+// fileTypeBreakdown 按扩展名与分类汇总检测结果 (含检查点恢复的结果)
+func fileTypeBreakdown(results []ScanResult) *extstats.Breakdown {
+	c := extstats.New()
+	for _, r := range results {
+		c.Add(r.FilePath, r.FileSize, r.Duration, r.Detected, r.Error != "")
+	}
+	return c.Breakdown()
+}
+
 func outputResults(summary *ScanSummary) {
 	// 输出摘要
 	if !quiet {
@@ -572,6 +748,9 @@ func outputResults(summary *ScanSummary) {
 		fmt.Printf("文件总数: %d\n", summary.TotalFiles)
 		fmt.Printf("已扫描: %d\n", summary.ScannedFiles)
 		fmt.Printf("已跳过: %d\n", summary.SkippedFiles)
+		if summary.ResumedFiles > 0 {
+			fmt.Printf("检查点恢复: %d\n", summary.ResumedFiles)
+		}
 		fmt.Printf("错误数: %d\n", summary.ErrorFiles)
 		fmt.Printf("检测命中: %d\n", summary.DetectedFiles)
 		fmt.Printf("扫描总大小: %s\n", formatSize(summary.TotalSize))
@@ -579,6 +758,10 @@ func outputResults(summary *ScanSummary) {
 			speed := float64(summary.TotalSize) / summary.Duration.Seconds() / 1024 / 1024
 			fmt.Printf("扫描速度: %.2f MB/s\n", speed)
 		}
+		if summary.FileTypes != nil {
+			fmt.Println()
+			fmt.Print(summary.FileTypes.Format("", 15))
+		}
 	}
 
 	// 输出到文件
@@ -645,9 +828,16 @@ func formatText(summary *ScanSummary) []byte {
 	sb.WriteString(fmt.Sprintf("扫描耗时: %v\n", summary.Duration))
 	sb.WriteString(fmt.Sprintf("文件总数: %d\n", summary.TotalFiles))
 	sb.WriteString(fmt.Sprintf("已扫描: %d\n", summary.ScannedFiles))
+	if summary.ResumedFiles > 0 {
+		sb.WriteString(fmt.Sprintf("检查点恢复: %d\n", summary.ResumedFiles))
+	}
 	sb.WriteString(fmt.Sprintf("检测命中: %d\n", summary.DetectedFiles))
 	sb.WriteString(fmt.Sprintf("错误数: %d\n", summary.ErrorFiles))
 	sb.WriteString(fmt.Sprintf("扫描总大小: %s\n\n", formatSize(summary.TotalSize)))
+	if summary.FileTypes != nil {
+		sb.WriteString(summary.FileTypes.Format("", 0))
+		sb.WriteString("\n")
+	}
 
 	if summary.DetectedFiles > 0 {
 		sb.WriteString(fmt.Sprintf("检测命中详情\n"))
@@ -705,11 +895,21 @@ func printHelp() {
 
 用法:
   %s [选项]
+  %s inject [选项] <文件>...   将流式标识注入文件，详见 %s inject -h
 
-扫描目标:
-  -p, --path <路径>          扫描目标路径（文件或目录）[必需]
+扫描目标（-p 与 --files-from 二选一）:
+  -p, --path <路径>          扫描目标路径（文件或目录）
   -r, --recursive            递归扫描子目录 (默认: true)
       --follow-links         跟随符号链接 (默认: false)
+      --files-from <文件>    从文件读取待扫描路径，每行一个，- 表示标准输入；列表中的目录按 -r 展开
+  -0, --null                 列表以 NUL 分隔 (配合 find -print0 / fd -0)
+      --checkpoint <文件>    断点续扫: 记录已扫描的文件，中断后以同一文件重新运行时跳过未变化的文件，完成后删除
+
+定向扫描（目录遍历时生效，直接指定的文件不受限制）:
+      --max-depth <N>        目录遍历的最大深度，1 表示只扫描目录下的文件 (默认: 0 不限)
+      --min-size <大小>       跳过小于该大小的文件，支持 K/M/G 单位
+      --newer-than <时间>     只扫描该时间之后修改的文件: 时长 (48h、7d、2w) 或日期 (2024-05-01)
+      --older-than <时间>     只扫描该时间之前修改的文件
 
 规则配置:
   -f, --rules <文件>         规则文件路径（JSON格式）
@@ -717,9 +917,12 @@ func printHelp() {
       --base64 <Base64>      单条规则的Base64内容
       --rule-id <ID>         单条规则的ID (默认: 1)
       --rule-desc <描述>     单条规则的描述 (默认: "CLI测试规则")
+      --verify               校验规则文件 SM2 签名，无效时拒绝加载
+      --pubkey <公钥>        签名公钥（十六进制 04||X||Y 或文件路径）
+                             仅指定 --pubkey 时签名无效只告警
 
 检测配置:
-      --max-size <MB>        最大文件大小，单位MB (默认: 500)
+      --max-size <大小>       最大文件大小，支持 K/M/G 单位，无单位时按 MB 计 (默认: 500M)
       --timeout <秒>         单文件超时时间 (默认: 30)
       --scan-archive         扫描压缩包内容 (默认: true)
   -w, --workers <数量>       并发工作协程数 (默认: CPU核心数)
@@ -731,6 +934,12 @@ func printHelp() {
   -q, --quiet                静默模式（只输出命中结果）
       --progress             显示进度 (默认: true)
 
+性能基准:
+      --bench                重复扫描并输出吞吐（文件/秒、MB/秒）、延迟 p50/p95/p99
+                             与每文件内存分配，--format json 的结果可与其他版本对比
+      --bench-rounds <轮数>  测量轮数 (默认: 3)
+      --bench-warmup <轮数>  预热轮数，不计入统计 (默认: 1)
+
 其他:
   -h, --help                 显示帮助信息
       --version              显示版本信息
@@ -771,10 +980,22 @@ func printHelp() {
   # 使用4个工作协程扫描
   %s -p /data -f rules.json -w 4
 
+  # 性能基准，保存 JSON 结果用于版本间对比
+  %s -p /data -f rules.json --bench --bench-rounds 5 --format json -o bench.json
+
+  # 只扫描最近一天修改过的文件
+  find /data -type f -mtime -1 -print0 | %s --files-from - -0 -f rules.json
+
+  # 事件排查: 扫描最近 48 小时修改过的文件
+  %s -p /home -f rules.json --newer-than 48h
+
+  # 扫描大型共享目录，中断后执行同一命令继续
+  %s -p /mnt/share -f rules.json --checkpoint share.ckpt --format json -o share.json
+
 退出码:
   0    正常完成，未检测到敏感文件
   1    发生错误
   2    检测到敏感文件
 
-`, toolName, toolVersion, toolName, toolName, toolName, toolName, toolName, toolName)
+`, toolName, toolVersion, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName, toolName)
 }
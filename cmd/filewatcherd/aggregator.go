@@ -0,0 +1,42 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	detectorservice "linuxFileWatcher/internal/service/detector"
+)
+
+// initAlertAggregator 初始化告警去重聚合
+// 各检测来源的告警回调经 alertAggregator.Wrap 包装后，窗口内的重复命中合并为一条
+func initAlertAggregator() {
+	dc := config.Get().Scanner.AlertDedup
+	if !dc.Enable {
+		return
+	}
+
+	alertAggregator = detectorservice.NewAlertAggregator(detectorservice.AlertDedupConfig{
+		Window:     dc.Window,
+		MaxEntries: dc.MaxEntries,
+	})
+	logger.Info("告警去重聚合已启用", "window", dc.Window)
+}
+
+// startAlertAggregator 启动告警去重聚合
+func startAlertAggregator() {
+	if alertAggregator == nil {
+		return
+	}
+	alertAggregator.Start()
+}
+
+// stopAlertAggregator 停止告警去重聚合，输出窗口内尚未输出的告警
+func stopAlertAggregator() {
+	if alertAggregator != nil {
+		fmt.Println("正在停止告警去重聚合...")
+		alertAggregator.Stop()
+	}
+}
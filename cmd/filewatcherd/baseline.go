@@ -0,0 +1,90 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security/baseline"
+	"linuxFileWatcher/internal/security/merkle"
+	"linuxFileWatcher/internal/storage"
+)
+
+// maxBaselineAlerts 单次目录基线校验逐条上报的变化数，其余合并为一条汇总
+const maxBaselineAlerts = 20
+
+// initBaselines 为配置的目录建立 Merkle 树基线，节点保存在本地数据库
+func initBaselines() {
+	bc := config.Get().Security.Integrity.Baseline
+	stores := storage.GetStores()
+	if !bc.Enable || len(bc.Paths) == 0 || stores == nil {
+		return
+	}
+
+	keyPath := bc.SigningKey
+	if keyPath == "" {
+		keyPath = filepath.Join(config.Get().Agent.DataDir, "keys", "baseline_sm2.key")
+	}
+	key, err := baseline.LoadOrCreateKey(keyPath)
+	if err != nil {
+		// 未签名的基线仍校验节点哈希链，但无法发现整体重算的伪造
+		logger.Error("基线签名密钥不可用，基线不签名", "path", keyPath, "error", err)
+	}
+
+	var trees []*merkle.Tree
+	for _, path := range bc.Paths {
+		t, err := merkle.New(path, stores.MerkleNodes, merkle.Options{FullHash: bc.FullHash, Key: key})
+		if err != nil {
+			logger.Error("目录基线初始化失败", "path", path, "error", err)
+			continue
+		}
+		trees = append(trees, t)
+	}
+	baselineMonitor = merkle.NewMonitor(trees, merkle.MonitorConfig{
+		Interval: bc.Interval,
+		Accept:   bc.Accept,
+	}, reportBaselineChanges)
+}
+
+// reportBaselineChanges 目录树相对基线发生变化时生成安全事件
+func reportBaselineChanges(r *merkle.Report) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	report := model.NewSecurityStatusReport(config.Version)
+	for i, c := range r.Changes {
+		if i == maxBaselineAlerts {
+			break
+		}
+		msg := fmt.Sprintf("目录基线文件变化 (%s): %s", c.Type, c.Path)
+		if c.Detail != "" {
+			msg += " " + c.Detail
+		}
+		report.AddSignatureAlert(c.Path, msg)
+	}
+	if total := len(r.Changes) + r.Truncated; total > maxBaselineAlerts {
+		report.AddSignatureAlert(r.Root, fmt.Sprintf("目录 %s 共 %d 处变化，仅列出前 %d 处", r.Root, total, maxBaselineAlerts))
+	}
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存目录基线安全事件失败", "error", err)
+	}
+}
+
+// startBaselines 启动目录基线校验
+func startBaselines() {
+	if baselineMonitor != nil {
+		baselineMonitor.Start()
+	}
+}
+
+// stopBaselines 停止目录基线校验
+func stopBaselines() {
+	if baselineMonitor != nil {
+		baselineMonitor.Stop()
+	}
+}
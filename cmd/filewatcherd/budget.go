@@ -0,0 +1,34 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/budget"
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+)
+
+// initBudget 初始化全局并发与内存预算，并在配置重载时调整上限
+func initBudget() {
+	applyBudget(config.Get())
+	config.OnReload(applyBudget)
+}
+
+// applyBudget 按配置与 cgroup 配额推算预算上限并应用到全局调度器
+func applyBudget(cfg *config.AppConfig) {
+	bc := cfg.Scanner.Budget
+	limits := budget.Auto(budget.Config{
+		Workers:       bc.MaxWorkers,
+		MemoryMB:      bc.MemoryMB,
+		MemoryPercent: bc.MemoryPercent,
+		Archive:       bc.ArchiveWorkers,
+		OCR:           bc.OCRWorkers,
+	})
+	budget.Default().SetLimits(limits)
+	logger.Info("全局并发与内存预算",
+		"workers", limits.Workers,
+		"memory_mb", limits.Memory/1024/1024,
+		"archive", limits.Archive,
+		"ocr", limits.OCR,
+	)
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security/canary"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initCanary 初始化诱饵文件，放置失败的文件记录日志后跳过
+func initCanary() {
+	cc := config.Get().Security.Canary
+	if !cc.Enable || len(cc.Files) == 0 {
+		return
+	}
+	m := canary.New(canary.Config{
+		Files:          cc.Files,
+		AllowProcesses: cc.AllowProcesses,
+		Dedup:          cc.Deduplication,
+	}, reportCanaryAccess)
+	planted, err := m.Plant()
+	if err != nil {
+		logger.Error("部分诱饵文件放置失败", "error", err)
+	}
+	if len(planted) == 0 {
+		return
+	}
+	canaryMonitor = m
+}
+
+// reportCanaryAccess 诱饵文件被打开时生成紧急级安全事件
+func reportCanaryAccess(a canary.Access) {
+	logger.Error("诱饵文件被访问", "path", a.Canary.Path, "pid", a.PID, "exe", a.Exe)
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddProcessAlert(a.Time, fmt.Sprintf("诱饵文件被访问: %s (%s)", a.Canary.Path, a.Owner()))
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存诱饵文件安全事件失败", "error", err)
+	}
+}
+
+// startCanary 启动诱饵文件监控
+func startCanary() {
+	if canaryMonitor == nil {
+		return
+	}
+	if err := canaryMonitor.Start(); err != nil {
+		logger.Error("诱饵文件监控启动失败", "error", err)
+		canaryMonitor = nil
+	}
+}
+
+// stopCanary 停止诱饵文件监控
+func stopCanary() {
+	if canaryMonitor != nil {
+		canaryMonitor.Stop()
+	}
+}
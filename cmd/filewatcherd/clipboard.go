@@ -0,0 +1,66 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/clipboard"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initClipboardMonitor 初始化剪贴板监控
+// 当前会话没有可用的剪贴板工具时仅记录警告
+func initClipboardMonitor() {
+	cc := config.Get().Scanner.Clipboard
+	if !cc.Enable || detectorMgr == nil {
+		return
+	}
+
+	source, err := clipboard.DetectSource()
+	if err != nil {
+		logger.Warn("剪贴板监控未启用", "error", err)
+		return
+	}
+
+	sink := func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if err := stores.Alerts.Push(*record); err != nil {
+			logger.Error("保存剪贴板告警失败", "error", err)
+		}
+		if logItem != nil {
+			if err := stores.AlertLogs.Push(*logItem); err != nil {
+				logger.Error("保存剪贴板告警日志失败", "error", err)
+			}
+		}
+	}
+
+	clipboardMonitor = clipboard.NewMonitor(clipboard.Config{
+		PollInterval:       cc.PollInterval,
+		MaxBytes:           int64(cc.MaxSizeMB) << 20,
+		Cooldown:           cc.Cooldown,
+		MaxAlertsPerMinute: cc.MaxAlertsPerMinute,
+	}, source, detectorMgr, alertSink(sink))
+}
+
+// startClipboardMonitor 启动剪贴板监控
+func startClipboardMonitor() {
+	if clipboardMonitor == nil {
+		return
+	}
+	clipboardMonitor.Start()
+}
+
+// stopClipboardMonitor 停止剪贴板监控
+func stopClipboardMonitor() {
+	if clipboardMonitor != nil {
+		fmt.Println("正在停止剪贴板监控...")
+		clipboardMonitor.Stop()
+	}
+}
@@ -0,0 +1,94 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/container"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initContainerScanner 初始化容器文件系统扫描
+func initContainerScanner() {
+	cc := config.Get().Scanner.Containers
+	if !cc.Enable || detectorMgr == nil {
+		return
+	}
+
+	fmt.Println("正在初始化容器文件系统扫描...")
+
+	useDocker := false
+	for _, name := range cc.Runtimes {
+		if name == "docker" {
+			useDocker = true
+		}
+	}
+
+	var runtimes []container.Runtime
+	for _, name := range cc.Runtimes {
+		switch name {
+		case "docker":
+			runtimes = append(runtimes, container.NewDockerRuntime(cc.DockerSocket))
+		case "containerd":
+			// Docker 的容器位于 containerd 的 moby 命名空间，已通过 Docker API 枚举时跳过
+			var skip []string
+			if useDocker {
+				skip = append(skip, "moby")
+			}
+			runtimes = append(runtimes, container.NewContainerdRuntime(cc.ContainerdStateDir, skip...))
+		default:
+			logger.Warn("未知的容器运行时，已忽略", "runtime", name)
+		}
+	}
+	if len(runtimes) == 0 {
+		logger.Warn("未配置可用的容器运行时，容器扫描未启用")
+		return
+	}
+
+	// 命中告警写入存储，由上报服务统一发送
+	sink := func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if record != nil {
+			if err := stores.Alerts.Push(*record); err != nil {
+				logger.Error("保存容器告警失败", "error", err)
+			}
+		}
+		if logItem != nil {
+			if err := stores.AlertLogs.Push(*logItem); err != nil {
+				logger.Error("保存容器告警日志失败", "error", err)
+			}
+		}
+	}
+
+	containerScanner = container.NewScanner(container.Config{
+		PollInterval:   cc.PollInterval,
+		RescanInterval: cc.RescanInterval,
+		Paths:          cc.Paths,
+		ScanTimeout:    cc.ScanTimeout,
+	}, runtimes, detectorMgr, alertSink(sink))
+
+	logger.Info("容器文件系统扫描初始化成功", "runtimes", cc.Runtimes)
+}
+
+// startContainerScanner 启动容器文件系统扫描
+func startContainerScanner() {
+	if containerScanner == nil {
+		return
+	}
+	containerScanner.Start()
+}
+
+// stopContainerScanner 停止容器文件系统扫描
+func stopContainerScanner() {
+	if containerScanner != nil {
+		fmt.Println("正在停止容器文件系统扫描...")
+		containerScanner.Stop()
+	}
+}
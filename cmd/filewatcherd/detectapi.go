@@ -0,0 +1,147 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/detectapi"
+	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/systemd"
+	"linuxFileWatcher/internal/upgrade"
+)
+
+// initDetectAPI 初始化本机检测服务
+// systemd socket 激活传入名为 detectapi 的 socket 时直接使用该 socket，视为已启用
+func initDetectAPI() {
+	ac := config.Get().API
+	ln := systemd.Listener("detectapi")
+	if ln == nil {
+		// 原地升级时由旧进程交接
+		ln = upgrade.Listener("detectapi")
+	}
+	if (!ac.Enable && ln == nil) || detectorMgr == nil {
+		return
+	}
+
+	dc := detectapi.Config{
+		SocketPath: ac.Socket,
+		Listener:   ln,
+		MaxBytes:   int64(ac.MaxBytesMB) << 20,
+		Timeout:    ac.Timeout,
+	}
+	if netWhitelist != nil {
+		dc.Whitelist = whitelistRules{mgr: netWhitelist}
+	}
+	if ruleStats != nil {
+		dc.RuleStats = ruleStatsAPI{tracker: ruleStats}
+	}
+	if evidenceVault != nil {
+		dc.Evidence = evidenceAPI{vault: evidenceVault}
+	}
+	dc.LogLevels = logLevelsAPI{}
+	dc.Audit = auditDetectAPI
+	detectAPI = detectapi.NewServer(dc, detectorMgr, detectorRules{mgr: detectorMgr})
+}
+
+// startDetectAPI 启动本机检测服务
+func startDetectAPI() {
+	if detectAPI == nil {
+		return
+	}
+	if err := detectAPI.Start(); err != nil {
+		logger.Error("本机检测服务启动失败", "error", err)
+	}
+}
+
+// stopDetectAPI 停止本机检测服务，等待处理中的请求完成
+func stopDetectAPI() {
+	if detectAPI == nil {
+		return
+	}
+	fmt.Println("正在停止本机检测服务...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := detectAPI.Stop(ctx); err != nil {
+		logger.Warn("本机检测服务停止超时", "error", err)
+	}
+}
+
+// auditDetectAPI 将本机接口的敏感操作写入审计日志
+func auditDetectAPI(p detectapi.Peer, message string) error {
+	stores := storage.GetStores()
+	if stores == nil {
+		return errors.New("storage not initialized")
+	}
+	now := time.Now()
+	record := model.NewSystemAuditRequest(
+		fmt.Sprintf("api%d", now.UnixMilli()),
+		fmt.Sprintf("uid:%d", p.UID),
+		now.Format("2006-01-02 15:04:05.000"),
+		model.LogTypeLocalOperation,
+		model.OpTypeEvidenceAccess,
+		message,
+	)
+	return stores.AuditLogs.Push(*record)
+}
+
+// logLevelsAPI 将全局日志级别暴露给本机检测服务
+type logLevelsAPI struct{}
+
+func (logLevelsAPI) GetLogLevels() detectapi.LogLevelsResponse {
+	global, modules := logger.Levels()
+	return detectapi.LogLevelsResponse{Global: global, Modules: modules}
+}
+
+func (logLevelsAPI) SetLogLevel(module, level string) error {
+	if err := logger.SetLevel(module, level); err != nil {
+		return &detectapi.Error{Code: detectapi.CodeInvalidArgument, Message: err.Error()}
+	}
+	return nil
+}
+
+// detectorRules 将检测器管理器的模块开关与阈值暴露给本机检测服务
+type detectorRules struct {
+	mgr *detector.Manager
+}
+
+func (r detectorRules) GetRules() detectapi.Rules {
+	cfg := r.mgr.Config()
+	return detectapi.Rules{
+		EnableElectronicLabel: cfg.EnableElectronicLabel,
+		EnableSecretMarker:    cfg.EnableSecretMarker,
+		EnableLayout:          cfg.EnableLayout,
+		EnableHash:            cfg.EnableHash,
+		EnableKeywords:        cfg.EnableKeywords,
+		EnableWasmRules:       cfg.EnableWasmRules,
+		EnableFingerprint:     cfg.EnableFingerprint,
+		EnableEDM:             cfg.EnableEDM,
+		SecretMarkerOCR:       cfg.SecretMarkerOCR,
+		LayoutThreshold:       cfg.LayoutThreshold,
+		LayoutEnableOCR:       cfg.LayoutEnableOCR,
+	}
+}
+
+func (r detectorRules) SetRules(rules detectapi.Rules) error {
+	cfg := r.mgr.Config()
+	cfg.EnableElectronicLabel = rules.EnableElectronicLabel
+	cfg.EnableSecretMarker = rules.EnableSecretMarker
+	cfg.EnableLayout = rules.EnableLayout
+	cfg.EnableHash = rules.EnableHash
+	cfg.EnableKeywords = rules.EnableKeywords
+	cfg.EnableWasmRules = rules.EnableWasmRules
+	cfg.EnableFingerprint = rules.EnableFingerprint
+	cfg.EnableEDM = rules.EnableEDM
+	cfg.SecretMarkerOCR = rules.SecretMarkerOCR
+	cfg.LayoutThreshold = rules.LayoutThreshold
+	cfg.LayoutEnableOCR = rules.LayoutEnableOCR
+	r.mgr.UpdateConfig(cfg)
+	return nil
+}
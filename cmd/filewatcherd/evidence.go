@@ -0,0 +1,86 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/detectapi"
+	"linuxFileWatcher/internal/service/evidence"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initEvidenceVault 初始化告警取证库
+// 证据由处置动作 evidence 留存，使用与本地缓存相同的数据密钥加密
+func initEvidenceVault() error {
+	cfg := config.Get()
+	ec := cfg.Scanner.Evidence
+	if !ec.Enable {
+		return nil
+	}
+
+	dir := ec.Dir
+	if dir == "" {
+		dir = filepath.Join(cfg.Agent.DataDir, "evidence")
+	}
+	var store evidence.Store
+	if stores := storage.GetStores(); stores != nil {
+		store = stores.Evidence
+	}
+	vault, err := evidence.New(evidence.Config{
+		Dir:          dir,
+		Mode:         ec.Mode,
+		MaxFileSize:  ec.MaxFileSizeMB << 20,
+		MaxTotalSize: ec.MaxTotalSizeMB << 20,
+		Retention:    ec.Retention,
+	}, goldenCipher{}, store)
+	if err != nil {
+		return err
+	}
+	evidenceVault = vault
+	storage.RegisterVault("evidence", vault)
+	logger.Info("告警取证留存已启用", "dir", dir, "mode", ec.Mode)
+	return nil
+}
+
+// evidenceAPI 将告警取证库暴露给本机检测服务
+type evidenceAPI struct {
+	vault *evidence.Vault
+}
+
+func (e evidenceAPI) ListEvidence() []detectapi.EvidenceItem {
+	items := e.vault.List()
+	out := make([]detectapi.EvidenceItem, 0, len(items))
+	for _, r := range items {
+		out = append(out, toAPIEvidence(r))
+	}
+	return out
+}
+
+func (e evidenceAPI) GetEvidence(alertID string) (detectapi.EvidenceItem, []byte, error) {
+	r, content, err := e.vault.Get(alertID)
+	switch {
+	case errors.Is(err, evidence.ErrNotFound):
+		return detectapi.EvidenceItem{}, nil, &detectapi.Error{Code: detectapi.CodeNotFound, Message: err.Error()}
+	case err != nil:
+		return detectapi.EvidenceItem{}, nil, err
+	}
+	return toAPIEvidence(r), content, nil
+}
+
+func toAPIEvidence(r model.EvidenceRecord) detectapi.EvidenceItem {
+	return detectapi.EvidenceItem{
+		AlertID:   r.AlertID,
+		FilePath:  r.FilePath,
+		Mode:      r.Mode,
+		FileSize:  r.FileSize,
+		Size:      r.Size,
+		SM3:       r.SM3,
+		Truncated: r.Truncated,
+		CreatedAt: r.CreatedAt,
+	}
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/exfil"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initExfilCorrelator 初始化批量外发检测
+// 扫描队列与可移动介质扫描在检测完成后通过 exfil.Observe 提交事件
+func initExfilCorrelator() {
+	ec := config.Get().Scanner.Exfil
+	if !ec.Enable {
+		return
+	}
+
+	sink := func(record *model.AlertRecord) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if err := stores.Alerts.Push(*record); err != nil {
+			logger.Error("保存外发告警失败", "error", err)
+		}
+	}
+
+	exfil.SetDefault(exfil.NewCorrelator(exfil.Config{
+		Threshold: ec.Threshold,
+		Window:    ec.Window,
+		Cooldown:  ec.Cooldown,
+	}, nil, sink))
+	logger.Info("批量外发检测已启用", "threshold", ec.Threshold, "window", ec.Window)
+}
+
+// observeExfil 命中的涉密文件先计入批量外发关联 (去重聚合之前，逐个文件计数)，再交给告警链
+func observeExfil(sink func(*model.AlertRecord, *model.AlertLogItem)) func(*model.AlertRecord, *model.AlertLogItem) {
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		if record != nil {
+			exfil.Observe(exfil.FileEvent{Path: record.FilePath, Sensitive: true})
+		}
+		sink(record, logItem)
+	}
+}
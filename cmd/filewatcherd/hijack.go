@@ -0,0 +1,69 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security/hijack"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initHijackDetector 初始化内核模块与 LD_PRELOAD 劫持检测
+// 未配置模块白名单时以客户端启动时已加载的模块为准
+func initHijackDetector() {
+	hc := config.Get().Security.Hijack
+	if !hc.Enable {
+		return
+	}
+	hijackDetector = hijack.New(hijack.Config{
+		Interval:         hc.CheckInterval,
+		ModuleAllowlist:  hc.ModuleAllowlist,
+		LibraryAllowlist: hc.LibraryAllowlist,
+		Processes:        hc.Processes,
+	}, reportHijack)
+}
+
+// hijackTypeNames 劫持类型的中文名称
+var hijackTypeNames = map[hijack.FindingType]string{
+	hijack.FindingModule:       "未授权内核模块",
+	hijack.FindingHiddenModule: "隐藏内核模块",
+	hijack.FindingPreloadFile:  "ld.so.preload 预加载库",
+	hijack.FindingPreloadEnv:   "进程预加载库",
+}
+
+// reportHijack 发现可疑注入时生成紧急级安全事件，与自我保护走同一上报路径
+func reportHijack(f hijack.Finding) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	msg := fmt.Sprintf("%s: %s", hijackTypeNames[f.Type], f.Name)
+	if f.PID > 0 {
+		msg += fmt.Sprintf(" (%s, pid %d, %s)", f.Process, f.PID, f.Detail)
+	} else if f.Detail != "" {
+		msg += " (" + f.Detail + ")"
+	}
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddProcessAlert(f.Time, msg)
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存劫持检测安全事件失败", "error", err)
+	}
+}
+
+// startHijackDetector 启动劫持检测
+func startHijackDetector() {
+	if hijackDetector != nil {
+		hijackDetector.Start()
+	}
+}
+
+// stopHijackDetector 停止劫持检测
+func stopHijackDetector() {
+	if hijackDetector != nil {
+		hijackDetector.Stop()
+	}
+}
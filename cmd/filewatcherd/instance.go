@@ -0,0 +1,32 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/instance"
+	"linuxFileWatcher/internal/logger"
+)
+
+// acquireInstanceLock 获取数据目录的单实例锁并写入 PID 文件
+// 多个实例同时写同一 SQLite 存储会损坏数据，锁被占用时拒绝启动
+func acquireInstanceLock(force bool) error {
+	cfg := config.Get()
+	lock, err := instance.Acquire(cfg.Agent.DataDir, instance.Options{Force: force})
+	if err != nil {
+		return err
+	}
+	instanceLock = lock
+	logger.Info("单实例锁获取成功", "pid_file", lock.Path())
+	return nil
+}
+
+// releaseInstanceLock 释放单实例锁并删除 PID 文件
+func releaseInstanceLock() {
+	if instanceLock == nil {
+		return
+	}
+	if err := instanceLock.Release(); err != nil {
+		logger.Error("释放单实例锁失败", "error", err)
+	}
+}
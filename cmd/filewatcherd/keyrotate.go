@@ -0,0 +1,67 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security/kms"
+	"linuxFileWatcher/internal/service/keyrotate"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initKeyRotator 初始化数据密钥轮换 (需要 KMS 与存储已初始化)
+func initKeyRotator() {
+	ring := kms.Default()
+	if ring == nil {
+		return
+	}
+	kc := config.Get().Security.KMS
+
+	audit := func(message string) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		now := time.Now()
+		record := model.NewSystemAuditRequest(
+			fmt.Sprintf("kms%d", now.UnixMilli()),
+			"system",
+			now.Format("2006-01-02 15:04:05.000"),
+			model.LogTypeLocalOperation,
+			model.OpTypeKeyRotation,
+			message,
+		)
+		if err := stores.AuditLogs.Push(*record); err != nil {
+			logger.Error("保存密钥轮换审计日志失败", "error", err)
+		}
+	}
+
+	keyRotator = keyrotate.NewRotator(keyrotate.Config{
+		Interval:     kc.RotateInterval,
+		KeepPrevious: kc.KeepPrevious,
+	}, ring, func(ctx context.Context) (int, error) {
+		return storage.ReEncryptAll(ctx, ring)
+	}, audit)
+}
+
+// startKeyRotator 启动数据密钥轮换
+func startKeyRotator() {
+	if keyRotator == nil {
+		return
+	}
+	keyRotator.Start()
+}
+
+// stopKeyRotator 停止数据密钥轮换，未完成的重加密下次启动继续
+func stopKeyRotator() {
+	if keyRotator != nil {
+		fmt.Println("正在停止数据密钥轮换...")
+		keyRotator.Stop()
+	}
+}
@@ -0,0 +1,132 @@
+//go:build linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/kms"
+)
+
+// initKMS 按配置加载密钥管理后端，解封本地数据密钥
+// backend 为 local 时不启用，存储加密沿用 security 模块内置密钥
+func initKMS() error {
+	cfg := config.Get()
+	backend, err := openKMS(cfg)
+	if err != nil || backend == nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ring, err := kms.LoadOrCreate(ctx, backend, filepath.Join(cfg.Agent.DataDir, "keys", "data.key"))
+	if err != nil {
+		return err
+	}
+	kms.SetDefault(ring)
+	logger.Info("密钥管理后端已启用", "backend", ring.Backend())
+	return nil
+}
+
+// openKMS 按配置打开密钥管理后端，backend 为 local 时返回 nil
+func openKMS(cfg *config.AppConfig) (kms.KMS, error) {
+	kc := cfg.Security.KMS
+	keyFile := kc.KeyFile
+	if keyFile == "" {
+		keyFile = filepath.Join(cfg.Agent.DataDir, "keys", "kek")
+	}
+	return kms.Open(kms.Config{
+		Backend:        kc.Backend,
+		KeyFile:        keyFile,
+		TPMTCTI:        kc.TPM.TCTI,
+		TPMPCRs:        kc.TPM.PCRs,
+		PKCS11Module:   kc.PKCS11.Module,
+		PKCS11Token:    kc.PKCS11.Token,
+		PKCS11KeyLabel: kc.PKCS11.KeyLabel,
+		PKCS11PINFile:  kc.PKCS11.PINFile,
+	})
+}
+
+// initConfigSecrets 解密配置中的 ENC[...] 加密项 (filewatcherd config encrypt 生成)
+// 在日志初始化之前进行，日志投递的请求头等也可以加密保存；重载配置时同样解密
+func initConfigSecrets() error {
+	backend, err := openKMS(config.Get())
+	if err != nil {
+		return err
+	}
+	var decrypt config.SecretDecrypter
+	if backend != nil {
+		decrypt = func(data []byte) ([]byte, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			return kms.OpenSecret(ctx, backend, data)
+		}
+	}
+	keys, err := config.SetSecretDecrypter(decrypt)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		fmt.Printf("已解密 %d 个加密配置项\n", len(keys))
+	}
+	return nil
+}
+
+// runConfigEncrypt 使用配置的密钥管理后端加密配置值，输出可直接写入配置文件的 ENC[...]
+// 值从参数读取，未给出时从标准输入读取 (避免明文留在 shell 历史中)
+func runConfigEncrypt(args []string) int {
+	fs := flag.NewFlagSet("config encrypt", flag.ContinueOnError)
+	configPath := fs.String("c", "configs/config.yml", "配置文件路径 (读取 security.kms 配置)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var plaintext []byte
+	switch fs.NArg() {
+	case 0:
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取标准输入失败: %v\n", err)
+			return 1
+		}
+		plaintext = bytes.TrimRight(data, "\r\n")
+	case 1:
+		plaintext = []byte(fs.Arg(0))
+	default:
+		fmt.Fprintln(os.Stderr, "用法: filewatcherd config encrypt [-c 配置文件] [值]")
+		return 2
+	}
+
+	cfg, err := config.Read(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败: %v\n", err)
+		return 1
+	}
+	backend, err := openKMS(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开密钥管理后端失败: %v\n", err)
+		return 1
+	}
+	if backend == nil {
+		fmt.Fprintln(os.Stderr, "加密配置值需要启用密钥管理后端 (security.kms.backend 为 file、tpm 或 pkcs11)")
+		return 1
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sealed, err := kms.SealSecret(ctx, backend, plaintext)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
+		return 1
+	}
+	fmt.Println(config.FormatSecret(sealed))
+	return 0
+}
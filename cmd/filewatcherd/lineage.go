@@ -0,0 +1,30 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/service/lineage"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initLineageTracker 初始化涉密文件流转追踪
+// 告警经 lineageTracker.Wrap 关联到首次告警；文件监控观察到的改名通过 lineage.Observe 提交
+func initLineageTracker() {
+	lc := config.Get().Scanner.Lineage
+	if !lc.Enable {
+		return
+	}
+
+	var store lineage.Store
+	if stores := storage.GetStores(); stores != nil {
+		store = stores.FileLineage
+	}
+	lineageTracker = lineage.New(lineage.Config{
+		Retention: lc.Retention,
+		MaxEdges:  lc.MaxEdges,
+	}, store)
+	lineage.SetDefault(lineageTracker)
+	logger.Info("涉密文件流转追踪已启用", "retention", lc.Retention)
+}
@@ -1,23 +1,65 @@
+//go:build linux
+
+// 守护进程依赖 fanotify、netguard、DBus 等 Linux 专有模块，仅在 Linux 上构建；
+// 检测库 (internal/detector) 与 internal/watcher 可在 Windows/macOS 上单独使用
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"linuxFileWatcher/internal/config"
 	"linuxFileWatcher/internal/detector"
+	"linuxFileWatcher/internal/detector/edm"
+	"linuxFileWatcher/internal/detector/fingerprint"
+	"linuxFileWatcher/internal/detector/keyword"
+	"linuxFileWatcher/internal/detector/plugin"
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/detector/wasmrule"
+	"linuxFileWatcher/internal/i18n"
 	"linuxFileWatcher/internal/identity"
+	"linuxFileWatcher/internal/instance"
 	"linuxFileWatcher/internal/logger"
-	"linuxFileWatcher/internal/postmanager"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
 	"linuxFileWatcher/internal/security"
+	"linuxFileWatcher/internal/security/canary"
+	"linuxFileWatcher/internal/security/envelope"
+	"linuxFileWatcher/internal/security/hijack"
+	"linuxFileWatcher/internal/security/merkle"
+	"linuxFileWatcher/internal/security/netguard/bandwidth"
+	"linuxFileWatcher/internal/security/netguard/conntrack"
+	"linuxFileWatcher/internal/security/netguard/dnsguard"
+	"linuxFileWatcher/internal/security/netguard/geoip"
+	"linuxFileWatcher/internal/security/netguard/listener"
+	"linuxFileWatcher/internal/security/netguard/whitelist"
+	"linuxFileWatcher/internal/security/selfprotect"
+	"linuxFileWatcher/internal/service/clipboard"
+	"linuxFileWatcher/internal/service/command"
+	"linuxFileWatcher/internal/service/container"
+	"linuxFileWatcher/internal/service/detectapi"
 	detectorservice "linuxFileWatcher/internal/service/detector"
+	"linuxFileWatcher/internal/service/evidence"
+	"linuxFileWatcher/internal/service/keyrotate"
+	"linuxFileWatcher/internal/service/lineage"
+	"linuxFileWatcher/internal/service/notify"
+	"linuxFileWatcher/internal/service/offline"
+	"linuxFileWatcher/internal/service/printjob"
+	"linuxFileWatcher/internal/service/realtime"
+	"linuxFileWatcher/internal/service/removable"
+	"linuxFileWatcher/internal/service/response"
+	"linuxFileWatcher/internal/service/rulestats"
 	securityservice "linuxFileWatcher/internal/service/security"
+	"linuxFileWatcher/internal/service/webhook"
 	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/systemd"
 )
 
 // ==========================================
@@ -25,22 +67,181 @@ import (
 // ==========================================
 
 var (
-	// 涉密检测服务实例
-	scannerSvc *detectorservice.ScannerService
+	// 涉密检测扫描调度实例
+	scanQueue *detectorservice.ScanQueue
+
+	// 扫描命中的告警回调 (启动时经 alertSink 包装)
+	scanSink func(*model.AlertRecord, *model.AlertLogItem)
+
+	// 定时扫描调度器实例
+	scanScheduler *detectorservice.ScanScheduler
+
+	// 检测器管理器实例
+	detectorMgr *detector.Manager
+
+	// 告警去重聚合实例
+	alertAggregator *detectorservice.AlertAggregator
+
+	// 涉密文件流转追踪实例
+	lineageTracker *lineage.Tracker
+
+	// 检测规则命中统计实例
+	ruleStats *rulestats.Tracker
+
+	// 告警取证库实例
+	evidenceVault *evidence.Vault
+
+	// 检测结果处置策略实例
+	responseEngine *response.Engine
+
+	// 桌面用户通知实例
+	desktopNotifier *notify.Notifier
+
+	// 告警 Webhook 输出实例
+	alertWebhook *webhook.Sink
+
+	// 可移动介质监控实例
+	mountMonitor *removable.MountMonitor
+
+	// 实时文件写入监控实例
+	realtimeMonitor *realtime.Monitor
+
+	// 容器文件系统扫描实例
+	containerScanner *container.Scanner
+
+	// 剪贴板监控实例
+	clipboardMonitor *clipboard.Monitor
+
+	// 打印作业检测实例
+	printInspector *printjob.Inspector
+
+	// 本机检测服务实例
+	detectAPI *detectapi.Server
+
+	// 数据密钥轮换实例
+	keyRotator *keyrotate.Rotator
+
+	// 离线模式数据包打包实例
+	offlineBundler *offline.Bundler
+
+	// 自我保护实例
+	selfProtect *selfprotect.Monitor
+
+	// 目录树基线校验实例
+	baselineMonitor *merkle.Monitor
+
+	// 内核模块与 LD_PRELOAD 劫持检测实例
+	hijackDetector *hijack.Detector
+	canaryMonitor  *canary.Monitor
+	listenMonitor  *listener.Monitor
+	dnsGuard       *dnsguard.Guard
+	geoDB          *geoip.DB
+	geoRules       *geoip.Rules
+	bandwidthMon   *bandwidth.Monitor
+	conntrackMon   *conntrack.Monitor
+	netWhitelist   *whitelist.Manager
+
+	// 服务端指令拉取 (未启用拉取时为 nil，执行器见 command.Default)
+	commandPoller *command.Poller
 
 	// 安全监控服务实例
 	securityMonitorSvc *securityservice.SecurityMonitorService
+
+	// systemd 看门狗停止函数
+	stopWatchdogFn context.CancelFunc
+
+	// 单实例锁
+	instanceLock *instance.Lock
+
+	// 原地升级的目标二进制，非空时退出流程改为交接并 exec
+	upgradeBinary    string
+	upgradeStartedAt time.Time
 )
 
 // ==========================================
 // 参数解析
 // ==========================================
 
+// cmdArgs 命令行参数
+type cmdArgs struct {
+	configPath    string
+	forceTakeover bool
+	supervise     bool
+	// --set key=value 配置覆盖，可重复
+	sets stringList
+}
+
+// stringList 可重复的字符串参数
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
 // parseArgs 解析命令行参数
-func parseArgs() string {
-	configPath := flag.String("c", "configs/config.yml", "配置文件路径")
+func parseArgs() cmdArgs {
+	var args cmdArgs
+	flag.StringVar(&args.configPath, "c", "configs/config.yml", "配置文件路径")
+	flag.BoolVar(&args.forceTakeover, "force-takeover", false, "数据目录已被其他实例占用时结束该实例并接管")
+	flag.BoolVar(&args.supervise, "supervise", false, "以监护进程运行客户端，被结束或暂停时记录事件并重新拉起 (用于无 systemd 的主机)")
+	flag.Var(&args.sets, "set", "覆盖配置项 key=value (如 scanner.workers=4)，可重复，优先级高于环境变量 FILEWATCHER_* 与配置文件")
 	flag.Parse()
-	return *configPath
+	return args
+}
+
+// runSubcommand 执行不启动客户端的子命令，args 不是子命令时 ok 为 false
+//
+//	filewatcherd validate [-c config.yml]   按配置结构校验配置文件，有问题时退出码为 1
+//	filewatcherd config print-defaults      输出带注释的完整默认配置
+//	filewatcherd config encrypt [值]         使用密钥管理后端加密配置值，输出 ENC[...]
+//	filewatcherd export-bundle [-all] <目录>  离线模式下将数据包导出到移动介质
+//	filewatcherd import-ack <回执文件>        导入管理平台的送达回执，删除已送达的数据包
+func runSubcommand(args []string) (code int, ok bool) {
+	switch args[0] {
+	case "validate":
+		fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+		configPath := fs.String("c", "configs/config.yml", "配置文件路径")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2, true
+		}
+		issues, err := config.Validate(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "配置校验失败: %v\n", err)
+			return 2, true
+		}
+		if len(issues) == 0 {
+			fmt.Printf("配置校验通过: %s\n", *configPath)
+			return 0, true
+		}
+		fmt.Fprintf(os.Stderr, "%s 存在 %d 个问题:\n", *configPath, len(issues))
+		for _, issue := range issues {
+			fmt.Fprintf(os.Stderr, "  - %s\n", issue)
+		}
+		return 1, true
+	case "config":
+		if len(args) >= 2 && args[1] == "encrypt" {
+			return runConfigEncrypt(args[2:]), true
+		}
+		if len(args) < 2 || args[1] != "print-defaults" {
+			fmt.Fprintln(os.Stderr, "用法: filewatcherd config print-defaults | config encrypt [-c 配置文件] [值]")
+			return 2, true
+		}
+		data, err := config.DefaultsYAML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "生成默认配置失败: %v\n", err)
+			return 1, true
+		}
+		os.Stdout.Write(data)
+		return 0, true
+	case "export-bundle":
+		return runExportBundle(args[1:]), true
+	case "import-ack":
+		return runImportAck(args[1:]), true
+	}
+	return 0, false
 }
 
 // ==========================================
@@ -55,6 +256,10 @@ func loadConfig(configPath string) error {
 		return fmt.Errorf("加载配置文件失败: %v", err)
 	}
 	fmt.Printf("配置文件加载成功: %s\n", configPath)
+	if err := initConfigSecrets(); err != nil {
+		return fmt.Errorf("解密配置项失败: %w", err)
+	}
+	i18n.SetLocale(config.Get().Agent.Locale)
 	return nil
 }
 
@@ -68,25 +273,73 @@ func initLogger() error {
 	fmt.Println("正在初始化日志系统...")
 	if err := logger.Setup(logger.Options{
 		Level:      cfg.Agent.LogLevel,
+		Format:     cfg.Agent.LogFormat,
+		Modules:    cfg.Agent.LogModules,
 		FilePath:   cfg.Agent.LogFile,
 		MaxSize:    cfg.Agent.LogMaxSize,
 		MaxBackups: cfg.Agent.LogMaxBackups,
 		MaxAge:     cfg.Agent.LogMaxAge,
 		Compress:   cfg.Agent.LogCompress,
 		Stdout:     cfg.Agent.LogStdout,
+		Ship:       logShipConfig(cfg.Agent.LogShip),
 	}); err != nil {
 		return fmt.Errorf("日志系统初始化失败: %w", err)
 	}
+	// 配置热加载时按配置重置级别，通过本机接口做的临时调整随之失效
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := logger.ResetLevels(cfg.Agent.LogLevel, cfg.Agent.LogModules); err != nil {
+			logger.Error("日志级别重载失败，沿用旧级别", "error", err)
+		}
+	})
 	logger.Info("Agent initialized", "version", config.Version)
 	return nil
 }
 
+// logShipConfig 日志远程投递参数，未启用时返回 nil
+func logShipConfig(c config.LogShipConfig) *logger.ShipConfig {
+	if !c.Enable {
+		return nil
+	}
+	return &logger.ShipConfig{
+		Protocol:      c.Protocol,
+		Address:       c.Address,
+		Headers:       c.Headers,
+		Level:         c.Level,
+		BufferSize:    c.BufferSize,
+		BatchSize:     c.BatchSize,
+		FlushInterval: c.FlushInterval,
+		Timeout:       c.Timeout,
+	}
+}
+
 // initSecurity 初始化安全模块（KMS、加密引擎等）
 func initSecurity() error {
 	fmt.Println("正在初始化安全模块...")
 	if err := security.Setup(); err != nil {
 		return fmt.Errorf("安全模块初始化失败: %w", err)
 	}
+	if err := initKMS(); err != nil {
+		return fmt.Errorf("密钥管理后端初始化失败: %w", err)
+	}
+	if err := initPayloadEncryption(); err != nil {
+		return fmt.Errorf("上报载荷加密初始化失败: %w", err)
+	}
+	if config.Get().Server.Offline.Enable {
+		// 离线数据包只能用服务端公钥加密保存
+		if envelope.Default() == nil {
+			return fmt.Errorf("离线模式需要启用 server.payload_encryption")
+		}
+	} else {
+		// 证书缺失时上报不可用，但不影响本地检测
+		if err := initServerTLS(); err != nil {
+			logger.Error("上报通道 TLS 初始化失败", "error", err)
+		}
+		// 限流配置无效时不限流
+		if err := initUploadQoS(); err != nil {
+			logger.Error("上报限流配置无效", "error", err)
+		}
+		initReportStream()
+	}
 	logger.Info("安全模块初始化成功")
 	return nil
 }
@@ -161,12 +414,31 @@ func initIdentity() error {
 	return nil
 }
 
+// initAgentUUID 加载或派生由硬件决定的本机稳定标识，重装客户端后保持不变
+func initAgentUUID() error {
+	id, err := config.InitAgentUUID(config.Get().Agent.DataDir)
+	if err != nil {
+		return err
+	}
+	model.SetAgentUUID(id)
+	logger.Info("本机标识", "agent_uuid", id)
+	return nil
+}
+
 // initDetectorManager 初始化全局检测器管理器
 func initDetectorManager() error {
 	fmt.Println("正在初始化检测器管理器...")
 	cfg := config.Get()
 	id := identity.Get()
 
+	// 规则签名校验需在子检测器加载策略之前设置
+	sc := cfg.Security.RuleSignature
+	verifier, err := policy.NewVerifier(policy.VerifyMode(sc.Mode), sc.PublicKey)
+	if err != nil {
+		return fmt.Errorf("规则签名校验配置无效: %w", err)
+	}
+	policy.SetDefaultVerifier(verifier)
+
 	detectorCfg := detector.GlobalConfig{
 		// 检测模块开关
 		EnableElectronicLabel: true,
@@ -174,12 +446,27 @@ func initDetectorManager() error {
 		EnableLayout:          true,
 		EnableHash:            true,
 		EnableKeywords:        true,
+		EnableWasmRules:       true,
+		EnableFingerprint:     true,
+		EnableEDM:             true,
+		EnableXattrLabel:      true,
 
 		// 检测配置
 		SecretMarkerOCR: true,
+		MarkerSampling: &model.SamplingParams{
+			HeadSize:   cfg.Scanner.Sampling.HeadSizeKB * 1024,
+			TailSize:   cfg.Scanner.Sampling.TailSizeKB * 1024,
+			Windows:    cfg.Scanner.Sampling.Windows,
+			WindowSize: cfg.Scanner.Sampling.WindowSizeKB * 1024,
+			Seed:       cfg.Scanner.Sampling.Seed,
+		},
 		LayoutThreshold: 0.8,
 		LayoutEnableOCR: true,
 
+		// 告警附带的命中位置
+		MaxMatchLocations: cfg.Scanner.MatchContext.MaxLocations,
+		MatchContextChars: cfg.Scanner.MatchContext.ContextChars,
+
 		// 基础环境信息（从 identity 读取）
 		CurrentCompany:      id.Company,
 		CurrentComputerName: id.ComputerName,
@@ -193,27 +480,143 @@ func initDetectorManager() error {
 	}
 
 	mgr := detector.InitGlobalManager(detectorCfg)
+	detectorMgr = mgr
 
 	if err := mgr.LoadConfig(detectorCfg.ConfigPath); err != nil {
 		logger.Warn("加载检测器配置失败，使用默认配置", "error", err)
 	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		dc := mgr.Config()
+		dc.MaxMatchLocations = cfg.Scanner.MatchContext.MaxLocations
+		dc.MatchContextChars = cfg.Scanner.MatchContext.ContextChars
+		mgr.UpdateConfig(dc)
+	})
+
+	// 插件配置错误不中断程序，仅内置检测模块生效
+	if err := mgr.SetPlugins(detectorPlugins(cfg.Scanner.Plugins)); err != nil {
+		logger.Error("检测插件配置无效", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := mgr.SetPlugins(detectorPlugins(cfg.Scanner.Plugins)); err != nil {
+			logger.Error("检测插件重载失败，沿用旧插件", "error", err)
+			return
+		}
+		logger.Info("检测插件已重载", "count", len(cfg.Scanner.Plugins))
+	})
+
+	// WASM 规则无效时不中断程序，其余检测模块照常生效；策略同步更新 policy.json 后通过 SIGHUP 重新加载
+	if err := loadWasmRules(mgr, cfg); err != nil {
+		logger.Error("WASM 脚本规则加载失败", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := loadWasmRules(mgr, cfg); err != nil {
+			logger.Error("WASM 脚本规则重载失败，沿用旧规则", "error", err)
+		}
+	})
+
+	// 内容指纹规则同上，无效时其余检测模块照常生效
+	if err := loadFingerprintRules(mgr, cfg); err != nil {
+		logger.Error("内容指纹规则加载失败", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := loadFingerprintRules(mgr, cfg); err != nil {
+			logger.Error("内容指纹规则重载失败，沿用旧规则", "error", err)
+		}
+	})
+	if err := loadEDMRules(mgr, cfg); err != nil {
+		logger.Error("精确数据匹配数据表加载失败", "error", err)
+	}
+	if err := loadKeywordRules(mgr, cfg); err != nil {
+		logger.Error("关键词模式与正则规则加载失败", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := loadKeywordRules(mgr, cfg); err != nil {
+			logger.Error("关键词模式与正则规则重载失败，沿用旧规则", "error", err)
+		}
+	})
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := loadEDMRules(mgr, cfg); err != nil {
+			logger.Error("精确数据匹配数据表重载失败，沿用旧数据表", "error", err)
+		}
+	})
+
+	// 路由配置无效时使用默认路由
+	routes, err := detector.ParseRoutes(cfg.Scanner.Routing)
+	if err != nil {
+		logger.Error("检测路由配置无效，使用默认路由", "error", err)
+		routes = detector.DefaultRoutes()
+	}
+	mgr.SetRoutes(routes)
+	config.OnReload(func(cfg *config.AppConfig) {
+		routes, err := detector.ParseRoutes(cfg.Scanner.Routing)
+		if err != nil {
+			logger.Error("检测路由重载失败，沿用旧路由", "error", err)
+			return
+		}
+		mgr.SetRoutes(routes)
+	})
+
+	// 子检测器熔断：状态变化写入系统审计
+	mgr.SetBreakerConfig(detectorBreaker(cfg.Scanner.Breaker))
+	mgr.SetHealthHandler(auditDetectorHealth)
+	config.OnReload(func(cfg *config.AppConfig) {
+		mgr.SetBreakerConfig(detectorBreaker(cfg.Scanner.Breaker))
+	})
 
 	logger.Info("检测器管理器初始化成功")
 	return nil
 }
 
-// initScannerService 初始化涉密检测服务
-func initScannerService() error {
-	fmt.Println("正在初始化涉密检测服务...")
+// detectorBreaker 将配置转换为熔断参数
+func detectorBreaker(c config.BreakerConfig) detector.BreakerConfig {
+	return detector.BreakerConfig{Threshold: c.Threshold, Cooldown: c.Cooldown}
+}
 
-	// 创建存储处理器
-	storageHandler := detectorservice.NewStorageHandler()
+// auditDetectorHealth 记录检测模块熔断与恢复
+func auditDetectorHealth(ev detector.HealthEvent) {
+	opType := model.OpTypeDetectorRecovered
+	message := fmt.Sprintf("检测模块 %s 对 %s 格式已恢复", ev.Module, ev.Format)
+	if ev.Open {
+		opType = model.OpTypeDetectorTripped
+		message = fmt.Sprintf("检测模块 %s 对 %s 格式连续失败 %d 次，暂停检测: %s", ev.Module, ev.Format, ev.Failures, ev.LastError)
+		logger.Error("检测模块熔断", "module", ev.Module, "format", ev.Format, "failures", ev.Failures, "error", ev.LastError)
+	} else {
+		logger.Info("检测模块恢复", "module", ev.Module, "format", ev.Format)
+	}
 
-	// 创建检测服务
-	scannerSvc = detectorservice.NewScannerService(storageHandler)
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	record := model.NewSystemAuditRequest(
+		fmt.Sprintf("brk%d", ev.Time.UnixMilli()),
+		"system",
+		ev.Time.Format("2006-01-02 15:04:05.000"),
+		model.LogTypeOther,
+		opType,
+		message,
+	)
+	if err := stores.AuditLogs.Push(*record); err != nil {
+		logger.Error("保存检测模块健康审计日志失败", "error", err)
+	}
+}
 
-	logger.Info("涉密检测服务初始化成功")
-	return nil
+// detectorPlugins 将配置转换为插件参数
+func detectorPlugins(list []config.DetectorPluginConfig) []plugin.Config {
+	cfgs := make([]plugin.Config, 0, len(list))
+	for _, pc := range list {
+		cfgs = append(cfgs, plugin.Config{
+			Name:        pc.Name,
+			Command:     pc.Command,
+			Args:        pc.Args,
+			Env:         pc.Env,
+			Timeout:     pc.Timeout,
+			MaxFileSize: pc.MaxFileSizeMB * 1024 * 1024,
+			SendContent: pc.SendContent,
+			Options:     pc.Options,
+		})
+	}
+	return cfgs
 }
 
 // initSecurityMonitor 初始化安全监控服务
@@ -270,18 +673,6 @@ func loadSecurityMonitorConfig() securityservice.SecurityMonitorConfig {
 // 服务启动
 // ==========================================
 
-// startScannerService 启动涉密检测服务
-func startScannerService() {
-	if scannerSvc == nil {
-		logger.Warn("涉密检测服务未初始化，跳过启动")
-		return
-	}
-
-	fmt.Println("正在启动涉密检测服务...")
-	scannerSvc.Start()
-	logger.Info("涉密检测服务启动成功")
-}
-
 // startSecurityMonitor 启动安全监控服务 (非阻塞)
 func startSecurityMonitor() {
 	if securityMonitorSvc == nil {
@@ -298,20 +689,9 @@ func startSecurityMonitor() {
 	logger.Info("安全监控服务启动成功")
 }
 
-// startPostManager 启动上报服务 (非阻塞)
-func startPostManager() {
-	fmt.Println("正在启动 PostManager 上报服务...")
-	if err := postmanager.Init(); err != nil {
-		logger.Error("postmanager模块初始化失败", "error", err)
-		return
-	}
-	postmanager.StartAllReporting()
-	logger.Info("所有上报服务启动成功")
-}
-
 // startFileWatcherSimulation 模拟文件监控 (仅用于测试数据生产)
 func startFileWatcherSimulation() {
-	if scannerSvc == nil {
+	if scanQueue == nil {
 		logger.Warn("涉密检测服务未初始化，跳过文件监控模拟")
 		return
 	}
@@ -330,7 +710,10 @@ func startFileWatcherSimulation() {
 				if err != nil || info.IsDir() {
 					return nil
 				}
-				scannerSvc.SubmitTask(path)
+				if !pathfilter.Default().Allow(path) {
+					return nil
+				}
+				scanQueue.Submit(detectorservice.ScanTask{Path: path, Priority: detectorservice.PriorityRealtime, Source: "simulation"})
 				time.Sleep(50 * time.Millisecond)
 				return nil
 			})
@@ -353,11 +736,87 @@ func stopSecurityMonitor() {
 	}
 }
 
-// stopScannerService 停止涉密检测服务
-func stopScannerService() {
-	if scannerSvc != nil {
-		fmt.Println("正在停止涉密检测服务...")
-		scannerSvc.Stop()
+// stopDetectorPlugins 停止外部检测插件进程
+// loadWasmRules 从策略目录加载 WASM 脚本规则 (经规则签名校验)
+func loadWasmRules(mgr *detector.Manager, cfg *config.AppConfig) error {
+	var rules model.WasmRuleDetectConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleWasmRuleDetect, &rules); err != nil {
+		return err
+	}
+
+	wc := cfg.Scanner.WasmRules
+	limits := wasmrule.Limits{
+		MemoryLimit: wc.MemoryLimitMB * 1024 * 1024,
+		Timeout:     wc.Timeout,
+		MaxTextSize: wc.MaxTextSizeMB * 1024 * 1024,
+	}
+	if err := mgr.SetWasmRules(&rules, limits); err != nil {
+		return err
+	}
+	if len(rules.Rules) > 0 {
+		logger.Info("WASM 脚本规则已加载", "count", len(rules.Rules))
+	}
+	return nil
+}
+
+// loadFingerprintRules 从策略目录加载内容指纹规则 (经规则签名校验)
+func loadFingerprintRules(mgr *detector.Manager, cfg *config.AppConfig) error {
+	var rules model.FingerprintDetectConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleFingerprintDetect, &rules); err != nil {
+		return err
+	}
+
+	fc := cfg.Scanner.Fingerprint
+	th := fingerprint.Thresholds{
+		MaxDistance:    fc.MaxDistance,
+		MinContainment: fc.MinContainment,
+		MinMatches:     fc.MinMatches,
+	}
+	if err := mgr.SetFingerprintRules(&rules, th); err != nil {
+		return err
+	}
+	if len(rules.Rules) > 0 {
+		logger.Info("内容指纹规则已加载", "count", len(rules.Rules))
+	}
+	return nil
+}
+
+// loadEDMRules 从策略目录加载精确数据匹配数据表 (经规则签名校验)
+func loadEDMRules(mgr *detector.Manager, cfg *config.AppConfig) error {
+	var rules model.EDMDetectConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleEDMDetect, &rules); err != nil {
+		return err
+	}
+
+	ec := cfg.Scanner.EDM
+	if err := mgr.SetEDMRules(&rules, edm.Thresholds{MinColumns: ec.MinColumns, MinRows: ec.MinRows}); err != nil {
+		return err
+	}
+	if len(rules.Rules) > 0 {
+		logger.Info("精确数据匹配数据表已加载", "tables", len(rules.Rules))
+	}
+	return nil
+}
+
+// loadKeywordRules 从关键词检测策略加载内置模式规则与正则规则 (经规则签名校验)
+func loadKeywordRules(mgr *detector.Manager, cfg *config.AppConfig) error {
+	var rules model.KeywordDetectConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleKeywordDetect, &rules); err != nil {
+		return err
+	}
+
+	kc := cfg.Scanner.Keyword
+	return mgr.SetKeywordRules(&rules, keyword.Limits{
+		MaxLength:  kc.RegexMaxLength,
+		MaxProgram: kc.RegexMaxProgram,
+		MaxMatches: kc.RegexMaxMatches,
+	})
+}
+
+func stopDetectorPlugins() {
+	if detectorMgr != nil {
+		fmt.Println("正在停止检测插件...")
+		detectorMgr.Close()
 	}
 }
 
@@ -376,16 +835,34 @@ func flushStorage() {
 // ==========================================
 
 func main() {
+	// 子命令的输出可能被重定向为文件 (如默认配置)，先于其他输出处理
+	if len(os.Args) > 1 {
+		if code, ok := runSubcommand(os.Args[1:]); ok {
+			os.Exit(code)
+		}
+	}
 	fmt.Println("1")
 	// ==========================================
 	// 阶段 1: 参数解析与配置加载
 	// ==========================================
-	configPath := parseArgs()
+	args := parseArgs()
 
-	if err := loadConfig(configPath); err != nil {
+	if err := config.SetOverrides(args.sets); err != nil {
+		fmt.Fprintf(os.Stderr, "--set 参数无效: %v\n", err)
+		os.Exit(2)
+	}
+	if err := loadConfig(args.configPath); err != nil {
 		panic(fmt.Sprintf("配置加载失败: %v", err))
 	}
 
+	if args.supervise {
+		if err := runSupervisor(); err != nil {
+			fmt.Fprintf(os.Stderr, "监护进程退出: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// ==========================================
 	// 阶段 2: 基础设施初始化
 	// ==========================================
@@ -393,6 +870,18 @@ func main() {
 		panic(fmt.Sprintf("日志系统初始化失败: %v", err))
 	}
 
+	// 必须在打开数据库之前获取，另一实例运行时直接退出
+	if err := acquireInstanceLock(args.forceTakeover); err != nil {
+		fmt.Fprintf(os.Stderr, "启动失败: %v\n", err)
+		logger.Error("单实例锁获取失败", "error", err)
+		os.Exit(1)
+	}
+
+	// 上报模型构造时填入本机标识，需先于任何上报记录的生成
+	if err := initAgentUUID(); err != nil {
+		panic(fmt.Sprintf("本机标识初始化失败: %v", err))
+	}
+
 	// 安全模块必须在数据库之前初始化（存储需要加密功能）
 	if err := initSecurity(); err != nil {
 		panic(fmt.Sprintf("安全模块初始化失败: %v", err))
@@ -405,6 +894,8 @@ func main() {
 	if err := initStores(); err != nil {
 		panic(fmt.Sprintf("存储实例初始化失败: %v", err))
 	}
+	finishUpgrade()
+	importSupervisorEvents()
 
 	// ==========================================
 	// 阶段 3: 业务模块初始化
@@ -413,14 +904,67 @@ func main() {
 		panic(fmt.Sprintf("身份信息初始化失败: %v", err))
 	}
 
+	initBudget()
+	initTracing()
+
 	if err := initDetectorManager(); err != nil {
 		panic(fmt.Sprintf("检测器管理器初始化失败: %v", err))
 	}
 
+	if err := initPathFilter(); err != nil {
+		panic(fmt.Sprintf("路径过滤规则初始化失败: %v", err))
+	}
+
 	if err := initScannerService(); err != nil {
 		panic(fmt.Sprintf("涉密检测服务初始化失败: %v", err))
 	}
 
+	// 定时扫描配置错误不中断程序，仅禁用定时扫描
+	if err := initScanScheduler(); err != nil {
+		logger.Error("定时扫描调度器初始化失败", "error", err)
+	}
+	initRealtimeMonitor()
+
+	initAlertAggregator()
+	initLineageTracker()
+	initRuleStats()
+	// 通知模板错误不中断程序，仅禁用桌面通知
+	if err := initDesktopNotifier(); err != nil {
+		logger.Error("桌面用户通知初始化失败", "error", err)
+	}
+	// Webhook 配置错误不中断程序，仅禁用 Webhook 输出
+	if err := initAlertWebhook(); err != nil {
+		logger.Error("告警 Webhook 输出初始化失败", "error", err)
+	}
+	// 处置规则错误不中断程序，命中结果仅告警
+	// 取证库错误不中断程序，evidence 动作仅记录日志
+	if err := initEvidenceVault(); err != nil {
+		logger.Error("告警取证库初始化失败", "error", err)
+	}
+	if err := initResponseEngine(); err != nil {
+		logger.Error("处置策略初始化失败", "error", err)
+	}
+	initMountMonitor()
+	initContainerScanner()
+	initExfilCorrelator()
+	initClipboardMonitor()
+	initPrintInspector()
+	initNetWhitelist()
+	initDetectAPI()
+	initKeyRotator()
+	initOfflineBundler()
+	initSelfProtect(args.configPath)
+	initBaselines()
+	initCommandExecutor()
+	initHijackDetector()
+	initCanary()
+	initListenMonitor()
+	initGeoIP()
+	initNetPcap()
+	initDNSGuard()
+	initBandwidthMonitor()
+	initConntrack()
+
 	// 安全监控初始化失败不中断程序
 	if err := initSecurityMonitor(); err != nil {
 		logger.Error("安全监控服务初始化失败", "error", err)
@@ -430,7 +974,28 @@ func main() {
 	// 阶段 4: 服务启动
 	// ==========================================
 	startScannerService()
+	restorePendingScans()
+	startRealtimeMonitor()
+	startScanScheduler()
+	startAlertAggregator()
+	startAlertWebhook()
+	startMountMonitor()
+	startContainerScanner()
+	startClipboardMonitor()
+	startPrintInspector()
+	startDetectAPI()
+	startKeyRotator()
+	startSelfProtect()
+	startBaselines()
+	startHijackDetector()
+	startCanary()
+	startListenMonitor()
+	startDNSGuard()
+	startBandwidthMonitor()
+	startConntrack()
 	startPostManager()
+	startCommandPoller()
+	startOfflineBundler()
 	startSecurityMonitor()
 	startFileWatcherSimulation()
 
@@ -439,20 +1004,84 @@ func main() {
 	// ==========================================
 	fmt.Println("=== 应用已完全启动 (按 Ctrl+C 停止) ===")
 	logger.Info("应用启动完成")
+	notifySystemd(systemd.StateReady, systemd.Status("running"))
+	startWatchdog()
 
 	// ==========================================
 	// 阶段 6: 优雅退出
 	// ==========================================
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	sig := <-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR2)
+
+	var sig os.Signal
+	for sig = range sigChan {
+		// SIGUSR2: 原地升级为磁盘上已替换的新版本二进制
+		if sig == syscall.SIGUSR2 {
+			if requestUpgrade() {
+				break
+			}
+			continue
+		}
+		if sig != syscall.SIGHUP {
+			break
+		}
+		// SIGHUP: 重载配置 (过滤规则等)
+		notifySystemd(systemd.StateReloading)
+		if err := config.Reload(); err != nil {
+			logger.Error("配置重载失败", "error", err)
+		} else {
+			logger.Info("配置已重载")
+		}
+		notifySystemd(systemd.StateReady, systemd.Status("running"))
+	}
 	fmt.Printf("\n[Main] 收到信号: %v，正在关闭服务...\n", sig)
+	if upgradeBinary != "" {
+		// exec 后 PID 不变，systemd 视为重载，新进程就绪后发送 READY=1
+		notifySystemd(systemd.StateReloading, systemd.Status("upgrading"))
+	} else {
+		notifySystemd(systemd.StateStopping)
+	}
+	stopWatchdog()
 
 	// 按依赖顺序停止服务（后启动的先停止）
 	stopSecurityMonitor()
+	stopCommandExecutor()
+	stopReportStream()
+	stopConntrack()
+	stopBandwidthMonitor()
+	stopDNSGuard()
+	stopNetPcap()
+	stopListenMonitor()
+	stopCanary()
+	stopHijackDetector()
+	stopBaselines()
+	stopSelfProtect()
+	stopKeyRotator()
+	var apiFile *os.File
+	if upgradeBinary != "" {
+		apiFile = handoffDetectAPI()
+	} else {
+		stopDetectAPI()
+	}
+	stopPrintInspector()
+	stopClipboardMonitor()
+	stopContainerScanner()
+	stopMountMonitor()
+	stopScanScheduler()
+	stopRealtimeMonitor()
 	stopScannerService()
+	stopAlertAggregator()
+	stopAlertWebhook()
+	stopDetectorPlugins()
+	stopTracing()
+	stopOfflineBundler()
 	flushStorage()
+	// 发送缓冲中的远程日志，此后日志只输出到标准错误
+	logger.Close()
+	if upgradeBinary != "" {
+		execUpgrade(apiFile)
+	}
+	releaseInstanceLock()
 
 	fmt.Println("[Main] 程序已安全退出")
 }
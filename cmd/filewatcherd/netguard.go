@@ -0,0 +1,412 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/procinfo"
+	"linuxFileWatcher/internal/security/netguard/bandwidth"
+	"linuxFileWatcher/internal/security/netguard/conntrack"
+	"linuxFileWatcher/internal/security/netguard/dnsguard"
+	"linuxFileWatcher/internal/security/netguard/geoip"
+	"linuxFileWatcher/internal/security/netguard/listener"
+	"linuxFileWatcher/internal/security/netguard/pcap"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initListenMonitor 初始化监听端口监控
+// 未配置白名单时以客户端启动时已存在的监听为准
+func initListenMonitor() {
+	lc := config.Get().Security.NetGuard.Listen
+	if !lc.Enable {
+		return
+	}
+	m, err := listener.New(listener.Config{
+		Interval:       lc.CheckInterval,
+		Host:           lc.Host,
+		Processes:      lc.Processes,
+		AllowPorts:     lc.AllowPorts,
+		AllowProcesses: lc.AllowProcesses,
+	}, reportListener)
+	if err != nil {
+		logger.Error("监听端口监控配置无效", "error", err)
+		return
+	}
+	listenMonitor = m
+}
+
+// reportListener 发现白名单之外的监听端口时生成网络安全事件，附带所属程序路径
+func reportListener(l listener.Listener) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	owner := "unknown process"
+	if l.PID > 0 {
+		owner = fmt.Sprintf("%s, pid %d", l.Exe, l.PID)
+		if l.Exe == "" {
+			owner = fmt.Sprintf("%s, pid %d", l.Process, l.PID)
+		}
+	}
+	msg := fmt.Sprintf("新监听端口 %s (%s)", l.String(), owner)
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddNetworkAlert(l.Address, uint16(l.Port), msg)
+	report.SetSession(processSession(l.PID))
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存监听端口安全事件失败", "error", err)
+	}
+}
+
+// processSession 进程所属的登录会话，多用户服务器上据此确定实际操作人；进程已退出时为空
+func processSession(pid int) model.SessionInfo {
+	if pid <= 0 {
+		return model.SessionInfo{}
+	}
+	p, err := procinfo.Lookup(pid)
+	if err != nil {
+		return model.SessionInfo{}
+	}
+	return p.SessionInfo
+}
+
+// startListenMonitor 启动监听端口监控
+func startListenMonitor() {
+	if listenMonitor != nil {
+		listenMonitor.Start()
+	}
+}
+
+// stopListenMonitor 停止监听端口监控
+func stopListenMonitor() {
+	if listenMonitor != nil {
+		listenMonitor.Stop()
+	}
+}
+
+// initGeoIP 加载 GeoIP 库与按国家 / ASN 的规则，失败时网络告警不附带地理信息
+func initGeoIP() {
+	gc := config.Get().Security.NetGuard.GeoIP
+	if len(gc.Databases) == 0 {
+		return
+	}
+	db, err := geoip.Open(gc.Databases...)
+	if err != nil {
+		logger.Error("加载 GeoIP 库失败", "error", err)
+		return
+	}
+	rules, err := geoip.ParseRules(gc.Allow, gc.Alert)
+	if err != nil {
+		logger.Error("GeoIP 规则无效", "error", err)
+		rules = nil
+	}
+	geoDB, geoRules = db, rules
+	logger.Info("GeoIP 库已加载", "databases", len(gc.Databases), "rules", !rules.Empty())
+}
+
+// lookupGeo 查询对端 IP 的地理位置与 ASN
+func lookupGeo(ip net.IP) (geoip.Info, model.GeoInfo) {
+	info, err := geoDB.Lookup(ip)
+	if err != nil {
+		logger.Debug("GeoIP 查询失败", "ip", ip, "error", err)
+	}
+	return info, model.GeoInfo{Country: info.Country, City: info.City, ASN: info.ASN, ASOrg: info.ASOrg}
+}
+
+// checkResolverGeo 按 GeoIP 规则检查 DNS 解析服务器
+func checkResolverGeo(resolver net.IP) (string, bool) {
+	info, _ := lookupGeo(resolver)
+	if geoRules.Evaluate(info) != geoip.VerdictAlert {
+		return "", false
+	}
+	return info.String(), true
+}
+
+// initNetPcap 初始化网络告警抓包，需在各网络告警模块之前调用
+func initNetPcap() {
+	cfg := config.Get()
+	pc := cfg.Security.NetGuard.Pcap
+	if !pc.Enable {
+		return
+	}
+	dir := pc.Dir
+	if dir == "" {
+		dir = filepath.Join(cfg.Agent.DataDir, "pcap")
+	}
+	r, err := pcap.New(pcap.Config{
+		Dir:           dir,
+		MaxDuration:   pc.MaxDuration,
+		MaxBytes:      pc.MaxSizeMB << 20,
+		SnapLen:       pc.SnapLen,
+		MaxConcurrent: pc.MaxConcurrent,
+		Quota:         pc.QuotaMB << 20,
+	})
+	if err != nil {
+		logger.Error("网络告警抓包初始化失败", "dir", dir, "error", err)
+		return
+	}
+	pcap.SetDefault(r)
+	logger.Info("网络告警抓包已启用", "dir", dir)
+}
+
+// captureAlert 对网络告警的对端抓包，抓包不可用或并发已满时只记录日志
+func captureAlert(ip net.IP, port uint16, alertTime time.Time, meta pcap.Meta) {
+	r := pcap.Default()
+	if r == nil {
+		return
+	}
+	path, err := r.Capture(ip, port, alertTime, meta)
+	if err != nil {
+		logger.Warn("网络告警抓包失败", "ip", ip, "source", meta.Source, "error", err)
+		return
+	}
+	logger.Info("网络告警抓包", "ip", ip, "source", meta.Source, "file", path)
+}
+
+// stopNetPcap 结束进行中的抓包
+func stopNetPcap() {
+	if r := pcap.Default(); r != nil {
+		r.Stop()
+	}
+}
+
+// initDNSGuard 初始化 DNS 查询监控
+// 黑名单由策略同步下发 (经规则签名校验)，需在 initDetectorManager 设置签名校验器之后调用
+func initDNSGuard() {
+	cfg := config.Get()
+	dc := cfg.Security.NetGuard.DNS
+	if !dc.Enable {
+		return
+	}
+	gc := dnsguard.Config{
+		Block:         dc.Block,
+		Deduplication: dc.DeduplicationTime,
+	}
+	// 去重记录落盘，重启后有效期内不重复告警
+	if stores := storage.GetStores(); stores != nil {
+		gc.DedupStore = stores.NetguardSeen
+	}
+	if !geoRules.Empty() {
+		gc.ResolverCheck = checkResolverGeo
+	}
+	dnsGuard = dnsguard.New(gc, reportDNSQuery)
+
+	// 策略无效时不中断程序；策略同步更新 policy.json 后通过 SIGHUP 重新加载
+	if err := loadDNSBlocklist(cfg); err != nil {
+		logger.Error("DNS 黑名单加载失败", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := loadDNSBlocklist(cfg); err != nil {
+			logger.Error("DNS 黑名单重载失败，沿用旧规则", "error", err)
+		}
+	})
+}
+
+// loadDNSBlocklist 合并本地黑名单与策略下发的规则，同一域名以拦截规则为准
+func loadDNSBlocklist(cfg *config.AppConfig) error {
+	var bl model.DNSBlockDetectConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleDNSBlockDetect, &bl); err != nil {
+		return err
+	}
+
+	local := cfg.Security.NetGuard.DNS.Blocklist
+	rules := make([]dnsguard.Rule, 0, len(local)+len(bl.Rules))
+	for _, d := range local {
+		rules = append(rules, dnsguard.Rule{Domain: d, Action: dnsguard.ActionAlert})
+	}
+	for _, r := range bl.Rules {
+		rules = append(rules, dnsguard.Rule{
+			RuleID: r.RuleID,
+			Domain: r.RuleContent,
+			Action: dnsguard.Action(r.Action),
+			Desc:   r.RuleDesc,
+		})
+	}
+	return dnsGuard.SetRules(rules)
+}
+
+// reportDNSQuery 查询命中黑名单或发往受限解析服务器时生成网络安全事件，附带解析服务器的地理信息
+func reportDNSQuery(q dnsguard.Query) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	info, geo := lookupGeo(q.Resolver)
+	if q.Rule.Domain == "" {
+		msg := fmt.Sprintf("DNS 查询发往受限解析服务器 %s: %s (%s)", q.Resolver, q.Name, q.Rule.Desc)
+		report := model.NewSecurityStatusReport(config.Version)
+		report.AddGeoNetworkAlert(q.Resolver.String(), 53, msg, geo)
+		if err := stores.SecurityReports.Push(*report); err != nil {
+			logger.Error("保存 DNS 安全事件失败", "error", err)
+		}
+		captureAlert(q.Resolver, 53, q.Time, pcap.Meta{Source: "dns", Message: msg, Geo: info.String()})
+		return
+	}
+
+	msg := "DNS 查询命中黑名单: " + q.Name
+	var notes []string
+	if q.Rule.Desc != "" {
+		notes = append(notes, q.Rule.Desc)
+	}
+	if q.Rule.RuleID != 0 {
+		notes = append(notes, fmt.Sprintf("rule %d", q.Rule.RuleID))
+	}
+	if q.Blocked {
+		notes = append(notes, "已拦截")
+	}
+	if len(notes) > 0 {
+		msg += " (" + strings.Join(notes, ", ") + ")"
+	}
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddGeoNetworkAlert(q.Resolver.String(), 53, msg, geo)
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存 DNS 安全事件失败", "error", err)
+	}
+	captureAlert(q.Resolver, 53, q.Time, pcap.Meta{Source: "dns", Message: msg, Geo: info.String()})
+}
+
+// startDNSGuard 启动 DNS 查询监控，抓包不可用时拦截规则仍然生效
+func startDNSGuard() {
+	if dnsGuard == nil {
+		return
+	}
+	if err := dnsGuard.Start(); err != nil {
+		logger.Error("DNS 查询监控启动失败", "error", err)
+	}
+}
+
+// stopDNSGuard 停止 DNS 查询监控并删除拦截规则
+func stopDNSGuard() {
+	if dnsGuard != nil {
+		dnsGuard.Stop()
+	}
+}
+
+// initBandwidthMonitor 初始化外发流量统计
+// 需在 initNetWhitelist、initGeoIP 之后调用，网络白名单与 GeoIP 白名单命中的对端不统计
+func initBandwidthMonitor() {
+	cfg := config.Get()
+	bc := cfg.Security.NetGuard.Bandwidth
+	if !bc.Enable {
+		return
+	}
+	allowed := append([]string(nil), bc.Whitelist...)
+	// 上报与文件上传发往服务端，不计入外发流量
+	if u, err := url.Parse(cfg.Server.URL); err == nil && u.Hostname() != "" {
+		if ips, err := net.LookupIP(u.Hostname()); err == nil {
+			for _, ip := range ips {
+				allowed = append(allowed, ip.String())
+			}
+		} else {
+			logger.Warn("解析服务端地址失败，外发流量白名单不含服务端", "host", u.Hostname(), "error", err)
+		}
+	}
+
+	bwc := bandwidth.Config{
+		Interval:  bc.CheckInterval,
+		Window:    bc.Window,
+		Threshold: uint64(bc.ThresholdMB) << 20,
+		Whitelist: allowed,
+	}
+	// 网络白名单可在运行时修改，每次判断时读取
+	bwc.Allow = func(ip net.IP) bool {
+		if netWhitelist != nil && netWhitelist.Allowed(ip) {
+			return true
+		}
+		if geoRules.Empty() {
+			return false
+		}
+		info, _ := lookupGeo(ip)
+		return geoRules.Evaluate(info) == geoip.VerdictAllow
+	}
+	m, err := bandwidth.New(bwc, reportBandwidthFlow)
+	if err != nil {
+		logger.Error("外发流量统计配置无效", "error", err)
+		return
+	}
+	bandwidthMon = m
+}
+
+// reportBandwidthFlow 窗口内向同一对端的发送量超过阈值时生成网络安全事件
+func reportBandwidthFlow(f bandwidth.Flow) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	info, geo := lookupGeo(f.Remote)
+	msg := fmt.Sprintf("大流量外发: %s 内向 %s 发送 %s (%s)",
+		f.Window, net.JoinHostPort(f.Remote.String(), fmt.Sprint(f.RemotePort)), bandwidth.FormatBytes(f.Bytes), f.Owner())
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddGeoNetworkAlert(f.Remote.String(), uint16(f.RemotePort), msg, geo)
+	report.SetSession(processSession(f.PID))
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存外发流量安全事件失败", "error", err)
+	}
+	captureAlert(f.Remote, uint16(f.RemotePort), f.Time, pcap.Meta{
+		Source:  "bandwidth",
+		Message: msg,
+		PID:     f.PID,
+		Process: f.Process,
+		Geo:     info.String(),
+	})
+}
+
+// startBandwidthMonitor 启动外发流量统计
+func startBandwidthMonitor() {
+	if bandwidthMon != nil {
+		bandwidthMon.Start()
+	}
+}
+
+// stopBandwidthMonitor 停止外发流量统计
+func stopBandwidthMonitor() {
+	if bandwidthMon != nil {
+		bandwidthMon.Stop()
+	}
+}
+
+// initConntrack 初始化连接跟踪事件订阅
+// 需在 initBandwidthMonitor 之后调用，连接销毁事件补充两次采样之间关闭的连接的发送量
+func initConntrack() {
+	cc := config.Get().Security.NetGuard.Conntrack
+	if !cc.Enable || bandwidthMon == nil {
+		return
+	}
+	if cc.Accounting {
+		if err := conntrack.EnableAccounting(); err != nil {
+			logger.Warn("开启连接跟踪字节计数失败", "error", err)
+		}
+	}
+	conntrackMon = conntrack.NewMonitor(conntrack.MonitorConfig{
+		Events: []conntrack.EventType{conntrack.EventDestroy},
+	}, bandwidthMon.ObserveConntrack)
+}
+
+// startConntrack 启动连接跟踪事件订阅，nf_conntrack 不可用时只依赖周期采样
+func startConntrack() {
+	if conntrackMon == nil {
+		return
+	}
+	if err := conntrackMon.Start(); err != nil {
+		logger.Warn("连接跟踪事件订阅不可用", "error", err)
+		conntrackMon = nil
+		return
+	}
+	logger.Info("连接跟踪事件订阅已启动")
+}
+
+// stopConntrack 停止连接跟踪事件订阅
+func stopConntrack() {
+	if conntrackMon != nil {
+		conntrackMon.Stop()
+	}
+}
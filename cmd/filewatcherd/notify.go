@@ -0,0 +1,36 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/service/notify"
+)
+
+// initDesktopNotifier 初始化桌面用户通知
+func initDesktopNotifier() error {
+	nc := config.Get().Scanner.Notify
+	if !nc.Enable {
+		return nil
+	}
+
+	templates := make(map[notify.Kind]notify.Template, len(nc.Templates))
+	for kind, t := range nc.Templates {
+		templates[notify.Kind(kind)] = notify.Template{Summary: t.Summary, Body: t.Body, Urgency: t.Urgency}
+	}
+	n, err := notify.NewNotifier(notify.Config{
+		Locale:       nc.Locale,
+		Timeout:      nc.Timeout,
+		Cooldown:     nc.Cooldown,
+		MaxPerMinute: nc.MaxPerMinute,
+		Templates:    templates,
+	}, nil, nil)
+	if err != nil {
+		return err
+	}
+	desktopNotifier = n
+
+	logger.Info("桌面用户通知已启用", "locale", nc.Locale)
+	return nil
+}
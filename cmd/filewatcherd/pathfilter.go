@@ -0,0 +1,42 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// initPathFilter 初始化全局路径过滤规则，并在配置重载时刷新
+func initPathFilter() error {
+	if err := applyPathFilter(config.Get()); err != nil {
+		return err
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := applyPathFilter(cfg); err != nil {
+			logger.Error("路径过滤规则重载失败，沿用旧规则", "error", err)
+			return
+		}
+		logger.Info("路径过滤规则已重载")
+	})
+	return nil
+}
+
+// applyPathFilter 按配置构建路径过滤器并替换全局实例
+func applyPathFilter(cfg *config.AppConfig) error {
+	fc := cfg.Scanner.Filter
+	filter, err := pathfilter.New(pathfilter.Options{
+		ExcludeDirs:  cfg.Scanner.ExcludeDirs,
+		ExcludeGlobs: fc.ExcludeGlobs,
+		IncludeGlobs: fc.IncludeGlobs,
+		ExcludeRegex: fc.ExcludeRegex,
+		MaxDepth:     fc.MaxDepth,
+		SkipFSTypes:  fc.SkipFSTypes,
+	})
+	if err != nil {
+		return err
+	}
+	pathfilter.SetDefault(filter)
+	return nil
+}
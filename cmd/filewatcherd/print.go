@@ -0,0 +1,60 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/printjob"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initPrintInspector 初始化打印作业检测
+func initPrintInspector() {
+	pc := config.Get().Scanner.Print
+	if !pc.Enable || detectorMgr == nil {
+		return
+	}
+
+	sink := func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if err := stores.Alerts.Push(*record); err != nil {
+			logger.Error("保存打印告警失败", "error", err)
+		}
+		if logItem != nil {
+			if err := stores.AlertLogs.Push(*logItem); err != nil {
+				logger.Error("保存打印告警日志失败", "error", err)
+			}
+		}
+	}
+
+	printInspector = printjob.NewInspector(printjob.Config{
+		SpoolDir:     pc.SpoolDir,
+		PollInterval: pc.PollInterval,
+		Action:       printjob.Action(pc.Action),
+		ReleaseClean: pc.ReleaseClean,
+		MaxBytes:     int64(pc.MaxSizeMB) << 20,
+	}, detectorMgr, nil, alertSink(sink))
+}
+
+// startPrintInspector 启动打印作业检测
+func startPrintInspector() {
+	if printInspector == nil {
+		return
+	}
+	printInspector.Start()
+}
+
+// stopPrintInspector 停止打印作业检测
+func stopPrintInspector() {
+	if printInspector != nil {
+		fmt.Println("正在停止打印作业检测...")
+		printInspector.Stop()
+	}
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/service/lineage"
+	"linuxFileWatcher/internal/service/realtime"
+)
+
+// initRealtimeMonitor 初始化实时文件写入监控
+// 监控 scanner.watch_dirs 下写入完成的文件，以实时优先级提交扫描；fsnotify 回退时观察到的改名提交流转追踪
+func initRealtimeMonitor() {
+	dirs := config.Get().Scanner.WatchDirs
+	if len(dirs) == 0 || scanQueue == nil {
+		return
+	}
+	realtimeMonitor = realtime.New(realtime.Config{
+		Dirs:     dirs,
+		OnRename: lineage.Observe,
+	}, scanQueue.Submit)
+}
+
+// startRealtimeMonitor 启动实时文件写入监控，失败时仅依赖定时扫描
+func startRealtimeMonitor() {
+	if realtimeMonitor == nil {
+		return
+	}
+	fmt.Println("正在启动实时文件监控...")
+	if err := realtimeMonitor.Start(); err != nil {
+		logger.Error("实时文件监控启动失败", "error", err)
+		realtimeMonitor = nil
+	}
+}
+
+// stopRealtimeMonitor 停止实时文件写入监控
+func stopRealtimeMonitor() {
+	if realtimeMonitor != nil {
+		fmt.Println("正在停止实时文件监控...")
+		realtimeMonitor.Stop()
+	}
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/removable"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initMountMonitor 初始化可移动介质监控
+func initMountMonitor() {
+	rc := config.Get().Scanner.Removable
+	if !rc.Enable || detectorMgr == nil {
+		return
+	}
+
+	fmt.Println("正在初始化可移动介质监控...")
+
+	// 命中告警写入存储，由上报服务统一发送
+	sink := func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if record != nil {
+			if err := stores.Alerts.Push(*record); err != nil {
+				logger.Error("保存介质告警失败", "error", err)
+			}
+		}
+		if logItem != nil {
+			if err := stores.AlertLogs.Push(*logItem); err != nil {
+				logger.Error("保存介质告警日志失败", "error", err)
+			}
+		}
+	}
+
+	mountMonitor = removable.NewMountMonitor(removable.Config{
+		PollInterval:         rc.PollInterval,
+		AutoScan:             rc.AutoScan,
+		BlockWriteUntilClean: rc.BlockWriteUntilClean,
+		ScanTimeout:          rc.ScanTimeout,
+	}, detectorMgr, observeExfil(alertSink(sink)))
+
+	logger.Info("可移动介质监控初始化成功")
+}
+
+// startMountMonitor 启动可移动介质监控
+func startMountMonitor() {
+	if mountMonitor == nil {
+		return
+	}
+	mountMonitor.Start()
+}
+
+// stopMountMonitor 停止可移动介质监控
+func stopMountMonitor() {
+	if mountMonitor != nil {
+		fmt.Println("正在停止可移动介质监控...")
+		mountMonitor.Stop()
+	}
+}
@@ -0,0 +1,94 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/notify"
+	"linuxFileWatcher/internal/service/response"
+)
+
+// initResponseEngine 初始化检测结果处置策略
+// 规则错误时不启用处置策略 (命中结果仅告警)；配置重载时规则错误则沿用旧规则
+func initResponseEngine() error {
+	cfg := config.Get()
+	rc := cfg.Scanner.Response
+	if !rc.Enable {
+		return nil
+	}
+
+	rules, err := compileResponsePolicy(rc)
+	if err != nil {
+		return err
+	}
+
+	quarantineDir := rc.QuarantineDir
+	if quarantineDir == "" {
+		quarantineDir = filepath.Join(cfg.Agent.DataDir, "quarantine")
+	}
+	engine := response.NewEngine(rules)
+	engine.Handle(response.ActionQuarantine, response.Quarantine(quarantineDir))
+	engine.Handle(response.ActionBlock, response.Block())
+	engine.Handle(response.ActionLabel, response.Label())
+	if evidenceVault != nil {
+		engine.Handle(response.ActionEvidence, func(_ context.Context, record *model.AlertRecord, _ response.Decision) error {
+			_, err := evidenceVault.Capture(record, time.Now())
+			return err
+		})
+	}
+	if desktopNotifier != nil {
+		engine.Handle(response.ActionNotify, func(_ context.Context, record *model.AlertRecord, d response.Decision) error {
+			kind := notify.KindDetection
+			if d.Has(response.ActionBlock) || d.Has(response.ActionQuarantine) {
+				kind = notify.KindBlocked
+			}
+			desktopNotifier.NotifyAsync(notify.Event{Kind: kind, Record: record})
+			return nil
+		})
+	}
+	responseEngine = engine
+
+	config.OnReload(func(cfg *config.AppConfig) {
+		rules, err := compileResponsePolicy(cfg.Scanner.Response)
+		if err != nil {
+			logger.Error("处置策略重载失败，沿用旧规则", "error", err)
+			return
+		}
+		engine.SetPolicy(rules)
+		logger.Info("处置策略已重载", "rules", len(rules.Rules))
+	})
+
+	logger.Info("检测结果处置策略已启用", "rules", len(rules.Rules), "quarantine_dir", quarantineDir)
+	return nil
+}
+
+// compileResponsePolicy 按配置编译处置规则
+func compileResponsePolicy(rc config.ResponseConfig) (*response.Policy, error) {
+	specs := make([]response.RuleSpec, 0, len(rc.Rules))
+	for _, r := range rc.Rules {
+		specs = append(specs, response.RuleSpec{
+			Name:     r.Name,
+			Modules:  r.Modules,
+			RuleIDs:  r.RuleIDs,
+			MinLevel: r.MinLevel,
+			Paths:    r.Paths,
+			Actions:  r.Actions,
+		})
+	}
+	return response.Compile(specs, rc.DefaultActions)
+}
+
+// alertSink 检测命中回调链：规则命中统计 -> 处置策略 -> 告警去重聚合 -> 文件流转关联 -> Webhook -> sink
+// 未启用处置策略时每次命中都发送桌面通知；已降级规则的命中只计数，不进入后续环节
+func alertSink(sink func(*model.AlertRecord, *model.AlertLogItem)) func(*model.AlertRecord, *model.AlertLogItem) {
+	if responseEngine == nil {
+		return ruleStats.Wrap(desktopNotifier.Wrap(alertAggregator.Wrap(lineageTracker.Wrap(alertWebhook.Wrap(sink)))))
+	}
+	return ruleStats.Wrap(responseEngine.Wrap(alertAggregator.Wrap(lineageTracker.Wrap(alertWebhook.Wrap(sink)))))
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/detectapi"
+	"linuxFileWatcher/internal/service/rulestats"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initRuleStats 初始化检测规则命中统计
+// 规则被标记或降级时生成安全事件上报管理端
+func initRuleStats() {
+	rc := config.Get().Scanner.RuleStats
+	if !rc.Enable {
+		return
+	}
+
+	var (
+		stats  rulestats.StatStore
+		alerts rulestats.AlertStore
+	)
+	if stores := storage.GetStores(); stores != nil {
+		stats, alerts = stores.RuleStats, stores.RuleAlerts
+	}
+	ruleStats = rulestats.New(rulestats.Config{
+		MinSamples:      rc.MinSamples,
+		FlagPrecision:   rc.FlagPrecision,
+		DemotePrecision: rc.DemotePrecision,
+		AutoDemote:      rc.AutoDemote,
+		Retention:       rc.Retention,
+	}, stats, alerts, reportRuleQuality)
+	logger.Info("检测规则命中统计已启用", "auto_demote", rc.AutoDemote)
+}
+
+// reportRuleQuality 规则误报较多或被降级时生成提示级安全事件
+func reportRuleQuality(s model.RuleStat) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	msg := fmt.Sprintf("检测规则误报较多: %s/%d 命中 %d 次，误报 %d 次 (准确率 %.0f%%)",
+		s.Module, s.RuleID, s.Hits, s.FalsePositives, rulestats.Precision(s)*100)
+	if s.Status == rulestats.StatusDemoted {
+		msg += "，已自动降级"
+	}
+	logger.Warn(msg)
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddRuleQualityAlert(msg)
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存规则质量安全事件失败", "error", err)
+	}
+}
+
+// ruleStatsAPI 将检测规则命中统计与误报标记暴露给本机检测服务
+type ruleStatsAPI struct {
+	tracker *rulestats.Tracker
+}
+
+func (r ruleStatsAPI) ListRuleStats() []detectapi.RuleStat {
+	stats := r.tracker.List()
+	out := make([]detectapi.RuleStat, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, toAPIRuleStat(s))
+	}
+	return out
+}
+
+func (r ruleStatsAPI) MarkAlert(alertID string, falsePositive bool) (detectapi.RuleStat, error) {
+	s, err := r.tracker.Mark(alertID, falsePositive, time.Now())
+	if err != nil {
+		return detectapi.RuleStat{}, ruleStatsAPIError(err)
+	}
+	return toAPIRuleStat(s), nil
+}
+
+func (r ruleStatsAPI) ResetRuleStat(module string, ruleID int64) error {
+	return ruleStatsAPIError(r.tracker.Reset(module, ruleID))
+}
+
+func toAPIRuleStat(s model.RuleStat) detectapi.RuleStat {
+	return detectapi.RuleStat{
+		Module:         s.Module,
+		RuleID:         s.RuleID,
+		Hits:           s.Hits,
+		FalsePositives: s.FalsePositives,
+		Precision:      rulestats.Precision(s),
+		LastHit:        s.LastHit,
+		Status:         s.Status,
+	}
+}
+
+// ruleStatsAPIError 规则统计错误对应的接口错误码
+func ruleStatsAPIError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, rulestats.ErrAlertNotFound), errors.Is(err, rulestats.ErrRuleNotFound):
+		return &detectapi.Error{Code: detectapi.CodeNotFound, Message: err.Error()}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,184 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	detectorservice "linuxFileWatcher/internal/service/detector"
+	"linuxFileWatcher/internal/service/exfil"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initScannerService 初始化涉密检测服务
+func initScannerService() error {
+	fmt.Println("正在初始化涉密检测服务...")
+
+	// 降低进程 CPU/I/O 优先级，避免全盘扫描拖慢宿主机
+	scannerCfg := config.Get().Scanner
+	if err := detectorservice.ApplyProcessPriority(scannerCfg.Nice, scannerCfg.IONiceClass); err != nil {
+		logger.Warn("设置进程优先级失败", "nice", scannerCfg.Nice, "ionice_class", scannerCfg.IONiceClass, "error", err)
+	}
+
+	if detectorMgr == nil {
+		return errors.New("detector manager is not initialized")
+	}
+	// 待扫描任务写入日志，崩溃或升级重启后恢复
+	var journal detectorservice.JobJournal
+	if stores := storage.GetStores(); stores != nil {
+		journal = stores.ScanJobs
+	}
+	scanQueue = detectorservice.NewScanQueue(detectorservice.ScanQueueConfig{
+		Workers:    scannerCfg.Workers,
+		Capacity:   scannerCfg.QueueSize,
+		RateLimit:  scannerCfg.RateLimit,
+		ReadRateMB: scannerCfg.ReadRateMB,
+		Journal:    journal,
+	}, scanFile)
+	config.OnReload(func(cfg *config.AppConfig) {
+		scanQueue.SetReadRate(cfg.Scanner.ReadRateMB)
+	})
+
+	logger.Info("涉密检测服务初始化成功")
+	return nil
+}
+
+// scanFile 检测扫描队列中的单个文件，命中告警写入存储，由上报服务统一发送
+func scanFile(ctx context.Context, task detectorservice.ScanTask) error {
+	// 实时事件附带写入进程，命中时记录到告警
+	hit, record, logItem, err := detectorMgr.Detect(detector.WithProcess(ctx, task.Process), task.Path)
+	if err != nil {
+		return err
+	}
+	// 位于可移动介质或网络挂载点的涉密文件计入批量外发关联
+	exfil.Observe(exfil.FileEvent{Path: task.Path, Sensitive: hit})
+	if hit && scanSink != nil {
+		scanSink(record, logItem)
+	}
+	return nil
+}
+
+// initScanScheduler 初始化定时全盘扫描调度器
+func initScanScheduler() error {
+	schedules := config.Get().Scanner.Schedules
+	if len(schedules) == 0 || scanQueue == nil {
+		return nil
+	}
+
+	fmt.Println("正在初始化定时扫描调度器...")
+
+	specs := make([]detectorservice.ScheduleSpec, 0, len(schedules))
+	for _, sc := range schedules {
+		specs = append(specs, detectorservice.ScheduleSpec{
+			Name:        sc.Name,
+			Paths:       sc.Paths,
+			Schedule:    sc.Schedule,
+			Include:     sc.Include,
+			Exclude:     sc.Exclude,
+			MaxDuration: sc.MaxDuration,
+			MaxDepth:    sc.MaxDepth,
+			MinSize:     sc.MinSizeKB << 10,
+			MaxSize:     sc.MaxSizeMB << 20,
+			NewerThan:   sc.NewerThan,
+			OlderThan:   sc.OlderThan,
+		})
+	}
+
+	stores := storage.GetStores()
+	sched, err := detectorservice.NewScanScheduler(specs, scanQueue.Submit, stores.ScanCheckpoints, stores.ScanRuns)
+	if err != nil {
+		return err
+	}
+	scanScheduler = sched
+
+	logger.Info("定时扫描调度器初始化成功", "plans", len(specs))
+	return nil
+}
+
+// startScannerService 启动涉密检测服务
+func startScannerService() {
+	if scanQueue == nil {
+		logger.Warn("涉密检测服务未初始化，跳过启动")
+		return
+	}
+
+	fmt.Println("正在启动涉密检测服务...")
+	// 处置策略、去重等告警包装在检测服务之后初始化，启动时再组装告警链
+	scanSink = alertSink(func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		stores := storage.GetStores()
+		if stores == nil {
+			return
+		}
+		if record != nil {
+			if err := stores.Alerts.Push(*record); err != nil {
+				logger.Error("保存扫描告警失败", "error", err)
+			}
+		}
+		if logItem != nil {
+			if err := stores.AlertLogs.Push(*logItem); err != nil {
+				logger.Error("保存扫描告警日志失败", "error", err)
+			}
+		}
+	})
+	scanQueue.Start()
+	logger.Info("涉密检测服务启动成功")
+}
+
+// restorePendingScans 恢复上次退出时未完成的扫描任务
+// 任务在扫描完成后才从日志删除，恢复后再次中断仍可在下次启动时恢复
+func restorePendingScans() {
+	if scanQueue == nil {
+		return
+	}
+
+	n, err := scanQueue.Restore()
+	if err != nil {
+		logger.Error("恢复待扫描任务失败", "restored", n, "error", err)
+		return
+	}
+	if n > 0 {
+		logger.Info("已恢复未完成的扫描任务", "count", n)
+	}
+}
+
+// startScanScheduler 启动定时扫描调度器
+func startScanScheduler() {
+	if scanScheduler == nil {
+		return
+	}
+	scanScheduler.Start()
+}
+
+// stopScanScheduler 停止定时扫描调度器
+func stopScanScheduler() {
+	if scanScheduler != nil {
+		fmt.Println("正在停止定时扫描调度器...")
+		scanScheduler.Stop()
+	}
+}
+
+// stopScannerService 停止涉密检测服务
+// 原地升级时等待进行中的扫描完成 (最长 30 秒)，避免新进程重复扫描；
+// 中断或排队中的任务均保留在任务日志中，由下次启动恢复
+func stopScannerService() {
+	if scanQueue == nil {
+		return
+	}
+	fmt.Println("正在停止涉密检测服务...")
+	if upgradeBinary == "" {
+		scanQueue.Stop()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := scanQueue.Drain(ctx); err != nil {
+		logger.Warn("等待扫描完成超时，未完成的任务由新进程恢复", "error", err)
+	}
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security"
+	"linuxFileWatcher/internal/security/kms"
+	"linuxFileWatcher/internal/security/pkgverify"
+	"linuxFileWatcher/internal/security/selfprotect"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initSelfProtect 为自身二进制、配置、规则文件与数据库建立基线 (需在检测器管理器设置签名校验器之后)
+func initSelfProtect(configPath string) {
+	cfg := config.Get()
+	sc := cfg.Security.Integrity.SelfProtect
+	if !sc.Enable {
+		return
+	}
+
+	var targets []selfprotect.Target
+	if exe, err := os.Executable(); err == nil {
+		targets = append(targets, selfprotect.Target{Path: exe, Kind: selfprotect.KindBinary})
+	} else {
+		logger.Warn("获取自身二进制路径失败，不保护二进制", "error", err)
+	}
+	if path, err := filepath.Abs(configPath); err == nil {
+		targets = append(targets, selfprotect.Target{Path: path, Kind: selfprotect.KindConfig})
+	}
+	targets = append(targets, ruleTargets(cfg.Scanner.PoliciesPath)...)
+	targets = append(targets, selfprotect.Target{
+		Path: filepath.Join(cfg.Agent.DataDir, cfg.Database.FileName),
+		Kind: selfprotect.KindDatabase,
+	})
+	for _, path := range sc.ExtraPaths {
+		targets = append(targets, selfprotect.Target{Path: path, Kind: selfprotect.KindConfig})
+	}
+
+	var packages *pkgverify.Checker
+	if sc.PackageVerify {
+		packages = packageChecker(targets, sc.PackagePaths)
+	}
+
+	m, err := selfprotect.New(selfprotect.Config{
+		Interval:     cfg.Security.Integrity.CheckInterval,
+		Targets:      targets,
+		ProtectedDir: filepath.Join(cfg.Agent.DataDir, "protected"),
+		Cipher:       goldenCipher{},
+		Restore:      sc.Restore,
+		Remediate:    sc.Remediate,
+		Packages:     packages,
+		// 恢复后经原地升级流程重新执行，处理中的扫描与检测服务连接不中断
+		OnRestore: func(string) {
+			syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+		},
+	}, reportTamper)
+	if err != nil {
+		logger.Error("自我保护初始化失败", "error", err)
+		return
+	}
+	selfProtect = m
+	storage.RegisterVault("selfprotect", m)
+}
+
+// packageChecker 按软件包数据库校验自身二进制与额外指定的系统文件
+// 未找到支持的软件包数据库时不校验
+func packageChecker(targets []selfprotect.Target, extra []string) *pkgverify.Checker {
+	db, err := pkgverify.Open("/")
+	if err != nil {
+		logger.Warn("软件包数据库不可用，跳过软件包校验", "error", err)
+		return nil
+	}
+	var paths []string
+	for _, t := range targets {
+		if t.Kind == selfprotect.KindBinary {
+			paths = append(paths, t.Path)
+		}
+	}
+	paths = append(paths, extra...)
+	logger.Info("启用软件包校验", "manager", db.Name(), "files", len(paths))
+	return pkgverify.NewChecker(db, paths)
+}
+
+// ruleTargets 策略目录下的规则文件
+// 配置了规则签名公钥时，签名有效的变更视为服务端正常下发，不上报
+func ruleTargets(root string) []selfprotect.Target {
+	var verify func([]byte) error
+	if v := policy.DefaultVerifier(); v != nil && v.PublicKey != nil {
+		verify = func(data []byte) error {
+			return policy.VerifyBundle(data, v.PublicKey)
+		}
+	}
+
+	var targets []selfprotect.Target
+	filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".json" {
+			targets = append(targets, selfprotect.Target{Path: path, Kind: selfprotect.KindRule, Verify: verify})
+		}
+		return nil
+	})
+	return targets
+}
+
+// tamperKindNames 受保护文件类型的中文名称
+var tamperKindNames = map[selfprotect.Kind]string{
+	selfprotect.KindBinary:   "程序",
+	selfprotect.KindConfig:   "配置",
+	selfprotect.KindRule:     "规则",
+	selfprotect.KindDatabase: "数据库",
+}
+
+// reportTamper 自身文件被篡改时生成紧急级安全事件
+func reportTamper(v selfprotect.Violation) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	msg := fmt.Sprintf("%s文件被篡改 (%s): %s", tamperKindNames[v.Kind], v.Type, v.Path)
+	if v.Restored {
+		msg += "，已从黄金副本恢复"
+	}
+	report := model.NewSecurityStatusReport(config.Version)
+	report.AddTamperAlert(msg, v.Before, v.After)
+	if err := stores.SecurityReports.Push(*report); err != nil {
+		logger.Error("保存自我保护安全事件失败", "error", err)
+	}
+}
+
+// goldenCipher 黄金副本加密，与本地缓存使用相同的数据密钥
+type goldenCipher struct{}
+
+func (goldenCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if ring := kms.Default(); ring != nil {
+		return ring.Encrypt(plaintext)
+	}
+	return security.EncryptLocal(plaintext)
+}
+
+func (goldenCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	if ring := kms.Default(); ring != nil && kms.IsSealed(ciphertext) {
+		return ring.Decrypt(ciphertext)
+	}
+	return security.DecryptLocal(ciphertext)
+}
+
+// startSelfProtect 启动自我保护校验
+func startSelfProtect() {
+	if selfProtect == nil {
+		return
+	}
+	selfProtect.Start()
+}
+
+// stopSelfProtect 停止自我保护校验
+func stopSelfProtect() {
+	if selfProtect != nil {
+		selfProtect.Stop()
+	}
+}
@@ -0,0 +1,639 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/postmanager"
+	"linuxFileWatcher/internal/safewalk"
+	"linuxFileWatcher/internal/security/envelope"
+	"linuxFileWatcher/internal/security/netguard/whitelist"
+	"linuxFileWatcher/internal/security/tlsclient"
+	"linuxFileWatcher/internal/service/command"
+	detectorservice "linuxFileWatcher/internal/service/detector"
+	"linuxFileWatcher/internal/service/offline"
+	"linuxFileWatcher/internal/service/reportstream"
+	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/uploadqos"
+)
+
+// openOfflineSpool 按配置打开离线数据包目录
+func openOfflineSpool(cfg *config.AppConfig) (*offline.Spool, error) {
+	dir := cfg.Server.Offline.BundleDir
+	if dir == "" {
+		dir = filepath.Join(cfg.Agent.DataDir, "offline")
+	}
+	return offline.NewSpool(dir)
+}
+
+// runExportBundle 将未送达的离线数据包复制到目标目录 (移动介质)
+// 本地数据包保留到导入平台回执为止，-all 时重新导出已导出过的数据包
+func runExportBundle(args []string) int {
+	fs := flag.NewFlagSet("export-bundle", flag.ContinueOnError)
+	configPath := fs.String("c", "configs/config.yml", "配置文件路径")
+	all := fs.Bool("all", false, "同时导出已导出过但尚未确认送达的数据包")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "用法: filewatcherd export-bundle [-c 配置文件] [-all] <目标目录>")
+		return 2
+	}
+
+	cfg, err := config.Read(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败: %v\n", err)
+		return 1
+	}
+	spool, err := openOfflineSpool(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开数据包目录失败: %v\n", err)
+		return 1
+	}
+	exported, err := spool.Export(fs.Arg(0), *all)
+	for _, info := range exported {
+		fmt.Printf("%s  %s  %d 字节\n", info.ID, info.Created.Local().Format("2006-01-02 15:04:05"), info.Size)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "导出失败 (已导出 %d 个): %v\n", len(exported), err)
+		return 1
+	}
+	pending, _ := spool.List()
+	fmt.Printf("已导出 %d 个数据包到 %s，本地待确认 %d 个\n", len(exported), fs.Arg(0), len(pending))
+	return 0
+}
+
+// runImportAck 导入管理平台的送达回执，删除已送达的数据包
+// 回执签名按 security.rule_signature 校验，与规则包使用同一服务端公钥
+func runImportAck(args []string) int {
+	fs := flag.NewFlagSet("import-ack", flag.ContinueOnError)
+	configPath := fs.String("c", "configs/config.yml", "配置文件路径")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "用法: filewatcherd import-ack [-c 配置文件] <回执文件>")
+		return 2
+	}
+
+	cfg, err := config.Read(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取配置失败: %v\n", err)
+		return 1
+	}
+	sc := cfg.Security.RuleSignature
+	verifier, err := policy.NewVerifier(policy.VerifyMode(sc.Mode), sc.PublicKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "签名校验配置无效: %v\n", err)
+		return 1
+	}
+	ack, err := offline.ReadAck(fs.Arg(0), func(data []byte) error {
+		return verifier.Check(fs.Arg(0), data)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "回执无效: %v\n", err)
+		return 1
+	}
+	spool, err := openOfflineSpool(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "打开数据包目录失败: %v\n", err)
+		return 1
+	}
+	removed, skipped, err := spool.Remove(ack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "删除已送达数据包失败 (已删除 %d 个): %v\n", len(removed), err)
+		return 1
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "跳过 %d 个本地不存在或不属于本机 (%s) 的数据包: %s\n", len(skipped), ack.AgentUUID, strings.Join(skipped, ", "))
+	}
+	pending, _ := spool.List()
+	fmt.Printf("已确认送达 %d 个数据包，本地待确认 %d 个\n", len(removed), len(pending))
+	return 0
+}
+
+// initPayloadEncryption 加载服务端公钥，用于加密离线数据包
+func initPayloadEncryption() error {
+	cfg := config.Get()
+	pc := cfg.Server.PayloadEncryption
+	if !pc.Enable {
+		return nil
+	}
+	if !cfg.Server.Offline.Enable {
+		// 在线上报尚未接入信封加密，避免误以为上报内容已加密
+		logger.Warn("载荷信封加密目前只用于离线数据包，在线上报不加密", "algorithm", envelope.Algorithm)
+		return nil
+	}
+	sealer, err := envelope.NewSealer(pc.PublicKey)
+	if err != nil {
+		return err
+	}
+	envelope.SetDefault(sealer)
+	logger.Info("离线数据包信封加密已启用", "algorithm", envelope.Algorithm)
+	return nil
+}
+
+// initServerTLS 加载上报通道的客户端证书、根证书与指纹固定配置
+func initServerTLS() error {
+	sc := config.Get().Server
+	mgr, err := tlsclient.NewManager(tlsclient.Config{
+		CACert:           sc.CACert,
+		ClientCert:       sc.ClientCert,
+		ClientKey:        sc.ClientKey,
+		PinnedCertSHA256: sc.PinnedCertSHA256,
+		PinnedSPKISHA256: sc.PinnedSPKISHA256,
+		ReloadInterval:   sc.CertReloadInterval,
+		Audit:            auditTLSFailure,
+	})
+	if err != nil {
+		return err
+	}
+	tlsclient.SetDefault(mgr)
+	return nil
+}
+
+// initUploadQoS 按配置启用上报限流，配置重载时更新
+func initUploadQoS() error {
+	qc := config.Get().Server.UploadQoS
+	config.OnReload(func(cfg *config.AppConfig) {
+		qc := cfg.Server.UploadQoS
+		if !qc.Enable {
+			uploadqos.SetDefault(nil)
+			return
+		}
+		if l := uploadqos.Default(); l != nil {
+			if err := l.Update(uploadQoSConfig(qc)); err != nil {
+				logger.Error("上报限流重载失败，沿用旧配置", "error", err)
+			}
+			return
+		}
+		l, err := uploadqos.New(uploadQoSConfig(qc))
+		if err != nil {
+			logger.Error("上报限流重载失败", "error", err)
+			return
+		}
+		uploadqos.SetDefault(l)
+	})
+	if !qc.Enable {
+		return nil
+	}
+	l, err := uploadqos.New(uploadQoSConfig(qc))
+	if err != nil {
+		return err
+	}
+	uploadqos.SetDefault(l)
+	logger.Info("上报限流已启用", "rate", qc.Rate, "burst", qc.Burst, "bandwidth_kbps", l.Bandwidth())
+	return nil
+}
+
+// initReportStream 创建 gRPC 流式上报通道 (需要上报通道 TLS 已初始化)，在上报服务启动时连接
+// 上报模块通过 reportstream.Default() 发送批次，通道未连接时使用 HTTP 上报
+func initReportStream() {
+	sc := config.Get().Server
+	mgr := tlsclient.Default()
+	if !sc.Stream.Enable || mgr == nil {
+		return
+	}
+	addr := sc.Stream.URL
+	if addr == "" {
+		addr = sc.URL
+	}
+	u, err := url.Parse(addr)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		logger.Error("gRPC 流式通道地址无效 (需为 https)，不启用", "url", addr, "error", err)
+		return
+	}
+
+	// 长连接不设置整体超时，由确认超时判断连接失效
+	c := reportstream.New(reportstream.Config{
+		URL:         addr,
+		AckTimeout:  sc.Stream.AckTimeout,
+		MaxInFlight: sc.Stream.MaxInFlight,
+		Backoff:     sc.Stream.Backoff,
+		MaxBackoff:  sc.Stream.MaxBackoff,
+		Hello: reportstream.Hello{
+			AgentUUID:     model.AgentUUID(),
+			Version:       config.Version,
+			SchemaVersion: model.ReportSchemaVersion,
+		},
+		Execute: handleStreamCommand,
+	}, mgr.Client(u.Hostname(), 0))
+	reportstream.SetDefault(c)
+}
+
+// handleStreamCommand 流式通道下发的指令: 载荷为签名指令，交给指令执行器校验后执行
+func handleStreamCommand(ctx context.Context, cmd reportstream.Command) (string, error) {
+	exec := command.Default()
+	if exec == nil {
+		return "", errors.New("command execution is not enabled")
+	}
+	// 外层字段不在签名范围内，须与签名指令一致
+	var signed command.Command
+	if err := json.Unmarshal(cmd.Payload, &signed); err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	if signed.ID != cmd.ID || signed.Type != cmd.Type {
+		return "", fmt.Errorf("command %s (%s) does not match signed command %s (%s)", cmd.ID, cmd.Type, signed.ID, signed.Type)
+	}
+	r := exec.Execute(ctx, cmd.Payload)
+	if r.Result != 0 {
+		return "", errors.New(r.Message)
+	}
+	return r.Message, nil
+}
+
+// initCommandExecutor 创建服务端指令执行器，指令由流式通道推送或定期拉取 (需要上报通道 TLS 已初始化)
+func initCommandExecutor() {
+	cfg := config.Get()
+	cc := cfg.Server.Command
+	if !cc.Enable || cfg.Server.Offline.Enable {
+		return
+	}
+	// 指令始终强制校验签名，与 security.rule_signature.mode 无关
+	verifier, err := policy.NewVerifier(policy.VerifyEnforce, cfg.Security.RuleSignature.PublicKey)
+	if err != nil {
+		logger.Error("服务端指令签名公钥无效，不执行服务端指令", "error", err)
+		return
+	}
+	exec, err := command.New(command.Config{
+		PublicKey: verifier.PublicKey,
+		AgentUUID: model.AgentUUID(),
+		MaxAge:    cc.MaxAge,
+		Timeout:   cc.Timeout,
+		StatePath: filepath.Join(cfg.Agent.DataDir, "commands.json"),
+		Audit:     auditCommand,
+		Report:    reportCommandResult,
+	})
+	if err != nil {
+		logger.Error("服务端指令执行器初始化失败", "error", err)
+		return
+	}
+	exec.Handle(command.TypeRescan, handleRescanCommand)
+	exec.Handle(command.TypeRebaseline, handleRebaselineCommand)
+	exec.Handle(command.TypeWhitelist, handleWhitelistCommand)
+	exec.Handle(command.TypeDiagnostics, handleDiagnosticsCommand)
+	exec.Handle(command.TypeRuleUpdate, handleRuleUpdateCommand)
+	command.SetDefault(exec)
+
+	if !cc.Poll.Enable {
+		logger.Info("服务端指令执行已启用", "stream", cfg.Server.Stream.Enable)
+		return
+	}
+	mgr := tlsclient.Default()
+	u, err := url.Parse(cfg.Server.URL)
+	if mgr == nil || err != nil || u.Host == "" {
+		logger.Error("管理平台地址或上报通道 TLS 不可用，不拉取服务端指令", "url", cfg.Server.URL, "error", err)
+		return
+	}
+	commandPoller = command.NewPoller(command.PollConfig{
+		URL:       cfg.Server.URL,
+		Path:      cc.Poll.Path,
+		AgentUUID: model.AgentUUID(),
+		Interval:  cc.Poll.Interval,
+		Timeout:   cfg.Server.Timeout,
+	}, mgr.Client(u.Hostname(), cfg.Server.Timeout), exec)
+	logger.Info("服务端指令执行已启用", "stream", cfg.Server.Stream.Enable, "poll_interval", cc.Poll.Interval)
+}
+
+// auditCommand 服务端指令的执行记录写入审计日志
+func auditCommand(record *model.SystemAuditRequest) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	if err := stores.AuditLogs.Push(*record); err != nil {
+		logger.Error("保存指令执行审计日志失败", "error", err)
+	}
+}
+
+// reportCommandResult 指令执行结果写入指令结果上报
+func reportCommandResult(r *model.CommandResultReport) {
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	if err := stores.CommandResults.Push(*r); err != nil {
+		logger.Error("保存指令执行结果失败", "cmd_id", r.CmdID, "error", err)
+	}
+}
+
+// handleRescanCommand 服务端指令: 立即扫描指定的文件或目录 (经路径过滤)，参数 {"paths": [...]}
+func handleRescanCommand(ctx context.Context, payload json.RawMessage) (command.Result, error) {
+	var args struct {
+		Paths []string `json:"paths"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return command.Result{}, fmt.Errorf("invalid payload: %w", err)
+	}
+	if scanQueue == nil {
+		return command.Result{}, errors.New("scanner service is not running")
+	}
+	if len(args.Paths) == 0 {
+		return command.Result{}, errors.New("no paths")
+	}
+	for _, p := range args.Paths {
+		if !filepath.IsAbs(p) {
+			return command.Result{}, fmt.Errorf("path must be absolute: %q", p)
+		}
+	}
+
+	// 多个路径共用已访问集合，重叠的目录与硬链接只提交一次
+	submitted := 0
+	walker := safewalk.New(safewalk.Options{})
+	for _, root := range args.Paths {
+		err := walker.Walk(filepath.Clean(root), func(path string, d os.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil || !d.Type().IsRegular() {
+				return nil
+			}
+			if !pathfilter.Default().Allow(path) {
+				return nil
+			}
+			if err := scanQueue.Submit(detectorservice.ScanTask{Path: path, Priority: detectorservice.PriorityManual, Source: "command"}); err != nil {
+				return err
+			}
+			submitted++
+			return nil
+		})
+		if err != nil {
+			return command.Result{}, err
+		}
+	}
+	return command.Result{Message: fmt.Sprintf("submitted %d files", submitted)}, nil
+}
+
+// handleRebaselineCommand 服务端指令: 确认变化为预期变更，以当前状态重建路径所在目录的完整性基线，参数 {"paths": [...]}
+// 详情中列出被接受的变化
+func handleRebaselineCommand(ctx context.Context, payload json.RawMessage) (command.Result, error) {
+	var args struct {
+		Paths []string `json:"paths"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return command.Result{}, fmt.Errorf("invalid payload: %w", err)
+	}
+	if baselineMonitor == nil {
+		return command.Result{}, errors.New("directory baseline is not enabled")
+	}
+	if len(args.Paths) == 0 {
+		return command.Result{}, errors.New("no paths")
+	}
+
+	var res command.Result
+	accepted := 0
+	for _, p := range args.Paths {
+		if !filepath.IsAbs(p) {
+			return res, fmt.Errorf("path must be absolute: %q", p)
+		}
+		r, err := baselineMonitor.Rebaseline(ctx, p)
+		if err != nil {
+			return res, err
+		}
+		accepted += len(r.Changes) + r.Truncated
+		for _, c := range r.Changes {
+			res.Detail = append(res.Detail, fmt.Sprintf("%s %s", c.Type, c.Path))
+		}
+	}
+	res.Message = fmt.Sprintf("baseline rebuilt, %d changes accepted", accepted)
+	return res, nil
+}
+
+// handleWhitelistCommand 服务端指令: 增删网络白名单的运行时规则 (持久化，重启后保留)
+// 参数 {"add": [{"value": "10.0.0.0/8", "desc": "..."}], "remove": ["..."]}；整体替换策略规则使用 rule_update
+func handleWhitelistCommand(_ context.Context, payload json.RawMessage) (command.Result, error) {
+	var args struct {
+		Add []struct {
+			Value string `json:"value"`
+			Desc  string `json:"desc"`
+		} `json:"add"`
+		Remove []string `json:"remove"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return command.Result{}, fmt.Errorf("invalid payload: %w", err)
+	}
+	if netWhitelist == nil {
+		return command.Result{}, errors.New("network whitelist is not enabled")
+	}
+	if len(args.Add) == 0 && len(args.Remove) == 0 {
+		return command.Result{}, errors.New("no rules")
+	}
+	// 先校验全部规则，避免部分生效
+	for _, r := range args.Add {
+		if _, err := whitelist.NewRule(r.Value); err != nil {
+			return command.Result{}, err
+		}
+	}
+
+	var res command.Result
+	for _, v := range args.Remove {
+		err := netWhitelist.RemoveRule(v)
+		if errors.Is(err, whitelist.ErrNotFound) {
+			res.Detail = append(res.Detail, "not found "+v)
+			continue
+		}
+		if err != nil {
+			return res, err
+		}
+		res.Detail = append(res.Detail, "removed "+v)
+	}
+	for _, r := range args.Add {
+		rule, err := netWhitelist.AddRule(r.Value, r.Desc)
+		if err != nil {
+			return res, err
+		}
+		res.Detail = append(res.Detail, "added "+rule.Value)
+	}
+	logger.Info("已按服务端指令更新网络白名单", "add", len(args.Add), "remove", len(args.Remove))
+	res.Message = fmt.Sprintf("whitelist updated, %d rules", len(netWhitelist.List()))
+	return res, nil
+}
+
+// handleDiagnosticsCommand 服务端指令: 收集运行诊断信息 (进程状态与各上报通道状态)，随执行结果上报
+func handleDiagnosticsCommand(_ context.Context, _ json.RawMessage) (command.Result, error) {
+	detail := []string{fmt.Sprintf("version=%s agent_uuid=%s", config.Version, model.AgentUUID())}
+	detail = append(detail, command.RuntimeDiagnostics()...)
+	if c := reportstream.Default(); c != nil {
+		detail = append(detail, fmt.Sprintf("stream_connected=%t", c.Connected()))
+	}
+	if alertWebhook != nil {
+		for _, st := range alertWebhook.Stats() {
+			detail = append(detail, fmt.Sprintf("webhook %s sent=%d failed=%d dropped=%d queued=%d", st.Name, st.Sent, st.Failed, st.Dropped, st.Queued))
+		}
+	}
+	if netWhitelist != nil {
+		detail = append(detail, fmt.Sprintf("net_whitelist_rules=%d", len(netWhitelist.List())))
+	}
+	return command.Result{Message: fmt.Sprintf("collected %d items", len(detail)), Detail: detail}, nil
+}
+
+// handleRuleUpdateCommand 服务端指令: 保存下发的策略 (校验签名) 后重新加载配置，与策略同步写入 policy.json 后 SIGHUP 相同
+// 参数 {"module": "...", "policy": {...}}
+func handleRuleUpdateCommand(_ context.Context, payload json.RawMessage) (command.Result, error) {
+	var args struct {
+		Module string          `json:"module"`
+		Policy json.RawMessage `json:"policy"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return command.Result{}, fmt.Errorf("invalid payload: %w", err)
+	}
+	if len(args.Policy) == 0 {
+		return command.Result{}, errors.New("no policy")
+	}
+	m := policy.NewManager(config.Get().Scanner.PoliciesPath)
+	if err := m.SavePolicy(args.Module, args.Policy); err != nil {
+		return command.Result{}, err
+	}
+	logger.Info("已保存服务端下发的策略，重新加载", "module", args.Module, "path", m.GetPolicyPath(args.Module))
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		return command.Result{}, err
+	}
+	return command.Result{Message: "policy updated"}, nil
+}
+
+// uploadQoSConfig 转换上报限流配置
+func uploadQoSConfig(qc config.UploadQoSConfig) uploadqos.Config {
+	endpoints := make(map[string]uploadqos.Limit, len(qc.Endpoints))
+	for path, lim := range qc.Endpoints {
+		endpoints[path] = uploadqos.Limit{Rate: lim.Rate, Burst: lim.Burst}
+	}
+	weekdays := make([]time.Weekday, len(qc.BusinessHours.Weekdays))
+	for i, d := range qc.BusinessHours.Weekdays {
+		weekdays[i] = time.Weekday(d)
+	}
+	return uploadqos.Config{
+		Default:       uploadqos.Limit{Rate: qc.Rate, Burst: qc.Burst},
+		Endpoints:     endpoints,
+		BandwidthKBps: qc.BandwidthKBps,
+		BusinessHours: uploadqos.BusinessHours{
+			BandwidthKBps: qc.BusinessHours.BandwidthKBps,
+			Start:         qc.BusinessHours.Start,
+			End:           qc.BusinessHours.End,
+			Weekdays:      weekdays,
+		},
+	}
+}
+
+// auditTLSFailure 记录上报通道 TLS 失败
+func auditTLSFailure(message string) {
+	logger.Error("上报通道 TLS 失败", "detail", message)
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	now := time.Now()
+	record := model.NewSystemAuditRequest(
+		fmt.Sprintf("tls%d", now.UnixMilli()),
+		"system",
+		now.Format("2006-01-02 15:04:05.000"),
+		model.LogTypeOther,
+		model.OpTypeTLSFailure,
+		message,
+	)
+	if err := stores.AuditLogs.Push(*record); err != nil {
+		logger.Error("保存 TLS 失败审计日志失败", "error", err)
+	}
+}
+
+// initOfflineBundler 离线模式下初始化数据包打包 (需要存储与载荷加密已初始化)
+func initOfflineBundler() {
+	cfg := config.Get()
+	if !cfg.Server.Offline.Enable {
+		return
+	}
+	stores := storage.GetStores()
+	if stores == nil {
+		logger.Error("存储未初始化，离线数据包打包不可用")
+		return
+	}
+	spool, err := openOfflineSpool(cfg)
+	if err != nil {
+		logger.Error("离线数据包目录初始化失败", "error", err)
+		return
+	}
+	offlineBundler = offline.NewBundler(offline.Config{
+		Interval: cfg.Server.Offline.Interval,
+	}, spool, envelope.Default(), config.AgentUUID,
+		offline.StoreSource("alerts", stores.Alerts),
+		offline.StoreSource("alert_logs", stores.AlertLogs),
+		offline.StoreSource("audit_logs", stores.AuditLogs),
+		offline.StoreSource("security_reports", stores.SecurityReports),
+		offline.StoreSource("command_results", stores.CommandResults),
+		offline.StoreSource("policy_results", stores.PolicyResults),
+	)
+}
+
+// startOfflineBundler 启动离线数据包定期打包
+func startOfflineBundler() {
+	if offlineBundler == nil {
+		return
+	}
+	offlineBundler.Start()
+}
+
+// stopOfflineBundler 停止定期打包，并将剩余的上报数据打包
+func stopOfflineBundler() {
+	if offlineBundler != nil {
+		fmt.Println("正在打包离线数据...")
+		offlineBundler.Stop()
+	}
+}
+
+// startPostManager 启动上报服务 (非阻塞)，离线模式不连接管理平台
+func startPostManager() {
+	if config.Get().Server.Offline.Enable {
+		logger.Info("离线模式，不启动上报服务")
+		return
+	}
+	fmt.Println("正在启动 PostManager 上报服务...")
+	if err := postmanager.Init(); err != nil {
+		logger.Error("postmanager模块初始化失败", "error", err)
+		return
+	}
+	if c := reportstream.Default(); c != nil {
+		c.Start()
+	}
+	postmanager.StartAllReporting()
+	logger.Info("所有上报服务启动成功")
+}
+
+// startCommandPoller 开始定期拉取服务端指令
+func startCommandPoller() {
+	if commandPoller != nil {
+		commandPoller.Start()
+	}
+}
+
+// stopCommandExecutor 停止拉取，取消执行中的服务端指令 (流式通道断开前停止，其后收到的指令不再执行)
+func stopCommandExecutor() {
+	if commandPoller != nil {
+		commandPoller.Stop()
+	}
+	if exec := command.Default(); exec != nil {
+		exec.Stop()
+	}
+}
+
+// stopReportStream 断开 gRPC 流式上报通道，未确认的批次下次启动后按原批次 ID 重发
+func stopReportStream() {
+	if c := reportstream.Default(); c != nil {
+		fmt.Println("正在断开流式上报通道...")
+		c.Stop()
+	}
+}
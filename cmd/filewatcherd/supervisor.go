@@ -0,0 +1,131 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/supervisor"
+)
+
+// runSupervisor 以子进程运行客户端并监护其存活，收到 SIGINT/SIGTERM 时停止客户端后返回
+func runSupervisor() error {
+	cfg := config.Get()
+	// 监护进程与客户端分别写日志文件，避免同时轮转同一文件
+	logFile := cfg.Agent.LogFile
+	if logFile != "" {
+		ext := filepath.Ext(logFile)
+		logFile = strings.TrimSuffix(logFile, ext) + "-supervisor" + ext
+	}
+	if err := logger.Setup(logger.Options{
+		Level:      cfg.Agent.LogLevel,
+		Format:     cfg.Agent.LogFormat,
+		Modules:    cfg.Agent.LogModules,
+		FilePath:   logFile,
+		MaxSize:    cfg.Agent.LogMaxSize,
+		MaxBackups: cfg.Agent.LogMaxBackups,
+		MaxAge:     cfg.Agent.LogMaxAge,
+		Compress:   cfg.Agent.LogCompress,
+		Stdout:     cfg.Agent.LogStdout,
+		Ship:       logShipConfig(cfg.Agent.LogShip),
+	}); err != nil {
+		return fmt.Errorf("日志系统初始化失败: %w", err)
+	}
+	defer logger.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Agent.DataDir, 0755); err != nil {
+		return err
+	}
+	sup := supervisor.New(supervisor.Config{
+		Path:    exe,
+		Args:    withoutFlag(os.Args[1:], "supervise"),
+		DataDir: cfg.Agent.DataDir,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR2)
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP || sig == syscall.SIGUSR2 {
+				// 重载配置与原地升级由客户端处理
+				if err := sup.Signal(sig); err != nil {
+					logger.Warn("转发信号失败", "signal", sig, "error", err)
+				}
+				continue
+			}
+			cancel()
+		}
+	}()
+
+	logger.Info("监护进程已启动", "agent", exe)
+	return sup.Run(ctx)
+}
+
+// withoutFlag 去掉命令行中的布尔参数 (-name / --name / --name=value)
+func withoutFlag(args []string, name string) []string {
+	out := make([]string, 0, len(args))
+	for _, arg := range args {
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed != arg && (trimmed == name || strings.HasPrefix(trimmed, name+"=")) {
+			continue
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// supervisorKindNames 客户端异常类型描述
+var supervisorKindNames = map[supervisor.ExitKind]string{
+	supervisor.ExitKilled:  "被信号结束",
+	supervisor.ExitExited:  "非预期退出",
+	supervisor.ExitStopped: "被暂停",
+}
+
+// importSupervisorEvents 导入监护进程记录的客户端被结束/暂停事件，生成紧急级安全事件
+func importSupervisorEvents() {
+	cfg := config.Get()
+	events, err := supervisor.TakeEvents(cfg.Agent.DataDir)
+	if err != nil {
+		logger.Error("读取客户端存活事件失败", "error", err)
+	}
+	stores := storage.GetStores()
+	if len(events) == 0 || stores == nil {
+		return
+	}
+
+	for _, ev := range events {
+		msg := fmt.Sprintf("客户端进程 %d %s", ev.PID, supervisorKindNames[ev.Kind])
+		if ev.Signal != "" {
+			msg += fmt.Sprintf(" (%s)", ev.Signal)
+		} else if ev.ExitCode >= 0 {
+			msg += fmt.Sprintf(" (退出码 %d)", ev.ExitCode)
+		}
+		if k := ev.Killer; k != nil {
+			msg += fmt.Sprintf("，来源进程 %d %s uid=%d auid=%d", k.PID, k.Exe, k.UID, k.AUID)
+		}
+
+		report := model.NewSecurityStatusReport(config.Version)
+		report.AddProcessAlert(time.UnixMilli(ev.Time), msg)
+		if err := stores.SecurityReports.Push(*report); err != nil {
+			logger.Error("保存客户端存活事件失败", "error", err)
+		}
+	}
+	logger.Warn("已导入客户端被结束/暂停事件", "count", len(events))
+}
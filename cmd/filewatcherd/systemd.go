@@ -0,0 +1,64 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/systemd"
+)
+
+// notifySystemd 向 systemd 报告状态 (Type=notify)，非 systemd 启动时为空操作
+func notifySystemd(states ...string) {
+	if _, err := systemd.Notify(states...); err != nil {
+		logger.Warn("systemd 状态通知失败", "error", err)
+	}
+}
+
+// startWatchdog 单元配置 WatchdogSec 时定期执行健康检查并喂狗
+// 存储不可用、检测器锁死或本机检测服务异常退出时停止喂狗，由 systemd 重启进程
+func startWatchdog() {
+	timeout := systemd.WatchdogTimeout()
+	if timeout <= 0 {
+		return
+	}
+
+	w := systemd.NewWatchdog(timeout)
+	w.Add("storage", func(ctx context.Context) error {
+		db, err := storage.GetDB()
+		if err != nil {
+			return err
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	if detectorMgr != nil {
+		// 规则更新与检测共用读写锁，锁无法获取即检测流水线卡死
+		w.Add("detector", func(context.Context) error {
+			detectorMgr.Config()
+			return nil
+		})
+	}
+	if detectAPI != nil {
+		w.Add("detectapi", func(context.Context) error {
+			return detectAPI.Healthy()
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopWatchdogFn = cancel
+	go w.Run(ctx)
+	logger.Info("systemd 看门狗已启动", "timeout", timeout)
+}
+
+// stopWatchdog 停止喂狗 (退出流程中不再做健康检查)
+func stopWatchdog() {
+	if stopWatchdogFn != nil {
+		stopWatchdogFn()
+	}
+}
@@ -0,0 +1,57 @@
+//go:build linux
+
+package main
+
+import (
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// initTracing 初始化检测时间线与上报请求追踪，并在配置重载时切换
+// 配置错误不中断程序，仅关闭追踪
+func initTracing() {
+	applyTracing(config.Get())
+	config.OnReload(applyTracing)
+}
+
+// applyTracing 按配置替换全局追踪器，旧追踪器发送完剩余时间线后关闭
+func applyTracing(cfg *config.AppConfig) {
+	tc := cfg.Scanner.Trace
+	var tr *tracing.Tracer
+	if tc.Enable {
+		exporter := tracing.NewLogExporter()
+		if tc.Exporter == "otlp" {
+			var err error
+			exporter, err = tracing.NewOTLPExporter(tracing.OTLPConfig{
+				Endpoint:        tc.OTLPEndpoint,
+				MetricsEndpoint: tc.OTLPMetricsEndpoint,
+				MetricsInterval: tc.MetricsInterval,
+				Headers:         tc.OTLPHeaders,
+				ServiceName:     tc.ServiceName,
+			})
+			if err != nil {
+				logger.Error("检测时间线追踪配置无效，已关闭", "error", err)
+				exporter = nil
+			}
+		}
+		if exporter != nil {
+			tr = tracing.New(tracing.Options{
+				MinDuration: tc.MinDuration,
+				SampleRatio: tc.SampleRatio,
+				Exporter:    exporter,
+			})
+			logger.Info("检测时间线追踪已开启", "exporter", tc.Exporter, "min_duration", tc.MinDuration, "sample_ratio", tc.SampleRatio)
+		}
+	}
+	if old := tracing.SetDefault(tr); old != nil {
+		old.Close()
+	}
+}
+
+// stopTracing 发送剩余的检测时间线
+func stopTracing() {
+	if tr := tracing.SetDefault(nil); tr != nil {
+		tr.Close()
+	}
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/storage"
+	"linuxFileWatcher/internal/upgrade"
+)
+
+// requestUpgrade 收到升级信号时检查磁盘上的新版本二进制
+// 二进制不可用时放弃升级并继续运行，返回 false
+func requestUpgrade() bool {
+	binary, err := os.Executable()
+	if err == nil {
+		err = upgrade.CheckBinary(binary)
+	}
+	if err != nil {
+		logger.Error("原地升级目标不可用，继续运行当前版本", "error", err)
+		return false
+	}
+	upgradeBinary = binary
+	upgradeStartedAt = time.Now()
+	logger.Info("开始原地升级", "binary", binary, "version", config.Version)
+	return true
+}
+
+// handoffDetectAPI 停止本机检测服务并保留监听 socket，交给升级后的新进程
+func handoffDetectAPI() *os.File {
+	if detectAPI == nil {
+		return nil
+	}
+	fmt.Println("正在交接本机检测服务...")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	f, err := detectAPI.Handoff(ctx)
+	if err != nil {
+		logger.Warn("本机检测服务交接未完成", "error", err)
+	}
+	return f
+}
+
+// execUpgrade 写入交接状态并以新版本二进制替换当前进程，成功时不返回
+// 调用前所有服务已停止：扫描任务在完成前一直保留在任务日志中 (含排空超时被中断的扫描)，由新进程启动时恢复
+func execUpgrade(apiFile *os.File) {
+	cfg := config.Get()
+	st := &upgrade.State{
+		FromVersion: config.Version,
+		PID:         os.Getpid(),
+		StartedAt:   upgradeStartedAt.UnixMilli(),
+	}
+	var files []upgrade.File
+	if apiFile != nil {
+		files = append(files, upgrade.File{Name: "detectapi", File: apiFile})
+		st.Listeners = append(st.Listeners, "detectapi")
+	}
+	if stores := storage.GetStores(); stores != nil {
+		jobs, err := stores.ScanJobs.LoadAll()
+		if err != nil {
+			logger.Warn("读取待扫描任务失败", "error", err)
+		}
+		st.PendingJobs = len(jobs)
+	}
+	if err := upgrade.SaveState(cfg.Agent.DataDir, st); err != nil {
+		logger.Error("保存升级交接状态失败", "error", err)
+	}
+
+	fmt.Printf("[Main] 正在升级: %s\n", upgradeBinary)
+	logger.Info("执行新版本二进制", "binary", upgradeBinary, "pending_jobs", st.PendingJobs)
+	// 单实例锁的描述符带 CLOEXEC，exec 后由新进程重新获取 (PID 不变，PID 文件保持有效)
+	err := upgrade.Exec(upgradeBinary, os.Args, files)
+
+	// exec 失败时服务均已停止，退出后由 systemd 按 Restart= 拉起
+	logger.Error("原地升级失败", "binary", upgradeBinary, "error", err)
+	releaseInstanceLock()
+	os.Exit(1)
+}
+
+// finishUpgrade 升级后的新进程读取交接状态并记录审计日志
+func finishUpgrade() {
+	cfg := config.Get()
+	st, err := upgrade.LoadState(cfg.Agent.DataDir)
+	if err != nil {
+		logger.Error("读取升级交接状态失败", "error", err)
+		return
+	}
+	if st == nil {
+		return
+	}
+
+	elapsed := time.Since(time.UnixMilli(st.StartedAt))
+	logger.Info("原地升级完成",
+		"from", st.FromVersion,
+		"to", config.Version,
+		"pending_jobs", st.PendingJobs,
+		"elapsed", elapsed,
+	)
+
+	stores := storage.GetStores()
+	if stores == nil {
+		return
+	}
+	now := time.Now()
+	record := model.NewSystemAuditRequest(
+		fmt.Sprintf("upg%d", now.UnixMilli()),
+		"system",
+		now.Format("2006-01-02 15:04:05.000"),
+		model.LogTypeInstallUninstall,
+		model.OpTypeUpgrade,
+		fmt.Sprintf("客户端由 %s 原地升级至 %s，耗时 %s，待恢复扫描任务 %d 个", st.FromVersion, config.Version, elapsed.Round(time.Millisecond), st.PendingJobs),
+	)
+	if err := stores.AuditLogs.Push(*record); err != nil {
+		logger.Error("保存升级审计日志失败", "error", err)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/service/response"
+	"linuxFileWatcher/internal/service/webhook"
+)
+
+// initAlertWebhook 初始化告警 Webhook 输出
+func initAlertWebhook() error {
+	wc := config.Get().Scanner.Webhook
+	if !wc.Enable || len(wc.Endpoints) == 0 {
+		return nil
+	}
+
+	endpoints := make([]webhook.Endpoint, 0, len(wc.Endpoints))
+	for _, ep := range wc.Endpoints {
+		endpoints = append(endpoints, webhook.Endpoint{
+			Name:          ep.Name,
+			URL:           ep.URL,
+			Method:        ep.Method,
+			Headers:       ep.Headers,
+			Body:          ep.Body,
+			Secret:        ep.Secret,
+			SignAlgorithm: ep.SignAlgorithm,
+			CAFile:        ep.CAFile,
+			Filter: response.RuleSpec{
+				Modules:  ep.Modules,
+				RuleIDs:  ep.RuleIDs,
+				MinLevel: ep.MinLevel,
+				Paths:    ep.Paths,
+			},
+		})
+	}
+	sink, err := webhook.New(webhook.Config{
+		Endpoints:    endpoints,
+		Timeout:      wc.Timeout,
+		MaxRetries:   wc.MaxRetries,
+		RetryBackoff: wc.RetryBackoff,
+		QueueSize:    wc.QueueSize,
+	})
+	if err != nil {
+		return err
+	}
+	alertWebhook = sink
+
+	logger.Info("告警 Webhook 输出已启用", "endpoints", len(endpoints))
+	return nil
+}
+
+// startAlertWebhook 启动告警 Webhook 发送
+func startAlertWebhook() {
+	if alertWebhook == nil {
+		return
+	}
+	alertWebhook.Start()
+}
+
+// stopAlertWebhook 停止告警 Webhook 输出 (在告警去重聚合之后，聚合输出的告警仍可发送)
+func stopAlertWebhook() {
+	if alertWebhook != nil {
+		fmt.Println("正在停止告警 Webhook 输出...")
+		alertWebhook.Stop()
+	}
+}
@@ -0,0 +1,112 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+
+	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/security/netguard/whitelist"
+	"linuxFileWatcher/internal/service/detectapi"
+	"linuxFileWatcher/internal/storage"
+)
+
+// initNetWhitelist 初始化网络白名单 (本地配置、策略下发与运行时添加的规则)
+// 策略经规则签名校验，需在 initDetectorManager 设置签名校验器之后、initDetectAPI 之前调用
+func initNetWhitelist() {
+	cfg := config.Get()
+	var store whitelist.Store
+	if stores := storage.GetStores(); stores != nil {
+		store = stores.NetguardWhitelist
+	}
+	wl, err := whitelist.New(cfg.Security.NetGuard.Whitelist, store)
+	if err != nil {
+		logger.Error("网络白名单配置无效", "error", err)
+		return
+	}
+	netWhitelist = wl
+
+	if err := loadNetWhitelistPolicy(cfg); err != nil {
+		logger.Error("网络白名单策略加载失败", "error", err)
+	}
+	config.OnReload(func(cfg *config.AppConfig) {
+		if err := netWhitelist.SetRules(whitelist.SourceConfig, cfg.Security.NetGuard.Whitelist, nil); err != nil {
+			logger.Error("网络白名单配置无效，沿用旧规则", "error", err)
+		}
+		if err := loadNetWhitelistPolicy(cfg); err != nil {
+			logger.Error("网络白名单策略重载失败，沿用旧规则", "error", err)
+		}
+	})
+}
+
+// loadNetWhitelistPolicy 加载策略同步下发的白名单
+func loadNetWhitelistPolicy(cfg *config.AppConfig) error {
+	var wc model.NetWhitelistConfig
+	if err := policy.NewManager(cfg.Scanner.PoliciesPath).LoadPolicy(model.ModuleNetWhitelist, &wc); err != nil {
+		return err
+	}
+	rules := make([]string, 0, len(wc.Rules))
+	descs := make([]string, 0, len(wc.Rules))
+	for _, r := range wc.Rules {
+		rules = append(rules, r.RuleContent)
+		descs = append(descs, r.RuleDesc)
+	}
+	if err := netWhitelist.SetRules(whitelist.SourcePolicy, rules, descs); err != nil {
+		return err
+	}
+	logger.Info("网络白名单策略已加载", "rules", len(rules))
+	return nil
+}
+
+// whitelistRules 将网络白名单暴露给本机检测服务
+type whitelistRules struct {
+	mgr *whitelist.Manager
+}
+
+func (w whitelistRules) ListWhitelist() []detectapi.WhitelistRule {
+	rules := w.mgr.List()
+	out := make([]detectapi.WhitelistRule, 0, len(rules))
+	for _, r := range rules {
+		out = append(out, toAPIWhitelistRule(r))
+	}
+	return out
+}
+
+func (w whitelistRules) AddWhitelist(value, desc string) (detectapi.WhitelistRule, error) {
+	r, err := w.mgr.AddRule(value, desc)
+	if err != nil {
+		return detectapi.WhitelistRule{}, whitelistAPIError(err)
+	}
+	return toAPIWhitelistRule(r), nil
+}
+
+func (w whitelistRules) RemoveWhitelist(value string) error {
+	return whitelistAPIError(w.mgr.RemoveRule(value))
+}
+
+func toAPIWhitelistRule(r whitelist.Rule) detectapi.WhitelistRule {
+	out := detectapi.WhitelistRule{Value: r.Value, Source: string(r.Source), Desc: r.Desc}
+	if !r.CreatedAt.IsZero() {
+		out.CreatedAt = r.CreatedAt.Unix()
+	}
+	return out
+}
+
+// whitelistAPIError 白名单错误对应的接口错误码
+func whitelistAPIError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, whitelist.ErrInvalidRule):
+		return &detectapi.Error{Code: detectapi.CodeInvalidArgument, Message: err.Error()}
+	case errors.Is(err, whitelist.ErrNotFound):
+		return &detectapi.Error{Code: detectapi.CodeNotFound, Message: err.Error()}
+	case errors.Is(err, whitelist.ErrReadOnly):
+		return &detectapi.Error{Code: detectapi.CodePermissionDenied, Message: err.Error()}
+	default:
+		return err
+	}
+}
@@ -0,0 +1,222 @@
+// Package archiveguard 压缩包与 OOXML/OFD 等 ZIP 容器的展开配额，防止压缩炸弹
+// 同一文件 (含其中嵌套展开的压缩包与嵌入对象) 共用一份配额：累计解压大小、文件数量、压缩比、嵌套层数与单项解压时长。
+// 超出配额时返回可用 errors.Is(err, ErrBomb) 识别的 *BombError
+package archiveguard
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrBomb 疑似压缩炸弹
+var ErrBomb = errors.New("archive bomb")
+
+// BombError 超出展开配额
+type BombError struct {
+	Name   string // 触发的包内文件，与单个文件无关时为空
+	Reason string
+}
+
+func (e *BombError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("archive bomb: %s", e.Reason)
+	}
+	return fmt.Sprintf("archive bomb: %s: %s", e.Name, e.Reason)
+}
+
+// Is 支持 errors.Is(err, ErrBomb)
+func (e *BombError) Is(target error) bool {
+	return target == ErrBomb
+}
+
+// Limits 展开配额，各项为 0 时不限制
+type Limits struct {
+	// 累计解压大小
+	MaxTotal int64
+	// 累计展开的文件数量
+	MaxEntries int
+	// 单个文件的最大压缩比 (解压大小 / 压缩大小)，解压超过 RatioThreshold 后才检查
+	MaxRatio       int64
+	RatioThreshold int64
+	// 压缩包与嵌入对象的最大嵌套层数
+	MaxDepth int
+	// 单个文件的解压时长
+	EntryTimeout time.Duration
+}
+
+// DefaultLimits 默认配额
+// 正常 Office/OFD 文档的 XML 部件压缩比一般在 20 以内，超过 100 倍且解压超过 1MB 视为炸弹
+func DefaultLimits() Limits {
+	return Limits{
+		MaxTotal:       256 * 1024 * 1024,
+		MaxEntries:     1000,
+		MaxRatio:       100,
+		RatioThreshold: 1024 * 1024,
+		MaxDepth:       4,
+		EntryTimeout:   10 * time.Second,
+	}
+}
+
+// Quota 一个文件的展开配额，可在多个 goroutine 中共用
+type Quota struct {
+	limits Limits
+	depth  int
+	shared *usage
+}
+
+// usage 同一文件各层共用的计数
+type usage struct {
+	mu      sync.Mutex
+	total   int64
+	entries int
+}
+
+// New 按 limits 创建配额
+func New(limits Limits) *Quota {
+	return &Quota{limits: limits, shared: &usage{}}
+}
+
+// Default 按 DefaultLimits 创建配额
+func Default() *Quota {
+	return New(DefaultLimits())
+}
+
+type quotaKey struct{}
+
+// NewContext 返回携带配额的 ctx，嵌套展开时传给下层
+func NewContext(ctx context.Context, q *Quota) context.Context {
+	return context.WithValue(ctx, quotaKey{}, q)
+}
+
+// FromContext 取 ctx 携带的配额，没有时按默认配额新建
+func FromContext(ctx context.Context) *Quota {
+	if q, ok := ctx.Value(quotaKey{}).(*Quota); ok {
+		return q
+	}
+	return Default()
+}
+
+// Enter 进入下一层嵌套，返回共用计数的下层配额；超过嵌套层数时返回 *BombError
+func (q *Quota) Enter() (*Quota, error) {
+	if q.limits.MaxDepth > 0 && q.depth >= q.limits.MaxDepth {
+		return nil, &BombError{Reason: fmt.Sprintf("nesting depth exceeds %d", q.limits.MaxDepth)}
+	}
+	return &Quota{limits: q.limits, depth: q.depth + 1, shared: q.shared}, nil
+}
+
+// Depth 当前嵌套层数
+func (q *Quota) Depth() int {
+	return q.depth
+}
+
+// Open 打开 ZIP 中的文件，读取过程中按实际解压的字节检查配额
+// 头部声明的大小不可信，只用于提前拒绝声明即超出配额的文件
+func (q *Quota) Open(f *zip.File) (io.ReadCloser, error) {
+	if err := q.admit(f.Name, int64(f.UncompressedSize64), int64(f.CompressedSize64)); err != nil {
+		return nil, err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{q.Reader(f.Name, rc, int64(f.CompressedSize64)), rc}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ReadAll 读取 ZIP 中的整个文件
+func (q *Quota) ReadAll(f *zip.File) ([]byte, error) {
+	rc, err := q.Open(f)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// Reader 包装已解压的数据流 (tar/gzip 等)，compressed 为压缩大小，未知时传 -1 不检查压缩比
+// 流式展开的文件需先调用 Admit 登记
+func (q *Quota) Reader(name string, r io.Reader, compressed int64) io.Reader {
+	g := &guardedReader{Reader: r, quota: q, name: name, compressed: compressed}
+	if q.limits.EntryTimeout > 0 {
+		g.deadline = time.Now().Add(q.limits.EntryTimeout)
+	}
+	return g
+}
+
+// Admit 登记一个流式展开的文件 (tar/gzip)，size 为声明的大小，未知时传 0
+func (q *Quota) Admit(name string, size int64) error {
+	return q.admit(name, size, -1)
+}
+
+// admit 登记一个文件并按声明的大小检查配额
+func (q *Quota) admit(name string, declared, compressed int64) error {
+	q.shared.mu.Lock()
+	defer q.shared.mu.Unlock()
+
+	q.shared.entries++
+	if q.limits.MaxEntries > 0 && q.shared.entries > q.limits.MaxEntries {
+		return &BombError{Name: name, Reason: fmt.Sprintf("entry count exceeds %d", q.limits.MaxEntries)}
+	}
+	if q.limits.MaxTotal > 0 && q.shared.total+declared > q.limits.MaxTotal {
+		return &BombError{Name: name, Reason: fmt.Sprintf("declared size %d exceeds total limit %d", declared, q.limits.MaxTotal)}
+	}
+	return q.checkRatio(name, declared, compressed)
+}
+
+// add 记账 n 字节实际解压的数据
+func (q *Quota) add(name string, n int64) error {
+	q.shared.mu.Lock()
+	defer q.shared.mu.Unlock()
+
+	q.shared.total += n
+	if q.limits.MaxTotal > 0 && q.shared.total > q.limits.MaxTotal {
+		return &BombError{Name: name, Reason: fmt.Sprintf("decompressed size exceeds %d", q.limits.MaxTotal)}
+	}
+	return nil
+}
+
+func (q *Quota) checkRatio(name string, size, compressed int64) error {
+	if q.limits.MaxRatio <= 0 || compressed < 0 || size <= q.limits.RatioThreshold {
+		return nil
+	}
+	if compressed == 0 || size/compressed > q.limits.MaxRatio {
+		return &BombError{Name: name, Reason: fmt.Sprintf("compression ratio exceeds %d", q.limits.MaxRatio)}
+	}
+	return nil
+}
+
+// guardedReader 按实际读出的字节记账
+type guardedReader struct {
+	io.Reader
+	quota      *Quota
+	name       string
+	compressed int64
+	read       int64
+	deadline   time.Time
+}
+
+func (g *guardedReader) Read(p []byte) (int, error) {
+	if !g.deadline.IsZero() && time.Now().After(g.deadline) {
+		return 0, &BombError{Name: g.name, Reason: fmt.Sprintf("decompression exceeds %s", g.quota.limits.EntryTimeout)}
+	}
+	n, err := g.Reader.Read(p)
+	if n > 0 {
+		g.read += int64(n)
+		if qerr := g.quota.add(g.name, int64(n)); qerr != nil {
+			return n, qerr
+		}
+		if qerr := g.quota.checkRatio(g.name, g.read, g.compressed); qerr != nil {
+			return n, qerr
+		}
+	}
+	return n, err
+}
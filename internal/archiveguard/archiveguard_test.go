@@ -0,0 +1,120 @@
+package archiveguard
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestQuota_Ratio(t *testing.T) {
+	zr := buildZip(t, map[string][]byte{
+		"zeros.xml": make([]byte, 4*1024*1024),
+		"text.xml":  []byte(strings.Repeat("<w:t>正文</w:t>", 100)),
+	})
+
+	for _, f := range zr.File {
+		_, err := Default().ReadAll(f)
+		switch f.Name {
+		case "zeros.xml":
+			var be *BombError
+			if !errors.Is(err, ErrBomb) || !errors.As(err, &be) || be.Name != "zeros.xml" {
+				t.Errorf("%s: err = %v", f.Name, err)
+			}
+		default:
+			// 解压未超过 RatioThreshold 的小文件不检查压缩比
+			if err != nil {
+				t.Errorf("%s: err = %v", f.Name, err)
+			}
+		}
+	}
+}
+
+func TestQuota_Total(t *testing.T) {
+	zr := buildZip(t, map[string][]byte{
+		"a.bin": bytes.Repeat([]byte("x"), 800),
+		"b.bin": bytes.Repeat([]byte("y"), 800),
+	})
+
+	q := New(Limits{MaxTotal: 1024})
+	if _, err := q.ReadAll(zr.File[0]); err != nil {
+		t.Fatalf("ReadAll(%s) error = %v", zr.File[0].Name, err)
+	}
+	// 声明的大小已超出剩余配额，不解压直接拒绝
+	if _, err := q.Open(zr.File[1]); !errors.Is(err, ErrBomb) {
+		t.Errorf("Open(%s) err = %v, want ErrBomb", zr.File[1].Name, err)
+	}
+}
+
+func TestQuota_SharedAcrossLevels(t *testing.T) {
+	q := New(Limits{MaxEntries: 2, MaxTotal: 100, MaxDepth: 1})
+	child, err := q.Enter()
+	if err != nil || child.Depth() != 1 {
+		t.Fatalf("Enter() = %v, %v", child, err)
+	}
+	if _, err := child.Enter(); !errors.Is(err, ErrBomb) {
+		t.Errorf("超过嵌套层数 err = %v", err)
+	}
+
+	// 文件数量与解压大小在各层之间累计
+	if err := q.Admit("a", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := child.Admit("b", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Admit("c", 10); !errors.Is(err, ErrBomb) {
+		t.Errorf("超过文件数量 err = %v", err)
+	}
+
+	q = New(Limits{MaxTotal: 100})
+	child, _ = q.Enter()
+	io.Copy(io.Discard, q.Reader("a", bytes.NewReader(make([]byte, 60)), -1))
+	if _, err := io.Copy(io.Discard, child.Reader("b", bytes.NewReader(make([]byte, 60)), -1)); !errors.Is(err, ErrBomb) {
+		t.Errorf("超过累计大小 err = %v", err)
+	}
+}
+
+func TestQuota_EntryTimeout(t *testing.T) {
+	q := New(Limits{EntryTimeout: time.Millisecond})
+	r := q.Reader("slow", bytes.NewReader(make([]byte, 10)), -1)
+	time.Sleep(5 * time.Millisecond)
+	if _, err := r.Read(make([]byte, 4)); !errors.Is(err, ErrBomb) {
+		t.Errorf("Read() err = %v, want ErrBomb", err)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if q := FromContext(context.Background()); q == nil || q.Depth() != 0 {
+		t.Fatalf("FromContext() = %v", q)
+	}
+	q, _ := Default().Enter()
+	if got := FromContext(NewContext(context.Background(), q)); got != q {
+		t.Errorf("FromContext() 未返回 ctx 中的配额")
+	}
+}
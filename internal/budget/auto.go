@@ -0,0 +1,70 @@
+package budget
+
+import (
+	"math"
+	"runtime"
+)
+
+const (
+	// defaultMemory 无法获知内存上限时的内存预算
+	defaultMemory = 1024 * 1024 * 1024
+	// defaultMemoryPercent 内存预算占 cgroup 内存上限 (未限制时为物理内存) 的百分比
+	defaultMemoryPercent = 50
+)
+
+// Config 预算配置，各项为 0 时自动推算
+type Config struct {
+	// 总并发槽位，自动时为 cgroup CPU 配额 (向上取整)，未限制时为 CPU 核数
+	Workers int
+	// 内存预算 (MB)
+	MemoryMB int64
+	// 自动推算内存预算时占内存上限的百分比
+	MemoryPercent int
+	// 压缩包同时展开数上限，自动时为总槽位的一半
+	Archive int
+	// OCR 同时识别数上限，自动时为总槽位的一半
+	OCR int
+}
+
+// Quota 所在 cgroup 的资源上限，0 表示未限制或无法获知
+type Quota struct {
+	CPUs   float64
+	Memory int64
+}
+
+// Auto 按配置与 cgroup 配额推算预算上限
+func Auto(cfg Config) Limits {
+	return autoLimits(cfg, DetectQuota(), runtime.NumCPU(), physicalMemory())
+}
+
+func autoLimits(cfg Config, q Quota, numCPU int, physMem int64) Limits {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = numCPU
+		if q.CPUs > 0 {
+			workers = min(numCPU, max(1, int(math.Ceil(q.CPUs))))
+		}
+	}
+
+	memory := cfg.MemoryMB * 1024 * 1024
+	if memory <= 0 {
+		percent := cfg.MemoryPercent
+		if percent <= 0 || percent > 100 {
+			percent = defaultMemoryPercent
+		}
+		limit := q.Memory
+		if limit <= 0 || (physMem > 0 && limit > physMem) {
+			limit = physMem
+		}
+		if limit > 0 {
+			memory = limit * int64(percent) / 100
+		}
+	}
+
+	return Limits{
+		Workers: workers,
+		Memory:  memory,
+		Archive: cfg.Archive,
+		OCR:     cfg.OCR,
+	}.normalize()
+}
@@ -0,0 +1,284 @@
+// Package budget 进程级并发与内存预算
+// 扫描、压缩包展开与 OCR 共享总并发槽位与内存预算，并各有并发上限；
+// 默认值按所在 cgroup 的 CPU 配额与内存上限推算，使代理进程不超出容器配额
+package budget
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Class 任务类别
+type Class int
+
+const (
+	// ClassScan 单个文件的检测
+	ClassScan Class = iota
+	// ClassArchive 压缩包展开
+	ClassArchive
+	// ClassOCR 图片文字识别
+	ClassOCR
+
+	numClasses
+)
+
+func (c Class) String() string {
+	switch c {
+	case ClassScan:
+		return "scan"
+	case ClassArchive:
+		return "archive"
+	case ClassOCR:
+		return "ocr"
+	default:
+		return fmt.Sprintf("class(%d)", int(c))
+	}
+}
+
+// Limits 预算上限
+type Limits struct {
+	// 总并发槽位
+	Workers int
+	// 内存预算 (字节)
+	Memory int64
+	// 压缩包同时展开数上限
+	Archive int
+	// OCR 同时识别数上限
+	OCR int
+}
+
+// normalize 补全缺省值：至少 1 个槽位，压缩包与 OCR 默认占一半槽位且不超过总槽位
+func (l Limits) normalize() Limits {
+	if l.Workers <= 0 {
+		l.Workers = 1
+	}
+	if l.Memory <= 0 {
+		l.Memory = defaultMemory
+	}
+	half := max(1, l.Workers/2)
+	if l.Archive <= 0 {
+		l.Archive = half
+	}
+	if l.OCR <= 0 {
+		l.OCR = half
+	}
+	l.Archive = min(l.Archive, l.Workers)
+	l.OCR = min(l.OCR, l.Workers)
+	return l
+}
+
+func (l Limits) cap(c Class) int {
+	switch c {
+	case ClassArchive:
+		return l.Archive
+	case ClassOCR:
+		return l.OCR
+	default:
+		return l.Workers
+	}
+}
+
+// Usage 当前占用
+type Usage struct {
+	// 顶层任务占用的槽位
+	Slots  int
+	Memory int64
+	// 各类别进行中的任务数 (含嵌套任务)
+	Active map[Class]int
+}
+
+// Release 归还预算，可重复调用
+type Release func()
+
+// ticket 顶层任务的预算凭证，随 ctx 传给嵌套任务
+type ticket struct {
+	classes [numClasses]bool
+}
+
+type ticketKey struct{}
+
+// Scheduler 并发与内存预算调度器
+// 顶层任务 (无凭证的 ctx) 占用一个槽位，等待槽位、类别上限与内存均满足；
+// 嵌套任务 (如检测中的压缩包展开、OCR) 沿用父任务的槽位，只等待类别上限，内存直接记账不等待，
+// 避免持有预算的任务相互等待；超支期间新的顶层任务等待
+type Scheduler struct {
+	mu      sync.Mutex
+	limits  Limits
+	slots   int
+	active  [numClasses]int
+	memory  int64
+	changed chan struct{}
+}
+
+// New 创建调度器
+func New(limits Limits) *Scheduler {
+	return &Scheduler{
+		limits:  limits.normalize(),
+		changed: make(chan struct{}),
+	}
+}
+
+// Limits 当前上限
+func (s *Scheduler) Limits() Limits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limits
+}
+
+// Workers 总并发槽位，供各模块确定工作协程数
+func (s *Scheduler) Workers() int {
+	return s.Limits().Workers
+}
+
+// SetLimits 调整上限 (配置重载时调用)，已占用的预算不受影响
+func (s *Scheduler) SetLimits(limits Limits) {
+	s.mu.Lock()
+	s.limits = limits.normalize()
+	s.broadcast()
+	s.mu.Unlock()
+}
+
+// Usage 当前占用
+func (s *Scheduler) Usage() Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := Usage{Slots: s.slots, Memory: s.memory, Active: make(map[Class]int, numClasses)}
+	for c := Class(0); c < numClasses; c++ {
+		u.Active[c] = s.active[c]
+	}
+	return u
+}
+
+// Acquire 申请 class 类任务的预算，mem 为预计占用的内存 (超过预算时按预算计，允许单独运行)
+// 返回的 ctx 携带凭证，传给嵌套任务；ctx 取消时返回其错误
+func (s *Scheduler) Acquire(ctx context.Context, class Class, mem int64) (context.Context, Release, error) {
+	parent, _ := ctx.Value(ticketKey{}).(*ticket)
+	nested := parent != nil
+	// 同类嵌套 (如检测入口被重复调用) 直接通过，不重复记账
+	if nested && parent.classes[class] {
+		return ctx, func() {}, nil
+	}
+
+	for {
+		s.mu.Lock()
+		if mem > s.limits.Memory {
+			mem = s.limits.Memory
+		}
+		if s.admit(class, mem, nested) {
+			s.active[class]++
+			s.memory += mem
+			if !nested {
+				s.slots++
+			}
+			s.mu.Unlock()
+			break
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx, func() {}, ctx.Err()
+		case <-changed:
+		}
+	}
+
+	t := &ticket{}
+	if parent != nil {
+		t.classes = parent.classes
+	}
+	t.classes[class] = true
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.active[class]--
+			s.memory -= mem
+			if !nested {
+				s.slots--
+			}
+			s.broadcast()
+			s.mu.Unlock()
+		})
+	}
+	return context.WithValue(ctx, ticketKey{}, t), release, nil
+}
+
+// Limit 只按类别上限排队，不占槽位、不计内存
+// 用于无法传递 ctx 的嵌套调用 (如检测中启动的外部 tesseract 进程)，调用方不得再等待其他预算
+func (s *Scheduler) Limit(class Class) Release {
+	for {
+		s.mu.Lock()
+		if s.active[class] < s.limits.cap(class) {
+			s.active[class]++
+			s.mu.Unlock()
+			break
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		<-changed
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.active[class]--
+			s.broadcast()
+			s.mu.Unlock()
+		})
+	}
+}
+
+// admit 是否可立即占用，调用方持有锁
+func (s *Scheduler) admit(class Class, mem int64, nested bool) bool {
+	if s.active[class] >= s.limits.cap(class) {
+		return false
+	}
+	if nested {
+		return true
+	}
+	return s.slots < s.limits.Workers && s.memory+mem <= s.limits.Memory
+}
+
+// broadcast 唤醒所有等待者，调用方持有锁
+func (s *Scheduler) broadcast() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// ==========================================
+// 全局调度器
+// ==========================================
+
+var (
+	defaultMu        sync.RWMutex
+	defaultScheduler *Scheduler
+)
+
+// Default 返回全局调度器
+// 未调用 SetDefault 时按 cgroup 配额自动推算上限
+func Default() *Scheduler {
+	defaultMu.RLock()
+	s := defaultScheduler
+	defaultMu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultScheduler == nil {
+		defaultScheduler = New(Auto(Config{}))
+	}
+	return defaultScheduler
+}
+
+// SetDefault 替换全局调度器
+func SetDefault(s *Scheduler) {
+	defaultMu.Lock()
+	defaultScheduler = s
+	defaultMu.Unlock()
+}
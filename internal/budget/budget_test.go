@@ -0,0 +1,168 @@
+package budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Slots(t *testing.T) {
+	s := New(Limits{Workers: 2, Memory: 1000})
+	ctx := context.Background()
+
+	_, r1, _ := s.Acquire(ctx, ClassScan, 10)
+	_, r2, _ := s.Acquire(ctx, ClassScan, 10)
+
+	// 槽位用尽时等待
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.Acquire(short, ClassScan, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("槽位用尽时应等待: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, r3, err := s.Acquire(ctx, ClassScan, 10)
+		if err == nil {
+			r3()
+		}
+		close(done)
+	}()
+	r1()
+	r1() // 重复归还无副作用
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("归还槽位后等待者未被唤醒")
+	}
+	r2()
+
+	if u := s.Usage(); u.Slots != 0 || u.Memory != 0 {
+		t.Errorf("全部归还后 Usage() = %+v", u)
+	}
+}
+
+func TestScheduler_Memory(t *testing.T) {
+	s := New(Limits{Workers: 4, Memory: 100})
+	ctx := context.Background()
+
+	_, r1, _ := s.Acquire(ctx, ClassScan, 80)
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.Acquire(short, ClassScan, 30); err == nil {
+		t.Fatal("内存不足时应等待")
+	}
+	r1()
+
+	// 超过预算的任务按预算计，可单独运行
+	_, r2, err := s.Acquire(ctx, ClassScan, 500)
+	if err != nil || s.Usage().Memory != 100 {
+		t.Fatalf("超预算任务: err = %v, usage = %+v", err, s.Usage())
+	}
+	r2()
+}
+
+func TestScheduler_Nested(t *testing.T) {
+	s := New(Limits{Workers: 1, Memory: 100, OCR: 1})
+	ctx, release, _ := s.Acquire(context.Background(), ClassScan, 100)
+	defer release()
+
+	// 嵌套任务沿用父任务槽位，内存直接记账
+	octx, ocr, err := s.Acquire(ctx, ClassOCR, 50)
+	if err != nil {
+		t.Fatalf("嵌套任务不应等待: %v", err)
+	}
+	if u := s.Usage(); u.Slots != 1 || u.Memory != 150 || u.Active[ClassOCR] != 1 {
+		t.Errorf("Usage() = %+v", u)
+	}
+
+	// 同类嵌套直接通过
+	if _, r, err := s.Acquire(octx, ClassOCR, 50); err != nil {
+		t.Fatalf("同类嵌套: %v", err)
+	} else {
+		r()
+	}
+
+	// 类别上限对嵌套任务生效
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := s.Acquire(short, ClassOCR, 0); err == nil {
+		t.Error("OCR 上限已满时应等待")
+	}
+	ocr()
+}
+
+func TestScheduler_Limit(t *testing.T) {
+	s := New(Limits{Workers: 1, OCR: 1})
+	_, release, _ := s.Acquire(context.Background(), ClassScan, 0)
+	defer release()
+
+	// 不占槽位，槽位用尽时仍可进入
+	r1 := s.Limit(ClassOCR)
+	done := make(chan struct{})
+	go func() {
+		s.Limit(ClassOCR)()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("OCR 上限已满时应等待")
+	case <-time.After(20 * time.Millisecond):
+	}
+	r1()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("归还后等待者未被唤醒")
+	}
+}
+
+func TestScheduler_SetLimits(t *testing.T) {
+	s := New(Limits{Workers: 1})
+	ctx := context.Background()
+	_, r1, _ := s.Acquire(ctx, ClassScan, 0)
+	defer r1()
+
+	done := make(chan error, 1)
+	go func() {
+		_, r, err := s.Acquire(ctx, ClassScan, 0)
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+	s.SetLimits(Limits{Workers: 2})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("扩大上限后等待者未被唤醒")
+	}
+}
+
+func TestAutoLimits(t *testing.T) {
+	const gb = 1024 * 1024 * 1024
+
+	l := autoLimits(Config{}, Quota{CPUs: 1.5, Memory: 2 * gb}, 8, 16*gb)
+	if l.Workers != 2 || l.Memory != gb || l.Archive != 1 || l.OCR != 1 {
+		t.Errorf("cgroup 限制: %+v", l)
+	}
+
+	l = autoLimits(Config{}, Quota{}, 8, 16*gb)
+	if l.Workers != 8 || l.Memory != 8*gb || l.OCR != 4 {
+		t.Errorf("未限制: %+v", l)
+	}
+
+	l = autoLimits(Config{Workers: 3, MemoryMB: 512, OCR: 10}, Quota{CPUs: 1}, 8, 0)
+	if l.Workers != 3 || l.Memory != 512*1024*1024 || l.OCR != 3 {
+		t.Errorf("配置优先: %+v", l)
+	}
+
+	l = autoLimits(Config{}, Quota{}, 4, 0)
+	if l.Memory != defaultMemory {
+		t.Errorf("无法获知内存时使用默认预算: %+v", l)
+	}
+}
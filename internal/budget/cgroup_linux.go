@@ -0,0 +1,156 @@
+//go:build linux
+
+package budget
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// DetectQuota 读取当前进程所在 cgroup (v2 或 v1) 的 CPU 配额与内存上限
+// 逐级向上取各层上限的最小值，容器内 cgroup 命名空间的根即容器自身的 cgroup
+func DetectQuota() Quota {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return Quota{}
+	}
+	return readQuota(cgroupRoot, string(data))
+}
+
+// readQuota 按 /proc/self/cgroup 内容在 root 下查找配额
+func readQuota(root, procCgroup string) Quota {
+	var q Quota
+	for _, line := range strings.Split(strings.TrimSpace(procCgroup), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		controllers, path := parts[1], parts[2]
+
+		if parts[0] == "0" && controllers == "" {
+			// cgroup v2 统一层级 (混合模式挂载在 unified 下)
+			v2 := root
+			if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+				v2 = filepath.Join(root, "unified")
+			}
+			walkUp(v2, path, func(dir string) {
+				q.CPUs = minPositive(q.CPUs, readCPUMax(filepath.Join(dir, "cpu.max")))
+				q.Memory = minPositive(q.Memory, readLimit(filepath.Join(dir, "memory.max")))
+			})
+			continue
+		}
+
+		for _, c := range strings.Split(controllers, ",") {
+			switch c {
+			case "cpu":
+				walkUp(filepath.Join(root, controllers), path, func(dir string) {
+					q.CPUs = minPositive(q.CPUs, readCFSQuota(dir))
+				})
+			case "memory":
+				walkUp(filepath.Join(root, c), path, func(dir string) {
+					q.Memory = minPositive(q.Memory, readLimit(filepath.Join(dir, "memory.limit_in_bytes")))
+				})
+			}
+		}
+	}
+	return q
+}
+
+// walkUp 从 base/path 逐级向上访问到 base
+func walkUp(base, path string, fn func(dir string)) {
+	dir := filepath.Join(base, filepath.Clean("/"+path))
+	for {
+		fn(dir)
+		if dir == base || len(dir) < len(base) {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// readCPUMax 解析 v2 cpu.max ("$MAX $PERIOD"，MAX 为 "max" 表示不限)
+func readCPUMax(path string) float64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || quota <= 0 || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// readCFSQuota 解析 v1 cpu.cfs_quota_us / cpu.cfs_period_us (-1 表示不限)
+func readCFSQuota(dir string) float64 {
+	quota := readInt(filepath.Join(dir, "cpu.cfs_quota_us"))
+	period := readInt(filepath.Join(dir, "cpu.cfs_period_us"))
+	if quota <= 0 || period <= 0 {
+		return 0
+	}
+	return float64(quota) / float64(period)
+}
+
+// readLimit 解析内存上限，"max" 或接近 int64 上限 (v1 不限) 视为未限制
+func readLimit(path string) int64 {
+	v := readInt(path)
+	if v <= 0 || v >= 1<<62 {
+		return 0
+	}
+	return v
+}
+
+func readInt(path string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// minPositive 取两者中较小的正数，0 表示未限制
+func minPositive[T int64 | float64](a, b T) T {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 || a < b {
+		return a
+	}
+	return b
+}
+
+// physicalMemory 物理内存总量 (/proc/meminfo MemTotal)
+func physicalMemory() int64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return kb * 1024
+		}
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCgroupFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadQuota_V2(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, filepath.Join(root, "cgroup.controllers"), "cpu memory")
+	writeCgroupFile(t, filepath.Join(root, "pod/cpu.max"), "400000 100000\n")
+	writeCgroupFile(t, filepath.Join(root, "pod/memory.max"), "1073741824\n")
+	writeCgroupFile(t, filepath.Join(root, "pod/agent/cpu.max"), "150000 100000\n")
+	writeCgroupFile(t, filepath.Join(root, "pod/agent/memory.max"), "max\n")
+
+	// 取各层最小值
+	q := readQuota(root, "0::/pod/agent\n")
+	if q.CPUs != 1.5 || q.Memory != 1<<30 {
+		t.Errorf("readQuota() = %+v", q)
+	}
+}
+
+func TestReadQuota_V1(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, filepath.Join(root, "cpu,cpuacct/docker/abc/cpu.cfs_quota_us"), "200000\n")
+	writeCgroupFile(t, filepath.Join(root, "cpu,cpuacct/docker/abc/cpu.cfs_period_us"), "100000\n")
+	writeCgroupFile(t, filepath.Join(root, "memory/memory.limit_in_bytes"), "9223372036854771712\n")
+	writeCgroupFile(t, filepath.Join(root, "memory/docker/abc/memory.limit_in_bytes"), "536870912\n")
+
+	q := readQuota(root, "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n0::/\n")
+	if q.CPUs != 2 || q.Memory != 512<<20 {
+		t.Errorf("readQuota() = %+v", q)
+	}
+}
+
+func TestReadQuota_Unlimited(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFile(t, filepath.Join(root, "cgroup.controllers"), "")
+	writeCgroupFile(t, filepath.Join(root, "cpu.max"), "max 100000\n")
+	if q := readQuota(root, "0::/\n"); q != (Quota{}) {
+		t.Errorf("readQuota() = %+v", q)
+	}
+}
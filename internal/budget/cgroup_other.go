@@ -0,0 +1,12 @@
+//go:build !linux
+
+package budget
+
+// DetectQuota 非 Linux 平台没有 cgroup
+func DetectQuota() Quota {
+	return Quota{}
+}
+
+func physicalMemory() int64 {
+	return 0
+}
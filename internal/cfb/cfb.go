@@ -0,0 +1,380 @@
+// Package cfb 读取 OLE2 复合文档 (Compound File Binary, MS-CFB) 中的流
+// 只支持读取：旧版 Office 文档与 OOXML 中的 OLE 嵌入对象 (oleObject*.bin) 均为此格式
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+// Signature 复合文档头部魔数
+var Signature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+var (
+	// ErrFormat 不是复合文档或结构损坏
+	ErrFormat = errors.New("cfb: invalid compound file")
+	// ErrNotFound 指定名称的流不存在
+	ErrNotFound = errors.New("cfb: stream not found")
+)
+
+// 扇区编号中的特殊值
+const (
+	maxRegSect = 0xFFFFFFFA
+	endOfChain = 0xFFFFFFFE
+	noStream   = 0xFFFFFFFF
+)
+
+// 目录项类型
+const (
+	typeStorage = 1
+	typeStream  = 2
+	typeRoot    = 5
+)
+
+const (
+	headerSize     = 512
+	dirEntrySize   = 128
+	headerDifatLen = 109
+)
+
+// Entry 目录中的一个流
+type Entry struct {
+	Name string
+	Path string // 自根存储起以 "/" 分隔的完整路径
+	Size int64
+
+	start uint32
+}
+
+// File 解析后的复合文档
+type File struct {
+	data       []byte
+	sectorSize int
+	miniSize   int
+	miniCutoff int64
+	fat        []uint32
+	miniFat    []uint32
+	ministream []byte
+	entries    []Entry
+}
+
+// IsCFB data 是否以复合文档魔数开头
+func IsCFB(data []byte) bool {
+	return bytes.HasPrefix(data, Signature)
+}
+
+// Open 解析内存中的复合文档
+func Open(data []byte) (*File, error) {
+	if len(data) < headerSize || !IsCFB(data) {
+		return nil, ErrFormat
+	}
+	le := binary.LittleEndian
+	sectorShift := le.Uint16(data[0x1E:])
+	miniShift := le.Uint16(data[0x20:])
+	if sectorShift != 9 && sectorShift != 12 || miniShift != 6 {
+		return nil, ErrFormat
+	}
+
+	f := &File{
+		data:       data,
+		sectorSize: 1 << sectorShift,
+		miniSize:   1 << miniShift,
+		miniCutoff: int64(le.Uint32(data[0x38:])),
+	}
+	if err := f.readFat(); err != nil {
+		return nil, err
+	}
+
+	dir, err := f.readChain(le.Uint32(data[0x30:]), -1)
+	if err != nil {
+		return nil, err
+	}
+	root, err := f.readDirectory(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// 小于 miniCutoff 的流存放在根目录项指向的迷你流中
+	if first := le.Uint32(data[0x3C:]); first != endOfChain && first != noStream {
+		raw, err := f.readChain(first, -1)
+		if err != nil {
+			return nil, err
+		}
+		f.miniFat = toSectors(raw)
+		// 迷你流的最后一个迷你扇区可能不满，按整条链读取
+		if f.ministream, err = f.readChain(root.start, -1); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// readFat 按 DIFAT (头部 109 项及后续 DIFAT 扇区链) 读取 FAT
+func (f *File) readFat() error {
+	le := binary.LittleEndian
+	numFat := int(le.Uint32(f.data[0x2C:]))
+	if numFat > f.sectorCount() {
+		return ErrFormat
+	}
+
+	var fatSectors []uint32
+	for i := 0; i < headerDifatLen && len(fatSectors) < numFat; i++ {
+		fatSectors = append(fatSectors, le.Uint32(f.data[0x4C+i*4:]))
+	}
+	next := le.Uint32(f.data[0x44:])
+	for visited := 0; len(fatSectors) < numFat && next <= maxRegSect; visited++ {
+		sec, ok := f.sector(next)
+		if !ok || visited > f.sectorCount() {
+			return ErrFormat
+		}
+		per := f.sectorSize/4 - 1
+		for i := 0; i < per && len(fatSectors) < numFat; i++ {
+			fatSectors = append(fatSectors, le.Uint32(sec[i*4:]))
+		}
+		next = le.Uint32(sec[per*4:])
+	}
+	if len(fatSectors) < numFat {
+		return ErrFormat
+	}
+
+	f.fat = make([]uint32, 0, numFat*f.sectorSize/4)
+	for _, n := range fatSectors {
+		sec, ok := f.sector(n)
+		if !ok {
+			return ErrFormat
+		}
+		f.fat = append(f.fat, toSectors(sec)...)
+	}
+	return nil
+}
+
+// readDirectory 解析目录项，返回根目录项
+func (f *File) readDirectory(dir []byte) (Entry, error) {
+	le := binary.LittleEndian
+	n := len(dir) / dirEntrySize
+	if n == 0 || dir[0x42] != typeRoot {
+		return Entry{}, ErrFormat
+	}
+
+	type node struct {
+		name               string
+		typ                byte
+		left, right, child uint32
+		start              uint32
+		size               int64
+	}
+	nodes := make([]node, n)
+	for i := range nodes {
+		e := dir[i*dirEntrySize : (i+1)*dirEntrySize]
+		nameLen := int(le.Uint16(e[0x40:]))
+		if nameLen > 64 {
+			nameLen = 64
+		}
+		u := make([]uint16, 0, nameLen/2)
+		for j := 0; j+1 < nameLen; j += 2 {
+			if c := le.Uint16(e[j:]); c != 0 {
+				u = append(u, c)
+			}
+		}
+		nodes[i] = node{
+			name:  string(utf16.Decode(u)),
+			typ:   e[0x42],
+			left:  le.Uint32(e[0x44:]),
+			right: le.Uint32(e[0x48:]),
+			child: le.Uint32(e[0x4C:]),
+			start: le.Uint32(e[0x74:]),
+			size:  int64(le.Uint64(e[0x78:])),
+		}
+		// 512 字节扇区的版本 3 文件只使用低 32 位
+		if f.sectorSize == 512 {
+			nodes[i].size &= 0xFFFFFFFF
+		}
+	}
+
+	// 每个存储的子项组成一棵红黑树，按中序遍历展开；visited 防止损坏文件造成循环
+	visited := make([]bool, n)
+	var walk func(id uint32, prefix string)
+	walk = func(id uint32, prefix string) {
+		if id >= uint32(n) || visited[id] {
+			return
+		}
+		visited[id] = true
+		nd := nodes[id]
+		walk(nd.left, prefix)
+		switch nd.typ {
+		case typeStream:
+			f.entries = append(f.entries, Entry{Name: nd.name, Path: prefix + nd.name, Size: nd.size, start: nd.start})
+		case typeStorage:
+			walk(nd.child, prefix+nd.name+"/")
+		}
+		walk(nd.right, prefix)
+	}
+	visited[0] = true
+	walk(nodes[0].child, "")
+
+	return Entry{Name: nodes[0].name, Size: nodes[0].size, start: nodes[0].start}, nil
+}
+
+// Entries 全部流 (按目录顺序)
+func (f *File) Entries() []Entry {
+	return f.entries
+}
+
+// Find 按名称查找流，name 含 "/" 时按完整路径匹配，否则匹配任意存储中的流；不区分大小写
+func (f *File) Find(name string) (Entry, bool) {
+	byPath := strings.Contains(name, "/")
+	for _, e := range f.entries {
+		if byPath && strings.EqualFold(e.Path, name) || !byPath && strings.EqualFold(e.Name, name) {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// ReadStream 读取流的全部内容
+func (f *File) ReadStream(name string) ([]byte, error) {
+	e, ok := f.Find(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return f.Read(e)
+}
+
+// Read 读取目录项对应的流
+func (f *File) Read(e Entry) ([]byte, error) {
+	if e.Size == 0 {
+		return []byte{}, nil
+	}
+	if e.Size < f.miniCutoff {
+		return f.readMiniChain(e.start, e.Size)
+	}
+	return f.readChain(e.start, e.Size)
+}
+
+// readChain 沿 FAT 读取扇区链，size 为负时读取整条链
+func (f *File) readChain(start uint32, size int64) ([]byte, error) {
+	if size > int64(len(f.data)) {
+		return nil, ErrFormat
+	}
+	var buf []byte
+	for n, steps := start, 0; n != endOfChain; steps++ {
+		sec, ok := f.sector(n)
+		if !ok || steps > len(f.fat) {
+			return nil, ErrFormat
+		}
+		buf = append(buf, sec...)
+		if size >= 0 && int64(len(buf)) >= size {
+			return buf[:size], nil
+		}
+		if int(n) >= len(f.fat) {
+			return nil, ErrFormat
+		}
+		n = f.fat[n]
+	}
+	if size > int64(len(buf)) {
+		return nil, ErrFormat
+	}
+	return buf, nil
+}
+
+// readMiniChain 沿迷你 FAT 在迷你流中读取
+func (f *File) readMiniChain(start uint32, size int64) ([]byte, error) {
+	if size > int64(len(f.ministream)) {
+		return nil, ErrFormat
+	}
+	buf := make([]byte, 0, size)
+	for n, steps := start, 0; int64(len(buf)) < size; steps++ {
+		off := int(n) * f.miniSize
+		if n > maxRegSect || int(n) >= len(f.miniFat) || off+f.miniSize > len(f.ministream) || steps > len(f.miniFat) {
+			return nil, ErrFormat
+		}
+		buf = append(buf, f.ministream[off:off+f.miniSize]...)
+		n = f.miniFat[n]
+	}
+	return buf[:size], nil
+}
+
+// sector 返回第 n 个扇区 (头部之后从 0 编号)
+func (f *File) sector(n uint32) ([]byte, bool) {
+	if n > maxRegSect {
+		return nil, false
+	}
+	off := (int64(n) + 1) * int64(f.sectorSize)
+	if off+int64(f.sectorSize) > int64(len(f.data)) {
+		return nil, false
+	}
+	return f.data[off : off+int64(f.sectorSize)], true
+}
+
+func (f *File) sectorCount() int {
+	return len(f.data)/f.sectorSize - 1
+}
+
+func toSectors(b []byte) []uint32 {
+	s := make([]uint32, len(b)/4)
+	for i := range s {
+		s[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return s
+}
+
+// ParseOle10Native 解析 "\x01Ole10Native" 流 (OLE 打包程序嵌入的任意文件)，返回原始文件名与文件内容
+// 结构: 总长度、类型、标签、源路径 (以 0 结尾)、保留字段、临时路径 (带长度前缀)、内容长度、内容
+func ParseOle10Native(data []byte) (string, []byte, error) {
+	r := &nativeReader{data: data}
+	r.skip(4 + 2) // 总长度、类型
+	label := r.cstring()
+	src := r.cstring()
+	r.skip(4)
+	r.skip(int(r.uint32())) // 临时路径
+	size := r.uint32()
+	if r.err || uint64(size) > uint64(len(data)-r.off) {
+		return "", nil, ErrFormat
+	}
+
+	name := label
+	if i := strings.LastIndexAny(src, `\/`); src != "" {
+		name = src[i+1:]
+	}
+	return name, data[r.off : r.off+int(size)], nil
+}
+
+// nativeReader 顺序读取，越界时置 err
+type nativeReader struct {
+	data []byte
+	off  int
+	err  bool
+}
+
+func (r *nativeReader) skip(n int) {
+	if n < 0 || r.off+n > len(r.data) {
+		r.err = true
+		r.off = len(r.data)
+		return
+	}
+	r.off += n
+}
+
+func (r *nativeReader) uint32() uint32 {
+	if r.off+4 > len(r.data) {
+		r.err = true
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.data[r.off:])
+	r.off += 4
+	return v
+}
+
+func (r *nativeReader) cstring() string {
+	i := bytes.IndexByte(r.data[r.off:], 0)
+	if i < 0 {
+		r.err = true
+		return ""
+	}
+	s := string(r.data[r.off : r.off+i])
+	r.off += i + 1
+	return s
+}
@@ -0,0 +1,240 @@
+package cfb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+type testStream struct {
+	path string // 最多一级存储，如 "ObjectPool/Contents"
+	data []byte
+}
+
+// buildCFB 构造版本 3 (512 字节扇区) 的复合文档，小流写入迷你流
+func buildCFB(t *testing.T, streams []testStream) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+
+	// 扇区 0 留给 FAT
+	sectors := [][]byte{nil}
+	fat := []uint32{0xFFFFFFFD}
+	alloc := func(data []byte) uint32 {
+		if len(data) == 0 {
+			return endOfChain
+		}
+		start := uint32(len(sectors))
+		for off := 0; off < len(data); off += 512 {
+			sec := make([]byte, 512)
+			copy(sec, data[off:])
+			sectors = append(sectors, sec)
+			fat = append(fat, uint32(len(sectors)))
+		}
+		fat[len(fat)-1] = endOfChain
+		return start
+	}
+
+	type dirEntry struct {
+		name               string
+		typ                byte
+		left, right, child uint32
+		start              uint32
+		size               int
+	}
+	entries := []dirEntry{{name: "Root Entry", typ: typeRoot, left: noStream, right: noStream, child: noStream}}
+	// 同一存储的子项以右兄弟串联
+	lastChild := map[int]int{}
+	link := func(parent, id int) {
+		if prev, ok := lastChild[parent]; ok {
+			entries[prev].right = uint32(id)
+		} else {
+			entries[parent].child = uint32(id)
+		}
+		lastChild[parent] = id
+	}
+	storages := map[string]int{}
+
+	var mini []byte
+	var miniFat []uint32
+	for _, s := range streams {
+		parent, name := 0, s.path
+		if i := strings.Index(s.path, "/"); i >= 0 {
+			storage := s.path[:i]
+			id, ok := storages[storage]
+			if !ok {
+				id = len(entries)
+				entries = append(entries, dirEntry{name: storage, typ: typeStorage, left: noStream, right: noStream, child: noStream})
+				link(0, id)
+				storages[storage] = id
+			}
+			parent, name = id, s.path[i+1:]
+		}
+
+		e := dirEntry{name: name, typ: typeStream, left: noStream, right: noStream, child: noStream, size: len(s.data), start: endOfChain}
+		switch {
+		case len(s.data) >= 4096:
+			e.start = alloc(s.data)
+		case len(s.data) > 0:
+			e.start = uint32(len(mini) / 64)
+			for off := 0; off < len(s.data); off += 64 {
+				sec := make([]byte, 64)
+				copy(sec, s.data[off:])
+				mini = append(mini, sec...)
+				miniFat = append(miniFat, uint32(len(mini)/64))
+			}
+			miniFat[len(miniFat)-1] = endOfChain
+		}
+		entries = append(entries, e)
+		link(parent, len(entries)-1)
+	}
+
+	entries[0].start = alloc(mini)
+	entries[0].size = len(mini)
+	miniFatBytes := make([]byte, len(miniFat)*4)
+	for i, v := range miniFat {
+		le.PutUint32(miniFatBytes[i*4:], v)
+	}
+	miniFatStart := alloc(miniFatBytes)
+
+	dir := make([]byte, len(entries)*dirEntrySize)
+	for i, e := range entries {
+		b := dir[i*dirEntrySize:]
+		u := utf16.Encode([]rune(e.name))
+		for j, c := range u {
+			le.PutUint16(b[j*2:], c)
+		}
+		le.PutUint16(b[0x40:], uint16((len(u)+1)*2))
+		b[0x42] = e.typ
+		b[0x43] = 1
+		le.PutUint32(b[0x44:], e.left)
+		le.PutUint32(b[0x48:], e.right)
+		le.PutUint32(b[0x4C:], e.child)
+		le.PutUint32(b[0x74:], e.start)
+		le.PutUint64(b[0x78:], uint64(e.size))
+	}
+	dirStart := alloc(dir)
+
+	if len(fat) > 128 {
+		t.Fatalf("测试文件过大")
+	}
+	sectors[0] = make([]byte, 512)
+	for i := range sectors[0] {
+		sectors[0][i] = 0xFF
+	}
+	for i, v := range fat {
+		le.PutUint32(sectors[0][i*4:], v)
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, Signature)
+	le.PutUint16(header[0x18:], 0x3E)
+	le.PutUint16(header[0x1A:], 3)
+	le.PutUint16(header[0x1C:], 0xFFFE)
+	le.PutUint16(header[0x1E:], 9)
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1)
+	le.PutUint32(header[0x30:], dirStart)
+	le.PutUint32(header[0x38:], 4096)
+	le.PutUint32(header[0x3C:], miniFatStart)
+	le.PutUint32(header[0x40:], uint32(len(miniFatBytes)+511)/512)
+	le.PutUint32(header[0x44:], endOfChain)
+	for i := 0; i < headerDifatLen; i++ {
+		le.PutUint32(header[0x4C+i*4:], noStream)
+	}
+	le.PutUint32(header[0x4C:], 0)
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	for _, sec := range sectors {
+		buf.Write(sec)
+	}
+	return buf.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 500)
+	data := buildCFB(t, []testStream{
+		{"\x01CompObj", []byte("Word.Document.8")},
+		{"Package", large},
+		{"ObjectPool/Contents", []byte(strings.Repeat("机密", 40))},
+		{"Empty", nil},
+	})
+
+	f, err := Open(data)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	var paths []string
+	for _, e := range f.Entries() {
+		paths = append(paths, e.Path)
+	}
+	if got := strings.Join(paths, ","); got != "\x01CompObj,Package,ObjectPool/Contents,Empty" {
+		t.Errorf("Entries() = %q", got)
+	}
+
+	tests := []struct {
+		name string
+		want []byte
+	}{
+		{"\x01compobj", []byte("Word.Document.8")},
+		{"Package", large},
+		{"Contents", []byte(strings.Repeat("机密", 40))},
+		{"ObjectPool/Contents", []byte(strings.Repeat("机密", 40))},
+		{"Empty", []byte{}},
+	}
+	for _, tt := range tests {
+		got, err := f.ReadStream(tt.name)
+		if err != nil || !bytes.Equal(got, tt.want) {
+			t.Errorf("ReadStream(%q) = %d 字节, err = %v", tt.name, len(got), err)
+		}
+	}
+	if _, err := f.ReadStream("Missing"); err != ErrNotFound {
+		t.Errorf("ReadStream(Missing) err = %v", err)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	valid := buildCFB(t, []testStream{{"Package", []byte("x")}})
+
+	// FAT 指向自身形成循环
+	loop := append([]byte(nil), valid...)
+	binary.LittleEndian.PutUint32(loop[0x30:], 0)
+	binary.LittleEndian.PutUint32(loop[512:], 0)
+
+	for name, data := range map[string][]byte{
+		"非复合文档": []byte("PK\x03\x04"),
+		"截断":    valid[:600],
+		"循环":    loop,
+	} {
+		if _, err := Open(data); err == nil {
+			t.Errorf("%s: Open() 未返回错误", name)
+		}
+	}
+}
+
+func TestParseOle10Native(t *testing.T) {
+	payload := []byte("绝密★启用前")
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&b, le, uint32(0))
+	binary.Write(&b, le, uint16(2))
+	b.WriteString("附件.txt\x00")
+	b.WriteString(`C:\Users\a\附件.txt` + "\x00")
+	binary.Write(&b, le, uint32(0x00030000))
+	tmp := `C:\Temp\附件.txt` + "\x00"
+	binary.Write(&b, le, uint32(len(tmp)))
+	b.WriteString(tmp)
+	binary.Write(&b, le, uint32(len(payload)))
+	b.Write(payload)
+
+	name, data, err := ParseOle10Native(b.Bytes())
+	if err != nil || name != "附件.txt" || !bytes.Equal(data, payload) {
+		t.Errorf("ParseOle10Native() = %q %q %v", name, data, err)
+	}
+
+	if _, _, err := ParseOle10Native(b.Bytes()[:b.Len()-2]); err == nil {
+		t.Error("截断的流未返回错误")
+	}
+}
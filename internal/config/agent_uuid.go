@@ -0,0 +1,226 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// =========================================================================
+// 本机稳定标识 (Agent UUID)
+// =========================================================================
+//
+// 由 machine-id 与 DMI 产品 UUID 派生 (UUIDv5)，重装客户端或清空数据目录后仍得到同一标识，
+// 服务端据此识别同一终端，避免重复登记。派生结果保存在数据目录，并记录硬件摘要：
+//   - 硬件摘要与本机不符 (数据目录随镜像克隆到其他主机) 时重新派生
+//   - 克隆主机 machine-id 未重置时，DMI UUID 仍可区分；服务端报告重复时调用 RegenerateAgentUUID 加盐重新生成
+//   - 两者均不可用 (如容器) 时使用随机 UUID，仅保存在数据目录
+
+var (
+	// machineIDPaths machine-id 文件，按顺序取第一个有效值
+	machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+	// dmiUUIDPath DMI 产品 UUID (仅 root 可读)
+	dmiUUIDPath = "/sys/class/dmi/id/product_uuid"
+
+	// agentUUIDFilename 派生结果文件名 (位于数据目录)
+	agentUUIDFilename = "agent.uuid"
+
+	// agentUUIDNamespace UUIDv5 命名空间
+	agentUUIDNamespace = [16]byte{0x6c, 0x66, 0x77, 0x2d, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x2d, 0x75, 0x75, 0x69, 0x64, 0x76, 0x35}
+
+	// agentUUID 当前标识，由 mu 保护
+	agentUUID     string
+	agentUUIDPath string
+)
+
+// 固件未填写或厂商统一填写的 DMI UUID，不能区分主机
+var bogusDMIUUIDs = map[string]bool{
+	"00000000-0000-0000-0000-000000000000": true,
+	"ffffffff-ffff-ffff-ffff-ffffffffffff": true,
+	"03000200-0400-0500-0006-000700080009": true,
+	"00020003-0004-0005-0006-000700080009": true,
+}
+
+// 标识来源
+const (
+	AgentUUIDSourceHardware = "hardware" // machine-id 和/或 DMI UUID
+	AgentUUIDSourceRandom   = "random"   // 无可用硬件标识
+)
+
+// agentUUIDFile 派生结果文件格式
+type agentUUIDFile struct {
+	UUID   string `json:"uuid"`
+	Source string `json:"source"`
+	// 派生时的硬件摘要，用于识别克隆的数据目录
+	Hardware string `json:"hardware"`
+	// 服务端报告重复后加入的随机盐
+	Salt string `json:"salt,omitempty"`
+}
+
+// InitAgentUUID 加载或派生本机稳定标识，结果保存在 dataDir
+func InitAgentUUID(dataDir string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	agentUUIDPath = filepath.Join(dataDir, agentUUIDFilename)
+	hw := readHardwareIDs()
+	digest := hw.digest()
+
+	if saved, err := loadAgentUUIDFile(agentUUIDPath); err == nil {
+		if saved.Hardware == digest {
+			agentUUID = saved.UUID
+			return agentUUID, nil
+		}
+		fmt.Printf("[Identity] Hardware changed since %s was written (cloned data dir?), deriving a new agent UUID.\n", agentUUIDPath)
+	}
+
+	f, err := deriveAgentUUID(hw, "")
+	if err != nil {
+		return "", err
+	}
+	if err := saveAgentUUIDFile(agentUUIDPath, f); err != nil {
+		return "", err
+	}
+	agentUUID = f.UUID
+	return agentUUID, nil
+}
+
+// RegenerateAgentUUID 服务端报告标识与其他终端重复时调用，加入随机盐重新生成并保存
+func RegenerateAgentUUID() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if agentUUIDPath == "" {
+		return "", fmt.Errorf("agent uuid not initialized")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	f, err := deriveAgentUUID(readHardwareIDs(), hex.EncodeToString(salt))
+	if err != nil {
+		return "", err
+	}
+	if err := saveAgentUUIDFile(agentUUIDPath, f); err != nil {
+		return "", err
+	}
+	agentUUID = f.UUID
+	return agentUUID, nil
+}
+
+// AgentUUID 返回本机稳定标识，InitAgentUUID 之前为空
+func AgentUUID() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return agentUUID
+}
+
+// hardwareIDs 本机硬件标识
+type hardwareIDs struct {
+	MachineID string
+	DMIUUID   string
+}
+
+func (h hardwareIDs) empty() bool {
+	return h.MachineID == "" && h.DMIUUID == ""
+}
+
+// digest 硬件标识摘要，不保存原始值
+func (h hardwareIDs) digest() string {
+	sum := sha256.Sum256([]byte("machine-id=" + h.MachineID + "\ndmi=" + h.DMIUUID))
+	return hex.EncodeToString(sum[:])
+}
+
+// readHardwareIDs 读取 machine-id 与 DMI UUID，无效值视为不可用
+func readHardwareIDs() hardwareIDs {
+	var h hardwareIDs
+	for _, p := range machineIDPaths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		// systemd 首次启动前为 "uninitialized"
+		if id := strings.ToLower(strings.TrimSpace(string(data))); len(id) == 32 && isHex(id) && strings.Trim(id, "0") != "" {
+			h.MachineID = id
+			break
+		}
+	}
+	if data, err := os.ReadFile(dmiUUIDPath); err == nil {
+		if id := strings.ToLower(strings.TrimSpace(string(data))); id != "" && !bogusDMIUUIDs[id] {
+			h.DMIUUID = id
+		}
+	}
+	return h
+}
+
+// deriveAgentUUID 由硬件标识与盐派生 UUIDv5，无硬件标识时生成随机 UUIDv4
+func deriveAgentUUID(hw hardwareIDs, salt string) (*agentUUIDFile, error) {
+	f := &agentUUIDFile{Hardware: hw.digest(), Salt: salt}
+	if hw.empty() {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return nil, err
+		}
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		f.UUID, f.Source = formatUUID(b), AgentUUIDSourceRandom
+		return f, nil
+	}
+
+	h := sha1.New()
+	h.Write(agentUUIDNamespace[:])
+	h.Write([]byte("machine-id=" + hw.MachineID + "\ndmi=" + hw.DMIUUID + "\nsalt=" + salt))
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	b[6] = b[6]&0x0f | 0x50
+	b[8] = b[8]&0x3f | 0x80
+	f.UUID, f.Source = formatUUID(b), AgentUUIDSourceHardware
+	return f, nil
+}
+
+func formatUUID(b [16]byte) string {
+	s := hex.EncodeToString(b[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func loadAgentUUIDFile(path string) (*agentUUIDFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f agentUUIDFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if f.UUID == "" {
+		return nil, fmt.Errorf("empty agent uuid in %s", path)
+	}
+	return &f, nil
+}
+
+// saveAgentUUIDFile 先写临时文件再重命名，与 DeviceID 文件一样仅属主可读写
+func saveAgentUUIDFile(path string, f *agentUUIDFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create data dir: %v", err)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write agent uuid file: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
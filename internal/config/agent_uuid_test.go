@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[45][0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// fakeHardware 将 machine-id 与 DMI UUID 指向临时文件，空字符串表示文件不存在
+func fakeHardware(t *testing.T, machineID, dmi string) {
+	dir := t.TempDir()
+	oldMachine, oldDMI := machineIDPaths, dmiUUIDPath
+	t.Cleanup(func() { machineIDPaths, dmiUUIDPath = oldMachine, oldDMI })
+
+	machineIDPaths = []string{filepath.Join(dir, "machine-id")}
+	dmiUUIDPath = filepath.Join(dir, "product_uuid")
+	if machineID != "" {
+		os.WriteFile(machineIDPaths[0], []byte(machineID+"\n"), 0644)
+	}
+	if dmi != "" {
+		os.WriteFile(dmiUUIDPath, []byte(dmi+"\n"), 0644)
+	}
+}
+
+func TestAgentUUID(t *testing.T) {
+	defer func() { agentUUID, agentUUIDPath = "", "" }()
+	const machineID = "4c4c4544004d3510804cb4c04f4e4d32"
+
+	fakeHardware(t, machineID, "4C4C4544-004D-3510-804C-B4C04F4E4D32")
+	id, err := InitAgentUUID(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !uuidPattern.MatchString(id) || id[14] != '5' {
+		t.Fatalf("InitAgentUUID() = %q, want UUIDv5", id)
+	}
+
+	// 重装客户端 (新的数据目录) 得到同一标识
+	dataDir := t.TempDir()
+	if again, _ := InitAgentUUID(dataDir); again != id || AgentUUID() != id {
+		t.Errorf("reinstall uuid = %q, want %q", again, id)
+	}
+
+	// 克隆主机 machine-id 相同、DMI UUID 不同
+	fakeHardware(t, machineID, "4C4C4544-004D-3510-804C-B4C04F4E4D33")
+	cloned, err := InitAgentUUID(dataDir)
+	if err != nil || cloned == id {
+		t.Errorf("cloned uuid = %q, %v; want different from %q", cloned, err, id)
+	}
+
+	// 服务端报告重复后加盐重新生成，重启后保持
+	regen, err := RegenerateAgentUUID()
+	if err != nil || regen == cloned || !uuidPattern.MatchString(regen) {
+		t.Fatalf("RegenerateAgentUUID() = %q, %v", regen, err)
+	}
+	if again, _ := InitAgentUUID(dataDir); again != regen {
+		t.Errorf("uuid after restart = %q, want %q", again, regen)
+	}
+}
+
+func TestAgentUUID_Fallback(t *testing.T) {
+	defer func() { agentUUID, agentUUIDPath = "", "" }()
+
+	// 未初始化的 machine-id 与占位 DMI UUID 视为不可用
+	fakeHardware(t, "uninitialized", "03000200-0400-0500-0006-000700080009")
+	dataDir := t.TempDir()
+	id, err := InitAgentUUID(dataDir)
+	if err != nil || !uuidPattern.MatchString(id) || id[14] != '4' {
+		t.Fatalf("InitAgentUUID() = %q, %v; want random UUIDv4", id, err)
+	}
+	f, err := loadAgentUUIDFile(filepath.Join(dataDir, agentUUIDFilename))
+	if err != nil || f.Source != AgentUUIDSourceRandom {
+		t.Fatalf("saved = %+v, %v", f, err)
+	}
+	// 随机标识保存在数据目录，重启后保持
+	if again, _ := InitAgentUUID(dataDir); again != id {
+		t.Errorf("uuid after restart = %q, want %q", again, id)
+	}
+
+	// 仅有 DMI UUID 时仍按硬件派生
+	fakeHardware(t, "", "4C4C4544-004D-3510-804C-B4C04F4E4D32")
+	if id, _ := InitAgentUUID(t.TempDir()); id[14] != '5' {
+		t.Errorf("dmi-only uuid = %q, want UUIDv5", id)
+	}
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// modelSource 配置结构定义，字段注释即配置项说明
+//
+//go:embed model.go
+var modelSource string
+
+// DefaultsYAML 生成带注释的完整默认配置: 包含全部配置项与默认值，注释取自配置结构的字段说明
+func DefaultsYAML() ([]byte, error) {
+	docs, err := fieldDocs()
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	setDefaults(v)
+
+	var b strings.Builder
+	b.WriteString("# ================================================\n")
+	b.WriteString("# LinuxFileWatcher 默认配置 (filewatcherd config print-defaults 生成)\n")
+	b.WriteString("# ================================================\n")
+	w := &yamlWriter{b: &b, docs: docs, v: v}
+	w.writeStruct("", reflect.TypeOf(AppConfig{}), 0)
+	return []byte(b.String()), nil
+}
+
+// fieldDocs 解析配置结构源码，返回 "类型名.字段名" 与 "类型名" -> 注释行，"类型名.字段名#" -> 行尾注释
+func fieldDocs() (map[string][]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), "model.go", modelSource, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析配置结构失败: %w", err)
+	}
+	docs := make(map[string][]string)
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			// 类型注释以类型名开头，只保留说明部分
+			if lines := commentLines(doc); len(lines) > 0 {
+				lines[0] = strings.TrimSpace(strings.TrimPrefix(lines[0], ts.Name.Name))
+				if lines[0] == "" {
+					lines = lines[1:]
+				}
+				docs[ts.Name.Name] = lines
+			}
+			for _, field := range st.Fields.List {
+				for _, name := range field.Names {
+					key := ts.Name.Name + "." + name.Name
+					docs[key] = commentLines(field.Doc)
+					// 行尾注释以 "#" 后缀保存，输出在值之后
+					if inline := commentLines(field.Comment); len(inline) > 0 {
+						docs[key+"#"] = inline
+					}
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+func commentLines(g *ast.CommentGroup) []string {
+	if g == nil {
+		return nil
+	}
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(g.Text()), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// yamlWriter 按配置结构输出 YAML
+type yamlWriter struct {
+	b    *strings.Builder
+	docs map[string][]string
+	v    *viper.Viper
+}
+
+func (w *yamlWriter) comment(lines []string, indent int) {
+	for _, l := range lines {
+		fmt.Fprintf(w.b, "%s# %s\n", strings.Repeat("  ", indent), l)
+	}
+}
+
+func (w *yamlWriter) writeStruct(prefix string, t reflect.Type, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := configKey(f)
+		if name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+		doc := w.docs[t.Name()+"."+f.Name]
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			if indent == 0 {
+				w.b.WriteString("\n")
+			}
+			if len(doc) == 0 {
+				doc = w.docs[f.Type.Name()]
+			}
+			w.comment(doc, indent)
+			fmt.Fprintf(w.b, "%s%s:\n", pad, name)
+			w.writeStruct(key, f.Type, indent+1)
+			continue
+		}
+
+		w.comment(doc, indent)
+		line := fmt.Sprintf("%s%s: %s", pad, name, w.value(key, f.Type))
+		if inline := w.docs[t.Name()+"."+f.Name+"#"]; len(inline) > 0 {
+			line += "  # " + strings.Join(inline, " ")
+		}
+		w.b.WriteString(line + "\n")
+		// 结构体列表给出注释掉的条目模板
+		if f.Type.Kind() == reflect.Slice && f.Type.Elem().Kind() == reflect.Struct && !w.v.IsSet(key) {
+			w.template(f.Type.Elem(), indent+1)
+		}
+	}
+}
+
+// template 输出注释掉的列表条目字段
+func (w *yamlWriter) template(t reflect.Type, indent int) {
+	pad := strings.Repeat("  ", indent)
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := configKey(f)
+		if name == "" {
+			continue
+		}
+		dash := "  "
+		if first {
+			dash, first = "- ", false
+		}
+		line := fmt.Sprintf("%s# %s%s: %s", pad, dash, name, zeroValue(f.Type))
+		doc := append(append([]string(nil), w.docs[t.Name()+"."+f.Name]...), w.docs[t.Name()+"."+f.Name+"#"]...)
+		if len(doc) > 0 {
+			line += "  # " + strings.Join(doc, " ")
+		}
+		w.b.WriteString(line + "\n")
+	}
+}
+
+// value 配置项默认值的 YAML 写法，未设置默认值时为零值
+func (w *yamlWriter) value(key string, t reflect.Type) string {
+	if !w.v.IsSet(key) {
+		return zeroValue(t)
+	}
+	return yamlScalar(w.v.Get(key))
+}
+
+func zeroValue(t reflect.Type) string {
+	if t == durationType {
+		return `"0s"`
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return `""`
+	case reflect.Bool:
+		return "false"
+	case reflect.Slice:
+		return "[]"
+	case reflect.Map, reflect.Struct:
+		return "{}"
+	case reflect.Float32, reflect.Float64:
+		return "0.0"
+	}
+	return "0"
+}
+
+func yamlScalar(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case time.Duration:
+		return strconv.Quote(x.String())
+	case []string:
+		items := make([]string, len(x))
+		for i, s := range x {
+			items[i] = strconv.Quote(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []int:
+		items := make([]string, len(x))
+		for i, n := range x {
+			items[i] = strconv.Itoa(n)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case []interface{}:
+		items := make([]string, len(x))
+		for i, s := range x {
+			items[i] = yamlScalar(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		if len(x) == 0 {
+			return "{}"
+		}
+		keys := sortedMapKeys(x)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = strconv.Quote(k) + ": " + yamlScalar(x[k])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case map[string]string:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = strconv.Quote(k) + ": " + strconv.Quote(x[k])
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	case float64:
+		s := strconv.FormatFloat(x, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	}
+	return fmt.Sprint(v)
+}
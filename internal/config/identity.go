@@ -127,8 +127,8 @@ func GetFullVersionInfo() string {
 		status = "Unregistered"
 	}
 	return fmt.Sprintf(
-		"Version:     %s\nVendor:      %s\nStatus:      %s\nDeviceID:    %s\nStoragePath: %s\nHW-FP:       %s\nBuilt:       %s",
-		Version, Vendor, status, DeviceID, idFilePath, HardwareFingerprint, BuildTime,
+		"Version:     %s\nVendor:      %s\nStatus:      %s\nDeviceID:    %s\nAgentUUID:   %s\nStoragePath: %s\nHW-FP:       %s\nBuilt:       %s",
+		Version, Vendor, status, DeviceID, agentUUID, idFilePath, HardwareFingerprint, BuildTime,
 	)
 }
 
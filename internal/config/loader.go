@@ -2,17 +2,22 @@ package config
 
 import (
 	"fmt"
-	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/spf13/viper"
 )
 
-// GlobalConfig 全局配置单例
-// 在调用 LoadConfig 成功后，该变量会被填充，后续模块直接读取即可
+// globalConfig 全局配置单例
+// 在调用 LoadConfig 成功后填充，后续模块通过 Get 读取；Reload 整体替换指针，读取方无需加锁
 var (
-	GlobalConfig *AppConfig
+	globalConfig atomic.Pointer[AppConfig]
 	loadOnce     sync.Once
+
+	// 保留 viper 实例用于运行时重载
+	loadedViper *viper.Viper
+	reloadMu    sync.Mutex
+	reloadHooks []func(*AppConfig)
 )
 
 // LoadConfig 加载配置
@@ -22,60 +27,111 @@ func LoadConfig(configPath string) error {
 	var err error
 
 	loadOnce.Do(func() {
-		v := viper.New()
-
-		// 1. 设置默认值 (兜底策略)
-		setDefaults(v)
-
-		// 2. 配置读取规则
-		if configPath != "" {
-			// 如果指定了具体文件，直接读取
-			v.SetConfigFile(configPath)
-		} else {
-			// 否则在常见目录搜索名为 "config" 的文件
-			v.SetConfigName("config")
-			v.SetConfigType("yaml")
-			v.AddConfigPath("/etc/linuxFileWatcher/") // 生产环境标准路径
-			v.AddConfigPath(".")                      // 当前目录 (开发调试用)
-		}
-
-		// 3. 配置环境变量覆盖 (高级特性)
-		// 允许通过环境变量 LFW_SERVER_URL 来覆盖 server.url
-		v.SetEnvPrefix("LFW")
-		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-		v.AutomaticEnv()
-
-		// 4. 读取配置文件
-		if err = v.ReadInConfig(); err != nil {
-			// 如果是“未找到配置文件”错误，且我们要用默认值跑，可以忽略
-			// 但对于安全软件，建议强制要求配置文件存在
-			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				err = fmt.Errorf("config file not found: %v", err)
-				return
-			}
-			err = fmt.Errorf("failed to read config file: %v", err)
+		var v *viper.Viper
+		var config *AppConfig
+		if v, config, err = readConfig(configPath); err != nil {
 			return
 		}
 
-		// 5. 反序列化到结构体
-		var config AppConfig
-		if err = v.Unmarshal(&config); err != nil {
-			err = fmt.Errorf("failed to unmarshal config: %v", err)
-			return
-		}
-
-		// 6. 赋值给全局单例
-		GlobalConfig = &config
+		// 赋值给全局单例
+		globalConfig.Store(config)
+		loadedViper = v
 		fmt.Printf("[Config] Loaded successfully from: %s\n", v.ConfigFileUsed())
 	})
 
 	return err
 }
 
+// Read 按与 LoadConfig 相同的规则读取配置 (默认值、环境变量与 --set 覆盖)，不修改全局配置
+// 供子命令等只需读取配置的场景使用，加密项保持密文
+func Read(configPath string) (*AppConfig, error) {
+	_, config, err := readConfig(configPath)
+	return config, err
+}
+
+func readConfig(configPath string) (*viper.Viper, *AppConfig, error) {
+	v := viper.New()
+
+	// 1. 设置默认值 (兜底策略)
+	setDefaults(v)
+
+	// 2. 配置读取规则
+	if configPath != "" {
+		// 如果指定了具体文件，直接读取
+		v.SetConfigFile(configPath)
+	} else {
+		// 否则在常见目录搜索名为 "config" 的文件
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("/etc/linuxFileWatcher/") // 生产环境标准路径
+		v.AddConfigPath(".")                      // 当前目录 (开发调试用)
+	}
+
+	// 3. 配置环境变量覆盖 (高级特性)
+	// 允许通过环境变量 FILEWATCHER_SERVER_URL (兼容 LFW_SERVER_URL) 来覆盖 server.url
+	bindEnvs(v)
+
+	// 4. 读取配置文件
+	if err := v.ReadInConfig(); err != nil {
+		// 如果是“未找到配置文件”错误，且我们要用默认值跑，可以忽略
+		// 但对于安全软件，建议强制要求配置文件存在
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			return nil, nil, fmt.Errorf("config file not found: %v", err)
+		}
+		return nil, nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	// 命令行 --set 覆盖优先级最高
+	applyOverrides(v)
+
+	// 5. 反序列化到结构体
+	var config AppConfig
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %v", err)
+	}
+	return v, &config, nil
+}
+
+// OnReload 注册配置重载回调
+// 回调在 Reload 成功后按注册顺序执行，用于刷新过滤规则等可热更新的配置
+func OnReload(fn func(*AppConfig)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// Reload 重新读取配置文件并替换全局配置
+// 解析失败时保留原配置
+func Reload() error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if loadedViper == nil {
+		return fmt.Errorf("config not loaded")
+	}
+	if err := loadedViper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var config AppConfig
+	if err := loadedViper.Unmarshal(&config); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %v", err)
+	}
+	if _, err := decryptSecrets(&config, secretDecrypter); err != nil {
+		return err
+	}
+	globalConfig.Store(&config)
+
+	for _, fn := range reloadHooks {
+		fn(&config)
+	}
+	return nil
+}
+
 // setDefaults 定义配置文件的“默认行为”
 func setDefaults(v *viper.Viper) {
 	// Agent 基础
 	v.SetDefault("agent.log_level", "info")
+	v.SetDefault("agent.log_format", "text")
 	v.SetDefault("agent.log_file", "/var/log/linuxFileWatcher/agent.log")
 	v.SetDefault("agent.data_dir", "/var/lib/linuxFileWatcher") // 数据存储目录默认值
 	// 【新增】日志轮转默认值 (参考业界标准)
@@ -84,26 +140,189 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("agent.log_max_age", 30)    // 保留 30 天
 	v.SetDefault("agent.log_compress", true) // 默认压缩旧日志
 	v.SetDefault("agent.log_stdout", false)  // 生产环境默认不打控制台(静默模式)
+	v.SetDefault("agent.locale", "")         // 为空时取 LANG 环境变量
+	v.SetDefault("agent.log_ship.enable", false)
+	v.SetDefault("agent.log_ship.protocol", "syslog")
+	v.SetDefault("agent.log_ship.address", "udp://127.0.0.1:514")
+	v.SetDefault("agent.log_ship.level", "info")
+	v.SetDefault("agent.log_ship.buffer_size", 10000)
+	v.SetDefault("agent.log_ship.batch_size", 200)
+	v.SetDefault("agent.log_ship.flush_interval", "2s")
+	v.SetDefault("agent.log_ship.timeout", "10s")
 
 	// Server 通信
 	v.SetDefault("server.timeout", "30s")
 	v.SetDefault("server.max_idle_conns", 10)
 	v.SetDefault("server.idle_conn_timeout", "30s")
+	v.SetDefault("server.cert_reload_interval", "1m")
+	v.SetDefault("server.payload_encryption.enable", false)
+	v.SetDefault("server.offline.enable", false)
+	v.SetDefault("server.offline.interval", "15m")
+	v.SetDefault("server.stream.enable", false)
+	v.SetDefault("server.stream.url", "") // 为空时使用 server.url
+	v.SetDefault("server.stream.ack_timeout", "30s")
+	v.SetDefault("server.stream.max_in_flight", 16)
+	v.SetDefault("server.stream.backoff", "5s")
+	v.SetDefault("server.stream.max_backoff", "5m")
+	v.SetDefault("server.command.enable", false)
+	v.SetDefault("server.command.max_age", "24h")
+	v.SetDefault("server.command.timeout", "10m")
+	v.SetDefault("server.command.poll.enable", false)
+	v.SetDefault("server.command.poll.path", "/api/v1/agent/commands")
+	v.SetDefault("server.command.poll.interval", "1m")
+	v.SetDefault("server.upload_qos.enable", false)
+	v.SetDefault("server.upload_qos.rate", 5)
+	v.SetDefault("server.upload_qos.burst", 20)
+	v.SetDefault("server.upload_qos.bandwidth_kbps", 0)
+	v.SetDefault("server.upload_qos.business_hours.bandwidth_kbps", 0)
+	v.SetDefault("server.upload_qos.business_hours.start", "08:30")
+	v.SetDefault("server.upload_qos.business_hours.end", "17:30")
+	v.SetDefault("server.upload_qos.business_hours.weekdays", []int{1, 2, 3, 4, 5})
 
 	// Scanner 扫描策略 (保守默认值)
 	v.SetDefault("scanner.rate_limit", 500)
 	v.SetDefault("scanner.workers", 1)
 	v.SetDefault("scanner.watch_dirs", []string{"/home"}) // 默认只扫 home
 	v.SetDefault("scanner.policies_path", "./policies")   // 默认策略文件目录
+	v.SetDefault("scanner.read_rate_mb", 20)              // 定时扫描默认 20MB/s
+	v.SetDefault("scanner.queue_size", 10000)
+	v.SetDefault("scanner.nice", 10)        // 降低 CPU 优先级，避免影响业务
+	v.SetDefault("scanner.ionice_class", 2) // best-effort
+	v.SetDefault("scanner.removable.enable", true)
+	v.SetDefault("scanner.removable.poll_interval", "2s")
+	v.SetDefault("scanner.removable.auto_scan", true)
+	v.SetDefault("scanner.removable.block_write_until_clean", false)
+	v.SetDefault("scanner.removable.scan_timeout", "30m")
+	v.SetDefault("scanner.containers.enable", false)
+	v.SetDefault("scanner.containers.runtimes", []string{"docker", "containerd"})
+	v.SetDefault("scanner.containers.docker_socket", "/var/run/docker.sock")
+	v.SetDefault("scanner.containers.containerd_state_dir", "/run/containerd/io.containerd.runtime.v2.task")
+	v.SetDefault("scanner.containers.poll_interval", "1m")
+	v.SetDefault("scanner.containers.rescan_interval", "24h")
+	v.SetDefault("scanner.containers.scan_timeout", "1h")
+	v.SetDefault("scanner.exfil.enable", true)
+	v.SetDefault("scanner.exfil.threshold", 10)
+	v.SetDefault("scanner.exfil.window", "5m")
+	v.SetDefault("scanner.exfil.cooldown", "10m")
+	v.SetDefault("scanner.lineage.enable", true)
+	v.SetDefault("scanner.lineage.retention", "720h")
+	v.SetDefault("scanner.lineage.max_edges", 100)
+	v.SetDefault("scanner.rule_stats.enable", true)
+	v.SetDefault("scanner.rule_stats.min_samples", 20)
+	v.SetDefault("scanner.rule_stats.flag_precision", 0.8)
+	v.SetDefault("scanner.rule_stats.demote_precision", 0.5)
+	v.SetDefault("scanner.rule_stats.auto_demote", false)
+	v.SetDefault("scanner.rule_stats.retention", "720h")
+	v.SetDefault("scanner.clipboard.enable", false)
+	v.SetDefault("scanner.clipboard.poll_interval", "1s")
+	v.SetDefault("scanner.clipboard.max_size_mb", 5)
+	v.SetDefault("scanner.clipboard.cooldown", "10m")
+	v.SetDefault("scanner.clipboard.max_alerts_per_minute", 6)
+	v.SetDefault("scanner.print.enable", false)
+	v.SetDefault("scanner.print.spool_dir", "/var/spool/cups")
+	v.SetDefault("scanner.print.poll_interval", "1s")
+	v.SetDefault("scanner.print.action", "log")
+	v.SetDefault("scanner.print.release_clean", false)
+	v.SetDefault("scanner.print.max_size_mb", 100)
+	v.SetDefault("scanner.alert_dedup.enable", true)
+	v.SetDefault("scanner.alert_dedup.window", "1m")
+	v.SetDefault("scanner.alert_dedup.max_entries", 10000)
+	v.SetDefault("scanner.response.enable", false)
+	v.SetDefault("scanner.response.default_actions", []string{"alert"})
+	v.SetDefault("scanner.evidence.enable", false)
+	v.SetDefault("scanner.evidence.mode", "file")
+	v.SetDefault("scanner.evidence.max_file_size_mb", 20)
+	v.SetDefault("scanner.evidence.max_total_size_mb", 1024)
+	v.SetDefault("scanner.evidence.retention", "2160h")
+	v.SetDefault("scanner.notify.enable", false)
+	v.SetDefault("scanner.notify.locale", "") // 为空时跟随 agent.locale
+	v.SetDefault("scanner.notify.timeout", "10s")
+	v.SetDefault("scanner.notify.cooldown", "5m")
+	v.SetDefault("scanner.notify.max_per_minute", 3)
+	v.SetDefault("scanner.webhook.enable", false)
+	v.SetDefault("scanner.webhook.timeout", "10s")
+	v.SetDefault("scanner.webhook.max_retries", 5)
+	v.SetDefault("scanner.webhook.retry_backoff", "2s")
+	v.SetDefault("scanner.webhook.queue_size", 1000)
+	v.SetDefault("scanner.wasm_rules.memory_limit_mb", 16)
+	v.SetDefault("scanner.wasm_rules.timeout", "2s")
+	v.SetDefault("scanner.wasm_rules.max_text_size_mb", 4)
+	v.SetDefault("scanner.fingerprint.max_distance", 3)
+	v.SetDefault("scanner.fingerprint.min_containment", 0.5)
+	v.SetDefault("scanner.fingerprint.min_matches", 8)
+	v.SetDefault("scanner.keyword.regex_max_length", 1024)
+	v.SetDefault("scanner.keyword.regex_max_program", 4096)
+	v.SetDefault("scanner.keyword.regex_max_matches", 10000)
+	v.SetDefault("scanner.edm.min_columns", 2)
+	v.SetDefault("scanner.edm.min_rows", 1)
+	v.SetDefault("scanner.match_context.max_locations", 20)
+	v.SetDefault("scanner.match_context.context_chars", 30)
+	v.SetDefault("scanner.sampling.head_size_kb", 1024)
+	v.SetDefault("scanner.sampling.tail_size_kb", 1024)
+	v.SetDefault("scanner.sampling.windows", 16)
+	v.SetDefault("scanner.sampling.window_size_kb", 64)
+	v.SetDefault("scanner.sampling.seed", 0)
+	v.SetDefault("scanner.budget.max_workers", 0)
+	v.SetDefault("scanner.budget.memory_mb", 0)
+	v.SetDefault("scanner.budget.memory_percent", 50)
+	v.SetDefault("scanner.budget.archive_workers", 0)
+	v.SetDefault("scanner.budget.ocr_workers", 0)
+	v.SetDefault("scanner.breaker.threshold", 5)
+	v.SetDefault("scanner.breaker.cooldown", "5m")
+	v.SetDefault("scanner.trace.enable", false)
+	v.SetDefault("scanner.trace.exporter", "log")
+	v.SetDefault("scanner.trace.min_duration", "0s")
+	v.SetDefault("scanner.trace.sample_ratio", 1.0)
+	v.SetDefault("scanner.trace.metrics_interval", "1m")
+	v.SetDefault("scanner.trace.otlp_endpoint", "http://127.0.0.1:4318/v1/traces")
+	v.SetDefault("scanner.trace.service_name", "filewatcherd")
+	v.SetDefault("scanner.filter.skip_fs_types", []string{
+		"proc", "sysfs", "devtmpfs", "devpts", "cgroup", "cgroup2", "securityfs",
+		"debugfs", "tracefs", "pstore", "bpf", "configfs", "nfs", "nfs4", "cifs",
+	})
 
 	// Security 安全策略
 	v.SetDefault("security.integrity.check_interval", "5m")
 	v.SetDefault("security.integrity.default_interval", "1m")
+	v.SetDefault("security.integrity.self_protect.enable", true)
+	v.SetDefault("security.integrity.self_protect.restore", false)
+	v.SetDefault("security.integrity.self_protect.remediate", false)
+	v.SetDefault("security.integrity.self_protect.package_verify", true)
+	v.SetDefault("security.integrity.baseline.enable", false)
+	v.SetDefault("security.integrity.baseline.interval", "1h")
+	v.SetDefault("security.integrity.baseline.accept", true)
 
 	v.SetDefault("security.netguard.enable", true)
 	v.SetDefault("security.netguard.check_interval", "1s")
 	v.SetDefault("security.netguard.deduplication_time", "1h")
 	v.SetDefault("security.netguard.monitor_self", true)
+	v.SetDefault("security.netguard.listen.enable", true)
+	v.SetDefault("security.netguard.listen.check_interval", "30s")
+	v.SetDefault("security.netguard.listen.processes", []string{"sshd"})
+	v.SetDefault("security.netguard.dns.enable", true)
+	v.SetDefault("security.netguard.dns.block", false)
+	v.SetDefault("security.netguard.dns.deduplication_time", "1h")
+	v.SetDefault("security.netguard.bandwidth.enable", true)
+	v.SetDefault("security.netguard.bandwidth.check_interval", "5s")
+	v.SetDefault("security.netguard.bandwidth.window", "10m")
+	v.SetDefault("security.netguard.bandwidth.threshold_mb", 100)
+	v.SetDefault("security.netguard.conntrack.enable", true)
+	v.SetDefault("security.netguard.conntrack.accounting", true)
+	v.SetDefault("security.netguard.pcap.enable", false)
+	v.SetDefault("security.netguard.pcap.max_duration", "60s")
+	v.SetDefault("security.netguard.pcap.max_size_mb", 10)
+	v.SetDefault("security.netguard.pcap.snaplen", 65535)
+	v.SetDefault("security.netguard.pcap.max_concurrent", 4)
+	v.SetDefault("security.netguard.pcap.quota_mb", 512)
+	v.SetDefault("security.hijack.enable", true)
+	v.SetDefault("security.hijack.check_interval", "5m")
+	v.SetDefault("security.hijack.processes", []string{"sshd", "sudo", "login", "systemd"})
+	v.SetDefault("security.canary.enable", false)
+	v.SetDefault("security.canary.deduplication_time", "1m")
+	v.SetDefault("security.rule_signature.mode", "off")
+	v.SetDefault("security.kms.backend", "local")
+	v.SetDefault("security.kms.rotate_interval", "0s")
+	v.SetDefault("security.kms.keep_previous", 3)
 	// 默认白名单至少包含回环，虽然代码里强制加了，这里配置上也体现一下更好
 	v.SetDefault("security.netguard.whitelist", []string{"127.0.0.1", "::1"})
 
@@ -122,14 +341,21 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("storage.alerts_memory_limit", 100)     // 告警记录内存限制：100条
 	v.SetDefault("storage.audit_logs_memory_limit", 200) // 审计日志内存限制：200条
 	v.SetDefault("storage.security_reports_limit", 50)   // 安全状态上报内存限制：50条
+
+	// 本机检测服务
+	v.SetDefault("api.enable", false)
+	v.SetDefault("api.socket", "/run/linuxFileWatcher/detect.sock")
+	v.SetDefault("api.max_bytes_mb", 32)
+	v.SetDefault("api.timeout", "2m")
 }
 
 // Get 获取配置的安全访问器 (可选)
 func Get() *AppConfig {
-	if GlobalConfig == nil {
+	cfg := globalConfig.Load()
+	if cfg == nil {
 		// 防御性编程：如果没有初始化就调用，返回一个空结构或 panic
 		// 这里为了安全起见，建议 panic 提示开发者必须先 Init
 		panic("Config not initialized! Call LoadConfig() first.")
 	}
-	return GlobalConfig
+	return cfg
 }
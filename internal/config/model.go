@@ -14,6 +14,7 @@ type AppConfig struct {
 	Security SecurityConfig `mapstructure:"security" yaml:"security"`
 	Database DatabaseConfig `mapstructure:"database" yaml:"database"`
 	Storage  StorageConfig  `mapstructure:"storage" yaml:"storage"`
+	API      APIConfig      `mapstructure:"api" yaml:"api"`
 }
 
 // ==========================================
@@ -23,6 +24,11 @@ type AppConfig struct {
 type AgentConfig struct {
 	// 日志级别: debug, info, warn, error
 	LogLevel string `mapstructure:"log_level" yaml:"log_level"`
+	// 日志格式: text (默认) / json (结构化，便于日志平台采集)
+	LogFormat string `mapstructure:"log_format" yaml:"log_format"`
+	// 按模块覆盖日志级别，如 detector: debug、netguard: info
+	// 模块名为 internal 下的包目录，security/service 下取其下一级目录
+	LogModules map[string]string `mapstructure:"log_modules" yaml:"log_modules"`
 	// 日志文件路径
 	LogFile string `mapstructure:"log_file" yaml:"log_file"`
 	// 数据存储目录 (覆盖默认的 /var/lib/...)
@@ -33,6 +39,32 @@ type AgentConfig struct {
 	LogMaxAge     int  `mapstructure:"log_max_age" yaml:"log_max_age"`         // 天数
 	LogCompress   bool `mapstructure:"log_compress" yaml:"log_compress"`       // 是否压缩
 	LogStdout     bool `mapstructure:"log_stdout" yaml:"log_stdout"`           // 是否打印到控制台
+	// 告警描述、错误信息与通知的语言: zh-CN, en-US，为空时取 LANG 环境变量
+	Locale string `mapstructure:"locale" yaml:"locale"`
+	// 日志远程投递 (syslog / HTTP)，便于集中排查无人值守终端
+	LogShip LogShipConfig `mapstructure:"log_ship" yaml:"log_ship"`
+}
+
+// LogShipConfig 日志远程投递参数
+// 日志先进本地缓冲再由后台批量发送，远端不可达时退避重试，缓冲满时丢弃最旧的日志
+type LogShipConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 协议: syslog (RFC 5424，消息体为 JSON) / http (批量 POST，JSON Lines)
+	Protocol string `mapstructure:"protocol" yaml:"protocol"`
+	// syslog: udp://host:514 或 tcp://host:601；http: 接收端 URL
+	Address string `mapstructure:"address" yaml:"address"`
+	// HTTP 附加请求头 (鉴权等)
+	Headers map[string]string `mapstructure:"headers" yaml:"headers"`
+	// 投递的最低级别，在本地 (含模块) 级别之上再过滤
+	Level string `mapstructure:"level" yaml:"level"`
+	// 本地缓冲上限 (条)
+	BufferSize int `mapstructure:"buffer_size" yaml:"buffer_size"`
+	// 单次发送条数上限
+	BatchSize int `mapstructure:"batch_size" yaml:"batch_size"`
+	// 发送间隔
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval"`
+	// 单次发送超时
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
 }
 
 // ==========================================
@@ -87,12 +119,118 @@ type ServerConfig struct {
 	ClientCert string `mapstructure:"client_cert" yaml:"client_cert"`
 	// 客户端私钥路径
 	ClientKey string `mapstructure:"client_key" yaml:"client_key"`
+	// 服务端证书 SHA-256 指纹固定 (十六进制)，为空不固定
+	PinnedCertSHA256 []string `mapstructure:"pinned_cert_sha256" yaml:"pinned_cert_sha256"`
+	// 服务端公钥 SHA-256 固定 (SubjectPublicKeyInfo 的 Base64)，证书续期不换密钥时使用
+	PinnedSPKISHA256 []string `mapstructure:"pinned_spki_sha256" yaml:"pinned_spki_sha256"`
+	// 证书文件变更检查间隔，轮换后自动重新加载
+	CertReloadInterval time.Duration `mapstructure:"cert_reload_interval" yaml:"cert_reload_interval"`
 	// HTTP 请求超时
 	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
 	// 最大空闲连接数
 	MaxIdleConns int `mapstructure:"max_idle_conns" yaml:"max_idle_conns"`
 	// 空闲连接超时
 	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout" yaml:"idle_conn_timeout"`
+	// 载荷信封加密 (离线数据包)
+	PayloadEncryption PayloadEncryptionConfig `mapstructure:"payload_encryption" yaml:"payload_encryption"`
+	// 离线 (物理隔离) 模式
+	Offline OfflineConfig `mapstructure:"offline" yaml:"offline"`
+	// 上报限流与带宽控制
+	UploadQoS UploadQoSConfig `mapstructure:"upload_qos" yaml:"upload_qos"`
+	// gRPC 流式上报通道
+	Stream StreamConfig `mapstructure:"stream" yaml:"stream"`
+	// 服务端指令执行
+	Command CommandConfig `mapstructure:"command" yaml:"command"`
+}
+
+// CommandConfig 服务端指令执行配置
+// 指令须由 security.rule_signature.public_key 对应的私钥签名，由流式通道推送或定期拉取；
+// 执行记录写入审计日志，结果通过指令结果上报回传
+type CommandConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 指令未指定过期时间时的有效期
+	MaxAge time.Duration `mapstructure:"max_age" yaml:"max_age"`
+	// 单条指令执行超时
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// 定期拉取 (没有流式通道时使用)
+	Poll CommandPollConfig `mapstructure:"poll" yaml:"poll"`
+}
+
+// CommandPollConfig 指令拉取配置
+type CommandPollConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 待执行指令查询接口路径
+	Path string `mapstructure:"path" yaml:"path"`
+	// 拉取周期
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+}
+
+// StreamConfig gRPC 双向流上报通道配置
+// 服务端逐批确认，未确认的批次重连后按原批次 ID 重发；服务端可在同一通道下发签名指令 (见 CommandConfig)
+type StreamConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// gRPC 服务地址 (https)，为空时使用 server.url
+	URL string `mapstructure:"url" yaml:"url"`
+	// 批次确认超时，超时视为连接失效并重连
+	AckTimeout time.Duration `mapstructure:"ack_timeout" yaml:"ack_timeout"`
+	// 已发送未确认的批次上限
+	MaxInFlight int `mapstructure:"max_in_flight" yaml:"max_in_flight"`
+	// 重连间隔，连续失败时加倍直到 max_backoff
+	Backoff    time.Duration `mapstructure:"backoff" yaml:"backoff"`
+	MaxBackoff time.Duration `mapstructure:"max_backoff" yaml:"max_backoff"`
+}
+
+// PayloadEncryptionConfig 载荷信封加密配置 (SM4 数据密钥 + 服务端 SM2 公钥)
+// 目前用于离线数据包 (见 OfflineConfig)，在线上报尚未接入
+type PayloadEncryptionConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 服务端 SM2 公钥 (十六进制 04||X||Y) 或公钥文件路径
+	PublicKey string `mapstructure:"public_key" yaml:"public_key"`
+}
+
+// OfflineConfig 离线模式配置
+// 启用后不连接管理平台，上报数据定期打包为加密数据包 (需启用 payload_encryption)，
+// 通过 export-bundle 导出到移动介质，平台回执通过 import-ack 导入后删除已送达的数据包
+type OfflineConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 数据包目录，为空时使用 <data_dir>/offline
+	BundleDir string `mapstructure:"bundle_dir" yaml:"bundle_dir"`
+	// 打包周期
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+}
+
+// UploadQoSConfig 上报限流配置
+// 作用于所有发往管理平台的 HTTP 请求 (指令拉取、流式通道等)：各接口按令牌桶限制请求速率，
+// 请求体按总带宽限速发送；限速后单次请求耗时变长，server.timeout 需相应调大
+type UploadQoSConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 每个接口每秒请求数，0 不限
+	Rate float64 `mapstructure:"rate" yaml:"rate"`
+	// 每个接口允许的突发请求数，0 取 rate 向上取整
+	Burst int `mapstructure:"burst" yaml:"burst"`
+	// 按接口路径前缀覆盖 rate/burst
+	Endpoints map[string]UploadLimitConfig `mapstructure:"endpoints" yaml:"endpoints"`
+	// 上传总带宽 (KB/s)，0 不限
+	BandwidthKBps float64 `mapstructure:"bandwidth_kbps" yaml:"bandwidth_kbps"`
+	// 工作时间带宽上限
+	BusinessHours BusinessHoursConfig `mapstructure:"business_hours" yaml:"business_hours"`
+}
+
+// UploadLimitConfig 单个接口的请求速率
+type UploadLimitConfig struct {
+	Rate  float64 `mapstructure:"rate" yaml:"rate"`
+	Burst int     `mapstructure:"burst" yaml:"burst"`
+}
+
+// BusinessHoursConfig 工作时间带宽上限，与 bandwidth_kbps 同时配置时工作时间取较低值
+type BusinessHoursConfig struct {
+	// 工作时间上传带宽 (KB/s)，0 不单独限制
+	BandwidthKBps float64 `mapstructure:"bandwidth_kbps" yaml:"bandwidth_kbps"`
+	// 开始与结束时间 (HH:MM，本地时间)，结束早于开始表示跨午夜
+	Start string `mapstructure:"start" yaml:"start"`
+	End   string `mapstructure:"end" yaml:"end"`
+	// 生效的星期 (0 为周日)，为空表示每天
+	Weekdays []int `mapstructure:"weekdays" yaml:"weekdays"`
 }
 
 // ==========================================
@@ -110,6 +248,452 @@ type ScannerConfig struct {
 	Workers int `mapstructure:"workers" yaml:"workers"`
 	// 策略文件目录路径
 	PoliciesPath string `mapstructure:"policies_path" yaml:"policies_path"`
+	// 定时扫描磁盘读取限速 (MB/s)，0 表示不限速；实时事件不受限
+	ReadRateMB float64 `mapstructure:"read_rate_mb" yaml:"read_rate_mb"`
+	// 任务队列容量
+	QueueSize int `mapstructure:"queue_size" yaml:"queue_size"`
+	// 进程 CPU nice 值 (-20 ~ 19)，0 表示不修改
+	Nice int `mapstructure:"nice" yaml:"nice"`
+	// 进程 I/O 调度类别 (0 不修改, 1 realtime, 2 best-effort, 3 idle)
+	IONiceClass int `mapstructure:"ionice_class" yaml:"ionice_class"`
+	// 定时全盘扫描计划
+	Schedules []ScanScheduleConfig `mapstructure:"schedules" yaml:"schedules"`
+	// 路径过滤规则 (监控、扫描服务、定时扫描统一生效)
+	Filter PathFilterConfig `mapstructure:"filter" yaml:"filter"`
+	// 可移动介质监控
+	Removable RemovableConfig `mapstructure:"removable" yaml:"removable"`
+	// 容器文件系统扫描
+	Containers ContainerScanConfig `mapstructure:"containers" yaml:"containers"`
+	// 批量外发检测
+	Exfil ExfilConfig `mapstructure:"exfil" yaml:"exfil"`
+	// 涉密文件流转追踪
+	Lineage LineageConfig `mapstructure:"lineage" yaml:"lineage"`
+	// 规则命中统计与误报反馈
+	RuleStats RuleStatsConfig `mapstructure:"rule_stats" yaml:"rule_stats"`
+	// 剪贴板监控
+	Clipboard ClipboardConfig `mapstructure:"clipboard" yaml:"clipboard"`
+	// 打印作业检测
+	Print PrintConfig `mapstructure:"print" yaml:"print"`
+	// 告警去重聚合
+	AlertDedup AlertDedupConfig `mapstructure:"alert_dedup" yaml:"alert_dedup"`
+	// 检测结果处置策略
+	Response ResponseConfig `mapstructure:"response" yaml:"response"`
+	// 告警取证留存 (处置动作 evidence)
+	Evidence EvidenceConfig `mapstructure:"evidence" yaml:"evidence"`
+	// 桌面用户通知
+	Notify NotifyConfig `mapstructure:"notify" yaml:"notify"`
+	// 告警 Webhook 输出
+	Webhook WebhookConfig `mapstructure:"webhook" yaml:"webhook"`
+	// WASM 脚本规则沙箱限制 (规则本身随检测策略下发)
+	WasmRules WasmRulesConfig `mapstructure:"wasm_rules" yaml:"wasm_rules"`
+	// 内容指纹判定阈值 (规则本身随检测策略下发)
+	Fingerprint FingerprintConfig `mapstructure:"fingerprint" yaml:"fingerprint"`
+	// 关键词正则规则的安全限制
+	Keyword KeywordConfig `mapstructure:"keyword" yaml:"keyword"`
+	// 精确数据匹配判定阈值 (数据表随检测策略下发)
+	EDM EDMConfig `mapstructure:"edm" yaml:"edm"`
+	// 告警附带的命中位置与上下文
+	MatchContext MatchContextConfig `mapstructure:"match_context" yaml:"match_context"`
+	// 大文件稀疏采样 (密级标志兜底扫描)
+	Sampling SamplingConfig `mapstructure:"sampling" yaml:"sampling"`
+	// 全局并发与内存预算 (扫描、压缩包展开、OCR 共享)
+	Budget BudgetConfig `mapstructure:"budget" yaml:"budget"`
+	// 子检测器熔断 (畸形文件导致的 panic 或解析失败)
+	Breaker BreakerConfig `mapstructure:"breaker" yaml:"breaker"`
+	// 检测时间线与上报请求追踪 (排查慢文件，接入 OpenTelemetry)
+	Trace TraceConfig `mapstructure:"trace" yaml:"trace"`
+	// 外部检测插件，按顺序在内置检测模块之后调用
+	Plugins []DetectorPluginConfig `mapstructure:"plugins" yaml:"plugins"`
+	// 文件分类到检测模块的路由 (分类名 -> 模块名列表)，未配置的分类执行全部检测模块
+	// 模块名同告警检测模块 (插件为插件名称)，"expand" 表示展开压缩包逐项检测；整体为空时使用内置默认路由
+	Routing map[string][]string `mapstructure:"routing" yaml:"routing"`
+}
+
+// WasmRulesConfig WASM 脚本规则沙箱配置
+// 规则从 <policies_path>/wasm_rule_detect/policy.json 加载，SIGHUP 时重新加载
+type WasmRulesConfig struct {
+	// 单个规则实例的内存上限 (MB)
+	MemoryLimitMB int64 `mapstructure:"memory_limit_mb" yaml:"memory_limit_mb"`
+	// 单条规则单次执行超时
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// 传给规则的文本上限 (MB)，超出部分截断
+	MaxTextSizeMB int `mapstructure:"max_text_size_mb" yaml:"max_text_size_mb"`
+}
+
+// FingerprintConfig 内容指纹检测阈值
+// 规则从 <policies_path>/fingerprint_detect/policy.json 加载，规则中填写的阈值优先
+type FingerprintConfig struct {
+	// simhash 汉明距离上限，不超过即判定为整篇相似
+	MaxDistance int `mapstructure:"max_distance" yaml:"max_distance"`
+	// 规则的分段指纹出现在文件中的比例下限
+	MinContainment float64 `mapstructure:"min_containment" yaml:"min_containment"`
+	// 出现的分段指纹数下限，达到即判定为部分复制
+	MinMatches int `mapstructure:"min_matches" yaml:"min_matches"`
+}
+
+// KeywordConfig 关键词正则规则的安全限制，超出限制的规则整体拒绝加载
+type KeywordConfig struct {
+	// 表达式长度上限 (字节)
+	RegexMaxLength int `mapstructure:"regex_max_length" yaml:"regex_max_length"`
+	// 编译后的程序指令数上限
+	RegexMaxProgram int `mapstructure:"regex_max_program" yaml:"regex_max_program"`
+	// 单条规则在一个文件中的匹配次数上限
+	RegexMaxMatches int `mapstructure:"regex_max_matches" yaml:"regex_max_matches"`
+}
+
+// EDMConfig 精确数据匹配检测阈值
+// 数据表从 <policies_path>/edm_detect/policy.json 加载，数据表中填写的阈值优先
+type EDMConfig struct {
+	// 同一行出现在文件中的列数下限，行内非空列更少时要求全部出现
+	MinColumns int `mapstructure:"min_columns" yaml:"min_columns"`
+	// 命中的不同行数下限
+	MinRows int `mapstructure:"min_rows" yaml:"min_rows"`
+}
+
+// MatchContextConfig 告警附带的命中位置 (字节/字符偏移、页码、段落) 与上下文
+// 写入告警扩展字段 match_locations，管理端据此高亮显示而无需重新扫描文件
+type MatchContextConfig struct {
+	// 每条告警附带的命中位置数上限，0 表示不附带 (最多 100)
+	MaxLocations int `mapstructure:"max_locations" yaml:"max_locations"`
+	// 命中内容前后各附带的字符数 (最多 200)，个人敏感信息不附带上下文
+	ContextChars int `mapstructure:"context_chars" yaml:"context_chars"`
+}
+
+// BudgetConfig 全局并发与内存预算，各项为 0 时按所在 cgroup 的 CPU 配额与内存上限自动推算
+type BudgetConfig struct {
+	// 总并发数，0 为 cgroup CPU 配额 (向上取整)，未限制时为 CPU 核数
+	MaxWorkers int `mapstructure:"max_workers" yaml:"max_workers"`
+	// 内存预算 (MB)，0 为内存上限 (cgroup 未限制时为物理内存) 的 memory_percent%
+	MemoryMB int64 `mapstructure:"memory_mb" yaml:"memory_mb"`
+	// 自动推算内存预算时的百分比
+	MemoryPercent int `mapstructure:"memory_percent" yaml:"memory_percent"`
+	// 压缩包同时展开数，0 为总并发数的一半
+	ArchiveWorkers int `mapstructure:"archive_workers" yaml:"archive_workers"`
+	// OCR 同时识别数，0 为总并发数的一半
+	OCRWorkers int `mapstructure:"ocr_workers" yaml:"ocr_workers"`
+}
+
+// BreakerConfig 子检测器熔断参数
+// 同一检测模块在同一文件格式上连续失败 threshold 次后暂停该模块对该格式的检测，cooldown 后试探恢复
+type BreakerConfig struct {
+	// 连续失败次数阈值
+	Threshold int `mapstructure:"threshold" yaml:"threshold"`
+	// 熔断冷却时间
+	Cooldown time.Duration `mapstructure:"cooldown" yaml:"cooldown"`
+}
+
+// TraceConfig 检测时间线与上报请求追踪
+// 开启后记录每个文件在排队、读取、类型识别、文本抽取、各检测模块与评分阶段的耗时，以及上报通道的 HTTP 请求；
+// otlp 方式同时推送检测与请求指标
+type TraceConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 输出方式: log (结构化日志) 或 otlp (OTLP/HTTP JSON)
+	Exporter string `mapstructure:"exporter" yaml:"exporter"`
+	// 只输出总耗时不低于该值的文件，0 输出全部
+	MinDuration time.Duration `mapstructure:"min_duration" yaml:"min_duration"`
+	// 输出比例 (0, 1]，未采样的文件与请求仍计入指标
+	SampleRatio float64 `mapstructure:"sample_ratio" yaml:"sample_ratio"`
+	// OTLP span 接收端地址
+	OTLPEndpoint string `mapstructure:"otlp_endpoint" yaml:"otlp_endpoint"`
+	// OTLP 指标接收端地址，为空时由 otlp_endpoint 推导 (/v1/traces 替换为 /v1/metrics)
+	OTLPMetricsEndpoint string `mapstructure:"otlp_metrics_endpoint" yaml:"otlp_metrics_endpoint"`
+	// 指标推送间隔
+	MetricsInterval time.Duration `mapstructure:"metrics_interval" yaml:"metrics_interval"`
+	// OTLP 附加请求头 (鉴权等)
+	OTLPHeaders map[string]string `mapstructure:"otlp_headers" yaml:"otlp_headers"`
+	// OTLP 资源属性 service.name
+	ServiceName string `mapstructure:"service_name" yaml:"service_name"`
+}
+
+// SamplingConfig 大文件稀疏采样参数
+// 超过头尾与窗口总量的文件只扫描文件头、文件尾与 windows 个由 seed 确定的中间窗口；
+// 哈希检测的采样参数随策略规则下发，不使用此配置
+type SamplingConfig struct {
+	// 文件头采样大小 (KB)
+	HeadSizeKB int64 `mapstructure:"head_size_kb" yaml:"head_size_kb"`
+	// 文件尾采样大小 (KB)
+	TailSizeKB int64 `mapstructure:"tail_size_kb" yaml:"tail_size_kb"`
+	// 中间窗口数量
+	Windows int `mapstructure:"windows" yaml:"windows"`
+	// 中间窗口大小 (KB)
+	WindowSizeKB int64 `mapstructure:"window_size_kb" yaml:"window_size_kb"`
+	// 窗口位置随机种子，相同种子与文件得到相同窗口
+	Seed uint64 `mapstructure:"seed" yaml:"seed"`
+}
+
+// DetectorPluginConfig 外部检测插件配置
+// 插件为独立可执行程序，通过标准输入/输出交换 JSON-RPC 消息，协议见 internal/detector/plugin
+type DetectorPluginConfig struct {
+	// 插件名称 (唯一)，同时作为告警的检测模块名
+	Name    string   `mapstructure:"name" yaml:"name"`
+	Command string   `mapstructure:"command" yaml:"command"`
+	Args    []string `mapstructure:"args" yaml:"args"`
+	// 追加的环境变量 (KEY=VALUE)
+	Env []string `mapstructure:"env" yaml:"env"`
+	// 单次检测超时，超时后插件进程被重启
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// 超过该大小 (MB) 的文件不交给插件，0 表示不限
+	MaxFileSizeMB int64 `mapstructure:"max_file_size_mb" yaml:"max_file_size_mb"`
+	// 发送文件内容而非路径 (插件无权读取被检测文件时使用)
+	SendContent bool `mapstructure:"send_content" yaml:"send_content"`
+	// 传给插件的自定义选项
+	Options map[string]string `mapstructure:"options" yaml:"options"`
+}
+
+// NotifyConfig 桌面用户通知配置
+// 通过 DBus org.freedesktop.Notifications 通知触发检测的用户，依赖 gdbus (glib2)
+// 启用处置策略时由规则中的 notify 动作触发，否则每次命中都通知
+type NotifyConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 通知语言: zh-CN, en-US，为空时跟随 agent.locale
+	Locale string `mapstructure:"locale" yaml:"locale"`
+	// 通知显示时长，0 使用桌面环境默认值
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// 同一用户同一文件的重复通知间隔
+	Cooldown time.Duration `mapstructure:"cooldown" yaml:"cooldown"`
+	// 每个用户每分钟最多通知数
+	MaxPerMinute int `mapstructure:"max_per_minute" yaml:"max_per_minute"`
+	// 自定义模板 (detection: 检测命中, blocked: 操作被阻断)
+	Templates map[string]NotifyTemplateConfig `mapstructure:"templates" yaml:"templates"`
+}
+
+// NotifyTemplateConfig 通知模板 (text/template 语法)
+type NotifyTemplateConfig struct {
+	Summary string `mapstructure:"summary" yaml:"summary"`
+	Body    string `mapstructure:"body" yaml:"body"`
+	// 紧急程度: low, normal, critical
+	Urgency string `mapstructure:"urgency" yaml:"urgency"`
+}
+
+// WebhookConfig 告警 Webhook 输出配置
+// 告警经去重聚合后按模板渲染 JSON 请求体发送到各接收端 (工单系统、SOAR、聊天机器人)，不经过管理平台
+type WebhookConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 单次请求超时
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
+	// 失败后的最大重试次数 (网络错误、408、429 与 5xx)
+	MaxRetries int `mapstructure:"max_retries" yaml:"max_retries"`
+	// 首次重试间隔，之后每次加倍
+	RetryBackoff time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff"`
+	// 每个接收端的待发送队列长度，满时丢弃新的告警
+	QueueSize int `mapstructure:"queue_size" yaml:"queue_size"`
+	// 接收端
+	Endpoints []WebhookEndpointConfig `mapstructure:"endpoints" yaml:"endpoints"`
+}
+
+// WebhookEndpointConfig Webhook 接收端，筛选条件语义同处置规则，未设置的条件视为匹配
+type WebhookEndpointConfig struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	URL  string `mapstructure:"url" yaml:"url"`
+	// 请求方法: POST / PUT
+	Method string `mapstructure:"method" yaml:"method"`
+	// 附加请求头 (鉴权等，值可加密)
+	Headers map[string]string `mapstructure:"headers" yaml:"headers"`
+	// 请求体模板 (text/template 语法，输出须为 JSON)，为空时发送完整告警记录
+	Body string `mapstructure:"body" yaml:"body"`
+	// HMAC 签名密钥 (可加密)，为空时不签名
+	Secret string `mapstructure:"secret" yaml:"secret"`
+	// 签名算法: hmac-sha256 / hmac-sm3
+	SignAlgorithm string `mapstructure:"sign_algorithm" yaml:"sign_algorithm"`
+	// 额外信任的 CA 证书 (PEM)
+	CAFile string `mapstructure:"ca_file" yaml:"ca_file"`
+	// 筛选条件: 检测模块、命中策略 ID、最低密级、文件路径 glob
+	Modules  []string `mapstructure:"modules" yaml:"modules"`
+	RuleIDs  []string `mapstructure:"rule_ids" yaml:"rule_ids"`
+	MinLevel string   `mapstructure:"min_level" yaml:"min_level"`
+	Paths    []string `mapstructure:"paths" yaml:"paths"`
+}
+
+// ResponseConfig 检测结果处置策略配置
+// 规则按顺序匹配，首条命中的规则决定处置动作；配置重载 (SIGHUP) 时热更新
+type ResponseConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 隔离目录，为空时使用 <data_dir>/quarantine
+	QuarantineDir string `mapstructure:"quarantine_dir" yaml:"quarantine_dir"`
+	// 没有规则命中时的动作
+	DefaultActions []string `mapstructure:"default_actions" yaml:"default_actions"`
+	// 处置规则
+	Rules []ResponseRuleConfig `mapstructure:"rules" yaml:"rules"`
+}
+
+// EvidenceConfig 告警取证留存配置
+// 处置规则包含 evidence 动作时，文件副本或命中摘录加密保存到取证库，通过本机接口按告警 ID 取回
+type EvidenceConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 取证库目录，为空时使用 <data_dir>/evidence
+	Dir string `mapstructure:"dir" yaml:"dir"`
+	// 留存方式: file (文件副本，超过大小上限时改为命中摘录) / excerpt (仅命中摘录)
+	Mode string `mapstructure:"mode" yaml:"mode"`
+	// 单个文件副本的大小上限 (MB)
+	MaxFileSizeMB int64 `mapstructure:"max_file_size_mb" yaml:"max_file_size_mb"`
+	// 取证库总量上限 (MB)，超过时淘汰最旧的证据
+	MaxTotalSizeMB int64 `mapstructure:"max_total_size_mb" yaml:"max_total_size_mb"`
+	// 证据保留时间
+	Retention time.Duration `mapstructure:"retention" yaml:"retention"`
+}
+
+// ResponseRuleConfig 处置规则，未设置的条件视为匹配
+type ResponseRuleConfig struct {
+	Name string `mapstructure:"name" yaml:"name"`
+	// 检测模块: keyword_detect, md5_detect, secret_level_detect, electronic_secret_detect, official_format_detect, xattr_label_detect, archive_bomb
+	Modules []string `mapstructure:"modules" yaml:"modules"`
+	// 命中策略 ID，单个 "42" 或区间 "1000-1999"
+	RuleIDs []string `mapstructure:"rule_ids" yaml:"rule_ids"`
+	// 最低密级: 内部/秘密/机密/绝密
+	MinLevel string `mapstructure:"min_level" yaml:"min_level"`
+	// 文件路径 glob
+	Paths []string `mapstructure:"paths" yaml:"paths"`
+	// 处置动作: log, alert, quarantine, block, notify, evidence, label (密级写入文件扩展属性 security.dlp.*)
+	Actions []string `mapstructure:"actions" yaml:"actions"`
+}
+
+// AlertDedupConfig 告警去重聚合配置
+// 同一文件 (路径 + 命中策略 + 文件哈希) 在窗口内的重复命中合并为一条告警
+type AlertDedupConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 聚合窗口，自首次命中起计算，窗口结束时输出告警
+	Window time.Duration `mapstructure:"window" yaml:"window"`
+	// 同时聚合的告警数上限
+	MaxEntries int `mapstructure:"max_entries" yaml:"max_entries"`
+}
+
+// PrintConfig 打印作业检测配置
+// 读取 CUPS 假脱机目录需要 root 权限；需在打印前拦截时，队列应设置 job-hold-until-default=indefinite 并开启 ReleaseClean
+type PrintConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// CUPS 假脱机目录
+	SpoolDir string `mapstructure:"spool_dir" yaml:"spool_dir"`
+	// 轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
+	// 命中后的处置方式: log 仅告警, cancel 告警并取消作业
+	Action string `mapstructure:"action" yaml:"action"`
+	// 检测通过后释放挂起的作业
+	ReleaseClean bool `mapstructure:"release_clean" yaml:"release_clean"`
+	// 单个作业最大检测大小 (MB)
+	MaxSizeMB int `mapstructure:"max_size_mb" yaml:"max_size_mb"`
+}
+
+// ClipboardConfig 剪贴板监控配置
+// 依赖 wl-paste (Wayland) 或 xclip (X11)，守护进程需能访问桌面会话 (WAYLAND_DISPLAY/DISPLAY)
+type ClipboardConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
+	// 单次检测的最大内容 (MB)，超过则跳过
+	MaxSizeMB int `mapstructure:"max_size_mb" yaml:"max_size_mb"`
+	// 相同内容的重复告警间隔
+	Cooldown time.Duration `mapstructure:"cooldown" yaml:"cooldown"`
+	// 每分钟最多告警数
+	MaxAlertsPerMinute int `mapstructure:"max_alerts_per_minute" yaml:"max_alerts_per_minute"`
+}
+
+// ExfilConfig 批量外发检测配置
+// Window 时间内向同一可移动介质/网络挂载点写入 Threshold 个涉密文件即产生聚合告警
+type ExfilConfig struct {
+	Enable    bool          `mapstructure:"enable" yaml:"enable"`
+	Threshold int           `mapstructure:"threshold" yaml:"threshold"`
+	Window    time.Duration `mapstructure:"window" yaml:"window"`
+	Cooldown  time.Duration `mapstructure:"cooldown" yaml:"cooldown"`
+}
+
+// LineageConfig 涉密文件流转追踪配置
+// 已告警文件的复制、改名关联到首次告警，告警中附带流转图
+type LineageConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 流转图最后一次变化后的保留时间
+	Retention time.Duration `mapstructure:"retention" yaml:"retention"`
+	// 单个流转图的最大记录数
+	MaxEdges int `mapstructure:"max_edges" yaml:"max_edges"`
+}
+
+// RuleStatsConfig 规则命中统计与误报反馈配置
+// 误报通过本机接口按告警 ID 标记，准确率过低的规则上报管理端
+type RuleStatsConfig struct {
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 判定规则质量所需的最少命中次数
+	MinSamples int `mapstructure:"min_samples" yaml:"min_samples"`
+	// 准确率低于该值时标记规则并上报
+	FlagPrecision float64 `mapstructure:"flag_precision" yaml:"flag_precision"`
+	// 准确率低于该值时自动降级规则 (命中不再产生告警)
+	DemotePrecision float64 `mapstructure:"demote_precision" yaml:"demote_precision"`
+	// 是否自动降级规则
+	AutoDemote bool `mapstructure:"auto_demote" yaml:"auto_demote"`
+	// 告警索引保留时间，超过后不能再标记误报
+	Retention time.Duration `mapstructure:"retention" yaml:"retention"`
+}
+
+// RemovableConfig 可移动介质 (U 盘/移动硬盘/光盘) 监控配置
+type RemovableConfig struct {
+	// 是否启用
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 挂载表轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
+	// 插入后自动扫描
+	AutoScan bool `mapstructure:"auto_scan" yaml:"auto_scan"`
+	// 扫描通过前禁止写入 (需要 root 权限)
+	BlockWriteUntilClean bool `mapstructure:"block_write_until_clean" yaml:"block_write_until_clean"`
+	// 单个介质扫描超时
+	ScanTimeout time.Duration `mapstructure:"scan_timeout" yaml:"scan_timeout"`
+}
+
+// ContainerScanConfig 容器文件系统扫描配置
+// 通过 /proc/<pid>/root 访问运行中容器的根文件系统，需要 root 权限
+type ContainerScanConfig struct {
+	// 是否启用
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 容器运行时: docker, containerd
+	Runtimes []string `mapstructure:"runtimes" yaml:"runtimes"`
+	// Docker Engine API 套接字
+	DockerSocket string `mapstructure:"docker_socket" yaml:"docker_socket"`
+	// containerd v2 shim 任务目录
+	ContainerdStateDir string `mapstructure:"containerd_state_dir" yaml:"containerd_state_dir"`
+	// 容器列表轮询间隔
+	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
+	// 已扫描容器的重新扫描间隔，0 表示只扫描一次
+	RescanInterval time.Duration `mapstructure:"rescan_interval" yaml:"rescan_interval"`
+	// 容器内的扫描路径，为空表示整个根文件系统
+	Paths []string `mapstructure:"paths" yaml:"paths"`
+	// 单个容器扫描超时
+	ScanTimeout time.Duration `mapstructure:"scan_timeout" yaml:"scan_timeout"`
+}
+
+// PathFilterConfig 路径过滤规则
+type PathFilterConfig struct {
+	// 排除的文件/目录 glob ("*" 不跨目录，"**" 跨任意层)
+	ExcludeGlobs []string `mapstructure:"exclude_globs" yaml:"exclude_globs"`
+	// 包含的文件 glob，为空表示全部
+	IncludeGlobs []string `mapstructure:"include_globs" yaml:"include_globs"`
+	// 排除的路径正则
+	ExcludeRegex []string `mapstructure:"exclude_regex" yaml:"exclude_regex"`
+	// 相对监控/扫描根目录的最大深度，0 表示不限
+	MaxDepth int `mapstructure:"max_depth" yaml:"max_depth"`
+	// 跳过的文件系统类型
+	SkipFSTypes []string `mapstructure:"skip_fs_types" yaml:"skip_fs_types"`
+}
+
+// ScanScheduleConfig 定时扫描计划
+type ScanScheduleConfig struct {
+	// 计划名称 (唯一)
+	Name string `mapstructure:"name" yaml:"name"`
+	// 扫描路径列表
+	Paths []string `mapstructure:"paths" yaml:"paths"`
+	// cron 表达式 (分 时 日 月 周)，支持 @hourly/@daily/@weekly/@monthly
+	Schedule string `mapstructure:"schedule" yaml:"schedule"`
+	// 包含的文件 glob，为空表示全部
+	Include []string `mapstructure:"include" yaml:"include"`
+	// 排除的文件/目录 glob
+	Exclude []string `mapstructure:"exclude" yaml:"exclude"`
+	// 单次运行最长时间，超时后中止并保留断点，0 表示不限
+	MaxDuration time.Duration `mapstructure:"max_duration" yaml:"max_duration"`
+	// 相对扫描路径的最大深度，0 表示不限
+	MaxDepth int `mapstructure:"max_depth" yaml:"max_depth"`
+	// 文件大小区间，0 表示不限
+	MinSizeKB int64 `mapstructure:"min_size_kb" yaml:"min_size_kb"`
+	MaxSizeMB int64 `mapstructure:"max_size_mb" yaml:"max_size_mb"`
+	// 只扫描最近 newer_than 内修改过的文件 (如 48h)，0 表示不限
+	NewerThan time.Duration `mapstructure:"newer_than" yaml:"newer_than"`
+	// 只扫描 older_than 之前修改的文件，0 表示不限
+	OlderThan time.Duration `mapstructure:"older_than" yaml:"older_than"`
 }
 
 // ==========================================
@@ -121,6 +705,59 @@ type SecurityConfig struct {
 	Integrity IntegrityConfig `mapstructure:"integrity" yaml:"integrity"`
 	// 网络异常检测
 	NetGuard NetGuardConfig `mapstructure:"netguard" yaml:"netguard"`
+	// 内核模块与动态链接劫持检测
+	Hijack HijackConfig `mapstructure:"hijack" yaml:"hijack"`
+	// 诱饵文件
+	Canary CanaryConfig `mapstructure:"canary" yaml:"canary"`
+	// 规则包签名校验
+	RuleSignature RuleSignatureConfig `mapstructure:"rule_signature" yaml:"rule_signature"`
+	// 密钥管理后端
+	KMS KMSConfig `mapstructure:"kms" yaml:"kms"`
+}
+
+// KMSConfig 本地数据加密密钥管理配置
+// 切换后端后旧后端加密的缓存数据仍可读取 (local 格式兼容)，但 file/tpm/pkcs11 之间切换需清空缓存
+type KMSConfig struct {
+	// 后端: local (内置密钥), file, tpm, pkcs11
+	Backend string `mapstructure:"backend" yaml:"backend"`
+	// file: 密钥加密密钥文件，为空使用 <data_dir>/keys/kek
+	KeyFile string `mapstructure:"key_file" yaml:"key_file"`
+	// TPM2 密封
+	TPM TPMConfig `mapstructure:"tpm" yaml:"tpm"`
+	// PKCS#11 硬件密码模块
+	PKCS11 PKCS11Config `mapstructure:"pkcs11" yaml:"pkcs11"`
+	// 数据密钥轮换周期，0 不自动轮换
+	RotateInterval time.Duration `mapstructure:"rotate_interval" yaml:"rotate_interval"`
+	// 轮换后保留用于解密的历史密钥数
+	KeepPrevious int `mapstructure:"keep_previous" yaml:"keep_previous"`
+}
+
+// TPMConfig TPM2 后端配置
+type TPMConfig struct {
+	// TCTI (如 "device:/dev/tpmrm0")，为空使用 tpm2-tools 默认值
+	TCTI string `mapstructure:"tcti" yaml:"tcti"`
+	// 绑定的 PCR (如 "sha256:0,7")，为空不绑定
+	PCRs string `mapstructure:"pcrs" yaml:"pcrs"`
+}
+
+// PKCS11Config PKCS#11 后端配置
+type PKCS11Config struct {
+	// 模块路径
+	Module string `mapstructure:"module" yaml:"module"`
+	// 令牌标签
+	Token string `mapstructure:"token" yaml:"token"`
+	// RSA 密钥对标签
+	KeyLabel string `mapstructure:"key_label" yaml:"key_label"`
+	// 用户 PIN 文件
+	PINFile string `mapstructure:"pin_file" yaml:"pin_file"`
+}
+
+// RuleSignatureConfig 服务端下发规则/策略的 SM2 签名校验配置
+type RuleSignatureConfig struct {
+	// 校验模式: off 不校验, warn 校验失败仅告警, enforce 拒绝加载未签名或签名无效的规则
+	Mode string `mapstructure:"mode" yaml:"mode"`
+	// 服务端 SM2 公钥 (十六进制 04||X||Y) 或公钥文件路径
+	PublicKey string `mapstructure:"public_key" yaml:"public_key"`
 }
 
 type IntegrityConfig struct {
@@ -128,6 +765,46 @@ type IntegrityConfig struct {
 	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
 	// 默认检测周期 (当传入无效值时使用)
 	DefaultInterval time.Duration `mapstructure:"default_interval" yaml:"default_interval"`
+	// 自我保护: 监控自身二进制、配置、规则文件与数据库
+	SelfProtect SelfProtectConfig `mapstructure:"self_protect" yaml:"self_protect"`
+	// 大规模目录树基线 (Merkle 树)，节点保存在本地数据库
+	Baseline BaselineConfig `mapstructure:"baseline" yaml:"baseline"`
+}
+
+// BaselineConfig 目录树完整性基线配置
+// 每个目录保存一个节点，校验时只重新计算大小、时间或 inode 变化的文件，逐层对比定位变化
+type BaselineConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 建立基线的目录
+	Paths []string `mapstructure:"paths" yaml:"paths"`
+	// 校验周期，目录树较大时应明显长于 check_interval
+	Interval time.Duration `mapstructure:"interval" yaml:"interval"`
+	// 每次重新计算全部文件的内容哈希 (较慢)
+	FullHash bool `mapstructure:"full_hash" yaml:"full_hash"`
+	// 上报后以当前状态为新基线，同一变化只上报一次
+	Accept bool `mapstructure:"accept" yaml:"accept"`
+	// 基线签名 SM2 私钥文件 (十六进制 d)，可使用单位下发的密钥
+	// 为空使用 <data_dir>/keys/baseline_sm2.key，不存在时生成终端密钥并写出公钥文件 .pub
+	SigningKey string `mapstructure:"signing_key" yaml:"signing_key"`
+}
+
+// SelfProtectConfig 自我保护配置，校验周期沿用 check_interval
+type SelfProtectConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 二进制被篡改时从受保护副本 (<data_dir>/protected) 恢复并重新执行
+	// 开启后替换二进制会被视为篡改，升级需先停止服务
+	Restore bool `mapstructure:"restore" yaml:"restore"`
+	// 配置与规则文件被修改或删除时从加密黄金副本恢复 (签名有效的规则更新不受影响)
+	// 开启后手工修改配置会被还原，需先停止服务
+	Remediate bool `mapstructure:"remediate" yaml:"remediate"`
+	// 额外保护的文件 (如证书、插件)
+	ExtraPaths []string `mapstructure:"extra_paths" yaml:"extra_paths"`
+	// 按发行版软件包数据库 (dpkg / rpm) 校验自身二进制与 PackagePaths，不依赖本地基线
+	PackageVerify bool `mapstructure:"package_verify" yaml:"package_verify"`
+	// 额外按软件包数据库校验的系统文件 (如 /usr/bin/auditctl)
+	PackagePaths []string `mapstructure:"package_paths" yaml:"package_paths"`
 }
 
 type NetGuardConfig struct {
@@ -136,9 +813,148 @@ type NetGuardConfig struct {
 	// 检测周期 (e.g., "1s")
 	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
 	// 额外白名单 (除回环和服务端IP外)
+	// 与策略下发 (net_whitelist) 及运行时通过本机接口添加的规则合并，重载配置后生效
 	Whitelist []string `mapstructure:"whitelist" yaml:"whitelist"`
 	// 去重时间 (e.g., "1h")
 	DeduplicationTime time.Duration `mapstructure:"deduplication_time" yaml:"deduplication_time"`
 	// 监控自身
 	MonitorSelf bool `mapstructure:"monitor_self" yaml:"monitor_self"`
+	// 监听端口与服务暴露监控
+	Listen ListenGuardConfig `mapstructure:"listen" yaml:"listen"`
+	// DNS 查询监控与域名黑名单
+	DNS DNSGuardConfig `mapstructure:"dns" yaml:"dns"`
+	// 网络告警的 GeoIP / ASN 信息与按国家、ASN 的规则
+	GeoIP GeoIPConfig `mapstructure:"geoip" yaml:"geoip"`
+	// 外发流量统计与大流量外传告警
+	Bandwidth BandwidthGuardConfig `mapstructure:"bandwidth" yaml:"bandwidth"`
+	// 连接跟踪 (nf_conntrack) 事件订阅
+	Conntrack ConntrackConfig `mapstructure:"conntrack" yaml:"conntrack"`
+	// 网络告警抓包取证
+	Pcap PcapConfig `mapstructure:"pcap" yaml:"pcap"`
+}
+
+// PcapConfig 网络告警抓包配置
+// 网络告警触发后对告警对端抓包，pcap 文件与记录告警内容的同名 .json 保存在 Dir；需要 CAP_NET_RAW，不可用时只告警
+type PcapConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 抓包目录，为空时使用 <data_dir>/pcap
+	Dir string `mapstructure:"dir" yaml:"dir"`
+	// 单次抓包时长上限
+	MaxDuration time.Duration `mapstructure:"max_duration" yaml:"max_duration"`
+	// 单个抓包文件的大小上限 (MB)
+	MaxSizeMB int64 `mapstructure:"max_size_mb" yaml:"max_size_mb"`
+	// 每个报文保存的最大长度 (字节)
+	SnapLen int `mapstructure:"snaplen" yaml:"snaplen"`
+	// 同时进行的抓包数上限，超过时新的告警不抓包
+	MaxConcurrent int `mapstructure:"max_concurrent" yaml:"max_concurrent"`
+	// 抓包目录总量上限 (MB)，超过时淘汰最旧的抓包
+	QuotaMB int64 `mapstructure:"quota_mb" yaml:"quota_mb"`
+}
+
+// ConntrackConfig 连接跟踪配置
+// 订阅连接销毁事件，补充外发流量统计在两次采样之间关闭的连接；需要 nf_conntrack 模块与 CAP_NET_ADMIN，不可用时跳过
+type ConntrackConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 启动时开启内核连接字节计数 (net.netfilter.nf_conntrack_acct)，只对之后建立的连接生效
+	Accounting bool `mapstructure:"accounting" yaml:"accounting"`
+}
+
+// BandwidthGuardConfig 外发流量统计配置
+// 按 (进程, 对端 IP) 统计 TCP 发送量，白名单为网络白名单 (NetGuard.Whitelist 等)、服务端地址与 Whitelist 的并集，GeoIP 白名单命中的对端也不统计
+type BandwidthGuardConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 采样周期
+	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
+	// 统计窗口，同一进程与对端在窗口内只告警一次
+	Window time.Duration `mapstructure:"window" yaml:"window"`
+	// 窗口内发送量阈值 (MB)
+	ThresholdMB int64 `mapstructure:"threshold_mb" yaml:"threshold_mb"`
+	// 额外的对端白名单 (IP 或 CIDR)，如备份服务器
+	Whitelist []string `mapstructure:"whitelist" yaml:"whitelist"`
+}
+
+// GeoIPConfig GeoIP 配置
+// 规则条目为国家代码 (如 "CN") 或 ASN (如 "AS4134")，"!" 前缀取反，告警规则优先于白名单
+type GeoIPConfig struct {
+	// MaxMind 格式库文件，如 GeoLite2-City.mmdb、GeoLite2-ASN.mmdb，为空时不查询
+	Databases []string `mapstructure:"databases" yaml:"databases"`
+	// 白名单: 命中的对端视为允许
+	Allow []string `mapstructure:"allow" yaml:"allow"`
+	// 告警规则: 如 ["!CN"] 对境外对端告警
+	Alert []string `mapstructure:"alert" yaml:"alert"`
+}
+
+// DNSGuardConfig DNS 查询监控配置
+// 黑名单来自策略同步下发的 <policies_path>/dns_block_detect/policy.json (SIGHUP 时重新加载) 与本地 Blocklist
+type DNSGuardConfig struct {
+	// 是否开启 (需要 CAP_NET_RAW)
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 执行策略中的拦截动作 (iptables 字符串匹配)，关闭时仅告警
+	Block bool `mapstructure:"block" yaml:"block"`
+	// 本地告警域名，匹配域名及其子域名
+	Blocklist []string `mapstructure:"blocklist" yaml:"blocklist"`
+	// 同一域名的告警去重时间
+	DeduplicationTime time.Duration `mapstructure:"deduplication_time" yaml:"deduplication_time"`
+}
+
+// ListenGuardConfig 监听端口监控配置
+type ListenGuardConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 检测周期
+	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
+	// 监控整机的监听端口，否则只监控自身与 Processes 中的进程
+	Host bool `mapstructure:"host" yaml:"host"`
+	// 受监控的进程名
+	Processes []string `mapstructure:"processes" yaml:"processes"`
+	// 允许的端口 (如 "22"、"tcp/443"、"8000-8100")，与 AllowProcesses 均为空时以启动时已存在的监听为白名单
+	AllowPorts []string `mapstructure:"allow_ports" yaml:"allow_ports"`
+	// 允许任意监听的程序路径 (支持 * 通配)
+	AllowProcesses []string `mapstructure:"allow_processes" yaml:"allow_processes"`
+}
+
+// HijackConfig 内核模块与 LD_PRELOAD 劫持检测配置
+type HijackConfig struct {
+	// 是否开启
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 检测周期
+	CheckInterval time.Duration `mapstructure:"check_interval" yaml:"check_interval"`
+	// 允许加载的内核模块 (支持 * 通配)，为空时以启动时已加载的模块为白名单
+	ModuleAllowlist []string `mapstructure:"module_allowlist" yaml:"module_allowlist"`
+	// 允许预加载的库路径 (支持 * 通配)
+	LibraryAllowlist []string `mapstructure:"library_allowlist" yaml:"library_allowlist"`
+	// 检查 LD_PRELOAD / LD_AUDIT 的进程名，自身进程总是检查
+	Processes []string `mapstructure:"processes" yaml:"processes"`
+}
+
+// CanaryConfig 诱饵文件配置
+// 在敏感目录放置带唯一标识的诱饵文件，任何进程打开即产生紧急级告警；需要 fanotify (CAP_SYS_ADMIN)
+type CanaryConfig struct {
+	// 是否开启 (会在下列路径创建文件，默认关闭)
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// 诱饵文件绝对路径，所在目录需已存在，同名的非诱饵文件不会被覆盖
+	Files []string `mapstructure:"files" yaml:"files"`
+	// 允许访问诱饵文件的程序路径 (支持 * 通配)，如备份、杀毒软件
+	AllowProcesses []string `mapstructure:"allow_processes" yaml:"allow_processes"`
+	// 同一进程访问同一诱饵文件的告警间隔
+	Deduplication time.Duration `mapstructure:"deduplication_time" yaml:"deduplication_time"`
+}
+
+// ==========================================
+// 7. 本机检测服务
+// ==========================================
+
+// APIConfig 通过 Unix Socket 向同机组件 (邮件网关、打印服务等) 提供 gRPC 检测接口
+type APIConfig struct {
+	// 是否启用
+	Enable bool `mapstructure:"enable" yaml:"enable"`
+	// Socket 文件路径
+	Socket string `mapstructure:"socket" yaml:"socket"`
+	// 单次提交内容上限 (MB)
+	MaxBytesMB int `mapstructure:"max_bytes_mb" yaml:"max_bytes_mb"`
+	// 单次检测超时
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout"`
 }
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// 环境变量覆盖: 配置键转为大写、"." 换为 "_" 并加前缀，如 scanner.workers -> FILEWATCHER_SCANNER_WORKERS
+// 兼容旧前缀 LFW_，两者同时设置时 FILEWATCHER_ 优先；列表以逗号分隔
+const (
+	EnvPrefix       = "FILEWATCHER"
+	legacyEnvPrefix = "LFW"
+)
+
+var (
+	overridesMu sync.Mutex
+	// overrides 命令行 --set 覆盖 (配置键 -> 值)，优先级高于环境变量与配置文件
+	overrides map[string]string
+)
+
+// SetOverrides 设置命令行覆盖 ("key=value" 列表，如 scanner.workers=4)，在 LoadConfig 之前调用
+// 配置键按配置结构校验，未知的键返回错误；重载配置时覆盖仍然生效
+func SetOverrides(sets []string) error {
+	parsed := make(map[string]string, len(sets))
+	for _, s := range sets {
+		key, value, ok := strings.Cut(s, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("无效的覆盖 %q，应为 key=value", s)
+		}
+		if _, found := lookupKey(key); !found {
+			return fmt.Errorf("未知的配置项 %q", key)
+		}
+		parsed[key] = value
+	}
+
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides = parsed
+	return nil
+}
+
+// applyOverrides 将命令行覆盖写入 viper (最高优先级)
+func applyOverrides(v *viper.Viper) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	for key, value := range overrides {
+		v.Set(key, value)
+	}
+}
+
+// bindEnvs 为全部配置项绑定环境变量，未出现在配置文件且无默认值的键也能被覆盖
+// 映射与结构体列表不能用单个环境变量表达，需写在配置文件或使用 --set
+func bindEnvs(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	walkKeys("", reflect.TypeOf(AppConfig{}), func(key string, t reflect.Type) {
+		if t.Kind() == reflect.Map || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct) {
+			return
+		}
+		v.BindEnv(key, envName(EnvPrefix, key), envName(legacyEnvPrefix, key))
+	})
+}
+
+// envName 配置键对应的环境变量名
+func envName(prefix, key string) string {
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// walkKeys 遍历配置结构的叶子配置项 (结构体之外的字段)
+func walkKeys(prefix string, t reflect.Type, fn func(key string, t reflect.Type)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := configKey(f)
+		if name == "" {
+			continue
+		}
+		key := joinKey(prefix, name)
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			walkKeys(key, f.Type, fn)
+			continue
+		}
+		fn(key, f.Type)
+	}
+}
+
+// lookupKey 按配置结构查找配置键，映射的下一级键 (如 agent.log_modules.detector) 视为存在
+func lookupKey(key string) (reflect.Type, bool) {
+	t := reflect.TypeOf(AppConfig{})
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := structFields(t)[part]
+			if !ok {
+				return nil, false
+			}
+			t = f.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			return nil, false
+		}
+		if t == durationType && i < len(parts)-1 {
+			return nil, false
+		}
+	}
+	return t, true
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loadWithOverrides 与 LoadConfig 相同的顺序加载: 默认值 < 配置文件 < 环境变量 < --set
+func loadWithOverrides(t *testing.T, yaml string) *AppConfig {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	os.WriteFile(path, []byte(yaml), 0644)
+	v := viper.New()
+	setDefaults(v)
+	v.SetConfigFile(path)
+	bindEnvs(v)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	applyOverrides(v)
+	var cfg AppConfig
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+func TestOverrides(t *testing.T) {
+	// 配置文件与默认值中都没有的键也可以由环境变量覆盖
+	t.Setenv("FILEWATCHER_SCANNER_TRACE_OTLP_ENDPOINT", "http://collector:4318/v1/traces")
+	t.Setenv("FILEWATCHER_SCANNER_WATCH_DIRS", "/data,/srv")
+	t.Setenv("FILEWATCHER_SERVER_TIMEOUT", "7s")
+	t.Setenv("LFW_SERVER_TIMEOUT", "9s")
+	t.Setenv("LFW_SERVER_URL", "https://legacy.example.com")
+	t.Setenv("FILEWATCHER_SECURITY_NETGUARD_CHECK_INTERVAL", "3s")
+
+	if err := SetOverrides([]string{"scanner.workers=6", "security.netguard.check_interval=5s", "agent.log_modules.detector=debug"}); err != nil {
+		t.Fatal(err)
+	}
+	defer SetOverrides(nil)
+
+	cfg := loadWithOverrides(t, "scanner:\n  workers: 2\nserver:\n  url: \"https://file.example.com\"\n")
+	if cfg.Scanner.Trace.OTLPEndpoint != "http://collector:4318/v1/traces" {
+		t.Errorf("OTLPEndpoint = %q", cfg.Scanner.Trace.OTLPEndpoint)
+	}
+	if len(cfg.Scanner.WatchDirs) != 2 || cfg.Scanner.WatchDirs[1] != "/srv" {
+		t.Errorf("WatchDirs = %v", cfg.Scanner.WatchDirs)
+	}
+	// FILEWATCHER_ 优先于 LFW_，旧前缀仍然有效
+	if cfg.Server.Timeout != 7*time.Second || cfg.Server.URL != "https://legacy.example.com" {
+		t.Errorf("Server = %+v", cfg.Server)
+	}
+	// --set 优先于环境变量与配置文件
+	if cfg.Scanner.Workers != 6 || cfg.Security.NetGuard.CheckInterval != 5*time.Second {
+		t.Errorf("Workers = %d, CheckInterval = %v", cfg.Scanner.Workers, cfg.Security.NetGuard.CheckInterval)
+	}
+	if cfg.Agent.LogModules["detector"] != "debug" {
+		t.Errorf("LogModules = %v", cfg.Agent.LogModules)
+	}
+}
+
+func TestSetOverrides_Invalid(t *testing.T) {
+	defer SetOverrides(nil)
+	for _, set := range []string{"scanner.workers", "=1", "scanner.wokers=1", "scanner.trace.min_duration.x=1"} {
+		if err := SetOverrides([]string{set}); err == nil {
+			t.Errorf("SetOverrides(%q) should fail", set)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// 加密配置项: 字符串配置值可写为 ENC[<base64 密文>]，由 KMS 封装的密钥加密 (filewatcherd config encrypt 生成)
+// 加载配置后由主程序注册的解密函数替换为明文，配置文件中始终只保存密文
+const (
+	secretPrefix = "ENC["
+	secretSuffix = "]"
+)
+
+// SecretDecrypter 解密 ENC[...] 中的密文
+type SecretDecrypter func(ciphertext []byte) ([]byte, error)
+
+// secretDecrypter 当前的解密函数，Reload 时同样使用 (由 reloadMu 保护)
+var secretDecrypter SecretDecrypter
+
+// IsEncrypted 配置值是否为 ENC[...] 形式的密文
+func IsEncrypted(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, secretPrefix) && strings.HasSuffix(s, secretSuffix)
+}
+
+// FormatSecret 将密文编码为配置值 ENC[<base64>]
+func FormatSecret(ciphertext []byte) string {
+	return secretPrefix + base64.StdEncoding.EncodeToString(ciphertext) + secretSuffix
+}
+
+// parseSecret 取出 ENC[...] 中的密文
+func parseSecret(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	data, err := base64.StdEncoding.DecodeString(s[len(secretPrefix) : len(s)-len(secretSuffix)])
+	if err != nil {
+		return nil, fmt.Errorf("加密配置值不是有效的 base64: %v", err)
+	}
+	return data, nil
+}
+
+// SetSecretDecrypter 设置解密函数并解密当前配置中的加密项，之后 Reload 时同样解密
+// fn 为 nil 表示未启用 KMS，配置中存在加密项时返回错误；返回已解密的配置键
+func SetSecretDecrypter(fn SecretDecrypter) ([]string, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	cur := globalConfig.Load()
+	if cur == nil {
+		return nil, fmt.Errorf("config not loaded")
+	}
+	// 在副本上解密，失败时保留原配置
+	cfg := *cur
+	keys, err := decryptSecrets(&cfg, fn)
+	if err != nil {
+		return nil, err
+	}
+	secretDecrypter = fn
+	globalConfig.Store(&cfg)
+	return keys, nil
+}
+
+// decryptSecrets 遍历配置中的字符串 (含列表与映射的值)，将 ENC[...] 替换为明文
+// 列表与映射在修改前复制，不影响 viper 内部保存的值
+func decryptSecrets(cfg *AppConfig, fn SecretDecrypter) ([]string, error) {
+	var keys []string
+	var firstErr error
+	decrypt := func(key, s string) string {
+		if firstErr != nil || !IsEncrypted(s) {
+			return s
+		}
+		if strings.HasPrefix(key, "security.kms.") {
+			// 解密依赖密钥管理配置本身
+			firstErr = fmt.Errorf("%s: 密钥管理后端配置不能加密", key)
+			return s
+		}
+		if fn == nil {
+			firstErr = fmt.Errorf("%s: 配置值已加密，但未启用密钥管理后端 (security.kms.backend)", key)
+			return s
+		}
+		data, err := parseSecret(s)
+		if err == nil {
+			data, err = fn(data)
+		}
+		if err != nil {
+			firstErr = fmt.Errorf("%s: 解密失败: %w", key, err)
+			return s
+		}
+		keys = append(keys, key)
+		return string(data)
+	}
+	walkStrings("", reflect.ValueOf(cfg).Elem(), decrypt)
+	return keys, firstErr
+}
+
+// walkStrings 遍历结构体中的字符串值，fn 返回替换后的值
+func walkStrings(key string, v reflect.Value, fn func(key, s string) string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == durationType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if name := configKey(v.Type().Field(i)); name != "" {
+				walkStrings(joinKey(key, name), v.Field(i), fn)
+			}
+		}
+	case reflect.String:
+		if s := fn(key, v.String()); s != v.String() {
+			v.SetString(s)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			walkStrings(fmt.Sprintf("%s[%d]", key, i), cp.Index(i), fn)
+		}
+		v.Set(cp)
+	case reflect.Map:
+		if v.IsNil() || v.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		cp := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			cp.SetMapIndex(iter.Key(), reflect.ValueOf(fn(joinKey(key, iter.Key().String()), iter.Value().String())).Convert(v.Type().Elem()))
+		}
+		v.Set(cp)
+	}
+}
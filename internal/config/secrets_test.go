@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// xorDecrypter 测试用的可逆 "加密"
+func xorDecrypter(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty")
+	}
+	out := make([]byte, len(data))
+	for i, b := range data {
+		out[i] = b ^ 0x5a
+	}
+	return out, nil
+}
+
+func xorSecret(s string) string {
+	data, _ := xorDecrypter([]byte(s))
+	return FormatSecret(data)
+}
+
+func TestSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	write := func(header string) {
+		os.WriteFile(path, []byte(`
+agent:
+  log_ship:
+    headers:
+      authorization: "`+header+`"
+server:
+  url: "https://file.example.com"
+scanner:
+  trace:
+    otlp_headers:
+      x-api-key: "`+xorSecret("token-1")+`"
+`), 0644)
+	}
+	write(xorSecret("Bearer abc"))
+
+	v := viper.New()
+	setDefaults(v)
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	var cfg AppConfig
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	oldCfg, oldViper := globalConfig.Load(), loadedViper
+	globalConfig.Store(&cfg)
+	loadedViper = v
+	defer func() {
+		globalConfig.Store(oldCfg)
+		loadedViper, secretDecrypter = oldViper, nil
+	}()
+
+	// 未启用 KMS 时存在加密项应报错，原配置不变
+	if _, err := SetSecretDecrypter(nil); err == nil || !strings.Contains(err.Error(), "security.kms.backend") {
+		t.Errorf("SetSecretDecrypter(nil) error = %v", err)
+	}
+	if !IsEncrypted(Get().Scanner.Trace.OTLPHeaders["x-api-key"]) {
+		t.Error("config modified after failed decryption")
+	}
+
+	keys, err := SetSecretDecrypter(xorDecrypter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Errorf("decrypted keys = %v", keys)
+	}
+	got := Get()
+	if got.Agent.LogShip.Headers["authorization"] != "Bearer abc" || got.Scanner.Trace.OTLPHeaders["x-api-key"] != "token-1" {
+		t.Errorf("decrypted = %v, %v", got.Agent.LogShip.Headers, got.Scanner.Trace.OTLPHeaders)
+	}
+	if got.Server.URL != "https://file.example.com" {
+		t.Errorf("Server.URL = %q", got.Server.URL)
+	}
+	// viper 内部保存的值仍为密文
+	if !IsEncrypted(v.GetStringMapString("agent.log_ship.headers")["authorization"]) {
+		t.Error("viper value was decrypted in place")
+	}
+
+	// 重载时同样解密；解密失败保留原配置
+	write(xorSecret("Bearer def"))
+	if err := Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if h := Get().Agent.LogShip.Headers["authorization"]; h != "Bearer def" {
+		t.Errorf("reloaded header = %q", h)
+	}
+	write("ENC[]")
+	if err := Reload(); err == nil || !strings.Contains(err.Error(), "agent.log_ship.headers.authorization") {
+		t.Errorf("Reload(bad secret) error = %v", err)
+	}
+	if h := Get().Agent.LogShip.Headers["authorization"]; h != "Bearer def" {
+		t.Errorf("header after failed reload = %q", h)
+	}
+
+	// 密钥管理配置本身不能加密
+	cfg.Security.KMS.PKCS11.PINFile = xorSecret("/etc/pin")
+	if _, err := decryptSecrets(&cfg, xorDecrypter); err == nil || !strings.Contains(err.Error(), "security.kms.pkcs11.pin_file") {
+		t.Errorf("decryptSecrets(kms) error = %v", err)
+	}
+
+	write("ENC[not base64!]")
+	issues, err := Validate(path)
+	if err != nil || len(issues) != 1 || issues[0].Key != "agent.log_ship.headers.authorization" {
+		t.Errorf("Validate() = %v, %v", issues, err)
+	}
+}
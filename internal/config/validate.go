@@ -0,0 +1,417 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Issue 配置校验发现的问题
+type Issue struct {
+	// 配置键路径，如 scanner.trace.min_duration、security.netguard.whitelist[2]
+	Key string
+	// 问题与修改建议
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Key == "" {
+		return i.Message
+	}
+	return i.Key + ": " + i.Message
+}
+
+// Validate 按配置结构校验配置文件: 未知的键、类型不符 (如错误的时间间隔)、取值无效 (如白名单中的 CIDR)
+// 返回的 error 表示文件无法读取或解析；问题按键排序返回，为空表示校验通过
+func Validate(configPath string) ([]Issue, error) {
+	raw := viper.New()
+	raw.SetConfigFile(configPath)
+	if err := raw.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var issues []Issue
+	checkValue("", raw.AllSettings(), reflect.TypeOf(AppConfig{}), &issues)
+
+	// 与启动时一致地合并默认值后反序列化，兜底类型检查未覆盖的情况
+	v := viper.New()
+	setDefaults(v)
+	v.SetConfigFile(configPath)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	// 类型错误的字段保持零值，其余字段仍可检查取值
+	var cfg AppConfig
+	if err := v.Unmarshal(&cfg); err != nil && len(issues) == 0 {
+		issues = append(issues, Issue{Message: fmt.Sprintf("配置无法解析: %v", err)})
+	}
+	issues = append(issues, checkSemantics(&cfg)...)
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Key < issues[j].Key })
+	return issues, nil
+}
+
+// checkValue 按字段类型检查配置值，规则与反序列化的弱类型转换一致 (如数字可写成字符串)
+func checkValue(key string, val interface{}, t reflect.Type, issues *[]Issue) {
+	if val == nil {
+		return
+	}
+	add := func(format string, args ...interface{}) {
+		*issues = append(*issues, Issue{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case t == durationType:
+		switch v := val.(type) {
+		case int, int64, float64:
+			// 数字按纳秒解析，多半是漏写了单位
+			add("时间间隔 %v 缺少单位，将按纳秒解析，应写为如 \"30s\"、\"5m\"、\"1h\"", v)
+		case string:
+			if _, err := time.ParseDuration(v); err != nil {
+				add("无效的时间间隔 %q，应为数字加单位，如 \"500ms\"、\"30s\"、\"5m\"、\"1h\" (不支持 d，天数请写为小时)", v)
+			}
+		default:
+			add("应为时间间隔字符串，如 \"30s\"")
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			add("应为配置段 (键值映射)，实际为 %s", describe(val))
+			return
+		}
+		fields := structFields(t)
+		for _, k := range sortedMapKeys(m) {
+			f, ok := fields[k]
+			if !ok {
+				msg := "未知的配置项，将被忽略"
+				if s := suggest(k, fields); s != "" {
+					msg += fmt.Sprintf("，是否应为 %q?", s)
+				}
+				*issues = append(*issues, Issue{Key: joinKey(key, k), Message: msg})
+				continue
+			}
+			checkValue(joinKey(key, k), m[k], f.Type, issues)
+		}
+	case reflect.Map:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			add("应为键值映射，实际为 %s", describe(val))
+			return
+		}
+		for _, k := range sortedMapKeys(m) {
+			checkValue(joinKey(key, k), m[k], t.Elem(), issues)
+		}
+	case reflect.Slice:
+		switch v := val.(type) {
+		case []interface{}:
+			for i, item := range v {
+				checkValue(fmt.Sprintf("%s[%d]", key, i), item, t.Elem(), issues)
+			}
+		case string:
+			// 字符串列表允许写成逗号分隔的字符串
+			if t.Elem().Kind() != reflect.String {
+				add("应为列表，实际为字符串")
+			}
+		default:
+			add("应为列表，实际为 %s", describe(val))
+		}
+	case reflect.String:
+		switch val.(type) {
+		case map[string]interface{}, []interface{}:
+			add("应为字符串，实际为 %s", describe(val))
+		}
+	case reflect.Bool:
+		switch v := val.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(v); err != nil {
+				add("应为 true 或 false，实际为 %q", v)
+			}
+		default:
+			add("应为 true 或 false，实际为 %s", describe(val))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := val.(type) {
+		case int, int64:
+		case float64:
+			if v != math.Trunc(v) {
+				add("应为整数，实际为 %v", v)
+			}
+		case string:
+			if _, err := strconv.ParseInt(strings.TrimSpace(v), 0, 64); err != nil {
+				add("应为整数，实际为 %q", v)
+			}
+		default:
+			add("应为整数，实际为 %s", describe(val))
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v := val.(type) {
+		case int, int64, float64:
+		case string:
+			if _, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				add("应为数字，实际为 %q", v)
+			}
+		default:
+			add("应为数字，实际为 %s", describe(val))
+		}
+	}
+}
+
+// checkSemantics 检查类型正确但取值无效的配置
+func checkSemantics(cfg *AppConfig) []Issue {
+	var issues []Issue
+	add := func(key, format string, args ...interface{}) {
+		issues = append(issues, Issue{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	levels := []string{"debug", "info", "warn", "warning", "error"}
+	if !oneOf(cfg.Agent.LogLevel, levels) {
+		add("agent.log_level", "无效的日志级别 %q，可选: debug、info、warn、error", cfg.Agent.LogLevel)
+	}
+	for module, lv := range cfg.Agent.LogModules {
+		if !oneOf(lv, levels) {
+			add("agent.log_modules."+module, "无效的日志级别 %q，可选: debug、info、warn、error", lv)
+		}
+	}
+	if cfg.Agent.LogFormat != "" && !oneOf(cfg.Agent.LogFormat, []string{"text", "json"}) {
+		add("agent.log_format", "无效的日志格式 %q，可选: text、json", cfg.Agent.LogFormat)
+	}
+	if ship := cfg.Agent.LogShip; ship.Enable {
+		switch ship.Protocol {
+		case "syslog":
+			if !strings.HasPrefix(ship.Address, "udp://") && !strings.HasPrefix(ship.Address, "tcp://") {
+				add("agent.log_ship.address", "syslog 地址应为 udp://host:port 或 tcp://host:port，实际为 %q", ship.Address)
+			}
+		case "http":
+			if !strings.HasPrefix(ship.Address, "http://") && !strings.HasPrefix(ship.Address, "https://") {
+				add("agent.log_ship.address", "日志接收地址应为 http:// 或 https:// 开头的 URL，实际为 %q", ship.Address)
+			}
+		default:
+			add("agent.log_ship.protocol", "未知的投递协议 %q，可选: syslog、http", ship.Protocol)
+		}
+	}
+
+	if cfg.Server.Offline.Enable && !cfg.Server.PayloadEncryption.Enable {
+		add("server.offline.enable", "离线模式的数据包使用服务端公钥加密，需同时启用 server.payload_encryption")
+	}
+
+	if st := cfg.Server.Stream; st.Enable && st.URL != "" && !strings.HasPrefix(st.URL, "https://") {
+		add("server.stream.url", "gRPC 流式通道需使用 TLS (HTTP/2)，地址应以 https:// 开头，实际为 %q", st.URL)
+	}
+
+	if cc := cfg.Server.Command; cc.Enable {
+		if cfg.Security.RuleSignature.PublicKey == "" {
+			add("server.command.enable", "服务端指令须校验签名，需配置 security.rule_signature.public_key")
+		}
+		if cc.MaxAge <= 0 {
+			add("server.command.max_age", "指令有效期应大于 0，实际为 %s", cc.MaxAge)
+		}
+		if cc.Poll.Enable && cc.Poll.Interval < 10*time.Second {
+			add("server.command.poll.interval", "拉取周期不应小于 10s，实际为 %s", cc.Poll.Interval)
+		}
+	}
+
+	if qos := cfg.Server.UploadQoS; qos.Enable && qos.BusinessHours.BandwidthKBps > 0 {
+		checkClock := func(key, v string) {
+			if _, err := time.Parse("15:04", strings.TrimSpace(v)); err != nil {
+				add(key, "无效的时间 %q，应为 HH:MM", v)
+			}
+		}
+		checkClock("server.upload_qos.business_hours.start", qos.BusinessHours.Start)
+		checkClock("server.upload_qos.business_hours.end", qos.BusinessHours.End)
+		for i, d := range qos.BusinessHours.Weekdays {
+			if d < 0 || d > 6 {
+				add(fmt.Sprintf("server.upload_qos.business_hours.weekdays[%d]", i), "无效的星期 %d，应为 0 (周日) 到 6", d)
+			}
+		}
+	}
+
+	if wh := cfg.Scanner.Webhook; wh.Enable {
+		for i, ep := range wh.Endpoints {
+			key := fmt.Sprintf("scanner.webhook.endpoints[%d]", i)
+			if !strings.HasPrefix(ep.URL, "http://") && !strings.HasPrefix(ep.URL, "https://") {
+				add(key+".url", "Webhook 地址应为 http:// 或 https:// 开头的 URL，实际为 %q", ep.URL)
+			}
+			if ep.Method != "" && !oneOf(ep.Method, []string{"post", "put"}) {
+				add(key+".method", "不支持的请求方法 %q，可选: POST、PUT", ep.Method)
+			}
+			if ep.SignAlgorithm != "" && !oneOf(ep.SignAlgorithm, []string{"hmac-sha256", "hmac-sm3"}) {
+				add(key+".sign_algorithm", "未知的签名算法 %q，可选: hmac-sha256、hmac-sm3", ep.SignAlgorithm)
+			}
+		}
+	}
+
+	for i, sc := range cfg.Scanner.Schedules {
+		key := fmt.Sprintf("scanner.schedules[%d]", i)
+		if sc.MaxDepth < 0 {
+			add(key+".max_depth", "扫描深度不能为负数")
+		}
+		if sc.MinSizeKB < 0 || sc.MaxSizeMB < 0 {
+			add(key+".min_size_kb", "文件大小不能为负数")
+		} else if sc.MaxSizeMB > 0 && sc.MinSizeKB > sc.MaxSizeMB*1024 {
+			add(key+".min_size_kb", "文件大小下限 %d KB 超过上限 max_size_mb (%d MB)", sc.MinSizeKB, sc.MaxSizeMB)
+		}
+		if sc.NewerThan > 0 && sc.OlderThan > 0 && sc.NewerThan <= sc.OlderThan {
+			add(key+".newer_than", "修改时间窗口为空: newer_than (%s) 应大于 older_than (%s)", sc.NewerThan, sc.OlderThan)
+		}
+	}
+
+	checkCIDRs := func(key string, rules []string) {
+		for i, r := range rules {
+			if err := checkIPOrCIDR(r); err != nil {
+				add(fmt.Sprintf("%s[%d]", key, i), "%v", err)
+			}
+		}
+	}
+	checkCIDRs("security.netguard.whitelist", cfg.Security.NetGuard.Whitelist)
+	checkCIDRs("security.netguard.bandwidth.whitelist", cfg.Security.NetGuard.Bandwidth.Whitelist)
+
+	if pc := cfg.Security.NetGuard.Pcap; pc.Enable && pc.MaxSizeMB > pc.QuotaMB {
+		add("security.netguard.pcap.max_size_mb", "单个抓包文件上限 %d MB 超过抓包目录总量上限 quota_mb (%d MB)", pc.MaxSizeMB, pc.QuotaMB)
+	}
+
+	// 加密项只检查格式，解密需要密钥管理后端，在启动时进行
+	walkStrings("", reflect.ValueOf(cfg).Elem(), func(key, s string) string {
+		if IsEncrypted(s) {
+			if _, err := parseSecret(s); err != nil {
+				add(key, "%v", err)
+			}
+		}
+		return s
+	})
+	return issues
+}
+
+// checkIPOrCIDR 白名单条目应为单个 IP 或 CIDR，与网络白名单的解析规则一致
+func checkIPOrCIDR(s string) error {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		ip, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("无效的 CIDR %q，应为如 10.0.0.0/8、fd00::/8", s)
+		}
+		if !ip.Equal(n.IP) {
+			// 主机位非零仍可使用，但多半是笔误
+			return fmt.Errorf("CIDR %q 的主机位不为零，实际生效的网段为 %s", s, n)
+		}
+		return nil
+	}
+	if net.ParseIP(s) == nil {
+		return fmt.Errorf("无效的 IP 地址 %q，应为单个 IP 或 CIDR (不支持域名与 IP 范围)", s)
+	}
+	return nil
+}
+
+// structFields 结构体的配置键 -> 字段
+func structFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name := configKey(f); name != "" {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// configKey 字段的配置键 (mapstructure 标签，与 viper 一致不区分大小写)
+func configKey(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return strings.ToLower(name)
+}
+
+// suggest 返回编辑距离最近的已知配置键，差距过大时返回空
+func suggest(key string, fields map[string]reflect.StructField) string {
+	best, bestDist := "", len(key)/2+1
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "配置段"
+	case []interface{}:
+		return "列表"
+	case string:
+		return "字符串"
+	case bool:
+		return "布尔值"
+	case int, int64, float64:
+		return "数字"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func oneOf(s string, options []string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, o := range options {
+		if s == o {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	os.WriteFile(path, []byte(`
+agent:
+  log_levle: "debug"
+  log_level: "verbose"
+  log_max_size: "100MB"
+scanner:
+  workers: 4
+  trace:
+    min_duration: "2d"
+  rule_stats:
+    retention: 3600
+security:
+  netguard:
+    whitelist:
+      - "10.0.0.0/8"
+      - "192.168.1.0/33"
+      - "backup.example.com"
+      - "10.1.2.3/16"
+    bandwidth:
+      whitelist: ["::1"]
+unknown_section:
+  a: 1
+`), 0644)
+
+	issues, err := Validate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, i := range issues {
+		got[i.Key] = i.Message
+	}
+	want := map[string]string{
+		"agent.log_levle":                "是否应为 \"log_level\"",
+		"agent.log_max_size":             "应为整数",
+		"scanner.trace.min_duration":     "无效的时间间隔 \"2d\"",
+		"scanner.rule_stats.retention":   "缺少单位",
+		"security.netguard.whitelist[1]": "无效的 CIDR",
+		"security.netguard.whitelist[2]": "无效的 IP 地址",
+		"security.netguard.whitelist[3]": "主机位不为零",
+		"unknown_section":                "未知的配置项",
+	}
+	for key, substr := range want {
+		if !strings.Contains(got[key], substr) {
+			t.Errorf("issue %s = %q, want containing %q", key, got[key], substr)
+		}
+	}
+	// log_level 的类型错误字段之外仍检查取值
+	if !strings.Contains(got["agent.log_level"], "无效的日志级别") || len(issues) != len(want)+1 {
+		t.Errorf("issues = %v", issues)
+	}
+
+	os.WriteFile(path, []byte("agent:\n  log_modules:\n    detector: \"debug\"\n    netguard: \"loud\"\n"), 0644)
+	issues, _ = Validate(path)
+	if len(issues) != 1 || issues[0].Key != "agent.log_modules.netguard" {
+		t.Errorf("issues = %v", issues)
+	}
+
+	os.WriteFile(path, []byte(`
+scanner:
+  schedules:
+    - name: "incident"
+      paths: ["/home"]
+      schedule: "@hourly"
+      min_size_kb: 4096
+      max_size_mb: 1
+      newer_than: "24h"
+      older_than: "48h"
+`), 0644)
+	issues, _ = Validate(path)
+	got = make(map[string]string)
+	for _, i := range issues {
+		got[i.Key] = i.Message
+	}
+	if len(issues) != 2 ||
+		!strings.Contains(got["scanner.schedules[0].min_size_kb"], "超过上限") ||
+		!strings.Contains(got["scanner.schedules[0].newer_than"], "时间窗口为空") {
+		t.Errorf("issues = %v", issues)
+	}
+
+	if _, err := Validate(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Error("Validate(missing) should fail")
+	}
+}
+
+func TestDefaultsYAML(t *testing.T) {
+	data, err := DefaultsYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, substr := range []string{
+		"agent:\n",
+		"  # 日志级别: debug, info, warn, error\n  log_level: \"info\"\n",
+		"  log_max_size: 100  # MB\n",
+		"    flush_interval: \"2s\"\n",
+		"whitelist: [\"127.0.0.1\", \"::1\"]",
+	} {
+		if !strings.Contains(out, substr) {
+			t.Errorf("defaults missing %q", substr)
+		}
+	}
+
+	// 输出的默认配置本身应通过校验
+	path := filepath.Join(t.TempDir(), "defaults.yml")
+	os.WriteFile(path, data, 0644)
+	issues, err := Validate(path)
+	if err != nil || len(issues) != 0 {
+		t.Errorf("Validate(defaults) = %v, %v", issues, err)
+	}
+}
@@ -0,0 +1,225 @@
+package detector
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/budget"
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// detectArchive 展开压缩包逐项检测，首个命中即返回
+// 告警归属压缩包本身 (路径、MD5、大小)，FileSummary 记录命中的包内文件
+// 超出展开配额 (archiveguard) 时停止展开并返回 archiveguard.ErrBomb
+func (m *Manager) detectArchive(ctx context.Context, c *content) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	// 展开期间同时驻留压缩包与单个包内文件，按 maxSharedSize 记账
+	ctx, release, err := budget.Default().Acquire(ctx, budget.ClassArchive, maxSharedSize)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer release()
+
+	// 包内文件再展开的嵌入对象与压缩包共用同一份配额
+	quota, err := archiveguard.FromContext(ctx).Enter()
+	if err != nil {
+		return false, nil, nil, err
+	}
+	ctx = archiveguard.NewContext(ctx, quota)
+
+	var (
+		found   bool
+		record  *model.AlertRecord
+		logItem *model.AlertLogItem
+	)
+
+	// 包内文件的各阶段记录在压缩包的时间线中
+	endArchive := tracing.Begin(ctx, "archive")
+	err = m.walkArchive(c, quota, func(name string, data []byte) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
+		doc := document.FromBytes(name, data, m.textExtractor())
+		defer doc.Close()
+
+		hit, r, l, err := m.detect(ctx, &content{
+			path:   c.path + "!/" + name,
+			name:   doc.Name,
+			size:   doc.Size,
+			md5:    doc.MD5,
+			typ:    doc.Type,
+			data:   data,
+			doc:    doc,
+			nested: true,
+		})
+		if err != nil || !hit {
+			return false, err
+		}
+
+		r.FileSummary = name
+		r.FilePath, r.FileName, r.FileMD5, r.FileSize = c.path, c.name, c.md5, int(c.size)
+		l.FilePath, l.FileName, l.FileMD5 = c.path, c.name, c.md5
+		found, record, logItem = true, r, l
+		return true, nil
+	})
+	endArchive(err)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	return found, record, logItem, nil
+}
+
+// walkArchive 依次读取压缩包内的普通文件，fn 返回 true 时停止
+// 支持 zip、tar、gz (含 tar.gz)；超过 maxSharedSize 的文件跳过，解压量按 quota 记账
+func (m *Manager) walkArchive(c *content, quota *archiveguard.Quota, fn func(name string, data []byte) (bool, error)) error {
+	r, closeFn, err := c.readerAt()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	w := &archiveWalker{fn: fn, quota: quota}
+	switch c.typ.Extension {
+	case "zip":
+		return w.zip(r, c.size)
+	case "tar":
+		return w.tar(io.NewSectionReader(r, 0, c.size), false)
+	case "gz":
+		return w.gzip(io.NewSectionReader(r, 0, c.size), c.size, strings.TrimSuffix(c.name, ".gz"))
+	default:
+		return fmt.Errorf("不支持展开的压缩格式: %s", c.typ.Extension)
+	}
+}
+
+// readerAt 返回内容的随机读取器，磁盘文件在 closeFn 中关闭
+func (c *content) readerAt() (r io.ReaderAt, closeFn func(), err error) {
+	switch {
+	case c.data != nil:
+		return bytes.NewReader(c.data), func() {}, nil
+	case c.doc != nil:
+		return bytes.NewReader(c.doc.Data()), func() {}, nil
+	default:
+		f, err := os.Open(c.path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, func() { f.Close() }, nil
+	}
+}
+
+// archiveWalker 逐项读取压缩包内的文件，解压量由 quota 记账
+type archiveWalker struct {
+	fn func(name string, data []byte) (bool, error)
+	// match 非 nil 时只读取名称匹配的文件 (仅 zip)
+	match func(name string) bool
+	quota *archiveguard.Quota
+	stop  bool
+}
+
+// visit 读取单个文件并回调
+func (w *archiveWalker) visit(name string, r io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(r, maxSharedSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSharedSize {
+		return nil
+	}
+	w.stop, err = w.fn(name, data)
+	return err
+}
+
+func (w *archiveWalker) zip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() || f.UncompressedSize64 > maxSharedSize || w.match != nil && !w.match(f.Name) {
+			continue
+		}
+		rc, err := w.quota.Open(f)
+		if errors.Is(err, archiveguard.ErrBomb) {
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		err = w.visit(f.Name, rc)
+		rc.Close()
+		if err != nil || w.stop {
+			return err
+		}
+	}
+	return nil
+}
+
+// tar 逐项展开，inMemory 表示内容已解压到内存 (tar.gz)，只登记文件数量
+func (w *archiveWalker) tar(r io.Reader, inMemory bool) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxSharedSize {
+			continue
+		}
+
+		var entry io.Reader = tr
+		if inMemory {
+			err = w.quota.Admit(hdr.Name, 0)
+		} else {
+			err = w.quota.Admit(hdr.Name, hdr.Size)
+			entry = w.quota.Reader(hdr.Name, tr, -1)
+		}
+		if err != nil {
+			return err
+		}
+		if err := w.visit(hdr.Name, entry); err != nil || w.stop {
+			return err
+		}
+	}
+}
+
+// gzip 解压后为 tar 时逐项展开，否则作为单个文件检测；compressed 为压缩文件大小，用于检查压缩比
+func (w *archiveWalker) gzip(r io.Reader, compressed int64, name string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	if err := w.quota.Admit(name, 0); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(w.quota.Reader(name, gr, compressed), maxSharedSize+1))
+	if err != nil {
+		return err
+	}
+	if _, err := tar.NewReader(bytes.NewReader(data)).Next(); err == nil {
+		return w.tar(bytes.NewReader(data), true)
+	}
+	if len(data) > maxSharedSize {
+		return nil
+	}
+	if gr.Name != "" {
+		name = gr.Name
+	}
+	w.stop, err = w.fn(name, data)
+	return err
+}
@@ -0,0 +1,119 @@
+package detector
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"linuxFileWatcher/internal/budget"
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/fastread"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// 批量预读参数：不大于 batchReadMaxSize 的文件每 batchReadSize 个一批整体读入
+// (io_uring 一次提交整批读请求)，其余文件由 Detect 自行读取
+const (
+	batchReadSize    = 64
+	batchReadMaxSize = 1024 * 1024
+)
+
+// BatchResult 批量检测中单个文件的结果
+type BatchResult struct {
+	Path    string
+	Found   bool
+	Record  *model.AlertRecord
+	LogItem *model.AlertLogItem
+	Err     error
+}
+
+// batchJob 待检测文件，preread 为 true 时 data 为预读的完整内容
+type batchJob struct {
+	idx     int
+	data    []byte
+	preread bool
+}
+
+// DetectBatch 批量检测文件，结果顺序与 paths 一致
+// 每个文件只读取、识别类型与抽取文本一次，由各子检测器共用；workers 个文件并行，<=0 时为全局预算的并发槽位数
+// ctx 取消后尚未开始的文件返回 ctx 的错误
+func (m *Manager) DetectBatch(ctx context.Context, paths []string, workers int) []BatchResult {
+	results := make([]BatchResult, len(paths))
+	if workers <= 0 {
+		workers = budget.Default().Workers()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan batchJob, batchReadSize)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				r := &results[job.idx]
+				r.Path = paths[job.idx]
+				if err := ctx.Err(); err != nil {
+					r.Err = err
+					continue
+				}
+				if job.preread {
+					r.Found, r.Record, r.LogItem, r.Err = m.detectPreread(ctx, r.Path, job.data)
+					continue
+				}
+				r.Found, r.Record, r.LogItem, r.Err = m.Detect(ctx, r.Path)
+			}
+		}()
+	}
+
+	reader := fastread.NewBatchReader(fastread.Options{
+		QueueDepth:  batchReadSize,
+		MaxFileSize: batchReadMaxSize,
+	})
+	defer reader.Close()
+
+	for start := 0; start < len(paths); start += batchReadSize {
+		end := min(start+batchReadSize, len(paths))
+		if ctx.Err() != nil {
+			for i := start; i < end; i++ {
+				jobs <- batchJob{idx: i}
+			}
+			continue
+		}
+		// 读取失败 (超限、非普通文件等) 的文件交由 Detect 按常规流程处理
+		for i, res := range reader.ReadFiles(paths[start:end]) {
+			jobs <- batchJob{idx: start + i, data: res.Data, preread: res.Err == nil}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// detectPreread 检测批量预读的文件，与 Detect 同样按全局预算排队
+func (m *Manager) detectPreread(ctx context.Context, path string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	ctx, tl := tracing.Start(ctx, path)
+	tl.Annotate("file.size", strconv.Itoa(len(data)))
+	found, record, logItem, err := m.detectPrereadDocument(ctx, path, data)
+	tl.Finish(found, err)
+	return found, record, logItem, err
+}
+
+func (m *Manager) detectPrereadDocument(ctx context.Context, path string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	endQueue := tracing.Begin(ctx, "queue")
+	ctx, release, err := budget.Default().Acquire(ctx, budget.ClassScan, int64(len(data)))
+	endQueue(err)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer release()
+
+	endIdentify := tracing.Begin(ctx, "identify")
+	doc := document.OpenData(path, data, m.textExtractor())
+	endIdentify(nil)
+	return m.detectDocument(ctx, doc)
+}
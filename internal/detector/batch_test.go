@@ -0,0 +1,130 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+)
+
+// sharedExtractor 充当公文版式检测器与共享抽取器，记录抽取次数
+type sharedExtractor struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (e *sharedExtractor) Extract(_ context.Context, doc *document.Document) (*document.Content, error) {
+	e.mu.Lock()
+	e.calls[doc.Path]++
+	e.mu.Unlock()
+	return &document.Content{Text: string(doc.Data())}, nil
+}
+
+func (e *sharedExtractor) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	c, err := doc.Content(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(c.Text, "通知") {
+		return &model.SubDetectResult{IsSecret: true, RuleDesc: "layout"}, nil
+	}
+	return nil, nil
+}
+
+func (e *sharedExtractor) DetectFile(context.Context, string) (*model.SubDetectResult, error) {
+	return nil, errors.New("DetectFile should not be called")
+}
+
+func (e *sharedExtractor) DetectBytes(context.Context, string, []byte) (*model.SubDetectResult, error) {
+	return nil, errors.New("DetectBytes should not be called")
+}
+
+// textKeywordDetector 基于共享文本的关键词检测
+type textKeywordDetector struct{}
+
+func (textKeywordDetector) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	c, err := doc.Content(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(c.Text, "绝密") {
+		return &model.SubDetectResult{IsSecret: true, RuleDesc: "keyword", MatchedText: "绝密"}, nil
+	}
+	return nil, nil
+}
+
+func (textKeywordDetector) DetectFile(context.Context, string) (*model.SubDetectResult, error) {
+	return nil, errors.New("DetectFile should not be called")
+}
+
+func TestManager_DetectBatch(t *testing.T) {
+	ex := &sharedExtractor{calls: make(map[string]int)}
+	m := &Manager{
+		config:           GlobalConfig{EnableLayout: true, EnableKeywords: true},
+		layoutDetector:   ex,
+		keywordsDetector: textKeywordDetector{},
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt": "绝密文件",
+		"b.txt": "普通内容",
+		"c.txt": "关于放假的通知",
+	}
+	var paths []string
+	for name, text := range files {
+		p := filepath.Join(dir, name)
+		os.WriteFile(p, []byte(text), 0644)
+		paths = append(paths, p)
+	}
+	paths = append(paths, filepath.Join(dir, "missing.txt"))
+
+	results := m.DetectBatch(context.Background(), paths, 2)
+	if len(results) != len(paths) {
+		t.Fatalf("结果数 %d, want %d", len(results), len(paths))
+	}
+
+	want := map[string]string{"a.txt": model.ModuleKeywordDetect, "b.txt": "", "c.txt": model.ModuleOfficialFormatDetect}
+	for i, r := range results {
+		if r.Path != paths[i] {
+			t.Errorf("结果顺序错误: %s != %s", r.Path, paths[i])
+		}
+		name := filepath.Base(r.Path)
+		if name == "missing.txt" {
+			if r.Err == nil {
+				t.Errorf("缺失文件应返回错误")
+			}
+			continue
+		}
+		module := ""
+		if r.Found {
+			module = r.Record.DetectModule
+		}
+		if r.Err != nil || module != want[name] {
+			t.Errorf("%s: found = %v, module = %q, err = %v", name, r.Found, module, r.Err)
+		}
+		// b.txt 经过公文与关键词两个检测器，文本只抽取一次
+		if ex.calls[r.Path] != 1 {
+			t.Errorf("%s 抽取 %d 次, want 1", name, ex.calls[r.Path])
+		}
+	}
+}
+
+func TestManager_DetectBatchCanceled(t *testing.T) {
+	m := &Manager{config: GlobalConfig{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := m.DetectBatch(ctx, []string{"a", "b"}, 1)
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, want Canceled", r.Path, r.Err)
+		}
+	}
+}
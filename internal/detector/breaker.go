@@ -0,0 +1,231 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// 熔断默认参数
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 5 * time.Minute
+)
+
+// BreakerConfig 子检测器熔断参数
+// 同一检测模块在同一格式上连续失败 (panic 或解析错误) Threshold 次后熔断，
+// 熔断期间跳过该模块对该格式的检测；Cooldown 后放行一次试探，成功即恢复
+type BreakerConfig struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.Threshold <= 0 {
+		c.Threshold = DefaultBreakerThreshold
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = DefaultBreakerCooldown
+	}
+	return c
+}
+
+// HealthEvent 检测模块健康状态变化
+type HealthEvent struct {
+	Module    string    // 检测模块名
+	Format    string    // 文件格式 (扩展名)
+	Open      bool      // true 为熔断，false 为恢复
+	Failures  int       // 熔断时的连续失败次数
+	LastError string    // 最近一次失败原因
+	Time      time.Time // 状态变化时间
+}
+
+// HealthHandler 接收健康事件，在检测 goroutine 中同步调用，不应阻塞
+type HealthHandler func(HealthEvent)
+
+// breakerKey 熔断粒度：检测模块 + 文件格式，单一格式的畸形文件不影响其他格式
+type breakerKey struct {
+	module string
+	format string
+}
+
+// breakerState 单个 (模块, 格式) 的熔断状态
+type breakerState struct {
+	failures  int
+	lastError string
+	openUntil time.Time // 非零表示已熔断
+	probing   bool      // 冷却结束后已放行一次试探，结果返回前不再放行
+}
+
+// breakers 子检测器熔断器，零值可用
+type breakers struct {
+	mu      sync.Mutex
+	cfg     BreakerConfig
+	states  map[breakerKey]*breakerState
+	handler HealthHandler
+	now     func() time.Time // 测试注入
+}
+
+func (b *breakers) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// allow 是否执行该模块对该格式的检测
+func (b *breakers) allow(key breakerKey) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := b.states[key]
+	if st == nil || st.openUntil.IsZero() {
+		return true
+	}
+	if st.probing || b.clock().Before(st.openUntil) {
+		return false
+	}
+	st.probing = true
+	return true
+}
+
+// record 记录一次检测结果，状态变化时通知健康事件
+func (b *breakers) record(key breakerKey, err error) {
+	var event *HealthEvent
+
+	b.mu.Lock()
+	st := b.states[key]
+	if err == nil {
+		// 成功：清零计数，熔断中的试探成功则恢复
+		if st != nil {
+			if !st.openUntil.IsZero() {
+				event = &HealthEvent{Module: key.module, Format: key.format, Time: b.clock()}
+			}
+			delete(b.states, key)
+		}
+	} else {
+		if st == nil {
+			if b.states == nil {
+				b.states = make(map[breakerKey]*breakerState)
+			}
+			st = &breakerState{}
+			b.states[key] = st
+		}
+		st.failures++
+		st.lastError = err.Error()
+		cfg := b.cfg.withDefaults()
+		if st.probing || (st.openUntil.IsZero() && st.failures >= cfg.Threshold) {
+			// 首次熔断或试探失败，重新计算冷却时间；只在首次熔断时通知
+			now := b.clock()
+			if st.openUntil.IsZero() {
+				event = &HealthEvent{
+					Module:    key.module,
+					Format:    key.format,
+					Open:      true,
+					Failures:  st.failures,
+					LastError: st.lastError,
+					Time:      now,
+				}
+			}
+			st.openUntil = now.Add(cfg.Cooldown)
+			st.probing = false
+		}
+	}
+	handler := b.handler
+	b.mu.Unlock()
+
+	if event != nil && handler != nil {
+		handler(*event)
+	}
+}
+
+// release 结果与检测器无关 (取消、文件不可访问)，不改变计数，只收回试探名额
+func (b *breakers) release(key breakerKey) {
+	b.mu.Lock()
+	if st := b.states[key]; st != nil {
+		st.probing = false
+	}
+	b.mu.Unlock()
+}
+
+// call 在熔断保护下执行子检测器，panic 转为错误；熔断中返回 errBreakerOpen
+func (b *breakers) call(ctx context.Context, key breakerKey, fn func() (*model.SubDetectResult, error)) (res *model.SubDetectResult, err error) {
+	if !b.allow(key) {
+		return nil, errBreakerOpen
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("子检测器 panic，已隔离",
+				"module", key.module,
+				"format", key.format,
+				"panic", r,
+				"stack", string(debug.Stack()),
+			)
+			res, err = nil, fmt.Errorf("%s panic: %v", key.module, r)
+		}
+		switch {
+		case err == nil:
+			b.record(key, nil)
+		case isDetectorFailure(ctx, err):
+			b.record(key, err)
+		default:
+			b.release(key)
+		}
+	}()
+
+	return fn()
+}
+
+// runGuarded 在熔断保护下调用子检测器，熔断粒度为 (检测模块, 文件格式)
+func (m *Manager) runGuarded(ctx context.Context, c *content, module string, d SubDetector) (*model.SubDetectResult, error) {
+	format := c.typ.Extension
+	if format == "" {
+		format = "unknown"
+	}
+	end := tracing.Begin(ctx, "detect."+module)
+	res, err := m.breakers.call(ctx, breakerKey{module: module, format: format}, func() (*model.SubDetectResult, error) {
+		return c.run(ctx, d)
+	})
+	end(err)
+	c.noteBomb(err)
+	return res, err
+}
+
+// errBreakerOpen 检测模块对该格式已熔断
+var errBreakerOpen = errors.New("detector circuit open")
+
+// isDetectorFailure 错误是否计入熔断：取消、超时、文件本身不可访问与压缩炸弹不算检测器故障
+func isDetectorFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, archiveguard.ErrBomb) &&
+		!errors.Is(err, fs.ErrNotExist) &&
+		!errors.Is(err, fs.ErrPermission)
+}
+
+// SetBreakerConfig 设置子检测器熔断参数，已熔断的模块按新冷却时间在下次失败后生效
+func (m *Manager) SetBreakerConfig(cfg BreakerConfig) {
+	m.breakers.mu.Lock()
+	m.breakers.cfg = cfg
+	m.breakers.mu.Unlock()
+}
+
+// SetHealthHandler 设置检测模块熔断与恢复的通知回调
+func (m *Manager) SetHealthHandler(h HealthHandler) {
+	m.breakers.mu.Lock()
+	m.breakers.handler = h
+	m.breakers.mu.Unlock()
+}
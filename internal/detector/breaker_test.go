@@ -0,0 +1,103 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// panicDetector 内容以 "BAD" 开头时 panic，其余按 "绝密" 关键词命中
+type panicDetector struct {
+	calls int
+}
+
+func (d *panicDetector) DetectFile(context.Context, string) (*model.SubDetectResult, error) {
+	return nil, errors.New("DetectFile should not be called")
+}
+
+func (d *panicDetector) DetectBytes(_ context.Context, _ string, data []byte) (*model.SubDetectResult, error) {
+	d.calls++
+	if bytes.HasPrefix(data, []byte("BAD")) {
+		panic("malformed input")
+	}
+	if bytes.Contains(data, []byte("绝密")) {
+		return &model.SubDetectResult{IsSecret: true, RuleDesc: "test", MatchedText: "绝密"}, nil
+	}
+	return nil, nil
+}
+
+func TestManager_BreakerIsolatesPanics(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	keywords := &panicDetector{}
+	var events []HealthEvent
+	m := &Manager{
+		config:           GlobalConfig{EnableKeywords: true},
+		keywordsDetector: keywords,
+		routes:           DefaultRoutes(),
+	}
+	m.breakers.now = func() time.Time { return now }
+	m.SetBreakerConfig(BreakerConfig{Threshold: 3, Cooldown: time.Minute})
+	m.SetHealthHandler(func(ev HealthEvent) { events = append(events, ev) })
+
+	// panic 不向上传播，连续失败达到阈值后熔断
+	for i := 0; i < 3; i++ {
+		if hit, _, _, err := m.DetectBytes(context.Background(), "bad.txt", []byte("BAD 绝密")); hit || err != nil {
+			t.Fatalf("第 %d 次: hit=%v err=%v", i+1, hit, err)
+		}
+	}
+	if len(events) != 1 || !events[0].Open || events[0].Module != model.ModuleKeywordDetect || events[0].Format != "txt" || events[0].Failures != 3 {
+		t.Fatalf("熔断事件 = %+v", events)
+	}
+
+	// 熔断期间跳过该格式，其他格式不受影响
+	calls := keywords.calls
+	if hit, _, _, _ := m.DetectBytes(context.Background(), "a.txt", []byte("绝密")); hit || keywords.calls != calls {
+		t.Errorf("熔断期间不应调用检测器: hit=%v calls=%d", hit, keywords.calls-calls)
+	}
+	if hit, _, _, _ := m.DetectBytes(context.Background(), "a.html", []byte("<html><body>绝密</body></html>")); !hit {
+		t.Error("其他格式不应受熔断影响")
+	}
+
+	// 冷却后试探失败继续熔断，不重复通知
+	now = now.Add(time.Minute)
+	m.DetectBytes(context.Background(), "bad.txt", []byte("BAD"))
+	if hit, _, _, _ := m.DetectBytes(context.Background(), "a.txt", []byte("绝密")); hit || len(events) != 1 {
+		t.Errorf("试探失败后应继续熔断: hit=%v events=%d", hit, len(events))
+	}
+
+	// 再次冷却后试探成功即恢复
+	now = now.Add(time.Minute)
+	if hit, _, _, _ := m.DetectBytes(context.Background(), "a.txt", []byte("绝密")); !hit {
+		t.Error("冷却后试探应放行")
+	}
+	if len(events) != 2 || events[1].Open || events[1].Format != "txt" {
+		t.Fatalf("恢复事件 = %+v", events)
+	}
+}
+
+func TestBreakers_IgnoresNonDetectorErrors(t *testing.T) {
+	var b breakers
+	b.cfg = BreakerConfig{Threshold: 1}
+	key := breakerKey{module: model.ModuleKeywordDetect, format: "pdf"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.call(ctx, key, func() (*model.SubDetectResult, error) { return nil, ctx.Err() })
+	b.call(context.Background(), key, func() (*model.SubDetectResult, error) { return nil, os.ErrNotExist })
+	if !b.allow(key) {
+		t.Fatal("取消与文件不存在不应计入熔断")
+	}
+
+	b.call(context.Background(), key, func() (*model.SubDetectResult, error) { return nil, errors.New("bad xref") })
+	if b.allow(key) {
+		t.Fatal("解析错误应计入熔断")
+	}
+	if _, err := b.call(context.Background(), key, func() (*model.SubDetectResult, error) { return nil, nil }); err != errBreakerOpen {
+		t.Errorf("熔断中 err = %v, want errBreakerOpen", err)
+	}
+}
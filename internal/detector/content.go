@@ -0,0 +1,122 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// MaxContentSize DetectReader 单次读取的内容上限
+const MaxContentSize = 100 * 1024 * 1024
+
+// ErrContentTooLarge 内存内容超过 MaxContentSize
+var ErrContentTooLarge = errors.New("content exceeds maximum detect size")
+
+// ContentDetector 支持直接检测内存内容的子检测器 (可选实现)
+// 未实现的子检测器在检测内存内容时会先写入临时文件
+type ContentDetector interface {
+	// DetectBytes name 为原始文件名，用于按扩展名区分格式，可为空
+	DetectBytes(ctx context.Context, name string, data []byte) (*model.SubDetectResult, error)
+}
+
+// DocumentDetector 支持共享中间表示的子检测器 (可选实现)
+// 同一文件的读取、类型识别与文本抽取只执行一次，实现该接口的子检测器直接使用抽取结果
+type DocumentDetector interface {
+	DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error)
+}
+
+// DetectBytes 检测内存中的内容 (网络载荷、剪贴板、解密缓冲区等)
+// name 为内容的原始名称或来源描述，写入告警的 FilePath，其基名用于识别格式
+func (m *Manager) DetectBytes(ctx context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	ctx, tl := tracing.Start(ctx, name)
+	tl.Annotate("file.size", strconv.Itoa(len(data)))
+
+	endIdentify := tracing.Begin(ctx, "identify")
+	doc := document.FromBytes(name, data, m.textExtractor())
+	endIdentify(nil)
+	defer doc.Close()
+
+	found, record, logItem, err := m.detect(ctx, &content{
+		path: name,
+		name: doc.Name,
+		size: doc.Size,
+		md5:  doc.MD5,
+		typ:  doc.Type,
+		data: data,
+		doc:  doc,
+	})
+	tl.Finish(found, err)
+	return found, record, logItem, err
+}
+
+// DetectReader 读取 r 的全部内容后检测，超过 MaxContentSize 时返回 ErrContentTooLarge
+func (m *Manager) DetectReader(ctx context.Context, name string, r io.Reader) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxContentSize+1))
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if len(data) > MaxContentSize {
+		return false, nil, nil, ErrContentTooLarge
+	}
+	return m.DetectBytes(ctx, name, data)
+}
+
+// content 待检测内容：磁盘文件或内存数据
+type content struct {
+	path string // 文件路径；内存内容为调用方提供的名称
+	name string
+	size int64
+	md5  string
+	typ  filetype.FileType
+
+	data []byte // 非 nil 表示内存内容
+	// 共享中间表示；磁盘文件超过 maxSharedSize 时为 nil，各子检测器按路径各自读取
+	doc *document.Document
+	// 压缩包内的文件，不再展开
+	nested bool
+	// OOXML 嵌入对象的嵌套层数
+	embedDepth int
+	// 展开压缩包、嵌入对象或子检测器解析 ZIP 容器时超出展开配额，检测未命中时作为检测错误返回
+	bomb error
+}
+
+// noteBomb 记录首个压缩炸弹错误
+func (c *content) noteBomb(err error) {
+	if c.bomb == nil && errors.Is(err, archiveguard.ErrBomb) {
+		c.bomb = err
+	}
+}
+
+// onDisk 是否为磁盘上的原始文件 (非内存内容、非压缩包或文档内的文件)
+func (c *content) onDisk() bool {
+	return !c.nested && c.data == nil
+}
+
+// run 调用子检测器检测内容
+func (c *content) run(ctx context.Context, d SubDetector) (*model.SubDetectResult, error) {
+	if c.doc != nil {
+		if dd, ok := d.(DocumentDetector); ok {
+			return dd.DetectDocument(ctx, c.doc)
+		}
+	}
+	if c.data == nil {
+		return d.DetectFile(ctx, c.path)
+	}
+	if cd, ok := d.(ContentDetector); ok {
+		return cd.DetectBytes(ctx, c.path, c.data)
+	}
+
+	// 内存内容落盘后检测，保留原始文件名以便按扩展名识别格式，多个子检测器共用
+	path, err := c.doc.File()
+	if err != nil {
+		return nil, err
+	}
+	return d.DetectFile(ctx, path)
+}
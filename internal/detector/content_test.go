@@ -0,0 +1,119 @@
+package detector
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fileOnlyDetector 仅支持按路径检测，记录收到的路径
+type fileOnlyDetector struct {
+	paths []string
+}
+
+func (d *fileOnlyDetector) DetectFile(_ context.Context, path string) (*model.SubDetectResult, error) {
+	d.paths = append(d.paths, path)
+	return nil, nil
+}
+
+// bytesDetector 支持内存检测，内容含 "绝密" 即命中
+type bytesDetector struct {
+	names []string
+}
+
+func (d *bytesDetector) DetectFile(_ context.Context, path string) (*model.SubDetectResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return d.DetectBytes(context.Background(), path, data)
+}
+
+func (d *bytesDetector) DetectBytes(_ context.Context, name string, data []byte) (*model.SubDetectResult, error) {
+	d.names = append(d.names, name)
+	if bytes.Contains(data, []byte("绝密")) {
+		return &model.SubDetectResult{IsSecret: true, RuleDesc: "test", MatchedText: "绝密"}, nil
+	}
+	return nil, nil
+}
+
+func TestManager_DetectBytes(t *testing.T) {
+	fileOnly := &fileOnlyDetector{}
+	inMemory := &bytesDetector{}
+	m := &Manager{
+		config:           GlobalConfig{EnableHash: true, EnableKeywords: true, CurrentUserName: "alice"},
+		hashDetector:     fileOnly,
+		keywordsDetector: inMemory,
+	}
+
+	hit, record, logItem, err := m.DetectBytes(context.Background(), "clipboard/通知.txt", []byte("绝密★启用前"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hit || record == nil || logItem == nil {
+		t.Fatalf("DetectBytes() hit = %v, record = %v", hit, record)
+	}
+	if record.FilePath != "clipboard/通知.txt" || record.FileName != "通知.txt" || record.FileSize != len("绝密★启用前") {
+		t.Errorf("告警文件信息 = %q %q %d", record.FilePath, record.FileName, record.FileSize)
+	}
+	if record.FileMD5 == "" || record.UserName != "alice" {
+		t.Errorf("告警缺少 MD5 或身份信息: %+v", record)
+	}
+
+	// 仅支持路径的子检测器通过临时文件检测，结束后临时文件被删除
+	if len(fileOnly.paths) != 1 {
+		t.Fatalf("fileOnly 调用 %d 次, want 1", len(fileOnly.paths))
+	}
+	if filepath.Base(fileOnly.paths[0]) != "通知.txt" {
+		t.Errorf("临时文件未保留原始文件名: %s", fileOnly.paths[0])
+	}
+	if _, err := os.Stat(fileOnly.paths[0]); !os.IsNotExist(err) {
+		t.Errorf("临时文件未清理: %v", err)
+	}
+
+	// 支持内存检测的子检测器直接收到原始名称
+	if len(inMemory.names) != 1 || inMemory.names[0] != "clipboard/通知.txt" {
+		t.Errorf("inMemory 收到 %v", inMemory.names)
+	}
+}
+
+func TestManager_DetectReader(t *testing.T) {
+	m := &Manager{
+		config:           GlobalConfig{EnableKeywords: true},
+		keywordsDetector: &bytesDetector{},
+	}
+
+	hit, _, _, err := m.DetectReader(context.Background(), "payload", strings.NewReader("普通内容"))
+	if err != nil || hit {
+		t.Errorf("DetectReader() = %v, %v", hit, err)
+	}
+}
+
+func TestManager_DetectMediaSkipsTextDetectors(t *testing.T) {
+	fileOnly := &fileOnlyDetector{}
+	inMemory := &bytesDetector{}
+	m := &Manager{
+		config:           GlobalConfig{EnableHash: true, EnableKeywords: true},
+		hashDetector:     fileOnly,
+		keywordsDetector: inMemory,
+		routes:           DefaultRoutes(),
+	}
+
+	// MP3 (ID3) 中恰好包含关键词字节，不应交给关键词检测
+	data := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), []byte("绝密")...)
+	hit, _, _, err := m.DetectBytes(context.Background(), "a.mp3", data)
+	if err != nil || hit {
+		t.Fatalf("DetectBytes() hit = %v, err = %v", hit, err)
+	}
+	if len(inMemory.names) != 0 {
+		t.Errorf("音频文件不应执行关键词检测: %v", inMemory.names)
+	}
+	if len(fileOnly.paths) != 1 {
+		t.Errorf("哈希检测调用 %d 次, want 1", len(fileOnly.paths))
+	}
+}
@@ -0,0 +1,39 @@
+package detector
+
+import (
+	"context"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/procinfo"
+)
+
+// processKey 进程信息在 context 中的键
+type processKey struct{}
+
+// WithProcess 附加写入文件的进程信息，命中时写入告警记录
+func WithProcess(ctx context.Context, p *model.ProcessInfo) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, processKey{}, p)
+}
+
+// LookupProcess 根据 fanotify 事件中的 PID 读取写入进程信息，结果通过 WithProcess 附加到检测上下文
+// 需在事件到达后尽快调用 (扫描任务排队前)，进程退出后 /proc/<pid> 将不可读
+func LookupProcess(pid int) *model.ProcessInfo {
+	if pid <= 0 {
+		return nil
+	}
+	p, err := procinfo.Lookup(pid)
+	if err != nil {
+		// 进程已退出时至少保留 PID
+		p = &model.ProcessInfo{PID: pid}
+	}
+	return p
+}
+
+// ProcessFromContext 读取进程信息，不存在时返回 nil
+func ProcessFromContext(ctx context.Context) *model.ProcessInfo {
+	p, _ := ctx.Value(processKey{}).(*model.ProcessInfo)
+	return p
+}
@@ -0,0 +1,159 @@
+// Package document 检测流水线的共享中间表示
+// 每个文件只读取、识别类型与抽取文本一次，结果由各子检测器共用，
+// 避免密级标志、公文版式、脚本规则等子检测器各自重复打开与解析同一文件
+package document
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"linuxFileWatcher/internal/detector/govcheck/extractor"
+	"linuxFileWatcher/internal/fastread"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// ErrUnsupported 抽取器不支持该格式 (或未配置抽取器)
+var ErrUnsupported = errors.New("document: unsupported format")
+
+// Content 文本抽取结果
+type Content struct {
+	// 抽取的文本，区分页面的格式在页之间插入分页符 (PageBreak)
+	Text string
+	// 版式特征，格式不提供版式信息时为 nil
+	Style *extractor.StyleFeatures
+	// 解析超出内存预算被截断，文本不完整
+	Partial bool
+}
+
+// Extractor 文本抽取器 (由公文版式检测器实现)
+type Extractor interface {
+	Extract(ctx context.Context, doc *Document) (*Content, error)
+}
+
+// Document 单个待检测文件的中间表示
+// 原始内容常驻内存，文本在首次调用 Content 时抽取并缓存，可被多个子检测器并发读取
+type Document struct {
+	// 文件路径；内存内容为调用方提供的来源描述
+	Path string
+	Name string
+	Size int64
+	MD5  string
+	// 按魔数、内容特征与扩展名识别的文件类型
+	Type filetype.FileType
+
+	data      []byte
+	onDisk    bool
+	extractor Extractor
+
+	once    sync.Once
+	content *Content
+	err     error
+
+	mu      sync.Mutex
+	tmpDir  string
+	spilled string
+}
+
+// Open 读取磁盘文件并构建中间表示，调用方需确认文件大小可整体读入内存
+func Open(path string, ex Extractor) (*Document, error) {
+	data, err := fastread.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenData(path, data, ex), nil
+}
+
+// OpenData 以已读入内存的磁盘文件内容构建中间表示 (批量预读)
+func OpenData(path string, data []byte, ex Extractor) *Document {
+	doc := FromBytes(path, data, ex)
+	doc.onDisk = true
+	return doc
+}
+
+// FromBytes 以内存内容构建中间表示，name 的基名用于识别格式
+func FromBytes(name string, data []byte, ex Extractor) *Document {
+	sum := md5.Sum(data)
+	return &Document{
+		Path:      name,
+		Name:      filepath.Base(name),
+		Size:      int64(len(data)),
+		MD5:       hex.EncodeToString(sum[:]),
+		Type:      filetype.DetectContentType(name, data),
+		data:      data,
+		extractor: ex,
+	}
+}
+
+// Data 原始内容，调用方不得修改
+func (d *Document) Data() []byte {
+	return d.data
+}
+
+// OnDisk Path 是否为可直接读取的磁盘文件
+func (d *Document) OnDisk() bool {
+	return d.onDisk
+}
+
+// Content 抽取文本与版式特征，仅在首次调用时执行，之后返回缓存结果 (包括错误)
+// 首次调用的 ctx 决定抽取的超时
+func (d *Document) Content(ctx context.Context) (*Content, error) {
+	d.once.Do(func() {
+		if d.extractor == nil {
+			d.err = ErrUnsupported
+			return
+		}
+		end := tracing.Begin(ctx, "extract")
+		d.content, d.err = d.extractor.Extract(ctx, d)
+		if d.err == nil && d.content == nil {
+			d.err = ErrUnsupported
+		}
+		end(d.err)
+	})
+	return d.content, d.err
+}
+
+// File 返回可按路径读取的文件：磁盘文件返回原路径，内存内容写入临时文件 (保留原始文件名，多次调用共用)
+func (d *Document) File() (string, error) {
+	if d.onDisk {
+		return d.Path, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.spilled != "" {
+		return d.spilled, nil
+	}
+
+	dir, err := os.MkdirTemp("", "detect-")
+	if err != nil {
+		return "", err
+	}
+	d.tmpDir = dir
+
+	name := d.Name
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "content"
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, d.data, 0600); err != nil {
+		return "", err
+	}
+	d.spilled = path
+	return path, nil
+}
+
+// Close 删除 File 创建的临时文件
+func (d *Document) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.tmpDir != "" {
+		_ = os.RemoveAll(d.tmpDir)
+		d.tmpDir, d.spilled = "", ""
+	}
+}
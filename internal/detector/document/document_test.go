@@ -0,0 +1,86 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// countingExtractor 记录抽取次数
+type countingExtractor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *countingExtractor) Extract(_ context.Context, doc *Document) (*Content, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+	return &Content{Text: string(doc.Data())}, nil
+}
+
+func TestDocument_ContentOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "通知.txt")
+	os.WriteFile(path, []byte("关于开展检查工作的通知"), 0644)
+
+	ex := &countingExtractor{}
+	doc, err := Open(path, ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer doc.Close()
+
+	if doc.Name != "通知.txt" || doc.Size != int64(len("关于开展检查工作的通知")) || doc.MD5 == "" || doc.Type.Extension != "txt" {
+		t.Errorf("Open() = %+v", doc)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c, err := doc.Content(context.Background()); err != nil || c.Text != "关于开展检查工作的通知" {
+				t.Errorf("Content() = %+v, %v", c, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if ex.calls != 1 {
+		t.Errorf("抽取 %d 次, want 1", ex.calls)
+	}
+
+	if p, err := doc.File(); err != nil || p != path {
+		t.Errorf("磁盘文件 File() = %q, %v", p, err)
+	}
+}
+
+func TestDocument_NoExtractor(t *testing.T) {
+	doc := FromBytes("a.txt", []byte("x"), nil)
+	if _, err := doc.Content(context.Background()); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Content() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestDocument_FileSpill(t *testing.T) {
+	doc := FromBytes("clipboard/通知.txt", []byte("绝密"), nil)
+
+	p1, err := doc.File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, _ := doc.File()
+	if p1 != p2 || filepath.Base(p1) != "通知.txt" {
+		t.Errorf("File() = %q, %q", p1, p2)
+	}
+	if data, _ := os.ReadFile(p1); string(data) != "绝密" {
+		t.Errorf("临时文件内容 = %q", data)
+	}
+
+	doc.Close()
+	if _, err := os.Stat(p1); !os.IsNotExist(err) {
+		t.Errorf("临时文件未清理: %v", err)
+	}
+}
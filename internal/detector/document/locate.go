@@ -0,0 +1,123 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// PageBreak 抽取文本中的分页符，区分页面的格式 (PDF) 在页之间插入 "\n\f\n"
+const PageBreak = '\f'
+
+// 子检测器记录的命中位置上限与上下文长度 (字符数)，由检测器管理器按配置进一步截取
+const (
+	MaxLocations    = 100
+	MaxContextChars = 200
+)
+
+// Locator 计算命中内容在文本中的位置
+// 按偏移递增顺序调用 Locate 时只扫描一遍文本；偏移回退时从头重新扫描
+type Locator struct {
+	text  string
+	paged bool
+
+	pos    int  // 已扫描到的字节偏移
+	chars  int  // pos 之前的字符数
+	page   int  // pos 所在页码
+	para   int  // pos 所在页内已出现的段落数
+	inLine bool // pos 所在行是否已出现非空白字符
+}
+
+// NewLocator 创建文本的位置计算器
+func NewLocator(text string) *Locator {
+	l := &Locator{text: text, paged: strings.IndexRune(text, PageBreak) >= 0}
+	l.reset()
+	return l
+}
+
+func (l *Locator) reset() {
+	l.pos, l.chars, l.page, l.para, l.inLine = 0, 0, 1, 0, false
+}
+
+// Locate 计算字节区间 [start, end) 的位置，附带前后各 contextChars 个字符的上下文
+// contextChars <= 0 时不附带上下文
+func (l *Locator) Locate(start, end, contextChars int) model.MatchLocation {
+	start = max(0, min(start, len(l.text)))
+	end = max(start, min(end, len(l.text)))
+	if start < l.pos {
+		l.reset()
+	}
+	for l.pos < start {
+		r, size := utf8.DecodeRuneInString(l.text[l.pos:])
+		switch {
+		case r == PageBreak:
+			l.page++
+			l.para, l.inLine = 0, false
+		case r == '\n':
+			l.inLine = false
+		case !l.inLine && !unicode.IsSpace(r):
+			l.para++
+			l.inLine = true
+		}
+		l.pos += size
+		l.chars++
+	}
+
+	para := l.para
+	if !l.inLine {
+		// 命中内容位于新段落的开头
+		para++
+	}
+	loc := model.MatchLocation{
+		ByteOffset: start,
+		ByteLength: end - start,
+		CharOffset: l.chars,
+		CharLength: utf8.RuneCountInString(l.text[start:end]),
+		Paragraph:  para,
+		Text:       l.text[start:end],
+	}
+	if l.paged {
+		loc.Page = l.page
+	}
+	if contextChars > 0 {
+		loc.Before, loc.After = Context(l.text, start, end, contextChars)
+	}
+	return loc
+}
+
+// Context 字节区间 [start, end) 前后各 n 个字符，不跨越分页符
+func Context(text string, start, end, n int) (before, after string) {
+	i := start
+	for c := 0; c < n && i > 0; c++ {
+		r, size := utf8.DecodeLastRuneInString(text[:i])
+		if r == PageBreak {
+			break
+		}
+		i -= size
+	}
+	j := end
+	for c := 0; c < n && j < len(text); c++ {
+		r, size := utf8.DecodeRuneInString(text[j:])
+		if r == PageBreak {
+			break
+		}
+		j += size
+	}
+	return text[i:start], text[end:j]
+}
+
+// TrimContext 将上下文截取为前后各 n 个字符，n <= 0 时去掉上下文
+func TrimContext(loc *model.MatchLocation, n int) {
+	if n <= 0 {
+		loc.Before, loc.After = "", ""
+		return
+	}
+	if r := []rune(loc.Before); len(r) > n {
+		loc.Before = string(r[len(r)-n:])
+	}
+	if r := []rune(loc.After); len(r) > n {
+		loc.After = string(r[:n])
+	}
+}
@@ -0,0 +1,57 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestLocator(t *testing.T) {
+	text := "关于开展检查的通知\n\n各单位：\n  项目编号 AB-1 已下发\n\f\n第二页 AB-2 内容"
+	l := NewLocator(text)
+
+	at := func(s string, n int) model.MatchLocation {
+		i := strings.Index(text, s)
+		return l.Locate(i, i+len(s), n)
+	}
+	got := at("AB-1", 5)
+	want := model.MatchLocation{
+		ByteOffset: strings.Index(text, "AB-1"), ByteLength: 4,
+		CharOffset: 23, CharLength: 4,
+		Page: 1, Paragraph: 3,
+		Text: "AB-1", Before: "项目编号 ", After: " 已下发\n",
+	}
+	if got != want {
+		t.Errorf("Locate(AB-1) = %+v, want %+v", got, want)
+	}
+
+	// 下一页，上下文不跨越分页符
+	got = at("AB-2", 10)
+	if got.Page != 2 || got.Paragraph != 1 || got.Before != "\n第二页 " || got.After != " 内容" {
+		t.Errorf("Locate(AB-2) = %+v", got)
+	}
+
+	// 偏移回退时重新扫描；位于段落开头
+	got = at("各单位", 0)
+	if got.Page != 1 || got.Paragraph != 2 || got.CharOffset != 11 || got.Before != "" {
+		t.Errorf("Locate(各单位) = %+v", got)
+	}
+
+	// 不区分页面的文本没有页码
+	if got := NewLocator("甲\n乙").Locate(4, 7, 0); got.Page != 0 || got.Paragraph != 2 || got.Text != "乙" {
+		t.Errorf("unpaged = %+v", got)
+	}
+}
+
+func TestTrimContext(t *testing.T) {
+	loc := model.MatchLocation{Before: "一二三四五", After: "六七八九十"}
+	TrimContext(&loc, 2)
+	if loc.Before != "四五" || loc.After != "六七" {
+		t.Errorf("TrimContext(2) = %+v", loc)
+	}
+	TrimContext(&loc, 0)
+	if loc.Before != "" || loc.After != "" {
+		t.Errorf("TrimContext(0) = %+v", loc)
+	}
+}
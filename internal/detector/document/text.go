@@ -0,0 +1,76 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/govcheck/processor"
+	"linuxFileWatcher/internal/model"
+)
+
+// MaxTextSize 文本类子检测器 (关键词、个人信息、指纹、精确数据匹配) 参与检测的文本上限 (字节)，超出部分不检测
+const MaxTextSize = 16 * 1024 * 1024
+
+// TextDetector 只检测文本内容的子检测器
+type TextDetector interface {
+	DetectText(ctx context.Context, text string) (*model.SubDetectResult, error)
+}
+
+// DetectTextFile 读取文件前 MaxTextSize 字节，按纯文本检测；非文本内容返回未命中
+func DetectTextFile(ctx context.Context, d TextDetector, filePath string) (*model.SubDetectResult, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, MaxTextSize))
+	if err != nil {
+		return nil, err
+	}
+	text, ok := PlainText(data)
+	if !ok {
+		return &model.SubDetectResult{}, nil
+	}
+	return d.DetectText(ctx, text)
+}
+
+// DetectTextDocument 使用检测流水线共享抽取的文本，抽取器不支持的格式按纯文本处理
+func DetectTextDocument(ctx context.Context, d TextDetector, doc *Document) (*model.SubDetectResult, error) {
+	content, err := doc.Content(ctx)
+	if err == nil {
+		return d.DetectText(ctx, content.Text)
+	}
+	if !errors.Is(err, ErrUnsupported) {
+		return nil, err
+	}
+	text, ok := PlainText(doc.Data())
+	if !ok {
+		return &model.SubDetectResult{}, nil
+	}
+	return d.DetectText(ctx, text)
+}
+
+// PlainText 不含 NUL 字节的内容视为文本 (带 BOM 的 UTF-16 除外)，按检测到的字符集 (GBK、Big5 等) 转换为 UTF-8
+// 国内办公环境导出的 TXT、CSV 常为 GBK 编码，直接按 UTF-8 处理会丢弃全部中文
+func PlainText(data []byte) (string, bool) {
+	if len(data) == 0 {
+		return "", false
+	}
+	utf16 := bytes.HasPrefix(data, []byte{0xFF, 0xFE}) || bytes.HasPrefix(data, []byte{0xFE, 0xFF})
+	if !utf16 && bytes.IndexByte(data[:min(len(data), 8192)], 0) >= 0 {
+		return "", false
+	}
+	return strings.ToValidUTF8(processor.DecodeText(data, ""), ""), true
+}
+
+// TruncateText 截取文本前 MaxTextSize 字节，不在 UTF-8 字符中间截断
+func TruncateText(text string) string {
+	if len(text) <= MaxTextSize {
+		return text
+	}
+	return strings.ToValidUTF8(text[:MaxTextSize], "")
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestPlainText(t *testing.T) {
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("客户名单：张伟，李娜"))
+	utf16, _ := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().Bytes([]byte("机密文件"))
+
+	tests := []struct {
+		name   string
+		data   []byte
+		want   string
+		wantOK bool
+	}{
+		{"UTF-8", []byte("机密文件"), "机密文件", true},
+		{"GBK", gbk, "客户名单：张伟，李娜", true},
+		{"带 BOM 的 UTF-16", utf16, "机密文件", true},
+		{"二进制", []byte("机密\x00文件"), "", false},
+		{"空内容", nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PlainText(tt.data)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("PlainText() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// textFunc 以函数实现 TextDetector
+type textFunc func(text string) *model.SubDetectResult
+
+func (f textFunc) DetectText(_ context.Context, text string) (*model.SubDetectResult, error) {
+	return f(text), nil
+}
+
+func TestDetectTextFile(t *testing.T) {
+	var got string
+	d := textFunc(func(text string) *model.SubDetectResult {
+		got = text
+		return &model.SubDetectResult{IsSecret: true}
+	})
+
+	path := filepath.Join(t.TempDir(), "名单.csv")
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("姓名,电话\n张伟,13800138000\n"))
+	os.WriteFile(path, gbk, 0644)
+	if res, err := DetectTextFile(context.Background(), d, path); err != nil || !res.IsSecret || got != "姓名,电话\n张伟,13800138000\n" {
+		t.Errorf("DetectTextFile() = %+v, %v, text %q", res, err, got)
+	}
+
+	// 抽取器不支持的格式按纯文本处理
+	got = ""
+	doc := FromBytes("名单.csv", gbk, nil)
+	if res, err := DetectTextDocument(context.Background(), d, doc); err != nil || !res.IsSecret || got == "" {
+		t.Errorf("DetectTextDocument() = %+v, %v, text %q", res, err, got)
+	}
+}
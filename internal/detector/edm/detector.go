@@ -0,0 +1,219 @@
+package edm
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+)
+
+// 默认阈值
+const (
+	DefaultMinColumns = 2
+	DefaultMinRows    = 1
+)
+
+// Thresholds 判定阈值，规则中填写的阈值优先
+type Thresholds struct {
+	// 同一行出现在文档中的列数下限 (行内非空列更少时要求全部出现)，<=0 使用 DefaultMinColumns
+	MinColumns int
+	// 命中的不同行数下限，<=0 使用 DefaultMinRows
+	MinRows int
+}
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.MinColumns <= 0 {
+		t.MinColumns = DefaultMinColumns
+	}
+	if t.MinRows <= 0 {
+		t.MinRows = DefaultMinRows
+	}
+	return t
+}
+
+// table 解析后的数据表
+type table struct {
+	cfg        model.EDMDetectRule
+	minColumns int
+	minRows    int
+	// 每行非空单元格数
+	filled []uint8
+}
+
+// cell 倒排索引项
+type cell struct {
+	table int32
+	row   int32
+	col   uint8
+}
+
+// saltGroup 盐值相同的数据表共用一次候选值哈希
+type saltGroup struct {
+	salt  []byte
+	index map[uint64][]cell
+}
+
+// Detector 精确数据匹配检测器，实现 detector.SubDetector
+type Detector struct {
+	tables   []*table
+	groups   []*saltGroup
+	withText bool // 是否有文本列，决定是否生成中文 n-gram 候选
+}
+
+// New 解析策略中的全部数据表，任一规则无效时返回错误
+func New(cfg *model.EDMDetectConfig, th Thresholds) (*Detector, error) {
+	th = th.withDefaults()
+	d := &Detector{}
+	if cfg == nil {
+		return d, nil
+	}
+	groups := make(map[string]*saltGroup)
+	for _, rc := range cfg.Rules {
+		if len(rc.Columns) == 0 || len(rc.Columns) > maxColumns {
+			return nil, fmt.Errorf("rule %d: %w: %d columns", rc.RuleID, ErrInvalidRule, len(rc.Columns))
+		}
+		salt, err := decodeSalt(rc.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rc.RuleID, err)
+		}
+		hashes, err := decodeTable(rc.RuleContent, len(rc.Columns))
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rc.RuleID, err)
+		}
+		for _, c := range rc.Columns {
+			if c == ColumnText {
+				d.withText = true
+			}
+		}
+
+		t := &table{cfg: rc, minColumns: th.MinColumns, minRows: th.MinRows}
+		if rc.MinColumns > 0 {
+			t.minColumns = rc.MinColumns
+		}
+		if rc.MinRows > 0 {
+			t.minRows = rc.MinRows
+		}
+
+		g, ok := groups[rc.Salt]
+		if !ok {
+			g = &saltGroup{salt: salt, index: make(map[uint64][]cell)}
+			groups[rc.Salt] = g
+			d.groups = append(d.groups, g)
+		}
+		idx := int32(len(d.tables))
+		cols := len(rc.Columns)
+		t.filled = make([]uint8, len(hashes)/cols)
+		for i, h := range hashes {
+			if h == 0 {
+				continue
+			}
+			row := i / cols
+			t.filled[row]++
+			g.index[h] = append(g.index[h], cell{table: idx, row: int32(row), col: uint8(i % cols)})
+		}
+		d.tables = append(d.tables, t)
+	}
+	return d, nil
+}
+
+// Len 数据表数量
+func (d *Detector) Len() int {
+	return len(d.tables)
+}
+
+// DetectFile 检测文本文件，只读取前 document.MaxTextSize 字节；非文本格式由 DetectDocument 使用共享抽取的文本检测
+func (d *Detector) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	return document.DetectTextFile(ctx, d, filePath)
+}
+
+// DetectDocument 使用检测流水线共享抽取的文本，抽取器不支持的格式按纯文本处理
+func (d *Detector) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	return document.DetectTextDocument(ctx, d, doc)
+}
+
+// rowKey 命中行
+type rowKey struct {
+	table int32
+	row   int32
+}
+
+// DetectText 提取候选值并匹配数据表，多个数据表命中时取命中行数最多的一个
+func (d *Detector) DetectText(ctx context.Context, text string) (*model.SubDetectResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(d.tables) == 0 {
+		return &model.SubDetectResult{}, nil
+	}
+	text = document.TruncateText(text)
+
+	cands := candidates(text, d.withText)
+	hits := make(map[rowKey]uint64)      // 行 -> 命中列位图
+	rowSample := make(map[rowKey]string) // 行 -> 首个命中的原文
+	seen := make(map[string]bool, len(cands))
+	for _, g := range d.groups {
+		clear(seen)
+		for _, c := range cands {
+			if seen[c.value] {
+				continue
+			}
+			seen[c.value] = true
+			for _, e := range g.index[Hash(g.salt, c.value)] {
+				k := rowKey{e.table, e.row}
+				hits[k] |= 1 << e.col
+				if _, ok := rowSample[k]; !ok {
+					rowSample[k] = c.raw
+				}
+			}
+		}
+	}
+
+	rows := make([]int, len(d.tables))
+	cols := make([]uint64, len(d.tables))
+	sample := make([]string, len(d.tables))
+	for k, mask := range hits {
+		t := d.tables[k.table]
+		need := min(t.minColumns, int(t.filled[k.row]))
+		if bits.OnesCount64(mask) >= need {
+			rows[k.table]++
+			cols[k.table] |= mask
+			if sample[k.table] == "" {
+				sample[k.table] = rowSample[k]
+			}
+		}
+	}
+
+	best := -1
+	for i, t := range d.tables {
+		if rows[i] >= t.minRows && (best < 0 || rows[i] > rows[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return &model.SubDetectResult{}, nil
+	}
+
+	t := d.tables[best]
+	var names []string
+	for i, c := range t.cfg.Columns {
+		if cols[best]&(1<<i) != 0 {
+			names = append(names, c)
+		}
+	}
+	desc := t.cfg.RuleDesc
+	if desc == "" {
+		desc = "结构化敏感数据匹配"
+	}
+	return &model.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: model.SecretLevel(t.cfg.SensitivityLevel),
+		RuleID:      t.cfg.RuleID,
+		RuleDesc:    desc,
+		MatchedText: Mask(strings.TrimSpace(sample[best])),
+		ContextText: fmt.Sprintf("数据表命中 %d 行，命中列: %s", rows[best], strings.Join(names, ", ")),
+		AlertType:   int(model.AlertTypeOther),
+	}, nil
+}
@@ -0,0 +1,204 @@
+// Package edm 精确数据匹配 (Exact Data Match) 检测
+// 服务端将结构化敏感数据表 (身份证号、手机号、银行卡号等) 的每个单元格规范化后加盐计算 HMAC，
+// 只下发哈希表 (策略 edm_detect)，终端不保存原始数据。检测时对抽取文本中的候选值做相同的规范化与哈希，
+// 同一行有足够多的列出现在文档中即判定命中，可发现从业务数据库导出的数据泄露
+package edm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 列类型，决定单元格与文本候选值的规范化方式
+const (
+	ColumnIDCard   = "id_card"   // 身份证号
+	ColumnPhone    = "phone"     // 手机号
+	ColumnBankCard = "bank_card" // 银行卡号
+	ColumnEmail    = "email"     // 电子邮箱
+	ColumnNumber   = "number"    // 其他数字编号 (账号、工号等)
+	ColumnText     = "text"      // 文本 (姓名等)
+)
+
+// maxColumns 单个数据表的列数上限
+const maxColumns = 64
+
+// ErrInvalidRule 规则内容无法解析
+var ErrInvalidRule = errors.New("edm: invalid rule")
+
+// Normalize 按列类型规范化单元格，服务端生成哈希表与终端检测使用相同的规则
+func Normalize(column, value string) string {
+	value = strings.TrimSpace(value)
+	switch column {
+	case ColumnIDCard:
+		return strings.ToUpper(digitsOnly(value, true))
+	case ColumnPhone:
+		d := digitsOnly(value, false)
+		if len(d) == 13 && strings.HasPrefix(d, "86") {
+			d = d[2:]
+		}
+		return d
+	case ColumnBankCard, ColumnNumber:
+		return digitsOnly(value, false)
+	case ColumnEmail:
+		return strings.ToLower(value)
+	default:
+		return strings.ToLower(strings.Join(strings.Fields(value), ""))
+	}
+}
+
+// digitsOnly 只保留数字，keepX 时保留 X (身份证校验位)
+func digitsOnly(s string, keepX bool) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' || keepX && (r == 'x' || r == 'X') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Hash 规范化后的值的哈希 (HMAC-SHA256 前 8 字节)，空值返回 0
+func Hash(salt []byte, normalized string) uint64 {
+	if normalized == "" {
+		return 0
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(normalized))
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+// EncodeTable 生成规则内容：各行按列顺序排列的大端哈希的 Base64，空单元格为 0
+func EncodeTable(salt []byte, columns []string, rows [][]string) (string, error) {
+	if len(columns) == 0 || len(columns) > maxColumns {
+		return "", fmt.Errorf("%w: %d columns", ErrInvalidRule, len(columns))
+	}
+	buf := make([]byte, 0, len(rows)*len(columns)*8)
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return "", fmt.Errorf("%w: row %d has %d cells", ErrInvalidRule, i, len(row))
+		}
+		for j, cell := range row {
+			buf = binary.BigEndian.AppendUint64(buf, Hash(salt, Normalize(columns[j], cell)))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// decodeTable 解析规则内容，返回按行排列的哈希
+func decodeTable(content string, columns int) ([]uint64, error) {
+	buf, err := base64.StdEncoding.DecodeString(content)
+	if err != nil || len(buf) == 0 || len(buf)%(columns*8) != 0 {
+		return nil, fmt.Errorf("%w: table content", ErrInvalidRule)
+	}
+	out := make([]uint64, len(buf)/8)
+	for i := range out {
+		out[i] = binary.BigEndian.Uint64(buf[i*8:])
+	}
+	return out, nil
+}
+
+// decodeSalt 解析十六进制盐值
+func decodeSalt(s string) ([]byte, error) {
+	salt, err := hex.DecodeString(s)
+	if err != nil || len(salt) < 8 {
+		return nil, fmt.Errorf("%w: salt must be at least 8 bytes of hex", ErrInvalidRule)
+	}
+	return salt, nil
+}
+
+var (
+	// 数字串，允许以单个空格或连字符分组 (如 "138-0013-8000"、"6222 0212 3456 7890")
+	numberPattern = regexp.MustCompile(`\+?[0-9][0-9]*(?:[ \-][0-9]+)*[Xx]?`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)+`)
+)
+
+// candidate 文本中的候选值
+type candidate struct {
+	value string // 规范化后的值
+	raw   string // 原文，用于告警中的脱敏展示
+}
+
+// candidates 提取文本中可能出现在数据表中的值
+// 分组的数字同时产生整体与各组两种候选，text 为 true 时对中文连续片段生成 2-4 字的候选 (姓名等)
+func candidates(text string, withText bool) []candidate {
+	var out []candidate
+	add := func(value, raw string) {
+		if value != "" {
+			out = append(out, candidate{value: value, raw: raw})
+		}
+	}
+
+	for _, m := range numberPattern.FindAllString(text, -1) {
+		whole := digitsOnly(m, true)
+		if len(whole) < 6 {
+			continue
+		}
+		add(strings.ToUpper(whole), m)
+		if d := digitsOnly(m, false); d != whole {
+			add(d, m)
+		}
+		if len(whole) == 13 && strings.HasPrefix(whole, "86") {
+			add(whole[2:], m)
+		}
+		if groups := strings.FieldsFunc(m, func(r rune) bool { return r == ' ' || r == '-' }); len(groups) > 1 {
+			for _, g := range groups {
+				if d := digitsOnly(g, true); len(d) >= 6 {
+					add(strings.ToUpper(d), g)
+				}
+			}
+		}
+	}
+	for _, m := range emailPattern.FindAllString(text, -1) {
+		add(strings.ToLower(m), m)
+	}
+	if !withText {
+		return out
+	}
+
+	// 拉丁字母单词与中文 n-gram
+	var run []rune
+	flush := func() {
+		for n := 2; n <= 4; n++ {
+			for i := 0; i+n <= len(run); i++ {
+				s := string(run[i : i+n])
+				add(s, s)
+			}
+		}
+		run = run[:0]
+	}
+	for _, f := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if !unicode.Is(unicode.Han, []rune(f)[0]) {
+			add(strings.ToLower(f), f)
+			continue
+		}
+		for _, r := range f {
+			if unicode.Is(unicode.Han, r) {
+				run = append(run, r)
+				continue
+			}
+			flush()
+		}
+		flush()
+	}
+	return out
+}
+
+// Mask 脱敏展示，保留前 3 位与后 4 位
+func Mask(s string) string {
+	r := []rune(s)
+	if len(r) <= 7 {
+		if len(r) <= 1 {
+			return "*"
+		}
+		return string(r[:1]) + strings.Repeat("*", len(r)-1)
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-7) + string(r[len(r)-4:])
+}
@@ -0,0 +1,164 @@
+package edm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"linuxFileWatcher/internal/model"
+)
+
+var testSalt = []byte("0123456789abcdef")
+
+// 测试用数据表: 姓名 / 身份证号 / 手机号
+var (
+	testColumns = []string{ColumnText, ColumnIDCard, ColumnPhone}
+	testRows    = [][]string{
+		{"张伟", "11010519491231002X", "138-0013-8000"},
+		{"李娜", "440524188001010014", "+86 139 1234 5678"},
+		{"王芳", "", "13700001111"},
+	}
+)
+
+func testConfig(t *testing.T, columns []string, rows [][]string) *model.EDMDetectConfig {
+	t.Helper()
+	content, err := EncodeTable(testSalt, columns, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := model.NewEDMDetectRule(31, columns, "30313233343536373839616263646566", content, 4)
+	rule.RuleDesc = "客户信息表"
+	return &model.EDMDetectConfig{Rules: []model.EDMDetectRule{*rule}}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		column, in, want string
+	}{
+		{ColumnIDCard, " 110105 19491231 002x ", "11010519491231002X"},
+		{ColumnPhone, "+86 138-0013-8000", "13800138000"},
+		{ColumnPhone, "13800138000", "13800138000"},
+		{ColumnBankCard, "6222 0212 3456 7890", "6222021234567890"},
+		{ColumnEmail, " Zhang.Wei@Example.COM ", "zhang.wei@example.com"},
+		{ColumnText, "张 伟", "张伟"},
+		{ColumnText, "  ", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.column, tt.in); got != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, want %q", tt.column, tt.in, got, tt.want)
+		}
+	}
+
+	if Hash(testSalt, "") != 0 || Hash(testSalt, "a") == Hash([]byte("another salt"), "a") {
+		t.Error("Hash ignores empty value or salt")
+	}
+
+	content, _ := EncodeTable(testSalt, testColumns, testRows)
+	hashes, err := decodeTable(content, len(testColumns))
+	if err != nil || len(hashes) != 9 || hashes[7] != 0 || hashes[1] != Hash(testSalt, "11010519491231002X") {
+		t.Errorf("table round trip = %v, %v", hashes, err)
+	}
+	if _, err := EncodeTable(testSalt, testColumns, [][]string{{"a"}}); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("EncodeTable(short row) = %v", err)
+	}
+}
+
+func TestDetector_Rows(t *testing.T) {
+	d, err := New(testConfig(t, testColumns, testRows), Thresholds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 姓名与身份证号出现在同一文档 (格式与数据表不同)
+	res, err := d.DetectText(ctx, "报销人：张伟，证件号码 110105 19491231 002x，请财务核对。")
+	if err != nil || !res.IsSecret || res.RuleID != 31 || res.SecretLevel != 4 || res.RuleDesc != "客户信息表" {
+		t.Fatalf("name + id = %+v, %v", res, err)
+	}
+	if strings.Contains(res.MatchedText, "19491231") || !strings.Contains(res.ContextText, "id_card") {
+		t.Errorf("match = %q / %q", res.MatchedText, res.ContextText)
+	}
+
+	// 带国家码的手机号与身份证号
+	if res, _ := d.DetectText(ctx, "440524188001010014 电话 +86 139-1234-5678"); !res.IsSecret {
+		t.Error("id + phone not detected")
+	}
+
+	// 行内只有两个非空单元格时两个都要出现
+	if res, _ := d.DetectText(ctx, "王芳 13700001111"); !res.IsSecret {
+		t.Error("sparse row not detected")
+	}
+
+	// 单列命中、跨行拼凑与无关内容不命中
+	for _, text := range []string{
+		"联系电话 13800138000",
+		"张伟的电话是 13912345678",
+		"订单号 20240101123456，金额 3200 元",
+	} {
+		if res, _ := d.DetectText(ctx, text); res.IsSecret {
+			t.Errorf("DetectText(%q) = %+v", text, res)
+		}
+	}
+
+	// 提高行数阈值后单行不再命中
+	strict, _ := New(testConfig(t, testColumns, testRows), Thresholds{MinRows: 2})
+	if res, _ := strict.DetectText(ctx, "张伟 13800138000"); res.IsSecret {
+		t.Errorf("min rows ignored: %+v", res)
+	}
+	if res, _ := strict.DetectText(ctx, "张伟 13800138000\n李娜 13912345678"); !res.IsSecret || !strings.Contains(res.ContextText, "2 行") {
+		t.Errorf("two rows = %+v", res)
+	}
+}
+
+func TestDetector_File(t *testing.T) {
+	d, err := New(testConfig(t, testColumns, testRows), Thresholds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "export.csv")
+	os.WriteFile(csv, []byte("name,id,phone\n张伟,11010519491231002X,13800138000\n"), 0644)
+	bin := filepath.Join(dir, "blob.bin")
+	os.WriteFile(bin, []byte("\x00张伟,11010519491231002X"), 0644)
+
+	if res, err := d.DetectFile(context.Background(), csv); err != nil || !res.IsSecret {
+		t.Errorf("DetectFile(csv) = %+v, %v", res, err)
+	}
+	if res, err := d.DetectFile(context.Background(), bin); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(bin) = %+v, %v", res, err)
+	}
+	// Excel 另存的 GBK 编码 CSV
+	gbk, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("姓名,身份证号,手机号\n张伟,11010519491231002X,13800138000\n李娜,440524188001010014,13912345678\n"))
+	gbkCSV := filepath.Join(dir, "export-gbk.csv")
+	os.WriteFile(gbkCSV, gbk, 0644)
+	if res, err := d.DetectFile(context.Background(), gbkCSV); err != nil || !res.IsSecret || !strings.Contains(res.ContextText, ColumnText) {
+		t.Errorf("DetectFile(gbk csv) = %+v, %v", res, err)
+	}
+
+	for _, rc := range []model.EDMDetectRule{
+		*model.NewEDMDetectRule(1, []string{ColumnPhone}, "00", "AAAAAAAAAAA=", 3),
+		*model.NewEDMDetectRule(2, []string{ColumnPhone, ColumnIDCard}, "30313233343536373839", "AAAAAAAAAAA=", 3),
+		*model.NewEDMDetectRule(3, nil, "30313233343536373839", "AAAAAAAAAAA=", 3),
+	} {
+		if _, err := New(&model.EDMDetectConfig{Rules: []model.EDMDetectRule{rc}}, Thresholds{}); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("New(rule %d) = %v", rc.RuleID, err)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	for in, want := range map[string]string{
+		"13800138000": "138****8000",
+		"张伟":          "张*",
+		"x":           "*",
+	} {
+		if got := Mask(in); got != want {
+			t.Errorf("Mask(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
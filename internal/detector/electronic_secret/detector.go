@@ -4,14 +4,18 @@ package electronic_secret
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"strings"
 
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/budget"
 	"linuxFileWatcher/internal/detector/core"
 	"linuxFileWatcher/internal/extractous"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/i18n"
 	"linuxFileWatcher/internal/model"
 )
 
@@ -114,30 +118,26 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 	}
 
 	// 获取文件类型
-	fileType, err := core.GetFileType(path)
+	fileType, err := filetype.DetectFileType(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file type: %w", err)
 	}
 
-	// 只处理图片和PDF文件
-	if fileType != "image" && fileType != "document" {
-		return &core.DetectionResult{
-			DetectorName: d.name,
-			Detected:     false,
-			Matches:      []core.MatchDetail{},
-		}, nil
-	}
-
 	// 执行电子密级标志检测
+	// 只处理图片、文档与版式文件；音视频、压缩包、可执行文件等已压缩编码的内容不含标志，跳过以免空耗 OCR 与抽取
 	matches := []core.MatchDetail{}
 
-	switch fileType {
-	case "image":
+	switch {
+	case filetype.IsImageFile(fileType):
 		// 图片文件检测
 		matches = d.detectInImage(path)
-	case "document":
+	case filetype.IsDocumentFile(fileType), filetype.IsPdfFile(fileType), filetype.IsOfdFile(fileType):
 		// 文档文件检测
-		matches = d.detectInDocument(path)
+		matches, err = d.detectInDocument(path)
+		if err != nil && len(matches) == 0 {
+			// 压缩炸弹
+			return nil, err
+		}
 	}
 
 	// 构建检测结果
@@ -157,6 +157,7 @@ func (d *Detector) detectInImage(path string) []core.MatchDetail {
 	// 使用 tesseract OCR 库提取图片中的文字
 	// 构建 tesseract 命令
 	cmd := exec.Command("tesseract", path, "stdout", "--oem", "3", "--psm", "6")
+	defer budget.Default().Limit(budget.ClassOCR)()
 
 	// 捕获标准输出
 	var out bytes.Buffer
@@ -192,8 +193,8 @@ func (d *Detector) detectInImage(path string) []core.MatchDetail {
 				RuleID:      ruleID,
 				RuleDesc:    rule.RuleDesc,
 				AlertType:   int(model.AlertTypeOther),
-				FileSummary: "检测到电子密级标志",
-				FileDesc:    "在图片中检测到电子密级标志",
+				FileSummary: i18n.T("detect.electronic.summary"),
+				FileDesc:    i18n.T("detect.electronic.in_image"),
 				FileLevel:   5,
 			})
 		}
@@ -202,15 +203,15 @@ func (d *Detector) detectInImage(path string) []core.MatchDetail {
 	return matches
 }
 
-// detectInDocument 在文档中检测电子密级标志
-func (d *Detector) detectInDocument(path string) []core.MatchDetail {
+// detectInDocument 在文档中检测电子密级标志，元数据所在的 ZIP 容器超出展开配额时返回 archiveguard.ErrBomb
+func (d *Detector) detectInDocument(path string) ([]core.MatchDetail, error) {
 	matches := []core.MatchDetail{}
 
 	// 使用 extractous-go 库提取文档内容
 	extractor := extractous.New()
 	content, err := extractor.Extract(path)
 	if err != nil {
-		return matches
+		return matches, nil
 	}
 
 	// 检查文档内容中的电子密级标志特征
@@ -233,22 +234,22 @@ func (d *Detector) detectInDocument(path string) []core.MatchDetail {
 				RuleID:      ruleID,
 				RuleDesc:    rule.RuleDesc,
 				AlertType:   int(model.AlertTypeOther),
-				FileSummary: "检测到电子密级标志",
-				FileDesc:    "在文档中检测到电子密级标志",
+				FileSummary: i18n.T("detect.electronic.summary"),
+				FileDesc:    i18n.T("detect.electronic.in_doc"),
 				FileLevel:   5,
 			})
 		}
 	}
 
 	// 检查文档元数据中的电子密级标志
-	metaMatches := d.detectInMetadata(path)
+	metaMatches, err := d.detectInMetadata(path)
 	matches = append(matches, metaMatches...)
 
-	return matches
+	return matches, err
 }
 
 // detectInMetadata 在文档元数据中检测电子密级标志
-func (d *Detector) detectInMetadata(path string) []core.MatchDetail {
+func (d *Detector) detectInMetadata(path string) ([]core.MatchDetail, error) {
 	matches := []core.MatchDetail{}
 
 	// 获取文件扩展名
@@ -258,30 +259,29 @@ func (d *Detector) detectInMetadata(path string) []core.MatchDetail {
 	switch ext {
 	case ".docx", ".xlsx", ".pptx", ".ofd":
 		// 处理基于 Zip 结构的文档 (Office OpenXML / OFD)
-		metaMatches := d.checkZipBasedDocs(path)
-		matches = append(matches, metaMatches...)
+		return d.checkZipBasedDocs(path)
 	// TODO: 后续可以扩展 PDF 的 XMP 解析逻辑
 	// case ".pdf":
 	//     return c.checkPDF(ctx.FilePath)
 	default:
 		// 不支持的格式，跳过
-		return matches
+		return matches, nil
 	}
-
-	return matches
 }
 
 // checkZipBasedDocs 处理基于 Zip 结构的文档 (Office OpenXML / OFD)
-func (d *Detector) checkZipBasedDocs(path string) []core.MatchDetail {
+// 元数据文件按 archiveguard 默认配额解压，超出时停止并返回 archiveguard.ErrBomb
+func (d *Detector) checkZipBasedDocs(path string) ([]core.MatchDetail, error) {
 	matches := []core.MatchDetail{}
 
 	// 1. 尝试作为 Zip 打开
 	r, err := zip.OpenReader(path)
 	if err != nil {
 		// 如果打不开（可能加密了，或者损坏了），视为未命中
-		return matches
+		return matches, nil
 	}
 	defer r.Close()
+	quota := archiveguard.Default()
 
 	// 2. 遍历 Zip 内的文件列表
 	for _, f := range r.File {
@@ -294,28 +294,28 @@ func (d *Detector) checkZipBasedDocs(path string) []core.MatchDetail {
 			strings.HasSuffix(f.Name, "OFD.xml") {
 
 			// 3. 读取元数据文件内容并检测
-			metaMatches := d.scanZipEntry(f)
+			metaMatches, err := d.scanZipEntry(quota, f)
 			matches = append(matches, metaMatches...)
+			if err != nil {
+				return matches, err
+			}
 		}
 	}
 
-	return matches
+	return matches, nil
 }
 
-// scanZipEntry 读取 Zip 中的单个文件并匹配关键词
-func (d *Detector) scanZipEntry(f *zip.File) []core.MatchDetail {
+// scanZipEntry 读取 Zip 中的单个文件并匹配关键词，只返回压缩炸弹错误
+func (d *Detector) scanZipEntry(quota *archiveguard.Quota, f *zip.File) ([]core.MatchDetail, error) {
 	matches := []core.MatchDetail{}
 
-	rc, err := f.Open()
-	if err != nil {
-		return matches
-	}
-	defer rc.Close()
-
 	// 读取内容 (元数据文件通常很小，几KB，可以直接读入内存)
-	content, err := io.ReadAll(rc)
+	content, err := quota.ReadAll(f)
+	if errors.Is(err, archiveguard.ErrBomb) {
+		return matches, err
+	}
 	if err != nil {
-		return matches
+		return matches, nil
 	}
 
 	xmlContent := string(content)
@@ -329,14 +329,14 @@ func (d *Detector) scanZipEntry(f *zip.File) []core.MatchDetail {
 				Content:     tag,
 				Location:    "metadata",
 				RuleID:      0, // 元数据检测无特定规则ID
-				RuleDesc:    "电子密级标志元数据检测",
+				RuleDesc:    i18n.T("detect.electronic.rule_desc"),
 				AlertType:   int(model.AlertTypeOther),
-				FileSummary: "检测到电子密级标志",
-				FileDesc:    fmt.Sprintf("在元数据文件 '%s' 中检测到电子密级标志", f.Name),
+				FileSummary: i18n.T("detect.electronic.summary"),
+				FileDesc:    i18n.T("detect.electronic.file_desc", f.Name),
 				FileLevel:   5,
 			})
 		}
 	}
 
-	return matches
+	return matches, nil
 }
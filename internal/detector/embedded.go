@@ -0,0 +1,170 @@
+package detector
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/budget"
+	"linuxFileWatcher/internal/cfb"
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/tracing"
+)
+
+// embeddingDirs OOXML 包中存放嵌入对象的目录
+var embeddingDirs = []string{"word/embeddings/", "xl/embeddings/", "ppt/embeddings/"}
+
+// embedsObjects 是否为可能含嵌入对象的 OOXML 文档
+// 嵌入的 xlsx/pptx 没有专门的文件类型，识别为 zip，只在嵌入对象内部继续递归
+// 递归层数由 archiveguard 的嵌套层数限制
+func embedsObjects(c *content) bool {
+	switch c.typ.Extension {
+	case "docx", "docm", "dotx", "dotm":
+		return true
+	case "zip":
+		return c.embedDepth > 0
+	}
+	return false
+}
+
+// detectEmbedded 逐个检测 OOXML 文档中的嵌入对象 (OLE 对象、嵌入的文档与图表)，首个命中即返回
+// 告警归属外层文档，FileSummary 记录命中的嵌入对象在包内的路径
+func (m *Manager) detectEmbedded(ctx context.Context, c *content) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	ctx, release, err := budget.Default().Acquire(ctx, budget.ClassArchive, maxSharedSize)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer release()
+
+	quota, err := archiveguard.FromContext(ctx).Enter()
+	if err != nil {
+		return false, nil, nil, err
+	}
+	ctx = archiveguard.NewContext(ctx, quota)
+
+	ra, closeFn, err := c.readerAt()
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer closeFn()
+
+	var (
+		found   bool
+		record  *model.AlertRecord
+		logItem *model.AlertLogItem
+	)
+
+	endEmbedded := tracing.Begin(ctx, "embedded")
+	w := &archiveWalker{match: isEmbedding, quota: quota, fn: func(entry string, data []byte) (bool, error) {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		name, data := embeddedPayload(entry, data)
+		if data == nil {
+			return false, nil
+		}
+
+		doc := document.FromBytes(name, data, m.textExtractor())
+		defer doc.Close()
+
+		hit, r, l, err := m.detect(ctx, &content{
+			path:       c.path + "!/" + name,
+			name:       doc.Name,
+			size:       doc.Size,
+			md5:        doc.MD5,
+			typ:        doc.Type,
+			data:       data,
+			doc:        doc,
+			nested:     true,
+			embedDepth: c.embedDepth + 1,
+		})
+		if err != nil || !hit {
+			return false, err
+		}
+
+		// 多层嵌入时保留内层路径
+		if r.FileSummary != "" {
+			name += "!/" + r.FileSummary
+		}
+		r.FileSummary = name
+		r.FilePath, r.FileName, r.FileMD5, r.FileSize = c.path, c.name, c.md5, int(c.size)
+		l.FilePath, l.FileName, l.FileMD5 = c.path, c.name, c.md5
+		found, record, logItem = true, r, l
+		return true, nil
+	}}
+	err = w.zip(ra, c.size)
+	endEmbedded(err)
+	if err != nil && !errors.Is(err, zip.ErrFormat) {
+		return false, nil, nil, err
+	}
+	return found, record, logItem, nil
+}
+
+// isEmbedding 包内文件是否位于嵌入对象目录
+func isEmbedding(name string) bool {
+	for _, dir := range embeddingDirs {
+		if strings.HasPrefix(name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// embeddedPayload 取出嵌入对象中的实际文件，返回用于识别格式的名称与内容
+// OLE 对象 (oleObject*.bin) 依次尝试 Package 流 (嵌入的 OOXML)、\x01Ole10Native 流 (打包的任意文件)、
+// CONTENTS 流 (如嵌入的 PDF)；旧版 Office 文档本身即为复合文档，原样检测。无法识别时返回 nil
+func embeddedPayload(entry string, data []byte) (string, []byte) {
+	if !cfb.IsCFB(data) {
+		return entry, data
+	}
+	f, err := cfb.Open(data)
+	if err != nil {
+		return "", nil
+	}
+	base := strings.TrimSuffix(entry, path.Ext(entry))
+
+	if pkg, err := f.ReadStream("Package"); err == nil && len(pkg) > 0 {
+		return base + ooxmlExtension(pkg), pkg
+	}
+	if native, err := f.ReadStream("\x01Ole10Native"); err == nil {
+		if name, payload, err := cfb.ParseOle10Native(native); err == nil && len(payload) > 0 {
+			if name == "" {
+				name = path.Base(base)
+			}
+			return path.Join(path.Dir(entry), name), payload
+		}
+	}
+	if contents, err := f.ReadStream("CONTENTS"); err == nil && bytes.HasPrefix(contents, []byte("%PDF")) {
+		return base + ".pdf", contents
+	}
+	for stream, ext := range map[string]string{"WordDocument": ".doc", "Workbook": ".xls", "PowerPoint Document": ".ppt"} {
+		if _, ok := f.Find(stream); ok {
+			return base + ext, data
+		}
+	}
+	return "", nil
+}
+
+// ooxmlExtension 按包内目录区分嵌入的 OOXML 文档类型
+func ooxmlExtension(data []byte) string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ".bin"
+	}
+	for _, f := range zr.File {
+		switch {
+		case strings.HasPrefix(f.Name, "word/"):
+			return ".docx"
+		case strings.HasPrefix(f.Name, "xl/"):
+			return ".xlsx"
+		case strings.HasPrefix(f.Name, "ppt/"):
+			return ".pptx"
+		}
+	}
+	return ".zip"
+}
@@ -0,0 +1,112 @@
+package detector
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildDocx 打包 OOXML 文档，store 为 true 时不压缩 (便于 bytesDetector 直接匹配原始内容)
+func buildDocx(t *testing.T, files map[string][]byte, store bool) []byte {
+	t.Helper()
+	method := zip.Deflate
+	if store {
+		method = zip.Store
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestManager_DetectEmbedded(t *testing.T) {
+	cover := []byte("<w:document>关于报送材料的函</w:document>")
+	secret := buildDocx(t, map[string][]byte{"word/document.xml": []byte("<w:document>绝密★启用前</w:document>")}, true)
+	middle := buildDocx(t, map[string][]byte{
+		"word/document.xml":        cover,
+		"word/embeddings/附件2.docx": secret,
+	}, false)
+
+	tests := []struct {
+		name        string
+		files       map[string][]byte
+		wantHit     bool
+		wantSummary string
+	}{
+		{
+			name: "嵌入涉密文档",
+			files: map[string][]byte{
+				"word/document.xml":                            cover,
+				"word/embeddings/Microsoft_Word_Document.docx": secret,
+			},
+			wantHit:     true,
+			wantSummary: "word/embeddings/Microsoft_Word_Document.docx",
+		},
+		{
+			name: "多层嵌入",
+			files: map[string][]byte{
+				"word/document.xml":        cover,
+				"word/embeddings/附件1.docx": middle,
+			},
+			wantHit:     true,
+			wantSummary: "word/embeddings/附件1.docx!/word/embeddings/附件2.docx",
+		},
+		{
+			name: "嵌入对象不涉密",
+			files: map[string][]byte{
+				"word/document.xml":              cover,
+				"word/embeddings/oleObject1.bin": []byte("not an ole object"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildDocx(t, tt.files, false)
+			path := filepath.Join(t.TempDir(), "封面.docx")
+			os.WriteFile(path, data, 0644)
+
+			m := &Manager{
+				config:           GlobalConfig{EnableKeywords: true},
+				keywordsDetector: &bytesDetector{},
+				routes:           DefaultRoutes(),
+			}
+			hit, record, logItem, err := m.Detect(context.Background(), path)
+			if err != nil || hit != tt.wantHit {
+				t.Fatalf("Detect() hit = %v, err = %v", hit, err)
+			}
+			if !hit {
+				return
+			}
+			if record.FilePath != path || record.FileSummary != tt.wantSummary || record.FileSize != len(data) || logItem.FilePath != path {
+				t.Errorf("告警 = %q %q %d", record.FilePath, record.FileSummary, record.FileSize)
+			}
+
+			if hit, _, _, _ := m.DetectBytes(context.Background(), "封面.docx", data); !hit {
+				t.Error("DetectBytes() 未命中")
+			}
+		})
+	}
+}
+
+func TestEmbeddedPayload(t *testing.T) {
+	if name, data := embeddedPayload("word/embeddings/a.docx", []byte("PK")); name != "word/embeddings/a.docx" || string(data) != "PK" {
+		t.Errorf("非 OLE 对象 = %q %q", name, data)
+	}
+	// 魔数正确但结构损坏的 OLE 对象跳过
+	if _, data := embeddedPayload("word/embeddings/oleObject1.bin", append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 600)...)); data != nil {
+		t.Errorf("损坏的 OLE 对象 = %d 字节", len(data))
+	}
+}
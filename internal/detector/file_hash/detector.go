@@ -7,11 +7,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"time"
 
 	"linuxFileWatcher/internal/config"
 	"linuxFileWatcher/internal/detector/core"
 	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/detector/sampling"
+	"linuxFileWatcher/internal/fastread"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/gmsm/sm3"
 	"linuxFileWatcher/internal/logger"
 	"linuxFileWatcher/internal/model"
 	"linuxFileWatcher/internal/security/integrity"
@@ -34,10 +39,34 @@ type Detector struct {
 	// 规则ID到规则详情的映射
 	ruleMap map[int64]model.HashDetectRule
 
+	// 采样哈希规则，按采样参数分组 (key 为 sampling.Params.String())
+	sampled map[string]*sampledRules
+
 	// 策略管理器
 	policyManager *policy.Manager
 }
 
+// 采样哈希规则类型
+const (
+	ruleTypeSampledMD5 = 2
+	ruleTypeSampledSM3 = 3
+)
+
+// sampledRules 采样参数相同的采样哈希规则，每组只读取一次采样窗口
+type sampledRules struct {
+	params sampling.Params
+	// 按 RuleType 分类的哈希映射
+	hashToRuleID map[int]map[string]int64
+}
+
+// sampledMatch 采样哈希命中
+type sampledMatch struct {
+	ruleID   int64
+	ruleType int
+	hash     string
+	params   string
+}
+
 // NewDetector 创建新的文件哈希检测器
 func NewDetector() *Detector {
 	// 初始化策略管理器
@@ -95,6 +124,7 @@ func NewDetector() *Detector {
 			1: make(map[string]int64), // SM3
 		},
 		ruleMap:       make(map[int64]model.HashDetectRule),
+		sampled:       make(map[string]*sampledRules),
 		policyManager: policyManager,
 	}
 }
@@ -117,18 +147,13 @@ func (d *Detector) Init(config interface{}) error {
 		1: make(map[string]int64), // SM3
 	}
 	d.ruleMap = make(map[int64]model.HashDetectRule)
+	d.sampled = make(map[string]*sampledRules)
 
 	// 如果传入了配置参数，使用传入的配置
 	if config != nil {
 		if hashConfig, ok := config.(*model.HashDetectConfig); ok {
-			// 编译哈希值到规则ID的映射，按RuleType分类
 			for _, rule := range hashConfig.Rules {
-				// 确保RuleType对应的映射存在
-				if _, exists := d.hashToRuleID[rule.RuleType]; !exists {
-					d.hashToRuleID[rule.RuleType] = make(map[string]int64)
-				}
-				d.hashToRuleID[rule.RuleType][rule.RuleContent] = rule.RuleID
-				d.ruleMap[rule.RuleID] = rule
+				d.addRule(rule)
 			}
 			return nil
 		}
@@ -145,19 +170,35 @@ func (d *Detector) loadPolicy() error {
 		return err
 	}
 
-	// 编译哈希值到规则ID的映射，按RuleType分类
 	for _, rule := range config.Rules {
-		// 确保RuleType对应的映射存在
-		if _, exists := d.hashToRuleID[rule.RuleType]; !exists {
-			d.hashToRuleID[rule.RuleType] = make(map[string]int64)
-		}
-		d.hashToRuleID[rule.RuleType][rule.RuleContent] = rule.RuleID
-		d.ruleMap[rule.RuleID] = rule
+		d.addRule(rule)
 	}
 
 	return nil
 }
 
+// addRule 编译哈希值到规则ID的映射，按RuleType分类；采样规则按采样参数分组
+func (d *Detector) addRule(rule model.HashDetectRule) {
+	d.ruleMap[rule.RuleID] = rule
+
+	target := d.hashToRuleID
+	if rule.RuleType == ruleTypeSampledMD5 || rule.RuleType == ruleTypeSampledSM3 {
+		params := sampling.FromPolicy(rule.Sampling)
+		group, ok := d.sampled[params.String()]
+		if !ok {
+			group = &sampledRules{params: params, hashToRuleID: make(map[int]map[string]int64)}
+			d.sampled[params.String()] = group
+		}
+		target = group.hashToRuleID
+	}
+
+	// 确保RuleType对应的映射存在
+	if _, exists := target[rule.RuleType]; !exists {
+		target[rule.RuleType] = make(map[string]int64)
+	}
+	target[rule.RuleType][rule.RuleContent] = rule.RuleID
+}
+
 // Detect 执行检测操作
 func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 	// 检查文件是否存在
@@ -169,6 +210,15 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
+	// 块设备、管道等非普通文件没有固定内容，读取可能阻塞或遍历整个设备
+	if _, special := filetype.FromMode(fileInfo.Mode()); special {
+		return &core.DetectionResult{
+			DetectorName: d.name,
+			Detected:     false,
+			Matches:      []core.MatchDetail{},
+		}, nil
+	}
+
 	// 检查文件大小
 	if fileInfo.Size() == 0 {
 		return &core.DetectionResult{
@@ -178,10 +228,11 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 		}, nil
 	}
 
-	// 性能优化：限制文件大小，避免对超大文件进行哈希计算
+	// 性能优化：限制文件大小，避免对超大文件进行完整哈希计算，超大文件只匹配采样哈希规则
 	// 这里设置为 100MB，可以根据实际情况调整
 	const maxFileSize = 100 * 1024 * 1024 // 100MB
-	if fileInfo.Size() > maxFileSize {
+	full := fileInfo.Size() <= maxFileSize
+	if !full && len(d.sampled) == 0 {
 		logger.Info("File too large, skipping hash detection",
 			"path", path,
 			"size", fileInfo.Size(),
@@ -203,8 +254,8 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 	var md5Err, sm3Err error
 
 	// 根据策略中的 RuleType 确定需要检测的哈希类型
-	needMD5 := len(d.hashToRuleID[0]) > 0
-	needSM3 := len(d.hashToRuleID[1]) > 0
+	needMD5 := full && len(d.hashToRuleID[0]) > 0
+	needSM3 := full && len(d.hashToRuleID[1]) > 0
 
 	if needMD5 {
 		md5Hash, md5Err = computeFileMD5(path)
@@ -316,6 +367,49 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 		}
 	}
 
+	// 检查采样哈希，结果记录采样参数以便复现
+	for _, m := range d.matchSampled(path, fileInfo.Size()) {
+		rule, ruleExists := d.ruleMap[m.ruleID]
+		algo := "MD5"
+		if m.ruleType == ruleTypeSampledSM3 {
+			algo = "SM3"
+		}
+		ruleDesc := "Sampled " + algo + " Hash Match"
+		if ruleExists && rule.RuleDesc != "" {
+			ruleDesc = rule.RuleDesc
+		}
+		fileDesc := fmt.Sprintf("文件 %s 的采样 %s 哈希值匹配敏感文件规则 (采样参数 %s)", fileName, algo, m.params)
+
+		matches = append(matches, core.MatchDetail{
+			MatchType:   "file_hash",
+			Content:     m.hash,
+			Location:    "sampled",
+			RuleID:      m.ruleID,
+			RuleDesc:    ruleDesc,
+			AlertType:   int(model.AlertTypeOther),
+			FileSummary: "敏感文件采样哈希匹配",
+			FileDesc:    fileDesc,
+			FileLevel:   4,
+		})
+
+		alert := model.NewAlertRecord(fmt.Sprintf("alert_%d", time.Now().UnixNano()))
+		alert.Time = time.Now().Format("2006-01-02 15:04:05")
+		alert.RuleID = m.ruleID
+		alert.RuleDesc = ruleDesc
+		alert.FilterType = 0
+		alert.FileSummary = "敏感文件采样哈希匹配"
+		alert.AlertType = model.AlertTypeOther
+		alert.FileMD5 = md5Hash // 超大文件不计算完整 MD5，此处为空
+		alert.FilePath = path
+		alert.FileName = fileName
+		alert.FileSize = fileSize
+		alert.HighlightText = m.hash
+		alert.FileDesc = fileDesc
+		alert.FileLevel = 4
+
+		alerts = append(alerts, alert)
+	}
+
 	// 存储告警记录
 	stores := storage.GetStores()
 	if stores != nil {
@@ -354,10 +448,63 @@ func (d *Detector) Detect(path string) (*core.DetectionResult, error) {
 	}, nil
 }
 
+// matchSampled 按各组采样参数计算采样哈希并匹配规则
+func (d *Detector) matchSampled(path string, size int64) []sampledMatch {
+	if len(d.sampled) == 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Error("Failed to open file for sampled hash", "path", path, "error", err)
+		return nil
+	}
+	defer f.Close()
+
+	// 按参数顺序计算，结果顺序稳定
+	keys := make([]string, 0, len(d.sampled))
+	for key := range d.sampled {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var matches []sampledMatch
+	for _, key := range keys {
+		group := d.sampled[key]
+		md5Rules, sm3Rules := group.hashToRuleID[ruleTypeSampledMD5], group.hashToRuleID[ruleTypeSampledSM3]
+		md5h, sm3h := md5.New(), sm3.New()
+		err := group.params.Read(f, size, func(_ sampling.Window, data []byte) error {
+			if len(md5Rules) > 0 {
+				md5h.Write(data)
+			}
+			if len(sm3Rules) > 0 {
+				sm3h.Write(data)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("Failed to compute sampled hash", "path", path, "sampling", key, "error", err)
+			continue
+		}
+
+		if hash := hex.EncodeToString(md5h.Sum(nil)); len(md5Rules) > 0 {
+			if ruleID, ok := md5Rules[hash]; ok {
+				matches = append(matches, sampledMatch{ruleID, ruleTypeSampledMD5, hash, key})
+			}
+		}
+		if hash := hex.EncodeToString(sm3h.Sum(nil)); len(sm3Rules) > 0 {
+			if ruleID, ok := sm3Rules[hash]; ok {
+				matches = append(matches, sampledMatch{ruleID, ruleTypeSampledSM3, hash, key})
+			}
+		}
+	}
+	return matches
+}
+
 // computeFileMD5 计算文件的 MD5 哈希值
 func computeFileMD5(filePath string) (string, error) {
-	// 以只读模式打开
-	f, err := os.Open(filePath)
+	// 以只读、顺序读方式打开
+	f, err := fastread.Open(filePath)
 	if err != nil {
 		return "", err
 	}
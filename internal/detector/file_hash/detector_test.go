@@ -2,11 +2,16 @@
 package file_hash
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"linuxFileWatcher/internal/config"
+	"linuxFileWatcher/internal/detector/sampling"
+	"linuxFileWatcher/internal/model"
 )
 
 // TestNewDetector 测试创建检测器
@@ -171,3 +176,41 @@ func TestLoadPolicy(t *testing.T) {
 		// 继续执行，因为策略文件可能不存在
 	}
 }
+
+// TestDetectSampledHash 测试采样哈希规则
+func TestDetectSampledHash(t *testing.T) {
+	params := &model.SamplingParams{HeadSize: 16, TailSize: 16, Windows: 2, WindowSize: 8, Seed: 42}
+	data := make([]byte, 4096)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	testFile := filepath.Join(t.TempDir(), "large.bin")
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// 按相同参数离线计算采样 MD5 作为规则内容
+	f, _ := os.Open(testFile)
+	h := md5.New()
+	sampling.FromPolicy(params).Hash(f, int64(len(data)), h)
+	f.Close()
+
+	rule := model.NewHashDetectRule(2001, ruleTypeSampledMD5, hex.EncodeToString(h.Sum(nil)))
+	rule.Sampling = params
+	detector := NewDetector()
+	if err := detector.Init(&model.HashDetectConfig{Rules: []model.HashDetectRule{*rule}}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := detector.Detect(testFile)
+	if err != nil {
+		t.Fatalf("Failed to detect test file: %v", err)
+	}
+	if !result.Detected || len(result.Matches) != 1 {
+		t.Fatalf("Expected sampled hash match, got %+v", result)
+	}
+	m := result.Matches[0]
+	if m.RuleID != 2001 || m.Location != "sampled" || !strings.Contains(m.FileDesc, sampling.FromPolicy(params).String()) {
+		t.Errorf("Unexpected match: %+v", m)
+	}
+}
@@ -0,0 +1,203 @@
+package fingerprint
+
+import (
+	"context"
+	"fmt"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+)
+
+// 默认阈值
+const (
+	DefaultMaxDistance    = 3
+	DefaultMinContainment = 0.5
+	DefaultMinMatches     = 8
+)
+
+// excerptLen 告警中附带的命中片段长度 (规范化后的字符数)
+const excerptLen = 60
+
+// Thresholds 判定阈值，规则中填写的阈值优先
+type Thresholds struct {
+	// simhash 汉明距离上限 (含)，<=0 使用 DefaultMaxDistance
+	MaxDistance int
+	// 规则的分段指纹出现在文档中的比例下限，<=0 使用 DefaultMinContainment
+	MinContainment float64
+	// 出现的分段指纹数下限，达到即判定为部分复制，<=0 使用 DefaultMinMatches
+	MinMatches int
+}
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.MaxDistance <= 0 {
+		t.MaxDistance = DefaultMaxDistance
+	}
+	if t.MinContainment <= 0 {
+		t.MinContainment = DefaultMinContainment
+	}
+	if t.MinMatches <= 0 {
+		t.MinMatches = DefaultMinMatches
+	}
+	return t
+}
+
+// rule 解析后的规则
+type rule struct {
+	cfg            model.FingerprintDetectRule
+	simhash        uint64
+	shingles       int // 分段指纹数
+	maxDistance    int
+	minContainment float64
+}
+
+// Detector 内容指纹检测器，实现 detector.SubDetector
+// 分段指纹建立倒排索引，检测耗时与规则数量基本无关
+type Detector struct {
+	th       Thresholds
+	simRules []*rule
+	shRules  []*rule
+	// 分段指纹 -> shRules 下标
+	index map[uint64][]int32
+}
+
+// New 解析策略中的全部规则，任一规则无效时返回错误
+func New(cfg *model.FingerprintDetectConfig, th Thresholds) (*Detector, error) {
+	d := &Detector{th: th.withDefaults(), index: make(map[uint64][]int32)}
+	if cfg == nil {
+		return d, nil
+	}
+	for _, rc := range cfg.Rules {
+		r := &rule{cfg: rc, maxDistance: d.th.MaxDistance, minContainment: d.th.MinContainment}
+		if rc.MaxDistance > 0 {
+			r.maxDistance = rc.MaxDistance
+		}
+		if rc.MinContainment > 0 {
+			r.minContainment = rc.MinContainment
+		}
+
+		switch rc.RuleType {
+		case RuleTypeSimHash:
+			h, err := DecodeSimHash(rc.RuleContent)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", rc.RuleID, err)
+			}
+			r.simhash = h
+			d.simRules = append(d.simRules, r)
+		case RuleTypeShingles:
+			hashes, err := DecodeShingles(rc.RuleContent)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d: %w", rc.RuleID, err)
+			}
+			idx := int32(len(d.shRules))
+			seen := make(map[uint64]bool, len(hashes))
+			for _, h := range hashes {
+				if !seen[h] {
+					seen[h] = true
+					d.index[h] = append(d.index[h], idx)
+				}
+			}
+			r.shingles = len(seen)
+			d.shRules = append(d.shRules, r)
+		default:
+			return nil, fmt.Errorf("rule %d: %w: unknown rule type %d", rc.RuleID, ErrInvalidRule, rc.RuleType)
+		}
+	}
+	return d, nil
+}
+
+// Len 规则数量
+func (d *Detector) Len() int {
+	return len(d.simRules) + len(d.shRules)
+}
+
+// DetectFile 检测文本文件，只读取前 document.MaxTextSize 字节；非文本格式由 DetectDocument 使用共享抽取的文本检测
+func (d *Detector) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	return document.DetectTextFile(ctx, d, filePath)
+}
+
+// DetectDocument 使用检测流水线共享抽取的文本，抽取器不支持的格式按纯文本处理
+func (d *Detector) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	return document.DetectTextDocument(ctx, d, doc)
+}
+
+// DetectText 计算文本指纹并与规则比对，多条规则命中时取相似度最高的一条
+func (d *Detector) DetectText(ctx context.Context, text string) (*model.SubDetectResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	text = document.TruncateText(text)
+	runes := normalize(text)
+	fp, marks := compute(runes)
+	if len(marks) == 0 {
+		return &model.SubDetectResult{}, nil
+	}
+
+	var (
+		best      *rule
+		bestScore float64
+		matched   string
+		excerpt   string
+	)
+
+	for _, r := range d.simRules {
+		dist := Distance(fp.SimHash, r.simhash)
+		if dist > r.maxDistance {
+			continue
+		}
+		if score := 1 - float64(dist)/64; score > bestScore {
+			best, bestScore = r, score
+			matched = fmt.Sprintf("simhash 距离 %d", dist)
+			excerpt = string(runes[:min(excerptLen, len(runes))])
+		}
+	}
+
+	if len(d.shRules) > 0 {
+		counts := make([]int, len(d.shRules))
+		first := make([]int, len(d.shRules))
+		seen := make(map[uint64]bool, len(marks))
+		for _, m := range marks {
+			if seen[m.hash] {
+				continue
+			}
+			seen[m.hash] = true
+			for _, idx := range d.index[m.hash] {
+				if counts[idx] == 0 {
+					first[idx] = m.pos
+				}
+				counts[idx]++
+			}
+		}
+		for i, r := range d.shRules {
+			n := counts[i]
+			if n == 0 {
+				continue
+			}
+			containment := float64(n) / float64(r.shingles)
+			if containment < r.minContainment && n < d.th.MinMatches {
+				continue
+			}
+			if containment > bestScore {
+				best, bestScore = r, containment
+				matched = fmt.Sprintf("分段指纹 %d/%d (%.0f%%)", n, r.shingles, containment*100)
+				excerpt = string(runes[first[i]:min(first[i]+excerptLen, len(runes))])
+			}
+		}
+	}
+
+	if best == nil {
+		return &model.SubDetectResult{}, nil
+	}
+	desc := best.cfg.RuleDesc
+	if desc == "" {
+		desc = "内容指纹匹配"
+	}
+	return &model.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: model.SecretLevel(best.cfg.SensitivityLevel),
+		RuleID:      best.cfg.RuleID,
+		RuleDesc:    desc,
+		MatchedText: matched,
+		ContextText: excerpt,
+		AlertType:   int(model.AlertTypeOther),
+	}, nil
+}
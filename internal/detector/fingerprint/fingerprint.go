@@ -0,0 +1,191 @@
+// Package fingerprint 内容指纹检测 (派生文档检测)
+// 对已知涉密文档的规范化文本计算两类指纹，随检测策略 fingerprint_detect 下发：
+//   - simhash: 整篇文本的 64 位相似哈希，汉明距离小于阈值即为改写、增删少量内容后的同一文档
+//   - 分段指纹: 按 winnowing 从全部 shingle 哈希中选取的子集，文档中出现足够多的分段指纹即为部分复制
+//     (如将涉密文档的段落粘贴到新文件)
+//
+// 规范化只保留字母与数字并转为小写，排版、标点与空白的差异不影响指纹
+package fingerprint
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+const (
+	// ShingleSize 每个 shingle 的字符数 (规范化后)，约为半句中文
+	ShingleSize = 12
+	// Window winnowing 窗口，连续 Window 个 shingle 中至少选取一个，长度不小于
+	// ShingleSize+Window-1 的相同片段一定产生相同的分段指纹
+	Window = 8
+)
+
+// 规则类型
+const (
+	RuleTypeSimHash  = 0 // simhash，整篇相似
+	RuleTypeShingles = 1 // 分段指纹，部分复制
+)
+
+// ErrInvalidRule 规则内容无法解析
+var ErrInvalidRule = errors.New("fingerprint: invalid rule content")
+
+// Fingerprint 文本指纹
+type Fingerprint struct {
+	SimHash uint64
+	// 分段指纹，升序且不重复
+	Shingles []uint64
+}
+
+// mark 选中的分段指纹及其在规范化文本中的位置
+type mark struct {
+	hash uint64
+	pos  int
+}
+
+// Compute 计算文本指纹，规范化后不足一个 shingle 的文本返回空指纹
+func Compute(text string) Fingerprint {
+	fp, _ := compute(normalize(text))
+	return fp
+}
+
+// compute 计算规范化文本的指纹，同时返回分段指纹的位置
+func compute(runes []rune) (Fingerprint, []mark) {
+	hashes := shingles(runes)
+	if len(hashes) == 0 {
+		return Fingerprint{}, nil
+	}
+	marks := winnow(hashes)
+	set := make([]uint64, 0, len(marks))
+	seen := make(map[uint64]bool, len(marks))
+	for _, m := range marks {
+		if !seen[m.hash] {
+			seen[m.hash] = true
+			set = append(set, m.hash)
+		}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+	return Fingerprint{SimHash: simhash(hashes), Shingles: set}, marks
+}
+
+// normalize 只保留字母与数字并转为小写
+func normalize(text string) []rune {
+	out := make([]rune, 0, len(text)/2)
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			out = append(out, unicode.ToLower(r))
+		}
+	}
+	return out
+}
+
+// shingles 计算每个位置上 ShingleSize 个字符的哈希
+func shingles(runes []rune) []uint64 {
+	if len(runes) < ShingleSize {
+		return nil
+	}
+	out := make([]uint64, 0, len(runes)-ShingleSize+1)
+	h := fnv.New64a()
+	buf := make([]byte, 0, ShingleSize*4)
+	for i := 0; i+ShingleSize <= len(runes); i++ {
+		buf = buf[:0]
+		for _, r := range runes[i : i+ShingleSize] {
+			buf = binary.AppendUvarint(buf, uint64(r))
+		}
+		h.Reset()
+		h.Write(buf)
+		out = append(out, h.Sum64())
+	}
+	return out
+}
+
+// winnow 在每个窗口中选取最小哈希 (相同时取最右)，连续窗口选中同一位置只记录一次
+func winnow(hashes []uint64) []mark {
+	w := Window
+	if len(hashes) < w {
+		w = len(hashes)
+	}
+	var out []mark
+	last := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		min := start
+		for i := start + 1; i < start+w; i++ {
+			if hashes[i] <= hashes[min] {
+				min = i
+			}
+		}
+		if min != last {
+			out = append(out, mark{hash: hashes[min], pos: min})
+			last = min
+		}
+	}
+	return out
+}
+
+// simhash 以全部 shingle 哈希等权计算
+func simhash(hashes []uint64) uint64 {
+	var v [64]int
+	for _, h := range hashes {
+		for i := 0; i < 64; i++ {
+			if h&(1<<i) != 0 {
+				v[i]++
+			} else {
+				v[i]--
+			}
+		}
+	}
+	var out uint64
+	for i := 0; i < 64; i++ {
+		if v[i] > 0 {
+			out |= 1 << i
+		}
+	}
+	return out
+}
+
+// Distance simhash 汉明距离
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// EncodeSimHash 编码为 simhash 规则内容 (16 位十六进制)
+func EncodeSimHash(h uint64) string {
+	return fmt.Sprintf("%016x", h)
+}
+
+// DecodeSimHash 解析 simhash 规则内容
+func DecodeSimHash(s string) (uint64, error) {
+	h, err := strconv.ParseUint(s, 16, 64)
+	if err != nil || len(s) != 16 {
+		return 0, fmt.Errorf("%w: simhash %q", ErrInvalidRule, s)
+	}
+	return h, nil
+}
+
+// EncodeShingles 编码为分段指纹规则内容 (大端 uint64 数组的 Base64)
+func EncodeShingles(hashes []uint64) string {
+	buf := make([]byte, 0, len(hashes)*8)
+	for _, h := range hashes {
+		buf = binary.BigEndian.AppendUint64(buf, h)
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+// DecodeShingles 解析分段指纹规则内容
+func DecodeShingles(s string) ([]uint64, error) {
+	buf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(buf) == 0 || len(buf)%8 != 0 {
+		return nil, fmt.Errorf("%w: shingles", ErrInvalidRule)
+	}
+	out := make([]uint64, len(buf)/8)
+	for i := range out {
+		out[i] = binary.BigEndian.Uint64(buf[i*8:])
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package fingerprint
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// 涉密文档与无关文档的样例段落
+var (
+	secretParas = []string{
+		"根据上级部署，第三季度将在华东地区开展联合演练，参演单位包括指挥中心、保障大队与通信站，演练时间暂定为九月中旬。",
+		"演练期间各单位须严格落实保密要求，涉及兵力部署、装备型号与行动路线的材料一律不得通过互联网传输，纸质文件按规定登记。",
+		"通信保障方案采用双链路备份，主链路故障时由备用站在十五分钟内完成切换，切换过程由值班人员全程记录并于次日上报。",
+		"经费预算共计三百二十万元，其中装备维护一百一十万元，物资采购九十五万元，其余用于人员培训与后勤保障，明细见附件二。",
+		"本文件仅限参演单位主要负责人阅知，不得复印、摘抄或转发，阅后由机要人员统一收回，遗失须立即报告。",
+	}
+	otherParas = []string{
+		"公司年度团建活动定于下月初举行，地点为郊外农庄，活动内容包括徒步、烧烤和拔河比赛，请各部门统计参加人数。",
+		"食堂将于本周五起调整供餐时间，午餐提前半小时，晚餐增加素食窗口，欢迎大家提出意见和建议。",
+		"The quarterly newsletter covers product updates, customer stories and upcoming community events for all teams.",
+	}
+)
+
+func rulesFor(t *testing.T, text string) *model.FingerprintDetectConfig {
+	t.Helper()
+	fp := Compute(text)
+	if len(fp.Shingles) == 0 {
+		t.Fatal("empty fingerprint")
+	}
+	return &model.FingerprintDetectConfig{Rules: []model.FingerprintDetectRule{
+		*model.NewFingerprintDetectRule(11, RuleTypeSimHash, EncodeSimHash(fp.SimHash), 4),
+		*model.NewFingerprintDetectRule(12, RuleTypeShingles, EncodeShingles(fp.Shingles), 4),
+	}}
+}
+
+func TestCompute_Normalize(t *testing.T) {
+	text := strings.Join(secretParas, "\n")
+	reformatted := strings.NewReplacer("，", ", ", "。", ".\n\n", "演练", "演 练").Replace(text)
+	a, b := Compute(text), Compute(reformatted)
+	if a.SimHash != b.SimHash || EncodeShingles(a.Shingles) != EncodeShingles(b.Shingles) {
+		t.Error("punctuation and whitespace changed the fingerprint")
+	}
+	if fp := Compute("短文本"); fp.SimHash != 0 || len(fp.Shingles) != 0 {
+		t.Errorf("Compute(short) = %+v", fp)
+	}
+
+	got, err := DecodeShingles(EncodeShingles(a.Shingles))
+	if err != nil || len(got) != len(a.Shingles) || got[0] != a.Shingles[0] {
+		t.Errorf("shingles round trip = %v, %v", len(got), err)
+	}
+	if h, err := DecodeSimHash(EncodeSimHash(a.SimHash)); err != nil || h != a.SimHash {
+		t.Errorf("simhash round trip = %x, %v", h, err)
+	}
+	for _, s := range []string{"", "xyz", "AAAA"} {
+		if _, err := DecodeShingles(s); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("DecodeShingles(%q) = %v", s, err)
+		}
+	}
+}
+
+func TestDetector_DerivedDocuments(t *testing.T) {
+	secret := strings.Join(secretParas, "\n")
+	d, err := New(rulesFor(t, secret), Thresholds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// 部分复制: 一段涉密内容粘贴进无关文档
+	pasted := otherParas[0] + "\n" + secretParas[2] + "\n" + otherParas[1]
+	res, err := d.DetectText(ctx, pasted)
+	if err != nil || !res.IsSecret || res.RuleID != 12 || res.SecretLevel != 4 {
+		t.Fatalf("partial copy = %+v, %v", res, err)
+	}
+	if !strings.Contains(res.MatchedText, "分段指纹") || !strings.Contains(string(normalize(secretParas[2])), res.ContextText[:9]) {
+		t.Errorf("partial copy match = %q / %q", res.MatchedText, res.ContextText)
+	}
+
+	// 整篇改写少量字词
+	edited := strings.Replace(secret, "三百二十万元", "三百万元", 1)
+	if res, _ := d.DetectText(ctx, edited); !res.IsSecret {
+		t.Error("edited copy not detected")
+	}
+
+	// 无关文档
+	if res, _ := d.DetectText(ctx, strings.Join(otherParas, "\n")); res.IsSecret {
+		t.Errorf("unrelated document detected: %+v", res)
+	}
+
+	// 只有 simhash 规则时，整篇改写由 simhash 命中，部分复制不命中
+	fp := Compute(secret)
+	sim, err := New(&model.FingerprintDetectConfig{Rules: []model.FingerprintDetectRule{
+		*model.NewFingerprintDetectRule(21, RuleTypeSimHash, EncodeSimHash(fp.SimHash), 3),
+	}}, Thresholds{MaxDistance: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := sim.DetectText(ctx, edited); !res.IsSecret || !strings.HasPrefix(res.MatchedText, "simhash") {
+		t.Errorf("simhash edited = %+v", res)
+	}
+	if res, _ := sim.DetectText(ctx, pasted); res.IsSecret {
+		t.Errorf("simhash matched a partial copy: %+v", res)
+	}
+
+	// 阈值提高后部分复制不再命中
+	strict, _ := New(rulesFor(t, secret), Thresholds{MinMatches: 1000, MinContainment: 0.9})
+	if res, _ := strict.DetectText(ctx, pasted); res.IsSecret {
+		t.Errorf("strict thresholds matched: %+v", res)
+	}
+}
+
+func TestDetector_File(t *testing.T) {
+	d, err := New(rulesFor(t, strings.Join(secretParas, "")), Thresholds{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "notes.txt")
+	os.WriteFile(txt, []byte("会议纪要\n"+secretParas[1]+secretParas[3]), 0644)
+	bin := filepath.Join(dir, "blob.bin")
+	os.WriteFile(bin, append([]byte{0, 1, 2}, secretParas[1]...), 0644)
+
+	if res, err := d.DetectFile(context.Background(), txt); err != nil || !res.IsSecret {
+		t.Errorf("DetectFile(txt) = %+v, %v", res, err)
+	}
+	if res, err := d.DetectFile(context.Background(), bin); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(bin) = %+v, %v", res, err)
+	}
+
+	bad := &model.FingerprintDetectConfig{Rules: []model.FingerprintDetectRule{
+		*model.NewFingerprintDetectRule(1, 7, "00", 3),
+	}}
+	if _, err := New(bad, Thresholds{}); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("New(bad) = %v", err)
+	}
+}
@@ -12,6 +12,10 @@ type Detector interface {
 	// DetectFile 检测单个文件是否为公文格式
 	// 返回通用中间结果，由 Manager 组装成 AlertRecord
 	DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error)
+
+	// DetectBytes 检测内存中的内容 (网络载荷、剪贴板、解密缓冲区等)
+	// name 为原始文件名，用于识别格式，可为空
+	DetectBytes(ctx context.Context, name string, data []byte) (*model.SubDetectResult, error)
 }
 
 // Config 公文版式检测配置
@@ -2,6 +2,7 @@ package detector
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"linuxFileWatcher/internal/detector/govcheck/fileutil"
 	"linuxFileWatcher/internal/detector/govcheck/processor"
 	"linuxFileWatcher/internal/detector/govcheck/scorer"
+	"linuxFileWatcher/internal/filetype"
 )
 
 // Detector 公文检测器
@@ -41,6 +43,12 @@ type StyleProcessor interface {
 	ProcessWithStyle(filePath string) (*processor.ProcessResultWithStyle, error)
 }
 
+// ContentProcessor 支持直接处理内存内容的处理器接口 (可选实现)
+type ContentProcessor interface {
+	Processor
+	ProcessBytes(name string, content []byte) (string, error)
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -140,8 +148,8 @@ func (d *Detector) Detect(filePath string) *DetectionResult {
 	}
 
 	// 检查文件类型是否支持公文检测
-	if !fileutil.IsSupportedForDetection(fileInfo.Type) {
-		reason := fileutil.GetUnsupportedReason(fileInfo.Type)
+	if !filetype.IsSupportedForDetection(fileInfo.Type) {
+		reason := filetype.GetUnsupportedReason(fileInfo.Type)
 		result.SetError(fmt.Errorf("不支持此文件类型进行公文检测: %s (%s)",
 			fileInfo.Type.Description, reason))
 		result.ProcessTime = time.Since(startTime)
@@ -171,6 +179,7 @@ func (d *Detector) Detect(filePath string) *DetectionResult {
 			return result
 		}
 		textContent = styleResult.Text
+		result.Partial = styleResult.Partial
 		if styleResult.HasStyle {
 			styleFeatures = styleResult.StyleFeatures
 		}
@@ -185,6 +194,122 @@ func (d *Detector) Detect(filePath string) *DetectionResult {
 		textContent = text
 	}
 
+	d.scoreText(result, textContent, styleFeatures)
+	result.ProcessTime = time.Since(startTime)
+
+	return result
+}
+
+// SupportsContent 判断指定类型的处理器能否直接处理内存内容
+func (d *Detector) SupportsContent(fileType string) bool {
+	proc, ok := d.GetProcessor(fileType)
+	if !ok {
+		return false
+	}
+	_, ok = proc.(ContentProcessor)
+	return ok
+}
+
+// DetectContent 检测内存中的内容 (无需落盘)
+// name 为原始文件名，用于识别格式；仅支持实现了 ContentProcessor 的处理器
+func (d *Detector) DetectContent(name string, data []byte) *DetectionResult {
+	startTime := time.Now()
+
+	fileType := filetype.DetectContentType(name, data)
+	result := NewDetectionResult(name, filepath.Base(name), int64(len(data)))
+	result.Threshold = d.config.Threshold
+	result.FileType = fileType.Extension
+
+	if len(data) == 0 {
+		result.SetError(fmt.Errorf("文件为空"))
+		return result
+	}
+
+	if d.config.MaxFileSize > 0 && int64(len(data)) > d.config.MaxFileSize {
+		result.SetError(fmt.Errorf("文件过大: %d 字节 (限制: %d 字节)",
+			len(data), d.config.MaxFileSize))
+		return result
+	}
+
+	proc, ok := d.GetProcessor(fileType.Extension)
+	if !ok {
+		result.SetError(fmt.Errorf("暂未实现此格式的处理器: %s (%s)",
+			fileType.Extension, fileType.Description))
+		result.ProcessTime = time.Since(startTime)
+		return result
+	}
+	contentProc, ok := proc.(ContentProcessor)
+	if !ok {
+		result.SetError(fmt.Errorf("此格式的处理器不支持内存内容: %s", fileType.Extension))
+		result.ProcessTime = time.Since(startTime)
+		return result
+	}
+
+	text, err := contentProc.ProcessBytes(name, data)
+	if err != nil {
+		result.SetError(fmt.Errorf("处理内容失败: %w", err))
+		result.ProcessTime = time.Since(startTime)
+		return result
+	}
+
+	d.scoreText(result, text, nil)
+	result.ProcessTime = time.Since(startTime)
+
+	return result
+}
+
+// Extract 按文件类型选择处理器抽取文本与版式特征
+// data 非 nil 且处理器支持内存内容时直接解析，否则按 filePath 读取；格式不提供版式信息时 StyleFeatures 为 nil
+func (d *Detector) Extract(filePath, fileType string, data []byte) (*processor.ProcessResultWithStyle, error) {
+	proc, ok := d.GetProcessor(fileType)
+	if !ok {
+		return nil, fmt.Errorf("暂未实现此格式的处理器: %s", fileType)
+	}
+
+	if data != nil {
+		if contentProc, ok := proc.(ContentProcessor); ok {
+			text, err := contentProc.ProcessBytes(filePath, data)
+			if err != nil {
+				return nil, err
+			}
+			return &processor.ProcessResultWithStyle{Text: text}, nil
+		}
+	}
+
+	if styleProc, ok := proc.(StyleProcessor); ok {
+		styleResult, err := styleProc.ProcessWithStyle(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !styleResult.HasStyle {
+			styleResult.StyleFeatures = nil
+		}
+		return styleResult, nil
+	}
+
+	text, err := proc.Process(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &processor.ProcessResultWithStyle{Text: text}, nil
+}
+
+// DetectExtracted 对已抽取的文本与版式特征评分 (文本由上游检测流水线统一抽取)
+func (d *Detector) DetectExtracted(filePath string, size int64, fileType, textContent string, styleFeatures *extractor.StyleFeatures) *DetectionResult {
+	startTime := time.Now()
+
+	result := NewDetectionResult(filePath, filepath.Base(filePath), size)
+	result.Threshold = d.config.Threshold
+	result.FileType = fileType
+
+	d.scoreText(result, textContent, styleFeatures)
+	result.ProcessTime = time.Since(startTime)
+
+	return result
+}
+
+// scoreText 提取特征、评分并填充结果
+func (d *Detector) scoreText(result *DetectionResult, textContent string, styleFeatures *extractor.StyleFeatures) {
 	// 提取特征
 	var features *extractor.Features
 	if styleFeatures != nil {
@@ -206,9 +331,6 @@ func (d *Detector) Detect(filePath string) *DetectionResult {
 	d.fillFeatureResult(result, features, scoreResult)
 
 	result.SetSuccess()
-	result.ProcessTime = time.Since(startTime)
-
-	return result
 }
 
 // fillFeatureResult 填充特征检测结果
@@ -259,6 +381,7 @@ func (d *Detector) fillFeatureResult(result *DetectionResult, features *extracto
 			LineSpacingMatch: sf.LineSpacingMatch,
 			HasSealImage:     sf.HasSealImage,
 			SealImageHint:    sf.SealImageHint,
+			SealOrgNames:     sf.SealOrgNames,
 			StyleScore:       sf.StyleScore,
 			StyleReasons:     sf.StyleReasons,
 		}
@@ -3,6 +3,8 @@ package detector
 import (
 	"os"
 	"testing"
+
+	"linuxFileWatcher/internal/detector/govcheck/processor"
 )
 
 // ============================================================
@@ -368,4 +370,36 @@ func containsString(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+// ============================================================
+// 内存内容检测测试
+// ============================================================
+
+func TestDetector_DetectContent(t *testing.T) {
+	d := New(nil)
+	d.RegisterProcessor(processor.NewTextProcessor())
+	d.RegisterProcessor(processor.NewDocxProcessor())
+
+	if !d.SupportsContent("txt") || d.SupportsContent("docx") {
+		t.Fatal("SupportsContent: 文本处理器应支持内存内容，DOCX 处理器不支持")
+	}
+
+	text := "国务院办公厅关于印发通知的通知\n国办发〔2024〕1号\n各省、自治区、直辖市人民政府：\n现将有关事项通知如下。\n国务院办公厅\n2024年1月15日"
+	result := d.DetectContent("notice.txt", []byte(text))
+	if !result.Success {
+		t.Fatalf("DetectContent() 失败: %s", result.Error)
+	}
+	if result.FileName != "notice.txt" || result.FileType != "txt" || result.FileSize != int64(len(text)) {
+		t.Errorf("结果文件信息 = %q %q %d", result.FileName, result.FileType, result.FileSize)
+	}
+
+	// 按路径解析的格式不支持内存检测
+	result = d.DetectContent("a.docx", []byte("PK\x03\x04 fake docx"))
+	if result.Success || result.Error == "" {
+		t.Error("DOCX 内容应返回不支持错误")
+	}
+
+	if result = d.DetectContent("empty.txt", nil); result.Success {
+		t.Error("空内容应返回错误")
+	}
+}
@@ -2,9 +2,12 @@ package detector
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
+
+	"linuxFileWatcher/internal/archiveguard"
 )
 
 // DetectionResult 表示单个文件的检测结果
@@ -31,6 +34,8 @@ type DetectionResult struct {
 	ProcessTime time.Duration `json:"process_time_ns"` // 处理耗时
 	Error       string        `json:"error,omitempty"` // 错误信息(如有)
 	Success     bool          `json:"success"`         // 是否处理成功
+	Partial     bool          `json:"partial,omitempty"` // 解析超出内存预算，仅检测了部分内容
+	ArchiveBomb bool          `json:"archive_bomb,omitempty"` // 展开文件包超出配额，疑似压缩炸弹
 }
 
 // FeatureResult 表示公文特征检测结果
@@ -102,8 +107,9 @@ type StyleFeatureResult struct {
 	LineSpacingMatch bool `json:"line_spacing_match"` // 行距是否符合
 
 	// 印章特征
-	HasSealImage  bool   `json:"has_seal_image"`            // 是否有印章图片
-	SealImageHint string `json:"seal_image_hint,omitempty"` // 印章提示
+	HasSealImage  bool     `json:"has_seal_image"`            // 是否有印章图片
+	SealImageHint string   `json:"seal_image_hint,omitempty"` // 印章提示
+	SealOrgNames  []string `json:"seal_org_names,omitempty"`  // 电子签章的印章名称
 
 	// 综合
 	StyleScore   float64  `json:"style_score"`             // 版式得分
@@ -128,6 +134,7 @@ func (r *DetectionResult) SetError(err error) {
 	r.Success = false
 	if err != nil {
 		r.Error = err.Error()
+		r.ArchiveBomb = errors.Is(err, archiveguard.ErrBomb)
 	}
 }
 
@@ -89,8 +89,9 @@ type StyleFeatures struct {
 	LineSpacing      float64 // 行距(磅)
 
 	// 印章特征
-	HasSealImage  bool   // 是否有印章图片
-	SealImageHint string // 印章提示
+	HasSealImage  bool     // 是否有印章图片
+	SealImageHint string   // 印章提示
+	SealOrgNames  []string // 电子签章中的印章名称 (签章单位)
 
 	// 综合评估
 	StyleScore      float64  // 版式得分
@@ -172,6 +173,17 @@ func (e *Extractor) ExtractWithStyle(text string, styleInfo *StyleFeatures) *Fea
 	// 合并版式特征
 	if styleInfo != nil {
 		features.StyleFeatures = styleInfo
+
+		// 电子签章的印章名称是可靠的签发机关线索
+		if !e.config.EnableKeywords {
+			return features
+		}
+		for _, name := range styleInfo.SealOrgNames {
+			if orgNames := rules.FindOrgNames(name); len(orgNames) > 0 {
+				features.OrgNames = uniqueStrings(append(features.OrgNames, orgNames...))
+				features.HasOrgName = true
+			}
+		}
 	}
 
 	return features
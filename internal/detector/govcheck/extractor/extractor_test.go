@@ -865,6 +865,23 @@ func TestExtractor_DisableKeywords(t *testing.T) {
 	}
 }
 
+func TestExtractor_ExtractWithStyle_SealOrgNames(t *testing.T) {
+	ext := New(nil)
+	text := "关于加强工作的通知\n请认真贯彻执行。"
+	style := &StyleFeatures{HasSealImage: true, SealOrgNames: []string{"XX市人民政府", "合同专用章"}}
+
+	features := ext.ExtractWithStyle(text, style)
+	if !features.HasOrgName || len(features.OrgNames) != 1 || features.OrgNames[0] != "市人民政府" {
+		t.Errorf("HasOrgName=%v OrgNames=%v, 期望从印章名称中识别机关名称", features.HasOrgName, features.OrgNames)
+	}
+
+	// 印章名称不是机关名称时不计入
+	features = ext.ExtractWithStyle(text, &StyleFeatures{SealOrgNames: []string{"合同专用章"}})
+	if features.HasOrgName {
+		t.Errorf("OrgNames=%v, 不期望检测到机关名称", features.OrgNames)
+	}
+}
+
 // ============================================================
 // 辅助函数测试
 // ============================================================
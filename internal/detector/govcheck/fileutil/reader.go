@@ -3,17 +3,21 @@ package fileutil
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/safewalk"
 )
 
 // FileInfo 文件信息
 type FileInfo struct {
-	Path      string   // 绝对路径
-	Name      string   // 文件名
-	Size      int64    // 文件大小
-	Type      FileType // 文件类型
-	Extension string   // 原始扩展名
+	Path      string            // 绝对路径
+	Name      string            // 文件名
+	Size      int64             // 文件大小
+	Type      filetype.FileType // 文件类型
+	Extension string            // 原始扩展名
 }
 
 // ReadFileHeader 读取文件头部指定字节数
@@ -76,9 +80,9 @@ func GetFileInfo(filePath string) (*FileInfo, error) {
 	}
 
 	// 检测文件类型
-	fileType, err := DetectFileType(absPath)
+	fileType, err := filetype.DetectFileType(absPath)
 	if err != nil {
-		fileType = TypeUnknown
+		fileType = filetype.TypeUnknown
 	}
 
 	return &FileInfo{
@@ -147,11 +151,11 @@ func CollectFiles(dirPath string, recursive bool) ([]string, error) {
 	var files []string
 
 	if recursive {
-		err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+		err := safewalk.Walk(dirPath, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && !isHiddenFile(info.Name()) {
+			if !d.IsDir() && !isHiddenFile(d.Name()) {
 				absPath, err := filepath.Abs(path)
 				if err != nil {
 					return err
@@ -188,19 +192,19 @@ func isHiddenFile(name string) bool {
 }
 
 // FilterFilesByType 按文件类型过滤文件列表
-func FilterFilesByType(files []string, categories ...Category) ([]string, error) {
+func FilterFilesByType(files []string, categories ...filetype.Category) ([]string, error) {
 	if len(categories) == 0 {
 		return files, nil
 	}
 
-	categorySet := make(map[Category]bool)
+	categorySet := make(map[filetype.Category]bool)
 	for _, c := range categories {
 		categorySet[c] = true
 	}
 
 	var filtered []string
 	for _, file := range files {
-		ft, err := DetectFileType(file)
+		ft, err := filetype.DetectFileType(file)
 		if err != nil {
 			continue
 		}
@@ -210,4 +214,4 @@ func FilterFilesByType(files []string, categories ...Category) ([]string, error)
 	}
 
 	return filtered, nil
-}
\ No newline at end of file
+}
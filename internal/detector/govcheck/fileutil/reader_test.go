@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"linuxFileWatcher/internal/filetype"
 )
 
 // ============================================================
@@ -421,7 +423,7 @@ func TestFilterFilesByType(t *testing.T) {
 	}
 
 	// 只过滤文本类型
-	filtered, err := FilterFilesByType(filePaths, CategoryText)
+	filtered, err := FilterFilesByType(filePaths, filetype.CategoryText)
 	if err != nil {
 		t.Fatalf("FilterFilesByType 失败: %v", err)
 	}
@@ -431,7 +433,7 @@ func TestFilterFilesByType(t *testing.T) {
 	}
 
 	// 过滤多个类型
-	filtered, err = FilterFilesByType(filePaths, CategoryText, CategoryPDF)
+	filtered, err = FilterFilesByType(filePaths, filetype.CategoryText, filetype.CategoryPDF)
 	if err != nil {
 		t.Fatalf("FilterFilesByType 失败: %v", err)
 	}
@@ -449,4 +451,4 @@ func TestFilterFilesByType(t *testing.T) {
 	if len(filtered) != len(filePaths) {
 		t.Errorf("过滤后 %d 个文件, want %d", len(filtered), len(filePaths))
 	}
-}
\ No newline at end of file
+}
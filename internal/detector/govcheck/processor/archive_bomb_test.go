@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/archiveguard"
+)
+
+// ============================================================
+// 压缩炸弹测试
+// ============================================================
+
+// zipBytes 打包文件 (Deflate 压缩)
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOfdParser_ArchiveBomb(t *testing.T) {
+	data := zipBytes(t, map[string][]byte{
+		"OFD.xml":            []byte(`<ofd:OFD><ofd:DocBody><ofd:DocRoot>Doc_0/Document.xml</ofd:DocRoot></ofd:DocBody></ofd:OFD>`),
+		"Doc_0/Document.xml": []byte(`<ofd:Document><ofd:Pages><ofd:Page ID="1" BaseLoc="Pages/Page_0/Content.xml"/></ofd:Pages></ofd:Document>`),
+		// 4MB 全零内容的压缩比远超上限
+		"Doc_0/Pages/Page_0/Content.xml": make([]byte, 4*1024*1024),
+	})
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewOfdParser(zr).Parse(); !errors.Is(err, archiveguard.ErrBomb) {
+		t.Errorf("Parse() err = %v, want ErrBomb", err)
+	}
+}
+
+func TestDocxProcessor_ArchiveBomb(t *testing.T) {
+	data := zipBytes(t, map[string][]byte{
+		"word/document.xml": make([]byte, 4*1024*1024),
+	})
+	path := filepath.Join(t.TempDir(), "bomb.docx")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewDocxProcessor().ProcessWithStyle(path); !errors.Is(err, archiveguard.ErrBomb) {
+		t.Errorf("ProcessWithStyle() err = %v, want ErrBomb", err)
+	}
+}
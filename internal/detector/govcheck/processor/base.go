@@ -8,6 +8,7 @@ import (
 	"linuxFileWatcher/internal/detector/govcheck/extractor"
 	"linuxFileWatcher/internal/detector/govcheck/scorer"
 	"linuxFileWatcher/internal/detector/govcheck/errors"
+	"linuxFileWatcher/internal/i18n"
 )
 
 // ============================================================
@@ -168,34 +169,34 @@ func WrapProcessorError(processor, filePath, operation string, err error) error
 
 // FileValidationError 文件验证错误
 func FileValidationError(processor, filePath, reason string) *ProcessorError {
-	return NewProcessorError(processor, filePath, "文件验证", fmt.Errorf(reason))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.validate"), fmt.Errorf(reason))
 }
 
 // ContentExtractionError 内容提取错误
 func ContentExtractionError(processor, filePath, reason string) *ProcessorError {
-	return NewProcessorError(processor, filePath, "内容提取", fmt.Errorf(reason))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.extract"), fmt.Errorf(reason))
 }
 
 // ParsingError 解析错误
 func ParsingError(processor, filePath, reason string) *ProcessorError {
-	return NewProcessorError(processor, filePath, "解析", fmt.Errorf(reason))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.parse"), fmt.Errorf(reason))
 }
 
 // FormatError 格式错误
 func FormatError(processor, filePath, expected, actual string) *ProcessorError {
-	return NewProcessorError(processor, filePath, "格式检查",
-		fmt.Errorf("期望格式: %s, 实际格式: %s", expected, actual))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.format"),
+		i18n.Errorf("processor.err.format_mismatch", expected, actual))
 }
 
 // FileSizeError 文件大小错误
 func FileSizeError(processor, filePath string, size, maxSize int64) *ProcessorError {
-	return NewProcessorError(processor, filePath, "大小检查",
-		fmt.Errorf("文件大小 %d 字节, 超过限制 %d 字节", size, maxSize))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.size"),
+		i18n.Errorf("processor.err.too_large", size, maxSize))
 }
 
 // EmptyFileError 空文件错误
 func EmptyFileError(processor, filePath string) *ProcessorError {
-	return NewProcessorError(processor, filePath, "文件检查", fmt.Errorf("文件为空"))
+	return NewProcessorError(processor, filePath, i18n.T("processor.op.check"), i18n.Errorf("processor.err.empty"))
 }
 
 // ExternalToolError 外部工具错误
@@ -373,9 +374,9 @@ func (c *ProcessorChain) Process(filePath string) (string, error) {
 	}
 
 	if lastErr != nil {
-		return "", fmt.Errorf("所有处理器都失败: %w", lastErr)
+		return "", i18n.Errorf("processor.err.all_failed", lastErr)
 	}
-	return "", fmt.Errorf("没有可用的处理器")
+	return "", i18n.Errorf("processor.err.none")
 }
 
 // ============================================================
@@ -402,12 +403,12 @@ func NewFullProcessor(registry *Registry) *FullProcessor {
 func (fp *FullProcessor) ProcessAndScore(filePath string, fileType string) (*FullResult, error) {
 	proc, ok := fp.registry.GetByType(fileType)
 	if !ok {
-		return nil, fmt.Errorf("不支持的文件类型: %s", fileType)
+		return nil, i18n.Errorf("processor.err.unsupported_type", fileType)
 	}
 
 	text, err := proc.Process(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("处理文件失败: %w", err)
+		return nil, i18n.Errorf("processor.err.process", err)
 	}
 
 	features := fp.extractor.Extract(text)
@@ -0,0 +1,243 @@
+package processor
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// ============================================================
+// 字符集检测与转码
+// ============================================================
+
+// Charset 文本字符集
+type Charset string
+
+const (
+	CharsetUnknown Charset = ""
+	CharsetUTF8    Charset = "utf-8"
+	CharsetUTF16LE Charset = "utf-16le"
+	CharsetUTF16BE Charset = "utf-16be"
+	CharsetGB18030 Charset = "gb18030" // GBK/GB2312 的超集，统一按 GB18030 解码
+	CharsetBig5    Charset = "big5"
+)
+
+// charsetSniffLen 嗅探 meta/XML 声明时读取的最大字节数
+const charsetSniffLen = 4096
+
+var (
+	// <meta charset="gbk"> 或 <meta http-equiv="Content-Type" content="text/html; charset=gb2312">
+	metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-zA-Z0-9_\-]+)`)
+	// <?xml version="1.0" encoding="GB2312"?>
+	xmlEncodingPattern = regexp.MustCompile(`(?i)<\?xml[^>]+encoding\s*=\s*["']([a-zA-Z0-9_\-]+)["']`)
+	// EML/MHT 头部: Content-Type: text/plain; charset="gb2312"
+	mimeCharsetPattern = regexp.MustCompile(`(?im)^content-type:[^\n]*charset\s*=\s*"?([a-zA-Z0-9_\-]+)`)
+)
+
+// NormalizeCharset 将各种字符集别名规范化
+func NormalizeCharset(name string) Charset {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.ReplaceAll(name, "_", "-")
+
+	switch name {
+	case "utf-8", "utf8":
+		return CharsetUTF8
+	case "utf-16le", "utf-16":
+		return CharsetUTF16LE
+	case "utf-16be", "unicodefffe":
+		return CharsetUTF16BE
+	case "gbk", "gb2312", "gb18030", "x-gbk", "cp936", "ms936", "windows-936", "euc-cn", "hz-gb-2312":
+		return CharsetGB18030
+	case "big5", "big5-hkscs", "cp950", "ms950", "windows-950", "x-x-big5":
+		return CharsetBig5
+	default:
+		return CharsetUnknown
+	}
+}
+
+// DetectCharset 检测内容字符集
+// 优先级：BOM > 调用方声明 > 文档内声明 (meta/XML/MIME) > 统计检测
+func DetectCharset(data []byte, declared string) Charset {
+	if cs, _ := detectBOM(data); cs != CharsetUnknown {
+		return cs
+	}
+
+	if cs := NormalizeCharset(declared); cs != CharsetUnknown {
+		return cs
+	}
+
+	if cs := sniffDeclaredCharset(data); cs != CharsetUnknown {
+		// 声明为 UTF-8 但内容并不合法时，继续统计检测
+		if cs != CharsetUTF8 || utf8.Valid(data) {
+			return cs
+		}
+	}
+
+	return detectCharsetStatistical(data)
+}
+
+// DecodeText 按检测到的字符集将内容转换为 UTF-8
+func DecodeText(data []byte, declared string) string {
+	cs := DetectCharset(data, declared)
+	if _, bomLen := detectBOM(data); bomLen > 0 {
+		data = data[bomLen:]
+	}
+	return decodeWithCharsetName(data, cs)
+}
+
+// decodeWithCharsetName 使用指定字符集解码
+func decodeWithCharsetName(data []byte, cs Charset) string {
+	var enc encoding.Encoding
+	switch cs {
+	case CharsetUTF16LE:
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case CharsetUTF16BE:
+		enc = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case CharsetGB18030:
+		enc = simplifiedchinese.GB18030
+	case CharsetBig5:
+		enc = traditionalchinese.Big5
+	default:
+		return string(data)
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// detectBOM 检测字节序标记，返回字符集和 BOM 长度
+func detectBOM(data []byte) (Charset, int) {
+	switch {
+	case len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF:
+		return CharsetUTF8, 3
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return CharsetUTF16LE, 2
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return CharsetUTF16BE, 2
+	}
+	return CharsetUnknown, 0
+}
+
+// sniffDeclaredCharset 从 HTML meta、XML 声明或 MIME 头部读取字符集
+func sniffDeclaredCharset(data []byte) Charset {
+	head := data
+	if len(head) > charsetSniffLen {
+		head = head[:charsetSniffLen]
+	}
+
+	if m := xmlEncodingPattern.FindSubmatch(head); m != nil {
+		if cs := NormalizeCharset(string(m[1])); cs != CharsetUnknown {
+			return cs
+		}
+	}
+	if m := metaCharsetPattern.FindSubmatch(head); m != nil {
+		if cs := NormalizeCharset(string(m[1])); cs != CharsetUnknown {
+			return cs
+		}
+	}
+	if m := mimeCharsetPattern.FindSubmatch(head); m != nil {
+		return NormalizeCharset(string(m[1]))
+	}
+	return CharsetUnknown
+}
+
+// detectCharsetStatistical 基于字节分布统计检测字符集
+func detectCharsetStatistical(data []byte) Charset {
+	if len(data) == 0 {
+		return CharsetUTF8
+	}
+
+	if cs := detectUTF16NoBOM(data); cs != CharsetUnknown {
+		return cs
+	}
+
+	if utf8.Valid(data) {
+		return CharsetUTF8
+	}
+
+	gbScore, big5Score := scoreDoubleByte(data)
+	if big5Score > gbScore {
+		return CharsetBig5
+	}
+	return CharsetGB18030
+}
+
+// detectUTF16NoBOM 检测无 BOM 的 UTF-16 文本
+// 以 ASCII 为主的 UTF-16 文本在奇数 (LE) 或偶数 (BE) 位置有大量零字节
+func detectUTF16NoBOM(data []byte) Charset {
+	sample := data
+	if len(sample) > 1024 {
+		sample = sample[:1024]
+	}
+	if len(sample) < 4 {
+		return CharsetUnknown
+	}
+
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+
+	half := len(sample) / 2
+	switch {
+	case oddZeros > half*3/10 && evenZeros <= half/20:
+		return CharsetUTF16LE
+	case evenZeros > half*3/10 && oddZeros <= half/20:
+		return CharsetUTF16BE
+	}
+	return CharsetUnknown
+}
+
+// scoreDoubleByte 统计双字节序列更符合 GB2312 常用字区还是 Big5 常用字区
+//
+// GB2312 一/二级汉字：首字节 0xB0-0xF7，尾字节 0xA1-0xFE
+// Big5 常用字：首字节 0xA4-0xC6，尾字节 0x40-0x7E 或 0xA1-0xFE
+// 两者在 0xB0-0xC6/0xA1-0xFE 区间重叠，只统计可区分的部分；得分相同时按 GB 处理
+func scoreDoubleByte(data []byte) (gbScore, big5Score int) {
+	for i := 0; i+1 < len(data); i++ {
+		lead, trail := data[i], data[i+1]
+		if lead < 0x81 {
+			continue
+		}
+
+		switch {
+		case lead >= 0xA1 && lead <= 0xC6 && trail >= 0x40 && trail <= 0x7E:
+			// GB2312 中不存在的尾字节区间 (含 Big5 全角标点 0xA140-0xA17E)
+			big5Score++
+		case lead >= 0xC7 && lead <= 0xF7 && trail >= 0xA1 && trail <= 0xFE:
+			// Big5 常用字区之外的 GB2312 汉字
+			gbScore++
+		}
+		i++
+	}
+	return gbScore, big5Score
+}
+
+// rtfCodepageCharset 将 RTF \\ansicpgN 代码页映射为字符集
+func rtfCodepageCharset(codepage int) Charset {
+	switch codepage {
+	case 936, 54936:
+		return CharsetGB18030
+	case 950:
+		return CharsetBig5
+	case 65001:
+		return CharsetUTF8
+	default:
+		return CharsetUnknown
+	}
+}
@@ -0,0 +1,98 @@
+package processor
+
+import "testing"
+
+// ============================================================
+// 字符集检测测试
+// ============================================================
+
+func TestDetectCharset_BOM(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Charset
+	}{
+		{"UTF-8 BOM", []byte{0xEF, 0xBB, 0xBF, 'a'}, CharsetUTF8},
+		{"UTF-16LE BOM", []byte{0xFF, 0xFE, 'a', 0}, CharsetUTF16LE},
+		{"UTF-16BE BOM", []byte{0xFE, 0xFF, 0, 'a'}, CharsetUTF16BE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// BOM 优先于调用方声明
+			if got := DetectCharset(tt.data, "gbk"); got != tt.want {
+				t.Errorf("DetectCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCharset_Declared(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Charset
+	}{
+		{"meta charset", `<html><head><meta charset="gb2312"></head>`, CharsetGB18030},
+		{"http-equiv", `<meta http-equiv="Content-Type" content="text/html; charset=big5">`, CharsetBig5},
+		{"xml encoding", `<?xml version="1.0" encoding="GBK"?><root/>`, CharsetGB18030},
+		{"mime header", "Content-Type: text/plain; charset=\"cp936\"\r\n\r\nbody", CharsetGB18030},
+		{"plain ascii", "hello world", CharsetUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectCharset([]byte(tt.data), ""); got != tt.want {
+				t.Errorf("DetectCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectCharset_Statistical(t *testing.T) {
+	// "关于印发通知" (GBK)
+	gbk := []byte{0xB9, 0xD8, 0xD3, 0xDA, 0xD3, 0xA1, 0xB7, 0xA2, 0xCD, 0xA8, 0xD6, 0xAA}
+	if got := DetectCharset(gbk, ""); got != CharsetGB18030 {
+		t.Errorf("GBK 文本检测为 %q, want %q", got, CharsetGB18030)
+	}
+
+	// "關於，通知" (Big5)
+	big5 := []byte{0xC3, 0xF6, 0xA9, 0xF3, 0xA1, 0x41, 0xB3, 0x71, 0xAA, 0xBE}
+	if got := DetectCharset(big5, ""); got != CharsetBig5 {
+		t.Errorf("Big5 文本检测为 %q, want %q", got, CharsetBig5)
+	}
+
+	// 无 BOM 的 UTF-16LE ASCII 文本
+	utf16le := []byte{'a', 0, 'b', 0, 'c', 0, 'd', 0, 'e', 0, 'f', 0}
+	if got := DetectCharset(utf16le, ""); got != CharsetUTF16LE {
+		t.Errorf("UTF-16LE 文本检测为 %q, want %q", got, CharsetUTF16LE)
+	}
+}
+
+func TestNormalizeCharset(t *testing.T) {
+	tests := map[string]Charset{
+		"UTF8":        CharsetUTF8,
+		"gb_2312":     CharsetUnknown,
+		"GB2312":      CharsetGB18030,
+		"windows-936": CharsetGB18030,
+		"Big5-HKSCS":  CharsetBig5,
+		"iso-8859-1":  CharsetUnknown,
+	}
+	for name, want := range tests {
+		if got := NormalizeCharset(name); got != want {
+			t.Errorf("NormalizeCharset(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRTFCodepageCharset(t *testing.T) {
+	if rtfCodepageCharset(936) != CharsetGB18030 {
+		t.Error("代码页 936 应映射为 GB18030")
+	}
+	if rtfCodepageCharset(950) != CharsetBig5 {
+		t.Error("代码页 950 应映射为 Big5")
+	}
+	if rtfCodepageCharset(1252) != CharsetUnknown {
+		t.Error("代码页 1252 不应映射为中文字符集")
+	}
+}
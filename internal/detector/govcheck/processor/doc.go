@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"unicode/utf16"
 
 	"linuxFileWatcher/internal/detector/govcheck/extractor"
 )
@@ -27,6 +26,7 @@ type DocProcessorConfig struct {
 	UseLibreOffice  bool   // 是否使用 LibreOffice
 	LibreOfficePath string // LibreOffice 可执行文件路径
 	FallbackToBasic bool   // 是否回退到基础提取
+	MaxMemory       int64  // 基础提取的内存预算（字节），超出时只扫描文件前部并标记为部分抽取（0=不限制）
 }
 
 // DefaultDocProcessorConfig 返回默认配置
@@ -38,6 +38,7 @@ func DefaultDocProcessorConfig() *DocProcessorConfig {
 		UseLibreOffice:  true,
 		LibreOfficePath: "", // 从 PATH 查找
 		FallbackToBasic: true,
+		MaxMemory:       DefaultMaxExtractMemory,
 	}
 }
 
@@ -102,6 +103,7 @@ func (p *DocProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithStyl
 	var text string
 	var err error
 	var extractMethod string
+	var partial bool
 
 	// 方法 1: 尝试使用 antiword
 	if p.config.UseAntiword {
@@ -121,7 +123,7 @@ func (p *DocProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithStyl
 
 	// 方法 3: 回退到基础提取
 	if text == "" && p.config.FallbackToBasic {
-		text, err = p.extractBasic(filePath)
+		text, partial, err = p.extractBasic(filePath)
 		if err == nil && strings.TrimSpace(text) != "" {
 			extractMethod = "basic"
 		}
@@ -136,6 +138,7 @@ func (p *DocProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithStyl
 	// 清理文本
 	text = p.cleanText(text)
 	result.Text = text
+	result.Partial = partial
 
 	// 创建基础版式特征
 	result.StyleFeatures = &extractor.StyleFeatures{
@@ -204,13 +207,9 @@ func (p *DocProcessor) validateMagicNumber(filePath string) error {
 
 // extractWithAntiword 使用 antiword 提取文本
 func (p *DocProcessor) extractWithAntiword(filePath string) (string, error) {
-	antiwordPath := p.config.AntiwordPath
-	if antiwordPath == "" {
-		antiwordPath = "antiword"
-	}
-
 	// 检查 antiword 是否可用
-	if _, err := exec.LookPath(antiwordPath); err != nil {
+	antiwordPath := p.findAntiword()
+	if antiwordPath == "" {
 		return "", fmt.Errorf("antiword 未安装或不在 PATH 中")
 	}
 
@@ -281,53 +280,8 @@ func (p *DocProcessor) extractWithLibreOffice(filePath string) (string, error) {
 		return "", fmt.Errorf("读取转换结果失败: %w", err)
 	}
 
-	// 处理可能的 BOM
-	text := string(content)
-	text = strings.TrimPrefix(text, "\xEF\xBB\xBF") // UTF-8 BOM
-
-	// 如果是空的，尝试其他编码
-	if strings.TrimSpace(text) == "" {
-		// 可能是 UTF-16 编码
-		if len(content) >= 2 {
-			if content[0] == 0xFF && content[1] == 0xFE {
-				// UTF-16 LE
-				text = decodeUTF16LEForDoc(content[2:])
-			} else if content[0] == 0xFE && content[1] == 0xFF {
-				// UTF-16 BE
-				text = decodeUTF16BEForDoc(content[2:])
-			}
-		}
-	}
-
-	return text, nil
-}
-
-// decodeUTF16LE 解码 UTF-16 LE
-func decodeUTF16LEForDoc(data []byte) string {
-	if len(data)%2 != 0 {
-		data = data[:len(data)-1]
-	}
-
-	u16s := make([]uint16, len(data)/2)
-	for i := 0; i < len(u16s); i++ {
-		u16s[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
-	}
-
-	return string(utf16.Decode(u16s))
-}
-
-// decodeUTF16BE 解码 UTF-16 BE
-func decodeUTF16BEForDoc(data []byte) string {
-	if len(data)%2 != 0 {
-		data = data[:len(data)-1]
-	}
-
-	u16s := make([]uint16, len(data)/2)
-	for i := 0; i < len(u16s); i++ {
-		u16s[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
-	}
-
-	return string(utf16.Decode(u16s))
+	// LibreOffice 按系统区域设置输出，可能是 UTF-8/UTF-16/GBK，统一检测转码
+	return DecodeText(content, ""), nil
 }
 
 // findLibreOffice 查找 LibreOffice 可执行文件
@@ -337,99 +291,76 @@ func (p *DocProcessor) findLibreOffice() string {
 			return p.config.LibreOfficePath
 		}
 	}
+	return findExecutable([]string{"soffice", "libreoffice", "loffice"}, libreOfficeCandidates())
+}
 
-	// 常见的 LibreOffice 可执行文件名
-	names := []string{
-		"soffice",
-		"libreoffice",
-		"loffice",
-	}
-
-	for _, name := range names {
-		if path, err := exec.LookPath(name); err == nil {
-			return path
-		}
-	}
-
-	// Windows 常见路径
-	windowsPaths := []string{
-		`C:\Program Files\LibreOffice\program\soffice.exe`,
-		`C:\Program Files (x86)\LibreOffice\program\soffice.exe`,
-		`D:\Program Files\LibreOffice\program\soffice.exe`,
-		`D:\LibreOffice\program\soffice.exe`,
-	}
-
-	for _, path := range windowsPaths {
-		if _, err := os.Stat(path); err == nil {
+// findAntiword 查找 antiword 可执行文件
+func (p *DocProcessor) findAntiword() string {
+	if p.config.AntiwordPath != "" {
+		if path, err := exec.LookPath(p.config.AntiwordPath); err == nil {
 			return path
 		}
+		return ""
 	}
+	return findExecutable([]string{"antiword"}, antiwordCandidates())
+}
 
-	// macOS 常见路径
-	macPaths := []string{
-		"/Applications/LibreOffice.app/Contents/MacOS/soffice",
-	}
-
-	for _, path := range macPaths {
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
+// basicMemoryFactor 基础提取的堆内存占用相对扫描输入的估算倍数
+// (UTF-16 片段逐字符转为 rune 后再拼接为字符串)
+const basicMemoryFactor = 4
 
-	// Linux 常见路径
-	linuxPaths := []string{
-		"/usr/bin/soffice",
-		"/usr/bin/libreoffice",
-		"/opt/libreoffice/program/soffice",
+// extractBasic 基础文本提取（从 OLE2 复合文档中提取可见文本）
+// 文件以只读映射方式访问；按内存预算折算可扫描的长度，超出部分不扫描并返回 partial=true
+func (p *DocProcessor) extractBasic(filePath string) (text string, partial bool, err error) {
+	mapped, err := openMapped(filePath)
+	if err != nil {
+		return "", false, err
 	}
+	defer mapped.Close()
 
-	for _, path := range linuxPaths {
-		if _, err := os.Stat(path); err == nil {
-			return path
+	content := mapped.data
+	if p.config.MaxMemory > 0 {
+		if limit := p.config.MaxMemory / basicMemoryFactor; int64(len(content)) > limit {
+			content = content[:limit]
+			partial = true
 		}
 	}
 
-	return ""
-}
-
-// extractBasic 基础文本提取（从 OLE2 复合文档中提取可见文本）
-func (p *DocProcessor) extractBasic(filePath string) (string, error) {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "", err
-	}
-
 	var allText []string
+	err = guardFault(func() error {
+		// 方法 1: 尝试查找 Word Document 流中的文本
+		wordText := p.extractWordDocumentStream(content)
+		if wordText != "" {
+			allText = append(allText, wordText)
+		}
 
-	// 方法 1: 尝试查找 Word Document 流中的文本
-	wordText := p.extractWordDocumentStream(content)
-	if wordText != "" {
-		allText = append(allText, wordText)
-	}
-
-	// 方法 2: 提取 Unicode (UTF-16LE) 文本
-	if len(allText) == 0 {
-		unicodeText := p.extractUnicodeText(content)
-		if unicodeText != "" {
-			allText = append(allText, unicodeText)
+		// 方法 2: 提取 Unicode (UTF-16LE) 文本
+		if len(allText) == 0 {
+			unicodeText := p.extractUnicodeText(content)
+			if unicodeText != "" {
+				allText = append(allText, unicodeText)
+			}
 		}
-	}
 
-	// 方法 3: 提取 ASCII 文本（作为最后手段）
-	if len(allText) == 0 {
-		asciiText := p.extractASCIIText(content)
-		if asciiText != "" {
-			allText = append(allText, asciiText)
+		// 方法 3: 提取 ASCII 文本（作为最后手段）
+		if len(allText) == 0 {
+			asciiText := p.extractASCIIText(content)
+			if asciiText != "" {
+				allText = append(allText, asciiText)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return "", false, err
 	}
 
 	if len(allText) == 0 {
-		return "", fmt.Errorf("无法提取文本内容")
+		return "", false, fmt.Errorf("无法提取文本内容")
 	}
 
 	// 合并结果
-	result := strings.Join(allText, "\n")
-	return result, nil
+	return strings.Join(allText, "\n"), partial, nil
 }
 
 // extractWordDocumentStream 尝试从 Word Document 流提取文本
@@ -733,12 +664,7 @@ func (p *DocProcessor) removeControlChars(text string) string {
 
 // IsAntiwordAvailable 检查 antiword 是否可用
 func (p *DocProcessor) IsAntiwordAvailable() bool {
-	antiwordPath := p.config.AntiwordPath
-	if antiwordPath == "" {
-		antiwordPath = "antiword"
-	}
-	_, err := exec.LookPath(antiwordPath)
-	return err == nil
+	return p.findAntiword() != ""
 }
 
 // IsLibreOfficeAvailable 检查 LibreOffice 是否可用
@@ -4,12 +4,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"regexp"
 	"strings"
 
+	"linuxFileWatcher/internal/archiveguard"
 	"linuxFileWatcher/internal/detector/govcheck/extractor"
 )
 
@@ -114,8 +116,11 @@ func (p *DocxProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithSty
 	}
 	defer zipReader.Close()
 
+	// 文本抽取与版式解析共用展开配额，超出时 (压缩炸弹) 返回 archiveguard.ErrBomb
+	quota := archiveguard.Default()
+
 	// 1. 提取文本内容
-	text, err := p.extractText(&zipReader.Reader)
+	text, err := p.extractText(&zipReader.Reader, quota)
 	if err != nil {
 		return nil, NewProcessorError(p.Name(), filePath, "提取文本内容", err)
 	}
@@ -124,6 +129,7 @@ func (p *DocxProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithSty
 	// 2. 解析版式特征（如果启用）
 	if p.config.ParseStyle {
 		styleParser := NewDocxStyleParser(&zipReader.Reader)
+		styleParser.quota = quota
 		docxStyleFeatures, err := styleParser.Parse()
 		if err == nil && docxStyleFeatures != nil {
 			// 转换为 extractor.StyleFeatures
@@ -187,12 +193,12 @@ func (p *DocxProcessor) convertStyleFeatures(dsf *DocxStyleFeatures) *extractor.
 }
 
 // extractText 提取所有文本内容
-func (p *DocxProcessor) extractText(zipReader *zip.Reader) (string, error) {
+func (p *DocxProcessor) extractText(zipReader *zip.Reader, quota *archiveguard.Quota) (string, error) {
 	var textBuilder strings.Builder
 
 	// 提取页眉
 	if p.config.ExtractHeaders {
-		headers := p.extractHeaders(zipReader)
+		headers := p.extractHeaders(zipReader, quota)
 		if headers != "" {
 			textBuilder.WriteString(headers)
 			textBuilder.WriteString("\n")
@@ -200,7 +206,7 @@ func (p *DocxProcessor) extractText(zipReader *zip.Reader) (string, error) {
 	}
 
 	// 提取主文档内容
-	mainContent, err := p.extractDocument(zipReader)
+	mainContent, err := p.extractDocument(zipReader, quota)
 	if err != nil {
 		return "", err
 	}
@@ -208,7 +214,7 @@ func (p *DocxProcessor) extractText(zipReader *zip.Reader) (string, error) {
 
 	// 提取页脚
 	if p.config.ExtractFooters {
-		footers := p.extractFooters(zipReader)
+		footers := p.extractFooters(zipReader, quota)
 		if footers != "" {
 			textBuilder.WriteString("\n")
 			textBuilder.WriteString(footers)
@@ -226,10 +232,10 @@ func (p *DocxProcessor) extractText(zipReader *zip.Reader) (string, error) {
 }
 
 // extractDocument 提取主文档内容
-func (p *DocxProcessor) extractDocument(zipReader *zip.Reader) (string, error) {
+func (p *DocxProcessor) extractDocument(zipReader *zip.Reader, quota *archiveguard.Quota) (string, error) {
 	for _, file := range zipReader.File {
 		if file.Name == "word/document.xml" {
-			return p.extractXMLText(file)
+			return p.extractXMLText(quota, file)
 		}
 	}
 
@@ -237,12 +243,12 @@ func (p *DocxProcessor) extractDocument(zipReader *zip.Reader) (string, error) {
 }
 
 // extractHeaders 提取所有页眉
-func (p *DocxProcessor) extractHeaders(zipReader *zip.Reader) string {
+func (p *DocxProcessor) extractHeaders(zipReader *zip.Reader, quota *archiveguard.Quota) string {
 	var headers []string
 
 	for _, file := range zipReader.File {
 		if strings.HasPrefix(file.Name, "word/header") && strings.HasSuffix(file.Name, ".xml") {
-			if text, err := p.extractXMLText(file); err == nil && text != "" {
+			if text, err := p.extractXMLText(quota, file); err == nil && text != "" {
 				headers = append(headers, text)
 			}
 		}
@@ -252,12 +258,12 @@ func (p *DocxProcessor) extractHeaders(zipReader *zip.Reader) string {
 }
 
 // extractFooters 提取所有页脚
-func (p *DocxProcessor) extractFooters(zipReader *zip.Reader) string {
+func (p *DocxProcessor) extractFooters(zipReader *zip.Reader, quota *archiveguard.Quota) string {
 	var footers []string
 
 	for _, file := range zipReader.File {
 		if strings.HasPrefix(file.Name, "word/footer") && strings.HasSuffix(file.Name, ".xml") {
-			if text, err := p.extractXMLText(file); err == nil && text != "" {
+			if text, err := p.extractXMLText(quota, file); err == nil && text != "" {
 				footers = append(footers, text)
 			}
 		}
@@ -267,14 +273,8 @@ func (p *DocxProcessor) extractFooters(zipReader *zip.Reader) string {
 }
 
 // extractXMLText 从XML文件中提取文本
-func (p *DocxProcessor) extractXMLText(file *zip.File) (string, error) {
-	rc, err := file.Open()
-	if err != nil {
-		return "", err
-	}
-	defer rc.Close()
-
-	content, err := io.ReadAll(rc)
+func (p *DocxProcessor) extractXMLText(quota *archiveguard.Quota, file *zip.File) (string, error) {
+	content, err := quota.ReadAll(file)
 	if err != nil {
 		return "", err
 	}
@@ -384,6 +384,7 @@ type ProcessResultWithStyle struct {
 	Text          string                   // 提取的文本内容
 	StyleFeatures *extractor.StyleFeatures // 版式特征
 	HasStyle      bool                     // 是否包含版式信息
+	Partial       bool                     // 解析超出内存预算被截断，文本不完整
 }
 
 // ============================================================
@@ -460,6 +461,7 @@ func (p *WpsProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithStyl
 // processAsZip 作为 ZIP 格式处理
 func (p *WpsProcessor) processAsZip(filePath string, zipReader *zip.Reader) (*ProcessResultWithStyle, error) {
 	result := &ProcessResultWithStyle{}
+	quota := archiveguard.Default()
 
 	// 尝试多种可能的文档路径
 	documentPaths := []string{
@@ -475,7 +477,10 @@ func (p *WpsProcessor) processAsZip(filePath string, zipReader *zip.Reader) (*Pr
 	for _, docPath := range documentPaths {
 		for _, file := range zipReader.File {
 			if file.Name == docPath {
-				content, err := p.extractXMLText(file)
+				content, err := p.extractXMLText(quota, file)
+				if errors.Is(err, archiveguard.ErrBomb) {
+					return nil, NewProcessorError(p.Name(), filePath, "提取内容", err)
+				}
 				if err == nil && content != "" {
 					mainContent = content
 					foundPath = docPath
@@ -493,7 +498,10 @@ func (p *WpsProcessor) processAsZip(filePath string, zipReader *zip.Reader) (*Pr
 		var allText strings.Builder
 		for _, file := range zipReader.File {
 			if strings.HasSuffix(strings.ToLower(file.Name), ".xml") {
-				content, err := p.extractXMLText(file)
+				content, err := p.extractXMLText(quota, file)
+				if errors.Is(err, archiveguard.ErrBomb) {
+					return nil, NewProcessorError(p.Name(), filePath, "提取内容", err)
+				}
 				if err == nil && content != "" {
 					allText.WriteString(content)
 					allText.WriteString("\n")
@@ -512,6 +520,7 @@ func (p *WpsProcessor) processAsZip(filePath string, zipReader *zip.Reader) (*Pr
 	// 如果是 word/document.xml，尝试解析版式特征
 	if foundPath == "word/document.xml" {
 		styleParser := NewDocxStyleParser(zipReader)
+		styleParser.quota = quota
 		if features, err := styleParser.Parse(); err == nil && features != nil {
 			result.StyleFeatures = p.docxParser.convertStyleFeatures(features)
 			result.HasStyle = true
@@ -551,14 +560,8 @@ func (p *WpsProcessor) processAsOLE2(filePath string) (*ProcessResultWithStyle,
 }
 
 // extractXMLText 从 XML 文件中提取文本
-func (p *WpsProcessor) extractXMLText(file *zip.File) (string, error) {
-	rc, err := file.Open()
-	if err != nil {
-		return "", err
-	}
-	defer rc.Close()
-
-	content, err := io.ReadAll(rc)
+func (p *WpsProcessor) extractXMLText(quota *archiveguard.Quota, file *zip.File) (string, error) {
+	content, err := quota.ReadAll(file)
 	if err != nil {
 		return "", err
 	}
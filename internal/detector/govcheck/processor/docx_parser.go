@@ -4,21 +4,28 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"io"
+	"math"
 	"strconv"
 	"strings"
+
+	"linuxFileWatcher/internal/archiveguard"
 )
 
 // DocxStyleParser DOCX样式解析器
 type DocxStyleParser struct {
 	zipReader *zip.Reader
+	quota     *archiveguard.Quota // 包内文件的展开配额，与同一文件的文本抽取共用
 	features  *DocxStyleFeatures
+	styles    *docxStyleSheet // 样式表，解析正文前加载
 }
 
 // NewDocxStyleParser 创建样式解析器
 func NewDocxStyleParser(zipReader *zip.Reader) *DocxStyleParser {
 	return &DocxStyleParser{
 		zipReader: zipReader,
+		quota:     archiveguard.Default(),
 		features:  NewDocxStyleFeatures(),
 	}
 }
@@ -27,7 +34,10 @@ func NewDocxStyleParser(zipReader *zip.Reader) *DocxStyleParser {
 func (p *DocxStyleParser) Parse() (*DocxStyleFeatures, error) {
 	// 1. 解析主文档内容（颜色、字体、段落）
 	if err := p.parseDocument(); err != nil {
-		// 不中断，继续解析其他内容
+		// 不中断，继续解析其他内容；超出展开配额 (压缩炸弹) 时中止
+		if errors.Is(err, archiveguard.ErrBomb) {
+			return nil, err
+		}
 	}
 
 	// 2. 解析样式定义
@@ -57,6 +67,13 @@ func (p *DocxStyleParser) Parse() (*DocxStyleFeatures, error) {
 
 // parseDocument 解析主文档
 func (p *DocxStyleParser) parseDocument() error {
+	// 未设置直接格式的文本使用样式表中的字体、字号和行距
+	styles, err := p.readZipFile("word/styles.xml")
+	if errors.Is(err, archiveguard.ErrBomb) {
+		return err
+	}
+	p.styles = parseDocxStyleSheet(styles)
+
 	content, err := p.readZipFile("word/document.xml")
 	if err != nil {
 		return err
@@ -66,8 +83,18 @@ func (p *DocxStyleParser) parseDocument() error {
 }
 
 // parseDocumentXML 解析文档XML
+// 文本运行的字体、字号、加粗按 文档默认值 -> 段落样式 -> 字符样式 -> 直接格式 的顺序解析
 func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 	decoder := xml.NewDecoder(bytes.NewReader(content))
+	if p.styles == nil {
+		p.styles = parseDocxStyleSheet(nil)
+	}
+
+	// 当前段落样式给出的字体属性与行距
+	var paraRun docxRunProps
+	var paraLine docxLineSpacing
+	paraHasText := false
+	lineSpacingCounts := make(map[float64]int) // 行距（磅，取 0.5 的整数倍） -> 段落数
 
 	var currentColor string
 	var currentFontName string
@@ -99,6 +126,8 @@ func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 				inParagraph = true
 				paragraphIndex++
 				p.features.ParagraphFeatures.TotalParagraphs++
+				paraRun, paraLine = p.styles.paragraph("")
+				paraHasText = false
 
 				// 检查段落对齐方式
 				for _, attr := range t.Attr {
@@ -109,29 +138,30 @@ func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 
 			case "pPr": // 段落属性
 				// 解析段落属性
-				p.parseParagraphProperties(decoder)
+				styleID, line := p.parseParagraphProperties(decoder)
+				paraRun, paraLine = p.styles.paragraph(styleID)
+				if line.Set {
+					paraLine = line
+				}
 
 			case "r": // Run（文本运行）开始
 				inRun = true
 				currentColor = ""
-				currentFontName = ""
-				currentFontSize = 0
-				currentIsBold = false
+				currentFontName = paraRun.Font
+				currentFontSize = paraRun.Size
+				currentIsBold = paraRun.Bold
 				currentText.Reset()
 
 			case "rPr": // Run属性
 				if inRun {
-					color, font, size, bold := p.parseRunProperties(decoder)
+					color, direct := p.parseRunProperties(decoder)
 					if color != "" {
 						currentColor = color
 					}
-					if font != "" {
-						currentFontName = font
-					}
-					if size > 0 {
-						currentFontSize = size
-					}
-					currentIsBold = bold
+					run := paraRun.merge(p.styles.character(direct.StyleID)).merge(direct)
+					currentFontName = run.Font
+					currentFontSize = run.Size
+					currentIsBold = run.Bold
 				}
 
 			case "t": // 文本内容
@@ -172,9 +202,19 @@ func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 			case "p": // 段落结束
 				inParagraph = false
 
+				// 只统计有文本的段落的行距
+				if paraHasText {
+					if pt := paraLine.points(paraRun.Size); pt > 0 {
+						lineSpacingCounts[math.Round(pt*2)/2]++
+					}
+				}
+
 			case "r": // Run结束
 				if inRun {
 					text := strings.TrimSpace(currentText.String())
+					if text != "" {
+						paraHasText = true
+					}
 
 					// 记录颜色信息
 					if currentColor != "" {
@@ -252,6 +292,12 @@ func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 		_ = inParagraph // 避免未使用警告
 	}
 
+	// 行距取有文本段落中最常见的值（正文段落占多数）
+	if spacing := mostCommonLineSpacing(lineSpacingCounts); spacing > 0 {
+		p.features.ParagraphFeatures.LineSpacing = spacing
+		p.features.ParagraphFeatures.LineSpacingMatch = IsStandardLineSpacing(spacing)
+	}
+
 	// 整理颜色信息
 	for color, count := range colorCounts {
 		if count > 0 {
@@ -268,8 +314,8 @@ func (p *DocxStyleParser) parseDocumentXML(content []byte) error {
 	return nil
 }
 
-// parseRunProperties 解析Run属性
-func (p *DocxStyleParser) parseRunProperties(decoder *xml.Decoder) (color, font string, size float64, bold bool) {
+// parseRunProperties 解析Run属性，返回颜色与直接设置的字体属性（含字符样式ID）
+func (p *DocxStyleParser) parseRunProperties(decoder *xml.Decoder) (color string, props docxRunProps) {
 	depth := 1
 
 	for depth > 0 {
@@ -291,10 +337,14 @@ func (p *DocxStyleParser) parseRunProperties(decoder *xml.Decoder) (color, font
 				}
 
 			case "sz", "szCs":
+				// szCs 为复杂文种字号，仅在未设置 sz 时使用
+				if t.Name.Local == "szCs" && props.Size > 0 {
+					break
+				}
 				for _, attr := range t.Attr {
 					if attr.Name.Local == "val" {
-						if s, err := strconv.ParseFloat(attr.Value, 64); err == nil {
-							size = s / 2.0 // 半磅转磅
+						if size := parseHalfPoints(attr.Value); size > 0 {
+							props.Size = size // 半磅转磅
 						}
 					}
 				}
@@ -302,16 +352,19 @@ func (p *DocxStyleParser) parseRunProperties(decoder *xml.Decoder) (color, font
 			case "rFonts":
 				for _, attr := range t.Attr {
 					if attr.Name.Local == "eastAsia" {
-						font = attr.Value
+						props.Font = attr.Value
 						break
 					}
-					if attr.Name.Local == "ascii" && font == "" {
-						font = attr.Value
+					if attr.Name.Local == "ascii" && props.Font == "" {
+						props.Font = attr.Value
 					}
 				}
 
 			case "b":
-				bold = true
+				props.Bold, props.BoldSet = docxOnOff(docxAttr(t, "val")), true
+
+			case "rStyle":
+				props.StyleID = docxAttr(t, "val")
 			}
 
 		case xml.EndElement:
@@ -325,8 +378,8 @@ func (p *DocxStyleParser) parseRunProperties(decoder *xml.Decoder) (color, font
 	return
 }
 
-// parseParagraphProperties 解析段落属性
-func (p *DocxStyleParser) parseParagraphProperties(decoder *xml.Decoder) {
+// parseParagraphProperties 解析段落属性，返回段落样式ID与直接设置的行距
+func (p *DocxStyleParser) parseParagraphProperties(decoder *xml.Decoder) (styleID string, line docxLineSpacing) {
 	depth := 1
 
 	for depth > 0 {
@@ -363,17 +416,13 @@ func (p *DocxStyleParser) parseParagraphProperties(decoder *xml.Decoder) {
 					}
 				}
 
-			case "spacing": // 行距
-				for _, attr := range t.Attr {
-					if attr.Name.Local == "line" {
-						if val, err := strconv.ParseFloat(attr.Value, 64); err == nil {
-							// 行距以 twips 为单位
-							spacingPt := TwipsToPt(val)
-							p.features.ParagraphFeatures.LineSpacing = spacingPt
-							p.features.ParagraphFeatures.LineSpacingMatch = IsStandardLineSpacing(spacingPt)
-						}
-					}
+			case "spacing": // 行距，按段落统计后确定（段落标记 rPr 中的 spacing 为字符间距，没有 line 属性）
+				if l := parseDocxLineSpacing(docxAttr(t, "line"), docxAttr(t, "lineRule")); l.Set {
+					line = l
 				}
+
+			case "pStyle": // 段落样式
+				styleID = docxAttr(t, "val")
 			}
 
 		case xml.EndElement:
@@ -383,6 +432,8 @@ func (p *DocxStyleParser) parseParagraphProperties(decoder *xml.Decoder) {
 			}
 		}
 	}
+
+	return
 }
 
 // updateFontDistribution 更新字体分布统计
@@ -628,7 +679,7 @@ func (p *DocxStyleParser) parseImages() error {
 
 // checkImageRedish 检查图片是否为红色主导
 func (p *DocxStyleParser) checkImageRedish(file *zip.File) (bool, error) {
-	rc, err := file.Open()
+	rc, err := p.quota.Open(file)
 	if err != nil {
 		return false, err
 	}
@@ -758,13 +809,7 @@ func (p *DocxStyleParser) calculateStyleScore() {
 func (p *DocxStyleParser) readZipFile(name string) ([]byte, error) {
 	for _, file := range p.zipReader.File {
 		if file.Name == name {
-			rc, err := file.Open()
-			if err != nil {
-				return nil, err
-			}
-			defer rc.Close()
-
-			return io.ReadAll(rc)
+			return p.quota.ReadAll(file)
 		}
 	}
 
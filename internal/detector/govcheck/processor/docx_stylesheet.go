@@ -0,0 +1,256 @@
+package processor
+
+import (
+	"encoding/xml"
+	"strconv"
+)
+
+// ============================================================
+// DOCX 样式表 (word/styles.xml) 解析与继承
+// ============================================================
+
+// maxDocxStyleDepth basedOn 继承链的最大长度，防止循环引用
+const maxDocxStyleDepth = 16
+
+// docxAutoLineFactor 单倍行距 (lineRule=auto) 约为字号的 1.3 倍 (中文字体在 Word 中的典型值)
+const docxAutoLineFactor = 1.3
+
+// docxDefaultFontSize Word 未设置字号时的默认值 (五号，10.5 磅)
+const docxDefaultFontSize = 10.5
+
+// docxRunProps 文本运行的字体属性，零值表示未设置
+type docxRunProps struct {
+	Font    string  // 字体名称，优先取 eastAsia
+	Size    float64 // 字号（磅）
+	Bold    bool
+	BoldSet bool   // 是否显式设置了加粗 (含 <w:b w:val="0"/> 取消加粗)
+	StyleID string // 字符样式 (rStyle)，仅直接格式使用
+}
+
+// merge 以 o 中已设置的属性覆盖 r
+func (r docxRunProps) merge(o docxRunProps) docxRunProps {
+	if o.Font != "" {
+		r.Font = o.Font
+	}
+	if o.Size > 0 {
+		r.Size = o.Size
+	}
+	if o.BoldSet {
+		r.Bold, r.BoldSet = o.Bold, true
+	}
+	return r
+}
+
+// docxLineSpacing 段落行距 <w:spacing w:line="" w:lineRule=""/>
+type docxLineSpacing struct {
+	Line float64 // exact/atLeast 时以 twips 为单位，auto 时以 1/240 行为单位
+	Rule string
+	Set  bool
+}
+
+// points 行距（磅），auto 按字号估算单倍行高
+func (s docxLineSpacing) points(fontSize float64) float64 {
+	if !s.Set || s.Line <= 0 {
+		return 0
+	}
+	switch s.Rule {
+	case "exact", "atLeast":
+		return TwipsToPt(s.Line)
+	default:
+		if fontSize <= 0 {
+			fontSize = docxDefaultFontSize
+		}
+		return s.Line / 240 * fontSize * docxAutoLineFactor
+	}
+}
+
+// docxStyleDef 一个样式定义
+type docxStyleDef struct {
+	basedOn string
+	run     docxRunProps
+	line    docxLineSpacing
+}
+
+// docxStyleSheet 文档默认格式与样式定义
+type docxStyleSheet struct {
+	defaultRun   docxRunProps
+	defaultLine  docxLineSpacing
+	defaultStyle string // 默认段落样式 (w:default="1")
+	styles       map[string]docxStyleDef
+}
+
+// docxStylesXML styles.xml 中用到的部分
+type docxStylesXML struct {
+	RunDefaults  docxRPrXML     `xml:"docDefaults>rPrDefault>rPr"`
+	ParaDefaults docxPPrXML     `xml:"docDefaults>pPrDefault>pPr"`
+	Styles       []docxStyleXML `xml:"style"`
+}
+
+type docxStyleXML struct {
+	Type    string      `xml:"type,attr"`
+	ID      string      `xml:"styleId,attr"`
+	Default string      `xml:"default,attr"`
+	BasedOn *docxValXML `xml:"basedOn"`
+	RPr     docxRPrXML  `xml:"rPr"`
+	PPr     docxPPrXML  `xml:"pPr"`
+}
+
+type docxValXML struct {
+	Val string `xml:"val,attr"`
+}
+
+type docxRPrXML struct {
+	Fonts *struct {
+		EastAsia string `xml:"eastAsia,attr"`
+		ASCII    string `xml:"ascii,attr"`
+	} `xml:"rFonts"`
+	Sz *docxValXML `xml:"sz"`
+	B  *docxValXML `xml:"b"`
+}
+
+type docxPPrXML struct {
+	Spacing *struct {
+		Line     string `xml:"line,attr"`
+		LineRule string `xml:"lineRule,attr"`
+	} `xml:"spacing"`
+}
+
+func (x docxRPrXML) props() docxRunProps {
+	var r docxRunProps
+	if x.Fonts != nil {
+		r.Font = x.Fonts.EastAsia
+		if r.Font == "" {
+			r.Font = x.Fonts.ASCII
+		}
+	}
+	if x.Sz != nil {
+		r.Size = parseHalfPoints(x.Sz.Val)
+	}
+	if x.B != nil {
+		r.Bold, r.BoldSet = docxOnOff(x.B.Val), true
+	}
+	return r
+}
+
+func (x docxPPrXML) lineSpacing() docxLineSpacing {
+	if x.Spacing == nil {
+		return docxLineSpacing{}
+	}
+	return parseDocxLineSpacing(x.Spacing.Line, x.Spacing.LineRule)
+}
+
+// parseDocxStyleSheet 解析 styles.xml，内容为空或解析失败时返回空样式表
+func parseDocxStyleSheet(content []byte) *docxStyleSheet {
+	sheet := &docxStyleSheet{styles: make(map[string]docxStyleDef)}
+	if len(content) == 0 {
+		return sheet
+	}
+
+	var doc docxStylesXML
+	if err := xml.Unmarshal(content, &doc); err != nil {
+		return sheet
+	}
+
+	sheet.defaultRun = doc.RunDefaults.props()
+	sheet.defaultLine = doc.ParaDefaults.lineSpacing()
+	for _, s := range doc.Styles {
+		if s.ID == "" {
+			continue
+		}
+		def := docxStyleDef{run: s.RPr.props(), line: s.PPr.lineSpacing()}
+		if s.BasedOn != nil {
+			def.basedOn = s.BasedOn.Val
+		}
+		sheet.styles[s.ID] = def
+		if s.Type == "paragraph" && s.Default != "" && docxOnOff(s.Default) && sheet.defaultStyle == "" {
+			sheet.defaultStyle = s.ID
+		}
+	}
+	return sheet
+}
+
+// chain 样式及其 basedOn 祖先，从最上层祖先开始
+func (s *docxStyleSheet) chain(id string) []docxStyleDef {
+	var defs []docxStyleDef
+	for i := 0; id != "" && i < maxDocxStyleDepth; i++ {
+		def, ok := s.styles[id]
+		if !ok {
+			break
+		}
+		defs = append([]docxStyleDef{def}, defs...)
+		id = def.basedOn
+	}
+	return defs
+}
+
+// paragraph 段落样式的最终字体属性与行距 (文档默认值 + 继承链)，id 为空时使用默认段落样式
+func (s *docxStyleSheet) paragraph(id string) (docxRunProps, docxLineSpacing) {
+	if id == "" {
+		id = s.defaultStyle
+	}
+	run, line := s.defaultRun, s.defaultLine
+	for _, def := range s.chain(id) {
+		run = run.merge(def.run)
+		if def.line.Set {
+			line = def.line
+		}
+	}
+	return run, line
+}
+
+// character 字符样式的字体属性 (只含样式自身及其继承链的设置)
+func (s *docxStyleSheet) character(id string) docxRunProps {
+	var run docxRunProps
+	for _, def := range s.chain(id) {
+		run = run.merge(def.run)
+	}
+	return run
+}
+
+// parseDocxLineSpacing 解析 spacing 的 line 与 lineRule 属性
+func parseDocxLineSpacing(line, rule string) docxLineSpacing {
+	v, err := strconv.ParseFloat(line, 64)
+	if err != nil || v <= 0 {
+		return docxLineSpacing{}
+	}
+	return docxLineSpacing{Line: v, Rule: rule, Set: true}
+}
+
+// parseHalfPoints 半磅转磅，无效时返回 0
+func parseHalfPoints(val string) float64 {
+	v, err := strconv.ParseFloat(val, 64)
+	if err != nil || v <= 0 {
+		return 0
+	}
+	return v / 2.0
+}
+
+// docxOnOff OOXML 开关属性，省略 val 表示开启
+func docxOnOff(val string) bool {
+	switch val {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
+
+// docxAttr 按本地名称取元素属性
+func docxAttr(t xml.StartElement, name string) string {
+	for _, attr := range t.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// mostCommonLineSpacing 出现次数最多的行距，次数相同时取较小值
+func mostCommonLineSpacing(counts map[float64]int) float64 {
+	best, bestCount := 0.0, 0
+	for spacing, n := range counts {
+		if n > bestCount || (n == bestCount && spacing < best) {
+			best, bestCount = spacing, n
+		}
+	}
+	return best
+}
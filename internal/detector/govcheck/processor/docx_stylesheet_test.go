@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"testing"
+)
+
+// ============================================================
+// DOCX 样式继承测试
+// ============================================================
+
+const testDocxStyles = `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+  <w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:eastAsia="仿宋_GB2312"/><w:sz w:val="32"/></w:rPr></w:rPrDefault>
+  <w:pPrDefault><w:pPr><w:spacing w:line="560" w:lineRule="exact"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading"><w:basedOn w:val="Normal"/><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="DocTitle"><w:basedOn w:val="Heading"/><w:rPr><w:rFonts w:eastAsia="方正小标宋简体"/><w:sz w:val="44"/></w:rPr><w:pPr><w:spacing w:line="240" w:lineRule="auto"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Loop"><w:basedOn w:val="Loop"/></w:style>
+<w:style w:type="character" w:styleId="Plain"><w:rPr><w:b w:val="0"/></w:rPr></w:style>
+</w:styles>`
+
+func TestDocxStyleSheet_Resolve(t *testing.T) {
+	sheet := parseDocxStyleSheet([]byte(testDocxStyles))
+
+	run, line := sheet.paragraph("")
+	if run.Font != "仿宋_GB2312" || run.Size != 16 || run.Bold {
+		t.Errorf("默认段落样式 = %+v", run)
+	}
+	if got := line.points(run.Size); got != 28 {
+		t.Errorf("默认行距 = %v, want 28", got)
+	}
+
+	// DocTitle -> Heading -> Normal 逐级继承
+	run, line = sheet.paragraph("DocTitle")
+	if run.Font != "方正小标宋简体" || run.Size != 22 || !run.Bold {
+		t.Errorf("DocTitle = %+v", run)
+	}
+	if got := line.points(run.Size); got < 28 || got > 29 {
+		t.Errorf("DocTitle 单倍行距 = %v, want 约 28.6", got)
+	}
+
+	// 循环继承与不存在的样式不会死循环
+	if run, _ := sheet.paragraph("Loop"); run.Size != 16 {
+		t.Errorf("Loop = %+v", run)
+	}
+	if run, _ := sheet.paragraph("Missing"); run.Size != 16 {
+		t.Errorf("Missing = %+v", run)
+	}
+
+	if c := sheet.character("Plain"); !c.BoldSet || c.Bold {
+		t.Errorf("Plain = %+v", c)
+	}
+}
+
+func TestDocxStyleParser_StyledDocument(t *testing.T) {
+	// 正文只通过样式表设置三号仿宋、28 磅固定行距，标题段落使用 DocTitle 样式
+	doc := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="DocTitle"/><w:jc w:val="center"/></w:pPr><w:r><w:t>关于开展检查工作的通知</w:t></w:r></w:p>
+<w:p><w:r><w:t>各有关单位：</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:rStyle w:val="Plain"/></w:rPr><w:t>现将有关事项通知如下。</w:t></w:r></w:p>
+<w:p><w:pPr><w:spacing w:before="120"/></w:pPr><w:r><w:t>一、检查范围</w:t></w:r></w:p>
+<w:p><w:pPr><w:spacing w:line="400" w:lineRule="exact"/></w:pPr></w:p>
+</w:body></w:document>`
+
+	p := NewDocxStyleParser(nil)
+	p.styles = parseDocxStyleSheet([]byte(testDocxStyles))
+	if err := p.parseDocumentXML([]byte(doc)); err != nil {
+		t.Fatalf("parseDocumentXML() error = %v", err)
+	}
+
+	ff := p.features.FontFeatures
+	if !ff.TitleFontMatch || !ff.BodyFontMatch || !ff.HasOfficialFonts {
+		t.Errorf("TitleFontMatch=%v BodyFontMatch=%v HasOfficialFonts=%v", ff.TitleFontMatch, ff.BodyFontMatch, ff.HasOfficialFonts)
+	}
+
+	// 空段落的 20 磅行距不参与统计，标题段落为少数
+	pf := p.features.ParagraphFeatures
+	if pf.LineSpacing != 28 || !pf.LineSpacingMatch {
+		t.Errorf("LineSpacing=%v LineSpacingMatch=%v", pf.LineSpacing, pf.LineSpacingMatch)
+	}
+}
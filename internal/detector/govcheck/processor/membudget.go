@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// ============================================================
+// 单文件解析内存预算
+// ============================================================
+
+// DefaultMaxExtractMemory 单文件解析的默认内存预算
+// 超出预算时停止解析并返回已抽取的部分文本 (Partial)，而不是让畸形文件耗尽代理进程内存
+const DefaultMaxExtractMemory = 64 * 1024 * 1024
+
+// memBudget 单文件解析的内存记账，按累计分配量计；nil 表示不限制
+type memBudget struct {
+	limit    int64
+	used     int64
+	exceeded bool
+}
+
+// newMemBudget 创建预算，limit<=0 时不限制 (返回 nil)
+func newMemBudget(limit int64) *memBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &memBudget{limit: limit}
+}
+
+// take 记账 n 字节，超出预算时返回 false 并标记为部分抽取
+func (b *memBudget) take(n int) bool {
+	if b == nil {
+		return true
+	}
+	if b.used+int64(n) > b.limit {
+		b.exceeded = true
+		return false
+	}
+	b.used += int64(n)
+	return true
+}
+
+// remaining 剩余预算，不限制时返回 -1
+func (b *memBudget) remaining() int64 {
+	if b == nil {
+		return -1
+	}
+	return b.limit - b.used
+}
+
+// partial 是否因超出预算而截断
+func (b *memBudget) partial() bool {
+	return b != nil && b.exceeded
+}
+
+// ============================================================
+// 只读映射读取
+// ============================================================
+
+// mappedFile 只读映射的文件内容，不占用 Go 堆；平台不支持映射时退化为整体读入
+// 解析结果不得引用 data，Close 后 data 失效
+type mappedFile struct {
+	data  []byte
+	unmap func() error
+}
+
+// Close 解除映射
+func (m *mappedFile) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.data, m.unmap = nil, nil
+	return err
+}
+
+// guardFault 执行 fn，访问映射内存时的总线错误 (文件在解析期间被截断) 转为错误返回
+// fn 必须在当前 goroutine 中访问映射内存
+func guardFault(fn func() error) (err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			if fault, ok := r.(interface{ Addr() uintptr }); ok {
+				err = fmt.Errorf("读取文件映射失败 (文件可能在解析期间被截断): %v", fault)
+				return
+			}
+			panic(r)
+		}
+	}()
+	return fn()
+}
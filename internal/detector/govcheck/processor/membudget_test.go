@@ -0,0 +1,79 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// 解析内存预算测试
+// ============================================================
+
+func flateStream(t *testing.T, data []byte) PdfStreamObject {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return PdfStreamObject{
+		Dict:    PdfDictObject{Dict: map[string]PdfObject{"Filter": PdfNameObject{Value: "FlateDecode"}}},
+		RawData: buf.Bytes(),
+	}
+}
+
+func TestPdfParser_DecodeStreamBudget(t *testing.T) {
+	// 高压缩比的流 (压缩炸弹) 超出预算时截断
+	stream := flateStream(t, bytes.Repeat([]byte("BT (x) Tj ET\n"), 10000))
+
+	parser := NewPdfParser(nil)
+	parser.SetMemoryLimit(1000)
+	data, err := parser.decodeStream(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1000 || !parser.Partial() {
+		t.Errorf("解码 %d 字节, Partial() = %v", len(data), parser.Partial())
+	}
+
+	// 预算用尽后不再解码
+	if data, _ := parser.decodeStream(stream); len(data) != 0 {
+		t.Errorf("预算用尽后解码 %d 字节", len(data))
+	}
+
+	// 不限制时完整解码
+	parser = NewPdfParser(nil)
+	data, err = parser.decodeStream(stream)
+	if err != nil || len(data) != 130000 || parser.Partial() {
+		t.Errorf("不限制: %d 字节, err = %v, Partial() = %v", len(data), err, parser.Partial())
+	}
+}
+
+func TestDocProcessor_ExtractBasicBudget(t *testing.T) {
+	// UTF-16LE 中文文本
+	var content []byte
+	for _, r := range strings.Repeat("关于印发通知的函", 2000) {
+		content = append(content, byte(r), byte(r>>8))
+	}
+	path := filepath.Join(t.TempDir(), "a.doc")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewDocProcessorWithConfig(&DocProcessorConfig{MaxMemory: 4000})
+	text, partial, err := p.extractBasic(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !partial || !strings.Contains(text, "关于印发") || len(text) > 4000 {
+		t.Errorf("partial = %v, 文本 %d 字节", partial, len(text))
+	}
+
+	p = NewDocProcessor()
+	if _, partial, err := p.extractBasic(path); err != nil || partial {
+		t.Errorf("默认预算: partial = %v, err = %v", partial, err)
+	}
+}
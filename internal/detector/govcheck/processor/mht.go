@@ -0,0 +1,265 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================
+// MHT/MHTML 网页归档解析
+// ============================================================
+
+// mhtPart MHT 归档中解码后的单个 MIME 部件
+type mhtPart struct {
+	ContentType string // 媒体类型 (小写，不含参数)
+	Charset     string // 声明的字符集
+	Location    string // Content-Location
+	Data        []byte // 已完成传输编码解码的原始数据
+}
+
+// mhtArchive MHT 归档解析结果
+type mhtArchive struct {
+	HTMLParts  []mhtPart
+	TextParts  []mhtPart
+	ImageParts []mhtPart
+}
+
+// parseMHT 解析 MHT 归档
+// MHT 本质是 multipart/related 邮件格式，各部件可能使用 quoted-printable 或 base64 编码
+func parseMHT(content []byte) (*mhtArchive, error) {
+	header, body, err := splitMIMEHeader(content)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &mhtArchive{}
+	if err := collectMIMEParts(header, body, archive, 0); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// splitMIMEHeader 拆分 MIME 头部与正文
+func splitMIMEHeader(content []byte) (textproto.MIMEHeader, []byte, error) {
+	sep := []byte("\r\n\r\n")
+	idx := bytes.Index(content, sep)
+	if idx < 0 {
+		sep = []byte("\n\n")
+		idx = bytes.Index(content, sep)
+	}
+	if idx < 0 {
+		return nil, nil, fmt.Errorf("未找到 MIME 头部结束标记")
+	}
+
+	headerData := append(content[:idx:idx], sep...)
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(headerData)))
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, nil, fmt.Errorf("解析 MIME 头部失败: %w", err)
+	}
+	return header, content[idx+len(sep):], nil
+}
+
+// collectMIMEParts 递归收集 MIME 部件
+func collectMIMEParts(header textproto.MIMEHeader, body []byte, archive *mhtArchive, depth int) error {
+	// 防止恶意嵌套
+	if depth > 8 {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+	mediaType = strings.ToLower(mediaType)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return fmt.Errorf("multipart 部件缺少 boundary")
+		}
+
+		mr := multipart.NewReader(bytes.NewReader(body), boundary)
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// 截断的归档：保留已解析的部件
+				return nil
+			}
+
+			data, err := io.ReadAll(part)
+			if err != nil {
+				continue
+			}
+			if err := collectMIMEParts(part.Header, data, archive, depth+1); err != nil {
+				continue
+			}
+		}
+		return nil
+	}
+
+	decoded := decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+	part := mhtPart{
+		ContentType: mediaType,
+		Charset:     strings.ToLower(params["charset"]),
+		Location:    header.Get("Content-Location"),
+		Data:        decoded,
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		archive.HTMLParts = append(archive.HTMLParts, part)
+	case strings.HasPrefix(mediaType, "text/plain"):
+		archive.TextParts = append(archive.TextParts, part)
+	case strings.HasPrefix(mediaType, "image/"):
+		archive.ImageParts = append(archive.ImageParts, part)
+	}
+
+	return nil
+}
+
+// decodeTransferEncoding 按 Content-Transfer-Encoding 解码部件数据
+func decodeTransferEncoding(encoding string, data []byte) []byte {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		cleaned := bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, data)
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(cleaned)))
+		// 部分损坏时保留已解码部分
+		n, _ := base64.StdEncoding.Decode(decoded, cleaned)
+		return decoded[:n]
+
+	case "quoted-printable":
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+		if err != nil && len(decoded) == 0 {
+			return []byte(decodeQuotedPrintable(string(data)))
+		}
+		return decoded
+
+	default:
+		return data
+	}
+}
+
+// processMHT 提取 MHT 归档中的正文文本，并对内嵌图片执行 OCR
+func (p *TextProcessor) processMHT(content []byte) (string, error) {
+	archive, err := parseMHT(content)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+
+	for _, part := range archive.HTMLParts {
+		text := p.decodeWithCharset(part.Data, part.Charset)
+		sb.WriteString(p.processHTML(text))
+		sb.WriteString("\n")
+	}
+
+	// 仅当没有 HTML 正文时使用纯文本部件，避免重复计分
+	if len(archive.HTMLParts) == 0 {
+		for _, part := range archive.TextParts {
+			sb.WriteString(p.decodeWithCharset(part.Data, part.Charset))
+			sb.WriteString("\n")
+		}
+	}
+
+	if p.config.EnableInlineOCR && len(archive.ImageParts) > 0 {
+		ocrText := p.recognizeInlineImages(archive.ImageParts)
+		if ocrText != "" {
+			sb.WriteString("\n")
+			sb.WriteString(ocrText)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// recognizeInlineImages 对内嵌图片执行 OCR
+// 网页导出的公文常以图片形式保存红头和印章，需要 OCR 才能参与评分
+func (p *TextProcessor) recognizeInlineImages(parts []mhtPart) string {
+	ocr := GetOcrManager()
+	if !ocr.IsAvailable() {
+		return ""
+	}
+
+	tmpDir, err := os.MkdirTemp("", "mht_ocr_")
+	if err != nil {
+		return ""
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var sb strings.Builder
+	count := 0
+
+	for i, part := range parts {
+		if p.config.MaxInlineImages > 0 && count >= p.config.MaxInlineImages {
+			break
+		}
+		// 过小的图片通常是图标或间隔图，跳过
+		if len(part.Data) < p.config.MinInlineImageSize {
+			continue
+		}
+
+		ext := imageExtForMediaType(part.ContentType)
+		if ext == "" {
+			continue
+		}
+
+		imgPath := filepath.Join(tmpDir, fmt.Sprintf("inline_%d.%s", i, ext))
+		if err := os.WriteFile(imgPath, part.Data, 0600); err != nil {
+			continue
+		}
+		count++
+
+		text, err := ocr.Recognize(imgPath)
+		if err != nil {
+			continue
+		}
+		text = normalizeImageText(text)
+		if text != "" {
+			sb.WriteString(text)
+			sb.WriteString("\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// imageExtForMediaType 根据图片媒体类型返回扩展名
+func imageExtForMediaType(mediaType string) string {
+	switch mediaType {
+	case "image/png":
+		return "png"
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	case "image/bmp", "image/x-ms-bmp":
+		return "bmp"
+	case "image/tiff":
+		return "tif"
+	case "image/webp":
+		return "webp"
+	default:
+		return ""
+	}
+}
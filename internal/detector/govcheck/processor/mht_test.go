@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+// ============================================================
+// MHT 解析测试
+// ============================================================
+
+const sampleMHT = "From: <Saved by Blink>\r\n" +
+	"Subject: test\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/related; type=\"text/html\"; boundary=\"----BOUNDARY\"\r\n" +
+	"\r\n" +
+	"------BOUNDARY\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: http://example.com/doc.html\r\n" +
+	"\r\n" +
+	"<html><head><style>p{color:red}</style></head><body><p>=E5=85=B3=E4=BA=8E&amp;=\r\n" +
+	"=E9=80=9A=E7=9F=A5</p><script>var x=1;</script></body></html>\r\n" +
+	"------BOUNDARY\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"Content-Location: http://example.com/a.png\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"------BOUNDARY--\r\n"
+
+func TestParseMHT(t *testing.T) {
+	archive, err := parseMHT([]byte(sampleMHT))
+	if err != nil {
+		t.Fatalf("parseMHT 失败: %v", err)
+	}
+
+	if len(archive.HTMLParts) != 1 {
+		t.Fatalf("HTMLParts = %d, want 1", len(archive.HTMLParts))
+	}
+	if len(archive.ImageParts) != 1 {
+		t.Fatalf("ImageParts = %d, want 1", len(archive.ImageParts))
+	}
+
+	html := string(archive.HTMLParts[0].Data)
+	if !strings.Contains(html, "关于&amp;通知") {
+		t.Errorf("quoted-printable 解码错误: %q", html)
+	}
+
+	png := archive.ImageParts[0].Data
+	if len(png) < 4 || string(png[1:4]) != "PNG" {
+		t.Errorf("base64 解码错误: %v", png)
+	}
+}
+
+func TestParseMHT_NoHeader(t *testing.T) {
+	if _, err := parseMHT([]byte("<html>no mime header</html>")); err == nil {
+		t.Error("缺少 MIME 头部时应返回错误")
+	}
+}
+
+func TestStripHTMLTagsSimple_Invisible(t *testing.T) {
+	input := `<p>正文</p><script>alert("x")</script><!-- 注释 --><style>.a{}</style>&lt;结束&gt;`
+	got := stripHTMLTagsSimple(input)
+
+	for _, bad := range []string{"alert", "注释", ".a{}"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("结果不应包含 %q: %q", bad, got)
+		}
+	}
+	if !strings.Contains(got, "正文") || !strings.Contains(got, "<结束>") {
+		t.Errorf("正文或实体丢失: %q", got)
+	}
+}
+
+func TestImageExtForMediaType(t *testing.T) {
+	tests := map[string]string{
+		"image/png":  "png",
+		"image/jpeg": "jpg",
+		"image/gif":  "gif",
+		"text/html":  "",
+	}
+	for mediaType, want := range tests {
+		if got := imageExtForMediaType(mediaType); got != want {
+			t.Errorf("imageExtForMediaType(%q) = %q, want %q", mediaType, got, want)
+		}
+	}
+}
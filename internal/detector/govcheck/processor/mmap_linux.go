@@ -0,0 +1,44 @@
+//go:build linux
+
+package processor
+
+import (
+	"os"
+	"syscall"
+)
+
+// openMapped 只读映射整个文件，空文件返回空内容
+func openMapped(path string) (*mappedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return &mappedFile{}, nil
+	}
+	if int64(int(size)) != size {
+		return nil, syscall.EFBIG
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_PRIVATE)
+	if err != nil {
+		// 部分文件系统不支持映射，退回整体读入
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return &mappedFile{data: data}, nil
+	}
+	syscall.Madvise(data, syscall.MADV_SEQUENTIAL)
+	return &mappedFile{
+		data:  data,
+		unmap: func() error { return syscall.Munmap(data) },
+	}, nil
+}
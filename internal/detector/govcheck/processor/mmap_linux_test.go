@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var faultSink byte
+
+func TestGuardFault_TruncatedMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.pdf")
+	if err := os.WriteFile(path, make([]byte, 3*os.Getpagesize()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mapped, err := openMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mapped.Close()
+
+	// 映射后文件被截断，访问超出文件末尾的页触发总线错误
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	err = guardFault(func() error {
+		faultSink = mapped.data[2*os.Getpagesize()]
+		return nil
+	})
+	if err == nil {
+		t.Fatal("访问被截断的映射应返回错误")
+	}
+}
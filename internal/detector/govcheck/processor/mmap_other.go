@@ -0,0 +1,14 @@
+//go:build !linux
+
+package processor
+
+import "os"
+
+// openMapped 非 Linux 平台整体读入
+func openMapped(path string) (*mappedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{data: data}, nil
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"linuxFileWatcher/internal/budget"
 )
 
 // ============================================================
@@ -267,6 +269,9 @@ func (t *TesseractOcr) RecognizeWithLang(imagePath string, lang string) (string,
 	// tesseract imagePath stdout -l lang
 	args := []string{imagePath, "stdout", "-l", lang}
 
+	// tesseract 进程占用大量 CPU 与内存，按全局 OCR 并发上限排队
+	defer budget.Default().Limit(budget.ClassOCR)()
+
 	cmd := exec.Command(t.execPath, args...)
 
 	if t.dataPath != "" {
@@ -195,6 +195,12 @@ func (p *OfdProcessor) detectPageSettings(parser *OfdParser, sf *extractor.Style
 			sf.StyleReasons = append(sf.StyleReasons, "纸张为A4规格")
 		}
 	}
+
+	// 页边距: 与 DOCX 使用相同的公文标准
+	if top, bottom, left, right, ok := parser.GetMargins(); ok && CheckMargins(top, bottom, left, right) {
+		sf.MarginMatch = true
+		sf.StyleReasons = append(sf.StyleReasons, "页边距符合公文标准")
+	}
 }
 
 // detectColors 检测颜色
@@ -254,6 +260,12 @@ func (p *OfdProcessor) detectSignatures(parser *OfdParser, sf *extractor.StyleFe
 		sf.SealImageHint = "检测到电子签章"
 		sf.StyleReasons = append(sf.StyleReasons, "检测到电子签章")
 	}
+
+	// 印章名称即签章单位，作为机关名称线索提供给特征提取
+	if names := parser.GetSealNames(); len(names) > 0 {
+		sf.SealOrgNames = names
+		sf.SealImageHint = "检测到电子签章: " + strings.Join(names, "、")
+	}
 }
 
 // calculateStyleScore 计算版式得分
@@ -272,10 +284,13 @@ func (p *OfdProcessor) calculateStyleScore(sf *extractor.StyleFeatures) {
 		score += 0.20
 	}
 
-	// 页面设置 (0.15)
+	// 页面设置 (0.20)
 	if sf.IsA4Paper {
 		score += 0.15
 	}
+	if sf.MarginMatch {
+		score += 0.05
+	}
 
 	// 字体 (0.10)
 	if sf.HasOfficialFonts {
@@ -0,0 +1,141 @@
+package processor
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ============================================================
+// OFD 页面几何 (纸张尺寸与页边距)
+// ============================================================
+
+var (
+	ofdPageAreaPattern    = regexp.MustCompile(`(?s)<(PageArea|Area)>(.*?)</(?:PageArea|Area)>`)
+	ofdPhysicalBoxPattern = regexp.MustCompile(`<PhysicalBox>([^<]+)</PhysicalBox>`)
+	ofdContentBoxPattern  = regexp.MustCompile(`<ContentBox>([^<]+)</ContentBox>`)
+	ofdTextObjectPattern  = regexp.MustCompile(`(?s)<TextObject\b([^>]*)>(.*?)</TextObject>`)
+	ofdBoundaryPattern    = regexp.MustCompile(`\bBoundary="([^"]+)"`)
+	ofdTextCodePattern    = regexp.MustCompile(`<TextCode[^>]*>([^<]*)</TextCode>`)
+)
+
+// ofdBox OFD 矩形 "x y w h"，单位为毫米，原点在页面左上角
+type ofdBox struct {
+	X, Y, W, H float64
+}
+
+func (b ofdBox) empty() bool { return b.W <= 0 || b.H <= 0 }
+
+// union 两个矩形的外包矩形，空矩形不参与
+func (b ofdBox) union(o ofdBox) ofdBox {
+	if b.empty() {
+		return o
+	}
+	if o.empty() {
+		return b
+	}
+	x1, y1 := math.Min(b.X, o.X), math.Min(b.Y, o.Y)
+	x2, y2 := math.Max(b.X+b.W, o.X+o.W), math.Max(b.Y+b.H, o.Y+o.H)
+	return ofdBox{X: x1, Y: y1, W: x2 - x1, H: y2 - y1}
+}
+
+// inside 是否严格位于 page 之内 (与页面重合的版心等于未设置页边距)
+func (b ofdBox) inside(page ofdBox) bool {
+	return !b.empty() && b != page &&
+		b.X >= page.X && b.Y >= page.Y &&
+		b.X+b.W <= page.X+page.W && b.Y+b.H <= page.Y+page.H
+}
+
+// parseOfdBox 解析 "x y w h" 格式的矩形
+func parseOfdBox(s string) (ofdBox, bool) {
+	parts := strings.Fields(s)
+	if len(parts) < 4 {
+		return ofdBox{}, false
+	}
+	var v [4]float64
+	for i := range v {
+		f, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil {
+			return ofdBox{}, false
+		}
+		v[i] = f
+	}
+	b := ofdBox{X: v[0], Y: v[1], W: v[2], H: v[3]}
+	return b, !b.empty()
+}
+
+// parseOfdPageArea 解析 PageArea (Document.xml) 或 Area (页面) 中的 PhysicalBox 与 ContentBox
+// 未声明的区域为零值
+func parseOfdPageArea(content []byte) (physical, contentBox ofdBox) {
+	m := ofdPageAreaPattern.FindSubmatch(content)
+	if m == nil {
+		return ofdBox{}, ofdBox{}
+	}
+	if pm := ofdPhysicalBoxPattern.FindSubmatch(m[2]); pm != nil {
+		physical, _ = parseOfdBox(string(pm[1]))
+	}
+	if cm := ofdContentBoxPattern.FindSubmatch(m[2]); cm != nil {
+		contentBox, _ = parseOfdBox(string(cm[1]))
+	}
+	return physical, contentBox
+}
+
+// ofdTextBounds 页面中文本对象边界的并集
+// 只由页码字符组成的文本对象 (如 "— 1 —") 不计入，页码位于版心之外
+func ofdTextBounds(content []byte) ofdBox {
+	var bounds ofdBox
+	for _, m := range ofdTextObjectPattern.FindAllSubmatch(content, -1) {
+		bm := ofdBoundaryPattern.FindSubmatch(m[1])
+		if bm == nil {
+			continue
+		}
+		box, ok := parseOfdBox(string(bm[1]))
+		if !ok {
+			continue
+		}
+
+		var text strings.Builder
+		for _, tm := range ofdTextCodePattern.FindAllSubmatch(m[2], -1) {
+			text.Write(tm[1])
+		}
+		if isPageNumberText(text.String()) {
+			continue
+		}
+		bounds = bounds.union(box)
+	}
+	return bounds
+}
+
+// GetMargins 获取页边距（毫米）
+// 优先使用声明的版心 (ContentBox)；未声明或与页面重合时，取全部页面文本范围到页面四边的距离
+func (p *OfdParser) GetMargins() (top, bottom, left, right float64, ok bool) {
+	width, height := p.GetPageSize()
+	if width <= 0 || height <= 0 {
+		return 0, 0, 0, 0, false
+	}
+	page := ofdBox{W: width, H: height}
+
+	area := p.contentBox
+	if len(p.pages) > 0 && !p.pages[0].ContentBox.empty() {
+		area = p.pages[0].ContentBox
+	}
+	if !area.inside(page) {
+		area = ofdBox{}
+		for _, pg := range p.pages {
+			area = area.union(pg.TextBounds)
+		}
+		if area.empty() {
+			return 0, 0, 0, 0, false
+		}
+	}
+
+	top = area.Y
+	bottom = height - area.Y - area.H
+	left = area.X
+	right = width - area.X - area.W
+	if top < 0 || bottom < 0 || left < 0 || right < 0 {
+		return 0, 0, 0, 0, false
+	}
+	return top, bottom, left, right, true
+}
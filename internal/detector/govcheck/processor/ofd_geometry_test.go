@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"linuxFileWatcher/internal/detector/govcheck/extractor"
+)
+
+// ============================================================
+// OFD 页面几何测试
+// ============================================================
+
+// buildOfd 构造只含单页的最小 OFD 包
+func buildOfd(t *testing.T, pageArea, pageContent string) *OfdParser {
+	t.Helper()
+	return openOfd(t, map[string]string{
+		"OFD.xml": `<ofd:OFD><ofd:DocBody><ofd:DocRoot>Doc_0/Document.xml</ofd:DocRoot></ofd:DocBody></ofd:OFD>`,
+		"Doc_0/Document.xml": `<ofd:Document><ofd:CommonData><ofd:PageArea>` + pageArea + `</ofd:PageArea></ofd:CommonData>` +
+			`<ofd:Pages><ofd:Page ID="1" BaseLoc="Pages/Page_0/Content.xml"/></ofd:Pages></ofd:Document>`,
+		"Doc_0/Pages/Page_0/Content.xml": `<ofd:Page>` + pageContent + `</ofd:Page>`,
+	})
+}
+
+// openOfd 将文件打包为 OFD 并解析
+func openOfd(t *testing.T, files map[string]string) *OfdParser {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser := NewOfdParser(zr)
+	if err := parser.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return parser
+}
+
+func TestOfdParser_GetMargins(t *testing.T) {
+	const a4 = `<ofd:PhysicalBox>0 0 210 297</ofd:PhysicalBox>`
+	body := func(boundary string) string {
+		return `<ofd:Content><ofd:Layer><ofd:TextObject ID="2" Boundary="` + boundary + `" Font="3" Size="5.6">` +
+			`<ofd:TextCode X="0" Y="5">正文</ofd:TextCode></ofd:TextObject>` +
+			`<ofd:TextObject ID="3" Boundary="100 275 10 5"><ofd:TextCode X="0" Y="4">— 1 —</ofd:TextCode></ofd:TextObject>` +
+			`</ofd:Layer></ofd:Content>`
+	}
+
+	tests := []struct {
+		name        string
+		pageArea    string
+		pageContent string
+		want        [4]float64 // 上、下、左、右
+		wantOK      bool
+		wantMatch   bool
+	}{
+		{
+			name:        "声明的版心",
+			pageArea:    a4 + `<ofd:ContentBox>28 37 156 225</ofd:ContentBox>`,
+			pageContent: body("0 0 210 297"),
+			want:        [4]float64{37, 35, 28, 26},
+			wantOK:      true,
+			wantMatch:   true,
+		},
+		{
+			name:        "版心与页面重合时按文本范围",
+			pageArea:    a4 + `<ofd:ContentBox>0 0 210 297</ofd:ContentBox>`,
+			pageContent: body("28.5 36 155 226"),
+			want:        [4]float64{36, 35, 28.5, 26.5},
+			wantOK:      true,
+			wantMatch:   true,
+		},
+		{
+			name:        "页面自身的版心优先",
+			pageArea:    a4 + `<ofd:ContentBox>28 37 156 225</ofd:ContentBox>`,
+			pageContent: `<ofd:Area><ofd:PhysicalBox>0 0 210 297</ofd:PhysicalBox><ofd:ContentBox>10 10 190 277</ofd:ContentBox></ofd:Area>`,
+			want:        [4]float64{10, 10, 10, 10},
+			wantOK:      true,
+		},
+		{
+			name:     "没有版心也没有文本",
+			pageArea: a4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := buildOfd(t, tt.pageArea, tt.pageContent)
+			top, bottom, left, right, ok := parser.GetMargins()
+			if ok != tt.wantOK || [4]float64{top, bottom, left, right} != tt.want {
+				t.Fatalf("GetMargins() = %v %v %v %v %v, want %v %v", top, bottom, left, right, ok, tt.want, tt.wantOK)
+			}
+
+			sf := &extractor.StyleFeatures{}
+			NewOfdProcessor().detectPageSettings(parser, sf)
+			if !sf.IsA4Paper || sf.MarginMatch != tt.wantMatch {
+				t.Errorf("IsA4Paper=%v MarginMatch=%v, want true %v", sf.IsA4Paper, sf.MarginMatch, tt.wantMatch)
+			}
+		})
+	}
+}
@@ -3,13 +3,15 @@ package processor
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"path"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+
+	"linuxFileWatcher/internal/archiveguard"
 )
 
 // ============================================================
@@ -19,12 +21,17 @@ import (
 // OfdParser OFD解析器
 type OfdParser struct {
 	zipReader    *zip.Reader
+	quota        *archiveguard.Quota // 包内文件的展开配额
+	bomb         error               // 读取时超出展开配额
 	docRoot      string              // 文档根目录，如 "Doc_0"
 	pages        []*OfdParsedPage    // 解析后的页面
 	fonts        map[string]string   // 字体ID -> 字体名称
 	pageWidth    float64             // 页面宽度（mm）
 	pageHeight   float64             // 页面高度（mm）
+	contentBox   ofdBox              // 文档默认版心（mm），未声明时为零值
 	hasSignature bool                // 是否有签章
+	signListPath string              // OFD.xml 中声明的签名列表路径
+	signatures   []*OfdSignature     // 解析到的电子签章
 	docTitle     string              // 文档标题
 	colors       []string            // 检测到的颜色
 	hasRedColor  bool                // 是否有红色
@@ -36,12 +43,16 @@ type OfdParsedPage struct {
 	TextContent string
 	Colors      []string
 	HasRedColor bool
+	PhysicalBox ofdBox // 页面自身声明的尺寸，未声明时为零值
+	ContentBox  ofdBox // 页面自身声明的版心，未声明时为零值
+	TextBounds  ofdBox // 文本对象边界的并集（不含页码）
 }
 
 // NewOfdParser 创建OFD解析器
 func NewOfdParser(zipReader *zip.Reader) *OfdParser {
 	return &OfdParser{
 		zipReader: zipReader,
+		quota:     archiveguard.Default(),
 		fonts:     make(map[string]string),
 		pages:     make([]*OfdParsedPage, 0),
 		colors:    make([]string, 0),
@@ -63,7 +74,8 @@ func (p *OfdParser) Parse() error {
 	// 3. 检查签章
 	p.checkSignatures()
 
-	return nil
+	// 页面、资源等读取失败时不中断，但超出展开配额 (压缩炸弹) 时整体失败
+	return p.bomb
 }
 
 // parseOfdXml 解析 OFD.xml
@@ -87,6 +99,11 @@ func (p *OfdParser) parseOfdXml() error {
 		}
 	}
 
+	// 提取签名列表路径
+	if m := ofdSignaturesPathPattern.FindSubmatch(content); m != nil {
+		p.signListPath = strings.TrimSpace(string(m[1]))
+	}
+
 	// 提取文档标题
 	titleMatch := regexp.MustCompile(`<Title>([^<]*)</Title>`).FindSubmatch(content)
 	if titleMatch != nil {
@@ -106,11 +123,14 @@ func (p *OfdParser) parseDocumentXml() error {
 
 	content = removeNamespacePrefix(content)
 
-	// 提取页面尺寸
-	physicalBoxMatch := regexp.MustCompile(`<PhysicalBox>([^<]+)</PhysicalBox>`).FindSubmatch(content)
-	if physicalBoxMatch != nil {
+	// 提取页面尺寸与版心（CommonData/PageArea）
+	physicalBox, contentBox := parseOfdPageArea(content)
+	if !physicalBox.empty() {
+		p.pageWidth, p.pageHeight = physicalBox.W, physicalBox.H
+	} else if physicalBoxMatch := ofdPhysicalBoxPattern.FindSubmatch(content); physicalBoxMatch != nil {
 		p.parsePhysicalBox(string(physicalBoxMatch[1]))
 	}
+	p.contentBox = contentBox
 
 	// 提取公共资源路径并解析
 	publicResMatch := regexp.MustCompile(`<PublicRes>([^<]+)</PublicRes>`).FindSubmatch(content)
@@ -193,6 +213,10 @@ func (p *OfdParser) parsePage(pageID, pagePath string) (*OfdParsedPage, error) {
 		Colors:  make([]string, 0),
 	}
 
+	// 页面自身的尺寸与版心（Area），以及文本范围
+	page.PhysicalBox, page.ContentBox = parseOfdPageArea(content)
+	page.TextBounds = ofdTextBounds(content)
+
 	// 提取所有 TextCode 中的文本
 	var texts []string
 	textCodePattern := regexp.MustCompile(`<TextCode[^>]*>([^<]*)</TextCode>`)
@@ -233,7 +257,14 @@ func (p *OfdParser) parsePage(pageID, pagePath string) (*OfdParsedPage, error) {
 }
 
 // checkSignatures 检查签章
+// 优先解析签名列表；未声明签名列表时按文件名判断
 func (p *OfdParser) checkSignatures() {
+	p.parseSignatures()
+	if len(p.signatures) > 0 {
+		p.hasSignature = true
+		return
+	}
+
 	for _, file := range p.zipReader.File {
 		nameLower := strings.ToLower(file.Name)
 		if strings.Contains(nameLower, "sign") ||
@@ -284,8 +315,11 @@ func (p *OfdParser) HasSignature() bool {
 	return p.hasSignature
 }
 
-// GetPageSize 获取页面尺寸（毫米）
+// GetPageSize 获取页面尺寸（毫米），首页声明了自身尺寸时以首页为准
 func (p *OfdParser) GetPageSize() (width, height float64) {
+	if len(p.pages) > 0 && !p.pages[0].PhysicalBox.empty() {
+		return p.pages[0].PhysicalBox.W, p.pages[0].PhysicalBox.H
+	}
 	return p.pageWidth, p.pageHeight
 }
 
@@ -396,13 +430,11 @@ func (p *OfdParser) readZipFile(name string) ([]byte, error) {
 		fileName = strings.ReplaceAll(fileName, "\\", "/")
 
 		if fileName == name || strings.EqualFold(fileName, name) {
-			rc, err := file.Open()
-			if err != nil {
-				return nil, err
+			data, err := p.quota.ReadAll(file)
+			if errors.Is(err, archiveguard.ErrBomb) && p.bomb == nil {
+				p.bomb = err
 			}
-			defer rc.Close()
-
-			return io.ReadAll(rc)
+			return data, err
 		}
 	}
 
@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"encoding/asn1"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// ============================================================
+// OFD 电子签章 (Signatures.xml 与 SES 印章结构)
+// ============================================================
+
+// maxDerDepth DER 结构的最大嵌套层数
+const maxDerDepth = 16
+
+var (
+	ofdSignaturesPathPattern = regexp.MustCompile(`<Signatures>([^<]+)</Signatures>`)
+	ofdSignaturePattern      = regexp.MustCompile(`<Signature\b([^>]*?)/?>`)
+	ofdAttrPattern           = regexp.MustCompile(`(\w+)="([^"]*)"`)
+	ofdSealBaseLocPattern    = regexp.MustCompile(`(?s)<Seal>\s*<BaseLoc>([^<]+)</BaseLoc>`)
+	ofdSignedValuePattern    = regexp.MustCompile(`<SignedValue>([^<]+)</SignedValue>`)
+	ofdSignDateTimePattern   = regexp.MustCompile(`<SignatureDateTime>([^<]+)</SignatureDateTime>`)
+	ofdStampAnnotPattern     = regexp.MustCompile(`<StampAnnot\b[^>]*\bPageRef="([^"]+)"`)
+)
+
+// OfdSignature 一个电子签章
+type OfdSignature struct {
+	ID       string
+	Type     string // Seal 为签章，Sign 为纯数字签名
+	PageRef  string // 签章外观所在页面ID
+	SignTime string // 签章时间 (SignatureDateTime)
+	SealName string // 印章名称，通常为签章单位名称
+}
+
+// parseSignatures 解析 OFD.xml 中 Signatures 指向的签名列表及各签名的印章结构
+// 签名列表中的 BaseLoc 相对 Signatures.xml 所在目录，以 "/" 开头时相对包根目录
+func (p *OfdParser) parseSignatures() {
+	if p.signListPath == "" {
+		return
+	}
+	listPath := p.resolveOfdPath("", p.signListPath)
+	content, err := p.readZipFile(listPath)
+	if err != nil && p.docRoot != "" {
+		// 部分生成工具写入相对文档根目录的路径
+		listPath = path.Join(p.docRoot, p.signListPath)
+		content, err = p.readZipFile(listPath)
+	}
+	if err != nil {
+		return
+	}
+	content = removeNamespacePrefix(content)
+
+	for _, m := range ofdSignaturePattern.FindAllSubmatch(content, -1) {
+		attrs := make(map[string]string)
+		for _, am := range ofdAttrPattern.FindAllSubmatch(m[1], -1) {
+			attrs[string(am[1])] = string(am[2])
+		}
+		if attrs["BaseLoc"] == "" {
+			continue
+		}
+
+		sig := &OfdSignature{ID: attrs["ID"], Type: attrs["Type"]}
+		p.parseSignature(sig, p.resolveOfdPath(path.Dir(listPath), attrs["BaseLoc"]))
+		p.signatures = append(p.signatures, sig)
+	}
+}
+
+// parseSignature 解析单个签名描述文件，印章名称取自印章文件，没有印章文件时取自签名值
+func (p *OfdParser) parseSignature(sig *OfdSignature, sigPath string) {
+	content, err := p.readZipFile(sigPath)
+	if err != nil {
+		return
+	}
+	content = removeNamespacePrefix(content)
+	dir := path.Dir(sigPath)
+
+	if m := ofdSignDateTimePattern.FindSubmatch(content); m != nil {
+		sig.SignTime = strings.TrimSpace(string(m[1]))
+	}
+	if m := ofdStampAnnotPattern.FindSubmatch(content); m != nil {
+		sig.PageRef = string(m[1])
+	}
+
+	var sources []string
+	if m := ofdSealBaseLocPattern.FindSubmatch(content); m != nil {
+		sources = append(sources, strings.TrimSpace(string(m[1])))
+	}
+	if m := ofdSignedValuePattern.FindSubmatch(content); m != nil {
+		sources = append(sources, strings.TrimSpace(string(m[1])))
+	}
+	for _, src := range sources {
+		data, err := p.readZipFile(p.resolveOfdPath(dir, src))
+		if err != nil {
+			continue
+		}
+		if name := findSealName(data, 0); name != "" {
+			sig.SealName = name
+			return
+		}
+	}
+}
+
+// resolveOfdPath 解析包内路径: 以 "/" 开头时相对包根目录，否则相对 dir
+func (p *OfdParser) resolveOfdPath(dir, loc string) string {
+	loc = strings.ReplaceAll(strings.TrimSpace(loc), "\\", "/")
+	if strings.HasPrefix(loc, "/") {
+		return strings.TrimPrefix(path.Clean(loc), "/")
+	}
+	return path.Join(dir, loc)
+}
+
+// findSealName 在 DER 编码的印章 (SESeal) 或签名值 (SES_Signature，内嵌完整印章) 中查找印章名称
+// 印章属性 SES_ESPropertyInfo 以 type INTEGER、name UTF8String 开头 (GM/T 0031 与 GB/T 38540 相同)
+func findSealName(data []byte, depth int) string {
+	if depth > maxDerDepth {
+		return ""
+	}
+	for len(data) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &v)
+		if err != nil {
+			return ""
+		}
+		data = rest
+
+		switch {
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagSequence:
+			if name := sealPropertyName(v.Bytes); name != "" {
+				return name
+			}
+			if name := findSealName(v.Bytes, depth+1); name != "" {
+				return name
+			}
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagOctetString:
+			// 内容为 DER 结构的 OCTET STRING
+			if len(v.Bytes) > 0 && v.Bytes[0] == 0x30 {
+				if name := findSealName(v.Bytes, depth+1); name != "" {
+					return name
+				}
+			}
+		case v.IsCompound:
+			if name := findSealName(v.Bytes, depth+1); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// sealPropertyName SEQUENCE 内容以 INTEGER、含中文的字符串开头时返回该字符串
+func sealPropertyName(content []byte) string {
+	var first, second asn1.RawValue
+	rest, err := asn1.Unmarshal(content, &first)
+	if err != nil || first.Class != asn1.ClassUniversal || first.Tag != asn1.TagInteger {
+		return ""
+	}
+	if _, err := asn1.Unmarshal(rest, &second); err != nil || second.Class != asn1.ClassUniversal {
+		return ""
+	}
+
+	var name string
+	switch second.Tag {
+	case asn1.TagUTF8String:
+		name = string(second.Bytes)
+	case asn1.TagBMPString:
+		name = decodeBMPString(second.Bytes)
+	default:
+		return ""
+	}
+	name = strings.TrimSpace(name)
+	if !containsHan(name) {
+		return ""
+	}
+	return name
+}
+
+// decodeBMPString 解码 UTF-16BE 编码的 BMPString
+func decodeBMPString(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSignatures 获取解析到的电子签章
+func (p *OfdParser) GetSignatures() []*OfdSignature {
+	return p.signatures
+}
+
+// GetSealNames 获取全部签章的印章名称 (去重)
+func (p *OfdParser) GetSealNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, sig := range p.signatures {
+		if sig.SealName != "" && !seen[sig.SealName] {
+			seen[sig.SealName] = true
+			names = append(names, sig.SealName)
+		}
+	}
+	return names
+}
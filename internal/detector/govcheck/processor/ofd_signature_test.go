@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"encoding/asn1"
+	"testing"
+
+	"linuxFileWatcher/internal/detector/govcheck/extractor"
+)
+
+// ============================================================
+// OFD 电子签章测试
+// ============================================================
+
+// 测试用的简化 SES 印章结构，字段顺序与 GB/T 38540 一致
+type testSealHeader struct {
+	ID      string `asn1:"ia5"`
+	Version int
+	Vid     string `asn1:"ia5"`
+}
+
+type testSealProperty struct {
+	Type         int
+	Name         string `asn1:"utf8"`
+	CertListType int
+}
+
+type testSealPicture struct {
+	Type   string `asn1:"ia5"`
+	Data   []byte
+	Width  int
+	Height int
+}
+
+type testSealInfo struct {
+	Header   testSealHeader
+	EsID     string `asn1:"ia5"`
+	Property testSealProperty
+	Picture  testSealPicture
+}
+
+type testSeal struct {
+	Info      testSealInfo
+	Cert      []byte
+	Signature asn1.BitString
+}
+
+// testSignedValue 签名值 (TBS_Sign 内嵌印章)
+type testSignedValue struct {
+	ToSign struct {
+		Version int
+		Seal    testSeal
+	}
+	Signature asn1.BitString
+}
+
+func newTestSeal(t *testing.T, name string) testSeal {
+	t.Helper()
+	return testSeal{
+		Info: testSealInfo{
+			Header:   testSealHeader{ID: "ES", Version: 4, Vid: "vendor"},
+			EsID:     "1100000000001",
+			Property: testSealProperty{Type: 1, Name: name, CertListType: 1},
+			Picture:  testSealPicture{Type: "PNG", Data: []byte{0x89, 'P', 'N', 'G'}, Width: 40, Height: 40},
+		},
+		Cert:      []byte{0x30, 0x03, 0x0c, 0x01, 'x'},
+		Signature: asn1.BitString{Bytes: []byte{1, 2, 3}, BitLength: 24},
+	}
+}
+
+func TestOfdParser_Signatures(t *testing.T) {
+	seal, err := asn1.Marshal(newTestSeal(t, "XX市人民政府"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sv testSignedValue
+	sv.ToSign.Version = 4
+	sv.ToSign.Seal = newTestSeal(t, "XX市财政局")
+	signedValue, err := asn1.Marshal(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := openOfd(t, map[string]string{
+		"OFD.xml": `<ofd:OFD><ofd:DocBody><ofd:DocRoot>Doc_0/Document.xml</ofd:DocRoot>` +
+			`<ofd:Signatures>Doc_0/Signs/Signatures.xml</ofd:Signatures></ofd:DocBody></ofd:OFD>`,
+		"Doc_0/Document.xml": `<ofd:Document><ofd:Pages></ofd:Pages></ofd:Document>`,
+		"Doc_0/Signs/Signatures.xml": `<ofd:Signatures><ofd:MaxSignId>3</ofd:MaxSignId>` +
+			`<ofd:Signature ID="1" Type="Seal" BaseLoc="Sign_0/Signature.xml"/>` +
+			`<ofd:Signature ID="2" BaseLoc="/Doc_0/Signs/Sign_1/Signature.xml"></ofd:Signature>` +
+			`<ofd:Signature ID="3" Type="Seal" BaseLoc="Sign_2/Signature.xml"/></ofd:Signatures>`,
+		// 印章名称取自印章文件
+		"Doc_0/Signs/Sign_0/Signature.xml": `<ofd:Signature><ofd:SignedInfo>` +
+			`<ofd:SignatureDateTime>20240301120000Z</ofd:SignatureDateTime>` +
+			`<ofd:StampAnnot ID="1" PageRef="1" Boundary="120 220 40 40"/>` +
+			`<ofd:Seal><ofd:BaseLoc>Seal.esl</ofd:BaseLoc></ofd:Seal></ofd:SignedInfo>` +
+			`<ofd:SignedValue>SignedValue.dat</ofd:SignedValue></ofd:Signature>`,
+		"Doc_0/Signs/Sign_0/Seal.esl": string(seal),
+		// 没有印章文件时取自签名值
+		"Doc_0/Signs/Sign_1/Signature.xml": `<ofd:Signature><ofd:SignedInfo></ofd:SignedInfo>` +
+			`<ofd:SignedValue>/Doc_0/Signs/Sign_1/SignedValue.dat</ofd:SignedValue></ofd:Signature>`,
+		"Doc_0/Signs/Sign_1/SignedValue.dat": string(signedValue),
+		// 签名值无法解析
+		"Doc_0/Signs/Sign_2/Signature.xml":   `<ofd:Signature><ofd:SignedValue>SignedValue.dat</ofd:SignedValue></ofd:Signature>`,
+		"Doc_0/Signs/Sign_2/SignedValue.dat": "not der",
+	})
+
+	sigs := parser.GetSignatures()
+	if len(sigs) != 3 || !parser.HasSignature() {
+		t.Fatalf("GetSignatures() = %d, HasSignature=%v", len(sigs), parser.HasSignature())
+	}
+	if s := sigs[0]; s.ID != "1" || s.Type != "Seal" || s.PageRef != "1" || s.SignTime != "20240301120000Z" || s.SealName != "XX市人民政府" {
+		t.Errorf("sig[0] = %+v", *s)
+	}
+	if s := sigs[1]; s.SealName != "XX市财政局" {
+		t.Errorf("sig[1] = %+v", *s)
+	}
+	if s := sigs[2]; s.SealName != "" {
+		t.Errorf("sig[2] = %+v", *s)
+	}
+
+	names := parser.GetSealNames()
+	if len(names) != 2 || names[0] != "XX市人民政府" || names[1] != "XX市财政局" {
+		t.Errorf("GetSealNames() = %v", names)
+	}
+
+	sf := &extractor.StyleFeatures{}
+	NewOfdProcessor().detectSignatures(parser, sf)
+	if !sf.HasSealImage || len(sf.SealOrgNames) != 2 || sf.SealImageHint != "检测到电子签章: XX市人民政府、XX市财政局" {
+		t.Errorf("HasSealImage=%v SealOrgNames=%v SealImageHint=%q", sf.HasSealImage, sf.SealOrgNames, sf.SealImageHint)
+	}
+}
@@ -21,6 +21,7 @@ type PdfProcessorConfig struct {
 	MaxPages       int   // 最大处理页数 (0=不限制)
 	ExtractStyle   bool  // 是否提取版式特征
 	NormalizeSpace bool  // 是否规范化空白字符
+	MaxMemory      int64 // 单文件解析内存预算 (字节)，超出时截断并标记为部分抽取 (0=不限制)
 }
 
 // DefaultPdfProcessorConfig 返回默认配置
@@ -30,6 +31,7 @@ func DefaultPdfProcessorConfig() *PdfProcessorConfig {
 		MaxPages:       0,                  // 不限制
 		ExtractStyle:   true,
 		NormalizeSpace: true,
+		MaxMemory:      DefaultMaxExtractMemory,
 	}
 }
 
@@ -99,55 +101,67 @@ func (p *PdfProcessor) ProcessWithStyle(filePath string) (*ProcessResultWithStyl
 			fmt.Errorf("文件过大: %d 字节 (限制: %d 字节)", info.Size(), p.config.MaxFileSize))
 	}
 
-	// 读取文件内容
-	data, err := os.ReadFile(filePath)
+	// 只读映射文件内容，原始数据不占用堆内存
+	mapped, err := openMapped(filePath)
 	if err != nil {
 		return nil, NewProcessorError(p.Name(), filePath, "读取文件", err)
 	}
+	defer mapped.Close()
+	data := mapped.data
 
 	// 创建PDF解析器
 	parser := NewPdfParser(data)
-	if err := parser.Parse(); err != nil {
-		return nil, NewProcessorError(p.Name(), filePath, "解析PDF", err)
-	}
+	parser.SetMemoryLimit(p.config.MaxMemory)
 
-	// 提取文本
-	textExtractor := NewPdfTextExtractor(parser)
-	text, err := textExtractor.ExtractText()
-	if err != nil {
-		return nil, NewProcessorError(p.Name(), filePath, "提取文本", err)
-	}
+	err = guardFault(func() error {
+		if err := parser.Parse(); err != nil {
+			return NewProcessorError(p.Name(), filePath, "解析PDF", err)
+		}
 
-	// 规范化文本
-	if p.config.NormalizeSpace {
-		text = normalizePdfText(text)
-	}
+		// 提取文本
+		textExtractor := NewPdfTextExtractor(parser)
+		text, err := textExtractor.ExtractText()
+		if err != nil {
+			return NewProcessorError(p.Name(), filePath, "提取文本", err)
+		}
 
-	result.Text = text
+		// 规范化文本
+		if p.config.NormalizeSpace {
+			text = normalizePdfText(text)
+		}
 
-	// 提取版式特征
-	if p.config.ExtractStyle {
-		styleFeatures := p.extractStyleFeatures(parser, data)
-		if styleFeatures != nil {
-			result.StyleFeatures = styleFeatures
-			result.HasStyle = true
+		result.Text = text
+
+		// 提取版式特征
+		if p.config.ExtractStyle {
+			styleFeatures := p.extractStyleFeatures(parser, textExtractor)
+			if styleFeatures != nil {
+				result.StyleFeatures = styleFeatures
+				result.HasStyle = true
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	result.Partial = parser.Partial()
 
 	return result, nil
 }
 
 // extractStyleFeatures 提取PDF版式特征
-func (p *PdfProcessor) extractStyleFeatures(parser *PdfParser, data []byte) *extractor.StyleFeatures {
+// 红色文本取自文本抽取时跟踪的颜色状态，因此须在 ExtractText 之后调用
+func (p *PdfProcessor) extractStyleFeatures(parser *PdfParser, text *PdfTextExtractor) *extractor.StyleFeatures {
 	sf := &extractor.StyleFeatures{
 		StyleReasons: make([]string, 0),
 	}
 
 	// 1. 检测页面设置
-	p.detectPageSettings(parser, sf)
+	p.detectPageSettings(parser, text, sf)
 
 	// 2. 检测红色内容
-	p.detectRedContent(parser, data, sf)
+	p.detectRedContent(parser, text, sf)
 
 	// 3. 检测字体
 	p.detectFonts(parser, sf)
@@ -159,69 +173,70 @@ func (p *PdfProcessor) extractStyleFeatures(parser *PdfParser, data []byte) *ext
 }
 
 // detectPageSettings 检测页面设置
-func (p *PdfProcessor) detectPageSettings(parser *PdfParser, sf *extractor.StyleFeatures) {
+// 纸张尺寸取首页可见区域 (CropBox，可从页面树继承)；页边距由全部页面的文本范围估算
+func (p *PdfProcessor) detectPageSettings(parser *PdfParser, text *PdfTextExtractor, sf *extractor.StyleFeatures) {
 	pages := parser.GetPages()
 	if len(pages) == 0 {
 		return
 	}
 
-	// 获取第一页的MediaBox
-	firstPage := pages[0]
-	mediaBox := firstPage.GetArray("MediaBox")
-
-	if len(mediaBox) >= 4 {
-		// MediaBox = [x1, y1, x2, y2]，单位是点(point)，1点 = 1/72英寸
-		var x1, y1, x2, y2 float64
-
-		if v, ok := mediaBox[0].(PdfIntObject); ok {
-			x1 = float64(v.Value)
-		} else if v, ok := mediaBox[0].(PdfRealObject); ok {
-			x1 = v.Value
-		}
-
-		if v, ok := mediaBox[1].(PdfIntObject); ok {
-			y1 = float64(v.Value)
-		} else if v, ok := mediaBox[1].(PdfRealObject); ok {
-			y1 = v.Value
-		}
-
-		if v, ok := mediaBox[2].(PdfIntObject); ok {
-			x2 = float64(v.Value)
-		} else if v, ok := mediaBox[2].(PdfRealObject); ok {
-			x2 = v.Value
-		}
-
-		if v, ok := mediaBox[3].(PdfIntObject); ok {
-			y2 = float64(v.Value)
-		} else if v, ok := mediaBox[3].(PdfRealObject); ok {
-			y2 = v.Value
-		}
+	geometry, ok := parser.pageGeometry(pages[0])
+	if !ok {
+		return
+	}
 
-		// 计算页面尺寸（点转毫米：1点 = 25.4/72 mm）
-		widthPt := x2 - x1
-		heightPt := y2 - y1
+	sf.PageWidth, sf.PageHeight = geometry.sizeMM()
 
-		sf.PageWidth = widthPt * 25.4 / 72.0
-		sf.PageHeight = heightPt * 25.4 / 72.0
+	// 检查是否为A4（210mm x 297mm，允许±3mm误差）
+	isA4Width := sf.PageWidth >= 207 && sf.PageWidth <= 213
+	isA4Height := sf.PageHeight >= 294 && sf.PageHeight <= 300
 
-		// 检查是否为A4（210mm x 297mm，允许±3mm误差）
-		isA4Width := sf.PageWidth >= 207 && sf.PageWidth <= 213
-		isA4Height := sf.PageHeight >= 294 && sf.PageHeight <= 300
+	// 也检查横向A4
+	isA4Landscape := (sf.PageWidth >= 294 && sf.PageWidth <= 300) &&
+		(sf.PageHeight >= 207 && sf.PageHeight <= 213)
 
-		// 也检查横向A4
-		isA4Landscape := (sf.PageWidth >= 294 && sf.PageWidth <= 300) &&
-			(sf.PageHeight >= 207 && sf.PageHeight <= 213)
+	sf.IsA4Paper = (isA4Width && isA4Height) || isA4Landscape
 
-		sf.IsA4Paper = (isA4Width && isA4Height) || isA4Landscape
+	if sf.IsA4Paper {
+		sf.StyleReasons = append(sf.StyleReasons, "纸张为A4规格")
+	}
 
-		if sf.IsA4Paper {
-			sf.StyleReasons = append(sf.StyleReasons, "纸张为A4规格")
-		}
+	// 页边距: 文本范围到页面四边的距离，与 DOCX 使用相同的公文标准
+	bounds, found := text.TextBounds()
+	if !found {
+		return
+	}
+	if top, bottom, left, right, ok := geometry.marginsMM(bounds); ok && CheckMargins(top, bottom, left, right) {
+		sf.MarginMatch = true
+		sf.StyleReasons = append(sf.StyleReasons, "页边距符合公文标准")
 	}
 }
 
 // detectRedContent 检测红色内容
-func (p *PdfProcessor) detectRedContent(parser *PdfParser, data []byte, sf *extractor.StyleFeatures) {
+// 优先按文本颜色判断: 首页前几行的红色文本为红头 (如 "XX文件")；
+// 没有红色文本时 (红头转曲为路径等) 回退为检查内容流中的红色颜色设置
+func (p *PdfProcessor) detectRedContent(parser *PdfParser, text *PdfTextExtractor, sf *extractor.StyleFeatures) {
+	runs, count := text.RedRuns()
+	if count > 0 {
+		sf.HasRedText = true
+		sf.RedTextCount = count
+		for _, run := range runs {
+			if run.Page == 0 && run.Line <= pdfRedHeaderLines {
+				sf.HasRedHeader = true
+			}
+			if sample := strings.TrimSpace(run.Text); sample != "" && len(sf.RedSamples) < 3 {
+				sf.RedSamples = append(sf.RedSamples, sample)
+			}
+		}
+		if sf.HasRedHeader {
+			sf.StyleReasons = append(sf.StyleReasons, "检测到红头（首页顶部红色文本）")
+		} else {
+			sf.StyleReasons = append(sf.StyleReasons, "检测到红色文本")
+		}
+		p.detectSealImages(parser, sf)
+		return
+	}
+
 	pages := parser.GetPages()
 
 	for pageIdx, page := range pages {
@@ -271,7 +286,7 @@ func (p *PdfProcessor) getPageContentData(parser *PdfParser, contentsObj PdfObje
 
 	switch c := contents.(type) {
 	case PdfStreamObject:
-		data, _ := c.GetDecodedData()
+		data, _ := parser.decodeStream(c)
 		return data
 
 	case PdfArrayObject:
@@ -282,7 +297,7 @@ func (p *PdfProcessor) getPageContentData(parser *PdfParser, contentsObj PdfObje
 				continue
 			}
 			if stream, ok := streamObj.(PdfStreamObject); ok {
-				data, err := stream.GetDecodedData()
+				data, err := parser.decodeStream(stream)
 				if err == nil {
 					allData = append(allData, data...)
 					allData = append(allData, '\n')
@@ -513,10 +528,13 @@ func (p *PdfProcessor) calculateStyleScore(sf *extractor.StyleFeatures) {
 		score += 0.15
 	}
 
-	// 页面设置 (0.15)
+	// 页面设置 (0.20)
 	if sf.IsA4Paper {
 		score += 0.15
 	}
+	if sf.MarginMatch {
+		score += 0.05
+	}
 
 	// 字体 (0.10)
 	if sf.HasOfficialFonts {
@@ -543,6 +561,12 @@ func normalizePdfText(text string) string {
 	var cleanedLines []string
 
 	for _, line := range lines {
+		// 保留分页符
+		if line == "\f" {
+			cleanedLines = append(cleanedLines, line)
+			continue
+		}
+
 		// 移除行首尾空白
 		line = strings.TrimSpace(line)
 
@@ -560,5 +584,6 @@ func normalizePdfText(text string) string {
 		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
 	}
 
-	return strings.TrimSpace(text)
+	// 首尾的分页符对应无文本的页面，不能移除
+	return strings.Trim(text, " \t\r\n")
 }
\ No newline at end of file
@@ -0,0 +1,114 @@
+package processor
+
+import "strings"
+
+// ============================================================
+// 内容流颜色状态 (红色文本检测)
+// ============================================================
+
+// pdfRedHeaderLines 首页前几行的红色文本视为红头，与 DOCX 按段落序号判断一致
+const pdfRedHeaderLines = 3
+
+// maxPdfRedRuns 保留的红色文本段上限，超出后只计数
+const maxPdfRedRuns = 64
+
+// pdfRedRun 一段红色文本
+type pdfRedRun struct {
+	Page int    // 页序号，从 0 开始
+	Line int    // 页内文本行序号，从 1 开始
+	Text string // 解码后的文本
+}
+
+// pdfRGB 设备 RGB 颜色，分量 0~1
+type pdfRGB struct {
+	R, G, B float64
+}
+
+// isRed R 高、G 和 B 低
+func (c pdfRGB) isRed() bool {
+	return c.R >= 0.7 && c.G <= 0.3 && c.B <= 0.3
+}
+
+// pdfGraphicsColor 随 q/Q 保存与恢复的颜色相关状态
+type pdfGraphicsColor struct {
+	fill       pdfRGB
+	stroke     pdfRGB
+	renderMode int // Tr 文本渲染模式
+}
+
+// pdfColorTracker 跟踪内容流的填充/描边颜色与文本渲染模式
+// 颜色由 g/G、rg/RG、k/K 以及 sc/scn/SC/SCN 设置；sc 系列按数值分量个数推断颜色空间
+// (1 灰度、3 RGB、4 CMYK)，其他颜色空间 (Pattern、Separation 等) 视为非红色
+type pdfColorTracker struct {
+	cur   pdfGraphicsColor
+	stack []pdfGraphicsColor
+}
+
+// apply 处理颜色与图形状态相关的操作符，其他操作符忽略
+func (t *pdfColorTracker) apply(op string, operands []string) {
+	switch op {
+	case "q":
+		t.stack = append(t.stack, t.cur)
+	case "Q":
+		if n := len(t.stack); n > 0 {
+			t.cur = t.stack[n-1]
+			t.stack = t.stack[:n-1]
+		}
+	case "Tr":
+		if len(operands) >= 1 {
+			t.cur.renderMode = int(parseFloat(operands[len(operands)-1]))
+		}
+	case "cs":
+		// 设置颜色空间时颜色恢复为初始值 (黑色)
+		t.cur.fill = pdfRGB{}
+	case "CS":
+		t.cur.stroke = pdfRGB{}
+	case "g", "rg", "k", "sc", "scn":
+		if c, ok := pdfOperandColor(operands); ok {
+			t.cur.fill = c
+		}
+	case "G", "RG", "K", "SC", "SCN":
+		if c, ok := pdfOperandColor(operands); ok {
+			t.cur.stroke = c
+		}
+	}
+}
+
+// textIsRed 当前文本是否以红色绘制
+// 渲染模式 0/2/4/6 使用填充色，1/5 只描边，3/7 不可见
+func (t *pdfColorTracker) textIsRed() bool {
+	switch t.cur.renderMode {
+	case 1, 5:
+		return t.cur.stroke.isRed()
+	case 3, 7:
+		return false
+	default:
+		return t.cur.fill.isRed()
+	}
+}
+
+// pdfOperandColor 将操作数末尾连续的数值按分量个数转换为 RGB
+func pdfOperandColor(operands []string) (pdfRGB, bool) {
+	var comps []float64
+	for i := len(operands) - 1; i >= 0 && len(comps) < 4; i-- {
+		if strings.HasPrefix(operands[i], "/") {
+			// scn 的图案名称
+			if len(comps) == 0 {
+				return pdfRGB{}, false
+			}
+			break
+		}
+		comps = append([]float64{parseFloat(operands[i])}, comps...)
+	}
+
+	switch len(comps) {
+	case 1:
+		return pdfRGB{comps[0], comps[0], comps[0]}, true
+	case 3:
+		return pdfRGB{comps[0], comps[1], comps[2]}, true
+	case 4:
+		c, m, y, k := comps[0], comps[1], comps[2], comps[3]
+		return pdfRGB{(1 - c) * (1 - k), (1 - m) * (1 - k), (1 - y) * (1 - k)}, true
+	}
+	return pdfRGB{}, false
+}
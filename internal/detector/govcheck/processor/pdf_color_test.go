@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"testing"
+
+	"linuxFileWatcher/internal/detector/govcheck/extractor"
+)
+
+// ============================================================
+// PDF 红色文本检测测试
+// ============================================================
+
+func TestPdfTextExtractor_RedRuns(t *testing.T) {
+	e := NewPdfTextExtractor(NewPdfParser(nil))
+	e.parseContentStream([]byte(`
+BT /F1 22 Tf 1 0 0 rg 100 750 Td (XX) Tj (File) Tj ET
+q 1 0 0 RG 0 700 m 500 700 l S Q
+BT 0 g 100 680 Td (Body) Tj 0 -20 Td 0 1 1 0 k (Seal) Tj ET
+BT 3 Tr 1 0 0 rg 100 600 Td (Hidden) Tj ET
+q 0 Tr /CS0 cs 0.8 0.1 0.1 sc BT 100 100 Td (Footer) Tj ET Q
+BT 0 Tr 0 g 100 80 Td (Black) Tj ET
+`))
+
+	runs, count := e.RedRuns()
+	want := []pdfRedRun{
+		{Page: 0, Line: 1, Text: "XXFile"},
+		{Page: 0, Line: 3, Text: "Seal"},
+		{Page: 0, Line: 5, Text: "Footer"},
+	}
+	if count != len(want) || len(runs) != len(want) {
+		t.Fatalf("RedRuns() = %+v (%d), want %+v", runs, count, want)
+	}
+	for i := range want {
+		if runs[i] != want[i] {
+			t.Errorf("run[%d] = %+v, want %+v", i, runs[i], want[i])
+		}
+	}
+}
+
+func TestPdfProcessor_DetectRedContent(t *testing.T) {
+	p := NewPdfProcessor()
+	parser := NewPdfParser(nil)
+
+	// 红色文本只出现在正文中: 有红色文本，不是红头
+	e := NewPdfTextExtractor(parser)
+	e.parseContentStream([]byte("BT 100 750 Td (Title) Tj 0 -20 Td (A) Tj 0 -20 Td (B) Tj 0 -20 Td 1 0 0 rg (Red) Tj ET"))
+	sf := &extractor.StyleFeatures{}
+	p.detectRedContent(parser, e, sf)
+	if !sf.HasRedText || sf.HasRedHeader || sf.RedTextCount != 1 {
+		t.Errorf("正文红字: HasRedText=%v HasRedHeader=%v count=%d", sf.HasRedText, sf.HasRedHeader, sf.RedTextCount)
+	}
+
+	// 第二页顶部的红色文本不是红头
+	e = NewPdfTextExtractor(parser)
+	e.page = 1
+	e.parseContentStream([]byte("BT 1 0 0 rg 100 750 Td (Banner) Tj ET"))
+	sf = &extractor.StyleFeatures{}
+	p.detectRedContent(parser, e, sf)
+	if !sf.HasRedText || sf.HasRedHeader {
+		t.Errorf("次页红字: HasRedText=%v HasRedHeader=%v", sf.HasRedText, sf.HasRedHeader)
+	}
+
+	// 首页顶部红色文本为红头
+	e = NewPdfTextExtractor(parser)
+	e.parseContentStream([]byte("BT 0.8 0 0 rg 100 750 Td (Banner) Tj ET"))
+	sf = &extractor.StyleFeatures{}
+	p.detectRedContent(parser, e, sf)
+	if !sf.HasRedHeader || len(sf.RedSamples) != 1 || sf.RedSamples[0] != "Banner" {
+		t.Errorf("红头: HasRedHeader=%v samples=%v", sf.HasRedHeader, sf.RedSamples)
+	}
+}
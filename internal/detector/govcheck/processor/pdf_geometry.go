@@ -0,0 +1,327 @@
+package processor
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ============================================================
+// PDF 页面几何 (纸张尺寸与页边距)
+// ============================================================
+
+// pdfPointToMM 1 点 = 1/72 英寸
+const pdfPointToMM = 25.4 / 72.0
+
+// maxPdfParentDepth 沿 Parent 查找可继承属性的最大层数，防止循环引用
+const maxPdfParentDepth = 32
+
+// pdfRect 用户空间中的矩形，单位为点
+type pdfRect struct {
+	X1, Y1, X2, Y2 float64
+}
+
+func (r pdfRect) width() float64  { return r.X2 - r.X1 }
+func (r pdfRect) height() float64 { return r.Y2 - r.Y1 }
+func (r pdfRect) empty() bool     { return r.X2 <= r.X1 || r.Y2 <= r.Y1 }
+
+// intersect 两个矩形的交集
+func (r pdfRect) intersect(o pdfRect) pdfRect {
+	return pdfRect{
+		X1: math.Max(r.X1, o.X1), Y1: math.Max(r.Y1, o.Y1),
+		X2: math.Min(r.X2, o.X2), Y2: math.Min(r.Y2, o.Y2),
+	}
+}
+
+// pdfPageGeometry 页面可见区域与旋转角度
+type pdfPageGeometry struct {
+	Box    pdfRect // CropBox 与 MediaBox 的交集，没有 CropBox 时为 MediaBox
+	Rotate int     // 顺时针旋转角度: 0/90/180/270
+}
+
+// sizeMM 显示时的页面宽高 (毫米)，旋转 90/270 度时宽高互换
+func (g pdfPageGeometry) sizeMM() (width, height float64) {
+	width, height = g.Box.width()*pdfPointToMM, g.Box.height()*pdfPointToMM
+	if g.Rotate == 90 || g.Rotate == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// marginsMM 文本范围到页面四边的距离 (毫米)
+// 只处理未旋转的页面，旋转后上下左右与用户空间不对应
+func (g pdfPageGeometry) marginsMM(text pdfRect) (top, bottom, left, right float64, ok bool) {
+	if g.Rotate != 0 || text.empty() {
+		return 0, 0, 0, 0, false
+	}
+	top = (g.Box.Y2 - text.Y2) * pdfPointToMM
+	bottom = (text.Y1 - g.Box.Y1) * pdfPointToMM
+	left = (text.X1 - g.Box.X1) * pdfPointToMM
+	right = (g.Box.X2 - text.X2) * pdfPointToMM
+	if top < 0 || bottom < 0 || left < 0 || right < 0 {
+		return 0, 0, 0, 0, false
+	}
+	return top, bottom, left, right, true
+}
+
+// pageGeometry 解析页面的 MediaBox/CropBox/Rotate
+// 这些属性可从页面树的上级节点继承，数组及其元素都可能是间接引用
+func (p *PdfParser) pageGeometry(page PdfDictObject) (pdfPageGeometry, bool) {
+	media, ok := p.pageRect(page, "MediaBox")
+	if !ok {
+		return pdfPageGeometry{}, false
+	}
+
+	g := pdfPageGeometry{Box: media}
+	if crop, ok := p.pageRect(page, "CropBox"); ok {
+		if visible := crop.intersect(media); !visible.empty() {
+			g.Box = visible
+		}
+	}
+
+	if obj := p.inheritedPageAttr(page, "Rotate"); obj != nil {
+		if v, ok := p.pdfNumber(obj); ok {
+			g.Rotate = ((int(v)%360 + 360) % 360) / 90 * 90
+		}
+	}
+	return g, true
+}
+
+// inheritedPageAttr 查找页面属性，页面本身没有时沿 Parent 向上查找
+func (p *PdfParser) inheritedPageAttr(page PdfDictObject, key string) PdfObject {
+	node := page
+	for i := 0; i < maxPdfParentDepth; i++ {
+		if v := node.Get(key); v != nil {
+			return v
+		}
+		parentObj := node.Get("Parent")
+		if parentObj == nil {
+			return nil
+		}
+		parent, err := p.resolveRef(parentObj)
+		if err != nil {
+			return nil
+		}
+		parentDict, ok := parent.(PdfDictObject)
+		if !ok {
+			return nil
+		}
+		node = parentDict
+	}
+	return nil
+}
+
+// pageRect 读取页面的矩形属性，坐标按大小规范化为左下、右上
+func (p *PdfParser) pageRect(page PdfDictObject, key string) (pdfRect, bool) {
+	obj := p.inheritedPageAttr(page, key)
+	if obj == nil {
+		return pdfRect{}, false
+	}
+	obj, err := p.resolveRef(obj)
+	if err != nil {
+		return pdfRect{}, false
+	}
+	arr, ok := obj.(PdfArrayObject)
+	if !ok || len(arr.Items) < 4 {
+		return pdfRect{}, false
+	}
+
+	var v [4]float64
+	for i := range v {
+		if v[i], ok = p.pdfNumber(arr.Items[i]); !ok {
+			return pdfRect{}, false
+		}
+	}
+	r := pdfRect{
+		X1: math.Min(v[0], v[2]), Y1: math.Min(v[1], v[3]),
+		X2: math.Max(v[0], v[2]), Y2: math.Max(v[1], v[3]),
+	}
+	if r.empty() {
+		return pdfRect{}, false
+	}
+	return r, true
+}
+
+// pdfNumber 读取数值对象，支持间接引用
+func (p *PdfParser) pdfNumber(obj PdfObject) (float64, bool) {
+	obj, err := p.resolveRef(obj)
+	if err != nil {
+		return 0, false
+	}
+	switch v := obj.(type) {
+	case PdfIntObject:
+		return float64(v.Value), true
+	case PdfRealObject:
+		return v.Value, true
+	}
+	return 0, false
+}
+
+// ============================================================
+// 内容流文本范围
+// ============================================================
+
+// pdfMatrix 仿射变换矩阵 [a b c d e f]
+type pdfMatrix [6]float64
+
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// mul 先应用 m 再应用 n
+func (m pdfMatrix) mul(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func (m pdfMatrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// pdfOperandMatrix 取操作数末尾的 6 个数值
+func pdfOperandMatrix(operands []string) (pdfMatrix, bool) {
+	if len(operands) < 6 {
+		return pdfMatrix{}, false
+	}
+	var m pdfMatrix
+	for i, s := range operands[len(operands)-6:] {
+		m[i] = parseFloat(s)
+	}
+	return m, true
+}
+
+// pdfTextBounds 跟踪文本位置，累计文本在用户空间中占据的范围
+// 字形宽度无法从字体程序取得，按中日韩字符 1 个字号、其他字符半个字号估算；
+// 字形高度按表意字框取基线下 0.12 至基线上 0.88 个字号
+type pdfTextBounds struct {
+	ctm      pdfMatrix
+	stack    []pdfMatrix
+	tm       pdfMatrix // 文本矩阵
+	tlm      pdfMatrix // 文本行矩阵
+	leading  float64
+	fontSize float64
+
+	bounds pdfRect
+	found  bool
+}
+
+func newPdfTextBounds() *pdfTextBounds {
+	return &pdfTextBounds{ctm: pdfIdentity, tm: pdfIdentity, tlm: pdfIdentity}
+}
+
+// reset 开始新的页面，保留已累计的范围
+func (b *pdfTextBounds) reset() {
+	b.ctm, b.tm, b.tlm = pdfIdentity, pdfIdentity, pdfIdentity
+	b.stack = b.stack[:0]
+	b.leading, b.fontSize = 0, 0
+}
+
+// apply 处理图形状态与文本定位操作符，文本显示由 show 处理
+func (b *pdfTextBounds) apply(op string, operands []string) {
+	last := func(i int) float64 { return parseFloat(operands[len(operands)-i]) }
+	switch op {
+	case "q":
+		b.stack = append(b.stack, b.ctm)
+	case "Q":
+		if n := len(b.stack); n > 0 {
+			b.ctm = b.stack[n-1]
+			b.stack = b.stack[:n-1]
+		}
+	case "cm":
+		if m, ok := pdfOperandMatrix(operands); ok {
+			b.ctm = m.mul(b.ctm)
+		}
+	case "BT":
+		b.tm, b.tlm = pdfIdentity, pdfIdentity
+	case "Tm":
+		if m, ok := pdfOperandMatrix(operands); ok {
+			b.tm, b.tlm = m, m
+		}
+	case "Td", "TD":
+		if len(operands) >= 2 {
+			tx, ty := last(2), last(1)
+			if op == "TD" {
+				b.leading = -ty
+			}
+			b.moveLine(tx, ty)
+		}
+	case "TL":
+		if len(operands) >= 1 {
+			b.leading = last(1)
+		}
+	case "T*", "'", "\"":
+		b.moveLine(0, -b.leading)
+	case "Tf":
+		if len(operands) >= 1 {
+			b.fontSize = math.Abs(last(1))
+		}
+	}
+}
+
+func (b *pdfTextBounds) moveLine(tx, ty float64) {
+	b.tlm = pdfMatrix{1, 0, 0, 1, tx, ty}.mul(b.tlm)
+	b.tm = b.tlm
+}
+
+// show 记录一段文本占据的范围，并将文本位置移到其后
+// 只由页码字符 (数字、空白、连接号) 组成的文本不计入，页码位于版心之外
+func (b *pdfTextBounds) show(text string) {
+	size := b.fontSize
+	if size == 0 {
+		size = 1
+	}
+	width := 0.0
+	for _, r := range text {
+		if isPdfWideRune(r) {
+			width += size
+		} else {
+			width += size / 2
+		}
+	}
+	if width == 0 {
+		return
+	}
+
+	if !isPageNumberText(text) {
+		m := b.tm.mul(b.ctm)
+		for _, pt := range [4][2]float64{{0, -0.12 * size}, {width, -0.12 * size}, {0, 0.88 * size}, {width, 0.88 * size}} {
+			x, y := m.apply(pt[0], pt[1])
+			b.extend(x, y)
+		}
+	}
+	b.tm = pdfMatrix{1, 0, 0, 1, width, 0}.mul(b.tm)
+}
+
+func (b *pdfTextBounds) extend(x, y float64) {
+	if !b.found {
+		b.bounds = pdfRect{X1: x, Y1: y, X2: x, Y2: y}
+		b.found = true
+		return
+	}
+	b.bounds.X1 = math.Min(b.bounds.X1, x)
+	b.bounds.Y1 = math.Min(b.bounds.Y1, y)
+	b.bounds.X2 = math.Max(b.bounds.X2, x)
+	b.bounds.Y2 = math.Max(b.bounds.Y2, y)
+}
+
+// isPdfWideRune 全角字符 (中日韩文字与全角标点) 占一个字号宽
+func isPdfWideRune(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF00 && r <= 0xFFEF)
+}
+
+// isPageNumberText 是否只由页码字符组成，如 "— 1 —"、"-2-"
+func isPageNumberText(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return true
+	}
+	for _, r := range text {
+		if !unicode.IsDigit(r) && !unicode.IsSpace(r) && !strings.ContainsRune("-—–‐－", r) {
+			return false
+		}
+	}
+	return true
+}
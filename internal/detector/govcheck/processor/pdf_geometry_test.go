@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/detector/govcheck/extractor"
+)
+
+// ============================================================
+// PDF 页面几何测试
+// ============================================================
+
+func pdfNumbers(v ...float64) PdfArrayObject {
+	arr := PdfArrayObject{}
+	for _, f := range v {
+		arr.Items = append(arr.Items, PdfRealObject{Value: f})
+	}
+	return arr
+}
+
+func TestPdfParser_PageGeometry(t *testing.T) {
+	parser := NewPdfParser(nil)
+
+	// MediaBox 与 Rotate 从上级 Pages 节点继承，CropBox 超出 MediaBox 的部分不可见
+	parent := PdfDictObject{Dict: map[string]PdfObject{
+		"Type":     PdfNameObject{Value: "Pages"},
+		"MediaBox": pdfNumbers(0, 0, 595.28, 841.89),
+	}}
+	page := PdfDictObject{Dict: map[string]PdfObject{
+		"Type":    PdfNameObject{Value: "Page"},
+		"Parent":  parent,
+		"CropBox": pdfNumbers(-10, 0, 600, 900),
+	}}
+	g, ok := parser.pageGeometry(page)
+	if !ok {
+		t.Fatal("pageGeometry() 未找到继承的 MediaBox")
+	}
+	if w, h := g.sizeMM(); math.Abs(w-210) > 0.1 || math.Abs(h-297) > 0.1 {
+		t.Errorf("sizeMM() = %.1f x %.1f, want 210 x 297", w, h)
+	}
+
+	// 旋转 90 度后宽高互换，且不估算页边距
+	parent.Dict["Rotate"] = PdfIntObject{Value: -270}
+	g, _ = parser.pageGeometry(page)
+	if w, h := g.sizeMM(); g.Rotate != 90 || w < h {
+		t.Errorf("Rotate=%d sizeMM() = %.1f x %.1f, want 横向", g.Rotate, w, h)
+	}
+	if _, _, _, _, ok := g.marginsMM(pdfRect{X1: 100, Y1: 100, X2: 200, Y2: 200}); ok {
+		t.Error("旋转页面不应估算页边距")
+	}
+
+	if _, ok := parser.pageGeometry(PdfDictObject{Dict: map[string]PdfObject{}}); ok {
+		t.Error("没有 MediaBox 的页面不应返回几何信息")
+	}
+}
+
+func TestPdfProcessor_DetectPageSettings(t *testing.T) {
+	pt := func(mm float64) float64 { return mm / pdfPointToMM }
+	const size = 16.0
+	pageW, pageH := pt(210), pt(297)
+
+	// 左边距 28mm；首行字形顶部距上边 37mm，末行字形底部距下边 35mm；
+	// 每行 55 个半角字符宽 440pt，右边距约 26.8mm；页码 "- 1 -" 位于版心之外
+	left := pt(28)
+	firstBaseline := pageH - pt(37) - 0.88*size
+	lastBaseline := pt(35) + 0.12*size
+	line := "(" + strings.Repeat("x", 55) + ") Tj"
+	content := fmt.Sprintf(`q 1 0 0 1 10 0 cm
+BT /F1 %g Tf 1 0 0 1 %f %f Tm %s ET
+BT /F1 %g Tf %f %f Td %s ET Q
+BT /F1 14 Tf 280 60 Td (- 1 -) Tj ET`,
+		size, left-10, firstBaseline, line,
+		size, left-10, lastBaseline, line)
+
+	parser := NewPdfParser(nil)
+	parser.pageObjs = []PdfObject{PdfDictObject{Dict: map[string]PdfObject{
+		"Type":     PdfNameObject{Value: "Page"},
+		"MediaBox": pdfNumbers(0, 0, pageW, pageH),
+	}}}
+	e := NewPdfTextExtractor(parser)
+	e.parseContentStream([]byte(content))
+
+	p := NewPdfProcessor()
+	sf := &extractor.StyleFeatures{}
+	p.detectPageSettings(parser, e, sf)
+	if !sf.IsA4Paper || !sf.MarginMatch {
+		b, _ := e.TextBounds()
+		t.Errorf("IsA4Paper=%v MarginMatch=%v bounds=%+v", sf.IsA4Paper, sf.MarginMatch, b)
+	}
+
+	// 文本铺满页面时页边距不符合
+	e = NewPdfTextExtractor(parser)
+	e.parseContentStream([]byte(fmt.Sprintf("BT /F1 %g Tf 0 10 Td %s ET", size, line)))
+	sf = &extractor.StyleFeatures{}
+	p.detectPageSettings(parser, e, sf)
+	if !sf.IsA4Paper || sf.MarginMatch {
+		t.Errorf("铺满页面: IsA4Paper=%v MarginMatch=%v", sf.IsA4Paper, sf.MarginMatch)
+	}
+}
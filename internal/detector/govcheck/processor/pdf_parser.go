@@ -232,6 +232,7 @@ type PdfParser struct {
 	trailer    *PdfDictObject          // trailer字典
 	pageObjs   []PdfObject             // 页面对象列表
 	fontObjs   map[string]*PdfDictObject // 字体对象
+	budget     *memBudget                // 流解码与文本抽取的内存预算
 }
 
 // NewPdfParser 创建PDF解析器
@@ -244,6 +245,33 @@ func NewPdfParser(data []byte) *PdfParser {
 	}
 }
 
+// SetMemoryLimit 设置流解码与文本抽取的内存预算 (字节)，<=0 表示不限制
+func (p *PdfParser) SetMemoryLimit(limit int64) {
+	p.budget = newMemBudget(limit)
+}
+
+// Partial 是否因超出内存预算而截断了流解码或文本抽取
+func (p *PdfParser) Partial() bool {
+	return p.budget.partial()
+}
+
+// decodeStream 解码流对象并计入内存预算，超出预算时截断解码结果
+func (p *PdfParser) decodeStream(o PdfStreamObject) ([]byte, error) {
+	if o.Dict.GetString("Filter") != "FlateDecode" {
+		return o.GetDecodedData()
+	}
+
+	decoded, truncated, err := decodeFlateLimit(o.RawData, p.budget.remaining())
+	if err != nil {
+		return decoded, err
+	}
+	p.budget.take(len(decoded))
+	if truncated {
+		p.budget.exceeded = true
+	}
+	return decoded, nil
+}
+
 // Parse 解析PDF文件
 func (p *PdfParser) Parse() error {
 	// 1. 验证PDF头
@@ -991,18 +1019,31 @@ func isDelimiter(ch byte) bool {
 
 // decodeFlate 解压FlateDecode数据
 func decodeFlate(data []byte) ([]byte, error) {
+	decoded, _, err := decodeFlateLimit(data, -1)
+	return decoded, err
+}
+
+// decodeFlateLimit 解压FlateDecode数据，limit>=0 时最多输出 limit 字节，超出部分截断并返回 true
+func decodeFlateLimit(data []byte, limit int64) ([]byte, bool, error) {
 	reader, err := zlib.NewReader(bytes.NewReader(data))
 	if err != nil {
-		return data, err
+		return data, false, err
 	}
 	defer reader.Close()
 
-	decoded, err := io.ReadAll(reader)
+	var r io.Reader = reader
+	if limit >= 0 {
+		r = io.LimitReader(reader, limit+1)
+	}
+	decoded, err := io.ReadAll(r)
 	if err != nil {
-		return data, err
+		return data, false, err
 	}
 
-	return decoded, nil
+	if limit >= 0 && int64(len(decoded)) > limit {
+		return decoded[:limit], true, nil
+	}
+	return decoded, false, nil
 }
 
 // ============================================================
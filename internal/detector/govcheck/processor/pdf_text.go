@@ -14,6 +14,14 @@ type PdfTextExtractor struct {
 	parser        *PdfParser
 	cMaps         map[string]map[uint16]rune
 	fontEncodings map[string]string
+
+	// 红色文本，供版式特征判断红头
+	page     int
+	redRuns  []pdfRedRun
+	redCount int
+
+	// 文本范围，供版式特征估算页边距
+	bounds *pdfTextBounds
 }
 
 // NewPdfTextExtractor 创建文本提取器
@@ -22,10 +30,14 @@ func NewPdfTextExtractor(parser *PdfParser) *PdfTextExtractor {
 		parser:        parser,
 		cMaps:         make(map[string]map[uint16]rune),
 		fontEncodings: make(map[string]string),
+		bounds:        newPdfTextBounds(),
 	}
 }
 
-// ExtractText 提取所有页面的文本
+// pdfPageBreak 页之间的分隔 (独占一行的换页符)，检测结果据此计算命中所在页码
+const pdfPageBreak = "\n\f\n"
+
+// ExtractText 提取所有页面的文本，页之间以 pdfPageBreak 分隔 (无文本的页面也保留分隔)
 func (e *PdfTextExtractor) ExtractText() (string, error) {
 	pages := e.parser.GetPages()
 	if len(pages) == 0 {
@@ -33,24 +45,33 @@ func (e *PdfTextExtractor) ExtractText() (string, error) {
 	}
 
 	var allText strings.Builder
+	hasText := false
 
 	for i, page := range pages {
+		e.page = i
 		pageText, err := e.extractPageText(&page)
 		if err != nil {
-			continue
+			pageText = ""
 		}
+		// 后处理按页进行，避免分页符被当作控制字符移除
+		pageText = postProcessPdfText(pageText)
 
-		if pageText != "" {
-			if i > 0 {
-				allText.WriteString("\n")
-			}
-			allText.WriteString(pageText)
+		// 超出内存预算时保留已抽取的页面
+		if !e.parser.budget.take(len(pageText)) {
+			break
 		}
+		if i > 0 {
+			allText.WriteString(pdfPageBreak)
+		}
+		allText.WriteString(pageText)
+		hasText = hasText || pageText != ""
 	}
 
-	// 后处理
-	result := postProcessPdfText(allText.String())
-	return result, nil
+	// 全部页面都没有文本时 (扫描件) 不返回分页符
+	if !hasText {
+		return "", nil
+	}
+	return allText.String(), nil
 }
 
 // extractPageText 提取单个页面的文本
@@ -126,7 +147,7 @@ func (e *PdfTextExtractor) loadPageFonts(page *PdfDictObject) {
 			}
 
 			if stream, ok := toUnicode.(PdfStreamObject); ok {
-				data, err := stream.GetDecodedData()
+				data, err := e.parser.decodeStream(stream)
 				if err == nil {
 					cmap := parseCMap(data)
 					if len(cmap) > 0 {
@@ -147,7 +168,7 @@ func (e *PdfTextExtractor) getContentData(contentsObj PdfObject) ([]byte, error)
 
 	switch c := contents.(type) {
 	case PdfStreamObject:
-		return c.GetDecodedData()
+		return e.parser.decodeStream(c)
 
 	case PdfArrayObject:
 		var allData bytes.Buffer
@@ -157,7 +178,7 @@ func (e *PdfTextExtractor) getContentData(contentsObj PdfObject) ([]byte, error)
 				continue
 			}
 			if stream, ok := streamObj.(PdfStreamObject); ok {
-				data, err := stream.GetDecodedData()
+				data, err := e.parser.decodeStream(stream)
 				if err == nil {
 					allData.Write(data)
 					allData.WriteByte('\n')
@@ -171,17 +192,69 @@ func (e *PdfTextExtractor) getContentData(contentsObj PdfObject) ([]byte, error)
 	}
 }
 
+// RedRuns 返回抽取过程中以红色绘制的文本段 (同一行连续的红色文本合并为一段) 及红色文本段总数
+func (e *PdfTextExtractor) RedRuns() ([]pdfRedRun, int) {
+	return e.redRuns, e.redCount
+}
+
+// TextBounds 返回已抽取页面的文本在用户空间中的总范围 (不含页码)
+func (e *PdfTextExtractor) TextBounds() (pdfRect, bool) {
+	return e.bounds.bounds, e.bounds.found
+}
+
 // parseContentStream 解析内容流提取文本（简化版，不添加换行）
+// 同时跟踪颜色状态与文本位置，记录红色文本段和文本范围
 func (e *PdfTextExtractor) parseContentStream(data []byte) string {
 	var result strings.Builder
 	var currentFont string
+	e.bounds.reset()
+
+	var colors pdfColorTracker
+	line := 0
+	newLine := true
+	lastY := 0.0
+	showText := func(text string) {
+		if newLine {
+			line++
+			newLine = false
+		}
+		e.bounds.show(text)
+		if text == "" || !colors.textIsRed() {
+			return
+		}
+		if n := len(e.redRuns); n > 0 && e.redRuns[n-1].Page == e.page && e.redRuns[n-1].Line == line {
+			e.redRuns[n-1].Text += text
+			return
+		}
+		e.redCount++
+		if len(e.redRuns) < maxPdfRedRuns {
+			e.redRuns = append(e.redRuns, pdfRedRun{Page: e.page, Line: line, Text: text})
+		}
+	}
 
 	tokens := tokenizeContentStream(data)
 	operandStack := make([]string, 0)
 
 	for _, token := range tokens {
 		if isOperator(token) {
+			colors.apply(token, operandStack)
+			e.bounds.apply(token, operandStack)
+
 			switch token {
+			case "Td", "TD":
+				// 只有纵向移动才换行
+				if len(operandStack) >= 2 && parseFloat(operandStack[len(operandStack)-1]) != 0 {
+					newLine = true
+				}
+
+			case "Tm":
+				if len(operandStack) >= 6 {
+					if y := parseFloat(operandStack[len(operandStack)-1]); y != lastY {
+						lastY = y
+						newLine = true
+					}
+				}
+
 			case "Tf":
 				if len(operandStack) >= 2 {
 					fontName := operandStack[len(operandStack)-2]
@@ -193,25 +266,30 @@ func (e *PdfTextExtractor) parseContentStream(data []byte) string {
 				if len(operandStack) >= 1 {
 					text := e.decodeTextString(operandStack[len(operandStack)-1], currentFont)
 					result.WriteString(text)
+					showText(text)
 				}
 
 			case "TJ":
 				if len(operandStack) >= 1 {
 					text := e.decodeTJArray(operandStack[len(operandStack)-1], currentFont)
 					result.WriteString(text)
+					showText(text)
 				}
 
 			case "'", "\"":
 				// 这些操作符包含换行语义
 				if len(operandStack) >= 1 {
 					result.WriteString("\n")
+					newLine = true
 					text := e.decodeTextString(operandStack[len(operandStack)-1], currentFont)
 					result.WriteString(text)
+					showText(text)
 				}
 
 			case "T*":
 				// 明确的换行
 				result.WriteString("\n")
+				newLine = true
 			}
 
 			operandStack = operandStack[:0]
@@ -943,7 +1021,8 @@ func convertToUTF8(s string) string {
 		return s
 	}
 
-	if decoded, err := decodeGBK([]byte(s)); err == nil {
+	// 按统计检测结果转码 (GB18030/Big5/UTF-16)
+	if decoded := DecodeText([]byte(s), ""); isValidUTF8String(decoded) {
 		return decoded
 	}
 
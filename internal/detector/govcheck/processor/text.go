@@ -1,16 +1,20 @@
 package processor
 
 import (
-	"bytes"
 	"fmt"
-	"io"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"golang.org/x/net/html"
 	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/transform"
+)
+
+// HTML 清理用正则
+var (
+	htmlInvisiblePattern = regexp.MustCompile(`(?is)<(script|style|noscript|template)\b[^>]*>.*?</(script|style|noscript|template)\s*>`)
+	htmlCommentPattern   = regexp.MustCompile(`(?s)<!--.*?-->`)
 )
 
 // TextProcessor 文本文件处理器
@@ -21,10 +25,16 @@ type TextProcessor struct {
 
 // TextProcessorConfig 文本处理器配置
 type TextProcessorConfig struct {
-	MaxFileSize    int64 // 最大文件大小 (字节)
-	AutoDetectGBK  bool  // 自动检测GBK编码
+	MaxFileSize    int64  // 最大文件大小 (字节)
+	AutoDetectGBK  bool   // 自动检测 GBK/GB18030/Big5/UTF-16 等遗留编码
+	DefaultCharset string // 强制使用的字符集 (为空则自动检测)
 	StripHTMLTags  bool  // 是否去除HTML标签
 	NormalizeSpace bool  // 是否规范化空白字符
+
+	// MHT 内嵌图片 OCR
+	EnableInlineOCR    bool // 是否对 MHT 内嵌图片执行 OCR
+	MaxInlineImages    int  // 单个文件最多 OCR 的图片数 (0 表示不限制)
+	MinInlineImageSize int  // 参与 OCR 的最小图片字节数
 }
 
 // DefaultTextProcessorConfig 返回默认配置
@@ -34,6 +44,10 @@ func DefaultTextProcessorConfig() *TextProcessorConfig {
 		AutoDetectGBK:  true,
 		StripHTMLTags:  true,
 		NormalizeSpace: true,
+
+		EnableInlineOCR:    true,
+		MaxInlineImages:    10,
+		MinInlineImageSize: 2 * 1024,
 	}
 }
 
@@ -83,10 +97,6 @@ func (p *TextProcessor) Process(filePath string) (string, error) {
 		return "", NewProcessorError(p.Name(), filePath, "获取文件信息", err)
 	}
 
-	if info.Size() == 0 {
-		return "", NewProcessorError(p.Name(), filePath, "检查文件", fmt.Errorf("文件为空"))
-	}
-
 	if p.config.MaxFileSize > 0 && info.Size() > p.config.MaxFileSize {
 		return "", NewProcessorError(p.Name(), filePath, "检查文件大小",
 			fmt.Errorf("文件过大: %d 字节 (限制: %d 字节)", info.Size(), p.config.MaxFileSize))
@@ -98,11 +108,37 @@ func (p *TextProcessor) Process(filePath string) (string, error) {
 		return "", NewProcessorError(p.Name(), filePath, "读取文件", err)
 	}
 
+	return p.ProcessBytes(filePath, content)
+}
+
+// ProcessBytes 处理内存中的文本内容，name 用于按扩展名区分 HTML/XML/RTF/MHT/EML
+func (p *TextProcessor) ProcessBytes(name string, content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", NewProcessorError(p.Name(), name, "检查文件", fmt.Errorf("文件为空"))
+	}
+
+	if p.config.MaxFileSize > 0 && int64(len(content)) > p.config.MaxFileSize {
+		return "", NewProcessorError(p.Name(), name, "检查文件大小",
+			fmt.Errorf("文件过大: %d 字节 (限制: %d 字节)", len(content), p.config.MaxFileSize))
+	}
+
+	ext := strings.ToLower(getFileExtension(name))
+
+	// MHT 归档按 MIME 部件解码，不能整体转码
+	if ext == "mht" || ext == "mhtml" {
+		if text, err := p.processMHT(content); err == nil {
+			if p.config.NormalizeSpace {
+				text = normalizeWhitespace(text)
+			}
+			return text, nil
+		}
+		// 非标准 MHT (如直接另存的 HTML)，回退到 HTML 处理
+	}
+
 	// 检测并转换编码
 	text := p.decodeContent(content)
 
 	// 根据文件类型进行处理
-	ext := strings.ToLower(getFileExtension(filePath))
 	switch ext {
 	case "html", "htm", "mht", "mhtml":
 		text = p.processHTML(text)
@@ -127,36 +163,20 @@ func (p *TextProcessor) Process(filePath string) (string, error) {
 
 // decodeContent 检测并解码文件内容
 func (p *TextProcessor) decodeContent(content []byte) string {
-	// 检查BOM
-	if len(content) >= 3 && content[0] == 0xEF && content[1] == 0xBB && content[2] == 0xBF {
-		// UTF-8 BOM
-		return string(content[3:])
-	}
-	if len(content) >= 2 {
-		if content[0] == 0xFF && content[1] == 0xFE {
-			// UTF-16 LE BOM
-			return decodeUTF16LE(content[2:])
-		}
-		if content[0] == 0xFE && content[1] == 0xFF {
-			// UTF-16 BE BOM
-			return decodeUTF16BE(content[2:])
-		}
-	}
-
-	// 尝试作为UTF-8解析
-	text := string(content)
-	if isValidUTF8(content) {
-		return text
+	if !p.config.AutoDetectGBK {
+		// 关闭遗留编码检测时仅处理 BOM
+		cs, bomLen := detectBOM(content)
+		return decodeWithCharsetName(content[bomLen:], cs)
 	}
+	return DecodeText(content, p.config.DefaultCharset)
+}
 
-	// 自动检测GBK编码
-	if p.config.AutoDetectGBK && mightBeGBK(content) {
-		if decoded, err := decodeGBK(content); err == nil {
-			return decoded
-		}
+// decodeWithCharset 按声明的字符集解码内容，未声明时自动检测
+func (p *TextProcessor) decodeWithCharset(content []byte, charset string) string {
+	if NormalizeCharset(charset) != CharsetUnknown {
+		return DecodeText(content, charset)
 	}
-
-	return text
+	return p.decodeContent(content)
 }
 
 // processHTML 处理HTML内容
@@ -251,22 +271,9 @@ func isValidUTF8(data []byte) bool {
 	return true
 }
 
-// mightBeGBK 检测是否可能是GBK编码
-func mightBeGBK(data []byte) bool {
-	hasHighByte := false
-	for _, b := range data {
-		if b >= 0x80 {
-			hasHighByte = true
-			break
-		}
-	}
-	return hasHighByte && !isValidUTF8(data)
-}
-
-// decodeGBK 解码GBK编码
+// decodeGBK 解码GBK编码 (按 GB18030 解码，兼容 GBK/GB2312)
 func decodeGBK(data []byte) (string, error) {
-	reader := transform.NewReader(bytes.NewReader(data), simplifiedchinese.GBK.NewDecoder())
-	decoded, err := io.ReadAll(reader)
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
 	if err != nil {
 		return "", err
 	}
@@ -275,30 +282,12 @@ func decodeGBK(data []byte) (string, error) {
 
 // decodeUTF16LE 解码UTF-16 LE
 func decodeUTF16LE(data []byte) string {
-	if len(data)%2 != 0 {
-		data = data[:len(data)-1]
-	}
-
-	var result strings.Builder
-	for i := 0; i < len(data); i += 2 {
-		r := rune(data[i]) | rune(data[i+1])<<8
-		result.WriteRune(r)
-	}
-	return result.String()
+	return decodeWithCharsetName(data, CharsetUTF16LE)
 }
 
 // decodeUTF16BE 解码UTF-16 BE
 func decodeUTF16BE(data []byte) string {
-	if len(data)%2 != 0 {
-		data = data[:len(data)-1]
-	}
-
-	var result strings.Builder
-	for i := 0; i < len(data); i += 2 {
-		r := rune(data[i])<<8 | rune(data[i+1])
-		result.WriteRune(r)
-	}
-	return result.String()
+	return decodeWithCharsetName(data, CharsetUTF16BE)
 }
 
 // extractTextFromNode 从HTML节点提取文本
@@ -311,9 +300,9 @@ func extractTextFromNode(n *html.Node, sb *strings.Builder) {
 		}
 	}
 
-	// 跳过 script 和 style 标签
+	// 跳过脚本、样式等不可见内容（注释节点不会进入 TextNode 分支）
 	if n.Type == html.ElementNode {
-		if n.Data == "script" || n.Data == "style" {
+		if isInvisibleElement(n.Data) {
 			return
 		}
 		// 在块级元素后添加换行
@@ -327,6 +316,15 @@ func extractTextFromNode(n *html.Node, sb *strings.Builder) {
 	}
 }
 
+// isInvisibleElement 检查是否为不参与正文展示的元素
+func isInvisibleElement(tag string) bool {
+	switch tag {
+	case "script", "style", "noscript", "template", "head", "object", "iframe", "svg":
+		return true
+	}
+	return false
+}
+
 // isBlockElement 检查是否为块级元素
 func isBlockElement(tag string) bool {
 	blockElements := map[string]bool{
@@ -341,6 +339,10 @@ func isBlockElement(tag string) bool {
 
 // stripHTMLTagsSimple 简单的HTML标签移除
 func stripHTMLTagsSimple(content string) string {
+	// 移除脚本、样式和注释及其内容
+	content = htmlInvisiblePattern.ReplaceAllString(content, " ")
+	content = htmlCommentPattern.ReplaceAllString(content, " ")
+
 	// 移除HTML标签
 	re := regexp.MustCompile(`<[^>]*>`)
 	text := re.ReplaceAllString(content, " ")
@@ -427,17 +429,30 @@ func decodeHTMLEntities(text string) string {
 }
 
 // extractRTFText 从RTF中提取文本
+// \'xx 十六进制转义按 \ansicpgN 声明的代码页解码，\uN 按 Unicode 码点输出
 func extractRTFText(content string) string {
 	var result strings.Builder
 	inGroup := 0
 	skipGroup := false
 	i := 0
 
+	// 待解码的代码页字节 (GBK/Big5 汉字由两个 \'xx 组成)
+	charset := CharsetGB18030
+	var pending []byte
+	flush := func() {
+		// 不支持的代码页 (如西文 1252) 与旧实现一致直接丢弃
+		if len(pending) > 0 && charset != CharsetUnknown {
+			result.WriteString(decodeWithCharsetName(pending, charset))
+		}
+		pending = pending[:0]
+	}
+
 	for i < len(content) {
 		ch := content[i]
 
 		switch ch {
 		case '{':
+			flush()
 			inGroup++
 			// 检查是否需要跳过的组
 			if i+10 < len(content) {
@@ -452,6 +467,7 @@ func extractRTFText(content string) string {
 			i++
 
 		case '}':
+			flush()
 			inGroup--
 			if inGroup <= 1 {
 				skipGroup = false
@@ -468,20 +484,51 @@ func extractRTFText(content string) string {
 			if i < len(content) {
 				if content[i] == '\'' {
 					// 十六进制字符 \'xx
+					if i+3 <= len(content) {
+						if b, err := strconv.ParseUint(content[i+1:i+3], 16, 8); err == nil {
+							pending = append(pending, byte(b))
+						}
+					}
 					i += 3
 				} else if content[i] == '\n' || content[i] == '\r' {
 					i++
 				} else {
 					// 普通控制字
+					start := i
 					for i < len(content) && ((content[i] >= 'a' && content[i] <= 'z') ||
-						(content[i] >= 'A' && content[i] <= 'Z') ||
-						(content[i] >= '0' && content[i] <= '9') ||
-						content[i] == '-') {
+						(content[i] >= 'A' && content[i] <= 'Z')) {
+						i++
+					}
+					word := content[start:i]
+					paramStart := i
+					for i < len(content) && ((content[i] >= '0' && content[i] <= '9') || content[i] == '-') {
 						i++
 					}
+					param, _ := strconv.Atoi(content[paramStart:i])
 					if i < len(content) && content[i] == ' ' {
 						i++
 					}
+
+					switch word {
+					case "ansicpg":
+						flush()
+						charset = rtfCodepageCharset(param)
+					case "u":
+						flush()
+						if param < 0 {
+							param += 65536
+						}
+						result.WriteRune(rune(param))
+						// 跳过紧随其后的替代字符 (\uc1 默认 1 个)
+						if i < len(content) && content[i] == '?' {
+							i++
+						} else if i+4 <= len(content) && content[i] == '\\' && content[i+1] == '\'' {
+							i += 4
+						}
+					case "par", "line":
+						flush()
+						result.WriteByte('\n')
+					}
 				}
 			}
 
@@ -489,12 +536,14 @@ func extractRTFText(content string) string {
 			i++
 
 		default:
+			flush()
 			if !skipGroup && inGroup >= 1 {
 				result.WriteByte(ch)
 			}
 			i++
 		}
 	}
+	flush()
 
 	return result.String()
 }
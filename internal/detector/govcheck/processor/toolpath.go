@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ============================================================
+// 外部工具查找 (LibreOffice / antiword)
+// ============================================================
+
+// findExecutable 查找外部工具
+// 先在 PATH 中按名称查找 (Windows 下自动补全 .exe)，再依次尝试当前平台的常见安装路径
+func findExecutable(names []string, candidates []string) string {
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// libreOfficeCandidates 当前平台 LibreOffice 的常见安装路径
+func libreOfficeCandidates() []string {
+	switch runtime.GOOS {
+	case "windows":
+		paths := programFilesPaths(`LibreOffice\program\soffice.exe`)
+		return append(paths,
+			`D:\Program Files\LibreOffice\program\soffice.exe`,
+			`D:\LibreOffice\program\soffice.exe`,
+		)
+	case "darwin":
+		home, _ := os.UserHomeDir()
+		return []string{
+			"/Applications/LibreOffice.app/Contents/MacOS/soffice",
+			filepath.Join(home, "Applications/LibreOffice.app/Contents/MacOS/soffice"),
+			"/opt/homebrew/bin/soffice",
+		}
+	default:
+		return []string{
+			"/usr/bin/soffice",
+			"/usr/bin/libreoffice",
+			"/usr/local/bin/soffice",
+			"/opt/libreoffice/program/soffice",
+			"/snap/bin/libreoffice",
+		}
+	}
+}
+
+// antiwordCandidates 当前平台 antiword 的常见安装路径
+func antiwordCandidates() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return append(programFilesPaths(`antiword\antiword.exe`), `C:\antiword\antiword.exe`)
+	case "darwin":
+		return []string{
+			"/opt/homebrew/bin/antiword", // Homebrew (Apple Silicon)
+			"/usr/local/bin/antiword",    // Homebrew (Intel)
+			"/opt/local/bin/antiword",    // MacPorts
+		}
+	default:
+		return []string{
+			"/usr/bin/antiword",
+			"/usr/local/bin/antiword",
+		}
+	}
+}
+
+// programFilesPaths 拼接 Windows 各 Program Files 目录下的相对路径，未设置的环境变量被跳过
+func programFilesPaths(rel string) []string {
+	var paths []string
+	for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "ProgramW6432"} {
+		if dir := os.Getenv(env); dir != "" {
+			paths = append(paths, filepath.Join(dir, rel))
+		}
+	}
+	return append(paths,
+		filepath.Join(`C:\Program Files`, rel),
+		filepath.Join(`C:\Program Files (x86)`, rel),
+	)
+}
@@ -2,6 +2,7 @@ package govcheck
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,8 +11,13 @@ import (
 
 	globalModel "linuxFileWatcher/internal/model"
 
+	"linuxFileWatcher/internal/archiveguard"
+	"linuxFileWatcher/internal/detector/document"
 	"linuxFileWatcher/internal/detector/govcheck/detector"
 	"linuxFileWatcher/internal/detector/govcheck/processor"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/tracing"
 )
 
 // 公文版式检测规则 ID
@@ -122,6 +128,112 @@ func (s *service) DetectFile(ctx context.Context, filePath string) (*globalModel
 		return nil, nil // 不支持的类型跳过
 	}
 
+	return s.run(ctx, func() *detector.DetectionResult {
+		return s.detector.Detect(filePath)
+	})
+}
+
+// DetectBytes 检测内存中的内容
+// 文本类格式 (TXT/HTML/XML/RTF/MHT/EML) 直接在内存中解析；
+// DOC/DOCX/PDF/OFD 等依赖外部工具或按路径解析的格式写入临时文件后检测
+func (s *service) DetectBytes(ctx context.Context, name string, data []byte) (*globalModel.SubDetectResult, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if s.config.MaxFileSize > 0 && int64(len(data)) > s.config.MaxFileSize {
+		return nil, nil
+	}
+
+	fileType := filetype.DetectContentType(name, data)
+	if fileType.Extension == "" || !isTypeSupported(fileType.Extension, s.detector.SupportedTypes()) {
+		return nil, nil
+	}
+
+	if s.detector.SupportsContent(fileType.Extension) {
+		return s.run(ctx, func() *detector.DetectionResult {
+			return s.detector.DetectContent(name, data)
+		})
+	}
+
+	dir, err := os.MkdirTemp("", "govcheck-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := writeTemp(dir, name, fileType.Extension, data)
+	if err != nil {
+		return nil, err
+	}
+	return s.DetectFile(ctx, path)
+}
+
+// writeTemp 将内存内容写入 dir
+// 处理器按扩展名选择，临时文件名补齐识别出的扩展名
+func writeTemp(dir, name, ext string, data []byte) (string, error) {
+	base := filepath.Base(name)
+	if base == "." || base == string(filepath.Separator) {
+		base = "content"
+	}
+	if !strings.EqualFold(strings.TrimPrefix(filepath.Ext(base), "."), ext) {
+		base += "." + ext
+	}
+	path := filepath.Join(dir, base)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// run 带超时与 panic 恢复执行检测，并将结果转换为通用中间结果
+func (s *service) run(ctx context.Context, detect func() *detector.DetectionResult) (*globalModel.SubDetectResult, error) {
+	var result *detector.DetectionResult
+	err := s.guard(ctx, func() error {
+		result = detect()
+		return nil
+	})
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		// 超时视为未命中
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. 结果转换
+	if result == nil {
+		return nil, nil
+	}
+
+	// 检测失败；压缩炸弹单独上报，其余视为未命中
+	if result.ArchiveBomb {
+		return nil, fmt.Errorf("%w: %s", archiveguard.ErrBomb, result.Error)
+	}
+	if !result.Success || result.Error != "" {
+		return nil, nil
+	}
+
+	// 未达到阈值，不是公文
+	if !result.IsOfficialDoc {
+		return nil, nil
+	}
+
+	// 6. 构造返回结果
+	subResult := &globalModel.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: globalModel.LevelInternal, // 公文默认内部级别
+		RuleID:      RuleIDGovCheck,            // int64 类型
+		RuleDesc:    s.buildRuleDesc(result),
+		MatchedText: s.buildMatchedText(result),
+		ContextText: s.buildContextText(result),
+		AlertType:   3, // 公文版式告警类型
+	}
+
+	return subResult, nil
+}
+
+// guard 带超时与 panic 恢复执行 fn，超时返回 ctx 的错误 (fn 仍在后台运行至结束)
+func (s *service) guard(ctx context.Context, fn func() error) error {
 	// 3. 设置超时控制
 	var detectCtx context.Context
 	var cancel context.CancelFunc
@@ -135,88 +247,128 @@ func (s *service) DetectFile(ctx context.Context, filePath string) (*globalModel
 	defer cancel()
 
 	// 4. 执行检测（带 panic 恢复）
-	var result *detector.DetectionResult
-	var detectErr error
+	var fnErr error
 
 	done := make(chan struct{})
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
-				detectErr = fmt.Errorf("检测过程发生异常: %v", r)
+				fnErr = fmt.Errorf("检测过程发生异常: %v", r)
 			}
 			close(done)
 		}()
-		result = s.detector.Detect(filePath)
+		fnErr = fn()
 	}()
 
 	// 等待检测完成或超时
 	select {
 	case <-done:
-		// 检测完成
+		return fnErr
 	case <-detectCtx.Done():
-		// 超时
-		return nil, nil
+		return detectCtx.Err()
 	}
+}
 
-	if detectErr != nil {
-		return nil, detectErr
+// Extract 实现 document.Extractor：抽取文本与版式特征，供检测流水线中的各子检测器共用
+func (s *service) Extract(ctx context.Context, doc *document.Document) (*document.Content, error) {
+	ext := doc.Type.Extension
+	if ext == "" || !isTypeSupported(ext, s.detector.SupportedTypes()) {
+		return nil, document.ErrUnsupported
+	}
+	if s.config.MaxFileSize > 0 && doc.Size > s.config.MaxFileSize {
+		return nil, document.ErrUnsupported
 	}
 
-	// 5. 结果转换
-	if result == nil {
-		return nil, nil
+	// 不支持内存内容的处理器按路径解析
+	path := doc.Path
+	if !doc.OnDisk() && !s.detector.SupportsContent(ext) {
+		dir, err := os.MkdirTemp("", "govcheck-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		if path, err = writeTemp(dir, doc.Name, ext, doc.Data()); err != nil {
+			return nil, err
+		}
 	}
 
-	// 检测失败
-	if !result.Success || result.Error != "" {
-		return nil, nil
+	var content *document.Content
+	err := s.guard(ctx, func() error {
+		res, err := s.detector.Extract(path, ext, doc.Data())
+		if err != nil {
+			return err
+		}
+		content = &document.Content{Text: res.Text, Style: res.StyleFeatures, Partial: res.Partial}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return content, nil
+}
 
-	// 未达到阈值，不是公文
-	if !result.IsOfficialDoc {
+// DetectDocument 使用共享抽取结果检测
+func (s *service) DetectDocument(ctx context.Context, doc *document.Document) (*globalModel.SubDetectResult, error) {
+	if doc.Size == 0 {
+		return nil, nil
+	}
+	if s.config.MaxFileSize > 0 && doc.Size > s.config.MaxFileSize {
 		return nil, nil
 	}
 
-	// 6. 构造返回结果
-	subResult := &globalModel.SubDetectResult{
-		IsSecret:    true,
-		SecretLevel: globalModel.LevelInternal, // 公文默认内部级别
-		RuleID:      RuleIDGovCheck,            // int64 类型
-		RuleDesc:    s.buildRuleDesc(result),
-		MatchedText: s.buildMatchedText(result),
-		ContextText: s.buildContextText(result),
-		AlertType:   3, // 公文版式告警类型
+	content, err := doc.Content(ctx)
+	if errors.Is(err, document.ErrUnsupported) {
+		// 共享抽取器不是本检测器或格式不支持时，按原方式检测
+		if doc.OnDisk() {
+			return s.DetectFile(ctx, doc.Path)
+		}
+		return s.DetectBytes(ctx, doc.Path, doc.Data())
+	}
+	if errors.Is(err, archiveguard.ErrBomb) {
+		return nil, err
+	}
+	if err != nil {
+		// 抽取失败或超时与直接检测一致，视为未命中
+		return nil, nil
 	}
 
-	return subResult, nil
+	// 特征提取与评分
+	end := tracing.Begin(ctx, "govcheck.score")
+	res, err := s.run(ctx, func() *detector.DetectionResult {
+		result := s.detector.DetectExtracted(doc.Path, doc.Size, doc.Type.Extension, content.Text, content.Style)
+		result.Partial = content.Partial
+		return result
+	})
+	end(err)
+	return res, err
 }
 
 // buildRuleDesc 构建规则描述
 func (s *service) buildRuleDesc(result *detector.DetectionResult) string {
 	if result.Features == nil {
-		return fmt.Sprintf("公文版式检测命中 (置信度: %.1f%%)", result.Confidence*100)
+		return i18n.T("detect.govcheck.hit_detail", i18n.T("detect.govcheck.confidence", result.Confidence*100))
 	}
 
 	var parts []string
 
 	// 添加文种信息
 	if result.Features.TitleType != "" {
-		parts = append(parts, fmt.Sprintf("文种: %s", result.Features.TitleType))
+		parts = append(parts, i18n.T("detect.govcheck.title_type", result.Features.TitleType))
 	}
 
 	// 添加发文机关
 	if result.Features.HasOrgName && len(result.Features.OrgNames) > 0 {
-		parts = append(parts, fmt.Sprintf("发文机关: %s", strings.Join(result.Features.OrgNames, "、")))
+		parts = append(parts, i18n.T("detect.govcheck.org", strings.Join(result.Features.OrgNames, i18n.T("detect.govcheck.list_sep"))))
 	}
 
 	// 添加置信度
-	parts = append(parts, fmt.Sprintf("置信度: %.1f%%", result.Confidence*100))
+	parts = append(parts, i18n.T("detect.govcheck.confidence", result.Confidence*100))
 
 	if len(parts) > 0 {
-		return fmt.Sprintf("公文版式检测命中 (%s)", strings.Join(parts, "; "))
+		return i18n.T("detect.govcheck.hit_detail", strings.Join(parts, "; "))
 	}
 
-	return "公文版式检测命中"
+	return i18n.T("detect.govcheck.hit")
 }
 
 // buildMatchedText 构建匹配文本（用于高亮显示）
@@ -298,6 +450,10 @@ func (s *service) buildContextText(result *detector.DetectionResult) string {
 		context = append(context, fmt.Sprintf("印发: %s", result.Features.PrintInfo))
 	}
 
+	if result.Partial {
+		context = append(context, i18n.T("detect.govcheck.partial"))
+	}
+
 	return strings.Join(context, "\n")
 }
 
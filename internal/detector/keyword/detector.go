@@ -0,0 +1,149 @@
+package keyword
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/detector/pii"
+	"linuxFileWatcher/internal/model"
+)
+
+// 告警中附带的命中文本与上下文长度 (字符数)
+const (
+	matchLen   = 64
+	contextLen = 30
+)
+
+// regexRule 编译后的正则规则
+type regexRule struct {
+	cfg        model.KeywordDetectRule
+	re         *regexp.Regexp
+	minCount   int
+	maxMatches int
+	level      model.SecretLevel
+}
+
+// Detector 关键词检测器 (内置模式与正则规则)，实现 detector.SubDetector
+type Detector struct {
+	patterns *pii.Detector
+	regexes  []*regexRule
+}
+
+// New 解析关键词策略中的模式规则与正则规则，关键词表达式规则忽略；任一规则无效时返回错误
+func New(cfg *model.KeywordDetectConfig, lim Limits) (*Detector, error) {
+	lim = lim.withDefaults()
+	patterns, err := pii.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d := &Detector{patterns: patterns}
+	if cfg == nil {
+		return d, nil
+	}
+	for _, rc := range cfg.Rules {
+		if rc.RuleType != model.KeywordRuleTypeRegex {
+			continue
+		}
+		re, err := CompileRegex(rc.RuleContent, lim)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rc.RuleID, err)
+		}
+		r := &regexRule{
+			cfg:        rc,
+			re:         re,
+			minCount:   max(rc.MinMatchCount, 1),
+			maxMatches: lim.MaxMatches,
+			level:      model.SecretLevel(rc.SensitivityLevel),
+		}
+		if rc.MaxMatchCount > 0 {
+			r.maxMatches = min(rc.MaxMatchCount, lim.MaxMatches)
+		}
+		if r.maxMatches < r.minCount {
+			return nil, fmt.Errorf("rule %d: %w: max_match_count %d is below min_match_count %d", rc.RuleID, ErrInvalidRule, r.maxMatches, r.minCount)
+		}
+		if r.level == model.LevelUnknown {
+			r.level = model.LevelInternal
+		}
+		d.regexes = append(d.regexes, r)
+	}
+	return d, nil
+}
+
+// Len 规则数量
+func (d *Detector) Len() int {
+	return d.patterns.Len() + len(d.regexes)
+}
+
+// DetectFile 检测文本文件，只读取前 document.MaxTextSize 字节；非文本格式由 DetectDocument 使用共享抽取的文本检测
+func (d *Detector) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	return document.DetectTextFile(ctx, d, filePath)
+}
+
+// DetectDocument 使用检测流水线共享抽取的文本，抽取器不支持的格式按纯文本处理
+func (d *Detector) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	return document.DetectTextDocument(ctx, d, doc)
+}
+
+// DetectText 先匹配内置模式规则，未命中时按顺序匹配正则规则，返回首条命中的正则规则
+// 正则规则的结果附带前 document.MaxLocations 处命中的位置与上下文
+func (d *Detector) DetectText(ctx context.Context, text string) (*model.SubDetectResult, error) {
+	text = document.TruncateText(text)
+	res, err := d.patterns.DetectText(ctx, text)
+	if err != nil || res.IsSecret {
+		return res, err
+	}
+
+	for _, r := range d.regexes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		locs := r.re.FindAllStringIndex(text, r.maxMatches)
+		if len(locs) < r.minCount {
+			continue
+		}
+		start, end := locs[0][0], locs[0][1]
+		desc := r.cfg.RuleDesc
+		if desc == "" {
+			desc = "正则规则匹配"
+		}
+		count := fmt.Sprintf("%d", len(locs))
+		if len(locs) == r.maxMatches {
+			count += "+"
+		}
+		locator := document.NewLocator(text)
+		locations := make([]model.MatchLocation, 0, min(len(locs), document.MaxLocations))
+		for _, loc := range locs[:min(len(locs), document.MaxLocations)] {
+			locations = append(locations, locator.Locate(loc[0], loc[1], document.MaxContextChars))
+		}
+		return &model.SubDetectResult{
+			IsSecret:    true,
+			SecretLevel: r.level,
+			RuleID:      r.cfg.RuleID,
+			RuleDesc:    desc,
+			MatchedText: truncate(text[start:end], matchLen),
+			ContextText: fmt.Sprintf("命中 %s 处: %s", count, excerpt(text, start, end)),
+			AlertType:   int(model.AlertTypeOther),
+			Locations:   locations,
+		}, nil
+	}
+	return &model.SubDetectResult{}, nil
+}
+
+// excerpt 命中位置前后各 contextLen 个字符
+func excerpt(text string, start, end int) string {
+	before := []rune(strings.ToValidUTF8(text[max(start-contextLen*4, 0):start], ""))
+	after := []rune(strings.ToValidUTF8(text[end:min(end+contextLen*4, len(text))], ""))
+	s := string(before[max(len(before)-contextLen, 0):]) + text[start:end] + string(after[:min(len(after), contextLen)])
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate 截断到 n 个字符
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "..."
+	}
+	return s
+}
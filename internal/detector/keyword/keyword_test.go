@@ -0,0 +1,120 @@
+package keyword
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestCompileRegex_Limits(t *testing.T) {
+	if _, err := CompileRegex(`项目编号[:：]\s*[A-Z]{2}-\d{6}`, Limits{}); err != nil {
+		t.Fatalf("CompileRegex(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		lim  Limits
+	}{
+		{"syntax", `(unclosed`, Limits{}},
+		{"backreference", `(a)\1`, Limits{}},
+		{"too long", strings.Repeat("a", 100), Limits{MaxLength: 50}},
+		{"nested repeat", `(?:\w{100}){20}`, Limits{}},
+		{"program size", `\w{1000}`, Limits{MaxProgram: 500}},
+		{"empty match", `\d*`, Limits{}},
+	}
+	for _, tt := range tests {
+		if _, err := CompileRegex(tt.expr, tt.lim); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: CompileRegex(%q) = %v", tt.name, tt.expr, err)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		expr     string
+		severity Severity
+		contains string
+	}{
+		{`(a+)+b`, SeverityWarning, "nested unbounded"},
+		{`(\d|1x)*y`, SeverityWarning, "overlapping alternation"},
+		{`.*机密`, SeverityWarning, "leading"},
+		{strings.Repeat(`\w{1000}`, 5), SeverityError, "instructions"},
+		{`a**`, SeverityError, "invalid"},
+	}
+	for _, tt := range tests {
+		issues := Lint(tt.expr, Limits{})
+		found := false
+		for _, is := range issues {
+			if is.Severity == tt.severity && strings.Contains(is.Message, tt.contains) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Lint(%q) = %+v, want %s containing %q", tt.expr, issues, tt.severity, tt.contains)
+		}
+	}
+
+	for _, expr := range []string{`(a|b)+c`, `合同编号\d{8}`, `(?i)top\s+secret`} {
+		if issues := Lint(expr, Limits{}); len(issues) != 0 {
+			t.Errorf("Lint(%q) = %+v", expr, issues)
+		}
+	}
+}
+
+func TestDetector(t *testing.T) {
+	cfg := model.NewKeywordDetectConfig()
+	project := model.NewKeywordRegexRule(51, `项目编号[:：]\s*[A-Z]{2}-\d{6}`, 2)
+	project.RuleDesc = "项目编号"
+	project.SensitivityLevel = int(model.LevelSecret)
+	capped := model.NewKeywordRegexRule(52, `ZX-\d{4}`, 3)
+	capped.MaxMatchCount = 3
+	cfg.Rules = append(cfg.Rules, *project, *capped, *model.NewKeywordPatternRule(53, "phone", 1))
+	d, err := New(cfg, Limits{})
+	if err != nil || d.Len() != 3 {
+		t.Fatalf("New() = %v, %v", d, err)
+	}
+	ctx := context.Background()
+
+	// 模式规则优先
+	if res, _ := d.DetectText(ctx, "联系人 13800138000 项目编号：AB-123456 项目编号:CD-654321"); !res.IsSecret || res.RuleID != 53 {
+		t.Errorf("pattern first = %+v", res)
+	}
+
+	res, err := d.DetectText(ctx, "附件一\n项目编号：AB-123456\n附件二\n项目编号:CD-654321")
+	if err != nil || !res.IsSecret || res.RuleID != 51 || res.SecretLevel != model.LevelSecret {
+		t.Fatalf("regex = %+v, %v", res, err)
+	}
+	if res.MatchedText != "项目编号：AB-123456" || !strings.HasPrefix(res.ContextText, "命中 2 处: 附件一 项目编号：AB-123456 附件二") {
+		t.Errorf("regex match = %q / %q", res.MatchedText, res.ContextText)
+	}
+	if locs := res.Locations; len(locs) != 2 || locs[1].Paragraph != 4 || locs[1].Text != "项目编号:CD-654321" || locs[1].Before != "附件一\n项目编号：AB-123456\n附件二\n" {
+		t.Errorf("regex locations = %+v", res.Locations)
+	}
+	if res, _ := d.DetectText(ctx, "项目编号：AB-123456"); res.IsSecret {
+		t.Errorf("below min count = %+v", res)
+	}
+
+	// 达到匹配次数上限后停止计数
+	res, _ = d.DetectText(ctx, strings.Repeat("ZX-1234 ", 100))
+	if !res.IsSecret || res.RuleID != 52 || !strings.HasPrefix(res.ContextText, "命中 3+ 处") || res.SecretLevel != model.LevelInternal {
+		t.Errorf("capped = %+v", res)
+	}
+
+	for _, rc := range []*model.KeywordDetectRule{
+		model.NewKeywordRegexRule(1, `(a`, 1),
+		func() *model.KeywordDetectRule {
+			r := model.NewKeywordRegexRule(2, `abc`, 10)
+			r.MaxMatchCount = 5
+			return r
+		}(),
+	} {
+		bad := &model.KeywordDetectConfig{Rules: []model.KeywordDetectRule{*rc}}
+		if _, err := New(bad, Limits{}); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("New(rule %d) = %v", rc.RuleID, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Package keyword 关键词检测
+// 汇总关键词策略中除关键词表达式以外的规则类型：内置个人敏感信息模式 (internal/detector/pii) 与正则表达式。
+// 正则使用 Go regexp (RE2 语法，匹配耗时与文本长度线性相关)，编译前检查表达式长度与编译后的程序规模，
+// 每条规则的匹配次数有上限，避免下发的规则拖慢检测
+package keyword
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+)
+
+// 默认限制
+const (
+	DefaultMaxLength  = 1024
+	DefaultMaxProgram = 4096
+	DefaultMaxMatches = 10000
+)
+
+// ErrInvalidRule 规则无效或超出限制
+var ErrInvalidRule = errors.New("keyword: invalid rule")
+
+// Limits 正则规则的安全限制
+type Limits struct {
+	// 表达式长度上限 (字节)，<=0 使用 DefaultMaxLength
+	MaxLength int
+	// 编译后的程序指令数上限，计数重复 (如 {1000}) 会按次数展开，<=0 使用 DefaultMaxProgram
+	MaxProgram int
+	// 单条规则在一个文件中的匹配次数上限，规则未填写 max_match_count 时使用，<=0 使用 DefaultMaxMatches
+	MaxMatches int
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxLength <= 0 {
+		l.MaxLength = DefaultMaxLength
+	}
+	if l.MaxProgram <= 0 {
+		l.MaxProgram = DefaultMaxProgram
+	}
+	if l.MaxMatches <= 0 {
+		l.MaxMatches = DefaultMaxMatches
+	}
+	return l
+}
+
+// CompileRegex 在限制内编译正则规则，超出限制或可匹配空串时返回错误
+func CompileRegex(expr string, lim Limits) (*regexp.Regexp, error) {
+	lim = lim.withDefaults()
+	if len(expr) > lim.MaxLength {
+		return nil, fmt.Errorf("%w: expression is %d bytes, limit %d", ErrInvalidRule, len(expr), lim.MaxLength)
+	}
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if len(prog.Inst) > lim.MaxProgram {
+		return nil, fmt.Errorf("%w: program has %d instructions, limit %d", ErrInvalidRule, len(prog.Inst), lim.MaxProgram)
+	}
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	if compiled.MatchString("") {
+		return nil, fmt.Errorf("%w: expression matches the empty string", ErrInvalidRule)
+	}
+	return compiled, nil
+}
+
+// Severity 检查结果级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 规则无法加载
+	SeverityWarning Severity = "warning" // 可以加载，但可能误报或拖慢检测
+)
+
+// Issue 规则检查发现的问题
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Lint 检查正则规则，用于规则下发前的审核
+// 除 CompileRegex 的错误外，还标出在回溯型引擎 (PCRE、Java 等) 中会灾难性回溯的写法：
+// 嵌套的无界重复 (如 (a+)+) 与无界重复下可重叠的分支 (如 (a|ab)*)；RE2 不受影响，但这类规则通常也是误写
+func Lint(expr string, lim Limits) []Issue {
+	var issues []Issue
+	if _, err := CompileRegex(expr, lim); err != nil {
+		issues = append(issues, Issue{Severity: SeverityError, Message: err.Error()})
+	}
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return issues
+	}
+	warn := func(format string, args ...any) {
+		issues = append(issues, Issue{Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if nested := nestedRepeat(re, false); nested != nil {
+		warn("nested unbounded repetition %q may backtrack catastrophically in other engines", nested)
+	}
+	if alt := overlappingAlternate(re, false); alt != nil {
+		warn("overlapping alternation %q under unbounded repetition may backtrack catastrophically in other engines", alt)
+	}
+	if leadingWildcard(re) {
+		warn("leading .* or .+ is redundant for unanchored matching and slows the search")
+	}
+	return issues
+}
+
+// unbounded 是否为无界重复
+func unbounded(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1
+	}
+	return false
+}
+
+// nestedRepeat 返回嵌套在无界重复内的无界重复
+func nestedRepeat(re *syntax.Regexp, inside bool) *syntax.Regexp {
+	if unbounded(re) {
+		if inside {
+			return re
+		}
+		inside = true
+	}
+	for _, sub := range re.Sub {
+		if r := nestedRepeat(sub, inside); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// overlappingAlternate 返回无界重复内首字符可能相同的分支
+func overlappingAlternate(re *syntax.Regexp, inside bool) *syntax.Regexp {
+	if unbounded(re) {
+		inside = true
+	}
+	if inside && re.Op == syntax.OpAlternate {
+		for i := range re.Sub {
+			for j := i + 1; j < len(re.Sub); j++ {
+				if firstOverlap(re.Sub[i], re.Sub[j]) {
+					return re
+				}
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		if r := overlappingAlternate(sub, inside); r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+// firstOverlap 两个分支的首字符集合是否相交，无法判断时按相交处理
+func firstOverlap(a, b *syntax.Regexp) bool {
+	ra, oka := firstRunes(a)
+	rb, okb := firstRunes(b)
+	if !oka || !okb {
+		return true
+	}
+	for i := 0; i+1 < len(ra); i += 2 {
+		for j := 0; j+1 < len(rb); j += 2 {
+			if ra[i] <= rb[j+1] && rb[j] <= ra[i+1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstRunes 分支首字符的区间集合 (与 syntax.Regexp.Rune 的 CharClass 格式相同)
+func firstRunes(re *syntax.Regexp) ([]rune, bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 || re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []rune{re.Rune[0], re.Rune[0]}, true
+	case syntax.OpCharClass:
+		return re.Rune, true
+	case syntax.OpConcat, syntax.OpCapture:
+		if len(re.Sub) > 0 {
+			return firstRunes(re.Sub[0])
+		}
+	case syntax.OpPlus:
+		return firstRunes(re.Sub[0])
+	}
+	return nil, false
+}
+
+// leadingWildcard 表达式是否以 .* 或 .+ 开头
+func leadingWildcard(re *syntax.Regexp) bool {
+	for re.Op == syntax.OpConcat || re.Op == syntax.OpCapture {
+		if len(re.Sub) == 0 {
+			return false
+		}
+		re = re.Sub[0]
+	}
+	if re.Op != syntax.OpStar && re.Op != syntax.OpPlus {
+		return false
+	}
+	op := re.Sub[0].Op
+	return op == syntax.OpAnyChar || op == syntax.OpAnyCharNotNL
+}
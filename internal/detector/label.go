@@ -0,0 +1,39 @@
+package detector
+
+import (
+	"context"
+	"errors"
+
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/xattrlabel"
+)
+
+// labelDetector 读取文件扩展属性中外部标注的密级分类标签 (security.dlp.*)
+// 本程序处置时写回的标签不作为信号：规则调整后文件应按内容重新判定，不能由旧的判定结果自我延续
+type labelDetector struct{}
+
+func (labelDetector) DetectFile(_ context.Context, path string) (*model.SubDetectResult, error) {
+	l, err := xattrlabel.Read(path)
+	if errors.Is(err, xattrlabel.ErrInvalidLevel) {
+		// 无法识别的标注不是检测器故障，按无标签处理
+		return nil, nil
+	}
+	if err != nil || l == nil || l.Source == xattrlabel.SourceAgent {
+		return nil, err
+	}
+
+	name := xattrlabel.LevelName(l.Level)
+	res := &model.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: l.Level,
+		RuleID:      l.RuleID,
+		RuleDesc:    i18n.T("detect.xattr_label.hit", name),
+		MatchedText: name,
+		AlertType:   2, // 与密级标志告警一致
+	}
+	if l.Source != "" {
+		res.ContextText = i18n.T("detect.xattr_label.source", l.Source)
+	}
+	return res, nil
+}
@@ -0,0 +1,46 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/xattrlabel"
+)
+
+func TestManager_DetectXattrLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   xattrlabel.Label
+		wantHit bool
+	}{
+		{"外部标注", xattrlabel.Label{Level: model.LevelSecret, RuleID: 7, Source: "dms"}, true},
+		{"本程序写回的判定", xattrlabel.Label{Level: model.LevelSecret, Source: xattrlabel.SourceAgent}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "报告.txt")
+			os.WriteFile(path, []byte("普通内容"), 0644)
+			if err := xattrlabel.Write(path, tt.label); err != nil {
+				t.Skip("无法写入扩展属性:", err)
+			}
+
+			m := &Manager{config: GlobalConfig{EnableXattrLabel: true}, routes: DefaultRoutes()}
+			hit, record, _, err := m.Detect(context.Background(), path)
+			if err != nil || hit != tt.wantHit {
+				t.Fatalf("Detect() hit = %v, err = %v", hit, err)
+			}
+			if hit && (record.DetectModule != model.ModuleXattrLabelDetect || record.FileLevel != int(model.LevelSecret) || record.RuleID != 7) {
+				t.Errorf("告警 = %s %d %d", record.DetectModule, record.FileLevel, record.RuleID)
+			}
+
+			// 内存内容没有扩展属性
+			if hit, _, _, _ := m.DetectBytes(context.Background(), path, []byte("普通内容")); hit {
+				t.Error("DetectBytes() 命中")
+			}
+		})
+	}
+}
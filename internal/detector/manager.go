@@ -7,11 +7,24 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strconv"
+	"sync"
 	"time"
 
 	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/budget"
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/detector/edm"
+	"linuxFileWatcher/internal/detector/fingerprint"
+	"linuxFileWatcher/internal/detector/keyword"
 	"linuxFileWatcher/internal/detector/govcheck"
+	"linuxFileWatcher/internal/detector/plugin"
 	"linuxFileWatcher/internal/detector/secret_level"
+	"linuxFileWatcher/internal/detector/wasmrule"
+	"linuxFileWatcher/internal/fastread"
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/tracing"
 )
 
 // SubDetector 定义所有子检测模块必须实现的通用接口
@@ -26,13 +39,25 @@ type GlobalConfig struct {
 	EnableLayout          bool
 	EnableHash            bool
 	EnableKeywords        bool
+	EnableWasmRules       bool
+	EnableFingerprint     bool
+	EnableEDM             bool
+	// 读取文件扩展属性中外部标注的分类标签 (security.dlp.*)
+	EnableXattrLabel bool
 
 	SecretMarkerOCR bool
+	// 密级标志兜底扫描的大文件采样参数，nil 使用默认参数
+	MarkerSampling *model.SamplingParams
 
 	// 公文版式检测配置
 	LayoutThreshold float64
 	LayoutEnableOCR bool
 
+	// 告警附带的命中位置数上限，<=0 时不附带位置
+	MaxMatchLocations int
+	// 命中位置前后附带的上下文字符数
+	MatchContextChars int
+
 	// 基础信息
 	CurrentCompany      string
 	CurrentComputerName string
@@ -44,6 +69,7 @@ type GlobalConfig struct {
 
 // Manager 涉密信息检测模块管理器
 type Manager struct {
+	mu     sync.RWMutex // 保护 config 与子检测器，支持运行时更新规则
 	config GlobalConfig
 
 	secretMarkerDetector    secret_level.Detector
@@ -51,17 +77,37 @@ type Manager struct {
 	layoutDetector          govcheck.Detector // 公文版式检测器
 	hashDetector            SubDetector
 	keywordsDetector        SubDetector
+
+	// WASM 脚本规则，nil 表示未下发
+	wasmRules *wasmrule.Engine
+
+	// 内容指纹规则，nil 表示未下发
+	fingerprints *fingerprint.Detector
+
+	// 精确数据匹配数据表，nil 表示未下发
+	edmTables *edm.Detector
+
+	// 外部检测插件，按配置顺序在内置检测器之后调用
+	plugins []*plugin.Plugin
+
+	// 文件分类到检测模块的路由
+	routes Routes
+
+	// 子检测器 panic 隔离与按格式熔断
+	breakers breakers
 }
 
 // NewManager 初始化管理器
 func NewManager(cfg GlobalConfig) *Manager {
 	mgr := &Manager{
 		config: cfg,
+		routes: DefaultRoutes(),
 	}
 
 	// 1. 初始化密级标志检测器
 	markerCfg := secret_level.Config{
 		EnableOCR: cfg.SecretMarkerOCR,
+		Sampling:  cfg.MarkerSampling,
 	}
 	mgr.secretMarkerDetector = secret_level.NewDetector(markerCfg)
 
@@ -82,28 +128,282 @@ func NewManager(cfg GlobalConfig) *Manager {
 }
 
 // UpdateConfig 更新配置
+// 公文版式检测阈值或 OCR 开关变化时重建对应检测器，正在进行的检测不受影响
 func (m *Manager) UpdateConfig(newCfg GlobalConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if newCfg.SecretMarkerOCR != m.config.SecretMarkerOCR || !reflect.DeepEqual(newCfg.MarkerSampling, m.config.MarkerSampling) {
+		m.secretMarkerDetector = secret_level.NewDetector(secret_level.Config{
+			EnableOCR: newCfg.SecretMarkerOCR,
+			Sampling:  newCfg.MarkerSampling,
+		})
+	}
+	if newCfg.LayoutThreshold != m.config.LayoutThreshold || newCfg.LayoutEnableOCR != m.config.LayoutEnableOCR {
+		layoutCfg := govcheck.DefaultConfig()
+		if newCfg.LayoutThreshold > 0 {
+			layoutCfg.Threshold = newCfg.LayoutThreshold
+		}
+		layoutCfg.EnableOCR = newCfg.LayoutEnableOCR
+		m.layoutDetector = govcheck.NewDetector(layoutCfg)
+	}
 	m.config = newCfg
+}
+
+// SetPlugins 替换外部检测插件 (配置加载与重载时调用)
+// 配置未变化的插件沿用原进程，被移除或配置变化的插件进程在替换后退出
+func (m *Manager) SetPlugins(cfgs []plugin.Config) error {
+	m.mu.RLock()
+	old := m.plugins
+	m.mu.RUnlock()
+
+	reuse := make(map[string]*plugin.Plugin, len(old))
+	for _, p := range old {
+		reuse[p.Name()] = p
+	}
 
-	// 更新公文版式检测器配置（如果需要热更新）
-	// 注意：当前实现需要重新创建检测器才能更新配置
+	list := make([]*plugin.Plugin, 0, len(cfgs))
+	seen := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		if seen[cfg.Name] {
+			return fmt.Errorf("检测插件名称重复: %s", cfg.Name)
+		}
+		seen[cfg.Name] = true
+
+		if p, ok := reuse[cfg.Name]; ok && reflect.DeepEqual(p.Config(), cfg) {
+			list = append(list, p)
+			continue
+		}
+		p, err := plugin.New(cfg)
+		if err != nil {
+			return err
+		}
+		list = append(list, p)
+	}
+
+	m.mu.Lock()
+	m.plugins = list
+	m.mu.Unlock()
+
+	kept := make(map[*plugin.Plugin]bool, len(list))
+	for _, p := range list {
+		kept[p] = true
+	}
+	for _, p := range old {
+		if !kept[p] {
+			p.Close()
+		}
+	}
+	return nil
 }
 
+// SetWasmRules 替换 WASM 脚本规则 (策略加载与重载时调用)
+// 规则全部编译成功后才替换，任一规则无效时沿用原规则；rules 为 nil 或为空时清除
+func (m *Manager) SetWasmRules(rules *model.WasmRuleDetectConfig, limits wasmrule.Limits) error {
+	var engine *wasmrule.Engine
+	if rules != nil && len(rules.Rules) > 0 {
+		var err error
+		if engine, err = wasmrule.New(context.Background(), rules, limits); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	old := m.wasmRules
+	m.wasmRules = engine
+	m.mu.Unlock()
+
+	// 进行中的检测仍在使用旧规则，结束后再释放，不阻塞策略重载
+	if old != nil {
+		go old.Close(context.Background())
+	}
+	return nil
+}
+
+// SetFingerprintRules 替换内容指纹规则 (策略加载与重载时调用)
+// 规则全部解析成功后才替换，任一规则无效时沿用原规则；rules 为 nil 或为空时清除
+func (m *Manager) SetFingerprintRules(rules *model.FingerprintDetectConfig, th fingerprint.Thresholds) error {
+	var d *fingerprint.Detector
+	if rules != nil && len(rules.Rules) > 0 {
+		var err error
+		if d, err = fingerprint.New(rules, th); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.fingerprints = d
+	m.mu.Unlock()
+	return nil
+}
+
+// SetKeywordRules 替换关键词检测的内置模式规则与正则规则 (策略加载与重载时调用)
+// 关键词表达式规则不在此处理；全部解析成功后才替换，没有可用规则时清除
+func (m *Manager) SetKeywordRules(rules *model.KeywordDetectConfig, lim keyword.Limits) error {
+	d, err := keyword.New(rules, lim)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if d.Len() > 0 {
+		m.keywordsDetector = d
+	} else {
+		m.keywordsDetector = nil
+	}
+	m.mu.Unlock()
+	return nil
+}
+
+// SetEDMRules 替换精确数据匹配数据表 (策略加载与重载时调用)
+// 数据表全部解析成功后才替换，任一数据表无效时沿用原数据表；rules 为 nil 或为空时清除
+func (m *Manager) SetEDMRules(rules *model.EDMDetectConfig, th edm.Thresholds) error {
+	var d *edm.Detector
+	if rules != nil && len(rules.Rules) > 0 {
+		var err error
+		if d, err = edm.New(rules, th); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	m.edmTables = d
+	m.mu.Unlock()
+	return nil
+}
+
+// Close 停止外部检测插件进程并释放 WASM 规则
+func (m *Manager) Close() {
+	m.mu.Lock()
+	plugins := m.plugins
+	wasmRules := m.wasmRules
+	m.plugins = nil
+	m.wasmRules = nil
+	m.mu.Unlock()
+
+	for _, p := range plugins {
+		p.Close()
+	}
+	if wasmRules != nil {
+		wasmRules.Close(context.Background())
+	}
+}
+
+// Config 返回当前配置
+func (m *Manager) Config() GlobalConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.config
+}
+
+// maxSharedSize 整体读入内存构建共享中间表示的文件大小上限
+// 更大的文件由各子检测器按路径各自读取
+const maxSharedSize = 32 * 1024 * 1024
+
 // Detect 主检测入口
 func (m *Manager) Detect(ctx context.Context, filePath string) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	ctx, tl := tracing.Start(ctx, filePath)
+	found, record, logItem, err := m.detectPath(ctx, filePath)
+	tl.Finish(found, err)
+	return found, record, logItem, err
+}
+
+// detectPath 检测磁盘文件
+func (m *Manager) detectPath(ctx context.Context, filePath string) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
 	// 0. 预处理：获取文件通用信息
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return false, nil, nil, err
 	}
 
+	// 设备、管道等非普通文件没有可检测的内容，读取可能阻塞或遍历整个设备
+	if _, special := filetype.FromMode(fileInfo.Mode()); special {
+		return false, nil, nil, nil
+	}
+
+	tracing.FromContext(ctx).Annotate("file.size", strconv.FormatInt(fileInfo.Size(), 10))
+
+	// 按全局并发与内存预算排队，与其他扫描、压缩包展开、OCR 任务合计不超出容器配额
+	endQueue := tracing.Begin(ctx, "queue")
+	ctx, release, err := budget.Default().Acquire(ctx, budget.ClassScan, min(fileInfo.Size(), maxSharedSize))
+	endQueue(err)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	defer release()
+
+	// 文件只读取一次，类型识别与文本抽取结果由各子检测器共用
+	if fileInfo.Size() <= maxSharedSize {
+		endRead := tracing.Begin(ctx, "read")
+		data, err := fastread.ReadFile(filePath)
+		endRead(err)
+		if err == nil {
+			endIdentify := tracing.Begin(ctx, "identify")
+			doc := document.OpenData(filePath, data, m.textExtractor())
+			endIdentify(nil)
+			return m.detectDocument(ctx, doc)
+		}
+	}
+
+	endIdentify := tracing.Begin(ctx, "identify")
 	fileMD5, err := calculateMD5(filePath)
 	if err != nil {
 		fileMD5 = ""
 	}
+	fileType, _ := filetype.DetectFileType(filePath)
+	endIdentify(nil)
+
+	return m.detect(ctx, &content{
+		path: filePath,
+		name: fileInfo.Name(),
+		size: fileInfo.Size(),
+		md5:  fileMD5,
+		typ:  fileType,
+	})
+}
+
+// detectDocument 检测已整体读入内存的磁盘文件
+func (m *Manager) detectDocument(ctx context.Context, doc *document.Document) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	defer doc.Close()
+	return m.detect(ctx, &content{
+		path: doc.Path,
+		name: doc.Name,
+		size: doc.Size,
+		md5:  doc.MD5,
+		typ:  doc.Type,
+		doc:  doc,
+	})
+}
+
+// textExtractor 共享文本抽取器，由公文版式检测器提供 (其处理器同时输出文本与版式特征)
+func (m *Manager) textExtractor() document.Extractor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if ex, ok := m.layoutDetector.(document.Extractor); ok {
+		return ex
+	}
+	return nil
+}
+
+// detect 依次调用各子检测器，首个命中即返回
+func (m *Manager) detect(ctx context.Context, c *content) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	// 取当前配置与检测器快照，检测期间规则更新不影响本次结果
+	m.mu.RLock()
+	cfg := m.config
+	secretMarkerDetector, layoutDetector := m.secretMarkerDetector, m.layoutDetector
+	wasmRules, plugins, routes := m.wasmRules, m.plugins, m.routes
+	keywordsDetector := m.keywordsDetector
+	fingerprints, edmTables := m.fingerprints, m.edmTables
+	// WASM 规则在替换后才释放运行时，登记本次检测以免被替换的规则在使用中关闭
+	if wasmRules != nil {
+		wasmRules.Acquire()
+		defer wasmRules.Release()
+	}
+	m.mu.RUnlock()
 
 	// 构造结果处理闭包
-	handleResult := func(res *model.SubDetectResult) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	// module 为命中的检测模块，写入告警供处置策略按模块匹配
+	handleResult := func(module string, res *model.SubDetectResult) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
 		if res == nil || !res.IsSecret {
 			return false, nil, nil, nil
 		}
@@ -116,77 +416,158 @@ func (m *Manager) Detect(ctx context.Context, filePath string) (bool, *model.Ale
 			FilterType:    1,
 			FileSummary:   "",
 			AlertType:     model.AlertType(res.AlertType),
-			FileMD5:       fileMD5,
-			FilePath:      filePath,
-			FileName:      fileInfo.Name(),
-			FileSize:      int(fileInfo.Size()),
+			FileMD5:       c.md5,
+			FilePath:      c.path,
+			FileName:      c.name,
+			FileSize:      int(c.size),
 			HighlightText: res.MatchedText,
 			FileDesc:      res.ContextText,
-			Company:       m.config.CurrentCompany,
-			ComputerName:  m.config.CurrentComputerName,
-			OrgID:         m.config.CurrentOrgID,
-			OrgPath:       m.config.CurrentOrgPath,
-			UserName:      m.config.CurrentUserName,
-			UserID:        m.config.CurrentUserID,
+			Company:       cfg.CurrentCompany,
+			ComputerName:  cfg.CurrentComputerName,
+			OrgID:         cfg.CurrentOrgID,
+			OrgPath:       cfg.CurrentOrgPath,
+			UserName:      cfg.CurrentUserName,
+			UserID:        cfg.CurrentUserID,
 			FileLevel:     int(res.SecretLevel),
+			DetectModule:  module,
+		}
+		record.SetProcess(ProcessFromContext(ctx))
+		if locs := matchLocations(res.Locations, cfg); len(locs) > 0 {
+			record.AddExtendFields(map[string]interface{}{FieldMatchLocations: locs})
 		}
 
 		logItem := &model.AlertLogItem{
-			FileName: fileInfo.Name(),
-			FilePath: filePath,
-			FileMD5:  fileMD5,
+			FileName: c.name,
+			FilePath: c.path,
+			FileMD5:  c.md5,
 			Time:     record.Time,
 		}
 
 		return true, record, logItem, nil
 	}
 
+	// 按文件分类路由：只执行该分类配置的检测模块，压缩包先展开逐项检测
+	run := func(module string) bool {
+		return routes.allows(c.typ.Category, module)
+	}
+	if !c.nested {
+		tracing.FromContext(ctx).Annotate("file.type", c.typ.Extension)
+	}
+	// 0. 外部标注的分类标签，只读取文件扩展属性，不受文件分类路由限制
+	if cfg.EnableXattrLabel && c.onDisk() {
+		res, err := m.runGuarded(ctx, c, model.ModuleXattrLabelDetect, labelDetector{})
+		if err == nil && res != nil && res.IsSecret {
+			return handleResult(model.ModuleXattrLabelDetect, res)
+		}
+	}
+
+	if !c.nested && routes.expands(c.typ.Category) {
+		found, record, logItem, err := m.detectArchive(ctx, c)
+		if err == nil && found {
+			return found, record, logItem, nil
+		}
+		c.noteBomb(err)
+	}
+
 	// 1. 电子密级检测
-	if m.config.EnableElectronicLabel && m.electronicLabelDetector != nil {
-		res, err := m.electronicLabelDetector.DetectFile(ctx, filePath)
+	if cfg.EnableElectronicLabel && m.electronicLabelDetector != nil && run(model.ModuleElectronicSecretDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleElectronicSecretDetect, m.electronicLabelDetector)
 		if err == nil && res != nil && res.IsSecret {
-			return handleResult(res)
+			return handleResult(model.ModuleElectronicSecretDetect, res)
 		}
 	}
 
 	// 2. 密级标志检测
-	if m.config.EnableSecretMarker && m.secretMarkerDetector != nil {
-		res, err := m.secretMarkerDetector.DetectFile(ctx, filePath)
+	if cfg.EnableSecretMarker && secretMarkerDetector != nil && run(model.ModuleSecretLevelDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleSecretLevelDetect, secretMarkerDetector)
 		if err == nil && res != nil && res.IsSecret {
-			return handleResult(res)
+			return handleResult(model.ModuleSecretLevelDetect, res)
 		}
 	}
 
 	// 3. 公文版式检测
-	if m.config.EnableLayout && m.layoutDetector != nil {
-		res, err := m.layoutDetector.DetectFile(ctx, filePath)
+	if cfg.EnableLayout && layoutDetector != nil && run(model.ModuleOfficialFormatDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleOfficialFormatDetect, layoutDetector)
 		if err == nil && res != nil && res.IsSecret {
-			return handleResult(res)
+			return handleResult(model.ModuleOfficialFormatDetect, res)
 		}
 	}
 
 	// 4. 哈希检测
-	if m.config.EnableHash && m.hashDetector != nil {
-		res, err := m.hashDetector.DetectFile(ctx, filePath)
+	if cfg.EnableHash && m.hashDetector != nil && run(model.ModuleMD5Detect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleMD5Detect, m.hashDetector)
+		if err == nil && res != nil && res.IsSecret {
+			return handleResult(model.ModuleMD5Detect, res)
+		}
+	}
+
+	// 5. 内容指纹检测 (派生文档)
+	if cfg.EnableFingerprint && fingerprints != nil && run(model.ModuleFingerprintDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleFingerprintDetect, fingerprints)
+		if err == nil && res != nil && res.IsSecret {
+			return handleResult(model.ModuleFingerprintDetect, res)
+		}
+	}
+
+	// 6. 精确数据匹配 (结构化敏感数据)
+	if cfg.EnableEDM && edmTables != nil && run(model.ModuleEDMDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleEDMDetect, edmTables)
+		if err == nil && res != nil && res.IsSecret {
+			return handleResult(model.ModuleEDMDetect, res)
+		}
+	}
+
+	// 7. 关键词检测
+	if cfg.EnableKeywords && keywordsDetector != nil && run(model.ModuleKeywordDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleKeywordDetect, keywordsDetector)
 		if err == nil && res != nil && res.IsSecret {
-			return handleResult(res)
+			return handleResult(model.ModuleKeywordDetect, res)
 		}
 	}
 
-	// 5. 关键词检测
-	if m.config.EnableKeywords && m.keywordsDetector != nil {
-		res, err := m.keywordsDetector.DetectFile(ctx, filePath)
+	// 8. WASM 脚本规则
+	if cfg.EnableWasmRules && wasmRules != nil && run(model.ModuleWasmRuleDetect) {
+		res, err := m.runGuarded(ctx, c, model.ModuleWasmRuleDetect, wasmRules)
 		if err == nil && res != nil && res.IsSecret {
-			return handleResult(res)
+			return handleResult(model.ModuleWasmRuleDetect, res)
 		}
 	}
 
-	return false, nil, nil, nil
+	// 9. 外部检测插件，检测模块为插件名称
+	for _, p := range plugins {
+		if !run(p.Name()) {
+			continue
+		}
+		res, err := m.runGuarded(ctx, c, p.Name(), p)
+		if err == nil && res != nil && res.IsSecret {
+			return handleResult(p.Name(), res)
+		}
+	}
+
+	// 10. 文档本身未命中时检测其中的嵌入对象 (封面文档嵌入涉密附件)
+	if embedsObjects(c) {
+		found, record, logItem, err := m.detectEmbedded(ctx, c)
+		if err == nil && found {
+			return found, record, logItem, nil
+		}
+		c.noteBomb(err)
+	}
+
+	// 未命中且触发了压缩炸弹保护：超出配额的内容未检测，可能借此夹带涉密文件，按疑似压缩炸弹告警
+	// 包内文件返回错误，由外层压缩包统一告警
+	if c.bomb != nil && !c.nested {
+		return handleResult(model.ModuleArchiveBomb, &model.SubDetectResult{
+			IsSecret:    true,
+			RuleDesc:    "疑似压缩炸弹，超出展开配额的内容未检测",
+			ContextText: c.bomb.Error(),
+		})
+	}
+	return false, nil, nil, c.bomb
 }
 
 // calculateMD5 计算文件 MD5
 func calculateMD5(path string) (string, error) {
-	f, err := os.Open(path)
+	f, err := fastread.Open(path)
 	if err != nil {
 		return "", err
 	}
@@ -199,6 +580,22 @@ func calculateMD5(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
+// FieldMatchLocations 告警扩展字段中的命中位置
+const FieldMatchLocations = "match_locations"
+
+// matchLocations 按配置截取子检测器返回的命中位置与上下文
+func matchLocations(locs []model.MatchLocation, cfg GlobalConfig) []model.MatchLocation {
+	if len(locs) == 0 || cfg.MaxMatchLocations <= 0 {
+		return nil
+	}
+	out := make([]model.MatchLocation, 0, min(len(locs), cfg.MaxMatchLocations))
+	for _, loc := range locs[:min(len(locs), cfg.MaxMatchLocations)] {
+		document.TrimContext(&loc, cfg.MatchContextChars)
+		out = append(out, loc)
+	}
+	return out
+}
+
 // generateAlertID 生成告警 ID
 func generateAlertID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
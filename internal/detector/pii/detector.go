@@ -0,0 +1,152 @@
+package pii
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+)
+
+// ErrInvalidRule 模式规则内容无法解析
+var ErrInvalidRule = errors.New("pii: invalid rule")
+
+// rule 解析后的模式规则
+type rule struct {
+	cfg      model.KeywordDetectRule
+	patterns []string
+	minCount int
+	level    model.SecretLevel
+}
+
+// Detector 关键词检测的模式子类型，实现 detector.SubDetector
+type Detector struct {
+	rules []*rule
+}
+
+// New 解析关键词策略中的模式规则 (规则类型 model.KeywordRuleTypePattern)，其他类型的规则忽略
+// 规则内容为逗号分隔的模式名称，多个模式的个数合并计算；任一模式规则无效时返回错误
+func New(cfg *model.KeywordDetectConfig) (*Detector, error) {
+	d := &Detector{}
+	if cfg == nil {
+		return d, nil
+	}
+	for _, rc := range cfg.Rules {
+		if rc.RuleType != model.KeywordRuleTypePattern {
+			continue
+		}
+		r := &rule{cfg: rc, minCount: max(rc.MinMatchCount, 1), level: model.SecretLevel(rc.SensitivityLevel)}
+		if r.level == model.LevelUnknown {
+			r.level = model.LevelInternal
+		}
+		for _, name := range strings.Split(rc.RuleContent, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := Lookup(name); !ok {
+				return nil, fmt.Errorf("rule %d: %w: unknown pattern %q", rc.RuleID, ErrInvalidRule, name)
+			}
+			r.patterns = append(r.patterns, name)
+		}
+		d.rules = append(d.rules, r)
+	}
+	return d, nil
+}
+
+// Len 模式规则数量
+func (d *Detector) Len() int {
+	return len(d.rules)
+}
+
+// DetectFile 检测文本文件，只读取前 document.MaxTextSize 字节；非文本格式由 DetectDocument 使用共享抽取的文本检测
+func (d *Detector) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	return document.DetectTextFile(ctx, d, filePath)
+}
+
+// DetectDocument 使用检测流水线共享抽取的文本，抽取器不支持的格式按纯文本处理
+func (d *Detector) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	return document.DetectTextDocument(ctx, d, doc)
+}
+
+// DetectText 统计各模式的个数并按规则阈值判定，多条规则命中时取个数最多的一条
+func (d *Detector) DetectText(ctx context.Context, text string) (*model.SubDetectResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(d.rules) == 0 {
+		return &model.SubDetectResult{}, nil
+	}
+	text = document.TruncateText(text)
+
+	counts := Scan(text)
+	var (
+		best      *rule
+		bestCount int
+	)
+	for _, r := range d.rules {
+		n := 0
+		for _, name := range r.patterns {
+			n += counts[name].Count
+		}
+		if n >= r.minCount && n > bestCount {
+			best, bestCount = r, n
+		}
+	}
+	if best == nil {
+		return &model.SubDetectResult{}, nil
+	}
+
+	var (
+		matched, summary []string
+		spans            [][2]int
+	)
+	for _, name := range best.patterns {
+		m, ok := counts[name]
+		if !ok {
+			continue
+		}
+		p, _ := Lookup(name)
+		matched = append(matched, mask(m.Sample))
+		summary = append(summary, fmt.Sprintf("%s %d 个", p.Desc, m.Count))
+		spans = append(spans, m.Spans...)
+	}
+	desc := best.cfg.RuleDesc
+	if desc == "" {
+		desc = "个人敏感信息"
+	}
+	return &model.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: best.level,
+		RuleID:      best.cfg.RuleID,
+		RuleDesc:    desc,
+		MatchedText: strings.Join(matched, ", "),
+		ContextText: strings.Join(summary, ", "),
+		AlertType:   int(model.AlertTypeOther),
+		Locations:   locate(text, spans),
+	}, nil
+}
+
+// locate 命中位置，按偏移排序，最多 document.MaxLocations 处
+// 命中内容脱敏且不附带上下文，避免告警中出现完整的敏感数据
+func locate(text string, spans [][2]int) []model.MatchLocation {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	spans = spans[:min(len(spans), document.MaxLocations)]
+	locator := document.NewLocator(text)
+	out := make([]model.MatchLocation, 0, len(spans))
+	for _, s := range spans {
+		loc := locator.Locate(s[0], s[1], 0)
+		loc.Text = mask(loc.Text)
+		out = append(out, loc)
+	}
+	return out
+}
+
+// mask 脱敏展示，保留前 3 位与后 4 位
+func mask(s string) string {
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= 7 {
+		return string(r[:min(len(r), 1)]) + strings.Repeat("*", max(len(r)-1, 0))
+	}
+	return string(r[:3]) + strings.Repeat("*", len(r)-7) + string(r[len(r)-4:])
+}
@@ -0,0 +1,200 @@
+// Package pii 内置个人敏感信息模式库
+// 识别身份证号 (校验位)、银行卡号 (Luhn)、手机号、护照号与车牌号，按模式统计文本中出现的不同值的个数，
+// 作为关键词检测的模式子类型 (规则类型 model.KeywordRuleTypePattern) 使用，
+// 可配置为同一文件中出现足够多的个人信息时才告警
+package pii
+
+import (
+	"regexp"
+	"strings"
+)
+
+// 模式名称，即模式规则的规则内容
+const (
+	PatternIDCard       = "id_card"       // 居民身份证号
+	PatternBankCard     = "bank_card"     // 银行卡号
+	PatternPhone        = "phone"         // 手机号
+	PatternPassport     = "passport"      // 护照号
+	PatternLicensePlate = "license_plate" // 机动车号牌
+)
+
+// Pattern 内置模式
+type Pattern struct {
+	Name string // 模式名称
+	Desc string // 中文名称，用于告警展示
+	re   *regexp.Regexp
+	// normalize 去除分隔符等格式差异，返回空字符串表示校验未通过
+	normalize func(string) string
+}
+
+// provinces 车牌省份简称
+const provinces = "京津沪渝冀豫云辽黑湘皖鲁新苏浙赣鄂桂甘晋蒙陕吉闽贵粤青藏川宁琼"
+
+// patterns 内置模式，按匹配顺序排列：身份证号先于银行卡号，同时满足两者的 18 位数字按身份证号统计
+var patterns = []*Pattern{
+	{
+		Name:      PatternIDCard,
+		Desc:      "身份证号",
+		re:        regexp.MustCompile(`\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`),
+		normalize: normalizeIDCard,
+	},
+	{
+		Name:      PatternBankCard,
+		Desc:      "银行卡号",
+		re:        regexp.MustCompile(`\b[3-6]\d{3}(?:[ -]?\d{4}){2,3}(?:[ -]?\d{1,3})?\b`),
+		normalize: normalizeBankCard,
+	},
+	{
+		Name:      PatternPhone,
+		Desc:      "手机号",
+		re:        regexp.MustCompile(`(?:\+86[ -]?|\b86[ -]?|\b)1[3-9]\d(?:[ -]?\d{4}){2}\b`),
+		normalize: normalizePhone,
+	},
+	{
+		Name:      PatternPassport,
+		Desc:      "护照号",
+		re:        regexp.MustCompile(`\b(?:[EGDSP]\d{8}|E[A-HJ-NP-Z]\d{7})\b`),
+		normalize: strings.ToUpper,
+	},
+	{
+		Name:      PatternLicensePlate,
+		Desc:      "车牌号",
+		re:        regexp.MustCompile(`[` + provinces + `][A-HJ-NP-Z][·•]?(?:[DF][A-HJ-NP-Z0-9]\d{4}|\d{5}[DF]|[A-HJ-NP-Z0-9]{5})\b`),
+		normalize: normalizePlate,
+	},
+}
+
+// Lookup 按名称查找内置模式
+func Lookup(name string) (*Pattern, bool) {
+	for _, p := range patterns {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Names 全部内置模式名称
+func Names() []string {
+	names := make([]string, len(patterns))
+	for i, p := range patterns {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Match 一个模式在文本中的匹配结果
+type Match struct {
+	Count  int      // 不同值的个数
+	Sample string   // 首个匹配的原文
+	Spans  [][2]int // 各不同值首次出现的字节区间，按出现顺序
+}
+
+// Scan 统计文本中各内置模式出现的不同值的个数，未出现的模式不在结果中
+// 一段数字只计入首个校验通过的模式
+func Scan(text string) map[string]Match {
+	out := make(map[string]Match)
+	claimed := make(map[string]bool) // 已计入其他模式的数字串
+	for _, p := range patterns {
+		seen := make(map[string]bool)
+		m := Match{}
+		for _, loc := range p.re.FindAllStringIndex(text, -1) {
+			raw := text[loc[0]:loc[1]]
+			v := p.normalize(raw)
+			if v == "" || seen[v] || claimed[digits(v)] {
+				continue
+			}
+			seen[v] = true
+			if m.Count == 0 {
+				m.Sample = raw
+			}
+			m.Count++
+			m.Spans = append(m.Spans, [2]int{loc[0], loc[1]})
+		}
+		for v := range seen {
+			claimed[digits(v)] = true
+		}
+		if m.Count > 0 {
+			out[p.Name] = m
+		}
+	}
+	return out
+}
+
+// ==========================================
+// 规范化与校验
+// ==========================================
+
+// idWeights 身份证号前 17 位的加权因子 (GB 11643)
+var idWeights = [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+
+// normalizeIDCard 校验 18 位身份证号的校验位
+func normalizeIDCard(s string) string {
+	s = strings.ToUpper(s)
+	sum := 0
+	for i, w := range idWeights {
+		sum += int(s[i]-'0') * w
+	}
+	if "10X98765432"[sum%11] != s[17] {
+		return ""
+	}
+	return s
+}
+
+// normalizeBankCard 校验 16-19 位卡号的 Luhn 校验位
+func normalizeBankCard(s string) string {
+	d := digits(s)
+	if len(d) < 16 || len(d) > 19 || !luhn(d) {
+		return ""
+	}
+	return d
+}
+
+// luhn Luhn (模 10) 校验
+func luhn(d string) bool {
+	sum := 0
+	double := false
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if double {
+			if n *= 2; n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// normalizePhone 去除国家码与分隔符，保留 11 位手机号
+func normalizePhone(s string) string {
+	d := digits(s)
+	if len(d) == 13 {
+		d = d[2:]
+	}
+	if len(d) != 11 {
+		return ""
+	}
+	return d
+}
+
+// normalizePlate 去除分隔点，号码部分须含数字 (排除连续字母的英文单词)
+func normalizePlate(s string) string {
+	s = strings.NewReplacer("·", "", "•", "").Replace(s)
+	if digits(s) == "" {
+		return ""
+	}
+	return s
+}
+
+// digits 只保留数字
+func digits(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package pii
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestScan(t *testing.T) {
+	text := `客户：张伟，身份证 11010519491231002X，备用证件 110105194912310021 (校验位错误)
+手机 +86 138-0013-8000，座机 010-12345678，重复 13800138000
+工资卡 6222 0212 3456 7890 128，另一卡号 6222021234567890129 (Luhn 错误)
+护照 E12345678，车牌 京A·12345，粤BD12345，编号 ABCDEFG`
+
+	got := Scan(text)
+	want := map[string]int{
+		PatternIDCard:       1,
+		PatternPhone:        1,
+		PatternBankCard:     1,
+		PatternPassport:     1,
+		PatternLicensePlate: 2,
+	}
+	for name, n := range want {
+		if got[name].Count != n {
+			t.Errorf("Scan()[%s] = %+v, want %d", name, got[name], n)
+		}
+	}
+	if got[PatternIDCard].Sample != "11010519491231002X" || got[PatternBankCard].Sample != "6222 0212 3456 7890 128" {
+		t.Errorf("samples = %+v", got)
+	}
+
+	// 同时满足银行卡 Luhn 校验的身份证号只计入身份证号
+	if got := Scan("440524188001010014"); got[PatternIDCard].Count != 1 || got[PatternBankCard].Count != 0 {
+		t.Errorf("Scan(id) = %+v", got)
+	}
+	if !luhn("6222021234567890128") || luhn("6222021234567890129") {
+		t.Error("luhn")
+	}
+}
+
+func TestDetector_Threshold(t *testing.T) {
+	cfg := model.NewKeywordDetectConfig()
+	bulk := model.NewKeywordPatternRule(41, "id_card", 20)
+	bulk.RuleDesc = "批量身份证号"
+	bulk.SensitivityLevel = int(model.LevelConfidential)
+	cfg.Rules = append(cfg.Rules,
+		*model.NewKeywordDetectRule(40, "机密 and 文件"), // 关键词表达式规则由关键词引擎处理
+		*bulk,
+		*model.NewKeywordPatternRule(42, "phone, bank_card", 3),
+	)
+	d, err := New(cfg)
+	if err != nil || d.Len() != 2 {
+		t.Fatalf("New() = %v, %v", d, err)
+	}
+	ctx := context.Background()
+
+	// 20 个不同的身份证号，重复出现不计数
+	var ids []string
+	for seq := 0; seq < 20; seq++ {
+		id := withCheckDigit(fmt.Sprintf("11010519900101%03d", seq))
+		ids = append(ids, id, id)
+	}
+	if res, _ := d.DetectText(ctx, strings.Join(ids[:38], "\n")); res.IsSecret {
+		t.Errorf("19 ids matched: %+v", res)
+	}
+	res, err := d.DetectText(ctx, strings.Join(ids, "\n"))
+	if err != nil || !res.IsSecret || res.RuleID != 41 || res.SecretLevel != model.LevelConfidential {
+		t.Fatalf("20 ids = %+v, %v", res, err)
+	}
+	if res.ContextText != "身份证号 20 个" || strings.Contains(res.MatchedText, ids[0][6:14]) {
+		t.Errorf("result = %q / %q", res.MatchedText, res.ContextText)
+	}
+
+	// 多个模式合并计数
+	res, _ = d.DetectText(ctx, "13800138000 13912345678 6222021234567890128")
+	if !res.IsSecret || res.RuleID != 42 || res.SecretLevel != model.LevelInternal || res.ContextText != "手机号 2 个, 银行卡号 1 个" {
+		t.Errorf("combined = %+v", res)
+	}
+	// 位置按出现顺序，脱敏且不附带上下文
+	if locs := res.Locations; len(locs) != 3 || locs[1].ByteOffset != 12 || locs[2].ByteOffset != 24 ||
+		locs[2].Text != "622************0128" || locs[2].Before != "" || locs[2].After != "" {
+		t.Errorf("locations = %+v", res.Locations)
+	}
+	if res, _ := d.DetectText(ctx, "13800138000 13912345678"); res.IsSecret {
+		t.Errorf("below threshold = %+v", res)
+	}
+
+	bad := model.NewKeywordDetectConfig()
+	bad.Rules = append(bad.Rules, *model.NewKeywordPatternRule(1, "id_card,ssn", 1))
+	if _, err := New(bad); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("New(bad) = %v", err)
+	}
+}
+
+func TestDetector_File(t *testing.T) {
+	cfg := model.NewKeywordDetectConfig()
+	cfg.Rules = append(cfg.Rules, *model.NewKeywordPatternRule(1, "phone", 2))
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	csv := filepath.Join(dir, "contacts.csv")
+	os.WriteFile(csv, []byte("name,phone\n张伟,13800138000\n李娜,13912345678\n"), 0644)
+	bin := filepath.Join(dir, "blob.bin")
+	os.WriteFile(bin, []byte("\x0013800138000 13912345678"), 0644)
+
+	if res, err := d.DetectFile(context.Background(), csv); err != nil || !res.IsSecret {
+		t.Errorf("DetectFile(csv) = %+v, %v", res, err)
+	}
+	if res, err := d.DetectFile(context.Background(), bin); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(bin) = %+v, %v", res, err)
+	}
+}
+
+// withCheckDigit 为 17 位本体码补上校验位
+func withCheckDigit(body string) string {
+	sum := 0
+	for i, w := range idWeights {
+		sum += int(body[i]-'0') * w
+	}
+	return body + string("10X98765432"[sum%11])
+}
@@ -0,0 +1,365 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// 插件输出单行消息上限
+	maxMessageSize = 16 * 1024 * 1024
+	// 崩溃后重启的退避时间
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+	// Close 时等待插件自行退出的时间
+	shutdownGrace = 3 * time.Second
+)
+
+// ErrUnavailable 插件崩溃后处于重启退避期
+var ErrUnavailable = errors.New("plugin is unavailable")
+
+// Config 插件配置
+type Config struct {
+	// 插件名称，同时作为告警的检测模块 (DetectModule)，供处置策略按模块匹配
+	Name    string
+	Command string
+	Args    []string
+	// 追加的环境变量 (KEY=VALUE)
+	Env []string
+	// 工作目录，为空时为 Command 所在目录
+	Dir string
+	// 单次调用超时，超时后插件进程被终止并重启
+	Timeout time.Duration
+	// 超过该大小的文件不交给插件，0 表示不限
+	MaxFileSize int64
+	// 以 content 字段发送文件内容，而非让插件自行读取路径 (插件运行在受限环境时使用)
+	SendContent bool
+	// 传给插件 initialize 的自定义选项
+	Options map[string]string
+}
+
+// Plugin 外部检测插件，实现 detector.SubDetector 与 detector.ContentDetector
+// 进程在首次检测时启动，调用串行执行
+type Plugin struct {
+	cfg Config
+
+	mu        sync.Mutex
+	proc      *process
+	info      Info
+	failures  int
+	nextStart time.Time
+	closed    bool
+}
+
+// process 运行中的插件进程
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	nextID uint64
+	exited chan struct{}
+}
+
+// New 创建插件 (不立即启动进程)
+func New(cfg Config) (*Plugin, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("plugin name is required")
+	}
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("plugin %s: command is required", cfg.Name)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Plugin{cfg: cfg}, nil
+}
+
+// Name 插件名称
+func (p *Plugin) Name() string { return p.cfg.Name }
+
+// Config 插件配置
+func (p *Plugin) Config() Config { return p.cfg }
+
+// DetectFile 检测磁盘文件
+func (p *Plugin) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.MaxFileSize > 0 && info.Size() > p.cfg.MaxFileSize {
+		return nil, nil
+	}
+
+	params := DetectParams{Path: filePath, Name: info.Name(), Size: info.Size()}
+	if p.cfg.SendContent {
+		if params.Content, err = os.ReadFile(filePath); err != nil {
+			return nil, err
+		}
+	}
+	return p.detect(ctx, params, nil)
+}
+
+// DetectBytes 检测内存内容
+// 插件不接受 content 时先写入临时文件，再以路径调用
+func (p *Plugin) DetectBytes(ctx context.Context, name string, data []byte) (*model.SubDetectResult, error) {
+	if p.cfg.MaxFileSize > 0 && int64(len(data)) > p.cfg.MaxFileSize {
+		return nil, nil
+	}
+	params := DetectParams{Path: name, Name: filepath.Base(name), Size: int64(len(data)), Content: data}
+	return p.detect(ctx, params, func() (string, func(), error) {
+		dir, err := os.MkdirTemp("", "plugin-")
+		if err != nil {
+			return "", nil, err
+		}
+		path := filepath.Join(dir, params.Name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, err
+		}
+		return path, func() { os.RemoveAll(dir) }, nil
+	})
+}
+
+// detect 发送 detect 请求
+// spill 非 nil 时，若插件不接受 content，则调用 spill 将内容落盘后改为按路径检测
+func (p *Plugin) detect(ctx context.Context, params DetectParams, spill func() (string, func(), error)) (*model.SubDetectResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.ensureStarted(); err != nil {
+		return nil, err
+	}
+	if params.Content != nil && !p.info.AcceptsContent {
+		if spill == nil {
+			params.Content = nil
+		} else {
+			path, cleanup, err := spill()
+			if err != nil {
+				return nil, err
+			}
+			defer cleanup()
+			params.Path, params.Content = path, nil
+		}
+	}
+
+	var res DetectResult
+	if err := p.call(ctx, MethodDetect, params, &res); err != nil {
+		return nil, err
+	}
+	if !res.Detected {
+		return &model.SubDetectResult{}, nil
+	}
+	if res.RuleDesc == "" {
+		res.RuleDesc = p.cfg.Name
+	}
+	return &model.SubDetectResult{
+		IsSecret:    true,
+		SecretLevel: model.SecretLevel(res.SecretLevel),
+		RuleID:      res.RuleID,
+		RuleDesc:    res.RuleDesc,
+		MatchedText: res.MatchedText,
+		ContextText: res.ContextText,
+		AlertType:   res.AlertType,
+	}, nil
+}
+
+// ensureStarted 启动插件进程并完成 initialize 握手，调用方需持有 p.mu
+func (p *Plugin) ensureStarted() error {
+	if p.closed {
+		return fmt.Errorf("plugin %s: closed", p.cfg.Name)
+	}
+	if p.proc != nil {
+		return nil
+	}
+	if time.Now().Before(p.nextStart) {
+		return fmt.Errorf("plugin %s: %w", p.cfg.Name, ErrUnavailable)
+	}
+
+	proc, err := p.start()
+	if err != nil {
+		p.fail()
+		return fmt.Errorf("plugin %s: start failed: %w", p.cfg.Name, err)
+	}
+	p.proc = proc
+
+	var info Info
+	initParams := InitializeParams{ProtocolVersion: ProtocolVersion, Options: p.cfg.Options}
+	if err := p.call(context.Background(), MethodInitialize, initParams, &info); err != nil {
+		p.kill()
+		return fmt.Errorf("plugin %s: initialize failed: %w", p.cfg.Name, err)
+	}
+	p.info = info
+	p.failures = 0
+	logger.Info("检测插件已启动", "plugin", p.cfg.Name, "version", info.Version, "pid", proc.cmd.Process.Pid)
+	return nil
+}
+
+func (p *Plugin) start() (*process, error) {
+	cmd := exec.Command(p.cfg.Command, p.cfg.Args...)
+	cmd.Env = append(os.Environ(), p.cfg.Env...)
+	cmd.Dir = p.cfg.Dir
+	if cmd.Dir == "" {
+		cmd.Dir = filepath.Dir(p.cfg.Command)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+
+	proc := &process{cmd: cmd, stdin: stdin, stdout: scanner, exited: make(chan struct{})}
+	go func() {
+		lines := bufio.NewScanner(stderr)
+		for lines.Scan() {
+			logger.Debug("检测插件输出", "plugin", p.cfg.Name, "stderr", lines.Text())
+		}
+	}()
+	go func() {
+		err := cmd.Wait()
+		close(proc.exited)
+		logger.Debug("检测插件进程退出", "plugin", p.cfg.Name, "error", err)
+	}()
+	return proc, nil
+}
+
+// call 发送请求并等待响应，调用方需持有 p.mu
+// 超时、读写失败或插件输出无法解析时终止进程，下次调用重新启动
+func (p *Plugin) call(ctx context.Context, method string, params, result interface{}) error {
+	proc := p.proc
+	proc.nextID++
+	id := proc.nextID
+
+	line, err := json.Marshal(request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	var resp response
+	go func() {
+		if _, err := proc.stdin.Write(append(line, '\n')); err != nil {
+			done <- err
+			return
+		}
+		for {
+			if !proc.stdout.Scan() {
+				err := proc.stdout.Err()
+				if err == nil {
+					err = io.ErrUnexpectedEOF
+				}
+				done <- err
+				return
+			}
+			resp = response{}
+			if err := json.Unmarshal(proc.stdout.Bytes(), &resp); err != nil {
+				done <- fmt.Errorf("invalid message: %w", err)
+				return
+			}
+			// 忽略插件发出的通知等与本次请求无关的消息
+			if resp.ID == id {
+				done <- nil
+				return
+			}
+		}
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		p.kill()
+		<-done
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if err != nil {
+		p.kill()
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %w", method, resp.Error)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// kill 终止插件进程并进入重启退避，调用方需持有 p.mu
+func (p *Plugin) kill() {
+	if p.proc == nil {
+		return
+	}
+	p.proc.stdin.Close()
+	p.proc.cmd.Process.Kill()
+	<-p.proc.exited
+	p.proc = nil
+	p.fail()
+}
+
+// fail 记录一次失败并计算下次允许启动的时间
+func (p *Plugin) fail() {
+	backoff := minBackoff << p.failures
+	if backoff > maxBackoff || backoff <= 0 {
+		backoff = maxBackoff
+	} else {
+		p.failures++
+	}
+	p.nextStart = time.Now().Add(backoff)
+	logger.Warn("检测插件不可用，稍后重启", "plugin", p.cfg.Name, "retry_in", backoff)
+}
+
+// Close 通知插件退出，超时未退出则强制终止
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	proc := p.proc
+	if proc == nil {
+		return nil
+	}
+	p.proc = nil
+
+	if line, err := json.Marshal(request{JSONRPC: "2.0", Method: MethodShutdown}); err == nil {
+		proc.stdin.Write(append(line, '\n'))
+	}
+	proc.stdin.Close()
+
+	select {
+	case <-proc.exited:
+	case <-time.After(shutdownGrace):
+		proc.cmd.Process.Kill()
+		<-proc.exited
+	}
+	return nil
+}
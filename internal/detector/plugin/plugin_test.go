@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 以环境变量区分：测试二进制自身作为插件进程运行
+const helperEnv = "PLUGIN_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(helperEnv); mode != "" {
+		runHelper(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runHelper 模拟插件: 文件名或内容包含 "secret" 时命中
+// mode=hang 时 detect 不响应，用于测试超时
+func runHelper(mode string) {
+	in := bufio.NewScanner(os.Stdin)
+	out := json.NewEncoder(os.Stdout)
+	for in.Scan() {
+		var req struct {
+			ID     uint64          `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.Unmarshal(in.Bytes(), &req)
+
+		switch req.Method {
+		case MethodInitialize:
+			out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID,
+				"result": Info{Name: "helper", Version: "1.0", AcceptsContent: mode != "path-only"}})
+		case MethodDetect:
+			if mode == "hang" {
+				time.Sleep(time.Hour)
+			}
+			var p DetectParams
+			json.Unmarshal(req.Params, &p)
+			fmt.Fprintf(os.Stderr, "detect %s\n", p.Path)
+
+			text := string(p.Content)
+			if p.Content == nil {
+				data, _ := os.ReadFile(p.Path)
+				text = string(data)
+			}
+			res := DetectResult{}
+			if strings.Contains(p.Name, "secret") || strings.Contains(text, "secret") {
+				res = DetectResult{Detected: true, SecretLevel: 2, RuleID: 9001, MatchedText: "secret"}
+			}
+			// 先输出一条无关通知，调用方应忽略
+			out.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": "log"})
+			out.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": res})
+		case MethodShutdown:
+			return
+		}
+	}
+}
+
+func newHelper(t *testing.T, mode string, timeout time.Duration) *Plugin {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	p, err := New(Config{
+		Name:    "acme",
+		Command: exe,
+		Env:     []string{helperEnv + "=" + mode},
+		Timeout: timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestPlugin_Detect(t *testing.T) {
+	p := newHelper(t, "content", 5*time.Second)
+	dir := t.TempDir()
+
+	hit := filepath.Join(dir, "a.txt")
+	os.WriteFile(hit, []byte("top secret"), 0644)
+	res, err := p.DetectFile(context.Background(), hit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsSecret || res.RuleID != 9001 || res.RuleDesc != "acme" || res.SecretLevel != 2 {
+		t.Errorf("DetectFile() = %+v", res)
+	}
+
+	clean := filepath.Join(dir, "b.txt")
+	os.WriteFile(clean, []byte("hello"), 0644)
+	if res, err := p.DetectFile(context.Background(), clean); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(clean) = %+v, %v", res, err)
+	}
+
+	if res, err := p.DetectBytes(context.Background(), "clipboard", []byte("secret")); err != nil || !res.IsSecret {
+		t.Errorf("DetectBytes() = %+v, %v", res, err)
+	}
+}
+
+func TestPlugin_PathOnlySpillsContent(t *testing.T) {
+	p := newHelper(t, "path-only", 5*time.Second)
+	res, err := p.DetectBytes(context.Background(), "net/payload.bin", []byte("secret"))
+	if err != nil || !res.IsSecret {
+		t.Errorf("DetectBytes() = %+v, %v", res, err)
+	}
+}
+
+func TestPlugin_TimeoutRestarts(t *testing.T) {
+	p := newHelper(t, "hang", 200*time.Millisecond)
+	f := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(f, []byte("x"), 0644)
+
+	if _, err := p.DetectFile(context.Background(), f); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("超时应返回 DeadlineExceeded, got %v", err)
+	}
+	// 进程已被终止，退避期内不重启
+	if _, err := p.DetectFile(context.Background(), f); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("退避期内应返回 ErrUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Package plugin 外部检测插件
+// 插件是独立的可执行程序，agent 启动后通过标准输入/输出与其交换 JSON-RPC 2.0 消息
+// (每条消息占一行)，插件进程常驻并串行处理请求，崩溃或超时后自动重启。
+//
+// 调用流程:
+//
+//	→ {"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocol_version":1,"options":{...}}}
+//	← {"jsonrpc":"2.0","id":1,"result":{"name":"acme","version":"1.0","accepts_content":true}}
+//	→ {"jsonrpc":"2.0","id":2,"method":"detect","params":{"path":"/home/u/a.docx","name":"a.docx","size":1024}}
+//	← {"jsonrpc":"2.0","id":2,"result":{"detected":true,"secret_level":2,"rule_id":9001,"rule_desc":"客户规则"}}
+//	→ {"jsonrpc":"2.0","method":"shutdown"}
+//
+// 检测内存内容 (剪贴板、网络载荷等) 或配置了 send_content 时，params 中的 content 为 Base64 编码的文件内容，
+// 此时 path 仅为来源描述，插件不应再读取该路径。插件的标准错误输出会写入 agent 日志
+package plugin
+
+import "encoding/json"
+
+// ProtocolVersion 插件协议版本
+const ProtocolVersion = 1
+
+// 方法名
+const (
+	MethodInitialize = "initialize"
+	MethodDetect     = "detect"
+	MethodShutdown   = "shutdown"
+)
+
+// request JSON-RPC 请求，ID 为 0 表示通知 (不需要响应)
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      uint64      `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// response JSON-RPC 响应
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError 插件返回的错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// InitializeParams initialize 请求参数
+type InitializeParams struct {
+	ProtocolVersion int               `json:"protocol_version"`
+	Options         map[string]string `json:"options,omitempty"` // 配置文件中的插件自定义选项
+}
+
+// Info initialize 响应
+type Info struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// 插件能否处理 content 字段；为 false 时内存内容会先写入临时文件再以路径调用
+	AcceptsContent bool `json:"accepts_content"`
+}
+
+// DetectParams detect 请求参数
+type DetectParams struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	Content []byte `json:"content,omitempty"` // JSON 中为 Base64
+}
+
+// DetectResult detect 响应，字段与 model.SubDetectResult 对应
+type DetectResult struct {
+	Detected    bool   `json:"detected"`
+	SecretLevel int    `json:"secret_level"` // model.SecretLevel
+	RuleID      int64  `json:"rule_id"`
+	RuleDesc    string `json:"rule_desc"`
+	MatchedText string `json:"matched_text"`
+	ContextText string `json:"context_text"`
+	AlertType   int    `json:"alert_type"`
+}
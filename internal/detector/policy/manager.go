@@ -6,12 +6,30 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 )
 
+// defaultVerifier 新建管理器默认使用的签名校验器 (由主程序按配置设置)
+var defaultVerifier atomic.Pointer[Verifier]
+
+// SetDefaultVerifier 设置默认签名校验器，之后创建的管理器加载策略时校验签名
+func SetDefaultVerifier(v *Verifier) {
+	defaultVerifier.Store(v)
+}
+
+// DefaultVerifier 当前默认签名校验器，未设置时返回 nil
+func DefaultVerifier() *Verifier {
+	return defaultVerifier.Load()
+}
+
 // Manager 策略管理器
 type Manager struct {
 	// 策略存储根路径
 	rootPath string
+
+	// 签名校验器，nil 表示不校验
+	verifier *Verifier
 }
 
 // NewManager 创建新的策略管理器
@@ -23,9 +41,15 @@ func NewManager(rootPath string) *Manager {
 
 	return &Manager{
 		rootPath: rootPath,
+		verifier: defaultVerifier.Load(),
 	}
 }
 
+// SetVerifier 设置签名校验器
+func (m *Manager) SetVerifier(v *Verifier) {
+	m.verifier = v
+}
+
 // LoadPolicy 从本地文件加载策略
 // moduleName: 模块名称，如 "file_hash", "secret_level" 等
 // config: 用于接收策略配置的指针
@@ -45,6 +69,11 @@ func (m *Manager) LoadPolicy(moduleName string, config interface{}) error {
 		return fmt.Errorf("failed to read policy file: %w", err)
 	}
 
+	// 校验服务端签名
+	if err := m.verifier.Check(policyFile, policyData); err != nil {
+		return err
+	}
+
 	// 反序列化策略配置
 	if err := json.Unmarshal(policyData, config); err != nil {
 		return fmt.Errorf("failed to unmarshal policy: %w", err)
@@ -53,6 +82,45 @@ func (m *Manager) LoadPolicy(moduleName string, config interface{}) error {
 	return nil
 }
 
+// SavePolicy 保存服务端下发的策略 (签名校验规则同 LoadPolicy)，写入临时文件后改名，加载方不会读到不完整的文件
+func (m *Manager) SavePolicy(moduleName string, data []byte) error {
+	if moduleName == "" || moduleName != filepath.Base(moduleName) || strings.HasPrefix(moduleName, ".") {
+		return fmt.Errorf("invalid policy module %q", moduleName)
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("policy for %s is not valid JSON", moduleName)
+	}
+	policyFile := m.GetPolicyPath(moduleName)
+	if err := m.verifier.Check(policyFile, data); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(policyFile), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(policyFile), ".policy.json.*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), policyFile)
+}
+
 // GetPolicyPath 获取指定模块的策略文件路径
 func (m *Manager) GetPolicyPath(moduleName string) string {
 	return filepath.Join(m.rootPath, moduleName, "policy.json")
@@ -0,0 +1,191 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/logger"
+)
+
+// ==========================================
+// 规则包 SM2 签名
+// ==========================================
+//
+// 签名放在规则包 JSON 顶层的 "signature" 字段 (Base64 编码的 DER 签名值)。
+// 签名原文为去掉该字段后的规范化 JSON：键按字典序排列、无多余空白、不转义 HTML 字符、数值保持原样。
+
+// SignatureField 规则包中的签名字段名
+const SignatureField = "signature"
+
+// VerifyMode 签名校验模式
+type VerifyMode string
+
+const (
+	VerifyOff     VerifyMode = "off"     // 不校验
+	VerifyWarn    VerifyMode = "warn"    // 校验失败仅告警，仍加载
+	VerifyEnforce VerifyMode = "enforce" // 校验失败拒绝加载
+)
+
+var (
+	// ErrUnsigned 规则包没有签名
+	ErrUnsigned = errors.New("policy: bundle is not signed")
+	// ErrBadSignature 签名校验失败
+	ErrBadSignature = errors.New("policy: signature verification failed")
+)
+
+// Verifier 规则包签名校验器
+type Verifier struct {
+	Mode      VerifyMode
+	PublicKey *sm2.PublicKey
+}
+
+// NewVerifier 创建校验器，keyOrPath 为十六进制公钥 (04||X||Y) 或公钥文件路径
+func NewVerifier(mode VerifyMode, keyOrPath string) (*Verifier, error) {
+	switch mode {
+	case "", VerifyOff:
+		return &Verifier{Mode: VerifyOff}, nil
+	case VerifyWarn, VerifyEnforce:
+	default:
+		return nil, fmt.Errorf("policy: unknown verify mode %q", mode)
+	}
+
+	key := []byte(keyOrPath)
+	if !isHex(keyOrPath) {
+		data, err := os.ReadFile(keyOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("policy: read public key: %w", err)
+		}
+		key = data
+	}
+	pub, err := sm2.ParsePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{Mode: mode, PublicKey: pub}, nil
+}
+
+// Check 按校验模式检查规则包，返回 error 表示应拒绝加载 (warn 模式下校验失败仅记录日志)
+func (v *Verifier) Check(source string, data []byte) error {
+	if v == nil || v.Mode == VerifyOff || v.Mode == "" {
+		return nil
+	}
+	err := VerifyBundle(data, v.PublicKey)
+	if err == nil {
+		return nil
+	}
+	if v.Mode == VerifyEnforce {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	logger.Warn("规则包签名校验失败，仍按 warn 模式加载", "source", source, "error", err)
+	return nil
+}
+
+// VerifyBundle 校验规则包签名
+func VerifyBundle(data []byte, pub *sm2.PublicKey) error {
+	return VerifyWithUID(data, pub, nil)
+}
+
+// VerifyWithUID 按指定的 SM2 用户标识校验签名，uid 为 nil 时与 VerifyBundle 相同
+// 与规则包共用签名密钥的其他数据 (如服务端指令) 使用各自的用户标识，规则包签名不能冒充指令，反之亦然
+func VerifyWithUID(data []byte, pub *sm2.PublicKey, uid []byte) error {
+	payload, sig, err := Canonicalize(data)
+	if err != nil {
+		return err
+	}
+	if sig == "" {
+		return ErrUnsigned
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	if !sm2.VerifyWithUID(pub, uid, payload, raw) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// SignBundle 对规则包签名，返回写入签名字段后的规范化 JSON
+func SignBundle(data []byte, priv *sm2.PrivateKey) ([]byte, error) {
+	return SignWithUID(data, priv, nil)
+}
+
+// SignWithUID 按指定的 SM2 用户标识签名，uid 为 nil 时与 SignBundle 相同
+func SignWithUID(data []byte, priv *sm2.PrivateKey, uid []byte) ([]byte, error) {
+	payload, _, err := Canonicalize(data)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sm2.SignWithUID(nil, priv, uid, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]interface{}
+	if err := decodeJSON(payload, &obj); err != nil {
+		return nil, err
+	}
+	obj[SignatureField] = base64.StdEncoding.EncodeToString(sig)
+	return encodeCanonical(obj)
+}
+
+// Canonicalize 返回去掉签名字段后的规范化 JSON 以及签名字段的值
+func Canonicalize(data []byte) (payload []byte, signature string, err error) {
+	var obj map[string]interface{}
+	if err := decodeJSON(data, &obj); err != nil {
+		return nil, "", fmt.Errorf("policy: bundle must be a JSON object: %w", err)
+	}
+	if v, ok := obj[SignatureField]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: signature field is not a string", ErrBadSignature)
+		}
+		signature = s
+		delete(obj, SignatureField)
+	}
+	payload, err = encodeCanonical(obj)
+	return payload, signature, err
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("trailing data after JSON value")
+	}
+	return nil
+}
+
+// encodeCanonical map 键由 encoding/json 按字典序输出
+func encodeCanonical(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+func isHex(s string) bool {
+	s = strings.TrimSpace(s)
+	if len(s) != 130 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
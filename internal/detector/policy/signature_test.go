@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/model"
+)
+
+const bundle = `{
+  "rules": [
+    {"rule_id": 12345678901234567, "rule_type": 0, "rule_content": "d41d8cd98f00b204e9800998ecf8427e", "rule_desc": "<测试>"}
+  ]
+}`
+
+func TestSignVerifyBundle(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+
+	signed, err := SignBundle([]byte(bundle), priv)
+	if err != nil {
+		t.Fatalf("SignBundle() error = %v", err)
+	}
+	if err := VerifyBundle(signed, &priv.PublicKey); err != nil {
+		t.Fatalf("VerifyBundle() error = %v", err)
+	}
+
+	// 签名与格式无关：重新排版后仍可校验
+	_, sig, _ := Canonicalize(signed)
+	reformatted := []byte(`{"signature":"` + sig + `",` + bundle[1:])
+	if err := VerifyBundle(reformatted, &priv.PublicKey); err != nil {
+		t.Errorf("VerifyBundle(reformatted) error = %v", err)
+	}
+
+	tampered := []byte(`{"signature":"` + sig + `","rules":[]}`)
+	if err := VerifyBundle(tampered, &priv.PublicKey); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifyBundle(tampered) error = %v, want ErrBadSignature", err)
+	}
+	if err := VerifyBundle([]byte(bundle), &priv.PublicKey); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("VerifyBundle(unsigned) error = %v, want ErrUnsigned", err)
+	}
+}
+
+func TestManager_LoadPolicyVerify(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	root := t.TempDir()
+	dir := filepath.Join(root, model.ModuleMD5Detect)
+	os.MkdirAll(dir, 0755)
+	if err := os.WriteFile(filepath.Join(dir, "policy.json"), []byte(bundle), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager(root)
+	var cfg model.HashDetectConfig
+
+	m.SetVerifier(&Verifier{Mode: VerifyEnforce, PublicKey: &priv.PublicKey})
+	if err := m.LoadPolicy(model.ModuleMD5Detect, &cfg); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("enforce 模式加载未签名策略 error = %v", err)
+	}
+
+	m.SetVerifier(&Verifier{Mode: VerifyWarn, PublicKey: &priv.PublicKey})
+	if err := m.LoadPolicy(model.ModuleMD5Detect, &cfg); err != nil || len(cfg.Rules) != 1 {
+		t.Fatalf("warn 模式应继续加载: err = %v, rules = %d", err, len(cfg.Rules))
+	}
+
+	signed, _ := SignBundle([]byte(bundle), priv)
+	os.WriteFile(filepath.Join(dir, "policy.json"), signed, 0644)
+	cfg = model.HashDetectConfig{}
+	m.SetVerifier(&Verifier{Mode: VerifyEnforce, PublicKey: &priv.PublicKey})
+	if err := m.LoadPolicy(model.ModuleMD5Detect, &cfg); err != nil || len(cfg.Rules) != 1 {
+		t.Fatalf("加载已签名策略: err = %v, rules = %d", err, len(cfg.Rules))
+	}
+	if cfg.Rules[0].RuleID != 12345678901234567 {
+		t.Errorf("RuleID = %d", cfg.Rules[0].RuleID)
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	if _, err := NewVerifier(VerifyEnforce, priv.PublicKey.Hex()); err != nil {
+		t.Errorf("NewVerifier(hex) error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.pub")
+	os.WriteFile(path, []byte(priv.PublicKey.Hex()+"\n"), 0644)
+	if _, err := NewVerifier(VerifyWarn, path); err != nil {
+		t.Errorf("NewVerifier(file) error = %v", err)
+	}
+	if _, err := NewVerifier("strict", path); err == nil {
+		t.Error("NewVerifier() 应拒绝未知模式")
+	}
+}
+
+func TestManager_SavePolicy(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	m := NewManager(t.TempDir())
+	m.SetVerifier(&Verifier{Mode: VerifyEnforce, PublicKey: &priv.PublicKey})
+
+	if err := m.SavePolicy(model.ModuleMD5Detect, []byte(bundle)); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("SavePolicy(unsigned) error = %v, want ErrUnsigned", err)
+	}
+	if _, err := os.Stat(m.GetPolicyPath(model.ModuleMD5Detect)); !os.IsNotExist(err) {
+		t.Error("unsigned policy written")
+	}
+	for _, name := range []string{"", "..", "../etc", "a/b"} {
+		if err := m.SavePolicy(name, []byte(`{}`)); err == nil {
+			t.Errorf("SavePolicy(%q) accepted", name)
+		}
+	}
+
+	signed, _ := SignBundle([]byte(bundle), priv)
+	if err := m.SavePolicy(model.ModuleMD5Detect, signed); err != nil {
+		t.Fatalf("SavePolicy(signed) error = %v", err)
+	}
+	var cfg model.HashDetectConfig
+	if err := m.LoadPolicy(model.ModuleMD5Detect, &cfg); err != nil {
+		t.Errorf("LoadPolicy() after save error = %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package detector
+
+import (
+	"fmt"
+
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/model"
+)
+
+// RouteExpand 路由中表示展开压缩包、逐项检测其中文件的伪模块名
+const RouteExpand = "expand"
+
+// Route 某一文件分类执行的检测
+type Route struct {
+	// 展开压缩包逐项检测 (仅对 archive 分类生效，不递归展开)
+	Expand bool
+	// 执行的检测模块 (model.Module*，外部插件为插件名称)
+	Modules map[string]bool
+}
+
+// Routes 文件分类到检测模块的路由，未配置的分类执行全部已启用的检测模块
+type Routes map[filetype.Category]Route
+
+// DefaultRoutes 默认路由：音视频与可执行文件不含可检测的文本，只做哈希检测
+func DefaultRoutes() Routes {
+	hashOnly := Route{Modules: map[string]bool{model.ModuleMD5Detect: true}}
+	return Routes{
+		filetype.CategoryAudio:      hashOnly,
+		filetype.CategoryVideo:      hashOnly,
+		filetype.CategoryExecutable: hashOnly,
+	}
+}
+
+// ParseRoutes 解析路由配置 (分类名 -> 检测模块名列表)，为空时返回 DefaultRoutes
+// 模块列表为空表示该分类不做任何检测；"expand" 表示展开压缩包
+func ParseRoutes(cfg map[string][]string) (Routes, error) {
+	if len(cfg) == 0 {
+		return DefaultRoutes(), nil
+	}
+
+	known := make(map[filetype.Category]bool)
+	for _, c := range filetype.GetAllCategories() {
+		known[c] = true
+	}
+
+	routes := make(Routes, len(cfg))
+	for name, modules := range cfg {
+		category := filetype.Category(name)
+		if !known[category] {
+			return nil, fmt.Errorf("检测路由文件分类无效: %s", name)
+		}
+		route := Route{Modules: make(map[string]bool, len(modules))}
+		for _, module := range modules {
+			if module == RouteExpand {
+				route.Expand = true
+				continue
+			}
+			route.Modules[module] = true
+		}
+		routes[category] = route
+	}
+	return routes, nil
+}
+
+// allows 该分类是否执行指定检测模块
+func (r Routes) allows(category filetype.Category, module string) bool {
+	route, ok := r[category]
+	return !ok || route.Modules[module]
+}
+
+// expands 该分类是否展开压缩包
+func (r Routes) expands(category filetype.Category) bool {
+	return category == filetype.CategoryArchive && r[category].Expand
+}
+
+// SetRoutes 替换检测路由 (配置加载与重载时调用)
+func (m *Manager) SetRoutes(routes Routes) {
+	m.mu.Lock()
+	m.routes = routes
+	m.mu.Unlock()
+}
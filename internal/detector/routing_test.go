@@ -0,0 +1,138 @@
+package detector
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/filetype"
+	"linuxFileWatcher/internal/model"
+)
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes(map[string][]string{
+		"image":   {model.ModuleSecretLevelDetect},
+		"archive": {RouteExpand, model.ModuleMD5Detect},
+		"video":   {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		category filetype.Category
+		module   string
+		want     bool
+	}{
+		{filetype.CategoryImage, model.ModuleSecretLevelDetect, true},
+		{filetype.CategoryImage, model.ModuleKeywordDetect, false},
+		{filetype.CategoryArchive, model.ModuleMD5Detect, true},
+		{filetype.CategoryArchive, RouteExpand, false},
+		{filetype.CategoryVideo, model.ModuleMD5Detect, false},
+		{filetype.CategoryText, model.ModuleKeywordDetect, true}, // 未配置的分类执行全部模块
+	}
+	for _, tt := range tests {
+		if got := routes.allows(tt.category, tt.module); got != tt.want {
+			t.Errorf("allows(%s, %s) = %v, want %v", tt.category, tt.module, got, tt.want)
+		}
+	}
+	if !routes.expands(filetype.CategoryArchive) || routes.expands(filetype.CategoryImage) {
+		t.Errorf("expands() 结果错误: %+v", routes)
+	}
+
+	if _, err := ParseRoutes(map[string][]string{"movie": {}}); err == nil {
+		t.Error("无效分类应返回错误")
+	}
+	if routes, _ := ParseRoutes(nil); routes.allows(filetype.CategoryAudio, model.ModuleKeywordDetect) {
+		t.Error("空配置应使用默认路由")
+	}
+}
+
+func TestManager_DetectArchive(t *testing.T) {
+	newManager := func() (*Manager, *bytesDetector) {
+		keywords := &bytesDetector{}
+		routes, _ := ParseRoutes(map[string][]string{"archive": {RouteExpand}})
+		return &Manager{
+			config:           GlobalConfig{EnableKeywords: true},
+			keywordsDetector: keywords,
+			routes:           routes,
+		}, keywords
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for name, text := range map[string]string{"a.txt": "普通内容", "docs/b.txt": "绝密★启用前"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(text))
+	}
+	zw.Close()
+
+	var tgzBuf bytes.Buffer
+	gw := gzip.NewWriter(&tgzBuf)
+	tw := tar.NewWriter(gw)
+	tw.WriteHeader(&tar.Header{Name: "c.txt", Mode: 0644, Size: int64(len("绝密")), Typeflag: tar.TypeReg})
+	tw.Write([]byte("绝密"))
+	tw.Close()
+	gw.Close()
+
+	tests := []struct {
+		name      string
+		data      []byte
+		wantEntry string
+	}{
+		{"a.zip", zipBuf.Bytes(), "docs/b.txt"},
+		{"a.tar.gz", tgzBuf.Bytes(), "c.txt"},
+	}
+	for _, tt := range tests {
+		// 磁盘文件与内存内容均可展开
+		path := filepath.Join(t.TempDir(), tt.name)
+		os.WriteFile(path, tt.data, 0644)
+
+		m, _ := newManager()
+		hit, record, logItem, err := m.Detect(context.Background(), path)
+		if err != nil || !hit {
+			t.Fatalf("%s: Detect() hit = %v, err = %v", tt.name, hit, err)
+		}
+		if record.FilePath != path || record.FileSummary != tt.wantEntry || record.FileSize != len(tt.data) || logItem.FilePath != path {
+			t.Errorf("%s: 告警 = %q %q %d", tt.name, record.FilePath, record.FileSummary, record.FileSize)
+		}
+
+		m, keywords := newManager()
+		if hit, _, _, _ := m.DetectBytes(context.Background(), tt.name, tt.data); !hit {
+			t.Errorf("%s: DetectBytes() 未命中, 检测 %v", tt.name, keywords.names)
+		}
+	}
+}
+
+func TestManager_DetectArchiveBomb(t *testing.T) {
+	routes, _ := ParseRoutes(map[string][]string{"archive": {RouteExpand, model.ModuleKeywordDetect}})
+	keywords := &bytesDetector{}
+	m := &Manager{
+		config:           GlobalConfig{EnableKeywords: true},
+		keywordsDetector: keywords,
+		routes:           routes,
+	}
+
+	// 4MB 的 0 压缩后只有几 KB，压缩比远超上限
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, _ := zw.Create("zeros.txt")
+	w.Write(make([]byte, 4*1024*1024))
+	zw.Close()
+
+	// 超出展开配额的内容未检测，按疑似压缩炸弹告警
+	hit, record, _, err := m.DetectBytes(context.Background(), "bomb.zip", zipBuf.Bytes())
+	if !hit || err != nil || record.DetectModule != model.ModuleArchiveBomb || !strings.Contains(record.FileDesc, "zeros.txt") {
+		t.Fatalf("DetectBytes() hit = %v, record = %+v, err = %v", hit, record, err)
+	}
+	// 压缩包本身仍按路由执行检测模块
+	if len(keywords.names) != 1 || keywords.names[0] != "bomb.zip" {
+		t.Errorf("检测 %v", keywords.names)
+	}
+}
@@ -0,0 +1,125 @@
+// Package sampling 大文件稀疏采样
+// 采样窗口由文件头、文件尾与中间 N 个伪随机窗口组成，窗口位置只取决于采样参数与文件大小，
+// 同一文件在任何主机上得到相同的窗口，采样哈希与标志扫描结果可复现
+package sampling
+
+import (
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// Params 采样参数，与策略中的 model.SamplingParams 一致
+type Params model.SamplingParams
+
+// DefaultParams 默认参数：头尾各 1MB，中间 16 个 64KB 窗口
+func DefaultParams() Params {
+	return Params{
+		HeadSize:   1024 * 1024,
+		TailSize:   1024 * 1024,
+		Windows:    16,
+		WindowSize: 64 * 1024,
+	}
+}
+
+// FromPolicy 转换策略中的采样参数，nil 时返回默认参数
+func FromPolicy(p *model.SamplingParams) Params {
+	if p == nil {
+		return DefaultParams()
+	}
+	return Params(*p)
+}
+
+// String 参数的规范表示，随检测结果记录以便复现
+func (p Params) String() string {
+	return fmt.Sprintf("head=%d,tail=%d,windows=%dx%d,seed=%d", p.HeadSize, p.TailSize, p.Windows, p.WindowSize, p.Seed)
+}
+
+// Total 采样字节数上限，不大于该值的文件完整读取
+func (p Params) Total() int64 {
+	return p.HeadSize + p.TailSize + int64(p.Windows)*p.WindowSize
+}
+
+// Window 采样窗口
+type Window struct {
+	Offset int64
+	Length int64
+}
+
+// Plan 计算 size 字节文件的采样窗口，按偏移升序且互不重叠
+func (p Params) Plan(size int64) []Window {
+	if size <= 0 {
+		return nil
+	}
+	if size <= p.Total() {
+		return []Window{{0, size}}
+	}
+
+	var ws []Window
+	if p.HeadSize > 0 {
+		ws = append(ws, Window{0, p.HeadSize})
+	}
+	if p.TailSize > 0 {
+		ws = append(ws, Window{size - p.TailSize, p.TailSize})
+	}
+
+	// 中间窗口落在头尾之间，位置由 splitmix64(seed, size, i) 决定
+	if span := size - p.HeadSize - p.TailSize - p.WindowSize; p.WindowSize > 0 && span >= 0 {
+		state := p.Seed ^ uint64(size)
+		for i := 0; i < p.Windows; i++ {
+			off := p.HeadSize + int64(splitmix64(&state)%uint64(span+1))
+			ws = append(ws, Window{off, p.WindowSize})
+		}
+	}
+	return merge(ws)
+}
+
+// merge 排序并合并重叠或相邻的窗口
+func merge(ws []Window) []Window {
+	sort.Slice(ws, func(i, j int) bool { return ws[i].Offset < ws[j].Offset })
+	out := ws[:0]
+	for _, w := range ws {
+		if n := len(out); n > 0 && w.Offset <= out[n-1].Offset+out[n-1].Length {
+			if end := w.Offset + w.Length; end > out[n-1].Offset+out[n-1].Length {
+				out[n-1].Length = end - out[n-1].Offset
+			}
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9E3779B97F4A7C15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Read 依次读取各采样窗口，fn 返回错误时停止
+func (p Params) Read(r io.ReaderAt, size int64, fn func(w Window, data []byte) error) error {
+	for _, w := range p.Plan(size) {
+		buf := make([]byte, w.Length)
+		n, err := r.ReadAt(buf, w.Offset)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err := fn(w, buf[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hash 按偏移顺序将各窗口内容写入 h，文件不大于 Total() 时等同于完整文件的哈希
+func (p Params) Hash(r io.ReaderAt, size int64, h hash.Hash) error {
+	return p.Read(r, size, func(_ Window, data []byte) error {
+		_, err := h.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,74 @@
+package sampling
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestParams_PlanDeterministic(t *testing.T) {
+	p := Params{HeadSize: 100, TailSize: 100, Windows: 4, WindowSize: 10, Seed: 7}
+	const size = 10000
+
+	w1, w2 := p.Plan(size), p.Plan(size)
+	if !reflect.DeepEqual(w1, w2) {
+		t.Fatalf("相同参数的窗口不一致: %v != %v", w1, w2)
+	}
+	if w1[0] != (Window{0, 100}) || w1[len(w1)-1] != (Window{size - 100, 100}) {
+		t.Errorf("缺少头尾窗口: %v", w1)
+	}
+	for i := 1; i < len(w1); i++ {
+		if w1[i].Offset < w1[i-1].Offset+w1[i-1].Length {
+			t.Errorf("窗口重叠或无序: %v", w1)
+		}
+	}
+
+	p.Seed = 8
+	if reflect.DeepEqual(w1, p.Plan(size)) {
+		t.Errorf("不同种子应得到不同窗口: %v", w1)
+	}
+}
+
+func TestParams_PlanSmallFile(t *testing.T) {
+	p := DefaultParams()
+	if got := p.Plan(p.Total()); !reflect.DeepEqual(got, []Window{{0, p.Total()}}) {
+		t.Errorf("小文件应完整读取: %v", got)
+	}
+	if got := p.Plan(0); got != nil {
+		t.Errorf("空文件 Plan() = %v", got)
+	}
+}
+
+func TestParams_Hash(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	// 不超过采样总量时等同完整哈希
+	p := Params{HeadSize: 8000, TailSize: 2000}
+	h := md5.New()
+	if err := p.Hash(bytes.NewReader(data), int64(len(data)), h); err != nil {
+		t.Fatal(err)
+	}
+	full := md5.Sum(data)
+	if hex.EncodeToString(h.Sum(nil)) != hex.EncodeToString(full[:]) {
+		t.Error("小文件采样哈希应等于完整哈希")
+	}
+
+	// 超过时只读取采样窗口
+	p = Params{HeadSize: 10, TailSize: 10, Windows: 2, WindowSize: 5}
+	var read int64
+	p.Read(bytes.NewReader(data), int64(len(data)), func(w Window, b []byte) error {
+		read += int64(len(b))
+		return nil
+	})
+	if read > p.Total() || read < 20 {
+		t.Errorf("读取 %d 字节, 采样上限 %d", read, p.Total())
+	}
+}
+
+func TestParams_String(t *testing.T) {
+	if got := DefaultParams().String(); got != "head=1048576,tail=1048576,windows=16x65536,seed=0" {
+		t.Errorf("String() = %q", got)
+	}
+}
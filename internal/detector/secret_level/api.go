@@ -10,12 +10,18 @@ type Detector interface {
 	// DetectFile 检测单个文件
 	// 返回通用中间结果，由 Manager 组装成 AlertRecord
 	DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error)
+
+	// DetectBytes 检测内存中的内容 (网络载荷、剪贴板、解密缓冲区等)
+	// name 为原始文件名，用于按扩展名区分格式，可为空
+	DetectBytes(ctx context.Context, name string, data []byte) (*model.SubDetectResult, error)
 }
 
 // Config 组件配置
 type Config struct {
 	EnableOCR      bool 
 	OCRMaxFileSize int64 
+	// 大文件兜底扫描的采样参数，nil 使用默认参数
+	Sampling *model.SamplingParams
 }
 
 // NewDetector 创建实例
@@ -31,5 +31,6 @@ type ScanResult struct {
 	IsSecret    bool        `json:"is_secret"`
 	Level       SecretLevel `json:"level"`
 	MatchedText string      `json:"matched_text"`
-	FilePath    string      `json:"file_path"` // 解析器有时会填充这个字段
+	FilePath    string      `json:"file_path"`          // 解析器有时会填充这个字段
+	Sampling    string      `json:"sampling,omitempty"` // 大文件采样扫描时的采样参数，便于复现
 }
@@ -3,7 +3,10 @@ package parser
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+
+	"linuxFileWatcher/internal/detector/sampling"
 	"linuxFileWatcher/internal/detector/secret_level/model"
 )
 
@@ -35,31 +38,45 @@ var (
 )
 
 // BinaryScanner 兜底扫描器
-type BinaryScanner struct{}
+// 不超过采样总量的内容完整扫描，更大的文件只扫描采样窗口 (头、尾与中间伪随机窗口)
+type BinaryScanner struct {
+	sampling sampling.Params
+}
 
-func NewBinaryScanner() *BinaryScanner {
-	return &BinaryScanner{}
+func NewBinaryScanner(p sampling.Params) *BinaryScanner {
+	return &BinaryScanner{sampling: p}
 }
 
-func (s *BinaryScanner) Detect(ctx context.Context, reader io.ReaderAt, size int64) (*model.ScanResult, error) {
-	// 策略: 只扫描前 1MB
-	const scanLimit = 1024 * 1024
-	readSize := size
-	if readSize > scanLimit {
-		readSize = scanLimit
-	}
+// errFound 命中后停止读取后续窗口
+var errFound = errors.New("found")
 
-	buf := make([]byte, readSize)
-	if _, err := reader.ReadAt(buf, 0); err != nil && err != io.EOF {
+func (s *BinaryScanner) Detect(ctx context.Context, reader io.ReaderAt, size int64) (*model.ScanResult, error) {
+	var result *model.ScanResult
+	err := s.sampling.Read(reader, size, func(_ sampling.Window, buf []byte) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if result, _ = scanBuffer(buf); result.IsSecret {
+			return errFound
+		}
+		return nil
+	})
+	if err != nil && err != errFound {
 		return nil, err
 	}
-
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	default:
+	if result == nil {
+		result = &model.ScanResult{IsSecret: false}
+	}
+	if size > s.sampling.Total() {
+		result.Sampling = s.sampling.String()
 	}
-	
+	return result, nil
+}
+
+// scanBuffer 在单个缓冲区中匹配密级关键词
+func scanBuffer(buf []byte) (*model.ScanResult, error) {
 	// 为了支持不区分大小写的 RTF 匹配，可以将 buffer 转小写后再匹配 RTF 关键字
 	// 但这会消耗一次内存拷贝。考虑到 RTF 关键字本身很长，直接匹配字节序列性能更好。
 	// 这里简单起见，直接字节匹配。
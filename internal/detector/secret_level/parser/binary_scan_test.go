@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"linuxFileWatcher/internal/detector/sampling"
+	"linuxFileWatcher/internal/detector/secret_level/model"
+)
+
+func TestBinaryScanner_Sampling(t *testing.T) {
+	p := sampling.Params{HeadSize: 64, TailSize: 64, Windows: 2, WindowSize: 16, Seed: 1}
+	s := NewBinaryScanner(p)
+
+	// 标志位于文件尾，采样窗口覆盖
+	data := append(bytes.Repeat([]byte{0}, 4096), []byte("绝密★启用前")...)
+	res, err := s.Detect(context.Background(), bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsSecret || res.Level != model.LevelTopSecret || res.Sampling != p.String() {
+		t.Errorf("尾部标志: %+v", res)
+	}
+
+	// 标志位于未采样的中间区域
+	data = bytes.Repeat([]byte{0}, 4096)
+	copy(data[2000:], "绝密")
+	for _, w := range p.Plan(int64(len(data))) {
+		if w.Offset <= 2000 && 2000 < w.Offset+w.Length {
+			t.Skip("标志恰好落在采样窗口内")
+		}
+	}
+	if res, _ := s.Detect(context.Background(), bytes.NewReader(data), int64(len(data))); res.IsSecret {
+		t.Errorf("未采样区域不应命中: %+v", res)
+	}
+
+	// 小文件完整扫描，不记录采样参数
+	data = []byte("机密文件")
+	if res, _ := s.Detect(context.Background(), bytes.NewReader(data), int64(len(data))); !res.IsSecret || res.Sampling != "" {
+		t.Errorf("小文件: %+v", res)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/otiai10/gosseract/v2"
+	"linuxFileWatcher/internal/budget"
 	"linuxFileWatcher/internal/detector/secret_level/engine"
 	"linuxFileWatcher/internal/detector/secret_level/model"
 
@@ -22,6 +23,9 @@ import (
 	_ "golang.org/x/image/webp"
 )
 
+// ocrMemoryFactor 图片解码与识别的内存占用相对文件大小的估算倍数
+const ocrMemoryFactor = 8
+
 // ImageScanner 使用 Tesseract OCR 进行识别
 type ImageScanner struct{}
 
@@ -39,6 +43,13 @@ func (s *ImageScanner) Detect(ctx context.Context, reader io.ReaderAt, size int6
 		return nil, nil // 返回 nil 表示不处理，worker 会决定是否 fallback
 	}
 
+	// 解码后的位图通常是文件大小的数倍，按全局 OCR 并发与内存预算排队
+	ctx, release, err := budget.Default().Acquire(ctx, budget.ClassOCR, size*ocrMemoryFactor)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	buf := make([]byte, size)
 	if _, err := reader.ReadAt(buf, 0); err != nil && err != io.EOF {
 		return nil, err
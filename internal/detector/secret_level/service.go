@@ -1,6 +1,7 @@
 package secret_level
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -11,9 +12,12 @@ import (
 
 	globalModel "linuxFileWatcher/internal/model" // 引用全局 model
 
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/detector/sampling"
 	"linuxFileWatcher/internal/detector/secret_level/format"
 	"linuxFileWatcher/internal/detector/secret_level/model"
 	"linuxFileWatcher/internal/detector/secret_level/parser"
+	"linuxFileWatcher/internal/i18n"
 )
 
 type service struct {
@@ -36,7 +40,7 @@ func newService(cfg Config) *service {
 		ofdScanner:    parser.NewOFDScanner(),
 		pdfScanner:    parser.NewPDFScanner(),
 		textScanner:   parser.NewTextScanner(),
-		binaryScanner: parser.NewBinaryScanner(),
+		binaryScanner: parser.NewBinaryScanner(sampling.FromPolicy(cfg.Sampling)),
 		imageScanner:  parser.NewImageScanner(),
 	}
 }
@@ -53,15 +57,28 @@ func (s *service) DetectFile(ctx context.Context, path string) (*globalModel.Sub
 	if err != nil {
 		return nil, err
 	}
-	size := stat.Size()
+	return s.detect(ctx, f, stat.Size(), path)
+}
+
+// DetectBytes 检测内存中的内容，name 仅用于按扩展名区分格式
+func (s *service) DetectBytes(ctx context.Context, name string, data []byte) (*globalModel.SubDetectResult, error) {
+	return s.detect(ctx, bytes.NewReader(data), int64(len(data)), name)
+}
 
+// DetectDocument 检测流水线共享的文档，直接使用已读入内存的内容，不再重新打开文件
+func (s *service) DetectDocument(ctx context.Context, doc *document.Document) (*globalModel.SubDetectResult, error) {
+	return s.detect(ctx, bytes.NewReader(doc.Data()), doc.Size, doc.Name)
+}
+
+// detect 检测实现，各解析器均基于 io.ReaderAt，文件与内存内容共用
+func (s *service) detect(ctx context.Context, f io.ReaderAt, size int64, name string) (*globalModel.SubDetectResult, error) {
 	// 1. 识别格式
 	header := make([]byte, 261)
 	if _, err := f.ReadAt(header, 0); err != nil && err != io.EOF {
 		return nil, err
 	}
 	fileType := format.IdentifyType(header)
-	ext := strings.ToLower(filepath.Ext(path))
+	ext := strings.ToLower(filepath.Ext(name))
 
 	// 2. 超时控制
 	var scanCtx context.Context
@@ -145,13 +162,17 @@ func (s *service) DetectFile(ctx context.Context, path string) (*globalModel.Sub
 			globalLevel = globalModel.LevelInternal
 		}
 
-		return &globalModel.SubDetectResult{
+		res := &globalModel.SubDetectResult{
 			IsSecret:    true,
 			SecretLevel: globalLevel,
-			RuleDesc:    "密级标志检测命中: " + rawResult.MatchedText,
+			RuleDesc:    i18n.T("detect.secret_level.hit", rawResult.MatchedText),
 			MatchedText: rawResult.MatchedText,
 			AlertType:   2, // 假设 2 代表密级标志告警
-		}, nil
+		}
+		if rawResult.Sampling != "" {
+			res.ContextText = i18n.T("detect.sampled", rawResult.Sampling)
+		}
+		return res, nil
 	}
 
 	return nil, nil
@@ -0,0 +1,57 @@
+// Package wasmrule WASM 脚本规则
+// 复杂的自定义内容规则以 WASM 模块形式随检测策略 (模块 wasm_rule_detect) 下发，
+// 每次检测在独立的沙箱实例中执行：模块不允许导入任何宿主函数 (无文件、网络、时钟访问)，
+// 线性内存与执行时间受 Limits 约束，超时后实例被强制终止。
+//
+// 模块需导出:
+//
+//	memory                               线性内存
+//	alloc(size i32) -> i32               分配 size 字节，返回偏移
+//	match(ptr i32, len i32) -> i64       输入为 [ptr, ptr+len) 处的 JSON，
+//	                                     返回值高 32 位为输出 JSON 的偏移，低 32 位为长度，0 表示未命中
+//
+// 输入:
+//
+//	{"text":"提取的文本","metadata":{"path":"/home/u/a.docx","name":"a.docx","ext":".docx","size":1024,
+//	 "rule_id":9001,"extended_fields":{...}}}
+//
+// 输出:
+//
+//	{"matched":true,"secret_level":2,"rule_desc":"客户规则","matched_text":"...","context_text":"..."}
+//
+// 模块可导出 _initialize (WASI reactor 约定)，实例化后先调用
+package wasmrule
+
+// 导出函数名
+const (
+	ExportMemory = "memory"
+	ExportAlloc  = "alloc"
+	ExportMatch  = "match"
+)
+
+// Input match 输入
+type Input struct {
+	Text     string   `json:"text"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Metadata 文件元数据
+type Metadata struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	Ext  string `json:"ext"`
+	Size int64  `json:"size"`
+	// 当前执行的规则 ID 与策略中的扩展字段，同一模块可按扩展字段参数化
+	RuleID         int64                  `json:"rule_id"`
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// Output match 输出，字段与 model.SubDetectResult 对应
+type Output struct {
+	Matched     bool   `json:"matched"`
+	SecretLevel int    `json:"secret_level"` // model.SecretLevel，为 0 时使用规则的敏感级别
+	RuleDesc    string `json:"rule_desc"`    // 为空时使用规则描述
+	MatchedText string `json:"matched_text"`
+	ContextText string `json:"context_text"`
+	AlertType   int    `json:"alert_type"`
+}
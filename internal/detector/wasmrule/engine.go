@@ -0,0 +1,404 @@
+package wasmrule
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+const (
+	DefaultMemoryLimit = 16 * 1024 * 1024
+	DefaultTimeout     = 2 * time.Second
+	DefaultMaxTextSize = 4 * 1024 * 1024
+
+	// 模块输出 JSON 上限
+	maxOutputSize = 64 * 1024
+	wasmPageSize  = 64 * 1024
+)
+
+// Limits 沙箱资源限制
+type Limits struct {
+	// 单个实例的线性内存上限 (字节)，按 64KB 页向下取整
+	MemoryLimit int64
+	// 单条规则单次执行超时，超时后实例被终止
+	Timeout time.Duration
+	// 传给模块的文本上限 (字节)，超出部分截断
+	MaxTextSize int
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MemoryLimit < wasmPageSize {
+		l.MemoryLimit = DefaultMemoryLimit
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultTimeout
+	}
+	if l.MaxTextSize <= 0 {
+		l.MaxTextSize = DefaultMaxTextSize
+	}
+	return l
+}
+
+// Engine WASM 脚本规则检测器，实现 detector.SubDetector
+// 规则在创建时编译，检测时每条规则实例化独立的模块，实例之间不共享状态
+type Engine struct {
+	limits  Limits
+	runtime wazero.Runtime
+	rules   []*rule
+
+	// 进行中的检测，Close 等待其结束后才释放运行时
+	inflight sync.WaitGroup
+}
+
+// rule 编译后的规则
+type rule struct {
+	cfg      model.WasmRuleDetectRule
+	compiled wazero.CompiledModule
+}
+
+// New 编译策略中的全部规则，任一规则无效时返回错误
+func New(ctx context.Context, cfg *model.WasmRuleDetectConfig, limits Limits) (*Engine, error) {
+	limits = limits.withDefaults()
+	runtimeCfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(limits.MemoryLimit / wasmPageSize)).
+		WithCloseOnContextDone(true)
+
+	e := &Engine{
+		limits:  limits,
+		runtime: wazero.NewRuntimeWithConfig(ctx, runtimeCfg),
+	}
+	if cfg == nil {
+		return e, nil
+	}
+
+	seen := make(map[int64]bool, len(cfg.Rules))
+	for _, rc := range cfg.Rules {
+		if seen[rc.RuleID] {
+			e.Close(ctx)
+			return nil, fmt.Errorf("wasm rule %d: duplicate rule id", rc.RuleID)
+		}
+		seen[rc.RuleID] = true
+
+		compiled, err := e.compile(ctx, rc)
+		if err != nil {
+			e.Close(ctx)
+			return nil, fmt.Errorf("wasm rule %d: %w", rc.RuleID, err)
+		}
+		e.rules = append(e.rules, &rule{cfg: rc, compiled: compiled})
+	}
+	return e, nil
+}
+
+// compile 解码、校验并编译规则模块
+func (e *Engine) compile(ctx context.Context, rc model.WasmRuleDetectRule) (wazero.CompiledModule, error) {
+	bin, err := base64.StdEncoding.DecodeString(rc.RuleContent)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 content: %w", err)
+	}
+	if rc.RuleHash != "" {
+		sum := sha256.Sum256(bin)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), rc.RuleHash) {
+			return nil, errors.New("sha256 mismatch")
+		}
+	}
+
+	compiled, err := e.runtime.CompileModule(ctx, bin)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExports(compiled); err != nil {
+		compiled.Close(ctx)
+		return nil, err
+	}
+	return compiled, nil
+}
+
+// checkExports 校验模块只依赖 ABI 约定的导出，不导入任何宿主函数或内存
+func checkExports(compiled wazero.CompiledModule) error {
+	if n := len(compiled.ImportedFunctions()); n > 0 {
+		return fmt.Errorf("module imports %d host functions, imports are not allowed", n)
+	}
+	if len(compiled.ImportedMemories()) > 0 {
+		return errors.New("module imports memory, imports are not allowed")
+	}
+	if _, ok := compiled.ExportedMemories()[ExportMemory]; !ok {
+		return fmt.Errorf("missing export %q", ExportMemory)
+	}
+
+	want := map[string][2][]api.ValueType{
+		ExportAlloc: {{api.ValueTypeI32}, {api.ValueTypeI32}},
+		ExportMatch: {{api.ValueTypeI32, api.ValueTypeI32}, {api.ValueTypeI64}},
+	}
+	exports := compiled.ExportedFunctions()
+	for name, sig := range want {
+		fn, ok := exports[name]
+		if !ok {
+			return fmt.Errorf("missing export %q", name)
+		}
+		if !equalTypes(fn.ParamTypes(), sig[0]) || !equalTypes(fn.ResultTypes(), sig[1]) {
+			return fmt.Errorf("export %q has wrong signature", name)
+		}
+	}
+	return nil
+}
+
+func equalTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Len 规则数量
+func (e *Engine) Len() int {
+	return len(e.rules)
+}
+
+// Acquire 登记一次进行中的检测，检测结束后调用 Release
+// 须在规则仍生效时调用 (检测管理器在读锁内取规则快照时登记)，Close 等待全部登记的检测结束
+func (e *Engine) Acquire() {
+	e.inflight.Add(1)
+}
+
+// Release 结束 Acquire 登记的检测
+func (e *Engine) Release() {
+	e.inflight.Done()
+}
+
+// DetectFile 读取文件前 MaxTextSize 字节按纯文本执行规则，首个命中即返回
+// 检测流水线中可整体读入的文件走 DetectDocument (共享抽取)，超出读入上限的大文件只检测开头的文本内容
+func (e *Engine) DetectFile(ctx context.Context, filePath string) (*model.SubDetectResult, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有规则接受该文件大小时不读取内容
+	if !e.acceptsSize(info.Size()) {
+		return &model.SubDetectResult{}, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(e.limits.MaxTextSize)))
+	if err != nil {
+		return nil, err
+	}
+	text, ok := document.PlainText(data)
+	if !ok {
+		return &model.SubDetectResult{}, nil
+	}
+	return e.DetectText(ctx, text, Metadata{
+		Path: filePath,
+		Name: info.Name(),
+		Ext:  strings.ToLower(filepath.Ext(filePath)),
+		Size: info.Size(),
+	})
+}
+
+// DetectDocument 使用检测流水线共享抽取的文本执行规则，共享抽取不支持的格式按纯文本处理
+func (e *Engine) DetectDocument(ctx context.Context, doc *document.Document) (*model.SubDetectResult, error) {
+	if !e.acceptsSize(doc.Size) {
+		return &model.SubDetectResult{}, nil
+	}
+
+	var text string
+	content, err := doc.Content(ctx)
+	switch {
+	case err == nil:
+		text = content.Text
+	case errors.Is(err, document.ErrUnsupported):
+		var ok bool
+		if text, ok = document.PlainText(doc.Data()); !ok {
+			return &model.SubDetectResult{}, nil
+		}
+	default:
+		return nil, err
+	}
+	return e.DetectText(ctx, text, Metadata{
+		Path: doc.Path,
+		Name: doc.Name,
+		Ext:  strings.ToLower(filepath.Ext(doc.Name)),
+		Size: doc.Size,
+	})
+}
+
+// DetectText 对已提取的文本执行规则，meta 中的 RuleID 与 ExtendedFields 由各规则填充
+// 单条规则执行失败 (超时、超出内存、输出无效) 时记录日志并继续下一条
+func (e *Engine) DetectText(ctx context.Context, text string, meta Metadata) (*model.SubDetectResult, error) {
+	textJSON, err := json.Marshal(truncate(text, e.limits.MaxTextSize))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range e.rules {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !r.accepts(meta.Size) {
+			continue
+		}
+
+		meta.RuleID = r.cfg.RuleID
+		meta.ExtendedFields = r.cfg.ExtendedFields
+		metaJSON, err := json.Marshal(meta)
+		if err != nil {
+			return nil, err
+		}
+		input := make([]byte, 0, len(textJSON)+len(metaJSON)+24)
+		input = append(input, `{"text":`...)
+		input = append(input, textJSON...)
+		input = append(input, `,"metadata":`...)
+		input = append(input, metaJSON...)
+		input = append(input, '}')
+
+		out, err := e.run(ctx, r, input)
+		if err != nil {
+			logger.Warn("WASM 规则执行失败", "rule_id", r.cfg.RuleID, "path", meta.Path, "error", err)
+			continue
+		}
+		if !out.Matched {
+			continue
+		}
+
+		res := &model.SubDetectResult{
+			IsSecret:    true,
+			SecretLevel: model.SecretLevel(out.SecretLevel),
+			RuleID:      r.cfg.RuleID,
+			RuleDesc:    out.RuleDesc,
+			MatchedText: out.MatchedText,
+			ContextText: out.ContextText,
+			AlertType:   out.AlertType,
+		}
+		if res.SecretLevel == 0 {
+			res.SecretLevel = model.SecretLevel(r.cfg.SensitivityLevel)
+		}
+		if res.RuleDesc == "" {
+			res.RuleDesc = r.cfg.RuleDesc
+		}
+		return res, nil
+	}
+	return &model.SubDetectResult{}, nil
+}
+
+// run 在新实例中执行单条规则，实例在返回前销毁
+func (e *Engine) run(ctx context.Context, r *rule, input []byte) (*Output, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.limits.Timeout)
+	defer cancel()
+
+	mod, err := e.runtime.InstantiateModule(ctx, r.compiled,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, interrupted(ctx, err)
+	}
+	defer mod.Close(context.Background())
+
+	mem := mod.ExportedMemory(ExportMemory)
+	res, err := mod.ExportedFunction(ExportAlloc).Call(ctx, uint64(len(input)))
+	if err != nil {
+		return nil, interrupted(ctx, err)
+	}
+	ptr := uint32(res[0])
+	if !mem.Write(ptr, input) {
+		return nil, fmt.Errorf("alloc returned out of range offset %d", ptr)
+	}
+
+	res, err = mod.ExportedFunction(ExportMatch).Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, interrupted(ctx, err)
+	}
+	if res[0] == 0 {
+		return &Output{}, nil
+	}
+
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	if outLen > maxOutputSize {
+		return nil, fmt.Errorf("output too large: %d bytes", outLen)
+	}
+	data, ok := mem.Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("output out of range: offset %d, length %d", outPtr, outLen)
+	}
+	var out Output
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	return &out, nil
+}
+
+// interrupted 超时或取消导致的实例终止返回 ctx 的错误
+func interrupted(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// acceptsSize 是否有规则接受该文件大小
+func (e *Engine) acceptsSize(size int64) bool {
+	for _, r := range e.rules {
+		if r.accepts(size) {
+			return true
+		}
+	}
+	return false
+}
+
+// accepts 文件大小是否满足规则的过滤条件 (单位 KB，0 表示不限)
+func (r *rule) accepts(size int64) bool {
+	f := r.cfg.FilterFileSize
+	if f == nil {
+		return true
+	}
+	kb := size / 1024
+	if f.MinSize > 0 && kb < int64(f.MinSize) {
+		return false
+	}
+	if f.MaxSize > 0 && kb > int64(f.MaxSize) {
+		return false
+	}
+	return true
+}
+
+// truncate 按字节截断，不拆分 UTF-8 字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Close 等待 Acquire 登记的检测结束后释放编译缓存
+func (e *Engine) Close(ctx context.Context) error {
+	e.inflight.Wait()
+	return e.runtime.Close(ctx)
+}
@@ -0,0 +1,187 @@
+package wasmrule
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/detector/document"
+	"linuxFileWatcher/internal/model"
+)
+
+// 手工汇编的测试模块，均导出 memory、alloc (bump 分配) 与 match
+const (
+	// match 返回 {"matched":true,"secret_level":2,"matched_text":"token"}
+	wasmHit = "0061736d01000000010f0360017f017f60027f7f017e600000030302000105030100010607017f014180080b071a03066d656d6f7279020005616c6c6f630000056d6174636800010a17020b002300230020006a24000b090042b880808080020b0b3e010041100b387b226d617463686564223a747275652c227365637265745f6c6576656c223a322c226d6174636865645f74657874223a22746f6b656e227d"
+	// match 返回 {"matched":false}
+	wasmMiss = "0061736d01000000010f0360017f017f60027f7f017e600000030302000105030100010607017f014180080b071a03066d656d6f7279020005616c6c6f630000056d6174636800010a17020b002300230020006a24000b0900429180808080020b0b17010041100b117b226d617463686564223a66616c73657d"
+	// match 死循环
+	wasmLoop = "0061736d01000000010f0360017f017f60027f7f017e600000030302000105030100010607017f014180080b071a03066d656d6f7279020005616c6c6f630000056d6174636800010a17020b002300230020006a24000b090003400c000b42000b"
+	// 导入 env.f
+	wasmImport = "0061736d01000000010f0360017f017f60027f7f017e60000002090103656e7601660002030302000105030100010607017f014180080b071a03066d656d6f7279020005616c6c6f630001056d6174636800020a17020b002300230020006a24000b0900428280808080020b0b08010041100b027b7d"
+)
+
+func wasmRule(t *testing.T, id int64, hexModule string) model.WasmRuleDetectRule {
+	t.Helper()
+	bin, err := hex.DecodeString(hexModule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := model.NewWasmRuleDetectRule(id, base64.StdEncoding.EncodeToString(bin), 3)
+	r.RuleDesc = "wasm rule"
+	return *r
+}
+
+func newEngine(t *testing.T, limits Limits, rules ...model.WasmRuleDetectRule) *Engine {
+	t.Helper()
+	e, err := New(context.Background(), &model.WasmRuleDetectConfig{Rules: rules}, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close(context.Background()) })
+	return e
+}
+
+func TestEngine_DetectText(t *testing.T) {
+	e := newEngine(t, Limits{}, wasmRule(t, 1, wasmMiss), wasmRule(t, 2, wasmHit))
+
+	res, err := e.DetectText(context.Background(), "some text", Metadata{Name: "a.txt", Size: 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsSecret || res.RuleID != 2 || res.SecretLevel != 2 || res.MatchedText != "token" || res.RuleDesc != "wasm rule" {
+		t.Errorf("DetectText() = %+v", res)
+	}
+
+	e = newEngine(t, Limits{}, wasmRule(t, 1, wasmMiss))
+	if res, err := e.DetectText(context.Background(), "x", Metadata{}); err != nil || res.IsSecret {
+		t.Errorf("DetectText(miss) = %+v, %v", res, err)
+	}
+}
+
+func TestEngine_Timeout(t *testing.T) {
+	e := newEngine(t, Limits{Timeout: 100 * time.Millisecond}, wasmRule(t, 1, wasmLoop), wasmRule(t, 2, wasmHit))
+
+	start := time.Now()
+	if _, err := e.run(context.Background(), e.rules[0], []byte(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("死循环应超时, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("超时未及时中止实例")
+	}
+
+	// 超时的规则跳过，后续规则照常执行
+	res, err := e.DetectText(context.Background(), "x", Metadata{})
+	if err != nil || !res.IsSecret || res.RuleID != 2 {
+		t.Errorf("DetectText() = %+v, %v", res, err)
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	hit := wasmRule(t, 1, wasmHit)
+
+	badHash := hit
+	badHash.RuleHash = "00"
+	goodHash := hit
+	bin, _ := hex.DecodeString(wasmHit)
+	sum := sha256.Sum256(bin)
+	goodHash.RuleHash = hex.EncodeToString(sum[:])
+
+	cases := []struct {
+		name  string
+		rules []model.WasmRuleDetectRule
+		ok    bool
+	}{
+		{"valid", []model.WasmRuleDetectRule{goodHash}, true},
+		{"imports", []model.WasmRuleDetectRule{wasmRule(t, 1, wasmImport)}, false},
+		{"hash mismatch", []model.WasmRuleDetectRule{badHash}, false},
+		{"not base64", []model.WasmRuleDetectRule{{RuleID: 1, RuleContent: "!"}}, false},
+		{"not wasm", []model.WasmRuleDetectRule{{RuleID: 1, RuleContent: "AAAA"}}, false},
+		{"duplicate id", []model.WasmRuleDetectRule{hit, hit}, false},
+	}
+	for _, tc := range cases {
+		e, err := New(context.Background(), &model.WasmRuleDetectConfig{Rules: tc.rules}, Limits{})
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: New() error = %v", tc.name, err)
+		}
+		if e != nil {
+			e.Close(context.Background())
+		}
+	}
+}
+
+func TestEngine_DetectFileSizeFilter(t *testing.T) {
+	r := wasmRule(t, 1, wasmHit)
+	r.FilterFileSize = model.NewFilterFileSize(1, 0)
+	e := newEngine(t, Limits{}, r)
+
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	os.WriteFile(small, []byte("x"), 0644)
+	if res, err := e.DetectFile(context.Background(), small); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(small) = %+v, %v", res, err)
+	}
+
+	large := filepath.Join(dir, "large.txt")
+	os.WriteFile(large, []byte(strings.Repeat("a", 2048)), 0644)
+	if res, err := e.DetectFile(context.Background(), large); err != nil || !res.IsSecret {
+		t.Errorf("DetectFile(large) = %+v, %v", res, err)
+	}
+
+	// 非文本内容不执行规则
+	binary := filepath.Join(dir, "large.bin")
+	os.WriteFile(binary, make([]byte, 2048), 0644)
+	if res, err := e.DetectFile(context.Background(), binary); err != nil || res.IsSecret {
+		t.Errorf("DetectFile(binary) = %+v, %v", res, err)
+	}
+}
+
+func TestEngine_DetectDocumentPlainText(t *testing.T) {
+	e := newEngine(t, Limits{}, wasmRule(t, 1, wasmHit))
+
+	// 未配置抽取器时按纯文本处理
+	doc := document.FromBytes("notes.txt", []byte("机密"), nil)
+	if res, err := e.DetectDocument(context.Background(), doc); err != nil || !res.IsSecret {
+		t.Errorf("DetectDocument() = %+v, %v", res, err)
+	}
+}
+
+func TestEngine_CloseWaitsForInflight(t *testing.T) {
+	e, err := New(context.Background(), &model.WasmRuleDetectConfig{Rules: []model.WasmRuleDetectRule{wasmRule(t, 1, wasmHit)}}, Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Acquire()
+	closed := make(chan struct{})
+	go func() {
+		e.Close(context.Background())
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close() 未等待进行中的检测")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if res, err := e.DetectText(context.Background(), "text", Metadata{}); err != nil || !res.IsSecret {
+		t.Errorf("DetectText() during Close = %+v, %v", res, err)
+	}
+	e.Release()
+	<-closed
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("密级abc", 4); got != "密" {
+		t.Errorf("truncate() = %q", got)
+	}
+	if got := truncate("abc", 10); got != "abc" {
+		t.Errorf("truncate() = %q", got)
+	}
+}
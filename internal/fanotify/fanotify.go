@@ -0,0 +1,24 @@
+// Package fanotify 基于 fanotify 的文件事件监听
+// 与 inotify 不同，fanotify 事件携带发起进程的 PID，可用于告警的进程溯源
+package fanotify
+
+import "errors"
+
+// 事件掩码 (linux/fanotify.h)
+const (
+	EventAccess     uint64 = 0x00000001 // FAN_ACCESS
+	EventModify     uint64 = 0x00000002 // FAN_MODIFY
+	EventCloseWrite uint64 = 0x00000008 // FAN_CLOSE_WRITE
+	EventOpen       uint64 = 0x00000020 // FAN_OPEN
+	EventOnChild    uint64 = 0x08000000 // FAN_EVENT_ON_CHILD
+)
+
+// Event 文件事件
+type Event struct {
+	Path string // 文件路径
+	PID  int    // 发起进程 PID
+	Mask uint64 // 事件掩码
+}
+
+// ErrUnsupported 当前平台不支持 fanotify
+var ErrUnsupported = errors.New("fanotify is not supported on this platform")
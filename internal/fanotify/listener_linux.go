@@ -0,0 +1,132 @@
+//go:build linux && (amd64 || arm64)
+
+package fanotify
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// fanotify 常量 (linux/fanotify.h)
+const (
+	fanCloexec       = 0x00000001
+	fanNonblock      = 0x00000002
+	fanClassNotif    = 0x00000000
+	fanMarkAdd       = 0x00000001
+	fanMarkMount     = 0x00000010
+	metadataVersion  = 3
+	metadataSize     = 24 // sizeof(struct fanotify_event_metadata)
+	readBufferSize   = 64 * 1024
+	openFlagsLargeRO = syscall.O_RDONLY | syscall.O_LARGEFILE | syscall.O_CLOEXEC
+)
+
+// Listener fanotify 监听器 (需要 CAP_SYS_ADMIN)
+type Listener struct {
+	file   *os.File
+	selfID int
+}
+
+// NewListener 创建监听器
+func NewListener() (*Listener, error) {
+	fd, _, errno := syscall.Syscall(syscall.SYS_FANOTIFY_INIT,
+		uintptr(fanCloexec|fanNonblock|fanClassNotif), uintptr(openFlagsLargeRO), 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("fanotify_init failed: %w", errno)
+	}
+	// 非阻塞 fd 交给 Go 运行时轮询，Close 时可唤醒阻塞中的 Read
+	return &Listener{file: os.NewFile(fd, "fanotify"), selfID: os.Getpid()}, nil
+}
+
+// AddMount 监听整个挂载点上的写入事件
+func (l *Listener) AddMount(path string, mask uint64) error {
+	return l.mark(fanMarkAdd|fanMarkMount, mask, path)
+}
+
+// AddPath 监听单个目录 (含直接子文件) 的写入事件
+func (l *Listener) AddPath(path string, mask uint64) error {
+	return l.mark(fanMarkAdd, mask|EventOnChild, path)
+}
+
+// AddFile 监听单个文件的事件，如 EventOpen 时文件被任意进程打开即产生事件
+func (l *Listener) AddFile(path string, mask uint64) error {
+	return l.mark(fanMarkAdd, mask, path)
+}
+
+func (l *Listener) mark(flags uint, mask uint64, path string) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	atFDCWD := -100
+	_, _, errno := syscall.Syscall6(syscall.SYS_FANOTIFY_MARK,
+		l.file.Fd(), uintptr(flags), uintptr(mask), uintptr(atFDCWD), uintptr(unsafe.Pointer(p)), 0)
+	if errno != 0 {
+		return fmt.Errorf("fanotify_mark %s failed: %w", path, errno)
+	}
+	return nil
+}
+
+// Run 读取事件并回调，直到 ctx 取消或监听器关闭
+// 本进程自身产生的事件会被忽略，避免扫描读写形成循环
+func (l *Listener) Run(ctx context.Context, handler func(Event)) error {
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	buf := make([]byte, readBufferSize)
+	for {
+		n, err := l.file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("read fanotify events failed: %w", err)
+		}
+
+		for _, ev := range l.parse(buf[:n]) {
+			handler(ev)
+		}
+	}
+}
+
+// parse 解析事件并关闭事件携带的文件描述符
+func (l *Listener) parse(data []byte) []Event {
+	var events []Event
+	for len(data) >= metadataSize {
+		eventLen := binary.LittleEndian.Uint32(data[0:4])
+		version := data[4]
+		mask := binary.LittleEndian.Uint64(data[8:16])
+		fd := int32(binary.LittleEndian.Uint32(data[16:20]))
+		pid := int32(binary.LittleEndian.Uint32(data[20:24]))
+
+		if eventLen < metadataSize || int(eventLen) > len(data) || version != metadataVersion {
+			break
+		}
+		data = data[eventLen:]
+
+		if fd < 0 {
+			// 队列溢出等事件不携带文件
+			continue
+		}
+		path, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(int(fd)))
+		syscall.Close(int(fd))
+		if err != nil || int(pid) == l.selfID {
+			continue
+		}
+
+		events = append(events, Event{Path: path, PID: int(pid), Mask: mask})
+	}
+	return events
+}
+
+// Close 关闭监听器
+func (l *Listener) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,85 @@
+//go:build linux && (amd64 || arm64)
+
+package fanotify
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListener_CloseWrite(t *testing.T) {
+	l, err := NewListener()
+	if err != nil {
+		t.Skipf("fanotify 不可用 (需要 CAP_SYS_ADMIN): %v", err)
+	}
+	defer l.Close()
+
+	dir := t.TempDir()
+	if err := l.AddPath(dir, EventCloseWrite); err != nil {
+		t.Skipf("fanotify_mark 失败: %v", err)
+	}
+
+	events := make(chan Event, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx, func(ev Event) { events <- ev })
+
+	// 由子进程写入，本进程自身的事件会被忽略
+	target := filepath.Join(dir, "a.txt")
+	cmd := exec.Command("sh", "-c", "echo x > "+target)
+	if err := cmd.Run(); err != nil {
+		t.Skipf("无法执行 sh: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Path != target {
+			t.Errorf("Path = %q, want %q", ev.Path, target)
+		}
+		if ev.PID <= 0 || ev.PID == os.Getpid() {
+			t.Errorf("PID = %d", ev.PID)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到 fanotify 事件")
+	}
+}
+
+func TestListener_OpenFile(t *testing.T) {
+	l, err := NewListener()
+	if err != nil {
+		t.Skipf("fanotify 不可用 (需要 CAP_SYS_ADMIN): %v", err)
+	}
+	defer l.Close()
+
+	target := filepath.Join(t.TempDir(), "decoy.txt")
+	if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(target, EventOpen); err != nil {
+		t.Skipf("fanotify_mark 失败: %v", err)
+	}
+
+	events := make(chan Event, 8)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx, func(ev Event) { events <- ev })
+
+	// 本进程读取被忽略，子进程读取产生事件
+	os.ReadFile(target)
+	if err := exec.Command("cat", target).Run(); err != nil {
+		t.Skipf("无法执行 cat: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Path != target || ev.Mask&EventOpen == 0 || ev.PID == os.Getpid() {
+			t.Errorf("event = %+v", ev)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到 fanotify 事件")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !(linux && (amd64 || arm64))
+
+package fanotify
+
+import "context"
+
+// Listener 非支持平台的占位实现
+type Listener struct{}
+
+// NewListener 当前平台不支持 fanotify
+func NewListener() (*Listener, error) {
+	return nil, ErrUnsupported
+}
+
+func (l *Listener) AddMount(path string, mask uint64) error { return ErrUnsupported }
+func (l *Listener) AddPath(path string, mask uint64) error  { return ErrUnsupported }
+func (l *Listener) AddFile(path string, mask uint64) error  { return ErrUnsupported }
+func (l *Listener) Run(ctx context.Context, handler func(Event)) error {
+	return ErrUnsupported
+}
+func (l *Listener) Close() error { return nil }
@@ -0,0 +1,38 @@
+//go:build linux
+
+package fastread
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	fadvSequential = 2 // POSIX_FADV_SEQUENTIAL
+	fadvWillNeed   = 3 // POSIX_FADV_WILLNEED
+	oNoAtime       = 0o1000000
+)
+
+// openFile 以 O_NOATIME 打开文件，省去访问时间回写；非属主无权使用时退回普通只读
+// O_NONBLOCK 防止打开管道时阻塞，对普通文件无影响
+func openFile(path string) (*os.File, error) {
+	flags := os.O_RDONLY | syscall.O_CLOEXEC | syscall.O_NONBLOCK
+	fd, err := syscall.Open(path, flags|oNoAtime, 0)
+	if err == syscall.EPERM {
+		fd, err = syscall.Open(path, flags, 0)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(fd), path), nil
+}
+
+// adviseSequential 提示内核顺序读取，加大预读窗口
+func adviseSequential(f *os.File) {
+	fadvise(f, 0, 0, fadvSequential)
+}
+
+// adviseWillNeed 提示内核立即异步预读整个文件
+func adviseWillNeed(f *os.File, size int64) {
+	fadvise(f, 0, size, fadvWillNeed)
+}
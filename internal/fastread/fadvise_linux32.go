@@ -0,0 +1,8 @@
+//go:build linux && (386 || arm || mips || mipsle)
+
+package fastread
+
+import "os"
+
+// 32 位平台 64 位偏移需拆分为寄存器对且各架构顺序不同，不发出预读提示
+func fadvise(f *os.File, offset, length int64, advice int) {}
@@ -0,0 +1,12 @@
+//go:build linux && (amd64 || arm64 || loong64 || riscv64 || ppc64 || ppc64le || s390x || mips64 || mips64le)
+
+package fastread
+
+import (
+	"os"
+	"syscall"
+)
+
+func fadvise(f *os.File, offset, length int64, advice int) {
+	syscall.Syscall6(syscall.SYS_FADVISE64, f.Fd(), uintptr(offset), uintptr(length), uintptr(advice), 0, 0)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package fastread
+
+import "os"
+
+func openFile(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+func adviseSequential(f *os.File) {}
+
+func adviseWillNeed(f *os.File, size int64) {}
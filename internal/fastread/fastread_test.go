@@ -0,0 +1,90 @@
+package fastread
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T) (dir string, want map[string][]byte) {
+	dir = t.TempDir()
+	want = map[string][]byte{
+		"empty.txt": {},
+		"small.txt": []byte("hello"),
+		"large.bin": bytes.Repeat([]byte("0123456789abcdef"), 40000),
+	}
+	for name, data := range want {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, want
+}
+
+func TestReadFile(t *testing.T) {
+	dir, want := writeFiles(t)
+	for name, data := range want {
+		got, err := ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s): %v", name, err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("ReadFile(%s) 内容不一致: %d != %d 字节", name, len(got), len(data))
+		}
+	}
+}
+
+func TestBatchReader(t *testing.T) {
+	dir, want := writeFiles(t)
+	paths := []string{
+		filepath.Join(dir, "small.txt"),
+		filepath.Join(dir, "missing.txt"),
+		filepath.Join(dir, "large.bin"),
+		dir,
+		filepath.Join(dir, "empty.txt"),
+	}
+
+	for _, disable := range []bool{false, true} {
+		// QueueDepth 小于文件数，覆盖分批提交
+		r := NewBatchReader(Options{DisableURing: disable, QueueDepth: 2})
+		t.Logf("读取方式: %s", r.Kind())
+		results := r.ReadFiles(paths)
+		r.Close()
+
+		if len(results) != len(paths) {
+			t.Fatalf("%s: 结果数 %d", r.Kind(), len(results))
+		}
+		for i, res := range results {
+			if res.Path != paths[i] {
+				t.Errorf("%s: 结果顺序错误 %s != %s", r.Kind(), res.Path, paths[i])
+			}
+		}
+		for _, i := range []int{0, 2, 4} {
+			if results[i].Err != nil || !bytes.Equal(results[i].Data, want[filepath.Base(paths[i])]) {
+				t.Errorf("%s: %s 读取错误: %v", r.Kind(), paths[i], results[i].Err)
+			}
+		}
+		if !os.IsNotExist(results[1].Err) {
+			t.Errorf("%s: 不存在的文件应返回 NotExist: %v", r.Kind(), results[1].Err)
+		}
+		if !errors.Is(results[3].Err, ErrNotRegular) {
+			t.Errorf("%s: 目录应返回 ErrNotRegular: %v", r.Kind(), results[3].Err)
+		}
+	}
+}
+
+func TestBatchReader_MaxFileSize(t *testing.T) {
+	dir, _ := writeFiles(t)
+	r := NewBatchReader(Options{MaxFileSize: 1024})
+	defer r.Close()
+
+	results := r.ReadFiles([]string{filepath.Join(dir, "large.bin"), filepath.Join(dir, "small.txt")})
+	if !errors.Is(results[0].Err, ErrTooLarge) {
+		t.Errorf("超限文件应返回 ErrTooLarge: %v", results[0].Err)
+	}
+	if string(results[1].Data) != "hello" {
+		t.Errorf("small.txt = %q", results[1].Data)
+	}
+}
@@ -0,0 +1,323 @@
+//go:build linux
+
+package fastread
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysIOURingSetup = 425
+	sysIOURingEnter = 426
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	ioringOpRead         = 22     // IORING_OP_READ, 5.6+
+	ioringEnterGetEvents = 1 << 0 // IORING_ENTER_GETEVENTS
+	ioringFeatSingleMmap = 1 << 0 // IORING_FEAT_SINGLE_MMAP
+	ioringFeatRWCurPos   = 1 << 3 // 与 IORING_OP_READ 同时引入，用于探测内核版本
+	sqeSize              = 64
+	cqeSize              = 16
+)
+
+type sqringOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type cqringOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  sqringOffsets
+	cqOff                                                                  cqringOffsets
+}
+
+type uringSQE struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	rwFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	addr3       uint64
+	pad         uint64
+}
+
+type uringCQE struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// uringReader 每批打开 QueueDepth 个文件，一次 io_uring_enter 提交全部读请求并等待完成
+type uringReader struct {
+	mu   sync.Mutex // 提交队列不支持并发使用
+	opts Options
+
+	fd      int
+	sqRing  []byte
+	cqRing  []byte
+	sqes    []byte
+	params  uringParams
+	entries uint32
+
+	// 提交失败后不再使用环，退回 fadvise 读取
+	broken   bool
+	fallback adviseReader
+	pinned   [][][]byte
+}
+
+func newURingReader(opts Options) (BatchReader, error) {
+	r := &uringReader{opts: opts, fd: -1, fallback: adviseReader{opts: opts}}
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uintptr(opts.QueueDepth), uintptr(unsafe.Pointer(&r.params)), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	r.fd = int(fd)
+	p := &r.params
+	if p.features&ioringFeatRWCurPos == 0 {
+		r.Close()
+		return nil, errors.New("fastread: kernel lacks IORING_OP_READ")
+	}
+	r.entries = p.sqEntries
+
+	sqSize := int(p.sqOff.array + p.sqEntries*4)
+	cqSize := int(p.cqOff.cqes + p.cqEntries*cqeSize)
+	if p.features&ioringFeatSingleMmap != 0 {
+		sqSize = max(sqSize, cqSize)
+	}
+
+	var err error
+	prot, flags := syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE
+	if r.sqRing, err = syscall.Mmap(r.fd, ioringOffSQRing, sqSize, prot, flags); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if p.features&ioringFeatSingleMmap != 0 {
+		r.cqRing = r.sqRing
+	} else if r.cqRing, err = syscall.Mmap(r.fd, ioringOffCQRing, cqSize, prot, flags); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if r.sqes, err = syscall.Mmap(r.fd, ioringOffSQEs, int(p.sqEntries)*sqeSize, prot, flags); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *uringReader) Kind() string { return "io_uring" }
+
+func (r *uringReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sqes != nil {
+		syscall.Munmap(r.sqes)
+		r.sqes = nil
+	}
+	if r.cqRing != nil && &r.cqRing[0] != &r.sqRing[0] {
+		syscall.Munmap(r.cqRing)
+	}
+	r.cqRing = nil
+	if r.sqRing != nil {
+		syscall.Munmap(r.sqRing)
+		r.sqRing = nil
+	}
+	if r.fd >= 0 {
+		syscall.Close(r.fd)
+		r.fd = -1
+	}
+	return nil
+}
+
+func ringU32(mem []byte, off uint32) *uint32 {
+	return (*uint32)(unsafe.Pointer(&mem[off]))
+}
+
+func (r *uringReader) ReadFiles(paths []string) []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]Result, len(paths))
+	if r.broken {
+		return r.fallback.ReadFiles(paths)
+	}
+	batch := min(r.opts.QueueDepth, int(r.entries))
+	for start := 0; start < len(paths); start += batch {
+		end := min(start+batch, len(paths))
+		if r.broken {
+			copy(results[start:], r.fallback.ReadFiles(paths[start:]))
+			break
+		}
+		r.readBatch(paths[start:end], results[start:end])
+	}
+	return results
+}
+
+// readBatch 打开一批文件并提交读请求
+func (r *uringReader) readBatch(paths []string, results []Result) {
+	files := make([]*os.File, len(paths))
+	bufs := make([][]byte, len(paths))
+	defer func() {
+		for _, f := range files {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}()
+
+	sqOff := &r.params.sqOff
+	mask := *ringU32(r.sqRing, sqOff.ringMask)
+	tail := atomic.LoadUint32(ringU32(r.sqRing, sqOff.tail))
+	submitted := 0
+	for i, path := range paths {
+		results[i].Path = path
+		f, size, err := openRegular(path, r.opts.MaxFileSize)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		files[i] = f
+		if size == 0 {
+			results[i].Data, results[i].Err = readAll(f, 0)
+			continue
+		}
+		bufs[i] = make([]byte, size)
+
+		idx := tail & mask
+		sqe := (*uringSQE)(unsafe.Pointer(&r.sqes[idx*sqeSize]))
+		*sqe = uringSQE{
+			opcode:   ioringOpRead,
+			fd:       int32(f.Fd()),
+			addr:     uint64(uintptr(unsafe.Pointer(&bufs[i][0]))),
+			len:      uint32(size),
+			userData: uint64(i),
+		}
+		*(*uint32)(unsafe.Pointer(&r.sqRing[sqOff.array+idx*4])) = idx
+		tail++
+		submitted++
+	}
+	atomic.StoreUint32(ringU32(r.sqRing, sqOff.tail), tail)
+
+	if submitted == 0 {
+		return
+	}
+	if err := r.enter(submitted); err != nil {
+		// 已入队的请求状态未知，环不再使用；缓冲区保持引用以防内核仍在写入
+		r.broken = true
+		r.pinned = append(r.pinned, bufs)
+		for i := range paths {
+			if bufs[i] != nil {
+				results[i].Data, results[i].Err = readAll(&offsetReader{f: files[i]}, int64(len(bufs[i])))
+			}
+		}
+		return
+	}
+	for i, res := range r.reap(submitted) {
+		complete(files[i], bufs[i], res, &results[i])
+	}
+	runtime.KeepAlive(bufs)
+}
+
+// complete 处理单个读请求的完成结果，按打开时的文件大小读取
+func complete(f *os.File, buf []byte, res int32, result *Result) {
+	if res < 0 {
+		result.Err = &os.PathError{Op: "read", Path: result.Path, Err: syscall.Errno(-res)}
+		return
+	}
+	// 短读时用 pread 读完剩余部分，文件被截断时以实际长度为准
+	n := int(res)
+	for n < len(buf) {
+		m, err := f.ReadAt(buf[n:], int64(n))
+		n += m
+		if err != nil {
+			break
+		}
+	}
+	result.Data = buf[:n]
+}
+
+// enter 提交 n 个请求并等待全部完成
+func (r *uringReader) enter(n int) error {
+	toSubmit, wait := n, n
+	for toSubmit > 0 || wait > 0 {
+		flags := uintptr(0)
+		if wait > 0 {
+			flags = ioringEnterGetEvents
+		}
+		ret, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(r.fd), uintptr(toSubmit), uintptr(wait), flags, 0, 0)
+		if errno == syscall.EINTR || errno == syscall.EAGAIN {
+			continue
+		}
+		if errno != 0 {
+			return errno
+		}
+		toSubmit -= int(ret)
+		if toSubmit < 0 {
+			toSubmit = 0
+		}
+		wait = n - r.ready()
+		if wait < 0 {
+			wait = 0
+		}
+	}
+	return nil
+}
+
+// ready 完成队列中待取的条目数
+func (r *uringReader) ready() int {
+	cqOff := &r.params.cqOff
+	head := atomic.LoadUint32(ringU32(r.cqRing, cqOff.head))
+	tail := atomic.LoadUint32(ringU32(r.cqRing, cqOff.tail))
+	return int(tail - head)
+}
+
+// reap 取出 n 个完成条目，返回请求序号到结果的映射
+func (r *uringReader) reap(n int) map[int]int32 {
+	cqOff := &r.params.cqOff
+	mask := *ringU32(r.cqRing, cqOff.ringMask)
+	head := atomic.LoadUint32(ringU32(r.cqRing, cqOff.head))
+	tail := atomic.LoadUint32(ringU32(r.cqRing, cqOff.tail))
+
+	out := make(map[int]int32, n)
+	for ; head != tail && len(out) < n; head++ {
+		cqe := (*uringCQE)(unsafe.Pointer(&r.cqRing[cqOff.cqes+(head&mask)*cqeSize]))
+		out[int(cqe.userData)] = cqe.res
+	}
+	atomic.StoreUint32(ringU32(r.cqRing, cqOff.head), head)
+	return out
+}
+
+// offsetReader 从指定偏移开始顺序 pread
+type offsetReader struct {
+	f   *os.File
+	off int64
+}
+
+func (o *offsetReader) Read(p []byte) (int, error) {
+	n, err := o.f.ReadAt(p, o.off)
+	o.off += int64(n)
+	if err != nil && n > 0 {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package fastread
+
+import "errors"
+
+func newURingReader(opts Options) (BatchReader, error) {
+	return nil, errors.New("fastread: io_uring not supported on this platform")
+}
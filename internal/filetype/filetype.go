@@ -1,4 +1,6 @@
-package fileutil
+// Package filetype 基于魔数与内容特征识别文件类型，供检测流水线各模块共用
+// 扩展名仅作为最后备选，检测结果通过 Reliable 标明是否可靠
+package filetype
 
 import (
 	"bytes"
@@ -20,6 +22,11 @@ const (
 	CategoryImage    Category = "image"
 	CategoryArchive  Category = "archive"
 	CategoryOther    Category = "other"
+
+	CategoryAudio      Category = "audio"
+	CategoryVideo      Category = "video"
+	CategoryExecutable Category = "executable"
+	CategorySpecial    Category = "special" // 块设备、字符设备、管道等非普通文件
 )
 
 // DetectionMethod 检测方法
@@ -37,7 +44,7 @@ type FileType struct {
 	Extension   string          // 文件扩展名 (不含点)
 	MimeType    string          // MIME类型
 	Description string          // 描述
-	Category    Category        // 分类: text, document, pdf, ofd, image, archive, other
+	Category    Category        // 分类: text, document, pdf, ofd, image, archive, audio, video, executable, special, other
 	Method      DetectionMethod // 检测方法
 	Reliable    bool            // 检测结果是否可靠
 }
@@ -87,6 +94,31 @@ var (
 	TypeGZ  = FileType{"gz", "application/gzip", "Gzip压缩文件", CategoryArchive, MethodUnknown, false}
 	TypeTAR = FileType{"tar", "application/x-tar", "Tar归档文件", CategoryArchive, MethodUnknown, false}
 
+	// 音频类
+	TypeMP3  = FileType{"mp3", "audio/mpeg", "MP3音频", CategoryAudio, MethodUnknown, false}
+	TypeWAV  = FileType{"wav", "audio/wav", "WAV音频", CategoryAudio, MethodUnknown, false}
+	TypeFLAC = FileType{"flac", "audio/flac", "FLAC音频", CategoryAudio, MethodUnknown, false}
+	TypeOGG  = FileType{"ogg", "audio/ogg", "Ogg音频", CategoryAudio, MethodUnknown, false}
+	TypeAMR  = FileType{"amr", "audio/amr", "AMR音频", CategoryAudio, MethodUnknown, false}
+	TypeM4A  = FileType{"m4a", "audio/mp4", "MPEG-4音频", CategoryAudio, MethodUnknown, false}
+
+	// 视频类
+	TypeMP4  = FileType{"mp4", "video/mp4", "MPEG-4视频", CategoryVideo, MethodUnknown, false}
+	TypeMOV  = FileType{"mov", "video/quicktime", "QuickTime视频", CategoryVideo, MethodUnknown, false}
+	TypeAVI  = FileType{"avi", "video/x-msvideo", "AVI视频", CategoryVideo, MethodUnknown, false}
+	TypeMKV  = FileType{"mkv", "video/x-matroska", "Matroska视频", CategoryVideo, MethodUnknown, false}
+	TypeWEBM = FileType{"webm", "video/webm", "WebM视频", CategoryVideo, MethodUnknown, false}
+	TypeFLV  = FileType{"flv", "video/x-flv", "Flash视频", CategoryVideo, MethodUnknown, false}
+
+	// 可执行文件类
+	TypeELF = FileType{"elf", "application/x-executable", "ELF可执行文件", CategoryExecutable, MethodUnknown, false}
+
+	// 特殊文件类 (由文件模式识别，不读取内容)
+	TypeBlockDevice = FileType{"", "inode/blockdevice", "块设备", CategorySpecial, MethodUnknown, true}
+	TypeCharDevice  = FileType{"", "inode/chardevice", "字符设备", CategorySpecial, MethodUnknown, true}
+	TypeFIFO        = FileType{"", "inode/fifo", "命名管道", CategorySpecial, MethodUnknown, true}
+	TypeSocket      = FileType{"", "inode/socket", "套接字", CategorySpecial, MethodUnknown, true}
+
 	// 未知类型
 	TypeUnknown = FileType{"", "application/octet-stream", "未知文件类型", CategoryOther, MethodUnknown, false}
 )
@@ -123,7 +155,24 @@ var magicSignatures = []struct {
 	{[]byte{0x42, 0x4D}, 0, FileType{"bmp", "image/bmp", "BMP图片", CategoryImage, MethodMagic, true}},
 	{[]byte{0x49, 0x49, 0x2A, 0x00}, 0, FileType{"tiff", "image/tiff", "TIFF图片(LE)", CategoryImage, MethodMagic, true}},
 	{[]byte{0x4D, 0x4D, 0x00, 0x2A}, 0, FileType{"tiff", "image/tiff", "TIFF图片(BE)", CategoryImage, MethodMagic, true}},
-	{[]byte("RIFF"), 0, FileType{"webp", "image/webp", "WebP图片", CategoryImage, MethodMagic, true}}, // 需要进一步检查 WEBP
+	{[]byte("RIFF"), 0, FileType{"webp", "image/webp", "WebP图片", CategoryImage, MethodMagic, true}}, // 需要进一步检查 WEBP/WAVE/AVI
+
+	// 音频
+	{[]byte("ID3"), 0, FileType{"mp3", "audio/mpeg", "MP3音频", CategoryAudio, MethodMagic, true}},
+	{[]byte{0xFF, 0xFB}, 0, FileType{"mp3", "audio/mpeg", "MP3音频", CategoryAudio, MethodMagic, true}},
+	{[]byte{0xFF, 0xF3}, 0, FileType{"mp3", "audio/mpeg", "MP3音频", CategoryAudio, MethodMagic, true}},
+	{[]byte{0xFF, 0xF2}, 0, FileType{"mp3", "audio/mpeg", "MP3音频", CategoryAudio, MethodMagic, true}},
+	{[]byte("fLaC"), 0, FileType{"flac", "audio/flac", "FLAC音频", CategoryAudio, MethodMagic, true}},
+	{[]byte("OggS"), 0, FileType{"ogg", "audio/ogg", "Ogg音频", CategoryAudio, MethodMagic, true}},
+	{[]byte("#!AMR"), 0, FileType{"amr", "audio/amr", "AMR音频", CategoryAudio, MethodMagic, true}},
+
+	// 视频
+	{[]byte("ftyp"), 4, FileType{"mp4", "video/mp4", "MPEG-4视频", CategoryVideo, MethodMagic, true}}, // 需要按 brand 区分 M4A/MOV
+	{[]byte{0x1A, 0x45, 0xDF, 0xA3}, 0, FileType{"mkv", "video/x-matroska", "Matroska视频", CategoryVideo, MethodMagic, true}},
+	{[]byte("FLV\x01"), 0, FileType{"flv", "video/x-flv", "Flash视频", CategoryVideo, MethodMagic, true}},
+
+	// 可执行文件
+	{[]byte{0x7F, 0x45, 0x4C, 0x46}, 0, FileType{"elf", "application/x-executable", "ELF可执行文件", CategoryExecutable, MethodMagic, true}},
 
 	// RTF
 	{[]byte("{\\rtf"), 0, FileType{"rtf", "application/rtf", "富文本格式", CategoryText, MethodMagic, true}},
@@ -176,10 +225,35 @@ var extensionMap = map[string]FileType{
 	"7z":  Type7Z,
 	"gz":  TypeGZ,
 	"tar": TypeTAR,
+
+	// 音频类
+	"mp3":  TypeMP3,
+	"wav":  TypeWAV,
+	"flac": TypeFLAC,
+	"ogg":  TypeOGG,
+	"amr":  TypeAMR,
+	"m4a":  TypeM4A,
+
+	// 视频类
+	"mp4":  TypeMP4,
+	"mov":  TypeMOV,
+	"avi":  TypeAVI,
+	"mkv":  TypeMKV,
+	"webm": TypeWEBM,
+	"flv":  TypeFLV,
 }
 
 // DetectFileType 检测文件类型（增强版，优先使用内容检测）
+// 块设备、管道等非普通文件只按文件模式识别，不打开读取
 func DetectFileType(filePath string) (FileType, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return TypeUnknown, err
+	}
+	if special, ok := FromMode(info.Mode()); ok {
+		return special, nil
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return TypeUnknown, err
@@ -215,12 +289,8 @@ func DetectFileType(filePath string) (FileType, error) {
 		return specificType, nil
 	}
 
-	// 如果检测到 WEBP，需要验证
-	if detectedType.Extension == "webp" {
-		if !isValidWebP(header) {
-			detectedType = TypeUnknown
-		}
-	}
+	// RIFF/ftyp/EBML 容器需要按内部标识细分
+	detectedType = detectMediaSubtype(detectedType, header)
 
 	// 魔数检测成功，直接返回
 	if detectedType.Extension != "" && detectedType.Reliable {
@@ -244,6 +314,35 @@ func DetectFileType(filePath string) (FileType, error) {
 	return TypeUnknown, nil
 }
 
+// DetectContentType 检测内存内容的文件类型
+// ZIP/OLE2 容器的细分类型 (DOCX/OFD/WPS 等) 需要读取内部结构，此处按 name 的扩展名区分
+func DetectContentType(name string, data []byte) FileType {
+	header := data
+	if len(header) > 4096 {
+		header = header[:4096]
+	}
+	byExt := GetFileTypeByExtension(filepath.Ext(name))
+
+	detectedType := detectByMagic(header)
+	switch {
+	case detectedType.Extension == "zip", detectedType.Extension == "doc" && detectedType.Method == MethodMagic:
+		if byExt.Extension != "" && byExt.Category != CategoryArchive {
+			return byExt
+		}
+		return detectedType
+	default:
+		detectedType = detectMediaSubtype(detectedType, header)
+	}
+	if detectedType.Extension != "" && detectedType.Reliable {
+		return detectedType
+	}
+
+	if contentType := detectByContent(header); contentType.Extension != "" {
+		return contentType
+	}
+	return byExt
+}
+
 // detectByMagic 通过魔数检测文件类型
 func detectByMagic(header []byte) FileType {
 	for _, sig := range magicSignatures {
@@ -520,6 +619,48 @@ func isValidWebP(header []byte) bool {
 	return false
 }
 
+// detectMediaSubtype 细分 RIFF (WebP/WAV/AVI)、ftyp (MP4/M4A/MOV) 与 EBML (MKV/WebM) 容器
+// 无法识别的 RIFF 返回 TypeUnknown，其余类型原样返回
+func detectMediaSubtype(detected FileType, header []byte) FileType {
+	switch detected.Extension {
+	case "webp":
+		if isValidWebP(header) {
+			return detected
+		}
+		if len(header) >= 12 {
+			switch string(header[8:12]) {
+			case "WAVE":
+				return FileType{"wav", "audio/wav", "WAV音频", CategoryAudio, MethodMagic, true}
+			case "AVI ":
+				return FileType{"avi", "video/x-msvideo", "AVI视频", CategoryVideo, MethodMagic, true}
+			}
+		}
+		return TypeUnknown
+
+	case "mp4":
+		if len(header) < 12 {
+			return detected
+		}
+		switch string(header[8:12]) {
+		case "M4A ", "M4B ":
+			return FileType{"m4a", "audio/mp4", "MPEG-4音频", CategoryAudio, MethodMagic, true}
+		case "qt  ":
+			return FileType{"mov", "video/quicktime", "QuickTime视频", CategoryVideo, MethodMagic, true}
+		}
+
+	case "mkv":
+		// EBML 头部的 DocType 为 webm
+		n := len(header)
+		if n > 64 {
+			n = 64
+		}
+		if bytes.Contains(header[:n], []byte("webm")) {
+			return FileType{"webm", "video/webm", "WebM视频", CategoryVideo, MethodMagic, true}
+		}
+	}
+	return detected
+}
+
 // detectZipSubtype 检测 ZIP 子类型（DOCX, OFD 等）
 func detectZipSubtype(filePath string) FileType {
 	file, err := os.Open(filePath)
@@ -624,6 +765,12 @@ func GetUnsupportedReason(fileType FileType) string {
 		return "图片文件需要OCR识别，请确保已安装Tesseract"
 	case CategoryArchive:
 		return "压缩文件，请先解压后检测"
+	case CategoryAudio, CategoryVideo:
+		return "音视频文件不包含可检测的文本"
+	case CategoryExecutable:
+		return "可执行文件不包含可检测的文本"
+	case CategorySpecial:
+		return "非普通文件（设备、管道或套接字）"
 	case CategoryOther:
 		return "未知或不支持的文件格式"
 	default:
@@ -672,6 +819,46 @@ func IsArchiveFile(fileType FileType) bool {
 	return fileType.Category == CategoryArchive
 }
 
+// IsAudioFile 检查是否是音频文件
+func IsAudioFile(fileType FileType) bool {
+	return fileType.Category == CategoryAudio
+}
+
+// IsVideoFile 检查是否是视频文件
+func IsVideoFile(fileType FileType) bool {
+	return fileType.Category == CategoryVideo
+}
+
+// IsMediaFile 检查是否是音视频文件（已压缩编码，不含可检测的文本）
+func IsMediaFile(fileType FileType) bool {
+	return IsAudioFile(fileType) || IsVideoFile(fileType)
+}
+
+// IsExecutableFile 检查是否是可执行文件
+func IsExecutableFile(fileType FileType) bool {
+	return fileType.Category == CategoryExecutable
+}
+
+// IsSpecialFile 检查是否是块设备、管道等非普通文件
+func IsSpecialFile(fileType FileType) bool {
+	return fileType.Category == CategorySpecial
+}
+
+// FromMode 根据文件模式识别非普通文件，普通文件与目录返回 false
+func FromMode(mode os.FileMode) (FileType, bool) {
+	switch {
+	case mode&os.ModeDevice != 0 && mode&os.ModeCharDevice != 0:
+		return TypeCharDevice, true
+	case mode&os.ModeDevice != 0:
+		return TypeBlockDevice, true
+	case mode&os.ModeNamedPipe != 0:
+		return TypeFIFO, true
+	case mode&os.ModeSocket != 0:
+		return TypeSocket, true
+	}
+	return TypeUnknown, false
+}
+
 // IsReliableDetection 检查检测结果是否可靠
 func IsReliableDetection(fileType FileType) bool {
 	return fileType.Reliable
@@ -701,6 +888,10 @@ func GetAllCategories() []Category {
 		CategoryOFD,
 		CategoryImage,
 		CategoryArchive,
+		CategoryAudio,
+		CategoryVideo,
+		CategoryExecutable,
+		CategorySpecial,
 		CategoryOther,
 	}
 }
@@ -744,4 +935,4 @@ func ValidateFileType(filePath string) (matched bool, detected FileType, expecte
 		detected.Category == expected.Category
 
 	return matched, detected, expected
-}
\ No newline at end of file
+}
@@ -1,4 +1,4 @@
-package fileutil
+package filetype
 
 import (
 	"os"
@@ -139,6 +139,86 @@ func TestDetectFileType_ByMagicNumber(t *testing.T) {
 			wantExt:      "webp",
 			wantReliable: true,
 		},
+		{
+			name:         "WAV魔数",
+			filename:     "test.bin",
+			content:      []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+			wantCategory: CategoryAudio,
+			wantExt:      "wav",
+			wantReliable: true,
+		},
+		{
+			name:         "AVI魔数",
+			filename:     "test.bin",
+			content:      []byte("RIFF\x00\x00\x00\x00AVI LIST"),
+			wantCategory: CategoryVideo,
+			wantExt:      "avi",
+			wantReliable: true,
+		},
+		{
+			name:         "MP3魔数(ID3)",
+			filename:     "test.bin",
+			content:      []byte("ID3\x04\x00\x00\x00\x00\x00\x00"),
+			wantCategory: CategoryAudio,
+			wantExt:      "mp3",
+			wantReliable: true,
+		},
+		{
+			name:         "MP3魔数(帧同步)",
+			filename:     "test.bin",
+			content:      []byte{0xFF, 0xFB, 0x90, 0x64, 0x00, 0x00, 0x00, 0x00},
+			wantCategory: CategoryAudio,
+			wantExt:      "mp3",
+			wantReliable: true,
+		},
+		{
+			name:         "FLAC魔数",
+			filename:     "test.bin",
+			content:      []byte("fLaC\x00\x00\x00\x22"),
+			wantCategory: CategoryAudio,
+			wantExt:      "flac",
+			wantReliable: true,
+		},
+		{
+			name:         "MP4魔数",
+			filename:     "test.bin",
+			content:      []byte("\x00\x00\x00\x18ftypisom\x00\x00\x02\x00"),
+			wantCategory: CategoryVideo,
+			wantExt:      "mp4",
+			wantReliable: true,
+		},
+		{
+			name:         "M4A魔数",
+			filename:     "test.bin",
+			content:      []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"),
+			wantCategory: CategoryAudio,
+			wantExt:      "m4a",
+			wantReliable: true,
+		},
+		{
+			name:         "MOV魔数",
+			filename:     "test.bin",
+			content:      []byte("\x00\x00\x00\x14ftypqt  \x00\x00\x00\x00"),
+			wantCategory: CategoryVideo,
+			wantExt:      "mov",
+			wantReliable: true,
+		},
+		{
+			name:         "WebM魔数",
+			filename:     "test.bin",
+			content:      []byte("\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm"),
+			wantCategory: CategoryVideo,
+			wantExt:      "webm",
+			wantReliable: true,
+		},
+		{
+			name:         "ELF魔数",
+			filename:     "test.bin",
+			content:      []byte{0x7F, 0x45, 0x4C, 0x46, 0x02, 0x01, 0x01, 0x00},
+			wantCategory: CategoryExecutable,
+			wantExt:      "elf",
+			wantReliable: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -812,8 +892,8 @@ func TestGetSupportedCategories(t *testing.T) {
 func TestGetAllCategories(t *testing.T) {
 	categories := GetAllCategories()
 
-	if len(categories) != 7 {
-		t.Errorf("所有分类应有7个，实际有 %d 个", len(categories))
+	if len(categories) != 11 {
+		t.Errorf("所有分类应有11个，实际有 %d 个", len(categories))
 	}
 
 	expectedCategories := map[Category]bool{
@@ -824,6 +904,11 @@ func TestGetAllCategories(t *testing.T) {
 		CategoryImage:    false,
 		CategoryArchive:  false,
 		CategoryOther:    false,
+
+		CategoryAudio:      false,
+		CategoryVideo:      false,
+		CategoryExecutable: false,
+		CategorySpecial:    false,
 	}
 
 	for _, c := range categories {
@@ -837,6 +922,46 @@ func TestGetAllCategories(t *testing.T) {
 	}
 }
 
+// ============================================================
+// FromMode 测试
+// ============================================================
+
+func TestFromMode(t *testing.T) {
+	tests := []struct {
+		mode   os.FileMode
+		want   FileType
+		wantOk bool
+	}{
+		{0644, TypeUnknown, false},
+		{os.ModeDir | 0755, TypeUnknown, false},
+		{os.ModeDevice | 0660, TypeBlockDevice, true},
+		{os.ModeDevice | os.ModeCharDevice | 0666, TypeCharDevice, true},
+		{os.ModeNamedPipe | 0600, TypeFIFO, true},
+		{os.ModeSocket | 0755, TypeSocket, true},
+	}
+
+	for _, tt := range tests {
+		got, ok := FromMode(tt.mode)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("FromMode(%v) = %v, %v, want %v, %v", tt.mode, got.Description, ok, tt.want.Description, tt.wantOk)
+		}
+	}
+}
+
+func TestDetectFileType_Device(t *testing.T) {
+	if _, err := os.Stat("/dev/null"); err != nil {
+		t.Skip("/dev/null 不存在")
+	}
+
+	fileType, err := DetectFileType("/dev/null")
+	if err != nil {
+		t.Fatalf("DetectFileType 失败: %v", err)
+	}
+	if !IsSpecialFile(fileType) || fileType != TypeCharDevice {
+		t.Errorf("DetectFileType(/dev/null) = %+v, want 字符设备", fileType)
+	}
+}
+
 // ============================================================
 // FileType 结构体测试
 // ============================================================
@@ -905,4 +1030,4 @@ func TestDetectFileType_TinyFile(t *testing.T) {
 	if err != nil {
 		t.Logf("小文件检测结果: %v", err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,346 @@
+package sm2
+
+import (
+	"math/big"
+	"math/bits"
+	"sync"
+)
+
+// ==========================================
+// 常数时间的域运算与标量乘
+// crypto/elliptic 的通用 CurveParams 实现不是常数时间的，执行时间随标量变化，
+// 在本机签名/加密时会通过时序泄露私钥 d 或随机数 k (泄露 k 即可推出私钥)。
+// 凡是涉及 d 或 k 的运算都走这里：4×64 位 Montgomery 表示 (R = 2^256)，
+// 完备加法公式 (Renes-Costello-Batina, a = -3) 与固定窗口标量乘，
+// 分支与访存都不依赖秘密数据。只涉及公开数据的验签仍使用 crypto/elliptic。
+// ==========================================
+
+// limbs 256 位整数，小端序的 64 位分量
+type limbs [4]uint64
+
+// montField 模 m 的 Montgomery 域运算，m 为 256 位以内的奇数
+type montField struct {
+	m     limbs
+	m0inv uint64 // -m^-1 mod 2^64
+	rr    limbs  // R^2 mod m
+	one   limbs  // R mod m，即 Montgomery 表示的 1
+	exp   *big.Int
+}
+
+func newMontField(m *big.Int) *montField {
+	f := &montField{exp: new(big.Int).Sub(m, big.NewInt(2))}
+	f.m = bigToLimbs(m)
+
+	// 牛顿迭代求 m[0] 模 2^64 的逆，每次迭代有效位数翻倍
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - f.m[0]*inv
+	}
+	f.m0inv = -inv
+
+	r := new(big.Int).Lsh(big.NewInt(1), 256)
+	f.one = bigToLimbs(new(big.Int).Mod(r, m))
+	f.rr = bigToLimbs(new(big.Int).Mod(new(big.Int).Mul(r, r), m))
+	return f
+}
+
+// mul z = x * y * R^-1 mod m (CIOS)
+func (f *montField) mul(x, y *limbs) limbs {
+	var t [6]uint64
+	for i := 0; i < 4; i++ {
+		var c, cc, hi, lo uint64
+		for j := 0; j < 4; j++ {
+			hi, lo = bits.Mul64(x[j], y[i])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j], c = lo, hi
+		}
+		t[4], cc = bits.Add64(t[4], c, 0)
+		t[5] = cc
+
+		mm := t[0] * f.m0inv
+		hi, lo = bits.Mul64(mm, f.m[0])
+		_, cc = bits.Add64(lo, t[0], 0)
+		c = hi + cc
+		for j := 1; j < 4; j++ {
+			hi, lo = bits.Mul64(mm, f.m[j])
+			lo, cc = bits.Add64(lo, t[j], 0)
+			hi += cc
+			lo, cc = bits.Add64(lo, c, 0)
+			hi += cc
+			t[j-1], c = lo, hi
+		}
+		t[3], cc = bits.Add64(t[4], c, 0)
+		t[4] = t[5] + cc
+	}
+	return f.reduce(limbs{t[0], t[1], t[2], t[3]}, t[4])
+}
+
+// reduce (carry·2^256 + x) < 2m 时返回其模 m 的值
+func (f *montField) reduce(x limbs, carry uint64) limbs {
+	var d limbs
+	var b uint64
+	d[0], b = bits.Sub64(x[0], f.m[0], 0)
+	d[1], b = bits.Sub64(x[1], f.m[1], b)
+	d[2], b = bits.Sub64(x[2], f.m[2], b)
+	d[3], b = bits.Sub64(x[3], f.m[3], b)
+	_, b = bits.Sub64(carry, 0, b)
+	// b == 1 表示 x < m，保留 x
+	return ctSelect(b, &x, &d)
+}
+
+func (f *montField) add(x, y *limbs) limbs {
+	var z limbs
+	var c uint64
+	z[0], c = bits.Add64(x[0], y[0], 0)
+	z[1], c = bits.Add64(x[1], y[1], c)
+	z[2], c = bits.Add64(x[2], y[2], c)
+	z[3], c = bits.Add64(x[3], y[3], c)
+	return f.reduce(z, c)
+}
+
+func (f *montField) sub(x, y *limbs) limbs {
+	var z limbs
+	var b uint64
+	z[0], b = bits.Sub64(x[0], y[0], 0)
+	z[1], b = bits.Sub64(x[1], y[1], b)
+	z[2], b = bits.Sub64(x[2], y[2], b)
+	z[3], b = bits.Sub64(x[3], y[3], b)
+	// 借位时加回 m
+	mask := -b
+	var c uint64
+	z[0], c = bits.Add64(z[0], f.m[0]&mask, 0)
+	z[1], c = bits.Add64(z[1], f.m[1]&mask, c)
+	z[2], c = bits.Add64(z[2], f.m[2]&mask, c)
+	z[3], _ = bits.Add64(z[3], f.m[3]&mask, c)
+	return z
+}
+
+// inverse x^(m-2)，m 为素数时即乘法逆元；指数是公开的，按位平方乘不泄露 x
+func (f *montField) inverse(x *limbs) limbs {
+	z := f.one
+	for i := f.exp.BitLen() - 1; i >= 0; i-- {
+		z = f.mul(&z, &z)
+		if f.exp.Bit(i) == 1 {
+			z = f.mul(&z, x)
+		}
+	}
+	return z
+}
+
+// toMont 普通表示转 Montgomery 表示，x 须小于 m
+func (f *montField) toMont(x *limbs) limbs {
+	return f.mul(x, &f.rr)
+}
+
+// fromMont Montgomery 表示转普通表示
+func (f *montField) fromMont(x *limbs) limbs {
+	one := limbs{1}
+	return f.mul(x, &one)
+}
+
+// isZero x == 0 时返回 1，否则返回 0
+func isZero(x *limbs) uint64 {
+	v := x[0] | x[1] | x[2] | x[3]
+	return 1 ^ (v|-v)>>63
+}
+
+// ctEq a == b 时返回 1，否则返回 0
+func ctEq(a, b uint64) uint64 {
+	v := a ^ b
+	return 1 ^ (v|-v)>>63
+}
+
+// ctSelect c == 1 时返回 a，c == 0 时返回 b
+func ctSelect(c uint64, a, b *limbs) limbs {
+	mask := -c
+	var z limbs
+	for i := range z {
+		z[i] = a[i]&mask | b[i]&^mask
+	}
+	return z
+}
+
+// bytesToLimbs 32 字节大端序转 limbs
+func bytesToLimbs(b *[32]byte) limbs {
+	var z limbs
+	for i := 0; i < 4; i++ {
+		off := 24 - 8*i
+		z[i] = uint64(b[off])<<56 | uint64(b[off+1])<<48 | uint64(b[off+2])<<40 | uint64(b[off+3])<<32 |
+			uint64(b[off+4])<<24 | uint64(b[off+5])<<16 | uint64(b[off+6])<<8 | uint64(b[off+7])
+	}
+	return z
+}
+
+// limbsToBytes limbs 转 32 字节大端序
+func limbsToBytes(x *limbs) [32]byte {
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		off := 24 - 8*i
+		for j := 0; j < 8; j++ {
+			b[off+j] = byte(x[i] >> (56 - 8*j))
+		}
+	}
+	return b
+}
+
+func bigToLimbs(v *big.Int) limbs {
+	var b [32]byte
+	v.FillBytes(b[:])
+	return bytesToLimbs(&b)
+}
+
+func limbsToBig(x *limbs) *big.Int {
+	b := limbsToBytes(x)
+	return new(big.Int).SetBytes(b[:])
+}
+
+// projPoint 射影坐标 (X:Y:Z) 表示的点，坐标为模 p 的 Montgomery 表示；无穷远点为 (0:1:0)
+type projPoint struct {
+	x, y, z limbs
+}
+
+// ctCurve 常数时间实现所需的曲线常量
+type ctCurve struct {
+	fp, fn *montField
+	b      limbs // Montgomery 表示的 b
+	g      projPoint
+}
+
+var (
+	ctOnce sync.Once
+	ctP256 *ctCurve
+)
+
+func ctCurveP256() *ctCurve {
+	ctOnce.Do(func() {
+		params := P256().Params()
+		c := &ctCurve{fp: newMontField(params.P), fn: newMontField(params.N)}
+		b := bigToLimbs(params.B)
+		c.b = c.fp.toMont(&b)
+		c.g = c.fromAffine(params.Gx, params.Gy)
+		ctP256 = c
+	})
+	return ctP256
+}
+
+func (c *ctCurve) identity() projPoint {
+	return projPoint{y: c.fp.one}
+}
+
+func (c *ctCurve) fromAffine(x, y *big.Int) projPoint {
+	xl, yl := bigToLimbs(x), bigToLimbs(y)
+	return projPoint{x: c.fp.toMont(&xl), y: c.fp.toMont(&yl), z: c.fp.one}
+}
+
+// toAffine 转仿射坐标，无穷远点返回 (0, 0)
+func (c *ctCurve) toAffine(p *projPoint) (*big.Int, *big.Int) {
+	f := c.fp
+	zinv := f.inverse(&p.z)
+	x := f.mul(&p.x, &zinv)
+	y := f.mul(&p.y, &zinv)
+	x, y = f.fromMont(&x), f.fromMont(&y)
+	return limbsToBig(&x), limbsToBig(&y)
+}
+
+// add 完备加法公式 (https://eprint.iacr.org/2015/1060 算法 4)，
+// 对 p1 == p2 与无穷远点同样适用，不需要按输入分支
+func (c *ctCurve) add(p1, p2 *projPoint) projPoint {
+	f := c.fp
+	t0 := f.mul(&p1.x, &p2.x)
+	t1 := f.mul(&p1.y, &p2.y)
+	t2 := f.mul(&p1.z, &p2.z)
+	t3 := f.add(&p1.x, &p1.y)
+	t4 := f.add(&p2.x, &p2.y)
+	t3 = f.mul(&t3, &t4)
+	t4 = f.add(&t0, &t1)
+	t3 = f.sub(&t3, &t4)
+	t4 = f.add(&p1.y, &p1.z)
+	x3 := f.add(&p2.y, &p2.z)
+	t4 = f.mul(&t4, &x3)
+	x3 = f.add(&t1, &t2)
+	t4 = f.sub(&t4, &x3)
+	x3 = f.add(&p1.x, &p1.z)
+	y3 := f.add(&p2.x, &p2.z)
+	x3 = f.mul(&x3, &y3)
+	y3 = f.add(&t0, &t2)
+	y3 = f.sub(&x3, &y3)
+	z3 := f.mul(&c.b, &t2)
+	x3 = f.sub(&y3, &z3)
+	z3 = f.add(&x3, &x3)
+	x3 = f.add(&x3, &z3)
+	z3 = f.sub(&t1, &x3)
+	x3 = f.add(&t1, &x3)
+	y3 = f.mul(&c.b, &y3)
+	t1 = f.add(&t2, &t2)
+	t2 = f.add(&t1, &t2)
+	y3 = f.sub(&y3, &t2)
+	y3 = f.sub(&y3, &t0)
+	t1 = f.add(&y3, &y3)
+	y3 = f.add(&t1, &y3)
+	t1 = f.add(&t0, &t0)
+	t0 = f.add(&t1, &t0)
+	t0 = f.sub(&t0, &t2)
+	t1 = f.mul(&t4, &y3)
+	t2 = f.mul(&t0, &y3)
+	y3 = f.mul(&x3, &z3)
+	y3 = f.add(&y3, &t2)
+	x3 = f.mul(&t3, &x3)
+	x3 = f.sub(&x3, &t1)
+	z3 = f.mul(&t4, &z3)
+	t1 = f.mul(&t3, &t0)
+	z3 = f.add(&z3, &t1)
+	return projPoint{x: x3, y: y3, z: z3}
+}
+
+// scalarMult 4 位固定窗口标量乘：每个窗口固定做 4 次倍点和 1 次加法，
+// 预计算表按掩码全表扫描取值，执行路径与访存地址都与标量无关
+func (c *ctCurve) scalarMult(q *projPoint, scalar *[32]byte) projPoint {
+	var table [16]projPoint
+	table[0] = c.identity()
+	for i := 1; i < 16; i++ {
+		table[i] = c.add(&table[i-1], q)
+	}
+
+	acc := c.identity()
+	for _, b := range scalar {
+		for _, w := range [2]uint64{uint64(b >> 4), uint64(b & 0x0f)} {
+			for i := 0; i < 4; i++ {
+				acc = c.add(&acc, &acc)
+			}
+			var sel projPoint
+			for i := range table {
+				mask := ctEq(uint64(i), w)
+				sel.x = ctSelect(mask, &table[i].x, &sel.x)
+				sel.y = ctSelect(mask, &table[i].y, &sel.y)
+				sel.z = ctSelect(mask, &table[i].z, &sel.z)
+			}
+			acc = c.add(&acc, &sel)
+		}
+	}
+	return acc
+}
+
+// scalarBaseMultCT 常数时间计算 k·G，k 为 32 字节大端序
+func scalarBaseMultCT(k *[32]byte) (*big.Int, *big.Int) {
+	c := ctCurveP256()
+	p := c.scalarMult(&c.g, k)
+	return c.toAffine(&p)
+}
+
+// scalarMultCT 常数时间计算 k·(x, y)，(x, y) 须为曲线上的点
+func scalarMultCT(x, y *big.Int, k *[32]byte) (*big.Int, *big.Int) {
+	c := ctCurveP256()
+	q := c.fromAffine(x, y)
+	p := c.scalarMult(&q, k)
+	return c.toAffine(&p)
+}
+
+// scalar32 标量的 32 字节大端序编码
+func scalar32(v *big.Int) *[32]byte {
+	var b [32]byte
+	v.FillBytes(b[:])
+	return &b
+}
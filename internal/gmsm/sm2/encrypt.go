@@ -0,0 +1,126 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+)
+
+// ==========================================
+// 公钥加密 (GB/T 32918.4)，密文格式 C1 || C3 || C2
+// ==========================================
+
+// ErrDecryption 解密失败 (密文损坏或私钥不匹配)
+var ErrDecryption = errors.New("sm2: decryption error")
+
+// Encrypt 使用公钥加密
+func Encrypt(random io.Reader, pub *PublicKey, msg []byte) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	curve := P256()
+	if pub == nil || pub.X == nil || !curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrInvalidPublicKey
+	}
+	n := curve.Params().N
+	one := big.NewInt(1)
+
+	for {
+		k, err := rand.Int(random, new(big.Int).Sub(n, one))
+		if err != nil {
+			return nil, err
+		}
+		k.Add(k, one)
+
+		kb := scalar32(k)
+		x1, y1 := scalarBaseMultCT(kb)
+		x2, y2 := scalarMultCT(pub.X, pub.Y, kb)
+		x2b, y2b := fill32(x2), fill32(y2)
+
+		t := kdf(len(msg), x2b, y2b)
+		if len(msg) > 0 && allZero(t) {
+			continue
+		}
+
+		out := make([]byte, 0, 65+sm3.Size+len(msg))
+		out = append(out, (&PublicKey{X: x1, Y: y1}).Bytes()...)
+		out = append(out, c3(x2b, msg, y2b)...)
+		for i := range msg {
+			out = append(out, msg[i]^t[i])
+		}
+		return out, nil
+	}
+}
+
+// Decrypt 使用私钥解密 Encrypt 的输出
+func Decrypt(priv *PrivateKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 65+sm3.Size {
+		return nil, ErrDecryption
+	}
+	c1, err := ParsePublicKey(ciphertext[:65])
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	hash := ciphertext[65 : 65+sm3.Size]
+	c2 := ciphertext[65+sm3.Size:]
+
+	x2, y2 := scalarMultCT(c1.X, c1.Y, scalar32(priv.D))
+	x2b, y2b := fill32(x2), fill32(y2)
+
+	t := kdf(len(c2), x2b, y2b)
+	if len(c2) > 0 && allZero(t) {
+		return nil, ErrDecryption
+	}
+	msg := make([]byte, len(c2))
+	for i := range c2 {
+		msg[i] = c2[i] ^ t[i]
+	}
+	if subtle.ConstantTimeCompare(c3(x2b, msg, y2b), hash) != 1 {
+		return nil, ErrDecryption
+	}
+	return msg, nil
+}
+
+// kdf 密钥派生函数: SM3(Z || ct) 依次拼接
+func kdf(length int, z ...[]byte) []byte {
+	out := make([]byte, 0, length+sm3.Size)
+	var ct uint32 = 1
+	for len(out) < length {
+		h := sm3.New()
+		for _, b := range z {
+			h.Write(b)
+		}
+		var c [4]byte
+		binary.BigEndian.PutUint32(c[:], ct)
+		h.Write(c[:])
+		out = h.Sum(out)
+		ct++
+	}
+	return out[:length]
+}
+
+func c3(x2, msg, y2 []byte) []byte {
+	h := sm3.New()
+	h.Write(x2)
+	h.Write(msg)
+	h.Write(y2)
+	return h.Sum(nil)
+}
+
+func fill32(v *big.Int) []byte {
+	return v.FillBytes(make([]byte, 32))
+}
+
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,264 @@
+// Package sm2 SM2 椭圆曲线数字签名 (GB/T 32918-2016)
+// 用于校验服务端下发的规则/策略签名；签名值采用 ASN.1 DER 编码的 SEQUENCE{r, s} (GM/T 0009)
+// 本机签名、生成密钥与加解密中涉及私钥 d 或随机数 k 的标量乘和模 n 运算使用常数时间实现 (curve.go)，
+// 只有验签使用 crypto/elliptic 的通用实现
+package sm2
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+)
+
+// DefaultUID 未指定用户标识时使用的默认值 (GM/T 0009)
+var DefaultUID = []byte("1234567812345678")
+
+var (
+	ErrInvalidPublicKey  = errors.New("sm2: invalid public key")
+	ErrInvalidPrivateKey = errors.New("sm2: invalid private key")
+	ErrInvalidSignature  = errors.New("sm2: invalid signature encoding")
+)
+
+var (
+	initOnce sync.Once
+	p256     *elliptic.CurveParams
+)
+
+// P256 返回 SM2 推荐曲线 (sm2p256v1)
+func P256() elliptic.Curve {
+	initOnce.Do(func() {
+		p256 = &elliptic.CurveParams{Name: "SM2-P-256", BitSize: 256}
+		p256.P, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF00000000FFFFFFFFFFFFFFFF", 16)
+		p256.N, _ = new(big.Int).SetString("FFFFFFFEFFFFFFFFFFFFFFFFFFFFFFFF7203DF6B21C6052B53BBF40939D54123", 16)
+		p256.B, _ = new(big.Int).SetString("28E9FA9E9D9F5E344D5A9E4BCF6509A7F39789F515AB8F92DDBCBD414D940E93", 16)
+		p256.Gx, _ = new(big.Int).SetString("32C4AE2C1F1981195F9904466A39C9948FE30BBFF2660BE1715A4589334C74C7", 16)
+		p256.Gy, _ = new(big.Int).SetString("BC3736A2F4F6779C59BDCEE36B692153D0A9877CC62A474002DF32E52139F0A0", 16)
+	})
+	return p256
+}
+
+// PublicKey SM2 公钥
+type PublicKey struct {
+	X, Y *big.Int
+}
+
+// PrivateKey SM2 私钥
+type PrivateKey struct {
+	PublicKey
+	D *big.Int
+}
+
+// GenerateKey 生成密钥对
+func GenerateKey(random io.Reader) (*PrivateKey, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	params := P256().Params()
+	// d ∈ [1, n-2]
+	max := new(big.Int).Sub(params.N, big.NewInt(2))
+	d, err := rand.Int(random, max)
+	if err != nil {
+		return nil, err
+	}
+	d.Add(d, big.NewInt(1))
+
+	priv := &PrivateKey{D: d}
+	priv.X, priv.Y = scalarBaseMultCT(scalar32(d))
+	return priv, nil
+}
+
+// ParsePublicKey 解析未压缩格式公钥 (04 || X || Y)，支持原始字节或十六进制字符串
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	if s := strings.TrimSpace(string(data)); len(s) == 130 {
+		if raw, err := hex.DecodeString(s); err == nil {
+			data = raw
+		}
+	}
+	if len(data) != 65 || data[0] != 4 {
+		return nil, ErrInvalidPublicKey
+	}
+	pub := &PublicKey{
+		X: new(big.Int).SetBytes(data[1:33]),
+		Y: new(big.Int).SetBytes(data[33:]),
+	}
+	if !P256().IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrInvalidPublicKey
+	}
+	return pub, nil
+}
+
+// Bytes 未压缩格式编码 (04 || X || Y)
+func (pub *PublicKey) Bytes() []byte {
+	out := make([]byte, 65)
+	out[0] = 4
+	pub.X.FillBytes(out[1:33])
+	pub.Y.FillBytes(out[33:])
+	return out
+}
+
+// Hex 未压缩格式的十六进制编码
+func (pub *PublicKey) Hex() string {
+	return hex.EncodeToString(pub.Bytes())
+}
+
+// ParsePrivateKey 解析私钥 (32 字节 d)，支持原始字节或十六进制字符串
+func ParsePrivateKey(data []byte) (*PrivateKey, error) {
+	if s := strings.TrimSpace(string(data)); len(s) == 64 {
+		if raw, err := hex.DecodeString(s); err == nil {
+			data = raw
+		}
+	}
+	if len(data) != 32 {
+		return nil, ErrInvalidPrivateKey
+	}
+	d := new(big.Int).SetBytes(data)
+	// d ∈ [1, n-2]
+	max := new(big.Int).Sub(P256().Params().N, big.NewInt(2))
+	if d.Sign() <= 0 || d.Cmp(max) > 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	priv := &PrivateKey{D: d}
+	priv.X, priv.Y = scalarBaseMultCT((*[32]byte)(data))
+	return priv, nil
+}
+
+// EncodePrivateKey 私钥 d 的十六进制编码 (32 字节)
+func EncodePrivateKey(priv *PrivateKey) string {
+	return hex.EncodeToString(priv.D.FillBytes(make([]byte, 32)))
+}
+
+// za 计算用户杂凑值 Z = SM3(ENTL || ID || a || b || xG || yG || xA || yA)
+func za(pub *PublicKey, uid []byte) []byte {
+	params := P256().Params()
+	a := new(big.Int).Sub(params.P, big.NewInt(3))
+
+	h := sm3.New()
+	entl := len(uid) * 8
+	h.Write([]byte{byte(entl >> 8), byte(entl)})
+	h.Write(uid)
+	var buf [32]byte
+	for _, v := range []*big.Int{a, params.B, params.Gx, params.Gy, pub.X, pub.Y} {
+		h.Write(v.FillBytes(buf[:]))
+	}
+	return h.Sum(nil)
+}
+
+// digest e = SM3(Z || M)
+func digest(pub *PublicKey, uid, msg []byte) *big.Int {
+	if uid == nil {
+		uid = DefaultUID
+	}
+	h := sm3.New()
+	h.Write(za(pub, uid))
+	h.Write(msg)
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+type signature struct {
+	R, S *big.Int
+}
+
+// Sign 使用默认用户标识对消息签名，返回 DER 编码的签名值
+func Sign(random io.Reader, priv *PrivateKey, msg []byte) ([]byte, error) {
+	return SignWithUID(random, priv, nil, msg)
+}
+
+// SignWithUID 使用指定用户标识对消息签名，uid 为 nil 时使用默认用户标识
+// 同一密钥签发不同用途的数据时以不同的用户标识区分，一种用途的签名不能用于另一种用途
+func SignWithUID(random io.Reader, priv *PrivateKey, uid, msg []byte) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	n := P256().Params().N
+	e := digest(&priv.PublicKey, uid, msg)
+	one := big.NewInt(1)
+
+	// 涉及 d 与 k 的运算都在常数时间的模 n 域内进行
+	fn := ctCurveP256().fn
+	d := bigToLimbs(priv.D)
+	d = fn.toMont(&d)
+	// (1 + d)^-1
+	dInv := fn.add(&fn.one, &d)
+	dInv = fn.inverse(&dInv)
+
+	for {
+		k, err := rand.Int(random, new(big.Int).Sub(n, one))
+		if err != nil {
+			return nil, err
+		}
+		k.Add(k, one)
+		kb := scalar32(k)
+
+		x1, _ := scalarBaseMultCT(kb)
+		r := new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		km := bytesToLimbs(kb)
+		km = fn.toMont(&km)
+		rm := bigToLimbs(r)
+		rm = fn.toMont(&rm)
+		if rk := fn.add(&rm, &km); r.Sign() == 0 || isZero(&rk) == 1 {
+			continue
+		}
+
+		// s = (1 + d)^-1 * (k - r*d) mod n
+		sm := fn.mul(&rm, &d)
+		sm = fn.sub(&km, &sm)
+		sm = fn.mul(&dInv, &sm)
+		if isZero(&sm) == 1 {
+			continue
+		}
+		sm = fn.fromMont(&sm)
+		return asn1.Marshal(signature{R: r, S: limbsToBig(&sm)})
+	}
+}
+
+// Verify 使用默认用户标识校验 DER 编码的签名
+func Verify(pub *PublicKey, msg, sig []byte) bool {
+	return VerifyWithUID(pub, nil, msg, sig)
+}
+
+// VerifyWithUID 使用指定用户标识校验 DER 编码的签名，uid 为 nil 时使用默认用户标识
+func VerifyWithUID(pub *PublicKey, uid, msg, sig []byte) bool {
+	var sv signature
+	rest, err := asn1.Unmarshal(sig, &sv)
+	if err != nil || len(rest) > 0 || sv.R == nil || sv.S == nil {
+		return false
+	}
+	return verify(pub, uid, msg, sv.R, sv.S)
+}
+
+func verify(pub *PublicKey, uid, msg []byte, r, s *big.Int) bool {
+	curve := P256()
+	n := curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	if pub == nil || pub.X == nil || !curve.IsOnCurve(pub.X, pub.Y) {
+		return false
+	}
+
+	t := new(big.Int).Add(r, s)
+	t.Mod(t, n)
+	if t.Sign() == 0 {
+		return false
+	}
+
+	// (x1, y1) = s*G + t*P，输入都是公开数据，不需要常数时间实现
+	x1, y1 := curve.ScalarBaseMult(s.Bytes())
+	x2, y2 := curve.ScalarMult(pub.X, pub.Y, t.Bytes())
+	x, _ := curve.Add(x1, y1, x2, y2)
+
+	e := digest(pub, uid, msg)
+	R := e.Add(e, x)
+	R.Mod(R, n)
+	return R.Cmp(r) == 0
+}
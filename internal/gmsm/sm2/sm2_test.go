@@ -0,0 +1,186 @@
+package sm2
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	priv, err := GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	msg := []byte("规则包内容")
+
+	sig, err := Sign(nil, priv, msg)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !Verify(&priv.PublicKey, msg, sig) {
+		t.Fatal("Verify() = false for valid signature")
+	}
+	if Verify(&priv.PublicKey, []byte("篡改后的内容"), sig) {
+		t.Error("Verify() = true for modified message")
+	}
+
+	other, _ := GenerateKey(nil)
+	if Verify(&other.PublicKey, msg, sig) {
+		t.Error("Verify() = true for wrong key")
+	}
+	if Verify(&priv.PublicKey, msg, sig[:len(sig)-1]) {
+		t.Error("Verify() = true for truncated signature")
+	}
+}
+
+func TestSignVerifyWithUID(t *testing.T) {
+	priv, _ := GenerateKey(nil)
+	msg := []byte(`{"type":"rescan"}`)
+	uid := []byte("command")
+
+	sig, err := SignWithUID(nil, priv, uid, msg)
+	if err != nil {
+		t.Fatalf("SignWithUID() error = %v", err)
+	}
+	if !VerifyWithUID(&priv.PublicKey, uid, msg, sig) {
+		t.Fatal("VerifyWithUID() = false for valid signature")
+	}
+	// 用户标识不同的签名互不通用
+	if Verify(&priv.PublicKey, msg, sig) {
+		t.Error("Verify() = true for signature with another uid")
+	}
+	def, _ := Sign(nil, priv, msg)
+	if VerifyWithUID(&priv.PublicKey, uid, msg, def) {
+		t.Error("VerifyWithUID() = true for default uid signature")
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	priv, _ := GenerateKey(nil)
+
+	pub, err := ParsePublicKey([]byte(priv.PublicKey.Hex()))
+	if err != nil {
+		t.Fatalf("ParsePublicKey(hex) error = %v", err)
+	}
+	if pub.X.Cmp(priv.X) != 0 || pub.Y.Cmp(priv.Y) != 0 {
+		t.Error("ParsePublicKey(hex) 结果不一致")
+	}
+	if _, err := ParsePublicKey(priv.PublicKey.Bytes()); err != nil {
+		t.Errorf("ParsePublicKey(raw) error = %v", err)
+	}
+
+	bad := priv.PublicKey.Bytes()
+	bad[64] ^= 1
+	if _, err := ParsePublicKey(bad); err == nil {
+		t.Error("ParsePublicKey() 应拒绝不在曲线上的点")
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	priv, _ := GenerateKey(nil)
+
+	parsed, err := ParsePrivateKey([]byte(EncodePrivateKey(priv) + "\n"))
+	if err != nil {
+		t.Fatalf("ParsePrivateKey() error = %v", err)
+	}
+	if parsed.D.Cmp(priv.D) != 0 || parsed.X.Cmp(priv.X) != 0 || parsed.Y.Cmp(priv.Y) != 0 {
+		t.Error("ParsePrivateKey() 结果不一致")
+	}
+
+	sig, err := Sign(nil, parsed, []byte("msg"))
+	if err != nil || !Verify(&priv.PublicKey, []byte("msg"), sig) {
+		t.Errorf("解析后的私钥签名无法校验: %v", err)
+	}
+
+	if _, err := ParsePrivateKey(make([]byte, 32)); err == nil {
+		t.Error("ParsePrivateKey() 应拒绝 d = 0")
+	}
+	if _, err := ParsePrivateKey([]byte("abcd")); err == nil {
+		t.Error("ParsePrivateKey() 应拒绝长度错误的私钥")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	priv, _ := GenerateKey(nil)
+	for _, msg := range []string{"", "a", "SM4 数据密钥 0123456789abcdef 跨越多个 KDF 分组的较长明文内容"} {
+		ct, err := Encrypt(nil, &priv.PublicKey, []byte(msg))
+		if err != nil {
+			t.Fatalf("Encrypt(%q) error = %v", msg, err)
+		}
+		if len(ct) != 65+32+len(msg) {
+			t.Errorf("密文长度 = %d", len(ct))
+		}
+		got, err := Decrypt(priv, ct)
+		if err != nil || string(got) != msg {
+			t.Errorf("Decrypt() = %q, %v, want %q", got, err, msg)
+		}
+	}
+
+	ct, _ := Encrypt(nil, &priv.PublicKey, []byte("key"))
+	ct[len(ct)-1] ^= 1
+	if _, err := Decrypt(priv, ct); err != ErrDecryption {
+		t.Errorf("Decrypt(tampered) error = %v", err)
+	}
+	other, _ := GenerateKey(nil)
+	ct[len(ct)-1] ^= 1
+	if _, err := Decrypt(other, ct); err != ErrDecryption {
+		t.Errorf("Decrypt(wrong key) error = %v", err)
+	}
+}
+
+func TestScalarMultCT(t *testing.T) {
+	curve := P256()
+	params := curve.Params()
+	n := params.N
+	scalars := []*big.Int{
+		big.NewInt(1), big.NewInt(2), big.NewInt(15), big.NewInt(16),
+		new(big.Int).Sub(n, big.NewInt(2)), new(big.Int).Sub(n, big.NewInt(1)),
+	}
+	for i := 0; i < 16; i++ {
+		k, _ := rand.Int(rand.Reader, n)
+		scalars = append(scalars, k)
+	}
+	qx, qy := curve.ScalarBaseMult([]byte{7})
+
+	for _, k := range scalars {
+		wantX, wantY := curve.ScalarBaseMult(k.Bytes())
+		if x, y := scalarBaseMultCT(scalar32(k)); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Errorf("scalarBaseMultCT(%x) 与 crypto/elliptic 结果不一致", k)
+		}
+		wantX, wantY = curve.ScalarMult(qx, qy, k.Bytes())
+		if x, y := scalarMultCT(qx, qy, scalar32(k)); x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Errorf("scalarMultCT(%x) 与 crypto/elliptic 结果不一致", k)
+		}
+	}
+
+	// n·G 为无穷远点
+	if x, y := scalarBaseMultCT(scalar32(n)); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("scalarBaseMultCT(n) = (%x, %x), want (0, 0)", x, y)
+	}
+}
+
+func TestMontField(t *testing.T) {
+	params := P256().Params()
+	for _, m := range []*big.Int{params.P, params.N} {
+		f := newMontField(m)
+		for i := 0; i < 32; i++ {
+			a, _ := rand.Int(rand.Reader, m)
+			b, _ := rand.Int(rand.Reader, m)
+			al, bl := bigToLimbs(a), bigToLimbs(b)
+			am, bm := f.toMont(&al), f.toMont(&bl)
+
+			check := func(op string, got limbs, want *big.Int) {
+				got = f.fromMont(&got)
+				if limbsToBig(&got).Cmp(want.Mod(want, m)) != 0 {
+					t.Errorf("%s(%x, %x) mod %x 结果错误", op, a, b, m)
+				}
+			}
+			check("mul", f.mul(&am, &bm), new(big.Int).Mul(a, b))
+			check("add", f.add(&am, &bm), new(big.Int).Add(a, b))
+			check("sub", f.sub(&am, &bm), new(big.Int).Sub(a, b))
+			if a.Sign() != 0 {
+				check("inverse", f.inverse(&am), new(big.Int).ModInverse(a, m))
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package sm3
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// 标准附录 A 示例
+func TestSum(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	}
+	for _, c := range cases {
+		got := Sum([]byte(c.in))
+		if hex.EncodeToString(got[:]) != c.want {
+			t.Errorf("Sum(%q) = %x, want %s", c.in, got, c.want)
+		}
+
+		// 分段写入结果一致
+		h := New()
+		for i := 0; i < len(c.in); i += 7 {
+			end := i + 7
+			if end > len(c.in) {
+				end = len(c.in)
+			}
+			h.Write([]byte(c.in[i:end]))
+		}
+		if s := hex.EncodeToString(h.Sum(nil)); s != c.want {
+			t.Errorf("New().Sum(%q) = %s, want %s", c.in, s, c.want)
+		}
+	}
+}
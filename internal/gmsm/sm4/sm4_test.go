@@ -0,0 +1,59 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+)
+
+// 标准附录 A 示例
+func TestCipher(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	want, _ := hex.DecodeString("681edf34d206965e86b3e94f536e4246")
+
+	c, err := NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, BlockSize)
+	c.Encrypt(out, key)
+	if !bytes.Equal(out, want) {
+		t.Fatalf("Encrypt() = %x, want %x", out, want)
+	}
+	c.Decrypt(out, out)
+	if !bytes.Equal(out, key) {
+		t.Fatalf("Decrypt() = %x, want %x", out, key)
+	}
+
+	// 加密 1000000 次
+	if !testing.Short() {
+		want, _ = hex.DecodeString("595298c7c6fd271f0402f804c33d3f66")
+		copy(out, key)
+		for i := 0; i < 1000000; i++ {
+			c.Encrypt(out, out)
+		}
+		if !bytes.Equal(out, want) {
+			t.Errorf("1000000 次加密 = %x, want %x", out, want)
+		}
+	}
+
+	if _, err := NewCipher(key[:8]); err == nil {
+		t.Error("NewCipher() 应拒绝错误的密钥长度")
+	}
+}
+
+func TestGCM(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	c, _ := NewCipher(key)
+	aead, err := cipher.NewGCM(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	sealed := aead.Seal(nil, nonce, []byte("命中内容"), nil)
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil || string(plain) != "命中内容" {
+		t.Errorf("Open() = %q, %v", plain, err)
+	}
+}
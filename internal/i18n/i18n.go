@@ -0,0 +1,105 @@
+// Package i18n 多语言消息目录
+// 告警描述、错误信息、桌面通知与调试工具帮助文本按键查询，支持 zh-CN 与 en-US；
+// 语言由配置 agent.locale 指定，未配置时取 LC_ALL/LC_MESSAGES/LANG 环境变量
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// 支持的语言
+const (
+	ZhCN = "zh-CN"
+	EnUS = "en-US"
+
+	// DefaultLocale 默认语言，也是缺失翻译时的回退语言
+	DefaultLocale = ZhCN
+)
+
+var current atomic.Value // string
+
+// Normalize 规范化语言标识
+// 接受 "en_US.UTF-8"、"en-us"、"en" 等写法，不支持的语言 (含 C/POSIX) 返回 DefaultLocale
+func Normalize(locale string) string {
+	l := strings.ToLower(strings.TrimSpace(locale))
+	switch {
+	case strings.HasPrefix(l, "en"):
+		return EnUS
+	case strings.HasPrefix(l, "zh"):
+		return ZhCN
+	default:
+		return DefaultLocale
+	}
+}
+
+// Supported 是否为支持的语言 (接受 Normalize 可识别的写法)
+func Supported(locale string) bool {
+	l := strings.ToLower(strings.TrimSpace(locale))
+	return strings.HasPrefix(l, "en") || strings.HasPrefix(l, "zh")
+}
+
+// FromEnv 按 POSIX 优先级读取环境变量中的语言
+func FromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return Normalize(v)
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale 设置全局语言，为空时取环境变量
+func SetLocale(locale string) {
+	if locale == "" {
+		current.Store(FromEnv())
+		return
+	}
+	current.Store(Normalize(locale))
+}
+
+// Locale 返回全局语言，未调用 SetLocale 时为 DefaultLocale
+// 不直接读取环境变量，库代码与单元测试的输出不随运行环境变化
+func Locale() string {
+	if l, ok := current.Load().(string); ok {
+		return l
+	}
+	return DefaultLocale
+}
+
+// T 按全局语言查询消息，args 非空时按 fmt 格式化
+func T(key string, args ...interface{}) string {
+	return Tl(Locale(), key, args...)
+}
+
+// Tl 按指定语言查询消息
+func Tl(locale, key string, args ...interface{}) string {
+	format := lookup(locale, key)
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Errorf 按全局语言格式化错误，格式串中的 %w 包装原始错误
+func Errorf(key string, args ...interface{}) error {
+	format := lookup(Locale(), key)
+	if len(args) == 0 {
+		return errors.New(format)
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// lookup 缺失翻译时回退到 DefaultLocale，仍缺失则返回 key 本身，便于发现遗漏
+func lookup(locale, key string) string {
+	if format, ok := catalog[Normalize(locale)][key]; ok {
+		return format
+	}
+	if format, ok := catalog[DefaultLocale][key]; ok {
+		return format
+	}
+	return key
+}
@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"en_US.UTF-8": EnUS,
+		"en-us":       EnUS,
+		"en":          EnUS,
+		"zh_CN.UTF-8": ZhCN,
+		"zh_TW":       ZhCN,
+		"C":           DefaultLocale,
+		"POSIX":       DefaultLocale,
+		"":            DefaultLocale,
+		"fr_FR":       DefaultLocale,
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "en_US.UTF-8")
+	if got := FromEnv(); got != EnUS {
+		t.Errorf("LANG=en_US: FromEnv() = %q", got)
+	}
+	t.Setenv("LC_ALL", "zh_CN.UTF-8")
+	if got := FromEnv(); got != ZhCN {
+		t.Errorf("LC_ALL 应优先于 LANG: FromEnv() = %q", got)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	SetLocale("en_US.UTF-8")
+	if got := T("detect.secret_level.hit", "绝密"); got != "Classification marking detected: 绝密" {
+		t.Errorf("T() = %q", got)
+	}
+	if got := Tl(ZhCN, "exfil.rule_desc", "5m", "可移动介质", "/media/u", 3); got != "5m 内向可移动介质 /media/u 拷贝 3 个涉密文件" {
+		t.Errorf("Tl(zh-CN) = %q", got)
+	}
+	if got := T("exfil.rule_desc", "5m", "removable media", "/media/u", 3); got != "3 classified files copied to removable media /media/u within 5m" {
+		t.Errorf("T(en-US) 调整参数顺序 = %q", got)
+	}
+	if got := T("no.such.key"); got != "no.such.key" {
+		t.Errorf("缺失的键 = %q", got)
+	}
+
+	err := Errorf("processor.err.process", io.EOF)
+	if !errors.Is(err, io.EOF) || err.Error() != "failed to process file: EOF" {
+		t.Errorf("Errorf() = %v", err)
+	}
+}
+
+// TestCatalogComplete 各语言的键与格式化参数一致
+func TestCatalogComplete(t *testing.T) {
+	verb := regexp.MustCompile(`%(\[\d+\])?[-+# 0]*[\d.]*[a-zA-Z%]`)
+	count := func(s string) int {
+		n := 0
+		for _, m := range verb.FindAllString(s, -1) {
+			if m != "%%" {
+				n++
+			}
+		}
+		return n
+	}
+
+	base := catalog[DefaultLocale]
+	for locale, msgs := range catalog {
+		for key, format := range base {
+			tr, ok := msgs[key]
+			if !ok {
+				t.Errorf("%s 缺少翻译 %q", locale, key)
+				continue
+			}
+			if count(tr) != count(format) {
+				t.Errorf("%s %q 参数个数与 %s 不一致", locale, key, DefaultLocale)
+			}
+		}
+		for key := range msgs {
+			if _, ok := base[key]; !ok {
+				t.Errorf("%s 多余的键 %q", locale, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package i18n
+
+// ==========================================
+// 消息目录
+// ==========================================
+//
+// 键按 "模块.用途" 命名；格式串遵循 fmt 语法，译文可用 %[n]v 调整参数顺序。
+// 新增消息时两种语言需同时补齐 (TestCatalogComplete 会检查)
+
+var catalog = map[string]map[string]string{
+	ZhCN: {
+		// 密级名称
+		"level.top_secret":   "绝密",
+		"level.secret":       "机密",
+		"level.confidential": "秘密",
+		"level.internal":     "内部",
+
+		// 检测告警描述
+		"detect.secret_level.hit":     "密级标志检测命中: %s",
+		"detect.sampled":              "大文件采样检测 (%s)",
+		"detect.electronic.rule_desc": "电子密级标志元数据检测",
+		"detect.electronic.summary":   "检测到电子密级标志",
+		"detect.electronic.file_desc": "在元数据文件 '%s' 中检测到电子密级标志",
+		"detect.electronic.in_image":  "在图片中检测到电子密级标志",
+		"detect.electronic.in_doc":    "在文档中检测到电子密级标志",
+		"detect.govcheck.hit":         "公文版式检测命中",
+		"detect.govcheck.hit_detail":  "公文版式检测命中 (%s)",
+		"detect.govcheck.title_type":  "文种: %s",
+		"detect.govcheck.org":         "发文机关: %s",
+		"detect.govcheck.confidence":  "置信度: %.1f%%",
+		"detect.govcheck.list_sep":    "、",
+		"detect.govcheck.partial":     "部分抽取: 超出内存预算，仅检测了文件前部",
+		"detect.xattr_label.hit":      "文件扩展属性分类标签: %s",
+		"detect.xattr_label.source":   "标注来源: %s",
+		"exfil.rule_desc":             "%s 内向%s %s 拷贝 %d 个涉密文件",
+		"exfil.dest.removable":        "可移动介质",
+		"exfil.dest.network":          "网络挂载",
+
+		// 文档处理器错误
+		"processor.op.validate":          "文件验证",
+		"processor.op.extract":           "内容提取",
+		"processor.op.parse":             "解析",
+		"processor.op.format":            "格式检查",
+		"processor.op.size":              "大小检查",
+		"processor.op.check":             "文件检查",
+		"processor.err.format_mismatch":  "期望格式: %s, 实际格式: %s",
+		"processor.err.too_large":        "文件大小 %d 字节, 超过限制 %d 字节",
+		"processor.err.empty":            "文件为空",
+		"processor.err.all_failed":       "所有处理器都失败: %w",
+		"processor.err.none":             "没有可用的处理器",
+		"processor.err.unsupported_type": "不支持的文件类型: %s",
+		"processor.err.process":          "处理文件失败: %w",
+
+		// 调试工具
+		"cli.secret_level.dir":        "要扫描的目录路径",
+		"cli.secret_level.workers":    "并发工作线程数",
+		"cli.secret_level.verbose":    "显示详细调试日志",
+		"cli.secret_level.ocr":        "开启 OCR 检测 (默认开启)",
+		"cli.secret_level.files_from": "从文件读取待扫描路径列表，- 表示标准输入 (指定后忽略 -d)",
+		"cli.secret_level.null":       "-files-from 的列表以 NUL 分隔 (配合 find -print0)",
+		"cli.fatal.access_dir":        "Fatal: 无法访问目标目录: %v",
+		"cli.fatal.not_dir":           "Fatal: 目标路径不是一个目录: %s",
+		"cli.fatal.read_list":         "Fatal: 读取文件列表失败: %v",
+		"cli.warn.skip_path":          "[WARN] 跳过 %s: %v",
+		"cli.scan.start":              "[INFO] 开始扫描... 并发数: %d",
+	},
+	EnUS: {
+		"level.top_secret":   "Top Secret",
+		"level.secret":       "Secret",
+		"level.confidential": "Confidential",
+		"level.internal":     "Internal",
+
+		"detect.secret_level.hit":     "Classification marking detected: %s",
+		"detect.sampled":              "large file sampled (%s)",
+		"detect.electronic.rule_desc": "Electronic classification label in metadata",
+		"detect.electronic.summary":   "Electronic classification label detected",
+		"detect.electronic.file_desc": "Electronic classification label found in metadata file '%s'",
+		"detect.electronic.in_image":  "Electronic classification label found in image",
+		"detect.electronic.in_doc":    "Electronic classification label found in document",
+		"detect.govcheck.hit":         "Official document layout detected",
+		"detect.govcheck.hit_detail":  "Official document layout detected (%s)",
+		"detect.govcheck.title_type":  "document type: %s",
+		"detect.govcheck.org":         "issuing authority: %s",
+		"detect.govcheck.confidence":  "confidence: %.1f%%",
+		"detect.govcheck.list_sep":    ", ",
+		"detect.govcheck.partial":     "partial extraction: memory budget exceeded, only the leading part was examined",
+		"detect.xattr_label.hit":      "Classification label in file extended attributes: %s",
+		"detect.xattr_label.source":   "labeled by: %s",
+		"exfil.rule_desc":             "%[4]d classified files copied to %[2]s %[3]s within %[1]s",
+		"exfil.dest.removable":        "removable media",
+		"exfil.dest.network":          "network mount",
+
+		"processor.op.validate":          "file validation",
+		"processor.op.extract":           "content extraction",
+		"processor.op.parse":             "parsing",
+		"processor.op.format":            "format check",
+		"processor.op.size":              "size check",
+		"processor.op.check":             "file check",
+		"processor.err.format_mismatch":  "expected format %s, got %s",
+		"processor.err.too_large":        "file size %d bytes exceeds limit of %d bytes",
+		"processor.err.empty":            "file is empty",
+		"processor.err.all_failed":       "all processors failed: %w",
+		"processor.err.none":             "no processor available",
+		"processor.err.unsupported_type": "unsupported file type: %s",
+		"processor.err.process":          "failed to process file: %w",
+
+		"cli.secret_level.dir":        "directory to scan",
+		"cli.secret_level.workers":    "number of concurrent workers",
+		"cli.secret_level.verbose":    "show verbose debug logs",
+		"cli.secret_level.ocr":        "enable OCR detection (on by default)",
+		"cli.secret_level.files_from": "read paths to scan from a file, - for stdin (-d is ignored)",
+		"cli.secret_level.null":       "-files-from list is NUL-delimited (for find -print0)",
+		"cli.fatal.access_dir":        "Fatal: cannot access target directory: %v",
+		"cli.fatal.not_dir":           "Fatal: target path is not a directory: %s",
+		"cli.fatal.read_list":         "Fatal: cannot read file list: %v",
+		"cli.warn.skip_path":          "[WARN] skipping %s: %v",
+		"cli.scan.start":              "[INFO] Scanning... workers: %d",
+	},
+}
@@ -0,0 +1,124 @@
+// Package instance 单实例保护
+// 通过数据目录下 PID 文件上的 flock 保证同一数据目录只有一个守护进程运行，
+// 避免多个进程同时写 SQLite 存储导致数据损坏
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PIDFileName 数据目录下的 PID 文件名，同时作为锁文件
+const PIDFileName = "filewatcherd.pid"
+
+// DefaultTakeoverTimeout 强制接管时等待旧实例退出的时间，超时后强制结束
+const DefaultTakeoverTimeout = 30 * time.Second
+
+// ErrNotSupported 当前平台不支持强制接管
+var ErrNotSupported = errors.New("instance: takeover not supported on this platform")
+
+// LockedError 另一实例持有锁
+type LockedError struct {
+	Path string
+	PID  int // 持有锁的进程，PID 文件不可读时为 0
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("another instance (pid %d) is running with lock %s; stop it first or use --force-takeover", e.PID, e.Path)
+	}
+	return fmt.Sprintf("another instance is running with lock %s; stop it first or use --force-takeover", e.Path)
+}
+
+// Options 加锁选项
+type Options struct {
+	// Force 锁被占用时结束持有锁的旧实例并接管
+	Force bool
+	// TakeoverTimeout 强制接管时等待旧实例正常退出的时间 (SIGTERM 后)，0 使用默认值
+	TakeoverTimeout time.Duration
+}
+
+// Lock 单实例锁，进程退出前调用 Release
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire 在 dir 下加锁并写入当前进程 PID
+// 锁被其他实例占用时返回 *LockedError；opts.Force 时先结束旧实例再加锁
+func Acquire(dir string, opts Options) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("instance: create data dir: %w", err)
+	}
+	path := filepath.Join(dir, PIDFileName)
+
+	l, err := acquire(path)
+	var locked *LockedError
+	if errors.As(err, &locked) && opts.Force {
+		timeout := opts.TakeoverTimeout
+		if timeout <= 0 {
+			timeout = DefaultTakeoverTimeout
+		}
+		l, err = takeover(path, locked.PID, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.writePID(); err != nil {
+		l.Release()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path PID 文件路径
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// Release 删除 PID 文件并释放锁
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	// 先删除再关闭 (释放锁)：新实例加锁后会校验路径仍指向已锁定的文件
+	err := os.Remove(l.path)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return err
+}
+
+// writePID 将当前进程 PID 写入已锁定的文件
+func (l *Lock) writePID() error {
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("instance: write pid file: %w", err)
+	}
+	if _, err := l.file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return fmt.Errorf("instance: write pid file: %w", err)
+	}
+	return l.file.Sync()
+}
+
+// ReadPID 读取 PID 文件中记录的进程号
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("instance: invalid pid file %s", path)
+	}
+	return pid, nil
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package instance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestAcquire(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+
+	l, err := Acquire(dir, Options{})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	pid, err := ReadPID(l.Path())
+	if err != nil || pid != os.Getpid() {
+		t.Errorf("ReadPID = %d, %v, want %d", pid, err, os.Getpid())
+	}
+
+	// flock 作用于打开的文件描述，同一进程再次加锁也会冲突
+	_, err = Acquire(dir, Options{})
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("second Acquire error = %v, want *LockedError", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Errorf("LockedError.PID = %d, want %d", locked.PID, os.Getpid())
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := os.Stat(l.Path()); !os.IsNotExist(err) {
+		t.Errorf("pid file still exists after Release: %v", err)
+	}
+
+	l2, err := Acquire(dir, Options{})
+	if err != nil {
+		t.Fatalf("Acquire after Release: %v", err)
+	}
+	l2.Release()
+}
+
+func TestAcquireStalePIDFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, PIDFileName)
+	// 旧实例异常退出遗留的 PID 文件不持有锁
+	if err := os.WriteFile(path, []byte("999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Acquire(dir, Options{})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer l.Release()
+
+	data, _ := os.ReadFile(path)
+	if string(data) != strconv.Itoa(os.Getpid())+"\n" {
+		t.Errorf("pid file = %q", data)
+	}
+}
+
+func TestTakeoverRefusesSelf(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Acquire(dir, Options{})
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	defer l.Release()
+
+	// 锁由本进程持有，不能向自己发信号
+	if _, err := Acquire(dir, Options{Force: true}); err == nil {
+		t.Fatal("forced Acquire against own lock succeeded")
+	}
+}
+
+func TestLockedErrorMessage(t *testing.T) {
+	err := &LockedError{Path: "/var/lib/x/filewatcherd.pid", PID: 42}
+	want := "another instance (pid 42) is running with lock /var/lib/x/filewatcherd.pid; stop it first or use --force-takeover"
+	if err.Error() != want {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// takeoverPoll 强制接管时检查锁是否释放的间隔
+const takeoverPoll = 100 * time.Millisecond
+
+// acquire 以非阻塞 flock 锁定 PID 文件
+func acquire(path string) (*Lock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|syscall.O_CLOEXEC, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("instance: open pid file: %w", err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			f.Close()
+			if errors.Is(err, syscall.EWOULDBLOCK) {
+				pid, _ := ReadPID(path)
+				return nil, &LockedError{Path: path, PID: pid}
+			}
+			return nil, fmt.Errorf("instance: lock pid file: %w", err)
+		}
+
+		// 旧实例在我们打开后、加锁前删除了文件：锁住的是已删除的文件，重新打开
+		if sameFile(f, path) {
+			return &Lock{path: path, file: f}, nil
+		}
+		f.Close()
+	}
+}
+
+// sameFile 已打开的文件与路径当前指向的文件是否相同
+func sameFile(f *os.File, path string) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	pi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi, pi)
+}
+
+// takeover 结束持有锁的旧实例并加锁
+// 先发送 SIGTERM 等待其优雅退出 (落盘缓存数据)，超时后发送 SIGKILL
+func takeover(path string, pid int, timeout time.Duration) (*Lock, error) {
+	if pid <= 1 || pid == os.Getpid() {
+		return nil, fmt.Errorf("instance: cannot take over lock %s: invalid owner pid %d", path, pid)
+	}
+
+	logger.Warn("强制接管单实例锁，结束旧实例", "pid", pid, "path", path)
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return nil, fmt.Errorf("instance: signal pid %d: %w", pid, err)
+	}
+	if l, err := waitLock(path, timeout); err == nil {
+		return l, nil
+	}
+
+	logger.Warn("旧实例未在期限内退出，强制结束", "pid", pid, "timeout", timeout)
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return nil, fmt.Errorf("instance: kill pid %d: %w", pid, err)
+	}
+	return waitLock(path, 5*time.Second)
+}
+
+// waitLock 在期限内轮询加锁
+func waitLock(path string, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		l, err := acquire(path)
+		var locked *LockedError
+		if err == nil || !errors.As(err, &locked) || time.Now().After(deadline) {
+			return l, err
+		}
+		time.Sleep(takeoverPoll)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package instance
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// acquire 非 Linux 平台不加锁，仅维护 PID 文件
+func acquire(path string) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("instance: open pid file: %w", err)
+	}
+	return &Lock{path: path, file: f}, nil
+}
+
+// takeover 非 Linux 平台不支持
+func takeover(path string, pid int, timeout time.Duration) (*Lock, error) {
+	return nil, ErrNotSupported
+}
@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// modulePrefix 项目内包路径前缀，用于从调用方函数名推导模块名
+const modulePrefix = "linuxFileWatcher/"
+
+// groupDirs 仅用于归类的目录，模块名取其下一级目录
+// 如 internal/security/netguard/dnsguard 的模块名为 netguard
+var groupDirs = map[string]bool{
+	"internal": true,
+	"security": true,
+	"service":  true,
+}
+
+// ParseLevel 解析日志级别: debug, info, warn (warning), error
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("未知的日志级别: %s", s)
+}
+
+// levelName 日志级别的配置写法
+func levelName(l slog.Level) string {
+	return strings.ToLower(l.String())
+}
+
+// levels 全局级别与按模块覆盖的级别，整体替换以便无锁读取
+type levels struct {
+	global  slog.Level
+	modules map[string]slog.Level
+	// min 所有级别中的最低值，低于它的日志无需解析调用方
+	min slog.Level
+}
+
+func newLevels(global slog.Level, modules map[string]slog.Level) *levels {
+	l := &levels{global: global, modules: modules, min: global}
+	for _, lv := range modules {
+		if lv < l.min {
+			l.min = lv
+		}
+	}
+	return l
+}
+
+// of 返回模块生效的级别，未覆盖的模块使用全局级别
+func (l *levels) of(module string) slog.Level {
+	if lv, ok := l.modules[module]; ok {
+		return lv
+	}
+	return l.global
+}
+
+// LevelSet 运行时可调整的日志级别
+type LevelSet struct {
+	mu  sync.Mutex // 串行化修改
+	cur atomic.Pointer[levels]
+}
+
+// NewLevelSet 按全局级别与模块级别 (模块名 -> 级别) 创建
+func NewLevelSet(global string, modules map[string]string) (*LevelSet, error) {
+	s := &LevelSet{}
+	if err := s.Reset(global, modules); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reset 整体替换全局级别与模块级别，运行时的调整被覆盖
+func (s *LevelSet) Reset(global string, modules map[string]string) error {
+	g, err := ParseLevel(global)
+	if err != nil {
+		return err
+	}
+	m := make(map[string]slog.Level, len(modules))
+	for name, lv := range modules {
+		parsed, err := ParseLevel(lv)
+		if err != nil {
+			return fmt.Errorf("模块 %s: %w", name, err)
+		}
+		m[strings.ToLower(name)] = parsed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur.Store(newLevels(g, m))
+	return nil
+}
+
+// Set 调整单个模块的级别；module 为空时调整全局级别，level 为空时取消该模块的覆盖
+func (s *LevelSet) Set(module, level string) error {
+	module = strings.ToLower(strings.TrimSpace(module))
+	if module == "" && level == "" {
+		return fmt.Errorf("全局日志级别不能为空")
+	}
+	var lv slog.Level
+	if level != "" {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		lv = parsed
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.cur.Load()
+	if module == "" {
+		s.cur.Store(newLevels(lv, old.modules))
+		return nil
+	}
+	m := make(map[string]slog.Level, len(old.modules)+1)
+	for k, v := range old.modules {
+		m[k] = v
+	}
+	if level == "" {
+		delete(m, module)
+	} else {
+		m[module] = lv
+	}
+	s.cur.Store(newLevels(old.global, m))
+	return nil
+}
+
+// Levels 返回全局级别与模块级别
+func (s *LevelSet) Levels() (string, map[string]string) {
+	l := s.cur.Load()
+	m := make(map[string]string, len(l.modules))
+	for k, v := range l.modules {
+		m[k] = levelName(v)
+	}
+	return levelName(l.global), m
+}
+
+// Enabled 判断模块在该级别是否输出
+func (s *LevelSet) Enabled(module string, level slog.Level) bool {
+	return level >= s.cur.Load().of(module)
+}
+
+// minLevel 所有级别中的最低值
+func (s *LevelSet) minLevel() slog.Level {
+	return s.cur.Load().min
+}
+
+// moduleCache 调用位置 -> 模块名
+var moduleCache sync.Map
+
+// moduleOf 由调用位置推导模块名: 项目内包路径中第一个非归类目录
+// 如 internal/detector/file_hash -> detector；main 包的函数名不含路径，模块名为 main
+func moduleOf(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	if m, ok := moduleCache.Load(pc); ok {
+		return m.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	m := moduleFromFunc(frame.Function)
+	moduleCache.Store(pc, m)
+	return m
+}
+
+// moduleFromFunc 由完整函数名 (包路径.函数) 推导模块名
+func moduleFromFunc(fn string) string {
+	// 去掉函数部分: 包路径最后一段之后的第一个点
+	pkg := fn
+	slash := strings.LastIndexByte(pkg, '/')
+	if dot := strings.IndexByte(pkg[slash+1:], '.'); dot >= 0 {
+		pkg = pkg[:slash+1+dot]
+	}
+	if !strings.HasPrefix(pkg, modulePrefix) {
+		return pkg
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(pkg, modulePrefix), "/") {
+		if !groupDirs[part] {
+			return part
+		}
+	}
+	return pkg
+}
@@ -0,0 +1,259 @@
+// Package logger 全局结构化日志：文本或 JSON 输出、按大小轮转、按模块设置级别并支持运行时调整
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ModuleKey 日志中记录模块名的字段
+const ModuleKey = "component"
+
+// Options 日志初始化参数
+type Options struct {
+	// 全局级别: debug, info, warn, error
+	Level string
+	// 输出格式: text (默认) / json
+	Format string
+	// 按模块覆盖级别，如 {"detector": "debug", "netguard": "info"}
+	// 模块名为 internal 下的包目录，security/service 下取其下一级目录
+	Modules map[string]string
+
+	// 日志文件路径，为空时输出到标准错误
+	FilePath   string
+	MaxSize    int // MB
+	MaxBackups int
+	MaxAge     int // 天
+	Compress   bool
+	// 同时输出到标准输出
+	Stdout bool
+
+	// 远程投递，nil 时不投递
+	Ship *ShipConfig
+}
+
+var (
+	// std 当前生效的日志处理器，Setup 前输出到标准错误
+	std atomic.Pointer[Handler]
+	// closer 当前日志文件，重新初始化时关闭
+	closer atomic.Pointer[lumberjack.Logger]
+	// shipper 当前远程投递，重新初始化时关闭
+	shipper atomic.Pointer[Shipper]
+)
+
+func init() {
+	levels, _ := NewLevelSet("info", nil)
+	std.Store(NewHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+}
+
+// Setup 按参数初始化全局日志，可重复调用
+func Setup(opts Options) error {
+	levels, err := NewLevelSet(opts.Level, opts.Modules)
+	if err != nil {
+		return err
+	}
+
+	var writers []io.Writer
+	var file *lumberjack.Logger
+	if opts.FilePath != "" {
+		if err := os.MkdirAll(filepath.Dir(opts.FilePath), 0755); err != nil {
+			return fmt.Errorf("创建日志目录失败: %w", err)
+		}
+		file = &lumberjack.Logger{
+			Filename:   opts.FilePath,
+			MaxSize:    opts.MaxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAge,
+			Compress:   opts.Compress,
+			LocalTime:  true,
+		}
+		writers = append(writers, file)
+	}
+	if opts.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+	if len(writers) == 0 {
+		writers = append(writers, os.Stderr)
+	}
+
+	inner, err := newInner(opts.Format, io.MultiWriter(writers...))
+	if err != nil {
+		return err
+	}
+	var ship *Shipper
+	if opts.Ship != nil {
+		if ship, err = NewShipper(*opts.Ship); err != nil {
+			return fmt.Errorf("日志远程投递配置无效: %w", err)
+		}
+		inner = teeHandler{inner, ship.Handler()}
+	}
+	h := NewHandler(inner, levels)
+	std.Store(h)
+	slog.SetDefault(slog.New(h))
+
+	if old := closer.Swap(file); old != nil {
+		old.Close()
+	}
+	if old := shipper.Swap(ship); old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// Close 发送剩余的远程日志并关闭日志文件，之后的日志输出到标准错误
+func Close() {
+	levels := std.Load().levels
+	std.Store(NewHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+	if old := shipper.Swap(nil); old != nil {
+		old.Close()
+	}
+	if old := closer.Swap(nil); old != nil {
+		old.Close()
+	}
+}
+
+// ShipperStats 返回远程投递统计，未启用时 ok 为 false
+func ShipperStats() (stats ShipStats, ok bool) {
+	s := shipper.Load()
+	if s == nil {
+		return ShipStats{}, false
+	}
+	return s.Stats(), true
+}
+
+// newInner 按格式创建底层处理器，级别过滤由 Handler 完成
+func newInner(format string, w io.Writer) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("未知的日志格式: %s", format)
+}
+
+// Handler 按调用方所在模块过滤级别，并在记录中附带模块名
+type Handler struct {
+	inner  slog.Handler
+	levels *LevelSet
+}
+
+// NewHandler 包装底层处理器，inner 自身不应再过滤级别
+func NewHandler(inner slog.Handler, levels *LevelSet) *Handler {
+	return &Handler{inner: inner, levels: levels}
+}
+
+func (h *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.levels.minLevel()
+}
+
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	module := moduleOf(r.PC)
+	if !h.levels.Enabled(module, r.Level) || !h.inner.Enabled(ctx, r.Level) {
+		return nil
+	}
+	if module != "" {
+		r.AddAttrs(slog.String(ModuleKey, module))
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{inner: h.inner.WithAttrs(attrs), levels: h.levels}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name), levels: h.levels}
+}
+
+// teeHandler 同时写入多个处理器，各处理器按自身级别过滤
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// Levels 返回当前的全局级别与模块级别
+func Levels() (string, map[string]string) {
+	return std.Load().levels.Levels()
+}
+
+// SetLevel 运行时调整日志级别；module 为空时调整全局级别，level 为空时取消该模块的覆盖
+func SetLevel(module, level string) error {
+	return std.Load().levels.Set(module, level)
+}
+
+// ResetLevels 按配置整体替换级别 (如配置热加载)，运行时的调整被覆盖
+func ResetLevels(global string, modules map[string]string) error {
+	return std.Load().levels.Reset(global, modules)
+}
+
+func Debug(msg string, args ...any) { log(slog.LevelDebug, msg, args) }
+func Info(msg string, args ...any)  { log(slog.LevelInfo, msg, args) }
+func Warn(msg string, args ...any)  { log(slog.LevelWarn, msg, args) }
+func Error(msg string, args ...any) { log(slog.LevelError, msg, args) }
+
+// log 记录调用方位置以便按模块过滤
+func log(level slog.Level, msg string, args []any) {
+	h := std.Load()
+	ctx := context.Background()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // 跳过 Callers、log 与 Info 等导出函数
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = h.Handle(ctx, r)
+}
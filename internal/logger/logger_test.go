@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestModuleFromFunc(t *testing.T) {
+	cases := map[string]string{
+		"linuxFileWatcher/internal/detector/file_hash.(*Detector).Detect":   "detector",
+		"linuxFileWatcher/internal/security/netguard/dnsguard.(*Guard).run": "netguard",
+		"linuxFileWatcher/internal/service/rulestats.(*Tracker).Wrap.func1": "rulestats",
+		"linuxFileWatcher/internal/storage.NewKeyedStore[...]":              "storage",
+		"main.initLogger": "main",
+		"github.com/fsnotify/fsnotify.(*Watcher).readEvents": "github.com/fsnotify/fsnotify",
+	}
+	for fn, want := range cases {
+		if got := moduleFromFunc(fn); got != want {
+			t.Errorf("moduleFromFunc(%q) = %q, want %q", fn, got, want)
+		}
+	}
+}
+
+func TestLevelSet(t *testing.T) {
+	s, err := NewLevelSet("info", map[string]string{"Detector": "debug", "netguard": "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Enabled("detector", slog.LevelDebug) || s.Enabled("netguard", slog.LevelInfo) || s.Enabled("storage", slog.LevelDebug) {
+		t.Error("module levels not applied")
+	}
+	if s.minLevel() != slog.LevelDebug {
+		t.Errorf("minLevel = %v", s.minLevel())
+	}
+
+	if err := s.Set("netguard", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("", "error"); err != nil {
+		t.Fatal(err)
+	}
+	global, modules := s.Levels()
+	if global != "error" || len(modules) != 1 || modules["detector"] != "debug" {
+		t.Errorf("Levels() = %s, %v", global, modules)
+	}
+
+	if err := s.Set("detector", "verbose"); err == nil {
+		t.Error("Set(verbose) should fail")
+	}
+	if err := s.Set("", ""); err == nil {
+		t.Error("Set(global, empty) should fail")
+	}
+	if _, err := NewLevelSet("info", map[string]string{"x": "loud"}); err == nil {
+		t.Error("NewLevelSet(loud) should fail")
+	}
+}
+
+func TestHandler_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	inner, err := newInner(FormatJSON, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels, _ := NewLevelSet("warn", nil)
+	old := std.Swap(NewHandler(inner, levels))
+	defer std.Store(old)
+
+	Info("丢弃")
+	if buf.Len() != 0 {
+		t.Fatalf("info logged at warn level: %s", buf.String())
+	}
+
+	// 仅对本模块开启 debug
+	if err := SetLevel("logger", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debug("调试", "path", "/tmp/a", "n", 3)
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output is not JSON: %q", buf.String())
+	}
+	if rec["msg"] != "调试" || rec["level"] != "DEBUG" || rec["path"] != "/tmp/a" || rec[ModuleKey] != "logger" {
+		t.Errorf("record = %v", rec)
+	}
+
+	buf.Reset()
+	if err := ResetLevels("warn", map[string]string{"detector": "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	Debug("丢弃")
+	Warn("警告")
+	if out := buf.String(); strings.Contains(out, "丢弃") || !strings.Contains(out, "警告") {
+		t.Errorf("output after reset = %q", out)
+	}
+
+	if _, err := newInner("xml", &buf); err == nil {
+		t.Error("newInner(xml) should fail")
+	}
+}
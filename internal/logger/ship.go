@@ -0,0 +1,450 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 远程投递协议
+const (
+	ShipSyslog = "syslog"
+	ShipHTTP   = "http"
+)
+
+// 远程投递默认参数
+const (
+	defaultShipBuffer   = 10000
+	defaultShipBatch    = 200
+	defaultShipFlush    = 2 * time.Second
+	defaultShipTimeout  = 10 * time.Second
+	shipMinBackoff      = time.Second
+	shipMaxBackoff      = time.Minute
+	syslogFacility      = 3 // daemon
+	syslogAppName       = "filewatcherd"
+	syslogMaxUDPMessage = 8192
+)
+
+// ShipConfig 日志远程投递参数
+type ShipConfig struct {
+	// 协议: syslog (RFC 5424) / http (批量 POST，JSON Lines)
+	Protocol string
+	// syslog: udp://host:514 或 tcp://host:601；http: 接收端 URL
+	Address string
+	// HTTP 附加请求头 (鉴权等)
+	Headers map[string]string
+	// 投递的最低级别，为空时 info；在本地 (含模块) 级别之上再过滤，避免 debug 日志刷屏远端
+	Level string
+	// 本地缓冲上限 (条)，远端不可达时缓冲，满后丢弃最旧的日志
+	BufferSize int
+	// 单次发送条数上限
+	BatchSize int
+	// 发送间隔
+	FlushInterval time.Duration
+	// 单次发送超时
+	Timeout time.Duration
+}
+
+// shipEntry 一条待投递的日志
+type shipEntry struct {
+	level slog.Level
+	time  time.Time
+	line  []byte // JSON，不含换行
+}
+
+// shipSender 批量发送，失败时整批保留重试
+type shipSender interface {
+	send(ctx context.Context, entries []shipEntry) error
+	close()
+}
+
+// Shipper 将日志异步投递到远端：写入只进本地缓冲，不阻塞调用方；
+// 远端不可达时保留缓冲并退避重试，缓冲满时丢弃最旧的日志，恢复后补发丢弃计数
+type Shipper struct {
+	cfg    ShipConfig
+	level  slog.Level
+	sender shipSender
+	host   string
+
+	mu      sync.Mutex
+	buf     []shipEntry
+	dropped int64
+	closed  bool
+
+	sent    atomic.Int64
+	failing atomic.Bool
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+
+	minBackoff time.Duration
+}
+
+// ShipStats 投递统计
+type ShipStats struct {
+	Sent     int64 `json:"sent"`
+	Buffered int   `json:"buffered"`
+	Dropped  int64 `json:"dropped"`
+	Failing  bool  `json:"failing"`
+}
+
+// NewShipper 校验参数并启动后台投递
+func NewShipper(cfg ShipConfig) (*Shipper, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultShipBuffer
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultShipBatch
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultShipFlush
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultShipTimeout
+	}
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "-"
+	}
+
+	var sender shipSender
+	switch cfg.Protocol {
+	case ShipSyslog:
+		u, err := url.Parse(cfg.Address)
+		if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+			return nil, fmt.Errorf("无效的 syslog 地址: %q (应为 udp://host:port 或 tcp://host:port)", cfg.Address)
+		}
+		sender = &syslogSender{network: u.Scheme, addr: u.Host, host: host, timeout: cfg.Timeout}
+	case ShipHTTP:
+		u, err := url.Parse(cfg.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("无效的日志接收地址: %q", cfg.Address)
+		}
+		sender = &httpSender{url: cfg.Address, headers: cfg.Headers, client: &http.Client{Timeout: cfg.Timeout}}
+	default:
+		return nil, fmt.Errorf("未知的日志投递协议: %q", cfg.Protocol)
+	}
+
+	s := &Shipper{
+		cfg:        cfg,
+		level:      level,
+		sender:     sender,
+		host:       host,
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		minBackoff: shipMinBackoff,
+	}
+	go s.loop()
+	return s, nil
+}
+
+// enqueue 追加到缓冲，满时丢弃最旧的
+func (s *Shipper) enqueue(e shipEntry) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.buf) >= s.cfg.BufferSize {
+		n := len(s.buf) - s.cfg.BufferSize + 1
+		s.buf = append(s.buf[:0], s.buf[n:]...)
+		s.dropped += int64(n)
+	}
+	s.buf = append(s.buf, e)
+	full := len(s.buf) >= s.cfg.BatchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Stats 返回投递统计
+func (s *Shipper) Stats() ShipStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ShipStats{Sent: s.sent.Load(), Buffered: len(s.buf), Dropped: s.dropped, Failing: s.failing.Load()}
+}
+
+// Close 停止投递，在超时内尽量发送剩余日志
+func (s *Shipper) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+	s.sender.close()
+	return nil
+}
+
+func (s *Shipper) loop() {
+	defer close(s.done)
+
+	timer := time.NewTimer(s.cfg.FlushInterval)
+	defer timer.Stop()
+	var backoff time.Duration
+	for {
+		select {
+		case <-s.stop:
+			// 退出前尽量发送，远端不可达时放弃
+			for s.flush() {
+			}
+			return
+		case <-s.wake:
+			if backoff > 0 {
+				continue // 退避期间不提前重试
+			}
+		case <-timer.C:
+		}
+
+		for s.flush() {
+		}
+		if s.failing.Load() {
+			backoff = min(max(backoff*2, s.minBackoff), shipMaxBackoff)
+			timer.Reset(backoff)
+		} else {
+			backoff = 0
+			timer.Reset(s.cfg.FlushInterval)
+		}
+	}
+}
+
+// flush 发送一批，成功且还有剩余时返回 true
+func (s *Shipper) flush() bool {
+	s.mu.Lock()
+	n := min(len(s.buf), s.cfg.BatchSize)
+	batch := append([]shipEntry(nil), s.buf[:n]...)
+	dropped := s.dropped
+	s.mu.Unlock()
+
+	// 补发丢弃计数，让远端知道日志不完整
+	if dropped > 0 {
+		batch = append([]shipEntry{s.droppedEntry(dropped)}, batch...)
+	}
+	if len(batch) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	err := s.sender.send(ctx, batch)
+	cancel()
+	if err != nil {
+		if !s.failing.Swap(true) {
+			// 此条日志也进入缓冲，远端恢复后一并送达
+			Warn("日志远程投递失败，暂存本地缓冲", "protocol", s.cfg.Protocol, "address", s.cfg.Address, "error", err)
+		}
+		return false
+	}
+	if s.failing.Swap(false) {
+		Info("日志远程投递已恢复", "protocol", s.cfg.Protocol, "address", s.cfg.Address)
+	}
+
+	s.mu.Lock()
+	// 发送期间缓冲可能因溢出丢弃了头部，这部分已送达，不计入丢弃
+	sentDropped := min(int64(n), s.dropped-dropped)
+	s.buf = append(s.buf[:0], s.buf[n-int(sentDropped):]...)
+	s.dropped -= dropped + sentDropped
+	more := len(s.buf) > 0
+	s.mu.Unlock()
+	s.sent.Add(int64(len(batch)))
+	return more
+}
+
+// droppedEntry 缓冲溢出提示
+func (s *Shipper) droppedEntry(n int64) shipEntry {
+	now := time.Now()
+	line, _ := json.Marshal(map[string]any{
+		"time":    now.Format(time.RFC3339Nano),
+		"level":   slog.LevelWarn.String(),
+		"msg":     "日志投递缓冲已满，丢弃最旧的日志",
+		"host":    s.host,
+		"dropped": n,
+		ModuleKey: "logger",
+	})
+	return shipEntry{level: slog.LevelWarn, time: now, line: line}
+}
+
+// shipHandler 将记录格式化为 JSON 后放入投递缓冲
+type shipHandler struct {
+	s     *Shipper
+	state *shipFormat
+	inner slog.Handler // 写入 state.buf 的 JSON 处理器
+}
+
+// shipFormat 同一投递器派生的处理器共享的格式化缓冲
+type shipFormat struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Handler 返回写入投递缓冲的处理器，JSON 中附带主机名
+func (s *Shipper) Handler() slog.Handler {
+	st := &shipFormat{}
+	inner := slog.NewJSONHandler(&st.buf, &slog.HandlerOptions{Level: s.level}).
+		WithAttrs([]slog.Attr{slog.String("host", s.host)})
+	return &shipHandler{s: s, state: st, inner: inner}
+}
+
+func (h *shipHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.s.level
+}
+
+func (h *shipHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.state.mu.Lock()
+	h.state.buf.Reset()
+	err := h.inner.Handle(ctx, r)
+	line := bytes.TrimRight(h.state.buf.Bytes(), "\n")
+	line = append([]byte(nil), line...)
+	h.state.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	h.s.enqueue(shipEntry{level: r.Level, time: r.Time, line: line})
+	return nil
+}
+
+func (h *shipHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &shipHandler{s: h.s, state: h.state, inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *shipHandler) WithGroup(name string) slog.Handler {
+	return &shipHandler{s: h.s, state: h.state, inner: h.inner.WithGroup(name)}
+}
+
+// ==========================================
+// 发送端
+// ==========================================
+
+// syslogSender RFC 5424 syslog，消息体为 JSON；TCP 使用八位组计数分帧 (RFC 6587)
+type syslogSender struct {
+	network string
+	addr    string
+	host    string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func (s *syslogSender) send(ctx context.Context, entries []shipEntry) error {
+	if s.conn == nil {
+		d := net.Dialer{Timeout: s.timeout}
+		conn, err := d.DialContext(ctx, s.network, s.addr)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		s.conn.SetWriteDeadline(dl)
+	}
+
+	var buf bytes.Buffer
+	for _, e := range entries {
+		msg := s.format(e)
+		if s.network == "udp" {
+			if len(msg) > syslogMaxUDPMessage {
+				msg = msg[:syslogMaxUDPMessage]
+			}
+			if _, err := s.conn.Write(msg); err != nil {
+				s.close()
+				return err
+			}
+			continue
+		}
+		buf.WriteString(strconv.Itoa(len(msg)))
+		buf.WriteByte(' ')
+		buf.Write(msg)
+	}
+	if buf.Len() > 0 {
+		if _, err := s.conn.Write(buf.Bytes()); err != nil {
+			s.close()
+			return err
+		}
+	}
+	return nil
+}
+
+// format <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+func (s *syslogSender) format(e shipEntry) []byte {
+	pri := syslogFacility*8 + syslogSeverity(e.level)
+	head := fmt.Sprintf("<%d>1 %s %s %s %d - - ", pri, e.time.Format(time.RFC3339Nano), s.host, syslogAppName, os.Getpid())
+	return append([]byte(head), e.line...)
+}
+
+func (s *syslogSender) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// syslogSeverity 日志级别对应的 syslog 严重程度
+func syslogSeverity(l slog.Level) int {
+	switch {
+	case l >= slog.LevelError:
+		return 3
+	case l >= slog.LevelWarn:
+		return 4
+	case l >= slog.LevelInfo:
+		return 6
+	}
+	return 7
+}
+
+// httpSender 以 JSON Lines 批量 POST
+type httpSender struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (h *httpSender) send(ctx context.Context, entries []shipEntry) error {
+	var body bytes.Buffer
+	for _, e := range entries {
+		body.Write(e.line)
+		body.WriteByte('\n')
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("日志接收端返回 %s", resp.Status)
+	}
+	return nil
+}
+
+func (h *httpSender) close() {
+	h.client.CloseIdleConnections()
+}
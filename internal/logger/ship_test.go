@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender 可切换失败的发送端
+type fakeSender struct {
+	mu    sync.Mutex
+	fail  bool
+	lines []string
+}
+
+func (f *fakeSender) send(_ context.Context, entries []shipEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("unreachable")
+	}
+	for _, e := range entries {
+		f.lines = append(f.lines, string(e.line))
+	}
+	return nil
+}
+
+func (f *fakeSender) close() {}
+
+func (f *fakeSender) setFail(v bool) {
+	f.mu.Lock()
+	f.fail = v
+	f.mu.Unlock()
+}
+
+func (f *fakeSender) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.lines...)
+}
+
+func newTestShipper(t *testing.T, sender shipSender, buffer int) *Shipper {
+	s := &Shipper{
+		cfg:        ShipConfig{BufferSize: buffer, BatchSize: 2, FlushInterval: time.Hour, Timeout: time.Second},
+		sender:     sender,
+		host:       "h1",
+		wake:       make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		minBackoff: time.Millisecond,
+	}
+	go s.loop()
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func record(msg string) slog.Record {
+	return slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+}
+
+func TestShipper_BufferAndRetry(t *testing.T) {
+	sender := &fakeSender{fail: true}
+	s := newTestShipper(t, sender, 3)
+	h := s.Handler()
+
+	// 远端不可达时缓冲，超过上限丢弃最旧的
+	for _, msg := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		h.Handle(context.Background(), record(msg))
+	}
+	s.flush()
+	if st := s.Stats(); st.Buffered != 3 || st.Dropped != 2 || !st.Failing {
+		t.Fatalf("Stats() while failing = %+v", st)
+	}
+
+	sender.setFail(false)
+	for s.flush() {
+	}
+	got := sender.received()
+	if len(got) != 4 || !strings.Contains(got[0], `"dropped":2`) || !strings.Contains(got[1], `"msg":"m3"`) ||
+		!strings.Contains(got[3], `"msg":"m5"`) || !strings.Contains(got[1], `"host":"h1"`) {
+		t.Fatalf("received = %q", got)
+	}
+	if st := s.Stats(); st.Buffered != 0 || st.Dropped != 0 || st.Failing || st.Sent != 4 {
+		t.Errorf("Stats() after recovery = %+v", st)
+	}
+}
+
+func TestShipper_HTTP(t *testing.T) {
+	lines := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var m map[string]any
+			json.Unmarshal(sc.Bytes(), &m)
+			lines <- m
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewShipper(ShipConfig{Protocol: ShipHTTP, Address: srv.URL, Level: "warn",
+		Headers: map[string]string{"Authorization": "Bearer t"}, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	levels, _ := NewLevelSet("debug", nil)
+	h := NewHandler(s.Handler(), levels)
+	old := std.Swap(h)
+	defer std.Store(old)
+
+	Info("低于投递级别")
+	Error("远程错误", "path", "/a")
+
+	select {
+	case m := <-lines:
+		if m["msg"] != "远程错误" || m["path"] != "/a" || m[ModuleKey] != "logger" || m["level"] != "ERROR" {
+			t.Errorf("shipped = %v", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no log shipped")
+	}
+	select {
+	case m := <-lines:
+		t.Errorf("unexpected log shipped: %v", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestShipper_SyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		// 八位组计数分帧: "长度 消息"
+		size, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(size))
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err == nil {
+			got <- string(msg)
+		}
+	}()
+
+	s, err := NewShipper(ShipConfig{Protocol: ShipSyslog, Address: "tcp://" + ln.Addr().String(), FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "磁盘空间不足", 0))
+
+	select {
+	case msg := <-got:
+		// daemon(3)*8 + warning(4) = 28
+		if !strings.HasPrefix(msg, "<28>1 ") || !strings.Contains(msg, " filewatcherd ") || !strings.Contains(msg, `"msg":"磁盘空间不足"`) {
+			t.Errorf("syslog message = %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no syslog message")
+	}
+}
+
+func TestNewShipper_Invalid(t *testing.T) {
+	for _, cfg := range []ShipConfig{
+		{Protocol: "kafka", Address: "x"},
+		{Protocol: ShipSyslog, Address: "127.0.0.1:514"},
+		{Protocol: ShipHTTP, Address: "ftp://x"},
+		{Protocol: ShipHTTP, Address: "http://x", Level: "loud"},
+	} {
+		if s, err := NewShipper(cfg); err == nil {
+			s.Close()
+			t.Errorf("NewShipper(%+v) should fail", cfg)
+		}
+	}
+}
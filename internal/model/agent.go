@@ -0,0 +1,22 @@
+package model
+
+import "sync/atomic"
+
+// ==========================================
+// 本机稳定标识
+// ==========================================
+
+// agentUUID 由硬件派生的本机稳定标识 (config.InitAgentUUID)，主程序初始化后设置
+// 各上报模型的构造函数自动填入，服务端据此识别重装后的同一终端
+var agentUUID atomic.Value
+
+// SetAgentUUID 设置本机稳定标识
+func SetAgentUUID(id string) {
+	agentUUID.Store(id)
+}
+
+// AgentUUID 返回本机稳定标识，未设置时为空
+func AgentUUID() string {
+	id, _ := agentUUID.Load().(string)
+	return id
+}
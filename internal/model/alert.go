@@ -1,5 +1,7 @@
 package model
 
+import "encoding/json"
+
 // ==========================================
 // 告警记录 - 数据模型
 // ==========================================
@@ -48,6 +50,21 @@ type AlertRecord struct {
 	FileLevel int `json:"file_xxx_level" gorm:"type:int"`
 	// 扩展字段：other
 	ExtendFields string `json:"extend_fields" gorm:"type:text"`
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" gorm:"-" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" gorm:"type:varchar(36)" since:"2"`
+
+	// 写入文件的进程 (来自文件监控 fanotify 事件，未知时为空)
+	ProcessPID     int    `json:"process_pid,omitempty" gorm:"type:int" since:"2"`
+	ProcessExe     string `json:"process_exe,omitempty" gorm:"type:text" since:"2"`
+	ProcessCmdline string `json:"process_cmdline,omitempty" gorm:"type:text" since:"2"`
+	ProcessUser    string `json:"process_user,omitempty" gorm:"type:varchar(256)" since:"2"`
+	// 写入文件的进程所属的登录会话 (多用户服务器上的实际操作人)，UserName 为终端登记的责任人
+	SessionInfo `gorm:"embedded"`
+
+	// 命中的检测模块 (Module* 常量)，用于按模块匹配处置策略
+	DetectModule string `json:"detect_module,omitempty" gorm:"type:varchar(64)" since:"2"`
 }
 
 // TableName 自定义表名
@@ -55,6 +72,39 @@ func (AlertRecord) TableName() string {
 	return "alert_records"
 }
 
+// SetProcess 填充写入文件的进程信息
+func (r *AlertRecord) SetProcess(p *ProcessInfo) {
+	if p == nil {
+		return
+	}
+	r.ProcessPID = p.PID
+	r.ProcessExe = p.Exe
+	r.ProcessCmdline = p.Cmdline
+	r.ProcessUser = p.User
+	r.SessionInfo = p.SessionInfo
+	// 终端未登记责任人时以登录用户代替
+	if r.UserName == "" {
+		r.UserName = p.LoginUser
+	}
+}
+
+// AddExtendFields 向扩展字段追加键值
+// 原扩展字段为 JSON 对象时合并，否则原内容保存在 "extend" 键下
+func (r *AlertRecord) AddExtendFields(fields map[string]interface{}) {
+	merged := make(map[string]interface{}, len(fields)+1)
+	if r.ExtendFields != "" {
+		if err := json.Unmarshal([]byte(r.ExtendFields), &merged); err != nil || merged == nil {
+			merged = map[string]interface{}{"extend": r.ExtendFields}
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	if data, err := json.Marshal(merged); err == nil {
+		r.ExtendFields = string(data)
+	}
+}
+
 // ==========================================
 // 辅助构造函数
 // ==========================================
@@ -81,5 +131,7 @@ func NewAlertRecord(id string) *AlertRecord {
 		UserID:        "",
 		FileLevel:     0,
 		ExtendFields:  "",
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
 	}
 }
@@ -14,6 +14,11 @@ type AlertLogReport struct {
 	// 管理系统下发的指令 ID: 字符串, 64 字节
 	CmdID string `json:"cmd_id"`
 
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
+
 	// 日志上报时间: 时间类型 (字符串格式)
 	Time string `json:"time"`
 
@@ -56,9 +61,11 @@ type AlertLogResponse struct {
 // NewAlertLogReport 创建新的审计报告
 func NewAlertLogReport(cmdID string) *AlertLogReport {
 	return &AlertLogReport{
-		CmdID:     cmdID,
-		Time:      time.Now().Format("2006-01-02 15:04:05"),
-		AuditLogs: make([]AlertLogItem, 0),
+		CmdID:         cmdID,
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
+		Time:          time.Now().Format("2006-01-02 15:04:05"),
+		AuditLogs:     make([]AlertLogItem, 0),
 	}
 }
 
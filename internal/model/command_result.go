@@ -33,6 +33,11 @@ type CommandResultReport struct {
 	// 根据不同的指令类型，定制不同的详情内容: 可选，数组类型，最长 128
 	Detail []string `json:"detail,omitempty"`
 
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
+
 	// 本地记录创建时间
 	CreatedAt time.Time `json:"-"`
 }
@@ -53,13 +58,15 @@ type CommandResultResponse struct {
 // NewCommandResultReport 创建新的指令执行结果报告
 func NewCommandResultReport(cmdID, cmd string) *CommandResultReport {
 	return &CommandResultReport{
-		Time:    time.Now().Format("2006-01-02 15:04:05"),
-		Type:    "command", // 默认类型为 command
-		Cmd:     cmd,
-		CmdID:   cmdID,
-		Result:  0, // 默认成功
-		Message: "成功",
-		Detail:  make([]string, 0),
+		Time:          time.Now().Format("2006-01-02 15:04:05"),
+		Type:          "command", // 默认类型为 command
+		Cmd:           cmd,
+		CmdID:         cmdID,
+		Result:        0, // 默认成功
+		Message:       "成功",
+		Detail:        make([]string, 0),
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
 	}
 }
 
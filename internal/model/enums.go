@@ -56,6 +56,13 @@ const (
 	ModuleSecretLevelDetect      = "secret_level_detect"      // 密级标志检测策略
 	ModuleElectronicSecretDetect = "electronic_secret_detect" // 电子密级标志检测策略
 	ModuleOfficialFormatDetect   = "official_format_detect"   // 公文版式检测策略
+	ModuleWasmRuleDetect         = "wasm_rule_detect"         // WASM 脚本规则检测策略
+	ModuleFingerprintDetect      = "fingerprint_detect"       // 内容指纹检测策略
+	ModuleEDMDetect              = "edm_detect"               // 精确数据匹配检测策略
+	ModuleXattrLabelDetect       = "xattr_label_detect"       // 扩展属性分类标签
+	ModuleArchiveBomb            = "archive_bomb"             // 疑似压缩炸弹 (超出展开配额，剩余内容未检测)
+	ModuleDNSBlockDetect         = "dns_block_detect"         // DNS 域名黑名单策略
+	ModuleNetWhitelist           = "net_whitelist"            // 网络连接白名单策略
 )
 
 // FileType 文件类型枚举
@@ -67,6 +74,13 @@ const (
 	FileTypeEmail    = 5 // 邮件
 )
 
+// KeywordRuleType 关键词检测规则类型
+const (
+	KeywordRuleTypeExpression = 0 // 关键词表达式
+	KeywordRuleTypePattern    = 1 // 内置个人敏感信息模式，规则内容为模式名称，见 internal/detector/pii
+	KeywordRuleTypeRegex      = 2 // 正则表达式 (RE2 语法)，见 internal/detector/keyword
+)
+
 // HashType 哈希类型枚举
 const (
 	HashTypeMD5 = iota // MD5
@@ -212,4 +226,16 @@ const (
 	OpTypeProcessStart SystemAuditOpType = "进程启动"
 	// 进程退出操作
 	OpTypeProcessExit SystemAuditOpType = "进程退出"
+	// 数据密钥轮换操作
+	OpTypeKeyRotation SystemAuditOpType = "密钥轮换"
+	// 上报通道 TLS 连接失败
+	OpTypeTLSFailure SystemAuditOpType = "TLS连接失败"
+	// 检测模块连续失败被熔断
+	OpTypeDetectorTripped SystemAuditOpType = "检测模块熔断"
+	// 熔断的检测模块恢复
+	OpTypeDetectorRecovered SystemAuditOpType = "检测模块恢复"
+	// 执行 (或拒绝) 管理平台下发的指令
+	OpTypeRemoteCommand SystemAuditOpType = "远程指令"
+	// 通过本机接口取回取证留存
+	OpTypeEvidenceAccess SystemAuditOpType = "取回证据"
 )
@@ -0,0 +1,31 @@
+package model
+
+// EvidenceRecord 告警取证留存的元数据，加密后的证据内容保存在取证库目录
+type EvidenceRecord struct {
+	// 告警 ID
+	AlertID string `json:"alert_id"`
+
+	// 被检测文件的路径
+	FilePath string `json:"file_path"`
+
+	// 留存方式: file (文件副本) / excerpt (命中摘录)
+	Mode string `json:"mode"`
+
+	// 原文件大小 (字节)
+	FileSize int64 `json:"file_size"`
+
+	// 留存内容大小 (字节，加密前)
+	Size int64 `json:"size"`
+
+	// 留存内容的 SM3 摘要 (十六进制，加密前)，取回时校验
+	SM3 string `json:"sm3"`
+
+	// 原文件 MD5 (来自告警)
+	FileMD5 string `json:"file_md5,omitempty"`
+
+	// 文件超过大小上限，改为留存命中摘录
+	Truncated bool `json:"truncated,omitempty"`
+
+	// 留存时间 (Unix 秒)
+	CreatedAt int64 `json:"created_at"`
+}
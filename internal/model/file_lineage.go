@@ -0,0 +1,30 @@
+package model
+
+// FileLineageEdge 涉密文件的一条流转记录 (持久化后守护进程重启仍可关联)
+// 首次告警的文件为流转起点，Kind 为 "origin" 且 From 为空；
+// 之后的复制、改名记录 From -> To，均指向起点告警
+type FileLineageEdge struct {
+	// 流转类型: origin / copy / rename
+	Kind string `json:"kind"`
+
+	// 来源路径，起点为空
+	From string `json:"from,omitempty"`
+
+	// 目标路径
+	To string `json:"to"`
+
+	// 起点告警 ID
+	RootAlertID string `json:"root_alert_id"`
+
+	// 目标路径产生的告警 ID，由文件监控直接观察到的改名没有告警
+	AlertID string `json:"alert_id,omitempty"`
+
+	// 文件 MD5
+	FileMD5 string `json:"file_md5,omitempty"`
+
+	// 发生时间 (Unix 秒)
+	Time int64 `json:"time"`
+
+	// 记录顺序，同一秒内的多条流转按此重放
+	Seq int64 `json:"seq,omitempty"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // ==========================================
 // 心跳接口 - 数据模型
 // ==========================================
@@ -18,6 +20,22 @@ type HeartbeatRequest struct {
 	Timestamp int64 `json:"timestamp" binding:"required"`
 	// 当前状态，字符串，如"running"
 	Status string `json:"status" binding:"required"`
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
+}
+
+// NewHeartbeatRequest 创建心跳请求
+func NewHeartbeatRequest(agentID, version, status string) *HeartbeatRequest {
+	return &HeartbeatRequest{
+		AgentID:       agentID,
+		Version:       version,
+		Timestamp:     time.Now().Unix(),
+		Status:        status,
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
+	}
 }
 
 // ==========================================
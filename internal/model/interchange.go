@@ -21,4 +21,30 @@ type SubDetectResult struct {
 	MatchedText   string // 命中的关键词 (对应 HighlightText)
 	ContextText   string // 上下文 (对应 FileDesc)
 	AlertType     int    // 告警类型映射
-}
\ No newline at end of file
+	Locations     []MatchLocation // 命中位置 (写入告警扩展字段 match_locations)
+}
+
+// MatchLocation 命中内容在检测文本中的位置，管理端据此高亮显示，无需重新扫描文件
+// 偏移相对于子检测器检测的文本：文档为抽取后的文本，纯文本文件为文件内容 (无效的 UTF-8 序列已丢弃)
+type MatchLocation struct {
+	// 字节偏移与长度
+	ByteOffset int `json:"byte_offset"`
+	ByteLength int `json:"byte_length"`
+
+	// 字符 (Unicode 码点) 偏移与长度
+	CharOffset int `json:"char_offset"`
+	CharLength int `json:"char_length"`
+
+	// 页码，从 1 开始；文本不区分页面时为 0
+	Page int `json:"page,omitempty"`
+
+	// 段落序号 (非空行)，从 1 开始，每页重新计数
+	Paragraph int `json:"paragraph"`
+
+	// 命中内容，敏感数据为脱敏后的内容
+	Text string `json:"text,omitempty"`
+
+	// 命中内容前后的上下文，敏感数据不附带上下文
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
@@ -0,0 +1,52 @@
+package model
+
+// ==========================================
+// 目录树完整性基线 (Merkle 树) - 数据模型
+// ==========================================
+
+// MerkleNode 目录节点，按目录绝对路径存取
+// 每个目录一条记录，文件只作为所在目录的条目保存，大目录树的记录数与目录数相当
+type MerkleNode struct {
+	// 目录绝对路径
+	Path string `json:"path"`
+
+	// 目录哈希: 按名称排序的全部条目 (名称、类型、属性、内容哈希) 的 SM3
+	Hash string `json:"hash"`
+
+	// 目录下的条目，按名称排序
+	Entries []MerkleEntry `json:"entries"`
+
+	// 生成时间 (Unix 秒)
+	UpdatedAt int64 `json:"updated_at"`
+
+	// 根节点哈希的 SM2 签名 (Base64 编码的 DER)，仅根节点有
+	Signature string `json:"signature,omitempty"`
+}
+
+// MerkleEntry 目录条目
+// 文件的大小、时间与 inode 用于增量校验: 均未变化时沿用记录的哈希，不重新读取内容
+type MerkleEntry struct {
+	// 条目名称 (不含路径)
+	Name string `json:"name"`
+
+	// 类型: file, dir, symlink
+	Type string `json:"type"`
+
+	// 权限位与属主
+	Mode uint32 `json:"mode"`
+	UID  uint32 `json:"uid"`
+	GID  uint32 `json:"gid"`
+
+	// 文件大小 (字节)
+	Size int64 `json:"size,omitempty"`
+
+	// 修改时间与状态变更时间 (Unix 纳秒)
+	MTime int64 `json:"mtime,omitempty"`
+	CTime int64 `json:"ctime,omitempty"`
+
+	// inode 编号
+	Ino uint64 `json:"ino,omitempty"`
+
+	// 内容哈希: 文件为内容 SM3，目录为子节点哈希，符号链接为链接目标的 SM3
+	Hash string `json:"hash"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// NetguardSeen 网络告警去重记录 (持久化后守护进程重启不重复告警)
+type NetguardSeen struct {
+	// 去重键，如 "dns:example.com"、"ip:1.2.3.4"
+	Key string `json:"key"`
+
+	// 过期时间 (Unix 秒)，过期后同一对象再次告警
+	ExpiresAt int64 `json:"expires_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// NetguardWhitelistRule 运行时添加的网络白名单规则 (持久化后守护进程重启仍然生效)
+type NetguardWhitelistRule struct {
+	// 规则，规范化后的 IP 或 CIDR，如 "10.0.0.0/8"
+	Value string `json:"value"`
+
+	// 规则说明，如 "备份服务器"
+	Desc string `json:"desc,omitempty"`
+
+	// 添加时间 (Unix 秒)
+	CreatedAt int64 `json:"created_at"`
+}
@@ -14,7 +14,7 @@ type PolicyRequest struct {
 	Type string `json:"type" binding:"required,eq=policy"`
 
 	// 检测策略对应的模块名
-	Module string `json:"module" binding:"required,oneof=keyword_detect md5_detect"`
+	Module string `json:"module" binding:"required,oneof=keyword_detect md5_detect wasm_rule_detect fingerprint_detect edm_detect dns_block_detect"`
 
 	// 策略对应版本号
 	Version string `json:"version" binding:"required,max=64"`
@@ -27,6 +27,10 @@ type PolicyRequest struct {
 
 	// 新的策略内容，根据模块不同内容不同
 	Config interface{} `json:"config"`
+
+	// SM2 签名 (Base64 编码的 DER 签名值)，签名原文为去掉本字段后的规范化 JSON，
+	// 由 policy.VerifyBundle 校验；落盘的 policy.json 同样携带该字段
+	Signature string `json:"signature,omitempty"`
 }
 
 // ==========================================
@@ -45,12 +49,19 @@ type FilterFileSize struct {
 type KeywordDetectRule struct {
 	// 策略ID，必填，数值，不超过20位数字的整数
 	RuleID int64 `json:"rule_id" binding:"required"`
+	// 规则类型，可选，数值型：0.关键词表达式（默认），1.内置个人敏感信息模式，2.正则表达式
+	RuleType int `json:"rule_type,omitempty" binding:"oneof=0 1 2"`
 	// 策略内容，必填，字符串，关键词规则为关键词表达式（括号为键词式表达）
+	// 模式规则为逗号分隔的模式名称：id_card, bank_card, phone, passport, license_plate；正则规则为 RE2 语法的表达式
 	RuleContent string `json:"rule_content" binding:"required"`
 	// 策略描述，可选，字符串，最长128
 	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
-	// 最少命中参数，可选，数值，不填默认1
+	// 最少命中参数，可选，数值，不填默认1；模式规则为同一文件中出现的不同值的个数下限
 	MinMatchCount int `json:"min_match_count,omitempty"`
+	// 最多匹配次数，可选，数值，仅正则规则使用，达到后停止匹配，不填使用本地配置的上限
+	MaxMatchCount int `json:"max_match_count,omitempty" binding:"min=0"`
+	// 敏感级别，可选，数值，1-5，仅模式规则与正则规则使用，不填默认为内部
+	SensitivityLevel int `json:"sensitivity_level,omitempty" binding:"min=0,max=5"`
 	// 过滤文件类型，可选，数值数组，不选默认空，表示对文件类型不做要求
 	FilterFileType []int `json:"filter_file_type,omitempty"`
 	// 过滤文件大小，可选，对象类型，不选默认null，表示对文件大小不做要求
@@ -69,16 +80,33 @@ type KeywordDetectConfig struct {
 type HashDetectRule struct {
 	// 策略ID，必填，数值，不超过20位数字的整数
 	RuleID int64 `json:"rule_id" binding:"required"`
-	// 策略内容类型，必填，数值型：0.md5，1.sm3
-	RuleType int `json:"rule_type" binding:"required,oneof=0 1"`
+	// 策略内容类型，必填，数值型：0.md5，1.sm3，2.采样md5，3.采样sm3
+	RuleType int `json:"rule_type" binding:"required,oneof=0 1 2 3"`
 	// 策略内容，必填，字符串，最长128
 	RuleContent string `json:"rule_content" binding:"required,max=128"`
 	// 策略描述，可选，字符串，最长128
 	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 采样参数，可选，仅采样类型使用，不选默认 null，表示使用默认采样参数
+	Sampling *SamplingParams `json:"sampling,omitempty"`
 	// 扩展字段集合，可选，json格式，由厂商根据市场需求增加的内容
 	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
 }
 
+// SamplingParams 大文件稀疏采样参数
+// 采样窗口为文件头、文件尾与中间若干个由 Seed 和文件大小确定的伪随机窗口，相同参数与文件得到相同结果
+type SamplingParams struct {
+	// 文件头采样字节数
+	HeadSize int64 `json:"head_size"`
+	// 文件尾采样字节数
+	TailSize int64 `json:"tail_size"`
+	// 中间窗口数量
+	Windows int `json:"windows"`
+	// 中间窗口字节数
+	WindowSize int64 `json:"window_size"`
+	// 窗口位置的随机种子
+	Seed uint64 `json:"seed"`
+}
+
 // HashDetectConfig 文件哈希检测策略配置
 type HashDetectConfig struct {
 	// 文件哈希检测策略规则列表
@@ -185,6 +213,127 @@ type FormatConfig struct {
 	ToleranceLevel int `json:"tolerance_level,omitempty"`
 }
 
+// WasmRuleDetectRule WASM 脚本规则
+// 规则逻辑由 WASM 模块实现，输入为提取的文本与文件元数据，输出为匹配结果，ABI 见 internal/detector/wasmrule
+type WasmRuleDetectRule struct {
+	// 策略ID，必填，数值，不超过20位数字的整数
+	RuleID int64 `json:"rule_id" binding:"required"`
+	// 策略内容，必填，Base64 编码的 WASM 模块
+	RuleContent string `json:"rule_content" binding:"required"`
+	// 模块 SHA-256 (十六进制)，可选，填写时加载前校验
+	RuleHash string `json:"rule_hash,omitempty" binding:"max=64"`
+	// 策略描述，可选，字符串，最长128
+	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 敏感级别，必填，数值，1-5；模块输出中的级别优先
+	SensitivityLevel int `json:"sensitivity_level" binding:"required,min=1,max=5"`
+	// 过滤文件大小，可选，对象类型，不选默认null，表示对文件大小不做要求
+	FilterFileSize *FilterFileSize `json:"filter_file_size,omitempty"`
+	// 扩展字段集合，可选，json格式，原样传给模块的 metadata.extended_fields
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// WasmRuleDetectConfig WASM 脚本规则检测策略配置
+type WasmRuleDetectConfig struct {
+	// WASM 脚本规则列表
+	Rules []WasmRuleDetectRule `json:"rules"`
+}
+
+// FingerprintDetectRule 内容指纹检测策略规则
+// 由服务端对已知涉密文档计算，指纹算法与编码见 internal/detector/fingerprint
+type FingerprintDetectRule struct {
+	// 策略ID，必填，数值，不超过20位数字的整数
+	RuleID int64 `json:"rule_id" binding:"required"`
+	// 策略内容类型，必填，数值型：0.simhash (整篇相似)，1.分段指纹 (部分复制)
+	RuleType int `json:"rule_type" binding:"oneof=0 1"`
+	// 策略内容，必填：simhash 为 16 位十六进制；分段指纹为大端 uint64 数组的 Base64
+	RuleContent string `json:"rule_content" binding:"required"`
+	// 策略描述，可选，字符串，最长128
+	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 敏感级别，必填，数值，1-5
+	SensitivityLevel int `json:"sensitivity_level" binding:"required,min=1,max=5"`
+	// simhash 汉明距离上限，可选，0 表示使用本地配置
+	MaxDistance int `json:"max_distance,omitempty" binding:"min=0,max=64"`
+	// 分段指纹出现比例下限，可选，0 表示使用本地配置
+	MinContainment float64 `json:"min_containment,omitempty" binding:"min=0,max=1"`
+	// 扩展字段集合，可选，json格式，由厂商根据市场需求增加的内容
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// FingerprintDetectConfig 内容指纹检测策略配置
+type FingerprintDetectConfig struct {
+	// 内容指纹检测策略规则列表
+	Rules []FingerprintDetectRule `json:"rules"`
+}
+
+// EDMDetectRule 精确数据匹配检测策略规则 (一张结构化敏感数据表)
+// 单元格按列类型规范化后以 Salt 为密钥计算 HMAC-SHA256，取前 8 字节，终端不保存原始数据，算法见 internal/detector/edm
+type EDMDetectRule struct {
+	// 策略ID，必填，数值，不超过20位数字的整数
+	RuleID int64 `json:"rule_id" binding:"required"`
+	// 列类型，必填，最多 64 列：id_card / phone / bank_card / email / number / text
+	Columns []string `json:"columns" binding:"required,min=1,max=64"`
+	// 盐值，必填，十六进制，至少 8 字节
+	Salt string `json:"salt" binding:"required,hexadecimal"`
+	// 策略内容，必填，各行按列顺序排列的大端 8 字节哈希的 Base64，空单元格为 0
+	RuleContent string `json:"rule_content" binding:"required"`
+	// 策略描述，可选，字符串，最长128
+	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 敏感级别，必填，数值，1-5
+	SensitivityLevel int `json:"sensitivity_level" binding:"required,min=1,max=5"`
+	// 同一行出现的列数下限，可选，0 表示使用本地配置
+	MinColumns int `json:"min_columns,omitempty" binding:"min=0,max=64"`
+	// 命中的不同行数下限，可选，0 表示使用本地配置
+	MinRows int `json:"min_rows,omitempty" binding:"min=0"`
+	// 扩展字段集合，可选，json格式，由厂商根据市场需求增加的内容
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// EDMDetectConfig 精确数据匹配检测策略配置
+type EDMDetectConfig struct {
+	// 数据表列表
+	Rules []EDMDetectRule `json:"rules"`
+}
+
+// DNSBlockDetectRule DNS 域名黑名单规则
+// 匹配域名本身及其所有子域名，由 internal/security/netguard/dnsguard 执行
+type DNSBlockDetectRule struct {
+	// 策略ID，必填，数值，不超过20位数字的整数
+	RuleID int64 `json:"rule_id" binding:"required"`
+	// 策略内容，必填，域名，如 duckdns.org (可带 "*." 前缀)
+	RuleContent string `json:"rule_content" binding:"required,max=253"`
+	// 动作，可选，数值：0.告警，1.告警并拦截 (需客户端开启拦截)
+	Action int `json:"action,omitempty" binding:"oneof=0 1"`
+	// 策略描述，可选，字符串，最长128，如 "动态域名"、"数据外泄"
+	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 扩展字段集合，可选，json格式，由厂商根据市场需求增加的内容
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// DNSBlockDetectConfig DNS 域名黑名单策略配置
+type DNSBlockDetectConfig struct {
+	// 域名黑名单规则列表
+	Rules []DNSBlockDetectRule `json:"rules"`
+}
+
+// NetWhitelistRule 网络连接白名单规则
+// 对端命中白名单时 netguard 不告警，由 internal/security/netguard/whitelist 执行
+type NetWhitelistRule struct {
+	// 策略ID，必填，数值，不超过20位数字的整数
+	RuleID int64 `json:"rule_id" binding:"required"`
+	// 策略内容，必填，IP 或 CIDR，如 10.0.0.0/8
+	RuleContent string `json:"rule_content" binding:"required,max=64"`
+	// 策略描述，可选，字符串，最长128，如 "备份服务器"
+	RuleDesc string `json:"rule_desc,omitempty" binding:"max=128"`
+	// 扩展字段集合，可选，json格式，由厂商根据市场需求增加的内容
+	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+}
+
+// NetWhitelistConfig 网络连接白名单策略配置
+type NetWhitelistConfig struct {
+	// 白名单规则列表
+	Rules []NetWhitelistRule `json:"rules"`
+}
+
 // ==========================================
 // 响应结构体定义
 // ==========================================
@@ -221,6 +370,22 @@ func NewKeywordDetectRule(ruleID int64, ruleContent string) *KeywordDetectRule {
 	}
 }
 
+// NewKeywordPatternRule 创建新的内置模式关键词规则
+func NewKeywordPatternRule(ruleID int64, patterns string, minMatchCount int) *KeywordDetectRule {
+	rule := NewKeywordDetectRule(ruleID, patterns)
+	rule.RuleType = KeywordRuleTypePattern
+	rule.MinMatchCount = minMatchCount
+	return rule
+}
+
+// NewKeywordRegexRule 创建新的正则关键词规则
+func NewKeywordRegexRule(ruleID int64, expr string, minMatchCount int) *KeywordDetectRule {
+	rule := NewKeywordDetectRule(ruleID, expr)
+	rule.RuleType = KeywordRuleTypeRegex
+	rule.MinMatchCount = minMatchCount
+	return rule
+}
+
 // NewKeywordDetectConfig 创建新的关键词检测策略配置
 func NewKeywordDetectConfig() *KeywordDetectConfig {
 	return &KeywordDetectConfig{
@@ -336,6 +501,113 @@ func NewFormatConfig() *FormatConfig {
 	}
 }
 
+// ==========================================
+// WASM 脚本规则检测策略辅助构造函数
+// ==========================================
+
+// NewWasmRuleDetectRule 创建新的 WASM 脚本规则
+func NewWasmRuleDetectRule(ruleID int64, ruleContent string, sensitivityLevel int) *WasmRuleDetectRule {
+	return &WasmRuleDetectRule{
+		RuleID:           ruleID,
+		RuleContent:      ruleContent,
+		SensitivityLevel: sensitivityLevel,
+		ExtendedFields:   make(map[string]interface{}),
+	}
+}
+
+// NewWasmRuleDetectConfig 创建新的 WASM 脚本规则检测策略配置
+func NewWasmRuleDetectConfig() *WasmRuleDetectConfig {
+	return &WasmRuleDetectConfig{
+		Rules: make([]WasmRuleDetectRule, 0),
+	}
+}
+
+// ==========================================
+// 内容指纹检测策略辅助构造函数
+// ==========================================
+
+// NewFingerprintDetectRule 创建新的内容指纹检测策略规则
+func NewFingerprintDetectRule(ruleID int64, ruleType int, ruleContent string, sensitivityLevel int) *FingerprintDetectRule {
+	return &FingerprintDetectRule{
+		RuleID:           ruleID,
+		RuleType:         ruleType,
+		RuleContent:      ruleContent,
+		SensitivityLevel: sensitivityLevel,
+		ExtendedFields:   make(map[string]interface{}),
+	}
+}
+
+// NewFingerprintDetectConfig 创建新的内容指纹检测策略配置
+func NewFingerprintDetectConfig() *FingerprintDetectConfig {
+	return &FingerprintDetectConfig{
+		Rules: make([]FingerprintDetectRule, 0),
+	}
+}
+
+// ==========================================
+// 精确数据匹配检测策略辅助构造函数
+// ==========================================
+
+// NewEDMDetectRule 创建新的精确数据匹配检测策略规则
+func NewEDMDetectRule(ruleID int64, columns []string, salt, ruleContent string, sensitivityLevel int) *EDMDetectRule {
+	return &EDMDetectRule{
+		RuleID:           ruleID,
+		Columns:          columns,
+		Salt:             salt,
+		RuleContent:      ruleContent,
+		SensitivityLevel: sensitivityLevel,
+		ExtendedFields:   make(map[string]interface{}),
+	}
+}
+
+// NewEDMDetectConfig 创建新的精确数据匹配检测策略配置
+func NewEDMDetectConfig() *EDMDetectConfig {
+	return &EDMDetectConfig{
+		Rules: make([]EDMDetectRule, 0),
+	}
+}
+
+// ==========================================
+// DNS 域名黑名单策略辅助构造函数
+// ==========================================
+
+// NewDNSBlockDetectRule 创建新的 DNS 域名黑名单规则
+func NewDNSBlockDetectRule(ruleID int64, domain string, action int) *DNSBlockDetectRule {
+	return &DNSBlockDetectRule{
+		RuleID:         ruleID,
+		RuleContent:    domain,
+		Action:         action,
+		ExtendedFields: make(map[string]interface{}),
+	}
+}
+
+// NewDNSBlockDetectConfig 创建新的 DNS 域名黑名单策略配置
+func NewDNSBlockDetectConfig() *DNSBlockDetectConfig {
+	return &DNSBlockDetectConfig{
+		Rules: make([]DNSBlockDetectRule, 0),
+	}
+}
+
+// ==========================================
+// 网络连接白名单策略辅助构造函数
+// ==========================================
+
+// NewNetWhitelistRule 创建新的网络连接白名单规则
+func NewNetWhitelistRule(ruleID int64, content string) *NetWhitelistRule {
+	return &NetWhitelistRule{
+		RuleID:         ruleID,
+		RuleContent:    content,
+		ExtendedFields: make(map[string]interface{}),
+	}
+}
+
+// NewNetWhitelistConfig 创建新的网络连接白名单策略配置
+func NewNetWhitelistConfig() *NetWhitelistConfig {
+	return &NetWhitelistConfig{
+		Rules: make([]NetWhitelistRule, 0),
+	}
+}
+
 // NewPolicyRequest 创建新的检测策略请求
 func NewPolicyRequest(module, version, cmd string, num int, config interface{}) *PolicyRequest {
 	return &PolicyRequest{
@@ -0,0 +1,41 @@
+package model
+
+// ==========================================
+// 进程信息 - 数据模型
+// ==========================================
+
+// ProcessInfo 文件操作发起进程
+// 由文件监控通过 fanotify 事件中的 PID 读取 /proc/<pid> 获得
+type ProcessInfo struct {
+	PID     int    `json:"pid"`
+	PPID    int    `json:"ppid"`
+	Comm    string `json:"comm"`    // 进程名
+	Exe     string `json:"exe"`     // 可执行文件路径
+	Cmdline string `json:"cmdline"` // 命令行 (参数以空格分隔)
+	UID     int    `json:"uid"`
+	User    string `json:"user"` // 用户名，无法解析时为 UID
+
+	// 进程所属的登录会话，多用户服务器上据此区分实际操作人
+	SessionInfo
+}
+
+// SessionInfo 登录会话
+// 登录用户取自 /proc/<pid>/loginuid，经 su/sudo 切换身份后仍为最初登录的用户；
+// 会话详情取自 systemd-logind (/run/systemd/sessions)
+type SessionInfo struct {
+	// 登录用户名，无法解析时为 UID；非登录会话启动的进程 (系统服务) 为空
+	LoginUser string `json:"login_user,omitempty" gorm:"type:varchar(256)" since:"2"`
+	// 会话 ID (审计会话 ID 或 logind 会话名)
+	SessionID string `json:"session_id,omitempty" gorm:"type:varchar(64)" since:"2"`
+	// 会话终端，如 pts/0、tty1
+	SessionTTY string `json:"session_tty,omitempty" gorm:"type:varchar(64)" since:"2"`
+	// 远程登录的来源地址 (如 SSH 客户端 IP)，本地登录为空
+	SessionRemoteHost string `json:"session_remote_host,omitempty" gorm:"type:varchar(256)" since:"2"`
+	// 创建会话的 PAM 服务，如 sshd、login、gdm-password
+	SessionService string `json:"session_service,omitempty" gorm:"type:varchar(64)" since:"2"`
+}
+
+// Empty 未关联到登录会话
+func (s SessionInfo) Empty() bool {
+	return s.LoginUser == "" && s.SessionID == ""
+}
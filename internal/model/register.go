@@ -16,6 +16,10 @@ type GetComputerClientIDRequest struct {
 	Hdcode string `json:"hdcode" binding:"required,max=1024"`
 	// 厂商编码，字符串，固定3位
 	Vendor string `json:"vendor" binding:"required,len=3"`
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变，服务端据此避免重复登记
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
 }
 
 // ==========================================
@@ -45,9 +49,11 @@ type GetComputerClientIDResponse struct {
 // NewGetComputerClientIDRequest 创建新的主机唯一编码查询请求
 func NewGetComputerClientIDRequest(mac, hwidcode, vendor string) *GetComputerClientIDRequest {
 	return &GetComputerClientIDRequest{
-		MAC:    mac,
-		Hdcode: hwidcode,
-		Vendor: vendor,
+		MAC:           mac,
+		Hdcode:        hwidcode,
+		Vendor:        vendor,
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
 	}
 }
 
@@ -133,6 +139,10 @@ type RegisterRequest struct {
 	Memo string `json:"memo" binding:"max=128"`
 	// 扩展字段集合，可选，对象类型
 	ExtendedFields map[string]interface{} `json:"extended_fields,omitempty"`
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
 }
 
 // RegisterResponse 注册响应
@@ -192,6 +202,8 @@ func NewRegisterRequest(softVersion string) *RegisterRequest {
 		Arch:           "",
 		Memo:           "",
 		ExtendedFields: make(map[string]interface{}),
+		SchemaVersion:  ReportSchemaVersion,
+		AgentUUID:      AgentUUID(),
 	}
 }
 
@@ -0,0 +1,44 @@
+package model
+
+// RuleStat 检测规则的命中与误报统计 (持久化后守护进程重启仍然保留)
+// 规则按检测模块与规则 ID 区分，不同模块的规则 ID 可以重复
+type RuleStat struct {
+	// 检测模块 (Module* 常量或插件名称)
+	Module string `json:"module"`
+
+	// 规则 ID，检测模块不区分规则时为 0
+	RuleID int64 `json:"rule_id"`
+
+	// 命中次数
+	Hits int64 `json:"hits"`
+
+	// 被标记为误报的次数
+	FalsePositives int64 `json:"false_positives"`
+
+	// 最近一次命中时间 (Unix 秒)
+	LastHit int64 `json:"last_hit"`
+
+	// 状态: normal (正常) / flagged (误报较多，已上报) / demoted (已降级，命中不再产生告警)
+	Status string `json:"status"`
+
+	// 状态变化时间 (Unix 秒)
+	StatusChanged int64 `json:"status_changed,omitempty"`
+}
+
+// RuleAlertRef 告警对应的规则，用于按告警 ID 标记误报
+type RuleAlertRef struct {
+	// 告警 ID
+	AlertID string `json:"alert_id"`
+
+	// 命中的检测模块
+	Module string `json:"module"`
+
+	// 命中的规则 ID
+	RuleID int64 `json:"rule_id"`
+
+	// 告警时间 (Unix 秒)
+	Time int64 `json:"time"`
+
+	// 是否已被标记为误报
+	FalsePositive bool `json:"false_positive"`
+}
@@ -0,0 +1,76 @@
+package model
+
+// ==========================================
+// 扫描任务持久化 - 数据模型
+// ==========================================
+
+// ScanJob 待扫描任务 (持久化后用于守护进程重启后恢复)
+type ScanJob struct {
+	// 文件路径
+	Path string `json:"path"`
+
+	// 任务优先级 (0 实时, 1 手动, 2 定时)
+	Priority int `json:"priority"`
+
+	// 任务来源 (watcher, scheduler, command)
+	Source string `json:"source,omitempty"`
+
+	// 入队时间 (Unix 秒)
+	EnqueuedAt int64 `json:"enqueued_at"`
+}
+
+// ScanCheckpoint 目录扫描断点
+// 目录遍历按字典序进行，记录最后完成的路径即可从断点继续
+type ScanCheckpoint struct {
+	// 扫描根目录
+	Root string `json:"root"`
+
+	// 最后一个已完成扫描的文件路径
+	LastPath string `json:"last_path"`
+
+	// 已扫描文件数
+	Scanned int64 `json:"scanned"`
+
+	// 是否已完成整个目录
+	Completed bool `json:"completed"`
+
+	// 更新时间 (Unix 秒)
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// 定时扫描运行状态
+const (
+	ScanRunRunning   = "running"
+	ScanRunCompleted = "completed"
+	ScanRunTimeout   = "timeout"  // 超过 max_duration，下次从断点继续
+	ScanRunCanceled  = "canceled" // 守护进程退出
+	ScanRunFailed    = "failed"
+)
+
+// ScanRunSummary 单次定时扫描运行摘要
+type ScanRunSummary struct {
+	// 运行 ID (计划名 + 开始时间)
+	RunID string `json:"run_id"`
+
+	// 计划名称
+	Schedule string `json:"schedule"`
+
+	// 扫描路径
+	Paths []string `json:"paths"`
+
+	// 开始/结束时间 (Unix 秒)
+	StartedAt  int64 `json:"started_at"`
+	FinishedAt int64 `json:"finished_at"`
+
+	// 已提交扫描的文件数
+	FilesEnqueued int64 `json:"files_enqueued"`
+
+	// 被 include/exclude 过滤的文件数
+	FilesSkipped int64 `json:"files_skipped"`
+
+	// 运行状态 (running, completed, timeout, canceled, failed)
+	Status string `json:"status"`
+
+	// 错误信息
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==========================================
+// 上报数据结构版本
+// ==========================================
+//
+// 上报模型中后续版本新增的字段以 since:"N" 标签标注，未标注的字段属于版本 1。
+// 上报数据的 schema_version 始终为当前版本 ReportSchemaVersion，按当前版本发送。
+// MarshalSchema 按指定的较低版本去掉新增字段并返回被去掉的字段，目前尚未用于上报。
+
+const (
+	// LegacySchemaVersion 最早的上报数据结构版本 (没有 schema_version 字段)
+	LegacySchemaVersion = 1
+	// ReportSchemaVersion 当前上报数据结构版本
+	// 2: 增加 schema_version、agent_uuid、写入进程与登录会话、检测模块、篡改前后哈希与对端地理位置
+	ReportSchemaVersion = 2
+)
+
+// schemaVersionField 上报数据中的版本字段
+const schemaVersionField = "schema_version"
+
+// MarshalSchema 按指定的数据结构版本序列化上报数据
+// 去掉高于 version 的版本新增的字段，schema_version 写为 version；返回被去掉的非空字段 (类型.字段)
+func MarshalSchema(v interface{}, version int) ([]byte, []string, error) {
+	if version > ReportSchemaVersion {
+		version = ReportSchemaVersion
+	} else if version < LegacySchemaVersion {
+		version = LegacySchemaVersion
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version >= ReportSchemaVersion && !hasSchemaField(reflect.TypeOf(v)) {
+		return data, nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	dropped := make(map[string]bool)
+	downgrade(doc, reflect.TypeOf(v), version, dropped)
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fields := make([]string, 0, len(dropped))
+	for f := range dropped {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	return out, fields, nil
+}
+
+// downgrade 按类型定义处理解码后的 JSON 值
+func downgrade(doc interface{}, t reflect.Type, version int, dropped map[string]bool) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, f := range schemaFields(t) {
+			val, present := obj[f.name]
+			if f.since > version {
+				delete(obj, f.name)
+				if present && f.name != schemaVersionField {
+					dropped[t.Name()+"."+f.name] = true
+				}
+				continue
+			}
+			if f.name == schemaVersionField {
+				obj[f.name] = version
+				continue
+			}
+			if present {
+				downgrade(val, f.typ, version, dropped)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if items, ok := doc.([]interface{}); ok {
+			for _, item := range items {
+				downgrade(item, t.Elem(), version, dropped)
+			}
+		}
+	case reflect.Map:
+		if obj, ok := doc.(map[string]interface{}); ok {
+			for _, item := range obj {
+				downgrade(item, t.Elem(), version, dropped)
+			}
+		}
+	}
+}
+
+// schemaField 结构体字段在 JSON 中的名称与引入版本
+type schemaField struct {
+	name  string
+	typ   reflect.Type
+	since int
+}
+
+// schemaFields 列出结构体输出到 JSON 的字段，匿名嵌入且无 JSON 名称的结构体字段展开到同一层
+func schemaFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		since := LegacySchemaVersion
+		if s, err := strconv.Atoi(f.Tag.Get("since")); err == nil {
+			since = s
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, sub := range schemaFields(ft) {
+				if sub.since < since {
+					sub.since = since
+				}
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, schemaField{name: name, typ: f.Type, since: since})
+	}
+	return fields
+}
+
+// hasSchemaField 类型 (或其元素类型) 是否带有 schema_version 字段
+func hasSchemaField(t reflect.Type) bool {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, f := range schemaFields(t) {
+		if f.name == schemaVersionField {
+			return true
+		}
+	}
+	return false
+}
@@ -18,6 +18,11 @@ type SecurityStatusReport struct {
 	// gorm: 限制数据库字段长度
 	SoftVersion string `gorm:"type:varchar(32);not null" json:"soft_version"`
 
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `gorm:"-" json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `gorm:"type:varchar(36)" json:"agent_uuid,omitempty" since:"2"`
+
 	// 业务状态采集时间: 时间类型, 最长 128
 	// 这里为了完全匹配 JSON 协议保持 string，数据库存为 varchar
 	// 建立索引方便按时间查询
@@ -53,6 +58,24 @@ type SuspectedEvent struct {
 
 	// 异常事件描述: 字符串, 最长 128
 	Msg string `gorm:"type:varchar(128)" json:"msg"`
+
+	// 文件篡改事件: 篡改前 (基线) 与篡改后的内容 SM3，其他事件为空
+	BeforeHash string `gorm:"type:varchar(64)" json:"before_hash,omitempty" since:"2"`
+	AfterHash  string `gorm:"type:varchar(64)" json:"after_hash,omitempty" since:"2"`
+
+	// 网络事件: 对端 IP 的地理位置与自治系统，未配置 GeoIP 库时为空
+	GeoInfo `gorm:"embedded"`
+
+	// 网络事件: 相关进程所属的登录会话，无法关联进程时为空
+	SessionInfo `gorm:"embedded"`
+}
+
+// GeoInfo 对端 IP 的地理位置与自治系统信息 (本地 GeoIP 库查询)
+type GeoInfo struct {
+	Country string `gorm:"type:varchar(8)" json:"country,omitempty" since:"2"`
+	City    string `gorm:"type:varchar(64)" json:"city,omitempty" since:"2"`
+	ASN     uint32 `json:"asn,omitempty" since:"2"`
+	ASOrg   string `gorm:"type:varchar(128)" json:"as_org,omitempty" since:"2"`
 }
 
 // TableName 自定义表名 (可选，符合 SQLite 命名习惯)
@@ -70,8 +93,10 @@ func (SuspectedEvent) TableName() string {
 
 func NewSecurityStatusReport(version string) *SecurityStatusReport {
 	return &SecurityStatusReport{
-		SoftVersion: version,
-		Time:        time.Now().Format("2006-01-02 15:04:05"),
+		SoftVersion:   version,
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
+		Time:          time.Now().Format("2006-01-02 15:04:05"),
 		// 初始化切片，避免 json 输出 null
 		Suspected: make([]SuspectedEvent, 0),
 	}
@@ -90,8 +115,27 @@ func (r *SecurityStatusReport) AddSignatureAlert(filePath string, msg string) {
 	r.Suspected = append(r.Suspected, event)
 }
 
+// AddTamperAlert 添加一条“受保护文件被篡改”，记录篡改前后的内容 SM3
+func (r *SecurityStatusReport) AddTamperAlert(msg, beforeHash, afterHash string) {
+	event := SuspectedEvent{
+		EventType:    TypeSecurityAbnormal,
+		EventSubType: SubTypeSignature,
+		Time:         time.Now().Format("2006-01-02 15:04:05"),
+		Risk:         RiskLevelCritical,
+		Msg:          limitString(msg, 128),
+		BeforeHash:   beforeHash,
+		AfterHash:    afterHash,
+	}
+	r.Suspected = append(r.Suspected, event)
+}
+
 // AddNetworkAlert 添加一条“通信 IP 异常”
 func (r *SecurityStatusReport) AddNetworkAlert(remoteIP string, port uint16, msg string) {
+	r.AddGeoNetworkAlert(remoteIP, port, msg, GeoInfo{})
+}
+
+// AddGeoNetworkAlert 添加一条附带对端地理位置与 ASN 的“通信 IP 异常”
+func (r *SecurityStatusReport) AddGeoNetworkAlert(remoteIP string, port uint16, msg string, geo GeoInfo) {
 	fullMsg := msg
 	if fullMsg == "" {
 		fullMsg = "Detected unauthorized communication with " + remoteIP
@@ -103,6 +147,44 @@ func (r *SecurityStatusReport) AddNetworkAlert(remoteIP string, port uint16, msg
 		Time:         time.Now().Format("2006-01-02 15:04:05"),
 		Risk:         RiskLevelSevere,
 		Msg:          limitString(fullMsg, 128),
+		GeoInfo: GeoInfo{
+			Country: limitString(geo.Country, 8),
+			City:    limitString(geo.City, 64),
+			ASN:     geo.ASN,
+			ASOrg:   limitString(geo.ASOrg, 128),
+		},
+	}
+	r.Suspected = append(r.Suspected, event)
+}
+
+// SetSession 为最近添加的事件附加登录会话
+func (r *SecurityStatusReport) SetSession(s SessionInfo) {
+	if len(r.Suspected) == 0 {
+		return
+	}
+	r.Suspected[len(r.Suspected)-1].SessionInfo = s
+}
+
+// AddProcessAlert 添加一条“客户端进程被结束或暂停”异常 (归入其他子类，紧急级)
+func (r *SecurityStatusReport) AddProcessAlert(eventTime time.Time, msg string) {
+	event := SuspectedEvent{
+		EventType:    TypeSecurityAbnormal,
+		EventSubType: SubTypeOther,
+		Time:         eventTime.Format("2006-01-02 15:04:05"),
+		Risk:         RiskLevelCritical,
+		Msg:          limitString(msg, 128),
+	}
+	r.Suspected = append(r.Suspected, event)
+}
+
+// AddRuleQualityAlert 添加一条“检测规则误报较多”异常 (归入其他子类，关注级)
+func (r *SecurityStatusReport) AddRuleQualityAlert(msg string) {
+	event := SuspectedEvent{
+		EventType:    TypeSecurityAbnormal,
+		EventSubType: SubTypeOther,
+		Time:         time.Now().Format("2006-01-02 15:04:05"),
+		Risk:         RiskLevelNotice,
+		Msg:          limitString(msg, 128),
 	}
 	r.Suspected = append(r.Suspected, event)
 }
@@ -31,6 +31,11 @@ type StrategyExecReport struct {
 	// 失败列表: 对象数组
 	Fail []StrategyFailItem `json:"fail"`
 
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
+
 	// 本地记录时间
 	CreatedAt time.Time `json:"-"`
 }
@@ -61,13 +66,15 @@ type StrategyResponse struct {
 // cmd: 指令名称, version: 任务ID, module: 策略类型
 func NewStrategyExecReport(cmd, version, module string) *StrategyExecReport {
 	return &StrategyExecReport{
-		Time:    time.Now().Format("2006-01-02 15:04:05"),
-		Type:    "policy", // 接口约束: 取值 policy
-		Cmd:     cmd,
-		Version: version,
-		Module:  module,
-		Success: make([]int64, 0),
-		Fail:    make([]StrategyFailItem, 0),
+		Time:          time.Now().Format("2006-01-02 15:04:05"),
+		Type:          "policy", // 接口约束: 取值 policy
+		Cmd:           cmd,
+		Version:       version,
+		Module:        module,
+		Success:       make([]int64, 0),
+		Fail:          make([]StrategyFailItem, 0),
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
 	}
 }
 
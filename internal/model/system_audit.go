@@ -22,6 +22,10 @@ type SystemAuditRequest struct {
 	OpType SystemAuditOpType `json:"opt_type" binding:"required,max=64"`
 	// 日志详情，字符串
 	Message string `json:"message" binding:"required"`
+	// 上报数据结构版本，构造函数填写当前版本 ReportSchemaVersion
+	SchemaVersion int `json:"schema_version,omitempty" since:"2"`
+	// 本机稳定标识 (硬件派生的 UUID)，重装后不变
+	AgentUUID string `json:"agent_uuid,omitempty" since:"2"`
 }
 
 // ==========================================
@@ -43,12 +47,14 @@ type SystemAuditResponse struct {
 // NewSystemAuditRequest 创建新的系统审计日志请求
 func NewSystemAuditRequest(id, user, time string, eventType SystemAuditLogType, opType SystemAuditOpType, message string) *SystemAuditRequest {
 	return &SystemAuditRequest{
-		ID:        id,
-		User:      user,
-		Time:      time,
-		EventType: eventType,
-		OpType:    opType,
-		Message:   message,
+		ID:            id,
+		User:          user,
+		Time:          time,
+		EventType:     eventType,
+		OpType:        opType,
+		Message:       message,
+		SchemaVersion: ReportSchemaVersion,
+		AgentUUID:     AgentUUID(),
 	}
 }
 
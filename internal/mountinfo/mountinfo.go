@@ -0,0 +1,111 @@
+// Package mountinfo 解析 /proc/self/mountinfo 挂载表
+package mountinfo
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Entry 挂载点信息
+type Entry struct {
+	MountPoint string // 挂载点
+	FSType     string // 文件系统类型
+	Source     string // 挂载源 (如 /dev/sdb1)
+	Options    string // 挂载选项 (如 rw,nosuid)
+}
+
+// ReadOnly 是否只读挂载
+func (e Entry) ReadOnly() bool {
+	for _, opt := range strings.Split(e.Options, ",") {
+		if opt == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse 解析 mountinfo 内容，结果按挂载点长度倒序，便于最长前缀匹配
+// 格式: 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func Parse(r io.Reader) []Entry {
+	var mounts []Entry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 {
+			continue
+		}
+
+		// 可选字段以 "-" 结束，其后为文件系统类型与挂载源
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+1 >= len(fields) {
+			continue
+		}
+
+		entry := Entry{
+			MountPoint: unescapePath(fields[4]),
+			FSType:     fields[sep+1],
+			Options:    fields[5],
+		}
+		if sep+2 < len(fields) {
+			entry.Source = unescapePath(fields[sep+2])
+		}
+		mounts = append(mounts, entry)
+	}
+
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].MountPoint) > len(mounts[j].MountPoint)
+	})
+	return mounts
+}
+
+// unescapePath 还原 mountinfo 中的八进制转义 (如 \040 表示空格)
+func unescapePath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, ok := parseOctal(s[i+1 : i+4]); ok {
+				sb.WriteByte(v)
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func parseOctal(s string) (byte, bool) {
+	var v int
+	for _, c := range s {
+		if c < '0' || c > '7' {
+			return 0, false
+		}
+		v = v*8 + int(c-'0')
+	}
+	if v > 255 {
+		return 0, false
+	}
+	return byte(v), true
+}
+
+// Lookup 按最长前缀匹配返回路径所在的挂载点
+func Lookup(mounts []Entry, path string) (Entry, bool) {
+	for _, m := range mounts {
+		if m.MountPoint == "/" || path == m.MountPoint || strings.HasPrefix(path, m.MountPoint+"/") {
+			return m, true
+		}
+	}
+	return Entry{}, false
+}
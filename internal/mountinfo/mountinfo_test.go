@@ -0,0 +1,42 @@
+package mountinfo
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+23 22 0:21 / /proc rw,nosuid shared:12 - proc proc rw
+24 22 0:22 / /sys rw,nosuid shared:7 - sysfs sysfs rw
+60 22 0:50 / /mnt/share rw,relatime shared:30 - nfs4 server:/export rw
+61 22 8:17 / /media/u/my\040disk ro,nosuid - vfat /dev/sdb1 rw,uid=1000
+`
+
+func TestParse(t *testing.T) {
+	mounts := Parse(strings.NewReader(sampleMountInfo))
+	if len(mounts) != 5 {
+		t.Fatalf("mounts = %d, want 5", len(mounts))
+	}
+
+	tests := map[string]string{
+		"/proc/1/status":          "proc",
+		"/mnt/share/a.doc":        "nfs4",
+		"/mnt/shared/a.doc":       "ext4",
+		"/media/u/my disk/a.docx": "vfat",
+		"/home/u/a.txt":           "ext4",
+	}
+	for path, want := range tests {
+		m, ok := Lookup(mounts, path)
+		if !ok || m.FSType != want {
+			t.Errorf("Lookup(%q) = %q, want %q", path, m.FSType, want)
+		}
+	}
+
+	usb, _ := Lookup(mounts, "/media/u/my disk")
+	if usb.Source != "/dev/sdb1" {
+		t.Errorf("Source = %q, want /dev/sdb1", usb.Source)
+	}
+	if !usb.ReadOnly() {
+		t.Error("ro 挂载应识别为只读")
+	}
+}
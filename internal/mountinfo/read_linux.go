@@ -0,0 +1,17 @@
+//go:build linux
+
+package mountinfo
+
+import (
+	"os"
+)
+
+// Read 读取当前进程可见的挂载表
+func Read() []Entry {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	return Parse(f)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package mountinfo
+
+// Read 非 Linux 平台不读取挂载表
+func Read() []Entry {
+	return nil
+}
@@ -0,0 +1,127 @@
+package pathfilter
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==========================================
+// 文件属性过滤
+// ==========================================
+
+// Attrs 按文件属性过滤: 大小区间与修改时间窗口，字段为零值表示不限
+// 与按路径的 Filter 配合使用，用于定向扫描 (如 "最近 48 小时修改过的文件")
+type Attrs struct {
+	// 最小/最大字节数 (含)
+	MinSize int64
+	MaxSize int64
+	// 修改时间不早于 NewerThan、早于 OlderThan
+	NewerThan time.Time
+	OlderThan time.Time
+}
+
+// IsZero 未设置任何条件
+func (a Attrs) IsZero() bool {
+	return a.MinSize == 0 && a.MaxSize == 0 && a.NewerThan.IsZero() && a.OlderThan.IsZero()
+}
+
+// Match 判断文件是否满足全部条件
+func (a Attrs) Match(info fs.FileInfo) bool {
+	size := info.Size()
+	if a.MinSize > 0 && size < a.MinSize {
+		return false
+	}
+	if a.MaxSize > 0 && size > a.MaxSize {
+		return false
+	}
+	mtime := info.ModTime()
+	if !a.NewerThan.IsZero() && mtime.Before(a.NewerThan) {
+		return false
+	}
+	if !a.OlderThan.IsZero() && !mtime.Before(a.OlderThan) {
+		return false
+	}
+	return true
+}
+
+// Validate 检查区间是否有效
+func (a Attrs) Validate() error {
+	if a.MinSize < 0 || a.MaxSize < 0 {
+		return fmt.Errorf("文件大小不能为负数")
+	}
+	if a.MaxSize > 0 && a.MinSize > a.MaxSize {
+		return fmt.Errorf("最小文件大小 %d 超过最大文件大小 %d", a.MinSize, a.MaxSize)
+	}
+	if !a.NewerThan.IsZero() && !a.OlderThan.IsZero() && !a.NewerThan.Before(a.OlderThan) {
+		return fmt.Errorf("修改时间窗口为空: 晚于 %s 且早于 %s",
+			a.NewerThan.Format(time.DateTime), a.OlderThan.Format(time.DateTime))
+	}
+	return nil
+}
+
+// sizeUnits 大小后缀 (1024 进制)
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// ParseSize 解析文件大小，如 "512"、"100K"、"1.5M"、"2GB"，后缀不区分大小写，按 1024 进制计算
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok || i == 0 {
+		return 0, fmt.Errorf("无效的文件大小 %q，应为数字加可选单位 K/M/G/T", s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的文件大小 %q", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// timeLayouts ParseTime 接受的绝对时间格式 (本地时区)
+var timeLayouts = []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", time.DateOnly}
+
+// ParseTime 解析时间点: 相对 now 的时长 (如 "30m"、"48h"，另支持 "7d" 天与 "2w" 周)，
+// 或绝对时间 "2006-01-02"、"2006-01-02 15:04[:05]"、RFC3339
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := ParseAge(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无效的时间 %q，应为时长 (如 48h、7d) 或日期 (如 2006-01-02)", s)
+}
+
+// ParseAge 解析时长，在 time.ParseDuration 的基础上支持单独的 "d" (天) 与 "w" (周) 后缀
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if num, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("无效的时长 %q", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时长 %q", s)
+	}
+	return d, nil
+}
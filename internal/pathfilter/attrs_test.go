@@ -0,0 +1,104 @@
+package pathfilter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"512":   512,
+		"100K":  100 << 10,
+		"1.5m":  3 << 19,
+		"2GB":   2 << 30,
+		"1 TiB": 1 << 40,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "M", "10X", "1.2.3K"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q) 应返回错误", bad)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+	tests := map[string]time.Time{
+		"48h":              now.Add(-48 * time.Hour),
+		"7d":               now.Add(-7 * 24 * time.Hour),
+		"2w":               now.Add(-14 * 24 * time.Hour),
+		"2024-05-01":       time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		"2024-05-01 08:30": time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local),
+	}
+	for in, want := range tests {
+		got, err := ParseTime(in, now)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("ParseTime(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-3d", "2024-13-01"} {
+		if _, err := ParseTime(bad, now); err == nil {
+			t.Errorf("ParseTime(%q) 应返回错误", bad)
+		}
+	}
+}
+
+func TestAttrsMatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.docx")
+	if err := os.WriteFile(path, make([]byte, 2048), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-72 * time.Hour)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		attrs Attrs
+		want  bool
+	}{
+		{"不限", Attrs{}, true},
+		{"大小区间内", Attrs{MinSize: 1024, MaxSize: 4096}, true},
+		{"小于下限", Attrs{MinSize: 4096}, false},
+		{"大于上限", Attrs{MaxSize: 1024}, false},
+		{"最近 48 小时", Attrs{NewerThan: time.Now().Add(-48 * time.Hour)}, false},
+		{"最近 7 天", Attrs{NewerThan: time.Now().Add(-7 * 24 * time.Hour)}, true},
+		{"早于 1 天前", Attrs{OlderThan: time.Now().Add(-24 * time.Hour)}, true},
+		{"早于 4 天前", Attrs{OlderThan: time.Now().Add(-96 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.attrs.Match(info); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttrsValidate(t *testing.T) {
+	now := time.Now()
+	bad := []Attrs{
+		{MinSize: -1},
+		{MinSize: 2048, MaxSize: 1024},
+		{NewerThan: now.Add(-time.Hour), OlderThan: now.Add(-48 * time.Hour)},
+	}
+	for i, a := range bad {
+		if err := a.Validate(); err == nil {
+			t.Errorf("case %d: 应返回错误", i)
+		}
+	}
+	if err := (Attrs{MinSize: 1, NewerThan: now.Add(-48 * time.Hour), OlderThan: now}).Validate(); err != nil {
+		t.Errorf("有效区间: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package pathfilter 统一的扫描路径过滤
+// 文件监控、扫描服务、定时扫描以及各调试工具的 collectFiles 都通过本包判断路径是否需要扫描，
+// 保证排除规则在各处一致
+package pathfilter
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"linuxFileWatcher/internal/mountinfo"
+	"linuxFileWatcher/internal/safewalk"
+)
+
+// Options 过滤规则
+type Options struct {
+	// 排除的目录 (绝对路径前缀)，兼容 scanner.exclude_dirs
+	ExcludeDirs []string
+	// 排除的文件/目录 glob
+	ExcludeGlobs []string
+	// 包含的文件 glob，为空表示全部
+	IncludeGlobs []string
+	// 排除的文件/目录正则 (匹配完整路径)
+	ExcludeRegex []string
+	// 相对扫描根目录的最大深度，0 表示不限
+	MaxDepth int
+	// 跳过的文件系统类型 (如 proc, sysfs, nfs)
+	SkipFSTypes []string
+}
+
+// pseudoFSTypes 伪文件系统，内容不是用户文件
+var pseudoFSTypes = []string{
+	"proc", "sysfs", "devtmpfs", "devpts", "cgroup", "cgroup2",
+	"securityfs", "debugfs", "tracefs", "pstore", "bpf", "configfs",
+	"fusectl", "mqueue", "hugetlbfs", "autofs", "binfmt_misc", "rpc_pipefs",
+}
+
+// networkFSTypes 网络文件系统，后台全盘扫描时跳过以免占用网络与远端 IO
+var networkFSTypes = []string{"nfs", "nfs4", "cifs", "smb3", "smbfs", "fuse.sshfs"}
+
+// DefaultOptions 默认过滤规则：跳过伪文件系统与网络文件系统
+func DefaultOptions() Options {
+	return Options{SkipFSTypes: append(append([]string{}, pseudoFSTypes...), networkFSTypes...)}
+}
+
+// ManualOptions 手动扫描的过滤规则：只跳过伪文件系统
+// 调试工具等由用户显式指定扫描路径，网络文件系统上的路径照常扫描
+func ManualOptions() Options {
+	return Options{SkipFSTypes: append([]string{}, pseudoFSTypes...)}
+}
+
+// Filter 编译后的过滤器，并发安全
+type Filter struct {
+	excludeDirs  []string
+	excludeGlobs []*regexp.Regexp
+	includeGlobs []*regexp.Regexp
+	excludeRegex []*regexp.Regexp
+	maxDepth     int
+	skipFSTypes  map[string]bool
+
+	mountsMu sync.RWMutex
+	mounts   []mountinfo.Entry
+}
+
+// New 编译过滤规则
+func New(opts Options) (*Filter, error) {
+	f := &Filter{
+		maxDepth:    opts.MaxDepth,
+		skipFSTypes: make(map[string]bool, len(opts.SkipFSTypes)),
+	}
+
+	for _, dir := range opts.ExcludeDirs {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			continue
+		}
+		f.excludeDirs = append(f.excludeDirs, filepath.Clean(dir))
+	}
+
+	var err error
+	if f.excludeGlobs, err = compileGlobs(opts.ExcludeGlobs); err != nil {
+		return nil, err
+	}
+	if f.includeGlobs, err = compileGlobs(opts.IncludeGlobs); err != nil {
+		return nil, err
+	}
+	for _, expr := range opts.ExcludeRegex {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("无效的排除正则 %q: %w", expr, err)
+		}
+		f.excludeRegex = append(f.excludeRegex, re)
+	}
+
+	for _, t := range opts.SkipFSTypes {
+		f.skipFSTypes[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	if len(f.skipFSTypes) > 0 {
+		f.mounts = mountinfo.Read()
+	}
+
+	return f, nil
+}
+
+func compileGlobs(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := compileGlob(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// RefreshMounts 重新读取挂载表 (挂载/卸载文件系统后调用)
+func (f *Filter) RefreshMounts() {
+	if len(f.skipFSTypes) == 0 {
+		return
+	}
+	mounts := mountinfo.Read()
+	f.mountsMu.Lock()
+	f.mounts = mounts
+	f.mountsMu.Unlock()
+}
+
+// SkipDir 判断遍历时是否跳过目录
+// depth: 相对扫描根目录的深度，根目录为 0
+func (f *Filter) SkipDir(path string, depth int) bool {
+	if f == nil {
+		return false
+	}
+	path = filepath.Clean(path)
+
+	if depth > 0 && f.maxDepth > 0 && depth >= f.maxDepth {
+		return true
+	}
+	if f.excluded(path) {
+		return true
+	}
+	return f.onSkippedFS(path)
+}
+
+// SkipFile 判断是否跳过文件
+// depth: 相对扫描根目录的深度，<0 表示未知 (如监控事件)，此时不检查深度
+func (f *Filter) SkipFile(path string, depth int) bool {
+	if f == nil {
+		return false
+	}
+	path = filepath.Clean(path)
+
+	if depth >= 0 && f.maxDepth > 0 && depth > f.maxDepth {
+		return true
+	}
+	if f.excluded(path) {
+		return true
+	}
+	if len(f.includeGlobs) > 0 && !matchAny(f.includeGlobs, path) {
+		return true
+	}
+	return f.onSkippedFS(path)
+}
+
+// Allow 判断单个路径 (如监控事件) 是否需要扫描
+// 会检查路径上各级目录的排除规则
+func (f *Filter) Allow(path string) bool {
+	if f == nil {
+		return true
+	}
+	path = filepath.Clean(path)
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if f.excluded(dir) {
+			return false
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return !f.SkipFile(path, -1)
+}
+
+// excluded 检查排除目录、排除 glob 与排除正则
+func (f *Filter) excluded(path string) bool {
+	for _, dir := range f.excludeDirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	if matchAny(f.excludeGlobs, path) {
+		return true
+	}
+	return matchAny(f.excludeRegex, path)
+}
+
+// onSkippedFS 检查路径是否位于需跳过的文件系统
+func (f *Filter) onSkippedFS(path string) bool {
+	if len(f.skipFSTypes) == 0 {
+		return false
+	}
+	f.mountsMu.RLock()
+	m, ok := mountinfo.Lookup(f.mounts, path)
+	f.mountsMu.RUnlock()
+	return ok && f.skipFSTypes[m.FSType]
+}
+
+func matchAny(res []*regexp.Regexp, path string) bool {
+	slashed := filepath.ToSlash(path)
+	for _, re := range res {
+		if re.MatchString(slashed) {
+			return true
+		}
+	}
+	return false
+}
+
+// Depth 计算 path 相对 root 的深度
+func Depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// WalkFunc 遍历回调，仅对通过过滤的普通文件调用
+type WalkFunc func(path string, d fs.DirEntry) error
+
+// Walk 按过滤规则遍历目录
+// 访问失败的路径会被跳过；fn 返回错误时中止遍历。符号链接成环、硬链接与重复挂载的目录只访问一次
+func (f *Filter) Walk(root string, fn WalkFunc) error {
+	root = filepath.Clean(root)
+	return safewalk.Walk(root, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		depth := Depth(root, path)
+		if d.IsDir() {
+			if path != root && f.SkipDir(path, depth) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if f.SkipFile(path, depth) {
+			return nil
+		}
+		return fn(path, d)
+	})
+}
+
+// ==========================================
+// 全局过滤器 (支持运行时重载)
+// ==========================================
+
+var (
+	defaultMu     sync.RWMutex
+	defaultFilter *Filter
+)
+
+// Default 返回全局过滤器
+// 未调用 SetDefault 时使用 DefaultOptions
+func Default() *Filter {
+	defaultMu.RLock()
+	f := defaultFilter
+	defaultMu.RUnlock()
+	if f != nil {
+		return f
+	}
+
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultFilter == nil {
+		defaultFilter, _ = New(DefaultOptions())
+	}
+	return defaultFilter
+}
+
+// SetDefault 替换全局过滤器 (配置重载时调用)
+func SetDefault(f *Filter) {
+	defaultMu.Lock()
+	defaultFilter = f
+	defaultMu.Unlock()
+}
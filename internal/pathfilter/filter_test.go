@@ -0,0 +1,166 @@
+package pathfilter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/mountinfo"
+)
+
+// ==========================================
+// glob 测试
+// ==========================================
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.tmp", "/home/a/b.tmp", true},
+		{"*.tmp", "/home/a/b.tmp.txt", false},
+		{"node_modules", "/src/app/node_modules", true},
+		{"/proc", "/proc", true},
+		{"/proc", "/home/proc", false},
+		{"/home/*/.cache", "/home/u/.cache", true},
+		{"/home/*/.cache", "/home/u/x/.cache", false},
+		{".git/objects", "/src/repo/.git/objects", true},
+		{"/data/**", "/data", true},
+		{"/data/**", "/data/a/b/c", true},
+		{"/data/**", "/database", false},
+		{"**/build/*.o", "/src/x/build/a.o", true},
+		{"~$*", "/docs/~$report.docx", true},
+		{"file[0-9].txt", "/a/file7.txt", true},
+		{"file[!0-9].txt", "/a/file7.txt", false},
+	}
+
+	for _, tt := range tests {
+		re, err := compileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("compileGlob(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("glob %q match %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := compileGlob("a[bc"); err == nil {
+		t.Error("未闭合的字符类应返回错误")
+	}
+}
+
+const sampleMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+23 22 0:21 / /proc rw,nosuid shared:12 - proc proc rw
+60 22 0:50 / /mnt/share rw,relatime shared:30 - nfs4 server:/export rw
+`
+
+// ==========================================
+// 过滤器测试
+// ==========================================
+
+func TestFilter_SkipFSType(t *testing.T) {
+	f, err := New(Options{SkipFSTypes: []string{"proc", "nfs4"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mounts = mountinfo.Parse(strings.NewReader(sampleMountInfo))
+
+	if !f.SkipDir("/proc", 1) || !f.SkipDir("/mnt/share", 2) {
+		t.Error("proc/nfs4 挂载点应被跳过")
+	}
+	if f.SkipDir("/home", 1) {
+		t.Error("ext4 目录不应被跳过")
+	}
+	if f.Allow("/mnt/share/doc/a.docx") {
+		t.Error("nfs4 上的文件不应扫描")
+	}
+}
+
+func TestManualOptions(t *testing.T) {
+	f, err := New(ManualOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.mounts = mountinfo.Parse(strings.NewReader(sampleMountInfo))
+
+	if !f.SkipDir("/proc", 1) {
+		t.Error("伪文件系统应被跳过")
+	}
+	if f.SkipDir("/mnt/share", 2) || !f.Allow("/mnt/share/doc/a.docx") {
+		t.Error("手动扫描不应跳过网络文件系统")
+	}
+}
+
+func TestFilter_Allow(t *testing.T) {
+	f, err := New(Options{
+		ExcludeDirs:  []string{"/var/cache"},
+		ExcludeGlobs: []string{".git", "*.tmp"},
+		ExcludeRegex: []string{`/backup-\d{8}/`},
+		IncludeGlobs: []string{"*.docx", "*.pdf", "*.tmp"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"/home/u/a.docx":               true,
+		"/home/u/a.txt":                false, // 不在 include 中
+		"/home/u/a.tmp":                false, // exclude 优先
+		"/home/u/repo/.git/x.pdf":      false,
+		"/var/cache/a.pdf":             false,
+		"/var/cached/a.pdf":            true,
+		"/data/backup-20240101/a.docx": false,
+		"/data/backup-2024/a.docx":     true,
+	}
+	for path, want := range tests {
+		if got := f.Allow(path); got != want {
+			t.Errorf("Allow(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if _, err := New(Options{ExcludeRegex: []string{"("}}); err == nil {
+		t.Error("无效正则应返回错误")
+	}
+}
+
+func TestFilter_Walk(t *testing.T) {
+	root := t.TempDir()
+	for _, p := range []string{"a.txt", "skip/b.txt", "d1/c.txt", "d1/d2/d.txt", "d1/x.tmp"} {
+		full := filepath.Join(root, p)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := New(Options{ExcludeGlobs: []string{"skip", "*.tmp"}, MaxDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	if err := f.Walk(root, func(path string, _ os.DirEntry) error {
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, filepath.ToSlash(rel))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+
+	want := []string{"a.txt", "d1/c.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Walk() = %v, want %v", got, want)
+	}
+}
+
+func TestDepth(t *testing.T) {
+	if Depth("/a", "/a") != 0 || Depth("/a", "/a/b") != 1 || Depth("/a", "/a/b/c") != 2 {
+		t.Error("Depth 计算错误")
+	}
+}
@@ -0,0 +1,89 @@
+package pathfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CompileGlob 按路径过滤规则相同的语义编译 glob，供其他模块匹配路径
+func CompileGlob(pattern string) (*regexp.Regexp, error) {
+	return compileGlob(pattern)
+}
+
+// compileGlob 将 glob 转换为正则表达式
+//
+// 规则：
+//   - 不含 "/" 的模式只匹配文件名，如 "*.tmp"、"node_modules"
+//   - 以 "/" 开头的模式匹配绝对路径，如 "/proc"、"/home/*/.cache"
+//   - 其余含 "/" 的模式匹配路径的任意后缀，如 ".git/objects"
+//   - "*" 不跨越目录分隔符，"**" 可跨越任意层目录
+//   - 结尾的 "/**" 同时匹配目录本身
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	p := strings.TrimSpace(pattern)
+	if p == "" {
+		return nil, fmt.Errorf("空 glob 模式")
+	}
+
+	var sb strings.Builder
+	switch {
+	case strings.HasPrefix(p, "/"):
+		sb.WriteString("^")
+	case strings.HasPrefix(p, "**/"):
+		sb.WriteString("(^|/)")
+		p = p[3:]
+	default:
+		sb.WriteString("(^|/)")
+	}
+
+	trailingAll := false
+	if strings.HasSuffix(p, "/**") {
+		trailingAll = true
+		p = strings.TrimSuffix(p, "/**")
+	}
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				// "**/" 匹配零或多层目录
+				if i+2 < len(p) && p[i+2] == '/' {
+					sb.WriteString("(.*/)?")
+					i += 2
+				} else {
+					sb.WriteString(".*")
+					i++
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("glob 模式缺少 ']': %q", pattern)
+			}
+			class := p[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if trailingAll {
+		sb.WriteString("(/.*)?")
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, fmt.Errorf("无效的 glob 模式 %q: %w", pattern, err)
+	}
+	return re, nil
+}
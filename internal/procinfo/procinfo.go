@@ -0,0 +1,91 @@
+// Package procinfo 读取 /proc/<pid> 获取进程信息，用于告警的进程溯源
+package procinfo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// procRoot proc 文件系统挂载点，测试时可替换
+var procRoot = "/proc"
+
+// Lookup 读取进程信息
+// 进程已退出时返回错误；部分字段 (如 exe) 因权限不足读取失败时留空
+func Lookup(pid int) (*model.ProcessInfo, error) {
+	if pid <= 0 {
+		return nil, fmt.Errorf("invalid pid: %d", pid)
+	}
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	status, err := os.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return nil, fmt.Errorf("read process status failed: %w", err)
+	}
+
+	info := &model.ProcessInfo{PID: pid, UID: -1}
+	parseStatus(status, info)
+
+	if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		info.Exe = strings.TrimSuffix(exe, " (deleted)")
+	}
+	if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		info.Cmdline = formatCmdline(cmdline)
+	}
+	info.SessionInfo = readSession(dir)
+
+	if info.UID >= 0 {
+		info.User = strconv.Itoa(info.UID)
+		if u, err := user.LookupId(info.User); err == nil {
+			info.User = u.Username
+		}
+	}
+
+	return info, nil
+}
+
+// parseStatus 解析 /proc/<pid>/status 中的 Name、PPid、Uid
+func parseStatus(data []byte, info *model.ProcessInfo) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Name":
+			info.Comm = value
+		case "PPid":
+			info.PPID, _ = strconv.Atoi(value)
+		case "Uid":
+			// Uid: real effective saved fs，取有效 UID
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				if uid, err := strconv.Atoi(fields[1]); err == nil {
+					info.UID = uid
+				}
+			}
+		}
+	}
+}
+
+// formatCmdline 将 NUL 分隔的命令行转换为空格分隔，并限制长度
+func formatCmdline(data []byte) string {
+	const maxLen = 1024
+
+	data = bytes.TrimRight(data, "\x00")
+	s := strings.ReplaceAll(string(data), "\x00", " ")
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	return s
+}
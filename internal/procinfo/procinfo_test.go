@@ -0,0 +1,98 @@
+package procinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestLookup_Fake(t *testing.T) {
+	root := t.TempDir()
+	old := procRoot
+	procRoot = root
+	defer func() { procRoot = old }()
+
+	dir := filepath.Join(root, "1234")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	status := "Name:\tcp\nState:\tR (running)\nPPid:\t42\nUid:\t1000\t1001\t1000\t1000\n"
+	_ = os.WriteFile(filepath.Join(dir, "status"), []byte(status), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "cmdline"), []byte("cp\x00-r\x00/home/u/a\x00/media/usb/\x00"), 0644)
+	_ = os.Symlink("/usr/bin/cp", filepath.Join(dir, "exe"))
+
+	info, err := Lookup(1234)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.ProcessInfo{PID: 1234, PPID: 42, Comm: "cp", Exe: "/usr/bin/cp", Cmdline: "cp -r /home/u/a /media/usb/", UID: 1001}
+	if info.PID != want.PID || info.PPID != want.PPID || info.Comm != want.Comm ||
+		info.Exe != want.Exe || info.Cmdline != want.Cmdline || info.UID != want.UID {
+		t.Errorf("Lookup() = %+v, want %+v", *info, want)
+	}
+	if info.User == "" {
+		t.Error("User 不应为空")
+	}
+
+	if _, err := Lookup(99999); err == nil {
+		t.Error("不存在的进程应返回错误")
+	}
+	if _, err := Lookup(0); err == nil {
+		t.Error("pid 0 应返回错误")
+	}
+}
+
+func TestLookup_Self(t *testing.T) {
+	if _, err := os.Stat("/proc/self/status"); err != nil {
+		t.Skip("当前平台无 /proc")
+	}
+	info, err := Lookup(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Exe == "" || info.Comm == "" {
+		t.Errorf("自身进程信息不完整: %+v", *info)
+	}
+}
+
+func TestLookup_Session(t *testing.T) {
+	root := t.TempDir()
+	oldProc, oldSessions := procRoot, sessionsRoot
+	procRoot, sessionsRoot = root, filepath.Join(root, "sessions")
+	defer func() { procRoot, sessionsRoot = oldProc, oldSessions }()
+	os.MkdirAll(sessionsRoot, 0755)
+	_ = os.WriteFile(filepath.Join(sessionsRoot, "7"), []byte("# This is private data. Do not parse.\nUID=1000\nUSER=alice\nACTIVE=1\nTTY=pts/3\nREMOTE=1\nREMOTE_HOST=10.0.0.8\nSERVICE=sshd\n"), 0644)
+	_ = os.WriteFile(filepath.Join(sessionsRoot, "c2"), []byte("UID=1001\nUSER=bob\nSERVICE=gdm-password\n"), 0644)
+
+	proc := func(pid, loginuid, sessionid, cgroup string) {
+		dir := filepath.Join(root, pid)
+		os.MkdirAll(dir, 0755)
+		_ = os.WriteFile(filepath.Join(dir, "status"), []byte("Name:\tvim\nPPid:\t1\nUid:\t0\t0\t0\t0\n"), 0644)
+		_ = os.WriteFile(filepath.Join(dir, "loginuid"), []byte(loginuid), 0644)
+		_ = os.WriteFile(filepath.Join(dir, "sessionid"), []byte(sessionid), 0644)
+		_ = os.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0644)
+	}
+	// sudo 切换为 root 后仍归属 alice 的 SSH 会话
+	proc("100", "1000", "7", "0::/user.slice/user-1000.slice/session-7.scope\n")
+	// 未启用审计，从 cgroup 找到 logind 会话
+	proc("200", "4294967295", "4294967295", "0::/user.slice/user-1001.slice/session-c2.scope\n")
+	// 系统服务不属于登录会话
+	proc("300", "4294967295", "4294967295", "0::/system.slice/cron.service\n")
+
+	info, err := Lookup(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.SessionInfo{LoginUser: "alice", SessionID: "7", SessionTTY: "pts/3", SessionRemoteHost: "10.0.0.8", SessionService: "sshd"}
+	if info.SessionInfo != want || info.UID != 0 {
+		t.Errorf("session = %+v, uid = %d", info.SessionInfo, info.UID)
+	}
+	if info, _ := Lookup(200); info.LoginUser != "bob" || info.SessionID != "c2" || info.SessionService != "gdm-password" {
+		t.Errorf("cgroup session = %+v", info.SessionInfo)
+	}
+	if info, _ := Lookup(300); !info.SessionInfo.Empty() {
+		t.Errorf("service session = %+v", info.SessionInfo)
+	}
+}
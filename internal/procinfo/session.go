@@ -0,0 +1,98 @@
+package procinfo
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// sessionsRoot systemd-logind 会话状态目录，测试时可替换
+var sessionsRoot = "/run/systemd/sessions"
+
+// unsetID loginuid/sessionid 未设置时的取值 ((uint32)-1)
+const unsetID = "4294967295"
+
+// sessionScope cgroup 路径中的 logind 会话，如 /user.slice/user-1000.slice/session-3.scope
+var sessionScope = regexp.MustCompile(`(?m)/session-([^/]+)\.scope(?:/|$)`)
+
+// readSession 读取进程所属的登录会话
+// 优先使用审计子系统的 loginuid/sessionid；未启用审计时从 cgroup 找到 logind 会话
+func readSession(dir string) model.SessionInfo {
+	var s model.SessionInfo
+	loginUID := readID(filepath.Join(dir, "loginuid"))
+	s.SessionID = readID(filepath.Join(dir, "sessionid"))
+	if s.SessionID == "" {
+		if cgroup, err := os.ReadFile(filepath.Join(dir, "cgroup")); err == nil {
+			if m := sessionScope.FindSubmatch(cgroup); m != nil {
+				s.SessionID = string(m[1])
+			}
+		}
+	}
+
+	// logind 的会话名与审计会话 ID 一致 (pam_systemd 在审计可用时沿用)
+	if s.SessionID != "" {
+		if props, err := readEnvFile(filepath.Join(sessionsRoot, s.SessionID)); err == nil {
+			if loginUID == "" {
+				loginUID = props["UID"]
+			}
+			s.SessionTTY = props["TTY"]
+			s.SessionRemoteHost = props["REMOTE_HOST"]
+			s.SessionService = props["SERVICE"]
+			if s.LoginUser = props["USER"]; s.LoginUser != "" && props["UID"] != loginUID {
+				// 会话文件属于其他用户时不采用其用户名
+				s.LoginUser = ""
+			}
+		}
+	}
+
+	if s.LoginUser == "" && loginUID != "" {
+		s.LoginUser = loginUID
+		if u, err := user.LookupId(loginUID); err == nil {
+			s.LoginUser = u.Username
+		}
+	}
+	return s
+}
+
+// readID 读取 loginuid/sessionid，未设置或读取失败时返回空
+func readID(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	id := strings.TrimSpace(string(data))
+	if id == "" || id == unsetID {
+		return ""
+	}
+	if _, err := strconv.ParseUint(id, 10, 32); err != nil {
+		return ""
+	}
+	return id
+}
+
+// readEnvFile 解析 logind 状态文件 (KEY=VALUE 每行一项)
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			props[key] = value
+		}
+	}
+	return props, scanner.Err()
+}
@@ -0,0 +1,146 @@
+// Package safewalk 防循环、防重复的目录遍历
+// 以 设备号+inode 记录已访问的目录与多链接文件：符号链接成环、bind mount 把目录挂到自身之下
+// 都不会导致无限遍历，同一文件的多个硬链接、多处挂载的同一目录只访问一次。
+// 回调与 filepath.WalkDir 一致 (按文件名顺序，支持 fs.SkipDir / fs.SkipAll)，可直接替换
+package safewalk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Options 遍历选项
+type Options struct {
+	// 跟随符号链接：指向目录的链接进入遍历，指向文件的链接按目标文件回调 (路径仍为链接路径)，
+	// 链接与目标只回调先遍历到的一个
+	// 不跟随时符号链接按原样回调，与 filepath.WalkDir 一致
+	FollowSymlinks bool
+	// 不跨越文件系统：跳过与根目录不在同一设备上的目录 (挂载点)
+	SameFilesystem bool
+}
+
+// Walker 带已访问集合的遍历器
+// 多次调用 Walk 共用同一集合，多个扫描根之间重叠的目录与硬链接也只访问一次；不可并发使用
+type Walker struct {
+	opts    Options
+	visited map[fileKey]bool
+}
+
+// New 创建遍历器
+func New(opts Options) *Walker {
+	return &Walker{opts: opts, visited: make(map[fileKey]bool)}
+}
+
+// Walk 使用新的遍历器遍历 root
+func Walk(root string, opts Options, fn fs.WalkDirFunc) error {
+	return New(opts).Walk(root, fn)
+}
+
+// Walk 遍历 root，语义同 filepath.WalkDir
+// 已访问过的目录、文件以及 SameFilesystem 下其他设备上的目录直接跳过，不回调 fn
+func (w *Walker) Walk(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Lstat(root)
+	if err == nil && w.opts.FollowSymlinks && info.Mode()&fs.ModeSymlink != 0 {
+		info, err = os.Stat(root)
+	}
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		dev, _ := deviceOf(info)
+		err = w.walk(root, fs.FileInfoToDirEntry(info), info, dev, fn)
+	}
+	if errors.Is(err, fs.SkipDir) || errors.Is(err, fs.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// walk 访问 path，info 为 d 对应 (符号链接已解析) 的文件信息
+func (w *Walker) walk(path string, d fs.DirEntry, info fs.FileInfo, rootDev uint64, fn fs.WalkDirFunc) error {
+	if info != nil && w.seen(path, info) {
+		return nil
+	}
+	if !d.IsDir() {
+		return fn(path, d, nil)
+	}
+	if w.opts.SameFilesystem && info != nil {
+		if dev, ok := deviceOf(info); ok && dev != rootDev {
+			return nil
+		}
+	}
+
+	if err := fn(path, d, nil); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		// 与 filepath.WalkDir 一致，读取目录失败时以错误再回调一次
+		if err = fn(path, d, err); err != nil {
+			if err == fs.SkipDir && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+
+	for _, e := range entries {
+		name := filepath.Join(path, e.Name())
+		child, childInfo := e, fs.FileInfo(nil)
+		if e.Type()&fs.ModeSymlink != 0 && w.opts.FollowSymlinks {
+			// 目标不存在的链接按原样回调
+			if target, err := os.Stat(name); err == nil {
+				child, childInfo = renamedEntry{fs.FileInfoToDirEntry(target), e.Name()}, target
+			}
+		} else if e.IsDir() || e.Type().IsRegular() {
+			// 读取失败 (如已被删除) 时不做去重，照常回调
+			childInfo, _ = e.Info()
+		}
+
+		if err := w.walk(name, child, childInfo, rootDev, fn); err != nil {
+			if err == fs.SkipDir {
+				if child.IsDir() {
+					continue
+				}
+				// 文件返回 SkipDir 时跳过所在目录的剩余项
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// seen 记录并判断是否已访问
+// 不跟随符号链接时，只有一个链接的普通文件不可能重复，不记录以节省内存
+func (w *Walker) seen(path string, info fs.FileInfo) bool {
+	if !info.IsDir() && !w.opts.FollowSymlinks && linkCount(info) <= 1 {
+		return false
+	}
+	key, ok := keyOf(path, info)
+	if !ok {
+		return false
+	}
+	if w.visited[key] {
+		return true
+	}
+	w.visited[key] = true
+	return false
+}
+
+// fileKey 文件的唯一标识；无法取得 inode 的平台以目录的真实路径代替
+type fileKey struct {
+	dev, ino uint64
+	path     string
+}
+
+// renamedEntry 以链接名呈现符号链接的目标
+type renamedEntry struct {
+	fs.DirEntry
+	name string
+}
+
+func (e renamedEntry) Name() string {
+	return e.name
+}
@@ -0,0 +1,125 @@
+package safewalk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+// collect 遍历并返回相对 root 的回调路径
+func collect(t *testing.T, w *Walker, root string, fn func(path string, d fs.DirEntry) error) []string {
+	t.Helper()
+	var got []string
+	err := w.Walk(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		got = append(got, filepath.ToSlash(rel))
+		if fn != nil {
+			return fn(path, d)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+	return got
+}
+
+func TestWalk_SymlinkLoop(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "a", "b"), 0755)
+	os.WriteFile(filepath.Join(root, "a", "b", "f.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(root, "g.txt"), []byte("y"), 0644)
+	// 指回上层目录形成环；指向文件的链接
+	if err := os.Symlink(root, filepath.Join(root, "a", "b", "up")); err != nil {
+		t.Skip("不支持符号链接:", err)
+	}
+	os.Symlink(filepath.Join(root, "g.txt"), filepath.Join(root, "a", "link.txt"))
+
+	// 跟随：环只遍历一次，指向文件的链接按目标文件回调，目标本身不再重复
+	got := collect(t, New(Options{FollowSymlinks: true}), root, nil)
+	want := []string{".", "a", "a/b", "a/b/f.txt", "a/link.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FollowSymlinks 遍历 = %v, want %v", got, want)
+	}
+
+	// 不跟随：链接按原样回调
+	var links []string
+	collect(t, New(Options{}), root, func(path string, d fs.DirEntry) error {
+		if d.Type()&fs.ModeSymlink != 0 {
+			links = append(links, filepath.Base(path))
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(links, []string{"up", "link.txt"}) {
+		t.Errorf("符号链接 = %v", links)
+	}
+}
+
+func TestWalk_Hardlinks(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("仅 Linux 识别硬链接")
+	}
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "x"), 0755)
+	os.MkdirAll(filepath.Join(root, "y"), 0755)
+	os.WriteFile(filepath.Join(root, "x", "f.txt"), []byte("x"), 0644)
+	if err := os.Link(filepath.Join(root, "x", "f.txt"), filepath.Join(root, "y", "f.txt")); err != nil {
+		t.Skip("不支持硬链接:", err)
+	}
+
+	got := collect(t, New(Options{}), root, nil)
+	want := []string{".", "x", "x/f.txt", "y"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("遍历 = %v, want %v", got, want)
+	}
+
+	// 同一 Walker 再次遍历重叠的目录不重复回调
+	w := New(Options{})
+	collect(t, w, filepath.Join(root, "x"), nil)
+	if got := collect(t, w, root, nil); !reflect.DeepEqual(got, []string{".", "y"}) {
+		t.Errorf("重叠的扫描根 = %v", got)
+	}
+}
+
+func TestWalk_Skip(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b", "c"} {
+		os.MkdirAll(filepath.Join(root, dir), 0755)
+		os.WriteFile(filepath.Join(root, dir, "1.txt"), nil, 0644)
+		os.WriteFile(filepath.Join(root, dir, "2.txt"), nil, 0644)
+	}
+
+	got := collect(t, New(Options{}), root, func(path string, d fs.DirEntry) error {
+		switch filepath.ToSlash(path[len(root):]) {
+		case "/a":
+			return fs.SkipDir
+		case "/b/1.txt":
+			return fs.SkipDir
+		case "/c/1.txt":
+			return fs.SkipAll
+		}
+		return nil
+	})
+	want := []string{".", "a", "b", "b/1.txt", "c", "c/1.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("遍历 = %v, want %v", got, want)
+	}
+}
+
+func TestWalk_MissingRoot(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	var called bool
+	err := Walk(missing, Options{}, func(path string, d fs.DirEntry, err error) error {
+		called = path == missing && d == nil && err != nil
+		return err
+	})
+	if !called || err == nil {
+		t.Errorf("called = %v, err = %v", called, err)
+	}
+}
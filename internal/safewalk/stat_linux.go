@@ -0,0 +1,34 @@
+//go:build linux
+
+package safewalk
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// keyOf 以 设备号+inode 标识文件
+func keyOf(_ string, info fs.FileInfo) (fileKey, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, false
+	}
+	return fileKey{dev: uint64(st.Dev), ino: st.Ino}, true
+}
+
+// deviceOf 文件所在设备号
+func deviceOf(info fs.FileInfo) (uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// linkCount 硬链接数
+func linkCount(info fs.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package safewalk
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// keyOf 非 Linux 平台以目录的真实路径标识，可防止符号链接成环，但不识别硬链接
+func keyOf(path string, info fs.FileInfo) (fileKey, bool) {
+	if !info.IsDir() {
+		return fileKey{}, false
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{path: real}, true
+}
+
+// deviceOf 非 Linux 平台不区分设备，SameFilesystem 不生效
+func deviceOf(fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// linkCount 非 Linux 平台视为单链接
+func linkCount(fs.FileInfo) uint64 {
+	return 1
+}
@@ -0,0 +1,274 @@
+// Package baseline 签名的文件完整性基线
+// 基线文件为 JSON，记录文件的 SM3 哈希、大小与权限，并以 SM2 私钥 (终端密钥或单位密钥) 签名；
+// 离线对比前先用可信公钥校验签名，证明基线生成后未被修改
+package baseline
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/gmsm/sm3"
+)
+
+// FormatVersion 基线文件格式版本
+const FormatVersion = 1
+
+var (
+	// ErrUnsigned 基线没有签名
+	ErrUnsigned = errors.New("baseline: not signed")
+	// ErrBadSignature 签名校验失败 (基线被修改或签名密钥不符)
+	ErrBadSignature = errors.New("baseline: signature verification failed")
+)
+
+// Status 对比结果
+type Status string
+
+const (
+	StatusOK       Status = "ok"       // 与基线一致
+	StatusModified Status = "modified" // 内容变化
+	StatusMetadata Status = "metadata" // 内容一致，权限变化
+	StatusMissing  Status = "missing"  // 文件不存在
+)
+
+// Entry 基线中的一个文件
+type Entry struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Mode uint32 `json:"mode"`
+	Hash string `json:"hash"` // 内容 SM3
+}
+
+// File 基线文件
+type File struct {
+	Version     int     `json:"version"`
+	GeneratedAt string  `json:"generated_at"`
+	Hostname    string  `json:"hostname,omitempty"`
+	Entries     []Entry `json:"entries"`
+	// 签名公钥 (04||X||Y 十六进制)，仅用于提示，校验时必须使用可信公钥
+	Signer string `json:"signer,omitempty"`
+	// 对去掉 signature 字段后的 JSON 的 SM2 签名 (Base64 编码的 DER)
+	Signature string `json:"signature,omitempty"`
+}
+
+// Result 一个文件的对比结果
+type Result struct {
+	Path     string
+	Status   Status
+	Expected string
+	Actual   string
+	Detail   string
+}
+
+// Generate 为 paths 生成基线，paths 按 Expand 展开 (目录递归、支持 glob 模式)
+func Generate(paths []string) (*File, error) {
+	f := &File{Version: FormatVersion, GeneratedAt: time.Now().Format(time.RFC3339)}
+	f.Hostname, _ = os.Hostname()
+
+	files, err := Expand(paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := HashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		f.Entries = append(f.Entries, Entry{Path: path, Size: info.Size(), Mode: fileMode(info), Hash: hash})
+	}
+	return f, nil
+}
+
+// Expand 将目标展开为文件列表 (绝对路径，去重并排序)
+// 目标可以是文件、目录 (递归包含其下的普通文件) 或 glob 模式 (如 /etc/ssh/*_config，匹配到的目录同样递归)；
+// glob 没有匹配任何文件时返回错误，避免拼写错误导致目标被静默忽略
+func Expand(targets []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, t := range targets {
+		abs, err := filepath.Abs(t)
+		if err != nil {
+			return nil, err
+		}
+		matches := []string{abs}
+		if hasMeta(abs) {
+			if matches, err = filepath.Glob(abs); err != nil {
+				return nil, fmt.Errorf("baseline: invalid pattern %q: %w", t, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("baseline: pattern %q matches no files", t)
+			}
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(m)
+				continue
+			}
+			err = filepath.WalkDir(m, func(path string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				add(path)
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// hasMeta 路径是否包含 glob 元字符
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[`)
+}
+
+// payload 签名原文: 去掉签名字段后的 JSON (字段顺序固定)
+func (f *File) payload() ([]byte, error) {
+	c := *f
+	c.Signature = ""
+	return json.Marshal(&c)
+}
+
+// Sign 以私钥签名，同时记录签名公钥
+func (f *File) Sign(priv *sm2.PrivateKey) error {
+	f.Signer = priv.PublicKey.Hex()
+	payload, err := f.payload()
+	if err != nil {
+		return err
+	}
+	sig, err := sm2.Sign(nil, priv, payload)
+	if err != nil {
+		return err
+	}
+	f.Signature = base64.StdEncoding.EncodeToString(sig)
+	return nil
+}
+
+// Verify 以可信公钥校验签名
+func (f *File) Verify(pub *sm2.PublicKey) error {
+	if f.Signature == "" {
+		return ErrUnsigned
+	}
+	sig, err := base64.StdEncoding.DecodeString(f.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	payload, err := f.payload()
+	if err != nil {
+		return err
+	}
+	if !sm2.Verify(pub, payload, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Save 写入基线文件 (0600)
+func (f *File) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Load 读取基线文件，不校验签名
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("baseline: parse %s: %w", path, err)
+	}
+	if f.Version != FormatVersion {
+		return nil, fmt.Errorf("baseline: unsupported version %d", f.Version)
+	}
+	return &f, nil
+}
+
+// LoadVerified 读取基线文件并校验签名，签名无效时不返回基线
+func LoadVerified(path string, pub *sm2.PublicKey) (*File, error) {
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Verify(pub); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Compare 将磁盘上的文件与基线对比
+func (f *File) Compare() []Result {
+	results := make([]Result, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		r := Result{Path: e.Path, Status: StatusOK, Expected: e.Hash}
+		info, err := os.Stat(e.Path)
+		if err != nil {
+			r.Status = StatusMissing
+			r.Detail = err.Error()
+			results = append(results, r)
+			continue
+		}
+		if r.Actual, err = HashFile(e.Path); err != nil {
+			r.Status = StatusMissing
+			r.Detail = err.Error()
+		} else if r.Actual != e.Hash {
+			r.Status = StatusModified
+		} else if mode := fileMode(info); mode != e.Mode {
+			r.Status = StatusMetadata
+			r.Detail = fmt.Sprintf("mode %04o -> %04o", e.Mode, mode)
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// HashFile 计算文件内容 SM3
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sm3.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileMode(info fs.FileInfo) uint32 {
+	return uint32(info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky))
+}
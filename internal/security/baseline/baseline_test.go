@@ -0,0 +1,180 @@
+package baseline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signedBaseline 为临时目录生成并签名基线，返回基线文件路径
+func signedBaseline(t *testing.T, priv *sm2.PrivateKey) (dir, path string) {
+	t.Helper()
+	dir = t.TempDir()
+	writeFile(t, filepath.Join(dir, "bin/tool"), "tool")
+	writeFile(t, filepath.Join(dir, "etc/app.conf"), "conf")
+
+	f, err := Generate([]string{filepath.Join(dir, "bin"), filepath.Join(dir, "etc/app.conf")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", f.Entries)
+	}
+	if err := f.Sign(priv); err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(t.TempDir(), "baseline.json")
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	return dir, path
+}
+
+func TestLoadVerified(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	_, path := signedBaseline(t, priv)
+
+	f, err := LoadVerified(path, &priv.PublicKey)
+	if err != nil {
+		t.Fatalf("LoadVerified() error = %v", err)
+	}
+	if f.Signer != priv.PublicKey.Hex() {
+		t.Errorf("Signer = %s", f.Signer)
+	}
+
+	other, _ := sm2.GenerateKey(nil)
+	if _, err := LoadVerified(path, &other.PublicKey); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("其他公钥校验 error = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestLoadVerified_Altered(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	_, path := signedBaseline(t, priv)
+
+	// 修改基线中的哈希
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Entries[0].Hash = "00"
+	if err := f.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVerified(path, &priv.PublicKey); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("LoadVerified() error = %v, want ErrBadSignature", err)
+	}
+
+	// 去掉签名
+	f.Signature = ""
+	f.Save(path)
+	if _, err := LoadVerified(path, &priv.PublicKey); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("LoadVerified() error = %v, want ErrUnsigned", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	dir, path := signedBaseline(t, priv)
+
+	writeFile(t, filepath.Join(dir, "bin/tool"), "tampered")
+	os.Chmod(filepath.Join(dir, "etc/app.conf"), 0666)
+
+	f, err := LoadVerified(path, &priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]Status)
+	for _, r := range f.Compare() {
+		rel, _ := filepath.Rel(dir, r.Path)
+		got[rel] = r.Status
+	}
+	if got["bin/tool"] != StatusModified || got["etc/app.conf"] != StatusMetadata {
+		t.Errorf("Compare() = %v", got)
+	}
+
+	os.Remove(filepath.Join(dir, "bin/tool"))
+	if r := f.Compare()[0]; r.Status != StatusMissing {
+		t.Errorf("删除后 status = %s, want missing", r.Status)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "etc/ssh/sshd_config"), "a")
+	writeFile(t, filepath.Join(dir, "etc/ssh/ssh_config"), "b")
+	writeFile(t, filepath.Join(dir, "etc/ssh/moduli"), "c")
+	writeFile(t, filepath.Join(dir, "bin/tool"), "d")
+	writeFile(t, filepath.Join(dir, "lib/a/x.so"), "e")
+
+	files, err := Expand([]string{
+		filepath.Join(dir, "etc/ssh/*_config"),
+		filepath.Join(dir, "bin/tool"),
+		filepath.Join(dir, "l*"),
+		filepath.Join(dir, "etc/ssh/sshd_config"), // 与 glob 重复
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(dir, f)
+		got = append(got, rel)
+	}
+	want := []string{"bin/tool", "etc/ssh/ssh_config", "etc/ssh/sshd_config", "lib/a/x.so"}
+	if len(got) != len(want) {
+		t.Fatalf("Expand() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expand() = %v, want %v", got, want)
+		}
+	}
+
+	if _, err := Expand([]string{filepath.Join(dir, "etc/*.conf")}); err == nil {
+		t.Error("未匹配的 glob 应返回错误")
+	}
+	if _, err := Expand([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("不存在的文件应返回错误")
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "baseline_sm2.key")
+	priv, err := LoadOrCreateKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("私钥文件权限 = %v, %v", info.Mode(), err)
+	}
+
+	again, err := LoadOrCreateKey(path)
+	if err != nil || again.D.Cmp(priv.D) != 0 {
+		t.Fatalf("再次读取私钥不一致: %v", err)
+	}
+
+	pub, err := LoadPublicKey(path + PublicKeySuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.Hex() != priv.PublicKey.Hex() {
+		t.Error("公钥文件与私钥不匹配")
+	}
+	if _, err := LoadPublicKey(priv.PublicKey.Hex()); err != nil {
+		t.Errorf("LoadPublicKey(hex) error = %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package baseline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+)
+
+// PublicKeySuffix 与私钥文件同目录保存的公钥文件后缀，供离线校验使用
+const PublicKeySuffix = ".pub"
+
+// LoadPrivateKey 读取私钥文件 (十六进制 d)
+func LoadPrivateKey(path string) (*sm2.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: read private key: %w", err)
+	}
+	return sm2.ParsePrivateKey(data)
+}
+
+// LoadOrCreateKey 读取私钥文件，不存在时生成终端密钥 (0600)，并写出公钥文件 path.pub
+func LoadOrCreateKey(path string) (*sm2.PrivateKey, error) {
+	priv, err := LoadPrivateKey(path)
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return priv, err
+	}
+
+	if priv, err = sm2.GenerateKey(nil); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// O_EXCL: 并发生成时以先写入者为准
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return LoadPrivateKey(path)
+		}
+		return nil, err
+	}
+	if _, err := f.WriteString(sm2.EncodePrivateKey(priv) + "\n"); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path+PublicKeySuffix, []byte(priv.PublicKey.Hex()+"\n"), 0644); err != nil {
+		return nil, err
+	}
+	return priv, nil
+}
+
+// LoadPublicKey 解析公钥，keyOrPath 为十六进制公钥 (04||X||Y) 或公钥文件路径
+func LoadPublicKey(keyOrPath string) (*sm2.PublicKey, error) {
+	if pub, err := sm2.ParsePublicKey([]byte(keyOrPath)); err == nil {
+		return pub, nil
+	}
+	data, err := os.ReadFile(keyOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: read public key: %w", err)
+	}
+	return sm2.ParsePublicKey(data)
+}
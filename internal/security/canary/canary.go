@@ -0,0 +1,347 @@
+// Package canary 诱饵文件 (蜜标)
+// 在敏感目录放置带唯一标识的诱饵文件，任何进程打开诱饵文件 (读取、复制、计算哈希) 时立即告警。
+// 正常业务不会访问诱饵文件，误报极低；标识同时写入文件内容，诱饵内容出现在副本或外发数据中时可按标识追溯来源
+package canary
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/fanotify"
+	"linuxFileWatcher/internal/logger"
+)
+
+// procRoot proc 文件系统挂载点，测试时可替换
+var procRoot = "/proc"
+
+// listener 文件打开事件来源
+type listener interface {
+	AddFile(path string, mask uint64) error
+	Run(ctx context.Context, handler func(fanotify.Event)) error
+	Close() error
+}
+
+// newListener 创建事件来源，测试时可替换
+var newListener = func() (listener, error) {
+	l, err := fanotify.NewListener()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// MarkerPrefix 诱饵标识前缀，完整标识为前缀加 32 位十六进制随机数
+const MarkerPrefix = "FWCANARY-"
+
+// markerLen 完整标识长度
+const markerLen = len(MarkerPrefix) + 32
+
+// DefaultDedup 同一进程访问同一诱饵文件的默认告警间隔
+const DefaultDedup = time.Minute
+
+// ErrNotCanary 目标路径已存在且不是诱饵文件，不会覆盖
+var ErrNotCanary = errors.New("canary: file exists and is not a canary")
+
+// Canary 一个诱饵文件
+type Canary struct {
+	Path   string
+	Marker string
+}
+
+// Access 诱饵文件被打开
+type Access struct {
+	Canary  Canary
+	PID     int
+	Process string
+	Exe     string
+	Time    time.Time
+}
+
+// Owner 访问进程描述
+func (a Access) Owner() string {
+	name := a.Process
+	if a.Exe != "" {
+		name = a.Exe
+	}
+	if name == "" {
+		name = "unknown"
+	}
+	return fmt.Sprintf("%s, pid %d", name, a.PID)
+}
+
+// Handler 诱饵文件被访问时的回调
+type Handler func(a Access)
+
+// Config 诱饵配置
+type Config struct {
+	// 诱饵文件绝对路径，所在目录需已存在
+	Files []string
+	// 允许访问诱饵文件的程序路径 (支持 * 通配)，如备份软件
+	AllowProcesses []string
+	// 同一进程访问同一诱饵文件的告警间隔，<=0 时使用 DefaultDedup
+	Dedup time.Duration
+}
+
+// accessKey 告警去重键
+type accessKey struct {
+	pid  int
+	path string
+}
+
+// Monitor 诱饵文件监控
+type Monitor struct {
+	cfg     Config
+	handler Handler
+
+	mu       sync.Mutex
+	canaries map[string]Canary
+	markers  map[string]Canary
+	last     map[accessKey]time.Time
+
+	l      listener
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New 创建诱饵文件监控
+func New(cfg Config, handler Handler) *Monitor {
+	if cfg.Dedup <= 0 {
+		cfg.Dedup = DefaultDedup
+	}
+	return &Monitor{
+		cfg:      cfg,
+		handler:  handler,
+		canaries: make(map[string]Canary),
+		markers:  make(map[string]Canary),
+		last:     make(map[accessKey]time.Time),
+	}
+}
+
+// Plant 放置全部诱饵文件，已存在的诱饵沿用其标识；单个文件失败不影响其他文件
+func (m *Monitor) Plant() ([]Canary, error) {
+	var errs []error
+	for _, p := range m.cfg.Files {
+		c, err := plant(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.mu.Lock()
+		m.canaries[c.Path] = c
+		m.markers[c.Marker] = c
+		m.mu.Unlock()
+	}
+	return m.Canaries(), errors.Join(errs...)
+}
+
+// Canaries 已放置的诱饵文件，按路径排序
+func (m *Monitor) Canaries() []Canary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Canary, 0, len(m.canaries))
+	for _, c := range m.canaries {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+// Find 在内容中查找诱饵标识，用于识别诱饵文件的副本
+func (m *Monitor) Find(data []byte) (Canary, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, marker := range findMarkers(data) {
+		if c, ok := m.markers[marker]; ok {
+			return c, true
+		}
+	}
+	return Canary{}, false
+}
+
+// Start 监听诱饵文件的打开事件 (需要 CAP_SYS_ADMIN)，需在 Plant 之后调用
+func (m *Monitor) Start() error {
+	canaries := m.Canaries()
+	if len(canaries) == 0 {
+		return errors.New("canary: no canary planted")
+	}
+	l, err := newListener()
+	if err != nil {
+		return err
+	}
+	for _, c := range canaries {
+		if err := l.AddFile(c.Path, fanotify.EventOpen); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.l, m.cancel, m.done = l, cancel, make(chan struct{})
+	go func() {
+		defer close(m.done)
+		if err := l.Run(ctx, func(ev fanotify.Event) {
+			if a, ok := m.handle(ev, time.Now()); ok && m.handler != nil {
+				m.handler(a)
+			}
+		}); err != nil {
+			logger.Error("诱饵文件监控异常退出", "error", err)
+		}
+	}()
+	logger.Info("诱饵文件监控已启动", "canaries", len(canaries))
+	return nil
+}
+
+// Stop 停止监控
+func (m *Monitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+	m.cancel = nil
+}
+
+// handle 处理一次打开事件，允许的程序与去重间隔内的重复访问不告警
+func (m *Monitor) handle(ev fanotify.Event, now time.Time) (Access, bool) {
+	a := Access{PID: ev.PID, Time: now}
+	a.Process, a.Exe = processName(ev.PID)
+	for _, pattern := range m.cfg.AllowProcesses {
+		if ok, _ := path.Match(pattern, a.Exe); ok && a.Exe != "" {
+			return Access{}, false
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 诱饵被改名后事件路径为新路径，仍按诱饵告警
+	c, ok := m.canaries[ev.Path]
+	if !ok {
+		c = Canary{Path: ev.Path}
+	}
+	a.Canary = c
+
+	key := accessKey{pid: ev.PID, path: ev.Path}
+	if t, ok := m.last[key]; ok && now.Sub(t) < m.cfg.Dedup {
+		return Access{}, false
+	}
+	m.last[key] = now
+	for k, t := range m.last {
+		if now.Sub(t) >= m.cfg.Dedup {
+			delete(m.last, k)
+		}
+	}
+	return a, true
+}
+
+// plant 放置一个诱饵文件，文件已是诱饵时读取其标识
+func plant(p string) (Canary, error) {
+	if !filepath.IsAbs(p) {
+		return Canary{}, fmt.Errorf("canary: path must be absolute: %s", p)
+	}
+	p = filepath.Clean(p)
+	data, err := os.ReadFile(p)
+	if err == nil {
+		markers := findMarkers(data)
+		if len(markers) == 0 {
+			return Canary{}, fmt.Errorf("%w: %s", ErrNotCanary, p)
+		}
+		return Canary{Path: p, Marker: markers[0]}, nil
+	}
+	if !os.IsNotExist(err) {
+		return Canary{}, err
+	}
+
+	marker, err := newMarker()
+	if err != nil {
+		return Canary{}, err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return Canary{}, fmt.Errorf("canary: create %s: %w", p, err)
+	}
+	if _, err := f.Write(render(p, marker)); err != nil {
+		f.Close()
+		os.Remove(p)
+		return Canary{}, fmt.Errorf("canary: write %s: %w", p, err)
+	}
+	if err := f.Close(); err != nil {
+		return Canary{}, err
+	}
+	// 修改时间设为较早的日期，与目录中的历史文件相似
+	old := time.Now().AddDate(0, -3, 0)
+	os.Chtimes(p, old, old)
+	return Canary{Path: p, Marker: marker}, nil
+}
+
+func newMarker() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return MarkerPrefix + hex.EncodeToString(b), nil
+}
+
+// findMarkers 查找内容中的全部诱饵标识
+func findMarkers(data []byte) []string {
+	var out []string
+	prefix := []byte(MarkerPrefix)
+	for {
+		i := bytes.Index(data, prefix)
+		if i < 0 {
+			return out
+		}
+		data = data[i:]
+		if len(data) >= markerLen && isHex(data[len(prefix):markerLen]) {
+			out = append(out, string(data[:markerLen]))
+			data = data[markerLen:]
+			continue
+		}
+		data = data[len(prefix):]
+	}
+}
+
+func isHex(b []byte) bool {
+	for _, c := range b {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// render 按扩展名生成诱饵内容，内容中的账号口令由标识派生，每个诱饵互不相同
+func render(p, marker string) []byte {
+	secret := marker[len(MarkerPrefix) : len(MarkerPrefix)+12]
+	var b strings.Builder
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".csv":
+		b.WriteString("系统,地址,账号,口令,备注\n")
+		fmt.Fprintf(&b, "财务系统,10.20.1.15,fin_admin,Fa@%s,%s\n", secret[:8], marker)
+		fmt.Fprintf(&b, "人事系统,10.20.1.22,hr_admin,Hr#%s,仅限内部使用\n", secret[4:])
+	default:
+		b.WriteString("# 内部系统账号 (请勿外传)\n")
+		fmt.Fprintf(&b, "# ref: %s\n", marker)
+		fmt.Fprintf(&b, "db_host=10.20.1.15\ndb_user=fin_admin\ndb_password=Fa@%s\n", secret)
+	}
+	return []byte(b.String())
+}
+
+// processName 读取进程名与可执行文件路径
+func processName(pid int) (string, string) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	comm, _ := os.ReadFile(filepath.Join(dir, "comm"))
+	exe, _ := os.Readlink(filepath.Join(dir, "exe"))
+	return strings.TrimSpace(string(comm)), exe
+}
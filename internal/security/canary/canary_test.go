@@ -0,0 +1,145 @@
+package canary
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/fanotify"
+)
+
+// fakeListener 由测试注入事件
+type fakeListener struct {
+	files  []string
+	events chan fanotify.Event
+}
+
+func (l *fakeListener) AddFile(path string, mask uint64) error {
+	l.files = append(l.files, path)
+	return nil
+}
+
+func (l *fakeListener) Run(ctx context.Context, handler func(fanotify.Event)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-l.events:
+			handler(ev)
+		}
+	}
+}
+
+func (l *fakeListener) Close() error { return nil }
+
+// fakeProc 以临时目录代替 /proc，pid 1000 为 cp，pid 2000 为备份程序
+func fakeProc(t *testing.T) {
+	t.Helper()
+	old := procRoot
+	procRoot = t.TempDir()
+	t.Cleanup(func() { procRoot = old })
+	for pid, exe := range map[string]string{"1000": "/usr/bin/cp", "2000": "/opt/backup/bin/agent"} {
+		dir := filepath.Join(procRoot, pid)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "comm"), []byte(filepath.Base(exe)+"\n"), 0644)
+		os.Symlink(exe, filepath.Join(dir, "exe"))
+	}
+}
+
+func TestMonitor_Plant(t *testing.T) {
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "账号.txt")
+	csv := filepath.Join(dir, "passwords.csv")
+	real := filepath.Join(dir, "report.txt")
+	os.WriteFile(real, []byte("业务数据"), 0644)
+
+	m := New(Config{Files: []string{txt, csv, real, "relative.txt"}}, nil)
+	got, err := m.Plant()
+	if !errors.Is(err, ErrNotCanary) {
+		t.Errorf("Plant() error = %v, want ErrNotCanary", err)
+	}
+	if len(got) != 2 || got[0].Marker == got[1].Marker {
+		t.Fatalf("Plant() = %+v", got)
+	}
+	if data, _ := os.ReadFile(real); string(data) != "业务数据" {
+		t.Error("existing file was overwritten")
+	}
+
+	data, err := os.ReadFile(csv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := m.Find(append([]byte("copied: "), data...))
+	if !ok || c.Path != csv {
+		t.Errorf("Find(copy) = %+v, %v", c, ok)
+	}
+	if _, ok := m.Find([]byte(MarkerPrefix + "not-a-marker")); ok {
+		t.Error("Find matched a malformed marker")
+	}
+
+	// 重新放置沿用已有诱饵的标识
+	again, err := New(Config{Files: []string{txt, csv}}, nil).Plant()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		if again[i] != got[i] {
+			t.Errorf("re-plant = %+v, want %+v", again[i], got[i])
+		}
+	}
+}
+
+func TestMonitor_Access(t *testing.T) {
+	fakeProc(t)
+	l := &fakeListener{events: make(chan fanotify.Event)}
+	old := newListener
+	newListener = func() (listener, error) { return l, nil }
+	t.Cleanup(func() { newListener = old })
+
+	decoy := filepath.Join(t.TempDir(), "salary.csv")
+	alerts := make(chan Access, 4)
+	m := New(Config{Files: []string{decoy}, AllowProcesses: []string{"/opt/backup/bin/*"}},
+		func(a Access) { alerts <- a })
+	if err := New(Config{}, nil).Start(); err == nil {
+		t.Error("Start() without canaries succeeded")
+	}
+	if _, err := m.Plant(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if len(l.files) != 1 || l.files[0] != decoy {
+		t.Fatalf("marked files = %v", l.files)
+	}
+
+	// 备份程序允许访问，同一进程的重复打开只告警一次
+	l.events <- fanotify.Event{Path: decoy, PID: 2000, Mask: fanotify.EventOpen}
+	l.events <- fanotify.Event{Path: decoy, PID: 1000, Mask: fanotify.EventOpen}
+	l.events <- fanotify.Event{Path: decoy, PID: 1000, Mask: fanotify.EventOpen}
+	select {
+	case a := <-alerts:
+		if a.PID != 1000 || a.Exe != "/usr/bin/cp" || a.Canary.Path != decoy || a.Canary.Marker == "" {
+			t.Errorf("access = %+v", a)
+		}
+		if a.Owner() != "/usr/bin/cp, pid 1000" {
+			t.Errorf("Owner() = %q", a.Owner())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no alert")
+	}
+	m.Stop()
+	if len(alerts) != 0 {
+		t.Errorf("unexpected alerts: %d", len(alerts))
+	}
+
+	// 去重间隔过后再次告警
+	later := time.Now().Add(2 * DefaultDedup)
+	if _, ok := m.handle(fanotify.Event{Path: decoy, PID: 1000}, later); !ok {
+		t.Error("access after dedup window was suppressed")
+	}
+}
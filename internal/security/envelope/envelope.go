@@ -0,0 +1,163 @@
+// Package envelope 上报数据信封加密
+// 每次上报生成随机 SM4 数据密钥，以 SM4-GCM 加密载荷，数据密钥用服务端 SM2 公钥加密，
+// 确保命中文本等敏感内容即使在 TLS 终结点之后也不以明文出现
+//
+// 目前由离线数据包 (service/offline) 使用；在线上报尚未接入，守护进程只在离线模式下设置 Default
+package envelope
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/gmsm/sm4"
+)
+
+// Algorithm 信封算法标识
+const Algorithm = "SM2-SM4-GCM"
+
+// Version 信封格式版本
+const Version = 1
+
+// ErrUnsupported 不支持的信封版本或算法
+var ErrUnsupported = errors.New("envelope: unsupported version or algorithm")
+
+// Envelope 加密信封 (JSON 中二进制字段为 Base64)
+type Envelope struct {
+	Version   int    `json:"version"`
+	Algorithm string `json:"alg"`
+	// 服务端公钥标识 (公钥 SM3 摘要前 8 字节的十六进制)，便于服务端轮换密钥
+	KeyID string `json:"kid"`
+	// SM2 加密的 SM4 数据密钥 (C1||C3||C2)
+	Key []byte `json:"key"`
+	// GCM 随机数
+	Nonce []byte `json:"nonce"`
+	// SM4-GCM 密文
+	Data []byte `json:"data"`
+}
+
+// Sealer 使用服务端公钥封装载荷
+type Sealer struct {
+	pub   *sm2.PublicKey
+	keyID string
+}
+
+// NewSealer 创建封装器，keyOrPath 为十六进制公钥 (04||X||Y) 或公钥文件路径
+func NewSealer(keyOrPath string) (*Sealer, error) {
+	key := []byte(strings.TrimSpace(keyOrPath))
+	if _, err := hex.DecodeString(string(key)); err != nil || len(key) != 130 {
+		data, err := os.ReadFile(keyOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("envelope: read server public key: %w", err)
+		}
+		key = data
+	}
+	pub, err := sm2.ParsePublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{pub: pub, keyID: KeyID(pub)}, nil
+}
+
+// KeyID 计算公钥标识
+func KeyID(pub *sm2.PublicKey) string {
+	sum := sm3.Sum(pub.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+// Seal 封装载荷
+func (s *Sealer) Seal(plaintext []byte) (*Envelope, error) {
+	dek := make([]byte, sm4.KeySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := sm2.Encrypt(nil, s.pub, dek)
+	if err != nil {
+		return nil, err
+	}
+	env := &Envelope{
+		Version:   Version,
+		Algorithm: Algorithm,
+		KeyID:     s.keyID,
+		Key:       wrapped,
+		Nonce:     nonce,
+	}
+	env.Data = aead.Seal(nil, nonce, plaintext, env.aad())
+	return env, nil
+}
+
+// SealJSON 序列化 v 并封装，返回信封 JSON
+func (s *Sealer) SealJSON(v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	env, err := s.Seal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// Open 使用服务端私钥解开信封 (服务端及测试使用)
+func Open(priv *sm2.PrivateKey, env *Envelope) ([]byte, error) {
+	if env.Version != Version || env.Algorithm != Algorithm {
+		return nil, ErrUnsupported
+	}
+	dek, err := sm2.Decrypt(priv, env.Key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, errors.New("envelope: invalid nonce")
+	}
+	return aead.Open(nil, env.Nonce, env.Data, env.aad())
+}
+
+// aad 版本、算法与密钥标识参与认证，防止被替换
+func (e *Envelope) aad() []byte {
+	return []byte(fmt.Sprintf("%d|%s|%s", e.Version, e.Algorithm, e.KeyID))
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// defaultSealer 全局封装器 (由主程序按配置设置，上报模块通过 Default 获取)
+var defaultSealer atomic.Pointer[Sealer]
+
+// SetDefault 设置全局封装器，nil 表示不加密
+func SetDefault(s *Sealer) {
+	defaultSealer.Store(s)
+}
+
+// Default 返回全局封装器，未启用时为 nil
+func Default() *Sealer {
+	return defaultSealer.Load()
+}
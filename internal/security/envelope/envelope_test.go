@@ -0,0 +1,64 @@
+package envelope
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/model"
+)
+
+func TestSealOpen(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	s, err := NewSealer(priv.PublicKey.Hex())
+	if err != nil {
+		t.Fatalf("NewSealer() error = %v", err)
+	}
+
+	alert := model.AlertRecord{FileName: "计划.docx", HighlightText: "绝密★10年"}
+	data, err := s.SealJSON(alert)
+	if err != nil {
+		t.Fatalf("SealJSON() error = %v", err)
+	}
+	if strings.Contains(string(data), "绝密") {
+		t.Fatal("信封中出现明文")
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	if env.KeyID != KeyID(&priv.PublicKey) || env.Algorithm != Algorithm {
+		t.Errorf("envelope = %+v", env)
+	}
+	plain, err := Open(priv, &env)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	var got model.AlertRecord
+	json.Unmarshal(plain, &got)
+	if got.HighlightText != alert.HighlightText {
+		t.Errorf("HighlightText = %q", got.HighlightText)
+	}
+
+	// 篡改密钥标识导致认证失败
+	env.KeyID = "0000000000000000"
+	if _, err := Open(priv, &env); err == nil {
+		t.Error("Open() 应拒绝被篡改的信封")
+	}
+}
+
+func TestNewSealer_File(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	path := filepath.Join(t.TempDir(), "server.pub")
+	os.WriteFile(path, []byte(priv.PublicKey.Hex()+"\n"), 0644)
+	if _, err := NewSealer(path); err != nil {
+		t.Errorf("NewSealer(file) error = %v", err)
+	}
+	if _, err := NewSealer(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("NewSealer(missing) error = nil")
+	}
+}
@@ -0,0 +1,363 @@
+// Package hijack 内核模块与动态链接劫持检测
+// 周期检查已加载的内核模块是否在白名单中、是否存在从 /proc/modules 隐藏的模块，
+// 以及 /etc/ld.so.preload 与受监控进程的 LD_PRELOAD / LD_AUDIT 是否注入了未授权的库
+package hijack
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// 以下路径测试时可替换
+var (
+	procRoot      = "/proc"
+	sysModuleRoot = "/sys/module"
+	preloadPath   = "/etc/ld.so.preload"
+)
+
+// FindingType 发现类型
+type FindingType string
+
+const (
+	FindingModule       FindingType = "kernel_module" // 不在白名单中的内核模块
+	FindingHiddenModule FindingType = "hidden_module" // /sys/module 中存在但 /proc/modules 中不可见的模块
+	FindingPreloadFile  FindingType = "ld_so_preload" // /etc/ld.so.preload 中的库
+	FindingPreloadEnv   FindingType = "ld_preload"    // 进程环境变量 LD_PRELOAD / LD_AUDIT 中的库
+)
+
+// DefaultInterval 默认检测周期
+const DefaultInterval = 5 * time.Minute
+
+// Finding 一条可疑注入
+type Finding struct {
+	Type FindingType
+	// 模块名或库路径
+	Name string
+	// 进程 (仅 FindingPreloadEnv)
+	PID     int
+	Process string
+	Detail  string
+	Time    time.Time
+}
+
+// key 去重键，同一进程的同一注入只上报一次
+func (f Finding) key() string {
+	return string(f.Type) + "\x00" + f.Name + "\x00" + strconv.Itoa(f.PID)
+}
+
+// Handler 发现可疑注入时的回调
+type Handler func(f Finding)
+
+// Config 检测配置
+type Config struct {
+	// 检测周期，<=0 时使用 DefaultInterval
+	Interval time.Duration
+	// 允许加载的内核模块名 (支持 * 通配)，为空时以启动时已加载的模块为白名单
+	ModuleAllowlist []string
+	// 允许预加载的库路径 (支持 * 通配)，如安全软件的 hook 库
+	LibraryAllowlist []string
+	// 检查 LD_PRELOAD 的进程名 (/proc/<pid>/comm)，自身进程总是检查
+	Processes []string
+}
+
+// Detector 劫持检测
+type Detector struct {
+	cfg     Config
+	handler Handler
+
+	mu sync.Mutex
+	// 未配置白名单时启动时已加载的模块
+	startupModules map[string]bool
+	// 已上报的发现，消失后再次出现时重新上报
+	reported map[string]bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建检测，未配置模块白名单时记录当前已加载的模块
+func New(cfg Config, handler Handler) *Detector {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	d := &Detector{cfg: cfg, handler: handler, reported: make(map[string]bool)}
+	if len(cfg.ModuleAllowlist) == 0 {
+		d.startupModules = make(map[string]bool)
+		mods, err := loadedModules()
+		if err != nil {
+			logger.Warn("读取已加载内核模块失败", "error", err)
+		}
+		for _, m := range mods {
+			d.startupModules[m.name] = true
+		}
+	}
+	return d
+}
+
+// Start 后台周期检测
+func (d *Detector) Start() {
+	d.stopCh = make(chan struct{})
+	d.wg.Add(1)
+	go d.loop()
+	logger.Info("劫持检测已启动", "interval", d.cfg.Interval, "processes", len(d.cfg.Processes))
+}
+
+// Stop 停止检测
+func (d *Detector) Stop() {
+	if d.stopCh == nil {
+		return
+	}
+	close(d.stopCh)
+	d.wg.Wait()
+	d.stopCh = nil
+}
+
+func (d *Detector) loop() {
+	defer d.wg.Done()
+	d.Check()
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case <-ticker.C:
+			d.Check()
+		}
+	}
+}
+
+// Check 执行一轮检测，返回本轮新发现的可疑注入
+func (d *Detector) Check() []Finding {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var all []Finding
+	all = append(all, d.checkModules()...)
+	all = append(all, d.checkPreloadFile()...)
+	all = append(all, d.checkProcesses()...)
+
+	seen := make(map[string]bool, len(all))
+	var found []Finding
+	for _, f := range all {
+		k := f.key()
+		seen[k] = true
+		if d.reported[k] {
+			continue
+		}
+		d.reported[k] = true
+		found = append(found, f)
+	}
+	for k := range d.reported {
+		if !seen[k] {
+			delete(d.reported, k)
+		}
+	}
+
+	for _, f := range found {
+		logger.Error("检测到可疑注入", "type", f.Type, "name", f.Name, "pid", f.PID, "process", f.Process, "detail", f.Detail)
+		if d.handler != nil {
+			d.handler(f)
+		}
+	}
+	return found
+}
+
+// module /proc/modules 中的一行
+type module struct {
+	name  string
+	taint string // 如 "OE": O 树外模块, E 未签名
+}
+
+// loadedModules 读取 /proc/modules
+// 行格式: name size refcount deps state address [(taint)]
+func loadedModules() ([]module, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, "modules"))
+	if err != nil {
+		return nil, err
+	}
+	var mods []module
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		m := module{name: fields[0]}
+		if len(fields) > 6 {
+			m.taint = strings.Trim(fields[6], "()")
+		}
+		mods = append(mods, m)
+	}
+	return mods, scanner.Err()
+}
+
+func (d *Detector) moduleAllowed(name string) bool {
+	if len(d.cfg.ModuleAllowlist) == 0 {
+		return d.startupModules[name]
+	}
+	return matchAny(d.cfg.ModuleAllowlist, name)
+}
+
+// checkModules 检查内核模块白名单与隐藏模块
+func (d *Detector) checkModules() []Finding {
+	mods, err := loadedModules()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取已加载内核模块失败", "error", err)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	var found []Finding
+	visible := make(map[string]bool, len(mods))
+	for _, m := range mods {
+		visible[m.name] = true
+		if d.moduleAllowed(m.name) {
+			continue
+		}
+		detail := "not in allowlist"
+		if m.taint != "" {
+			detail += ", taint " + m.taint
+		}
+		found = append(found, Finding{Type: FindingModule, Name: m.name, Detail: detail, Time: now})
+	}
+
+	// 可加载模块在 /sys/module 下有 initstate，内建模块没有
+	// 从模块链表中摘除自身的 rootkit 通常仍残留在 sysfs 中
+	entries, err := os.ReadDir(sysModuleRoot)
+	if err != nil {
+		return found
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if visible[name] {
+			continue
+		}
+		state, err := os.ReadFile(filepath.Join(sysModuleRoot, name, "initstate"))
+		if err != nil || strings.TrimSpace(string(state)) != "live" {
+			continue
+		}
+		// 读取 sysfs 与 /proc/modules 之间加载的模块
+		if mods, err := loadedModules(); err == nil && containsModule(mods, name) {
+			continue
+		}
+		found = append(found, Finding{Type: FindingHiddenModule, Name: name, Detail: "present in /sys/module but hidden from /proc/modules", Time: now})
+	}
+	return found
+}
+
+func containsModule(mods []module, name string) bool {
+	for _, m := range mods {
+		if m.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreloadFile 检查 /etc/ld.so.preload，其中的库会被注入所有动态链接的进程
+func (d *Detector) checkPreloadFile() []Finding {
+	data, err := os.ReadFile(preloadPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("读取 ld.so.preload 失败", "error", err)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	var found []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, lib := range splitLibs(line) {
+			if matchAny(d.cfg.LibraryAllowlist, lib) {
+				continue
+			}
+			found = append(found, Finding{Type: FindingPreloadFile, Name: lib, Detail: preloadPath, Time: now})
+		}
+	}
+	return found
+}
+
+// checkProcesses 检查自身与受监控进程的 LD_PRELOAD / LD_AUDIT
+func (d *Detector) checkProcesses() []Finding {
+	names := make(map[string]bool, len(d.cfg.Processes))
+	for _, n := range d.cfg.Processes {
+		names[n] = true
+	}
+	self := os.Getpid()
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	var found []Finding
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join(procRoot, e.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(comm))
+		if pid != self && !names[name] {
+			continue
+		}
+		// 其他用户的进程需要 root 权限才能读取环境变量，进程退出时同样读取失败
+		environ, err := os.ReadFile(filepath.Join(procRoot, e.Name(), "environ"))
+		if err != nil {
+			continue
+		}
+		for _, kv := range bytes.Split(environ, []byte{0}) {
+			key, value, ok := strings.Cut(string(kv), "=")
+			if !ok || (key != "LD_PRELOAD" && key != "LD_AUDIT") {
+				continue
+			}
+			for _, lib := range splitLibs(value) {
+				if matchAny(d.cfg.LibraryAllowlist, lib) {
+					continue
+				}
+				found = append(found, Finding{Type: FindingPreloadEnv, Name: lib, PID: pid, Process: name, Detail: key, Time: now})
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].PID < found[j].PID })
+	return found
+}
+
+// splitLibs 按空白与冒号分隔库列表 (与 ld.so 的解析一致)
+func splitLibs(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ':' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// matchAny 名称是否匹配任一模式 (path.Match 通配)
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok || p == name {
+			return true
+		}
+	}
+	return false
+}
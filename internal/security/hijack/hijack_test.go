@@ -0,0 +1,159 @@
+package hijack
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// fakeRoots 在临时目录下构造 /proc、/sys/module 与 ld.so.preload
+func fakeRoots(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	oldProc, oldSys, oldPreload := procRoot, sysModuleRoot, preloadPath
+	procRoot = filepath.Join(dir, "proc")
+	sysModuleRoot = filepath.Join(dir, "sys/module")
+	preloadPath = filepath.Join(dir, "etc/ld.so.preload")
+	t.Cleanup(func() {
+		procRoot, sysModuleRoot, preloadPath = oldProc, oldSys, oldPreload
+	})
+	for _, d := range []string{procRoot, sysModuleRoot, filepath.Dir(preloadPath)} {
+		os.MkdirAll(d, 0755)
+	}
+	return dir
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeModules(t *testing.T, lines string) {
+	write(t, filepath.Join(procRoot, "modules"), lines)
+}
+
+func sysModule(t *testing.T, name string) {
+	write(t, filepath.Join(sysModuleRoot, name, "initstate"), "live\n")
+}
+
+func process(t *testing.T, pid int, comm, environ string) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	write(t, filepath.Join(dir, "comm"), comm+"\n")
+	write(t, filepath.Join(dir, "environ"), environ)
+}
+
+func byType(fs []Finding) map[FindingType][]Finding {
+	m := make(map[FindingType][]Finding)
+	for _, f := range fs {
+		m[f.Type] = append(m[f.Type], f)
+	}
+	return m
+}
+
+func TestCheck_StartupModulesAllowed(t *testing.T) {
+	fakeRoots(t)
+	writeModules(t, "ext4 774144 1 - Live 0x0000000000000000\nxfs 1  0 - Live 0x0\n")
+	sysModule(t, "ext4")
+	sysModule(t, "xfs")
+
+	d := New(Config{}, nil)
+	if fs := d.Check(); len(fs) != 0 {
+		t.Fatalf("启动时已加载的模块被上报: %+v", fs)
+	}
+
+	writeModules(t, "ext4 774144 1 - Live 0x0\nxfs 1 0 - Live 0x0\nrootkit 16384 0 - Live 0x0 (OE)\n")
+	sysModule(t, "rootkit")
+	fs := d.Check()
+	if len(fs) != 1 || fs[0].Type != FindingModule || fs[0].Name != "rootkit" {
+		t.Fatalf("Check() = %+v, want rootkit", fs)
+	}
+	if fs[0].Detail != "not in allowlist, taint OE" {
+		t.Errorf("Detail = %q", fs[0].Detail)
+	}
+	// 同一模块只上报一次
+	if fs := d.Check(); len(fs) != 0 {
+		t.Errorf("重复上报: %+v", fs)
+	}
+}
+
+func TestCheck_ModuleAllowlist(t *testing.T) {
+	fakeRoots(t)
+	writeModules(t, "nvidia_drm 1 0 - Live 0x0 (POE)\nnvidia 1 0 - Live 0x0 (POE)\nevil 1 0 - Live 0x0\n")
+
+	d := New(Config{ModuleAllowlist: []string{"nvidia*", "ext4"}}, nil)
+	fs := d.Check()
+	if len(fs) != 1 || fs[0].Name != "evil" {
+		t.Fatalf("Check() = %+v, want evil", fs)
+	}
+}
+
+func TestCheck_HiddenModule(t *testing.T) {
+	fakeRoots(t)
+	writeModules(t, "ext4 1 1 - Live 0x0\n")
+	sysModule(t, "ext4")
+	sysModule(t, "diamorphine")
+	// 内建模块没有 initstate
+	os.MkdirAll(filepath.Join(sysModuleRoot, "kernel"), 0755)
+
+	d := New(Config{}, nil)
+	fs := d.Check()
+	if len(fs) != 1 || fs[0].Type != FindingHiddenModule || fs[0].Name != "diamorphine" {
+		t.Fatalf("Check() = %+v, want hidden diamorphine", fs)
+	}
+}
+
+func TestCheck_PreloadFile(t *testing.T) {
+	fakeRoots(t)
+	write(t, preloadPath, "# comment\n/usr/lib/libevil.so /lib/libok.so\n/tmp/.x.so:/usr/lib/vendor/hook.so\n")
+
+	d := New(Config{LibraryAllowlist: []string{"/lib/libok.so", "/usr/lib/vendor/*"}}, nil)
+	got := byType(d.Check())[FindingPreloadFile]
+	if len(got) != 2 || got[0].Name != "/usr/lib/libevil.so" || got[1].Name != "/tmp/.x.so" {
+		t.Fatalf("ld.so.preload findings = %+v", got)
+	}
+
+	// 清除后再次出现时重新上报
+	os.Remove(preloadPath)
+	if fs := d.Check(); len(fs) != 0 {
+		t.Fatalf("Check() = %+v", fs)
+	}
+	write(t, preloadPath, "/usr/lib/libevil.so\n")
+	if fs := d.Check(); len(fs) != 1 {
+		t.Errorf("再次出现未上报: %+v", fs)
+	}
+}
+
+func TestCheck_ProcessPreload(t *testing.T) {
+	fakeRoots(t)
+	self := os.Getpid()
+	process(t, self, "filewatcherd", "PATH=/usr/bin\x00LD_AUDIT=/tmp/audit.so\x00")
+	process(t, 1001, "sshd", "LD_PRELOAD=/dev/shm/libssh_hook.so\x00HOME=/root\x00")
+	process(t, 1002, "bash", "LD_PRELOAD=/tmp/ignored.so\x00")
+	process(t, 1003, "sshd", "HOME=/root\x00")
+
+	d := New(Config{Processes: []string{"sshd"}}, nil)
+	got := byType(d.Check())[FindingPreloadEnv]
+	if len(got) != 2 {
+		t.Fatalf("LD_PRELOAD findings = %+v, want 2", got)
+	}
+	for _, f := range got {
+		switch f.PID {
+		case 1001:
+			if f.Name != "/dev/shm/libssh_hook.so" || f.Process != "sshd" || f.Detail != "LD_PRELOAD" {
+				t.Errorf("sshd finding = %+v", f)
+			}
+		case self:
+			if f.Name != "/tmp/audit.so" || f.Detail != "LD_AUDIT" {
+				t.Errorf("self finding = %+v", f)
+			}
+		default:
+			t.Errorf("未受监控的进程被上报: %+v", f)
+		}
+	}
+}
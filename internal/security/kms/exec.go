@@ -0,0 +1,30 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runner 执行外部命令，stdin 可为 nil；测试中替换为桩实现
+type runner func(ctx context.Context, stdin []byte, env []string, name string, args ...string) ([]byte, error)
+
+// execRunner 执行命令并返回标准输出，失败时附带标准错误内容
+func execRunner(ctx context.Context, stdin []byte, env []string, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(cmd.Environ(), env...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
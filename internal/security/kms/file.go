@@ -0,0 +1,73 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// FileKMS 本地密钥文件后端
+// 密钥加密密钥 (KEK) 以 0600 权限保存在本地，安全性依赖文件权限，适用于无 TPM/HSM 的终端
+type FileKMS struct {
+	aead cipher.AEAD
+}
+
+// NewFileKMS 加载密钥文件，不存在时生成
+func NewFileKMS(keyFile string) (*FileKMS, error) {
+	if keyFile == "" {
+		return nil, fmt.Errorf("kms: file backend requires key_file")
+	}
+
+	kek, err := os.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		kek = make([]byte, dekSize)
+		if _, err := io.ReadFull(rand.Reader, kek); err != nil {
+			return nil, err
+		}
+		if err := writeFileAtomic(keyFile, kek, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if len(kek) != dekSize {
+		return nil, fmt.Errorf("kms: key file %s must contain %d bytes", keyFile, dekSize)
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &FileKMS{aead: aead}, nil
+}
+
+func (f *FileKMS) Name() string { return BackendFile }
+
+// Wrap 输出 nonce || AES-GCM(dek)
+func (f *FileKMS) Wrap(_ context.Context, dek []byte) ([]byte, error) {
+	nonce := make([]byte, f.aead.NonceSize(), f.aead.NonceSize()+len(dek)+f.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return f.aead.Seal(nonce, nonce, dek, nil), nil
+}
+
+func (f *FileKMS) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	n := f.aead.NonceSize()
+	if len(wrapped) < n {
+		return nil, ErrUnwrap
+	}
+	dek, err := f.aead.Open(nil, wrapped[:n], wrapped[n:], nil)
+	if err != nil {
+		return nil, ErrUnwrap
+	}
+	return dek, nil
+}
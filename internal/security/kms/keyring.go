@@ -0,0 +1,383 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// 数据密钥环
+// ==========================================
+//
+// 密钥文件保存 KMS 封装后的数据密钥集合，其中一把为当前密钥，其余为轮换前的历史密钥 (仅用于解密)。
+// 密文格式:
+//   v1: 00 'K' 'M' 'S' 01 || nonce || ciphertext            (单密钥，固定对应密钥 1)
+//   v2: 00 'K' 'M' 'S' 02 || keyID(4) || nonce || ciphertext
+
+var (
+	sealedPrefix = []byte{0x00, 'K', 'M', 'S'}
+	headerV1     = append(append([]byte{}, sealedPrefix...), 1)
+)
+
+const (
+	versionV1 = 1
+	versionV2 = 2
+
+	// legacyKeyID v1 密文及旧格式密钥文件对应的密钥号
+	legacyKeyID = 1
+)
+
+// dekSize 数据密钥长度 (AES-256)
+const dekSize = 32
+
+var (
+	// ErrNotSealed 数据不是 KMS 密文
+	ErrNotSealed = errors.New("kms: data is not sealed by keyring")
+	// ErrUnknownKey 密文对应的密钥已被清理
+	ErrUnknownKey = errors.New("kms: data key not found in keyring")
+)
+
+// KeyInfo 数据密钥信息
+type KeyInfo struct {
+	ID      uint32
+	Created time.Time
+	Active  bool
+}
+
+// keyFile 密钥文件格式
+type keyFile struct {
+	Active uint32      `json:"active"`
+	Keys   []keyRecord `json:"keys"`
+}
+
+type keyRecord struct {
+	ID      uint32 `json:"id"`
+	Created int64  `json:"created"`
+	Wrapped []byte `json:"wrapped"`
+}
+
+// dataKey 解封后的数据密钥
+type dataKey struct {
+	record keyRecord
+	aead   cipher.AEAD
+}
+
+// Keyring 使用 KMS 封装的数据密钥进行 AES-256-GCM 加解密，支持密钥轮换
+type Keyring struct {
+	kms  KMS
+	path string
+
+	mu     sync.RWMutex
+	active uint32
+	keys   map[uint32]*dataKey
+}
+
+// LoadOrCreate 从 path 读取封装的数据密钥并解封；文件不存在时生成新密钥并封装保存
+func LoadOrCreate(ctx context.Context, k KMS, path string) (*Keyring, error) {
+	ring := &Keyring{kms: k, path: path, keys: make(map[uint32]*dataKey)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if _, err := ring.addKey(ctx, legacyKeyID); err != nil {
+			return nil, err
+		}
+		return ring, ring.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kf, err := parseKeyFile(data, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, rec := range kf.Keys {
+		dek, err := k.Unwrap(ctx, rec.Wrapped)
+		if err != nil {
+			return nil, fmt.Errorf("unwrap data key %d from %s: %w", rec.ID, path, err)
+		}
+		aead, err := newAEAD(dek)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[rec.ID] = &dataKey{record: rec, aead: aead}
+	}
+	if _, ok := ring.keys[kf.Active]; !ok {
+		return nil, fmt.Errorf("kms: active key %d missing in %s", kf.Active, path)
+	}
+	ring.active = kf.Active
+	return ring, nil
+}
+
+// parseKeyFile 解析密钥文件，兼容仅包含单个封装密钥的旧格式
+func parseKeyFile(data []byte, path string) (*keyFile, error) {
+	var kf keyFile
+	if err := json.Unmarshal(data, &kf); err == nil && len(kf.Keys) > 0 {
+		return &kf, nil
+	}
+
+	created := time.Now()
+	if fi, err := os.Stat(path); err == nil {
+		created = fi.ModTime()
+	}
+	return &keyFile{
+		Active: legacyKeyID,
+		Keys:   []keyRecord{{ID: legacyKeyID, Created: created.Unix(), Wrapped: data}},
+	}, nil
+}
+
+// addKey 生成并封装新数据密钥，设为当前密钥 (调用方持有写锁或处于初始化阶段)
+func (r *Keyring) addKey(ctx context.Context, id uint32) (*dataKey, error) {
+	dek := make([]byte, dekSize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := r.kms.Wrap(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key: %w", err)
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	key := &dataKey{
+		record: keyRecord{ID: id, Created: time.Now().Unix(), Wrapped: wrapped},
+		aead:   aead,
+	}
+	r.keys[id] = key
+	r.active = id
+	return key, nil
+}
+
+// save 持久化密钥文件 (调用方持有锁或处于初始化阶段)
+func (r *Keyring) save() error {
+	kf := keyFile{Active: r.active}
+	for _, k := range r.keys {
+		kf.Keys = append(kf.Keys, k.record)
+	}
+	sort.Slice(kf.Keys, func(i, j int) bool { return kf.Keys[i].ID < kf.Keys[j].ID })
+
+	data, err := json.Marshal(kf)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(r.path, data, 0600)
+}
+
+func newAEAD(dek []byte) (cipher.AEAD, error) {
+	if len(dek) != dekSize {
+		return nil, fmt.Errorf("%w: data key length %d", ErrUnwrap, len(dek))
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Backend 封装数据密钥的后端名称
+func (r *Keyring) Backend() string {
+	return r.kms.Name()
+}
+
+// Keys 返回全部数据密钥信息，按密钥号升序
+func (r *Keyring) Keys() []KeyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]KeyInfo, 0, len(r.keys))
+	for id, k := range r.keys {
+		out = append(out, KeyInfo{ID: id, Created: time.Unix(k.record.Created, 0), Active: id == r.active})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Active 返回当前密钥信息
+func (r *Keyring) Active() KeyInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k := r.keys[r.active]
+	return KeyInfo{ID: r.active, Created: time.Unix(k.record.Created, 0), Active: true}
+}
+
+// Rotate 生成新的数据密钥并设为当前密钥，历史密钥保留用于解密
+func (r *Keyring) Rotate(ctx context.Context) (KeyInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var next uint32
+	for id := range r.keys {
+		if id > next {
+			next = id
+		}
+	}
+	prev := r.active
+	key, err := r.addKey(ctx, next+1)
+	if err != nil {
+		return KeyInfo{}, err
+	}
+	if err := r.save(); err != nil {
+		delete(r.keys, key.record.ID)
+		r.active = prev
+		return KeyInfo{}, err
+	}
+	return KeyInfo{ID: key.record.ID, Created: time.Unix(key.record.Created, 0), Active: true}, nil
+}
+
+// Prune 只保留当前密钥与最近 keep 把历史密钥，返回被清理的密钥号
+// 应在重加密完成后调用，否则使用被清理密钥加密的数据将无法解密
+func (r *Keyring) Prune(keep int) ([]uint32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var old []uint32
+	for id := range r.keys {
+		if id != r.active {
+			old = append(old, id)
+		}
+	}
+	if len(old) <= keep {
+		return nil, nil
+	}
+	sort.Slice(old, func(i, j int) bool { return old[i] < old[j] })
+	removed := old[:len(old)-keep]
+
+	backup := make(map[uint32]*dataKey, len(removed))
+	for _, id := range removed {
+		backup[id] = r.keys[id]
+		delete(r.keys, id)
+	}
+	if err := r.save(); err != nil {
+		for id, k := range backup {
+			r.keys[id] = k
+		}
+		return nil, err
+	}
+	return removed, nil
+}
+
+// Encrypt 使用当前密钥加密 (v2 格式)
+func (r *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	r.mu.RLock()
+	id := r.active
+	aead := r.keys[id].aead
+	r.mu.RUnlock()
+
+	header := make([]byte, 0, len(sealedPrefix)+5)
+	header = append(header, sealedPrefix...)
+	header = append(header, versionV2)
+	header = binary.BigEndian.AppendUint32(header, id)
+
+	nonceSize := aead.NonceSize()
+	out := make([]byte, len(header)+nonceSize, len(header)+nonceSize+len(plaintext)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header):]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// Decrypt 解密 Encrypt 的输出，支持历史密钥与 v1 格式
+func (r *Keyring) Decrypt(data []byte) ([]byte, error) {
+	id, header, body, err := parseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	key := r.keys[id]
+	r.mu.RUnlock()
+	if key == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, id)
+	}
+
+	nonceSize := key.aead.NonceSize()
+	if len(body) < nonceSize+key.aead.Overhead() {
+		return nil, errors.New("kms: ciphertext too short")
+	}
+	return key.aead.Open(nil, body[:nonceSize], body[nonceSize:], header)
+}
+
+// NeedsReEncrypt 密文是否使用非当前密钥加密
+func (r *Keyring) NeedsReEncrypt(data []byte) bool {
+	id, _, _, err := parseHeader(data)
+	if err != nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return id != r.active
+}
+
+// parseHeader 解析密文头，返回密钥号、头部 (作为 AAD) 与剩余数据
+func parseHeader(data []byte) (id uint32, header, body []byte, err error) {
+	if !IsSealed(data) {
+		return 0, nil, nil, ErrNotSealed
+	}
+	switch data[len(sealedPrefix)] {
+	case versionV1:
+		n := len(headerV1)
+		return legacyKeyID, data[:n], data[n:], nil
+	case versionV2:
+		n := len(sealedPrefix) + 5
+		if len(data) < n {
+			return 0, nil, nil, ErrNotSealed
+		}
+		return binary.BigEndian.Uint32(data[len(sealedPrefix)+1:]), data[:n], data[n:], nil
+	default:
+		return 0, nil, nil, ErrNotSealed
+	}
+}
+
+// IsSealed 数据是否为 Keyring 加密的密文
+func IsSealed(data []byte) bool {
+	if len(data) <= len(sealedPrefix) || !bytes.HasPrefix(data, sealedPrefix) {
+		return false
+	}
+	v := data[len(sealedPrefix)]
+	return v == versionV1 || v == versionV2
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免中途失败留下损坏的密钥文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".kms-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
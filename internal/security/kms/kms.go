@@ -0,0 +1,89 @@
+// Package kms 密钥管理后端
+// 本地数据 (告警缓存、配置黄金副本等) 使用随机生成的数据密钥 (DEK) 加密，DEK 由 KMS 后端封装后落盘：
+//   - file:   本地密钥文件 (KEK) 封装，仅依赖文件权限
+//   - tpm:    TPM2 密封 (tpm2-tools)，可绑定 PCR，密钥离开本机无法解封
+//   - pkcs11: PKCS#11 硬件密码模块 (OpenSC pkcs11-tool)，RSA-OAEP 私钥不出设备
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// 后端名称
+const (
+	BackendLocal  = "local" // 沿用 security 模块内置密钥，不启用 KMS
+	BackendFile   = "file"
+	BackendTPM    = "tpm"
+	BackendPKCS11 = "pkcs11"
+)
+
+var (
+	// ErrUnknownBackend 未知的后端类型
+	ErrUnknownBackend = errors.New("kms: unknown backend")
+	// ErrUnwrap 数据密钥解封失败 (密钥不匹配或数据损坏)
+	ErrUnwrap = errors.New("kms: unwrap failed")
+)
+
+// KMS 密钥封装后端
+type KMS interface {
+	// Name 后端名称
+	Name() string
+	// Wrap 封装数据密钥
+	Wrap(ctx context.Context, dek []byte) ([]byte, error)
+	// Unwrap 解封数据密钥
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// Config 后端配置
+type Config struct {
+	Backend string
+
+	// file: 密钥加密密钥文件，不存在时自动生成 (0600)
+	KeyFile string
+
+	// tpm: TCTI (如 "device:/dev/tpmrm0")，为空使用 tpm2-tools 默认值
+	TPMTCTI string
+	// tpm: 绑定的 PCR 选择 (如 "sha256:0,7")，为空不绑定
+	TPMPCRs string
+
+	// pkcs11: 模块路径 (如 /usr/lib/softhsm/libsofthsm2.so)
+	PKCS11Module string
+	// pkcs11: 令牌标签
+	PKCS11Token string
+	// pkcs11: RSA 密钥对标签
+	PKCS11KeyLabel string
+	// pkcs11: 用户 PIN 文件
+	PKCS11PINFile string
+}
+
+// Open 按配置创建后端，Backend 为空或 local 时返回 nil
+func Open(cfg Config) (KMS, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return nil, nil
+	case BackendFile:
+		return NewFileKMS(cfg.KeyFile)
+	case BackendTPM:
+		return NewTPMKMS(cfg.TPMTCTI, cfg.TPMPCRs), nil
+	case BackendPKCS11:
+		return NewPKCS11KMS(cfg.PKCS11Module, cfg.PKCS11Token, cfg.PKCS11KeyLabel, cfg.PKCS11PINFile)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, cfg.Backend)
+	}
+}
+
+// defaultKeyring 全局数据加密密钥环 (由主程序在安全模块初始化时设置)
+var defaultKeyring atomic.Pointer[Keyring]
+
+// SetDefault 设置全局密钥环，存储加密、黄金副本加密与密钥轮换通过 Default 获取
+func SetDefault(k *Keyring) {
+	defaultKeyring.Store(k)
+}
+
+// Default 返回全局密钥环，未启用 KMS 时为 nil
+func Default() *Keyring {
+	return defaultKeyring.Load()
+}
@@ -0,0 +1,252 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyring_FileBackend(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	k, err := Open(Config{Backend: BackendFile, KeyFile: filepath.Join(dir, "kek")})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	keyPath := filepath.Join(dir, "keys", "data.key")
+	ring, err := LoadOrCreate(ctx, k, keyPath)
+	if err != nil {
+		t.Fatalf("LoadOrCreate() error = %v", err)
+	}
+
+	sealed, err := ring.Encrypt([]byte("告警记录"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) {
+		t.Error("IsSealed() = false")
+	}
+
+	// 重新加载得到同一数据密钥
+	k2, _ := NewFileKMS(filepath.Join(dir, "kek"))
+	ring2, err := LoadOrCreate(ctx, k2, keyPath)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	plain, err := ring2.Decrypt(sealed)
+	if err != nil || string(plain) != "告警记录" {
+		t.Fatalf("Decrypt() = %q, %v", plain, err)
+	}
+
+	// 更换 KEK 后无法解封
+	other, _ := NewFileKMS(filepath.Join(dir, "other"))
+	if _, err := LoadOrCreate(ctx, other, keyPath); !errors.Is(err, ErrUnwrap) {
+		t.Errorf("wrong KEK error = %v, want ErrUnwrap", err)
+	}
+
+	if _, err := ring.Decrypt([]byte("legacy")); !errors.Is(err, ErrNotSealed) {
+		t.Errorf("Decrypt(legacy) error = %v", err)
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	k, _ := NewFileKMS(filepath.Join(dir, "kek"))
+	keyPath := filepath.Join(dir, "data.key")
+
+	// 旧格式密钥文件：仅包含单个封装密钥
+	dek := bytes.Repeat([]byte{1}, dekSize)
+	wrapped, _ := k.Wrap(ctx, dek)
+	os.WriteFile(keyPath, wrapped, 0600)
+	aead, _ := newAEAD(dek)
+	nonce := make([]byte, aead.NonceSize())
+	v1 := aead.Seal(append(append([]byte{}, headerV1...), nonce...), nonce, []byte("v1"), headerV1)
+
+	ring, err := LoadOrCreate(ctx, k, keyPath)
+	if err != nil {
+		t.Fatalf("LoadOrCreate(legacy) error = %v", err)
+	}
+	if plain, err := ring.Decrypt(v1); err != nil || string(plain) != "v1" {
+		t.Fatalf("Decrypt(v1) = %q, %v", plain, err)
+	}
+
+	old, _ := ring.Encrypt([]byte("old"))
+	for i := 0; i < 3; i++ {
+		if _, err := ring.Rotate(ctx); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+	if a := ring.Active(); a.ID != 4 {
+		t.Errorf("Active().ID = %d, want 4", a.ID)
+	}
+	if !ring.NeedsReEncrypt(old) || !ring.NeedsReEncrypt(v1) {
+		t.Error("NeedsReEncrypt() = false for old key")
+	}
+	fresh, _ := ring.Encrypt([]byte("new"))
+	if ring.NeedsReEncrypt(fresh) {
+		t.Error("NeedsReEncrypt() = true for active key")
+	}
+
+	// 重新加载后历史密钥仍可解密
+	ring2, err := LoadOrCreate(ctx, k, keyPath)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	if plain, err := ring2.Decrypt(old); err != nil || string(plain) != "old" {
+		t.Fatalf("Decrypt(old) after reload = %q, %v", plain, err)
+	}
+
+	removed, err := ring2.Prune(1)
+	if err != nil || len(removed) != 2 || removed[0] != 1 || removed[1] != 2 {
+		t.Fatalf("Prune(1) = %v, %v", removed, err)
+	}
+	if _, err := ring2.Decrypt(old); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt(pruned) error = %v, want ErrUnknownKey", err)
+	}
+	if len(ring2.Keys()) != 2 {
+		t.Errorf("Keys() = %v", ring2.Keys())
+	}
+}
+
+func TestSealSecret(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	k, _ := NewFileKMS(filepath.Join(dir, "kek"))
+
+	sealed, err := SealSecret(ctx, k, []byte("Bearer s3cr3t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 重新打开同一 KEK 即可解密，不依赖数据密钥文件
+	k2, _ := NewFileKMS(filepath.Join(dir, "kek"))
+	if plain, err := OpenSecret(ctx, k2, sealed); err != nil || string(plain) != "Bearer s3cr3t" {
+		t.Fatalf("OpenSecret() = %q, %v", plain, err)
+	}
+
+	other, _ := NewFileKMS(filepath.Join(dir, "other"))
+	if _, err := OpenSecret(ctx, other, sealed); !errors.Is(err, ErrUnwrap) {
+		t.Errorf("wrong KEK error = %v, want ErrUnwrap", err)
+	}
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := OpenSecret(ctx, k, tampered); err == nil {
+		t.Error("OpenSecret(tampered) should fail")
+	}
+	for _, bad := range [][]byte{nil, []byte("S"), sealed[:10]} {
+		if _, err := OpenSecret(ctx, k, bad); err == nil {
+			t.Errorf("OpenSecret(%x) should fail", bad)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if k, err := Open(Config{Backend: BackendLocal}); k != nil || err != nil {
+		t.Errorf("Open(local) = %v, %v", k, err)
+	}
+	if _, err := Open(Config{Backend: "vault"}); !errors.Is(err, ErrUnknownBackend) {
+		t.Errorf("Open(vault) error = %v", err)
+	}
+}
+
+func TestTPMBlob(t *testing.T) {
+	blob := encodeTPMBlob([]byte("pub"), []byte("private"))
+	pub, priv, err := decodeTPMBlob(blob)
+	if err != nil || string(pub) != "pub" || string(priv) != "private" {
+		t.Fatalf("decodeTPMBlob() = %q, %q, %v", pub, priv, err)
+	}
+	if _, _, err := decodeTPMBlob(blob[:len(blob)-1]); err == nil {
+		t.Error("decodeTPMBlob() 应拒绝截断数据")
+	}
+}
+
+func TestTPMKMS_Commands(t *testing.T) {
+	var calls []string
+	tpm := NewTPMKMS("device:/dev/tpmrm0", "sha256:0,7")
+	tpm.run = func(_ context.Context, stdin []byte, _ []string, name string, args ...string) ([]byte, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		// 模拟 tpm2_create 生成 pub/priv 文件
+		for i, a := range args {
+			if (a == "-u" || a == "-r") && name == "tpm2_create" {
+				os.WriteFile(args[i+1], []byte(a), 0600)
+			}
+		}
+		if name == "tpm2_unseal" {
+			return []byte("dek"), nil
+		}
+		return nil, nil
+	}
+
+	blob, err := tpm.Wrap(context.Background(), []byte("dek"))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	dek, err := tpm.Unwrap(context.Background(), blob)
+	if err != nil || string(dek) != "dek" {
+		t.Fatalf("Unwrap() = %q, %v", dek, err)
+	}
+
+	joined := strings.Join(calls, "\n")
+	for _, want := range []string{"tpm2_createpolicy -T device:/dev/tpmrm0 --policy-pcr -l sha256:0,7", "tpm2_unseal -T device:/dev/tpmrm0 -c"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("缺少命令 %q:\n%s", want, joined)
+		}
+	}
+	if !strings.Contains(joined, "-p pcr:sha256:0,7") {
+		t.Errorf("解封未带 PCR 策略:\n%s", joined)
+	}
+}
+
+func TestPKCS11KMS_WrapUnwrap(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	os.WriteFile(pinFile, []byte("123456\n"), 0600)
+	p, err := NewPKCS11KMS("/usr/lib/softhsm/libsofthsm2.so", "agent", "kms", pinFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 桩实现：读公钥返回 DER，解密用本地私钥模拟设备
+	p.run = func(_ context.Context, _ []byte, env []string, name string, args ...string) ([]byte, error) {
+		joined := strings.Join(args, " ")
+		if strings.Contains(joined, "--read-object") {
+			return pubDER, nil
+		}
+		if strings.Contains(joined, "123456") {
+			t.Error("PIN 不应出现在命令参数中")
+		}
+		if len(env) != 1 || env[0] != "KMS_PKCS11_PIN=123456" {
+			t.Errorf("env = %v", env)
+		}
+		var data []byte
+		for i, a := range args {
+			if a == "--input-file" {
+				data, _ = os.ReadFile(args[i+1])
+			}
+		}
+		return rsa.DecryptOAEP(sha1.New(), nil, rsaKey, data, nil)
+	}
+
+	dek := bytes.Repeat([]byte{7}, dekSize)
+	wrapped, err := p.Wrap(context.Background(), dek)
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	got, err := p.Unwrap(context.Background(), wrapped)
+	if err != nil || !bytes.Equal(got, dek) {
+		t.Fatalf("Unwrap() = %x, %v", got, err)
+	}
+}
@@ -0,0 +1,118 @@
+package kms
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// PKCS11KMS PKCS#11 硬件密码模块后端 (依赖 OpenSC pkcs11-tool)
+// 使用令牌中的 RSA 密钥对：公钥在本地做 RSA-OAEP(SHA-1) 封装，解封在设备内用私钥完成
+type PKCS11KMS struct {
+	module   string
+	token    string
+	keyLabel string
+	pin      string
+	run      runner
+
+	once   sync.Once
+	pubKey *rsa.PublicKey
+	pubErr error
+}
+
+// NewPKCS11KMS 创建 PKCS#11 后端
+func NewPKCS11KMS(module, token, keyLabel, pinFile string) (*PKCS11KMS, error) {
+	if module == "" || keyLabel == "" {
+		return nil, fmt.Errorf("kms: pkcs11 backend requires module and key_label")
+	}
+	var pin string
+	if pinFile != "" {
+		data, err := os.ReadFile(pinFile)
+		if err != nil {
+			return nil, fmt.Errorf("kms: read pin file: %w", err)
+		}
+		pin = strings.TrimSpace(string(data))
+	}
+	return &PKCS11KMS{module: module, token: token, keyLabel: keyLabel, pin: pin, run: execRunner}, nil
+}
+
+func (p *PKCS11KMS) Name() string { return BackendPKCS11 }
+
+func (p *PKCS11KMS) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	pub, err := p.publicKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.EncryptOAEP(sha1.New(), rand.Reader, pub, dek, nil)
+}
+
+func (p *PKCS11KMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "kms-p11-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "wrapped")
+	if err := os.WriteFile(in, wrapped, 0600); err != nil {
+		return nil, err
+	}
+
+	args := append(p.baseArgs(), "--login", "--decrypt", "-m", "RSA-PKCS-OAEP",
+		"--hash-algorithm", "SHA-1", "--mgf", "MGF1-SHA1",
+		"--label", p.keyLabel, "--input-file", in)
+	var env []string
+	if p.pin != "" {
+		// 通过环境变量传递 PIN，避免出现在进程参数中
+		args = append(args, "--pin", "env:KMS_PKCS11_PIN")
+		env = []string{"KMS_PKCS11_PIN=" + p.pin}
+	}
+	dek, err := p.run(ctx, nil, env, "pkcs11-tool", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnwrap, err)
+	}
+	return dek, nil
+}
+
+// publicKey 从令牌读取 RSA 公钥 (DER)
+func (p *PKCS11KMS) publicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	p.once.Do(func() {
+		args := append(p.baseArgs(), "--read-object", "--type", "pubkey", "--label", p.keyLabel)
+		der, err := p.run(ctx, nil, nil, "pkcs11-tool", args...)
+		if err != nil {
+			p.pubErr = err
+			return
+		}
+		p.pubKey, p.pubErr = parseRSAPublicKey(der)
+	})
+	return p.pubKey, p.pubErr
+}
+
+func (p *PKCS11KMS) baseArgs() []string {
+	args := []string{"--module", p.module}
+	if p.token != "" {
+		args = append(args, "--token-label", p.token)
+	}
+	return args
+}
+
+func parseRSAPublicKey(der []byte) (*rsa.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("kms: pkcs11 key is not RSA")
+	}
+	key, err := x509.ParsePKCS1PublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("kms: parse pkcs11 public key: %w", err)
+	}
+	return key, nil
+}
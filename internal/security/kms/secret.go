@@ -0,0 +1,95 @@
+package kms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ==========================================
+// 独立密文 (配置文件中的敏感项)
+// ==========================================
+//
+// 每个值使用独立的随机数据密钥加密，数据密钥由 KMS 后端封装后随密文保存，
+// 不依赖本地数据密钥文件，数据密钥轮换与清理不影响已写入配置文件的密文。
+// 格式: 'S' || version(1) || len(backend)(1) || backend || len(wrapped)(2) || wrapped || nonce || ciphertext
+// 'S' 到 wrapped 为头部，作为 AAD
+
+const (
+	secretMagic   = 'S'
+	secretVersion = 1
+)
+
+// ErrSecretFormat 数据不是 SealSecret 的输出
+var ErrSecretFormat = errors.New("kms: invalid secret format")
+
+// SealSecret 使用新的数据密钥加密单个值，数据密钥由 k 封装
+func SealSecret(ctx context.Context, k KMS, plaintext []byte) ([]byte, error) {
+	dek := make([]byte, dekSize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := k.Wrap(ctx, dek)
+	if err != nil {
+		return nil, fmt.Errorf("wrap secret key: %w", err)
+	}
+	name := k.Name()
+	if len(name) > 0xff || len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("kms: wrapped key too large (%d bytes)", len(wrapped))
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, 5+len(name)+len(wrapped))
+	header = append(header, secretMagic, secretVersion, byte(len(name)))
+	header = append(header, name...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	nonceSize := aead.NonceSize()
+	out := make([]byte, len(header)+nonceSize, len(header)+nonceSize+len(plaintext)+aead.Overhead())
+	copy(out, header)
+	nonce := out[len(header):]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// OpenSecret 解密 SealSecret 的输出，k 须与加密时为同一后端
+func OpenSecret(ctx context.Context, k KMS, data []byte) ([]byte, error) {
+	if len(data) < 3 || data[0] != secretMagic || data[1] != secretVersion {
+		return nil, ErrSecretFormat
+	}
+	nameEnd := 3 + int(data[2])
+	if len(data) < nameEnd+2 {
+		return nil, ErrSecretFormat
+	}
+	if name := string(data[3:nameEnd]); name != k.Name() {
+		return nil, fmt.Errorf("kms: secret sealed by backend %q, current backend is %q", name, k.Name())
+	}
+	wrappedEnd := nameEnd + 2 + int(binary.BigEndian.Uint16(data[nameEnd:]))
+	if len(data) < wrappedEnd {
+		return nil, ErrSecretFormat
+	}
+
+	dek, err := k.Unwrap(ctx, data[nameEnd+2:wrappedEnd])
+	if err != nil {
+		return nil, fmt.Errorf("unwrap secret key: %w", err)
+	}
+	aead, err := newAEAD(dek)
+	if err != nil {
+		return nil, err
+	}
+	header, body := data[:wrappedEnd], data[wrappedEnd:]
+	nonceSize := aead.NonceSize()
+	if len(body) < nonceSize+aead.Overhead() {
+		return nil, errors.New("kms: ciphertext too short")
+	}
+	return aead.Open(nil, body[:nonceSize], body[nonceSize:], header)
+}
@@ -0,0 +1,147 @@
+package kms
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TPMKMS TPM2 密封后端 (依赖 tpm2-tools)
+// 数据密钥作为 sealed data object 创建在存储层级主密钥下；主密钥由固定模板派生，无需持久化句柄。
+// 封装结果为 TPM2B_PUBLIC 与 TPM2B_PRIVATE 两段，只能在同一 TPM (且 PCR 状态满足策略) 上解封
+type TPMKMS struct {
+	tcti string
+	pcrs string
+	run  runner
+}
+
+// NewTPMKMS 创建 TPM 后端，pcrs 非空时密封绑定 PCR 策略
+func NewTPMKMS(tcti, pcrs string) *TPMKMS {
+	return &TPMKMS{tcti: tcti, pcrs: pcrs, run: execRunner}
+}
+
+func (t *TPMKMS) Name() string { return BackendTPM }
+
+func (t *TPMKMS) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "kms-tpm-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	primary := filepath.Join(dir, "primary.ctx")
+	pub := filepath.Join(dir, "seal.pub")
+	priv := filepath.Join(dir, "seal.priv")
+
+	if err := t.createPrimary(ctx, primary); err != nil {
+		return nil, err
+	}
+
+	args := []string{"-C", primary, "-u", pub, "-r", priv, "-i", "-"}
+	if t.pcrs != "" {
+		policy := filepath.Join(dir, "pcr.policy")
+		if _, err := t.tool(ctx, nil, "tpm2_createpolicy", "--policy-pcr", "-l", t.pcrs, "-L", policy); err != nil {
+			return nil, err
+		}
+		args = append(args, "-L", policy)
+	}
+	if _, err := t.tool(ctx, dek, "tpm2_create", args...); err != nil {
+		return nil, err
+	}
+
+	pubData, err := os.ReadFile(pub)
+	if err != nil {
+		return nil, err
+	}
+	privData, err := os.ReadFile(priv)
+	if err != nil {
+		return nil, err
+	}
+	return encodeTPMBlob(pubData, privData), nil
+}
+
+func (t *TPMKMS) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	pubData, privData, err := decodeTPMBlob(wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "kms-tpm-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	primary := filepath.Join(dir, "primary.ctx")
+	pub := filepath.Join(dir, "seal.pub")
+	priv := filepath.Join(dir, "seal.priv")
+	sealed := filepath.Join(dir, "seal.ctx")
+	if err := os.WriteFile(pub, pubData, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(priv, privData, 0600); err != nil {
+		return nil, err
+	}
+
+	if err := t.createPrimary(ctx, primary); err != nil {
+		return nil, err
+	}
+	if _, err := t.tool(ctx, nil, "tpm2_load", "-C", primary, "-u", pub, "-r", priv, "-c", sealed); err != nil {
+		return nil, err
+	}
+
+	args := []string{"-c", sealed}
+	if t.pcrs != "" {
+		args = append(args, "-p", "pcr:"+t.pcrs)
+	}
+	dek, err := t.tool(ctx, nil, "tpm2_unseal", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnwrap, err)
+	}
+	return dek, nil
+}
+
+// createPrimary 在存储层级下创建主密钥 (相同模板得到相同密钥)
+func (t *TPMKMS) createPrimary(ctx context.Context, out string) error {
+	_, err := t.tool(ctx, nil, "tpm2_createprimary", "-C", "o", "-c", out)
+	return err
+}
+
+func (t *TPMKMS) tool(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	if t.tcti != "" {
+		args = append([]string{"-T", t.tcti}, args...)
+	}
+	return t.run(ctx, stdin, nil, name, args...)
+}
+
+// encodeTPMBlob len(pub) || pub || len(priv) || priv
+func encodeTPMBlob(pub, priv []byte) []byte {
+	out := make([]byte, 0, 8+len(pub)+len(priv))
+	out = binary.BigEndian.AppendUint32(out, uint32(len(pub)))
+	out = append(out, pub...)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(priv)))
+	return append(out, priv...)
+}
+
+func decodeTPMBlob(data []byte) (pub, priv []byte, err error) {
+	next := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(data)
+		if uint64(len(data)-4) < uint64(n) {
+			return nil, false
+		}
+		v := data[4 : 4+n]
+		data = data[4+n:]
+		return v, true
+	}
+	pub, ok1 := next()
+	priv, ok2 := next()
+	if !ok1 || !ok2 || len(data) != 0 {
+		return nil, nil, fmt.Errorf("%w: malformed TPM blob", ErrUnwrap)
+	}
+	return pub, priv, nil
+}
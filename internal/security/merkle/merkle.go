@@ -0,0 +1,477 @@
+// Package merkle 为大规模目录树建立 Merkle 树完整性基线
+// 每个目录节点的哈希由其全部条目的哈希计算，根哈希即可代表整棵目录树；
+// 校验时只重新读取大小、时间或 inode 变化的文件，并逐层对比节点定位变化的条目
+//
+// 配置签名密钥时根节点哈希以 SM2 签名，加载子节点时校验其哈希与父节点记录一致，
+// 数据库中任一节点被修改都会被发现
+package merkle
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// 条目类型
+const (
+	TypeFile    = "file"
+	TypeDir     = "dir"
+	TypeSymlink = "symlink"
+)
+
+// ChangeType 变化类型
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"    // 新增
+	ChangeRemoved  ChangeType = "removed"  // 删除
+	ChangeModified ChangeType = "modified" // 内容或类型变化
+	ChangeMetadata ChangeType = "metadata" // 权限或属主变化
+	ChangeBaseline ChangeType = "baseline" // 基线本身被修改
+)
+
+// MaxChanges 单次报告保留的变化条目上限，超出部分只计数
+const MaxChanges = 1000
+
+var (
+	// ErrNoBaseline 尚未建立基线
+	ErrNoBaseline = errors.New("merkle: no baseline")
+	// ErrTampered 基线节点哈希不一致或根节点签名无效
+	ErrTampered = errors.New("merkle: baseline tampered")
+)
+
+// Change 相对基线的一处变化
+// 新增或删除的目录只报告目录本身，不逐项列出其中的文件
+type Change struct {
+	Path   string
+	Type   ChangeType
+	Detail string
+}
+
+// Report 一次校验的结果
+type Report struct {
+	Root string
+	// 当前根哈希
+	Hash string
+	// 基线根哈希，建立基线时为空
+	BaselineHash string
+	Changes      []Change
+	// 超出 MaxChanges 未列出的变化数
+	Truncated int
+	// 遍历的文件与目录数
+	Files int
+	Dirs  int
+	// 重新计算内容哈希的文件数
+	Hashed int
+	// 读取失败的条目数 (沿用基线记录，不视为变化)
+	Errors   int
+	Duration time.Duration
+}
+
+// Changed 是否发现变化
+func (r *Report) Changed() bool {
+	return len(r.Changes) > 0 || r.Truncated > 0
+}
+
+func (r *Report) add(c Change) {
+	if len(r.Changes) >= MaxChanges {
+		r.Truncated++
+		return
+	}
+	r.Changes = append(r.Changes, c)
+}
+
+// Store 目录节点存储，按目录绝对路径存取 (storage.KeyedStore[model.MerkleNode])
+type Store interface {
+	Get(path string) (*model.MerkleNode, error)
+	Put(path string, node model.MerkleNode) error
+	Delete(path string) error
+}
+
+// Options 校验选项
+type Options struct {
+	// 忽略大小、时间与 inode，每次重新计算全部文件的内容哈希
+	FullHash bool
+	// 根节点签名私钥，nil 不签名 (仍校验节点哈希链)
+	Key *sm2.PrivateKey
+}
+
+// Tree 一个根目录的 Merkle 树基线
+type Tree struct {
+	root  string
+	store Store
+	opts  Options
+}
+
+// New 创建根目录 root 的基线，节点保存在 store 中
+func New(root string, store Store, opts Options) (*Tree, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("merkle: %w", err)
+	}
+	return &Tree{root: abs, store: store, opts: opts}, nil
+}
+
+// Root 根目录
+func (t *Tree) Root() string {
+	return t.root
+}
+
+// HasBaseline 是否已建立基线
+func (t *Tree) HasBaseline() (bool, error) {
+	node, err := t.store.Get(t.root)
+	if err != nil {
+		return false, fmt.Errorf("merkle: load %s: %w", t.root, err)
+	}
+	return node != nil, nil
+}
+
+// Reset 删除全部基线节点
+func (t *Tree) Reset() {
+	t.remove(t.root)
+}
+
+// Verify 与基线对比，不修改基线；未建立基线时返回 ErrNoBaseline
+// 基线被修改时返回 ErrTampered
+func (t *Tree) Verify(ctx context.Context) (*Report, error) {
+	return t.run(ctx, false)
+}
+
+// Update 与基线对比，并以当前状态作为新基线
+// 未建立基线时即建立基线，报告中不含变化
+func (t *Tree) Update(ctx context.Context) (*Report, error) {
+	return t.run(ctx, true)
+}
+
+func (t *Tree) run(ctx context.Context, update bool) (*Report, error) {
+	start := time.Now()
+	base, err := t.store.Get(t.root)
+	if err != nil {
+		return nil, fmt.Errorf("merkle: load %s: %w", t.root, err)
+	}
+	if base == nil && !update {
+		return nil, ErrNoBaseline
+	}
+	if base != nil {
+		if err := t.checkRoot(base); err != nil {
+			return nil, err
+		}
+	}
+
+	w := &walker{tree: t, ctx: ctx, update: update, report: &Report{Root: t.root}}
+	if base != nil {
+		w.report.BaselineHash = base.Hash
+	}
+
+	info, err := os.Lstat(t.root)
+	if err != nil || !info.IsDir() {
+		if base != nil && (os.IsNotExist(err) || err == nil) {
+			w.report.add(Change{Path: t.root, Type: ChangeRemoved})
+			if update {
+				t.remove(t.root)
+			}
+			w.report.Duration = time.Since(start)
+			return w.report, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("not a directory")
+		}
+		return nil, fmt.Errorf("merkle: %s: %w", t.root, err)
+	}
+
+	hash, err := w.dir(t.root, base, base != nil)
+	if err != nil {
+		return nil, err
+	}
+	w.report.Hash = hash
+	w.report.Duration = time.Since(start)
+	return w.report, nil
+}
+
+// walker 一次遍历的状态
+type walker struct {
+	tree   *Tree
+	ctx    context.Context
+	update bool
+	report *Report
+}
+
+// dir 计算目录 path 的节点哈希，base 为该目录的基线节点 (不存在时为 nil)
+// report 为 false 时不记录变化 (所在目录整体为新增)
+func (w *walker) dir(path string, base *model.MerkleNode, report bool) (string, error) {
+	if err := w.ctx.Err(); err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	w.report.Dirs++
+
+	old := make(map[string]*model.MerkleEntry)
+	if base != nil {
+		for i := range base.Entries {
+			old[base.Entries[i].Name] = &base.Entries[i]
+		}
+	}
+
+	node := model.MerkleNode{Path: path, Entries: make([]model.MerkleEntry, 0, len(entries))}
+	for _, de := range entries {
+		name := de.Name()
+		child := filepath.Join(path, name)
+		prev := old[name]
+		delete(old, name)
+
+		e, ok, err := w.entry(child, prev, report)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return "", w.ctx.Err()
+			}
+			if errors.Is(err, ErrTampered) {
+				return "", err
+			}
+			if os.IsNotExist(err) {
+				// 遍历过程中被删除，按不存在处理
+				old[name] = prev
+				continue
+			}
+			w.report.Errors++
+			logger.Warn("目录基线读取失败", "path", child, "error", err)
+			if prev != nil {
+				node.Entries = append(node.Entries, *prev)
+			}
+			continue
+		}
+		if !ok {
+			// 设备、管道与 socket 不纳入基线
+			old[name] = prev
+			continue
+		}
+		if report {
+			w.compare(child, prev, &e)
+		}
+		if w.update && prev != nil && prev.Type == TypeDir && e.Type != TypeDir {
+			w.tree.remove(child)
+		}
+		node.Entries = append(node.Entries, e)
+	}
+
+	if base != nil {
+		for _, prev := range base.Entries {
+			if p, ok := old[prev.Name]; !ok || p == nil {
+				continue
+			}
+			child := filepath.Join(path, prev.Name)
+			if report {
+				w.report.add(Change{Path: child, Type: ChangeRemoved})
+			}
+			if w.update && prev.Type == TypeDir {
+				w.tree.remove(child)
+			}
+		}
+	}
+
+	node.Hash = nodeHash(node.Entries)
+	if w.update && (base == nil || !sameEntries(base.Entries, node.Entries)) {
+		node.UpdatedAt = time.Now().Unix()
+		if path == w.tree.root && w.tree.opts.Key != nil {
+			sig, err := sm2.Sign(nil, w.tree.opts.Key, rootMessage(path, node.Hash))
+			if err != nil {
+				return "", fmt.Errorf("merkle: sign: %w", err)
+			}
+			node.Signature = base64.StdEncoding.EncodeToString(sig)
+		}
+		if err := w.tree.store.Put(path, node); err != nil {
+			return "", fmt.Errorf("merkle: save %s: %w", path, err)
+		}
+	}
+	return node.Hash, nil
+}
+
+// entry 读取条目属性并计算哈希，ok 为 false 表示不纳入基线的类型
+func (w *walker) entry(path string, prev *model.MerkleEntry, report bool) (model.MerkleEntry, bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return model.MerkleEntry{}, false, err
+	}
+	e := model.MerkleEntry{
+		Name: info.Name(),
+		Mode: uint32(info.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)),
+	}
+	fillStat(&e, info)
+
+	switch {
+	case info.Mode().IsRegular():
+		e.Type = TypeFile
+		e.Size = info.Size()
+		e.MTime = info.ModTime().UnixNano()
+		w.report.Files++
+		if prev != nil && prev.Type == TypeFile && !w.tree.opts.FullHash && sameStat(prev, &e) {
+			e.Hash = prev.Hash
+			break
+		}
+		if e.Hash, err = hashFile(path); err != nil {
+			return e, false, err
+		}
+		w.report.Hashed++
+	case info.IsDir():
+		e.Type = TypeDir
+		// 类型未变的目录才有可对比的子节点
+		var sub *model.MerkleNode
+		if prev != nil && prev.Type == TypeDir {
+			if sub, err = w.tree.store.Get(path); err != nil {
+				return e, false, fmt.Errorf("merkle: load %s: %w", path, err)
+			}
+			// 子节点必须与父节点记录的哈希一致
+			if sub != nil && (sub.Hash != prev.Hash || nodeHash(sub.Entries) != sub.Hash) {
+				return e, false, fmt.Errorf("%w: %s", ErrTampered, path)
+			}
+		}
+		if e.Hash, err = w.dir(path, sub, report && sub != nil); err != nil {
+			return e, false, err
+		}
+	case info.Mode()&fs.ModeSymlink != 0:
+		e.Type = TypeSymlink
+		target, err := os.Readlink(path)
+		if err != nil {
+			return e, false, err
+		}
+		e.Hash = sum([]byte(target))
+	default:
+		return e, false, nil
+	}
+	return e, true, nil
+}
+
+// compare 对比条目与基线，记录变化
+func (w *walker) compare(path string, prev, cur *model.MerkleEntry) {
+	if prev == nil {
+		w.report.add(Change{Path: path, Type: ChangeAdded})
+		return
+	}
+	if prev.Type != cur.Type {
+		w.report.add(Change{Path: path, Type: ChangeModified, Detail: fmt.Sprintf("type %s -> %s", prev.Type, cur.Type)})
+		return
+	}
+	// 目录内的变化已在子节点中逐项记录
+	if cur.Type != TypeDir && prev.Hash != cur.Hash {
+		w.report.add(Change{Path: path, Type: ChangeModified, Detail: fmt.Sprintf("sm3 %s -> %s", shortHash(prev.Hash), shortHash(cur.Hash))})
+	}
+	if prev.Mode != cur.Mode || prev.UID != cur.UID || prev.GID != cur.GID {
+		w.report.add(Change{Path: path, Type: ChangeMetadata, Detail: fmt.Sprintf("mode %04o -> %04o, owner %d:%d -> %d:%d",
+			prev.Mode, cur.Mode, prev.UID, prev.GID, cur.UID, cur.GID)})
+	}
+}
+
+// checkRoot 校验根节点哈希与签名
+func (t *Tree) checkRoot(node *model.MerkleNode) error {
+	if nodeHash(node.Entries) != node.Hash {
+		return fmt.Errorf("%w: %s", ErrTampered, t.root)
+	}
+	if t.opts.Key == nil {
+		return nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(node.Signature)
+	if err != nil || !sm2.Verify(&t.opts.Key.PublicKey, rootMessage(t.root, node.Hash), sig) {
+		return fmt.Errorf("%w: %s: invalid signature", ErrTampered, t.root)
+	}
+	return nil
+}
+
+// rootMessage 根节点签名原文，包含根目录路径，避免不同根目录的节点互相替换
+func rootMessage(root, hash string) []byte {
+	return []byte("merkle\x00" + root + "\x00" + hash)
+}
+
+// remove 删除目录 path 及其子目录的基线节点
+func (t *Tree) remove(path string) {
+	node, err := t.store.Get(path)
+	if err != nil || node == nil {
+		return
+	}
+	for _, e := range node.Entries {
+		if e.Type == TypeDir {
+			t.remove(filepath.Join(path, e.Name))
+		}
+	}
+	if err := t.store.Delete(path); err != nil {
+		logger.Warn("删除目录基线节点失败", "path", path, "error", err)
+	}
+}
+
+// nodeHash 按条目顺序计算目录哈希，条目名称、类型、属性与内容哈希任一变化都会改变目录哈希
+func nodeHash(entries []model.MerkleEntry) string {
+	h := sm3.New()
+	for _, e := range entries {
+		io.WriteString(h, e.Name)
+		h.Write([]byte{0})
+		io.WriteString(h, e.Type)
+		h.Write([]byte{0})
+		io.WriteString(h, strconv.FormatUint(uint64(e.Mode), 8))
+		h.Write([]byte{0})
+		io.WriteString(h, strconv.FormatUint(uint64(e.UID), 10)+":"+strconv.FormatUint(uint64(e.GID), 10))
+		h.Write([]byte{0})
+		io.WriteString(h, e.Hash)
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sameStat 文件大小、时间与 inode 均未变化时认为内容未变
+// ctime 无法由普通用户设置，可发现修改内容后回拨 mtime 的情况
+func sameStat(a, b *model.MerkleEntry) bool {
+	return a.Size == b.Size && a.MTime == b.MTime && a.CTime == b.CTime && a.Ino == b.Ino
+}
+
+func sameEntries(a, b []model.MerkleEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hashFile 计算文件内容 SM3
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sm3.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sum(data []byte) string {
+	h := sm3.New()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
@@ -0,0 +1,429 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/model"
+)
+
+// memStore 内存节点存储
+type memStore struct {
+	nodes map[string]model.MerkleNode
+	puts  int
+}
+
+func newMemStore() *memStore {
+	return &memStore{nodes: make(map[string]model.MerkleNode)}
+}
+
+func (s *memStore) Get(path string) (*model.MerkleNode, error) {
+	n, ok := s.nodes[path]
+	if !ok {
+		return nil, nil
+	}
+	return &n, nil
+}
+
+func (s *memStore) Put(path string, node model.MerkleNode) error {
+	s.puts++
+	s.nodes[path] = node
+	return nil
+}
+
+func (s *memStore) Delete(path string) error {
+	delete(s.nodes, path)
+	return nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTree 在临时目录下创建文件并建立基线
+func newTree(t *testing.T, files map[string]string) (*Tree, *memStore, string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		writeFile(t, filepath.Join(root, name), content)
+	}
+	store := newMemStore()
+	tree, err := New(root, store, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := tree.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Changed() {
+		t.Fatalf("建立基线时报告变化: %+v", r.Changes)
+	}
+	return tree, store, root
+}
+
+func changeSet(r *Report, root string) map[string]ChangeType {
+	got := make(map[string]ChangeType)
+	for _, c := range r.Changes {
+		rel, _ := filepath.Rel(root, c.Path)
+		got[rel] = c.Type
+	}
+	return got
+}
+
+func TestVerify_NoBaseline(t *testing.T) {
+	tree, err := New(t.TempDir(), newMemStore(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Verify(context.Background()); !errors.Is(err, ErrNoBaseline) {
+		t.Fatalf("Verify() error = %v, want ErrNoBaseline", err)
+	}
+}
+
+func TestVerify_Unchanged(t *testing.T) {
+	tree, store, _ := newTree(t, map[string]string{
+		"a.txt":         "a",
+		"sub/b.txt":     "b",
+		"sub/deep/c.go": "c",
+	})
+	if len(store.nodes) != 3 {
+		t.Fatalf("节点数 = %d, want 3 (每个目录一个)", len(store.nodes))
+	}
+
+	r, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Changed() {
+		t.Errorf("未修改时报告变化: %+v", r.Changes)
+	}
+	if r.Hash != r.BaselineHash {
+		t.Errorf("根哈希 %s != 基线 %s", r.Hash, r.BaselineHash)
+	}
+	if r.Files != 3 || r.Dirs != 3 {
+		t.Errorf("Files=%d Dirs=%d, want 3/3", r.Files, r.Dirs)
+	}
+	// 属性未变的文件不重新计算哈希
+	if r.Hashed != 0 {
+		t.Errorf("Hashed = %d, want 0", r.Hashed)
+	}
+}
+
+func TestVerify_LocalizesChanges(t *testing.T) {
+	tree, _, root := newTree(t, map[string]string{
+		"a.txt":         "a",
+		"sub/b.txt":     "b",
+		"sub/deep/c.go": "c",
+		"gone/d.txt":    "d",
+		"other/e.txt":   "e",
+	})
+
+	writeFile(t, filepath.Join(root, "sub/deep/c.go"), "changed")
+	writeFile(t, filepath.Join(root, "sub/new.txt"), "new")
+	writeFile(t, filepath.Join(root, "added/x/y.txt"), "y")
+	if err := os.RemoveAll(filepath.Join(root, "gone")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "a.txt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ChangeType{
+		"sub/deep/c.go": ChangeModified,
+		"sub/new.txt":   ChangeAdded,
+		"added":         ChangeAdded, // 新增目录只报告目录本身
+		"gone":          ChangeRemoved,
+		"a.txt":         ChangeMetadata,
+	}
+	got := changeSet(r, root)
+	if len(got) != len(want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+	for path, typ := range want {
+		if got[path] != typ {
+			t.Errorf("%s: %q, want %q", path, got[path], typ)
+		}
+	}
+	if r.Hash == r.BaselineHash {
+		t.Error("根哈希未变化")
+	}
+	// 只重新计算属性变化的文件 (chmod 改变 ctime)
+	if r.Hashed != 4 {
+		t.Errorf("Hashed = %d, want 4 (a.txt, c.go, new.txt, y.txt)", r.Hashed)
+	}
+
+	// Verify 不修改基线
+	r2, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r2.Changes) != len(r.Changes) {
+		t.Errorf("再次校验 changes = %d, want %d", len(r2.Changes), len(r.Changes))
+	}
+}
+
+func TestVerify_MtimeRollback(t *testing.T) {
+	tree, _, root := newTree(t, map[string]string{"bin/tool": "original"})
+	path := filepath.Join(root, "bin/tool")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同长度改写后回拨修改时间，依靠 ctime 发现
+	time.Sleep(10 * time.Millisecond)
+	writeFile(t, path, "tampered")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changeSet(r, root); got["bin/tool"] != ChangeModified {
+		t.Errorf("changes = %v, want bin/tool modified", got)
+	}
+}
+
+func TestVerify_TypeChange(t *testing.T) {
+	tree, _, root := newTree(t, map[string]string{
+		"conf/a.yml": "a",
+		"file":       "f",
+	})
+	os.RemoveAll(filepath.Join(root, "conf"))
+	writeFile(t, filepath.Join(root, "conf"), "now a file")
+	os.Remove(filepath.Join(root, "file"))
+	if err := os.Symlink("/etc/passwd", filepath.Join(root, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := changeSet(r, root)
+	if got["conf"] != ChangeModified || got["file"] != ChangeModified || len(got) != 2 {
+		t.Errorf("changes = %v, want conf/file modified", got)
+	}
+}
+
+func TestUpdate_AcceptsChanges(t *testing.T) {
+	tree, store, root := newTree(t, map[string]string{
+		"a.txt":       "a",
+		"old/b.txt":   "b",
+		"old/x/c.txt": "c",
+	})
+	writeFile(t, filepath.Join(root, "a.txt"), "changed")
+	os.RemoveAll(filepath.Join(root, "old"))
+
+	r, err := tree.Update(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Changes) != 2 {
+		t.Fatalf("Update() changes = %+v, want 2", r.Changes)
+	}
+	// 删除目录的子节点一并清理
+	var paths []string
+	for p := range store.nodes {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if len(paths) != 1 || paths[0] != tree.Root() {
+		t.Errorf("nodes = %v, want only root", paths)
+	}
+
+	// 新基线下不再报告
+	r, err = tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Changed() {
+		t.Errorf("更新基线后仍报告变化: %+v", r.Changes)
+	}
+
+	// 无变化时不写入节点
+	puts := store.puts
+	if _, err := tree.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.puts != puts {
+		t.Errorf("无变化时写入 %d 个节点", store.puts-puts)
+	}
+}
+
+func TestVerify_RootRemoved(t *testing.T) {
+	tree, _, root := newTree(t, map[string]string{"a.txt": "a"})
+	os.RemoveAll(root)
+
+	r, err := tree.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Changes) != 1 || r.Changes[0].Type != ChangeRemoved || r.Changes[0].Path != root {
+		t.Errorf("changes = %+v, want root removed", r.Changes)
+	}
+}
+
+func TestVerify_FullHash(t *testing.T) {
+	tree, store, _ := newTree(t, map[string]string{"a": "a", "b": "b"})
+	full, err := New(tree.Root(), store, Options{FullHash: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := full.Verify(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Hashed != 2 || r.Changed() {
+		t.Errorf("Hashed=%d Changed=%v, want 2/false", r.Hashed, r.Changed())
+	}
+}
+
+func TestVerify_Canceled(t *testing.T) {
+	tree, _, _ := newTree(t, map[string]string{"a/b/c": "c"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tree.Verify(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Verify() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestMonitor_BuildsThenReports(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	tree, err := New(root, newMemStore(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports []*Report
+	m := NewMonitor([]*Tree{tree}, MonitorConfig{Accept: true}, func(r *Report) { reports = append(reports, r) })
+	ctx := context.Background()
+
+	m.Check(ctx) // 建立基线
+	writeFile(t, filepath.Join(root, "b.txt"), "b")
+	m.Check(ctx)
+	m.Check(ctx) // 已接受，不重复上报
+
+	if len(reports) != 1 {
+		t.Fatalf("handler 调用 %d 次, want 1", len(reports))
+	}
+	if got := changeSet(reports[0], root); got["b.txt"] != ChangeAdded {
+		t.Errorf("changes = %v", got)
+	}
+}
+
+func TestVerify_TamperedNode(t *testing.T) {
+	tree, store, root := newTree(t, map[string]string{"sub/deep/a.txt": "a"})
+
+	// 修改数据库中子节点记录的文件哈希，隐藏对文件的改动
+	deep := filepath.Join(root, "sub/deep")
+	node := store.nodes[deep]
+	node.Entries[0].Hash = "forged"
+	store.nodes[deep] = node
+
+	if _, err := tree.Verify(context.Background()); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Verify() error = %v, want ErrTampered", err)
+	}
+
+	// 连同节点哈希一起伪造也无法通过父节点校验
+	node.Hash = nodeHash(node.Entries)
+	store.nodes[deep] = node
+	if _, err := tree.Verify(context.Background()); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Verify() error = %v, want ErrTampered", err)
+	}
+}
+
+func TestVerify_RootSignature(t *testing.T) {
+	key, _ := sm2.GenerateKey(nil)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	store := newMemStore()
+	tree, err := New(root, store, Options{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.nodes[root].Signature == "" {
+		t.Fatal("根节点未签名")
+	}
+	if _, err := tree.Verify(context.Background()); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// 重新计算整条哈希链后，没有私钥无法生成有效签名
+	node := store.nodes[root]
+	node.Entries[0].Hash = "forged"
+	node.Hash = nodeHash(node.Entries)
+	store.nodes[root] = node
+	if _, err := tree.Verify(context.Background()); !errors.Is(err, ErrTampered) {
+		t.Fatalf("Verify() error = %v, want ErrTampered", err)
+	}
+
+	// 其他密钥校验失败
+	other, _ := sm2.GenerateKey(nil)
+	tree2, _ := New(root, store, Options{Key: other})
+	if _, err := tree2.Verify(context.Background()); !errors.Is(err, ErrTampered) {
+		t.Errorf("其他密钥 Verify() error = %v, want ErrTampered", err)
+	}
+}
+
+func TestMonitor_RebuildsTamperedBaseline(t *testing.T) {
+	tree, store, root := newTree(t, map[string]string{"a.txt": "a"})
+	node := store.nodes[root]
+	node.Hash = "forged"
+	store.nodes[root] = node
+
+	var reports []*Report
+	m := NewMonitor([]*Tree{tree}, MonitorConfig{}, func(r *Report) { reports = append(reports, r) })
+	m.Check(context.Background())
+
+	if len(reports) != 1 || reports[0].Changes[0].Type != ChangeBaseline {
+		t.Fatalf("reports = %+v, want one baseline change", reports)
+	}
+	if _, err := tree.Verify(context.Background()); err != nil {
+		t.Errorf("重建后 Verify() error = %v", err)
+	}
+}
+
+func TestMonitor_Rebaseline(t *testing.T) {
+	tree, _, root := newTree(t, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+	m := NewMonitor([]*Tree{tree}, MonitorConfig{}, nil)
+
+	writeFile(t, filepath.Join(root, "sub/b.txt"), "changed")
+	r, err := m.Rebaseline(context.Background(), filepath.Join(root, "sub/b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changeSet(r, root); got["sub/b.txt"] != ChangeModified {
+		t.Errorf("changes = %v", got)
+	}
+	if r, err := tree.Verify(context.Background()); err != nil || r.Changed() {
+		t.Errorf("重建后 Verify() = %+v, %v", r, err)
+	}
+
+	if _, err := m.Rebaseline(context.Background(), root+"-other/a.txt"); !errors.Is(err, ErrNotMonitored) {
+		t.Errorf("Rebaseline(outside) error = %v, want ErrNotMonitored", err)
+	}
+}
@@ -0,0 +1,173 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// DefaultInterval 默认校验周期
+const DefaultInterval = time.Hour
+
+// Handler 发现变化时的回调
+type Handler func(r *Report)
+
+// MonitorConfig 周期校验配置
+type MonitorConfig struct {
+	// 校验周期，<=0 时使用 DefaultInterval
+	Interval time.Duration
+	// 上报后以当前状态为新基线，同一变化只上报一次；关闭时变化恢复前每轮都会上报
+	Accept bool
+}
+
+// Monitor 周期校验多个目录树
+// 启动时为尚无基线的目录建立基线
+type Monitor struct {
+	trees   []*Tree
+	cfg     MonitorConfig
+	handler Handler
+
+	// 串行化周期校验与按需重建，同一目录树不并发遍历
+	mu sync.Mutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor 创建周期校验
+func NewMonitor(trees []*Tree, cfg MonitorConfig, handler Handler) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &Monitor{trees: trees, cfg: cfg, handler: handler}
+}
+
+// Start 启动校验
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go m.loop(ctx)
+	logger.Info("目录基线校验已启动", "roots", len(m.trees), "interval", m.cfg.Interval)
+}
+
+// Stop 停止校验，中断进行中的遍历
+func (m *Monitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+	m.cancel = nil
+}
+
+func (m *Monitor) loop(ctx context.Context) {
+	defer m.wg.Done()
+	m.Check(ctx)
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Check 依次校验所有目录树
+func (m *Monitor) Check(ctx context.Context) {
+	for _, t := range m.trees {
+		if ctx.Err() != nil {
+			return
+		}
+		m.check(ctx, t)
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, t *Tree) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	has, err := t.HasBaseline()
+	if err != nil {
+		logger.Error("读取目录基线失败", "root", t.Root(), "error", err)
+		return
+	}
+
+	var r *Report
+	if !has || m.cfg.Accept {
+		r, err = t.Update(ctx)
+	} else {
+		r, err = t.Verify(ctx)
+	}
+	if errors.Is(err, ErrTampered) {
+		// 基线不可信: 上报后丢弃并按当前状态重建
+		logger.Error("目录基线被篡改，重新建立基线", "root", t.Root(), "error", err)
+		if m.handler != nil {
+			m.handler(&Report{Root: t.Root(), Changes: []Change{{Path: t.Root(), Type: ChangeBaseline, Detail: err.Error()}}})
+		}
+		t.Reset()
+		has = false
+		r, err = t.Update(ctx)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("目录基线校验失败", "root", t.Root(), "error", err)
+		}
+		return
+	}
+
+	if !has {
+		logger.Info("目录基线已建立", "root", t.Root(), "files", r.Files, "dirs", r.Dirs, "duration", r.Duration)
+		return
+	}
+	logger.Debug("目录基线校验完成", "root", t.Root(), "files", r.Files, "hashed", r.Hashed,
+		"changes", len(r.Changes)+r.Truncated, "errors", r.Errors, "duration", r.Duration)
+	if r.Changed() && m.handler != nil {
+		m.handler(r)
+	}
+}
+
+// ErrNotMonitored 路径不在任何基线目录下
+var ErrNotMonitored = errors.New("merkle: path is not under a baseline root")
+
+// Rebaseline 以当前状态重建 path 所在目录树的基线 (确认变化为预期变更)，返回相对旧基线的变化
+// 基线以目录树为单位，path 为文件时整棵树的变化一并接受
+func (m *Monitor) Rebaseline(ctx context.Context, path string) (*Report, error) {
+	path = filepath.Clean(path)
+	var tree *Tree
+	for _, t := range m.trees {
+		if path == t.Root() || strings.HasPrefix(path, t.Root()+string(filepath.Separator)) {
+			// 嵌套的基线目录取最深的一个
+			if tree == nil || len(t.Root()) > len(tree.Root()) {
+				tree = t
+			}
+		}
+	}
+	if tree == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotMonitored, path)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, err := tree.Update(ctx)
+	if errors.Is(err, ErrTampered) {
+		// 重建即丢弃旧基线，不可信的基线不影响重建
+		tree.Reset()
+		r, err = tree.Update(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("目录基线已按指令重建", "root", tree.Root(), "path", path, "changes", len(r.Changes)+r.Truncated)
+	return r, nil
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package merkle
+
+import (
+	"os"
+	"syscall"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fillStat 填充属主、inode 与状态变更时间
+func fillStat(e *model.MerkleEntry, fi os.FileInfo) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		e.UID = st.Uid
+		e.GID = st.Gid
+		e.Ino = st.Ino
+		e.CTime = st.Ctim.Sec*1e9 + st.Ctim.Nsec
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package merkle
+
+import (
+	"os"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fillStat 非 Linux 平台不记录属主与 inode，增量校验只依据大小与修改时间
+func fillStat(e *model.MerkleEntry, fi os.FileInfo) {}
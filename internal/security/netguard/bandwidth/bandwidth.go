@@ -0,0 +1,605 @@
+// Package bandwidth 外发流量统计与大流量外传告警
+// 周期通过 sock_diag 读取每个 TCP 连接已被对端确认的发送字节数 (tcp_info.bytes_acked)，
+// 按 (进程, 对端 IP) 在滑动窗口内累计，向白名单之外的目标发送量超过阈值时上报。
+// 只统计 TCP (UDP socket 内核不提供字节计数)。两次采样之间关闭的连接 (含短连接) 的剩余发送量
+// 可由连接跟踪的销毁事件 (ObserveConntrack) 补充
+package bandwidth
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/netguard/conntrack"
+)
+
+// procRoot proc 文件系统挂载点，测试时可替换
+var procRoot = "/proc"
+
+// dumpSockets 读取全部 TCP 连接，测试时可替换
+var dumpSockets = readSockets
+
+// interfaceAddrs 本机地址，测试时可替换
+var interfaceAddrs = net.InterfaceAddrs
+
+// closedRetention 连接从采样中消失后保留其统计的时间，
+// 需长于连接跟踪记录在 TIME_WAIT 等关闭状态的超时 (默认 120s)
+const closedRetention = 5 * time.Minute
+
+// 默认值
+const (
+	DefaultInterval  = 5 * time.Second
+	DefaultWindow    = 10 * time.Minute
+	DefaultThreshold = 100 << 20
+)
+
+// Socket 一个 TCP 连接的发送统计
+type Socket struct {
+	// 内核分配的 socket cookie，连接生命周期内唯一
+	Cookie     uint64
+	Inode      uint64
+	Local      net.IP
+	LocalPort  int
+	Remote     net.IP
+	RemotePort int
+	UID        uint32
+	// 已被对端确认的发送字节数 (不含重传)
+	BytesAcked uint64
+}
+
+// Flow 超过阈值的外发流量
+type Flow struct {
+	// 发送进程，无法关联 (如进程已退出、权限不足) 时 PID 为 0
+	PID     int
+	Process string
+	Exe     string
+	Remote  net.IP
+	// 窗口内最近一次有数据发送的对端端口
+	RemotePort int
+	// 窗口内累计发送字节数
+	Bytes  uint64
+	Window time.Duration
+	Time   time.Time
+}
+
+// Owner 进程描述，如 "/usr/bin/curl, pid 1234"
+func (f Flow) Owner() string {
+	switch {
+	case f.PID == 0:
+		return "unknown process"
+	case f.Exe != "":
+		return fmt.Sprintf("%s, pid %d", f.Exe, f.PID)
+	}
+	return fmt.Sprintf("%s, pid %d", f.Process, f.PID)
+}
+
+// Handler 外发流量超过阈值时的回调
+type Handler func(f Flow)
+
+// Config 统计配置
+type Config struct {
+	// 采样周期，<=0 时使用 DefaultInterval
+	Interval time.Duration
+	// 统计窗口，同一 (进程, 对端) 告警后窗口内不重复告警，<=0 时使用 DefaultWindow
+	Window time.Duration
+	// 窗口内发送字节数阈值，0 时使用 DefaultThreshold
+	Threshold uint64
+	// 不统计的对端 IP 或 CIDR，回环地址总是不统计
+	Whitelist []string
+	// 额外的白名单判断 (如按 GeoIP 规则)，返回 true 时不统计
+	Allow func(ip net.IP) bool
+}
+
+// flowKey 统计键
+type flowKey struct {
+	pid    int
+	remote string
+}
+
+// sample 一次采样的发送量
+type sample struct {
+	t time.Time
+	n uint64
+}
+
+// flow 一个 (进程, 对端) 的滑动窗口
+type flow struct {
+	samples []sample
+	total   uint64
+	port    int
+	// 告警后的静默截止时间
+	silence time.Time
+}
+
+// Monitor 外发流量统计
+type Monitor struct {
+	cfg     Config
+	handler Handler
+	nets    []*net.IPNet
+
+	mu sync.Mutex
+	// 上次采样时各连接的已确认字节数
+	acked map[uint64]uint64
+	// 首次采样只记录基线，不计入启动前已发送的数据
+	primed bool
+	// socket inode -> pid 缓存
+	owners map[uint64]int
+	flows  map[flowKey]*flow
+	// 白名单之外的连接
+	conns map[tupleKey]*connState
+	// 本机地址
+	local map[string]bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建统计
+func New(cfg Config, handler Handler) (*Monitor, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultWindow
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	m := &Monitor{
+		cfg:     cfg,
+		handler: handler,
+		acked:   make(map[uint64]uint64),
+		owners:  make(map[uint64]int),
+		flows:   make(map[flowKey]*flow),
+		conns:   make(map[tupleKey]*connState),
+		local:   make(map[string]bool),
+	}
+	for _, s := range cfg.Whitelist {
+		n, err := parseNet(s)
+		if err != nil {
+			return nil, err
+		}
+		m.nets = append(m.nets, n)
+	}
+	return m, nil
+}
+
+// parseNet 解析 IP 或 CIDR
+func parseNet(s string) (*net.IPNet, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("bandwidth: bad whitelist entry %q", s)
+		}
+		return n, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("bandwidth: bad whitelist entry %q", s)
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Start 后台周期采样
+func (m *Monitor) Start() {
+	m.stopCh = make(chan struct{})
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// Stop 停止采样
+func (m *Monitor) Stop() {
+	if m.stopCh == nil {
+		return
+	}
+	close(m.stopCh)
+	m.wg.Wait()
+	m.stopCh = nil
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	m.Check()
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check 采样一次，返回本次超过阈值的外发流量并逐条回调
+func (m *Monitor) Check() []Flow {
+	socks, err := dumpSockets()
+	if err != nil {
+		logger.Warn("读取 TCP 连接统计失败", "error", err)
+		return nil
+	}
+	m.refreshLocal()
+	flows := m.observe(socks, time.Now())
+	m.report(flows)
+	return flows
+}
+
+func (m *Monitor) report(flows []Flow) {
+	if m.handler == nil {
+		return
+	}
+	for _, f := range flows {
+		m.handler(f)
+	}
+}
+
+// refreshLocal 更新本机地址，用于判断连接跟踪记录的方向
+func (m *Monitor) refreshLocal() {
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return
+	}
+	local := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok {
+			local[n.IP.String()] = true
+		}
+	}
+	m.mu.Lock()
+	m.local = local
+	m.mu.Unlock()
+}
+
+// Allowed 对端是否在白名单中
+func (m *Monitor) Allowed(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range m.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return m.cfg.Allow != nil && m.cfg.Allow(ip)
+}
+
+// tupleKey 连接的本端与对端地址
+type tupleKey struct {
+	local, remote string
+}
+
+func newTupleKey(local net.IP, localPort int, remote net.IP, remotePort int) tupleKey {
+	return tupleKey{
+		local:  net.JoinHostPort(local.String(), strconv.Itoa(localPort)),
+		remote: net.JoinHostPort(remote.String(), strconv.Itoa(remotePort)),
+	}
+}
+
+// connState 采样到的连接，连接跟踪销毁事件据此计算最后一次采样后的发送量
+type connState struct {
+	pid   int
+	acked uint64
+	// 最后一次出现在采样中的时间
+	seen time.Time
+}
+
+// delta 一个连接本次采样新增的发送量
+type delta struct {
+	sock Socket
+	n    uint64
+}
+
+// observe 计入一次采样结果
+func (m *Monitor) observe(socks []Socket, now time.Time) []Flow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pending []delta
+	seen := make(map[uint64]bool, len(socks))
+	for _, s := range socks {
+		seen[s.Cookie] = true
+		prev, ok := m.acked[s.Cookie]
+		m.acked[s.Cookie] = s.BytesAcked
+		if m.Allowed(s.Remote) {
+			continue
+		}
+		n := s.BytesAcked
+		if ok && n >= prev {
+			n -= prev
+		}
+		// 首次采样只记录基线
+		if m.primed && n > 0 {
+			pending = append(pending, delta{sock: s, n: n})
+		}
+	}
+	for cookie := range m.acked {
+		if !seen[cookie] {
+			delete(m.acked, cookie)
+		}
+	}
+	m.primed = true
+	m.resolveOwners(socks, pending)
+
+	// 记录连接的最新状态，供连接跟踪销毁事件计算剩余发送量
+	for _, s := range socks {
+		if m.Allowed(s.Remote) {
+			continue
+		}
+		k := newTupleKey(s.Local, s.LocalPort, s.Remote, s.RemotePort)
+		st := m.conns[k]
+		if st == nil {
+			st = &connState{pid: m.owners[s.Inode]}
+			m.conns[k] = st
+		}
+		if pid, ok := m.owners[s.Inode]; ok {
+			st.pid = pid
+		}
+		st.acked, st.seen = s.BytesAcked, now
+	}
+	for k, st := range m.conns {
+		if now.Sub(st.seen) > closedRetention {
+			delete(m.conns, k)
+		}
+	}
+
+	// 同一 (进程, 对端) 的多个连接本次合并为一个采样
+	var keys []flowKey
+	sums := make(map[flowKey]*delta)
+	for _, d := range pending {
+		key := flowKey{pid: m.owners[d.sock.Inode], remote: d.sock.Remote.String()}
+		if sum, ok := sums[key]; ok {
+			sum.n += d.n
+			sum.sock = d.sock
+			continue
+		}
+		keys = append(keys, key)
+		sums[key] = &delta{sock: d.sock, n: d.n}
+	}
+
+	var out []Flow
+	for _, key := range keys {
+		d := sums[key]
+		if f, ok := m.account(key, d.sock.Remote, d.sock.RemotePort, d.n, now); ok {
+			out = append(out, f)
+		}
+	}
+
+	horizon := now.Add(-m.cfg.Window)
+	for key, f := range m.flows {
+		f.prune(horizon)
+		if len(f.samples) == 0 && !now.Before(f.silence) {
+			delete(m.flows, key)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bytes > out[j].Bytes })
+	return out
+}
+
+// account 计入一个 (进程, 对端) 的发送量，超过阈值且不在静默期时返回告警，调用方持有锁
+func (m *Monitor) account(key flowKey, remote net.IP, port int, n uint64, now time.Time) (Flow, bool) {
+	f := m.flows[key]
+	if f == nil {
+		f = &flow{}
+		m.flows[key] = f
+	}
+	f.add(now, n, now.Add(-m.cfg.Window))
+	f.port = port
+	if f.total < m.cfg.Threshold || now.Before(f.silence) {
+		return Flow{}, false
+	}
+	fl := Flow{
+		PID:        key.pid,
+		Remote:     remote,
+		RemotePort: port,
+		Bytes:      f.total,
+		Window:     m.cfg.Window,
+		Time:       now,
+	}
+	if key.pid > 0 {
+		fl.Process, fl.Exe = processName(key.pid)
+	}
+	f.samples, f.total = nil, 0
+	f.silence = now.Add(m.cfg.Window)
+	return fl, true
+}
+
+// ObserveConntrack 计入一条已销毁 TCP 连接在最后一次采样之后的发送量，可作为 conntrack.Monitor 的回调。
+// 采样到的连接按采样时的进程统计；两次采样之间建立并关闭的连接无法关联进程，PID 为 0。
+// 连接跟踪的字节数包含头部，按报文数扣除典型头部长度估算载荷
+func (m *Monitor) ObserveConntrack(ev conntrack.Event) {
+	if ev.Type != conntrack.EventDestroy || ev.Flow.Orig.Proto != tcpProto {
+		return
+	}
+	now := ev.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	m.mu.Lock()
+	fl, ok := m.observeClosed(ev.Flow, now)
+	m.mu.Unlock()
+	if ok {
+		m.report([]Flow{fl})
+	}
+}
+
+// tcpProto IPPROTO_TCP
+const tcpProto = 6
+
+// observeClosed 计入已销毁连接的剩余发送量，调用方持有锁
+func (m *Monitor) observeClosed(f conntrack.Flow, now time.Time) (Flow, bool) {
+	o, r := f.Orig, f.Reply
+	// 本机发起的连接本端为发起方向的源地址；对端发起的连接本端为应答方向的源地址 (DNAT 后的真实地址)
+	out := newTupleKey(o.Src, int(o.SrcPort), o.Dst, int(o.DstPort))
+	in := newTupleKey(r.Src, int(r.SrcPort), o.Src, int(o.SrcPort))
+
+	var remote net.IP
+	var port int
+	var sent conntrack.Counters
+	st, known := m.conns[out]
+	switch {
+	case known:
+		remote, port, sent = o.Dst, int(o.DstPort), f.OrigCounters
+		delete(m.conns, out)
+	case m.conns[in] != nil:
+		st, known = m.conns[in], true
+		remote, port, sent = o.Src, int(o.SrcPort), f.ReplyCounters
+		delete(m.conns, in)
+	case m.local[o.Src.String()]:
+		remote, port, sent = o.Dst, int(o.DstPort), f.OrigCounters
+	case m.local[r.Src.String()]:
+		remote, port, sent = o.Src, int(o.SrcPort), f.ReplyCounters
+	default:
+		// 转发的流量 (如容器、路由) 不属于本机进程
+		return Flow{}, false
+	}
+	if m.Allowed(remote) {
+		return Flow{}, false
+	}
+
+	n := payload(sent, remote)
+	pid := 0
+	if known {
+		pid = st.pid
+		if n <= st.acked {
+			return Flow{}, false
+		}
+		n -= st.acked
+	}
+	if n == 0 {
+		return Flow{}, false
+	}
+	return m.account(flowKey{pid: pid, remote: remote.String()}, remote, port, n, now)
+}
+
+// payload 由连接跟踪计数估算 TCP 载荷字节数 (IPv4 40、IPv6 60 字节头部加 12 字节时间戳选项)
+func payload(c conntrack.Counters, remote net.IP) uint64 {
+	hdr := uint64(52)
+	if remote.To4() == nil {
+		hdr = 72
+	}
+	if c.Bytes <= c.Packets*hdr {
+		return 0
+	}
+	return c.Bytes - c.Packets*hdr
+}
+
+// resolveOwners 更新 inode -> pid 缓存，出现未知 inode 时才遍历进程 fd
+func (m *Monitor) resolveOwners(socks []Socket, pending []delta) {
+	live := make(map[uint64]bool, len(socks))
+	for _, s := range socks {
+		live[s.Inode] = true
+	}
+	for inode := range m.owners {
+		if !live[inode] {
+			delete(m.owners, inode)
+		}
+	}
+	unknown := false
+	for _, d := range pending {
+		if _, ok := m.owners[d.sock.Inode]; !ok {
+			unknown = true
+			break
+		}
+	}
+	if !unknown {
+		return
+	}
+	// 未找到的 inode 记为 0，连接存续期间不再反复遍历
+	found := socketOwners()
+	for _, d := range pending {
+		if _, ok := m.owners[d.sock.Inode]; !ok {
+			m.owners[d.sock.Inode] = found[d.sock.Inode]
+		}
+	}
+}
+
+// add 计入一次发送量
+func (f *flow) add(t time.Time, n uint64, horizon time.Time) {
+	f.samples = append(f.samples, sample{t: t, n: n})
+	f.total += n
+	f.prune(horizon)
+}
+
+// prune 移除窗口外的采样
+func (f *flow) prune(horizon time.Time) {
+	i := 0
+	for i < len(f.samples) && !f.samples[i].t.After(horizon) {
+		f.total -= f.samples[i].n
+		i++
+	}
+	f.samples = f.samples[i:]
+}
+
+// socketOwners 遍历全部进程打开的 socket，返回 inode -> pid
+func socketOwners() map[uint64]int {
+	owners := make(map[uint64]int)
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return owners
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(procRoot, e.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			// 继承给子进程的 socket 归属最先发现的进程
+			if _, ok := owners[inode]; !ok {
+				owners[inode] = pid
+			}
+		}
+	}
+	return owners
+}
+
+// processName 进程名与可执行文件路径
+func processName(pid int) (comm, exe string) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	if data, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		comm = strings.TrimSpace(string(data))
+	}
+	if link, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		exe = strings.TrimSuffix(link, " (deleted)")
+	}
+	return comm, exe
+}
+
+// FormatBytes 如 "150.0 MB"
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatUint(n, 10) + " B"
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit && exp < 4; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTP"[exp])
+}
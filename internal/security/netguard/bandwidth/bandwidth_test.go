@@ -0,0 +1,194 @@
+package bandwidth
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/security/netguard/conntrack"
+)
+
+// fakeProc 以临时目录代替 /proc，pid 1000 持有 socket inode 11、12
+func fakeProc(t *testing.T) {
+	t.Helper()
+	old := procRoot
+	procRoot = t.TempDir()
+	t.Cleanup(func() { procRoot = old })
+
+	dir := filepath.Join(procRoot, "1000")
+	os.MkdirAll(filepath.Join(dir, "fd"), 0755)
+	os.WriteFile(filepath.Join(dir, "comm"), []byte("curl\n"), 0644)
+	os.Symlink("/usr/bin/curl", filepath.Join(dir, "exe"))
+	for i, inode := range []int{11, 12} {
+		os.Symlink("socket:["+strconv.Itoa(inode)+"]", filepath.Join(dir, "fd", strconv.Itoa(3+i)))
+	}
+}
+
+func sock(cookie, inode uint64, remote string, acked uint64) Socket {
+	return Socket{Cookie: cookie, Inode: inode, Remote: net.ParseIP(remote), RemotePort: 443, BytesAcked: acked}
+}
+
+func TestMonitor_Threshold(t *testing.T) {
+	fakeProc(t)
+	m, err := New(Config{Window: time.Minute, Threshold: 1000, Whitelist: []string{"10.0.0.0/8"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Now()
+	// 首次采样为基线，启动前已发送的数据不计入
+	if got := m.observe([]Socket{sock(1, 11, "203.0.113.9", 5000)}, base); len(got) != 0 {
+		t.Fatalf("baseline alerted: %+v", got)
+	}
+	// 同一进程到同一对端的两个连接合并统计，白名单对端不统计
+	got := m.observe([]Socket{
+		sock(1, 11, "203.0.113.9", 5600),
+		sock(2, 12, "203.0.113.9", 300),
+		sock(3, 12, "10.1.2.3", 1<<20),
+	}, base.Add(5*time.Second))
+	if len(got) != 0 {
+		t.Fatalf("below threshold alerted: %+v", got)
+	}
+	got = m.observe([]Socket{
+		sock(1, 11, "203.0.113.9", 5800),
+		sock(2, 12, "203.0.113.9", 400),
+		sock(3, 12, "10.1.2.3", 2<<20),
+	}, base.Add(10*time.Second))
+	if len(got) != 1 {
+		t.Fatalf("got %d flows, want 1", len(got))
+	}
+	f := got[0]
+	if f.PID != 1000 || f.Exe != "/usr/bin/curl" || f.Bytes != 1200 || f.Remote.String() != "203.0.113.9" || f.RemotePort != 443 {
+		t.Errorf("flow = %+v", f)
+	}
+	if f.Owner() != "/usr/bin/curl, pid 1000" {
+		t.Errorf("Owner() = %q", f.Owner())
+	}
+
+	// 窗口内不重复告警
+	if got := m.observe([]Socket{sock(1, 11, "203.0.113.9", 9000)}, base.Add(20*time.Second)); len(got) != 0 {
+		t.Errorf("alerted again within window: %+v", got)
+	}
+	// 静默期后窗口内仍超过阈值时再次告警
+	got = m.observe([]Socket{sock(1, 11, "203.0.113.9", 9500)}, base.Add(75*time.Second))
+	if len(got) != 1 || got[0].Bytes != 3700 {
+		t.Errorf("after silence got %+v", got)
+	}
+}
+
+func TestMonitor_WindowExpiry(t *testing.T) {
+	fakeProc(t)
+	m, _ := New(Config{Window: time.Minute, Threshold: 1000}, nil)
+
+	base := time.Now()
+	m.observe(nil, base)
+	// 新连接的全部发送量计入
+	m.observe([]Socket{sock(1, 99, "198.51.100.1", 600)}, base)
+	// 窗口外的发送量不累计; 计数回绕 (cookie 复用) 时按新连接计
+	if got := m.observe([]Socket{sock(1, 99, "198.51.100.1", 500)}, base.Add(2*time.Minute)); len(got) != 0 {
+		t.Fatalf("expired samples counted: %+v", got)
+	}
+	got := m.observe([]Socket{sock(1, 99, "198.51.100.1", 1000)}, base.Add(2*time.Minute+time.Second))
+	if len(got) != 1 || got[0].Bytes != 1000 {
+		t.Fatalf("got %+v", got)
+	}
+	// 未找到所属进程
+	if got[0].PID != 0 || got[0].Owner() != "unknown process" {
+		t.Errorf("flow = %+v", got[0])
+	}
+}
+
+func TestMonitor_Allowed(t *testing.T) {
+	if _, err := New(Config{Whitelist: []string{"example.com"}}, nil); err == nil {
+		t.Error("New accepted a hostname")
+	}
+	m, err := New(Config{
+		Whitelist: []string{"192.0.2.10", "2001:db8::/32"},
+		Allow:     func(ip net.IP) bool { return ip.String() == "8.8.8.8" },
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, want := range map[string]bool{
+		"127.0.0.1":   true,
+		"::1":         true,
+		"192.0.2.10":  true,
+		"192.0.2.11":  false,
+		"2001:db8::5": true,
+		"8.8.8.8":     true,
+		"1.1.1.1":     false,
+	} {
+		if got := m.Allowed(net.ParseIP(ip)); got != want {
+			t.Errorf("Allowed(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+// destroyed 构造本机 local:40000 -> remote:443 的 TCP 连接销毁事件，sent 为发送方向的载荷字节数
+func destroyed(local, remote string, sent uint64, now time.Time) conntrack.Event {
+	orig := conntrack.Tuple{Src: net.ParseIP(local), Dst: net.ParseIP(remote), SrcPort: 40000, DstPort: 443, Proto: 6}
+	reply := conntrack.Tuple{Src: orig.Dst, Dst: orig.Src, SrcPort: 443, DstPort: 40000, Proto: 6}
+	return conntrack.Event{Type: conntrack.EventDestroy, Time: now, Flow: conntrack.Flow{
+		Orig: orig, Reply: reply,
+		// 每个报文 52 字节头部
+		OrigCounters: conntrack.Counters{Packets: 10, Bytes: sent + 520},
+	}}
+}
+
+func TestMonitor_ObserveConntrack(t *testing.T) {
+	fakeProc(t)
+	old := interfaceAddrs
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP("192.0.2.5"), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+	t.Cleanup(func() { interfaceAddrs = old })
+
+	var alerts []Flow
+	m, err := New(Config{Window: time.Minute, Threshold: 1000}, func(f Flow) { alerts = append(alerts, f) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.refreshLocal()
+
+	base := time.Now()
+	s := sock(1, 11, "203.0.113.9", 100)
+	s.Local, s.LocalPort = net.ParseIP("192.0.2.5"), 40000
+	m.observe([]Socket{s}, base)
+	s.BytesAcked = 600
+	m.observe([]Socket{s}, base.Add(5*time.Second))
+
+	// 采样到的连接关闭：只计入最后一次采样之后的 600 字节，并归属采样时的进程
+	m.ObserveConntrack(destroyed("192.0.2.5", "203.0.113.9", 1200, base.Add(6*time.Second)))
+	if len(alerts) != 1 || alerts[0].PID != 1000 || alerts[0].Bytes != 1100 {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+
+	// 未采样到的短连接归属 PID 0；转发流量与非销毁事件忽略
+	m.ObserveConntrack(destroyed("192.0.2.5", "198.51.100.7", 1500, base.Add(7*time.Second)))
+	m.ObserveConntrack(destroyed("172.17.0.2", "198.51.100.7", 1<<20, base.Add(7*time.Second)))
+	ev := destroyed("192.0.2.5", "198.51.100.8", 1<<20, base.Add(7*time.Second))
+	ev.Type = conntrack.EventNew
+	m.ObserveConntrack(ev)
+	if len(alerts) != 2 || alerts[1].PID != 0 || alerts[1].Bytes != 1500 || alerts[1].Remote.String() != "198.51.100.7" {
+		t.Fatalf("alerts = %+v", alerts)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[uint64]string{
+		512:              "512 B",
+		1536:             "1.5 KB",
+		150 << 20:        "150.0 MB",
+		3 << 30:          "3.0 GB",
+		DefaultThreshold: "100.0 MB",
+		5 << 50:          "5.0 PB",
+		(5 << 50) * 1024: "5120.0 PB",
+	} {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
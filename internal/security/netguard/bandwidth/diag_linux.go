@@ -0,0 +1,135 @@
+//go:build linux
+
+package bandwidth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// sock_diag 常量 (include/uapi/linux/sock_diag.h, inet_diag.h)
+const (
+	netlinkSockDiag   = 4   // NETLINK_SOCK_DIAG
+	sockDiagByFamily  = 20  // SOCK_DIAG_BY_FAMILY
+	inetDiagInfo      = 2   // INET_DIAG_INFO
+	sizeofDiagReqV2   = 56  // struct inet_diag_req_v2
+	sizeofDiagMsg     = 72  // struct inet_diag_msg
+	tcpInfoBytesAcked = 120 // tcp_info.tcpi_bytes_acked 偏移 (Linux 4.1+)
+)
+
+// connectedStates 统计的 TCP 状态: ESTABLISHED 至 CLOSING 中除 TIME_WAIT、CLOSE、LISTEN 外的状态
+const connectedStates = 1<<1 | 1<<2 | 1<<3 | 1<<4 | 1<<5 | 1<<8 | 1<<9 | 1<<11
+
+// readSockets 通过 sock_diag 读取 IPv4 与 IPv6 TCP 连接
+func readSockets() ([]Socket, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkSockDiag)
+	if err != nil {
+		return nil, fmt.Errorf("bandwidth: sock_diag: %w", err)
+	}
+	defer syscall.Close(fd)
+
+	var out []Socket
+	for i, family := range []uint8{syscall.AF_INET, syscall.AF_INET6} {
+		socks, err := dumpFamily(fd, family, uint32(i+1))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, socks...)
+	}
+	return out, nil
+}
+
+// dumpFamily 发送一次 dump 请求并读取全部应答
+func dumpFamily(fd int, family uint8, seq uint32) ([]Socket, error) {
+	req := make([]byte, syscall.NLMSG_HDRLEN+sizeofDiagReqV2)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:6], sockDiagByFamily)
+	binary.NativeEndian.PutUint16(req[6:8], syscall.NLM_F_REQUEST|syscall.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(req[8:12], seq)
+	body := req[syscall.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = syscall.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)
+	binary.NativeEndian.PutUint32(body[4:8], connectedStates)
+
+	if err := syscall.Sendto(fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("bandwidth: sock_diag request: %w", err)
+	}
+
+	var out []Socket
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("bandwidth: sock_diag receive: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("bandwidth: sock_diag: %w", err)
+		}
+		for _, msg := range msgs {
+			if msg.Header.Seq != seq {
+				continue
+			}
+			switch msg.Header.Type {
+			case syscall.NLMSG_DONE:
+				return out, nil
+			case syscall.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(msg.Data)); errno != 0 {
+						return nil, fmt.Errorf("bandwidth: sock_diag: %w", syscall.Errno(-errno))
+					}
+				}
+				return out, nil
+			case sockDiagByFamily:
+				if s, ok := parseDiagMsg(msg.Data); ok {
+					out = append(out, s)
+				}
+			}
+		}
+	}
+}
+
+// parseDiagMsg 解析 struct inet_diag_msg 及其后的 INET_DIAG_INFO (struct tcp_info)
+func parseDiagMsg(b []byte) (Socket, bool) {
+	if len(b) < sizeofDiagMsg {
+		return Socket{}, false
+	}
+	s := Socket{
+		LocalPort:  int(binary.BigEndian.Uint16(b[4:6])),
+		RemotePort: int(binary.BigEndian.Uint16(b[6:8])),
+		Cookie:     uint64(binary.NativeEndian.Uint32(b[44:48])) | uint64(binary.NativeEndian.Uint32(b[48:52]))<<32,
+		UID:        binary.NativeEndian.Uint32(b[64:68]),
+		Inode:      uint64(binary.NativeEndian.Uint32(b[68:72])),
+	}
+	if b[0] == syscall.AF_INET {
+		s.Local, s.Remote = net.IP(append([]byte(nil), b[8:12]...)), net.IP(append([]byte(nil), b[24:28]...))
+	} else {
+		s.Local, s.Remote = net.IP(append([]byte(nil), b[8:24]...)), net.IP(append([]byte(nil), b[24:40]...))
+		// IPv4 映射地址按 IPv4 统计
+		if ip4 := s.Remote.To4(); ip4 != nil {
+			s.Remote = ip4
+		}
+	}
+
+	// 属性: struct rtattr { u16 len; u16 type; } + 数据，按 4 字节对齐
+	for attrs := b[sizeofDiagMsg:]; len(attrs) >= syscall.SizeofRtAttr; {
+		l := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		typ := binary.NativeEndian.Uint16(attrs[2:4])
+		if l < syscall.SizeofRtAttr || l > len(attrs) {
+			break
+		}
+		if typ == inetDiagInfo && l >= syscall.SizeofRtAttr+tcpInfoBytesAcked+8 {
+			info := attrs[syscall.SizeofRtAttr:l]
+			s.BytesAcked = binary.NativeEndian.Uint64(info[tcpInfoBytesAcked:])
+		}
+		l = (l + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if l > len(attrs) {
+			break
+		}
+		attrs = attrs[l:]
+	}
+	return s, true
+}
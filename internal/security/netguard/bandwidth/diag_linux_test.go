@@ -0,0 +1,66 @@
+//go:build linux
+
+package bandwidth
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestReadSockets 在回环地址上发送数据，检查 sock_diag 读到的已确认字节数
+func TestReadSockets(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		io.Copy(io.Discard, c)
+	}()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	const size = 1 << 20
+	if _, err := c.Write(make([]byte, size)); err != nil {
+		t.Fatal(err)
+	}
+	local := c.LocalAddr().(*net.TCPAddr)
+	remote := ln.Addr().(*net.TCPAddr)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		socks, err := readSockets()
+		if err != nil {
+			t.Skipf("sock_diag unavailable: %v", err)
+		}
+		var found *Socket
+		for i, s := range socks {
+			if s.LocalPort == local.Port && s.RemotePort == remote.Port {
+				found = &socks[i]
+			}
+		}
+		if found == nil {
+			t.Fatalf("connection %v -> %v not found in %d sockets", local, remote, len(socks))
+		}
+		if found.BytesAcked >= size {
+			if found.Inode == 0 || found.Cookie == 0 || !found.Remote.Equal(remote.IP) {
+				t.Errorf("socket = %+v", *found)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("BytesAcked = %d, want >= %d", found.BytesAcked, size)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
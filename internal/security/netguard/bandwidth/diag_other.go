@@ -0,0 +1,10 @@
+//go:build !linux
+
+package bandwidth
+
+import "errors"
+
+// readSockets 仅支持 Linux
+func readSockets() ([]Socket, error) {
+	return nil, errors.New("bandwidth: sock_diag is only supported on linux")
+}
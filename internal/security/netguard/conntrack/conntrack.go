@@ -0,0 +1,368 @@
+// Package conntrack 通过 netlink (ctnetlink) 访问内核连接跟踪表
+// 订阅连接建立 / 更新 / 销毁事件 (无需轮询)，读取连接的字节与报文计数，
+// 并可删除已有连接的跟踪记录: 配合防火墙封禁规则，已建立的连接随后的报文按新连接匹配而被丢弃。
+// 需要加载 nf_conntrack 模块，计数需开启 net.netfilter.nf_conntrack_acct
+package conntrack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ctnetlink 常量 (include/uapi/linux/netfilter/nfnetlink*.h)
+const (
+	netlinkNetfilter = 12 // NETLINK_NETFILTER
+	subsysCTNetlink  = 1  // NFNL_SUBSYS_CTNETLINK
+
+	msgNew    = 0 // IPCTNL_MSG_CT_NEW
+	msgGet    = 1 // IPCTNL_MSG_CT_GET
+	msgDelete = 2 // IPCTNL_MSG_CT_DELETE
+
+	// 组播组 (旧式位掩码)
+	groupNew     = 1 << 0 // NF_NETLINK_CONNTRACK_NEW
+	groupUpdate  = 1 << 1 // NF_NETLINK_CONNTRACK_UPDATE
+	groupDestroy = 1 << 2 // NF_NETLINK_CONNTRACK_DESTROY
+
+	sizeofNfgenmsg = 4
+	nlmsgHdrLen    = 16
+
+	// nlmsghdr 标志
+	nlmFRequest = 0x1
+	nlmFAck     = 0x4
+	nlmFExcl    = 0x200
+	nlmFCreate  = 0x400
+	nlmFDump    = 0x300
+
+	afUnspec = 0
+	afInet   = 2
+	afInet6  = 10
+
+	nlaFNested   = 0x8000
+	nlaTypeMask  = 0x3fff
+	nlaHeaderLen = 4
+)
+
+// 属性类型 (enum ctattr_*)
+const (
+	ctaTupleOrig     = 1
+	ctaTupleReply    = 2
+	ctaStatus        = 3
+	ctaTimeout       = 7
+	ctaMark          = 8
+	ctaCountersOrig  = 9
+	ctaCountersReply = 10
+	ctaID            = 12
+	ctaZone          = 18
+
+	ctaTupleIP    = 1
+	ctaTupleProto = 2
+
+	ctaIPv4Src = 1
+	ctaIPv4Dst = 2
+	ctaIPv6Src = 3
+	ctaIPv6Dst = 4
+
+	ctaProtoNum     = 1
+	ctaProtoSrcPort = 2
+	ctaProtoDstPort = 3
+
+	ctaCountersPackets   = 1
+	ctaCountersBytes     = 2
+	ctaCounters32Packets = 3
+	ctaCounters32Bytes   = 4
+)
+
+// Tuple 连接一个方向的五元组
+type Tuple struct {
+	Src     net.IP
+	Dst     net.IP
+	SrcPort uint16
+	DstPort uint16
+	Proto   uint8 // IPPROTO_TCP (6) / IPPROTO_UDP (17) / ...
+}
+
+// Counters 一个方向的计数 (需开启 nf_conntrack_acct)
+type Counters struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+// Flow 一条连接跟踪记录
+type Flow struct {
+	ID     uint32
+	Family uint8 // AF_INET (2) / AF_INET6 (10)
+	// 发起方向与应答方向，NAT 时两者地址不对称
+	Orig  Tuple
+	Reply Tuple
+	// 发起方向与应答方向的计数，字节数包含 IP 与传输层头部
+	OrigCounters  Counters
+	ReplyCounters Counters
+	Status        uint32
+	Mark          uint32
+	Zone          uint16
+	// 剩余超时 (秒)
+	Timeout uint32
+}
+
+// ProtoName 协议名，如 tcp
+func (f Flow) ProtoName() string {
+	switch f.Orig.Proto {
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 1:
+		return "icmp"
+	case 58:
+		return "icmpv6"
+	case 132:
+		return "sctp"
+	}
+	return strconv.Itoa(int(f.Orig.Proto))
+}
+
+// String 如 "tcp 10.0.0.5:40000 -> 1.2.3.4:443"
+func (f Flow) String() string {
+	return fmt.Sprintf("%s %s -> %s", f.ProtoName(),
+		net.JoinHostPort(f.Orig.Src.String(), strconv.Itoa(int(f.Orig.SrcPort))),
+		net.JoinHostPort(f.Orig.Dst.String(), strconv.Itoa(int(f.Orig.DstPort))))
+}
+
+// Involves 连接的任一端 (含 NAT 转换后的地址) 是否为 ip
+func (f Flow) Involves(ip net.IP) bool {
+	return f.Orig.Src.Equal(ip) || f.Orig.Dst.Equal(ip) || f.Reply.Src.Equal(ip) || f.Reply.Dst.Equal(ip)
+}
+
+// EventType 连接事件类型
+type EventType int
+
+const (
+	EventNew     EventType = iota // 连接建立
+	EventUpdate                   // 状态变化 (如 TCP 建立完成、进入关闭)
+	EventDestroy                  // 连接销毁，携带最终计数
+)
+
+// String 事件类型名称
+func (t EventType) String() string {
+	switch t {
+	case EventNew:
+		return "new"
+	case EventUpdate:
+		return "update"
+	case EventDestroy:
+		return "destroy"
+	}
+	return "unknown"
+}
+
+// Event 连接事件
+type Event struct {
+	Type EventType
+	Flow Flow
+	Time time.Time
+}
+
+// Handler 连接事件回调
+type Handler func(ev Event)
+
+var errShortMessage = errors.New("conntrack: short message")
+
+// parseEvent 解析 ctnetlink 消息，msgType 与 flags 取自 nlmsghdr
+func parseEvent(msgType, flags uint16, data []byte) (Event, bool, error) {
+	if msgType>>8 != subsysCTNetlink {
+		return Event{}, false, nil
+	}
+	var typ EventType
+	switch msgType & 0xff {
+	case msgNew:
+		typ = EventUpdate
+		if flags&(nlmFCreate|nlmFExcl) != 0 {
+			typ = EventNew
+		}
+	case msgDelete:
+		typ = EventDestroy
+	default:
+		return Event{}, false, nil
+	}
+	f, err := parseFlow(data)
+	if err != nil {
+		return Event{}, false, err
+	}
+	return Event{Type: typ, Flow: f}, true, nil
+}
+
+// parseFlow 解析 nfgenmsg 及其后的连接属性
+func parseFlow(data []byte) (Flow, error) {
+	if len(data) < sizeofNfgenmsg {
+		return Flow{}, errShortMessage
+	}
+	f := Flow{Family: data[0]}
+	attrs, err := parseAttrs(data[sizeofNfgenmsg:])
+	if err != nil {
+		return Flow{}, err
+	}
+	if b, ok := attrs[ctaTupleOrig]; ok {
+		if f.Orig, err = parseTuple(b); err != nil {
+			return Flow{}, err
+		}
+	}
+	if b, ok := attrs[ctaTupleReply]; ok {
+		if f.Reply, err = parseTuple(b); err != nil {
+			return Flow{}, err
+		}
+	}
+	if b, ok := attrs[ctaCountersOrig]; ok {
+		f.OrigCounters = parseCounters(b)
+	}
+	if b, ok := attrs[ctaCountersReply]; ok {
+		f.ReplyCounters = parseCounters(b)
+	}
+	f.Status = be32(attrs[ctaStatus])
+	f.Mark = be32(attrs[ctaMark])
+	f.ID = be32(attrs[ctaID])
+	f.Timeout = be32(attrs[ctaTimeout])
+	if b := attrs[ctaZone]; len(b) >= 2 {
+		f.Zone = binary.BigEndian.Uint16(b)
+	}
+	return f, nil
+}
+
+func parseTuple(b []byte) (Tuple, error) {
+	var t Tuple
+	attrs, err := parseAttrs(b)
+	if err != nil {
+		return t, err
+	}
+	if ip, err := parseAttrs(attrs[ctaTupleIP]); err == nil {
+		for typ, v := range ip {
+			switch typ {
+			case ctaIPv4Src, ctaIPv6Src:
+				t.Src = net.IP(append([]byte(nil), v...))
+			case ctaIPv4Dst, ctaIPv6Dst:
+				t.Dst = net.IP(append([]byte(nil), v...))
+			}
+		}
+	}
+	if proto, err := parseAttrs(attrs[ctaTupleProto]); err == nil {
+		if v := proto[ctaProtoNum]; len(v) >= 1 {
+			t.Proto = v[0]
+		}
+		if v := proto[ctaProtoSrcPort]; len(v) >= 2 {
+			t.SrcPort = binary.BigEndian.Uint16(v)
+		}
+		if v := proto[ctaProtoDstPort]; len(v) >= 2 {
+			t.DstPort = binary.BigEndian.Uint16(v)
+		}
+	}
+	return t, nil
+}
+
+func parseCounters(b []byte) Counters {
+	var c Counters
+	attrs, err := parseAttrs(b)
+	if err != nil {
+		return c
+	}
+	if v := attrs[ctaCountersPackets]; len(v) >= 8 {
+		c.Packets = binary.BigEndian.Uint64(v)
+	} else {
+		c.Packets = uint64(be32(attrs[ctaCounters32Packets]))
+	}
+	if v := attrs[ctaCountersBytes]; len(v) >= 8 {
+		c.Bytes = binary.BigEndian.Uint64(v)
+	} else {
+		c.Bytes = uint64(be32(attrs[ctaCounters32Bytes]))
+	}
+	return c
+}
+
+func be32(b []byte) uint32 {
+	if len(b) < 4 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// parseAttrs 解析一层 netlink 属性 (struct nlattr { u16 len; u16 type; })，按 4 字节对齐
+func parseAttrs(b []byte) (map[uint16][]byte, error) {
+	attrs := make(map[uint16][]byte)
+	for len(b) >= nlaHeaderLen {
+		l := int(binary.NativeEndian.Uint16(b[0:2]))
+		typ := binary.NativeEndian.Uint16(b[2:4]) & nlaTypeMask
+		if l < nlaHeaderLen || l > len(b) {
+			return nil, fmt.Errorf("conntrack: bad attribute length %d", l)
+		}
+		attrs[typ] = b[nlaHeaderLen:l]
+		l = align(l)
+		if l > len(b) {
+			break
+		}
+		b = b[l:]
+	}
+	return attrs, nil
+}
+
+func align(n int) int {
+	return (n + 3) &^ 3
+}
+
+// appendAttr 追加一个属性
+func appendAttr(b []byte, typ uint16, data []byte) []byte {
+	l := nlaHeaderLen + len(data)
+	b = binary.NativeEndian.AppendUint16(b, uint16(l))
+	b = binary.NativeEndian.AppendUint16(b, typ)
+	b = append(b, data...)
+	return append(b, make([]byte, align(l)-l)...)
+}
+
+// encodeTuple 编码五元组 (CTA_TUPLE_IP + CTA_TUPLE_PROTO)
+func encodeTuple(t Tuple) []byte {
+	var ip []byte
+	if src, dst := t.Src.To4(), t.Dst.To4(); src != nil && dst != nil {
+		ip = appendAttr(ip, ctaIPv4Src, src)
+		ip = appendAttr(ip, ctaIPv4Dst, dst)
+	} else {
+		ip = appendAttr(ip, ctaIPv6Src, t.Src.To16())
+		ip = appendAttr(ip, ctaIPv6Dst, t.Dst.To16())
+	}
+	var proto []byte
+	proto = appendAttr(proto, ctaProtoNum, []byte{t.Proto})
+	proto = appendAttr(proto, ctaProtoSrcPort, binary.BigEndian.AppendUint16(nil, t.SrcPort))
+	proto = appendAttr(proto, ctaProtoDstPort, binary.BigEndian.AppendUint16(nil, t.DstPort))
+
+	var out []byte
+	out = appendAttr(out, ctaTupleIP|nlaFNested, ip)
+	return appendAttr(out, ctaTupleProto|nlaFNested, proto)
+}
+
+// request 构造 ctnetlink 请求: nlmsghdr + nfgenmsg + 属性
+func request(msg uint16, flags uint16, seq uint32, family uint8, attrs []byte) []byte {
+	b := make([]byte, nlmsgHdrLen+sizeofNfgenmsg, nlmsgHdrLen+sizeofNfgenmsg+len(attrs))
+	b = append(b, attrs...)
+	binary.NativeEndian.PutUint32(b[0:4], uint32(len(b)))
+	binary.NativeEndian.PutUint16(b[4:6], subsysCTNetlink<<8|msg)
+	binary.NativeEndian.PutUint16(b[6:8], flags)
+	binary.NativeEndian.PutUint32(b[8:12], seq)
+	b[nlmsgHdrLen] = family // nfgen_family，version 与 res_id 为 0
+	return b
+}
+
+// deleteRequest 按发起方向五元组删除一条连接
+func deleteRequest(f Flow, seq uint32) []byte {
+	attrs := appendAttr(nil, ctaTupleOrig|nlaFNested, encodeTuple(f.Orig))
+	if f.Zone != 0 {
+		attrs = appendAttr(attrs, ctaZone, binary.BigEndian.AppendUint16(nil, f.Zone))
+	}
+	family := f.Family
+	if family == 0 {
+		family = afInet6
+		if f.Orig.Src.To4() != nil {
+			family = afInet
+		}
+	}
+	return request(msgDelete, nlmFRequest|nlmFAck, seq, family, attrs)
+}
@@ -0,0 +1,105 @@
+package conntrack
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// flowMessage 构造内核格式的连接消息 (nfgenmsg + 属性)
+func flowMessage(family uint8, orig, reply Tuple, sent, recv uint64) []byte {
+	counters := func(bytes uint64) []byte {
+		var b []byte
+		b = appendAttr(b, ctaCountersPackets, binary.BigEndian.AppendUint64(nil, bytes/1000+1))
+		return appendAttr(b, ctaCountersBytes, binary.BigEndian.AppendUint64(nil, bytes))
+	}
+	msg := []byte{family, 0, 0, 0}
+	msg = appendAttr(msg, ctaTupleOrig|nlaFNested, encodeTuple(orig))
+	msg = appendAttr(msg, ctaTupleReply|nlaFNested, encodeTuple(reply))
+	msg = appendAttr(msg, ctaCountersOrig|nlaFNested, counters(sent))
+	msg = appendAttr(msg, ctaCountersReply|nlaFNested, counters(recv))
+	msg = appendAttr(msg, ctaStatus, binary.BigEndian.AppendUint32(nil, 0xe))
+	msg = appendAttr(msg, ctaID, binary.BigEndian.AppendUint32(nil, 42))
+	return msg
+}
+
+func TestParseEvent(t *testing.T) {
+	orig := Tuple{Src: net.ParseIP("10.0.0.5").To4(), Dst: net.ParseIP("203.0.113.9").To4(), SrcPort: 40000, DstPort: 443, Proto: 6}
+	// SNAT: 应答方向的目的地址为转换后的地址
+	reply := Tuple{Src: orig.Dst, Dst: net.ParseIP("198.51.100.1").To4(), SrcPort: 443, DstPort: 61000, Proto: 6}
+	data := flowMessage(afInet, orig, reply, 1500, 90000)
+
+	typ := uint16(subsysCTNetlink<<8 | msgNew)
+	ev, ok, err := parseEvent(typ, nlmFCreate|nlmFExcl, data)
+	if err != nil || !ok {
+		t.Fatalf("parseEvent: %v, %v", ok, err)
+	}
+	f := ev.Flow
+	if ev.Type != EventNew || f.ID != 42 || f.Status != 0xe || f.Family != afInet {
+		t.Errorf("event = %+v", ev)
+	}
+	if f.String() != "tcp 10.0.0.5:40000 -> 203.0.113.9:443" {
+		t.Errorf("String() = %q", f.String())
+	}
+	if f.OrigCounters.Bytes != 1500 || f.ReplyCounters.Bytes != 90000 || f.ReplyCounters.Packets != 91 {
+		t.Errorf("counters = %+v %+v", f.OrigCounters, f.ReplyCounters)
+	}
+	if !f.Reply.Dst.Equal(net.ParseIP("198.51.100.1")) || f.Reply.DstPort != 61000 {
+		t.Errorf("reply = %+v", f.Reply)
+	}
+	for ip, want := range map[string]bool{"203.0.113.9": true, "198.51.100.1": true, "192.0.2.1": false} {
+		if f.Involves(net.ParseIP(ip)) != want {
+			t.Errorf("Involves(%s) = %v", ip, !want)
+		}
+	}
+
+	// 无 CREATE 标志的 NEW 为更新，DELETE 为销毁，其他子系统忽略
+	if ev, _, _ := parseEvent(typ, 0, data); ev.Type != EventUpdate {
+		t.Errorf("update type = %v", ev.Type)
+	}
+	if ev, _, _ := parseEvent(subsysCTNetlink<<8|msgDelete, 0, data); ev.Type != EventDestroy {
+		t.Errorf("destroy type = %v", ev.Type)
+	}
+	if _, ok, _ := parseEvent(2<<8|msgNew, 0, data); ok {
+		t.Error("parsed message of another subsystem")
+	}
+	if _, _, err := parseEvent(typ, 0, data[:len(data)-3]); err == nil {
+		t.Error("parsed truncated message")
+	}
+}
+
+func TestDeleteRequest(t *testing.T) {
+	f := Flow{Orig: Tuple{
+		Src: net.ParseIP("2001:db8::1"), Dst: net.ParseIP("2001:db8::2"),
+		SrcPort: 1234, DstPort: 22, Proto: 6,
+	}, Zone: 3}
+	req := deleteRequest(f, 7)
+	if int(binary.NativeEndian.Uint32(req[0:4])) != len(req) {
+		t.Fatalf("nlmsg_len = %d, len = %d", binary.NativeEndian.Uint32(req[0:4]), len(req))
+	}
+	if typ := binary.NativeEndian.Uint16(req[4:6]); typ != subsysCTNetlink<<8|msgDelete {
+		t.Errorf("type = %#x", typ)
+	}
+	if binary.NativeEndian.Uint32(req[8:12]) != 7 || req[nlmsgHdrLen] != afInet6 {
+		t.Errorf("seq/family = %d/%d", binary.NativeEndian.Uint32(req[8:12]), req[nlmsgHdrLen])
+	}
+
+	// 请求体按内核格式解析回原五元组
+	got, err := parseFlow(req[nlmsgHdrLen:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Orig.Src.Equal(f.Orig.Src) || !got.Orig.Dst.Equal(f.Orig.Dst) ||
+		got.Orig.SrcPort != 1234 || got.Orig.DstPort != 22 || got.Orig.Proto != 6 || got.Zone != 3 {
+		t.Errorf("round trip = %+v", got)
+	}
+}
+
+func TestMonitorGroups(t *testing.T) {
+	if g := NewMonitor(MonitorConfig{}, nil).groups(); g != groupNew|groupDestroy {
+		t.Errorf("default groups = %#x", g)
+	}
+	if g := NewMonitor(MonitorConfig{Events: []EventType{EventUpdate}}, nil).groups(); g != groupUpdate {
+		t.Errorf("update groups = %#x", g)
+	}
+}
@@ -0,0 +1,126 @@
+package conntrack
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// DefaultReadBuffer 默认事件接收缓冲区
+const DefaultReadBuffer = 4 << 20
+
+// errOverrun 接收缓冲区溢出，部分事件已丢失
+var errOverrun = errors.New("conntrack: event buffer overrun")
+
+// MonitorConfig 事件订阅配置
+type MonitorConfig struct {
+	// 订阅的事件类型，为空时订阅建立与销毁 (更新事件数量较多)
+	Events []EventType
+	// 内核接收缓冲区大小，<=0 时使用 DefaultReadBuffer
+	ReadBuffer int
+}
+
+// Monitor 连接事件订阅
+type Monitor struct {
+	cfg     MonitorConfig
+	handler Handler
+
+	// 因缓冲区溢出丢失事件的次数
+	overruns atomic.Int64
+
+	sock   *eventSocket
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewMonitor 创建事件订阅
+func NewMonitor(cfg MonitorConfig, handler Handler) *Monitor {
+	if len(cfg.Events) == 0 {
+		cfg.Events = []EventType{EventNew, EventDestroy}
+	}
+	if cfg.ReadBuffer <= 0 {
+		cfg.ReadBuffer = DefaultReadBuffer
+	}
+	return &Monitor{cfg: cfg, handler: handler}
+}
+
+// groups 订阅的组播组
+func (m *Monitor) groups() uint32 {
+	var g uint32
+	for _, t := range m.cfg.Events {
+		switch t {
+		case EventNew:
+			g |= groupNew
+		case EventUpdate:
+			g |= groupUpdate
+		case EventDestroy:
+			g |= groupDestroy
+		}
+	}
+	return g
+}
+
+// Start 订阅事件并在后台回调 (需要 CAP_NET_ADMIN 与 nf_conntrack 模块)
+func (m *Monitor) Start() error {
+	sock, err := subscribe(m.groups(), m.cfg.ReadBuffer)
+	if err != nil {
+		return err
+	}
+	m.sock = sock
+	m.stopCh = make(chan struct{})
+	m.wg.Add(1)
+	go m.loop()
+	return nil
+}
+
+// Stop 停止订阅
+func (m *Monitor) Stop() {
+	if m.stopCh == nil {
+		return
+	}
+	close(m.stopCh)
+	m.wg.Wait()
+	m.sock.close()
+	m.stopCh = nil
+}
+
+// Overruns 因缓冲区溢出丢失事件的次数
+func (m *Monitor) Overruns() int64 {
+	return m.overruns.Load()
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	var lastWarn time.Time
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		default:
+		}
+		events, err := m.sock.receive()
+		if err != nil {
+			if errors.Is(err, errOverrun) {
+				m.overruns.Add(1)
+				// 溢出可能持续发生，限制日志频率
+				if time.Since(lastWarn) >= time.Minute {
+					logger.Warn("连接跟踪事件缓冲区溢出，部分事件丢失", "overruns", m.overruns.Load())
+					lastWarn = time.Now()
+				}
+				continue
+			}
+			logger.Error("读取连接跟踪事件失败", "error", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if m.handler == nil {
+			continue
+		}
+		for _, ev := range events {
+			m.handler(ev)
+		}
+	}
+}
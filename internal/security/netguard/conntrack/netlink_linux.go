@@ -0,0 +1,207 @@
+//go:build linux
+
+package conntrack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// acctSysctl 连接计数开关
+var acctSysctl = "/proc/sys/net/netfilter/nf_conntrack_acct"
+
+// EnableAccounting 开启连接字节与报文计数 (只对之后建立的连接生效)
+func EnableAccounting() error {
+	return os.WriteFile(acctSysctl, []byte("1\n"), 0644)
+}
+
+// Conn ctnetlink 请求连接
+type Conn struct {
+	mu  sync.Mutex
+	fd  int
+	seq uint32
+}
+
+// Dial 打开 ctnetlink 连接 (需要 CAP_NET_ADMIN)
+func Dial() (*Conn, error) {
+	fd, err := openNetlink(0)
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{fd: fd}, nil
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error {
+	return syscall.Close(c.fd)
+}
+
+// Dump 读取全部连接跟踪记录
+func (c *Conn) Dump() ([]Flow, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	req := request(msgGet, nlmFRequest|nlmFDump, c.seq, afUnspec, nil)
+	var flows []Flow
+	err := c.roundTrip(req, func(m syscall.NetlinkMessage) error {
+		if m.Header.Type>>8 != subsysCTNetlink {
+			return nil
+		}
+		f, err := parseFlow(m.Data)
+		if err != nil {
+			return err
+		}
+		flows = append(flows, f)
+		return nil
+	})
+	return flows, err
+}
+
+// Delete 删除一条连接，连接已不存在时返回 nil
+func (c *Conn) Delete(f Flow) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	err := c.roundTrip(deleteRequest(f, c.seq), nil)
+	if errors.Is(err, syscall.ENOENT) {
+		return nil
+	}
+	return err
+}
+
+// DeleteIP 删除与 ip 相关的全部连接，返回删除的数量
+func (c *Conn) DeleteIP(ip net.IP) (int, error) {
+	flows, err := c.Dump()
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, f := range flows {
+		if !f.Involves(ip) {
+			continue
+		}
+		if err := c.Delete(f); err != nil {
+			return n, fmt.Errorf("conntrack: delete %s: %w", f, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// roundTrip 发送请求并读取应答直到 NLMSG_DONE 或 ACK
+func (c *Conn) roundTrip(req []byte, handle func(syscall.NetlinkMessage) error) error {
+	if err := syscall.Sendto(c.fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return fmt.Errorf("conntrack: send: %w", err)
+	}
+	buf := make([]byte, 64<<10)
+	for {
+		n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return fmt.Errorf("conntrack: receive: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return fmt.Errorf("conntrack: %w", err)
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != c.seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) < 4 {
+					return errShortMessage
+				}
+				// errno 为 0 时为 ACK
+				if errno := int32(binary.NativeEndian.Uint32(m.Data)); errno != 0 {
+					return syscall.Errno(-errno)
+				}
+				return nil
+			default:
+				if handle != nil {
+					if err := handle(m); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+}
+
+// openNetlink 打开 NETLINK_NETFILTER socket 并加入组播组
+func openNetlink(groups uint32) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkNetfilter)
+	if err != nil {
+		return -1, fmt.Errorf("conntrack: netlink: %w", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: groups}); err != nil {
+		syscall.Close(fd)
+		return -1, fmt.Errorf("conntrack: bind: %w", err)
+	}
+	return fd, nil
+}
+
+// eventSocket 连接事件订阅
+type eventSocket struct {
+	fd  int
+	buf []byte
+}
+
+// subscribe 订阅连接事件，bufSize 为内核接收缓冲区大小
+func subscribe(groups uint32, bufSize int) (*eventSocket, error) {
+	fd, err := openNetlink(groups)
+	if err != nil {
+		return nil, err
+	}
+	// 事件突发时缓冲区不足会丢失事件 (ENOBUFS)；root 可超出 rmem_max
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUFFORCE, bufSize); err != nil {
+		syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, bufSize)
+	}
+	tv := syscall.NsecToTimeval(int64(time.Second))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("conntrack: set timeout: %w", err)
+	}
+	return &eventSocket{fd: fd, buf: make([]byte, 256<<10)}, nil
+}
+
+// receive 读取一批事件，超时返回空
+func (s *eventSocket) receive() ([]Event, error) {
+	n, _, err := syscall.Recvfrom(s.fd, s.buf, 0)
+	if err != nil {
+		if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR) {
+			return nil, nil
+		}
+		if errors.Is(err, syscall.ENOBUFS) {
+			return nil, errOverrun
+		}
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(s.buf[:n])
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var out []Event
+	for _, m := range msgs {
+		ev, ok, err := parseEvent(m.Header.Type, m.Header.Flags, m.Data)
+		if err != nil || !ok {
+			continue
+		}
+		ev.Time = now
+		out = append(out, ev)
+	}
+	return out, nil
+}
+
+func (s *eventSocket) close() error {
+	return syscall.Close(s.fd)
+}
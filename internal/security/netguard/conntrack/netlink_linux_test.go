@@ -0,0 +1,24 @@
+//go:build linux
+
+package conntrack
+
+import "testing"
+
+// TestDump 读取本机连接跟踪表，未加载 nf_conntrack 或权限不足时跳过
+func TestDump(t *testing.T) {
+	c, err := Dial()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer c.Close()
+	flows, err := c.Dump()
+	if err != nil {
+		t.Skipf("conntrack unavailable: %v", err)
+	}
+	for _, f := range flows {
+		if f.Orig.Src == nil || f.Orig.Dst == nil {
+			t.Errorf("flow without addresses: %+v", f)
+		}
+	}
+	t.Logf("%d flows", len(flows))
+}
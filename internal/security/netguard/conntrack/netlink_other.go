@@ -0,0 +1,45 @@
+//go:build !linux
+
+package conntrack
+
+import (
+	"errors"
+	"net"
+)
+
+var errUnsupported = errors.New("conntrack: only supported on linux")
+
+// EnableAccounting 仅支持 Linux
+func EnableAccounting() error {
+	return errUnsupported
+}
+
+// Conn ctnetlink 请求连接
+type Conn struct{}
+
+// Dial 仅支持 Linux
+func Dial() (*Conn, error) {
+	return nil, errUnsupported
+}
+
+// Close 关闭连接
+func (c *Conn) Close() error { return nil }
+
+// Dump 仅支持 Linux
+func (c *Conn) Dump() ([]Flow, error) { return nil, errUnsupported }
+
+// Delete 仅支持 Linux
+func (c *Conn) Delete(Flow) error { return errUnsupported }
+
+// DeleteIP 仅支持 Linux
+func (c *Conn) DeleteIP(net.IP) (int, error) { return 0, errUnsupported }
+
+type eventSocket struct{}
+
+func subscribe(uint32, int) (*eventSocket, error) {
+	return nil, errUnsupported
+}
+
+func (s *eventSocket) receive() ([]Event, error) { return nil, errUnsupported }
+
+func (s *eventSocket) close() error { return nil }
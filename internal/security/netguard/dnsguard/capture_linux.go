@@ -0,0 +1,84 @@
+//go:build linux
+
+package dnsguard
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// readTimeout 读取超时，用于及时响应停止
+const readTimeout = time.Second
+
+// capture AF_PACKET 抓包 socket
+// SOCK_DGRAM 去掉链路层头，报文从 IP 头开始
+type capture struct {
+	fd int
+}
+
+// queryFilter 只接收目的端口为 53 的 UDP 报文 (IPv4 非分片报文与无扩展头的 IPv6)
+var queryFilter = []syscall.SockFilter{
+	/* 0 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 0),
+	/* 1 */ *syscall.LsfStmt(syscall.BPF_ALU|syscall.BPF_RSH|syscall.BPF_K, 4),
+	/* 2 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 4, 0, 7),
+	// IPv4: 协议、分片偏移、UDP 目的端口
+	/* 3 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 9),
+	/* 4 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, syscall.IPPROTO_UDP, 0, 11),
+	/* 5 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_H|syscall.BPF_ABS, 6),
+	/* 6 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JSET|syscall.BPF_K, 0x1fff, 9, 0),
+	/* 7 */ *syscall.LsfStmt(syscall.BPF_LDX|syscall.BPF_B|syscall.BPF_MSH, 0),
+	/* 8 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_H|syscall.BPF_IND, 2),
+	/* 9 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 53, 5, 6),
+	// IPv6: 下一个头、UDP 目的端口
+	/* 10 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 6, 0, 5),
+	/* 11 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 6),
+	/* 12 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, syscall.IPPROTO_UDP, 0, 3),
+	/* 13 */ *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_H|syscall.BPF_ABS, 42),
+	/* 14 */ *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 53, 0, 1),
+	/* 15 */ *syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, 65535),
+	/* 16 */ *syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, 0),
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// openCapture 打开抓包 socket，需要 CAP_NET_RAW
+func openCapture() (*capture, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, int(htons(syscall.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("dnsguard: open packet socket: %w", err)
+	}
+	if err := syscall.AttachLsf(fd, queryFilter); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("dnsguard: attach filter: %w", err)
+	}
+	tv := syscall.NsecToTimeval(int64(readTimeout))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("dnsguard: set timeout: %w", err)
+	}
+	return &capture{fd: fd}, nil
+}
+
+// read 读取一个报文，非本机发出的报文返回长度 0
+// 附加过滤器之前进入队列的报文可能不是 DNS 查询，由 parsePacket 丢弃
+func (c *capture) read(buf []byte) (int, error) {
+	n, from, err := syscall.Recvfrom(c.fd, buf, 0)
+	if err != nil {
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			return 0, errTimeout
+		}
+		return 0, err
+	}
+	// 只检查本机发出的查询；回环接口上的报文会以发出、接收各出现一次
+	if ll, ok := from.(*syscall.SockaddrLinklayer); ok && ll.Pkttype != syscall.PACKET_OUTGOING {
+		return 0, nil
+	}
+	return n, nil
+}
+
+func (c *capture) close() {
+	syscall.Close(c.fd)
+}
@@ -0,0 +1,38 @@
+//go:build linux
+
+package dnsguard
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCapture_Loopback(t *testing.T) {
+	c, err := openCapture()
+	if err != nil {
+		t.Skipf("AF_PACKET 不可用 (需要 CAP_NET_RAW): %v", err)
+	}
+	defer c.close()
+
+	// 回环上没有 DNS 服务也会发出报文
+	conn, err := net.Dial("udp", "127.0.0.1:53")
+	if err != nil {
+		t.Skipf("无法创建 UDP socket: %v", err)
+	}
+	defer conn.Close()
+	conn.Write(dnsQuery("probe.dnsguard.test", 1))
+
+	buf := make([]byte, 65536)
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		n, err := c.read(buf)
+		if err != nil || n == 0 {
+			continue
+		}
+		if q, err := parsePacket(buf[:n]); err == nil && q.Name == "probe.dnsguard.test" {
+			return
+		}
+	}
+	t.Fatal("未抓到发往回环的 DNS 查询")
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package dnsguard
+
+import "errors"
+
+type capture struct{}
+
+func openCapture() (*capture, error) {
+	return nil, errors.New("dnsguard: packet capture is only supported on linux")
+}
+
+func (c *capture) read(buf []byte) (int, error) { return 0, errTimeout }
+
+func (c *capture) close() {}
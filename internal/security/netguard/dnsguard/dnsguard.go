@@ -0,0 +1,354 @@
+// Package dnsguard DNS 查询监控与域名黑名单
+// 通过 AF_PACKET 抓取本机发出的 DNS 查询 (UDP 53)，查询命中黑名单 (数据外泄、动态域名等) 时上报；
+// 拦截动作的规则通过 iptables 字符串匹配丢弃查询报文。黑名单与检测策略一样经策略同步下发
+// 只能看到客户端所在网络命名空间发出的查询，DoH / DoT 等加密查询不在检测范围内
+package dnsguard
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/netguard/seen"
+)
+
+// DefaultDeduplication 默认告警去重时间
+const DefaultDeduplication = time.Hour
+
+// Action 命中后的动作
+type Action int
+
+const (
+	ActionAlert Action = 0 // 仅告警
+	ActionBlock Action = 1 // 告警并拦截
+)
+
+// Rule 一条黑名单规则，匹配域名本身及其所有子域名
+type Rule struct {
+	RuleID int64
+	Domain string
+	Action Action
+	Desc   string
+}
+
+// Query 一次命中黑名单 (或发往受限解析服务器) 的 DNS 查询
+type Query struct {
+	Name     string // 查询域名 (小写，不含末尾的点)
+	Type     uint16 // 查询类型，如 1 (A)、16 (TXT)
+	Src      net.IP
+	Resolver net.IP // 目的 DNS 服务器
+	// 命中的黑名单规则，因解析服务器告警时 Domain 为空、Desc 为原因
+	Rule Rule
+	// 拦截规则已在防火墙生效 (抓包先于过滤，报文仍会被看到)
+	Blocked bool
+	Time    time.Time
+}
+
+// Handler 命中黑名单时的回调
+type Handler func(q Query)
+
+// Firewall 拦截查询的防火墙规则管理
+type Firewall interface {
+	// Apply 以给定域名全量替换拦截规则
+	Apply(domains []string) error
+	// Clear 删除全部拦截规则
+	Clear() error
+}
+
+// Config 监控配置
+type Config struct {
+	// 允许执行拦截动作，关闭时拦截规则仅告警
+	Block bool
+	// 同一域名的告警去重时间，<=0 时使用 DefaultDeduplication
+	Deduplication time.Duration
+	// 拦截使用的防火墙，nil 时使用 iptables
+	Firewall Firewall
+	// 检查未命中黑名单的查询的目的解析服务器 (如按 GeoIP 规则)，返回告警原因
+	ResolverCheck func(resolver net.IP) (reason string, alert bool)
+	// 去重记录的持久化存储，nil 时重启后重新告警
+	DedupStore seen.Store
+}
+
+// Guard DNS 查询监控
+type Guard struct {
+	cfg     Config
+	handler Handler
+
+	mu    sync.RWMutex
+	rules map[string]Rule // 规范化域名 -> 规则
+	// 已拦截的域名，规则未变化时不重复下发防火墙规则
+	blocked []string
+
+	dedup *seen.Cache
+
+	capture *capture
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建 DNS 查询监控
+func New(cfg Config, handler Handler) *Guard {
+	if cfg.Deduplication <= 0 {
+		cfg.Deduplication = DefaultDeduplication
+	}
+	if cfg.Firewall == nil {
+		cfg.Firewall = NewIPTables()
+	}
+	return &Guard{
+		cfg:     cfg,
+		handler: handler,
+		rules:   make(map[string]Rule),
+		dedup:   seen.New(seen.Config{TTL: cfg.Deduplication, Store: cfg.DedupStore, Namespace: "dns:"}),
+	}
+}
+
+// SetRules 全量替换黑名单，并同步防火墙拦截规则
+// 同一域名存在多条规则时以拦截规则为准
+func (g *Guard) SetRules(rules []Rule) error {
+	m := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		d := normalize(r.Domain)
+		if d == "" {
+			continue
+		}
+		r.Domain = d
+		if old, ok := m[d]; ok && old.Action == ActionBlock {
+			continue
+		}
+		m[d] = r
+	}
+
+	var blocked []string
+	if g.cfg.Block {
+		for d, r := range m {
+			if r.Action == ActionBlock {
+				blocked = append(blocked, d)
+			}
+		}
+	}
+
+	g.mu.Lock()
+	g.rules = m
+	changed := !sameSet(g.blocked, blocked)
+	g.blocked = blocked
+	g.mu.Unlock()
+
+	logger.Info("DNS 黑名单已更新", "rules", len(m), "blocked", len(blocked))
+	if !changed {
+		return nil
+	}
+	if len(blocked) == 0 {
+		return g.cfg.Firewall.Clear()
+	}
+	return g.cfg.Firewall.Apply(blocked)
+}
+
+// Match 查找域名命中的规则，依次匹配域名本身与各级父域名
+func (g *Guard) Match(name string) (Rule, bool) {
+	name = normalize(name)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for name != "" {
+		if r, ok := g.rules[name]; ok {
+			return r, true
+		}
+		_, parent, ok := strings.Cut(name, ".")
+		if !ok {
+			break
+		}
+		name = parent
+	}
+	return Rule{}, false
+}
+
+// Start 开始抓取 DNS 查询
+func (g *Guard) Start() error {
+	c, err := openCapture()
+	if err != nil {
+		return err
+	}
+	g.capture = c
+	g.stopCh = make(chan struct{})
+	g.wg.Add(1)
+	go g.loop()
+	logger.Info("DNS 查询监控已启动", "block", g.cfg.Block)
+	return nil
+}
+
+// Stop 停止抓取并删除拦截规则
+func (g *Guard) Stop() {
+	if g.stopCh != nil {
+		close(g.stopCh)
+		g.wg.Wait()
+		g.capture.close()
+		g.stopCh = nil
+	}
+	g.mu.Lock()
+	blocked := len(g.blocked) > 0
+	g.blocked = nil
+	g.mu.Unlock()
+	if blocked {
+		if err := g.cfg.Firewall.Clear(); err != nil {
+			logger.Warn("删除 DNS 拦截规则失败", "error", err)
+		}
+	}
+}
+
+func (g *Guard) loop() {
+	defer g.wg.Done()
+	buf := make([]byte, 65536)
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		default:
+		}
+		n, err := g.capture.read(buf)
+		if err != nil {
+			if !errors.Is(err, errTimeout) {
+				logger.Warn("读取 DNS 报文失败", "error", err)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		g.Inspect(buf[:n], time.Now())
+	}
+}
+
+// Inspect 检查一个 IP 报文，命中黑名单或解析服务器检查告警且不在去重时间内时回调
+func (g *Guard) Inspect(packet []byte, now time.Time) (Query, bool) {
+	q, err := parsePacket(packet)
+	if err != nil {
+		return Query{}, false
+	}
+	r, ok := g.Match(q.Name)
+	key := q.Name
+	if !ok {
+		if g.cfg.ResolverCheck == nil {
+			return Query{}, false
+		}
+		reason, alert := g.cfg.ResolverCheck(q.Resolver)
+		if !alert {
+			return Query{}, false
+		}
+		// 同一解析服务器只按去重时间上报一次
+		r, key = Rule{Desc: reason}, "resolver:"+q.Resolver.String()
+	}
+	q.Rule = r
+	q.Blocked = r.Action == ActionBlock && g.cfg.Block
+	q.Time = now
+
+	if !g.dedup.Alert(key, now) {
+		return Query{}, false
+	}
+
+	logger.Error("检测到可疑 DNS 查询", "name", q.Name, "type", q.Type, "rule_id", r.RuleID, "resolver", q.Resolver, "blocked", q.Blocked)
+	if g.handler != nil {
+		g.handler(q)
+	}
+	return q, true
+}
+
+var (
+	errNotQuery = errors.New("dnsguard: not a dns query")
+	errTimeout  = errors.New("dnsguard: read timeout")
+)
+
+// parsePacket 解析 IPv4 / IPv6 UDP 报文中的 DNS 查询
+func parsePacket(b []byte) (Query, error) {
+	var q Query
+	var udp []byte
+	if len(b) < 1 {
+		return q, errNotQuery
+	}
+	switch b[0] >> 4 {
+	case 4:
+		ihl := int(b[0]&0x0f) * 4
+		if len(b) < 20 || ihl < 20 || len(b) < ihl || b[9] != 17 || binary.BigEndian.Uint16(b[6:8])&0x1fff != 0 {
+			return q, errNotQuery
+		}
+		q.Src, q.Resolver = net.IP(b[12:16]), net.IP(b[16:20])
+		udp = b[ihl:]
+	case 6:
+		// 不处理扩展头
+		if len(b) < 40 || b[6] != 17 {
+			return q, errNotQuery
+		}
+		q.Src, q.Resolver = net.IP(b[8:24]), net.IP(b[24:40])
+		udp = b[40:]
+	default:
+		return q, errNotQuery
+	}
+	if len(udp) < 8 || binary.BigEndian.Uint16(udp[2:4]) != 53 {
+		return q, errNotQuery
+	}
+	name, qtype, err := parseQuestion(udp[8:])
+	if err != nil {
+		return q, err
+	}
+	q.Name, q.Type = name, qtype
+	q.Src = append(net.IP(nil), q.Src...)
+	q.Resolver = append(net.IP(nil), q.Resolver...)
+	return q, nil
+}
+
+// parseQuestion 解析 DNS 报文的第一个问题
+func parseQuestion(msg []byte) (string, uint16, error) {
+	// 头部 12 字节: id flags qdcount ancount nscount arcount
+	if len(msg) < 12 || msg[2]&0x80 != 0 || binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return "", 0, errNotQuery
+	}
+	var sb strings.Builder
+	off := 12
+	for {
+		if off >= len(msg) {
+			return "", 0, errNotQuery
+		}
+		l := int(msg[off])
+		off++
+		if l == 0 {
+			break
+		}
+		// 问题中不应出现压缩指针
+		if l > 63 || off+l > len(msg) || sb.Len()+l+1 > 253 {
+			return "", 0, errNotQuery
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte('.')
+		}
+		sb.Write(msg[off : off+l])
+		off += l
+	}
+	if off+4 > len(msg) || sb.Len() == 0 {
+		return "", 0, errNotQuery
+	}
+	return strings.ToLower(sb.String()), binary.BigEndian.Uint16(msg[off : off+2]), nil
+}
+
+// normalize 域名规范化: 小写，去掉首尾空白、"*." 前缀与末尾的点
+func normalize(domain string) string {
+	d := strings.ToLower(strings.TrimSpace(domain))
+	d = strings.TrimPrefix(d, "*.")
+	return strings.Trim(d, ".")
+}
+
+// sameSet 两个域名列表是否包含相同元素
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	m := make(map[string]bool, len(a))
+	for _, s := range a {
+		m[s] = true
+	}
+	for _, s := range b {
+		if !m[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package dnsguard
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFirewall 记录下发的拦截规则
+type fakeFirewall struct {
+	applied [][]string
+	cleared int
+}
+
+func (f *fakeFirewall) Apply(domains []string) error {
+	f.applied = append(f.applied, append([]string(nil), domains...))
+	return nil
+}
+
+func (f *fakeFirewall) Clear() error {
+	f.cleared++
+	return nil
+}
+
+// dnsQuery 构造一个 DNS 查询报文
+func dnsQuery(name string, qtype uint16) []byte {
+	msg := []byte{0x12, 0x34, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	msg = append(msg, encodeName(name)...)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	return binary.BigEndian.AppendUint16(msg, 1) // class IN
+}
+
+func udpPacket(dport uint16, payload []byte) []byte {
+	udp := make([]byte, 8)
+	binary.BigEndian.PutUint16(udp[0:2], 40000)
+	binary.BigEndian.PutUint16(udp[2:4], dport)
+	binary.BigEndian.PutUint16(udp[4:6], uint16(8+len(payload)))
+	return append(udp, payload...)
+}
+
+func ipv4Packet(udp []byte) []byte {
+	ip := make([]byte, 20)
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], uint16(20+len(udp)))
+	ip[8] = 64
+	ip[9] = 17
+	copy(ip[12:16], []byte{10, 0, 0, 5})
+	copy(ip[16:20], []byte{223, 5, 5, 5})
+	return append(ip, udp...)
+}
+
+func ipv6Packet(udp []byte) []byte {
+	ip := make([]byte, 40)
+	ip[0] = 0x60
+	binary.BigEndian.PutUint16(ip[4:6], uint16(len(udp)))
+	ip[6] = 17
+	ip[7] = 64
+	ip[23] = 1 // ::1
+	ip[39] = 1
+	return append(ip, udp...)
+}
+
+func TestParsePacket(t *testing.T) {
+	q, err := parsePacket(ipv4Packet(udpPacket(53, dnsQuery("Data.Evil.DuckDNS.org", 16))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Name != "data.evil.duckdns.org" || q.Type != 16 || q.Resolver.String() != "223.5.5.5" || q.Src.String() != "10.0.0.5" {
+		t.Errorf("IPv4 query = %+v", q)
+	}
+
+	q, err = parsePacket(ipv6Packet(udpPacket(53, dnsQuery("example.com", 28))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Name != "example.com" || q.Type != 28 || q.Resolver.String() != "::1" {
+		t.Errorf("IPv6 query = %+v", q)
+	}
+
+	// 非 53 端口、DNS 响应、截断报文
+	resp := dnsQuery("example.com", 1)
+	resp[2] |= 0x80
+	trunc := dnsQuery("example.com", 1)
+	for name, pkt := range map[string][]byte{
+		"port":     ipv4Packet(udpPacket(5353, dnsQuery("example.com", 1))),
+		"response": ipv4Packet(udpPacket(53, resp)),
+		"truncate": ipv4Packet(udpPacket(53, trunc[:len(trunc)-6])),
+		"empty":    nil,
+	} {
+		if _, err := parsePacket(pkt); !errors.Is(err, errNotQuery) {
+			t.Errorf("%s: parsePacket error = %v", name, err)
+		}
+	}
+}
+
+func TestGuard_Match(t *testing.T) {
+	g := New(Config{Firewall: &fakeFirewall{}}, nil)
+	g.SetRules([]Rule{
+		{RuleID: 1, Domain: "duckdns.org"},
+		{RuleID: 2, Domain: "*.ngrok.io.", Action: ActionBlock},
+		{RuleID: 3, Domain: " "},
+	})
+
+	for name, want := range map[string]int64{
+		"duckdns.org":          1,
+		"a.b.DuckDNS.org":      1,
+		"tunnel.ngrok.io":      2,
+		"ngrok.io":             2,
+		"notduckdns.org":       0,
+		"duckdns.org.evil.com": 0,
+		"example.com":          0,
+	} {
+		r, ok := g.Match(name)
+		if want == 0 {
+			if ok {
+				t.Errorf("Match(%q) = %+v, want no match", name, r)
+			}
+			continue
+		}
+		if !ok || r.RuleID != want {
+			t.Errorf("Match(%q) = %+v, %v, want rule %d", name, r, ok, want)
+		}
+	}
+}
+
+func TestGuard_InspectDeduplicates(t *testing.T) {
+	var got []Query
+	g := New(Config{Deduplication: time.Minute, Firewall: &fakeFirewall{}}, func(q Query) { got = append(got, q) })
+	g.SetRules([]Rule{{RuleID: 7, Domain: "dyndns.org"}})
+
+	pkt := ipv4Packet(udpPacket(53, dnsQuery("x.dyndns.org", 1)))
+	now := time.Now()
+	if _, ok := g.Inspect(pkt, now); !ok {
+		t.Fatal("命中黑名单的查询未上报")
+	}
+	if _, ok := g.Inspect(pkt, now.Add(30*time.Second)); ok {
+		t.Error("去重时间内重复上报")
+	}
+	if _, ok := g.Inspect(pkt, now.Add(2*time.Minute)); !ok {
+		t.Error("去重时间后未再次上报")
+	}
+	if _, ok := g.Inspect(ipv4Packet(udpPacket(53, dnsQuery("example.com", 1))), now); ok {
+		t.Error("未命中的查询被上报")
+	}
+	if len(got) != 2 || got[0].Rule.RuleID != 7 || got[0].Blocked {
+		t.Errorf("handler got %+v", got)
+	}
+}
+
+func TestGuard_ResolverCheck(t *testing.T) {
+	var checked []string
+	g := New(Config{Firewall: &fakeFirewall{}, ResolverCheck: func(ip net.IP) (string, bool) {
+		checked = append(checked, ip.String())
+		return "outside CN", ip.String() == "223.5.5.5"
+	}}, nil)
+	g.SetRules([]Rule{{RuleID: 1, Domain: "duckdns.org"}})
+
+	now := time.Now()
+	// 命中黑名单时按规则上报，不检查解析服务器
+	q, ok := g.Inspect(ipv4Packet(udpPacket(53, dnsQuery("a.duckdns.org", 1))), now)
+	if !ok || q.Rule.RuleID != 1 || len(checked) != 0 {
+		t.Fatalf("blocklist query = %+v, %v, checked %v", q, ok, checked)
+	}
+	q, ok = g.Inspect(ipv4Packet(udpPacket(53, dnsQuery("example.com", 1))), now)
+	if !ok || q.Rule.Domain != "" || q.Rule.Desc != "outside CN" {
+		t.Fatalf("resolver query = %+v, %v", q, ok)
+	}
+	// 同一解析服务器去重
+	if _, ok := g.Inspect(ipv4Packet(udpPacket(53, dnsQuery("example.org", 1))), now); ok {
+		t.Error("同一解析服务器重复上报")
+	}
+}
+
+func TestGuard_SetRulesFirewall(t *testing.T) {
+	fw := &fakeFirewall{}
+	g := New(Config{Block: true, Firewall: fw}, nil)
+
+	rules := []Rule{
+		{RuleID: 1, Domain: "duckdns.org", Action: ActionBlock},
+		{RuleID: 2, Domain: "example.net"},
+	}
+	g.SetRules(rules)
+	if len(fw.applied) != 1 || !reflect.DeepEqual(fw.applied[0], []string{"duckdns.org"}) {
+		t.Fatalf("applied = %v", fw.applied)
+	}
+	// 规则未变化时不重复下发
+	g.SetRules(rules)
+	if len(fw.applied) != 1 {
+		t.Errorf("重复下发: %v", fw.applied)
+	}
+
+	q, _ := g.Inspect(ipv4Packet(udpPacket(53, dnsQuery("a.duckdns.org", 1))), time.Now())
+	if !q.Blocked {
+		t.Errorf("拦截规则命中但 Blocked = false")
+	}
+
+	g.SetRules(rules[1:])
+	if fw.cleared != 1 {
+		t.Errorf("拦截规则删除后未清除防火墙规则")
+	}
+
+	// 未允许拦截时拦截规则仅告警
+	fw = &fakeFirewall{}
+	g = New(Config{Firewall: fw}, nil)
+	g.SetRules(rules)
+	if len(fw.applied) != 0 {
+		t.Errorf("未允许拦截时下发了防火墙规则: %v", fw.applied)
+	}
+}
+
+func TestIPTables_Apply(t *testing.T) {
+	var calls []string
+	ipt := &IPTables{
+		binaries: []string{"iptables"},
+		run: func(bin string, args ...string) ([]byte, error) {
+			calls = append(calls, bin+" "+strings.Join(args, " "))
+			if args[0] == "-C" {
+				return nil, errors.New("no rule")
+			}
+			return nil, nil
+		},
+	}
+	if err := ipt.Apply([]string{"duckdns.org"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"iptables -N FILEWATCHER_DNS",
+		"iptables -F FILEWATCHER_DNS",
+		"iptables -A FILEWATCHER_DNS -p udp --dport 53 -m string --algo bm --icase --hex-string |076475636b646e73036f726700| -j DROP",
+		"iptables -A FILEWATCHER_DNS -p tcp --dport 53 -m string --algo bm --icase --hex-string |076475636b646e73036f726700| -j DROP",
+		"iptables -C OUTPUT -j FILEWATCHER_DNS",
+		"iptables -I OUTPUT -j FILEWATCHER_DNS",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls:\n%s\nwant:\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,94 @@
+package dnsguard
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// chainName 拦截规则所在的自定义链
+const chainName = "FILEWATCHER_DNS"
+
+// IPTables 通过 iptables / ip6tables 的字符串匹配丢弃查询报文
+// 匹配查询名的 DNS 编码 (如 |07|duckdns|03|org|00|)，因此同时拦截子域名；需要 xt_string 模块
+type IPTables struct {
+	binaries []string
+	run      func(bin string, args ...string) ([]byte, error)
+}
+
+// NewIPTables 创建 iptables 防火墙，未安装 ip6tables 时只拦截 IPv4
+func NewIPTables() *IPTables {
+	return &IPTables{
+		binaries: []string{"iptables", "ip6tables"},
+		run: func(bin string, args ...string) ([]byte, error) {
+			return exec.Command(bin, append([]string{"-w"}, args...)...).CombinedOutput()
+		},
+	}
+}
+
+// Apply 以给定域名全量替换拦截规则
+func (t *IPTables) Apply(domains []string) error {
+	applied := 0
+	var firstErr error
+	for _, bin := range t.binaries {
+		if err := t.apply(bin, domains); err != nil {
+			logger.Warn("下发 DNS 拦截规则失败", "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		applied++
+	}
+	if applied == 0 {
+		return firstErr
+	}
+	return nil
+}
+
+func (t *IPTables) apply(bin string, domains []string) error {
+	// 链已存在时 -N 失败，随后清空
+	t.run(bin, "-N", chainName)
+	if out, err := t.run(bin, "-F", chainName); err != nil {
+		return fmt.Errorf("%s -F %s: %v: %s", bin, chainName, err, strings.TrimSpace(string(out)))
+	}
+	for _, d := range domains {
+		pattern := "|" + hex.EncodeToString(encodeName(d)) + "|"
+		for _, proto := range []string{"udp", "tcp"} {
+			args := []string{"-A", chainName, "-p", proto, "--dport", "53",
+				"-m", "string", "--algo", "bm", "--icase", "--hex-string", pattern, "-j", "DROP"}
+			if out, err := t.run(bin, args...); err != nil {
+				return fmt.Errorf("%s: block %s: %v: %s", bin, d, err, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+	if _, err := t.run(bin, "-C", "OUTPUT", "-j", chainName); err != nil {
+		if out, err := t.run(bin, "-I", "OUTPUT", "-j", chainName); err != nil {
+			return fmt.Errorf("%s -I OUTPUT: %v: %s", bin, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// Clear 删除拦截链
+func (t *IPTables) Clear() error {
+	for _, bin := range t.binaries {
+		t.run(bin, "-D", "OUTPUT", "-j", chainName)
+		t.run(bin, "-F", chainName)
+		t.run(bin, "-X", chainName)
+	}
+	return nil
+}
+
+// encodeName 域名的 DNS 报文编码: 各标签前加长度字节，以 0 结尾
+func encodeName(domain string) []byte {
+	var b []byte
+	for _, label := range strings.Split(domain, ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
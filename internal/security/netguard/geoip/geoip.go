@@ -0,0 +1,232 @@
+// Package geoip 网络告警的地理位置与自治系统 (ASN) 信息
+// 从本地 MaxMind 格式库 (GeoLite2-City / GeoLite2-ASN 或同格式的商业库) 查询对端 IP 的国家、城市与 ASN，
+// 并支持按国家 / ASN 配置白名单与告警规则
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Info 一个 IP 的地理位置与自治系统信息，库中未收录的字段为空
+type Info struct {
+	Country string // ISO 3166-1 国家代码，如 CN
+	City    string
+	ASN     uint32
+	ASOrg   string
+}
+
+// Empty 是否未查到任何信息
+func (i Info) Empty() bool {
+	return i == Info{}
+}
+
+// String 如 "CN/Beijing AS4134 CHINANET"
+func (i Info) String() string {
+	var parts []string
+	if loc := strings.Trim(i.Country+"/"+i.City, "/"); loc != "" {
+		parts = append(parts, loc)
+	}
+	if i.ASN != 0 {
+		parts = append(parts, "AS"+strconv.FormatUint(uint64(i.ASN), 10))
+	}
+	if i.ASOrg != "" {
+		parts = append(parts, i.ASOrg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// DB 多个 mmdb 库的组合查询，如城市库与 ASN 库
+type DB struct {
+	readers []*Reader
+	// 城市名称的语言优先级
+	languages []string
+}
+
+// Open 打开一个或多个 mmdb 文件
+func Open(paths ...string) (*DB, error) {
+	db := &DB{languages: []string{"zh-CN", "en"}}
+	for _, p := range paths {
+		r, err := OpenReader(p)
+		if err != nil {
+			return nil, err
+		}
+		db.readers = append(db.readers, r)
+	}
+	return db, nil
+}
+
+// NewDB 由已打开的读取器创建
+func NewDB(readers ...*Reader) *DB {
+	return &DB{readers: readers, languages: []string{"zh-CN", "en"}}
+}
+
+// Lookup 查询 IP，依次合并各库的结果；db 为 nil 时返回空信息
+func (db *DB) Lookup(ip net.IP) (Info, error) {
+	var info Info
+	if db == nil {
+		return info, nil
+	}
+	for _, r := range db.readers {
+		rec, err := r.Lookup(ip)
+		if err != nil {
+			return info, err
+		}
+		if rec == nil {
+			continue
+		}
+		if info.Country == "" {
+			info.Country = lookupString(rec, "country", "iso_code")
+			if info.Country == "" {
+				info.Country = lookupString(rec, "registered_country", "iso_code")
+			}
+		}
+		if info.City == "" {
+			for _, lang := range db.languages {
+				if info.City = lookupString(rec, "city", "names", lang); info.City != "" {
+					break
+				}
+			}
+		}
+		if info.ASN == 0 {
+			// ASN 库在顶层，部分商业库在 traits 下
+			asn := lookupUint(rec, "autonomous_system_number")
+			org := lookupString(rec, "autonomous_system_organization")
+			if asn == 0 {
+				asn = lookupUint(rec, "traits", "autonomous_system_number")
+				org = lookupString(rec, "traits", "autonomous_system_organization")
+			}
+			info.ASN, info.ASOrg = uint32(asn), org
+		}
+	}
+	return info, nil
+}
+
+// LookupString 查询 IP 字符串
+func (db *DB) LookupString(ip string) (Info, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Info{}, fmt.Errorf("geoip: invalid ip %q", ip)
+	}
+	return db.Lookup(parsed)
+}
+
+func lookup(rec map[string]interface{}, path ...string) interface{} {
+	var v interface{} = rec
+	for _, k := range path {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+func lookupString(rec map[string]interface{}, path ...string) string {
+	s, _ := lookup(rec, path...).(string)
+	return s
+}
+
+func lookupUint(rec map[string]interface{}, path ...string) uint64 {
+	return toUint(lookup(rec, path...))
+}
+
+// Verdict 规则判定结果
+type Verdict int
+
+const (
+	VerdictNone  Verdict = iota // 未命中规则，按 IP 白名单处理
+	VerdictAllow                // 命中白名单
+	VerdictAlert                // 命中告警规则
+)
+
+// Rules 按国家与 ASN 的白名单与告警规则
+// 条目为国家代码 (如 "CN") 或 ASN (如 "AS4134" / "4134")，"!" 前缀表示取反:
+// 告警规则 ["!CN"] 即对境外连接告警。告警规则优先于白名单
+type Rules struct {
+	allow []matcher
+	alert []matcher
+}
+
+type matcher struct {
+	country string
+	asn     uint32
+	negate  bool
+}
+
+// match 规则是否命中，缺少对应字段 (如只有 ASN 库时的国家) 时不命中
+func (m matcher) match(info Info) bool {
+	if m.asn != 0 {
+		return info.ASN != 0 && (info.ASN == m.asn) != m.negate
+	}
+	return info.Country != "" && strings.EqualFold(info.Country, m.country) != m.negate
+}
+
+// ParseRules 解析白名单与告警规则
+func ParseRules(allow, alert []string) (*Rules, error) {
+	r := &Rules{}
+	var err error
+	if r.allow, err = parseMatchers(allow); err != nil {
+		return nil, err
+	}
+	if r.alert, err = parseMatchers(alert); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parseMatchers(entries []string) ([]matcher, error) {
+	var ms []matcher
+	for _, e := range entries {
+		s := strings.TrimSpace(e)
+		var m matcher
+		if strings.HasPrefix(s, "!") {
+			m.negate, s = true, strings.TrimSpace(s[1:])
+		}
+		num := strings.TrimPrefix(strings.ToUpper(s), "AS")
+		if n, err := strconv.ParseUint(num, 10, 32); err == nil && n != 0 {
+			m.asn = uint32(n)
+		} else if len(s) == 2 && isLetters(s) {
+			m.country = strings.ToUpper(s)
+		} else {
+			return nil, fmt.Errorf("geoip: bad rule %q (want country code or ASN)", e)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+func isLetters(s string) bool {
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty 是否未配置任何规则
+func (r *Rules) Empty() bool {
+	return r == nil || len(r.allow)+len(r.alert) == 0
+}
+
+// Evaluate 判定一个 IP 的信息，库中未收录 (如内网地址) 时不命中任何规则
+func (r *Rules) Evaluate(info Info) Verdict {
+	if r == nil || info.Empty() {
+		return VerdictNone
+	}
+	for _, m := range r.alert {
+		if m.match(info) {
+			return VerdictAlert
+		}
+	}
+	for _, m := range r.allow {
+		if m.match(info) {
+			return VerdictAllow
+		}
+	}
+	return VerdictNone
+}
@@ -0,0 +1,275 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ==========================================
+// 测试用 mmdb 生成
+// ==========================================
+
+type trieNode struct {
+	child [2]*trieNode
+	data  int // 数据段偏移，-1 表示内部节点
+}
+
+// encode 编码数据段的值 (字符串、uint32、map、指针)
+func encode(v interface{}) []byte {
+	switch x := v.(type) {
+	case string:
+		return append(header(2, len(x)), x...)
+	case uint32:
+		b := binary.BigEndian.AppendUint32(nil, x)
+		for len(b) > 0 && b[0] == 0 {
+			b = b[1:]
+		}
+		return append(header(6, len(b)), b...)
+	case pointer:
+		return []byte{0x20 | byte(x>>8)&0x7, byte(x)}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := header(7, len(x))
+		for _, k := range keys {
+			out = append(out, encode(k)...)
+			out = append(out, encode(x[k])...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+// pointer 数据段指针 (小于 2048)
+type pointer uint16
+
+func header(typ, size int) []byte {
+	switch {
+	case size < 29:
+		return []byte{byte(typ<<5 | size)}
+	case size < 285:
+		return []byte{byte(typ<<5 | 29), byte(size - 29)}
+	default:
+		s := size - 285
+		return []byte{byte(typ<<5 | 30), byte(s >> 8), byte(s)}
+	}
+}
+
+// buildDB 生成 mmdb，networks 为 CIDR -> 记录
+func buildDB(t *testing.T, ipVersion, recordSize int, networks map[string]interface{}) []byte {
+	t.Helper()
+	root := &trieNode{data: -1}
+	var data []byte
+	cidrs := make([]string, 0, len(networks))
+	for c := range networks {
+		cidrs = append(cidrs, c)
+	}
+	sort.Strings(cidrs)
+	for _, c := range cidrs {
+		_, ipnet, err := net.ParseCIDR(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, _ := ipnet.Mask.Size()
+		addr := []byte(ipnet.IP)
+		if ip4 := ipnet.IP.To4(); ip4 != nil && ipVersion == 6 {
+			addr = append(make([]byte, 12), ip4...)
+			ones += 96
+		}
+		n := root
+		for i := 0; i < ones; i++ {
+			bit := (addr[i/8] >> (7 - uint(i%8))) & 1
+			if n.child[bit] == nil {
+				n.child[bit] = &trieNode{data: -1}
+			}
+			n = n.child[bit]
+		}
+		n.data = len(data)
+		data = append(data, encode(networks[c])...)
+	}
+
+	// 内部节点按广度优先编号
+	var nodes []*trieNode
+	ids := map[*trieNode]int{}
+	queue := []*trieNode{root}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		ids[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.child {
+			if c != nil && c.data < 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+	count := len(nodes)
+	record := func(c *trieNode) uint32 {
+		switch {
+		case c == nil:
+			return uint32(count)
+		case c.data >= 0:
+			return uint32(count + 16 + c.data)
+		}
+		return uint32(ids[c])
+	}
+
+	var tree []byte
+	for _, n := range nodes {
+		l, r := record(n.child[0]), record(n.child[1])
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(l>>16), byte(l>>8), byte(l), byte(r>>16), byte(r>>8), byte(r))
+		case 28:
+			tree = append(tree, byte(l>>16), byte(l>>8), byte(l), byte(l>>20)&0xf0|byte(r>>24)&0x0f, byte(r>>16), byte(r>>8), byte(r))
+		case 32:
+			tree = binary.BigEndian.AppendUint32(tree, l)
+			tree = binary.BigEndian.AppendUint32(tree, r)
+		}
+	}
+
+	out := append(tree, make([]byte, 16)...)
+	out = append(out, data...)
+	out = append(out, metadataMarker...)
+	out = append(out, encode(map[string]interface{}{
+		"database_type": "Test-City",
+		"ip_version":    uint32(ipVersion),
+		"node_count":    uint32(count),
+		"record_size":   uint32(recordSize),
+	})...)
+	return out
+}
+
+func cityRecord(country, city string) map[string]interface{} {
+	return map[string]interface{}{
+		"country": map[string]interface{}{"iso_code": country},
+		"city":    map[string]interface{}{"names": map[string]interface{}{"en": city}},
+	}
+}
+
+// ==========================================
+// 测试
+// ==========================================
+
+func TestReader_Lookup(t *testing.T) {
+	longName := strings.Repeat("Llanfairpwllgwyngyll", 3)
+	networks := map[string]interface{}{
+		"1.2.3.0/24":    cityRecord("CN", "Beijing"),
+		"8.8.8.0/24":    cityRecord("US", longName),
+		"9.9.9.0/24":    pointer(0), // 指向第一条记录
+		"2001:db8::/32": cityRecord("JP", "Tokyo"),
+	}
+	for _, size := range []int{24, 28, 32} {
+		r, err := NewReader(buildDB(t, 6, size, networks))
+		if err != nil {
+			t.Fatalf("record size %d: %v", size, err)
+		}
+		db := NewDB(r)
+		for ip, want := range map[string]Info{
+			"1.2.3.4":     {Country: "CN", City: "Beijing"},
+			"8.8.8.8":     {Country: "US", City: longName},
+			"9.9.9.9":     {Country: "CN", City: "Beijing"},
+			"2001:db8::1": {Country: "JP", City: "Tokyo"},
+			"10.0.0.1":    {},
+			"2001:db9::1": {},
+		} {
+			got, err := db.LookupString(ip)
+			if err != nil {
+				t.Fatalf("size %d: Lookup(%s): %v", size, ip, err)
+			}
+			if got != want {
+				t.Errorf("size %d: Lookup(%s) = %+v, want %+v", size, ip, got, want)
+			}
+		}
+		if m := r.Metadata(); m.DatabaseType != "Test-City" || m.IPVersion != 6 || m.RecordSize != uint(size) {
+			t.Errorf("Metadata() = %+v", m)
+		}
+	}
+}
+
+func TestDB_MergeCityAndASN(t *testing.T) {
+	dir := t.TempDir()
+	city := filepath.Join(dir, "city.mmdb")
+	asn := filepath.Join(dir, "asn.mmdb")
+	os.WriteFile(city, buildDB(t, 4, 24, map[string]interface{}{
+		"61.135.0.0/16": cityRecord("CN", "Beijing"),
+	}), 0644)
+	os.WriteFile(asn, buildDB(t, 4, 24, map[string]interface{}{
+		"61.135.0.0/16": map[string]interface{}{
+			"autonomous_system_number":       uint32(4808),
+			"autonomous_system_organization": "China Unicom",
+		},
+	}), 0644)
+
+	db, err := Open(city, asn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.LookupString("61.135.169.121")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Info{Country: "CN", City: "Beijing", ASN: 4808, ASOrg: "China Unicom"}
+	if got != want {
+		t.Fatalf("Lookup = %+v, want %+v", got, want)
+	}
+	if s := got.String(); s != "CN/Beijing AS4808 China Unicom" {
+		t.Errorf("String() = %q", s)
+	}
+	// IPv4 库不收录 IPv6 地址
+	if got, _ := db.LookupString("2001:db8::1"); !got.Empty() {
+		t.Errorf("IPv6 in IPv4 db = %+v", got)
+	}
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	if _, err := NewReader([]byte("not a database")); err == nil {
+		t.Error("NewReader succeeded on garbage")
+	}
+	db := buildDB(t, 4, 24, map[string]interface{}{"1.0.0.0/8": cityRecord("AU", "")})
+	// 截断搜索树
+	if _, err := NewReader(db[len(db)-80:]); err == nil {
+		t.Error("NewReader succeeded on truncated file")
+	}
+}
+
+func TestRules_Evaluate(t *testing.T) {
+	if _, err := ParseRules([]string{"China"}, nil); err == nil {
+		t.Error("ParseRules accepted a country name")
+	}
+
+	// 境外连接与指定 ASN 告警，告警规则优先于白名单
+	r, err := ParseRules([]string{"CN", "AS13335"}, []string{"!CN", "AS4134"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		info Info
+		want Verdict
+	}{
+		{Info{Country: "CN", ASN: 4808}, VerdictAllow},
+		{Info{Country: "CN", ASN: 4134}, VerdictAlert},
+		{Info{Country: "US", ASN: 13335}, VerdictAlert},
+		{Info{ASN: 13335}, VerdictAllow},
+		{Info{ASN: 9999}, VerdictNone},
+		{Info{}, VerdictNone},
+	} {
+		if got := r.Evaluate(tc.info); got != tc.want {
+			t.Errorf("Evaluate(%+v) = %v, want %v", tc.info, got, tc.want)
+		}
+	}
+
+	// 国家代码 AS (美属萨摩亚) 不是 ASN
+	r, _ = ParseRules(nil, []string{"as"})
+	if r.Evaluate(Info{Country: "AS"}) != VerdictAlert {
+		t.Error("country code AS not matched")
+	}
+}
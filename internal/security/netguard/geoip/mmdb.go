@@ -0,0 +1,377 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+// metadataMarker 元数据段起始标记，位于文件末尾 128KB 内
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator 搜索树与数据段之间的 16 字节分隔
+const dataSectionSeparator = 16
+
+// ErrInvalidDatabase 文件不是有效的 MaxMind DB
+var ErrInvalidDatabase = errors.New("geoip: invalid MaxMind DB")
+
+// Metadata 数据库元数据
+type Metadata struct {
+	DatabaseType string
+	IPVersion    uint
+	NodeCount    uint
+	RecordSize   uint
+	BuildEpoch   uint64
+}
+
+// Reader MaxMind DB (mmdb) 格式读取，整个文件读入内存
+// 格式说明: https://maxmind.github.io/MaxMind-DB/
+type Reader struct {
+	buf      []byte
+	meta     Metadata
+	treeSize uint
+	// IPv6 库中 IPv4 地址 (::/96) 的起始节点
+	ipv4Start uint
+}
+
+// OpenReader 读取 mmdb 文件
+func OpenReader(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// NewReader 从内存中的 mmdb 内容创建读取器
+func NewReader(buf []byte) (*Reader, error) {
+	i := bytes.LastIndex(buf, metadataMarker)
+	if i < 0 {
+		return nil, ErrInvalidDatabase
+	}
+	metaStart := i + len(metadataMarker)
+	d := decoder{buf: buf[metaStart:]}
+	v, _, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: metadata: %v", ErrInvalidDatabase, err)
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata is not a map", ErrInvalidDatabase)
+	}
+
+	r := &Reader{buf: buf}
+	r.meta.DatabaseType, _ = m["database_type"].(string)
+	r.meta.IPVersion = uint(toUint(m["ip_version"]))
+	r.meta.NodeCount = uint(toUint(m["node_count"]))
+	r.meta.RecordSize = uint(toUint(m["record_size"]))
+	r.meta.BuildEpoch = toUint(m["build_epoch"])
+
+	switch r.meta.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: unsupported record size %d", ErrInvalidDatabase, r.meta.RecordSize)
+	}
+	if r.meta.IPVersion != 4 && r.meta.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: unsupported ip version %d", ErrInvalidDatabase, r.meta.IPVersion)
+	}
+	r.treeSize = r.meta.NodeCount * r.meta.RecordSize * 2 / 8
+	if r.treeSize+dataSectionSeparator > uint(i) {
+		return nil, fmt.Errorf("%w: search tree exceeds file size", ErrInvalidDatabase)
+	}
+
+	if r.meta.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.meta.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// Metadata 数据库元数据
+func (r *Reader) Metadata() Metadata {
+	return r.meta
+}
+
+// Lookup 查询 IP 对应的记录，未收录时返回 nil
+func (r *Reader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	node, addr := uint(0), ip.To4()
+	switch {
+	case addr != nil && r.meta.IPVersion == 6:
+		node = r.ipv4Start
+	case addr == nil:
+		if r.meta.IPVersion == 4 {
+			return nil, nil
+		}
+		addr = ip.To16()
+		if addr == nil {
+			return nil, fmt.Errorf("geoip: invalid ip %v", ip)
+		}
+	}
+
+	total := len(addr) * 8
+	for i := 0; i < total && node < r.meta.NodeCount; i++ {
+		bit := (addr[i/8] >> (7 - uint(i%8))) & 1
+		node = r.record(node, uint(bit))
+	}
+
+	switch {
+	case node == r.meta.NodeCount:
+		return nil, nil
+	case node < r.meta.NodeCount+dataSectionSeparator:
+		return nil, fmt.Errorf("%w: bad record %d", ErrInvalidDatabase, node)
+	}
+	offset := node - r.meta.NodeCount - dataSectionSeparator
+	d := decoder{buf: r.buf[r.treeSize+dataSectionSeparator:]}
+	v, _, err := d.decode(offset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDatabase, err)
+	}
+	m, _ := v.(map[string]interface{})
+	return m, nil
+}
+
+// record 读取节点的左 (bit=0) 或右 (bit=1) 记录
+func (r *Reader) record(node, bit uint) uint {
+	b := r.buf
+	switch r.meta.RecordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[off:]))
+	}
+}
+
+// 数据段类型
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// maxDepth 嵌套深度上限，防止畸形文件导致栈溢出
+const maxDepth = 32
+
+var errCorrupt = errors.New("corrupt data section")
+
+// decoder 数据段解码
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+// decode 解码 offset 处的值，返回值与下一个值的偏移
+func (d *decoder) decode(offset uint) (interface{}, uint, error) {
+	if d.depth > maxDepth {
+		return nil, 0, errCorrupt
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	typ, size, offset, err := d.control(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	if typ == typePointer {
+		ptr, next, err := d.pointer(size, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, _, err := d.decode(ptr)
+		return v, next, err
+	}
+
+	end := offset + size
+	switch typ {
+	case typeMap:
+		m := make(map[string]interface{}, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, errCorrupt
+			}
+			v, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		a := make([]interface{}, 0, min(size, 64))
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			offset = next
+		}
+		return a, offset, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeContainer, typeEndMarker:
+		return nil, offset, nil
+	}
+
+	if end > uint(len(d.buf)) {
+		return nil, 0, errCorrupt
+	}
+	b := d.buf[offset:end]
+	switch typ {
+	case typeString:
+		return string(b), end, nil
+	case typeBytes:
+		return append([]byte(nil), b...), end, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, errCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), end, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, errCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), end, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, errCorrupt
+		}
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, end, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, errCorrupt
+		}
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		if size == 4 {
+			return int64(int32(v)), end, nil
+		}
+		return int64(v), end, nil
+	case typeUint128:
+		// 只用于 IPv6 相关字段，原样返回
+		return append([]byte(nil), b...), end, nil
+	}
+	return nil, 0, errCorrupt
+}
+
+// control 解析控制字节，返回类型、大小与数据起始偏移
+func (d *decoder) control(offset uint) (int, uint, uint, error) {
+	if offset >= uint(len(d.buf)) {
+		return 0, 0, 0, errCorrupt
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ := int(ctrl >> 5)
+	if typ == typeExtended {
+		if offset >= uint(len(d.buf)) {
+			return 0, 0, 0, errCorrupt
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+		if typ < typeInt32 || typ > typeFloat {
+			return 0, 0, 0, errCorrupt
+		}
+	}
+	if typ == typePointer {
+		return typ, uint(ctrl), offset, nil
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(d.buf)) {
+			return 0, 0, 0, errCorrupt
+		}
+		var v uint
+		for _, c := range d.buf[offset : offset+n] {
+			v = v<<8 | uint(c)
+		}
+		offset += n
+		switch n {
+		case 1:
+			size = 29 + v
+		case 2:
+			size = 285 + v
+		default:
+			size = 65821 + v
+		}
+	}
+	return typ, size, offset, nil
+}
+
+// pointer 解析指针，ctrl 为原始控制字节
+func (d *decoder) pointer(ctrl, offset uint) (uint, uint, error) {
+	n := (ctrl>>3)&0x3 + 1
+	if offset+n > uint(len(d.buf)) {
+		return 0, 0, errCorrupt
+	}
+	var v uint
+	for _, c := range d.buf[offset : offset+n] {
+		v = v<<8 | uint(c)
+	}
+	vvv := ctrl & 0x7
+	switch n {
+	case 1:
+		v |= vvv << 8
+	case 2:
+		v = (v | vvv<<16) + 2048
+	case 3:
+		v = (v | vvv<<24) + 526336
+	}
+	return v, offset + n, nil
+}
+
+func toUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,416 @@
+// Package listener 监听端口与服务暴露监控
+// 周期读取 /proc/net/{tcp,tcp6,udp,udp6} 中的监听 socket，通过 /proc/<pid>/fd 关联所属进程，
+// 受监控进程 (或整机) 出现白名单之外的新监听端口时上报，告警包含进程的可执行文件路径。
+// 只检查客户端所在网络命名空间，容器内的监听端口不在此处检查
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// procRoot proc 文件系统挂载点，测试时可替换
+var procRoot = "/proc"
+
+// DefaultInterval 默认检测周期 (遍历进程 fd 开销较大，不宜过短)
+const DefaultInterval = 30 * time.Second
+
+// socket 状态 (include/net/tcp_states.h)
+const (
+	stateListen = "0A" // TCP_LISTEN
+	stateClose  = "07" // TCP_CLOSE: 未连接的 UDP socket
+)
+
+// Listener 一个监听 socket
+type Listener struct {
+	Protocol string // tcp, tcp6, udp, udp6
+	Address  string // 绑定地址
+	Port     int
+	Inode    uint64
+	// 所属进程，无法关联 (如进程已退出、权限不足) 时 PID 为 0
+	PID     int
+	Process string // /proc/<pid>/comm
+	Exe     string // /proc/<pid>/exe
+}
+
+// String 如 tcp 0.0.0.0:22
+func (l Listener) String() string {
+	return l.Protocol + " " + net.JoinHostPort(l.Address, strconv.Itoa(l.Port))
+}
+
+// key 去重键: 同一程序在同一地址端口上的监听只上报一次 (重启后 PID 变化不重复上报)
+func (l Listener) key() string {
+	return l.String() + "\x00" + l.Exe
+}
+
+// Handler 发现新监听端口时的回调
+type Handler func(l Listener)
+
+// Config 监控配置
+type Config struct {
+	// 检测周期，<=0 时使用 DefaultInterval
+	Interval time.Duration
+	// 监控整机的监听端口，否则只监控自身与 Processes 中的进程
+	Host bool
+	// 受监控的进程名 (/proc/<pid>/comm)，自身进程总是监控
+	Processes []string
+	// 允许的端口: "22"、"tcp/443"、"udp/53"、"8000-8100"、"tcp/8000-8100"
+	// AllowPorts 与 AllowProcesses 均为空时以启动时已存在的监听为白名单
+	AllowPorts []string
+	// 允许任意监听的程序路径 (支持 * 通配)，如 /usr/sbin/sshd
+	AllowProcesses []string
+}
+
+// portRule 解析后的端口白名单规则
+type portRule struct {
+	proto    string // tcp / udp，空为任意
+	from, to int
+}
+
+// Monitor 监听端口监控
+type Monitor struct {
+	cfg     Config
+	handler Handler
+	rules   []portRule
+
+	mu sync.Mutex
+	// 未配置白名单时启动时已存在的监听
+	startup map[string]bool
+	// 已上报的监听，关闭后再次出现时重新上报
+	reported map[string]bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建监控，未配置白名单时记录当前已存在的监听
+func New(cfg Config, handler Handler) (*Monitor, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	m := &Monitor{cfg: cfg, handler: handler, reported: make(map[string]bool)}
+	for _, s := range cfg.AllowPorts {
+		r, err := parsePortRule(s)
+		if err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, r)
+	}
+
+	if len(cfg.AllowPorts) == 0 && len(cfg.AllowProcesses) == 0 {
+		m.startup = make(map[string]bool)
+		ls, err := m.Snapshot()
+		if err != nil {
+			logger.Warn("读取监听端口失败", "error", err)
+		}
+		for _, l := range ls {
+			m.startup[l.key()] = true
+		}
+	}
+	return m, nil
+}
+
+// Start 后台周期检测
+func (m *Monitor) Start() {
+	m.stopCh = make(chan struct{})
+	m.wg.Add(1)
+	go m.loop()
+	logger.Info("监听端口监控已启动", "interval", m.cfg.Interval, "host", m.cfg.Host, "processes", len(m.cfg.Processes))
+}
+
+// Stop 停止检测
+func (m *Monitor) Stop() {
+	if m.stopCh == nil {
+		return
+	}
+	close(m.stopCh)
+	m.wg.Wait()
+	m.stopCh = nil
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	m.Check()
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check 执行一轮检测，返回本轮新出现的白名单之外的监听
+func (m *Monitor) Check() []Listener {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ls, err := m.Snapshot()
+	if err != nil {
+		logger.Warn("读取监听端口失败", "error", err)
+		return nil
+	}
+
+	seen := make(map[string]bool, len(ls))
+	var found []Listener
+	for _, l := range ls {
+		if m.Allowed(l) {
+			continue
+		}
+		k := l.key()
+		seen[k] = true
+		if m.reported[k] {
+			continue
+		}
+		m.reported[k] = true
+		found = append(found, l)
+	}
+	for k := range m.reported {
+		if !seen[k] {
+			delete(m.reported, k)
+		}
+	}
+
+	for _, l := range found {
+		logger.Error("发现白名单之外的监听端口", "listener", l.String(), "pid", l.PID, "exe", l.Exe)
+		if m.handler != nil {
+			m.handler(l)
+		}
+	}
+	return found
+}
+
+// Allowed 监听是否在白名单中
+func (m *Monitor) Allowed(l Listener) bool {
+	if m.startup != nil {
+		return m.startup[l.key()]
+	}
+	proto := strings.TrimSuffix(l.Protocol, "6")
+	for _, r := range m.rules {
+		if (r.proto == "" || r.proto == proto) && l.Port >= r.from && l.Port <= r.to {
+			return true
+		}
+	}
+	if l.Exe != "" {
+		for _, p := range m.cfg.AllowProcesses {
+			if ok, _ := path.Match(p, l.Exe); ok || p == l.Exe {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Snapshot 读取受监控进程 (Host 时为整机) 的全部监听
+func (m *Monitor) Snapshot() ([]Listener, error) {
+	sockets, err := readListeners()
+	if err != nil {
+		return nil, err
+	}
+	owners := socketOwners(m.monitoredPIDs())
+
+	var out []Listener
+	for _, l := range sockets {
+		if pid, ok := owners[l.Inode]; ok {
+			l.PID = pid
+			l.Process, l.Exe = processName(pid)
+		} else if !m.cfg.Host {
+			continue
+		}
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Port != out[j].Port {
+			return out[i].Port < out[j].Port
+		}
+		return out[i].Protocol < out[j].Protocol
+	})
+	return out, nil
+}
+
+// monitoredPIDs 受监控的进程，Host 时返回全部进程
+func (m *Monitor) monitoredPIDs() []int {
+	names := make(map[string]bool, len(m.cfg.Processes))
+	for _, n := range m.cfg.Processes {
+		names[n] = true
+	}
+	self := os.Getpid()
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return []int{self}
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		if m.cfg.Host || pid == self {
+			pids = append(pids, pid)
+			continue
+		}
+		if comm, err := os.ReadFile(filepath.Join(procRoot, e.Name(), "comm")); err == nil && names[strings.TrimSpace(string(comm))] {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// readListeners 读取 /proc/net 下的监听 socket
+func readListeners() ([]Listener, error) {
+	var out []Listener
+	read := 0
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		data, err := os.ReadFile(filepath.Join(procRoot, "net", proto))
+		if err != nil {
+			// 未启用 IPv6 时没有 tcp6 / udp6
+			continue
+		}
+		read++
+		out = append(out, parseSockets(proto, data)...)
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("listener: no socket tables under %s/net", procRoot)
+	}
+	return out, nil
+}
+
+// parseSockets 解析 /proc/net/{tcp,udp}[6]
+// 行格式: sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ...
+func parseSockets(proto string, data []byte) []Listener {
+	udp := strings.HasPrefix(proto, "udp")
+	var out []Listener
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		st := fields[3]
+		if udp {
+			// 未连接的 UDP socket 即为监听
+			if st != stateClose || !strings.HasSuffix(fields[2], ":0000") {
+				continue
+			}
+		} else if st != stateListen {
+			continue
+		}
+		addr, port, err := parseAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		out = append(out, Listener{Protocol: proto, Address: addr, Port: port, Inode: inode})
+	}
+	return out
+}
+
+// parseAddr 解析 "0100007F:0016"，地址按 32 位字以主机字节序 (小端) 存放
+func parseAddr(s string) (string, int, error) {
+	hostHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", 0, fmt.Errorf("bad address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return "", 0, err
+	}
+	raw, err := hex.DecodeString(hostHex)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return "", 0, fmt.Errorf("bad address %q", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	return ip.String(), int(port), nil
+}
+
+// socketOwners 读取进程打开的 socket，返回 inode -> pid
+func socketOwners(pids []int) map[uint64]int {
+	owners := make(map[uint64]int)
+	for _, pid := range pids {
+		fdDir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			// 继承给子进程的 socket 归属最先发现的进程
+			if _, ok := owners[inode]; !ok {
+				owners[inode] = pid
+			}
+		}
+	}
+	return owners
+}
+
+// processName 进程名与可执行文件路径
+func processName(pid int) (comm, exe string) {
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	if data, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		comm = strings.TrimSpace(string(data))
+	}
+	if link, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+		exe = strings.TrimSuffix(link, " (deleted)")
+	}
+	return comm, exe
+}
+
+// parsePortRule 解析端口白名单规则
+func parsePortRule(s string) (portRule, error) {
+	var r portRule
+	spec := strings.TrimSpace(s)
+	if proto, rest, ok := strings.Cut(spec, "/"); ok {
+		proto = strings.ToLower(proto)
+		if proto != "tcp" && proto != "udp" {
+			return r, fmt.Errorf("listener: bad protocol in %q", s)
+		}
+		r.proto, spec = proto, rest
+	}
+	from, to, isRange := strings.Cut(spec, "-")
+	var err error
+	if r.from, err = strconv.Atoi(from); err != nil {
+		return r, fmt.Errorf("listener: bad port in %q", s)
+	}
+	r.to = r.from
+	if isRange {
+		if r.to, err = strconv.Atoi(to); err != nil || r.to < r.from {
+			return r, fmt.Errorf("listener: bad port range in %q", s)
+		}
+	}
+	if r.from < 0 || r.to > 65535 {
+		return r, fmt.Errorf("listener: port out of range in %q", s)
+	}
+	return r, nil
+}
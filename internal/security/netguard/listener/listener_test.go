@@ -0,0 +1,153 @@
+package listener
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const tcpHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+// fakeProc 在临时目录下构造 /proc
+func fakeProc(t *testing.T) {
+	t.Helper()
+	old := procRoot
+	procRoot = filepath.Join(t.TempDir(), "proc")
+	t.Cleanup(func() { procRoot = old })
+	if err := os.MkdirAll(filepath.Join(procRoot, "net"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeNet(t *testing.T, proto, rows string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(procRoot, "net", proto), []byte(tcpHeader+rows), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// process 构造进程目录，fd 指向给定 inode 的 socket
+func process(t *testing.T, pid int, comm, exe string, inodes ...int) {
+	t.Helper()
+	dir := filepath.Join(procRoot, strconv.Itoa(pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644)
+	os.Symlink(exe, filepath.Join(dir, "exe"))
+	for i, inode := range inodes {
+		os.Symlink("socket:["+strconv.Itoa(inode)+"]", filepath.Join(dir, "fd", strconv.Itoa(i+3)))
+	}
+}
+
+func TestParseSockets(t *testing.T) {
+	tcp := tcpHeader +
+		"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n" +
+		"   1: 0100007F:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0 100 0 0 10 0\n" +
+		"   2: 0100007F:0016 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000     0        0 1003 1 0 20 4 30 10 -1\n"
+	got := parseSockets("tcp", []byte(tcp))
+	if len(got) != 2 {
+		t.Fatalf("parseSockets(tcp) = %+v, want 2 LISTEN", got)
+	}
+	if got[0].Address != "0.0.0.0" || got[0].Port != 22 || got[0].Inode != 1001 {
+		t.Errorf("got[0] = %+v", got[0])
+	}
+	if got[1].Address != "127.0.0.1" || got[1].Port != 3306 {
+		t.Errorf("got[1] = %+v", got[1])
+	}
+
+	tcp6 := tcpHeader +
+		"   0: 00000000000000000000000001000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 2001 1 0 100 0 0 10 0\n"
+	got = parseSockets("tcp6", []byte(tcp6))
+	if len(got) != 1 || got[0].Address != "::1" || got[0].Port != 80 {
+		t.Fatalf("parseSockets(tcp6) = %+v", got)
+	}
+
+	udp := tcpHeader +
+		"  10: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 3001 2 0 0\n" +
+		"  11: 0F02000A:A1B2 08080808:0035 01 00000000:00000000 00:00000000 00000000     0        0 3002 2 0 0\n"
+	got = parseSockets("udp", []byte(udp))
+	if len(got) != 1 || got[0].Port != 53 {
+		t.Fatalf("parseSockets(udp) = %+v, want only the unconnected socket", got)
+	}
+}
+
+func TestParsePortRule(t *testing.T) {
+	for _, s := range []string{"22", "tcp/443", "UDP/53", "8000-8100", "tcp/1-1024"} {
+		if _, err := parsePortRule(s); err != nil {
+			t.Errorf("parsePortRule(%q) error: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "ssh", "icmp/1", "90-80", "70000"} {
+		if _, err := parsePortRule(s); err == nil {
+			t.Errorf("parsePortRule(%q) succeeded", s)
+		}
+	}
+}
+
+func TestCheck_MonitoredProcesses(t *testing.T) {
+	fakeProc(t)
+	writeNet(t, "tcp",
+		"   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n"+
+			"   1: 00000000:115C 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0 100 0 0 10 0\n"+
+			"   2: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1003 1 0 100 0 0 10 0\n")
+	process(t, 100, "sshd", "/usr/sbin/sshd", 1001)
+	process(t, 200, "sshd", "/tmp/.cache/sshd", 1002)
+	process(t, 300, "nginx", "/usr/sbin/nginx", 1003)
+
+	var reported []Listener
+	m, err := New(Config{Processes: []string{"sshd"}, AllowPorts: []string{"tcp/22"}}, func(l Listener) {
+		reported = append(reported, l)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := m.Check()
+	if len(got) != 1 || got[0].Port != 4444 || got[0].PID != 200 || got[0].Exe != "/tmp/.cache/sshd" {
+		t.Fatalf("Check() = %+v, want sshd on 4444", got)
+	}
+	if len(reported) != 1 {
+		t.Errorf("handler called %d times", len(reported))
+	}
+	// 同一监听只上报一次
+	if got := m.Check(); len(got) != 0 {
+		t.Errorf("重复上报: %+v", got)
+	}
+
+	// 整机模式下未受监控的进程与无法关联进程的 socket 同样检查
+	m, _ = New(Config{Host: true, AllowPorts: []string{"22"}, AllowProcesses: []string{"/usr/sbin/*"}}, nil)
+	if got := m.Check(); len(got) != 1 || got[0].Port != 4444 {
+		t.Fatalf("host Check() = %+v", got)
+	}
+}
+
+func TestCheck_StartupBaseline(t *testing.T) {
+	fakeProc(t)
+	writeNet(t, "tcp", "   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n")
+	writeNet(t, "udp", "")
+	process(t, 100, "sshd", "/usr/sbin/sshd", 1001)
+
+	m, err := New(Config{Host: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Check(); len(got) != 0 {
+		t.Fatalf("启动时已存在的监听被上报: %+v", got)
+	}
+
+	writeNet(t, "udp", "   0: 00000000:1E61 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0 0\n")
+	process(t, 400, "backdoor", "/dev/shm/bd", 2001)
+	got := m.Check()
+	if len(got) != 1 || got[0].String() != "udp 0.0.0.0:7777" || got[0].Process != "backdoor" {
+		t.Fatalf("Check() = %+v, want udp 7777", got)
+	}
+
+	// 关闭后再次出现时重新上报
+	writeNet(t, "udp", "")
+	m.Check()
+	writeNet(t, "udp", "   0: 00000000:1E61 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0 0\n")
+	if got := m.Check(); len(got) != 1 {
+		t.Errorf("再次出现未上报: %+v", got)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build linux
+
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// readTimeout 读取超时，用于及时响应停止与截止时间
+const readTimeout = time.Second
+
+// capture AF_PACKET 抓包 socket
+// SOCK_DGRAM 去掉链路层头，报文从 IP 头开始
+type capture struct {
+	fd int
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// hostFilter 只接收源或目的地址为 ip 的报文，接收的报文截断为 snapLen
+// IPv4 地址位于 IP 头偏移 12 (源) 与 16 (目的)，IPv6 位于 8 与 24，逐个 32 位字比较
+func hostFilter(ip net.IP, snapLen int) []syscall.SockFilter {
+	version, src, dst := 6, 8, 24
+	addr := ip.To16()
+	if ip4 := ip.To4(); ip4 != nil {
+		version, src, dst, addr = 4, 12, 16, ip4
+	}
+	words := len(addr) / 4
+	// 版本判断 3 条，每个地址每个字 2 条，接受与丢弃各 1 条
+	n := 3 + 4*words + 2
+	accept, reject := n-2, n-1
+	jump := func(from, to int) int { return to - from - 1 }
+
+	prog := make([]syscall.SockFilter, 0, n)
+	prog = append(prog,
+		*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 0),
+		*syscall.LsfStmt(syscall.BPF_ALU|syscall.BPF_RSH|syscall.BPF_K, 4),
+		*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, version, 0, jump(2, reject)),
+	)
+	for b, base := range []int{src, dst} {
+		// 不匹配时转到下一个地址，目的地址也不匹配时丢弃
+		next := reject
+		if b == 0 {
+			next = 3 + 2*words
+		}
+		for i := 0; i < words; i++ {
+			// BPF 按网络字节序加载
+			k := binary.BigEndian.Uint32(addr[4*i:])
+			prog = append(prog, *syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_W|syscall.BPF_ABS, base+4*i))
+			at := len(prog)
+			jt := 0
+			if i == words-1 {
+				jt = jump(at, accept)
+			}
+			prog = append(prog, *syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, int(k), jt, jump(at, next)))
+		}
+	}
+	prog = append(prog,
+		*syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, snapLen),
+		*syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, 0),
+	)
+	return prog
+}
+
+// openCapture 打开抓包 socket 并附加对端地址过滤器，需要 CAP_NET_RAW
+func openCapture(ip net.IP, snapLen int) (*capture, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, int(htons(syscall.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("pcap: open packet socket: %w", err)
+	}
+	if err := syscall.AttachLsf(fd, hostFilter(ip, snapLen)); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("pcap: attach filter: %w", err)
+	}
+	tv := syscall.NsecToTimeval(int64(readTimeout))
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("pcap: set timeout: %w", err)
+	}
+	return &capture{fd: fd}, nil
+}
+
+// read 读取一个报文，返回保存的长度与截断前的长度
+// 回环接口上的报文会以发出、接收各出现一次，与 tcpdump -i any 一致
+func (c *capture) read(buf []byte) (int, int, error) {
+	// MSG_TRUNC 返回报文的实际长度
+	n, _, err := syscall.Recvfrom(c.fd, buf, syscall.MSG_TRUNC)
+	if err != nil {
+		if err == syscall.EAGAIN || err == syscall.EINTR {
+			return 0, 0, errTimeout
+		}
+		return 0, 0, err
+	}
+	if n > len(buf) {
+		return len(buf), n, nil
+	}
+	return n, n, nil
+}
+
+func (c *capture) close() {
+	syscall.Close(c.fd)
+}
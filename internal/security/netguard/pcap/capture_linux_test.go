@@ -0,0 +1,82 @@
+//go:build linux
+
+package pcap
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Loopback(t *testing.T) {
+	if _, err := openCapture(net.IPv4(127, 0, 0, 1), DefaultSnapLen); err != nil {
+		t.Skipf("AF_PACKET 不可用 (需要 CAP_NET_RAW): %v", err)
+	}
+
+	dir := t.TempDir()
+	r, err := New(Config{Dir: dir, MaxDuration: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	target := net.IPv4(127, 0, 0, 3)
+	path, err := r.Capture(target, 9, time.Now(), Meta{Source: "test", Message: "probe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := r.Capture(target, 9, time.Now(), Meta{}); err != nil || again != path {
+		t.Errorf("同一对端重复抓包 = %s, %v", again, err)
+	}
+
+	// 只有发往 127.0.0.3 的报文应被保存
+	for _, addr := range []string{"127.0.0.2:9", "127.0.0.3:9"} {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			t.Skipf("无法创建 UDP socket: %v", err)
+		}
+		conn.Write([]byte("pcap probe"))
+		conn.Close()
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := os.Stat(path); err == nil && info.Size() > fileHeaderLen {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	r.Stop()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) <= fileHeaderLen {
+		t.Fatal("未抓到发往回环的报文")
+	}
+	for off := fileHeaderLen; off+recordHeaderLen <= len(data); {
+		caplen := int(binary.LittleEndian.Uint32(data[off+8:]))
+		pkt := data[off+recordHeaderLen : off+recordHeaderLen+caplen]
+		if !matchHost(pkt, target) {
+			t.Errorf("保存了其他对端的报文: %x", pkt[:20])
+		}
+		off += recordHeaderLen + caplen
+	}
+
+	var rec Record
+	meta, err := os.ReadFile(strings.TrimSuffix(path, extPcap) + extMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(meta, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Message != "probe" || rec.File != filepath.Base(path) || rec.Reason != "stopped" || rec.Packets == 0 {
+		t.Errorf("抓包记录 = %+v", rec)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package pcap
+
+import (
+	"errors"
+	"net"
+)
+
+type capture struct{}
+
+func openCapture(ip net.IP, snapLen int) (*capture, error) {
+	return nil, errors.New("pcap: packet capture is only supported on linux")
+}
+
+func (c *capture) read(buf []byte) (int, int, error) { return 0, 0, errTimeout }
+
+func (c *capture) close() {}
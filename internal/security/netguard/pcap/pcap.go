@@ -0,0 +1,461 @@
+// Package pcap 网络告警抓包取证
+// 网络告警触发后，对告警对端 IP 的收发报文抓包一段时间 (按时长与大小截止)，以 pcap 格式 (LINKTYPE_RAW，
+// 报文从 IP 头开始) 保存到取证目录，同名 .json 文件记录触发抓包的告警。抓包目录按总量上限淘汰最旧的记录。
+// 抓包需要 CAP_NET_RAW
+package pcap
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// 默认配置
+const (
+	DefaultMaxDuration   = time.Minute
+	DefaultMaxBytes      = 10 << 20
+	DefaultSnapLen       = 65535
+	DefaultMaxConcurrent = 4
+	DefaultQuota         = 512 << 20
+)
+
+// linkTypeRaw 报文从 IP 头开始，不含链路层头
+const linkTypeRaw = 101
+
+// 文件头 24 字节，每个报文记录头 16 字节
+const (
+	fileHeaderLen   = 24
+	recordHeaderLen = 16
+)
+
+// 文件扩展名
+const (
+	extPcap = ".pcap"
+	extMeta = ".json"
+)
+
+var (
+	// ErrBusy 同时进行的抓包数已达上限
+	ErrBusy = errors.New("pcap: too many captures in progress")
+	// ErrStopped 抓包已停止
+	ErrStopped = errors.New("pcap: stopped")
+	// errTimeout 读取超时，用于及时响应停止与截止时间
+	errTimeout = errors.New("pcap: read timeout")
+)
+
+// Config 抓包配置
+type Config struct {
+	// 抓包目录 (仅属主可访问)
+	Dir string
+	// 单次抓包时长上限，<=0 时使用 DefaultMaxDuration
+	MaxDuration time.Duration
+	// 单个抓包文件的大小上限，<=0 时使用 DefaultMaxBytes
+	MaxBytes int64
+	// 每个报文保存的最大长度，<=0 时使用 DefaultSnapLen
+	SnapLen int
+	// 同时进行的抓包数上限，<=0 时使用 DefaultMaxConcurrent
+	MaxConcurrent int
+	// 抓包目录总量上限，超过时淘汰最旧的记录；<=0 时使用 DefaultQuota
+	Quota int64
+}
+
+// Meta 触发抓包的告警
+type Meta struct {
+	// 告警来源，如 bandwidth、dns
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	PID     int    `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+	// 对端地理信息 (geoip.Info.String)
+	Geo string `json:"geo,omitempty"`
+}
+
+// Record 抓包记录，保存为与 pcap 文件同名的 .json
+type Record struct {
+	Meta
+	File       string    `json:"file"`
+	RemoteIP   string    `json:"remote_ip"`
+	RemotePort uint16    `json:"remote_port,omitempty"`
+	AlertTime  time.Time `json:"alert_time"`
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at"`
+	Packets    int       `json:"packets"`
+	Bytes      int64     `json:"bytes"`
+	// 结束原因: duration / size / stopped / error，抓包中为空
+	Reason string `json:"reason,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// stored 已完成的抓包，name 为不含扩展名的文件名
+type stored struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+// Recorder 告警抓包管理，可并发使用
+type Recorder struct {
+	cfg Config
+
+	mu sync.Mutex
+	// 进行中的抓包: 对端 IP -> pcap 文件路径
+	active map[string]string
+	// 已完成的抓包，按完成时间排序
+	files []stored
+	total int64
+
+	stopped atomic.Bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New 创建抓包管理，统计目录中已有的抓包并按总量上限淘汰
+func New(cfg Config) (*Recorder, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("pcap: dir is required")
+	}
+	if cfg.MaxDuration <= 0 {
+		cfg.MaxDuration = DefaultMaxDuration
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultMaxBytes
+	}
+	if cfg.SnapLen <= 0 {
+		cfg.SnapLen = DefaultSnapLen
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if cfg.Quota <= 0 {
+		cfg.Quota = DefaultQuota
+	}
+	if cfg.MaxBytes > cfg.Quota {
+		cfg.MaxBytes = cfg.Quota
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return nil, err
+	}
+	// 已存在的目录同样收紧权限
+	if err := os.Chmod(cfg.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{cfg: cfg, active: make(map[string]string), stop: make(chan struct{})}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.evict(0)
+	r.mu.Unlock()
+	return r, nil
+}
+
+// load 统计目录中已完成的抓包，中途退出留下的记录同样计入总量
+func (r *Recorder) load() error {
+	entries, err := os.ReadDir(r.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*stored)
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != extPcap && ext != extMeta) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ext)
+		s := byName[name]
+		if s == nil {
+			s = &stored{name: name}
+			byName[name] = s
+		}
+		s.size += info.Size()
+		if info.ModTime().After(s.modTime) {
+			s.modTime = info.ModTime()
+		}
+	}
+	for _, s := range byName {
+		r.files = append(r.files, *s)
+		r.total += s.size
+	}
+	sort.Slice(r.files, func(i, j int) bool { return r.files[i].modTime.Before(r.files[j].modTime) })
+	return nil
+}
+
+// Capture 开始对告警对端抓包，返回 pcap 文件路径；抓包在后台进行，按时长或大小上限结束
+// 该对端已在抓包时返回进行中的文件，不重复抓包
+func (r *Recorder) Capture(ip net.IP, port uint16, alertTime time.Time, meta Meta) (string, error) {
+	if r.stopped.Load() {
+		return "", ErrStopped
+	}
+	if ip == nil || ip.IsUnspecified() {
+		return "", fmt.Errorf("pcap: invalid address %v", ip)
+	}
+	key := ip.String()
+
+	r.mu.Lock()
+	if path, ok := r.active[key]; ok {
+		r.mu.Unlock()
+		return path, nil
+	}
+	if len(r.active) >= r.cfg.MaxConcurrent {
+		r.mu.Unlock()
+		return "", ErrBusy
+	}
+	// 为进行中的抓包预留空间，保证全部完成后不超过总量上限
+	r.evict(int64(len(r.active)+1) * r.cfg.MaxBytes)
+	name := fileName(ip, port, alertTime)
+	path := filepath.Join(r.cfg.Dir, name+extPcap)
+	r.active[key] = path
+	r.mu.Unlock()
+
+	c, err := openCapture(ip, r.cfg.SnapLen)
+	if err != nil {
+		r.finish(key, name)
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		c.close()
+		r.finish(key, name)
+		return "", err
+	}
+
+	rec := &Record{
+		Meta:       meta,
+		File:       filepath.Base(path),
+		RemoteIP:   key,
+		RemotePort: port,
+		AlertTime:  alertTime,
+		StartedAt:  time.Now(),
+	}
+	r.saveRecord(name, rec)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(c, f, ip, rec)
+		rec.EndedAt = time.Now()
+		f.Close()
+		c.close()
+		r.saveRecord(name, rec)
+		r.finish(key, name)
+		logger.Info("告警抓包结束", "file", path, "packets", rec.Packets, "bytes", rec.Bytes, "reason", rec.Reason)
+	}()
+	return path, nil
+}
+
+// run 抓包直到时长或大小上限，结果写入 rec
+func (r *Recorder) run(c *capture, f *os.File, ip net.IP, rec *Record) {
+	w, err := newWriter(f, r.cfg.SnapLen)
+	if err != nil {
+		rec.Reason, rec.Error = "error", err.Error()
+		return
+	}
+	rec.Bytes = w.size
+	deadline := rec.StartedAt.Add(r.cfg.MaxDuration)
+	buf := make([]byte, r.cfg.SnapLen)
+	for {
+		select {
+		case <-r.stop:
+			rec.Reason = "stopped"
+			return
+		default:
+		}
+		if !time.Now().Before(deadline) {
+			rec.Reason = "duration"
+			return
+		}
+		n, orig, err := c.read(buf)
+		if err != nil {
+			if errors.Is(err, errTimeout) {
+				continue
+			}
+			rec.Reason, rec.Error = "error", err.Error()
+			return
+		}
+		// 附加过滤器之前进入队列的报文可能不属于该对端
+		if n == 0 || !matchHost(buf[:n], ip) {
+			continue
+		}
+		if w.size+int64(recordHeaderLen+n) > r.cfg.MaxBytes {
+			rec.Reason = "size"
+			return
+		}
+		if err := w.writePacket(time.Now(), buf[:n], orig); err != nil {
+			rec.Reason, rec.Error = "error", err.Error()
+			return
+		}
+		rec.Packets++
+		rec.Bytes = w.size
+	}
+}
+
+// finish 结束抓包，已写入的文件计入总量
+func (r *Recorder) finish(key, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.active, key)
+	s := stored{name: name, modTime: time.Now()}
+	for _, ext := range []string{extPcap, extMeta} {
+		if info, err := os.Stat(filepath.Join(r.cfg.Dir, name+ext)); err == nil {
+			s.size += info.Size()
+		}
+	}
+	if s.size == 0 {
+		return
+	}
+	r.files = append(r.files, s)
+	r.total += s.size
+	r.evict(int64(len(r.active)) * r.cfg.MaxBytes)
+}
+
+// evict 淘汰最旧的抓包，使已完成的抓包与 reserve 之和不超过总量上限，需持有锁
+func (r *Recorder) evict(reserve int64) {
+	for len(r.files) > 0 && r.total+reserve > r.cfg.Quota {
+		s := r.files[0]
+		r.files = r.files[1:]
+		r.total -= s.size
+		logger.Info("抓包目录超过总量上限，淘汰最旧的抓包", "file", s.name+extPcap)
+		for _, ext := range []string{extPcap, extMeta} {
+			if err := os.Remove(filepath.Join(r.cfg.Dir, s.name+ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				logger.Warn("删除抓包文件失败", "file", s.name+ext, "error", err)
+			}
+		}
+	}
+}
+
+// saveRecord 写入抓包记录
+func (r *Recorder) saveRecord(name string, rec *Record) {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(filepath.Join(r.cfg.Dir, name+extMeta), data, 0600)
+	}
+	if err != nil {
+		logger.Warn("保存抓包记录失败", "file", name+extMeta, "error", err)
+	}
+}
+
+// Stop 结束进行中的抓包并等待文件写完，之后不再接受抓包
+func (r *Recorder) Stop() {
+	if r.stopped.Swap(true) {
+		return
+	}
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// fileName 抓包文件名 (不含扩展名)，如 20240102T150405.000_203.0.113.7_443
+func fileName(ip net.IP, port uint16, t time.Time) string {
+	return fmt.Sprintf("%s_%s_%d", t.Format("20060102T150405.000"), strings.ReplaceAll(ip.String(), ":", "-"), port)
+}
+
+// matchHost 报文 (从 IP 头开始) 的源或目的地址是否为 ip
+func matchHost(pkt []byte, ip net.IP) bool {
+	if len(pkt) == 0 {
+		return false
+	}
+	switch pkt[0] >> 4 {
+	case 4:
+		ip4 := ip.To4()
+		if ip4 == nil || len(pkt) < 20 {
+			return false
+		}
+		return net.IP(pkt[12:16]).Equal(ip4) || net.IP(pkt[16:20]).Equal(ip4)
+	case 6:
+		if ip.To4() != nil || len(pkt) < 40 {
+			return false
+		}
+		return net.IP(pkt[8:24]).Equal(ip) || net.IP(pkt[24:40]).Equal(ip)
+	}
+	return false
+}
+
+// writer pcap 文件写入
+type writer struct {
+	w    io.Writer
+	size int64
+}
+
+// newWriter 写入 pcap 文件头 (微秒时间戳、小端字节序)
+func newWriter(w io.Writer, snapLen int) (*writer, error) {
+	hdr := make([]byte, fileHeaderLen)
+	binary.LittleEndian.PutUint32(hdr[0:], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(snapLen))
+	binary.LittleEndian.PutUint32(hdr[20:], linkTypeRaw)
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &writer{w: w, size: fileHeaderLen}, nil
+}
+
+// writePacket 写入一个报文，orig 为截断前的长度
+func (w *writer) writePacket(t time.Time, data []byte, orig int) error {
+	if orig < len(data) {
+		orig = len(data)
+	}
+	hdr := make([]byte, recordHeaderLen)
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(t.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(t.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(orig))
+	if _, err := w.w.Write(hdr); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	w.size += int64(recordHeaderLen + len(data))
+	return nil
+}
+
+// writeFileAtomic 写入临时文件后改名，避免留下不完整的记录
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var defaultRecorder atomic.Pointer[Recorder]
+
+// SetDefault 设置全局抓包管理 (由主程序按配置设置，各网络告警模块触发抓包)
+func SetDefault(r *Recorder) {
+	defaultRecorder.Store(r)
+}
+
+// Default 返回全局抓包管理，未启用时为 nil
+func Default() *Recorder {
+	return defaultRecorder.Load()
+}
@@ -0,0 +1,131 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newWriter(&buf, 96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := time.Unix(1700000000, 123456000)
+	pkt := []byte{0x45, 0, 0, 20}
+	if err := w.writePacket(ts, pkt, 1500); err != nil {
+		t.Fatal(err)
+	}
+
+	b := buf.Bytes()
+	if int64(len(b)) != w.size || len(b) != fileHeaderLen+recordHeaderLen+len(pkt) {
+		t.Fatalf("size = %d, len = %d", w.size, len(b))
+	}
+	le := binary.LittleEndian
+	if le.Uint32(b[0:]) != 0xa1b2c3d4 || le.Uint16(b[4:]) != 2 || le.Uint16(b[6:]) != 4 {
+		t.Errorf("文件头 = %x", b[:8])
+	}
+	if le.Uint32(b[16:]) != 96 || le.Uint32(b[20:]) != linkTypeRaw {
+		t.Errorf("snaplen / linktype = %d / %d", le.Uint32(b[16:]), le.Uint32(b[20:]))
+	}
+	rec := b[fileHeaderLen:]
+	if le.Uint32(rec[0:]) != 1700000000 || le.Uint32(rec[4:]) != 123456 {
+		t.Errorf("时间戳 = %d.%d", le.Uint32(rec[0:]), le.Uint32(rec[4:]))
+	}
+	if le.Uint32(rec[8:]) != uint32(len(pkt)) || le.Uint32(rec[12:]) != 1500 {
+		t.Errorf("caplen / len = %d / %d", le.Uint32(rec[8:]), le.Uint32(rec[12:]))
+	}
+	if !bytes.Equal(rec[recordHeaderLen:], pkt) {
+		t.Errorf("报文 = %x", rec[recordHeaderLen:])
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	v4 := make([]byte, 20)
+	v4[0] = 0x45
+	copy(v4[12:], net.ParseIP("10.0.0.1").To4())
+	copy(v4[16:], net.ParseIP("203.0.113.7").To4())
+
+	v6 := make([]byte, 40)
+	v6[0] = 0x60
+	copy(v6[8:], net.ParseIP("2001:db8::1"))
+	copy(v6[24:], net.ParseIP("2001:db8::2"))
+
+	tests := []struct {
+		pkt  []byte
+		ip   string
+		want bool
+	}{
+		{v4, "203.0.113.7", true},
+		{v4, "10.0.0.1", true},
+		{v4, "203.0.113.8", false},
+		{v4, "2001:db8::1", false},
+		{v6, "2001:db8::2", true},
+		{v6, "2001:db8::3", false},
+		{v6, "10.0.0.1", false},
+		{v4[:10], "10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(tt.pkt, net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("matchHost(v%d, %s) = %v, want %v", tt.pkt[0]>>4, tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestRecorder_Quota(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for i, name := range []string{"a", "b", "c"} {
+		for _, ext := range []string{extPcap, extMeta} {
+			path := filepath.Join(dir, name+ext)
+			if err := os.WriteFile(path, make([]byte, 50), 0600); err != nil {
+				t.Fatal(err)
+			}
+			mt := old.Add(time.Duration(i) * time.Minute)
+			os.Chtimes(path, mt, mt)
+		}
+	}
+	// 临时文件与其他文件不计入
+	os.WriteFile(filepath.Join(dir, "notes.txt"), make([]byte, 500), 0600)
+
+	r, err := New(Config{Dir: dir, MaxBytes: 100, Quota: 250})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.pcap")); !os.IsNotExist(err) {
+		t.Errorf("最旧的抓包应被淘汰: %v", err)
+	}
+	if r.total != 200 || len(r.files) != 2 {
+		t.Fatalf("total = %d, files = %d", r.total, len(r.files))
+	}
+
+	// 新抓包预留 MaxBytes
+	r.mu.Lock()
+	r.evict(r.cfg.MaxBytes)
+	r.mu.Unlock()
+	if _, err := os.Stat(filepath.Join(dir, "b.json")); !os.IsNotExist(err) {
+		t.Errorf("预留空间时应淘汰 b: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.pcap")); err != nil {
+		t.Errorf("c 不应被淘汰: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("其他文件不应被删除: %v", err)
+	}
+}
+
+func TestRecorder_Stopped(t *testing.T) {
+	r, err := New(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Stop()
+	if _, err := r.Capture(net.ParseIP("203.0.113.7"), 443, time.Now(), Meta{}); err != ErrStopped {
+		t.Errorf("Capture() error = %v, want ErrStopped", err)
+	}
+}
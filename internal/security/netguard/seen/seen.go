@@ -0,0 +1,147 @@
+// Package seen 网络告警去重缓存
+// 同一对象 (如对端 IP、域名) 在有效期内只告警一次，过期后再次出现时重新告警；
+// 配置持久化存储时记录随告警写入，守护进程重启后继续生效
+package seen
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// DefaultTTL 默认有效期
+const DefaultTTL = time.Hour
+
+// pruneInterval 清理过期记录的最小间隔
+const pruneInterval = time.Minute
+
+// Store 持久化存储，storage.KeyedStore[model.NetguardSeen] 实现该接口
+type Store interface {
+	Put(key string, item model.NetguardSeen) error
+	Delete(key string) error
+	LoadAll() ([]model.NetguardSeen, error)
+}
+
+// Config 缓存配置
+type Config struct {
+	// 有效期，<=0 时使用 DefaultTTL
+	TTL time.Duration
+	// 持久化存储，nil 时只保存在内存
+	Store Store
+	// 键前缀，多个缓存共用一个存储时区分各自的记录，如 "dns:"
+	Namespace string
+}
+
+// Cache 带有效期的去重缓存
+type Cache struct {
+	cfg Config
+
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastPrune time.Time
+}
+
+// New 创建缓存，并从存储中加载未过期的记录
+func New(cfg Config) *Cache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	now := time.Now()
+	c := &Cache{cfg: cfg, expires: make(map[string]time.Time), lastPrune: now}
+	if cfg.Store == nil {
+		return c
+	}
+
+	items, err := cfg.Store.LoadAll()
+	if err != nil {
+		logger.Warn("读取网络告警去重记录失败", "error", err)
+		return c
+	}
+	for _, it := range items {
+		if !strings.HasPrefix(it.Key, cfg.Namespace) {
+			continue
+		}
+		key := strings.TrimPrefix(it.Key, cfg.Namespace)
+		exp := time.Unix(it.ExpiresAt, 0)
+		if !exp.After(now) {
+			c.deleteStored(key)
+			continue
+		}
+		// 有效期调短后按新的有效期截断
+		if limit := now.Add(cfg.TTL); exp.After(limit) {
+			exp = limit
+		}
+		c.expires[key] = exp
+	}
+	return c
+}
+
+// Alert 是否应当告警: 未出现过或记录已过期时记录并返回 true，有效期内返回 false
+func (c *Cache) Alert(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if exp, ok := c.expires[key]; ok && now.Before(exp) {
+		return false
+	}
+	exp := now.Add(c.cfg.TTL)
+	c.expires[key] = exp
+	if c.cfg.Store != nil {
+		if err := c.cfg.Store.Put(c.cfg.Namespace+key, model.NetguardSeen{Key: c.cfg.Namespace + key, ExpiresAt: exp.Unix()}); err != nil {
+			logger.Warn("保存网络告警去重记录失败", "key", key, "error", err)
+		}
+	}
+	if now.Sub(c.lastPrune) >= pruneInterval {
+		c.prune(now)
+	}
+	return true
+}
+
+// Seen 有效期内是否已告警 (不记录)
+func (c *Cache) Seen(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exp, ok := c.expires[key]
+	return ok && now.Before(exp)
+}
+
+// Forget 删除记录，下次出现时立即告警
+func (c *Cache) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.expires[key]; ok {
+		delete(c.expires, key)
+		c.deleteStored(key)
+	}
+}
+
+// Len 有效期内的记录数
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prune(time.Now())
+	return len(c.expires)
+}
+
+// prune 删除过期记录，调用方持有锁
+func (c *Cache) prune(now time.Time) {
+	for k, exp := range c.expires {
+		if !now.Before(exp) {
+			delete(c.expires, k)
+			c.deleteStored(k)
+		}
+	}
+	c.lastPrune = now
+}
+
+func (c *Cache) deleteStored(key string) {
+	if c.cfg.Store == nil {
+		return
+	}
+	if err := c.cfg.Store.Delete(c.cfg.Namespace + key); err != nil {
+		logger.Warn("删除网络告警去重记录失败", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,104 @@
+package seen
+
+import (
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memStore 内存实现的持久化存储
+type memStore map[string]model.NetguardSeen
+
+func (m memStore) Put(key string, item model.NetguardSeen) error {
+	m[key] = item
+	return nil
+}
+
+func (m memStore) Delete(key string) error {
+	delete(m, key)
+	return nil
+}
+
+func (m memStore) LoadAll() ([]model.NetguardSeen, error) {
+	var out []model.NetguardSeen
+	for _, it := range m {
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c := New(Config{TTL: time.Minute})
+	now := time.Now()
+	if !c.Alert("1.2.3.4", now) {
+		t.Fatal("首次出现未告警")
+	}
+	if c.Alert("1.2.3.4", now.Add(30*time.Second)) {
+		t.Error("有效期内重复告警")
+	}
+	if !c.Seen("1.2.3.4", now.Add(59*time.Second)) {
+		t.Error("Seen = false within TTL")
+	}
+	// 过期后重新告警，并重新计算有效期
+	if !c.Alert("1.2.3.4", now.Add(time.Minute)) {
+		t.Error("过期后未重新告警")
+	}
+	if c.Alert("1.2.3.4", now.Add(90*time.Second)) {
+		t.Error("再次告警后的有效期内重复告警")
+	}
+
+	c.Forget("1.2.3.4")
+	if !c.Alert("1.2.3.4", now.Add(91*time.Second)) {
+		t.Error("Forget 后未告警")
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	store := memStore{}
+	c := New(Config{TTL: time.Minute, Store: store})
+	now := time.Now()
+	c.Alert("a", now)
+	c.Alert("b", now)
+	if len(store) != 2 {
+		t.Fatalf("store = %v", store)
+	}
+	// 过期记录在清理间隔后从内存与存储中删除
+	c.Alert("c", now.Add(2*time.Minute))
+	if len(c.expires) != 1 || len(store) != 1 {
+		t.Errorf("after prune: cache %v, store %v", c.expires, store)
+	}
+}
+
+func TestCache_Persist(t *testing.T) {
+	store := memStore{}
+	now := time.Now()
+	c := New(Config{TTL: time.Hour, Store: store, Namespace: "ip:"})
+	c.Alert("1.2.3.4", now)
+	store["ip:5.6.7.8"] = model.NetguardSeen{Key: "ip:5.6.7.8", ExpiresAt: now.Add(-time.Minute).Unix()}
+	store["dns:example.com"] = model.NetguardSeen{Key: "dns:example.com", ExpiresAt: now.Add(time.Hour).Unix()}
+	if _, ok := store["ip:1.2.3.4"]; !ok {
+		t.Fatalf("记录未持久化: %v", store)
+	}
+
+	// 重启后加载本命名空间内未过期的记录，过期记录从存储中删除
+	c = New(Config{TTL: time.Hour, Store: store, Namespace: "ip:"})
+	if c.Alert("1.2.3.4", now.Add(time.Minute)) {
+		t.Error("重启后重复告警")
+	}
+	if !c.Alert("5.6.7.8", now) {
+		t.Error("过期记录仍然生效")
+	}
+	if c.Seen("example.com", now) {
+		t.Error("加载了其他命名空间的记录")
+	}
+	if _, ok := store["dns:example.com"]; !ok {
+		t.Error("删除了其他命名空间的记录")
+	}
+
+	// 有效期调短后按新有效期截断
+	c = New(Config{TTL: time.Minute, Store: store, Namespace: "ip:"})
+	if c.Seen("1.2.3.4", time.Now().Add(2*time.Minute)) {
+		t.Error("调短有效期后旧记录未截断")
+	}
+}
@@ -0,0 +1,239 @@
+// Package whitelist 网络连接白名单
+// 规则为 IP 或 CIDR，按来源分为本地配置、策略下发与运行时添加 (本机接口、调试工具)。
+// 配置与策略规则随配置重载、策略同步整体替换；运行时添加的规则写入存储，守护进程重启后恢复
+package whitelist
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// Source 规则来源
+type Source string
+
+const (
+	SourceConfig Source = "config" // 本地配置 (security.netguard.whitelist)
+	SourcePolicy Source = "policy" // 策略同步下发
+	SourceManual Source = "manual" // 运行时添加
+)
+
+var (
+	// ErrInvalidRule 规则不是合法的 IP 或 CIDR
+	ErrInvalidRule = errors.New("whitelist: invalid rule")
+	// ErrNotFound 规则不存在
+	ErrNotFound = errors.New("whitelist: rule not found")
+	// ErrReadOnly 配置与策略规则只能通过配置重载、策略同步修改
+	ErrReadOnly = errors.New("whitelist: rule is managed by config or policy")
+)
+
+// Rule 一条白名单规则
+type Rule struct {
+	// 规范化后的 IP 或 CIDR，如 "10.0.0.0/8"、"2001:db8::1"
+	Value  string
+	Source Source
+	Desc   string
+	// 添加时间，只对运行时添加的规则有效
+	CreatedAt time.Time
+
+	net *net.IPNet
+}
+
+// Store 持久化存储，storage.KeyedStore[model.NetguardWhitelistRule] 实现该接口
+type Store interface {
+	Put(key string, item model.NetguardWhitelistRule) error
+	Delete(key string) error
+	LoadAll() ([]model.NetguardWhitelistRule, error)
+}
+
+// Manager 白名单，可并发读写
+type Manager struct {
+	store Store
+
+	mu     sync.RWMutex
+	config []Rule
+	policy []Rule
+	manual map[string]Rule
+}
+
+// New 创建白名单，rules 为本地配置的规则；store 非 nil 时加载已持久化的运行时规则
+func New(rules []string, store Store) (*Manager, error) {
+	m := &Manager{store: store, manual: make(map[string]Rule)}
+	if err := m.SetRules(SourceConfig, rules, nil); err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return m, nil
+	}
+
+	items, err := store.LoadAll()
+	if err != nil {
+		logger.Warn("读取网络白名单失败", "error", err)
+		return m, nil
+	}
+	for _, it := range items {
+		r, err := NewRule(it.Value)
+		if err != nil {
+			logger.Warn("忽略无效的网络白名单规则", "rule", it.Value)
+			continue
+		}
+		r.Source, r.Desc, r.CreatedAt = SourceManual, it.Desc, time.Unix(it.CreatedAt, 0)
+		m.manual[r.Value] = r
+	}
+	return m, nil
+}
+
+// NewRule 解析规则，单个 IP 规范化为地址本身，CIDR 规范化为网络地址
+func NewRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, s)
+		}
+		return Rule{Value: n.String(), net: n}, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, s)
+	}
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, bits = ip4, 32
+	}
+	return Rule{Value: ip.String(), net: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}}, nil
+}
+
+// SetRules 整体替换配置或策略规则，descs 为对应规则的说明 (可为 nil)；任一规则无效时不做修改
+func (m *Manager) SetRules(source Source, rules []string, descs []string) error {
+	if source != SourceConfig && source != SourcePolicy {
+		return fmt.Errorf("whitelist: cannot replace %s rules", source)
+	}
+	parsed := make([]Rule, 0, len(rules))
+	for i, s := range rules {
+		r, err := NewRule(s)
+		if err != nil {
+			return err
+		}
+		r.Source = source
+		if i < len(descs) {
+			r.Desc = descs[i]
+		}
+		parsed = append(parsed, r)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if source == SourceConfig {
+		m.config = parsed
+	} else {
+		m.policy = parsed
+	}
+	return nil
+}
+
+// AddRule 添加运行时规则并持久化，规则已存在时更新说明
+func (m *Manager) AddRule(value, desc string) (Rule, error) {
+	r, err := NewRule(value)
+	if err != nil {
+		return Rule{}, err
+	}
+	r.Source, r.Desc, r.CreatedAt = SourceManual, desc, time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.manual[r.Value]; ok {
+		r.CreatedAt = old.CreatedAt
+	}
+	if m.store != nil {
+		item := model.NetguardWhitelistRule{Value: r.Value, Desc: r.Desc, CreatedAt: r.CreatedAt.Unix()}
+		if err := m.store.Put(r.Value, item); err != nil {
+			return Rule{}, fmt.Errorf("whitelist: save rule: %w", err)
+		}
+	}
+	m.manual[r.Value] = r
+	return r, nil
+}
+
+// RemoveRule 删除运行时规则，配置与策略规则返回 ErrReadOnly
+func (m *Manager) RemoveRule(value string) error {
+	r, err := NewRule(value)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.manual[r.Value]; !ok {
+		for _, list := range [][]Rule{m.config, m.policy} {
+			for _, c := range list {
+				if c.Value == r.Value {
+					return ErrReadOnly
+				}
+			}
+		}
+		return ErrNotFound
+	}
+	if m.store != nil {
+		if err := m.store.Delete(r.Value); err != nil {
+			return fmt.Errorf("whitelist: delete rule: %w", err)
+		}
+	}
+	delete(m.manual, r.Value)
+	return nil
+}
+
+// List 返回全部规则，依次为配置、策略与运行时规则 (按添加时间排序)
+func (m *Manager) List() []Rule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Rule, 0, len(m.config)+len(m.policy)+len(m.manual))
+	out = append(out, m.config...)
+	out = append(out, m.policy...)
+	start := len(out)
+	for _, r := range m.manual {
+		out = append(out, r)
+	}
+	manual := out[start:]
+	sort.Slice(manual, func(i, j int) bool {
+		if !manual[i].CreatedAt.Equal(manual[j].CreatedAt) {
+			return manual[i].CreatedAt.Before(manual[j].CreatedAt)
+		}
+		return manual[i].Value < manual[j].Value
+	})
+	return out
+}
+
+// Allowed ip 是否命中任一规则
+func (m *Manager) Allowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, list := range [][]Rule{m.config, m.policy} {
+		for _, r := range list {
+			if r.net.Contains(ip) {
+				return true
+			}
+		}
+	}
+	for _, r := range m.manual {
+		if r.net.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAllowed 字符串形式的 Allowed，无法解析的地址视为不在白名单中
+func (m *Manager) IsAllowed(ip string) bool {
+	return m.Allowed(net.ParseIP(ip))
+}
@@ -0,0 +1,161 @@
+package whitelist
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memStore 内存存储
+type memStore struct {
+	mu    sync.Mutex
+	items map[string]model.NetguardWhitelistRule
+}
+
+func newMemStore() *memStore {
+	return &memStore{items: make(map[string]model.NetguardWhitelistRule)}
+}
+
+func (s *memStore) Put(key string, item model.NetguardWhitelistRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = item
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func (s *memStore) LoadAll() ([]model.NetguardWhitelistRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.NetguardWhitelistRule
+	for _, it := range s.items {
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+func TestManager_Sources(t *testing.T) {
+	if _, err := New([]string{"example.com"}, nil); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("New accepted a hostname: %v", err)
+	}
+	m, err := New([]string{"127.0.0.1", "10.1.2.3/8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetRules(SourcePolicy, []string{"2001:db8::/32"}, []string{"总部"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddRule(" 192.0.2.7 ", "备份服务器"); err != nil {
+		t.Fatal(err)
+	}
+	for ip, want := range map[string]bool{
+		"127.0.0.1":   true,
+		"10.200.0.1":  true,
+		"2001:db8::5": true,
+		"192.0.2.7":   true,
+		"192.0.2.8":   false,
+		"bad":         false,
+	} {
+		if got := m.IsAllowed(ip); got != want {
+			t.Errorf("IsAllowed(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	rules := m.List()
+	want := []struct {
+		value  string
+		source Source
+	}{
+		{"127.0.0.1", SourceConfig}, {"10.0.0.0/8", SourceConfig},
+		{"2001:db8::/32", SourcePolicy}, {"192.0.2.7", SourceManual},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("List() = %+v", rules)
+	}
+	for i, w := range want {
+		if rules[i].Value != w.value || rules[i].Source != w.source {
+			t.Errorf("rule %d = %s/%s, want %s/%s", i, rules[i].Value, rules[i].Source, w.value, w.source)
+		}
+	}
+
+	// 配置与策略规则不能通过接口删除，无效规则不改变已有规则
+	if err := m.RemoveRule("10.0.0.0/8"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("RemoveRule(config) = %v", err)
+	}
+	if err := m.RemoveRule("192.0.2.9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveRule(missing) = %v", err)
+	}
+	if err := m.SetRules(SourcePolicy, []string{"198.51.100.1", "nope"}, nil); err == nil {
+		t.Error("SetRules accepted an invalid rule")
+	}
+	if !m.IsAllowed("2001:db8::5") {
+		t.Error("invalid policy update replaced existing rules")
+	}
+	if err := m.SetRules(SourceManual, nil, nil); err == nil {
+		t.Error("SetRules replaced manual rules")
+	}
+}
+
+func TestManager_Persistence(t *testing.T) {
+	store := newMemStore()
+	m, err := New(nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddRule("203.0.113.0/24", "合作方"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddRule("198.51.100.4", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RemoveRule("198.51.100.4"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后恢复运行时规则
+	m, err = New(nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := m.List()
+	if len(rules) != 1 || rules[0].Value != "203.0.113.0/24" || rules[0].Desc != "合作方" || rules[0].Source != SourceManual {
+		t.Fatalf("restored rules = %+v", rules)
+	}
+	if !m.IsAllowed("203.0.113.77") || m.IsAllowed("198.51.100.4") {
+		t.Error("restored rules do not match")
+	}
+}
+
+func TestManager_Concurrent(t *testing.T) {
+	m, err := New(nil, newMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				m.AddRule("192.0.2.1", "")
+				m.RemoveRule("192.0.2.1")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				m.IsAllowed("192.0.2.1")
+				m.List()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,84 @@
+package pkgverify
+
+import (
+	"bufio"
+	"crypto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// dpkgDB Debian 系 (dpkg) 软件包数据库
+// 每个软件包的文件摘要记录在 /var/lib/dpkg/info/<包名>[:<架构>].md5sums，
+// 每行为 "<md5>  <不含前导 / 的路径>"
+type dpkgDB struct {
+	infoDir string
+}
+
+func openDpkg(root string) DB {
+	infoDir := filepath.Join(root, "var/lib/dpkg/info")
+	if fi, err := os.Stat(infoDir); err != nil || !fi.IsDir() {
+		return nil
+	}
+	return &dpkgDB{infoDir: infoDir}
+}
+
+func (d *dpkgDB) Name() string { return "dpkg" }
+
+// Stamp 安装或升级软件包时 info 目录中的文件会被替换，目录修改时间随之变化
+func (d *dpkgDB) Stamp() time.Time {
+	fi, err := os.Stat(d.infoDir)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+func (d *dpkgDB) Lookup(paths []string) (map[string]FileRecord, error) {
+	wanted := wantedPaths(paths)
+	files, err := filepath.Glob(filepath.Join(d.infoDir, "*.md5sums"))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]FileRecord)
+	for _, file := range files {
+		pkg := strings.TrimSuffix(filepath.Base(file), ".md5sums")
+		if i := strings.IndexByte(pkg, ':'); i >= 0 {
+			pkg = pkg[:i]
+		}
+		if err := scanMd5sums(file, func(digest, path string) {
+			orig, ok := wanted[path]
+			if !ok {
+				return
+			}
+			// 同一文件按原始路径登记的记录优先于别名
+			if _, exists := records[orig]; !exists || path == orig {
+				records[orig] = FileRecord{Package: pkg, Algo: crypto.MD5, Digest: digest}
+			}
+		}); err != nil {
+			continue
+		}
+	}
+	return records, nil
+}
+
+// scanMd5sums 逐行读取 md5sums 文件
+func scanMd5sums(file string, fn func(digest, path string)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		digest, path, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(digest) != 32 {
+			continue
+		}
+		fn(digest, "/"+strings.TrimPrefix(path, "/"))
+	}
+	return scanner.Err()
+}
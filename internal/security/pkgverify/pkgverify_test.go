@@ -0,0 +1,170 @@
+package pkgverify
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func statusOf(results []Result) map[string]Status {
+	m := make(map[string]Status)
+	for _, r := range results {
+		m[r.Path] = r.Status
+	}
+	return m
+}
+
+func TestDpkgVerify(t *testing.T) {
+	root := t.TempDir()
+	bin := filepath.Join(t.TempDir(), "filewatcherd")
+	gone := filepath.Join(filepath.Dir(bin), "gone")
+	other := filepath.Join(filepath.Dir(bin), "other")
+	writeFile(t, bin, "original")
+	writeFile(t, other, "x")
+
+	md5sums := md5Hex("original") + "  " + strings.TrimPrefix(bin, "/") + "\n" +
+		md5Hex("gone") + "  " + strings.TrimPrefix(gone, "/") + "\n"
+	writeFile(t, filepath.Join(root, "var/lib/dpkg/info/filewatcher:amd64.md5sums"), md5sums)
+
+	db, err := Open(root)
+	if err != nil || db.Name() != "dpkg" {
+		t.Fatalf("Open = %v, %v", db, err)
+	}
+	c := NewChecker(db, []string{bin, gone, other})
+
+	got := statusOf(c.Verify())
+	want := map[string]Status{bin: StatusOK, gone: StatusMissing, other: StatusUnowned}
+	for p, s := range want {
+		if got[p] != s {
+			t.Errorf("%s: status = %s, want %s", p, got[p], s)
+		}
+	}
+
+	writeFile(t, bin, "tampered and re-signed")
+	results := c.Verify()
+	if results[0].Status != StatusModified || results[0].Package != "filewatcher" || results[0].Expected != md5Hex("original") {
+		t.Errorf("修改后 Verify()[0] = %+v", results[0])
+	}
+}
+
+func TestOpenNoDB(t *testing.T) {
+	if _, err := Open(t.TempDir()); err != ErrNoPackageDB {
+		t.Errorf("Open = %v, want ErrNoPackageDB", err)
+	}
+}
+
+func TestOpenLegacyRPM(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "var/lib/rpm/Packages"), "")
+	if db, err := Open(root); db != nil || err == nil {
+		t.Errorf("Open legacy rpmdb = %v, %v, want unsupported error", db, err)
+	}
+}
+
+// headerEntry 构造测试头部的索引项
+type headerEntry struct {
+	tag, typ int32
+	count    int32
+	data     []byte
+}
+
+func stringArray(ss ...string) headerEntry {
+	var b []byte
+	for _, s := range ss {
+		b = append(append(b, s...), 0)
+	}
+	return headerEntry{typ: rpmTypeStringArray, count: int32(len(ss)), data: b}
+}
+
+func int32Array(vs ...int32) headerEntry {
+	b := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		binary.BigEndian.PutUint32(b[i*4:], uint32(v))
+	}
+	return headerEntry{typ: rpmTypeInt32, count: int32(len(vs)), data: b}
+}
+
+func buildHeader(entries map[int32]headerEntry) []byte {
+	var index, data []byte
+	for tag, e := range entries {
+		// int32 数据需 4 字节对齐
+		for e.typ == rpmTypeInt32 && len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+		var ent [16]byte
+		binary.BigEndian.PutUint32(ent[0:], uint32(tag))
+		binary.BigEndian.PutUint32(ent[4:], uint32(e.typ))
+		binary.BigEndian.PutUint32(ent[8:], uint32(len(data)))
+		binary.BigEndian.PutUint32(ent[12:], uint32(e.count))
+		index = append(index, ent[:]...)
+		data = append(data, e.data...)
+	}
+	var head [8]byte
+	binary.BigEndian.PutUint32(head[0:], uint32(len(entries)))
+	binary.BigEndian.PutUint32(head[4:], uint32(len(data)))
+	return append(append(head[:], index...), data...)
+}
+
+func TestRPMVerify(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "filewatcherd")
+	writeFile(t, bin, "original")
+	sum := sha256.Sum256([]byte("original"))
+
+	name := stringArray("filewatcher")
+	name.typ = rpmTypeString
+	blob := buildHeader(map[int32]headerEntry{
+		rpmTagName:           name,
+		rpmTagBaseNames:      stringArray("filewatcherd", "config.yml"),
+		rpmTagDirNames:       stringArray(dir+"/", "/etc/filewatcherd/"),
+		rpmTagDirIndexes:     int32Array(0, 1),
+		rpmTagFileDigests:    stringArray(hex.EncodeToString(sum[:]), ""),
+		rpmTagFileDigestAlgo: int32Array(8),
+	})
+
+	db := &rpmDB{path: filepath.Join(dir, "rpmdb.sqlite"), loadBlobs: func(string) ([][]byte, error) {
+		return [][]byte{[]byte("garbage"), blob}, nil
+	}}
+	c := NewChecker(db, []string{bin})
+
+	if r := c.Verify()[0]; r.Status != StatusOK || r.Package != "filewatcher" || r.Manager != "rpm" {
+		t.Fatalf("Verify() = %+v", r)
+	}
+	writeFile(t, bin, "tampered")
+	if r := c.Verify()[0]; r.Status != StatusModified {
+		t.Errorf("修改后 Verify() = %+v", r)
+	}
+}
+
+func TestCandidatesUsrMerge(t *testing.T) {
+	got := candidates("/usr/bin/filewatcherd")
+	found := false
+	for _, c := range got {
+		if c == "/bin/filewatcherd" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("candidates = %v, want /bin alias", got)
+	}
+}
@@ -0,0 +1,70 @@
+package pkgverify
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// rpmDB Red Hat 系 (rpm) 软件包数据库
+// 仅支持 rpm 4.16 起的 sqlite 格式 (rpmdb.sqlite)，旧的 Berkeley DB / ndb 格式返回不支持
+type rpmDB struct {
+	path string
+	// loadBlobs 读取全部软件包头部，测试时可替换
+	loadBlobs func(path string) ([][]byte, error)
+}
+
+// rpmLegacyFiles 旧格式数据库文件
+var rpmLegacyFiles = []string{"Packages", "Packages.db"}
+
+func openRPM(root string) (DB, error) {
+	for _, dir := range []string{"var/lib/rpm", "usr/lib/sysimage/rpm"} {
+		path := filepath.Join(root, dir, "rpmdb.sqlite")
+		if _, err := os.Stat(path); err == nil {
+			return &rpmDB{path: path, loadBlobs: loadSQLiteBlobs}, nil
+		}
+		for _, name := range rpmLegacyFiles {
+			if _, err := os.Stat(filepath.Join(root, dir, name)); err == nil {
+				return nil, fmt.Errorf("pkgverify: unsupported rpm database format: %s", filepath.Join(dir, name))
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (r *rpmDB) Name() string { return "rpm" }
+
+// Stamp sqlite 数据库 (含 WAL) 的最后修改时间
+func (r *rpmDB) Stamp() time.Time {
+	var stamp time.Time
+	for _, p := range []string{r.path, r.path + "-wal"} {
+		if fi, err := os.Stat(p); err == nil && fi.ModTime().After(stamp) {
+			stamp = fi.ModTime()
+		}
+	}
+	return stamp
+}
+
+func (r *rpmDB) Lookup(paths []string) (map[string]FileRecord, error) {
+	blobs, err := r.loadBlobs(r.path)
+	if err != nil {
+		return nil, err
+	}
+	wanted := wantedPaths(paths)
+
+	records := make(map[string]FileRecord)
+	for _, blob := range blobs {
+		// 单个损坏的头部不影响其他软件包
+		_ = rpmFiles(blob, func(path string, rec FileRecord) {
+			orig, ok := wanted[path]
+			if !ok || rec.Digest == "" {
+				return
+			}
+			if _, exists := records[orig]; !exists || path == orig {
+				records[orig] = rec
+			}
+		})
+	}
+	return records, nil
+}
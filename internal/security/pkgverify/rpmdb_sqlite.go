@@ -0,0 +1,40 @@
+package pkgverify
+
+import (
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// loadSQLiteBlobs 以只读方式读取 rpmdb.sqlite 中全部软件包头部
+func loadSQLiteBlobs(path string) ([][]byte, error) {
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?mode=ro"), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pkgverify: open rpmdb: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("pkgverify: open rpmdb: %w", err)
+	}
+	defer sqlDB.Close()
+
+	rows, err := sqlDB.Query("SELECT blob FROM Packages")
+	if err != nil {
+		return nil, fmt.Errorf("pkgverify: read rpmdb: %w", err)
+	}
+	defer rows.Close()
+
+	var blobs [][]byte
+	for rows.Next() {
+		var blob []byte
+		if err := rows.Scan(&blob); err != nil {
+			return nil, fmt.Errorf("pkgverify: read rpmdb: %w", err)
+		}
+		blobs = append(blobs, blob)
+	}
+	return blobs, rows.Err()
+}
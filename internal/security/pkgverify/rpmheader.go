@@ -0,0 +1,179 @@
+package pkgverify
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ==========================================
+// rpm 头部解析
+// ==========================================
+//
+// rpmdb 中每个软件包存储为不带 lead/magic 的头部:
+//   int32 索引数 il | int32 数据长度 dl | il 个 16 字节索引 (tag, type, offset, count) | 数据区
+// 所有整数为大端序。文件列表以 BASENAMES + DIRNAMES[DIRINDEXES] 组合得到完整路径
+
+// 使用到的头部标签
+const (
+	rpmTagName           = 1000
+	rpmTagFileDigests    = 1035
+	rpmTagDirIndexes     = 1116
+	rpmTagBaseNames      = 1117
+	rpmTagDirNames       = 1118
+	rpmTagFileDigestAlgo = 5011
+)
+
+// 头部数据类型
+const (
+	rpmTypeInt32       = 4
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// rpmDigestAlgos FILEDIGESTALGO (PGP 哈希算法编号) 到摘要算法
+var rpmDigestAlgos = map[int32]crypto.Hash{
+	1:  crypto.MD5,
+	2:  crypto.SHA1,
+	8:  crypto.SHA256,
+	9:  crypto.SHA384,
+	10: crypto.SHA512,
+	11: crypto.SHA224,
+}
+
+var errBadHeader = errors.New("pkgverify: malformed rpm header")
+
+// rpmHeader 解析后的头部
+type rpmHeader struct {
+	entries map[int32]rpmEntry
+	data    []byte
+}
+
+type rpmEntry struct {
+	typ    int32
+	offset int32
+	count  int32
+}
+
+// parseRPMHeader 解析头部
+func parseRPMHeader(blob []byte) (*rpmHeader, error) {
+	if len(blob) < 8 {
+		return nil, errBadHeader
+	}
+	il := int(binary.BigEndian.Uint32(blob[0:4]))
+	dl := int(binary.BigEndian.Uint32(blob[4:8]))
+	if il <= 0 || dl < 0 || il > 1<<16 || 8+il*16+dl > len(blob) {
+		return nil, errBadHeader
+	}
+
+	h := &rpmHeader{entries: make(map[int32]rpmEntry, il), data: blob[8+il*16 : 8+il*16+dl]}
+	for i := 0; i < il; i++ {
+		e := blob[8+i*16 : 8+(i+1)*16]
+		tag := int32(binary.BigEndian.Uint32(e[0:4]))
+		h.entries[tag] = rpmEntry{
+			typ:    int32(binary.BigEndian.Uint32(e[4:8])),
+			offset: int32(binary.BigEndian.Uint32(e[8:12])),
+			count:  int32(binary.BigEndian.Uint32(e[12:16])),
+		}
+	}
+	return h, nil
+}
+
+// strings 读取字符串 (数组) 标签
+func (h *rpmHeader) strings(tag int32) ([]string, error) {
+	e, ok := h.entries[tag]
+	if !ok {
+		return nil, nil
+	}
+	switch e.typ {
+	case rpmTypeString, rpmTypeStringArray, rpmTypeI18NString:
+	default:
+		return nil, fmt.Errorf("pkgverify: rpm tag %d: unexpected type %d", tag, e.typ)
+	}
+	if e.offset < 0 || int(e.offset) > len(h.data) {
+		return nil, errBadHeader
+	}
+
+	out := make([]string, 0, e.count)
+	rest := h.data[e.offset:]
+	for i := int32(0); i < e.count; i++ {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return nil, errBadHeader
+		}
+		out = append(out, string(rest[:end]))
+		rest = rest[end+1:]
+	}
+	return out, nil
+}
+
+// int32s 读取 int32 数组标签
+func (h *rpmHeader) int32s(tag int32) ([]int32, error) {
+	e, ok := h.entries[tag]
+	if !ok {
+		return nil, nil
+	}
+	if e.typ != rpmTypeInt32 {
+		return nil, fmt.Errorf("pkgverify: rpm tag %d: unexpected type %d", tag, e.typ)
+	}
+	end := int(e.offset) + int(e.count)*4
+	if e.offset < 0 || e.count < 0 || end > len(h.data) {
+		return nil, errBadHeader
+	}
+	out := make([]int32, e.count)
+	for i := range out {
+		out[i] = int32(binary.BigEndian.Uint32(h.data[int(e.offset)+i*4:]))
+	}
+	return out, nil
+}
+
+// rpmFiles 遍历头部中的文件，回调完整路径与摘要记录
+func rpmFiles(blob []byte, fn func(path string, rec FileRecord)) error {
+	h, err := parseRPMHeader(blob)
+	if err != nil {
+		return err
+	}
+	names, err := h.strings(rpmTagName)
+	if err != nil || len(names) == 0 {
+		return errBadHeader
+	}
+	bases, err := h.strings(rpmTagBaseNames)
+	if err != nil || len(bases) == 0 {
+		return err
+	}
+	dirs, err := h.strings(rpmTagDirNames)
+	if err != nil {
+		return err
+	}
+	dirIdx, err := h.int32s(rpmTagDirIndexes)
+	if err != nil {
+		return err
+	}
+	digests, err := h.strings(rpmTagFileDigests)
+	if err != nil {
+		return err
+	}
+	if len(dirIdx) != len(bases) {
+		return errBadHeader
+	}
+
+	algo := crypto.MD5 // 未记录算法的旧软件包使用 MD5
+	if a, _ := h.int32s(rpmTagFileDigestAlgo); len(a) > 0 {
+		algo = rpmDigestAlgos[a[0]]
+	}
+
+	for i, base := range bases {
+		if int(dirIdx[i]) >= len(dirs) || dirIdx[i] < 0 {
+			return errBadHeader
+		}
+		rec := FileRecord{Package: names[0], Algo: algo}
+		if i < len(digests) {
+			rec.Digest = digests[i]
+		}
+		fn(dirs[dirIdx[i]]+base, rec)
+	}
+	return nil
+}
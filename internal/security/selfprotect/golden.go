@@ -0,0 +1,162 @@
+package selfprotect
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+)
+
+// errNoGolden 没有可用的黄金副本
+var errNoGolden = errors.New("no golden copy")
+
+// Cipher 黄金副本加解密 (由主程序提供，与本地缓存使用相同的数据密钥)
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// goldenStore 受保护文件的加密黄金副本，保存在仅属主可访问的目录
+// 每次启动与合法更新后刷新：由 systemd 或管理员启动时的文件视为可信版本
+type goldenStore struct {
+	dir    string
+	cipher Cipher
+	// 路径 -> 副本内容 SM3，恢复时校验解密结果
+	hashes map[string]string
+}
+
+func newGoldenStore(dir string, cipher Cipher) (*goldenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	// 已存在的目录同样收紧权限
+	if err := os.Chmod(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &goldenStore{dir: dir, cipher: cipher, hashes: make(map[string]string)}, nil
+}
+
+// file 副本文件名取路径的摘要，不暴露受保护文件的路径
+func (g *goldenStore) file(path string) string {
+	sum := sm3.Sum([]byte(path))
+	return filepath.Join(g.dir, hex.EncodeToString(sum[:16])+".gold")
+}
+
+// has 是否已保存内容为 hash 的副本
+func (g *goldenStore) has(path, hash string) bool {
+	return g.hashes[path] == hash
+}
+
+// save 保存文件当前内容
+func (g *goldenStore) save(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	hash := sm3Hex(data)
+	if g.hashes[path] == hash {
+		return nil
+	}
+	if data, err = g.cipher.Encrypt(data); err != nil {
+		return fmt.Errorf("encrypt golden copy: %w", err)
+	}
+	if err := writeFileAtomic(g.file(path), data, 0600); err != nil {
+		return err
+	}
+	g.hashes[path] = hash
+	return nil
+}
+
+// restore 解密副本并校验摘要后覆盖 path，返回恢复内容的 SM3
+func (g *goldenStore) restore(path string, mode os.FileMode) (string, error) {
+	want, ok := g.hashes[path]
+	if !ok {
+		return "", errNoGolden
+	}
+	data, err := os.ReadFile(g.file(path))
+	if err != nil {
+		return "", err
+	}
+	if data, err = g.cipher.Decrypt(data); err != nil {
+		return "", fmt.Errorf("decrypt golden copy: %w", err)
+	}
+	if sm3Hex(data) != want {
+		return "", fmt.Errorf("golden copy of %s does not match recorded sm3", path)
+	}
+
+	if mode == 0 {
+		mode = 0644
+	}
+	// 所在目录被一并删除时重建
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return want, writeFileAtomic(path, data, mode.Perm())
+}
+
+// reseal 以 convert 重新加密全部副本，返回改写的副本数与失败数
+func (g *goldenStore) reseal(ctx context.Context, convert func(data []byte) ([]byte, bool, error)) (total, failed int, err error) {
+	for path := range g.hashes {
+		if err := ctx.Err(); err != nil {
+			return total, failed, err
+		}
+		changed, err := g.resealFile(g.file(path), convert)
+		if err != nil {
+			logger.Error("黄金副本重加密失败", "path", path, "error", err)
+			failed++
+			continue
+		}
+		if changed {
+			total++
+		}
+	}
+	return total, failed, nil
+}
+
+func (g *goldenStore) resealFile(file string, convert func(data []byte) ([]byte, bool, error)) (bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+	out, changed, err := convert(data)
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, writeFileAtomic(file, out, 0600)
+}
+
+// writeFileAtomic 写入同目录临时文件后重命名，不会留下不完整的文件
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func sm3Hex(data []byte) string {
+	sum := sm3.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
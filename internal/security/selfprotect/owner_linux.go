@@ -0,0 +1,16 @@
+//go:build linux
+
+package selfprotect
+
+import (
+	"os"
+	"syscall"
+)
+
+// owner 文件属主与属组
+func owner(fi os.FileInfo) (uid, gid int) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int(st.Uid), int(st.Gid)
+	}
+	return -1, -1
+}
@@ -0,0 +1,426 @@
+// Package selfprotect 客户端自我保护
+// 启动时为自身二进制、配置文件、规则文件与 SQLite 数据库建立基线，周期校验是否被修改、替换、删除
+// 或更改权限，发现篡改时回调上报；可选从加密黄金副本恢复被修改、替换或删除的二进制、配置与规则文件
+package selfprotect
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/pkgverify"
+)
+
+// Kind 受保护文件类型
+type Kind string
+
+const (
+	KindBinary   Kind = "binary"   // 自身二进制
+	KindConfig   Kind = "config"   // 配置文件
+	KindRule     Kind = "rule"     // 规则/策略文件
+	KindDatabase Kind = "database" // SQLite 数据库，内容持续变化，只校验文件身份、权限与文件头
+)
+
+// ViolationType 篡改类型
+type ViolationType string
+
+const (
+	ViolationModified  ViolationType = "modified"  // 内容变化
+	ViolationReplaced  ViolationType = "replaced"  // 文件被替换 (inode 变化)
+	ViolationDeleted   ViolationType = "deleted"   // 文件被删除
+	ViolationMetadata  ViolationType = "metadata"  // 权限或属主变化
+	ViolationCorrupted ViolationType = "corrupted" // 数据库文件头损坏
+	ViolationPackage   ViolationType = "package"   // 与发行版软件包数据库记录的摘要不一致
+)
+
+// sqliteHeader SQLite 数据库文件头
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// DefaultInterval 默认校验周期
+const DefaultInterval = time.Minute
+
+// Target 受保护文件
+type Target struct {
+	Path string
+	Kind Kind
+	// Verify 内容变化时的合法性校验 (如规则包签名)，返回 nil 视为合法更新，仅更新基线不上报
+	Verify func(data []byte) error
+}
+
+// Violation 篡改事件
+type Violation struct {
+	Path     string
+	Kind     Kind
+	Type     ViolationType
+	Detail   string
+	Time     time.Time
+	Restored bool // 已从黄金副本恢复
+	// 篡改前 (基线) 与篡改后的内容 SM3，删除时 After 为空；数据库不记录
+	Before string
+	After  string
+}
+
+// Handler 篡改事件回调
+type Handler func(v Violation)
+
+// Config 自我保护配置
+type Config struct {
+	// 校验周期，<=0 时使用 DefaultInterval
+	Interval time.Duration
+	// 受保护文件
+	Targets []Target
+	// 黄金副本目录 (目录权限 0700)，非空时启动时保存需恢复的文件的副本
+	ProtectedDir string
+	// 黄金副本加密，启用恢复时必须提供 (副本不以明文落盘)
+	Cipher Cipher
+	// 二进制被篡改时从黄金副本恢复，恢复后调用 OnRestore (由主程序重新执行以加载校验过的二进制)
+	Restore   bool
+	OnRestore func(path string)
+	// 配置与规则文件被篡改时从黄金副本恢复
+	Remediate bool
+	// 按软件包数据库校验的文件 (rpm -V / dpkg --verify)，nil 不校验
+	// 不依赖本地基线，启动时即校验，可发现客户端启动前已被篡改的文件
+	Packages *pkgverify.Checker
+}
+
+// baseline 文件基线
+type baseline struct {
+	exists bool
+	info   os.FileInfo
+	mode   os.FileMode
+	uid    int
+	gid    int
+	hash   string // 内容 SM3，数据库为空
+}
+
+// Monitor 自我保护监控
+type Monitor struct {
+	cfg     Config
+	handler Handler
+
+	mu        sync.Mutex
+	baselines map[string]*baseline
+	golden    *goldenStore // nil 不恢复
+	// 已上报的软件包校验不一致 (路径 -> 当前摘要)，同一内容只上报一次
+	pkgReported map[string]string
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建监控并为所有受保护文件建立基线
+// 启动时不存在的文件同样记录基线，之后出现不视为篡改
+func New(cfg Config, handler Handler) (*Monitor, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	m := &Monitor{
+		cfg:         cfg,
+		handler:     handler,
+		baselines:   make(map[string]*baseline, len(cfg.Targets)),
+		pkgReported: make(map[string]string),
+	}
+	for _, t := range cfg.Targets {
+		b, err := snapshot(t)
+		if err != nil {
+			return nil, fmt.Errorf("selfprotect: baseline %s: %w", t.Path, err)
+		}
+		m.baselines[t.Path] = b
+	}
+
+	if cfg.ProtectedDir != "" && (cfg.Restore || cfg.Remediate) {
+		if cfg.Cipher == nil {
+			return nil, errors.New("selfprotect: golden copies require a cipher")
+		}
+		g, err := newGoldenStore(cfg.ProtectedDir, cfg.Cipher)
+		if err != nil {
+			return nil, fmt.Errorf("selfprotect: golden copy: %w", err)
+		}
+		m.golden = g
+		for _, t := range cfg.Targets {
+			if !m.restorable(t) || !m.baselines[t.Path].exists {
+				continue
+			}
+			if err := g.save(t.Path); err != nil {
+				return nil, fmt.Errorf("selfprotect: golden copy %s: %w", t.Path, err)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Start 后台周期校验
+func (m *Monitor) Start() {
+	m.stopCh = make(chan struct{})
+	m.wg.Add(1)
+	go m.loop()
+	logger.Info("自我保护已启动", "targets", len(m.cfg.Targets), "interval", m.cfg.Interval, "restore", m.cfg.Restore)
+}
+
+// Stop 停止校验
+func (m *Monitor) Stop() {
+	if m.stopCh == nil {
+		return
+	}
+	close(m.stopCh)
+	m.wg.Wait()
+	m.stopCh = nil
+}
+
+// Reseal 以 convert 重新加密黄金副本 (数据密钥轮换后)，返回改写的副本数；实现 storage.FileVault
+func (m *Monitor) Reseal(ctx context.Context, convert func(data []byte) ([]byte, bool, error)) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.golden == nil {
+		return 0, nil
+	}
+	total, failed, err := m.golden.reseal(ctx, convert)
+	if err == nil && failed > 0 {
+		err = fmt.Errorf("selfprotect: %d golden copies failed to re-encrypt", failed)
+	}
+	return total, err
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	// 软件包校验不依赖基线，启动时立即执行一次
+	if m.cfg.Packages != nil {
+		m.Check()
+	}
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.Check()
+		}
+	}
+}
+
+// Check 校验所有受保护文件，返回本轮发现的篡改
+// 每次变化只上报一次：上报后以当前状态为新基线
+func (m *Monitor) Check() []Violation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found []Violation
+	for _, t := range m.cfg.Targets {
+		if v, ok := m.checkTarget(t); ok {
+			found = append(found, v)
+		}
+	}
+	found = append(found, m.checkPackages()...)
+
+	for _, v := range found {
+		logger.Error("检测到自身文件被篡改", "path", v.Path, "kind", v.Kind, "type", v.Type, "detail", v.Detail, "restored", v.Restored)
+		if m.handler != nil {
+			m.handler(v)
+		}
+		if v.Restored && v.Kind == KindBinary && m.cfg.OnRestore != nil {
+			m.cfg.OnRestore(v.Path)
+		}
+	}
+	return found
+}
+
+// checkPackages 按软件包数据库校验，调用方持有 m.mu
+// 文件删除由基线校验上报，这里只上报摘要不一致
+func (m *Monitor) checkPackages() []Violation {
+	if m.cfg.Packages == nil {
+		return nil
+	}
+	var found []Violation
+	for _, r := range m.cfg.Packages.Verify() {
+		if r.Status != pkgverify.StatusModified {
+			delete(m.pkgReported, r.Path)
+			continue
+		}
+		if m.pkgReported[r.Path] == r.Actual {
+			continue
+		}
+		m.pkgReported[r.Path] = r.Actual
+		found = append(found, Violation{
+			Path:   r.Path,
+			Kind:   KindBinary,
+			Type:   ViolationPackage,
+			Detail: fmt.Sprintf("%s package %s: %s -> %s", r.Manager, r.Package, shortHash(r.Expected), shortHash(r.Actual)),
+			Time:   time.Now(),
+		})
+	}
+	return found
+}
+
+// checkTarget 校验单个文件，调用方持有 m.mu
+func (m *Monitor) checkTarget(t Target) (Violation, bool) {
+	old := m.baselines[t.Path]
+	cur, err := snapshot(t)
+	if err != nil {
+		// 读取失败 (如权限被修改) 保留原基线，下轮重试
+		logger.Warn("自我保护校验读取文件失败", "path", t.Path, "error", err)
+		return Violation{}, false
+	}
+
+	vType, detail := compare(t, old, cur)
+	if vType == "" {
+		// 启动后首次出现的文件以当前状态为基线
+		m.baselines[t.Path] = cur
+		m.saveGolden(t, cur)
+		return Violation{}, false
+	}
+	if vType == ViolationModified && t.Verify != nil {
+		if data, err := os.ReadFile(t.Path); err == nil && t.Verify(data) == nil {
+			logger.Info("受保护文件已合法更新", "path", t.Path, "kind", t.Kind)
+			m.baselines[t.Path] = cur
+			m.saveGolden(t, cur)
+			return Violation{}, false
+		}
+	}
+
+	v := Violation{Path: t.Path, Kind: t.Kind, Type: vType, Detail: detail, Time: time.Now()}
+	if t.Kind != KindDatabase {
+		v.Before, v.After = old.hash, cur.hash
+	}
+	switch vType {
+	case ViolationModified, ViolationReplaced, ViolationDeleted:
+		if !m.restorable(t) {
+			break
+		}
+		if _, err := m.golden.restore(t.Path, old.mode); err != nil {
+			logger.Error("从黄金副本恢复失败", "path", t.Path, "error", err)
+		} else if restored, err := snapshot(t); err == nil {
+			v.Restored = true
+			cur = restored
+		}
+	}
+	m.baselines[t.Path] = cur
+	return v, true
+}
+
+// restorable 是否从黄金副本恢复该文件
+func (m *Monitor) restorable(t Target) bool {
+	if m.golden == nil {
+		return false
+	}
+	switch t.Kind {
+	case KindBinary:
+		return m.cfg.Restore
+	case KindConfig, KindRule:
+		return m.cfg.Remediate
+	}
+	return false
+}
+
+// saveGolden 基线更新后刷新黄金副本，调用方持有 m.mu
+func (m *Monitor) saveGolden(t Target, cur *baseline) {
+	if !m.restorable(t) || !cur.exists || m.golden.has(t.Path, cur.hash) {
+		return
+	}
+	if err := m.golden.save(t.Path); err != nil {
+		logger.Warn("保存黄金副本失败", "path", t.Path, "error", err)
+	}
+}
+
+// compare 对比基线，返回篡改类型与描述，未变化时返回空
+func compare(t Target, old, cur *baseline) (ViolationType, string) {
+	switch {
+	case !old.exists:
+		// 启动时不存在的文件之后出现 (如首次下发的规则) 不视为篡改
+		return "", ""
+	case !cur.exists:
+		return ViolationDeleted, "file deleted"
+	case !os.SameFile(old.info, cur.info):
+		return ViolationReplaced, "file replaced"
+	}
+
+	if t.Kind == KindDatabase {
+		// 数据库的 hash 字段记录文件头异常，只在由正常变为异常时上报
+		if cur.hash != "" && old.hash == "" {
+			return ViolationCorrupted, cur.hash
+		}
+	} else if old.hash != cur.hash {
+		return ViolationModified, fmt.Sprintf("sm3 %s -> %s", shortHash(old.hash), shortHash(cur.hash))
+	}
+
+	if old.mode != cur.mode {
+		return ViolationMetadata, fmt.Sprintf("mode %v -> %v", old.mode, cur.mode)
+	}
+	if old.uid != cur.uid || old.gid != cur.gid {
+		return ViolationMetadata, fmt.Sprintf("owner %d:%d -> %d:%d", old.uid, old.gid, cur.uid, cur.gid)
+	}
+	return "", ""
+}
+
+// snapshot 读取文件当前状态
+// 数据库不计算哈希，hash 字段用于记录文件头异常
+func snapshot(t Target) (*baseline, error) {
+	fi, err := os.Stat(t.Path)
+	if os.IsNotExist(err) {
+		return &baseline{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := &baseline{exists: true, info: fi, mode: fi.Mode()}
+	b.uid, b.gid = owner(fi)
+
+	if t.Kind == KindDatabase {
+		if fi.Size() > 0 {
+			b.hash, err = checkSQLiteHeader(t.Path)
+		}
+		return b, err
+	}
+	b.hash, err = fileSM3(t.Path)
+	return b, err
+}
+
+// checkSQLiteHeader 文件头不是 SQLite 时返回异常描述
+func checkSQLiteHeader(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return "invalid sqlite header: truncated", nil
+	}
+	if !bytes.Equal(head, sqliteHeader) {
+		return "invalid sqlite header", nil
+	}
+	return "", nil
+}
+
+// fileSM3 计算文件内容 SM3
+func fileSM3(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sm3.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
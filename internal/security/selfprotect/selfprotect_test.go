@@ -0,0 +1,354 @@
+package selfprotect
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/security/pkgverify"
+)
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newMonitor(t *testing.T, cfg Config) (*Monitor, *[]Violation) {
+	t.Helper()
+	var got []Violation
+	m, err := New(cfg, func(v Violation) { got = append(got, v) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, &got
+}
+
+func TestCheck_ConfigModified(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.yml")
+	writeFile(t, cfgPath, "a: 1\n", 0600)
+
+	m, got := newMonitor(t, Config{Targets: []Target{{Path: cfgPath, Kind: KindConfig}}})
+	if vs := m.Check(); len(vs) != 0 {
+		t.Fatalf("未修改时 Check() = %+v", vs)
+	}
+
+	// 原地写入，inode 不变
+	f, err := os.OpenFile(cfgPath, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("a: 2\n")
+	f.Close()
+
+	vs := m.Check()
+	if len(vs) != 1 || vs[0].Type != ViolationModified || vs[0].Kind != KindConfig {
+		t.Fatalf("Check() = %+v, want one modified", vs)
+	}
+	if len(*got) != 1 {
+		t.Errorf("handler 调用 %d 次, want 1", len(*got))
+	}
+	// 同一变化只上报一次
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("重复上报: %+v", vs)
+	}
+}
+
+func TestCheck_DeletedReplacedMetadata(t *testing.T) {
+	dir := t.TempDir()
+	deleted := filepath.Join(dir, "deleted.json")
+	replaced := filepath.Join(dir, "replaced.json")
+	chmoded := filepath.Join(dir, "chmod.json")
+	for _, p := range []string{deleted, replaced, chmoded} {
+		writeFile(t, p, "{}", 0644)
+	}
+
+	m, _ := newMonitor(t, Config{Targets: []Target{
+		{Path: deleted, Kind: KindRule},
+		{Path: replaced, Kind: KindRule},
+		{Path: chmoded, Kind: KindRule},
+	}})
+
+	os.Remove(deleted)
+	tmp := filepath.Join(dir, "new.json")
+	writeFile(t, tmp, "{}", 0644)
+	os.Rename(tmp, replaced)
+	os.Chmod(chmoded, 0666)
+
+	want := map[string]ViolationType{deleted: ViolationDeleted, replaced: ViolationReplaced, chmoded: ViolationMetadata}
+	vs := m.Check()
+	if len(vs) != len(want) {
+		t.Fatalf("Check() = %+v", vs)
+	}
+	for _, v := range vs {
+		if want[v.Path] != v.Type {
+			t.Errorf("%s: type = %s, want %s", v.Path, v.Type, want[v.Path])
+		}
+	}
+}
+
+func TestCheck_RuleVerified(t *testing.T) {
+	rule := filepath.Join(t.TempDir(), "policy.json")
+	writeFile(t, rule, `{"v":1}`, 0644)
+
+	verify := func(data []byte) error {
+		if string(data) == `{"v":2,"signature":"ok"}` {
+			return nil
+		}
+		return errors.New("bad signature")
+	}
+	m, _ := newMonitor(t, Config{Targets: []Target{{Path: rule, Kind: KindRule, Verify: verify}}})
+
+	// 签名有效的更新只更新基线
+	writeFile(t, rule, `{"v":2,"signature":"ok"}`, 0644)
+	if vs := m.Check(); len(vs) != 0 {
+		t.Fatalf("合法更新被上报: %+v", vs)
+	}
+
+	writeFile(t, rule, `{"v":3}`, 0644)
+	if vs := m.Check(); len(vs) != 1 || vs[0].Type != ViolationModified {
+		t.Fatalf("Check() = %+v, want one modified", vs)
+	}
+}
+
+func TestCheck_DatabaseHeader(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "agent.db")
+	writeFile(t, db, "SQLite format 3\x00 pages...", 0600)
+
+	m, _ := newMonitor(t, Config{Targets: []Target{{Path: db, Kind: KindDatabase}}})
+
+	// 正常写入不上报
+	f, _ := os.OpenFile(db, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString("more pages")
+	f.Close()
+	if vs := m.Check(); len(vs) != 0 {
+		t.Fatalf("正常写入被上报: %+v", vs)
+	}
+
+	f, _ = os.OpenFile(db, os.O_WRONLY, 0)
+	f.WriteAt([]byte("XXXX"), 0)
+	f.Close()
+	if vs := m.Check(); len(vs) != 1 || vs[0].Type != ViolationCorrupted {
+		t.Fatalf("Check() = %+v, want one corrupted", vs)
+	}
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("重复上报: %+v", vs)
+	}
+}
+
+func TestCheck_BinaryRestore(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin", "filewatcherd")
+	os.MkdirAll(filepath.Dir(bin), 0755)
+	writeFile(t, bin, "original", 0755)
+
+	var restored string
+	m, _ := newMonitor(t, Config{
+		Targets:      []Target{{Path: bin, Kind: KindBinary}},
+		ProtectedDir: filepath.Join(dir, "protected"),
+		Cipher:       xorCipher{},
+		Restore:      true,
+		OnRestore:    func(path string) { restored = path },
+	})
+
+	fi, err := os.Stat(filepath.Join(dir, "protected"))
+	if err != nil || fi.Mode().Perm() != 0700 {
+		t.Fatalf("受保护目录 = %v, %v", fi, err)
+	}
+
+	writeFile(t, bin+".evil", "evil", 0755)
+	os.Rename(bin+".evil", bin)
+
+	vs := m.Check()
+	if len(vs) != 1 || !vs[0].Restored {
+		t.Fatalf("Check() = %+v, want one restored", vs)
+	}
+	if restored != bin {
+		t.Errorf("OnRestore path = %q", restored)
+	}
+	data, _ := os.ReadFile(bin)
+	if string(data) != "original" {
+		t.Errorf("恢复后内容 = %q", data)
+	}
+	fi, _ = os.Stat(bin)
+	if fi.Mode().Perm() != 0755 {
+		t.Errorf("恢复后权限 = %v, want 0755", fi.Mode().Perm())
+	}
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("恢复后重复上报: %+v", vs)
+	}
+}
+
+func TestNew_GoldenRequiresCipher(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "filewatcherd")
+	writeFile(t, bin, "original", 0755)
+	if _, err := New(Config{
+		Targets:      []Target{{Path: bin, Kind: KindBinary}},
+		ProtectedDir: filepath.Join(dir, "protected"),
+		Restore:      true,
+	}, nil); err == nil {
+		t.Error("未配置加密时不应保存黄金副本")
+	}
+}
+
+// xorCipher 测试用加密
+type xorCipher struct{}
+
+func (xorCipher) Encrypt(p []byte) ([]byte, error) { return xorBytes(p), nil }
+func (xorCipher) Decrypt(c []byte) ([]byte, error) { return xorBytes(c), nil }
+
+func xorBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out
+}
+
+func TestCheck_Remediate(t *testing.T) {
+	dir := t.TempDir()
+	protected := filepath.Join(dir, "protected")
+	cfgPath := filepath.Join(dir, "etc", "config.yml")
+	rule := filepath.Join(dir, "rules", "policy.json")
+	os.MkdirAll(filepath.Dir(cfgPath), 0755)
+	os.MkdirAll(filepath.Dir(rule), 0755)
+	writeFile(t, cfgPath, "secret: original\n", 0640)
+	writeFile(t, rule, `{"v":1}`, 0644)
+
+	m, got := newMonitor(t, Config{
+		Targets:      []Target{{Path: cfgPath, Kind: KindConfig}, {Path: rule, Kind: KindRule}},
+		ProtectedDir: protected,
+		Cipher:       xorCipher{},
+		Remediate:    true,
+	})
+
+	// 黄金副本加密保存
+	entries, _ := os.ReadDir(protected)
+	if len(entries) != 2 {
+		t.Fatalf("黄金副本数 = %d, want 2", len(entries))
+	}
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(protected, e.Name()))
+		if strings.Contains(string(data), "original") {
+			t.Errorf("黄金副本 %s 未加密", e.Name())
+		}
+	}
+
+	before, _ := fileSM3(cfgPath)
+	writeFile(t, cfgPath, "secret: evil\n", 0640)
+	after, _ := fileSM3(cfgPath)
+	os.RemoveAll(filepath.Dir(rule))
+
+	vs := m.Check()
+	if len(vs) != 2 || len(*got) != 2 {
+		t.Fatalf("Check() = %+v, want two violations", vs)
+	}
+	for _, v := range vs {
+		if !v.Restored {
+			t.Errorf("%s 未恢复", v.Path)
+		}
+		if v.Path == cfgPath && (v.Before != before || v.After != after) {
+			t.Errorf("hashes = %s -> %s, want %s -> %s", v.Before, v.After, before, after)
+		}
+		if v.Path == rule && (v.Type != ViolationDeleted || v.After != "") {
+			t.Errorf("rule violation = %+v", v)
+		}
+	}
+
+	if data, _ := os.ReadFile(cfgPath); string(data) != "secret: original\n" {
+		t.Errorf("配置恢复后内容 = %q", data)
+	}
+	if fi, err := os.Stat(cfgPath); err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("配置恢复后权限 = %v, %v", fi, err)
+	}
+	if data, _ := os.ReadFile(rule); string(data) != `{"v":1}` {
+		t.Errorf("规则恢复后内容 = %q", data)
+	}
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("恢复后重复上报: %+v", vs)
+	}
+}
+
+func TestCheck_RemediateCorruptedGolden(t *testing.T) {
+	dir := t.TempDir()
+	protected := filepath.Join(dir, "protected")
+	cfgPath := filepath.Join(dir, "config.yml")
+	writeFile(t, cfgPath, "a: 1\n", 0600)
+
+	m, _ := newMonitor(t, Config{
+		Targets:      []Target{{Path: cfgPath, Kind: KindConfig}},
+		ProtectedDir: protected,
+		Cipher:       xorCipher{},
+		Remediate:    true,
+	})
+
+	// 黄金副本同样被改写时不恢复
+	entries, _ := os.ReadDir(protected)
+	writeFile(t, filepath.Join(protected, entries[0].Name()), "a: 2\n", 0600)
+	writeFile(t, cfgPath, "a: 2\n", 0600)
+
+	vs := m.Check()
+	if len(vs) != 1 || vs[0].Restored {
+		t.Fatalf("Check() = %+v, want one unrestored", vs)
+	}
+	if data, _ := os.ReadFile(cfgPath); string(data) != "a: 2\n" {
+		t.Errorf("内容 = %q", data)
+	}
+}
+
+func TestNew_MissingFileIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	m, _ := newMonitor(t, Config{Targets: []Target{{Path: path, Kind: KindRule}}})
+
+	// 启动后首次出现的文件不视为篡改
+	writeFile(t, path, "{}", 0644)
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("Check() = %+v", vs)
+	}
+
+	// 之后的修改正常校验
+	writeFile(t, path, `{"a":1}`, 0644)
+	if vs := m.Check(); len(vs) != 1 || vs[0].Type != ViolationModified {
+		t.Errorf("Check() = %+v, want one modified", vs)
+	}
+}
+
+func TestCheck_PackageMismatch(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "filewatcherd")
+	// 启动前已被篡改：本地基线无法发现，软件包记录的是原始摘要
+	writeFile(t, bin, "tampered", 0755)
+
+	root := t.TempDir()
+	sum := md5.Sum([]byte("original"))
+	info := filepath.Join(root, "var/lib/dpkg/info")
+	os.MkdirAll(info, 0755)
+	writeFile(t, filepath.Join(info, "filewatcher.md5sums"), hex.EncodeToString(sum[:])+"  "+strings.TrimPrefix(bin, "/")+"\n", 0644)
+	db, err := pkgverify.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, got := newMonitor(t, Config{
+		Targets:  []Target{{Path: bin, Kind: KindBinary}},
+		Packages: pkgverify.NewChecker(db, []string{bin}),
+	})
+	vs := m.Check()
+	if len(vs) != 1 || vs[0].Type != ViolationPackage || vs[0].Path != bin {
+		t.Fatalf("Check() = %+v, want one package mismatch", vs)
+	}
+	// 同一内容只上报一次
+	if vs := m.Check(); len(vs) != 0 {
+		t.Errorf("重复上报: %+v", vs)
+	}
+	if len(*got) != 1 {
+		t.Errorf("handler 调用 %d 次, want 1", len(*got))
+	}
+}
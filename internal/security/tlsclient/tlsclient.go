@@ -0,0 +1,360 @@
+// Package tlsclient 管理平台上报通道的 TLS 配置
+// 支持客户端证书认证 (mTLS)、服务端证书指纹/公钥固定，证书文件轮换后自动重新加载，
+// TLS 握手失败时生成可读的错误说明供审计日志记录
+package tlsclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/tracing"
+	"linuxFileWatcher/internal/uploadqos"
+)
+
+// Config TLS 配置
+type Config struct {
+	// CA 根证书，为空使用系统根证书
+	CACert string
+	// 客户端证书与私钥，为空不进行客户端认证
+	ClientCert string
+	ClientKey  string
+	// 服务端证书 SHA-256 指纹 (十六进制，可含冒号)，任一匹配即通过
+	PinnedCertSHA256 []string
+	// 服务端公钥 SHA-256 (SubjectPublicKeyInfo 的 Base64)，任一匹配即通过
+	PinnedSPKISHA256 []string
+	// 证书文件变更检查的最小间隔
+	ReloadInterval time.Duration
+	// TLS 失败审计回调，为 nil 不记录
+	Audit AuditFunc
+}
+
+// ErrPinMismatch 服务端证书与固定的指纹不匹配
+var ErrPinMismatch = errors.New("tlsclient: server certificate does not match pinned fingerprint")
+
+// Manager 维护可热更新的证书与校验规则
+type Manager struct {
+	cfg Config
+
+	certPins map[string]bool
+	spkiPins map[string]bool
+
+	mu        sync.Mutex
+	roots     *x509.CertPool
+	cert      *tls.Certificate
+	loadedAt  map[string]time.Time // 文件路径 -> 加载时的修改时间
+	lastCheck time.Time
+}
+
+// NewManager 加载证书并创建管理器
+func NewManager(cfg Config) (*Manager, error) {
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		return nil, errors.New("tlsclient: client_cert and client_key must be set together")
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = time.Minute
+	}
+	m := &Manager{
+		cfg:      cfg,
+		certPins: make(map[string]bool),
+		spkiPins: make(map[string]bool),
+		loadedAt: make(map[string]time.Time),
+	}
+	for _, p := range cfg.PinnedCertSHA256 {
+		fp := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(p), ":", ""))
+		if b, err := hex.DecodeString(fp); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("tlsclient: invalid certificate fingerprint %q", p)
+		}
+		m.certPins[fp] = true
+	}
+	for _, p := range cfg.PinnedSPKISHA256 {
+		p = strings.TrimSpace(p)
+		if b, err := base64.StdEncoding.DecodeString(p); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("tlsclient: invalid SPKI pin %q", p)
+		}
+		m.spkiPins[p] = true
+	}
+	if err := m.reload(true); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TLSConfig 返回用于上报连接的 TLS 配置
+// 证书链校验在 VerifyConnection 中使用当前 (可能已重新加载的) 根证书完成
+func (m *Manager) TLSConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// 由 verify 完成校验，以便根证书轮换后无需重建连接池
+		InsecureSkipVerify:   true,
+		GetClientCertificate: m.getClientCertificate,
+		VerifyConnection:     m.verify,
+	}
+}
+
+// Transport 创建使用该 TLS 配置的 HTTP Transport
+func (m *Manager) Transport(serverName string) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = m.TLSConfig(serverName)
+	return t
+}
+
+// Client 创建上报用 HTTP 客户端，TLS 失败时按配置记录审计日志
+// 开启追踪时每个请求记录 span 与请求指标；启用上报限流时请求先等待接口配额，该等待不计入 span
+func (m *Manager) Client(serverName string, timeout time.Duration) *http.Client {
+	var rt http.RoundTripper = m.Transport(serverName)
+	if m.cfg.Audit != nil {
+		rt = WithAudit(rt, m.cfg.Audit, 0)
+	}
+	return &http.Client{Transport: uploadqos.Transport(tracing.Transport(rt)), Timeout: timeout}
+}
+
+func (m *Manager) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if err := m.reload(false); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cert == nil {
+		// 未配置客户端证书时发送空证书，由服务端决定是否拒绝
+		return &tls.Certificate{}, nil
+	}
+	return m.cert, nil
+}
+
+// verify 校验证书链、主机名与指纹固定
+func (m *Manager) verify(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("tlsclient: server presented no certificate")
+	}
+	if err := m.reload(false); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	roots := m.roots
+	m.mu.Unlock()
+
+	leaf := cs.PeerCertificates[0]
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, c := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	chains, err := leaf.Verify(opts)
+	if err != nil {
+		return err
+	}
+
+	if len(m.certPins) == 0 && len(m.spkiPins) == 0 {
+		return nil
+	}
+	certSum := sha256.Sum256(leaf.Raw)
+	if m.certPins[hex.EncodeToString(certSum[:])] {
+		return nil
+	}
+	// 公钥固定只认验证通过的链上的证书，服务端附带的无关证书不算数
+	for _, chain := range chains {
+		for _, c := range chain {
+			spkiSum := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+			if m.spkiPins[base64.StdEncoding.EncodeToString(spkiSum[:])] {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w (got sha256 %s)", ErrPinMismatch, hex.EncodeToString(certSum[:]))
+}
+
+// reload 证书文件修改时间变化时重新加载；加载失败时保留旧证书
+func (m *Manager) reload(force bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if !force && now.Sub(m.lastCheck) < m.cfg.ReloadInterval {
+		return nil
+	}
+	m.lastCheck = now
+
+	changed := force
+	for _, path := range []string{m.cfg.CACert, m.cfg.ClientCert, m.cfg.ClientKey} {
+		if path == "" {
+			continue
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			if force {
+				return fmt.Errorf("tlsclient: %w", err)
+			}
+			continue
+		}
+		if !fi.ModTime().Equal(m.loadedAt[path]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	roots, err := loadRoots(m.cfg.CACert)
+	if err != nil {
+		if force {
+			return err
+		}
+		return nil
+	}
+	var cert *tls.Certificate
+	if m.cfg.ClientCert != "" {
+		c, err := tls.LoadX509KeyPair(m.cfg.ClientCert, m.cfg.ClientKey)
+		if err != nil {
+			// 证书与私钥可能正在分别替换，下次检查时重试
+			if force {
+				return fmt.Errorf("tlsclient: load client certificate: %w", err)
+			}
+			return nil
+		}
+		cert = &c
+	}
+
+	m.roots = roots
+	m.cert = cert
+	for _, path := range []string{m.cfg.CACert, m.cfg.ClientCert, m.cfg.ClientKey} {
+		if fi, err := os.Stat(path); err == nil {
+			m.loadedAt[path] = fi.ModTime()
+		}
+	}
+	return nil
+}
+
+func loadRoots(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return x509.SystemCertPool()
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("tlsclient: read CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("tlsclient: no certificates found in %s", caFile)
+	}
+	return pool, nil
+}
+
+// Describe 将 TLS 相关错误转换为可读说明，非 TLS 错误返回空字符串
+func Describe(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var unknownAuth x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostErr x509.HostnameError
+	var alert tls.AlertError
+	var recordErr tls.RecordHeaderError
+	var certVerify *tls.CertificateVerificationError
+	switch {
+	case errors.Is(err, ErrPinMismatch):
+		return "服务端证书与固定指纹不匹配，可能存在中间人攻击: " + err.Error()
+	case errors.As(err, &unknownAuth):
+		return "服务端证书不是由受信任的 CA 签发: " + err.Error()
+	case errors.As(err, &hostErr):
+		return "服务端证书与访问地址不匹配: " + err.Error()
+	case errors.As(err, &invalid):
+		if invalid.Reason == x509.Expired {
+			return "服务端证书已过期或尚未生效: " + err.Error()
+		}
+		return "服务端证书无效: " + err.Error()
+	case errors.As(err, &alert):
+		return "服务端拒绝 TLS 握手 (客户端证书可能无效或已吊销): " + err.Error()
+	case errors.As(err, &recordErr):
+		return "服务端未使用 TLS 协议: " + err.Error()
+	case errors.As(err, &certVerify):
+		return "服务端证书校验失败: " + err.Error()
+	case strings.Contains(err.Error(), "tlsclient:"), strings.Contains(err.Error(), "tls:"):
+		return "TLS 连接失败: " + err.Error()
+	}
+	return ""
+}
+
+// ==========================================
+// 审计上报
+// ==========================================
+
+// AuditFunc TLS 失败审计回调
+type AuditFunc func(message string)
+
+// auditTransport 在 TLS 失败时记录审计日志，同一错误在 dedup 时间内只记录一次
+type auditTransport struct {
+	base  http.RoundTripper
+	audit AuditFunc
+	dedup time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// WithAudit 包装 RoundTripper，TLS 握手失败时调用 audit
+func WithAudit(base http.RoundTripper, audit AuditFunc, dedup time.Duration) http.RoundTripper {
+	if dedup <= 0 {
+		dedup = 10 * time.Minute
+	}
+	return &auditTransport{base: base, audit: audit, dedup: dedup, seen: make(map[string]time.Time)}
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	var netErr net.Error
+	msg := Describe(err)
+	if msg == "" || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return resp, err
+	}
+
+	msg = fmt.Sprintf("%s (%s)", msg, req.URL.Host)
+	now := time.Now()
+	t.mu.Lock()
+	last, ok := t.seen[msg]
+	report := !ok || now.Sub(last) >= t.dedup
+	if report {
+		t.seen[msg] = now
+	}
+	t.mu.Unlock()
+	if report {
+		t.audit(msg)
+	}
+	return resp, err
+}
+
+// ==========================================
+// 全局实例
+// ==========================================
+
+var defaultManager atomic.Pointer[Manager]
+
+// SetDefault 设置全局 TLS 管理器 (由主程序按配置设置，上报模块通过 Default 获取)
+func SetDefault(m *Manager) {
+	defaultManager.Store(m)
+}
+
+// Default 返回全局 TLS 管理器，未初始化时为 nil
+func Default() *Manager {
+	return defaultManager.Load()
+}
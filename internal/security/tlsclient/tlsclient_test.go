@@ -0,0 +1,243 @@
+package tlsclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发证书，返回证书与私钥 PEM
+func (ca *testCA) issue(t *testing.T, serial int64, server bool) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "agent"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if server {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// startServer 启动要求客户端证书的 HTTPS 服务，响应客户端证书序列号
+func startServer(t *testing.T, ca *testCA) (*httptest.Server, *x509.Certificate) {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, 100, true)
+	serverCert, _ := tls.X509KeyPair(certPEM, keyPEM)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].SerialNumber.String())
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	leaf, _ := x509.ParseCertificate(serverCert.Certificate[0])
+	return srv, leaf
+}
+
+func writeFiles(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func get(t *testing.T, m *Manager, url string) (string, error) {
+	t.Helper()
+	tr := m.Transport("")
+	tr.DisableKeepAlives = true
+	resp, err := (&http.Client{Transport: tr, Timeout: 5 * time.Second}).Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), nil
+}
+
+func TestManager_MutualTLSAndReload(t *testing.T) {
+	ca := newCA(t)
+	srv, _ := startServer(t, ca)
+	dir := t.TempDir()
+
+	certPEM, keyPEM := ca.issue(t, 7, false)
+	writeFiles(t, dir, map[string][]byte{"ca.crt": ca.pem, "client.crt": certPEM, "client.key": keyPEM})
+
+	m, err := NewManager(Config{
+		CACert:         filepath.Join(dir, "ca.crt"),
+		ClientCert:     filepath.Join(dir, "client.crt"),
+		ClientKey:      filepath.Join(dir, "client.key"),
+		ReloadInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if got, err := get(t, m, srv.URL); err != nil || got != "7" {
+		t.Fatalf("GET = %q, %v", got, err)
+	}
+
+	// 证书轮换后新连接使用新证书
+	certPEM, keyPEM = ca.issue(t, 8, false)
+	writeFiles(t, dir, map[string][]byte{"client.crt": certPEM, "client.key": keyPEM})
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{"client.crt", "client.key"} {
+		os.Chtimes(filepath.Join(dir, f), future, future)
+	}
+	if got, err := get(t, m, srv.URL); err != nil || got != "8" {
+		t.Fatalf("轮换后 GET = %q, %v", got, err)
+	}
+}
+
+func TestManager_Pinning(t *testing.T) {
+	ca := newCA(t)
+	srv, leaf := startServer(t, ca)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.issue(t, 7, false)
+	writeFiles(t, dir, map[string][]byte{"ca.crt": ca.pem, "client.crt": certPEM, "client.key": keyPEM})
+	base := Config{
+		CACert:     filepath.Join(dir, "ca.crt"),
+		ClientCert: filepath.Join(dir, "client.crt"),
+		ClientKey:  filepath.Join(dir, "client.key"),
+	}
+
+	sum := sha256.Sum256(leaf.Raw)
+	cfg := base
+	cfg.PinnedCertSHA256 = []string{strings.ToUpper(hex.EncodeToString(sum[:]))}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(t, m, srv.URL); err != nil {
+		t.Fatalf("匹配的指纹应通过: %v", err)
+	}
+
+	cfg = base
+	cfg.PinnedCertSHA256 = []string{strings.Repeat("ab", 32)}
+	m, _ = NewManager(cfg)
+
+	var audits []string
+	tr := m.Transport("")
+	tr.DisableKeepAlives = true
+	client := &http.Client{Transport: WithAudit(tr, func(msg string) { audits = append(audits, msg) }, time.Hour)}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Get(srv.URL); err == nil {
+			t.Fatal("指纹不匹配应失败")
+		}
+	}
+	if len(audits) != 1 || !strings.Contains(audits[0], "固定指纹") {
+		t.Errorf("audits = %q", audits)
+	}
+}
+
+func TestManager_SPKIPinOnVerifiedChain(t *testing.T) {
+	ca := newCA(t)
+	srv, _ := startServer(t, ca)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"ca.crt": ca.pem})
+
+	spki := func(c *x509.Certificate) string {
+		sum := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+	m, _ := NewManager(Config{CACert: filepath.Join(dir, "ca.crt"), PinnedSPKISHA256: []string{spki(ca.cert)}})
+	// 固定 CA 公钥：验证链包含 CA
+	leaf := srv.Certificate()
+	if err := m.verify(tls.ConnectionState{ServerName: "127.0.0.1", PeerCertificates: []*x509.Certificate{leaf}}); err != nil {
+		t.Fatalf("链上 CA 的公钥应通过: %v", err)
+	}
+
+	// 服务端附带的、不在验证链上的证书不能满足公钥固定
+	other := newCA(t)
+	m, _ = NewManager(Config{CACert: filepath.Join(dir, "ca.crt"), PinnedSPKISHA256: []string{spki(other.cert)}})
+	err := m.verify(tls.ConnectionState{ServerName: "127.0.0.1", PeerCertificates: []*x509.Certificate{leaf, other.cert}})
+	if !errors.Is(err, ErrPinMismatch) {
+		t.Errorf("链外证书 verify() = %v", err)
+	}
+}
+
+func TestManager_UntrustedServer(t *testing.T) {
+	srv, _ := startServer(t, newCA(t))
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string][]byte{"ca.crt": newCA(t).pem})
+
+	m, err := NewManager(Config{CACert: filepath.Join(dir, "ca.crt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = get(t, m, srv.URL)
+	if err == nil || !strings.Contains(Describe(err), "受信任的 CA") {
+		t.Errorf("Describe(%v) = %q", err, Describe(err))
+	}
+}
+
+func TestNewManager_InvalidConfig(t *testing.T) {
+	if _, err := NewManager(Config{ClientCert: "a.crt"}); err == nil {
+		t.Error("仅配置证书未配置私钥应报错")
+	}
+	if _, err := NewManager(Config{PinnedCertSHA256: []string{"zz"}}); err == nil {
+		t.Error("无效指纹应报错")
+	}
+}
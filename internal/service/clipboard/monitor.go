@@ -0,0 +1,228 @@
+// Package clipboard 剪贴板监控
+// 定期读取桌面会话剪贴板，对复制的文本和图片执行密级标志/关键词检测，涉密内容进入剪贴板时告警
+package clipboard
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// Config 剪贴板监控配置
+type Config struct {
+	// 轮询间隔
+	PollInterval time.Duration
+	// 单次检测的最大内容字节数，超过则跳过
+	MaxBytes int64
+	// 相同内容的重复告警间隔
+	Cooldown time.Duration
+	// 每分钟最多产生的告警数 (防止反复复制刷屏)，<=0 不限制
+	MaxAlertsPerMinute int
+}
+
+// Detector 内存内容检测接口 (由 detector.Manager 实现)
+type Detector interface {
+	DetectBytes(ctx context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// AlertSink 告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// Stats 监控统计
+type Stats struct {
+	Checked    int64 // 检测的剪贴板内容数
+	Alerts     int64 // 产生的告警数
+	Suppressed int64 // 因限流或冷却被抑制的命中数
+	Skipped    int64 // 超过大小上限被跳过的内容数
+}
+
+// Monitor 剪贴板监控
+type Monitor struct {
+	cfg      Config
+	source   Source
+	detector Detector
+	sink     AlertSink
+
+	mu       sync.Mutex
+	lastHash [sha256.Size]byte               // 上一次检测的内容，未变化时不重复检测
+	alerted  map[[sha256.Size]byte]time.Time // 命中内容的最近告警时间
+	recent   []time.Time                     // 最近一分钟的告警时间
+	stats    Stats
+	now      func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMonitor 创建剪贴板监控
+func NewMonitor(cfg Config, source Source, detector Detector, sink AlertSink) *Monitor {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 5 << 20
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 10 * time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		cfg:      cfg,
+		source:   source,
+		detector: detector,
+		sink:     sink,
+		alerted:  make(map[[sha256.Size]byte]time.Time),
+		now:      time.Now,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 启动监控 (非阻塞)
+// 启动时剪贴板中已有的内容同样会被检测
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go m.loop()
+	logger.Info("剪贴板监控已启动", "source", m.source.Name(), "poll_interval", m.cfg.PollInterval)
+}
+
+// Stop 停止监控
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Stats 返回统计信息
+func (m *Monitor) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.poll(m.ctx)
+		}
+	}
+}
+
+// poll 读取剪贴板，内容变化时执行检测
+func (m *Monitor) poll(ctx context.Context) {
+	content, err := m.source.Read(ctx, m.cfg.MaxBytes)
+	if errors.Is(err, errTooLarge) {
+		m.mu.Lock()
+		m.stats.Skipped++
+		m.mu.Unlock()
+		return
+	}
+	if err != nil {
+		logger.Debug("读取剪贴板失败", "source", m.source.Name(), "error", err)
+		return
+	}
+	if content == nil {
+		return
+	}
+	if int64(len(content.Data)) > m.cfg.MaxBytes {
+		m.mu.Lock()
+		m.stats.Skipped++
+		m.mu.Unlock()
+		return
+	}
+
+	hash := sha256.Sum256(content.Data)
+	m.mu.Lock()
+	if hash == m.lastHash {
+		m.mu.Unlock()
+		return
+	}
+	m.lastHash = hash
+	m.stats.Checked++
+	m.mu.Unlock()
+
+	hit, record, logItem, err := m.detector.DetectBytes(ctx, contentName(content), content.Data)
+	if err != nil {
+		logger.Warn("剪贴板内容检测失败", "mime", content.MIME, "error", err)
+		return
+	}
+	if !hit || record == nil {
+		return
+	}
+
+	if !m.allow(hash) {
+		return
+	}
+
+	record.AlertType = model.AlertTypeCopyPaste
+	record.FilePath = "clipboard://" + m.source.Name()
+	if logItem != nil {
+		logItem.FilePath = record.FilePath
+	}
+	logger.Warn("涉密内容进入剪贴板", "mime", content.MIME, "rule", record.RuleDesc)
+	m.sink(record, logItem)
+}
+
+// allow 告警限流：相同内容在冷却期内只告警一次，且每分钟告警数不超过上限
+func (m *Monitor) allow(hash [sha256.Size]byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if last, ok := m.alerted[hash]; ok && now.Sub(last) < m.cfg.Cooldown {
+		m.stats.Suppressed++
+		return false
+	}
+
+	cutoff := now.Add(-time.Minute)
+	kept := m.recent[:0]
+	for _, t := range m.recent {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	m.recent = kept
+	if m.cfg.MaxAlertsPerMinute > 0 && len(m.recent) >= m.cfg.MaxAlertsPerMinute {
+		m.stats.Suppressed++
+		return false
+	}
+
+	// 清理过期的冷却记录
+	for h, t := range m.alerted {
+		if now.Sub(t) >= m.cfg.Cooldown {
+			delete(m.alerted, h)
+		}
+	}
+
+	m.alerted[hash] = now
+	m.recent = append(m.recent, now)
+	m.stats.Alerts++
+	return true
+}
+
+// contentName 按内容类型生成检测用文件名，子检测器据扩展名选择解析方式
+func contentName(c *Content) string {
+	switch c.MIME {
+	case "image/png":
+		return "clipboard.png"
+	case "image/jpeg":
+		return "clipboard.jpg"
+	case "image/bmp":
+		return "clipboard.bmp"
+	default:
+		return "clipboard.txt"
+	}
+}
@@ -0,0 +1,134 @@
+package clipboard
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fakeSource 返回预设的剪贴板内容
+type fakeSource struct {
+	content *Content
+	err     error
+}
+
+func (s *fakeSource) Name() string { return "fake" }
+
+func (s *fakeSource) Read(context.Context, int64) (*Content, error) {
+	return s.content, s.err
+}
+
+// fakeDetector 内容含 "绝密" 即命中，记录收到的文件名
+type fakeDetector struct {
+	names []string
+}
+
+func (d *fakeDetector) DetectBytes(_ context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	d.names = append(d.names, name)
+	if strings.Contains(string(data), "绝密") {
+		return true, &model.AlertRecord{FilePath: name, RuleDesc: "密级标志"}, &model.AlertLogItem{FilePath: name}, nil
+	}
+	return false, nil, nil, nil
+}
+
+func newTestMonitor(cfg Config, src *fakeSource, det *fakeDetector) (*Monitor, *[]*model.AlertRecord) {
+	var alerts []*model.AlertRecord
+	m := NewMonitor(cfg, src, det, func(r *model.AlertRecord, _ *model.AlertLogItem) {
+		alerts = append(alerts, r)
+	})
+	return m, &alerts
+}
+
+func TestMonitor_AlertOnClassifiedContent(t *testing.T) {
+	src := &fakeSource{content: &Content{MIME: "text/plain", Data: []byte("普通内容")}}
+	det := &fakeDetector{}
+	m, alerts := newTestMonitor(Config{}, src, det)
+	ctx := context.Background()
+
+	m.poll(ctx)
+	m.poll(ctx) // 内容未变化不重复检测
+	if len(det.names) != 1 || len(*alerts) != 0 {
+		t.Fatalf("检测次数 = %d, 告警数 = %d", len(det.names), len(*alerts))
+	}
+
+	src.content = &Content{MIME: "image/png", Data: []byte("绝密★")}
+	m.poll(ctx)
+	if len(*alerts) != 1 {
+		t.Fatalf("告警数 = %d, want 1", len(*alerts))
+	}
+	if det.names[1] != "clipboard.png" {
+		t.Errorf("图片内容检测文件名 = %q", det.names[1])
+	}
+	a := (*alerts)[0]
+	if a.AlertType != model.AlertTypeCopyPaste || a.FilePath != "clipboard://fake" {
+		t.Errorf("告警 = %+v", a)
+	}
+}
+
+func TestMonitor_Throttle(t *testing.T) {
+	src := &fakeSource{}
+	det := &fakeDetector{}
+	m, alerts := newTestMonitor(Config{Cooldown: time.Hour, MaxAlertsPerMinute: 2}, src, det)
+	now := time.Unix(1700000000, 0)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	set := func(s string) {
+		src.content = &Content{MIME: "text/plain", Data: []byte(s)}
+		m.poll(ctx)
+	}
+
+	// 相同内容反复复制：冷却期内只告警一次
+	set("绝密 A")
+	set("其他")
+	set("绝密 A")
+	if len(*alerts) != 1 {
+		t.Fatalf("冷却期内告警数 = %d, want 1", len(*alerts))
+	}
+
+	// 每分钟上限
+	set("绝密 B")
+	set("绝密 C")
+	if len(*alerts) != 2 {
+		t.Fatalf("限流后告警数 = %d, want 2", len(*alerts))
+	}
+
+	now = now.Add(2 * time.Minute)
+	set("绝密 D")
+	if len(*alerts) != 3 {
+		t.Fatalf("限流窗口过后告警数 = %d, want 3", len(*alerts))
+	}
+
+	if st := m.Stats(); st.Suppressed != 2 || st.Alerts != 3 {
+		t.Errorf("Stats() = %+v", st)
+	}
+}
+
+func TestMonitor_SkipTooLarge(t *testing.T) {
+	src := &fakeSource{content: &Content{MIME: "text/plain", Data: make([]byte, 100)}}
+	det := &fakeDetector{}
+	m, _ := newTestMonitor(Config{MaxBytes: 10}, src, det)
+
+	m.poll(context.Background())
+	src.content, src.err = nil, errTooLarge
+	m.poll(context.Background())
+
+	if len(det.names) != 0 || m.Stats().Skipped != 2 {
+		t.Errorf("超限内容应跳过: names = %v, stats = %+v", det.names, m.Stats())
+	}
+}
+
+func TestPickType(t *testing.T) {
+	if got := pickType([]string{"TARGETS", "UTF8_STRING", "image/png"}); got != "image/png" {
+		t.Errorf("pickType() = %q, want image/png", got)
+	}
+	if got := pickType([]string{"text/plain", "text/plain;charset=utf-8"}); got != "text/plain;charset=utf-8" {
+		t.Errorf("pickType() = %q", got)
+	}
+	if got := pickType([]string{"application/x-kde-cutselection"}); got != "" {
+		t.Errorf("pickType() = %q, want empty", got)
+	}
+}
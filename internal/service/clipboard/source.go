@@ -0,0 +1,170 @@
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ==========================================
+// 剪贴板读取 (Wayland: wl-paste, X11: xclip)
+// ==========================================
+
+// ErrNoSource 当前会话没有可用的剪贴板工具
+var ErrNoSource = errors.New("clipboard: no wl-paste/xclip available for current session")
+
+// errTooLarge 剪贴板内容超过上限
+var errTooLarge = errors.New("clipboard: content too large")
+
+// Content 剪贴板内容
+type Content struct {
+	MIME string
+	Data []byte
+}
+
+// IsImage 是否为图片内容
+func (c *Content) IsImage() bool {
+	return strings.HasPrefix(c.MIME, "image/")
+}
+
+// Source 剪贴板读取接口
+type Source interface {
+	Name() string
+	// Read 读取当前剪贴板内容，剪贴板为空时返回 nil
+	Read(ctx context.Context, maxBytes int64) (*Content, error)
+}
+
+// preferredTypes 按优先级选择的内容类型 (图片优先，OCR 可识别截图中的密级标志)
+var preferredTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/bmp",
+	"text/plain;charset=utf-8",
+	"UTF8_STRING",
+	"text/plain",
+	"STRING",
+}
+
+// commandSource 通过命令行工具读取剪贴板
+type commandSource struct {
+	name      string
+	listTypes []string                   // 列出可用类型的命令
+	readType  func(mime string) []string // 读取指定类型的命令
+	timeout   time.Duration
+}
+
+// newWaylandSource wl-paste (wl-clipboard)
+func newWaylandSource() *commandSource {
+	return &commandSource{
+		name:      "wayland",
+		listTypes: []string{"wl-paste", "--list-types"},
+		readType: func(mime string) []string {
+			return []string{"wl-paste", "--no-newline", "--type", mime}
+		},
+		timeout: 2 * time.Second,
+	}
+}
+
+// newX11Source xclip
+func newX11Source() *commandSource {
+	return &commandSource{
+		name:      "x11",
+		listTypes: []string{"xclip", "-selection", "clipboard", "-o", "-t", "TARGETS"},
+		readType: func(mime string) []string {
+			return []string{"xclip", "-selection", "clipboard", "-o", "-t", mime}
+		},
+		timeout: 2 * time.Second,
+	}
+}
+
+// DetectSource 按会话环境选择剪贴板工具
+// 守护进程需以桌面用户身份运行，或设置 WAYLAND_DISPLAY/DISPLAY 与 XAUTHORITY
+func DetectSource() (Source, error) {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-paste"); err == nil {
+			return newWaylandSource(), nil
+		}
+	}
+	if os.Getenv("DISPLAY") != "" {
+		if _, err := exec.LookPath("xclip"); err == nil {
+			return newX11Source(), nil
+		}
+	}
+	return nil, ErrNoSource
+}
+
+func (s *commandSource) Name() string {
+	return s.name
+}
+
+func (s *commandSource) Read(ctx context.Context, maxBytes int64) (*Content, error) {
+	out, err := s.run(ctx, s.listTypes, 64<<10)
+	if err != nil {
+		// 剪贴板为空时工具返回非零退出码
+		return nil, nil
+	}
+
+	mime := pickType(strings.Split(string(out), "\n"))
+	if mime == "" {
+		return nil, nil
+	}
+
+	data, err := s.run(ctx, s.readType(mime), maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return &Content{MIME: mime, Data: data}, nil
+}
+
+// run 执行命令并读取输出，超过 limit 字节时返回 errTooLarge
+func (s *commandSource) run(ctx context.Context, args []string, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	_, readErr := io.Copy(&buf, io.LimitReader(stdout, limit+1))
+	// 超限时不再读取剩余输出，直接结束进程
+	if int64(buf.Len()) > limit {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, errTooLarge
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return buf.Bytes(), nil
+}
+
+// pickType 从可用类型中选择优先级最高的
+func pickType(available []string) string {
+	set := make(map[string]bool, len(available))
+	for _, t := range available {
+		set[strings.TrimSpace(t)] = true
+	}
+	for _, t := range preferredTypes {
+		if set[t] {
+			return t
+		}
+	}
+	return ""
+}
@@ -0,0 +1,343 @@
+// Package command 执行管理平台下发的指令 (立即扫描、重建基线、更新白名单、收集诊断信息等)
+// 指令由服务端 SM2 签名 (与规则包同一密钥，签名方式见 policy.SignBundle，但使用指令专用的用户标识 SignUID)，校验签名、目标主机与有效期并去重后，
+// 交给按类型注册的处理函数执行。每条指令 (含被拒绝的) 都记录审计日志，执行结果写入指令结果上报。
+// 指令可由 gRPC 流式通道推送，也可由 Poller 定期向服务端拉取
+package command
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// 指令类型
+const (
+	TypeRescan      = "rescan"              // 立即扫描指定文件或目录
+	TypeRebaseline  = "rebaseline"          // 以当前状态重建指定路径所在目录的完整性基线
+	TypeWhitelist   = "whitelist_update"    // 增删网络白名单规则
+	TypeDiagnostics = "collect_diagnostics" // 收集运行诊断信息
+	TypeRuleUpdate  = "rule_update"         // 保存下发的策略并重新加载
+)
+
+const (
+	// DefaultMaxAge 指令未指定过期时间时的有效期
+	DefaultMaxAge = 24 * time.Hour
+	// DefaultTimeout 单条指令执行超时
+	DefaultTimeout = 10 * time.Minute
+	// clockSkew 允许的签发时间超前量 (服务端与本机时钟偏差)
+	clockSkew = 5 * time.Minute
+)
+
+// SignUID 指令签名的 SM2 用户标识
+// 与规则包 (默认用户标识) 区分：同一密钥签名的规则包不能作为指令执行，指令也不能作为规则包加载
+var SignUID = []byte("linuxFileWatcher/command/v1")
+
+var (
+	// ErrExpired 指令已过期或签发时间无效
+	ErrExpired = errors.New("command: expired")
+	// ErrDuplicate 指令已执行过 (重放或重复下发)
+	ErrDuplicate = errors.New("command: already executed")
+	// ErrWrongAgent 指令的目标不是本机
+	ErrWrongAgent = errors.New("command: addressed to another agent")
+	// ErrUnknownType 没有注册该类型的处理函数
+	ErrUnknownType = errors.New("command: unknown type")
+	// ErrStopped 执行器已停止
+	ErrStopped = errors.New("command: executor stopped")
+)
+
+// Command 签名指令
+// 签名原文为去掉 signature 字段后的规范化 JSON
+type Command struct {
+	// 指令 ID，服务端保证唯一，本机据此去重
+	ID   string `json:"cmd_id"`
+	Type string `json:"type"`
+	// 目标主机 (agent_uuid)，为空表示不限
+	AgentUUID string `json:"agent_uuid,omitempty"`
+	// 签发、过期时间 (Unix 秒)，过期时间为 0 时按签发时间加 MaxAge
+	IssuedAt  int64 `json:"issued_at"`
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+	// 参数，格式由指令类型决定
+	Args      json.RawMessage `json:"args,omitempty"`
+	Signature string          `json:"signature"`
+}
+
+// Result 处理函数的执行结果
+type Result struct {
+	// 结果描述
+	Message string
+	// 详情，写入指令结果上报的 detail
+	Detail []string
+}
+
+// Handler 指令处理函数，ctx 在执行超时或执行器停止时取消
+type Handler func(ctx context.Context, args json.RawMessage) (Result, error)
+
+// Config 执行器配置
+type Config struct {
+	// 服务端签名公钥，必填
+	PublicKey *sm2.PublicKey
+	// 本机标识，指令指定了其他目标时拒绝
+	AgentUUID string
+	// 指令未指定过期时间时的有效期，<=0 时使用 DefaultMaxAge
+	MaxAge time.Duration
+	// 单条指令执行超时，<=0 时使用 DefaultTimeout
+	Timeout time.Duration
+	// 已执行指令 ID 的保存路径，重启后仍拒绝重放；为空只在内存中去重
+	StatePath string
+	// 审计日志输出，nil 只记录日志
+	Audit func(*model.SystemAuditRequest)
+	// 指令结果上报输出，nil 不上报
+	Report func(*model.CommandResultReport)
+}
+
+// Executor 指令执行器，可并发执行
+type Executor struct {
+	cfg      Config
+	handlers map[string]Handler
+
+	mu sync.Mutex
+	// 已执行的指令 ID -> 过期时间 (Unix 秒)，过期后指令本身即被拒绝，无需再记录
+	seen map[string]int64
+	// 已停止，由 mu 保护；Execute 在 mu 下检查并登记到 wg，Stop 置位后才等待，停止后不会再有指令开始执行
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建执行器，加载已执行指令记录
+func New(cfg Config) (*Executor, error) {
+	if cfg.PublicKey == nil {
+		return nil, errors.New("command: public key is required")
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	e := &Executor{cfg: cfg, handlers: make(map[string]Handler), seen: make(map[string]int64)}
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	if cfg.StatePath != "" {
+		data, err := os.ReadFile(cfg.StatePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("command: load state: %w", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &e.seen); err != nil {
+				// 记录损坏不影响执行，仍由有效期限制重放窗口
+				logger.Warn("已执行指令记录损坏，重新记录", "path", cfg.StatePath, "error", err)
+				e.seen = make(map[string]int64)
+			}
+		}
+	}
+	return e, nil
+}
+
+// Handle 注册指令处理函数，需在开始接收指令前调用
+func (e *Executor) Handle(typ string, h Handler) {
+	e.handlers[typ] = h
+}
+
+// Stop 取消执行中的指令并等待其返回
+func (e *Executor) Stop() {
+	e.mu.Lock()
+	e.stopped = true
+	e.cancel()
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
+// Execute 校验并执行一条签名指令 (阻塞至执行完成)，返回的结果已记录审计并写入上报
+// 执行器停止后不再接受指令，也不记录指令 ID，服务端可在重启后重新下发
+func (e *Executor) Execute(ctx context.Context, data []byte) *model.CommandResultReport {
+	e.mu.Lock()
+	if e.stopped {
+		e.mu.Unlock()
+		var cmd Command
+		json.Unmarshal(data, &cmd)
+		report := model.NewCommandResultReport(cmd.ID, cmd.Type)
+		report.SetFailure(ErrStopped.Error())
+		return report
+	}
+	e.wg.Add(1)
+	e.mu.Unlock()
+	defer e.wg.Done()
+	cmd, err := e.verify(data)
+	if cmd.Type == "" {
+		cmd.Type = "unknown"
+	}
+	report := model.NewCommandResultReport(cmd.ID, cmd.Type)
+	if err != nil {
+		logger.Warn("拒绝执行服务端指令", "cmd_id", cmd.ID, "type", cmd.Type, "error", err)
+		report.SetFailure(err.Error())
+		e.audit(cmd.ID, fmt.Sprintf("拒绝执行指令 %s (%s): %v", cmd.ID, cmd.Type, err))
+		e.report(report)
+		return report
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(e.ctx, cancel)
+	defer stop()
+
+	logger.Info("执行服务端指令", "cmd_id", cmd.ID, "type", cmd.Type)
+	start := time.Now()
+	res, err := e.handlers[cmd.Type](ctx, cmd.Args)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	for _, d := range res.Detail {
+		report.AddDetail(d)
+	}
+	if err != nil {
+		logger.Warn("服务端指令执行失败", "cmd_id", cmd.ID, "type", cmd.Type, "error", err)
+		report.SetFailure(err.Error())
+		e.audit(cmd.ID, fmt.Sprintf("执行指令 %s (%s) 失败，耗时 %s: %v", cmd.ID, cmd.Type, elapsed, err))
+	} else {
+		if res.Message != "" {
+			report.Message = res.Message
+		}
+		e.audit(cmd.ID, fmt.Sprintf("执行指令 %s (%s) 成功，耗时 %s: %s", cmd.ID, cmd.Type, elapsed, report.Message))
+	}
+	e.report(report)
+	return report
+}
+
+// verify 校验签名、目标、有效期与类型，通过后记录指令 ID
+// 校验失败时返回已解析出的字段，用于审计与结果上报
+func (e *Executor) verify(data []byte) (Command, error) {
+	var cmd Command
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return cmd, fmt.Errorf("command: invalid JSON: %w", err)
+	}
+	if err := policy.VerifyWithUID(data, e.cfg.PublicKey, SignUID); err != nil {
+		return cmd, err
+	}
+	if cmd.ID == "" {
+		return cmd, errors.New("command: missing cmd_id")
+	}
+	if cmd.AgentUUID != "" && cmd.AgentUUID != e.cfg.AgentUUID {
+		return cmd, ErrWrongAgent
+	}
+
+	now := time.Now()
+	expires := cmd.ExpiresAt
+	if expires == 0 {
+		expires = time.Unix(cmd.IssuedAt, 0).Add(e.cfg.MaxAge).Unix()
+	}
+	if cmd.IssuedAt <= 0 || time.Unix(cmd.IssuedAt, 0).After(now.Add(clockSkew)) || now.Unix() > expires {
+		return cmd, ErrExpired
+	}
+	if _, ok := e.handlers[cmd.Type]; !ok {
+		return cmd, fmt.Errorf("%w %q", ErrUnknownType, cmd.Type)
+	}
+
+	// 执行前记录: 执行中途退出后不再重复执行 (至多一次)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.seen[cmd.ID]; ok {
+		return cmd, ErrDuplicate
+	}
+	e.seen[cmd.ID] = expires
+	if err := e.save(now.Unix()); err != nil {
+		logger.Warn("保存已执行指令记录失败", "path", e.cfg.StatePath, "error", err)
+	}
+	return cmd, nil
+}
+
+// save 清理已过期的记录后写入文件，调用方持有 e.mu
+func (e *Executor) save(now int64) error {
+	for id, exp := range e.seen {
+		if exp < now {
+			delete(e.seen, id)
+		}
+	}
+	if e.cfg.StatePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(e.seen)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(e.cfg.StatePath), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomic(e.cfg.StatePath, data, 0600)
+}
+
+// audit 写入审计日志
+// 审计日志 ID 限 20 字节，取 "cmd" 加指令 ID 与日志内容 SM3 摘要的前 17 个十六进制字符：
+// 同一毫秒内到达的不同指令不会冲突，同一指令的执行与之后的重放拒绝也各自不同
+func (e *Executor) audit(cmdID, message string) {
+	if e.cfg.Audit == nil {
+		return
+	}
+	now := time.Now()
+	sum := sm3.Sum([]byte(cmdID + "\x00" + message))
+	e.cfg.Audit(model.NewSystemAuditRequest(
+		"cmd"+hex.EncodeToString(sum[:])[:17],
+		"system",
+		now.Format("2006-01-02 15:04:05.000"),
+		model.LogTypeLocalOperation,
+		model.OpTypeRemoteCommand,
+		message,
+	))
+}
+
+func (e *Executor) report(r *model.CommandResultReport) {
+	r.CreatedAt = time.Now()
+	if e.cfg.Report != nil {
+		e.cfg.Report(r)
+	}
+}
+
+// writeFileAtomic 写入临时文件后重命名，避免中途退出留下不完整的记录
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var defaultExecutor atomic.Pointer[Executor]
+
+// SetDefault 设置全局执行器 (由主程序按配置设置，流式通道与 Poller 收到的指令交给它执行)
+func SetDefault(e *Executor) {
+	defaultExecutor.Store(e)
+}
+
+// Default 返回全局执行器，未启用时为 nil
+func Default() *Executor {
+	return defaultExecutor.Load()
+}
@@ -0,0 +1,219 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/detector/policy"
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/model"
+)
+
+func sign(t *testing.T, priv *sm2.PrivateKey, cmd Command) []byte {
+	t.Helper()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := policy.SignWithUID(data, priv, SignUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestExecutor(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	other, _ := sm2.GenerateKey(nil)
+	state := filepath.Join(t.TempDir(), "commands.json")
+
+	var (
+		mu      sync.Mutex
+		audits  []*model.SystemAuditRequest
+		reports []*model.CommandResultReport
+		paths   []string
+	)
+	newExecutor := func() *Executor {
+		e, err := New(Config{
+			PublicKey: &priv.PublicKey,
+			AgentUUID: "agent-1",
+			StatePath: state,
+			Audit: func(r *model.SystemAuditRequest) {
+				mu.Lock()
+				audits = append(audits, r)
+				mu.Unlock()
+			},
+			Report: func(r *model.CommandResultReport) {
+				mu.Lock()
+				reports = append(reports, r)
+				mu.Unlock()
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Handle(TypeRescan, func(_ context.Context, args json.RawMessage) (Result, error) {
+			var a struct {
+				Paths []string `json:"paths"`
+			}
+			if err := json.Unmarshal(args, &a); err != nil {
+				return Result{}, err
+			}
+			if len(a.Paths) == 0 {
+				return Result{}, errors.New("no paths")
+			}
+			paths = append(paths, a.Paths...)
+			return Result{Message: "submitted", Detail: a.Paths}, nil
+		})
+		return e
+	}
+	e := newExecutor()
+
+	now := time.Now().Unix()
+	ok := Command{ID: "c1", Type: TypeRescan, AgentUUID: "agent-1", IssuedAt: now, Args: json.RawMessage(`{"paths":["/home"]}`)}
+	if r := e.Execute(context.Background(), sign(t, priv, ok)); r.Result != 0 || r.Message != "submitted" || r.CmdID != "c1" || len(r.Detail) != 1 {
+		t.Fatalf("Execute(ok) = %+v", r)
+	}
+
+	tampered := sign(t, priv, Command{ID: "c2", Type: TypeRescan, IssuedAt: now, Args: json.RawMessage(`{"paths":["/home"]}`)})
+	var obj map[string]interface{}
+	json.Unmarshal(tampered, &obj)
+	obj["args"] = map[string]interface{}{"paths": []string{"/"}}
+	tampered, _ = json.Marshal(obj)
+
+	// 同一密钥按规则包方式 (默认用户标识) 签名的数据不能作为指令执行
+	asBundle, _ := json.Marshal(Command{ID: "c9", Type: TypeRescan, IssuedAt: now, Args: json.RawMessage(`{"paths":["/"]}`)})
+	asBundle, _ = policy.SignBundle(asBundle, priv)
+
+	for name, data := range map[string][]byte{
+		"replay":      sign(t, priv, ok),
+		"tampered":    tampered,
+		"other key":   sign(t, other, Command{ID: "c3", Type: TypeRescan, IssuedAt: now}),
+		"other agent": sign(t, priv, Command{ID: "c4", Type: TypeRescan, AgentUUID: "agent-2", IssuedAt: now}),
+		"expired":     sign(t, priv, Command{ID: "c5", Type: TypeRescan, IssuedAt: now - 3600, ExpiresAt: now - 60}),
+		"future":      sign(t, priv, Command{ID: "c6", Type: TypeRescan, IssuedAt: now + 3600}),
+		"unknown":     sign(t, priv, Command{ID: "c7", Type: "reboot", IssuedAt: now}),
+		"bundle":      asBundle,
+		"not json":    []byte("reboot"),
+	} {
+		if r := e.Execute(context.Background(), data); r.Result != 1 {
+			t.Errorf("%s: Execute() = %+v, want rejected", name, r)
+		}
+	}
+
+	// 处理函数失败: 结果为失败，指令同样不再重复执行
+	bad := sign(t, priv, Command{ID: "c8", Type: TypeRescan, IssuedAt: now, Args: json.RawMessage(`{}`)})
+	if r := e.Execute(context.Background(), bad); r.Result != 1 || r.Message != "no paths" {
+		t.Errorf("Execute(bad) = %+v", r)
+	}
+
+	if len(paths) != 1 || paths[0] != "/home" {
+		t.Errorf("handler paths = %v", paths)
+	}
+	if len(audits) != 11 || len(reports) != 11 {
+		t.Errorf("audits = %d, reports = %d, want 11", len(audits), len(reports))
+	}
+	ids := make(map[string]bool)
+	for _, a := range audits {
+		if a.OpType != model.OpTypeRemoteCommand || len(a.ID) > 20 || ids[a.ID] {
+			t.Errorf("audit = %+v", a)
+		}
+		ids[a.ID] = true
+	}
+
+	// 重启后仍拒绝重放
+	e2 := newExecutor()
+	if r := e2.Execute(context.Background(), sign(t, priv, ok)); r.Result != 1 {
+		t.Errorf("replay after restart = %+v", r)
+	}
+	if len(paths) != 1 {
+		t.Errorf("replayed command executed: %v", paths)
+	}
+}
+
+func TestExecutor_StopWhileExecuting(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	e, err := New(Config{PublicKey: &priv.PublicKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var running, afterStop sync.WaitGroup
+	var stopped atomic.Bool
+	e.Handle(TypeRescan, func(ctx context.Context, _ json.RawMessage) (Result, error) {
+		if stopped.Load() {
+			t.Error("Stop() 返回后仍有指令开始执行")
+		}
+		<-ctx.Done()
+		return Result{}, ctx.Err()
+	})
+
+	// 与 Stop 并发到达的指令要么在 Stop 返回前结束，要么被拒绝
+	now := time.Now().Unix()
+	for i := 0; i < 20; i++ {
+		data := sign(t, priv, Command{ID: fmt.Sprintf("c%d", i), Type: TypeRescan, IssuedAt: now})
+		running.Add(1)
+		afterStop.Add(1)
+		go func() {
+			defer afterStop.Done()
+			running.Done()
+			e.Execute(context.Background(), data)
+		}()
+	}
+	running.Wait()
+	e.Stop()
+	stopped.Store(true)
+	if r := e.Execute(context.Background(), sign(t, priv, Command{ID: "late", Type: TypeRescan, IssuedAt: now})); r.Result != 1 || r.Message != ErrStopped.Error() {
+		t.Errorf("Execute() after Stop = %+v", r)
+	}
+	afterStop.Wait()
+}
+
+func TestPoller(t *testing.T) {
+	priv, _ := sm2.GenerateKey(nil)
+	now := time.Now().Unix()
+	cmd := sign(t, priv, Command{ID: "p1", Type: TypeDiagnostics, IssuedAt: now})
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agent/commands" || r.URL.Query().Get("agent_uuid") != "agent-1" {
+			http.NotFound(w, r)
+			return
+		}
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"commands":[` + string(cmd) + `]}`))
+	}))
+	defer srv.Close()
+
+	e, err := New(Config{PublicKey: &priv.PublicKey, AgentUUID: "agent-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Handle(TypeDiagnostics, func(context.Context, json.RawMessage) (Result, error) {
+		return Result{Detail: RuntimeDiagnostics()}, nil
+	})
+	p := NewPoller(PollConfig{URL: srv.URL, Path: "/api/v1/agent/commands", AgentUUID: "agent-1"}, srv.Client(), e)
+
+	cmds, err := p.Poll(context.Background())
+	if err != nil || len(cmds) != 1 {
+		t.Fatalf("Poll() = %d, %v", len(cmds), err)
+	}
+	if r := e.Execute(context.Background(), cmds[0]); r.Result != 0 || len(r.Detail) < 3 {
+		t.Errorf("Execute() = %+v", r)
+	}
+	if cmds, err := p.Poll(context.Background()); err != nil || len(cmds) != 0 {
+		t.Errorf("Poll() = %d, %v", len(cmds), err)
+	}
+}
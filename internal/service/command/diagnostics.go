@@ -0,0 +1,29 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// started 进程启动时间 (近似为包初始化时间)
+var started = time.Now()
+
+// RuntimeDiagnostics 收集进程运行状态，用于 collect_diagnostics 指令的详情
+// 组件相关的状态 (上报通道、队列等) 由注册处理函数的一方追加
+func RuntimeDiagnostics() []string {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	host, _ := os.Hostname()
+
+	lines := []string{
+		fmt.Sprintf("host=%s pid=%d uptime=%s", host, os.Getpid(), time.Since(started).Round(time.Second)),
+		fmt.Sprintf("go=%s os=%s/%s cpus=%d gomaxprocs=%d", runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.GOMAXPROCS(0)),
+		fmt.Sprintf("goroutines=%d heap_alloc=%dKB sys=%dKB gc=%d", runtime.NumGoroutine(), ms.HeapAlloc>>10, ms.Sys>>10, ms.NumGC),
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		lines = append(lines, fmt.Sprintf("open_fds=%d", len(fds)))
+	}
+	return lines
+}
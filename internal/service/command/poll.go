@@ -0,0 +1,130 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// PollConfig 拉取配置
+type PollConfig struct {
+	// 管理平台地址
+	URL string
+	// 待执行指令查询接口路径
+	Path string
+	// 本机标识，作为查询参数 agent_uuid
+	AgentUUID string
+	// 拉取周期
+	Interval time.Duration
+	// 单次查询超时
+	Timeout time.Duration
+}
+
+// pollResponse 查询接口返回的待执行指令，每条为签名指令 JSON
+type pollResponse struct {
+	Commands []json.RawMessage `json:"commands"`
+}
+
+// Poller 定期向服务端拉取待执行指令，用于没有流式通道的部署
+// 指令依次执行，执行结果由执行器写入指令结果上报
+type Poller struct {
+	cfg    PollConfig
+	client *http.Client
+	exec   *Executor
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPoller 创建拉取器
+func NewPoller(cfg PollConfig, client *http.Client, exec *Executor) *Poller {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Poller{cfg: cfg, client: client, exec: exec, ctx: ctx, cancel: cancel}
+}
+
+// Start 立即拉取并定期拉取 (非阻塞)
+func (p *Poller) Start() {
+	p.wg.Add(1)
+	go p.loop()
+	logger.Info("服务端指令拉取已启动", "interval", p.cfg.Interval)
+}
+
+// Stop 停止拉取，取消执行中的指令并等待其返回
+func (p *Poller) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Poller) loop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		cmds, err := p.Poll(p.ctx)
+		if err != nil && p.ctx.Err() == nil {
+			logger.Warn("拉取服务端指令失败", "error", err)
+		}
+		for _, data := range cmds {
+			if p.ctx.Err() != nil {
+				return
+			}
+			p.exec.Execute(p.ctx, data)
+		}
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 查询一次待执行指令，服务端没有待执行指令时返回 204 或空列表
+func (p *Poller) Poll(ctx context.Context) ([]json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	u := strings.TrimRight(p.cfg.URL, "/") + p.cfg.Path + "?agent_uuid=" + url.QueryEscape(p.cfg.AgentUUID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("commands: unexpected status %s", resp.Status)
+	}
+	var pr pollResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4<<20)).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("parse commands: %w", err)
+	}
+	return pr.Commands, nil
+}
@@ -0,0 +1,164 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(_ context.Context, path string) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	if strings.Contains(filepath.Base(path), "secret") {
+		return true, &model.AlertRecord{FilePath: path}, &model.AlertLogItem{FilePath: path}, nil
+	}
+	return false, nil, nil, nil
+}
+
+type fakeRuntime struct {
+	name       string
+	containers []Container
+}
+
+func (f fakeRuntime) Name() string { return f.name }
+func (f fakeRuntime) List(context.Context) ([]Container, error) {
+	return f.containers, nil
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanner_TagsAlertsWithContainer(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "app", "secret.doc"), "x")
+	writeFile(t, filepath.Join(root, "app", "readme.txt"), "x")
+	writeFile(t, filepath.Join(root, "proc", "1", "secret_status"), "x") // 虚拟文件系统不扫描
+	os.Symlink("/etc/secret_host", filepath.Join(root, "app", "secret_link"))
+
+	c := Container{ID: "0123456789abcdef", Name: "web", Image: "nginx:1.25", Runtime: "docker", RootFS: root}
+	dup := c
+	dup.Runtime = "containerd"
+
+	var mu sync.Mutex
+	var records []*model.AlertRecord
+	s := NewScanner(Config{}, []Runtime{
+		fakeRuntime{name: "docker", containers: []Container{c}},
+		fakeRuntime{name: "containerd", containers: []Container{dup}},
+	}, fakeDetector{}, func(r *model.AlertRecord, _ *model.AlertLogItem) {
+		mu.Lock()
+		records = append(records, r)
+		mu.Unlock()
+	})
+
+	if list := s.List(context.Background()); len(list) != 1 || list[0].Runtime != "docker" {
+		t.Fatalf("同一容器应只保留先出现的运行时: %+v", list)
+	}
+
+	s.poll()
+	if len(records) != 1 {
+		t.Fatalf("告警数 = %d, want 1", len(records))
+	}
+	var ext map[string]interface{}
+	if err := json.Unmarshal([]byte(records[0].ExtendFields), &ext); err != nil {
+		t.Fatal(err)
+	}
+	if ext["container_id"] != c.ID || ext["container_image"] != "nginx:1.25" || ext["container_path"] != "/app/secret.doc" {
+		t.Errorf("扩展字段 = %v", ext)
+	}
+	if records[0].FilePath != filepath.Join(root, "app", "secret.doc") {
+		t.Errorf("FilePath = %s", records[0].FilePath)
+	}
+
+	st := s.Containers()
+	if len(st) != 1 || st[0].State != StateHit || st[0].Scanned != 2 {
+		t.Errorf("扫描状态 = %+v", st)
+	}
+
+	// 未配置重扫间隔时不重复扫描
+	s.poll()
+	if len(records) != 1 {
+		t.Errorf("重复扫描: 告警数 = %d", len(records))
+	}
+}
+
+func TestContainerdRuntime_List(t *testing.T) {
+	state := t.TempDir()
+	bundle := filepath.Join(state, "k8s.io", "abc123")
+	writeFile(t, filepath.Join(bundle, "config.json"), `{"annotations":{
+		"io.kubernetes.cri.container-type":"container",
+		"io.kubernetes.cri.image-name":"docker.io/library/redis:7",
+		"io.kubernetes.cri.container-name":"redis",
+		"io.kubernetes.cri.sandbox-name":"cache-0"}}`)
+	os.MkdirAll(filepath.Join(bundle, "rootfs"), 0755)
+
+	pause := filepath.Join(state, "k8s.io", "pause1")
+	writeFile(t, filepath.Join(pause, "config.json"), `{"annotations":{"io.kubernetes.cri.container-type":"sandbox"}}`)
+	os.MkdirAll(filepath.Join(pause, "rootfs"), 0755)
+
+	os.MkdirAll(filepath.Join(state, "moby", "dockerct", "rootfs"), 0755)
+
+	list, err := NewContainerdRuntime(state, "moby").List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("容器列表 = %+v", list)
+	}
+	got := list[0]
+	if got.ID != "abc123" || got.Image != "docker.io/library/redis:7" || got.Name != "cache-0/redis" ||
+		got.RootFS != filepath.Join(bundle, "rootfs") {
+		t.Errorf("容器 = %+v", got)
+	}
+}
+
+func TestDockerRuntime_List(t *testing.T) {
+	merged := t.TempDir()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("不支持 unix 套接字: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Id":"c1","State":"running"},{"Id":"c2","State":"exited"}]`))
+	})
+	mux.HandleFunc("/containers/c1/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Id":          "c1",
+			"Name":        "/db",
+			"Config":      map[string]string{"Image": "postgres:16"},
+			"State":       map[string]interface{}{"Running": true, "Pid": 0},
+			"GraphDriver": map[string]interface{}{"Data": map[string]string{"MergedDir": merged}},
+		})
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	list, err := NewDockerRuntime(socket).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("容器列表 = %+v", list)
+	}
+	if got := list[0]; got.Name != "db" || got.Image != "postgres:16" || got.RootFS != merged {
+		t.Errorf("容器 = %+v", got)
+	}
+}
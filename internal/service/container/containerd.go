@@ -0,0 +1,112 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultContainerdStateDir containerd v2 shim 任务目录
+// 结构为 <namespace>/<容器 ID>/{config.json, init.pid, rootfs}，任务运行期间 rootfs 保持挂载
+const DefaultContainerdStateDir = "/run/containerd/io.containerd.runtime.v2.task"
+
+// CRI (Kubernetes) 写入 OCI 配置的注解
+const (
+	annotationImageName     = "io.kubernetes.cri.image-name"
+	annotationContainerName = "io.kubernetes.cri.container-name"
+	annotationSandboxName   = "io.kubernetes.cri.sandbox-name"
+	annotationContainerType = "io.kubernetes.cri.container-type"
+)
+
+// ContainerdRuntime 读取 containerd 任务目录枚举容器
+// 不依赖 containerd 的 gRPC 接口，覆盖 nerdctl、ctr 与 Kubernetes CRI 创建的容器
+type ContainerdRuntime struct {
+	stateDir string
+	// 跳过的命名空间，Docker 管理的容器位于 moby 命名空间，已由 DockerRuntime 覆盖时可跳过
+	skipNamespaces map[string]bool
+}
+
+// NewContainerdRuntime 创建 containerd 运行时，stateDir 为空时使用 DefaultContainerdStateDir
+func NewContainerdRuntime(stateDir string, skipNamespaces ...string) *ContainerdRuntime {
+	if stateDir == "" {
+		stateDir = DefaultContainerdStateDir
+	}
+	skip := make(map[string]bool, len(skipNamespaces))
+	for _, ns := range skipNamespaces {
+		skip[ns] = true
+	}
+	return &ContainerdRuntime{stateDir: stateDir, skipNamespaces: skip}
+}
+
+// Name 运行时名称
+func (c *ContainerdRuntime) Name() string { return "containerd" }
+
+// ociSpec OCI 运行时配置中需要的字段
+type ociSpec struct {
+	Annotations map[string]string `json:"annotations"`
+}
+
+// List 列出运行中的容器
+func (c *ContainerdRuntime) List(ctx context.Context) ([]Container, error) {
+	namespaces, err := os.ReadDir(c.stateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var list []Container
+	for _, ns := range namespaces {
+		if !ns.IsDir() || c.skipNamespaces[ns.Name()] {
+			continue
+		}
+		tasks, err := os.ReadDir(filepath.Join(c.stateDir, ns.Name()))
+		if err != nil {
+			continue
+		}
+		for _, task := range tasks {
+			if ctx.Err() != nil {
+				return list, ctx.Err()
+			}
+			if !task.IsDir() {
+				continue
+			}
+			if ct, ok := c.load(filepath.Join(c.stateDir, ns.Name(), task.Name())); ok {
+				list = append(list, ct)
+			}
+		}
+	}
+	return list, nil
+}
+
+// load 读取单个任务目录
+func (c *ContainerdRuntime) load(bundle string) (Container, bool) {
+	ct := Container{
+		ID:      filepath.Base(bundle),
+		Runtime: c.Name(),
+	}
+
+	var spec ociSpec
+	if data, err := os.ReadFile(filepath.Join(bundle, "config.json")); err == nil {
+		json.Unmarshal(data, &spec)
+	}
+	// Kubernetes Pod 的 pause 容器没有业务文件
+	if spec.Annotations[annotationContainerType] == "sandbox" {
+		return ct, false
+	}
+	ct.Image = spec.Annotations[annotationImageName]
+	ct.Name = spec.Annotations[annotationContainerName]
+	if pod := spec.Annotations[annotationSandboxName]; pod != "" && ct.Name != "" {
+		ct.Name = pod + "/" + ct.Name
+	}
+
+	if data, err := os.ReadFile(filepath.Join(bundle, "init.pid")); err == nil {
+		ct.PID, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+	ct.RootFS = rootFS(ct.PID, filepath.Join(bundle, "rootfs"))
+	return ct, ct.RootFS != ""
+}
@@ -0,0 +1,118 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultDockerSocket Docker Engine API 默认套接字
+const DefaultDockerSocket = "/var/run/docker.sock"
+
+// DockerRuntime 通过 Docker Engine API (unix 套接字) 枚举容器
+type DockerRuntime struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewDockerRuntime 创建 Docker 运行时，socket 为空时使用 DefaultDockerSocket
+func NewDockerRuntime(socket string) *DockerRuntime {
+	if socket == "" {
+		socket = DefaultDockerSocket
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	return &DockerRuntime{
+		client:  &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		baseURL: "http://docker",
+	}
+}
+
+// Name 运行时名称
+func (d *DockerRuntime) Name() string { return "docker" }
+
+// dockerSummary GET /containers/json 的返回项
+type dockerSummary struct {
+	ID    string `json:"Id"`
+	State string `json:"State"`
+}
+
+// dockerInspect GET /containers/{id}/json 的返回 (仅需要的字段)
+type dockerInspect struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image string `json:"Image"`
+	} `json:"Config"`
+	State struct {
+		Running bool `json:"Running"`
+		Pid     int  `json:"Pid"`
+	} `json:"State"`
+	GraphDriver struct {
+		Data map[string]string `json:"Data"`
+	} `json:"GraphDriver"`
+}
+
+// List 列出运行中的容器
+func (d *DockerRuntime) List(ctx context.Context) ([]Container, error) {
+	var summaries []dockerSummary
+	if err := d.get(ctx, "/containers/json", &summaries); err != nil {
+		return nil, err
+	}
+
+	list := make([]Container, 0, len(summaries))
+	for _, s := range summaries {
+		if s.State != "" && s.State != "running" {
+			continue
+		}
+		var info dockerInspect
+		if err := d.get(ctx, "/containers/"+url.PathEscape(s.ID)+"/json", &info); err != nil {
+			// 容器可能在枚举期间退出
+			continue
+		}
+		if !info.State.Running {
+			continue
+		}
+		root := rootFS(info.State.Pid, info.GraphDriver.Data["MergedDir"])
+		if root == "" {
+			continue
+		}
+		list = append(list, Container{
+			ID:      info.ID,
+			Name:    strings.TrimPrefix(info.Name, "/"),
+			Image:   info.Config.Image,
+			Runtime: d.Name(),
+			PID:     info.State.Pid,
+			RootFS:  root,
+		})
+	}
+	return list, nil
+}
+
+func (d *DockerRuntime) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("docker api %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("docker api %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
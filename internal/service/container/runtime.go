@@ -0,0 +1,59 @@
+// Package container 容器文件系统扫描
+// 枚举本机运行中的容器 (Docker Engine API、containerd 任务目录)，
+// 通过 /proc/<pid>/root 访问容器根文件系统并调用检测器扫描，告警附带容器 ID 与镜像
+package container
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// procRoot proc 文件系统挂载点，测试时可替换
+var procRoot = "/proc"
+
+// Container 运行中的容器
+type Container struct {
+	ID      string // 完整容器 ID
+	Name    string
+	Image   string // 镜像名 (如 nginx:1.25)，未知时为空
+	Runtime string // docker / containerd
+	PID     int    // 容器 init 进程在宿主机上的 PID，0 表示未知
+	// 宿主机上可访问的根文件系统路径
+	// 优先使用 /proc/<pid>/root (与存储驱动无关)，无法取得 PID 时为 overlay 合并目录
+	RootFS string
+}
+
+// ShortID 12 位短 ID，与 docker ps 输出一致
+func (c Container) ShortID() string {
+	if len(c.ID) > 12 {
+		return c.ID[:12]
+	}
+	return c.ID
+}
+
+// Runtime 容器运行时
+type Runtime interface {
+	// Name 运行时名称
+	Name() string
+	// List 列出运行中的容器
+	List(ctx context.Context) ([]Container, error)
+}
+
+// rootFS 选择容器根文件系统路径
+// 进程仍存活时使用 /proc/<pid>/root，否则回退到 fallback (可为空)
+func rootFS(pid int, fallback string) string {
+	if pid > 0 {
+		root := filepath.Join(procRoot, strconv.Itoa(pid), "root")
+		if _, err := os.Stat(root + string(filepath.Separator)); err == nil {
+			return root
+		}
+	}
+	if fallback != "" {
+		if info, err := os.Stat(fallback); err == nil && info.IsDir() {
+			return fallback
+		}
+	}
+	return ""
+}
@@ -0,0 +1,337 @@
+package container
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// Config 扫描配置
+type Config struct {
+	// 容器列表轮询间隔，发现新容器后扫描
+	PollInterval time.Duration
+	// 已扫描容器的重新扫描间隔，0 表示每个容器只扫描一次
+	RescanInterval time.Duration
+	// 容器内的扫描路径，为空表示整个根文件系统
+	Paths []string
+	// 单个容器扫描超时，0 表示不限
+	ScanTimeout time.Duration
+}
+
+// FileDetector 文件检测接口 (由 detector.Manager 实现)
+type FileDetector interface {
+	Detect(ctx context.Context, filePath string) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// AlertSink 命中涉密文件时的告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// 容器扫描状态
+const (
+	StateScanning = "scanning"
+	StateClean    = "clean"
+	StateHit      = "hit" // 发现涉密文件
+	StateFailed   = "failed"
+)
+
+// skipContainerDirs 容器内的虚拟文件系统，不扫描
+var skipContainerDirs = []string{"/proc", "/sys", "/dev"}
+
+// ContainerStatus 容器扫描状态
+type ContainerStatus struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Image      string    `json:"image"`
+	Runtime    string    `json:"runtime"`
+	State      string    `json:"state"`
+	Scanned    int64     `json:"scanned"`
+	Hits       int64     `json:"hits"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Scanner 容器文件系统扫描
+// 告警的 FilePath 为宿主机可访问的路径 (/proc/<pid>/root/...)，隔离/阻断等处置动作可直接作用；
+// 容器内路径、容器 ID、名称、镜像与运行时写入 ExtendFields
+type Scanner struct {
+	cfg      Config
+	runtimes []Runtime
+	detector FileDetector
+	sink     AlertSink
+
+	mu     sync.Mutex
+	status map[string]*ContainerStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScanner 创建扫描器，多个运行时返回同一容器时以先出现的为准
+func NewScanner(cfg Config, runtimes []Runtime, detector FileDetector, sink AlertSink) *Scanner {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Minute
+	}
+	if len(cfg.Paths) == 0 {
+		cfg.Paths = []string{"/"}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scanner{
+		cfg:      cfg,
+		runtimes: runtimes,
+		detector: detector,
+		sink:     sink,
+		status:   make(map[string]*ContainerStatus),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 启动扫描 (非阻塞)，启动后立即扫描当前运行的容器
+func (s *Scanner) Start() {
+	s.wg.Add(1)
+	go s.loop()
+
+	names := make([]string, 0, len(s.runtimes))
+	for _, rt := range s.runtimes {
+		names = append(names, rt.Name())
+	}
+	logger.Info("容器扫描已启动", "runtimes", names, "poll_interval", s.cfg.PollInterval)
+}
+
+// Stop 停止扫描并等待进行中的扫描退出
+func (s *Scanner) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scanner) loop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.poll()
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// List 汇总各运行时的容器列表
+func (s *Scanner) List(ctx context.Context) []Container {
+	seen := make(map[string]bool)
+	var list []Container
+	for _, rt := range s.runtimes {
+		containers, err := rt.List(ctx)
+		if err != nil {
+			logger.Debug("枚举容器失败", "runtime", rt.Name(), "error", err)
+			continue
+		}
+		for _, c := range containers {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			list = append(list, c)
+		}
+	}
+	return list
+}
+
+// poll 扫描新出现或到期需要重扫的容器，并清理已退出容器的状态
+func (s *Scanner) poll() {
+	containers := s.List(s.ctx)
+
+	running := make(map[string]bool, len(containers))
+	var due []Container
+
+	s.mu.Lock()
+	for _, c := range containers {
+		running[c.ID] = true
+		st, ok := s.status[c.ID]
+		if !ok || (s.cfg.RescanInterval > 0 && st.State != StateScanning &&
+			time.Since(st.FinishedAt) >= s.cfg.RescanInterval) {
+			due = append(due, c)
+		}
+	}
+	for id := range s.status {
+		if !running[id] {
+			delete(s.status, id)
+		}
+	}
+	s.mu.Unlock()
+
+	// 逐个扫描，避免多个容器同时扫描造成 I/O 压力
+	for _, c := range due {
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.ScanContainer(s.ctx, c)
+	}
+}
+
+// ScanContainer 扫描单个容器
+func (s *Scanner) ScanContainer(ctx context.Context, c Container) ContainerStatus {
+	st := &ContainerStatus{
+		ID:        c.ID,
+		Name:      c.Name,
+		Image:     c.Image,
+		Runtime:   c.Runtime,
+		State:     StateScanning,
+		StartedAt: time.Now(),
+	}
+	s.mu.Lock()
+	s.status[c.ID] = st
+	s.mu.Unlock()
+
+	logger.Info("开始扫描容器", "id", c.ShortID(), "name", c.Name, "image", c.Image, "runtime", c.Runtime)
+
+	if s.cfg.ScanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.ScanTimeout)
+		defer cancel()
+	}
+
+	var scanned, hits int64
+	var err error
+	for _, p := range s.cfg.Paths {
+		err = s.walk(ctx, c, p, func(hostPath, containerPath string) {
+			found, record, logItem, derr := s.detector.Detect(ctx, hostPath)
+			scanned++
+			if derr != nil {
+				logger.Debug("容器文件检测失败", "container", c.ShortID(), "path", containerPath, "error", derr)
+				return
+			}
+			if !found {
+				return
+			}
+			hits++
+			if record != nil {
+				record.AddExtendFields(map[string]interface{}{
+					"container_id":      c.ID,
+					"container_name":    c.Name,
+					"container_image":   c.Image,
+					"container_runtime": c.Runtime,
+					"container_path":    containerPath,
+				})
+			}
+			if s.sink != nil {
+				s.sink(record, logItem)
+			}
+		})
+		if err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	st.Scanned = scanned
+	st.Hits = hits
+	st.FinishedAt = time.Now()
+	switch {
+	case hits > 0:
+		st.State = StateHit
+	case err != nil:
+		st.State = StateFailed
+		st.Error = err.Error()
+	default:
+		st.State = StateClean
+	}
+	result := *st
+	s.mu.Unlock()
+
+	logger.Info("容器扫描完成",
+		"id", c.ShortID(),
+		"scanned", scanned,
+		"hits", hits,
+		"error", err,
+	)
+	return result
+}
+
+// walk 遍历容器内 dir 下的普通文件
+// 符号链接不跟随：容器内的绝对链接在宿主机上会解析到宿主机文件
+func (s *Scanner) walk(ctx context.Context, c Container, dir string, fn func(hostPath, containerPath string)) error {
+	dir = filepath.Clean("/" + dir)
+	filter := pathfilter.Default()
+
+	// /proc/<pid>/root 本身是符号链接，WalkDir 不会进入，需从其下一级开始遍历
+	var starts []string
+	if dir == "/" {
+		entries, err := os.ReadDir(c.RootFS + string(filepath.Separator))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			starts = append(starts, "/"+e.Name())
+		}
+	} else {
+		starts = []string{dir}
+	}
+
+	for _, start := range starts {
+		err := filepath.WalkDir(filepath.Join(c.RootFS, start), func(hostPath string, d fs.DirEntry, err error) error {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			containerPath := "/" + strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(hostPath, c.RootFS)), "/")
+			depth := pathfilter.Depth(dir, containerPath)
+			if d.IsDir() {
+				if skipContainerDir(containerPath) || filter.SkipDir(containerPath, depth) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || filter.SkipFile(containerPath, depth) {
+				return nil
+			}
+			fn(hostPath, containerPath)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func skipContainerDir(path string) bool {
+	for _, dir := range skipContainerDirs {
+		if path == dir || strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Containers 返回容器扫描状态快照
+func (s *Scanner) Containers() []ContainerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]ContainerStatus, 0, len(s.status))
+	for _, st := range s.status {
+		list = append(list, *st)
+	}
+	return list
+}
@@ -0,0 +1,123 @@
+package detectapi
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// 访问权限位
+const (
+	permRead   = 4
+	permSearch = 1
+)
+
+// POSIX ACL 条目类型 (linux/posix_acl.h)
+const (
+	aclUserObj  = 0x01
+	aclUser     = 0x02
+	aclGroupObj = 0x04
+	aclGroup    = 0x08
+	aclMask     = 0x10
+	aclOther    = 0x20
+)
+
+// aclEntry POSIX ACL 条目
+type aclEntry struct {
+	Tag  uint16
+	Perm uint16
+	ID   uint32
+}
+
+// fileAccess 文件的属主、属组、权限位与 ACL，用于按调用方凭据判断访问权限
+type fileAccess struct {
+	UID  uint32
+	GID  uint32
+	Mode uint32
+	// 扩展 ACL (system.posix_acl_access)，为空时只按权限位判断
+	ACL []aclEntry
+}
+
+// allows 凭据为 uid/groups 的进程是否具有 want 权限，按内核 posix_acl_permission 的顺序判断：
+// 属主 → 指定用户 → 属组与指定组 (任一匹配的组允许即可，都不允许则拒绝) → 其他用户
+func (f fileAccess) allows(uid uint32, groups []uint32, want uint16) bool {
+	acl := f.ACL
+	if len(acl) == 0 {
+		acl = []aclEntry{
+			{Tag: aclUserObj, Perm: uint16(f.Mode>>6) & 7},
+			{Tag: aclGroupObj, Perm: uint16(f.Mode>>3) & 7},
+			{Tag: aclOther, Perm: uint16(f.Mode) & 7},
+		}
+	}
+	mask := uint16(7)
+	for _, e := range acl {
+		if e.Tag == aclMask {
+			mask = e.Perm
+		}
+	}
+	inGroups := func(gid uint32) bool {
+		for _, g := range groups {
+			if g == gid {
+				return true
+			}
+		}
+		return false
+	}
+
+	if uid == f.UID {
+		for _, e := range acl {
+			if e.Tag == aclUserObj {
+				return e.Perm&want == want
+			}
+		}
+		return false
+	}
+	for _, e := range acl {
+		if e.Tag == aclUser && e.ID == uid {
+			return e.Perm&mask&want == want
+		}
+	}
+	matched := false
+	for _, e := range acl {
+		var gid uint32
+		switch e.Tag {
+		case aclGroupObj:
+			gid = f.GID
+		case aclGroup:
+			gid = e.ID
+		default:
+			continue
+		}
+		if !inGroups(gid) {
+			continue
+		}
+		if e.Perm&mask&want == want {
+			return true
+		}
+		matched = true
+	}
+	if matched {
+		return false
+	}
+	for _, e := range acl {
+		if e.Tag == aclOther {
+			return e.Perm&want == want
+		}
+	}
+	return false
+}
+
+// parseACL 解析 system.posix_acl_access 扩展属性 (版本 2，每个条目 8 字节，小端序)
+func parseACL(data []byte) ([]aclEntry, error) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != 2 || (len(data)-4)%8 != 0 {
+		return nil, errors.New("invalid posix acl")
+	}
+	entries := make([]aclEntry, 0, (len(data)-4)/8)
+	for b := data[4:]; len(b) > 0; b = b[8:] {
+		entries = append(entries, aclEntry{
+			Tag:  binary.LittleEndian.Uint16(b),
+			Perm: binary.LittleEndian.Uint16(b[2:]),
+			ID:   binary.LittleEndian.Uint32(b[4:]),
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,185 @@
+package detectapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+)
+
+// Client 检测服务客户端，供同机的 Go 组件使用
+type Client struct {
+	http *http.Client
+}
+
+// NewClient 创建连接到指定 socket 的客户端，以 gRPC (明文 HTTP/2) 调用
+func NewClient(socketPath string) *Client {
+	dialer := &net.Dialer{}
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	return &Client{
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+				Protocols: protocols,
+			},
+		},
+	}
+}
+
+// DetectFile 检测本机文件
+func (c *Client) DetectFile(ctx context.Context, path string) (*DetectResponse, error) {
+	var resp DetectResponse
+	err := c.call(ctx, MethodDetectFile, DetectFileRequest{Path: path}, &resp)
+	return &resp, err
+}
+
+// DetectBytes 检测内容
+func (c *Client) DetectBytes(ctx context.Context, name string, content []byte) (*DetectResponse, error) {
+	var resp DetectResponse
+	err := c.call(ctx, MethodDetectBytes, DetectBytesRequest{Name: name, Content: content}, &resp)
+	return &resp, err
+}
+
+// GetRules 获取当前检测规则
+func (c *Client) GetRules(ctx context.Context) (*Rules, error) {
+	var resp Rules
+	err := c.call(ctx, MethodGetRules, struct{}{}, &resp)
+	return &resp, err
+}
+
+// SetRules 更新检测规则，返回生效后的规则
+func (c *Client) SetRules(ctx context.Context, rules Rules) (*Rules, error) {
+	var resp Rules
+	err := c.call(ctx, MethodSetRules, rules, &resp)
+	return &resp, err
+}
+
+// Status 获取服务状态
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	var resp StatusResponse
+	err := c.call(ctx, MethodStatus, struct{}{}, &resp)
+	return &resp, err
+}
+
+// ListWhitelist 获取网络白名单
+func (c *Client) ListWhitelist(ctx context.Context) ([]WhitelistRule, error) {
+	var resp WhitelistResponse
+	err := c.call(ctx, MethodListWhitelist, struct{}{}, &resp)
+	return resp.Rules, err
+}
+
+// AddWhitelist 添加网络白名单规则 (IP 或 CIDR)，返回规范化后的规则
+func (c *Client) AddWhitelist(ctx context.Context, value, desc string) (*WhitelistRule, error) {
+	var resp WhitelistRule
+	err := c.call(ctx, MethodAddWhitelist, WhitelistRequest{Value: value, Desc: desc}, &resp)
+	return &resp, err
+}
+
+// RemoveWhitelist 删除运行时添加的网络白名单规则，返回删除后的规则列表
+func (c *Client) RemoveWhitelist(ctx context.Context, value string) ([]WhitelistRule, error) {
+	var resp WhitelistResponse
+	err := c.call(ctx, MethodRemoveWhitelist, WhitelistRequest{Value: value}, &resp)
+	return resp.Rules, err
+}
+
+// ListRuleStats 获取检测规则命中统计
+func (c *Client) ListRuleStats(ctx context.Context) ([]RuleStat, error) {
+	var resp RuleStatsResponse
+	err := c.call(ctx, MethodListRuleStats, struct{}{}, &resp)
+	return resp.Rules, err
+}
+
+// MarkAlert 标记或取消标记告警为误报，返回告警对应规则更新后的统计
+func (c *Client) MarkAlert(ctx context.Context, alertID string, falsePositive bool) (*RuleStat, error) {
+	var resp RuleStat
+	err := c.call(ctx, MethodMarkAlert, MarkAlertRequest{AlertID: alertID, FalsePositive: falsePositive}, &resp)
+	return &resp, err
+}
+
+// ResetRuleStat 重置规则命中统计，返回重置后的统计列表
+func (c *Client) ResetRuleStat(ctx context.Context, module string, ruleID int64) ([]RuleStat, error) {
+	var resp RuleStatsResponse
+	err := c.call(ctx, MethodResetRuleStat, ResetRuleStatRequest{Module: module, RuleID: ruleID}, &resp)
+	return resp.Rules, err
+}
+
+// ListEvidence 获取取证留存记录
+func (c *Client) ListEvidence(ctx context.Context) ([]EvidenceItem, error) {
+	var resp EvidenceListResponse
+	err := c.call(ctx, MethodListEvidence, struct{}{}, &resp)
+	return resp.Items, err
+}
+
+// GetEvidence 按告警 ID 取回证据内容
+func (c *Client) GetEvidence(ctx context.Context, alertID string) (*EvidenceItem, []byte, error) {
+	var resp GetEvidenceResponse
+	err := c.call(ctx, MethodGetEvidence, GetEvidenceRequest{AlertID: alertID}, &resp)
+	return &resp.Evidence, resp.Content, err
+}
+
+// GetLogLevels 获取当前日志级别
+func (c *Client) GetLogLevels(ctx context.Context) (*LogLevelsResponse, error) {
+	var resp LogLevelsResponse
+	err := c.call(ctx, MethodGetLogLevels, struct{}{}, &resp)
+	return &resp, err
+}
+
+// SetLogLevel 调整日志级别，module 为空时调整全局级别，level 为空时取消该模块的覆盖
+func (c *Client) SetLogLevel(ctx context.Context, module, level string) (*LogLevelsResponse, error) {
+	var resp LogLevelsResponse
+	err := c.call(ctx, MethodSetLogLevel, SetLogLevelRequest{Module: module, Level: level}, &resp)
+	return &resp, err
+}
+
+// call 发送 gRPC 请求并解析响应，服务端错误以 *Error 返回
+func (c *Client) call(ctx context.Context, method string, req, resp interface{}) error {
+	msg, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := writeFrame(&body, msg); err != nil {
+		return err
+	}
+
+	// 主机名仅占位，实际通过 socket 连接
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://detectapi"+methodPath(method), &body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", grpcJSONContentType)
+	httpReq.Header.Set("TE", "trailers")
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("detectapi: %s: unexpected status %d", method, httpResp.StatusCode)
+	}
+	// 失败时服务端只返回状态 (trailers-only)
+	if err := grpcError(httpResp.Header); err != nil {
+		return err
+	}
+	data, err := readFrame(httpResp.Body, math.MaxInt32)
+	if err != nil {
+		return fmt.Errorf("detectapi: %s: read response: %w", method, err)
+	}
+	// trailer 在读到响应体结尾后才可用
+	if _, err := io.Copy(io.Discard, httpResp.Body); err != nil {
+		return err
+	}
+	if err := grpcError(httpResp.Trailer); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, resp)
+}
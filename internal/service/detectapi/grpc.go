@@ -0,0 +1,140 @@
+package detectapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ==========================================
+// gRPC 传输
+// ==========================================
+//
+// 服务在同一 socket 上以明文 HTTP/2 (h2c) 提供 gRPC：消息使用 JSON 编解码 (content-subtype "json")，
+// 标准 gRPC 客户端注册 JSON codec 并以 CallContentSubtype("json") 调用即可，无需生成 protobuf 代码。
+// 未带 gRPC 内容类型的请求按 JSON/HTTP 处理，便于 curl 调试
+
+// gRPC 内容类型
+const (
+	grpcContentType     = "application/grpc"
+	grpcJSONContentType = "application/grpc+json"
+)
+
+// isGRPC 请求是否为 gRPC 调用
+func isGRPC(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), grpcContentType)
+}
+
+// writeFrame 写入一条 gRPC 消息 (1 字节压缩标志 + 4 字节长度 + 内容)
+func writeFrame(w io.Writer, msg []byte) error {
+	var hdr [5]byte
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// readFrame 读取一条 gRPC 消息，超过 limit 字节时返回 ResourceExhausted
+func readFrame(r io.Reader, limit int64) ([]byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if hdr[0] != 0 {
+		return nil, &Error{Code: CodeUnimplemented, Message: "compressed messages are not supported"}
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if int64(n) > limit {
+		return nil, &Error{Code: CodeResourceExhausted, Message: fmt.Sprintf("message exceeds %d bytes", limit)}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// grpcRequestBody 读取 gRPC 请求中的唯一一条消息，返回供方法解析的 JSON
+func grpcRequestBody(r io.Reader, limit int64) (io.Reader, error) {
+	msg, err := readFrame(r, limit)
+	if errors.Is(err, io.EOF) {
+		// 没有消息视为空请求
+		return bytes.NewReader(nil), nil
+	}
+	if err != nil {
+		var apiErr *Error
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &apiErr):
+			return nil, apiErr
+		case errors.As(err, &tooLarge):
+			return nil, &Error{Code: CodeResourceExhausted, Message: "request body too large"}
+		}
+		return nil, &Error{Code: CodeInvalidArgument, Message: "invalid grpc frame: " + err.Error()}
+	}
+	return bytes.NewReader(msg), nil
+}
+
+// writeGRPC 输出 gRPC 响应：成功时写入一条消息并以 grpc-status 0 结束，失败时仅返回状态 (trailers-only)
+func writeGRPC(w http.ResponseWriter, resp interface{}, err error) {
+	w.Header().Set("Content-Type", grpcJSONContentType)
+	if err != nil {
+		var apiErr *Error
+		if !errors.As(err, &apiErr) {
+			apiErr = &Error{Code: CodeInternal, Message: err.Error()}
+		}
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(apiErr.Code)))
+		w.Header().Set("Grpc-Message", encodeGRPCMessage(apiErr.Message))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(CodeInternal)))
+		w.Header().Set("Grpc-Message", encodeGRPCMessage(err.Error()))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	_ = writeFrame(w, msg)
+	w.Header().Set("Grpc-Status", "0")
+}
+
+// grpcError 读取响应头或 trailer 中的 gRPC 状态，0 或不存在时返回 nil
+func grpcError(h http.Header) error {
+	s := h.Get("Grpc-Status")
+	if s == "" || s == "0" {
+		return nil
+	}
+	code, err := strconv.Atoi(s)
+	if err != nil {
+		code = int(CodeInternal)
+	}
+	msg, _ := url.PathUnescape(h.Get("Grpc-Message"))
+	return &Error{Code: Code(code), Message: msg}
+}
+
+// encodeGRPCMessage 按 gRPC 规范对状态消息做百分号编码 (可打印 ASCII 以外的字节与 '%')
+func encodeGRPCMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
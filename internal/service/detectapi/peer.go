@@ -0,0 +1,38 @@
+package detectapi
+
+import (
+	"context"
+	"net"
+	"os"
+)
+
+// Peer 调用方进程的凭据，取自 Unix socket 的 SO_PEERCRED，内核填写，调用方无法伪造
+type Peer struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// peerKey 请求上下文中调用方凭据的键
+type peerKey struct{}
+
+// withPeer 记录连接对端的凭据，用于 http.Server.ConnContext
+// 非 Unix socket 连接或读取失败时不记录，管理方法一律拒绝
+func withPeer(ctx context.Context, c net.Conn) context.Context {
+	if p, ok := peerCred(c); ok {
+		return context.WithValue(ctx, peerKey{}, p)
+	}
+	return ctx
+}
+
+// PeerFromContext 取出调用方凭据
+func PeerFromContext(ctx context.Context) (Peer, bool) {
+	p, ok := ctx.Value(peerKey{}).(Peer)
+	return p, ok
+}
+
+// isAdmin 调用方是否可以调用管理方法：root 或与本服务相同的用户
+// socket 按属组开放给检测调用方，属组成员只能检测与查询，不能修改规则、白名单或取回证据
+func isAdmin(p Peer) bool {
+	return p.UID == 0 || p.UID == uint32(os.Geteuid())
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package detectapi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// peerCred 读取 Unix socket 对端进程的凭据
+func peerCred(c net.Conn) (Peer, bool) {
+	uc, ok := c.(*net.UnixConn)
+	if !ok {
+		return Peer{}, false
+	}
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return Peer{}, false
+	}
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil || credErr != nil {
+		return Peer{}, false
+	}
+	return Peer{PID: cred.Pid, UID: cred.Uid, GID: cred.Gid}, true
+}
+
+// peerCanRead 调用方能否读取 path: 文件需要读权限，沿途目录需要搜索权限 (属组含 /proc/<pid>/status 中的附加组，并考虑 POSIX ACL)
+// paths 依次为请求路径与解析符号链接后的路径，两者的沿途目录都要检查
+func peerCanRead(p Peer, paths ...string) error {
+	groups := peerGroups(p)
+	for _, path := range paths {
+		if err := peerCheck(p, groups, path, permRead); err != nil {
+			return err
+		}
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			if err := peerCheck(p, groups, dir, permSearch); err != nil {
+				return err
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// peerCheck 检查调用方对单个路径的权限
+func peerCheck(p Peer, groups []uint32, path string, want uint16) error {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return err
+	}
+	fa := fileAccess{UID: st.Uid, GID: st.Gid, Mode: st.Mode}
+	buf := make([]byte, 512)
+	if n, err := syscall.Getxattr(path, "system.posix_acl_access", buf); err == nil {
+		acl, err := parseACL(buf[:n])
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fa.ACL = acl
+	} else if !errors.Is(err, syscall.ENODATA) && !errors.Is(err, syscall.ENOTSUP) {
+		// ACL 过长 (ERANGE) 等无法判断的情况按无权限处理
+		return fmt.Errorf("%s: read acl: %w", path, err)
+	}
+	if !fa.allows(p.UID, groups, want) {
+		return fmt.Errorf("%s: %w", path, os.ErrPermission)
+	}
+	return nil
+}
+
+// peerGroups 调用方的主组与附加组，进程已退出时只有主组
+func peerGroups(p Peer) []uint32 {
+	groups := []uint32{p.GID}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", p.PID))
+	if err != nil {
+		return groups
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "Groups:") {
+			continue
+		}
+		for _, f := range strings.Fields(strings.TrimPrefix(line, "Groups:")) {
+			if g, err := strconv.ParseUint(f, 10, 32); err == nil {
+				groups = append(groups, uint32(g))
+			}
+		}
+		break
+	}
+	return groups
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package detectapi
+
+import (
+	"errors"
+	"net"
+)
+
+// peerCred 非 Linux 平台无法取得对端凭据
+func peerCred(net.Conn) (Peer, bool) {
+	return Peer{}, false
+}
+
+// peerCanRead 非 Linux 平台无法按调用方凭据判断权限
+func peerCanRead(Peer, ...string) error {
+	return errors.New("peer permission check is not supported")
+}
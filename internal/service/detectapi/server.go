@@ -0,0 +1,714 @@
+// Package detectapi 本机检测服务
+// 通过 Unix Domain Socket 对外提供涉密检测能力 (DetectFile / DetectBytes / GetRules / SetRules / Status)，
+// 供邮件网关、打印服务等同机组件直接提交内容检测，无需调用 debug_tools；
+// 同时提供网络白名单的在线维护 (ListWhitelist / AddWhitelist / RemoveWhitelist)、
+// 规则误报反馈 (ListRuleStats / MarkAlert / ResetRuleStat)、取证留存查询 (ListEvidence / GetEvidence)
+// 与运行时日志级别调整 (GetLogLevels / SetLogLevel)
+//
+// 协议: gRPC (明文 HTTP/2，JSON 编解码)，每个方法对应路径 /detection.v1.Detection/<Method>；
+// 同一路径也接受 JSON/HTTP 的 POST 请求。
+// 权限: socket 按属组开放，属组成员可以检测与查询；修改规则、白名单、误报标记、日志级别以及查看取证留存
+// 只允许 root 或与本服务相同的用户调用 (按 SO_PEERCRED 校验)。
+// DetectFile 的结果含命中文本，其他调用方只能检测自身有权读取的文件 (按对端凭据检查文件与沿途目录的权限及 ACL)
+package detectapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// ServiceName 服务名，方法路径前缀
+const ServiceName = "detection.v1.Detection"
+
+// 方法名
+const (
+	MethodDetectFile  = "DetectFile"
+	MethodDetectBytes = "DetectBytes"
+	MethodGetRules    = "GetRules"
+	MethodSetRules    = "SetRules"
+	MethodStatus      = "Status"
+
+	MethodListWhitelist   = "ListWhitelist"
+	MethodAddWhitelist    = "AddWhitelist"
+	MethodRemoveWhitelist = "RemoveWhitelist"
+
+	MethodListRuleStats = "ListRuleStats"
+	MethodMarkAlert     = "MarkAlert"
+	MethodResetRuleStat = "ResetRuleStat"
+
+	MethodListEvidence = "ListEvidence"
+	MethodGetEvidence  = "GetEvidence"
+
+	MethodGetLogLevels = "GetLogLevels"
+	MethodSetLogLevel  = "SetLogLevel"
+)
+
+// Detector 检测接口 (由 detector.Manager 实现)
+type Detector interface {
+	Detect(ctx context.Context, filePath string) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// BytesDetector 支持直接检测内存内容的检测器 (可选实现，由 detector.Manager 实现)
+type BytesDetector interface {
+	DetectBytes(ctx context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// RuleStore 检测规则读写接口
+type RuleStore interface {
+	GetRules() Rules
+	SetRules(rules Rules) error
+}
+
+// Whitelist 网络白名单读写接口，错误为 *Error 时按其错误码返回
+type Whitelist interface {
+	ListWhitelist() []WhitelistRule
+	AddWhitelist(value, desc string) (WhitelistRule, error)
+	RemoveWhitelist(value string) error
+}
+
+// RuleStats 规则命中统计与误报反馈接口，错误为 *Error 时按其错误码返回
+type RuleStats interface {
+	ListRuleStats() []RuleStat
+	MarkAlert(alertID string, falsePositive bool) (RuleStat, error)
+	ResetRuleStat(module string, ruleID int64) error
+}
+
+// Evidence 告警取证留存查询接口，错误为 *Error 时按其错误码返回
+type Evidence interface {
+	ListEvidence() []EvidenceItem
+	GetEvidence(alertID string) (EvidenceItem, []byte, error)
+}
+
+// LogLevels 日志级别查询与运行时调整接口，错误为 *Error 时按其错误码返回
+type LogLevels interface {
+	GetLogLevels() LogLevelsResponse
+	SetLogLevel(module, level string) error
+}
+
+// Config 服务配置
+type Config struct {
+	// Socket 文件路径
+	SocketPath string
+	// 已创建的监听 socket (systemd socket 激活)，非 nil 时不再创建 SocketPath，
+	// socket 文件的权限与清理由创建方负责
+	Listener net.Listener
+	// Socket 文件权限，0 时使用 0660 (仅属主与属组可访问)
+	SocketMode os.FileMode
+	// DetectBytes 单次提交内容上限，<=0 时使用 32MB
+	MaxBytes int64
+	// 单次检测超时，<=0 时使用 2 分钟
+	Timeout time.Duration
+	// 网络白名单，nil 时白名单方法返回不支持
+	Whitelist Whitelist
+	// 规则命中统计，nil 时误报反馈方法返回不支持
+	RuleStats RuleStats
+	// 取证库，nil 时取证留存方法返回不支持
+	Evidence Evidence
+	// 日志级别，nil 时日志级别方法返回不支持
+	LogLevels LogLevels
+	// 敏感操作 (取回证据) 的审计记录，返回错误时拒绝该操作；nil 时取回证据返回不支持
+	Audit AuditFunc
+}
+
+// AuditFunc 写入审计日志，p 为调用方凭据
+type AuditFunc func(p Peer, message string) error
+
+// Server 检测服务
+type Server struct {
+	cfg      Config
+	detector Detector
+	rules    RuleStore
+
+	mu       sync.Mutex
+	listener net.Listener
+	http     *http.Server
+	serveErr error // 监听异常退出的原因
+
+	startedAt time.Time
+	requests  atomic.Int64
+	detected  atomic.Int64
+	failures  atomic.Int64
+}
+
+// NewServer 创建检测服务
+// rules 为 nil 时 GetRules/SetRules 返回不支持
+func NewServer(cfg Config, detector Detector, rules RuleStore) *Server {
+	if cfg.SocketMode == 0 {
+		cfg.SocketMode = 0660
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 32 << 20
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Minute
+	}
+	return &Server{cfg: cfg, detector: detector, rules: rules}
+}
+
+// Start 监听 Socket 并在后台处理请求
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return nil
+	}
+	if s.cfg.Listener != nil {
+		s.serve(s.cfg.Listener)
+		logger.Info("本机检测服务已启动 (socket 激活)", "addr", s.cfg.Listener.Addr())
+		return nil
+	}
+	if s.cfg.SocketPath == "" {
+		return errors.New("detectapi: socket path is empty")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.cfg.SocketPath), 0750); err != nil {
+		return fmt.Errorf("detectapi: create socket dir: %w", err)
+	}
+	// 清理上次异常退出残留的 socket 文件
+	if fi, err := os.Lstat(s.cfg.SocketPath); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(s.cfg.SocketPath)
+	}
+
+	ln, err := net.Listen("unix", s.cfg.SocketPath)
+	if err != nil {
+		return fmt.Errorf("detectapi: listen %s: %w", s.cfg.SocketPath, err)
+	}
+	if err := os.Chmod(s.cfg.SocketPath, s.cfg.SocketMode); err != nil {
+		ln.Close()
+		return fmt.Errorf("detectapi: chmod socket: %w", err)
+	}
+
+	s.serve(ln)
+	logger.Info("本机检测服务已启动", "socket", s.cfg.SocketPath)
+	return nil
+}
+
+// serve 在后台处理监听 socket 上的请求，调用方持有 s.mu
+func (s *Server) serve(ln net.Listener) {
+	s.listener = ln
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	s.http = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ConnContext:       withPeer,
+		Protocols:         protocols,
+	}
+	s.startedAt = time.Now()
+	s.serveErr = nil
+
+	go func(srv *http.Server) {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("检测服务异常退出", "error", err)
+			s.mu.Lock()
+			s.serveErr = err
+			s.mu.Unlock()
+		}
+	}(s.http)
+}
+
+// Healthy 监听异常退出时返回原因，供看门狗健康检查
+func (s *Server) Healthy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.serveErr != nil {
+		return fmt.Errorf("detectapi: serve: %w", s.serveErr)
+	}
+	return nil
+}
+
+// Stop 停止服务，等待处理中的请求完成
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.http == nil {
+		return nil
+	}
+	err := s.http.Shutdown(ctx)
+	if s.cfg.Listener == nil {
+		_ = os.Remove(s.cfg.SocketPath)
+	}
+	s.http, s.listener = nil, nil
+	return err
+}
+
+// Handoff 停止服务但保留监听 socket，返回其文件供原地升级后的新进程继续监听
+// 等待处理中的请求完成；期间的新连接在 socket 队列中等待新进程接收，不会被拒绝
+// ctx 超时时仍返回文件与超时错误
+func (s *Server) Handoff(ctx context.Context) (*os.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.http == nil {
+		return nil, errors.New("detectapi: server not running")
+	}
+	fl, ok := s.listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("detectapi: listener %T cannot be handed off", s.listener)
+	}
+	// 关闭监听时保留 socket 文件，新进程沿用同一路径
+	if ul, ok := s.listener.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	f, err := fl.File()
+	if err != nil {
+		return nil, fmt.Errorf("detectapi: dup listener: %w", err)
+	}
+
+	err = s.http.Shutdown(ctx)
+	s.http, s.listener = nil, nil
+	return f, err
+}
+
+// Handler 返回请求路由，便于在测试或其他监听器上复用
+// 管理方法要求调用方凭据 (见 withPeer)，非 Unix socket 连接只能调用检测与查询方法
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(methodPath(MethodDetectFile), s.handle(s.detectFile))
+	mux.HandleFunc(methodPath(MethodDetectBytes), s.handle(s.detectBytes))
+	mux.HandleFunc(methodPath(MethodGetRules), s.handle(s.getRules))
+	mux.HandleFunc(methodPath(MethodSetRules), s.handle(admin(MethodSetRules, s.setRules)))
+	mux.HandleFunc(methodPath(MethodStatus), s.handle(s.status))
+	mux.HandleFunc(methodPath(MethodListWhitelist), s.handle(s.listWhitelist))
+	mux.HandleFunc(methodPath(MethodAddWhitelist), s.handle(admin(MethodAddWhitelist, s.addWhitelist)))
+	mux.HandleFunc(methodPath(MethodRemoveWhitelist), s.handle(admin(MethodRemoveWhitelist, s.removeWhitelist)))
+	mux.HandleFunc(methodPath(MethodListRuleStats), s.handle(s.listRuleStats))
+	mux.HandleFunc(methodPath(MethodMarkAlert), s.handle(admin(MethodMarkAlert, s.markAlert)))
+	mux.HandleFunc(methodPath(MethodResetRuleStat), s.handle(admin(MethodResetRuleStat, s.resetRuleStat)))
+	mux.HandleFunc(methodPath(MethodListEvidence), s.handle(admin(MethodListEvidence, s.listEvidence)))
+	mux.HandleFunc(methodPath(MethodGetEvidence), s.handle(admin(MethodGetEvidence, s.getEvidence)))
+	mux.HandleFunc(methodPath(MethodGetLogLevels), s.handle(s.getLogLevels))
+	mux.HandleFunc(methodPath(MethodSetLogLevel), s.handle(admin(MethodSetLogLevel, s.setLogLevel)))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if isGRPC(r) {
+			writeGRPC(w, nil, &Error{Code: CodeUnimplemented, Message: "unknown method " + r.URL.Path})
+			return
+		}
+		http.NotFound(w, r)
+	})
+	return mux
+}
+
+// methodPath 方法对应的请求路径
+func methodPath(method string) string {
+	return "/" + ServiceName + "/" + method
+}
+
+// handler 单个方法的处理函数
+type handler func(ctx context.Context, body io.Reader) (interface{}, error)
+
+// handle 统一处理请求方法校验、超时、gRPC 帧、错误编码与统计
+func (s *Server) handle(h handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		grpc := isGRPC(r)
+		if r.Method != http.MethodPost {
+			err := &Error{Code: CodeInvalidArgument, Message: "method must be POST"}
+			if grpc {
+				writeGRPC(w, nil, err)
+			} else {
+				writeError(w, err)
+			}
+			return
+		}
+		s.requests.Add(1)
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.cfg.Timeout)
+		defer cancel()
+
+		// 请求体上限为内容上限加上 base64 与 JSON 开销
+		limit := s.cfg.MaxBytes*4/3 + 64<<10
+		var body io.Reader = http.MaxBytesReader(w, r.Body, limit+5)
+		var err error
+		if grpc {
+			body, err = grpcRequestBody(body, limit)
+		}
+		var resp interface{}
+		if err == nil {
+			resp, err = h(ctx, body)
+		}
+		if err != nil {
+			s.failures.Add(1)
+		}
+
+		switch {
+		case grpc:
+			writeGRPC(w, resp, err)
+		case err != nil:
+			writeError(w, err)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(resp)
+		}
+	}
+}
+
+// admin 管理方法只允许 root 或与本服务相同的用户调用
+func admin(method string, h handler) handler {
+	return func(ctx context.Context, body io.Reader) (interface{}, error) {
+		p, ok := PeerFromContext(ctx)
+		if !ok {
+			return nil, &Error{Code: CodePermissionDenied, Message: method + " requires a unix socket connection"}
+		}
+		if !isAdmin(p) {
+			logger.Warn("拒绝非管理用户调用本机接口管理方法", "method", method, "uid", p.UID, "pid", p.PID)
+			return nil, &Error{Code: CodePermissionDenied, Message: method + " requires root"}
+		}
+		return h(ctx, body)
+	}
+}
+
+// decode 解析请求体，空请求体视为零值
+func decode(body io.Reader, v interface{}) error {
+	if err := json.NewDecoder(body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &Error{Code: CodeResourceExhausted, Message: "request body too large"}
+		}
+		return &Error{Code: CodeInvalidArgument, Message: "invalid request: " + err.Error()}
+	}
+	return nil
+}
+
+// ==========================================
+// 方法实现
+// ==========================================
+
+func (s *Server) detectFile(ctx context.Context, body io.Reader) (interface{}, error) {
+	var req DetectFileRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Path == "" || !filepath.IsAbs(req.Path) {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "path must be absolute"}
+	}
+	// 检测结果含命中文本与上下文，服务以 root 读取文件，须确认调用方本身可以读取该文件
+	p, ok := PeerFromContext(ctx)
+	if !ok {
+		return nil, &Error{Code: CodePermissionDenied, Message: MethodDetectFile + " requires a unix socket connection"}
+	}
+
+	real, err := filepath.EvalSymlinks(req.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &Error{Code: CodeNotFound, Message: err.Error()}
+		}
+		return nil, &Error{Code: CodePermissionDenied, Message: err.Error()}
+	}
+	if !isAdmin(p) {
+		if err := peerCanRead(p, req.Path, real); err != nil {
+			logger.Warn("拒绝检测调用方无权读取的文件", "path", req.Path, "uid", p.UID, "pid", p.PID, "error", err)
+			return nil, &Error{Code: CodePermissionDenied, Message: "permission denied: " + req.Path}
+		}
+	}
+	fi, err := os.Stat(real)
+	if err != nil {
+		return nil, &Error{Code: CodePermissionDenied, Message: err.Error()}
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "path is not a regular file"}
+	}
+
+	// 检测解析后的路径，告警中仍显示请求路径
+	resp, err := s.detectPath(ctx, real)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Alert != nil {
+		resp.Alert.FilePath = req.Path
+		resp.Alert.FileName = filepath.Base(req.Path)
+	}
+	return resp, nil
+}
+
+func (s *Server) detectBytes(ctx context.Context, body io.Reader) (interface{}, error) {
+	var req DetectBytesRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if int64(len(req.Content)) > s.cfg.MaxBytes {
+		return nil, &Error{Code: CodeResourceExhausted, Message: fmt.Sprintf("content exceeds %d bytes", s.cfg.MaxBytes)}
+	}
+
+	name := filepath.Base(req.Name)
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "content"
+	}
+
+	// 检测器支持内存检测时直接提交内容
+	if bd, ok := s.detector.(BytesDetector); ok {
+		return s.detect(ctx, func(ctx context.Context) (bool, *model.AlertRecord, error) {
+			sensitive, record, _, err := bd.DetectBytes(ctx, req.Name, req.Content)
+			return sensitive, record, err
+		})
+	}
+
+	// 否则写入临时文件，保留调用方提供的文件名以便按扩展名识别格式
+	dir, err := os.MkdirTemp("", "detectapi-")
+	if err != nil {
+		return nil, &Error{Code: CodeInternal, Message: err.Error()}
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, req.Content, 0600); err != nil {
+		return nil, &Error{Code: CodeInternal, Message: err.Error()}
+	}
+
+	resp, err := s.detectPath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	// 临时路径对调用方无意义，替换为提交时的名称
+	if resp.Alert != nil {
+		resp.Alert.FilePath = req.Name
+		resp.Alert.FileName = name
+	}
+	return resp, nil
+}
+
+// detectPath 检测本机文件
+func (s *Server) detectPath(ctx context.Context, path string) (*DetectResponse, error) {
+	return s.detect(ctx, func(ctx context.Context) (bool, *model.AlertRecord, error) {
+		sensitive, record, _, err := s.detector.Detect(ctx, path)
+		return sensitive, record, err
+	})
+}
+
+// detect 执行检测并统计结果
+func (s *Server) detect(ctx context.Context, fn func(ctx context.Context) (bool, *model.AlertRecord, error)) (*DetectResponse, error) {
+	start := time.Now()
+	sensitive, record, err := fn(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &Error{Code: CodeDeadlineExceeded, Message: err.Error()}
+		}
+		return nil, &Error{Code: CodeInternal, Message: err.Error()}
+	}
+	if sensitive {
+		s.detected.Add(1)
+	}
+	return &DetectResponse{
+		Sensitive: sensitive,
+		Alert:     record,
+		ElapsedMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+func (s *Server) getRules(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.rules == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "rules are not configurable"}
+	}
+	return s.rules.GetRules(), nil
+}
+
+func (s *Server) setRules(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.rules == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "rules are not configurable"}
+	}
+
+	// 以当前规则为基础，只覆盖请求中出现的字段
+	rules := s.rules.GetRules()
+	if err := decode(body, &rules); err != nil {
+		return nil, err
+	}
+	if rules.LayoutThreshold < 0 || rules.LayoutThreshold > 1 {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "layout_threshold must be within [0, 1]"}
+	}
+	if err := s.rules.SetRules(rules); err != nil {
+		return nil, &Error{Code: CodeInternal, Message: err.Error()}
+	}
+
+	logger.Info("检测规则已通过本机接口更新")
+	return s.rules.GetRules(), nil
+}
+
+func (s *Server) status(_ context.Context, _ io.Reader) (interface{}, error) {
+	st := StatusResponse{
+		Requests: s.requests.Load(),
+		Detected: s.detected.Load(),
+		Failures: s.failures.Load(),
+	}
+	if !s.startedAt.IsZero() {
+		st.StartedAt = s.startedAt.Unix()
+		st.UptimeSec = int64(time.Since(s.startedAt).Seconds())
+	}
+	if s.rules != nil {
+		rules := s.rules.GetRules()
+		st.Rules = &rules
+	}
+	return st, nil
+}
+
+func (s *Server) listWhitelist(_ context.Context, _ io.Reader) (interface{}, error) {
+	if s.cfg.Whitelist == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "whitelist is not configurable"}
+	}
+	return WhitelistResponse{Rules: s.cfg.Whitelist.ListWhitelist()}, nil
+}
+
+func (s *Server) addWhitelist(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.Whitelist == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "whitelist is not configurable"}
+	}
+	var req WhitelistRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Value == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "value is required"}
+	}
+	r, err := s.cfg.Whitelist.AddWhitelist(req.Value, req.Desc)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("网络白名单已通过本机接口添加", "rule", r.Value)
+	return r, nil
+}
+
+func (s *Server) removeWhitelist(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.Whitelist == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "whitelist is not configurable"}
+	}
+	var req WhitelistRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Value == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "value is required"}
+	}
+	if err := s.cfg.Whitelist.RemoveWhitelist(req.Value); err != nil {
+		return nil, err
+	}
+
+	logger.Info("网络白名单已通过本机接口删除", "rule", req.Value)
+	return WhitelistResponse{Rules: s.cfg.Whitelist.ListWhitelist()}, nil
+}
+
+func (s *Server) listRuleStats(_ context.Context, _ io.Reader) (interface{}, error) {
+	if s.cfg.RuleStats == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "rule stats are disabled"}
+	}
+	return RuleStatsResponse{Rules: s.cfg.RuleStats.ListRuleStats()}, nil
+}
+
+func (s *Server) markAlert(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.RuleStats == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "rule stats are disabled"}
+	}
+	var req MarkAlertRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.AlertID == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "alert_id is required"}
+	}
+	stat, err := s.cfg.RuleStats.MarkAlert(req.AlertID, req.FalsePositive)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("告警误报标记已通过本机接口更新", "alert", req.AlertID, "false_positive", req.FalsePositive,
+		"module", stat.Module, "rule_id", stat.RuleID, "precision", stat.Precision)
+	return stat, nil
+}
+
+func (s *Server) resetRuleStat(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.RuleStats == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "rule stats are disabled"}
+	}
+	var req ResetRuleStatRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Module == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "module is required"}
+	}
+	if err := s.cfg.RuleStats.ResetRuleStat(req.Module, req.RuleID); err != nil {
+		return nil, err
+	}
+
+	logger.Info("规则命中统计已通过本机接口重置", "module", req.Module, "rule_id", req.RuleID)
+	return RuleStatsResponse{Rules: s.cfg.RuleStats.ListRuleStats()}, nil
+}
+
+func (s *Server) listEvidence(_ context.Context, _ io.Reader) (interface{}, error) {
+	if s.cfg.Evidence == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "evidence capture is disabled"}
+	}
+	return EvidenceListResponse{Items: s.cfg.Evidence.ListEvidence()}, nil
+}
+
+func (s *Server) getEvidence(ctx context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.Evidence == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "evidence capture is disabled"}
+	}
+	if s.cfg.Audit == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "evidence retrieval requires audit logging"}
+	}
+	var req GetEvidenceRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.AlertID == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "alert_id is required"}
+	}
+	item, content, err := s.cfg.Evidence.GetEvidence(req.AlertID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 取回证据属于敏感操作，审计日志写入成功后才返回内容
+	p, _ := PeerFromContext(ctx)
+	msg := fmt.Sprintf("本机接口取回告警 %s 的证据 (方式 %s，%d 字节，SM3 %s)，调用进程 %d 用户 %d",
+		req.AlertID, item.Mode, item.Size, item.SM3, p.PID, p.UID)
+	if err := s.cfg.Audit(p, msg); err != nil {
+		logger.Error("取回证据的审计日志写入失败，拒绝返回证据", "alert", req.AlertID, "error", err)
+		return nil, &Error{Code: CodeInternal, Message: "audit log unavailable"}
+	}
+	logger.Info("证据已通过本机接口取回", "alert", req.AlertID, "mode", item.Mode, "size", item.Size, "uid", p.UID, "pid", p.PID)
+	return GetEvidenceResponse{Evidence: item, Content: content}, nil
+}
+
+func (s *Server) getLogLevels(_ context.Context, _ io.Reader) (interface{}, error) {
+	if s.cfg.LogLevels == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "log level control is disabled"}
+	}
+	return s.cfg.LogLevels.GetLogLevels(), nil
+}
+
+func (s *Server) setLogLevel(_ context.Context, body io.Reader) (interface{}, error) {
+	if s.cfg.LogLevels == nil {
+		return nil, &Error{Code: CodeUnimplemented, Message: "log level control is disabled"}
+	}
+	var req SetLogLevelRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Module == "" && req.Level == "" {
+		return nil, &Error{Code: CodeInvalidArgument, Message: "level is required"}
+	}
+	if err := s.cfg.LogLevels.SetLogLevel(req.Module, req.Level); err != nil {
+		return nil, err
+	}
+
+	// 先调整再记录，调高级别时此条日志仍按全局级别输出
+	logger.Warn("日志级别已通过本机接口调整", "target", req.Module, "level", req.Level)
+	return s.cfg.LogLevels.GetLogLevels(), nil
+}
@@ -0,0 +1,636 @@
+package detectapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fakeDetector 文件内容含 "绝密" 即判定为涉密
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(_ context.Context, path string) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if strings.Contains(string(data), "绝密") {
+		return true, &model.AlertRecord{FilePath: path, FileName: filepath.Base(path)}, nil, nil
+	}
+	return false, nil, nil, nil
+}
+
+// memRules 内存规则存储
+type memRules struct{ rules Rules }
+
+func (m *memRules) GetRules() Rules            { return m.rules }
+func (m *memRules) SetRules(rules Rules) error { m.rules = rules; return nil }
+
+func startTestServer(t *testing.T, rules RuleStore) *Client {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	srv := NewServer(Config{SocketPath: socket, MaxBytes: 1024}, fakeDetector{}, rules)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+
+	fi, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0660 {
+		t.Errorf("socket 权限 = %v, want 0660", fi.Mode().Perm())
+	}
+	return NewClient(socket)
+}
+
+func TestServer_DetectFile(t *testing.T) {
+	client := startTestServer(t, nil)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("绝密 ★ 10年"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.DetectFile(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Sensitive || resp.Alert == nil || resp.Alert.FilePath != path {
+		t.Errorf("DetectFile() = %+v", resp)
+	}
+
+	var apiErr *Error
+	if _, err := client.DetectFile(ctx, "relative.txt"); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("相对路径应返回 InvalidArgument, got %v", err)
+	}
+	if _, err := client.DetectFile(ctx, filepath.Join(t.TempDir(), "missing")); !errors.As(err, &apiErr) || apiErr.Code != CodeNotFound {
+		t.Errorf("不存在的文件应返回 NotFound, got %v", err)
+	}
+}
+
+func TestServer_DetectBytes(t *testing.T) {
+	client := startTestServer(t, nil)
+	ctx := context.Background()
+
+	resp, err := client.DetectBytes(ctx, "../../附件.txt", []byte("内部资料 绝密"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Sensitive || resp.Alert.FileName != "附件.txt" || resp.Alert.FilePath != "../../附件.txt" {
+		t.Errorf("DetectBytes() = %+v", resp.Alert)
+	}
+
+	resp, err = client.DetectBytes(ctx, "clean.txt", []byte("hello"))
+	if err != nil || resp.Sensitive {
+		t.Errorf("DetectBytes(clean) = %+v, %v", resp, err)
+	}
+
+	var apiErr *Error
+	if _, err := client.DetectBytes(ctx, "big.bin", make([]byte, 2048)); !errors.As(err, &apiErr) || apiErr.Code != CodeResourceExhausted {
+		t.Errorf("超限内容应返回 ResourceExhausted, got %v", err)
+	}
+}
+
+func TestServer_Rules(t *testing.T) {
+	store := &memRules{rules: Rules{EnableLayout: true, LayoutThreshold: 0.8}}
+	client := startTestServer(t, store)
+	ctx := context.Background()
+
+	got, err := client.GetRules(ctx)
+	if err != nil || !got.EnableLayout || got.LayoutThreshold != 0.8 {
+		t.Fatalf("GetRules() = %+v, %v", got, err)
+	}
+
+	got.EnableHash = true
+	got.LayoutThreshold = 0.6
+	if _, err := client.SetRules(ctx, *got); err != nil {
+		t.Fatal(err)
+	}
+	if !store.rules.EnableHash || store.rules.LayoutThreshold != 0.6 {
+		t.Errorf("规则未生效: %+v", store.rules)
+	}
+
+	got.LayoutThreshold = 2
+	var apiErr *Error
+	if _, err := client.SetRules(ctx, *got); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("非法阈值应被拒绝, got %v", err)
+	}
+
+	st, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Requests != 4 || st.Failures != 1 || st.Rules == nil {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestServer_RulesUnimplemented(t *testing.T) {
+	client := startTestServer(t, nil)
+
+	var apiErr *Error
+	if _, err := client.GetRules(context.Background()); !errors.As(err, &apiErr) || apiErr.Code != CodeUnimplemented {
+		t.Errorf("未配置规则存储时应返回 Unimplemented, got %v", err)
+	}
+}
+
+// memWhitelist 内存网络白名单，"10.0.0.0/8" 为只读的配置规则
+type memWhitelist struct{ rules []WhitelistRule }
+
+func (w *memWhitelist) ListWhitelist() []WhitelistRule { return w.rules }
+
+func (w *memWhitelist) AddWhitelist(value, desc string) (WhitelistRule, error) {
+	if net.ParseIP(value) == nil {
+		return WhitelistRule{}, &Error{Code: CodeInvalidArgument, Message: "invalid rule"}
+	}
+	r := WhitelistRule{Value: value, Source: "manual", Desc: desc}
+	w.rules = append(w.rules, r)
+	return r, nil
+}
+
+func (w *memWhitelist) RemoveWhitelist(value string) error {
+	for i, r := range w.rules {
+		if r.Value != value {
+			continue
+		}
+		if r.Source != "manual" {
+			return &Error{Code: CodePermissionDenied, Message: "read only"}
+		}
+		w.rules = append(w.rules[:i], w.rules[i+1:]...)
+		return nil
+	}
+	return &Error{Code: CodeNotFound, Message: "not found"}
+}
+
+func TestServer_Whitelist(t *testing.T) {
+	wl := &memWhitelist{rules: []WhitelistRule{{Value: "10.0.0.0/8", Source: "config"}}}
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	srv := NewServer(Config{SocketPath: socket, Whitelist: wl}, fakeDetector{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	client := NewClient(socket)
+	ctx := context.Background()
+
+	if r, err := client.AddWhitelist(ctx, "192.0.2.7", "备份服务器"); err != nil || r.Source != "manual" {
+		t.Fatalf("AddWhitelist() = %+v, %v", r, err)
+	}
+	rules, err := client.ListWhitelist(ctx)
+	if err != nil || len(rules) != 2 || rules[1].Desc != "备份服务器" {
+		t.Fatalf("ListWhitelist() = %+v, %v", rules, err)
+	}
+
+	var apiErr *Error
+	for value, code := range map[string]Code{
+		"":           CodeInvalidArgument,
+		"10.0.0.0/8": CodePermissionDenied,
+		"192.0.2.9":  CodeNotFound,
+	} {
+		if _, err := client.RemoveWhitelist(ctx, value); !errors.As(err, &apiErr) || apiErr.Code != code {
+			t.Errorf("RemoveWhitelist(%q) = %v, want code %d", value, err, code)
+		}
+	}
+	if rules, err := client.RemoveWhitelist(ctx, "192.0.2.7"); err != nil || len(rules) != 1 {
+		t.Errorf("RemoveWhitelist() = %+v, %v", rules, err)
+	}
+
+	// 未配置白名单时返回不支持
+	if _, err := startTestServer(t, nil).ListWhitelist(ctx); !errors.As(err, &apiErr) || apiErr.Code != CodeUnimplemented {
+		t.Errorf("未配置白名单时应返回 Unimplemented, got %v", err)
+	}
+}
+
+// memRuleStats 内存规则统计，告警 "a1"、"a2" 属于规则 keyword_detect/7
+type memRuleStats struct{ stat RuleStat }
+
+func (m *memRuleStats) ListRuleStats() []RuleStat { return []RuleStat{m.stat} }
+
+func (m *memRuleStats) MarkAlert(alertID string, falsePositive bool) (RuleStat, error) {
+	if alertID != "a1" && alertID != "a2" {
+		return RuleStat{}, &Error{Code: CodeNotFound, Message: "alert not found"}
+	}
+	if falsePositive {
+		m.stat.FalsePositives++
+	}
+	m.stat.Precision = float64(m.stat.Hits-m.stat.FalsePositives) / float64(m.stat.Hits)
+	return m.stat, nil
+}
+
+func (m *memRuleStats) ResetRuleStat(module string, ruleID int64) error {
+	if module != m.stat.Module || ruleID != m.stat.RuleID {
+		return &Error{Code: CodeNotFound, Message: "rule not found"}
+	}
+	m.stat = RuleStat{Module: module, RuleID: ruleID, Status: "normal", Precision: 1}
+	return nil
+}
+
+func TestServer_RuleStats(t *testing.T) {
+	rs := &memRuleStats{stat: RuleStat{Module: "keyword_detect", RuleID: 7, Hits: 4, Precision: 1, Status: "normal"}}
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	srv := NewServer(Config{SocketPath: socket, RuleStats: rs}, fakeDetector{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	client := NewClient(socket)
+	ctx := context.Background()
+
+	stat, err := client.MarkAlert(ctx, "a1", true)
+	if err != nil || stat.FalsePositives != 1 || stat.Precision != 0.75 {
+		t.Fatalf("MarkAlert() = %+v, %v", stat, err)
+	}
+	if rules, err := client.ListRuleStats(ctx); err != nil || len(rules) != 1 || rules[0].FalsePositives != 1 {
+		t.Errorf("ListRuleStats() = %+v, %v", rules, err)
+	}
+
+	var apiErr *Error
+	if _, err := client.MarkAlert(ctx, "", true); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("MarkAlert(\"\") = %v", err)
+	}
+	if _, err := client.MarkAlert(ctx, "missing", true); !errors.As(err, &apiErr) || apiErr.Code != CodeNotFound {
+		t.Errorf("MarkAlert(missing) = %v", err)
+	}
+	if _, err := client.ResetRuleStat(ctx, "md5_detect", 7); !errors.As(err, &apiErr) || apiErr.Code != CodeNotFound {
+		t.Errorf("ResetRuleStat(missing) = %v", err)
+	}
+	if rules, err := client.ResetRuleStat(ctx, "keyword_detect", 7); err != nil || rules[0].Hits != 0 {
+		t.Errorf("ResetRuleStat() = %+v, %v", rules, err)
+	}
+
+	// 未启用规则统计时返回不支持
+	if _, err := startTestServer(t, nil).ListRuleStats(ctx); !errors.As(err, &apiErr) || apiErr.Code != CodeUnimplemented {
+		t.Errorf("未启用规则统计时应返回 Unimplemented, got %v", err)
+	}
+}
+
+// memEvidence 内存取证库，只有告警 "a1" 留存了证据
+type memEvidence struct{}
+
+func (memEvidence) ListEvidence() []EvidenceItem {
+	return []EvidenceItem{{AlertID: "a1", Mode: "file", Size: 6}}
+}
+
+func (memEvidence) GetEvidence(alertID string) (EvidenceItem, []byte, error) {
+	if alertID != "a1" {
+		return EvidenceItem{}, nil, &Error{Code: CodeNotFound, Message: "evidence not found"}
+	}
+	return EvidenceItem{AlertID: "a1", Mode: "file", Size: 6}, []byte("绝密"), nil
+}
+
+func TestServer_Evidence(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	var audits []string
+	var auditErr error
+	audit := func(p Peer, msg string) error {
+		if p.PID != int32(os.Getpid()) {
+			t.Errorf("audit peer = %+v", p)
+		}
+		audits = append(audits, msg)
+		return auditErr
+	}
+	srv := NewServer(Config{SocketPath: socket, Evidence: memEvidence{}, Audit: audit}, fakeDetector{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	client := NewClient(socket)
+	ctx := context.Background()
+
+	if items, err := client.ListEvidence(ctx); err != nil || len(items) != 1 || items[0].AlertID != "a1" {
+		t.Errorf("ListEvidence() = %+v, %v", items, err)
+	}
+	item, content, err := client.GetEvidence(ctx, "a1")
+	if err != nil || item.Mode != "file" || string(content) != "绝密" {
+		t.Errorf("GetEvidence() = %+v, %q, %v", item, content, err)
+	}
+	if len(audits) != 1 || !strings.Contains(audits[0], "a1") {
+		t.Errorf("audits = %q", audits)
+	}
+
+	// 审计日志写入失败时不返回证据
+	var apiErr *Error
+	auditErr = errors.New("disk full")
+	if _, content, err := client.GetEvidence(ctx, "a1"); !errors.As(err, &apiErr) || apiErr.Code != CodeInternal || content != nil {
+		t.Errorf("审计失败时 GetEvidence() = %q, %v", content, err)
+	}
+	auditErr = nil
+	if _, _, err := client.GetEvidence(ctx, ""); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("GetEvidence(\"\") = %v", err)
+	}
+	if _, _, err := client.GetEvidence(ctx, "missing"); !errors.As(err, &apiErr) || apiErr.Code != CodeNotFound {
+		t.Errorf("GetEvidence(missing) = %v", err)
+	}
+	if _, err := startTestServer(t, nil).ListEvidence(ctx); !errors.As(err, &apiErr) || apiErr.Code != CodeUnimplemented {
+		t.Errorf("未启用取证留存时应返回 Unimplemented, got %v", err)
+	}
+}
+
+// memLogLevels 内存日志级别，只接受 debug/info
+type memLogLevels struct {
+	global  string
+	modules map[string]string
+}
+
+func (m *memLogLevels) GetLogLevels() LogLevelsResponse {
+	return LogLevelsResponse{Global: m.global, Modules: m.modules}
+}
+
+func (m *memLogLevels) SetLogLevel(module, level string) error {
+	if level != "" && level != "debug" && level != "info" {
+		return &Error{Code: CodeInvalidArgument, Message: "unknown level"}
+	}
+	switch {
+	case module == "":
+		m.global = level
+	case level == "":
+		delete(m.modules, module)
+	default:
+		m.modules[module] = level
+	}
+	return nil
+}
+
+func TestServer_LogLevels(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	levels := &memLogLevels{global: "info", modules: map[string]string{"netguard": "info"}}
+	srv := NewServer(Config{SocketPath: socket, LogLevels: levels}, fakeDetector{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Stop(context.Background()) })
+	client := NewClient(socket)
+	ctx := context.Background()
+
+	resp, err := client.SetLogLevel(ctx, "detector", "debug")
+	if err != nil || resp.Global != "info" || resp.Modules["detector"] != "debug" {
+		t.Fatalf("SetLogLevel(detector) = %+v, %v", resp, err)
+	}
+	if resp, err := client.SetLogLevel(ctx, "netguard", ""); err != nil || len(resp.Modules) != 1 {
+		t.Errorf("SetLogLevel(netguard, \"\") = %+v, %v", resp, err)
+	}
+	if resp, err := client.GetLogLevels(ctx); err != nil || resp.Modules["detector"] != "debug" {
+		t.Errorf("GetLogLevels() = %+v, %v", resp, err)
+	}
+
+	var apiErr *Error
+	if _, err := client.SetLogLevel(ctx, "detector", "trace"); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("SetLogLevel(trace) = %v", err)
+	}
+	if _, err := client.SetLogLevel(ctx, "", ""); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("SetLogLevel(\"\", \"\") = %v", err)
+	}
+	if _, err := startTestServer(t, nil).GetLogLevels(ctx); !errors.As(err, &apiErr) || apiErr.Code != CodeUnimplemented {
+		t.Errorf("未启用日志级别调整时应返回 Unimplemented, got %v", err)
+	}
+}
+
+// bytesDetector 同时支持内存检测的检测器
+type bytesDetector struct {
+	fakeDetector
+	names []string
+}
+
+func (d *bytesDetector) DetectBytes(_ context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	d.names = append(d.names, name)
+	if strings.Contains(string(data), "绝密") {
+		return true, &model.AlertRecord{FilePath: name}, nil, nil
+	}
+	return false, nil, nil, nil
+}
+
+func TestServer_DetectBytesInMemory(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	det := &bytesDetector{}
+	srv := NewServer(Config{SocketPath: socket}, det, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop(context.Background())
+
+	resp, err := NewClient(socket).DetectBytes(context.Background(), "mail/附件.txt", []byte("绝密"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Sensitive || resp.Alert.FilePath != "mail/附件.txt" {
+		t.Errorf("DetectBytes() = %+v", resp.Alert)
+	}
+	if len(det.names) != 1 || det.names[0] != "mail/附件.txt" {
+		t.Errorf("应直接调用 DetectBytes, got %v", det.names)
+	}
+}
+
+func TestServer_Handoff(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	old := NewServer(Config{SocketPath: socket}, fakeDetector{}, nil)
+	if err := old.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := old.Handoff(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 交接后 socket 文件保留，旧服务停止不再删除
+	if _, err := os.Stat(socket); err != nil {
+		t.Fatalf("交接后 socket 文件不存在: %v", err)
+	}
+	if err := old.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(Config{SocketPath: socket, Listener: ln}, fakeDetector{}, nil)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop(context.Background())
+
+	if _, err := NewClient(socket).Status(context.Background()); err != nil {
+		t.Fatalf("交接后 Status() error = %v", err)
+	}
+}
+
+func TestServer_GRPCAndJSON(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	srv := NewServer(Config{SocketPath: socket}, fakeDetector{}, &memRules{})
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop(context.Background())
+
+	// 客户端以 gRPC (明文 HTTP/2) 调用，响应以 grpc-status 结束
+	client := NewClient(socket)
+	var msg bytes.Buffer
+	writeFrame(&msg, []byte("{}"))
+	req, _ := http.NewRequest(http.MethodPost, "http://detectapi"+methodPath(MethodStatus), &msg)
+	req.Header.Set("Content-Type", grpcJSONContentType)
+	resp, err := client.http.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 || resp.Header.Get("Content-Type") != grpcJSONContentType || resp.Trailer.Get("Grpc-Status") != "0" {
+		t.Errorf("gRPC 响应 = %s %v trailer %v", resp.Proto, resp.Header, resp.Trailer)
+	}
+
+	var apiErr *Error
+	if _, err := client.DetectFile(context.Background(), "relative/路径.txt"); !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidArgument {
+		t.Errorf("gRPC 错误 = %v", err)
+	}
+
+	// 同一路径仍接受 JSON/HTTP 请求
+	plain := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+	}}}
+	resp, err = plain.Post("http://detectapi"+methodPath(MethodStatus), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("JSON 响应 = %d %v", resp.StatusCode, resp.Header)
+	}
+}
+
+func TestServer_AdminRequiresPeer(t *testing.T) {
+	store := &memRules{rules: Rules{LayoutThreshold: 0.8}}
+	srv := NewServer(Config{}, fakeDetector{}, store)
+	// TCP 连接没有对端凭据，只能调用检测与查询方法
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	post := func(method, body string) int {
+		resp, err := http.Post(ts.URL+methodPath(method), "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post(MethodGetRules, "{}"); code != http.StatusOK {
+		t.Errorf("GetRules = %d", code)
+	}
+	for _, m := range []string{MethodSetRules, MethodAddWhitelist, MethodRemoveWhitelist, MethodMarkAlert,
+		MethodResetRuleStat, MethodListEvidence, MethodGetEvidence, MethodSetLogLevel} {
+		if code := post(m, `{"layout_threshold": 0.1}`); code != http.StatusForbidden {
+			t.Errorf("%s = %d, want 403", m, code)
+		}
+	}
+	if store.rules.LayoutThreshold != 0.8 {
+		t.Errorf("规则被修改: %+v", store.rules)
+	}
+	// 无法确认调用方能否读取文件时不检测本机文件
+	path := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(path, []byte("绝密"), 0600)
+	if code := post(MethodDetectFile, fmt.Sprintf(`{"path": %q}`, path)); code != http.StatusForbidden {
+		t.Errorf("DetectFile = %d, want 403", code)
+	}
+
+	// Unix socket 连接按 SO_PEERCRED 识别调用方
+	if runtime.GOOS != "linux" {
+		return
+	}
+	a, b, err := socketPair()
+	if err != nil {
+		t.Skip(err)
+	}
+	defer a.Close()
+	defer b.Close()
+	p, ok := PeerFromContext(withPeer(context.Background(), a))
+	if !ok || p.UID != uint32(os.Geteuid()) || p.PID != int32(os.Getpid()) || !isAdmin(p) {
+		t.Errorf("peer = %+v, %v", p, ok)
+	}
+}
+
+// socketPair 建立一对相连的 Unix socket
+func socketPair() (net.Conn, net.Conn, error) {
+	ln, err := net.Listen("unix", filepath.Join(os.TempDir(), fmt.Sprintf("detectapi-%d.sock", os.Getpid())))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ln.Close()
+	a, err := net.Dial("unix", ln.Addr().String())
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := ln.Accept()
+	if err != nil {
+		a.Close()
+		return nil, nil, err
+	}
+	return a, b, nil
+}
+
+func TestFileAccess(t *testing.T) {
+	// 属主 1000:1000，rw-r-----
+	f := fileAccess{UID: 1000, GID: 1000, Mode: 0640}
+	tests := []struct {
+		name   string
+		f      fileAccess
+		uid    uint32
+		groups []uint32
+		want   bool
+	}{
+		{"属主", f, 1000, []uint32{1000}, true},
+		{"属组", f, 1001, []uint32{1001, 1000}, true},
+		{"其他用户", f, 1001, []uint32{1001}, false},
+		{"指定用户 ACL", fileAccess{UID: 1000, GID: 1000, Mode: 0640, ACL: []aclEntry{
+			{Tag: aclUserObj, Perm: 6}, {Tag: aclUser, Perm: 4, ID: 1002},
+			{Tag: aclGroupObj, Perm: 4}, {Tag: aclMask, Perm: 4}, {Tag: aclOther, Perm: 0},
+		}}, 1002, []uint32{1002}, true},
+		{"掩码屏蔽指定用户", fileAccess{UID: 1000, GID: 1000, Mode: 0600, ACL: []aclEntry{
+			{Tag: aclUserObj, Perm: 6}, {Tag: aclUser, Perm: 4, ID: 1002},
+			{Tag: aclGroupObj, Perm: 0}, {Tag: aclMask, Perm: 0}, {Tag: aclOther, Perm: 0},
+		}}, 1002, []uint32{1002}, false},
+		{"匹配的组都不允许时不看其他用户", fileAccess{UID: 1000, GID: 1000, Mode: 0604}, 1001, []uint32{1000}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.f.allows(tt.uid, tt.groups, permRead); got != tt.want {
+				t.Errorf("allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	acl, err := parseACL([]byte{2, 0, 0, 0, 0x02, 0, 4, 0, 0xea, 0x03, 0, 0})
+	if err != nil || len(acl) != 1 || acl[0] != (aclEntry{Tag: aclUser, Perm: 4, ID: 1002}) {
+		t.Errorf("parseACL() = %+v, %v", acl, err)
+	}
+	if _, err := parseACL([]byte{1, 0, 0, 0}); err == nil {
+		t.Error("parseACL() 应拒绝未知版本")
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	nobody := Peer{PID: -1, UID: 65534, GID: 65534}
+	private := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(private, []byte("绝密"), 0644)
+	if err := peerCanRead(nobody, private); err == nil {
+		t.Error("其他用户不能穿过 0700 的临时目录读取文件")
+	}
+	if err := peerCanRead(nobody, "/etc/passwd"); err != nil {
+		t.Errorf("peerCanRead(/etc/passwd) = %v", err)
+	}
+}
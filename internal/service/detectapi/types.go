@@ -0,0 +1,218 @@
+package detectapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 请求与响应
+// ==========================================
+
+// DetectFileRequest 检测本机文件
+type DetectFileRequest struct {
+	// 文件绝对路径，需对守护进程可读
+	Path string `json:"path"`
+}
+
+// DetectBytesRequest 检测提交的内容
+type DetectBytesRequest struct {
+	// 文件名 (用于按扩展名识别格式，如 "附件.docx")
+	Name string `json:"name"`
+	// 文件内容 (JSON 中为 base64)
+	Content []byte `json:"content"`
+}
+
+// DetectResponse 检测结果
+type DetectResponse struct {
+	Sensitive bool               `json:"sensitive"`
+	Alert     *model.AlertRecord `json:"alert,omitempty"`
+	ElapsedMs int64              `json:"elapsed_ms"`
+}
+
+// Rules 可在线调整的检测规则
+type Rules struct {
+	EnableElectronicLabel bool    `json:"enable_electronic_label"`
+	EnableSecretMarker    bool    `json:"enable_secret_marker"`
+	EnableLayout          bool    `json:"enable_layout"`
+	EnableHash            bool    `json:"enable_hash"`
+	EnableKeywords        bool    `json:"enable_keywords"`
+	EnableWasmRules       bool    `json:"enable_wasm_rules"`
+	EnableFingerprint     bool    `json:"enable_fingerprint"`
+	EnableEDM             bool    `json:"enable_edm"`
+	SecretMarkerOCR       bool    `json:"secret_marker_ocr"`
+	LayoutThreshold       float64 `json:"layout_threshold"`
+	LayoutEnableOCR       bool    `json:"layout_enable_ocr"`
+}
+
+// StatusResponse 服务状态
+type StatusResponse struct {
+	StartedAt int64  `json:"started_at"`
+	UptimeSec int64  `json:"uptime_sec"`
+	Requests  int64  `json:"requests"`
+	Detected  int64  `json:"detected"`
+	Failures  int64  `json:"failures"`
+	Rules     *Rules `json:"rules,omitempty"`
+}
+
+// WhitelistRule 网络白名单规则
+type WhitelistRule struct {
+	// IP 或 CIDR
+	Value string `json:"value"`
+	// 来源: config (本地配置)、policy (策略下发)、manual (运行时添加)
+	Source string `json:"source"`
+	Desc   string `json:"desc,omitempty"`
+	// 添加时间 (Unix 秒)，只对运行时添加的规则有效
+	CreatedAt int64 `json:"created_at,omitempty"`
+}
+
+// WhitelistRequest 添加或删除网络白名单规则
+type WhitelistRequest struct {
+	Value string `json:"value"`
+	// 规则说明，只用于添加
+	Desc string `json:"desc,omitempty"`
+}
+
+// WhitelistResponse 网络白名单规则列表
+type WhitelistResponse struct {
+	Rules []WhitelistRule `json:"rules"`
+}
+
+// RuleStat 检测规则命中统计
+type RuleStat struct {
+	Module         string `json:"module"`
+	RuleID         int64  `json:"rule_id"`
+	Hits           int64  `json:"hits"`
+	FalsePositives int64  `json:"false_positives"`
+	// 准确率 (1 - 误报/命中)
+	Precision float64 `json:"precision"`
+	// 最近一次命中时间 (Unix 秒)
+	LastHit int64 `json:"last_hit"`
+	// 状态: normal / flagged / demoted
+	Status string `json:"status"`
+}
+
+// RuleStatsResponse 规则命中统计列表
+type RuleStatsResponse struct {
+	Rules []RuleStat `json:"rules"`
+}
+
+// MarkAlertRequest 标记或取消标记告警为误报
+type MarkAlertRequest struct {
+	AlertID       string `json:"alert_id"`
+	FalsePositive bool   `json:"false_positive"`
+}
+
+// ResetRuleStatRequest 重置规则统计 (规则修正后恢复降级规则)
+type ResetRuleStatRequest struct {
+	Module string `json:"module"`
+	RuleID int64  `json:"rule_id"`
+}
+
+// EvidenceItem 告警取证留存记录
+type EvidenceItem struct {
+	AlertID  string `json:"alert_id"`
+	FilePath string `json:"file_path"`
+	// 留存方式: file / excerpt
+	Mode     string `json:"mode"`
+	FileSize int64  `json:"file_size"`
+	// 留存内容大小与 SM3 摘要
+	Size      int64  `json:"size"`
+	SM3       string `json:"sm3"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// 留存时间 (Unix 秒)
+	CreatedAt int64 `json:"created_at"`
+}
+
+// EvidenceListResponse 取证留存记录列表
+type EvidenceListResponse struct {
+	Items []EvidenceItem `json:"items"`
+}
+
+// GetEvidenceRequest 按告警 ID 取回证据
+type GetEvidenceRequest struct {
+	AlertID string `json:"alert_id"`
+}
+
+// GetEvidenceResponse 证据记录与解密后的内容 (JSON 中为 base64)
+type GetEvidenceResponse struct {
+	Evidence EvidenceItem `json:"evidence"`
+	Content  []byte       `json:"content"`
+}
+
+// LogLevelsResponse 当前生效的日志级别
+type LogLevelsResponse struct {
+	// 全局级别
+	Global string `json:"global"`
+	// 按模块覆盖的级别 (模块名 -> 级别)
+	Modules map[string]string `json:"modules"`
+}
+
+// SetLogLevelRequest 调整日志级别
+// Module 为空时调整全局级别；Level 为空时取消该模块的覆盖，恢复使用全局级别
+type SetLogLevelRequest struct {
+	Module string `json:"module,omitempty"`
+	Level  string `json:"level,omitempty"`
+}
+
+// ==========================================
+// 错误
+// ==========================================
+
+// Code 错误码 (取值与 gRPC 状态码一致，便于网关转换)
+type Code int
+
+const (
+	CodeOK                Code = 0
+	CodeInvalidArgument   Code = 3
+	CodeDeadlineExceeded  Code = 4
+	CodeNotFound          Code = 5
+	CodePermissionDenied  Code = 7
+	CodeResourceExhausted Code = 8
+	CodeUnimplemented     Code = 12
+	CodeInternal          Code = 13
+)
+
+// Error 接口错误
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// httpStatus 错误码对应的 HTTP 状态码
+func (c Code) httpStatus() int {
+	switch c {
+	case CodeInvalidArgument:
+		return http.StatusBadRequest
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodePermissionDenied:
+		return http.StatusForbidden
+	case CodeResourceExhausted:
+		return http.StatusRequestEntityTooLarge
+	case CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CodeUnimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = &Error{Code: CodeInternal, Message: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Code.httpStatus())
+	_ = json.NewEncoder(w).Encode(apiErr)
+}
@@ -0,0 +1,250 @@
+package detector
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 告警去重与聚合
+// ==========================================
+//
+// 同一文件被反复保存/扫描时会对相同内容重复命中，每次命中都写入存储并上报会淹没
+// 本地库和管理端。聚合器以 (文件路径, 命中策略, 文件哈希) 为键，在聚合窗口内
+// 将重复命中合并为一条告警，窗口结束时输出，扩展字段中附带命中次数及首次/末次命中时间。
+
+// 聚合信息写入 ExtendFields 的字段名
+const (
+	FieldHitCount  = "hit_count"
+	FieldFirstSeen = "first_seen"
+	FieldLastSeen  = "last_seen"
+)
+
+// alertTimeLayout 与告警记录 Time 字段格式一致
+const alertTimeLayout = "2006-01-02 15:04:05"
+
+// AlertSink 告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// AlertDedupConfig 告警聚合配置
+type AlertDedupConfig struct {
+	// 聚合窗口，自首次命中起计算
+	Window time.Duration
+	// 同时聚合的告警键上限，超过时提前输出最早的一条
+	MaxEntries int
+}
+
+// AlertDedupStats 聚合统计
+type AlertDedupStats struct {
+	Received int64 // 收到的告警数
+	Emitted  int64 // 输出的告警数
+	Merged   int64 // 被合并的重复告警数
+	Pending  int   // 当前窗口内等待输出的告警数
+}
+
+// pendingAlert 聚合窗口内的告警
+type pendingAlert struct {
+	key       string
+	record    *model.AlertRecord
+	logItem   *model.AlertLogItem
+	sink      AlertSink
+	hits      int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// AlertAggregator 告警聚合器
+type AlertAggregator struct {
+	cfg AlertDedupConfig
+
+	mu      sync.Mutex
+	pending map[string]*pendingAlert
+	order   []*pendingAlert // 按首次命中时间排列
+	stats   AlertDedupStats
+	now     func() time.Time
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewAlertAggregator 创建告警聚合器
+func NewAlertAggregator(cfg AlertDedupConfig) *AlertAggregator {
+	if cfg.Window <= 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	return &AlertAggregator{
+		cfg:     cfg,
+		pending: make(map[string]*pendingAlert),
+		now:     time.Now,
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Start 启动窗口到期检查 (非阻塞)
+func (a *AlertAggregator) Start() {
+	a.wg.Add(1)
+	go a.loop()
+	logger.Info("告警聚合已启动", "window", a.cfg.Window, "max_entries", a.cfg.MaxEntries)
+}
+
+// Stop 停止检查并输出所有未到期的告警，避免退出时丢失
+func (a *AlertAggregator) Stop() {
+	close(a.stopCh)
+	a.wg.Wait()
+	a.Flush()
+}
+
+// Stats 返回统计信息
+func (a *AlertAggregator) Stats() AlertDedupStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	st := a.stats
+	st.Pending = len(a.order)
+	return st
+}
+
+// Wrap 包装告警回调，经聚合后再交给 sink
+// a 为 nil (未启用聚合) 时原样返回 sink；返回未命名函数类型，可直接传给各监控模块
+func (a *AlertAggregator) Wrap(sink AlertSink) func(*model.AlertRecord, *model.AlertLogItem) {
+	if a == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		a.Submit(record, logItem, sink)
+	}
+}
+
+// Submit 提交一条告警
+// 窗口内首次出现的告警暂存，重复告警只累加计数并更新末次命中时间
+func (a *AlertAggregator) Submit(record *model.AlertRecord, logItem *model.AlertLogItem, sink AlertSink) {
+	if record == nil {
+		sink(record, logItem)
+		return
+	}
+
+	key := alertKey(record, logItem)
+	var evicted *pendingAlert
+
+	a.mu.Lock()
+	now := a.now()
+	a.stats.Received++
+	if p, ok := a.pending[key]; ok {
+		p.hits++
+		p.lastSeen = now
+		a.stats.Merged++
+		a.mu.Unlock()
+		return
+	}
+
+	if len(a.order) >= a.cfg.MaxEntries {
+		evicted = a.order[0]
+		a.order[0] = nil
+		a.order = a.order[1:]
+		delete(a.pending, evicted.key)
+		a.stats.Emitted++
+	}
+	p := &pendingAlert{
+		key:       key,
+		record:    record,
+		logItem:   logItem,
+		sink:      sink,
+		hits:      1,
+		firstSeen: now,
+		lastSeen:  now,
+	}
+	a.pending[key] = p
+	a.order = append(a.order, p)
+	a.mu.Unlock()
+
+	if evicted != nil {
+		evicted.emit()
+	}
+}
+
+// Flush 立即输出所有等待中的告警
+func (a *AlertAggregator) Flush() {
+	a.mu.Lock()
+	due := a.order
+	a.order = nil
+	a.pending = make(map[string]*pendingAlert)
+	a.stats.Emitted += int64(len(due))
+	a.mu.Unlock()
+
+	for _, p := range due {
+		p.emit()
+	}
+}
+
+func (a *AlertAggregator) loop() {
+	defer a.wg.Done()
+
+	// 检查间隔取窗口的 1/10，输出延迟最多超出窗口 10%
+	interval := a.cfg.Window / 10
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+			a.flushExpired()
+		}
+	}
+}
+
+// flushExpired 输出窗口已结束的告警
+func (a *AlertAggregator) flushExpired() {
+	a.mu.Lock()
+	now := a.now()
+	n := 0
+	for n < len(a.order) && now.Sub(a.order[n].firstSeen) >= a.cfg.Window {
+		n++
+	}
+	if n == 0 {
+		a.mu.Unlock()
+		return
+	}
+	due := make([]*pendingAlert, n)
+	copy(due, a.order[:n])
+	for i := 0; i < n; i++ {
+		delete(a.pending, a.order[i].key)
+		a.order[i] = nil
+	}
+	a.order = a.order[n:]
+	a.stats.Emitted += int64(n)
+	a.mu.Unlock()
+
+	for _, p := range due {
+		p.emit()
+	}
+}
+
+// emit 写入聚合信息并交给回调
+func (p *pendingAlert) emit() {
+	p.record.AddExtendFields(map[string]interface{}{
+		FieldHitCount:  p.hits,
+		FieldFirstSeen: p.firstSeen.Format(alertTimeLayout),
+		FieldLastSeen:  p.lastSeen.Format(alertTimeLayout),
+	})
+	p.sink(p.record, p.logItem)
+}
+
+// alertKey 聚合键: 文件路径 + 命中策略 + 文件哈希
+func alertKey(record *model.AlertRecord, logItem *model.AlertLogItem) string {
+	hash := record.FileMD5
+	if hash == "" && logItem != nil {
+		hash = logItem.FileMD5
+	}
+	return record.FilePath + "\x00" + strconv.FormatInt(record.RuleID, 10) + "\x00" + record.RuleDesc + "\x00" + hash
+}
@@ -0,0 +1,138 @@
+package detector
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 告警聚合测试
+// ==========================================
+
+type collectedAlerts struct {
+	records []*model.AlertRecord
+}
+
+func (c *collectedAlerts) sink(r *model.AlertRecord, _ *model.AlertLogItem) {
+	c.records = append(c.records, r)
+}
+
+func newTestAggregator(cfg AlertDedupConfig) (*AlertAggregator, *time.Time) {
+	a := NewAlertAggregator(cfg)
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	a.now = func() time.Time { return now }
+	return a, &now
+}
+
+func extendOf(t *testing.T, r *model.AlertRecord) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(r.ExtendFields), &m); err != nil {
+		t.Fatalf("ExtendFields 不是 JSON: %q", r.ExtendFields)
+	}
+	return m
+}
+
+func TestAlertAggregator_MergeWithinWindow(t *testing.T) {
+	a, now := newTestAggregator(AlertDedupConfig{Window: time.Minute})
+	out := &collectedAlerts{}
+	sink := a.Wrap(out.sink)
+
+	alert := func(path string) *model.AlertRecord {
+		return &model.AlertRecord{FilePath: path, RuleID: 7, FileMD5: "abc"}
+	}
+
+	sink(alert("/data/a.docx"), nil)
+	*now = now.Add(10 * time.Second)
+	sink(alert("/data/a.docx"), nil)
+	*now = now.Add(20 * time.Second)
+	sink(alert("/data/a.docx"), nil)
+	sink(alert("/data/b.docx"), nil)
+
+	a.flushExpired()
+	if len(out.records) != 0 {
+		t.Fatalf("窗口未结束不应输出, got %d", len(out.records))
+	}
+
+	*now = now.Add(30 * time.Second)
+	a.flushExpired()
+	if len(out.records) != 1 {
+		t.Fatalf("窗口结束后输出 %d 条, want 1", len(out.records))
+	}
+	ext := extendOf(t, out.records[0])
+	if ext[FieldHitCount] != float64(3) {
+		t.Errorf("hit_count = %v, want 3", ext[FieldHitCount])
+	}
+	if ext[FieldFirstSeen] != "2024-05-01 10:00:00" || ext[FieldLastSeen] != "2024-05-01 10:00:30" {
+		t.Errorf("first/last seen = %v / %v", ext[FieldFirstSeen], ext[FieldLastSeen])
+	}
+
+	// 窗口结束后再次命中重新开始计数
+	sink(alert("/data/a.docx"), nil)
+	a.Flush()
+	if len(out.records) != 3 {
+		t.Fatalf("Flush 后共输出 %d 条, want 3", len(out.records))
+	}
+	if st := a.Stats(); st.Received != 5 || st.Merged != 2 || st.Emitted != 3 || st.Pending != 0 {
+		t.Errorf("Stats() = %+v", st)
+	}
+}
+
+func TestAlertAggregator_KeyIncludesRuleAndHash(t *testing.T) {
+	a, _ := newTestAggregator(AlertDedupConfig{})
+	out := &collectedAlerts{}
+
+	a.Submit(&model.AlertRecord{FilePath: "/a", RuleID: 1, FileMD5: "x"}, nil, out.sink)
+	a.Submit(&model.AlertRecord{FilePath: "/a", RuleID: 2, FileMD5: "x"}, nil, out.sink)
+	a.Submit(&model.AlertRecord{FilePath: "/a", RuleID: 1, FileMD5: "y"}, nil, out.sink)
+	// 告警记录未填哈希时取日志条目中的 md5
+	a.Submit(&model.AlertRecord{FilePath: "/a", RuleID: 1}, &model.AlertLogItem{FileMD5: "x"}, out.sink)
+
+	if st := a.Stats(); st.Pending != 3 || st.Merged != 1 {
+		t.Errorf("Stats() = %+v", st)
+	}
+}
+
+func TestAlertAggregator_MaxEntriesEvictsOldest(t *testing.T) {
+	a, _ := newTestAggregator(AlertDedupConfig{MaxEntries: 2})
+	out := &collectedAlerts{}
+
+	a.Submit(&model.AlertRecord{FilePath: "/1"}, nil, out.sink)
+	a.Submit(&model.AlertRecord{FilePath: "/2"}, nil, out.sink)
+	a.Submit(&model.AlertRecord{FilePath: "/3"}, nil, out.sink)
+
+	if len(out.records) != 1 || out.records[0].FilePath != "/1" {
+		t.Fatalf("应提前输出最早的告警, got %+v", out.records)
+	}
+	if st := a.Stats(); st.Pending != 2 {
+		t.Errorf("Pending = %d, want 2", st.Pending)
+	}
+}
+
+func TestAlertAggregator_KeepsExtendFields(t *testing.T) {
+	a, _ := newTestAggregator(AlertDedupConfig{})
+	out := &collectedAlerts{}
+
+	a.Submit(&model.AlertRecord{FilePath: "/a", ExtendFields: `{"job_id":3}`}, nil, out.sink)
+	a.Submit(&model.AlertRecord{FilePath: "/b", ExtendFields: "plain"}, nil, out.sink)
+	a.Flush()
+
+	if ext := extendOf(t, out.records[0]); ext["job_id"] != float64(3) || ext[FieldHitCount] != float64(1) {
+		t.Errorf("合并 JSON 对象 = %s", out.records[0].ExtendFields)
+	}
+	if ext := extendOf(t, out.records[1]); ext["extend"] != "plain" {
+		t.Errorf("合并非 JSON 内容 = %s", out.records[1].ExtendFields)
+	}
+}
+
+func TestAlertAggregator_NilPassThrough(t *testing.T) {
+	var a *AlertAggregator
+	out := &collectedAlerts{}
+	a.Wrap(out.sink)(&model.AlertRecord{FilePath: "/x"}, nil)
+	if len(out.records) != 1 || out.records[0].ExtendFields != "" {
+		t.Errorf("未启用聚合时应原样输出, got %+v", out.records)
+	}
+}
@@ -0,0 +1,45 @@
+package detector
+
+import (
+	"testing"
+	"time"
+)
+
+// ==========================================
+// cron 解析测试
+// ==========================================
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) 应返回错误", expr)
+		}
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	base := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local) // 周五
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 3, 15, 10, 45, 0, 0, time.Local)},
+		{"0 2 * * *", time.Date(2024, 3, 16, 2, 0, 0, 0, time.Local)},
+		{"@hourly", time.Date(2024, 3, 15, 11, 0, 0, 0, time.Local)},
+		{"0 0 * * 0", time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.Local)},
+		{"30 9 1 * *", time.Date(2024, 4, 1, 9, 30, 0, 0, time.Local)},
+		{"0 8-18/5 * * 1-5", time.Date(2024, 3, 15, 13, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := cron.Next(base); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
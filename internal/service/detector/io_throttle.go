@@ -0,0 +1,126 @@
+package detector
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ==========================================
+// 磁盘读取限速 (令牌桶)
+// ==========================================
+
+// IOThrottle 基于令牌桶的磁盘读取限速器
+// 仅作用于定时扫描任务，实时事件不受限速影响
+type IOThrottle struct {
+	mu       sync.Mutex
+	rate     float64 // 每秒补充的字节数，<=0 表示不限速
+	burst    float64 // 桶容量 (字节)
+	tokens   float64
+	lastFill time.Time
+}
+
+// NewIOThrottle 创建限速器
+// mbPerSec: 每秒最大读取量 (MB)，<=0 表示不限速
+func NewIOThrottle(mbPerSec float64) *IOThrottle {
+	t := &IOThrottle{lastFill: time.Now()}
+	t.SetRate(mbPerSec)
+	return t
+}
+
+// SetRate 动态调整限速值 (MB/s)
+func (t *IOThrottle) SetRate(mbPerSec float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if mbPerSec <= 0 {
+		t.rate = 0
+		return
+	}
+	t.rate = mbPerSec * 1024 * 1024
+	// 桶容量为 1 秒的配额，允许短时突发
+	t.burst = t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+}
+
+// Enabled 是否启用了限速
+func (t *IOThrottle) Enabled() bool {
+	if t == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rate > 0
+}
+
+// WaitN 等待直到可以读取 n 个字节
+func (t *IOThrottle) WaitN(ctx context.Context, n int) error {
+	if t == nil || n <= 0 {
+		return nil
+	}
+
+	for {
+		t.mu.Lock()
+		if t.rate <= 0 {
+			t.mu.Unlock()
+			return nil
+		}
+
+		now := time.Now()
+		t.tokens += now.Sub(t.lastFill).Seconds() * t.rate
+		if t.tokens > t.burst {
+			t.tokens = t.burst
+		}
+		t.lastFill = now
+
+		// 单次请求超过桶容量时按桶容量扣减，避免永远等不到
+		need := float64(n)
+		if need > t.burst {
+			need = t.burst
+		}
+		if t.tokens >= need {
+			t.tokens -= need
+			t.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((need - t.tokens) / t.rate * float64(time.Second))
+		t.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Reader 返回受限速约束的 Reader
+func (t *IOThrottle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	if t == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, t: t}
+}
+
+// throttledReader 每次读取后按实际字节数扣减令牌
+type throttledReader struct {
+	ctx context.Context
+	r   io.Reader
+	t   *IOThrottle
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	n, err := tr.r.Read(p)
+	if n > 0 {
+		if werr := tr.t.WaitN(tr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,245 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+	"linuxFileWatcher/internal/safewalk"
+)
+
+// ==========================================
+// 任务队列持久化与断点续扫
+// ==========================================
+
+// JobJournal 待扫描任务的持久化接口 (由 storage.KeyedStore[model.ScanJob] 实现)
+type JobJournal interface {
+	Put(key string, job model.ScanJob) error
+	Delete(key string) error
+	LoadAll() ([]model.ScanJob, error)
+}
+
+// CheckpointStore 目录扫描断点的持久化接口 (由 storage.KeyedStore[model.ScanCheckpoint] 实现)
+type CheckpointStore interface {
+	Put(key string, cp model.ScanCheckpoint) error
+	Get(key string) (*model.ScanCheckpoint, error)
+	Delete(key string) error
+}
+
+// PersistentTaskQueue 带持久化日志的任务队列
+// 任务入队前先写日志，扫描完成后调用 Done 删除；守护进程崩溃或升级重启后通过 Restore 恢复
+type PersistentTaskQueue struct {
+	*TaskQueue
+	journal JobJournal
+
+	// 保护日志写入与删除的先后顺序；active 为已出队、尚未 Done 的任务数
+	mu     sync.Mutex
+	active map[string]int
+}
+
+// NewPersistentTaskQueue 创建持久化任务队列
+func NewPersistentTaskQueue(queue *TaskQueue, journal JobJournal) *PersistentTaskQueue {
+	return &PersistentTaskQueue{TaskQueue: queue, journal: journal, active: make(map[string]int)}
+}
+
+// Push 写入日志后入队
+// 队列已满时任务仍保留在日志中，下次重启时恢复
+func (q *PersistentTaskQueue) Push(task ScanTask) error {
+	if task.EnqueuedAt.IsZero() {
+		task.EnqueuedAt = time.Now()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.journal.Put(task.Path, toScanJob(task)); err != nil {
+		return err
+	}
+	return q.TaskQueue.Push(task)
+}
+
+// Pop 取出任务，扫描结束后须调用 Done
+func (q *PersistentTaskQueue) Pop(ctx context.Context) (ScanTask, bool) {
+	task, ok := q.TaskQueue.Pop(ctx)
+	if ok {
+		q.mu.Lock()
+		q.active[task.Path]++
+		q.mu.Unlock()
+	}
+	return task, ok
+}
+
+// Done 任务扫描完成后删除日志
+// 同一路径在扫描期间被再次提交 (仍在排队或正被其他 worker 扫描) 时保留日志
+func (q *PersistentTaskQueue) Done(path string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.active[path]--; q.active[path] <= 0 {
+		delete(q.active, path)
+	} else {
+		return nil
+	}
+	if q.TaskQueue.Queued(path) {
+		return nil
+	}
+	return q.journal.Delete(path)
+}
+
+// Restore 从日志恢复未完成的任务，返回恢复数量
+func (q *PersistentTaskQueue) Restore() (int, error) {
+	jobs, err := q.journal.LoadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for _, job := range jobs {
+		err := q.TaskQueue.Push(fromScanJob(job))
+		if errors.Is(err, ErrQueueFull) {
+			// 剩余任务留在日志中，待下次恢复
+			break
+		}
+		if err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+func toScanJob(task ScanTask) model.ScanJob {
+	return model.ScanJob{
+		Path:       task.Path,
+		Priority:   int(task.Priority),
+		Source:     task.Source,
+		EnqueuedAt: task.EnqueuedAt.Unix(),
+	}
+}
+
+func fromScanJob(job model.ScanJob) ScanTask {
+	return ScanTask{
+		Path:       job.Path,
+		Priority:   TaskPriority(job.Priority),
+		Source:     job.Source,
+		EnqueuedAt: time.Unix(job.EnqueuedAt, 0),
+	}
+}
+
+// checkpointInterval 每扫描多少个文件保存一次断点
+const checkpointInterval = 100
+
+// WalkWithCheckpoint 遍历目录并定期保存断点
+// 遍历遵循全局路径过滤规则 (pathfilter.Default)
+// 若 root 存在未完成的断点，则跳过断点之前已扫描的文件；遍历完成后标记为 Completed
+// fn 返回错误时中止遍历并保留断点
+func WalkWithCheckpoint(ctx context.Context, root string, store CheckpointStore, fn func(path string) error) error {
+	root = filepath.Clean(root)
+	return walkWithCheckpoint(ctx, root, root, store, 0, fn)
+}
+
+// checkpointKey 定时扫描计划的断点键
+// 不同计划可能扫描同一目录 (过滤条件、深度不同)，断点按计划与根目录分别保存，互不覆盖
+func checkpointKey(schedule, root string) string {
+	return schedule + ":" + filepath.Clean(root)
+}
+
+// walkWithCheckpoint 同 WalkWithCheckpoint，断点保存在 key 下；
+// maxDepth > 0 时不进入深度达到 maxDepth 的目录 (与 pathfilter 的 max_depth 含义一致，根目录下的文件深度为 1)
+func walkWithCheckpoint(ctx context.Context, root, key string, store CheckpointStore, maxDepth int, fn func(path string) error) error {
+	root = filepath.Clean(root)
+
+	cp, err := store.Get(key)
+	if err != nil {
+		return err
+	}
+	if cp == nil || cp.Completed {
+		cp = &model.ScanCheckpoint{Root: root}
+	}
+	resumeFrom := cp.LastPath
+
+	save := func() error {
+		cp.UpdatedAt = time.Now().Unix()
+		return store.Put(key, *cp)
+	}
+
+	filter := pathfilter.Default()
+	sinceSave := 0
+	// 不跟随符号链接，硬链接与 bind mount 重复挂载的目录只扫描一次
+	walkErr := safewalk.Walk(root, safewalk.Options{}, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			// 无权限等错误不影响其他目录
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if resumeFrom != "" {
+			if d.IsDir() {
+				// 整个目录都在断点之前，直接跳过
+				if path != root && comparePaths(path, resumeFrom) < 0 && !isAncestor(path, resumeFrom) {
+					return fs.SkipDir
+				}
+			} else if comparePaths(path, resumeFrom) <= 0 {
+				return nil
+			}
+		}
+		depth := pathfilter.Depth(root, path)
+		if d.IsDir() {
+			if path != root && (filter.SkipDir(path, depth) || (maxDepth > 0 && depth >= maxDepth)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || filter.SkipFile(path, depth) || (maxDepth > 0 && depth > maxDepth) {
+			return nil
+		}
+
+		if err := fn(path); err != nil {
+			return err
+		}
+
+		cp.LastPath = path
+		cp.Scanned++
+		sinceSave++
+		if sinceSave >= checkpointInterval {
+			sinceSave = 0
+			return save()
+		}
+		return nil
+	})
+
+	if walkErr != nil {
+		// 中断时尽量保存最新进度
+		_ = save()
+		return walkErr
+	}
+
+	cp.Completed = true
+	return save()
+}
+
+// comparePaths 按路径分量比较，与 filepath.WalkDir 的遍历顺序一致
+// 注意不能直接比较字符串: "a/b.txt" < "a/b/x"，但 WalkDir 会先遍历目录 b
+func comparePaths(a, b string) int {
+	as := strings.Split(filepath.ToSlash(a), "/")
+	bs := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// isAncestor 判断 dir 是否为 path 的上级目录
+func isAncestor(dir, path string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
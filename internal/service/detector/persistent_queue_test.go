@@ -0,0 +1,148 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memJournal 内存版任务日志
+type memJournal map[string]model.ScanJob
+
+func (m memJournal) Put(key string, job model.ScanJob) error { m[key] = job; return nil }
+func (m memJournal) Delete(key string) error                 { delete(m, key); return nil }
+func (m memJournal) LoadAll() ([]model.ScanJob, error) {
+	jobs := make([]model.ScanJob, 0, len(m))
+	for _, j := range m {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// memCheckpoints 内存版断点存储
+type memCheckpoints map[string]model.ScanCheckpoint
+
+func (m memCheckpoints) Put(key string, cp model.ScanCheckpoint) error { m[key] = cp; return nil }
+func (m memCheckpoints) Delete(key string) error                       { delete(m, key); return nil }
+func (m memCheckpoints) Get(key string) (*model.ScanCheckpoint, error) {
+	cp, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+func TestPersistentTaskQueue_Restore(t *testing.T) {
+	journal := memJournal{}
+
+	q := NewPersistentTaskQueue(NewTaskQueue(10), journal)
+	_ = q.Push(ScanTask{Path: "/a", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/b", Priority: PriorityRealtime})
+
+	task, _ := q.Pop(context.Background())
+	_ = q.Done(task.Path)
+
+	// 模拟重启：新队列从日志恢复
+	q2 := NewPersistentTaskQueue(NewTaskQueue(10), journal)
+	n, err := q2.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Restore() = %d, want 1", n)
+	}
+	task, _ = q2.Pop(context.Background())
+	if task.Path != "/a" || task.Priority != PriorityScheduled {
+		t.Errorf("恢复的任务 = %+v", task)
+	}
+}
+
+func TestWalkWithCheckpoint_Resume(t *testing.T) {
+	root := t.TempDir()
+	files := []string{"a.txt", "b/x.txt", "b/y.txt", "b.txt", "c.txt"}
+	for _, f := range files {
+		p := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := memCheckpoints{}
+	errStop := errors.New("stop")
+
+	// 第一次扫描在第 3 个文件处中断
+	var first []string
+	err := WalkWithCheckpoint(context.Background(), root, store, func(path string) error {
+		if len(first) == 2 {
+			return errStop
+		}
+		first = append(first, path)
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("err = %v, want errStop", err)
+	}
+
+	// 第二次扫描从断点继续
+	var second []string
+	if err := WalkWithCheckpoint(context.Background(), root, store, func(path string) error {
+		second = append(second, path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	all := append(first, second...)
+	if len(all) != len(files) {
+		t.Fatalf("共扫描 %d 个文件, want %d: %v", len(all), len(files), all)
+	}
+	seen := map[string]bool{}
+	for _, p := range all {
+		if seen[p] {
+			t.Errorf("文件被重复扫描: %s", p)
+		}
+		seen[p] = true
+	}
+
+	cp := store[filepath.Clean(root)]
+	if !cp.Completed {
+		t.Error("遍历完成后断点应标记为 Completed")
+	}
+}
+
+func TestComparePaths(t *testing.T) {
+	if comparePaths("/r/b/x", "/r/b.txt") >= 0 {
+		t.Error("/r/b/x 应排在 /r/b.txt 之前")
+	}
+	if comparePaths("/r/a", "/r/a") != 0 {
+		t.Error("相同路径应相等")
+	}
+}
+
+func TestPersistentTaskQueue_DoneKeepsResubmitted(t *testing.T) {
+	journal := memJournal{}
+	q := NewPersistentTaskQueue(NewTaskQueue(10), journal)
+
+	_ = q.Push(ScanTask{Path: "/a", Priority: PriorityScheduled})
+	task, _ := q.Pop(context.Background())
+
+	// 扫描期间文件再次变化，重新入队
+	_ = q.Push(ScanTask{Path: "/a", Priority: PriorityRealtime})
+	_ = q.Done(task.Path)
+	if _, ok := journal["/a"]; !ok {
+		t.Fatal("仍在排队的任务日志被删除")
+	}
+
+	task, _ = q.Pop(context.Background())
+	_ = q.Done(task.Path)
+	if _, ok := journal["/a"]; ok {
+		t.Error("扫描完成后日志未删除")
+	}
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package detector
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// ioprio 相关常量 (linux/ioprio.h)
+const (
+	ioprioClassShift = 13
+	ioprioWhoProcess = 1
+)
+
+// ApplyProcessPriority 设置当前进程的 CPU nice 值与 I/O 调度类别
+// nice: -20 ~ 19，0 表示不修改
+// ioClass: 0 不修改, 1 realtime, 2 best-effort, 3 idle
+func ApplyProcessPriority(nice, ioClass int) error {
+	if nice != 0 {
+		if nice < -20 || nice > 19 {
+			return fmt.Errorf("nice 值超出范围: %d", nice)
+		}
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice); err != nil {
+			return fmt.Errorf("设置 nice 失败: %w", err)
+		}
+	}
+
+	if ioClass != 0 {
+		if ioClass < 1 || ioClass > 3 {
+			return fmt.Errorf("ionice 类别超出范围: %d", ioClass)
+		}
+		// best-effort 使用最低级别 7，其余类别级别无意义
+		level := 0
+		if ioClass == 2 {
+			level = 7
+		}
+		prio := uintptr(ioClass<<ioprioClassShift | level)
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, prio)
+		if errno != 0 {
+			return fmt.Errorf("设置 ionice 失败: %w", errno)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package detector
+
+// ApplyProcessPriority 非 Linux 平台不支持，直接忽略
+func ApplyProcessPriority(nice, ioClass int) error {
+	return nil
+}
@@ -0,0 +1,230 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// ==========================================
+// 扫描任务调度
+// ==========================================
+
+// ScanFunc 扫描单个任务，返回后任务视为完成
+type ScanFunc func(ctx context.Context, task ScanTask) error
+
+// ScanQueueConfig 扫描调度配置
+type ScanQueueConfig struct {
+	// 并发扫描数，<=0 时为 1
+	Workers int
+	// 队列容量，<=0 时使用 TaskQueue 的默认值
+	Capacity int
+	// 每秒最多开始扫描的文件数，<=0 不限
+	RateLimit int
+	// 定时扫描磁盘读取限速 (MB/s)，<=0 不限速；实时与手动任务不受限
+	ReadRateMB float64
+	// 待扫描任务日志，nil 时不持久化；任务扫描完成后才从日志删除
+	Journal JobJournal
+}
+
+// throttleChunk 按文件大小扣减读取配额的分块大小
+const throttleChunk = 64 << 10
+
+// ScanQueue 扫描任务调度
+// 任务按优先级出队 (实时事件先于定时扫描)，由固定数量的 worker 执行；
+// 定时扫描任务按文件大小扣减磁盘读取配额，全盘扫描不会占满宿主机的磁盘带宽
+type ScanQueue struct {
+	cfg      ScanQueueConfig
+	queue    *TaskQueue
+	journal  *PersistentTaskQueue // 未配置日志时为 nil
+	throttle *IOThrottle
+	scan     ScanFunc
+	// 按 RateLimit 发放扫描许可，nil 不限
+	tick *time.Ticker
+
+	// ctx 取消时中断进行中的扫描；popCtx 取消时 worker 不再取新任务
+	ctx     context.Context
+	cancel  context.CancelFunc
+	popCtx  context.Context
+	stopPop context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScanQueue 创建扫描调度，scan 执行实际检测
+func NewScanQueue(cfg ScanQueueConfig, scan ScanFunc) *ScanQueue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	popCtx, stopPop := context.WithCancel(ctx)
+	q := &ScanQueue{
+		cfg:      cfg,
+		queue:    NewTaskQueue(cfg.Capacity),
+		throttle: NewIOThrottle(cfg.ReadRateMB),
+		scan:     scan,
+		ctx:      ctx,
+		cancel:   cancel,
+		popCtx:   popCtx,
+		stopPop:  stopPop,
+	}
+	if cfg.Journal != nil {
+		q.journal = NewPersistentTaskQueue(q.queue, cfg.Journal)
+	}
+	return q
+}
+
+// Submit 提交扫描任务，队列已满时返回 ErrQueueFull (已写入日志的任务下次启动时恢复)
+func (q *ScanQueue) Submit(task ScanTask) error {
+	if q.journal != nil {
+		return q.journal.Push(task)
+	}
+	return q.queue.Push(task)
+}
+
+// Restore 从日志恢复上次退出时未完成的任务，返回恢复数量
+func (q *ScanQueue) Restore() (int, error) {
+	if q.journal == nil {
+		return 0, nil
+	}
+	return q.journal.Restore()
+}
+
+// SetReadRate 调整定时扫描的磁盘读取限速 (配置重载)
+func (q *ScanQueue) SetReadRate(mbPerSec float64) {
+	q.throttle.SetRate(mbPerSec)
+}
+
+// Len 排队中的任务数
+func (q *ScanQueue) Len() int {
+	return q.queue.Len()
+}
+
+// Stats 各优先级的排队数与丢弃数
+func (q *ScanQueue) Stats() (queued map[TaskPriority]int, dropped map[TaskPriority]int64) {
+	return q.queue.Stats()
+}
+
+// Start 启动 worker (非阻塞)
+func (q *ScanQueue) Start() {
+	if q.cfg.RateLimit > 0 {
+		q.tick = time.NewTicker(time.Second / time.Duration(q.cfg.RateLimit))
+	}
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker()
+	}
+	logger.Info("扫描调度已启动", "workers", q.cfg.Workers, "read_rate_mb", q.cfg.ReadRateMB)
+}
+
+// Stop 停止调度，进行中的扫描被取消；未扫描完成的任务保留在日志中
+func (q *ScanQueue) Stop() {
+	q.cancel()
+	q.queue.Close()
+	q.wg.Wait()
+	if q.tick != nil {
+		q.tick.Stop()
+	}
+}
+
+// Drain 停止取出新任务并等待进行中的扫描完成，排队中的任务保留在日志中
+// ctx 到期时中断剩余扫描 (同样保留日志) 并返回 ctx 的错误
+func (q *ScanQueue) Drain(ctx context.Context) error {
+	q.stopPop()
+	q.queue.Close()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		q.cancel()
+		<-done
+	}
+	q.cancel()
+	if q.tick != nil {
+		q.tick.Stop()
+	}
+	return err
+}
+
+func (q *ScanQueue) worker() {
+	defer q.wg.Done()
+	for {
+		task, ok := q.pop()
+		if !ok || q.popCtx.Err() != nil {
+			// 排空期间取出的任务不扫描，日志保留
+			return
+		}
+		err := q.run(task)
+		if err != nil && q.popCtx.Err() != nil {
+			// 被停止或排空打断的扫描不算完成，日志保留到下次启动
+			return
+		}
+		if err != nil {
+			logger.Warn("扫描任务失败", "path", task.Path, "priority", task.Priority, "error", err)
+		}
+		q.done(task)
+	}
+}
+
+func (q *ScanQueue) pop() (ScanTask, bool) {
+	if q.journal != nil {
+		return q.journal.Pop(q.popCtx)
+	}
+	return q.queue.Pop(q.popCtx)
+}
+
+// done 扫描结束 (含检测失败，失败的文件不再重试) 后删除任务日志
+func (q *ScanQueue) done(task ScanTask) {
+	if q.journal == nil {
+		return
+	}
+	if err := q.journal.Done(task.Path); err != nil {
+		logger.Warn("删除扫描任务日志失败", "path", task.Path, "error", err)
+	}
+}
+
+// run 等待扫描许可与读取配额后执行扫描
+// 排空时不再等待许可，已开始的扫描继续到完成
+func (q *ScanQueue) run(task ScanTask) error {
+	if q.tick != nil {
+		select {
+		case <-q.popCtx.Done():
+			return q.popCtx.Err()
+		case <-q.tick.C:
+		}
+	}
+	if task.Priority == PriorityScheduled && q.throttle.Enabled() {
+		if err := q.waitRead(task.Path); err != nil {
+			return err
+		}
+	}
+	return q.scan(q.ctx, task)
+}
+
+// waitRead 按文件大小预先扣减读取配额 (检测器自行读取文件，无法在读取时限速)
+func (q *ScanQueue) waitRead(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for remain := info.Size(); remain > 0; remain -= throttleChunk {
+		if err := q.throttle.WaitN(q.popCtx, int(min(remain, throttleChunk))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package detector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ==========================================
+// 扫描调度测试
+// ==========================================
+
+func TestScanQueue_ScansInPriorityOrder(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	done := make(chan struct{}, 3)
+	q := NewScanQueue(ScanQueueConfig{Workers: 1}, func(ctx context.Context, task ScanTask) error {
+		mu.Lock()
+		got = append(got, task.Path)
+		mu.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+
+	// 启动前入队，单 worker 按优先级依次扫描
+	q.Submit(ScanTask{Path: "/sched", Priority: PriorityScheduled})
+	q.Submit(ScanTask{Path: "/manual", Priority: PriorityManual})
+	q.Submit(ScanTask{Path: "/rt", Priority: PriorityRealtime})
+	q.Start()
+	defer q.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("扫描超时")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"/rt", "/manual", "/sched"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("扫描顺序 = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScanQueue_ThrottlesScheduledReads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.bin")
+	if err := os.WriteFile(path, make([]byte, 3<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scanned := make(chan time.Time, 2)
+	// 1 MB/s，首秒配额用尽后 3 MB 的定时扫描文件约需 2 秒
+	q := NewScanQueue(ScanQueueConfig{Workers: 1, ReadRateMB: 1}, func(ctx context.Context, task ScanTask) error {
+		scanned <- time.Now()
+		return nil
+	})
+	q.Start()
+	defer q.Stop()
+
+	start := time.Now()
+	q.Submit(ScanTask{Path: path, Priority: PriorityRealtime})
+	if at := <-scanned; at.Sub(start) > 500*time.Millisecond {
+		t.Errorf("实时任务不应限速，耗时 %v", at.Sub(start))
+	}
+
+	start = time.Now()
+	q.Submit(ScanTask{Path: path, Priority: PriorityScheduled})
+	select {
+	case at := <-scanned:
+		if at.Sub(start) < time.Second {
+			t.Errorf("定时任务未限速，耗时 %v", at.Sub(start))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("扫描超时")
+	}
+}
+
+func TestScanQueue_JournalUntilScanned(t *testing.T) {
+	journal := memJournal{}
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	q := NewScanQueue(ScanQueueConfig{Workers: 1, Journal: journal}, func(ctx context.Context, task ScanTask) error {
+		started <- struct{}{}
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	q.Start()
+
+	q.Submit(ScanTask{Path: "/a", Priority: PriorityManual})
+	<-started
+	if _, ok := journal["/a"]; !ok {
+		t.Fatal("扫描中的任务应保留日志")
+	}
+
+	// 扫描被停止打断，日志保留供下次启动恢复
+	q.Stop()
+	if _, ok := journal["/a"]; !ok {
+		t.Fatal("中断的任务日志被删除")
+	}
+
+	q2 := NewScanQueue(ScanQueueConfig{Workers: 1, Journal: journal}, func(ctx context.Context, task ScanTask) error {
+		close(release)
+		return nil
+	})
+	if n, err := q2.Restore(); n != 1 || err != nil {
+		t.Fatalf("Restore() = %d, %v", n, err)
+	}
+	q2.Start()
+	<-release
+	q2.Stop()
+	if _, ok := journal["/a"]; ok {
+		t.Error("扫描完成后日志未删除")
+	}
+}
+
+func TestScanQueue_Drain(t *testing.T) {
+	journal := memJournal{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	q := NewScanQueue(ScanQueueConfig{Workers: 1, Journal: journal}, func(ctx context.Context, task ScanTask) error {
+		close(started)
+		<-release
+		return nil
+	})
+	q.Submit(ScanTask{Path: "/a", Priority: PriorityRealtime})
+	q.Submit(ScanTask{Path: "/b", Priority: PriorityScheduled})
+	q.Start()
+	<-started
+
+	drained := make(chan error)
+	go func() { drained <- q.Drain(context.Background()) }()
+	select {
+	case <-drained:
+		t.Fatal("Drain() 未等待进行中的扫描")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	// 进行中的扫描完成后删除日志，排队中的任务留待恢复
+	if _, ok := journal["/a"]; ok {
+		t.Error("已完成的任务日志未删除")
+	}
+	if _, ok := journal["/b"]; !ok {
+		t.Error("排队中的任务日志被删除")
+	}
+}
@@ -0,0 +1,337 @@
+package detector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// ==========================================
+// 定时全盘扫描调度
+// ==========================================
+
+// maxRunHistory 保留的运行摘要条数
+const maxRunHistory = 100
+
+// ScheduleSpec 定时扫描计划
+type ScheduleSpec struct {
+	Name        string
+	Paths       []string
+	Schedule    string        // cron 表达式
+	Include     []string      // 文件 glob，为空表示全部
+	Exclude     []string      // 文件 glob
+	MaxDuration time.Duration // 0 表示不限
+
+	// 定向扫描条件，零值表示不限
+	MaxDepth  int           // 相对扫描路径的最大深度
+	MinSize   int64         // 文件大小下限 (字节)
+	MaxSize   int64         // 文件大小上限 (字节)
+	NewerThan time.Duration // 只扫描最近这段时间内修改过的文件 (如 48h)
+	OlderThan time.Duration // 只扫描这段时间之前修改的文件
+}
+
+// attrs 以 now 为基准的文件属性条件
+func (spec ScheduleSpec) attrs(now time.Time) pathfilter.Attrs {
+	a := pathfilter.Attrs{MinSize: spec.MinSize, MaxSize: spec.MaxSize}
+	if spec.NewerThan > 0 {
+		a.NewerThan = now.Add(-spec.NewerThan)
+	}
+	if spec.OlderThan > 0 {
+		a.OlderThan = now.Add(-spec.OlderThan)
+	}
+	return a
+}
+
+// TaskSubmitter 向扫描服务提交任务
+type TaskSubmitter func(task ScanTask) error
+
+// RunRecorder 运行摘要持久化接口 (由 storage.KeyedStore[model.ScanRunSummary] 实现)
+type RunRecorder interface {
+	Put(key string, summary model.ScanRunSummary) error
+	Delete(key string) error
+	LoadAll() ([]model.ScanRunSummary, error)
+}
+
+// schedulePlan 解析后的计划
+type schedulePlan struct {
+	spec ScheduleSpec
+	cron *CronSchedule
+}
+
+// ScanScheduler 定时扫描调度器
+// 按 cron 计划遍历目录，将文件以 PriorityScheduled 提交给扫描服务，并记录每次运行摘要
+type ScanScheduler struct {
+	plans       map[string]*schedulePlan
+	submit      TaskSubmitter
+	checkpoints CheckpointStore
+	runs        RunRecorder
+
+	mu      sync.Mutex
+	running map[string]bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScanScheduler 创建调度器
+func NewScanScheduler(specs []ScheduleSpec, submit TaskSubmitter, checkpoints CheckpointStore, runs RunRecorder) (*ScanScheduler, error) {
+	plans := make(map[string]*schedulePlan, len(specs))
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("定时扫描计划缺少名称")
+		}
+		if _, dup := plans[spec.Name]; dup {
+			return nil, fmt.Errorf("定时扫描计划名称重复: %s", spec.Name)
+		}
+		if len(spec.Paths) == 0 {
+			return nil, fmt.Errorf("定时扫描计划 %s 未配置扫描路径", spec.Name)
+		}
+		cron, err := ParseCron(spec.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("定时扫描计划 %s: %w", spec.Name, err)
+		}
+		if spec.MaxDepth < 0 || spec.NewerThan < 0 || spec.OlderThan < 0 {
+			return nil, fmt.Errorf("定时扫描计划 %s: 深度与时间条件不能为负数", spec.Name)
+		}
+		if err := spec.attrs(time.Now()).Validate(); err != nil {
+			return nil, fmt.Errorf("定时扫描计划 %s: %w", spec.Name, err)
+		}
+		plans[spec.Name] = &schedulePlan{spec: spec, cron: cron}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ScanScheduler{
+		plans:       plans,
+		submit:      submit,
+		checkpoints: checkpoints,
+		runs:        runs,
+		running:     make(map[string]bool),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+// Start 启动所有计划 (非阻塞)
+func (s *ScanScheduler) Start() {
+	for _, plan := range s.plans {
+		s.wg.Add(1)
+		go s.loop(plan)
+	}
+	logger.Info("定时扫描调度器已启动", "plans", len(s.plans))
+}
+
+// Stop 停止调度并等待正在运行的扫描退出
+func (s *ScanScheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// loop 单个计划的调度循环
+func (s *ScanScheduler) loop(plan *schedulePlan) {
+	defer s.wg.Done()
+
+	for {
+		next := plan.cron.Next(time.Now())
+		if next.IsZero() {
+			logger.Warn("定时扫描计划无下一次触发时间", "schedule", plan.spec.Name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := s.RunNow(plan.spec.Name); err != nil {
+			logger.Warn("定时扫描未执行", "schedule", plan.spec.Name, "error", err)
+		}
+	}
+}
+
+// RunNow 立即执行指定计划 (阻塞直到完成)，供调度循环及管理接口手动触发
+func (s *ScanScheduler) RunNow(name string) (*model.ScanRunSummary, error) {
+	plan, ok := s.plans[name]
+	if !ok {
+		return nil, fmt.Errorf("定时扫描计划不存在: %s", name)
+	}
+
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("定时扫描计划 %s 正在运行", name)
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}()
+
+	summary := s.run(plan)
+	return &summary, nil
+}
+
+// run 执行一次扫描并记录摘要
+func (s *ScanScheduler) run(plan *schedulePlan) model.ScanRunSummary {
+	start := time.Now()
+	summary := model.ScanRunSummary{
+		RunID:     fmt.Sprintf("%s-%d", plan.spec.Name, start.Unix()),
+		Schedule:  plan.spec.Name,
+		Paths:     plan.spec.Paths,
+		StartedAt: start.Unix(),
+		Status:    model.ScanRunRunning,
+	}
+	s.record(summary)
+	logger.Info("定时扫描开始", "schedule", plan.spec.Name, "paths", plan.spec.Paths)
+
+	ctx := s.ctx
+	if plan.spec.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, plan.spec.MaxDuration)
+		defer cancel()
+	}
+
+	attrs := plan.spec.attrs(start)
+	var enqueued, skipped int64
+	var runErr error
+	for _, root := range plan.spec.Paths {
+		runErr = walkWithCheckpoint(ctx, root, checkpointKey(plan.spec.Name, root), s.checkpoints, plan.spec.MaxDepth, func(path string) error {
+			if !matchSchedulePath(plan.spec, path) || !matchScheduleAttrs(attrs, path) {
+				atomic.AddInt64(&skipped, 1)
+				return nil
+			}
+			err := s.submit(ScanTask{Path: path, Priority: PriorityScheduled, Source: "scheduler"})
+			if err != nil && !errors.Is(err, ErrQueueFull) {
+				return err
+			}
+			atomic.AddInt64(&enqueued, 1)
+			return nil
+		})
+		if runErr != nil {
+			break
+		}
+	}
+
+	summary.FinishedAt = time.Now().Unix()
+	summary.FilesEnqueued = enqueued
+	summary.FilesSkipped = skipped
+	switch {
+	case runErr == nil:
+		summary.Status = model.ScanRunCompleted
+	case errors.Is(runErr, context.DeadlineExceeded):
+		summary.Status = model.ScanRunTimeout
+	case errors.Is(runErr, context.Canceled):
+		summary.Status = model.ScanRunCanceled
+	default:
+		summary.Status = model.ScanRunFailed
+		summary.Error = runErr.Error()
+	}
+
+	s.record(summary)
+	logger.Info("定时扫描结束",
+		"schedule", plan.spec.Name,
+		"status", summary.Status,
+		"enqueued", enqueued,
+		"skipped", skipped,
+		"elapsed", time.Since(start).Round(time.Second),
+	)
+	return summary
+}
+
+// record 保存运行摘要并清理过旧记录
+func (s *ScanScheduler) record(summary model.ScanRunSummary) {
+	if s.runs == nil {
+		return
+	}
+	if err := s.runs.Put(summary.RunID, summary); err != nil {
+		logger.Error("保存定时扫描摘要失败", "run_id", summary.RunID, "error", err)
+		return
+	}
+	if summary.Status == model.ScanRunRunning {
+		return
+	}
+
+	all, err := s.runs.LoadAll()
+	if err != nil || len(all) <= maxRunHistory {
+		return
+	}
+	sortSummaries(all)
+	for _, old := range all[maxRunHistory:] {
+		_ = s.runs.Delete(old.RunID)
+	}
+}
+
+// Summaries 返回最近的运行摘要 (按开始时间倒序)，供管理接口查询
+func (s *ScanScheduler) Summaries(limit int) ([]model.ScanRunSummary, error) {
+	if s.runs == nil {
+		return nil, nil
+	}
+	all, err := s.runs.LoadAll()
+	if err != nil {
+		return nil, err
+	}
+	sortSummaries(all)
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// sortSummaries 按开始时间倒序排列
+func sortSummaries(list []model.ScanRunSummary) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt > list[j].StartedAt
+	})
+}
+
+// matchSchedulePath 按 include/exclude glob 过滤文件
+// glob 同时匹配完整路径与文件名
+func matchSchedulePath(spec ScheduleSpec, path string) bool {
+	for _, pattern := range spec.Exclude {
+		if matchGlob(pattern, path) {
+			return false
+		}
+	}
+	if len(spec.Include) == 0 {
+		return true
+	}
+	for _, pattern := range spec.Include {
+		if matchGlob(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchScheduleAttrs 按大小与修改时间过滤文件，文件已不存在时视为不匹配
+func matchScheduleAttrs(attrs pathfilter.Attrs, path string) bool {
+	if attrs.IsZero() {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && attrs.Match(info)
+}
+
+func matchGlob(pattern, path string) bool {
+	if ok, _ := filepath.Match(pattern, path); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(path))
+	return ok
+}
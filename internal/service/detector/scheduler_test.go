@@ -0,0 +1,189 @@
+package detector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memRuns 内存版运行摘要存储
+type memRuns map[string]model.ScanRunSummary
+
+func (m memRuns) Put(key string, s model.ScanRunSummary) error { m[key] = s; return nil }
+func (m memRuns) Delete(key string) error                      { delete(m, key); return nil }
+func (m memRuns) LoadAll() ([]model.ScanRunSummary, error) {
+	list := make([]model.ScanRunSummary, 0, len(m))
+	for _, s := range m {
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func TestScanScheduler_RunNow(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.docx", "b.pdf", "c.tmp", "d.txt"} {
+		if err := os.WriteFile(filepath.Join(root, f), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var submitted []ScanTask
+	submit := func(task ScanTask) error {
+		submitted = append(submitted, task)
+		return nil
+	}
+
+	runs := memRuns{}
+	s, err := NewScanScheduler([]ScheduleSpec{{
+		Name:     "nightly",
+		Paths:    []string{root},
+		Schedule: "@daily",
+		Include:  []string{"*.docx", "*.pdf", "*.tmp"},
+		Exclude:  []string{"*.tmp"},
+	}}, submit, memCheckpoints{}, runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := s.RunNow("nightly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Status != model.ScanRunCompleted {
+		t.Errorf("Status = %s, want completed", summary.Status)
+	}
+	if summary.FilesEnqueued != 2 || summary.FilesSkipped != 2 {
+		t.Errorf("enqueued=%d skipped=%d, want 2/2", summary.FilesEnqueued, summary.FilesSkipped)
+	}
+	for _, task := range submitted {
+		if task.Priority != PriorityScheduled {
+			t.Errorf("定时任务优先级 = %v, want scheduled", task.Priority)
+		}
+	}
+
+	list, _ := s.Summaries(10)
+	if len(list) != 1 || list[0].Status != model.ScanRunCompleted {
+		t.Errorf("Summaries() = %+v", list)
+	}
+
+	if _, err := s.RunNow("missing"); err == nil {
+		t.Error("不存在的计划应返回错误")
+	}
+}
+
+func TestScanScheduler_TargetedFilters(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-72 * time.Hour)
+	files := []struct {
+		path string
+		size int
+		old  bool
+	}{
+		{"recent.docx", 2048, false},
+		{"stale.docx", 2048, true},
+		{"tiny.docx", 10, false},
+		{"sub/nested.docx", 2048, false},
+		{"sub/deep/too-deep.docx", 2048, false},
+	}
+	for _, f := range files {
+		path := filepath.Join(root, f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, f.size), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if f.old {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var submitted []string
+	submit := func(task ScanTask) error {
+		submitted = append(submitted, filepath.Base(task.Path))
+		return nil
+	}
+	s, err := NewScanScheduler([]ScheduleSpec{{
+		Name:      "incident",
+		Paths:     []string{root},
+		Schedule:  "@daily",
+		MaxDepth:  2,
+		MinSize:   1024,
+		NewerThan: 48 * time.Hour,
+	}}, submit, memCheckpoints{}, memRuns{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := s.RunNow("incident")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted) != 2 || submitted[0] != "recent.docx" || submitted[1] != "nested.docx" {
+		t.Errorf("submitted = %v, want [recent.docx nested.docx]", submitted)
+	}
+	// too-deep.docx 所在目录不会被遍历，不计入 skipped
+	if summary.FilesSkipped != 2 {
+		t.Errorf("skipped = %d, want 2", summary.FilesSkipped)
+	}
+}
+
+func TestScanScheduler_CheckpointPerSchedule(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"a.docx", "b.pdf"} {
+		if err := os.WriteFile(filepath.Join(root, f), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts := map[string]int{}
+	submit := func(task ScanTask) error {
+		counts[filepath.Ext(task.Path)]++
+		return nil
+	}
+	checkpoints := memCheckpoints{}
+	s, err := NewScanScheduler([]ScheduleSpec{
+		{Name: "docs", Paths: []string{root}, Schedule: "@daily", Include: []string{"*.docx"}},
+		{Name: "pdfs", Paths: []string{root}, Schedule: "@daily", Include: []string{"*.pdf"}},
+	}, submit, checkpoints, memRuns{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一计划在同一目录的断点不影响本计划的遍历
+	checkpoints[checkpointKey("pdfs", root)] = model.ScanCheckpoint{Root: root, LastPath: filepath.Join(root, "b.pdf")}
+	if _, err := s.RunNow("docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RunNow("pdfs"); err != nil {
+		t.Fatal(err)
+	}
+	if counts[".docx"] != 1 || counts[".pdf"] != 0 {
+		t.Errorf("submitted = %v, want docx 1, pdf 0 (已越过断点)", counts)
+	}
+	if cp := checkpoints[checkpointKey("docs", root)]; !cp.Completed {
+		t.Errorf("docs 断点 = %+v", cp)
+	}
+}
+
+func TestNewScanScheduler_Validate(t *testing.T) {
+	cases := [][]ScheduleSpec{
+		{{Name: "", Paths: []string{"/"}, Schedule: "@daily"}},
+		{{Name: "a", Schedule: "@daily"}},
+		{{Name: "a", Paths: []string{"/"}, Schedule: "bad"}},
+		{{Name: "a", Paths: []string{"/"}, Schedule: "@daily"}, {Name: "a", Paths: []string{"/"}, Schedule: "@daily"}},
+		{{Name: "a", Paths: []string{"/"}, Schedule: "@daily", MaxDepth: -1}},
+		{{Name: "a", Paths: []string{"/"}, Schedule: "@daily", MinSize: 2048, MaxSize: 1024}},
+		{{Name: "a", Paths: []string{"/"}, Schedule: "@daily", NewerThan: time.Hour, OlderThan: 48 * time.Hour}},
+	}
+	for i, specs := range cases {
+		if _, err := NewScanScheduler(specs, nil, nil, nil); err == nil {
+			t.Errorf("case %d: 应返回错误", i)
+		}
+	}
+}
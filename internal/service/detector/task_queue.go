@@ -0,0 +1,251 @@
+package detector
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 扫描任务优先级队列
+// ==========================================
+
+// TaskPriority 扫描任务优先级 (数值越小优先级越高)
+type TaskPriority int
+
+const (
+	// PriorityRealtime 文件监控实时事件，用户刚刚保存/拷贝的文件
+	PriorityRealtime TaskPriority = 0
+	// PriorityManual 管理端下发或手动触发的扫描
+	PriorityManual TaskPriority = 1
+	// PriorityScheduled 定时全盘扫描，受 I/O 限速约束
+	PriorityScheduled TaskPriority = 2
+)
+
+// String 返回优先级名称
+func (p TaskPriority) String() string {
+	switch p {
+	case PriorityRealtime:
+		return "realtime"
+	case PriorityManual:
+		return "manual"
+	case PriorityScheduled:
+		return "scheduled"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	// ErrQueueFull 队列已满且无法淘汰更低优先级任务
+	ErrQueueFull = errors.New("scan task queue is full")
+	// ErrQueueClosed 队列已关闭
+	ErrQueueClosed = errors.New("scan task queue is closed")
+)
+
+// ScanTask 单个扫描任务
+type ScanTask struct {
+	Path       string       `json:"path"`
+	Priority   TaskPriority `json:"priority"`
+	Source     string       `json:"source,omitempty"` // 任务来源 (watcher, scheduler, command)
+	EnqueuedAt time.Time    `json:"enqueued_at"`
+	// 写入文件的进程 (实时事件在入队时读取)，不持久化
+	Process *model.ProcessInfo `json:"-"`
+
+	seq uint64 // 同优先级内保持 FIFO
+}
+
+// taskHeap 按 (Priority, seq) 排序的最小堆
+type taskHeap []*ScanTask
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].Priority != h[j].Priority {
+		return h[i].Priority < h[j].Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*ScanTask)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// TaskQueue 带优先级的有界扫描任务队列
+// 实时事件始终先于定时扫描出队；队列满时实时任务可以挤掉排在最后的低优先级任务
+type TaskQueue struct {
+	mu       sync.Mutex
+	notEmpty chan struct{} // 有新任务时发送信号 (容量 1)
+	items    taskHeap
+	capacity int
+	seq      uint64
+	closed   bool
+
+	// 同一路径在队列中只保留一份，避免监控事件风暴重复扫描
+	pending map[string]*ScanTask
+
+	dropped map[TaskPriority]int64
+}
+
+// NewTaskQueue 创建任务队列
+// capacity: 队列容量，<=0 时使用默认值 10000
+func NewTaskQueue(capacity int) *TaskQueue {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &TaskQueue{
+		notEmpty: make(chan struct{}, 1),
+		items:    make(taskHeap, 0, 64),
+		capacity: capacity,
+		pending:  make(map[string]*ScanTask),
+		dropped:  make(map[TaskPriority]int64),
+	}
+}
+
+// Push 提交任务
+// 同一路径已在队列中时只提升其优先级，不重复入队
+func (q *TaskQueue) Push(task ScanTask) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	if existing, ok := q.pending[task.Path]; ok {
+		if task.Priority < existing.Priority {
+			existing.Priority = task.Priority
+			heap.Init(&q.items)
+		}
+		return nil
+	}
+
+	if len(q.items) >= q.capacity {
+		if !q.evictLowerLocked(task.Priority) {
+			q.dropped[task.Priority]++
+			return ErrQueueFull
+		}
+	}
+
+	if task.EnqueuedAt.IsZero() {
+		task.EnqueuedAt = time.Now()
+	}
+	q.seq++
+	task.seq = q.seq
+
+	t := task
+	heap.Push(&q.items, &t)
+	q.pending[t.Path] = &t
+	q.signal()
+	return nil
+}
+
+// evictLowerLocked 淘汰一个比 priority 更低优先级的最新任务，调用方需持有锁
+func (q *TaskQueue) evictLowerLocked(priority TaskPriority) bool {
+	victim := -1
+	for i, it := range q.items {
+		if it.Priority <= priority {
+			continue
+		}
+		if victim < 0 || q.items.Less(victim, i) {
+			victim = i
+		}
+	}
+	if victim < 0 {
+		return false
+	}
+
+	removed := heap.Remove(&q.items, victim).(*ScanTask)
+	delete(q.pending, removed.Path)
+	q.dropped[removed.Priority]++
+	return true
+}
+
+// Pop 阻塞获取最高优先级的任务
+// ctx 取消或队列关闭且为空时返回 false
+func (q *TaskQueue) Pop(ctx context.Context) (ScanTask, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			t := heap.Pop(&q.items).(*ScanTask)
+			delete(q.pending, t.Path)
+			if len(q.items) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return *t, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+
+		if closed {
+			return ScanTask{}, false
+		}
+
+		select {
+		case <-ctx.Done():
+			return ScanTask{}, false
+		case <-q.notEmpty:
+		}
+	}
+}
+
+// signal 非阻塞地唤醒一个等待者，调用方需持有锁
+func (q *TaskQueue) signal() {
+	select {
+	case q.notEmpty <- struct{}{}:
+	default:
+	}
+}
+
+// Close 关闭队列，已入队的任务仍可被取出
+func (q *TaskQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.notEmpty)
+}
+
+// Queued path 是否在队列中等待扫描
+func (q *TaskQueue) Queued(path string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	_, ok := q.pending[path]
+	return ok
+}
+
+// Len 当前队列长度
+func (q *TaskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Stats 返回各优先级的排队数与丢弃数
+func (q *TaskQueue) Stats() (queued map[TaskPriority]int, dropped map[TaskPriority]int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queued = make(map[TaskPriority]int)
+	for _, it := range q.items {
+		queued[it.Priority]++
+	}
+	dropped = make(map[TaskPriority]int64, len(q.dropped))
+	for k, v := range q.dropped {
+		dropped[k] = v
+	}
+	return queued, dropped
+}
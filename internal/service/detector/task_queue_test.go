@@ -0,0 +1,139 @@
+package detector
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// ==========================================
+// 任务队列测试
+// ==========================================
+
+func TestTaskQueue_PriorityOrder(t *testing.T) {
+	q := NewTaskQueue(10)
+
+	_ = q.Push(ScanTask{Path: "/a", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/b", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/c", Priority: PriorityRealtime})
+	_ = q.Push(ScanTask{Path: "/d", Priority: PriorityManual})
+
+	want := []string{"/c", "/d", "/a", "/b"}
+	for _, w := range want {
+		task, ok := q.Pop(context.Background())
+		if !ok {
+			t.Fatal("Pop 不应失败")
+		}
+		if task.Path != w {
+			t.Errorf("Pop() = %s, want %s", task.Path, w)
+		}
+	}
+}
+
+func TestTaskQueue_DedupPromote(t *testing.T) {
+	q := NewTaskQueue(10)
+
+	_ = q.Push(ScanTask{Path: "/a", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/b", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/b", Priority: PriorityRealtime})
+
+	if q.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", q.Len())
+	}
+	task, _ := q.Pop(context.Background())
+	if task.Path != "/b" || task.Priority != PriorityRealtime {
+		t.Errorf("重复路径应提升优先级, got %+v", task)
+	}
+}
+
+func TestTaskQueue_FullEvictsLower(t *testing.T) {
+	q := NewTaskQueue(2)
+
+	_ = q.Push(ScanTask{Path: "/s1", Priority: PriorityScheduled})
+	_ = q.Push(ScanTask{Path: "/s2", Priority: PriorityScheduled})
+
+	if err := q.Push(ScanTask{Path: "/s3", Priority: PriorityScheduled}); err != ErrQueueFull {
+		t.Errorf("同级任务队列满时应返回 ErrQueueFull, got %v", err)
+	}
+	if err := q.Push(ScanTask{Path: "/r1", Priority: PriorityRealtime}); err != nil {
+		t.Fatalf("实时任务应挤掉定时任务, got %v", err)
+	}
+
+	first, _ := q.Pop(context.Background())
+	second, _ := q.Pop(context.Background())
+	if first.Path != "/r1" || second.Path != "/s1" {
+		t.Errorf("出队顺序 = %s, %s; want /r1, /s1", first.Path, second.Path)
+	}
+
+	_, dropped := q.Stats()
+	if dropped[PriorityScheduled] != 2 {
+		t.Errorf("dropped[scheduled] = %d, want 2", dropped[PriorityScheduled])
+	}
+}
+
+func TestTaskQueue_PopCancelAndClose(t *testing.T) {
+	q := NewTaskQueue(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, ok := q.Pop(ctx); ok {
+		t.Error("空队列在 ctx 超时后应返回 false")
+	}
+
+	done := make(chan bool)
+	go func() {
+		_, ok := q.Pop(context.Background())
+		done <- ok
+	}()
+	q.Close()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("关闭后的空队列 Pop 应返回 false")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close 未唤醒等待中的 Pop")
+	}
+
+	if err := q.Push(ScanTask{Path: "/x"}); err != ErrQueueClosed {
+		t.Errorf("关闭后 Push 应返回 ErrQueueClosed, got %v", err)
+	}
+}
+
+// ==========================================
+// 限速器测试
+// ==========================================
+
+func TestIOThrottle_Disabled(t *testing.T) {
+	th := NewIOThrottle(0)
+	if th.Enabled() {
+		t.Error("rate=0 时不应启用限速")
+	}
+	start := time.Now()
+	if err := th.WaitN(context.Background(), 100<<20); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("未启用限速时 WaitN 不应阻塞")
+	}
+}
+
+func TestIOThrottle_Limits(t *testing.T) {
+	th := NewIOThrottle(1) // 1MB/s，初始桶为空
+
+	start := time.Now()
+	if err := th.WaitN(context.Background(), 256<<10); err != nil {
+		t.Fatal(err)
+	}
+	// 256KB 在 1MB/s 下约需 250ms
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("限速未生效, elapsed = %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := th.WaitN(ctx, 512<<10); err == nil {
+		t.Error("ctx 取消后 WaitN 应返回错误")
+	}
+}
@@ -0,0 +1,488 @@
+// Package evidence 告警取证留存
+// 处置策略命中 evidence 动作时，将被检测文件的副本 (超过大小上限或不是本地文件时改为命中摘录)
+// 加密保存到本地取证库，并记录留存内容的 SM3 摘要；取证库按保留时间与总量上限淘汰最旧的证据。
+// 调查人员通过本机接口按告警 ID 取回，取回时校验摘要
+package evidence
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// 留存方式
+const (
+	ModeFile    = "file"
+	ModeExcerpt = "excerpt"
+)
+
+// 默认配置
+const (
+	DefaultMaxFileSize  = 20 << 20
+	DefaultMaxTotalSize = 1 << 30
+	DefaultRetention    = 90 * 24 * time.Hour
+)
+
+// FieldEvidence 告警扩展字段中的取证留存摘要
+const FieldEvidence = "evidence"
+
+// fieldMatchLocations 检测器写入告警扩展字段的命中位置 (detector.FieldMatchLocations)
+const fieldMatchLocations = "match_locations"
+
+// pruneInterval 两次按保留时间清理的最小间隔
+const pruneInterval = time.Hour
+
+// maxExcerptSize 命中摘录的大小上限 (字节)
+const maxExcerptSize = 256 << 10
+
+var (
+	// ErrNotFound 告警没有留存证据或已被淘汰
+	ErrNotFound = errors.New("evidence: not found")
+	// ErrCorrupt 解密后的内容与留存时的摘要不一致
+	ErrCorrupt = errors.New("evidence: digest mismatch")
+	// ErrNoContent 检测对象不是本地文件且告警中没有命中内容，无法留存
+	ErrNoContent = errors.New("evidence: nothing to capture")
+	// ErrInvalidID 告警 ID 不能作为证据文件名
+	ErrInvalidID = errors.New("evidence: invalid alert id")
+)
+
+// Config 取证库配置
+type Config struct {
+	// 取证库目录 (仅属主可访问)
+	Dir string
+	// 留存方式: file / excerpt，为空时使用 file
+	Mode string
+	// 单个文件副本的大小上限，超过时改为留存命中摘录；<=0 时使用 DefaultMaxFileSize
+	MaxFileSize int64
+	// 取证库总量上限，超过时淘汰最旧的证据；<=0 时使用 DefaultMaxTotalSize
+	MaxTotalSize int64
+	// 证据保留时间，<=0 时使用 DefaultRetention
+	Retention time.Duration
+}
+
+// Cipher 证据加解密 (由主程序提供，与本地缓存使用相同的数据密钥)
+type Cipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// Store 证据元数据持久化，storage.KeyedStore[model.EvidenceRecord] 实现该接口
+type Store interface {
+	Put(key string, item model.EvidenceRecord) error
+	Delete(key string) error
+	LoadAll() ([]model.EvidenceRecord, error)
+}
+
+// Vault 本地取证库，可并发使用
+type Vault struct {
+	cfg    Config
+	cipher Cipher
+	store  Store
+
+	mu     sync.Mutex
+	items  map[string]model.EvidenceRecord
+	total  int64
+	pruned time.Time
+}
+
+// New 创建取证库，store 非 nil 时加载已留存的证据元数据 (证据文件已丢失的条目被丢弃)
+func New(cfg Config, cipher Cipher, store Store) (*Vault, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("evidence: dir is required")
+	}
+	if cipher == nil {
+		return nil, errors.New("evidence: cipher is required")
+	}
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = ModeFile
+	case ModeFile, ModeExcerpt:
+	default:
+		return nil, fmt.Errorf("evidence: unknown mode %q", cfg.Mode)
+	}
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = DefaultMaxFileSize
+	}
+	if cfg.MaxTotalSize <= 0 {
+		cfg.MaxTotalSize = DefaultMaxTotalSize
+	}
+	if cfg.MaxFileSize > cfg.MaxTotalSize {
+		cfg.MaxFileSize = cfg.MaxTotalSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		return nil, err
+	}
+	// 已存在的目录同样收紧权限
+	if err := os.Chmod(cfg.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	v := &Vault{cfg: cfg, cipher: cipher, store: store, items: make(map[string]model.EvidenceRecord)}
+	if store != nil {
+		items, err := store.LoadAll()
+		if err != nil {
+			logger.Warn("读取取证留存记录失败", "error", err)
+		}
+		for _, e := range items {
+			if _, err := os.Stat(v.file(e.AlertID)); err != nil {
+				v.deleteMeta(e.AlertID)
+				continue
+			}
+			v.items[e.AlertID] = e
+			v.total += e.Size
+		}
+	}
+	v.prune(time.Now())
+	return v, nil
+}
+
+// file 证据文件路径
+func (v *Vault) file(alertID string) string {
+	return filepath.Join(v.cfg.Dir, alertID+".evd")
+}
+
+// validID 告警 ID 只能作为单个文件名使用
+func validID(id string) bool {
+	return id != "" && id != "." && id != ".." && !strings.ContainsAny(id, `/\`)
+}
+
+// Capture 留存告警对应的证据，并将留存摘要写入告警扩展字段 evidence
+// 同一告警只留存一次，再次调用返回已有记录
+func (v *Vault) Capture(record *model.AlertRecord, now time.Time) (model.EvidenceRecord, error) {
+	if record == nil || !validID(record.ID) {
+		return model.EvidenceRecord{}, ErrInvalidID
+	}
+	v.mu.Lock()
+	if e, ok := v.items[record.ID]; ok {
+		v.mu.Unlock()
+		return e, nil
+	}
+	v.mu.Unlock()
+
+	content, e, err := v.collect(record)
+	if err != nil {
+		return model.EvidenceRecord{}, err
+	}
+	sum := sm3.Sum(content)
+	e.SM3 = hex.EncodeToString(sum[:])
+	e.Size = int64(len(content))
+	e.CreatedAt = now.Unix()
+
+	sealed, err := v.cipher.Encrypt(content)
+	if err != nil {
+		return model.EvidenceRecord{}, fmt.Errorf("encrypt evidence: %w", err)
+	}
+	if err := writeFileAtomic(v.file(e.AlertID), sealed, 0600); err != nil {
+		return model.EvidenceRecord{}, err
+	}
+
+	v.mu.Lock()
+	if now.Sub(v.pruned) >= pruneInterval {
+		v.prune(now)
+	}
+	// 同一告警并发留存时以后写入的为准
+	if old, ok := v.items[e.AlertID]; ok {
+		v.total -= old.Size
+	}
+	v.items[e.AlertID] = e
+	v.total += e.Size
+	v.putMeta(e)
+	v.evict(e.AlertID)
+	v.mu.Unlock()
+
+	record.AddExtendFields(map[string]interface{}{FieldEvidence: map[string]interface{}{
+		"mode": e.Mode,
+		"size": e.Size,
+		"sm3":  e.SM3,
+	}})
+	return e, nil
+}
+
+// collect 读取待留存的内容：文件副本，或超过大小上限、非本地文件时的命中摘录
+func (v *Vault) collect(record *model.AlertRecord) ([]byte, model.EvidenceRecord, error) {
+	e := model.EvidenceRecord{
+		AlertID:  record.ID,
+		FilePath: record.FilePath,
+		Mode:     ModeExcerpt,
+		FileSize: int64(record.FileSize),
+		FileMD5:  record.FileMD5,
+	}
+
+	if v.cfg.Mode == ModeFile && filepath.IsAbs(record.FilePath) {
+		f, err := os.Open(record.FilePath)
+		if err != nil {
+			return nil, e, err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, e, err
+		}
+		e.FileSize = fi.Size()
+		if fi.Size() <= v.cfg.MaxFileSize {
+			// 文件在读取期间增长时同样按上限截断
+			data, err := io.ReadAll(io.LimitReader(f, v.cfg.MaxFileSize+1))
+			if err != nil {
+				return nil, e, err
+			}
+			if int64(len(data)) <= v.cfg.MaxFileSize {
+				e.Mode = ModeFile
+				return data, e, nil
+			}
+		}
+		e.Truncated = true
+	}
+
+	excerpt := Excerpt(record)
+	if excerpt == "" {
+		return nil, e, ErrNoContent
+	}
+	if len(excerpt) > maxExcerptSize {
+		excerpt = strings.ToValidUTF8(excerpt[:maxExcerptSize], "")
+	}
+	return []byte(excerpt), e, nil
+}
+
+// Excerpt 命中摘录：告警的命中内容、上下文与检测器记录的命中位置，没有命中内容时为空
+func Excerpt(record *model.AlertRecord) string {
+	var locs []model.MatchLocation
+	if record.ExtendFields != "" {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal([]byte(record.ExtendFields), &fields) == nil {
+			json.Unmarshal(fields[fieldMatchLocations], &locs)
+		}
+	}
+	if record.HighlightText == "" && record.FileDesc == "" && len(locs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "文件: %s\n", record.FilePath)
+	fmt.Fprintf(&b, "检测模块: %s 规则: %d %s\n", record.DetectModule, record.RuleID, record.RuleDesc)
+	if record.HighlightText != "" {
+		fmt.Fprintf(&b, "命中: %s\n", record.HighlightText)
+	}
+	if record.FileDesc != "" {
+		fmt.Fprintf(&b, "上下文: %s\n", record.FileDesc)
+	}
+	for _, l := range locs {
+		b.WriteString("[")
+		if l.Page > 0 {
+			fmt.Fprintf(&b, "第 %d 页 ", l.Page)
+		}
+		fmt.Fprintf(&b, "第 %d 段 字符 %d] %s【%s】%s\n", l.Paragraph, l.CharOffset, l.Before, l.Text, l.After)
+	}
+	return b.String()
+}
+
+// Get 取回证据，解密后校验摘要
+func (v *Vault) Get(alertID string) (model.EvidenceRecord, []byte, error) {
+	v.mu.Lock()
+	e, ok := v.items[alertID]
+	v.mu.Unlock()
+	if !ok {
+		return model.EvidenceRecord{}, nil, ErrNotFound
+	}
+
+	sealed, err := os.ReadFile(v.file(alertID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return model.EvidenceRecord{}, nil, ErrNotFound
+		}
+		return model.EvidenceRecord{}, nil, err
+	}
+	content, err := v.cipher.Decrypt(sealed)
+	if err != nil {
+		return e, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	sum := sm3.Sum(content)
+	if hex.EncodeToString(sum[:]) != e.SM3 {
+		return e, nil, ErrCorrupt
+	}
+	return e, content, nil
+}
+
+// List 全部证据元数据，按留存时间从新到旧排序
+func (v *Vault) List() []model.EvidenceRecord {
+	v.mu.Lock()
+	out := make([]model.EvidenceRecord, 0, len(v.items))
+	for _, e := range v.items {
+		out = append(out, e)
+	}
+	v.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt > out[j].CreatedAt
+		}
+		return out[i].AlertID < out[j].AlertID
+	})
+	return out
+}
+
+// Delete 删除证据 (调查结束后)
+func (v *Vault) Delete(alertID string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.items[alertID]; !ok {
+		return ErrNotFound
+	}
+	v.remove(alertID)
+	return nil
+}
+
+// Reseal 以 convert 重新加密全部证据文件 (数据密钥轮换后)，返回改写的文件数
+// 个别证据转换失败时继续处理其余证据，结束后返回错误；实现 storage.FileVault
+func (v *Vault) Reseal(ctx context.Context, convert func(data []byte) ([]byte, bool, error)) (int, error) {
+	v.mu.Lock()
+	ids := make([]string, 0, len(v.items))
+	for id := range v.items {
+		ids = append(ids, id)
+	}
+	v.mu.Unlock()
+
+	total, failed := 0, 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		changed, err := v.reseal(id, convert)
+		if err != nil {
+			logger.Error("证据重加密失败", "alert", id, "error", err)
+			failed++
+			continue
+		}
+		if changed {
+			total++
+		}
+	}
+	if failed > 0 {
+		return total, fmt.Errorf("evidence: %d files failed to re-encrypt", failed)
+	}
+	return total, nil
+}
+
+// reseal 转换单个证据文件，持有锁以免与淘汰、删除并发
+func (v *Vault) reseal(alertID string, convert func(data []byte) ([]byte, bool, error)) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.items[alertID]; !ok {
+		return false, nil
+	}
+	sealed, err := os.ReadFile(v.file(alertID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	out, changed, err := convert(sealed)
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, writeFileAtomic(v.file(alertID), out, 0600)
+}
+
+// evict 总量超过上限时淘汰最旧的证据 (keep 除外)，需持有锁
+func (v *Vault) evict(keep string) {
+	if v.total <= v.cfg.MaxTotalSize {
+		return
+	}
+	ids := make([]string, 0, len(v.items))
+	for id := range v.items {
+		if id != keep {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return v.items[ids[i]].CreatedAt < v.items[ids[j]].CreatedAt })
+	for _, id := range ids {
+		if v.total <= v.cfg.MaxTotalSize {
+			return
+		}
+		logger.Info("取证库超过总量上限，淘汰最旧的证据", "alert", id)
+		v.remove(id)
+	}
+}
+
+// prune 删除超过保留时间的证据，需持有锁 (New 中除外)
+func (v *Vault) prune(now time.Time) {
+	v.pruned = now
+	expire := now.Add(-v.cfg.Retention).Unix()
+	for id, e := range v.items {
+		if e.CreatedAt < expire {
+			v.remove(id)
+		}
+	}
+}
+
+// remove 删除证据文件与元数据，需持有锁
+func (v *Vault) remove(alertID string) {
+	v.total -= v.items[alertID].Size
+	delete(v.items, alertID)
+	if err := os.Remove(v.file(alertID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("删除证据文件失败", "alert", alertID, "error", err)
+	}
+	v.deleteMeta(alertID)
+}
+
+// putMeta 持久化证据元数据，需持有锁
+func (v *Vault) putMeta(e model.EvidenceRecord) {
+	if v.store == nil {
+		return
+	}
+	if err := v.store.Put(e.AlertID, e); err != nil {
+		logger.Warn("保存取证留存记录失败", "alert", e.AlertID, "error", err)
+	}
+}
+
+// deleteMeta 删除证据元数据，需持有锁 (New 中除外)
+func (v *Vault) deleteMeta(alertID string) {
+	if v.store == nil {
+		return
+	}
+	if err := v.store.Delete(alertID); err != nil {
+		logger.Warn("删除取证留存记录失败", "alert", alertID, "error", err)
+	}
+}
+
+// writeFileAtomic 写入临时文件后改名，避免留下不完整的证据
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
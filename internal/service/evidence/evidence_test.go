@@ -0,0 +1,219 @@
+package evidence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// xorCipher 测试用可逆加密
+type xorCipher struct{}
+
+func (xorCipher) Encrypt(p []byte) ([]byte, error) { return xor(p), nil }
+func (xorCipher) Decrypt(c []byte) ([]byte, error) { return xor(c), nil }
+
+func xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0x5a
+	}
+	return out
+}
+
+// memStore 内存键值存储
+type memStore struct {
+	mu    sync.Mutex
+	items map[string]model.EvidenceRecord
+}
+
+func (s *memStore) Put(key string, item model.EvidenceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = item
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func (s *memStore) LoadAll() ([]model.EvidenceRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.EvidenceRecord
+	for _, v := range s.items {
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func TestVault_CaptureFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "合同.txt")
+	content := []byte("项目编号：AB-123456 机密")
+	os.WriteFile(src, content, 0644)
+
+	store := &memStore{items: make(map[string]model.EvidenceRecord)}
+	vaultDir := filepath.Join(dir, "vault")
+	v, err := New(Config{Dir: vaultDir}, xorCipher{}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	record := &model.AlertRecord{ID: "a1", FilePath: src, FileMD5: "md5"}
+	e, err := v.Capture(record, now)
+	if err != nil || e.Mode != ModeFile || e.Size != int64(len(content)) || e.FileSize != e.Size || e.SM3 == "" {
+		t.Fatalf("Capture() = %+v, %v", e, err)
+	}
+	if !strings.Contains(record.ExtendFields, `"evidence"`) || !strings.Contains(record.ExtendFields, e.SM3) {
+		t.Errorf("extend fields = %s", record.ExtendFields)
+	}
+
+	// 磁盘上是密文，目录仅属主可访问
+	sealed, _ := os.ReadFile(filepath.Join(vaultDir, "a1.evd"))
+	if bytes.Contains(sealed, content) {
+		t.Error("evidence stored in plaintext")
+	}
+	if fi, _ := os.Stat(vaultDir); fi.Mode().Perm() != 0700 {
+		t.Errorf("vault mode = %v", fi.Mode())
+	}
+
+	// 原文件被修改或删除后仍可取回留存时的内容
+	os.Remove(src)
+	got, data, err := v.Get("a1")
+	if err != nil || !bytes.Equal(data, content) || got.FileMD5 != "md5" {
+		t.Fatalf("Get() = %+v, %q, %v", got, data, err)
+	}
+
+	// 重启后加载
+	v, _ = New(Config{Dir: vaultDir}, xorCipher{}, store)
+	if list := v.List(); len(list) != 1 || list[0].AlertID != "a1" {
+		t.Fatalf("List() after restart = %+v", list)
+	}
+
+	// 证据文件被篡改
+	os.WriteFile(filepath.Join(vaultDir, "a1.evd"), xor([]byte("篡改")), 0600)
+	if _, _, err := v.Get("a1"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Get(tampered) = %v", err)
+	}
+
+	if err := v.Delete("a1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := v.Get("a1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(deleted) = %v", err)
+	}
+	if len(store.items) != 0 {
+		t.Errorf("store after delete = %+v", store.items)
+	}
+	if _, err := v.Capture(&model.AlertRecord{ID: "../x", FilePath: src}, now); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Capture(../x) = %v", err)
+	}
+}
+
+func TestVault_Excerpt(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "大文件.txt")
+	os.WriteFile(src, bytes.Repeat([]byte("x"), 100), 0644)
+
+	v, err := New(Config{Dir: filepath.Join(dir, "vault"), MaxFileSize: 50}, xorCipher{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &model.AlertRecord{
+		ID: "a2", FilePath: src, DetectModule: model.ModuleKeywordDetect, RuleID: 51,
+		HighlightText: "AB-123456",
+	}
+	record.AddExtendFields(map[string]interface{}{"match_locations": []model.MatchLocation{
+		{Page: 2, Paragraph: 3, CharOffset: 10, Text: "AB-1", Before: "编号", After: "号"},
+	}})
+
+	e, err := v.Capture(record, time.Now())
+	if err != nil || e.Mode != ModeExcerpt || !e.Truncated || e.FileSize != 100 {
+		t.Fatalf("Capture(large) = %+v, %v", e, err)
+	}
+	_, data, _ := v.Get("a2")
+	if !strings.Contains(string(data), "命中: AB-123456") || !strings.Contains(string(data), "[第 2 页 第 3 段 字符 10] 编号【AB-1】号") {
+		t.Errorf("excerpt = %q", data)
+	}
+
+	// 剪贴板等非本地文件且没有命中内容
+	if _, err := v.Capture(&model.AlertRecord{ID: "a3", FilePath: "clipboard"}, time.Now()); !errors.Is(err, ErrNoContent) {
+		t.Errorf("Capture(no content) = %v", err)
+	}
+}
+
+func TestVault_Limits(t *testing.T) {
+	dir := t.TempDir()
+	v, err := New(Config{Dir: filepath.Join(dir, "vault"), MaxTotalSize: 250, Retention: time.Hour}, xorCipher{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		src := filepath.Join(dir, fmt.Sprintf("f%d", i))
+		os.WriteFile(src, bytes.Repeat([]byte{'a' + byte(i)}, 100), 0644)
+		if _, err := v.Capture(&model.AlertRecord{ID: fmt.Sprintf("e%d", i), FilePath: src}, now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 超过总量上限时淘汰最旧的
+	list := v.List()
+	if len(list) != 2 || list[0].AlertID != "e2" || list[1].AlertID != "e1" {
+		t.Fatalf("List() = %+v", list)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "vault", "e0.evd")); !os.IsNotExist(err) {
+		t.Errorf("evicted file still exists: %v", err)
+	}
+
+	// 超过保留时间
+	src := filepath.Join(dir, "f3")
+	os.WriteFile(src, []byte("new"), 0644)
+	v.Capture(&model.AlertRecord{ID: "e3", FilePath: src}, now.Add(2*time.Hour))
+	if list := v.List(); len(list) != 1 || list[0].AlertID != "e3" {
+		t.Errorf("List() after retention = %+v", list)
+	}
+}
+
+func TestVault_Reseal(t *testing.T) {
+	dir := t.TempDir()
+	v, err := New(Config{Dir: filepath.Join(dir, "vault"), Mode: ModeExcerpt}, xorCipher{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		record := &model.AlertRecord{ID: id, FilePath: "clipboard", FileDesc: id, HighlightText: "机密"}
+		if _, err := v.Capture(record, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 模拟密钥轮换：a1 转换成功，a2 无法解密
+	n, err := v.Reseal(context.Background(), func(data []byte) ([]byte, bool, error) {
+		if bytes.Contains(xor(data), []byte("a2")) {
+			return nil, false, errors.New("unknown key")
+		}
+		return data, true, nil
+	})
+	if n != 1 || err == nil {
+		t.Errorf("Reseal() = %d, %v", n, err)
+	}
+	for _, id := range []string{"a1", "a2"} {
+		if _, _, err := v.Get(id); err != nil {
+			t.Errorf("Get(%s) = %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+// Package exfil 外发通道监控
+// 关联文件监控事件，发现短时间内向可移动介质或网络挂载点批量拷贝涉密文件的行为，并产生聚合告警
+package exfil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/mountinfo"
+	"linuxFileWatcher/internal/service/removable"
+)
+
+// DestKind 外发目标类型
+type DestKind int
+
+const (
+	DestLocal     DestKind = iota // 本地磁盘 (不监控)
+	DestRemovable                 // 可移动介质
+	DestNetwork                   // 网络挂载
+)
+
+// String 返回目标类型名称
+func (k DestKind) String() string {
+	switch k {
+	case DestRemovable:
+		return "removable"
+	case DestNetwork:
+		return "network"
+	default:
+		return "local"
+	}
+}
+
+// networkFSTypes 网络文件系统类型
+var networkFSTypes = map[string]bool{
+	"nfs": true, "nfs4": true, "cifs": true, "smb3": true, "smbfs": true,
+	"fuse.sshfs": true, "fuse.rclone": true, "davfs": true, "9p": true,
+}
+
+// Config 关联规则
+type Config struct {
+	// 窗口内涉密文件数达到阈值即告警
+	Threshold int
+	// 统计窗口
+	Window time.Duration
+	// 同一目标两次告警的最小间隔
+	Cooldown time.Duration
+}
+
+// FileEvent 文件写入事件 (由文件监控在检测完成后提交)
+type FileEvent struct {
+	Path      string
+	Sensitive bool   // 检测结果是否涉密
+	Level     string // 密级 (可选)
+	Time      time.Time
+}
+
+// Destination 外发目标
+type Destination struct {
+	MountPoint string
+	Source     string
+	Kind       DestKind
+}
+
+// Resolver 解析文件所在的外发目标，本地磁盘返回 ok=false
+type Resolver func(path string) (Destination, bool)
+
+// AlertSink 聚合告警回调
+type AlertSink func(record *model.AlertRecord)
+
+// window 单个目标的滑动窗口
+type window struct {
+	dest      Destination
+	events    []FileEvent
+	lastAlert time.Time
+}
+
+// Correlator 外发行为关联器
+type Correlator struct {
+	cfg     Config
+	resolve Resolver
+	sink    AlertSink
+
+	mu      sync.Mutex
+	windows map[string]*window // 挂载点 -> 窗口
+}
+
+// NewCorrelator 创建关联器
+// resolve 为 nil 时使用基于挂载表的默认解析
+func NewCorrelator(cfg Config, resolve Resolver, sink AlertSink) *Correlator {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 10
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = cfg.Window
+	}
+	if resolve == nil {
+		resolve = NewMountResolver(30 * time.Second)
+	}
+	return &Correlator{
+		cfg:     cfg,
+		resolve: resolve,
+		sink:    sink,
+		windows: make(map[string]*window),
+	}
+}
+
+// Observe 提交一个文件事件
+func (c *Correlator) Observe(ev FileEvent) {
+	if !ev.Sensitive {
+		return
+	}
+	dest, ok := c.resolve(ev.Path)
+	if !ok {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	c.mu.Lock()
+	w, exists := c.windows[dest.MountPoint]
+	if !exists {
+		w = &window{dest: dest}
+		c.windows[dest.MountPoint] = w
+	}
+
+	// 清理窗口外事件，同一文件在窗口内只计一次
+	cutoff := ev.Time.Add(-c.cfg.Window)
+	kept := w.events[:0]
+	for _, e := range w.events {
+		if e.Time.After(cutoff) && e.Path != ev.Path {
+			kept = append(kept, e)
+		}
+	}
+	w.events = append(kept, ev)
+
+	var alert *model.AlertRecord
+	if len(w.events) >= c.cfg.Threshold && ev.Time.Sub(w.lastAlert) >= c.cfg.Cooldown {
+		alert = c.buildAlert(w, ev.Time)
+		w.lastAlert = ev.Time
+		w.events = nil
+	}
+	c.mu.Unlock()
+
+	if alert != nil {
+		logger.Warn("检测到批量外发涉密文件",
+			"dest", dest.MountPoint,
+			"kind", dest.Kind.String(),
+			"files", alert.HighlightText,
+		)
+		if c.sink != nil {
+			c.sink(alert)
+		}
+	}
+}
+
+// buildAlert 构造聚合告警，调用方需持有锁
+func (c *Correlator) buildAlert(w *window, now time.Time) *model.AlertRecord {
+	paths := make([]string, 0, len(w.events))
+	for _, e := range w.events {
+		paths = append(paths, e.Path)
+	}
+
+	alertType := model.AlertTypeCopyPaste
+	if w.dest.Kind == DestRemovable {
+		alertType = model.AlertTypeLocalToUSB
+	}
+
+	extend, _ := json.Marshal(map[string]interface{}{
+		"dest":        w.dest.MountPoint,
+		"dest_kind":   w.dest.Kind.String(),
+		"dest_source": w.dest.Source,
+		"files":       paths,
+		"window":      c.cfg.Window.String(),
+	})
+
+	record := model.NewAlertRecord(fmt.Sprintf("%d", now.UnixNano()))
+	record.Time = now.Format("2006-01-02 15:04:05")
+	record.AlertType = alertType
+	record.RuleDesc = i18n.T("exfil.rule_desc",
+		c.cfg.Window, destKindDesc(w.dest.Kind), w.dest.MountPoint, len(paths))
+	record.FilePath = w.dest.MountPoint
+	record.HighlightText = truncate(strings.Join(paths, ";"), 512)
+	record.ExtendFields = string(extend)
+	return record
+}
+
+func destKindDesc(k DestKind) string {
+	if k == DestRemovable {
+		return i18n.T("exfil.dest.removable")
+	}
+	return i18n.T("exfil.dest.network")
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// 避免截断在 UTF-8 字符中间
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// NewMountResolver 基于挂载表的目标解析，挂载表按 refresh 间隔缓存
+func NewMountResolver(refresh time.Duration) Resolver {
+	var (
+		mu       sync.Mutex
+		mounts   []mountinfo.Entry
+		loadedAt time.Time
+	)
+
+	return func(path string) (Destination, bool) {
+		mu.Lock()
+		if time.Since(loadedAt) > refresh {
+			mounts = mountinfo.Read()
+			loadedAt = time.Now()
+		}
+		m, ok := mountinfo.Lookup(mounts, path)
+		mu.Unlock()
+		if !ok {
+			return Destination{}, false
+		}
+
+		dest := Destination{MountPoint: m.MountPoint, Source: m.Source}
+		switch {
+		case networkFSTypes[m.FSType]:
+			dest.Kind = DestNetwork
+		case removable.IsRemovableDevice(m.Source):
+			dest.Kind = DestRemovable
+		default:
+			return Destination{}, false
+		}
+		return dest, true
+	}
+}
+
+// ==========================================
+// 全局关联器
+// ==========================================
+
+var (
+	defaultMu         sync.RWMutex
+	defaultCorrelator *Correlator
+)
+
+// SetDefault 设置全局关联器
+func SetDefault(c *Correlator) {
+	defaultMu.Lock()
+	defaultCorrelator = c
+	defaultMu.Unlock()
+}
+
+// Observe 向全局关联器提交事件，未启用时忽略
+func Observe(ev FileEvent) {
+	defaultMu.RLock()
+	c := defaultCorrelator
+	defaultMu.RUnlock()
+	if c != nil {
+		c.Observe(ev)
+	}
+}
@@ -0,0 +1,70 @@
+package exfil
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func testResolver(path string) (Destination, bool) {
+	switch {
+	case strings.HasPrefix(path, "/media/usb/"):
+		return Destination{MountPoint: "/media/usb", Source: "/dev/sdb1", Kind: DestRemovable}, true
+	case strings.HasPrefix(path, "/mnt/nfs/"):
+		return Destination{MountPoint: "/mnt/nfs", Source: "srv:/x", Kind: DestNetwork}, true
+	}
+	return Destination{}, false
+}
+
+func TestCorrelator_Threshold(t *testing.T) {
+	var alerts []*model.AlertRecord
+	c := NewCorrelator(Config{Threshold: 3, Window: time.Minute}, testResolver, func(r *model.AlertRecord) {
+		alerts = append(alerts, r)
+	})
+
+	base := time.Now()
+	// 本地文件、非涉密文件、重复文件不计数
+	c.Observe(FileEvent{Path: "/home/u/a.doc", Sensitive: true, Time: base})
+	c.Observe(FileEvent{Path: "/media/usb/x.doc", Sensitive: false, Time: base})
+	c.Observe(FileEvent{Path: "/media/usb/a.doc", Sensitive: true, Time: base})
+	c.Observe(FileEvent{Path: "/media/usb/a.doc", Sensitive: true, Time: base.Add(time.Second)})
+	c.Observe(FileEvent{Path: "/media/usb/b.doc", Sensitive: true, Time: base.Add(2 * time.Second)})
+	if len(alerts) != 0 {
+		t.Fatalf("未达阈值不应告警, got %d", len(alerts))
+	}
+
+	c.Observe(FileEvent{Path: "/media/usb/c.doc", Sensitive: true, Time: base.Add(3 * time.Second)})
+	if len(alerts) != 1 {
+		t.Fatalf("达到阈值应告警一次, got %d", len(alerts))
+	}
+	if alerts[0].AlertType != model.AlertTypeLocalToUSB || alerts[0].FilePath != "/media/usb" {
+		t.Errorf("告警内容错误: %+v", alerts[0])
+	}
+
+	// 冷却期内不重复告警
+	for i, p := range []string{"d", "e", "f"} {
+		c.Observe(FileEvent{Path: "/media/usb/" + p, Sensitive: true, Time: base.Add(time.Duration(4+i) * time.Second)})
+	}
+	if len(alerts) != 1 {
+		t.Errorf("冷却期内不应重复告警, got %d", len(alerts))
+	}
+}
+
+func TestCorrelator_WindowExpiry(t *testing.T) {
+	var alerts int
+	c := NewCorrelator(Config{Threshold: 2, Window: time.Minute}, testResolver, func(*model.AlertRecord) { alerts++ })
+
+	base := time.Now()
+	c.Observe(FileEvent{Path: "/mnt/nfs/a", Sensitive: true, Time: base})
+	c.Observe(FileEvent{Path: "/mnt/nfs/b", Sensitive: true, Time: base.Add(2 * time.Minute)})
+	if alerts != 0 {
+		t.Error("窗口外的事件不应累计")
+	}
+
+	c.Observe(FileEvent{Path: "/mnt/nfs/c", Sensitive: true, Time: base.Add(2*time.Minute + time.Second)})
+	if alerts != 1 {
+		t.Errorf("alerts = %d, want 1", alerts)
+	}
+}
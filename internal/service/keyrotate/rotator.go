@@ -0,0 +1,176 @@
+// Package keyrotate 数据密钥轮换
+// 按周期生成新的数据密钥，后台使用新密钥重加密本地存储 (告警缓存、上报队列等)，
+// 完成后只保留最近 N 把历史密钥用于解密，并将轮换事件写入审计日志
+package keyrotate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/kms"
+)
+
+// Config 轮换配置
+type Config struct {
+	// 轮换周期，<=0 不自动轮换
+	Interval time.Duration
+	// 保留的历史密钥数
+	KeepPrevious int
+	// 检查周期
+	CheckInterval time.Duration
+}
+
+// Keyring 密钥环 (由 kms.Keyring 实现)
+type Keyring interface {
+	Active() kms.KeyInfo
+	Keys() []kms.KeyInfo
+	Rotate(ctx context.Context) (kms.KeyInfo, error)
+	Prune(keep int) ([]uint32, error)
+}
+
+// ReEncryptFunc 使用当前密钥重加密存储数据，返回转换的记录数
+type ReEncryptFunc func(ctx context.Context) (int, error)
+
+// AuditFunc 审计回调
+type AuditFunc func(message string)
+
+// Rotator 数据密钥轮换器
+type Rotator struct {
+	cfg       Config
+	ring      Keyring
+	reencrypt ReEncryptFunc
+	audit     AuditFunc
+	now       func() time.Time
+
+	// 同一时刻只允许一次轮换/重加密
+	running sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRotator 创建轮换器
+func NewRotator(cfg Config, ring Keyring, reencrypt ReEncryptFunc, audit AuditFunc) *Rotator {
+	if cfg.KeepPrevious < 0 {
+		cfg.KeepPrevious = 0
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Rotator{
+		cfg:       cfg,
+		ring:      ring,
+		reencrypt: reencrypt,
+		audit:     audit,
+		now:       time.Now,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Start 启动后台轮换 (非阻塞)
+// 存在历史密钥时先继续上次未完成的重加密
+func (r *Rotator) Start() {
+	r.wg.Add(1)
+	go r.loop()
+	logger.Info("数据密钥轮换已启动", "interval", r.cfg.Interval, "keep_previous", r.cfg.KeepPrevious)
+}
+
+// Stop 停止轮换，进行中的重加密会被中断，下次启动时继续
+func (r *Rotator) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Rotator) loop() {
+	defer r.wg.Done()
+
+	if len(r.ring.Keys()) > 1 {
+		if err := r.finish(r.ctx); err != nil {
+			logger.Warn("继续重加密失败", "error", err)
+		}
+	}
+
+	ticker := time.NewTicker(r.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if r.due() {
+			if err := r.RotateNow(r.ctx); err != nil && r.ctx.Err() == nil {
+				logger.Error("数据密钥轮换失败", "error", err)
+			}
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// due 当前密钥是否已到轮换周期
+func (r *Rotator) due() bool {
+	if r.cfg.Interval <= 0 {
+		return false
+	}
+	return r.now().Sub(r.ring.Active().Created) >= r.cfg.Interval
+}
+
+// RotateNow 立即轮换：生成新密钥、重加密存储、清理多余的历史密钥
+func (r *Rotator) RotateNow(ctx context.Context) error {
+	r.running.Lock()
+	defer r.running.Unlock()
+
+	prev := r.ring.Active()
+	key, err := r.ring.Rotate(ctx)
+	if err != nil {
+		r.audit(fmt.Sprintf("数据密钥轮换失败: %v", err))
+		return err
+	}
+	logger.Info("已生成新数据密钥", "key_id", key.ID, "previous", prev.ID)
+	r.audit(fmt.Sprintf("数据密钥轮换: 新密钥 %d 替换密钥 %d", key.ID, prev.ID))
+
+	return r.reencryptAndPrune(ctx)
+}
+
+// finish 继续未完成的重加密
+func (r *Rotator) finish(ctx context.Context) error {
+	r.running.Lock()
+	defer r.running.Unlock()
+	return r.reencryptAndPrune(ctx)
+}
+
+func (r *Rotator) reencryptAndPrune(ctx context.Context) error {
+	start := r.now()
+	n, err := r.reencrypt(ctx)
+	if err != nil {
+		// 中断时保留全部历史密钥，下次启动继续
+		if ctx.Err() == nil {
+			r.audit(fmt.Sprintf("存储重加密失败 (已转换 %d 条): %v", n, err))
+		}
+		return err
+	}
+
+	removed, err := r.ring.Prune(r.cfg.KeepPrevious)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("存储重加密完成: 使用密钥 %d 转换 %d 条记录，耗时 %s", r.ring.Active().ID, n, r.now().Sub(start).Round(time.Second))
+	if len(removed) > 0 {
+		ids := make([]string, len(removed))
+		for i, id := range removed {
+			ids[i] = fmt.Sprint(id)
+		}
+		msg += "，清理历史密钥 " + strings.Join(ids, ",")
+	}
+	logger.Info(msg)
+	r.audit(msg)
+	return nil
+}
@@ -0,0 +1,95 @@
+package keyrotate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/security/kms"
+)
+
+func newRing(t *testing.T) *kms.Keyring {
+	t.Helper()
+	dir := t.TempDir()
+	k, err := kms.NewFileKMS(filepath.Join(dir, "kek"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ring, err := kms.LoadOrCreate(context.Background(), k, filepath.Join(dir, "data.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func TestRotator_RotateNow(t *testing.T) {
+	ring := newRing(t)
+	stored, _ := ring.Encrypt([]byte("告警"))
+
+	var audits []string
+	reencrypt := func(context.Context) (int, error) {
+		plain, err := ring.Decrypt(stored)
+		if err != nil {
+			return 0, err
+		}
+		stored, err = ring.Encrypt(plain)
+		return 1, err
+	}
+	r := NewRotator(Config{KeepPrevious: 1}, ring, reencrypt, func(m string) { audits = append(audits, m) })
+
+	for i := 0; i < 3; i++ {
+		if err := r.RotateNow(context.Background()); err != nil {
+			t.Fatalf("RotateNow() error = %v", err)
+		}
+	}
+
+	if ring.NeedsReEncrypt(stored) {
+		t.Error("存储数据未使用当前密钥")
+	}
+	if plain, err := ring.Decrypt(stored); err != nil || string(plain) != "告警" {
+		t.Errorf("Decrypt() = %q, %v", plain, err)
+	}
+	if n := len(ring.Keys()); n != 2 {
+		t.Errorf("保留密钥数 = %d, want 2", n)
+	}
+	if len(audits) != 6 || !strings.Contains(audits[5], "清理历史密钥 2") {
+		t.Errorf("audits = %q", audits)
+	}
+}
+
+func TestRotator_ReEncryptFailureKeepsKeys(t *testing.T) {
+	ring := newRing(t)
+	var audits []string
+	r := NewRotator(Config{KeepPrevious: 0}, ring, func(context.Context) (int, error) {
+		return 3, errors.New("disk full")
+	}, func(m string) { audits = append(audits, m) })
+
+	if err := r.RotateNow(context.Background()); err == nil {
+		t.Fatal("RotateNow() error = nil")
+	}
+	if n := len(ring.Keys()); n != 2 {
+		t.Errorf("重加密失败后应保留历史密钥: %d", n)
+	}
+	if len(audits) != 2 || !strings.Contains(audits[1], "已转换 3 条") {
+		t.Errorf("audits = %q", audits)
+	}
+}
+
+func TestRotator_Due(t *testing.T) {
+	ring := newRing(t)
+	r := NewRotator(Config{Interval: 24 * time.Hour}, ring, nil, nil)
+	if r.due() {
+		t.Error("新密钥不应到期")
+	}
+	r.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	if !r.due() {
+		t.Error("超过轮换周期应到期")
+	}
+	r.cfg.Interval = 0
+	if r.due() {
+		t.Error("Interval=0 不应自动轮换")
+	}
+}
@@ -0,0 +1,337 @@
+// Package lineage 涉密文件流转追踪
+// 文件首次告警后，其复制、改名产生的新路径关联到首次告警 (起点)，告警中附带流转图，
+// 调查人员看到的是一份文件的流转过程，而不是数十条互不相关的告警。
+// 关联依据: 文件监控观察到的改名 (Observe)，以及后续告警中相同的文件 MD5 (原路径仍存在为复制，否则为移动)
+package lineage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// 流转类型
+const (
+	KindOrigin = "origin" // 首次告警
+	KindCopy   = "copy"   // 复制
+	KindRename = "rename" // 改名或移动
+)
+
+// FieldLineage 告警扩展字段中的流转图
+const FieldLineage = "lineage"
+
+// 默认配置
+const (
+	DefaultRetention = 30 * 24 * time.Hour
+	DefaultMaxEdges  = 100
+)
+
+// pruneInterval 两次清理过期流转图的最小间隔
+const pruneInterval = time.Hour
+
+// fileExists 判断路径是否存在，测试时可替换
+var fileExists = func(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// Config 追踪配置
+type Config struct {
+	// 流转图最后一次变化后的保留时间，<=0 时使用 DefaultRetention
+	Retention time.Duration
+	// 单个流转图的最大记录数，超出后新路径仍关联起点但不再记录，<=0 时使用 DefaultMaxEdges
+	MaxEdges int
+}
+
+// Store 持久化存储，storage.KeyedStore[model.FileLineageEdge] 实现该接口
+type Store interface {
+	Put(key string, item model.FileLineageEdge) error
+	Delete(key string) error
+	LoadAll() ([]model.FileLineageEdge, error)
+}
+
+// Graph 一份文件的流转图，Edges[0] 为起点
+type Graph struct {
+	RootAlertID string                  `json:"root_alert_id"`
+	Edges       []model.FileLineageEdge `json:"edges"`
+}
+
+// AlertSink 告警回调
+type AlertSink func(*model.AlertRecord, *model.AlertLogItem)
+
+// Tracker 流转追踪，可并发使用
+type Tracker struct {
+	cfg   Config
+	store Store
+
+	mu     sync.Mutex
+	graphs map[string][]model.FileLineageEdge // 起点告警 ID -> 流转记录
+	byPath map[string]string                  // 当前路径 -> 起点告警 ID
+	byMD5  map[string]string                  // 文件 MD5 -> 起点告警 ID
+	seq    int64                              // 最近一条流转记录的顺序号
+	pruned time.Time
+}
+
+// New 创建流转追踪，store 非 nil 时加载已持久化的流转记录
+func New(cfg Config, store Store) *Tracker {
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	if cfg.MaxEdges <= 0 {
+		cfg.MaxEdges = DefaultMaxEdges
+	}
+	t := &Tracker{
+		cfg:    cfg,
+		store:  store,
+		graphs: make(map[string][]model.FileLineageEdge),
+		byPath: make(map[string]string),
+		byMD5:  make(map[string]string),
+	}
+	if store == nil {
+		return t
+	}
+
+	items, err := store.LoadAll()
+	if err != nil {
+		logger.Warn("读取文件流转记录失败", "error", err)
+		return t
+	}
+	// 起点在前，其余按记录顺序重放 (时间只精确到秒，同一秒内的复制、改名需按顺序号区分先后)
+	sort.SliceStable(items, func(i, j int) bool {
+		if (items[i].Kind == KindOrigin) != (items[j].Kind == KindOrigin) {
+			return items[i].Kind == KindOrigin
+		}
+		if items[i].Seq != items[j].Seq {
+			return items[i].Seq < items[j].Seq
+		}
+		return items[i].Time < items[j].Time
+	})
+	for i := range items {
+		t.seq = max(t.seq, items[i].Seq)
+		if items[i].Kind != KindOrigin {
+			if _, ok := t.graphs[items[i].RootAlertID]; !ok {
+				continue
+			}
+		}
+		t.apply(&items[i])
+	}
+	t.prune(time.Now())
+	return t
+}
+
+// Link 关联一条告警，告警属于已有流转图时返回流转图
+// 同一路径再次告警时补全由文件监控记录的改名的告警 ID
+func (t *Tracker) Link(record *model.AlertRecord, now time.Time) (Graph, bool) {
+	if record == nil || record.ID == "" || record.FilePath == "" {
+		return Graph{}, false
+	}
+	path := filepath.Clean(record.FilePath)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.pruned) >= pruneInterval {
+		t.prune(now)
+	}
+
+	if root, ok := t.byPath[path]; ok {
+		edges := t.graphs[root]
+		for i := range edges {
+			if edges[i].To == path && edges[i].AlertID == "" {
+				edges[i].AlertID = record.ID
+				t.save(edges[i])
+			}
+		}
+		return t.graph(root)
+	}
+
+	if root, ok := t.byMD5[record.FileMD5]; ok && record.FileMD5 != "" {
+		from, kind := t.source(root)
+		t.add(model.FileLineageEdge{
+			Kind: kind, From: from, To: path, RootAlertID: root,
+			AlertID: record.ID, FileMD5: record.FileMD5, Time: now.Unix(),
+		})
+		return t.graph(root)
+	}
+
+	t.add(model.FileLineageEdge{
+		Kind: KindOrigin, To: path, RootAlertID: record.ID,
+		AlertID: record.ID, FileMD5: record.FileMD5, Time: now.Unix(),
+	})
+	return Graph{}, false
+}
+
+// Observe 记录文件监控观察到的改名，原路径不属于任何流转图时忽略
+func (t *Tracker) Observe(from, to string, now time.Time) {
+	from, to = filepath.Clean(from), filepath.Clean(to)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	root, ok := t.byPath[from]
+	if !ok || from == to {
+		return
+	}
+	t.add(model.FileLineageEdge{
+		Kind: KindRename, From: from, To: to, RootAlertID: root,
+		FileMD5: t.graphs[root][0].FileMD5, Time: now.Unix(),
+	})
+}
+
+// Graph 返回指定起点告警的流转图
+func (t *Tracker) Graph(rootAlertID string) (Graph, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.graph(rootAlertID)
+}
+
+// Wrap 包装告警回调，属于已有流转图的告警附带流转图
+func (t *Tracker) Wrap(sink AlertSink) func(*model.AlertRecord, *model.AlertLogItem) {
+	if t == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		if g, ok := t.Link(record, time.Now()); ok {
+			record.AddExtendFields(map[string]interface{}{FieldLineage: g})
+		}
+		sink(record, logItem)
+	}
+}
+
+// source 新副本的来源: 流转图中最近的仍存在的路径为复制来源；均已不存在时视为从最近的路径移动而来
+func (t *Tracker) source(root string) (string, string) {
+	edges := t.graphs[root]
+	for i := len(edges) - 1; i >= 0; i-- {
+		if p := edges[i].To; t.byPath[p] == root && fileExists(p) {
+			return p, KindCopy
+		}
+	}
+	return edges[len(edges)-1].To, KindRename
+}
+
+// add 记录一条流转并持久化，需持有锁
+func (t *Tracker) add(e model.FileLineageEdge) {
+	if e.Kind != KindOrigin && len(t.graphs[e.RootAlertID]) >= t.cfg.MaxEdges {
+		t.byPath[e.To] = e.RootAlertID
+		if e.Kind == KindRename {
+			delete(t.byPath, e.From)
+		}
+		return
+	}
+	t.seq++
+	e.Seq = t.seq
+	if t.apply(&e) {
+		t.save(e)
+	}
+}
+
+// apply 更新内存索引，返回流转记录是否有变化，需持有锁
+// 替换同一路径的已有记录时沿用其顺序号，重启后重放的顺序与内存中一致
+func (t *Tracker) apply(e *model.FileLineageEdge) bool {
+	edges := t.graphs[e.RootAlertID]
+	changed := true
+	i := 0
+	for ; i < len(edges) && edges[i].To != e.To; i++ {
+	}
+	switch {
+	case i == len(edges):
+		edges = append(edges, *e)
+	case edges[i].Kind == KindOrigin:
+		// 改名回到起点路径时保留起点记录
+		changed = false
+	default:
+		e.Seq = edges[i].Seq
+		edges[i] = *e
+	}
+	t.graphs[e.RootAlertID] = edges
+
+	t.byPath[e.To] = e.RootAlertID
+	if e.Kind == KindRename && t.byPath[e.From] == e.RootAlertID {
+		delete(t.byPath, e.From)
+	}
+	if e.Kind == KindOrigin && e.FileMD5 != "" {
+		t.byMD5[e.FileMD5] = e.RootAlertID
+	}
+	return changed
+}
+
+// save 持久化一条流转，需持有锁
+func (t *Tracker) save(e model.FileLineageEdge) {
+	if t.store == nil {
+		return
+	}
+	if err := t.store.Put(edgeKey(e), e); err != nil {
+		logger.Warn("保存文件流转记录失败", "path", e.To, "error", err)
+	}
+}
+
+// graph 复制流转图，只有起点时返回 false，需持有锁
+func (t *Tracker) graph(root string) (Graph, bool) {
+	edges := t.graphs[root]
+	if len(edges) < 2 {
+		return Graph{}, false
+	}
+	return Graph{RootAlertID: root, Edges: append([]model.FileLineageEdge(nil), edges...)}, true
+}
+
+// prune 清理保留时间内没有变化的流转图，需持有锁 (或在创建时调用)
+func (t *Tracker) prune(now time.Time) {
+	t.pruned = now
+	cutoff := now.Add(-t.cfg.Retention).Unix()
+	for root, edges := range t.graphs {
+		latest := int64(0)
+		for _, e := range edges {
+			if e.Time > latest {
+				latest = e.Time
+			}
+		}
+		if latest >= cutoff {
+			continue
+		}
+		for _, e := range edges {
+			if t.byPath[e.To] == root {
+				delete(t.byPath, e.To)
+			}
+			if t.store != nil {
+				if err := t.store.Delete(edgeKey(e)); err != nil {
+					logger.Warn("删除文件流转记录失败", "path", e.To, "error", err)
+				}
+			}
+		}
+		for md5, r := range t.byMD5 {
+			if r == root {
+				delete(t.byMD5, md5)
+			}
+		}
+		delete(t.graphs, root)
+	}
+}
+
+// edgeKey 存储键，同一流转图中的同一路径只保留最近一条
+func edgeKey(e model.FileLineageEdge) string {
+	return e.RootAlertID + "|" + e.To
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultTracker *Tracker
+)
+
+// SetDefault 设置全局流转追踪
+func SetDefault(t *Tracker) {
+	defaultMu.Lock()
+	defaultTracker = t
+	defaultMu.Unlock()
+}
+
+// Observe 向全局流转追踪提交文件监控观察到的改名，未启用时忽略
+func Observe(from, to string) {
+	defaultMu.RLock()
+	t := defaultTracker
+	defaultMu.RUnlock()
+	if t != nil {
+		t.Observe(from, to, time.Now())
+	}
+}
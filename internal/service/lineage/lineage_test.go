@@ -0,0 +1,179 @@
+package lineage
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memStore 内存存储
+type memStore struct {
+	mu    sync.Mutex
+	items map[string]model.FileLineageEdge
+}
+
+func newMemStore() *memStore {
+	return &memStore{items: make(map[string]model.FileLineageEdge)}
+}
+
+func (s *memStore) Put(key string, item model.FileLineageEdge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = item
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func (s *memStore) LoadAll() ([]model.FileLineageEdge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.FileLineageEdge
+	for _, it := range s.items {
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+// fakeFiles 以集合代替文件系统
+func fakeFiles(t *testing.T, paths ...string) map[string]bool {
+	t.Helper()
+	files := make(map[string]bool)
+	for _, p := range paths {
+		files[p] = true
+	}
+	old := fileExists
+	fileExists = func(p string) bool { return files[p] }
+	t.Cleanup(func() { fileExists = old })
+	return files
+}
+
+func alert(id, path, md5 string) *model.AlertRecord {
+	return &model.AlertRecord{ID: id, FilePath: path, FileMD5: md5}
+}
+
+func kinds(g Graph) []string {
+	var out []string
+	for _, e := range g.Edges {
+		out = append(out, e.Kind+":"+e.From+">"+e.To)
+	}
+	return out
+}
+
+func TestTracker_Lineage(t *testing.T) {
+	files := fakeFiles(t, "/data/a.doc")
+	store := newMemStore()
+	tr := New(Config{}, store)
+	now := time.Now()
+
+	if _, ok := tr.Link(alert("A1", "/data/a.doc", "m1"), now); ok {
+		t.Fatal("first alert linked to a graph")
+	}
+
+	// 文件监控观察到改名，新路径再次告警时补全告警 ID
+	tr.Observe("/data/a.doc", "/data/b.doc", now)
+	tr.Observe("/data/other.doc", "/data/c.doc", now)
+	delete(files, "/data/a.doc")
+	files["/data/b.doc"] = true
+	g, ok := tr.Link(alert("A2", "/data/b.doc", "m1"), now)
+	if !ok || g.RootAlertID != "A1" || len(g.Edges) != 2 || g.Edges[1].AlertID != "A2" {
+		t.Fatalf("graph after rename = %+v", g)
+	}
+
+	// 相同内容出现在新路径: 原路径仍存在为复制，否则为移动
+	files["/media/usb/b.doc"] = true
+	if _, ok := tr.Link(alert("A3", "/media/usb/b.doc", "m1"), now); !ok {
+		t.Fatal("copy not linked")
+	}
+	delete(files, "/media/usb/b.doc")
+	g, ok = tr.Link(alert("A4", "/tmp/x.doc", "m1"), now)
+	want := []string{
+		"origin:>/data/a.doc",
+		"rename:/data/a.doc>/data/b.doc",
+		"copy:/data/b.doc>/media/usb/b.doc",
+		"copy:/data/b.doc>/tmp/x.doc",
+	}
+	if got := kinds(g); !ok || len(got) != len(want) {
+		t.Fatalf("graph = %v", got)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("edge %d = %s, want %s", i, got[i], want[i])
+			}
+		}
+	}
+	delete(files, "/data/b.doc")
+	g, _ = tr.Link(alert("A5", "/srv/y.doc", "m1"), now)
+	if e := g.Edges[len(g.Edges)-1]; e.Kind != KindRename || e.From != "/tmp/x.doc" {
+		t.Errorf("move = %+v", e)
+	}
+
+	if _, ok := tr.Link(alert("B1", "/data/other.doc", "m2"), now); ok {
+		t.Error("unrelated alert linked")
+	}
+
+	// 重启后恢复
+	g2, ok := New(Config{}, store).Graph("A1")
+	if !ok || len(g2.Edges) != len(g.Edges) || g2.Edges[1].AlertID != "A2" {
+		t.Errorf("restored graph = %+v", g2)
+	}
+}
+
+func TestTracker_Wrap(t *testing.T) {
+	fakeFiles(t, "/data/a.doc")
+	tr := New(Config{}, nil)
+	var got []*model.AlertRecord
+	sink := tr.Wrap(func(r *model.AlertRecord, _ *model.AlertLogItem) { got = append(got, r) })
+
+	sink(alert("A1", "/data/a.doc", "m1"), nil)
+	sink(alert("A2", "/home/u/a.doc", "m1"), nil)
+	if len(got) != 2 || got[0].ExtendFields != "" {
+		t.Fatalf("records = %+v", got)
+	}
+	var fields struct {
+		Lineage Graph `json:"lineage"`
+	}
+	if err := json.Unmarshal([]byte(got[1].ExtendFields), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields.Lineage.RootAlertID != "A1" || len(fields.Lineage.Edges) != 2 || fields.Lineage.Edges[1].Kind != KindCopy {
+		t.Errorf("lineage = %+v", fields.Lineage)
+	}
+
+	var nilTracker *Tracker
+	if nilTracker.Wrap(nil) != nil {
+		t.Error("nil tracker changed sink")
+	}
+}
+
+func TestTracker_Prune(t *testing.T) {
+	fakeFiles(t)
+	store := newMemStore()
+	tr := New(Config{Retention: time.Hour, MaxEdges: 2}, store)
+	now := time.Now()
+	tr.Link(alert("A1", "/data/a.doc", "m1"), now)
+	tr.Link(alert("A2", "/data/b.doc", "m1"), now)
+	tr.Link(alert("A3", "/data/c.doc", "m1"), now)
+	if g, _ := tr.Graph("A1"); len(g.Edges) != 2 {
+		t.Errorf("edges beyond MaxEdges recorded: %+v", g)
+	}
+	if g, ok := tr.Link(alert("A4", "/data/c.doc", "m1"), now); !ok || g.RootAlertID != "A1" {
+		t.Error("path beyond MaxEdges not linked")
+	}
+
+	later := now.Add(2 * time.Hour)
+	if _, ok := tr.Link(alert("B1", "/data/d.doc", "m1"), later); ok {
+		t.Error("expired graph still linked")
+	}
+	if len(store.items) != 1 {
+		t.Errorf("store after prune = %+v", store.items)
+	}
+}
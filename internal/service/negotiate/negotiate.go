@@ -0,0 +1,234 @@
+// Package negotiate 与管理平台协商上报数据结构版本
+// 启动时及之后定期查询服务端能力，上报数据按双方都支持的版本序列化：
+// 旧服务端收到降级后的数据，被去掉的新字段记录告警日志提示升级管理平台，而不是被服务端静默丢弃
+//
+// 实验性: 上报模块尚未按协商结果序列化 (Marshal)，守护进程不启动协商，也没有对应的配置项
+package negotiate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// SchemaHeader 请求头中携带客户端支持的数据结构版本
+const SchemaHeader = "X-Agent-Schema-Version"
+
+// Capabilities 服务端能力
+type Capabilities struct {
+	// 服务端支持的最高上报数据结构版本
+	SchemaVersion int `json:"schema_version"`
+	// 服务端支持的可选功能
+	Features []string `json:"features,omitempty"`
+}
+
+// Config 协商配置
+type Config struct {
+	// 管理平台地址
+	URL string
+	// 能力查询接口路径
+	Path string
+	// 重新协商周期 (服务端升级后无需重启客户端即可使用新版本)
+	Interval time.Duration
+	// 单次查询超时
+	Timeout time.Duration
+}
+
+// Negotiator 版本协商器
+type Negotiator struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.RWMutex
+	caps Capabilities
+	// 已提示过的被去掉字段，版本变化后重新提示
+	warned map[string]bool
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建协商器，协商成功前按旧服务端 (LegacySchemaVersion) 处理
+func New(cfg Config, client *http.Client) *Negotiator {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Hour
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Negotiator{
+		cfg:    cfg,
+		client: client,
+		caps:   Capabilities{SchemaVersion: model.LegacySchemaVersion},
+		warned: make(map[string]bool),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 立即协商并定期重新协商 (非阻塞)
+func (n *Negotiator) Start() {
+	n.wg.Add(1)
+	go n.loop()
+}
+
+// Stop 停止定期协商
+func (n *Negotiator) Stop() {
+	close(n.stopCh)
+	n.wg.Wait()
+}
+
+func (n *Negotiator) loop() {
+	defer n.wg.Done()
+	ticker := time.NewTicker(n.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), n.cfg.Timeout)
+		if _, err := n.Negotiate(ctx); err != nil {
+			logger.Warn("上报数据结构版本协商失败，沿用当前版本", "schema_version", n.SchemaVersion(), "error", err)
+		}
+		cancel()
+		select {
+		case <-n.stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Negotiate 查询服务端能力
+// 服务端没有能力查询接口 (404/405/501) 视为旧服务端；其他失败保留上次协商结果
+func (n *Negotiator) Negotiate(ctx context.Context) (Capabilities, error) {
+	url := strings.TrimRight(n.cfg.URL, "/") + n.cfg.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return n.Capabilities(), err
+	}
+	req.Header.Set(SchemaHeader, strconv.Itoa(model.ReportSchemaVersion))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return n.Capabilities(), err
+	}
+	defer resp.Body.Close()
+
+	var caps Capabilities
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&caps); err != nil {
+			return n.Capabilities(), fmt.Errorf("parse capabilities: %w", err)
+		}
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		caps.SchemaVersion = model.LegacySchemaVersion
+	default:
+		return n.Capabilities(), fmt.Errorf("capabilities: unexpected status %s", resp.Status)
+	}
+	if caps.SchemaVersion > model.ReportSchemaVersion {
+		caps.SchemaVersion = model.ReportSchemaVersion
+	} else if caps.SchemaVersion < model.LegacySchemaVersion {
+		caps.SchemaVersion = model.LegacySchemaVersion
+	}
+
+	n.mu.Lock()
+	prev := n.caps.SchemaVersion
+	n.caps = caps
+	if prev != caps.SchemaVersion {
+		n.warned = make(map[string]bool)
+	}
+	n.mu.Unlock()
+	if prev != caps.SchemaVersion {
+		logger.Info("上报数据结构版本已协商", "schema_version", caps.SchemaVersion, "agent_schema_version", model.ReportSchemaVersion)
+	}
+	return caps, nil
+}
+
+// Capabilities 返回最近一次协商的服务端能力
+func (n *Negotiator) Capabilities() Capabilities {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	caps := n.caps
+	caps.Features = append([]string(nil), n.caps.Features...)
+	return caps
+}
+
+// SchemaVersion 协商的上报数据结构版本
+func (n *Negotiator) SchemaVersion() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.caps.SchemaVersion
+}
+
+// Has 服务端是否支持可选功能
+func (n *Negotiator) Has(feature string) bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for _, f := range n.caps.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Marshal 按协商的版本序列化上报数据，服务端不支持的字段每个版本提示一次
+func (n *Negotiator) Marshal(v interface{}) ([]byte, error) {
+	version := n.SchemaVersion()
+	data, dropped, err := model.MarshalSchema(v, version)
+	if err != nil || len(dropped) == 0 {
+		return data, err
+	}
+
+	var fresh []string
+	n.mu.Lock()
+	for _, f := range dropped {
+		if !n.warned[f] {
+			n.warned[f] = true
+			fresh = append(fresh, f)
+		}
+	}
+	n.mu.Unlock()
+	if len(fresh) > 0 {
+		logger.Warn("管理平台不支持以下上报字段，已去除后上报，请升级管理平台",
+			"server_schema_version", version, "agent_schema_version", model.ReportSchemaVersion, "fields", strings.Join(fresh, ","))
+	}
+	return data, nil
+}
+
+// ==========================================
+// 全局实例
+// ==========================================
+
+var defaultNegotiator atomic.Pointer[Negotiator]
+
+// SetDefault 设置全局协商器 (由主程序按配置设置，上报模块通过 Marshal 使用)
+func SetDefault(n *Negotiator) {
+	defaultNegotiator.Store(n)
+}
+
+// Default 返回全局协商器，未初始化时为 nil
+func Default() *Negotiator {
+	return defaultNegotiator.Load()
+}
+
+// Marshal 使用全局协商器序列化上报数据，未初始化时按当前版本完整输出
+func Marshal(v interface{}) ([]byte, error) {
+	if n := Default(); n != nil {
+		return n.Marshal(v)
+	}
+	data, _, err := model.MarshalSchema(v, model.ReportSchemaVersion)
+	return data, err
+}
@@ -0,0 +1,119 @@
+package negotiate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestMarshalSchema(t *testing.T) {
+	alert := model.NewAlertRecord("a1")
+	alert.FileName = "计划.docx"
+	alert.AgentUUID = "6c66772d-6167-5e6e-b42d-757569647635"
+	alert.SetProcess(&model.ProcessInfo{PID: 42, Exe: "/usr/bin/cp", SessionInfo: model.SessionInfo{LoginUser: "alice", SessionID: "3"}})
+
+	decode := func(data []byte) map[string]interface{} {
+		var m map[string]interface{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// 当前版本完整输出
+	data, dropped, err := model.MarshalSchema(alert, model.ReportSchemaVersion)
+	if err != nil || len(dropped) != 0 {
+		t.Fatalf("MarshalSchema(current) dropped = %v, %v", dropped, err)
+	}
+	m := decode(data)
+	if m["schema_version"] != float64(model.ReportSchemaVersion) || m["login_user"] != "alice" || m["process_pid"] != float64(42) {
+		t.Errorf("current = %v", m)
+	}
+
+	// 旧服务端: 去掉新字段 (含嵌入的会话字段)，基础字段不变
+	data, dropped, err = model.MarshalSchema(alert, model.LegacySchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = decode(data)
+	for _, key := range []string{"schema_version", "agent_uuid", "process_pid", "process_exe", "login_user", "session_id"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("legacy payload contains %s", key)
+		}
+	}
+	if m["filename"] != "计划.docx" || m["id"] != "a1" || m["user_name"] != "alice" {
+		t.Errorf("legacy = %v", m)
+	}
+	want := []string{"AlertRecord.agent_uuid", "AlertRecord.login_user", "AlertRecord.process_exe", "AlertRecord.process_pid", "AlertRecord.session_id"}
+	if !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped = %v, want %v", dropped, want)
+	}
+
+	// 嵌套结构: 安全状态上报中的事件
+	report := model.NewSecurityStatusReport("1.0")
+	report.AddTamperAlert("篡改", "aa", "bb")
+	data, dropped, _ = model.MarshalSchema([]*model.SecurityStatusReport{report}, model.LegacySchemaVersion)
+	var reports []map[string]interface{}
+	json.Unmarshal(data, &reports)
+	event := reports[0]["suspected"].([]interface{})[0].(map[string]interface{})
+	if _, ok := event["before_hash"]; ok || event["msg"] != "篡改" {
+		t.Errorf("legacy event = %v", event)
+	}
+	if len(dropped) != 2 || dropped[0] != "SuspectedEvent.after_hash" {
+		t.Errorf("dropped = %v", dropped)
+	}
+}
+
+func TestNegotiator(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/capabilities" || r.Header.Get(SchemaHeader) == "" {
+			t.Errorf("request %s %v", r.URL.Path, r.Header)
+		}
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		w.Write([]byte(`{"schema_version": 9, "features": ["gzip"]}`))
+	}))
+	defer srv.Close()
+
+	n := New(Config{URL: srv.URL + "/", Path: "/api/capabilities"}, srv.Client())
+	if n.SchemaVersion() != model.LegacySchemaVersion {
+		t.Errorf("initial version = %d", n.SchemaVersion())
+	}
+
+	// 服务端版本高于客户端时使用客户端版本
+	caps, err := n.Negotiate(context.Background())
+	if err != nil || caps.SchemaVersion != model.ReportSchemaVersion || !n.Has("gzip") {
+		t.Fatalf("Negotiate() = %+v, %v", caps, err)
+	}
+
+	// 服务端临时故障时保留上次结果
+	status.Store(http.StatusBadGateway)
+	if _, err := n.Negotiate(context.Background()); err == nil || n.SchemaVersion() != model.ReportSchemaVersion {
+		t.Errorf("after 502: version = %d, err = %v", n.SchemaVersion(), err)
+	}
+
+	// 没有能力查询接口的旧服务端
+	status.Store(http.StatusNotFound)
+	if caps, err := n.Negotiate(context.Background()); err != nil || caps.SchemaVersion != model.LegacySchemaVersion {
+		t.Errorf("Negotiate(404) = %+v, %v", caps, err)
+	}
+	data, err := n.Marshal(model.NewHeartbeatRequest("id", "1.0", "running"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hb map[string]interface{}
+	json.Unmarshal(data, &hb)
+	if _, ok := hb["schema_version"]; ok || hb["status"] != "running" {
+		t.Errorf("legacy heartbeat = %s", data)
+	}
+}
@@ -0,0 +1,126 @@
+//go:build linux
+
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ==========================================
+// DBus 会话总线 (org.freedesktop.Notifications)
+// ==========================================
+
+// runtimeDir systemd-logind 为每个登录用户创建的运行目录，会话总线套接字位于其下
+var runtimeDir = "/run/user"
+
+// ResolveSession 查找用户的桌面会话总线
+// user 为空时返回唯一活动的非 root 会话，存在多个会话时无法确定目标用户
+func ResolveSession(name string) (*Session, error) {
+	if name == "" {
+		return soleSession()
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		// 进程用户无法解析时记录的是 UID
+		if _, convErr := strconv.Atoi(name); convErr != nil {
+			return nil, err
+		}
+		if u, err = user.LookupId(name); err != nil {
+			return nil, err
+		}
+	}
+	return sessionFor(u)
+}
+
+func sessionFor(u *user.User) (*Session, error) {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return nil, err
+	}
+	gid, _ := strconv.Atoi(u.Gid)
+
+	bus := filepath.Join(runtimeDir, u.Uid, "bus")
+	if fi, err := os.Stat(bus); err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return nil, fmt.Errorf("%w %s", ErrNoSession, u.Username)
+	}
+	return &Session{UID: uid, GID: gid, User: u.Username, BusAddr: "unix:path=" + bus}, nil
+}
+
+func soleSession() (*Session, error) {
+	matches, _ := filepath.Glob(filepath.Join(runtimeDir, "*", "bus"))
+	var found *user.User
+	for _, m := range matches {
+		uid := filepath.Base(filepath.Dir(m))
+		if uid == "0" {
+			continue
+		}
+		u, err := user.LookupId(uid)
+		if err != nil {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%w: multiple active sessions", ErrNoSession)
+		}
+		found = u
+	}
+	if found == nil {
+		return nil, ErrNoSession
+	}
+	return sessionFor(found)
+}
+
+// DBusSender 通过 gdbus 调用 org.freedesktop.Notifications.Notify
+// 守护进程以 root 运行时切换为目标用户身份连接其会话总线 (会话总线只接受同一用户的连接)
+type DBusSender struct{}
+
+// Send 发送通知
+func (DBusSender) Send(ctx context.Context, s *Session, msg *Message) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	timeout := int64(-1)
+	if msg.Timeout > 0 {
+		timeout = msg.Timeout.Milliseconds()
+	}
+
+	cmd := exec.CommandContext(ctx, "gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString(msg.AppName),
+		"0",
+		gvariantString("dialog-warning"),
+		gvariantString(msg.Summary),
+		gvariantString(msg.Body),
+		"@as []",
+		fmt.Sprintf("{'urgency': <byte %d>}", msg.Urgency),
+		strconv.FormatInt(timeout, 10),
+	)
+	cmd.Env = append(os.Environ(), "DBUS_SESSION_BUS_ADDRESS="+s.BusAddr)
+	if os.Geteuid() == 0 && s.UID != 0 {
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Credential: &syscall.Credential{Uid: uint32(s.UID), Gid: uint32(s.GID)},
+		}
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("gdbus notify %s: %w: %s", s.User, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gvariantString 转为 GVariant 文本格式的字符串字面量
+func gvariantString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`)
+	return "'" + r.Replace(s) + "'"
+}
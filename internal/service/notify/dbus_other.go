@@ -0,0 +1,18 @@
+//go:build !linux
+
+package notify
+
+import "context"
+
+// ResolveSession 非 Linux 平台不支持桌面通知
+func ResolveSession(string) (*Session, error) {
+	return nil, ErrNoSession
+}
+
+// DBusSender 非 Linux 平台不支持桌面通知
+type DBusSender struct{}
+
+// Send 非 Linux 平台不支持桌面通知
+func (DBusSender) Send(context.Context, *Session, *Message) error {
+	return ErrNoSession
+}
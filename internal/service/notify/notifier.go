@@ -0,0 +1,243 @@
+// Package notify 桌面用户通知
+// 当前用户的操作触发涉密检测或被阻断时，通过 DBus org.freedesktop.Notifications
+// 向其桌面会话弹出通知；通知内容按模板渲染并支持多语言，按用户限流
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// Kind 通知类型
+type Kind string
+
+const (
+	KindDetection Kind = "detection" // 检测到涉密文件
+	KindBlocked   Kind = "blocked"   // 操作已被阻断 (文件被隔离或撤销权限)
+)
+
+// Urgency 通知紧急程度 (freedesktop 规范)
+type Urgency byte
+
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+// ErrNoSession 找不到目标用户的桌面会话
+var ErrNoSession = errors.New("notify: no desktop session for user")
+
+// Session 目标用户的桌面会话
+type Session struct {
+	UID     int
+	GID     int
+	User    string
+	BusAddr string // DBUS_SESSION_BUS_ADDRESS
+}
+
+// Message 渲染后的通知内容
+type Message struct {
+	AppName string
+	Summary string
+	Body    string
+	Urgency Urgency
+	Timeout time.Duration
+}
+
+// Sender 通知发送接口
+type Sender interface {
+	Send(ctx context.Context, s *Session, msg *Message) error
+}
+
+// SessionResolver 按用户名查找桌面会话，user 为空时返回唯一活动的会话
+type SessionResolver func(user string) (*Session, error)
+
+// Config 通知配置
+type Config struct {
+	// 通知语言 (zh-CN/en-US)，为空时使用全局语言 (i18n.Locale)
+	Locale string
+	// 应用名称 (显示在通知中)
+	AppName string
+	// 通知显示时长，0 使用桌面环境默认值
+	Timeout time.Duration
+	// 同一用户同一文件的重复通知间隔
+	Cooldown time.Duration
+	// 每个用户每分钟最多通知数，<=0 不限制
+	MaxPerMinute int
+	// 自定义模板，覆盖内置模板
+	Templates map[Kind]Template
+}
+
+// Event 通知事件
+type Event struct {
+	Kind   Kind
+	Record *model.AlertRecord
+}
+
+// Notifier 桌面通知
+type Notifier struct {
+	cfg       Config
+	templates map[Kind]*compiledTemplate
+	sender    Sender
+	resolve   SessionResolver
+
+	mu      sync.Mutex
+	sent    map[string]time.Time   // 用户+文件 -> 最近通知时间
+	recent  map[string][]time.Time // 用户 -> 最近一分钟的通知时间
+	dropped int64
+	now     func() time.Time
+}
+
+// NewNotifier 创建桌面通知
+// sender/resolve 为 nil 时使用 DBus 会话总线与 /run/user/<uid>/bus
+func NewNotifier(cfg Config, sender Sender, resolve SessionResolver) (*Notifier, error) {
+	if cfg.Locale == "" {
+		cfg.Locale = i18n.Locale()
+	} else if !i18n.Supported(cfg.Locale) {
+		return nil, fmt.Errorf("notify: unsupported locale %q", cfg.Locale)
+	}
+	cfg.Locale = i18n.Normalize(cfg.Locale)
+	if cfg.AppName == "" {
+		cfg.AppName = "linuxFileWatcher"
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = time.Minute
+	}
+	templates, err := compileTemplates(cfg.Locale, cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+	if sender == nil {
+		sender = DBusSender{}
+	}
+	if resolve == nil {
+		resolve = ResolveSession
+	}
+	return &Notifier{
+		cfg:       cfg,
+		templates: templates,
+		sender:    sender,
+		resolve:   resolve,
+		sent:      make(map[string]time.Time),
+		recent:    make(map[string][]time.Time),
+		now:       time.Now,
+	}, nil
+}
+
+// Dropped 因限流被丢弃的通知数
+func (n *Notifier) Dropped() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dropped
+}
+
+// Notify 通知触发事件的用户
+// 用户取写入文件的进程所属的登录用户 (sudo 等切换身份后仍为原用户)，其次为进程用户与告警责任人；
+// 都没有时通知唯一活动的桌面会话
+func (n *Notifier) Notify(ctx context.Context, ev Event) error {
+	if ev.Record == nil {
+		return nil
+	}
+	user := ev.Record.LoginUser
+	if user == "" {
+		user = ev.Record.ProcessUser
+	}
+	if user == "" {
+		user = ev.Record.UserName
+	}
+	session, err := n.resolve(user)
+	if err != nil {
+		return err
+	}
+
+	if !n.allow(session.User, ev.Record.FilePath) {
+		logger.Debug("桌面通知被限流", "user", session.User, "path", ev.Record.FilePath)
+		return nil
+	}
+
+	tmpl, ok := n.templates[ev.Kind]
+	if !ok {
+		tmpl = n.templates[KindDetection]
+	}
+	msg, err := tmpl.render(n.cfg.Locale, ev)
+	if err != nil {
+		return err
+	}
+	msg.AppName = n.cfg.AppName
+	msg.Timeout = n.cfg.Timeout
+	return n.sender.Send(ctx, session, msg)
+}
+
+// allow 限流：同一用户同一文件在冷却期内只通知一次，且每分钟通知数不超过上限
+func (n *Notifier) allow(user, path string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	key := user + "\x00" + path
+	if last, ok := n.sent[key]; ok && now.Sub(last) < n.cfg.Cooldown {
+		n.dropped++
+		return false
+	}
+
+	cutoff := now.Add(-time.Minute)
+	kept := n.recent[user][:0]
+	for _, t := range n.recent[user] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	n.recent[user] = kept
+	if n.cfg.MaxPerMinute > 0 && len(kept) >= n.cfg.MaxPerMinute {
+		n.dropped++
+		return false
+	}
+
+	// 清理过期的冷却记录
+	for k, t := range n.sent {
+		if now.Sub(t) >= n.cfg.Cooldown {
+			delete(n.sent, k)
+		}
+	}
+
+	n.sent[key] = now
+	n.recent[user] = append(kept, now)
+	return true
+}
+
+// Wrap 包装告警回调，命中时通知触发检测的用户后交给 sink
+// 未启用处置策略时使用；n 为 nil 时原样返回 sink
+func (n *Notifier) Wrap(sink func(*model.AlertRecord, *model.AlertLogItem)) func(*model.AlertRecord, *model.AlertLogItem) {
+	if n == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		if record != nil {
+			n.NotifyAsync(Event{Kind: KindDetection, Record: record})
+		}
+		sink(record, logItem)
+	}
+}
+
+// NotifyAsync 在后台发送通知，不阻塞检测流程；失败仅记录日志 (用户未登录桌面时很常见)
+// 告警记录在发送前复制，调用方可继续修改
+func (n *Notifier) NotifyAsync(ev Event) {
+	if ev.Record == nil {
+		return
+	}
+	record := *ev.Record
+	ev.Record = &record
+	go func() {
+		if err := n.Notify(context.Background(), ev); err != nil {
+			logger.Debug("桌面通知发送失败", "path", record.FilePath, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// fakeSender 记录发送的通知
+type fakeSender struct {
+	sent []*Message
+	to   []string
+}
+
+func (f *fakeSender) Send(_ context.Context, s *Session, msg *Message) error {
+	f.sent = append(f.sent, msg)
+	f.to = append(f.to, s.User)
+	return nil
+}
+
+func fakeResolve(name string) (*Session, error) {
+	if name == "" {
+		return &Session{UID: 1000, User: "desktop"}, nil
+	}
+	if name == "nobody" {
+		return nil, ErrNoSession
+	}
+	return &Session{UID: 1001, User: name}, nil
+}
+
+func newTestNotifier(t *testing.T, cfg Config) (*Notifier, *fakeSender, *time.Time) {
+	t.Helper()
+	sender := &fakeSender{}
+	n, err := NewNotifier(cfg, sender, fakeResolve)
+	if err != nil {
+		t.Fatalf("NewNotifier() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	n.now = func() time.Time { return now }
+	return n, sender, &now
+}
+
+func TestNotifier_RenderLocalized(t *testing.T) {
+	record := &model.AlertRecord{
+		FilePath:    "/home/alice/报告.docx",
+		RuleDesc:    "密级标志",
+		FileLevel:   int(model.LevelTopSecret),
+		ProcessUser: "alice",
+	}
+
+	n, sender, _ := newTestNotifier(t, Config{})
+	if err := n.Notify(context.Background(), Event{Kind: KindDetection, Record: record}); err != nil {
+		t.Fatal(err)
+	}
+	msg := sender.sent[0]
+	if sender.to[0] != "alice" || msg.Summary != "检测到涉密文件" || msg.Urgency != UrgencyNormal {
+		t.Errorf("通知 = %+v -> %s", msg, sender.to[0])
+	}
+	if !strings.Contains(msg.Body, "报告.docx (绝密)") || !strings.Contains(msg.Body, "命中规则: 密级标志") {
+		t.Errorf("Body = %q", msg.Body)
+	}
+
+	n, sender, _ = newTestNotifier(t, Config{Locale: "en-US"})
+	record.ExtendFields = `{"quarantine_path":"/q/1_a"}`
+	if err := n.Notify(context.Background(), Event{Kind: KindBlocked, Record: record}); err != nil {
+		t.Fatal(err)
+	}
+	msg = sender.sent[0]
+	if msg.Urgency != UrgencyCritical || !strings.Contains(msg.Body, "(Top Secret)") || !strings.Contains(msg.Body, "quarantined") {
+		t.Errorf("英文阻断通知 = %+v", msg)
+	}
+}
+
+func TestNotifier_LoginUser(t *testing.T) {
+	// sudo 切换为 root 写入的文件通知原登录用户
+	record := &model.AlertRecord{FilePath: "/srv/a.txt", ProcessUser: "root"}
+	record.LoginUser = "alice"
+	n, sender, _ := newTestNotifier(t, Config{})
+	if err := n.Notify(context.Background(), Event{Kind: KindDetection, Record: record}); err != nil {
+		t.Fatal(err)
+	}
+	if sender.to[0] != "alice" {
+		t.Errorf("目标用户 = %s, want alice", sender.to[0])
+	}
+}
+
+func TestNotifier_CustomTemplate(t *testing.T) {
+	n, sender, _ := newTestNotifier(t, Config{Templates: map[Kind]Template{
+		KindDetection: {Summary: "注意: {{.Level}}", Urgency: "low"},
+	}})
+	record := &model.AlertRecord{FilePath: "/tmp/a.txt", FileLevel: int(model.LevelSecret)}
+	if err := n.Notify(context.Background(), Event{Kind: KindDetection, Record: record}); err != nil {
+		t.Fatal(err)
+	}
+	msg := sender.sent[0]
+	if msg.Summary != "注意: 机密" || msg.Urgency != UrgencyLow || !strings.HasPrefix(msg.Body, "a.txt") {
+		t.Errorf("自定义模板通知 = %+v", msg)
+	}
+	// 没有进程用户与责任人时通知唯一的桌面会话
+	if sender.to[0] != "desktop" {
+		t.Errorf("目标用户 = %s, want desktop", sender.to[0])
+	}
+
+	if _, err := NewNotifier(Config{Locale: "fr-FR"}, sender, fakeResolve); err == nil {
+		t.Error("不支持的语言应返回错误")
+	}
+	if _, err := NewNotifier(Config{Templates: map[Kind]Template{KindBlocked: {Body: "{{.Oops"}}}, sender, fakeResolve); err == nil {
+		t.Error("模板语法错误应返回错误")
+	}
+}
+
+func TestNotifier_RateLimit(t *testing.T) {
+	n, sender, now := newTestNotifier(t, Config{Cooldown: time.Hour, MaxPerMinute: 2})
+	ctx := context.Background()
+	notify := func(user, path string) {
+		rec := &model.AlertRecord{FilePath: path, ProcessUser: user}
+		if err := n.Notify(ctx, Event{Kind: KindDetection, Record: rec}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notify("alice", "/a")
+	notify("alice", "/a") // 冷却期内相同文件
+	notify("alice", "/b")
+	notify("alice", "/c") // 超过每分钟上限
+	notify("bob", "/c")   // 限流按用户计算
+	if len(sender.sent) != 3 || n.Dropped() != 2 {
+		t.Fatalf("发送 %d 条, 丢弃 %d 条", len(sender.sent), n.Dropped())
+	}
+
+	*now = now.Add(2 * time.Minute)
+	notify("alice", "/c")
+	if len(sender.sent) != 4 {
+		t.Errorf("限流窗口过后应继续发送, got %d", len(sender.sent))
+	}
+
+	rec := &model.AlertRecord{FilePath: "/x", ProcessUser: "nobody"}
+	if err := n.Notify(ctx, Event{Kind: KindDetection, Record: rec}); !errors.Is(err, ErrNoSession) {
+		t.Errorf("无会话 error = %v", err)
+	}
+}
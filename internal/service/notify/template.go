@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 通知模板
+// ==========================================
+//
+// 模板使用 text/template 语法，可用字段见 templateData，例如:
+//   summary: "检测到{{.Level}}文件"
+//   body:    "{{.FileName}} 命中规则: {{.Rule}}"
+
+// Template 通知模板
+type Template struct {
+	Summary string
+	Body    string
+	// 紧急程度: low/normal/critical，为空时阻断类通知为 critical，其余为 normal
+	Urgency string
+}
+
+// builtinTemplates 内置模板 (语言 -> 通知类型 -> 模板)
+var builtinTemplates = map[string]map[Kind]Template{
+	i18n.ZhCN: {
+		KindDetection: {
+			Summary: "检测到涉密文件",
+			Body:    "{{.FileName}}{{if .Level}} ({{.Level}}){{end}}\n{{if .Rule}}命中规则: {{.Rule}}\n{{end}}请按保密规定处理该文件。",
+		},
+		KindBlocked: {
+			Summary: "涉密文件操作已被阻止",
+			Body:    "{{.FileName}}{{if .Level}} ({{.Level}}){{end}}\n{{if .Rule}}命中规则: {{.Rule}}\n{{end}}{{if .Quarantined}}文件已被隔离{{else}}文件访问权限已被撤销{{end}}，如有疑问请联系管理员。",
+		},
+	},
+	i18n.EnUS: {
+		KindDetection: {
+			Summary: "Classified file detected",
+			Body:    "{{.FileName}}{{if .Level}} ({{.Level}}){{end}}\n{{if .Rule}}Rule: {{.Rule}}\n{{end}}Please handle this file according to the security policy.",
+		},
+		KindBlocked: {
+			Summary: "Operation on classified file blocked",
+			Body:    "{{.FileName}}{{if .Level}} ({{.Level}}){{end}}\n{{if .Rule}}Rule: {{.Rule}}\n{{end}}{{if .Quarantined}}The file has been quarantined{{else}}Access to the file has been revoked{{end}}. Contact your administrator if you have questions.",
+		},
+	},
+}
+
+// levelKeys 密级对应的消息目录键
+var levelKeys = map[model.SecretLevel]string{
+	model.LevelTopSecret:    "level.top_secret",
+	model.LevelSecret:       "level.secret",
+	model.LevelConfidential: "level.confidential",
+	model.LevelInternal:     "level.internal",
+}
+
+// templateData 模板可用字段
+type templateData struct {
+	FileName    string
+	FilePath    string
+	Rule        string // 命中规则描述
+	Level       string // 本地化的密级名称，未知时为空
+	Process     string // 写入文件的进程
+	User        string
+	Time        string
+	Quarantined bool // 文件已被隔离
+}
+
+type compiledTemplate struct {
+	summary *template.Template
+	body    *template.Template
+	urgency Urgency
+}
+
+// compileTemplates 编译指定语言的模板，overrides 中的非空字段覆盖内置模板
+func compileTemplates(locale string, overrides map[Kind]Template) (map[Kind]*compiledTemplate, error) {
+	builtin, ok := builtinTemplates[locale]
+	if !ok {
+		return nil, fmt.Errorf("notify: unsupported locale %q", locale)
+	}
+
+	out := make(map[Kind]*compiledTemplate, len(builtin))
+	for kind, t := range builtin {
+		if o, ok := overrides[kind]; ok {
+			if o.Summary != "" {
+				t.Summary = o.Summary
+			}
+			if o.Body != "" {
+				t.Body = o.Body
+			}
+			t.Urgency = o.Urgency
+		}
+		ct, err := compileTemplate(kind, t)
+		if err != nil {
+			return nil, err
+		}
+		out[kind] = ct
+	}
+	return out, nil
+}
+
+func compileTemplate(kind Kind, t Template) (*compiledTemplate, error) {
+	summary, err := template.New(string(kind) + ".summary").Parse(t.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("notify: template %s summary: %w", kind, err)
+	}
+	body, err := template.New(string(kind) + ".body").Parse(t.Body)
+	if err != nil {
+		return nil, fmt.Errorf("notify: template %s body: %w", kind, err)
+	}
+
+	ct := &compiledTemplate{summary: summary, body: body, urgency: UrgencyNormal}
+	switch strings.ToLower(t.Urgency) {
+	case "":
+		if kind == KindBlocked {
+			ct.urgency = UrgencyCritical
+		}
+	case "low":
+		ct.urgency = UrgencyLow
+	case "normal":
+	case "critical":
+		ct.urgency = UrgencyCritical
+	default:
+		return nil, fmt.Errorf("notify: template %s: unknown urgency %q", kind, t.Urgency)
+	}
+	return ct, nil
+}
+
+// levelName 本地化的密级名称，未知密级返回空
+func levelName(locale string, l model.SecretLevel) string {
+	key, ok := levelKeys[l]
+	if !ok {
+		return ""
+	}
+	return i18n.Tl(locale, key)
+}
+
+// render 渲染通知内容
+func (t *compiledTemplate) render(locale string, ev Event) (*Message, error) {
+	r := ev.Record
+	data := templateData{
+		FileName:    r.FileName,
+		FilePath:    r.FilePath,
+		Rule:        r.RuleDesc,
+		Level:       levelName(locale, model.SecretLevel(r.FileLevel)),
+		Process:     r.ProcessExe,
+		User:        r.ProcessUser,
+		Time:        r.Time,
+		Quarantined: strings.Contains(r.ExtendFields, `"quarantine_path"`),
+	}
+	if data.FileName == "" {
+		data.FileName = filepath.Base(r.FilePath)
+	}
+	if data.User == "" {
+		data.User = r.UserName
+	}
+
+	var summary, body strings.Builder
+	if err := t.summary.Execute(&summary, data); err != nil {
+		return nil, err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	return &Message{
+		Summary: summary.String(),
+		Body:    body.String(),
+		Urgency: t.urgency,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package offline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxAckSize 回执文件大小上限
+const maxAckSize = 16 << 20
+
+// Ack 管理平台导入数据包后生成的送达回执
+// 平台可按规则包的方式签名 (顶层 "signature" 字段，SM2 签名规范化 JSON)
+type Ack struct {
+	AgentUUID string   `json:"agent_uuid"`
+	Bundles   []string `json:"bundles"`
+}
+
+// VerifyFunc 校验回执签名，返回 error 表示拒绝该回执
+type VerifyFunc func(data []byte) error
+
+// ReadAck 读取并校验回执文件，verify 为 nil 时不校验签名
+func ReadAck(path string, verify VerifyFunc) (*Ack, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAckSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxAckSize {
+		return nil, fmt.Errorf("offline: ack file %s is too large", path)
+	}
+	return ParseAck(data, verify)
+}
+
+// ParseAck 解析并校验回执
+func ParseAck(data []byte, verify VerifyFunc) (*Ack, error) {
+	if verify != nil {
+		if err := verify(data); err != nil {
+			return nil, err
+		}
+	}
+	var ack Ack
+	if err := json.Unmarshal(data, &ack); err != nil {
+		return nil, fmt.Errorf("offline: parse ack: %w", err)
+	}
+	if ack.AgentUUID == "" {
+		return nil, fmt.Errorf("offline: ack has no agent_uuid")
+	}
+	for _, id := range ack.Bundles {
+		if !idPattern.MatchString(id) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+	}
+	return &ack, nil
+}
@@ -0,0 +1,220 @@
+package offline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security/envelope"
+)
+
+// Store 上报缓存 (由 storage.HybridStore 实现)
+type Store[T any] interface {
+	PopAll() ([]T, error)
+	Push(item T) error
+}
+
+// Source 一类上报数据
+type Source interface {
+	Name() string
+	// drain 取出全部数据并序列化，restore 在打包失败时放回
+	drain() (data json.RawMessage, count int, restore func(), err error)
+}
+
+type storeSource[T any] struct {
+	name  string
+	store Store[T]
+}
+
+// StoreSource 以上报缓存作为数据来源，name 为数据包中的数据类型
+func StoreSource[T any](name string, store Store[T]) Source {
+	return &storeSource[T]{name: name, store: store}
+}
+
+func (s *storeSource[T]) Name() string {
+	return s.name
+}
+
+func (s *storeSource[T]) drain() (json.RawMessage, int, func(), error) {
+	items, err := s.store.PopAll()
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	restore := func() {
+		for _, item := range items {
+			if err := s.store.Push(item); err != nil {
+				logger.Error("离线数据包打包失败后放回上报缓存失败", "type", s.name, "error", err)
+				return
+			}
+		}
+	}
+	if len(items) == 0 {
+		return nil, 0, restore, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		restore()
+		return nil, 0, nil, err
+	}
+	return data, len(items), restore, nil
+}
+
+// Config 打包配置
+type Config struct {
+	// 打包周期
+	Interval time.Duration
+}
+
+// Bundler 定期将上报缓存中的数据打包为加密数据包
+type Bundler struct {
+	cfg       Config
+	spool     *Spool
+	sealer    *envelope.Sealer
+	agentUUID func() string
+	sources   []Source
+	now       func() time.Time
+
+	// 同一时刻只允许一次打包
+	sealing sync.Mutex
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewBundler 创建打包器，sealer 为服务端公钥封装器 (离线模式必须加密)
+func NewBundler(cfg Config, spool *Spool, sealer *envelope.Sealer, agentUUID func() string, sources ...Source) *Bundler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Minute
+	}
+	return &Bundler{
+		cfg:       cfg,
+		spool:     spool,
+		sealer:    sealer,
+		agentUUID: agentUUID,
+		sources:   sources,
+		now:       time.Now,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start 启动定期打包 (非阻塞)
+func (b *Bundler) Start() {
+	b.wg.Add(1)
+	go b.loop()
+	logger.Info("离线模式已启用，上报数据将打包保存", "dir", b.spool.Dir(), "interval", b.cfg.Interval)
+}
+
+// Stop 停止定期打包，并将剩余数据打包，退出后可直接导出
+func (b *Bundler) Stop() {
+	close(b.stopCh)
+	b.wg.Wait()
+	if _, err := b.Seal(); err != nil {
+		logger.Error("退出前打包离线数据失败", "error", err)
+	}
+}
+
+func (b *Bundler) loop() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			if _, err := b.Seal(); err != nil {
+				logger.Error("打包离线数据失败", "error", err)
+			}
+		}
+	}
+}
+
+// Seal 立即打包上报缓存中的全部数据，没有数据时返回 nil
+// 任一步骤失败时已取出的数据放回上报缓存，下次重试
+func (b *Bundler) Seal() (*Info, error) {
+	b.sealing.Lock()
+	defer b.sealing.Unlock()
+
+	var restores []func()
+	rollback := func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+
+	data := make(map[string]json.RawMessage)
+	counts := make(map[string]int)
+	total := 0
+	for _, src := range b.sources {
+		raw, n, restore, err := src.drain()
+		if err != nil {
+			rollback()
+			return nil, fmt.Errorf("read %s: %w", src.Name(), err)
+		}
+		restores = append(restores, restore)
+		if n > 0 {
+			data[src.Name()] = raw
+			counts[src.Name()] = n
+			total += n
+		}
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	f, err := b.build(data, counts)
+	if err == nil {
+		_, err = b.spool.Write(f)
+	}
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+	logger.Info("离线数据包已生成", "id", f.ID, "items", total)
+	return b.spool.stat(f.ID)
+}
+
+// build 压缩并加密载荷
+func (b *Bundler) build(data map[string]json.RawMessage, counts map[string]int) (*File, error) {
+	created := b.now().UTC()
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := created.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+	agent := b.agentUUID()
+
+	plain, err := json.Marshal(&Payload{ID: id, AgentUUID: agent, Created: created, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(plain); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	env, err := b.sealer.Seal(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		Format:    Format,
+		Version:   Version,
+		ID:        id,
+		AgentUUID: agent,
+		Created:   created,
+		Counts:    counts,
+		Encoding:  EncodingGzip,
+		Payload:   env,
+	}, nil
+}
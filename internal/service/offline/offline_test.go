@@ -0,0 +1,207 @@
+package offline
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"linuxFileWatcher/internal/gmsm/sm2"
+	"linuxFileWatcher/internal/security/envelope"
+)
+
+// memStore 测试用的上报缓存
+type memStore[T any] struct {
+	mu      sync.Mutex
+	items   []T
+	popErr  error
+	pushErr error
+}
+
+func (s *memStore[T]) PopAll() ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.popErr != nil {
+		return nil, s.popErr
+	}
+	items := s.items
+	s.items = nil
+	return items, nil
+}
+
+func (s *memStore[T]) Push(item T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pushErr != nil {
+		return s.pushErr
+	}
+	s.items = append(s.items, item)
+	return nil
+}
+
+type alert struct {
+	FileName string `json:"file_name"`
+}
+
+func newTestBundler(t *testing.T) (*Bundler, *sm2.PrivateKey, *memStore[alert], *memStore[string]) {
+	priv, _ := sm2.GenerateKey(nil)
+	sealer, err := envelope.NewSealer(priv.PublicKey.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	spool, err := NewSpool(filepath.Join(t.TempDir(), "offline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := &memStore[alert]{}
+	audits := &memStore[string]{}
+	b := NewBundler(Config{}, spool, sealer, func() string { return "agent-1" },
+		StoreSource[alert]("alerts", alerts),
+		StoreSource[string]("audit_logs", audits),
+	)
+	return b, priv, alerts, audits
+}
+
+// openBundle 模拟平台解密数据包
+func openBundle(t *testing.T, priv *sm2.PrivateKey, path string) (*File, *Payload) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	zipped, err := envelope.Open(priv, f.Payload)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(zipped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := io.ReadAll(zr)
+	var p Payload
+	if err := json.Unmarshal(plain, &p); err != nil {
+		t.Fatal(err)
+	}
+	return &f, &p
+}
+
+func TestBundler_Seal(t *testing.T) {
+	b, priv, alerts, audits := newTestBundler(t)
+
+	// 没有数据时不生成数据包
+	if info, err := b.Seal(); info != nil || err != nil {
+		t.Fatalf("Seal(empty) = %v, %v", info, err)
+	}
+
+	alerts.Push(alert{FileName: "绝密计划.docx"})
+	alerts.Push(alert{FileName: "b.txt"})
+	audits.Push("login")
+	info, err := b.Seal()
+	if err != nil || info == nil {
+		t.Fatalf("Seal() = %v, %v", info, err)
+	}
+	if info.Counts["alerts"] != 2 || info.Counts["audit_logs"] != 1 || info.AgentUUID != "agent-1" {
+		t.Errorf("info = %+v", info)
+	}
+	if len(alerts.items) != 0 || len(audits.items) != 0 {
+		t.Error("stores not drained")
+	}
+
+	raw, _ := os.ReadFile(info.Path)
+	if strings.Contains(string(raw), "绝密") {
+		t.Fatal("数据包中出现明文")
+	}
+	f, p := openBundle(t, priv, info.Path)
+	if f.Encoding != EncodingGzip || p.ID != f.ID || p.AgentUUID != "agent-1" {
+		t.Errorf("file = %+v, payload = %+v", f, p)
+	}
+	var got []alert
+	json.Unmarshal(p.Data["alerts"], &got)
+	if len(got) != 2 || got[0].FileName != "绝密计划.docx" {
+		t.Errorf("alerts = %+v", got)
+	}
+
+	// 读取失败时已取出的数据放回
+	alerts.Push(alert{FileName: "c.txt"})
+	audits.popErr = errors.New("disk error")
+	if _, err := b.Seal(); err == nil {
+		t.Fatal("Seal() with failing store succeeded")
+	}
+	if len(alerts.items) != 1 {
+		t.Errorf("alerts after rollback = %+v", alerts.items)
+	}
+	infos, _ := b.spool.List()
+	if len(infos) != 1 {
+		t.Errorf("bundles = %d, want 1", len(infos))
+	}
+}
+
+func TestSpool_ExportAck(t *testing.T) {
+	b, _, alerts, _ := newTestBundler(t)
+	var ids []string
+	for i := 0; i < 2; i++ {
+		alerts.Push(alert{FileName: "a.txt"})
+		info, err := b.Seal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, info.ID)
+	}
+
+	dest := t.TempDir()
+	exported, err := b.spool.Export(dest, false)
+	if err != nil || len(exported) != 2 {
+		t.Fatalf("Export() = %d, %v", len(exported), err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, ids[0]+bundleExt)); err != nil {
+		t.Error(err)
+	}
+	// 已导出的数据包默认不重复导出
+	if again, _ := b.spool.Export(dest, false); len(again) != 0 {
+		t.Errorf("re-export = %d", len(again))
+	}
+	if again, _ := b.spool.Export(dest, true); len(again) != 2 {
+		t.Errorf("re-export all = %d", len(again))
+	}
+
+	// 签名校验失败的回执被拒绝
+	ackData, _ := json.Marshal(Ack{AgentUUID: "agent-1", Bundles: []string{ids[0], "20260101T000000Z-00000000"}})
+	reject := func([]byte) error { return errors.New("bad signature") }
+	if _, err := ParseAck(ackData, reject); err == nil {
+		t.Error("ParseAck() accepted ack with bad signature")
+	}
+	ack, err := ParseAck(ackData, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, skipped, err := b.spool.Remove(ack)
+	if err != nil || len(removed) != 1 || removed[0] != ids[0] || len(skipped) != 1 {
+		t.Errorf("Remove() = %v, %v, %v", removed, skipped, err)
+	}
+
+	// 其他终端的回执不删除本机数据包
+	removed, _, _ = b.spool.Remove(&Ack{AgentUUID: "agent-2", Bundles: []string{ids[1]}})
+	if len(removed) != 0 {
+		t.Errorf("removed bundle of another agent: %v", removed)
+	}
+	infos, _ := b.spool.List()
+	if len(infos) != 1 || infos[0].ID != ids[1] || infos[0].Exported.IsZero() {
+		t.Errorf("remaining = %+v", infos)
+	}
+
+	// 回执中的 ID 不能指向数据包目录之外
+	bad, _ := json.Marshal(Ack{AgentUUID: "agent-1", Bundles: []string{"../config"}})
+	if _, err := ParseAck(bad, nil); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("ParseAck(path traversal) error = %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+// Package offline 离线 (物理隔离) 模式的存储转发
+// 上报数据不经网络发送，定期打包为服务端公钥加密的数据包保存在本地；
+// 运维人员通过 export-bundle 将数据包导出到移动介质，在管理平台导入后带回平台回执，
+// 通过 import-ack 导入回执，删除已送达的数据包
+package offline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/security/envelope"
+)
+
+// Format 数据包格式标识
+const Format = "lfw-offline-bundle"
+
+// Version 数据包格式版本
+const Version = 1
+
+// EncodingGzip 载荷先 gzip 压缩再加密
+const EncodingGzip = "gzip"
+
+const (
+	bundleExt   = ".bundle"
+	exportedExt = ".exported"
+)
+
+// idPattern 数据包 ID: 创建时间 (UTC) + 随机后缀，按字典序即按时间排序
+var idPattern = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{8}$`)
+
+// ErrInvalidID 数据包 ID 格式无效 (防止回执中的 ID 指向数据包目录之外)
+var ErrInvalidID = errors.New("offline: invalid bundle id")
+
+// File 数据包文件: 明文头部便于平台识别与去重，数据在 Payload 信封中
+type File struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	AgentUUID string    `json:"agent_uuid"`
+	Created   time.Time `json:"created"`
+	// 各类数据的条数
+	Counts   map[string]int `json:"counts"`
+	Encoding string         `json:"encoding"`
+	// 加密的 Payload JSON
+	Payload *envelope.Envelope `json:"payload"`
+}
+
+// Payload 数据包载荷，ID 与终端标识重复写入加密部分，平台据此校验明文头部
+type Payload struct {
+	ID        string    `json:"id"`
+	AgentUUID string    `json:"agent_uuid"`
+	Created   time.Time `json:"created"`
+	// 键为数据类型 (alerts、audit_logs 等)，值为该类数据的 JSON 数组
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+// Info 本地数据包摘要
+type Info struct {
+	ID        string
+	AgentUUID string
+	Created   time.Time
+	Counts    map[string]int
+	Size      int64
+	Path      string
+	// 最近一次导出时间，未导出为零值
+	Exported time.Time
+}
+
+// Spool 数据包目录
+// 守护进程写入数据包，export-bundle / import-ack 子命令在另一进程中读取与删除，
+// 数据包先写临时文件再重命名，读取方不会看到未写完的文件
+type Spool struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewSpool 创建数据包目录
+func NewSpool(dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("offline: create bundle dir: %w", err)
+	}
+	return &Spool{dir: dir}, nil
+}
+
+// Dir 数据包目录
+func (s *Spool) Dir() string {
+	return s.dir
+}
+
+// Write 保存数据包
+func (s *Spool) Write(f *File) (string, error) {
+	if !idPattern.MatchString(f.ID) {
+		return "", ErrInvalidID
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := filepath.Join(s.dir, f.ID+bundleExt)
+	if err := writeFileSync(path, data, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List 列出未确认送达的数据包，按创建时间排序
+func (s *Spool) List() ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var infos []Info
+	for _, e := range entries {
+		id := strings.TrimSuffix(e.Name(), bundleExt)
+		if e.IsDir() || id == e.Name() || !idPattern.MatchString(id) {
+			continue
+		}
+		info, err := s.stat(id)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// 其他进程导入回执时已删除
+				continue
+			}
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos, nil
+}
+
+// stat 读取数据包头部与导出记录
+func (s *Spool) stat(id string) (*Info, error) {
+	path := filepath.Join(s.dir, id+bundleExt)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("offline: parse %s: %w", path, err)
+	}
+	if f.Format != Format || f.ID != id {
+		return nil, fmt.Errorf("offline: %s is not a bundle of %s", path, id)
+	}
+	info := &Info{
+		ID:        f.ID,
+		AgentUUID: f.AgentUUID,
+		Created:   f.Created,
+		Counts:    f.Counts,
+		Size:      int64(len(data)),
+		Path:      path,
+	}
+	if st, err := os.Stat(filepath.Join(s.dir, id+exportedExt)); err == nil {
+		info.Exported = st.ModTime()
+	}
+	return info, nil
+}
+
+// Export 将数据包复制到 dest (移动介质)，all 为 false 时只导出尚未导出过的数据包
+// 导出不删除本地数据包，收到平台回执后才删除；平台按 ID 去重，重复导出无害
+func (s *Spool) Export(dest string, all bool) ([]Info, error) {
+	infos, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		return nil, fmt.Errorf("offline: create export dir: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var exported []Info
+	for _, info := range infos {
+		if !info.Exported.IsZero() && !all {
+			continue
+		}
+		data, err := os.ReadFile(info.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// 并发导入的回执已删除
+				continue
+			}
+			return exported, err
+		}
+		if err := writeFileSync(filepath.Join(dest, info.ID+bundleExt), data, 0600); err != nil {
+			return exported, err
+		}
+		marker := filepath.Join(s.dir, info.ID+exportedExt)
+		if err := os.WriteFile(marker, nil, 0600); err != nil {
+			return exported, err
+		}
+		now := time.Now()
+		os.Chtimes(marker, now, now)
+		info.Exported = now
+		exported = append(exported, info)
+	}
+	if len(exported) > 0 {
+		if err := syncDir(dest); err != nil {
+			return exported, err
+		}
+	}
+	return exported, nil
+}
+
+// Remove 删除平台已确认送达的数据包，返回删除的 ID 与本地不存在或属于其他终端的 ID
+func (s *Spool) Remove(ack *Ack) (removed, skipped []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ack.Bundles {
+		if !idPattern.MatchString(id) {
+			return removed, skipped, fmt.Errorf("%w: %q", ErrInvalidID, id)
+		}
+		info, err := s.stat(id)
+		if err != nil {
+			if os.IsNotExist(err) {
+				skipped = append(skipped, id)
+				continue
+			}
+			return removed, skipped, err
+		}
+		if info.AgentUUID != ack.AgentUUID {
+			skipped = append(skipped, id)
+			continue
+		}
+		if err := os.Remove(info.Path); err != nil {
+			return removed, skipped, err
+		}
+		os.Remove(filepath.Join(s.dir, id+exportedExt))
+		removed = append(removed, id)
+	}
+	return removed, skipped, nil
+}
+
+// writeFileSync 先写临时文件并同步到磁盘再重命名，移动介质拔出前数据已落盘
+func writeFileSync(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// syncDir 同步目录项，确保重命名已落盘
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, os.ErrInvalid) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,308 @@
+// Package printjob 打印作业检测
+// 监控 CUPS 假脱机目录，对新提交的打印作业 (PDF/PostScript) 执行涉密检测，
+// 命中时按打印用户产生告警，并可取消作业；配合队列默认挂起 (job-hold-until) 可在打印前拦截
+package printjob
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// Action 命中后的处置方式
+type Action string
+
+const (
+	ActionLog    Action = "log"    // 仅告警
+	ActionCancel Action = "cancel" // 告警并取消作业
+)
+
+// Config 打印作业检测配置
+type Config struct {
+	// CUPS 假脱机目录
+	SpoolDir string
+	// 轮询间隔
+	PollInterval time.Duration
+	// 命中后的处置方式
+	Action Action
+	// 检测通过后释放挂起的作业 (队列需配置 job-hold-until-default=indefinite)
+	ReleaseClean bool
+	// 单个作业最大检测字节数，超过则跳过检测
+	MaxBytes int64
+}
+
+// Detector 内存内容检测接口 (由 detector.Manager 实现)
+type Detector interface {
+	DetectBytes(ctx context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// Controller 打印作业控制
+type Controller interface {
+	Cancel(jobID int) error
+	Release(jobID int) error
+}
+
+// AlertSink 告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// jobState 作业在轮询之间的状态
+type jobState struct {
+	size int64 // 上次轮询时的数据总大小，两次一致才认为写入完成
+	done bool
+}
+
+// Inspector 打印作业检测器
+type Inspector struct {
+	cfg        Config
+	detector   Detector
+	controller Controller
+	sink       AlertSink
+
+	mu   sync.Mutex
+	jobs map[int]*jobState
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewInspector 创建打印作业检测器，controller 为 nil 时使用 CUPS 命令行
+func NewInspector(cfg Config, detector Detector, controller Controller, sink AlertSink) *Inspector {
+	if cfg.SpoolDir == "" {
+		cfg.SpoolDir = "/var/spool/cups"
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Action == "" {
+		cfg.Action = ActionLog
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 100 << 20
+	}
+	if controller == nil {
+		controller = cupsController{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Inspector{
+		cfg:        cfg,
+		detector:   detector,
+		controller: controller,
+		sink:       sink,
+		jobs:       make(map[int]*jobState),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start 启动检测 (非阻塞)
+func (in *Inspector) Start() {
+	in.wg.Add(1)
+	go in.loop()
+	logger.Info("打印作业检测已启动", "spool_dir", in.cfg.SpoolDir, "action", in.cfg.Action)
+}
+
+// Stop 停止检测
+func (in *Inspector) Stop() {
+	in.cancel()
+	in.wg.Wait()
+}
+
+func (in *Inspector) loop() {
+	defer in.wg.Done()
+
+	ticker := time.NewTicker(in.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-in.ctx.Done():
+			return
+		case <-ticker.C:
+			in.poll(in.ctx)
+		}
+	}
+}
+
+// poll 扫描假脱机目录，检测写入完成的新作业
+func (in *Inspector) poll(ctx context.Context) {
+	current, err := listJobs(in.cfg.SpoolDir)
+	if err != nil {
+		logger.Debug("读取打印假脱机目录失败", "dir", in.cfg.SpoolDir, "error", err)
+		return
+	}
+
+	var ready []int
+	in.mu.Lock()
+	for id, files := range current {
+		size := totalSize(files)
+		st, ok := in.jobs[id]
+		if !ok {
+			in.jobs[id] = &jobState{size: size}
+			continue
+		}
+		if st.done {
+			continue
+		}
+		if size > 0 && size == st.size {
+			st.done = true
+			ready = append(ready, id)
+		}
+		st.size = size
+	}
+	// 已打印完成或被删除的作业
+	for id := range in.jobs {
+		if _, ok := current[id]; !ok {
+			delete(in.jobs, id)
+		}
+	}
+	in.mu.Unlock()
+
+	for _, id := range ready {
+		if ctx.Err() != nil {
+			return
+		}
+		in.inspect(ctx, id, current[id])
+	}
+}
+
+// inspect 检测单个作业
+func (in *Inspector) inspect(ctx context.Context, id int, files []string) {
+	job, err := loadJob(in.cfg.SpoolDir, id, files)
+	if err != nil {
+		logger.Warn("读取打印作业属性失败", "job_id", id, "error", err)
+		job = &Job{ID: id, DataFiles: files}
+	}
+
+	var hit bool
+	var record *model.AlertRecord
+	var logItem *model.AlertLogItem
+	for i, path := range job.DataFiles {
+		data, err := readLimited(path, in.cfg.MaxBytes)
+		if err != nil {
+			logger.Warn("读取打印作业数据失败", "job_id", id, "file", path, "error", err)
+			continue
+		}
+		hit, record, logItem, err = in.detector.DetectBytes(ctx, documentName(job, i), data)
+		if err != nil {
+			logger.Warn("打印作业检测失败", "job_id", id, "error", err)
+			continue
+		}
+		if hit && record != nil {
+			break
+		}
+	}
+
+	if !hit || record == nil {
+		if in.cfg.ReleaseClean {
+			if err := in.controller.Release(id); err != nil {
+				logger.Debug("释放打印作业失败", "job_id", id, "error", err)
+			}
+		}
+		return
+	}
+
+	action := in.cfg.Action
+	if action == ActionCancel {
+		if err := in.controller.Cancel(id); err != nil {
+			logger.Error("取消涉密打印作业失败", "job_id", id, "error", err)
+			action = ActionLog
+		}
+	}
+
+	in.fillRecord(record, job, action)
+	if logItem != nil {
+		logItem.FilePath = record.FilePath
+		logItem.FileName = record.FileName
+	}
+	logger.Warn("检测到涉密打印作业", "job_id", id, "user", job.User, "printer", job.Printer, "action", action)
+	in.sink(record, logItem)
+}
+
+// fillRecord 以打印作业信息覆盖告警记录
+func (in *Inspector) fillRecord(record *model.AlertRecord, job *Job, action Action) {
+	extend, _ := json.Marshal(map[string]interface{}{
+		"job_id":  job.ID,
+		"printer": job.Printer,
+		"host":    job.Host,
+		"format":  job.Format,
+		"action":  action,
+	})
+
+	record.AlertType = model.AlertTypePrint
+	record.FilePath = fmt.Sprintf("ipp://%s/%d", job.Printer, job.ID)
+	if job.Title != "" {
+		record.FileName = job.Title
+	}
+	// 告警归属于提交打印的用户，而非守护进程所在会话
+	if job.User != "" {
+		record.UserName = job.User
+		record.UserID = ""
+	}
+	record.ExtendFields = string(extend)
+}
+
+// documentName 生成检测用文件名，扩展名按文档格式确定
+func documentName(job *Job, index int) string {
+	ext := ".bin"
+	switch {
+	case job.Format == "application/pdf":
+		ext = ".pdf"
+	case job.Format == "application/postscript":
+		ext = ".ps"
+	case strings.HasPrefix(job.Format, "text/"):
+		ext = ".txt"
+	case strings.HasPrefix(job.Format, "image/"):
+		ext = "." + strings.TrimPrefix(job.Format, "image/")
+	}
+	return fmt.Sprintf("print-%d-%d%s", job.ID, index+1, ext)
+}
+
+// readLimited 读取文件，超过 limit 字节时返回错误
+func readLimited(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("document exceeds %d bytes", limit)
+	}
+	return data, nil
+}
+
+func totalSize(files []string) int64 {
+	var total int64
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			total += fi.Size()
+		}
+	}
+	return total
+}
+
+// cupsController 通过 CUPS 命令行控制作业
+type cupsController struct{}
+
+func (cupsController) Cancel(jobID int) error {
+	return exec.Command("cancel", strconv.Itoa(jobID)).Run()
+}
+
+func (cupsController) Release(jobID int) error {
+	return exec.Command("lp", "-i", strconv.Itoa(jobID), "-H", "resume").Run()
+}
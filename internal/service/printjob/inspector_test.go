@@ -0,0 +1,171 @@
+package printjob
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// buildControl 构造 IPP 编码的控制文件
+func buildControl(attrs [][3]string) []byte {
+	buf := []byte{0x02, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x02} // 版本/操作/请求号 + job 属性组
+	put := func(b []byte) {
+		var n [2]byte
+		binary.BigEndian.PutUint16(n[:], uint16(len(b)))
+		buf = append(buf, n[:]...)
+		buf = append(buf, b...)
+	}
+	for _, a := range attrs {
+		switch a[0] {
+		case "name":
+			buf = append(buf, ippTagName)
+		case "uri":
+			buf = append(buf, ippTagURI)
+		case "mime":
+			buf = append(buf, ippTagMimeType)
+		case "namelang":
+			buf = append(buf, ippTagNameLang)
+		}
+		put([]byte(a[1]))
+		if a[0] == "namelang" {
+			var v []byte
+			var n [2]byte
+			binary.BigEndian.PutUint16(n[:], 5)
+			v = append(v, n[:]...)
+			v = append(v, "zh-cn"...)
+			binary.BigEndian.PutUint16(n[:], uint16(len(a[2])))
+			v = append(v, n[:]...)
+			v = append(v, a[2]...)
+			put(v)
+			continue
+		}
+		put([]byte(a[2]))
+	}
+	return append(buf, ippTagEnd)
+}
+
+func writeJob(t *testing.T, dir string, id int, format, data string) {
+	t.Helper()
+	ctl := buildControl([][3]string{
+		{"name", "job-originating-user-name", "alice"},
+		{"name", "job-originating-host-name", "localhost"},
+		{"namelang", "job-name", "年度计划.pdf"},
+		{"uri", "job-printer-uri", "ipp://localhost/printers/Office"},
+		{"mime", "document-format", format},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "c"+padJobID(id)), ctl, 0600); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(dir, "d"+padJobID(id)+"-001")
+	if err := os.WriteFile(name, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadJob(t *testing.T) {
+	dir := t.TempDir()
+	writeJob(t, dir, 7, "application/pdf", "%PDF-1.4")
+
+	jobs, err := listJobs(dir)
+	if err != nil || len(jobs[7]) != 1 {
+		t.Fatalf("listJobs() = %v, %v", jobs, err)
+	}
+	job, err := loadJob(dir, 7, jobs[7])
+	if err != nil {
+		t.Fatalf("loadJob() error = %v", err)
+	}
+	if job.User != "alice" || job.Host != "localhost" || job.Title != "年度计划.pdf" ||
+		job.Printer != "Office" || job.Format != "application/pdf" {
+		t.Errorf("loadJob() = %+v", job)
+	}
+}
+
+type fakeDetector struct {
+	names []string
+}
+
+func (d *fakeDetector) DetectBytes(_ context.Context, name string, data []byte) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	d.names = append(d.names, name)
+	if strings.Contains(string(data), "绝密") {
+		return true, &model.AlertRecord{FilePath: name, UserName: "root"}, &model.AlertLogItem{FilePath: name}, nil
+	}
+	return false, nil, nil, nil
+}
+
+type fakeController struct {
+	cancelled, released []int
+}
+
+func (c *fakeController) Cancel(id int) error {
+	c.cancelled = append(c.cancelled, id)
+	return nil
+}
+
+func (c *fakeController) Release(id int) error {
+	c.released = append(c.released, id)
+	return nil
+}
+
+func TestInspector_Poll(t *testing.T) {
+	dir := t.TempDir()
+	det := &fakeDetector{}
+	ctl := &fakeController{}
+	var alerts []*model.AlertRecord
+	in := NewInspector(Config{SpoolDir: dir, Action: ActionCancel, ReleaseClean: true}, det, ctl,
+		func(r *model.AlertRecord, _ *model.AlertLogItem) { alerts = append(alerts, r) })
+	ctx := context.Background()
+
+	writeJob(t, dir, 1, "application/pdf", "%PDF 绝密★")
+	writeJob(t, dir, 2, "application/postscript", "%!PS 公开")
+
+	// 首次发现只记录大小，下一轮大小不变才检测
+	in.poll(ctx)
+	if len(det.names) != 0 {
+		t.Fatalf("首轮不应检测: %v", det.names)
+	}
+	in.poll(ctx)
+	in.poll(ctx) // 已检测的作业不重复检测
+	if len(det.names) != 2 {
+		t.Fatalf("检测次数 = %d, want 2", len(det.names))
+	}
+
+	if len(alerts) != 1 {
+		t.Fatalf("告警数 = %d, want 1", len(alerts))
+	}
+	a := alerts[0]
+	if a.AlertType != model.AlertTypePrint || a.UserName != "alice" || a.FileName != "年度计划.pdf" ||
+		a.FilePath != "ipp://Office/1" {
+		t.Errorf("告警 = %+v", a)
+	}
+	var extend map[string]interface{}
+	if err := json.Unmarshal([]byte(a.ExtendFields), &extend); err != nil || extend["action"] != "cancel" {
+		t.Errorf("ExtendFields = %s", a.ExtendFields)
+	}
+
+	if len(ctl.cancelled) != 1 || ctl.cancelled[0] != 1 {
+		t.Errorf("cancelled = %v", ctl.cancelled)
+	}
+	if len(ctl.released) != 1 || ctl.released[0] != 2 {
+		t.Errorf("released = %v", ctl.released)
+	}
+}
+
+func TestDocumentName(t *testing.T) {
+	cases := map[string]string{
+		"application/pdf":        "print-3-1.pdf",
+		"application/postscript": "print-3-1.ps",
+		"text/plain":             "print-3-1.txt",
+		"application/vnd.cups":   "print-3-1.bin",
+	}
+	for format, want := range cases {
+		if got := documentName(&Job{ID: 3, Format: format}, 0); got != want {
+			t.Errorf("documentName(%q) = %q, want %q", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package printjob
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==========================================
+// CUPS 假脱机目录解析
+// ==========================================
+//
+// /var/spool/cups 下每个作业对应:
+//   c<作业号>        控制文件 (IPP 编码的作业属性)
+//   d<作业号>-<序号>  文档数据 (PDF/PostScript 等)
+
+// Job 打印作业
+type Job struct {
+	ID        int
+	User      string   // job-originating-user-name
+	Host      string   // job-originating-host-name
+	Title     string   // job-name
+	Printer   string   // 目标打印机 (job-printer-uri 最后一段)
+	Format    string   // document-format
+	DataFiles []string // 文档数据文件
+}
+
+// IPP 属性值标签
+const (
+	ippTagEnd          = 0x03
+	ippTagMaxDelimiter = 0x0f
+	ippTagTextLang     = 0x35
+	ippTagNameLang     = 0x36
+	ippTagText         = 0x41
+	ippTagName         = 0x42
+	ippTagKeyword      = 0x44
+	ippTagURI          = 0x45
+	ippTagMimeType     = 0x49
+)
+
+var errShortControl = errors.New("printjob: truncated control file")
+
+// parseControl 解析 IPP 编码的控制文件，返回字符串类型属性的首个值
+func parseControl(data []byte) (map[string]string, error) {
+	// version(2) + operation/status(2) + request-id(4)
+	if len(data) < 8 {
+		return nil, errShortControl
+	}
+	attrs := make(map[string]string)
+	pos := 8
+	lastName := ""
+
+	for pos < len(data) {
+		tag := data[pos]
+		pos++
+		if tag == ippTagEnd {
+			return attrs, nil
+		}
+		if tag <= ippTagMaxDelimiter {
+			// 属性组分隔符
+			continue
+		}
+
+		if pos+2 > len(data) {
+			return attrs, errShortControl
+		}
+		nameLen := int(binary.BigEndian.Uint16(data[pos:]))
+		pos += 2
+		if pos+nameLen+2 > len(data) {
+			return attrs, errShortControl
+		}
+		name := string(data[pos : pos+nameLen])
+		pos += nameLen
+		valueLen := int(binary.BigEndian.Uint16(data[pos:]))
+		pos += 2
+		if pos+valueLen > len(data) {
+			return attrs, errShortControl
+		}
+		value := data[pos : pos+valueLen]
+		pos += valueLen
+
+		// 名称为空表示上一属性的附加值 (1setOf)，只保留首个值
+		if name == "" {
+			continue
+		}
+		lastName = name
+
+		switch tag {
+		case ippTagText, ippTagName, ippTagKeyword, ippTagURI, ippTagMimeType:
+			attrs[lastName] = string(value)
+		case ippTagTextLang, ippTagNameLang:
+			attrs[lastName] = decodeWithLanguage(value)
+		}
+	}
+	return attrs, nil
+}
+
+// decodeWithLanguage 解析 textWithLanguage/nameWithLanguage:
+// lang-len(2) + lang + text-len(2) + text
+func decodeWithLanguage(v []byte) string {
+	if len(v) < 2 {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(v))
+	if len(v) < 2+n+2 {
+		return ""
+	}
+	v = v[2+n:]
+	m := int(binary.BigEndian.Uint16(v))
+	if len(v) < 2+m {
+		return ""
+	}
+	return string(v[2 : 2+m])
+}
+
+// listJobs 列出假脱机目录中有文档数据的作业
+func listJobs(spoolDir string) (map[int][]string, error) {
+	entries, err := os.ReadDir(spoolDir)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(map[int][]string)
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasPrefix(name, "d") {
+			continue
+		}
+		idPart, _, ok := strings.Cut(name[1:], "-")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idPart)
+		if err != nil {
+			continue
+		}
+		jobs[id] = append(jobs[id], filepath.Join(spoolDir, name))
+	}
+	for id := range jobs {
+		sort.Strings(jobs[id])
+	}
+	return jobs, nil
+}
+
+// loadJob 读取作业控制文件
+func loadJob(spoolDir string, id int, dataFiles []string) (*Job, error) {
+	data, err := os.ReadFile(filepath.Join(spoolDir, "c"+padJobID(id)))
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := parseControl(data)
+	if err != nil && len(attrs) == 0 {
+		return nil, err
+	}
+
+	job := &Job{
+		ID:        id,
+		User:      attrs["job-originating-user-name"],
+		Host:      attrs["job-originating-host-name"],
+		Title:     attrs["job-name"],
+		Format:    attrs["document-format"],
+		DataFiles: dataFiles,
+	}
+	if uri := attrs["job-printer-uri"]; uri != "" {
+		job.Printer = uri[strings.LastIndex(uri, "/")+1:]
+	}
+	return job, nil
+}
+
+// padJobID CUPS 控制文件名中的作业号至少 5 位
+func padJobID(id int) string {
+	s := strconv.Itoa(id)
+	if len(s) < 5 {
+		s = strings.Repeat("0", 5-len(s)) + s
+	}
+	return s
+}
@@ -0,0 +1,174 @@
+// Package realtime 实时文件写入监控
+// 监控 scanner.watch_dirs 下的文件写入，写入完成后以实时优先级提交扫描。
+// 优先使用 fanotify (事件携带写入进程，告警可溯源到进程)；不支持或缺少 CAP_SYS_ADMIN 时回退到 fsnotify 递归监听
+package realtime
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"linuxFileWatcher/internal/detector"
+	"linuxFileWatcher/internal/fanotify"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/pathfilter"
+	detectorservice "linuxFileWatcher/internal/service/detector"
+	"linuxFileWatcher/internal/watcher"
+)
+
+// Submitter 提交扫描任务 (由 detectorservice.ScanQueue.Submit 实现)
+type Submitter func(task detectorservice.ScanTask) error
+
+// Config 监控配置
+type Config struct {
+	// 监控目录
+	Dirs []string
+	// 回退到 fsnotify 时观察到改名的回调 (from -> to)，可为 nil
+	OnRename func(from, to string)
+}
+
+// listener fanotify 事件来源
+type listener interface {
+	AddMount(path string, mask uint64) error
+	Run(ctx context.Context, handler func(fanotify.Event)) error
+	Close() error
+}
+
+// newListener 创建 fanotify 监听器，测试时可替换
+var newListener = func() (listener, error) {
+	l, err := fanotify.NewListener()
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Monitor 实时文件写入监控
+type Monitor struct {
+	cfg    Config
+	submit Submitter
+	dirs   []string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建监控器
+func New(cfg Config, submit Submitter) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	dirs := make([]string, 0, len(cfg.Dirs))
+	for _, d := range cfg.Dirs {
+		if abs, err := filepath.Abs(d); err == nil {
+			dirs = append(dirs, abs)
+		}
+	}
+	return &Monitor{cfg: cfg, submit: submit, dirs: dirs, ctx: ctx, cancel: cancel}
+}
+
+// Start 启动监控 (非阻塞)
+func (m *Monitor) Start() error {
+	if len(m.dirs) == 0 {
+		return errors.New("no watch dirs")
+	}
+	err := m.startFanotify()
+	if err == nil {
+		logger.Info("实时文件监控已启动", "backend", "fanotify", "dirs", m.dirs)
+		return nil
+	}
+	logger.Warn("fanotify 不可用，回退到 fsnotify (告警不含写入进程)", "error", err)
+
+	if err := m.startWatcher(); err != nil {
+		return err
+	}
+	logger.Info("实时文件监控已启动", "backend", "fsnotify", "dirs", m.dirs)
+	return nil
+}
+
+// Stop 停止监控
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// startFanotify 以挂载点为单位监听写入完成事件，事件按监控目录与路径过滤规则筛选
+func (m *Monitor) startFanotify() error {
+	l, err := newListener()
+	if err != nil {
+		return err
+	}
+	for _, dir := range m.dirs {
+		if err := l.AddMount(dir, fanotify.EventCloseWrite); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		if err := l.Run(m.ctx, m.handleFanotify); err != nil {
+			logger.Error("fanotify 监听中断", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (m *Monitor) handleFanotify(ev fanotify.Event) {
+	if !m.watched(ev.Path) || !pathfilter.Default().Allow(ev.Path) {
+		return
+	}
+	// 写入进程可能很快退出，入队前读取进程信息
+	m.enqueue(detectorservice.ScanTask{Path: ev.Path, Process: detector.LookupProcess(ev.PID)})
+}
+
+// startWatcher fsnotify 回退监控
+func (m *Monitor) startWatcher() error {
+	w, err := watcher.New(watcher.Options{})
+	if err != nil {
+		return err
+	}
+	for _, dir := range m.dirs {
+		if err := w.Add(dir); err != nil {
+			w.Close()
+			return err
+		}
+	}
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer w.Close()
+		if err := w.Run(m.ctx, m.handleWatcher); err != nil {
+			logger.Error("文件监控中断", "error", err)
+		}
+	}()
+	return nil
+}
+
+func (m *Monitor) handleWatcher(ev watcher.Event) {
+	if ev.Op.Has(watcher.OpRename) && m.cfg.OnRename != nil {
+		m.cfg.OnRename(ev.From, ev.Path)
+	}
+	m.enqueue(detectorservice.ScanTask{Path: ev.Path})
+}
+
+func (m *Monitor) enqueue(task detectorservice.ScanTask) {
+	task.Priority = detectorservice.PriorityRealtime
+	task.Source = "watcher"
+	if err := m.submit(task); err != nil {
+		logger.Warn("提交实时扫描任务失败", "path", task.Path, "error", err)
+	}
+}
+
+// watched path 是否位于监控目录下 (fanotify 按挂载点监听，挂载点上的其他目录需排除)
+func (m *Monitor) watched(path string) bool {
+	for _, dir := range m.dirs {
+		if path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
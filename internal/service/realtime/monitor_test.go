@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/fanotify"
+	detectorservice "linuxFileWatcher/internal/service/detector"
+)
+
+// fakeListener 由测试注入事件
+type fakeListener struct {
+	mounts []string
+	events chan fanotify.Event
+}
+
+func (l *fakeListener) AddMount(path string, mask uint64) error {
+	l.mounts = append(l.mounts, path)
+	return nil
+}
+
+func (l *fakeListener) Run(ctx context.Context, handler func(fanotify.Event)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev := <-l.events:
+			handler(ev)
+		}
+	}
+}
+
+func (l *fakeListener) Close() error { return nil }
+
+func TestMonitor_Fanotify(t *testing.T) {
+	fl := &fakeListener{events: make(chan fanotify.Event)}
+	old := newListener
+	newListener = func() (listener, error) { return fl, nil }
+	defer func() { newListener = old }()
+
+	dir := t.TempDir()
+	tasks := make(chan detectorservice.ScanTask, 4)
+	m := New(Config{Dirs: []string{dir}}, func(task detectorservice.ScanTask) error {
+		tasks <- task
+		return nil
+	})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	if len(fl.mounts) != 1 || fl.mounts[0] != dir {
+		t.Fatalf("mounts = %v", fl.mounts)
+	}
+
+	// 同一挂载点上监控目录以外的文件忽略
+	fl.events <- fanotify.Event{Path: filepath.Join(filepath.Dir(dir), "other.docx"), PID: os.Getpid(), Mask: fanotify.EventCloseWrite}
+	path := filepath.Join(dir, "报告.docx")
+	fl.events <- fanotify.Event{Path: path, PID: os.Getpid(), Mask: fanotify.EventCloseWrite}
+
+	select {
+	case task := <-tasks:
+		if task.Path != path || task.Priority != detectorservice.PriorityRealtime || task.Source != "watcher" {
+			t.Errorf("task = %+v", task)
+		}
+		if task.Process == nil || task.Process.PID != os.Getpid() {
+			t.Errorf("Process = %+v, want pid %d", task.Process, os.Getpid())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未提交扫描任务")
+	}
+	select {
+	case task := <-tasks:
+		t.Errorf("多余的任务 %+v", task)
+	default:
+	}
+}
@@ -0,0 +1,104 @@
+//go:build linux
+
+package removable
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"linuxFileWatcher/internal/mountinfo"
+)
+
+// sysRoot sysfs 挂载点，测试时可替换
+var sysRoot = "/sys"
+
+// IsRemovableDevice 判断挂载源是否为可移动块设备
+// 依据 /sys/class/block/<dev> 的 removable 标志，或设备挂在 USB 总线上 (移动硬盘 removable 通常为 0)
+func IsRemovableDevice(source string) bool {
+	if !strings.HasPrefix(source, "/dev/") {
+		return false
+	}
+
+	// /dev/disk/by-uuid/xxx 等符号链接解析为真实设备名
+	name := filepath.Base(source)
+	if real, err := filepath.EvalSymlinks(source); err == nil {
+		name = filepath.Base(real)
+	}
+
+	devPath, err := filepath.EvalSymlinks(filepath.Join(sysRoot, "class", "block", name))
+	if err != nil {
+		return false
+	}
+
+	// 分区需要查看所属磁盘
+	diskPath := devPath
+	if _, err := os.Stat(filepath.Join(devPath, "partition")); err == nil {
+		diskPath = filepath.Dir(devPath)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(diskPath, "removable")); err == nil {
+		if strings.TrimSpace(string(data)) == "1" {
+			return true
+		}
+	}
+	return strings.Contains(diskPath, "/usb")
+}
+
+// readMountTable 读取挂载表，测试时可替换
+var readMountTable = mountinfo.Read
+
+// optionFlags 挂载选项中需要在重新挂载时保留的 per-mount 标志
+var optionFlags = map[string]uintptr{
+	"nosuid":      syscall.MS_NOSUID,
+	"nodev":       syscall.MS_NODEV,
+	"noexec":      syscall.MS_NOEXEC,
+	"noatime":     syscall.MS_NOATIME,
+	"nodiratime":  syscall.MS_NODIRATIME,
+	"relatime":    syscall.MS_RELATIME,
+	"strictatime": syscall.MS_STRICTATIME,
+}
+
+// mountFlags 读取挂载点当前的 nosuid/nodev/noexec 等标志
+// MS_REMOUNT 只按传入的标志重新设置，未带上的限制会被清除 (如 U 盘上的 nosuid、noexec)
+func mountFlags(mountPoint string) (uintptr, error) {
+	var (
+		entry mountinfo.Entry
+		found bool
+	)
+	// 同一挂载点叠加挂载时以最后一条 (最上层) 为准
+	for _, e := range readMountTable() {
+		if e.MountPoint == mountPoint {
+			entry, found = e, true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("mount point %s not found in mountinfo", mountPoint)
+	}
+
+	var flags uintptr
+	for _, opt := range strings.Split(entry.Options, ",") {
+		flags |= optionFlags[opt]
+	}
+	return flags, nil
+}
+
+// remountReadOnly 将挂载点重新挂载为只读，保留原有的挂载标志
+func remountReadOnly(mountPoint string) error {
+	flags, err := mountFlags(mountPoint)
+	if err != nil {
+		return err
+	}
+	return syscall.Mount("", mountPoint, "", syscall.MS_REMOUNT|syscall.MS_RDONLY|flags, "")
+}
+
+// remountReadWrite 恢复读写，保留原有的挂载标志
+func remountReadWrite(mountPoint string) error {
+	flags, err := mountFlags(mountPoint)
+	if err != nil {
+		return err
+	}
+	return syscall.Mount("", mountPoint, "", syscall.MS_REMOUNT|flags, "")
+}
@@ -0,0 +1,83 @@
+//go:build linux
+
+package removable
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"linuxFileWatcher/internal/mountinfo"
+)
+
+func TestIsRemovableDevice(t *testing.T) {
+	root := t.TempDir()
+	old := sysRoot
+	sysRoot = root
+	defer func() { sysRoot = old }()
+
+	// 构造 sysfs: sdb 为 U 盘 (removable=1)，sdc 为 USB 移动硬盘，sda 为本地硬盘
+	devices := map[string]struct {
+		dir       string
+		removable string
+	}{
+		"sda": {"devices/pci0000:00/ata1/block/sda", "0"},
+		"sdb": {"devices/pci0000:00/usb1/1-1/block/sdb", "1"},
+		"sdc": {"devices/pci0000:00/usb2/2-1/block/sdc", "0"},
+	}
+	classDir := filepath.Join(root, "class", "block")
+	if err := os.MkdirAll(classDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, d := range devices {
+		disk := filepath.Join(root, d.dir)
+		part := filepath.Join(disk, name+"1")
+		if err := os.MkdirAll(part, 0755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(disk, "removable"), []byte(d.removable+"\n"), 0644)
+		_ = os.WriteFile(filepath.Join(part, "partition"), []byte("1\n"), 0644)
+		_ = os.Symlink(disk, filepath.Join(classDir, name))
+		_ = os.Symlink(part, filepath.Join(classDir, name+"1"))
+	}
+
+	tests := map[string]bool{
+		"/dev/sda1": false,
+		"/dev/sdb1": true,
+		"/dev/sdb":  true,
+		"/dev/sdc1": true,
+		"/dev/sdz1": false,
+		"server:/x": false,
+		"tmpfs":     false,
+	}
+	for source, want := range tests {
+		if got := IsRemovableDevice(source); got != want {
+			t.Errorf("IsRemovableDevice(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestMountFlags(t *testing.T) {
+	old := readMountTable
+	readMountTable = func() []mountinfo.Entry {
+		return []mountinfo.Entry{
+			{MountPoint: "/media/usb", Options: "rw,relatime"},
+			{MountPoint: "/media/usb", Options: "rw,nosuid,nodev,noexec,relatime"},
+		}
+	}
+	defer func() { readMountTable = old }()
+
+	flags, err := mountFlags("/media/usb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC | syscall.MS_RELATIME)
+	if flags != want {
+		t.Errorf("mountFlags() = %#x, want %#x", flags, want)
+	}
+
+	if _, err := mountFlags("/media/missing"); err == nil {
+		t.Error("未找到的挂载点应返回错误")
+	}
+}
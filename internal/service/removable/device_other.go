@@ -0,0 +1,20 @@
+//go:build !linux
+
+package removable
+
+import "errors"
+
+var errUnsupported = errors.New("当前平台不支持可移动介质控制")
+
+// IsRemovableDevice 非 Linux 平台无法识别可移动设备
+func IsRemovableDevice(source string) bool {
+	return false
+}
+
+func remountReadOnly(mountPoint string) error {
+	return errUnsupported
+}
+
+func remountReadWrite(mountPoint string) error {
+	return errUnsupported
+}
@@ -0,0 +1,300 @@
+// Package removable 可移动介质 (U 盘、移动硬盘、光盘) 挂载监控
+// 轮询挂载表发现新挂载的可移动介质，自动调用检测器扫描，并可在扫描通过前将介质置为只读
+package removable
+
+import (
+	"context"
+	"io/fs"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/mountinfo"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// Config 监控配置
+type Config struct {
+	// 挂载表轮询间隔
+	PollInterval time.Duration
+	// 发现新介质后自动扫描
+	AutoScan bool
+	// 扫描完成且未发现涉密文件前禁止写入 (remount ro)
+	BlockWriteUntilClean bool
+	// 单个介质扫描超时，0 表示不限
+	ScanTimeout time.Duration
+}
+
+// FileDetector 文件检测接口 (由 detector.Manager 实现)
+type FileDetector interface {
+	Detect(ctx context.Context, filePath string) (bool, *model.AlertRecord, *model.AlertLogItem, error)
+}
+
+// AlertSink 命中涉密文件时的告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// 介质扫描状态
+const (
+	StateScanning = "scanning"
+	StateClean    = "clean"
+	StateBlocked  = "blocked" // 发现涉密文件，保持只读
+	StateFailed   = "failed"
+	StateIgnored  = "ignored" // 未开启自动扫描
+)
+
+// MediaStatus 介质状态
+type MediaStatus struct {
+	MountPoint string    `json:"mount_point"`
+	Device     string    `json:"device"`
+	FSType     string    `json:"fs_type"`
+	State      string    `json:"state"`
+	ReadOnly   bool      `json:"read_only"` // 是否被本模块置为只读
+	Scanned    int64     `json:"scanned"`
+	Hits       int64     `json:"hits"`
+	MountedAt  time.Time `json:"mounted_at"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// MountMonitor 可移动介质挂载监控
+type MountMonitor struct {
+	cfg      Config
+	detector FileDetector
+	sink     AlertSink
+
+	// 以下函数便于测试替换
+	readMounts  func() []mountinfo.Entry
+	isRemovable func(source string) bool
+
+	mu    sync.Mutex
+	known map[string]mountinfo.Entry // 挂载点 -> 挂载信息
+	media map[string]*MediaStatus
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMountMonitor 创建监控器
+func NewMountMonitor(cfg Config, detector FileDetector, sink AlertSink) *MountMonitor {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MountMonitor{
+		cfg:         cfg,
+		detector:    detector,
+		sink:        sink,
+		readMounts:  mountinfo.Read,
+		isRemovable: IsRemovableDevice,
+		known:       make(map[string]mountinfo.Entry),
+		media:       make(map[string]*MediaStatus),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Start 启动监控 (非阻塞)
+// 启动时已存在的挂载视为基线，不触发扫描
+func (m *MountMonitor) Start() {
+	m.baseline()
+
+	m.wg.Add(1)
+	go m.loop()
+	logger.Info("可移动介质监控已启动", "poll_interval", m.cfg.PollInterval)
+}
+
+// Stop 停止监控并等待进行中的扫描退出
+func (m *MountMonitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// baseline 记录当前挂载表作为基线
+func (m *MountMonitor) baseline() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.readMounts() {
+		m.known[e.MountPoint] = e
+	}
+}
+
+func (m *MountMonitor) loop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.poll()
+		}
+	}
+}
+
+// poll 对比挂载表，处理新增与卸载的介质
+func (m *MountMonitor) poll() {
+	current := make(map[string]mountinfo.Entry)
+	for _, e := range m.readMounts() {
+		current[e.MountPoint] = e
+	}
+
+	var added []mountinfo.Entry
+
+	m.mu.Lock()
+	for mp, e := range current {
+		if old, ok := m.known[mp]; ok && old.Source == e.Source {
+			continue
+		}
+		if m.isRemovable(e.Source) {
+			added = append(added, e)
+		}
+	}
+	for mp := range m.known {
+		if _, ok := current[mp]; !ok {
+			if _, tracked := m.media[mp]; tracked {
+				logger.Info("可移动介质已卸载", "mount_point", mp)
+				delete(m.media, mp)
+			}
+		}
+	}
+	m.known = current
+	m.mu.Unlock()
+
+	// 同时对挂载点的 pathfilter 挂载缓存进行刷新
+	if len(added) > 0 {
+		pathfilter.Default().RefreshMounts()
+	}
+
+	for _, e := range added {
+		m.wg.Add(1)
+		go func(e mountinfo.Entry) {
+			defer m.wg.Done()
+			m.handleMount(e)
+		}(e)
+	}
+}
+
+// handleMount 处理新挂载的介质
+func (m *MountMonitor) handleMount(e mountinfo.Entry) {
+	status := &MediaStatus{
+		MountPoint: e.MountPoint,
+		Device:     e.Source,
+		FSType:     e.FSType,
+		State:      StateScanning,
+		MountedAt:  time.Now(),
+	}
+	m.mu.Lock()
+	m.media[e.MountPoint] = status
+	m.mu.Unlock()
+
+	logger.Info("发现可移动介质", "mount_point", e.MountPoint, "device", e.Source, "fs_type", e.FSType)
+
+	if !m.cfg.AutoScan {
+		m.update(e.MountPoint, func(s *MediaStatus) { s.State = StateIgnored })
+		return
+	}
+
+	if m.cfg.BlockWriteUntilClean && !e.ReadOnly() {
+		if err := remountReadOnly(e.MountPoint); err != nil {
+			logger.Warn("介质置为只读失败，继续扫描", "mount_point", e.MountPoint, "error", err)
+		} else {
+			m.update(e.MountPoint, func(s *MediaStatus) { s.ReadOnly = true })
+		}
+	}
+
+	scanned, hits, err := m.scan(e.MountPoint)
+
+	m.update(e.MountPoint, func(s *MediaStatus) {
+		s.Scanned = scanned
+		s.Hits = hits
+		s.FinishedAt = time.Now()
+		switch {
+		case hits > 0:
+			s.State = StateBlocked
+		case err != nil:
+			s.State = StateFailed
+			s.Error = err.Error()
+		default:
+			s.State = StateClean
+		}
+	})
+
+	// 扫描通过才恢复写入；发现涉密文件或扫描失败时保持只读
+	if hits == 0 && err == nil && m.isReadOnlyByUs(e.MountPoint) {
+		if rerr := remountReadWrite(e.MountPoint); rerr != nil {
+			logger.Error("介质恢复读写失败", "mount_point", e.MountPoint, "error", rerr)
+		} else {
+			m.update(e.MountPoint, func(s *MediaStatus) { s.ReadOnly = false })
+		}
+	}
+
+	logger.Info("可移动介质扫描完成",
+		"mount_point", e.MountPoint,
+		"scanned", scanned,
+		"hits", hits,
+		"error", err,
+	)
+}
+
+// scan 遍历介质并逐个检测
+func (m *MountMonitor) scan(root string) (scanned, hits int64, err error) {
+	ctx := m.ctx
+	if m.cfg.ScanTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.cfg.ScanTimeout)
+		defer cancel()
+	}
+
+	err = pathfilter.Default().Walk(root, func(path string, _ fs.DirEntry) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		isSecret, record, logItem, derr := m.detector.Detect(ctx, path)
+		scanned++
+		if derr != nil {
+			logger.Debug("介质文件检测失败", "path", path, "error", derr)
+			return nil
+		}
+		if isSecret {
+			hits++
+			if m.sink != nil {
+				m.sink(record, logItem)
+			}
+		}
+		return nil
+	})
+	return scanned, hits, err
+}
+
+func (m *MountMonitor) update(mountPoint string, fn func(*MediaStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok := m.media[mountPoint]; ok {
+		fn(s)
+	}
+}
+
+func (m *MountMonitor) isReadOnlyByUs(mountPoint string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.media[mountPoint]
+	return ok && s.ReadOnly
+}
+
+// Media 返回当前已挂载介质的状态快照
+func (m *MountMonitor) Media() []MediaStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]MediaStatus, 0, len(m.media))
+	for _, s := range m.media {
+		list = append(list, *s)
+	}
+	return list
+}
@@ -0,0 +1,87 @@
+package removable
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/mountinfo"
+)
+
+// fakeDetector 文件名含 "secret" 即判定为涉密
+type fakeDetector struct{}
+
+func (fakeDetector) Detect(_ context.Context, path string) (bool, *model.AlertRecord, *model.AlertLogItem, error) {
+	if strings.Contains(filepath.Base(path), "secret") {
+		return true, &model.AlertRecord{FilePath: path}, &model.AlertLogItem{FilePath: path}, nil
+	}
+	return false, nil, nil, nil
+}
+
+func TestMountMonitor_DetectAndScan(t *testing.T) {
+	usb := t.TempDir()
+	for _, f := range []string{"a.txt", "secret.docx"} {
+		if err := os.WriteFile(filepath.Join(usb, f), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var alerts []string
+	sink := func(r *model.AlertRecord, _ *model.AlertLogItem) {
+		mu.Lock()
+		alerts = append(alerts, r.FilePath)
+		mu.Unlock()
+	}
+
+	m := NewMountMonitor(Config{AutoScan: true}, fakeDetector{}, sink)
+	mounts := []mountinfo.Entry{{MountPoint: "/", FSType: "ext4", Source: "/dev/sda1"}}
+	m.readMounts = func() []mountinfo.Entry { return mounts }
+	m.isRemovable = func(source string) bool { return source == "/dev/sdb1" }
+
+	m.baseline()
+
+	// 插入 U 盘
+	mounts = append(mounts, mountinfo.Entry{MountPoint: usb, FSType: "vfat", Source: "/dev/sdb1"})
+	m.poll()
+	m.wg.Wait()
+
+	media := m.Media()
+	if len(media) != 1 {
+		t.Fatalf("Media() = %d, want 1", len(media))
+	}
+	if media[0].State != StateBlocked || media[0].Scanned != 2 || media[0].Hits != 1 {
+		t.Errorf("介质状态 = %+v", media[0])
+	}
+	if len(alerts) != 1 || filepath.Base(alerts[0]) != "secret.docx" {
+		t.Errorf("alerts = %v", alerts)
+	}
+
+	// 拔出 U 盘
+	mounts = mounts[:1]
+	m.poll()
+	if len(m.Media()) != 0 {
+		t.Error("卸载后介质状态应被清除")
+	}
+}
+
+func TestMountMonitor_IgnoresFixedDisks(t *testing.T) {
+	m := NewMountMonitor(Config{AutoScan: true}, fakeDetector{}, nil)
+	mounts := []mountinfo.Entry{}
+	m.readMounts = func() []mountinfo.Entry { return mounts }
+	m.isRemovable = func(string) bool { return false }
+
+	m.baseline()
+
+	mounts = append(mounts, mountinfo.Entry{MountPoint: "/data", FSType: "ext4", Source: "/dev/sdc1"})
+	m.poll()
+	m.wg.Wait()
+
+	if len(m.Media()) != 0 {
+		t.Error("固定磁盘不应被视为可移动介质")
+	}
+}
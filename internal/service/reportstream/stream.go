@@ -0,0 +1,503 @@
+// Package reportstream 基于 gRPC 双向流的上报通道
+// 上报批次经长连接发送，服务端逐批确认 (Ack)：未确认的批次在重连后按原批次 ID 重发，服务端按 ID 去重，
+// 从客户端看每个批次恰好送达一次；服务端可在同一通道下发指令 (立即扫描、规则更新)，无需等待心跳轮询
+package reportstream
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+)
+
+// MethodPath gRPC 方法路径
+const MethodPath = "/filewatcher.report.v1.ReportService/Stream"
+
+// 指令类型 (完整列表与载荷格式见 internal/service/command)
+const (
+	CommandRescan     = "rescan"      // 立即扫描
+	CommandRuleUpdate = "rule_update" // 规则更新
+)
+
+var (
+	// ErrClosed 通道已停止
+	ErrClosed = errors.New("reportstream: closed")
+	// ErrTooLarge 批次超过单条消息上限，应拆分后发送
+	ErrTooLarge = errors.New("reportstream: batch exceeds message size limit")
+)
+
+// RejectedError 服务端拒绝批次 (数据无效)，不应重发
+type RejectedError struct {
+	BatchID string
+	Message string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("reportstream: batch %s rejected: %s", e.BatchID, e.Message)
+}
+
+// Config 通道配置
+type Config struct {
+	// 服务端地址，如 https://server:8443
+	URL string
+	// 批次发出后等待确认的超时，超时视为连接失效并重连 (重连后重发)
+	AckTimeout time.Duration
+	// 已发送未确认的批次上限
+	MaxInFlight int
+	// 重连间隔，连续失败时加倍直到 MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// 连接建立后发送的身份信息
+	Hello Hello
+	// 服务端推送指令的执行器，为 nil 时拒绝全部指令
+	Execute CommandExecutor
+}
+
+// CommandExecutor 校验并执行服务端推送的指令，返回的消息随执行结果回传服务端
+// 通道本身不信任推送的指令：载荷须为签名指令，由执行器 (internal/service/command) 校验签名、目标终端、有效期与重放后执行
+type CommandExecutor func(ctx context.Context, cmd Command) (string, error)
+
+// Client 上报流式通道
+type Client struct {
+	cfg    Config
+	client *http.Client
+
+	mu      sync.Mutex
+	pending map[string]*pendingBatch
+	order   []*pendingBatch // 提交顺序，重连后按此顺序重发
+	results []CommandResult
+	closed  bool
+
+	wake      chan struct{}
+	connected atomic.Bool
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	now func() time.Time
+}
+
+type pendingBatch struct {
+	batch   Batch
+	msg     []byte
+	done    chan error
+	sent    bool
+	sentAt  time.Time
+	retryAt time.Time // 服务端要求稍后重发
+}
+
+// New 创建通道，client 需支持 HTTP/2 (TLS 连接默认协商 h2)，且不应设置整体超时
+func New(cfg Config, client *http.Client) *Client {
+	if cfg.AckTimeout <= 0 {
+		cfg.AckTimeout = 30 * time.Second
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = 16
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = 5 * time.Second
+	}
+	if cfg.MaxBackoff < cfg.Backoff {
+		cfg.MaxBackoff = 5 * time.Minute
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{
+		cfg:     cfg,
+		client:  client,
+		pending: make(map[string]*pendingBatch),
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+}
+
+// Start 在后台建立连接，断开后按退避间隔重连
+func (c *Client) Start() {
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Stop 断开连接，等待中的 Send 返回 ErrClosed (批次未确认，调用方下次按同一 ID 重发)
+func (c *Client) Stop() {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	for _, p := range c.order {
+		p.done <- ErrClosed
+	}
+	c.pending = make(map[string]*pendingBatch)
+	c.order = nil
+	c.mu.Unlock()
+
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Connected 当前是否已连接，未连接时调用方可改用 HTTP 上报
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// Send 发送批次并等待服务端确认
+// 连接断开时批次保留，重连后按原 ID 重发；服务端拒绝时返回 *RejectedError。
+// ctx 结束时放弃等待，之后的确认被忽略，调用方应按同一 ID 重发 (服务端去重)
+func (c *Client) Send(ctx context.Context, b Batch) error {
+	if b.ID == "" {
+		return fmt.Errorf("reportstream: batch id is required")
+	}
+	msg := wrap(fieldBatch, b.marshal())
+	if len(msg) > maxMessageSize {
+		return ErrTooLarge
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	p, ok := c.pending[b.ID]
+	if ok {
+		// 同一批次重复提交: 不重复发送，等待已有批次的确认
+		c.mu.Unlock()
+		return c.wait(ctx, p)
+	}
+	p = &pendingBatch{batch: b, msg: msg, done: make(chan error, 1)}
+	c.pending[b.ID] = p
+	c.order = append(c.order, p)
+	c.mu.Unlock()
+	c.signal()
+
+	return c.wait(ctx, p)
+}
+
+func (c *Client) wait(ctx context.Context, p *pendingBatch) error {
+	select {
+	case err := <-p.done:
+		// 同一批次可能有多个等待者
+		p.done <- err
+		return err
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		select {
+		case err := <-p.done:
+			p.done <- err
+			return err
+		default:
+		}
+		if c.pending[p.batch.ID] == p {
+			c.remove(p)
+		}
+		return ctx.Err()
+	}
+}
+
+// remove 移出待确认列表 (调用方持有 mu)
+func (c *Client) remove(p *pendingBatch) {
+	delete(c.pending, p.batch.ID)
+	for i, q := range c.order {
+		if q == p {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *Client) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Client) loop() {
+	defer c.wg.Done()
+
+	backoff := c.cfg.Backoff
+	for {
+		start := c.now()
+		err := c.session()
+		c.connected.Store(false)
+		if c.ctx.Err() != nil {
+			return
+		}
+		// 连接保持过一个确认周期视为曾正常工作，重置退避
+		if c.now().Sub(start) > c.cfg.AckTimeout {
+			backoff = c.cfg.Backoff
+		}
+		logger.Warn("上报流式通道断开，稍后重连", "url", c.cfg.URL, "retry_in", backoff, "error", err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-c.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.cfg.MaxBackoff)
+	}
+}
+
+// session 建立一次流式连接并收发消息，直到连接出错或通道停止
+func (c *Client) session() error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	c.mu.Lock()
+	for _, p := range c.order {
+		p.sent = false
+	}
+	c.mu.Unlock()
+
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(c.cfg.URL, "/")+MethodPath, pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("User-Agent", "linuxFileWatcher-reportstream")
+
+	// 先启动发送: 服务端可能在收到首条消息后才返回响应头
+	errc := make(chan error, 2)
+	go func() { errc <- c.writeLoop(ctx, pw) }()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		pw.CloseWithError(err)
+		<-errc
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		cancel()
+		pw.CloseWithError(err)
+		<-errc
+		return err
+	}
+
+	c.connected.Store(true)
+	logger.Info("上报流式通道已连接", "url", c.cfg.URL, "proto", resp.Proto)
+	go func() { errc <- c.readLoop(resp) }()
+
+	err = <-errc
+	cancel()
+	pw.CloseWithError(err)
+	resp.Body.Close()
+	<-errc
+	return err
+}
+
+// checkResponse 检查响应头，服务端直接以错误结束的流 (trailers-only) 在响应头中带有 grpc-status
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("reportstream: unexpected status %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/grpc") {
+		return fmt.Errorf("reportstream: unexpected content type %q", ct)
+	}
+	return grpcStatus(resp.Header)
+}
+
+// grpcStatus 将 grpc-status 转换为错误，0 或不存在时返回 nil
+func grpcStatus(h http.Header) error {
+	s := h.Get("Grpc-Status")
+	if s == "" || s == "0" {
+		return nil
+	}
+	code, _ := strconv.Atoi(s)
+	return fmt.Errorf("reportstream: grpc status %d: %s", code, h.Get("Grpc-Message"))
+}
+
+// writeLoop 先发送 Hello，之后发送未发出的批次与指令执行结果；检查确认超时
+func (c *Client) writeLoop(ctx context.Context, w io.Writer) error {
+	if err := writeFrame(w, wrap(fieldHello, c.cfg.Hello.marshal())); err != nil {
+		return err
+	}
+
+	tick := time.NewTicker(min(time.Second, c.cfg.AckTimeout/2))
+	defer tick.Stop()
+	for {
+		msgs, err := c.collect()
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if err := writeFrame(w, msg); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.wake:
+		case <-tick.C:
+		}
+	}
+}
+
+// collect 取出待发送的消息，标记批次为已发送；有批次确认超时时返回错误
+func (c *Client) collect() ([][]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	var msgs [][]byte
+	for _, r := range c.results {
+		msgs = append(msgs, wrap(fieldCommandResult, r.marshal()))
+	}
+	c.results = nil
+
+	inflight := 0
+	for _, p := range c.order {
+		if p.sent {
+			if now.Sub(p.sentAt) > c.cfg.AckTimeout {
+				return nil, fmt.Errorf("reportstream: batch %s not acknowledged within %v", p.batch.ID, c.cfg.AckTimeout)
+			}
+			inflight++
+		}
+	}
+	for _, p := range c.order {
+		if inflight >= c.cfg.MaxInFlight {
+			break
+		}
+		if p.sent || now.Before(p.retryAt) {
+			continue
+		}
+		p.sent, p.sentAt = true, now
+		msgs = append(msgs, p.msg)
+		inflight++
+	}
+	return msgs, nil
+}
+
+// readLoop 处理服务端的确认与指令，流结束时返回 trailer 中的 gRPC 状态
+// 指令在通道的生命周期内执行，不随本次连接断开而取消
+func (c *Client) readLoop(resp *http.Response) error {
+	for {
+		msg, err := readFrame(resp.Body)
+		if err == io.EOF {
+			// trailer 在读到响应体结尾后才可用
+			if err := grpcStatus(resp.Trailer); err != nil {
+				return err
+			}
+			return errors.New("reportstream: stream closed by server")
+		}
+		if err != nil {
+			return err
+		}
+
+		num, body, err := oneof(msg)
+		if err != nil {
+			return err
+		}
+		switch num {
+		case fieldAck:
+			var ack Ack
+			if err := ack.unmarshal(body); err != nil {
+				return err
+			}
+			c.ack(ack)
+		case fieldCommand:
+			var cmd Command
+			if err := cmd.unmarshal(body); err != nil {
+				return err
+			}
+			c.wg.Add(1)
+			go c.dispatch(c.ctx, cmd)
+		}
+	}
+}
+
+// ack 处理批次确认；未知或已放弃等待的批次忽略
+func (c *Client) ack(ack Ack) {
+	c.mu.Lock()
+	p, ok := c.pending[ack.BatchID]
+	if !ok {
+		c.mu.Unlock()
+		return
+	}
+	switch ack.Status {
+	case AckRetry:
+		p.sent = false
+		p.retryAt = c.now().Add(c.cfg.Backoff)
+		c.mu.Unlock()
+		logger.Debug("服务端要求稍后重发批次", "batch_id", ack.BatchID, "message", ack.Message)
+		return
+	case AckRejected:
+		c.remove(p)
+		c.mu.Unlock()
+		p.done <- &RejectedError{BatchID: ack.BatchID, Message: ack.Message}
+	default:
+		c.remove(p)
+		c.mu.Unlock()
+		p.done <- nil
+	}
+	c.signal()
+}
+
+// dispatch 执行指令并回传结果，结果在连接断开后随下次连接发送
+func (c *Client) dispatch(ctx context.Context, cmd Command) {
+	defer c.wg.Done()
+
+	res := CommandResult{CommandID: cmd.ID, Success: true}
+	if c.cfg.Execute == nil {
+		res.Success, res.Message = false, "command execution is not enabled"
+	} else if msg, err := c.cfg.Execute(ctx, cmd); err != nil {
+		res.Success, res.Message = false, err.Error()
+	} else {
+		res.Message = msg
+	}
+	logger.Info("执行服务端指令", "command_id", cmd.ID, "type", cmd.Type, "success", res.Success, "message", res.Message)
+
+	c.mu.Lock()
+	c.results = append(c.results, res)
+	c.mu.Unlock()
+	c.signal()
+}
+
+// BatchID 由上报类型与数据标识 (如记录 ID) 生成稳定的批次 ID，同一批数据重启后重发仍得到相同 ID
+func BatchID(endpoint string, keys ...string) string {
+	h := sm3.New()
+	h.Write([]byte(endpoint))
+	for _, k := range keys {
+		h.Write([]byte{0})
+		h.Write([]byte(k))
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// ==========================================
+// 全局实例
+// ==========================================
+
+var defaultClient atomic.Pointer[Client]
+
+// SetDefault 设置全局通道 (由主程序按配置设置，上报模块通过 Default 使用)
+func SetDefault(c *Client) {
+	defaultClient.Store(c)
+}
+
+// Default 返回全局通道，未启用时为 nil
+func Default() *Client {
+	return defaultClient.Load()
+}
@@ -0,0 +1,206 @@
+package reportstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWire(t *testing.T) {
+	b := Batch{ID: "b1", Endpoint: "/api/v1/alert", Items: [][]byte{[]byte(`{"id":"a1"}`), {}, []byte(`{"id":"a2"}`)}}
+	num, body, err := oneof(wrap(fieldBatch, b.marshal()))
+	if err != nil || num != fieldBatch {
+		t.Fatalf("oneof = %d, %v", num, err)
+	}
+	var got Batch
+	if err := got.unmarshal(body); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != b.ID || got.Endpoint != b.Endpoint || len(got.Items) != 3 || string(got.Items[2]) != `{"id":"a2"}` {
+		t.Errorf("batch = %+v", got)
+	}
+
+	// 未知字段 (含定长类型) 跳过
+	ack := Ack{BatchID: "b1", Status: AckRejected, Message: "bad"}
+	data := append(ack.marshal(), 0x39, 1, 2, 3, 4, 5, 6, 7, 8) // field 7, fixed64
+	var a Ack
+	if err := a.unmarshal(data); err != nil || a != ack {
+		t.Errorf("ack = %+v, %v", a, err)
+	}
+	if err := a.unmarshal([]byte{0x0a, 10, 'x'}); err == nil {
+		t.Error("truncated message accepted")
+	}
+}
+
+// fakeServer 按 report.proto 实现的服务端
+type fakeServer struct {
+	mu       sync.Mutex
+	sessions int
+	hello    Hello
+	stored   map[string]int // 批次 ID -> 收到次数
+	results  []CommandResult
+	retried  bool
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || r.URL.Path != MethodPath || r.Header.Get("Content-Type") != "application/grpc" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.sessions++
+	session := s.sessions
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	flush := func() { w.(http.Flusher).Flush() }
+	flush()
+
+	send := func(field int, msg []byte) {
+		writeFrame(w, wrap(field, msg))
+		flush()
+	}
+	for {
+		msg, err := readFrame(r.Body)
+		if err != nil {
+			w.Header().Set("Grpc-Status", "0")
+			return
+		}
+		num, body, _ := oneof(msg)
+		switch num {
+		case fieldHello:
+			s.mu.Lock()
+			s.hello.unmarshal(body)
+			s.mu.Unlock()
+			if session == 1 {
+				send(fieldCommand, (&Command{ID: "c1", Type: CommandRescan, Payload: []byte(`{"paths":["/home"]}`)}).marshal())
+				send(fieldCommand, (&Command{ID: "c2", Type: "reboot"}).marshal())
+			}
+		case fieldCommandResult:
+			var res CommandResult
+			res.unmarshal(body)
+			s.mu.Lock()
+			s.results = append(s.results, res)
+			s.mu.Unlock()
+		case fieldBatch:
+			var b Batch
+			b.unmarshal(body)
+			s.mu.Lock()
+			s.stored[b.ID]++
+			retry := b.ID == "later" && !s.retried
+			if retry {
+				s.retried = true
+			}
+			s.mu.Unlock()
+
+			switch {
+			case b.ID == "lost" && session == 1:
+				// 已收到但确认前断开，客户端重连后重发
+				w.Header().Set("Grpc-Status", "14")
+				w.Header().Set("Grpc-Message", "unavailable")
+				return
+			case b.ID == "bad":
+				send(fieldAck, (&Ack{BatchID: b.ID, Status: AckRejected, Message: "invalid"}).marshal())
+			case retry:
+				send(fieldAck, (&Ack{BatchID: b.ID, Status: AckRetry}).marshal())
+			default:
+				send(fieldAck, (&Ack{BatchID: b.ID}).marshal())
+			}
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	fs := &fakeServer{stored: make(map[string]int)}
+	srv := httptest.NewUnstartedServer(fs)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	rescanned := make(chan string, 1)
+	c := New(Config{
+		URL:        srv.URL,
+		AckTimeout: 5 * time.Second,
+		Backoff:    10 * time.Millisecond,
+		Hello:      Hello{AgentUUID: "u1", ClientID: "c1", SchemaVersion: 2},
+		Execute: func(_ context.Context, cmd Command) (string, error) {
+			if cmd.Type != CommandRescan {
+				return "", errors.New("unsupported command: " + cmd.Type)
+			}
+			rescanned <- string(cmd.Payload)
+			return "submitted", nil
+		},
+	}, srv.Client())
+	c.Start()
+	defer c.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 指令在同一通道下发，结果回传
+	select {
+	case p := <-rescanned:
+		if p != `{"paths":["/home"]}` {
+			t.Errorf("rescan payload = %s", p)
+		}
+	case <-ctx.Done():
+		t.Fatal("rescan command not dispatched")
+	}
+
+	// 确认前断开: 重连后按原 ID 重发
+	if err := c.Send(ctx, Batch{ID: "lost", Endpoint: "/api/v1/alert", Items: [][]byte{[]byte(`{}`)}}); err != nil {
+		t.Fatalf("Send(lost) = %v", err)
+	}
+	if err := c.Send(ctx, Batch{ID: "later"}); err != nil {
+		t.Fatalf("Send(later) = %v", err)
+	}
+	var rejected *RejectedError
+	if err := c.Send(ctx, Batch{ID: "bad"}); !errors.As(err, &rejected) || rejected.Message != "invalid" {
+		t.Fatalf("Send(bad) = %v", err)
+	}
+	if !c.Connected() {
+		t.Error("not connected")
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.sessions != 2 || fs.stored["lost"] != 2 || fs.stored["later"] != 2 || fs.stored["bad"] != 1 {
+		t.Errorf("sessions = %d, stored = %v", fs.sessions, fs.stored)
+	}
+	if fs.hello.AgentUUID != "u1" || fs.hello.SchemaVersion != 2 {
+		t.Errorf("hello = %+v", fs.hello)
+	}
+	if len(fs.results) != 2 {
+		t.Fatalf("results = %+v", fs.results)
+	}
+	for _, r := range fs.results {
+		if (r.CommandID == "c1") != r.Success {
+			t.Errorf("result = %+v", r)
+		}
+	}
+}
+
+func TestClient_RejectsCommandsWithoutExecutor(t *testing.T) {
+	c := New(Config{}, nil)
+	c.wg.Add(1)
+	c.dispatch(context.Background(), Command{ID: "c1", Type: CommandRescan})
+	if len(c.results) != 1 || c.results[0].Success {
+		t.Errorf("results = %+v", c.results)
+	}
+}
+
+func TestBatchID(t *testing.T) {
+	a := BatchID("/api/v1/alert", "1", "2")
+	if a != BatchID("/api/v1/alert", "1", "2") || len(a) != 32 {
+		t.Errorf("BatchID not stable: %s", a)
+	}
+	if a == BatchID("/api/v1/alert", "12") || a == BatchID("/api/v1/audit", "1", "2") {
+		t.Error("BatchID collision")
+	}
+}
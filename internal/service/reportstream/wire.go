@@ -0,0 +1,335 @@
+package reportstream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ==========================================
+// 消息编解码 (protobuf 线格式，字段定义见 report.proto)
+// ==========================================
+//
+// 消息只有字符串、字节、布尔与枚举字段，按 protobuf 线格式手工编解码，不引入 gRPC 与 protobuf 依赖；
+// 未知字段跳过，服务端新增字段不影响旧客户端。
+
+// AckStatus 批次确认状态
+type AckStatus int
+
+const (
+	AckOK       AckStatus = 0 // 已持久化 (含重复批次)
+	AckRetry    AckStatus = 1 // 服务端暂时无法处理，稍后重发
+	AckRejected AckStatus = 2 // 数据无效，不再重发
+)
+
+// Hello 连接建立后的首条消息
+type Hello struct {
+	AgentUUID     string
+	ClientID      string
+	Version       string
+	SchemaVersion int
+}
+
+// Batch 一批上报数据
+type Batch struct {
+	// 批次 ID，重发时不变，服务端据此去重 (可用 BatchID 由数据标识生成)
+	ID string
+	// 上报类型，与 HTTP 上报的接口路径一致
+	Endpoint string
+	// 每条上报数据的 JSON
+	Items [][]byte
+}
+
+// CommandResult 指令执行结果
+type CommandResult struct {
+	CommandID string
+	Success   bool
+	Message   string
+}
+
+// Ack 服务端对批次的确认
+type Ack struct {
+	BatchID string
+	Status  AckStatus
+	Message string
+}
+
+// Command 服务端下发的指令
+type Command struct {
+	ID      string
+	Type    string
+	Payload []byte
+}
+
+// agentMessage / serverMessage 的 oneof 字段编号
+const (
+	fieldHello         = 1
+	fieldBatch         = 2
+	fieldCommandResult = 3
+
+	fieldAck     = 1
+	fieldCommand = 2
+)
+
+// protobuf 线类型
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// maxMessageSize 单条消息上限，与 gRPC 默认的接收上限一致
+const maxMessageSize = 4 << 20
+
+var errMalformed = errors.New("reportstream: malformed message")
+
+func appendVarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+func appendTag(b []byte, field, wire int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendString proto3 默认值 (空字符串) 不编码
+func appendString(b []byte, field int, v string) []byte {
+	if v == "" {
+		return b
+	}
+	return appendBytesField(b, field, []byte(v))
+}
+
+func appendUint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, v)
+}
+
+func (h *Hello) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, h.AgentUUID)
+	b = appendString(b, 2, h.ClientID)
+	b = appendString(b, 3, h.Version)
+	b = appendUint(b, 4, uint64(h.SchemaVersion))
+	return b
+}
+
+func (m *Batch) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, m.ID)
+	b = appendString(b, 2, m.Endpoint)
+	for _, item := range m.Items {
+		b = appendBytesField(b, 3, item)
+	}
+	return b
+}
+
+func (r *CommandResult) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, r.CommandID)
+	if r.Success {
+		b = appendUint(b, 2, 1)
+	}
+	b = appendString(b, 3, r.Message)
+	return b
+}
+
+func (a *Ack) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, a.BatchID)
+	b = appendUint(b, 2, uint64(a.Status))
+	b = appendString(b, 3, a.Message)
+	return b
+}
+
+func (c *Command) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, c.ID)
+	b = appendString(b, 2, c.Type)
+	if len(c.Payload) > 0 {
+		b = appendBytesField(b, 3, c.Payload)
+	}
+	return b
+}
+
+// wrap 将消息放入外层消息的 oneof 字段
+func wrap(field int, msg []byte) []byte {
+	return appendBytesField(nil, field, msg)
+}
+
+// field 解码出的字段
+type field struct {
+	num    int
+	wire   int
+	varint uint64
+	bytes  []byte
+}
+
+// rangeFields 依次解码消息中的字段，未知的定长字段同样返回，由调用方忽略
+func rangeFields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errMalformed
+		}
+		b = b[n:]
+		f := field{num: int(tag >> 3), wire: int(tag & 7)}
+		switch f.wire {
+		case wireVarint:
+			if f.varint, n = binary.Uvarint(b); n <= 0 {
+				return errMalformed
+			}
+			b = b[n:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return errMalformed
+			}
+			f.bytes = b[n : n+int(l)]
+			b = b[n+int(l):]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errMalformed
+			}
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errMalformed
+			}
+			b = b[4:]
+		default:
+			return errMalformed
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Hello) unmarshal(b []byte) error {
+	return rangeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			h.AgentUUID = string(f.bytes)
+		case 2:
+			h.ClientID = string(f.bytes)
+		case 3:
+			h.Version = string(f.bytes)
+		case 4:
+			h.SchemaVersion = int(f.varint)
+		}
+		return nil
+	})
+}
+
+func (m *Batch) unmarshal(b []byte) error {
+	return rangeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			m.ID = string(f.bytes)
+		case 2:
+			m.Endpoint = string(f.bytes)
+		case 3:
+			m.Items = append(m.Items, append([]byte(nil), f.bytes...))
+		}
+		return nil
+	})
+}
+
+func (r *CommandResult) unmarshal(b []byte) error {
+	return rangeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			r.CommandID = string(f.bytes)
+		case 2:
+			r.Success = f.varint != 0
+		case 3:
+			r.Message = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (a *Ack) unmarshal(b []byte) error {
+	return rangeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			a.BatchID = string(f.bytes)
+		case 2:
+			a.Status = AckStatus(f.varint)
+		case 3:
+			a.Message = string(f.bytes)
+		}
+		return nil
+	})
+}
+
+func (c *Command) unmarshal(b []byte) error {
+	return rangeFields(b, func(f field) error {
+		switch f.num {
+		case 1:
+			c.ID = string(f.bytes)
+		case 2:
+			c.Type = string(f.bytes)
+		case 3:
+			c.Payload = append([]byte(nil), f.bytes...)
+		}
+		return nil
+	})
+}
+
+// oneof 取出外层消息中设置的 oneof 字段
+func oneof(b []byte) (int, []byte, error) {
+	num, body := 0, []byte(nil)
+	err := rangeFields(b, func(f field) error {
+		if f.wire == wireBytes {
+			num, body = f.num, f.bytes
+		}
+		return nil
+	})
+	return num, body, err
+}
+
+// ==========================================
+// gRPC 消息帧: 1 字节压缩标志 + 4 字节长度 (大端) + 消息
+// ==========================================
+
+func writeFrame(w io.Writer, msg []byte) error {
+	hdr := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+	_, err := w.Write(append(hdr, msg...))
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if hdr[0] != 0 {
+		return nil, fmt.Errorf("reportstream: compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxMessageSize {
+		return nil, fmt.Errorf("reportstream: message too large (%d bytes)", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
@@ -0,0 +1,131 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/xattrlabel"
+)
+
+// ==========================================
+// 内置处置动作
+// ==========================================
+
+// errNotLocalFile 检测对象不是本地文件 (剪贴板、打印作业等)，无法隔离/阻断
+var errNotLocalFile = errors.New("detected object is not a local file")
+
+// Quarantine 返回隔离动作：将文件移入 dir，仅 root 可读
+// 隔离后的路径写入告警扩展字段 quarantine_path
+func Quarantine(dir string) Handler {
+	return func(_ context.Context, record *model.AlertRecord, _ Decision) error {
+		src := record.FilePath
+		if !filepath.IsAbs(src) {
+			return errNotLocalFile
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+
+		id := record.ID
+		if id == "" {
+			id = strconv.FormatInt(time.Now().UnixNano(), 10)
+		}
+		dst := filepath.Join(dir, id+"_"+filepath.Base(src))
+		if err := moveFile(src, dst); err != nil {
+			return err
+		}
+		if err := os.Chmod(dst, 0400); err != nil {
+			return err
+		}
+
+		record.AddExtendFields(map[string]interface{}{"quarantine_path": dst})
+		return nil
+	}
+}
+
+// Block 返回阻断动作：撤销文件的全部访问权限 (root 以外的用户无法再读写)
+// 原权限写入告警扩展字段 blocked_mode，便于管理员核实后恢复
+func Block() Handler {
+	return func(_ context.Context, record *model.AlertRecord, _ Decision) error {
+		path := record.FilePath
+		if !filepath.IsAbs(path) {
+			return errNotLocalFile
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(path, 0); err != nil {
+			return err
+		}
+		record.AddExtendFields(map[string]interface{}{
+			"blocked_mode": fmt.Sprintf("%04o", fi.Mode().Perm()),
+		})
+		return nil
+	}
+}
+
+// Label 返回标注动作：将判定的密级、策略与检测模块写入文件扩展属性，供 samba、备份软件等下游工具按标签处理
+// 来源记为 xattrlabel.SourceAgent，检测时不会把本程序写回的标签当作外部标注
+func Label() Handler {
+	return func(_ context.Context, record *model.AlertRecord, _ Decision) error {
+		path := record.FilePath
+		if !filepath.IsAbs(path) {
+			return errNotLocalFile
+		}
+		level := model.SecretLevel(record.FileLevel)
+		if xattrlabel.LevelName(level) == "" {
+			// 未给出密级的命中 (如公文版式) 按内部标注
+			level = model.LevelInternal
+		}
+		err := xattrlabel.Write(path, xattrlabel.Label{
+			Level:  level,
+			RuleID: record.RuleID,
+			Module: record.DetectModule,
+			Source: xattrlabel.SourceAgent,
+			Time:   time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		record.AddExtendFields(map[string]interface{}{"xattr_label": xattrlabel.LevelName(level)})
+		return nil
+	}
+}
+
+// moveFile 移动文件，跨文件系统时复制后删除原文件
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,101 @@
+// Package response 检测结果处置策略
+// 按 (检测模块, 策略 ID 区间, 密级, 路径) 将 Manager.Detect 的命中结果映射为处置动作：
+// 记录日志、告警、隔离、阻断、通知用户。规则来自配置文件，配置重载时热更新
+package response
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// AlertSink 告警回调
+type AlertSink func(record *model.AlertRecord, logItem *model.AlertLogItem)
+
+// Handler 处置动作实现，d 为本次处置决策；返回的错误仅记录日志，不影响后续动作
+type Handler func(ctx context.Context, record *model.AlertRecord, d Decision) error
+
+// Engine 处置策略引擎
+type Engine struct {
+	policy atomic.Pointer[Policy]
+
+	mu       sync.RWMutex
+	handlers map[Action]Handler
+}
+
+// NewEngine 创建处置策略引擎
+// 隔离/阻断/通知动作需通过 Handle 注册实现，未注册的动作仅记录日志
+func NewEngine(p *Policy) *Engine {
+	e := &Engine{handlers: make(map[Action]Handler)}
+	e.SetPolicy(p)
+	return e
+}
+
+// SetPolicy 替换处置规则 (配置重载时调用)，正在处置的结果不受影响
+func (e *Engine) SetPolicy(p *Policy) {
+	if p == nil {
+		p = &Policy{Default: []Action{ActionAlert}}
+	}
+	e.policy.Store(p)
+}
+
+// Handle 注册动作实现
+func (e *Engine) Handle(action Action, h Handler) {
+	e.mu.Lock()
+	e.handlers[action] = h
+	e.mu.Unlock()
+}
+
+// Evaluate 对检测结果求值
+func (e *Engine) Evaluate(record *model.AlertRecord) Decision {
+	return e.policy.Load().Evaluate(record)
+}
+
+// Apply 求值并依次执行处置动作，alert 动作交给 sink
+func (e *Engine) Apply(ctx context.Context, record *model.AlertRecord, logItem *model.AlertLogItem, sink AlertSink) Decision {
+	d := e.Evaluate(record)
+
+	for _, a := range d.Actions {
+		switch a {
+		case ActionAlert:
+			record.AddExtendFields(map[string]interface{}{
+				"actions":       d.Actions,
+				"response_rule": d.Rule,
+			})
+			sink(record, logItem)
+		case ActionLog:
+			logger.Warn("检测命中", "path", record.FilePath, "module", record.DetectModule,
+				"rule_id", record.RuleID, "level", record.FileLevel, "response_rule", d.Rule)
+		default:
+			e.mu.RLock()
+			h := e.handlers[a]
+			e.mu.RUnlock()
+			if h == nil {
+				logger.Warn("处置动作未实现", "action", a, "path", record.FilePath)
+				continue
+			}
+			if err := h(ctx, record, d); err != nil {
+				logger.Error("执行处置动作失败", "action", a, "path", record.FilePath, "error", err)
+			}
+		}
+	}
+	return d
+}
+
+// Wrap 包装告警回调，检测结果经处置策略处理后，需要告警的再交给 sink
+// e 为 nil (未启用处置策略) 时原样返回 sink
+func (e *Engine) Wrap(sink AlertSink) func(*model.AlertRecord, *model.AlertLogItem) {
+	if e == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		if record == nil {
+			sink(record, logItem)
+			return
+		}
+		e.Apply(context.Background(), record, logItem, sink)
+	}
+}
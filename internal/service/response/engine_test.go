@@ -0,0 +1,174 @@
+package response
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/xattrlabel"
+)
+
+func mustCompile(t *testing.T, specs []RuleSpec, defaults []string) *Policy {
+	t.Helper()
+	p, err := Compile(specs, defaults)
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	return p
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	p := mustCompile(t, []RuleSpec{
+		{
+			Name:     "top-secret",
+			Modules:  []string{model.ModuleSecretLevelDetect},
+			MinLevel: "机密",
+			Actions:  []string{"alert", "quarantine"},
+		},
+		{
+			Name:    "hash-range",
+			RuleIDs: []string{"1000-1999", "42"},
+			Paths:   []string{"/home/**"},
+			Actions: []string{"notify", "BLOCK", "alert"},
+		},
+	}, []string{"log"})
+
+	tests := []struct {
+		name   string
+		record model.AlertRecord
+		rule   string
+		want   []Action
+	}{
+		{"绝密文件", model.AlertRecord{DetectModule: model.ModuleSecretLevelDetect, FileLevel: int(model.LevelTopSecret)}, "top-secret", []Action{ActionQuarantine, ActionAlert}},
+		{"机密文件", model.AlertRecord{DetectModule: model.ModuleSecretLevelDetect, FileLevel: int(model.LevelSecret)}, "top-secret", []Action{ActionQuarantine, ActionAlert}},
+		{"秘密文件低于下限", model.AlertRecord{DetectModule: model.ModuleSecretLevelDetect, FileLevel: int(model.LevelConfidential)}, "", []Action{ActionLog}},
+		{"区间内策略", model.AlertRecord{RuleID: 1500, FilePath: "/home/u/a.doc"}, "hash-range", []Action{ActionBlock, ActionNotify, ActionAlert}},
+		{"单个策略 ID", model.AlertRecord{RuleID: 42, FilePath: "/home/u/a.doc"}, "hash-range", []Action{ActionBlock, ActionNotify, ActionAlert}},
+		{"路径不匹配", model.AlertRecord{RuleID: 1500, FilePath: "/tmp/a.doc"}, "", []Action{ActionLog}},
+		{"区间外", model.AlertRecord{RuleID: 2000, FilePath: "/home/u/a.doc"}, "", []Action{ActionLog}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := p.Evaluate(&tt.record)
+			if d.Rule != tt.rule || !equalActions(d.Actions, tt.want) {
+				t.Errorf("Evaluate() = %+v, want rule %q actions %v", d, tt.rule, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	bad := []RuleSpec{
+		{Name: "no-actions"},
+		{Name: "bad-action", Actions: []string{"delete"}},
+		{Name: "bad-level", MinLevel: "最高", Actions: []string{"alert"}},
+		{Name: "bad-range", RuleIDs: []string{"9-1"}, Actions: []string{"alert"}},
+		{Name: "bad-glob", Paths: []string{"/a/[b"}, Actions: []string{"alert"}},
+	}
+	for _, spec := range bad {
+		if _, err := Compile([]RuleSpec{spec}, nil); err == nil || !strings.Contains(err.Error(), spec.Name) {
+			t.Errorf("Compile(%s) error = %v", spec.Name, err)
+		}
+	}
+
+	p := mustCompile(t, nil, nil)
+	if d := p.Evaluate(&model.AlertRecord{}); !equalActions(d.Actions, []Action{ActionAlert}) {
+		t.Errorf("默认动作 = %v, want [alert]", d.Actions)
+	}
+}
+
+func TestEngine_ApplyAndReload(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.docx")
+	if err := os.WriteFile(src, []byte("绝密"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	qdir := filepath.Join(dir, "quarantine")
+
+	e := NewEngine(mustCompile(t, []RuleSpec{{
+		Name:    "isolate",
+		Paths:   []string{"*.docx"},
+		Actions: []string{"alert", "quarantine"},
+	}}, nil))
+	e.Handle(ActionQuarantine, Quarantine(qdir))
+
+	var alerts []*model.AlertRecord
+	sink := e.Wrap(func(r *model.AlertRecord, _ *model.AlertLogItem) { alerts = append(alerts, r) })
+
+	sink(&model.AlertRecord{ID: "1", FilePath: src}, nil)
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("原文件应已移走, stat err = %v", err)
+	}
+	dst := filepath.Join(qdir, "1_a.docx")
+	if fi, err := os.Stat(dst); err != nil || fi.Mode().Perm() != 0400 {
+		t.Fatalf("隔离文件 stat = %v, %v", fi, err)
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0].ExtendFields, dst) || !strings.Contains(alerts[0].ExtendFields, `"response_rule":"isolate"`) {
+		t.Errorf("告警 = %+v", alerts)
+	}
+
+	// 热更新后新规则生效
+	e.SetPolicy(mustCompile(t, nil, []string{"log"}))
+	sink(&model.AlertRecord{ID: "2", FilePath: "/x.docx"}, nil)
+	if len(alerts) != 1 {
+		t.Errorf("规则更新为仅记录日志后不应告警, got %d", len(alerts))
+	}
+}
+
+func TestBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "b.txt")
+	if err := os.WriteFile(path, nil, 0640); err != nil {
+		t.Fatal(err)
+	}
+	record := &model.AlertRecord{FilePath: path}
+	if err := Block()(context.Background(), record, Decision{}); err != nil {
+		t.Fatal(err)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0 {
+		t.Errorf("权限 = %v, want 0", fi.Mode().Perm())
+	}
+	if !strings.Contains(record.ExtendFields, `"blocked_mode":"0640"`) {
+		t.Errorf("ExtendFields = %s", record.ExtendFields)
+	}
+
+	if err := Block()(context.Background(), &model.AlertRecord{FilePath: "clipboard://x"}, Decision{}); err != errNotLocalFile {
+		t.Errorf("非本地文件 error = %v", err)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.docx")
+	if err := os.WriteFile(path, nil, 0640); err != nil {
+		t.Fatal(err)
+	}
+	record := &model.AlertRecord{FilePath: path, FileLevel: int(model.LevelTopSecret), RuleID: 42, DetectModule: model.ModuleKeywordDetect}
+	if err := Label()(context.Background(), record, Decision{}); err != nil {
+		t.Skip("无法写入扩展属性:", err)
+	}
+	l, err := xattrlabel.Read(path)
+	if err != nil || l == nil || l.Level != model.LevelTopSecret || l.RuleID != 42 || l.Module != model.ModuleKeywordDetect || l.Source != xattrlabel.SourceAgent {
+		t.Errorf("xattrlabel.Read() = %+v, %v", l, err)
+	}
+	if !strings.Contains(record.ExtendFields, `"xattr_label":"绝密"`) {
+		t.Errorf("ExtendFields = %s", record.ExtendFields)
+	}
+
+	if err := Label()(context.Background(), &model.AlertRecord{FilePath: "clipboard://x"}, Decision{}); err != errNotLocalFile {
+		t.Errorf("非本地文件 error = %v", err)
+	}
+}
+
+func equalActions(a, b []Action) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
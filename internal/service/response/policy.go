@@ -0,0 +1,274 @@
+package response
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// ==========================================
+// 处置策略规则
+// ==========================================
+
+// Action 处置动作
+type Action string
+
+const (
+	ActionLog        Action = "log"        // 仅记录本地日志
+	ActionAlert      Action = "alert"      // 产生告警 (写入存储并上报)
+	ActionQuarantine Action = "quarantine" // 将文件移入隔离目录
+	ActionBlock      Action = "block"      // 撤销文件的全部访问权限
+	ActionNotify     Action = "notify"     // 桌面通知当前用户
+	ActionEvidence   Action = "evidence"   // 加密留存文件副本或命中摘录作为证据
+	ActionLabel      Action = "label"      // 将判定的密级写入文件扩展属性 (security.dlp.*)
+)
+
+// actionOrder 动作执行顺序：先留存证据与标注 (隔离会移走文件)，再处置文件，最后通知与告警，告警中可带上处置结果
+var actionOrder = []Action{ActionEvidence, ActionLabel, ActionBlock, ActionQuarantine, ActionNotify, ActionAlert, ActionLog}
+
+// RuleSpec 处置规则 (配置文件格式)
+// 条件之间为 "与" 关系，未设置的条件视为匹配
+type RuleSpec struct {
+	Name string
+	// 检测模块 (model.Module*)，如 secret_level_detect
+	Modules []string
+	// 命中策略 ID，单个 "42" 或区间 "1000-1999"
+	RuleIDs []string
+	// 最低密级: 内部/秘密/机密/绝密
+	MinLevel string
+	// 文件路径 glob，语义同路径过滤规则
+	Paths []string
+	// 处置动作
+	Actions []string
+}
+
+// Rule 编译后的处置规则
+type Rule struct {
+	Name     string
+	modules  map[string]bool
+	ruleIDs  []idRange
+	minLevel int // 密级严重程度，0 表示不限
+	paths    []*regexp.Regexp
+	Actions  []Action
+}
+
+type idRange struct{ lo, hi int64 }
+
+// Policy 一组按顺序匹配的处置规则，首条命中的规则生效
+type Policy struct {
+	Rules   []*Rule
+	Default []Action // 没有规则命中时的动作
+}
+
+// Decision 处置决策
+type Decision struct {
+	Rule    string // 命中的规则名，未命中时为空
+	Actions []Action
+}
+
+// Has 决策是否包含指定动作
+func (d Decision) Has(a Action) bool {
+	for _, x := range d.Actions {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Compile 编译处置规则
+// defaults 为空时未命中任何规则的检测结果只产生告警
+func Compile(specs []RuleSpec, defaults []string) (*Policy, error) {
+	p := &Policy{}
+
+	var err error
+	if len(defaults) == 0 {
+		p.Default = []Action{ActionAlert}
+	} else if p.Default, err = parseActions(defaults); err != nil {
+		return nil, fmt.Errorf("default_actions: %w", err)
+	}
+
+	for i, spec := range specs {
+		r, err := compileRule(spec)
+		if err != nil {
+			name := spec.Name
+			if name == "" {
+				name = "#" + strconv.Itoa(i+1)
+			}
+			return nil, fmt.Errorf("处置规则 %s: %w", name, err)
+		}
+		p.Rules = append(p.Rules, r)
+	}
+	return p, nil
+}
+
+func compileRule(spec RuleSpec) (*Rule, error) {
+	r, err := CompileMatch(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.Actions) == 0 {
+		return nil, fmt.Errorf("未配置处置动作")
+	}
+	actions, err := parseActions(spec.Actions)
+	if err != nil {
+		return nil, err
+	}
+	r.Actions = actions
+	return r, nil
+}
+
+// CompileMatch 只编译规则的匹配条件 (忽略 Actions)，供按同样条件筛选告警的模块使用
+func CompileMatch(spec RuleSpec) (*Rule, error) {
+	r := &Rule{Name: spec.Name}
+
+	if len(spec.Modules) > 0 {
+		r.modules = make(map[string]bool, len(spec.Modules))
+		for _, m := range spec.Modules {
+			r.modules[strings.TrimSpace(m)] = true
+		}
+	}
+
+	for _, s := range spec.RuleIDs {
+		rg, err := parseIDRange(s)
+		if err != nil {
+			return nil, err
+		}
+		r.ruleIDs = append(r.ruleIDs, rg)
+	}
+
+	if spec.MinLevel != "" {
+		sev, ok := levelSeverityByName[strings.TrimSpace(spec.MinLevel)]
+		if !ok {
+			return nil, fmt.Errorf("未知密级 %q", spec.MinLevel)
+		}
+		r.minLevel = sev
+	}
+
+	for _, g := range spec.Paths {
+		re, err := pathfilter.CompileGlob(g)
+		if err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, re)
+	}
+	return r, nil
+}
+
+// Evaluate 对检测结果求值，返回首条命中规则的动作
+func (p *Policy) Evaluate(record *model.AlertRecord) Decision {
+	for _, r := range p.Rules {
+		if r.Match(record) {
+			return Decision{Rule: r.Name, Actions: r.Actions}
+		}
+	}
+	return Decision{Actions: p.Default}
+}
+
+// Match 规则是否匹配检测结果
+func (r *Rule) Match(record *model.AlertRecord) bool {
+	if r.modules != nil && !r.modules[record.DetectModule] {
+		return false
+	}
+	if len(r.ruleIDs) > 0 {
+		ok := false
+		for _, rg := range r.ruleIDs {
+			if record.RuleID >= rg.lo && record.RuleID <= rg.hi {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if r.minLevel > 0 && levelSeverity(model.SecretLevel(record.FileLevel)) < r.minLevel {
+		return false
+	}
+	if len(r.paths) > 0 {
+		ok := false
+		for _, re := range r.paths {
+			if re.MatchString(record.FilePath) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// levelSeverityByName 密级名称对应的严重程度，数值越大越严重
+var levelSeverityByName = map[string]int{
+	string(model.SecretLevelInternal):     1,
+	string(model.SecretLevelSecret):       2,
+	string(model.SecretLevelConfidential): 3,
+	string(model.SecretLevelTopSecret):    4,
+}
+
+// levelSeverity model.SecretLevel 按数值从绝密到内部递减，转换为严重程度
+func levelSeverity(l model.SecretLevel) int {
+	switch l {
+	case model.LevelTopSecret:
+		return 4
+	case model.LevelSecret: // 机密
+		return 3
+	case model.LevelConfidential: // 秘密
+		return 2
+	case model.LevelInternal:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseIDRange 解析 "42" 或 "1000-1999"
+func parseIDRange(s string) (idRange, error) {
+	s = strings.TrimSpace(s)
+	lo, hi, isRange := strings.Cut(s, "-")
+	from, err := strconv.ParseInt(strings.TrimSpace(lo), 10, 64)
+	if err != nil {
+		return idRange{}, fmt.Errorf("无效的策略 ID %q", s)
+	}
+	if !isRange {
+		return idRange{from, from}, nil
+	}
+	to, err := strconv.ParseInt(strings.TrimSpace(hi), 10, 64)
+	if err != nil || to < from {
+		return idRange{}, fmt.Errorf("无效的策略 ID 区间 %q", s)
+	}
+	return idRange{from, to}, nil
+}
+
+// parseActions 解析动作列表，按 actionOrder 排序并去重
+func parseActions(names []string) ([]Action, error) {
+	set := make(map[Action]bool, len(names))
+	for _, n := range names {
+		a := Action(strings.ToLower(strings.TrimSpace(n)))
+		known := false
+		for _, k := range actionOrder {
+			if a == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("未知处置动作 %q", n)
+		}
+		set[a] = true
+	}
+	actions := make([]Action, 0, len(set))
+	for _, a := range actionOrder {
+		if set[a] {
+			actions = append(actions, a)
+		}
+	}
+	return actions, nil
+}
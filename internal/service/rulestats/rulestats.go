@@ -0,0 +1,352 @@
+// Package rulestats 检测规则命中统计与误报反馈
+// 每条告警按检测模块与规则 ID 计入命中次数，分析人员通过本机接口按告警 ID 标记误报，
+// 由此得到每条规则的准确率 (1 - 误报/命中)。命中足够多且准确率过低的规则被标记 (flagged) 并上报管理端；
+// 开启自动降级时准确率更低的规则被降级 (demoted)，其命中仍计数但不再产生告警，直到规则修正后重置统计
+package rulestats
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+)
+
+// 规则状态
+const (
+	StatusNormal  = "normal"
+	StatusFlagged = "flagged"
+	StatusDemoted = "demoted"
+)
+
+// 默认配置
+const (
+	DefaultMinSamples      = 20
+	DefaultFlagPrecision   = 0.8
+	DefaultDemotePrecision = 0.5
+	DefaultRetention       = 30 * 24 * time.Hour
+)
+
+// pruneInterval 两次清理过期告警索引的最小间隔
+const pruneInterval = time.Hour
+
+var (
+	// ErrAlertNotFound 告警不存在或已超过保留时间
+	ErrAlertNotFound = errors.New("rulestats: alert not found")
+	// ErrRuleNotFound 规则没有统计记录
+	ErrRuleNotFound = errors.New("rulestats: rule not found")
+)
+
+// Config 统计配置
+type Config struct {
+	// 判定规则质量所需的最少命中次数，<=0 时使用 DefaultMinSamples
+	MinSamples int
+	// 准确率低于该值时标记规则，<=0 时使用 DefaultFlagPrecision
+	FlagPrecision float64
+	// 准确率低于该值时降级规则 (仅 AutoDemote 时)，<=0 时使用 DefaultDemotePrecision
+	DemotePrecision float64
+	// 是否自动降级规则
+	AutoDemote bool
+	// 告警索引保留时间，超过后不能再标记误报，<=0 时使用 DefaultRetention
+	Retention time.Duration
+}
+
+// StatStore 规则统计持久化，storage.KeyedStore[model.RuleStat] 实现该接口
+type StatStore interface {
+	Put(key string, item model.RuleStat) error
+	Delete(key string) error
+	LoadAll() ([]model.RuleStat, error)
+}
+
+// AlertStore 告警索引持久化，storage.KeyedStore[model.RuleAlertRef] 实现该接口
+type AlertStore interface {
+	Put(key string, item model.RuleAlertRef) error
+	Delete(key string) error
+	LoadAll() ([]model.RuleAlertRef, error)
+}
+
+// Notify 规则被标记或降级时的回调，在锁外调用
+type Notify func(model.RuleStat)
+
+// AlertSink 告警回调
+type AlertSink func(*model.AlertRecord, *model.AlertLogItem)
+
+// Tracker 规则统计，可并发使用
+type Tracker struct {
+	cfg    Config
+	stats  StatStore
+	alerts AlertStore
+	notify Notify
+
+	mu     sync.Mutex
+	byRule map[string]*model.RuleStat
+	refs   map[string]model.RuleAlertRef // 告警 ID -> 规则
+	pruned time.Time
+}
+
+// New 创建规则统计，store 非 nil 时加载已持久化的统计与告警索引
+func New(cfg Config, stats StatStore, alerts AlertStore, notify Notify) *Tracker {
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = DefaultMinSamples
+	}
+	if cfg.FlagPrecision <= 0 {
+		cfg.FlagPrecision = DefaultFlagPrecision
+	}
+	if cfg.DemotePrecision <= 0 {
+		cfg.DemotePrecision = DefaultDemotePrecision
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+	t := &Tracker{
+		cfg:    cfg,
+		stats:  stats,
+		alerts: alerts,
+		notify: notify,
+		byRule: make(map[string]*model.RuleStat),
+		refs:   make(map[string]model.RuleAlertRef),
+	}
+
+	if stats != nil {
+		items, err := stats.LoadAll()
+		if err != nil {
+			logger.Warn("读取规则命中统计失败", "error", err)
+		}
+		for i := range items {
+			t.byRule[ruleKey(items[i].Module, items[i].RuleID)] = &items[i]
+		}
+	}
+	if alerts != nil {
+		items, err := alerts.LoadAll()
+		if err != nil {
+			logger.Warn("读取规则告警索引失败", "error", err)
+		}
+		for _, r := range items {
+			t.refs[r.AlertID] = r
+		}
+	}
+	t.prune(time.Now())
+	return t
+}
+
+// ruleKey 规则的存储键
+func ruleKey(module string, ruleID int64) string {
+	return fmt.Sprintf("%s/%d", module, ruleID)
+}
+
+// Precision 规则准确率，没有命中时为 1
+func Precision(s model.RuleStat) float64 {
+	if s.Hits <= 0 {
+		return 1
+	}
+	return float64(s.Hits-s.FalsePositives) / float64(s.Hits)
+}
+
+// Record 记录一条告警的命中，返回规则是否已被降级 (降级规则的告警不计入告警索引)
+func (t *Tracker) Record(record *model.AlertRecord, now time.Time) bool {
+	if record == nil || record.DetectModule == "" {
+		return false
+	}
+
+	t.mu.Lock()
+	if now.Sub(t.pruned) >= pruneInterval {
+		t.prune(now)
+	}
+
+	key := ruleKey(record.DetectModule, record.RuleID)
+	s, ok := t.byRule[key]
+	if !ok {
+		s = &model.RuleStat{Module: record.DetectModule, RuleID: record.RuleID, Status: StatusNormal}
+		t.byRule[key] = s
+	}
+	s.Hits++
+	s.LastHit = now.Unix()
+	changed := t.update(s, now)
+	t.putStat(key, *s)
+	out := *s
+
+	if s.Status != StatusDemoted && record.ID != "" {
+		ref := model.RuleAlertRef{AlertID: record.ID, Module: s.Module, RuleID: s.RuleID, Time: now.Unix()}
+		t.refs[ref.AlertID] = ref
+		if t.alerts != nil {
+			if err := t.alerts.Put(ref.AlertID, ref); err != nil {
+				logger.Warn("保存规则告警索引失败", "alert", ref.AlertID, "error", err)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	if changed {
+		t.notifyChange(out)
+	}
+	return out.Status == StatusDemoted
+}
+
+// Wrap 包装告警回调：记录命中，降级规则的告警不再传递给 sink
+func (t *Tracker) Wrap(sink AlertSink) func(*model.AlertRecord, *model.AlertLogItem) {
+	if t == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		if t.Record(record, time.Now()) {
+			logger.Info("规则已降级，告警不上报", "module", record.DetectModule, "rule_id", record.RuleID, "alert", record.ID)
+			return
+		}
+		sink(record, logItem)
+	}
+}
+
+// Mark 标记或取消标记告警为误报，返回更新后的规则统计
+// 准确率变化导致规则被标记或降级时调用 Notify
+func (t *Tracker) Mark(alertID string, falsePositive bool, now time.Time) (model.RuleStat, error) {
+	t.mu.Lock()
+	ref, ok := t.refs[alertID]
+	if !ok {
+		t.mu.Unlock()
+		return model.RuleStat{}, ErrAlertNotFound
+	}
+	key := ruleKey(ref.Module, ref.RuleID)
+	s, ok := t.byRule[key]
+	if !ok {
+		t.mu.Unlock()
+		return model.RuleStat{}, ErrRuleNotFound
+	}
+	if ref.FalsePositive == falsePositive {
+		out := *s
+		t.mu.Unlock()
+		return out, nil
+	}
+
+	ref.FalsePositive = falsePositive
+	t.refs[alertID] = ref
+	if t.alerts != nil {
+		if err := t.alerts.Put(alertID, ref); err != nil {
+			logger.Warn("保存规则告警索引失败", "alert", alertID, "error", err)
+		}
+	}
+	if falsePositive {
+		s.FalsePositives++
+	} else if s.FalsePositives > 0 {
+		s.FalsePositives--
+	}
+
+	changed := t.update(s, now)
+	t.putStat(key, *s)
+	out := *s
+	t.mu.Unlock()
+
+	if changed {
+		t.notifyChange(out)
+	}
+	return out, nil
+}
+
+// update 重新判定规则状态，返回状态是否变化，需持有锁
+func (t *Tracker) update(s *model.RuleStat, now time.Time) bool {
+	status := t.evaluate(s)
+	if status == s.Status {
+		return false
+	}
+	s.Status = status
+	s.StatusChanged = now.Unix()
+	return true
+}
+
+// notifyChange 规则被标记或降级时回调，恢复正常不回调
+func (t *Tracker) notifyChange(s model.RuleStat) {
+	if s.Status != StatusNormal && t.notify != nil {
+		t.notify(s)
+	}
+}
+
+// evaluate 按准确率判定规则状态，已降级的规则保持降级直到 Reset
+func (t *Tracker) evaluate(s *model.RuleStat) string {
+	if s.Status == StatusDemoted {
+		return StatusDemoted
+	}
+	if s.Hits < int64(t.cfg.MinSamples) {
+		return StatusNormal
+	}
+	p := Precision(*s)
+	switch {
+	case t.cfg.AutoDemote && p < t.cfg.DemotePrecision:
+		return StatusDemoted
+	case p < t.cfg.FlagPrecision:
+		return StatusFlagged
+	default:
+		return StatusNormal
+	}
+}
+
+// Reset 清除规则的统计与状态 (规则修正后恢复告警)，已记录的告警不能再标记误报
+func (t *Tracker) Reset(module string, ruleID int64) error {
+	key := ruleKey(module, ruleID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.byRule[key]; !ok {
+		return ErrRuleNotFound
+	}
+	delete(t.byRule, key)
+	for id, ref := range t.refs {
+		if ref.Module == module && ref.RuleID == ruleID {
+			t.deleteRef(id)
+		}
+	}
+	if t.stats != nil {
+		return t.stats.Delete(key)
+	}
+	return nil
+}
+
+// List 全部规则统计，按检测模块与规则 ID 排序
+func (t *Tracker) List() []model.RuleStat {
+	t.mu.Lock()
+	out := make([]model.RuleStat, 0, len(t.byRule))
+	for _, s := range t.byRule {
+		out = append(out, *s)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Module != out[j].Module {
+			return out[i].Module < out[j].Module
+		}
+		return out[i].RuleID < out[j].RuleID
+	})
+	return out
+}
+
+// putStat 持久化规则统计，需持有锁
+func (t *Tracker) putStat(key string, s model.RuleStat) {
+	if t.stats == nil {
+		return
+	}
+	if err := t.stats.Put(key, s); err != nil {
+		logger.Warn("保存规则命中统计失败", "rule", key, "error", err)
+	}
+}
+
+// deleteRef 删除告警索引，需持有锁
+func (t *Tracker) deleteRef(alertID string) {
+	delete(t.refs, alertID)
+	if t.alerts != nil {
+		if err := t.alerts.Delete(alertID); err != nil {
+			logger.Warn("删除规则告警索引失败", "alert", alertID, "error", err)
+		}
+	}
+}
+
+// prune 删除超过保留时间的告警索引，需持有锁 (New 中除外)
+func (t *Tracker) prune(now time.Time) {
+	t.pruned = now
+	expire := now.Add(-t.cfg.Retention).Unix()
+	for id, ref := range t.refs {
+		if ref.Time < expire {
+			t.deleteRef(id)
+		}
+	}
+}
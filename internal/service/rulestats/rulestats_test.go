@@ -0,0 +1,166 @@
+package rulestats
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// memStore 内存键值存储
+type memStore[T any] struct {
+	mu    sync.Mutex
+	items map[string]T
+}
+
+func newMemStore[T any]() *memStore[T] {
+	return &memStore[T]{items: make(map[string]T)}
+}
+
+func (s *memStore[T]) Put(key string, item T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[key] = item
+	return nil
+}
+
+func (s *memStore[T]) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+func (s *memStore[T]) LoadAll() ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]T, 0, len(s.items))
+	for _, v := range s.items {
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func alert(id, module string, ruleID int64) *model.AlertRecord {
+	return &model.AlertRecord{ID: id, DetectModule: module, RuleID: ruleID}
+}
+
+func TestTracker_FlagAndPersist(t *testing.T) {
+	stats, alerts := newMemStore[model.RuleStat](), newMemStore[model.RuleAlertRef]()
+	var notified []model.RuleStat
+	tr := New(Config{MinSamples: 10}, stats, alerts, func(s model.RuleStat) { notified = append(notified, s) })
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		tr.Record(alert(fmt.Sprintf("k%d", i), model.ModuleKeywordDetect, 41), now)
+	}
+	tr.Record(alert("h1", model.ModuleMD5Detect, 41), now)
+
+	// 标记两条误报: 准确率 80%，未低于阈值
+	for _, id := range []string{"k0", "k1"} {
+		if _, err := tr.Mark(id, true, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 重复标记不重复计数
+	s, _ := tr.Mark("k1", true, now)
+	if s.FalsePositives != 2 || s.Status != StatusNormal || len(notified) != 0 {
+		t.Fatalf("after 2 false positives = %+v, notified %d", s, len(notified))
+	}
+
+	s, err := tr.Mark("k2", true, now)
+	if err != nil || s.Status != StatusFlagged || Precision(s) != 0.7 {
+		t.Fatalf("after 3 false positives = %+v, %v", s, err)
+	}
+	if len(notified) != 1 || notified[0].RuleID != 41 || notified[0].Module != model.ModuleKeywordDetect {
+		t.Errorf("notified = %+v", notified)
+	}
+
+	// 取消标记后恢复正常，不回调
+	if s, _ := tr.Mark("k2", false, now); s.Status != StatusNormal || s.FalsePositives != 2 || len(notified) != 1 {
+		t.Errorf("unmark = %+v, notified %d", s, len(notified))
+	}
+	if _, err := tr.Mark("missing", true, now); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("Mark(missing) = %v", err)
+	}
+
+	// 重启后恢复统计与告警索引
+	tr = New(Config{MinSamples: 10}, stats, alerts, nil)
+	list := tr.List()
+	if len(list) != 2 || list[0].Module != model.ModuleKeywordDetect || list[0].Hits != 10 || list[1].Hits != 1 {
+		t.Fatalf("List() after restart = %+v", list)
+	}
+	if s, err := tr.Mark("k3", true, now); err != nil || s.FalsePositives != 3 || s.Status != StatusFlagged {
+		t.Errorf("Mark() after restart = %+v, %v", s, err)
+	}
+}
+
+func TestTracker_Demote(t *testing.T) {
+	tr := New(Config{MinSamples: 4, AutoDemote: true}, nil, nil, nil)
+	now := time.Now()
+
+	var delivered []string
+	sink := tr.Wrap(func(r *model.AlertRecord, _ *model.AlertLogItem) { delivered = append(delivered, r.ID) })
+	for i := 0; i < 4; i++ {
+		sink(alert(fmt.Sprintf("a%d", i), model.ModuleWasmRuleDetect, 7), nil)
+	}
+	for _, id := range []string{"a0", "a1", "a2"} {
+		tr.Mark(id, true, now)
+	}
+	if s := tr.List()[0]; s.Status != StatusDemoted {
+		t.Fatalf("status = %+v", s)
+	}
+
+	// 降级后命中仍计数但不产生告警，也不能再标记
+	sink(alert("a4", model.ModuleWasmRuleDetect, 7), nil)
+	if len(delivered) != 4 || tr.List()[0].Hits != 5 {
+		t.Errorf("delivered = %v, stat = %+v", delivered, tr.List()[0])
+	}
+	if _, err := tr.Mark("a4", true, now); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("Mark(demoted alert) = %v", err)
+	}
+	// 取消误报也不会自动恢复，需要重置
+	tr.Mark("a0", false, now)
+	if s := tr.List()[0]; s.Status != StatusDemoted {
+		t.Errorf("status after unmark = %+v", s)
+	}
+
+	if err := tr.Reset(model.ModuleWasmRuleDetect, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Reset(model.ModuleWasmRuleDetect, 7); !errors.Is(err, ErrRuleNotFound) {
+		t.Errorf("Reset(twice) = %v", err)
+	}
+	sink(alert("a5", model.ModuleWasmRuleDetect, 7), nil)
+	if len(delivered) != 5 || tr.List()[0].Hits != 1 {
+		t.Errorf("after reset delivered = %v, stat = %+v", delivered, tr.List())
+	}
+
+	// 未启用时原样传递
+	var nilTracker *Tracker
+	nilTracker.Wrap(func(r *model.AlertRecord, _ *model.AlertLogItem) { delivered = append(delivered, r.ID) })(alert("x", "m", 1), nil)
+	if len(delivered) != 6 {
+		t.Errorf("nil tracker dropped alert")
+	}
+}
+
+func TestTracker_Retention(t *testing.T) {
+	alerts := newMemStore[model.RuleAlertRef]()
+	tr := New(Config{Retention: time.Hour}, nil, alerts, nil)
+	start := time.Now()
+
+	tr.Record(alert("old", model.ModuleKeywordDetect, 1), start)
+	tr.Record(alert("new", model.ModuleKeywordDetect, 1), start.Add(2*time.Hour))
+	if _, err := tr.Mark("old", true, start); !errors.Is(err, ErrAlertNotFound) {
+		t.Errorf("Mark(expired) = %v", err)
+	}
+	if _, err := tr.Mark("new", true, start); err != nil {
+		t.Errorf("Mark(new) = %v", err)
+	}
+	if items, _ := alerts.LoadAll(); len(items) != 1 {
+		t.Errorf("persisted refs = %+v", items)
+	}
+}
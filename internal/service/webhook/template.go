@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"linuxFileWatcher/internal/i18n"
+	"linuxFileWatcher/internal/model"
+)
+
+// ==========================================
+// 请求体模板
+// ==========================================
+//
+// 模板使用 text/template 语法，可用字段见 templateData；字符串需用 json 函数输出以正确转义，例如:
+//   {"text": {{json (printf "检测到%s文件: %s" .Level .FilePath)}}}
+//   {"summary": {{json .FileName}}, "severity": {{if eq .LevelValue 1}}"critical"{{else}}"high"{{end}}}
+// 渲染结果必须是合法的 JSON，否则不发送
+
+// DefaultBody 默认请求体: 事件类型、主机与完整的告警记录
+const DefaultBody = `{"event": {{json .Event}}, "host": {{json .Host}}, "agent_uuid": {{json .AgentUUID}}, "time": {{json .Time}}, "alert": {{json .Record}}}`
+
+// levelKeys 密级对应的消息目录键
+var levelKeys = map[model.SecretLevel]string{
+	model.LevelTopSecret:    "level.top_secret",
+	model.LevelSecret:       "level.secret",
+	model.LevelConfidential: "level.confidential",
+	model.LevelInternal:     "level.internal",
+}
+
+// templateData 模板可用字段
+type templateData struct {
+	Event      string // 事件类型，目前为 detection
+	Host       string
+	AgentUUID  string
+	Time       string // RFC 3339
+	AlertID    string
+	FileName   string
+	FilePath   string
+	FileMD5    string
+	Rule       string // 命中规则描述
+	RuleID     int64
+	Module     string // 检测模块
+	Level      string // 本地化的密级名称，未知时为空
+	LevelValue int    // 密级数值: 1 绝密、2 机密、3 秘密、4 内部，0 未知
+	User       string // 登录用户，其次为进程用户与告警责任人
+	Process    string // 写入文件的进程
+	Record     *model.AlertRecord
+}
+
+// bodyTemplate 编译后的请求体模板
+type bodyTemplate struct {
+	tmpl *template.Template
+}
+
+// compileBody 编译请求体模板，并用空告警试渲染一次，尽早发现输出不是 JSON 的模板
+func compileBody(name, text string) (*bodyTemplate, error) {
+	if text == "" {
+		text = DefaultBody
+	}
+	tmpl, err := template.New(name).Funcs(template.FuncMap{
+		"json": toJSON,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %s: body template: %w", name, err)
+	}
+	t := &bodyTemplate{tmpl: tmpl}
+	if _, err := t.render(newTemplateData(&model.AlertRecord{}, "", time.Time{})); err != nil {
+		return nil, fmt.Errorf("webhook %s: %w", name, err)
+	}
+	return t, nil
+}
+
+// render 渲染请求体并检查是否为合法 JSON
+func (t *bodyTemplate) render(data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("body template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("body template output is not valid JSON: %.200s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+func newTemplateData(r *model.AlertRecord, host string, now time.Time) templateData {
+	data := templateData{
+		Event:      EventDetection,
+		Host:       host,
+		AgentUUID:  r.AgentUUID,
+		Time:       now.Format(time.RFC3339),
+		AlertID:    r.ID,
+		FileName:   r.FileName,
+		FilePath:   r.FilePath,
+		FileMD5:    r.FileMD5,
+		Rule:       r.RuleDesc,
+		RuleID:     r.RuleID,
+		Module:     r.DetectModule,
+		LevelValue: r.FileLevel,
+		User:       r.LoginUser,
+		Process:    r.ProcessExe,
+		Record:     r,
+	}
+	if key, ok := levelKeys[model.SecretLevel(r.FileLevel)]; ok {
+		data.Level = i18n.T(key)
+	}
+	if data.FileName == "" && data.FilePath != "" {
+		data.FileName = filepath.Base(data.FilePath)
+	}
+	if data.User == "" {
+		data.User = r.ProcessUser
+	}
+	if data.User == "" {
+		data.User = r.UserName
+	}
+	if data.AgentUUID == "" {
+		data.AgentUUID = model.AgentUUID()
+	}
+	return data
+}
+
+// toJSON 模板函数: 将值编码为 JSON (字符串带引号并转义)
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
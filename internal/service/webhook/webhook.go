@@ -0,0 +1,435 @@
+// Package webhook 告警 Webhook 输出
+// 检测命中后按模板渲染 JSON 请求体，HMAC 签名后发送到工单系统、SOAR 剧本或聊天机器人，不经过管理平台；
+// 每个接收端独立排队与重试，接收端不可用不影响检测流程与其他接收端
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/gmsm/sm3"
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/response"
+)
+
+// EventDetection 检测命中事件
+const EventDetection = "detection"
+
+// 请求头
+const (
+	// SignatureHeader 请求签名: <算法>=<hex(HMAC(secret, 时间戳 + "." + 请求体))>，如 sha256=...
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader 签名时间 (Unix 秒)，接收端可据此拒绝重放
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventHeader 事件类型
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader 投递 ID (告警 ID)，重试时不变，接收端可据此去重
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+// 签名算法
+const (
+	SignHMACSHA256 = "hmac-sha256"
+	SignHMACSM3    = "hmac-sm3"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultBackoff   = 2 * time.Second
+	defaultQueueSize = 1000
+	maxBackoff       = 5 * time.Minute
+	maxResponseDrain = 64 << 10
+	webhookUserAgent = "linuxFileWatcher-webhook"
+)
+
+// Endpoint 接收端配置
+type Endpoint struct {
+	// 名称，用于日志与统计
+	Name string
+	URL  string
+	// 请求方法: POST (默认) / PUT
+	Method string
+	// 附加请求头 (鉴权等)
+	Headers map[string]string
+	// 请求体模板，为空时使用 DefaultBody
+	Body string
+	// 签名密钥，为空时不签名
+	Secret string
+	// 签名算法: hmac-sha256 (默认) / hmac-sm3
+	SignAlgorithm string
+	// 筛选条件，语义同处置规则；未设置的条件视为匹配
+	Filter response.RuleSpec
+	// 额外信任的 CA 证书 (PEM)，用于内网自签证书的接收端
+	CAFile string
+}
+
+// Config Webhook 输出配置
+type Config struct {
+	Endpoints []Endpoint
+	// 单次请求超时
+	Timeout time.Duration
+	// 失败后的最大重试次数 (网络错误、408、429 与 5xx)，其余 4xx 不重试
+	MaxRetries int
+	// 首次重试间隔，之后每次加倍；接收端返回的 Retry-After 更长时以其为准
+	RetryBackoff time.Duration
+	// 每个接收端的待发送队列长度，满时丢弃新的告警
+	QueueSize int
+}
+
+// Stats 接收端投递统计
+type Stats struct {
+	Name    string `json:"name"`
+	Sent    int64  `json:"sent"`
+	Failed  int64  `json:"failed"`
+	Dropped int64  `json:"dropped"`
+	Queued  int    `json:"queued"`
+}
+
+// Sink 告警 Webhook 输出
+type Sink struct {
+	cfg       Config
+	endpoints []*endpoint
+	host      string
+
+	mu       sync.RWMutex
+	closed   bool
+	stopping chan struct{}
+	wg       sync.WaitGroup
+
+	now func() time.Time
+}
+
+type endpoint struct {
+	cfg    Endpoint
+	match  *response.Rule
+	body   *bodyTemplate
+	sign   func() hash.Hash
+	prefix string // 签名值前缀，如 sha256=
+	client *http.Client
+	queue  chan delivery
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+// delivery 一次待发送的通知，请求体在入队时渲染
+type delivery struct {
+	id   string
+	body []byte
+}
+
+// New 校验配置并创建 Webhook 输出，调用 Start 后开始发送
+func New(cfg Config) (*Sink, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultBackoff
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	host, _ := os.Hostname()
+
+	s := &Sink{
+		cfg:      cfg,
+		host:     host,
+		stopping: make(chan struct{}),
+		now:      time.Now,
+	}
+	for i, ec := range cfg.Endpoints {
+		if ec.Name == "" {
+			ec.Name = "#" + strconv.Itoa(i+1)
+		}
+		ep, err := newEndpoint(ec, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.endpoints = append(s.endpoints, ep)
+	}
+	return s, nil
+}
+
+func newEndpoint(ec Endpoint, cfg Config) (*endpoint, error) {
+	u, err := url.Parse(ec.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook %s: invalid url %q", ec.Name, ec.URL)
+	}
+	switch strings.ToUpper(ec.Method) {
+	case "":
+		ec.Method = http.MethodPost
+	case http.MethodPost, http.MethodPut:
+		ec.Method = strings.ToUpper(ec.Method)
+	default:
+		return nil, fmt.Errorf("webhook %s: unsupported method %q", ec.Name, ec.Method)
+	}
+
+	ep := &endpoint{cfg: ec, queue: make(chan delivery, cfg.QueueSize)}
+	switch strings.ToLower(ec.SignAlgorithm) {
+	case "", SignHMACSHA256:
+		ep.sign, ep.prefix = sha256.New, "sha256="
+	case SignHMACSM3:
+		ep.sign, ep.prefix = sm3.New, "sm3="
+	default:
+		return nil, fmt.Errorf("webhook %s: unknown sign algorithm %q", ec.Name, ec.SignAlgorithm)
+	}
+
+	if ep.match, err = response.CompileMatch(ec.Filter); err != nil {
+		return nil, fmt.Errorf("webhook %s: filter: %w", ec.Name, err)
+	}
+	if ep.body, err = compileBody(ec.Name, ec.Body); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ec.CAFile != "" {
+		pem, err := os.ReadFile(ec.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", ec.Name, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("webhook %s: no certificates in %s", ec.Name, ec.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	ep.client = &http.Client{Transport: transport, Timeout: cfg.Timeout}
+	return ep, nil
+}
+
+// Start 启动各接收端的发送协程
+func (s *Sink) Start() {
+	for _, ep := range s.endpoints {
+		s.wg.Add(1)
+		go s.worker(ep)
+	}
+}
+
+// Stop 停止接收新告警；队列中剩余的告警各尝试发送一次 (不再重试)，接收端不可达时丢弃
+func (s *Sink) Stop() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.stopping)
+	for _, ep := range s.endpoints {
+		close(ep.queue)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Stats 各接收端的投递统计
+func (s *Sink) Stats() []Stats {
+	stats := make([]Stats, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		stats = append(stats, Stats{
+			Name:    ep.cfg.Name,
+			Sent:    ep.sent.Load(),
+			Failed:  ep.failed.Load(),
+			Dropped: ep.dropped.Load(),
+			Queued:  len(ep.queue),
+		})
+	}
+	return stats
+}
+
+// Send 将告警放入匹配的接收端队列，不阻塞；请求体在此渲染，调用方之后可继续修改告警
+func (s *Sink) Send(record *model.AlertRecord) {
+	if record == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+
+	data := newTemplateData(record, s.host, s.now())
+	for _, ep := range s.endpoints {
+		if !ep.match.Match(record) {
+			continue
+		}
+		body, err := ep.body.render(data)
+		if err != nil {
+			ep.failed.Add(1)
+			logger.Warn("Webhook 请求体渲染失败", "webhook", ep.cfg.Name, "alert_id", record.ID, "error", err)
+			continue
+		}
+		select {
+		case ep.queue <- delivery{id: record.ID, body: body}:
+		default:
+			if ep.dropped.Add(1) == 1 {
+				logger.Warn("Webhook 发送队列已满，丢弃告警", "webhook", ep.cfg.Name, "queue_size", s.cfg.QueueSize)
+			}
+		}
+	}
+}
+
+// Wrap 包装告警回调，告警先放入 Webhook 队列再交给 sink；s 为 nil 时原样返回 sink
+func (s *Sink) Wrap(sink func(*model.AlertRecord, *model.AlertLogItem)) func(*model.AlertRecord, *model.AlertLogItem) {
+	if s == nil {
+		return sink
+	}
+	return func(record *model.AlertRecord, logItem *model.AlertLogItem) {
+		s.Send(record)
+		sink(record, logItem)
+	}
+}
+
+func (s *Sink) worker(ep *endpoint) {
+	defer s.wg.Done()
+
+	giveUp := false
+	for d := range ep.queue {
+		if giveUp {
+			ep.dropped.Add(1)
+			continue
+		}
+		if !s.deliver(ep, d) && s.isStopping() {
+			// 停止时接收端不可达，剩余告警不再逐条等待超时
+			giveUp = true
+		}
+	}
+}
+
+func (s *Sink) isStopping() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// deliver 发送一条通知，可重试的失败按退避间隔重试；停止期间只尝试一次
+func (s *Sink) deliver(ep *endpoint, d delivery) bool {
+	backoff := s.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := s.post(ep, d)
+		if err == nil {
+			ep.sent.Add(1)
+			return true
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= s.cfg.MaxRetries || s.isStopping() {
+			ep.failed.Add(1)
+			logger.Warn("Webhook 发送失败", "webhook", ep.cfg.Name, "alert_id", d.id, "attempts", attempt+1, "error", err)
+			return false
+		}
+
+		wait := backoff
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		wait = min(wait, maxBackoff)
+		backoff = min(backoff*2, maxBackoff)
+		logger.Debug("Webhook 发送失败，稍后重试", "webhook", ep.cfg.Name, "alert_id", d.id, "retry_in", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.stopping:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// permanentError 不应重试的失败 (接收端拒绝请求)
+type permanentError struct {
+	status string
+}
+
+func (e *permanentError) Error() string {
+	return "webhook: rejected with status " + e.status
+}
+
+// post 发送一次请求，返回接收端要求的重试等待时间
+func (s *Sink) post(ep *endpoint, d delivery) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, ep.cfg.Method, ep.cfg.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return 0, &permanentError{status: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	for k, v := range ep.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(EventHeader, EventDetection)
+	req.Header.Set(DeliveryHeader, d.id)
+	if ep.cfg.Secret != "" {
+		ts := strconv.FormatInt(s.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, ep.prefix+Sign(ep.sign, ep.cfg.Secret, ts, d.body))
+	}
+
+	resp, err := ep.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseDrain))
+	resp.Body.Close()
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return 0, nil
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return parseRetryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("webhook: status %s", resp.Status)
+	default:
+		return 0, &permanentError{status: resp.Status}
+	}
+}
+
+// Sign 计算签名 hex(HMAC(secret, timestamp + "." + body))，接收端按同样方式校验
+func Sign(h func() hash.Hash, secret, timestamp string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseRetryAfter 解析 Retry-After (秒数或 HTTP 日期)
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+	"linuxFileWatcher/internal/service/response"
+)
+
+func TestSink_Deliver(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var (
+		mu    sync.Mutex
+		got   []received
+		calls atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回 503，验证重试
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, received{header: r.Header.Clone(), body: body})
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, err := New(Config{
+		RetryBackoff: time.Millisecond,
+		MaxRetries:   3,
+		Endpoints: []Endpoint{{
+			Name:    "soar",
+			URL:     srv.URL + "/hook",
+			Headers: map[string]string{"Authorization": "Bearer t"},
+			Body:    `{"text": {{json (printf "%s: %s" .Level .FilePath)}}, "rule": {{.RuleID}}, "user": {{json .User}}}`,
+			Secret:  "s3cret",
+			Filter:  response.RuleSpec{MinLevel: "机密"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	secret := &model.AlertRecord{ID: "a1", FilePath: `/home/alice/"计划".docx`, RuleID: 7, FileLevel: int(model.LevelSecret), UserName: "alice"}
+	internal := &model.AlertRecord{ID: "a2", FilePath: "/tmp/x", FileLevel: int(model.LevelInternal)}
+	var sunk int
+	sink := s.Wrap(func(*model.AlertRecord, *model.AlertLogItem) { sunk++ })
+	sink(secret, nil)
+	sink(internal, nil) // 低于筛选密级，不发送
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats()[0].Sent == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Stop()
+
+	if sunk != 2 {
+		t.Errorf("sink called %d times", sunk)
+	}
+	if len(got) != 1 {
+		t.Fatalf("received %d requests, want 1", len(got))
+	}
+	r := got[0]
+	var body map[string]interface{}
+	if err := json.Unmarshal(r.body, &body); err != nil {
+		t.Fatalf("body %s: %v", r.body, err)
+	}
+	if body["text"] != `机密: /home/alice/"计划".docx` || body["rule"] != float64(7) || body["user"] != "alice" {
+		t.Errorf("body = %v", body)
+	}
+	if r.header.Get("Authorization") != "Bearer t" || r.header.Get(DeliveryHeader) != "a1" || r.header.Get(EventHeader) != EventDetection {
+		t.Errorf("header = %v", r.header)
+	}
+	want := "sha256=" + Sign(sha256.New, "s3cret", r.header.Get(TimestampHeader), r.body)
+	if r.header.Get(SignatureHeader) != want {
+		t.Errorf("signature = %s, want %s", r.header.Get(SignatureHeader), want)
+	}
+	if st := s.Stats()[0]; st.Sent != 1 || st.Failed != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestSink_Failures(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	s, err := New(Config{
+		RetryBackoff: time.Millisecond,
+		MaxRetries:   2,
+		Endpoints:    []Endpoint{{Name: "bad", URL: srv.URL + "/bad"}, {Name: "down", URL: srv.URL + "/down", SignAlgorithm: SignHMACSM3, Secret: "k"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	s.Send(&model.AlertRecord{ID: "a1"})
+
+	// 400 不重试，502 重试 2 次
+	deadline := time.Now().Add(5 * time.Second)
+	for calls.Load() < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.Stop()
+	if n := calls.Load(); n != 4 {
+		t.Errorf("calls = %d, want 4", n)
+	}
+	for _, st := range s.Stats() {
+		if st.Failed != 1 || st.Sent != 0 {
+			t.Errorf("stats = %+v", st)
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for name, ep := range map[string]Endpoint{
+		"url":       {URL: "ftp://example.com"},
+		"method":    {URL: "http://example.com", Method: "GET"},
+		"algorithm": {URL: "http://example.com", SignAlgorithm: "md5"},
+		"template":  {URL: "http://example.com", Body: `{"file": {{.FilePath}}}`}, // 未用 json 转义
+		"filter":    {URL: "http://example.com", Filter: response.RuleSpec{MinLevel: "最高"}},
+	} {
+		if _, err := New(Config{Endpoints: []Endpoint{ep}}); err == nil {
+			t.Errorf("%s: New() accepted %+v", name, ep)
+		}
+	}
+
+	// 默认请求体
+	s, err := New(Config{Endpoints: []Endpoint{{URL: "https://example.com/hook"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := s.endpoints[0].body.render(newTemplateData(&model.AlertRecord{ID: "a1", FilePath: "/a/b.txt"}, "host1", time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Event string             `json:"event"`
+		Host  string             `json:"host"`
+		Alert *model.AlertRecord `json:"alert"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil || doc.Event != EventDetection || doc.Host != "host1" || doc.Alert.ID != "a1" {
+		t.Errorf("default body = %s, %v", body, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"linuxFileWatcher/internal/logger"
 	"linuxFileWatcher/internal/security" // 需要调用加密
+	"linuxFileWatcher/internal/security/kms"
 )
 
 // HybridStore 混合存储引擎
@@ -35,12 +36,14 @@ func NewHybridStore[T any](db *gorm.DB, limit int, tableName string) (*HybridSto
 		logger.Debug("Table already exists", "table", tableName)
 	}
 
-	return &HybridStore[T]{
+	s := &HybridStore[T]{
 		db:        db,
 		tableName: tableName,
 		memStore:  make([]T, 0, limit),
 		memLimit:  limit,
-	}, nil
+	}
+	registerTable(tableName, s)
+	return s, nil
 }
 
 // Push 写入数据
@@ -132,16 +135,10 @@ func (s *HybridStore[T]) persistToDisk(items []T) error {
 	diskRecords := make([]DiskRecord, 0, len(items))
 
 	for _, item := range items {
-		// A. 序列化
-		jsonBytes, err := json.Marshal(item)
-		if err != nil {
-			return fmt.Errorf("json marshal failed: %v", err)
-		}
-
-		// B. 全量加密
-		cipherBytes, err := security.EncryptLocal(jsonBytes)
+		// A. 序列化 + B. 全量加密
+		cipherBytes, err := encodeAndEncrypt(item)
 		if err != nil {
-			return fmt.Errorf("encrypt failed: %v", err)
+			return err
 		}
 
 		// C. 包装
@@ -162,10 +159,36 @@ func (s *HybridStore[T]) persistToDisk(items []T) error {
 	return s.db.Table(s.tableName).CreateInBatches(diskRecords, 100).Error
 }
 
+// encodeAndEncrypt 序列化并加密
+func encodeAndEncrypt[T any](item T) ([]byte, error) {
+	jsonBytes, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal failed: %v", err)
+	}
+
+	// 启用 KMS 时使用其封装的数据密钥，否则沿用内置密钥
+	var cipherBytes []byte
+	if ring := kms.Default(); ring != nil {
+		cipherBytes, err = ring.Encrypt(jsonBytes)
+	} else {
+		cipherBytes, err = security.EncryptLocal(jsonBytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encrypt failed: %v", err)
+	}
+	return cipherBytes, nil
+}
+
 // decodeAndDecrypt 解密并反序列化
 func decodeAndDecrypt[T any](cipherData []byte) (*T, error) {
-	// A. 解密
-	jsonBytes, err := security.DecryptLocal(cipherData)
+	// A. 解密 (启用 KMS 前落盘的记录仍为内置密钥格式)
+	var jsonBytes []byte
+	var err error
+	if ring := kms.Default(); ring != nil && kms.IsSealed(cipherData) {
+		jsonBytes, err = ring.Decrypt(cipherData)
+	} else {
+		jsonBytes, err = security.DecryptLocal(cipherData)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// KeyedRecord 按业务主键存取的物理表结构
+// Key 为业务主键的 SHA256，避免明文路径落盘
+type KeyedRecord struct {
+	Key       string `gorm:"primaryKey;size:64"`
+	Data      []byte `gorm:"type:blob"` // SM4(JSON(BusinessData))
+	UpdatedAt int64  `gorm:"autoUpdateTime"`
+}
+
+// KeyedStore 键值持久化存储
+// 与 HybridStore 不同，数据直接落盘并可按 Key 覆盖/删除，适用于需要断电恢复的状态数据
+type KeyedStore[T any] struct {
+	db        *gorm.DB
+	tableName string
+}
+
+// NewKeyedStore 初始化
+func NewKeyedStore[T any](db *gorm.DB, tableName string) (*KeyedStore[T], error) {
+	if !db.Migrator().HasTable(tableName) {
+		if err := db.Table(tableName).AutoMigrate(&KeyedRecord{}); err != nil {
+			logger.Error("Failed to create table", "table", tableName, "error", err)
+			return nil, err
+		}
+		logger.Info("Created table successfully", "table", tableName)
+	}
+
+	s := &KeyedStore[T]{db: db, tableName: tableName}
+	registerTable(tableName, s)
+	return s, nil
+}
+
+// Put 写入或覆盖
+func (s *KeyedStore[T]) Put(key string, item T) error {
+	cipherBytes, err := encodeAndEncrypt(item)
+	if err != nil {
+		return err
+	}
+
+	rec := KeyedRecord{Key: hashKey(key), Data: cipherBytes}
+	return s.db.Table(s.tableName).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "updated_at"}),
+	}).Create(&rec).Error
+}
+
+// Get 读取单条，不存在时返回 (nil, nil)
+func (s *KeyedStore[T]) Get(key string) (*T, error) {
+	var rec KeyedRecord
+	result := s.db.Table(s.tableName).Where("key = ?", hashKey(key)).Limit(1).Find(&rec)
+	if result.Error != nil {
+		return nil, fmt.Errorf("read disk failed: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return decodeAndDecrypt[T](rec.Data)
+}
+
+// Delete 删除单条
+func (s *KeyedStore[T]) Delete(key string) error {
+	return s.db.Table(s.tableName).Unscoped().Where("key = ?", hashKey(key)).Delete(&KeyedRecord{}).Error
+}
+
+// LoadAll 读取全部数据 (不删除)
+func (s *KeyedStore[T]) LoadAll() ([]T, error) {
+	var records []KeyedRecord
+	if err := s.db.Table(s.tableName).Order("updated_at").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("read disk failed: %v", err)
+	}
+
+	result := make([]T, 0, len(records))
+	for _, rec := range records {
+		item, err := decodeAndDecrypt[T](rec.Data)
+		if err != nil {
+			logger.Error("Storage decrypt error", "key", rec.Key, "error", err)
+			continue
+		}
+		result = append(result, *item)
+	}
+	return result, nil
+}
+
+// Clear 清空全部数据
+func (s *KeyedStore[T]) Clear() error {
+	return s.db.Table(s.tableName).Unscoped().Where("1 = 1").Delete(&KeyedRecord{}).Error
+}
+
+// hashKey 计算业务主键的摘要
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
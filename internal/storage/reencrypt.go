@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/security"
+	"linuxFileWatcher/internal/security/kms"
+)
+
+// ==========================================
+// 密钥轮换后的重加密
+// ==========================================
+
+// reencryptBatch 每批处理的记录数
+const reencryptBatch = 200
+
+// reencrypt 将密文转换为当前密钥加密，changed 为 false 表示已是当前密钥
+// 内置密钥格式的旧记录同时迁移到 KMS 密钥
+func reencrypt(ring *kms.Keyring, data []byte) (out []byte, changed bool, err error) {
+	var plain []byte
+	switch {
+	case !kms.IsSealed(data):
+		plain, err = security.DecryptLocal(data)
+	case ring.NeedsReEncrypt(data):
+		plain, err = ring.Decrypt(data)
+	default:
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	out, err = ring.Encrypt(plain)
+	return out, err == nil, err
+}
+
+// ReEncrypt 使用当前密钥重新加密磁盘数据，返回转换的记录数
+// 内存中的数据为明文，刷盘时自动使用当前密钥，无需处理
+// 个别记录无法转换时继续处理其余记录，结束后返回错误，调用方不得清理历史密钥
+func (s *HybridStore[T]) ReEncrypt(ctx context.Context, ring *kms.Keyring) (int, error) {
+	var lastID uint
+	total, failed := 0, 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		s.mu.Lock()
+		var records []DiskRecord
+		err := s.db.Table(s.tableName).Where("id > ?", lastID).Order("id").Limit(reencryptBatch).Find(&records).Error
+		if err == nil {
+			for _, rec := range records {
+				lastID = rec.ID
+				data, changed, convErr := reencrypt(ring, rec.Data)
+				if convErr != nil {
+					logger.Error("Storage re-encrypt error", "table", s.tableName, "id", rec.ID, "error", convErr)
+					failed++
+					continue
+				}
+				if !changed {
+					continue
+				}
+				if err = s.db.Table(s.tableName).Where("id = ?", rec.ID).Update("data", data).Error; err != nil {
+					break
+				}
+				total++
+			}
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return total, fmt.Errorf("re-encrypt %s failed: %v", s.tableName, err)
+		}
+		if len(records) < reencryptBatch {
+			return total, failedErr(s.tableName, failed)
+		}
+	}
+}
+
+// ReEncrypt 使用当前密钥重新加密全部数据，返回转换的记录数
+// 个别记录无法转换时继续处理其余记录，结束后返回错误
+func (s *KeyedStore[T]) ReEncrypt(ctx context.Context, ring *kms.Keyring) (int, error) {
+	lastKey := ""
+	total, failed := 0, 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		var records []KeyedRecord
+		if err := s.db.Table(s.tableName).Where("key > ?", lastKey).Order("key").Limit(reencryptBatch).Find(&records).Error; err != nil {
+			return total, fmt.Errorf("re-encrypt %s failed: %v", s.tableName, err)
+		}
+		for _, rec := range records {
+			lastKey = rec.Key
+			data, changed, err := reencrypt(ring, rec.Data)
+			if err != nil {
+				logger.Error("Storage re-encrypt error", "table", s.tableName, "key", rec.Key, "error", err)
+				failed++
+				continue
+			}
+			if !changed {
+				continue
+			}
+			// 仅在数据未被并发覆盖时更新
+			res := s.db.Table(s.tableName).Where("key = ? AND data = ?", rec.Key, rec.Data).Update("data", data)
+			if res.Error != nil {
+				return total, fmt.Errorf("re-encrypt %s failed: %v", s.tableName, res.Error)
+			}
+			total += int(res.RowsAffected)
+		}
+		if len(records) < reencryptBatch {
+			return total, failedErr(s.tableName, failed)
+		}
+	}
+}
+
+// failedErr 存在无法转换的记录时返回错误
+func failedErr(name string, failed int) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("re-encrypt %s: %d records failed", name, failed)
+}
+
+// FileVault 保存加密文件的目录 (取证库、自我保护黄金副本)，由所属模块实现
+// Reseal 以 convert 逐个转换文件密文 (changed 为 false 时无需改写)，返回改写的文件数；
+// 个别文件转换失败时继续处理其余文件，结束后返回错误
+type FileVault interface {
+	Reseal(ctx context.Context, convert func(data []byte) (out []byte, changed bool, err error)) (int, error)
+}
+
+var (
+	vaultsMu sync.Mutex
+	vaults   = make(map[string]FileVault)
+)
+
+// RegisterVault 登记加密文件目录，密钥轮换时与存储表一同重加密
+// 使用数据密钥加密落盘文件的模块在初始化后必须登记，否则清理历史密钥后文件将无法解密
+func RegisterVault(name string, v FileVault) {
+	vaultsMu.Lock()
+	defer vaultsMu.Unlock()
+	vaults[name] = v
+}
+
+// reencrypter 可使用当前密钥重加密的存储表
+type reencrypter interface {
+	ReEncrypt(ctx context.Context, ring *kms.Keyring) (int, error)
+}
+
+var (
+	tablesMu sync.Mutex
+	tables   = make(map[string]reencrypter)
+)
+
+// registerTable 登记存储表，密钥轮换时重加密
+// 由 NewHybridStore/NewKeyedStore 在创建时调用，新增存储无需另行登记
+func registerTable(name string, s reencrypter) {
+	tablesMu.Lock()
+	defer tablesMu.Unlock()
+	tables[name] = s
+}
+
+// ReEncryptAll 使用当前密钥重新加密所有已创建的存储表与已登记的加密文件目录
+// 任一记录或文件未能转换时返回错误 (其余数据照常转换)，调用方不得清理历史密钥
+func ReEncryptAll(ctx context.Context, ring *kms.Keyring) (int, error) {
+	tablesMu.Lock()
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	tablesMu.Unlock()
+	sort.Strings(names)
+
+	total := 0
+	var errs []error
+	for _, name := range names {
+		tablesMu.Lock()
+		s := tables[name]
+		tablesMu.Unlock()
+		n, err := s.ReEncrypt(ctx, ring)
+		total += n
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return total, ctxErr
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	vaultsMu.Lock()
+	names = make([]string, 0, len(vaults))
+	for name := range vaults {
+		names = append(names, name)
+	}
+	vaultsMu.Unlock()
+	sort.Strings(names)
+
+	convert := func(data []byte) ([]byte, bool, error) {
+		return reencrypt(ring, data)
+	}
+	for _, name := range names {
+		vaultsMu.Lock()
+		v := vaults[name]
+		vaultsMu.Unlock()
+		n, err := v.Reseal(ctx, convert)
+		total += n
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return total, ctxErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("re-encrypt %s: %w", name, err))
+		}
+	}
+	return total, errors.Join(errs...)
+}
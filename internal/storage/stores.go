@@ -40,6 +40,36 @@ type Stores struct {
 	CommandResults *HybridStore[model.CommandResultReport]
 	// PolicyResults 缓存发送失败的策略执行结果
 	PolicyResults *HybridStore[model.StrategyExecReport]
+
+	// --- 扫描状态 (用于重启后断点续扫) ---
+	// ScanJobs 待扫描任务，按文件路径存取
+	ScanJobs *KeyedStore[model.ScanJob]
+	// ScanCheckpoints 目录扫描断点，按扫描根目录存取
+	ScanCheckpoints *KeyedStore[model.ScanCheckpoint]
+	// ScanRuns 定时扫描运行摘要，按运行 ID 存取
+	ScanRuns *KeyedStore[model.ScanRunSummary]
+
+	// --- 完整性基线 ---
+	// MerkleNodes 目录树基线节点，按目录路径存取
+	MerkleNodes *KeyedStore[model.MerkleNode]
+
+	// --- 网络监控 ---
+	// NetguardSeen 网络告警去重记录，按去重键存取
+	NetguardSeen *KeyedStore[model.NetguardSeen]
+	// NetguardWhitelist 运行时添加的网络白名单规则，按规则存取
+	NetguardWhitelist *KeyedStore[model.NetguardWhitelistRule]
+
+	// --- 文件流转 ---
+	// FileLineage 涉密文件的复制、改名记录，按起点告警与目标路径存取
+	FileLineage *KeyedStore[model.FileLineageEdge]
+
+	// --- 规则质量 ---
+	// RuleStats 检测规则命中与误报统计，按检测模块与规则 ID 存取
+	RuleStats *KeyedStore[model.RuleStat]
+	// RuleAlerts 告警对应的规则，按告警 ID 存取
+	RuleAlerts *KeyedStore[model.RuleAlertRef]
+	// Evidence 告警取证留存的元数据，按告警 ID 存取
+	Evidence *KeyedStore[model.EvidenceRecord]
 }
 
 // StoresOptions 存储实例配置选项
@@ -127,14 +157,89 @@ func SetupStores(db *gorm.DB, opts StoresOptions) error {
 			err = alertLogsErr
 			return
 		}
-		// 5. 创建存储实例管理器
+		// 6. 初始化扫描任务与断点存储 (直接落盘，不经过内存缓冲)
+		scanJobsStore, scanJobsErr := NewKeyedStore[model.ScanJob](db, "storage_scan_jobs")
+		if scanJobsErr != nil {
+			err = scanJobsErr
+			return
+		}
+		checkpointStore, checkpointErr := NewKeyedStore[model.ScanCheckpoint](db, "storage_scan_checkpoints")
+		if checkpointErr != nil {
+			err = checkpointErr
+			return
+		}
+
+		scanRunsStore, scanRunsErr := NewKeyedStore[model.ScanRunSummary](db, "storage_scan_runs")
+		if scanRunsErr != nil {
+			err = scanRunsErr
+			return
+		}
+
+		// 7. 初始化目录树基线存储 (每个目录一条记录)
+		merkleStore, merkleErr := NewKeyedStore[model.MerkleNode](db, "storage_merkle_nodes")
+		if merkleErr != nil {
+			err = merkleErr
+			return
+		}
+
+		// 8. 初始化网络告警去重存储
+		netguardSeenStore, netguardSeenErr := NewKeyedStore[model.NetguardSeen](db, "storage_netguard_seen")
+		if netguardSeenErr != nil {
+			err = netguardSeenErr
+			return
+		}
+
+		// 9. 初始化网络白名单存储
+		netguardWhitelistStore, netguardWhitelistErr := NewKeyedStore[model.NetguardWhitelistRule](db, "storage_netguard_whitelist")
+		if netguardWhitelistErr != nil {
+			err = netguardWhitelistErr
+			return
+		}
+
+		// 10. 初始化文件流转存储
+		fileLineageStore, fileLineageErr := NewKeyedStore[model.FileLineageEdge](db, "storage_file_lineage")
+		if fileLineageErr != nil {
+			err = fileLineageErr
+			return
+		}
+
+		// 11. 初始化规则命中统计与告警索引存储
+		ruleStatsStore, ruleStatsErr := NewKeyedStore[model.RuleStat](db, "storage_rule_stats")
+		if ruleStatsErr != nil {
+			err = ruleStatsErr
+			return
+		}
+		ruleAlertsStore, ruleAlertsErr := NewKeyedStore[model.RuleAlertRef](db, "storage_rule_alerts")
+		if ruleAlertsErr != nil {
+			err = ruleAlertsErr
+			return
+		}
+
+		// 12. 初始化告警取证留存存储
+		evidenceStore, evidenceErr := NewKeyedStore[model.EvidenceRecord](db, "storage_evidence")
+		if evidenceErr != nil {
+			err = evidenceErr
+			return
+		}
+
+		// 13. 创建存储实例管理器
 		stores = &Stores{
-			Alerts:          alertsStore,
-			AuditLogs:       auditLogsStore,
-			SecurityReports: securityReportsStore,
-			AlertLogs:       alertLogsStore,
-			CommandResults:  cmdResultStore,
-			PolicyResults:   policyResultStore,
+			Alerts:            alertsStore,
+			AuditLogs:         auditLogsStore,
+			SecurityReports:   securityReportsStore,
+			AlertLogs:         alertLogsStore,
+			CommandResults:    cmdResultStore,
+			PolicyResults:     policyResultStore,
+			ScanJobs:          scanJobsStore,
+			ScanCheckpoints:   checkpointStore,
+			ScanRuns:          scanRunsStore,
+			MerkleNodes:       merkleStore,
+			NetguardSeen:      netguardSeenStore,
+			NetguardWhitelist: netguardWhitelistStore,
+			FileLineage:       fileLineageStore,
+			RuleStats:         ruleStatsStore,
+			RuleAlerts:        ruleAlertsStore,
+			Evidence:          evidenceStore,
 		}
 	})
 
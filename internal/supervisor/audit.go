@@ -0,0 +1,153 @@
+package supervisor
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// auditKey 审计规则关键字
+const auditKey = "filewatcherd_kill"
+
+// auditTail 查找时读取的审计日志末尾长度
+const auditTail = 4 << 20
+
+// auditWatch 通过 auditctl 为客户端进程添加信号系统调用审计规则，
+// 客户端异常后从审计日志中查找向其发送信号的进程
+// 未安装 auditd/auditctl 或权限不足时降级为不识别
+type auditWatch struct {
+	logPath  string
+	disabled bool
+}
+
+func newAuditWatch(logPath string) *auditWatch {
+	return &auditWatch{logPath: logPath, disabled: logPath == "-"}
+}
+
+// ruleArgs auditctl 规则参数，a0 为信号目标进程
+func ruleArgs(op string, pid int) []string {
+	arch := "arch=b64"
+	if strconv.IntSize == 32 {
+		arch = "arch=b32"
+	}
+	return []string{op, "always,exit", "-F", arch,
+		"-S", "kill", "-S", "tkill", "-S", "tgkill",
+		"-F", "a0=" + strconv.Itoa(pid), "-k", auditKey}
+}
+
+// add 为进程添加审计规则
+func (a *auditWatch) add(pid int) {
+	if a.disabled {
+		return
+	}
+	if out, err := exec.Command("auditctl", ruleArgs("-a", pid)...).CombinedOutput(); err != nil {
+		logger.Warn("添加信号审计规则失败，无法识别结束客户端的进程", "error", err, "output", strings.TrimSpace(string(out)))
+		a.disabled = true
+	}
+}
+
+// remove 删除进程的审计规则
+func (a *auditWatch) remove(pid int) {
+	if a.disabled {
+		return
+	}
+	exec.Command("auditctl", ruleArgs("-d", pid)...).Run()
+}
+
+// lookup 查找 since 之后向 pid 发送信号的进程
+// auditd 异步写日志，未找到时短暂等待重试
+func (a *auditWatch) lookup(pid int, since time.Time) *Killer {
+	if a.disabled {
+		return nil
+	}
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		f, err := os.Open(a.logPath)
+		if err != nil {
+			return nil
+		}
+		if fi, err := f.Stat(); err == nil && fi.Size() > auditTail {
+			f.Seek(fi.Size()-auditTail, io.SeekStart)
+		}
+		k := findKiller(f, pid, since)
+		f.Close()
+		if k != nil {
+			return k
+		}
+	}
+	return nil
+}
+
+// findKiller 在审计日志中查找 since 之后最后一条向 pid 发送信号的 SYSCALL 记录
+func findKiller(r io.Reader, pid int, since time.Time) *Killer {
+	target := strconv.FormatInt(int64(pid), 16)
+	var found *Killer
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "type=SYSCALL ") || !strings.Contains(line, `key="`+auditKey+`"`) {
+			continue
+		}
+		fields := parseAuditFields(line)
+		if fields["a0"] != target || fields["success"] != "yes" {
+			continue
+		}
+		if t, ok := auditTime(fields["msg"]); !ok || t.Before(since) {
+			continue
+		}
+		found = &Killer{
+			PID:  atoi(fields["pid"]),
+			UID:  atoi(fields["uid"]),
+			AUID: atoi(fields["auid"]),
+			Comm: strings.Trim(fields["comm"], `"`),
+			Exe:  strings.Trim(fields["exe"], `"`),
+		}
+	}
+	return found
+}
+
+// parseAuditFields 解析 key=value 字段 (值不含空格)
+func parseAuditFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for _, kv := range strings.Fields(line) {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// auditTime 解析 msg=audit(1700000000.123:456): 中的时间
+func auditTime(msg string) (time.Time, bool) {
+	s := strings.TrimPrefix(msg, "audit(")
+	s, _, ok := strings.Cut(s, ":")
+	if !ok {
+		return time.Time{}, false
+	}
+	sec, frac, _ := strings.Cut(s, ".")
+	secs, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	ms, _ := strconv.ParseInt(frac, 10, 64)
+	return time.Unix(secs, ms*int64(time.Millisecond)), true
+}
+
+// atoi 解析审计字段中的数值，4294967295 (unset) 与无效值返回 -1
+func atoi(s string) int {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n == 4294967295 {
+		return -1
+	}
+	return int(n)
+}
@@ -0,0 +1,61 @@
+package supervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EventsFileName 数据目录下的事件文件 (每行一个 JSON)
+const EventsFileName = "supervisor-events.jsonl"
+
+// AppendEvent 追加事件
+func AppendEvent(dir string, ev Event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, EventsFileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("supervisor: open events: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("supervisor: write event: %w", err)
+	}
+	return f.Sync()
+}
+
+// TakeEvents 取出并删除全部事件
+// 先重命名再读取，监护进程之后追加的事件写入新文件，不会丢失
+func TakeEvents(dir string) ([]Event, error) {
+	path := filepath.Join(dir, EventsFileName)
+	taken := path + ".taken"
+	if err := os.Rename(path, taken); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("supervisor: take events: %w", err)
+	}
+	defer os.Remove(taken)
+
+	f, err := os.Open(taken)
+	if err != nil {
+		return nil, fmt.Errorf("supervisor: take events: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev Event
+		// 写入中断导致的残缺行跳过
+		if json.Unmarshal(scanner.Bytes(), &ev) == nil {
+			events = append(events, ev)
+		}
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,151 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// Run 启动并监护客户端，直到 ctx 取消 (通知客户端退出并等待后返回)
+// 首次启动失败时返回错误；之后的重启失败按退避重试
+func (s *Supervisor) Run(ctx context.Context) error {
+	audit := newAuditWatch(s.cfg.AuditLog)
+	var backoff time.Duration
+	restarts := 0
+
+	for first := true; ; first = false {
+		started := time.Now()
+		cmd := exec.Command(s.cfg.Path, s.cfg.Args...)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Start(); err != nil {
+			if first {
+				return fmt.Errorf("supervisor: start agent: %w", err)
+			}
+			logger.Error("重启客户端失败", "error", err)
+		} else {
+			pid := cmd.Process.Pid
+			s.pid.Store(int64(pid))
+			audit.add(pid)
+			logger.Info("客户端已启动", "pid", pid, "restarts", restarts)
+
+			ev, stopped := s.watch(ctx, pid, started, restarts, audit)
+			s.pid.Store(0)
+			audit.remove(pid)
+			cmd.Process.Release()
+			if stopped {
+				return nil
+			}
+
+			restarts++
+			ev.Restarts = restarts
+			s.record(ev)
+		}
+
+		backoff = s.nextBackoff(backoff, time.Since(started))
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// watch 等待客户端退出，期间被暂停时记录事件并恢复运行
+// ctx 取消时通知客户端退出，返回 stopped=true
+func (s *Supervisor) watch(ctx context.Context, pid int, started time.Time, restarts int, audit *auditWatch) (Event, bool) {
+	statusCh := make(chan syscall.WaitStatus, 1)
+	go func() {
+		defer close(statusCh)
+		for {
+			var ws syscall.WaitStatus
+			_, err := syscall.Wait4(pid, &ws, syscall.WUNTRACED, nil)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				return
+			}
+			statusCh <- ws
+			if !ws.Stopped() {
+				return
+			}
+		}
+	}()
+
+	stopping := false
+	done := ctx.Done()
+	var deadline <-chan time.Time
+	for {
+		select {
+		case <-done:
+			stopping, done = true, nil
+			syscall.Kill(pid, syscall.SIGTERM)
+			// 被暂停的客户端需恢复后才能处理 SIGTERM
+			syscall.Kill(pid, syscall.SIGCONT)
+			deadline = time.After(s.cfg.StopTimeout)
+
+		case <-deadline:
+			logger.Warn("客户端未在期限内退出，强制结束", "pid", pid)
+			syscall.Kill(pid, syscall.SIGKILL)
+			deadline = nil
+
+		case ws, ok := <-statusCh:
+			if !ok {
+				// wait 失败，进程已不可等待，按退出处理
+				return Event{Time: time.Now().UnixMilli(), PID: pid, Kind: ExitExited, ExitCode: -1}, stopping
+			}
+			if ws.Stopped() {
+				if !stopping {
+					ev := s.event(pid, ExitStopped, ws, started, audit)
+					ev.Restarts = restarts
+					s.record(ev)
+				}
+				syscall.Kill(pid, syscall.SIGCONT)
+				continue
+			}
+			if stopping {
+				return Event{}, true
+			}
+			kind := ExitExited
+			if ws.Signaled() {
+				kind = ExitKilled
+			}
+			return s.event(pid, kind, ws, started, audit), false
+		}
+	}
+}
+
+// event 根据等待状态生成事件
+func (s *Supervisor) event(pid int, kind ExitKind, ws syscall.WaitStatus, started time.Time, audit *auditWatch) Event {
+	ev := Event{Time: time.Now().UnixMilli(), PID: pid, Kind: kind, ExitCode: -1}
+	switch {
+	case ws.Exited():
+		ev.ExitCode = ws.ExitStatus()
+	case ws.Signaled():
+		ev.Signal = ws.Signal().String()
+	case ws.Stopped():
+		ev.Signal = ws.StopSignal().String()
+	}
+	ev.Killer = audit.lookup(pid, started)
+	return ev
+}
+
+// record 记录事件并写入数据目录，由客户端启动时导入
+func (s *Supervisor) record(ev Event) {
+	args := []interface{}{"pid", ev.PID, "kind", ev.Kind, "exit_code", ev.ExitCode, "signal", ev.Signal, "restarts", ev.Restarts}
+	if ev.Killer != nil {
+		args = append(args, "killer_pid", ev.Killer.PID, "killer_uid", ev.Killer.UID, "killer_auid", ev.Killer.AUID, "killer_exe", ev.Killer.Exe)
+	}
+	logger.Error("客户端被结束或暂停", args...)
+
+	if err := AppendEvent(s.cfg.DataDir, ev); err != nil {
+		logger.Error("保存客户端存活事件失败", "error", err)
+	}
+}
@@ -0,0 +1,118 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestSupervisor(t *testing.T, script string) *Supervisor {
+	t.Helper()
+	return New(Config{
+		Path:       "/bin/sh",
+		Args:       []string{"-c", script},
+		DataDir:    t.TempDir(),
+		AuditLog:   "-",
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+}
+
+// waitFor 轮询直到 cond 成立
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func runAsync(s *Supervisor) (context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Run(ctx) }()
+	return cancel, errCh
+}
+
+func TestRun_RestartsAfterExit(t *testing.T) {
+	s := newTestSupervisor(t, "exit 3")
+	cancel, errCh := runAsync(s)
+
+	var events []Event
+	waitFor(t, func() bool {
+		evs, _ := TakeEvents(s.cfg.DataDir)
+		events = append(events, evs...)
+		return len(events) >= 2
+	})
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	if events[0].Kind != ExitExited || events[0].ExitCode != 3 || events[0].Restarts != 1 || events[1].Restarts != 2 {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+func TestRun_KilledAndStopped(t *testing.T) {
+	s := newTestSupervisor(t, "while :; do sleep 0.05; done")
+	cancel, errCh := runAsync(s)
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+
+	waitFor(t, func() bool { return s.pid.Load() > 0 })
+	pid := int(s.pid.Load())
+
+	// 暂停后应记录事件并恢复运行
+	syscall.Kill(pid, syscall.SIGSTOP)
+	var events []Event
+	waitFor(t, func() bool {
+		evs, _ := TakeEvents(s.cfg.DataDir)
+		events = append(events, evs...)
+		return len(events) >= 1
+	})
+	if events[0].Kind != ExitStopped || events[0].Signal != "stopped (signal)" {
+		t.Errorf("stop event = %+v", events[0])
+	}
+	if int(s.pid.Load()) != pid {
+		t.Fatal("暂停后不应重启")
+	}
+
+	syscall.Kill(pid, syscall.SIGKILL)
+	waitFor(t, func() bool {
+		evs, _ := TakeEvents(s.cfg.DataDir)
+		events = append(events, evs...)
+		return len(events) >= 2
+	})
+	if events[1].Kind != ExitKilled || events[1].Signal != "killed" || events[1].PID != pid {
+		t.Errorf("kill event = %+v", events[1])
+	}
+	waitFor(t, func() bool { p := int(s.pid.Load()); return p > 0 && p != pid })
+}
+
+func TestRun_StopNoEvent(t *testing.T) {
+	s := newTestSupervisor(t, "exec sleep 30")
+	cancel, errCh := runAsync(s)
+	waitFor(t, func() bool { return s.pid.Load() > 0 })
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Run() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+	if evs, _ := TakeEvents(s.cfg.DataDir); len(evs) != 0 {
+		t.Errorf("监护停止不应记录事件: %+v", evs)
+	}
+}
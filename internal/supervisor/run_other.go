@@ -0,0 +1,10 @@
+//go:build !linux
+
+package supervisor
+
+import "context"
+
+// Run 非 Linux 平台不支持监护模式
+func (s *Supervisor) Run(ctx context.Context) error {
+	return ErrNotSupported
+}
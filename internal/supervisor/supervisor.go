@@ -0,0 +1,125 @@
+// Package supervisor 客户端存活保护
+// 以子进程方式运行客户端：客户端被杀死、非预期退出或被暂停时记录篡改事件
+// (通过审计日志尽量识别发送信号的进程与用户)，并按退避策略重新拉起或恢复运行。
+// 监护进程不打开数据库 (单实例锁由客户端持有)，事件写入数据目录，由重新启动的客户端导入上报
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotSupported 当前平台不支持监护模式
+var ErrNotSupported = errors.New("supervisor: not supported on this platform")
+
+// ExitKind 客户端异常类型
+type ExitKind string
+
+const (
+	ExitKilled  ExitKind = "killed"  // 被信号终止
+	ExitExited  ExitKind = "exited"  // 非监护进程要求的退出 (含收到 SIGTERM 后正常退出、崩溃)
+	ExitStopped ExitKind = "stopped" // 被暂停 (SIGSTOP 等)，已恢复运行
+)
+
+// Killer 发送信号的进程 (来自审计日志)
+type Killer struct {
+	PID  int    `json:"pid"`
+	UID  int    `json:"uid"`
+	AUID int    `json:"auid"` // 登录用户 ID，-1 表示未知 (如系统服务)
+	Comm string `json:"comm,omitempty"`
+	Exe  string `json:"exe,omitempty"`
+}
+
+// Event 客户端被杀死、退出或暂停事件
+type Event struct {
+	// 发生时间 (Unix 毫秒)
+	Time int64 `json:"time"`
+	// 客户端进程号
+	PID  int      `json:"pid"`
+	Kind ExitKind `json:"kind"`
+	// 退出码，被信号终止时为 -1
+	ExitCode int `json:"exit_code"`
+	// 终止或暂停信号
+	Signal string `json:"signal,omitempty"`
+	// 发送信号的进程，审计不可用时为空
+	Killer *Killer `json:"killer,omitempty"`
+	// 累计重启次数
+	Restarts int `json:"restarts"`
+}
+
+// Config 监护配置
+type Config struct {
+	// 客户端二进制与参数 (不含监护参数)
+	Path string
+	Args []string
+	// 数据目录，事件文件写入其中
+	DataDir string
+	// 审计日志路径，为空使用 DefaultAuditLog；设为 "-" 不识别发送信号的进程
+	AuditLog string
+	// 重启退避，<=0 时使用 1s / 1m
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// 运行超过此时长后重置退避，<=0 时使用 1m
+	StableAfter time.Duration
+	// 停止监护时等待客户端退出的时间，超时后强制结束，<=0 时使用 30s
+	StopTimeout time.Duration
+}
+
+// DefaultAuditLog auditd 默认日志路径
+const DefaultAuditLog = "/var/log/audit/audit.log"
+
+// Supervisor 客户端监护
+type Supervisor struct {
+	cfg Config
+	pid atomic.Int64 // 当前客户端进程号
+}
+
+// New 创建监护
+func New(cfg Config) *Supervisor {
+	if cfg.AuditLog == "" {
+		cfg.AuditLog = DefaultAuditLog
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.StableAfter <= 0 {
+		cfg.StableAfter = time.Minute
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = 30 * time.Second
+	}
+	return &Supervisor{cfg: cfg}
+}
+
+// Signal 将信号转发给客户端 (重载配置、原地升级等)
+func (s *Supervisor) Signal(sig os.Signal) error {
+	pid := int(s.pid.Load())
+	if pid <= 0 {
+		return errors.New("supervisor: agent not running")
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(sig)
+}
+
+// nextBackoff 下次重启前的等待时间
+func (s *Supervisor) nextBackoff(cur, ran time.Duration) time.Duration {
+	if ran >= s.cfg.StableAfter {
+		return s.cfg.MinBackoff
+	}
+	next := cur * 2
+	if next < s.cfg.MinBackoff {
+		next = s.cfg.MinBackoff
+	}
+	if next > s.cfg.MaxBackoff {
+		next = s.cfg.MaxBackoff
+	}
+	return next
+}
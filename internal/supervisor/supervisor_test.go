@@ -0,0 +1,78 @@
+package supervisor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleAudit = `type=SYSCALL msg=audit(1700000000.100:10): arch=c000003e syscall=62 success=yes exit=0 a0=7b a1=f a2=0 a3=0 items=0 ppid=1 pid=500 auid=4294967295 uid=0 gid=0 comm="systemd" exe="/usr/lib/systemd/systemd" key="filewatcherd_kill"
+type=SYSCALL msg=audit(1700000050.200:11): arch=c000003e syscall=62 success=no exit=-1 a0=7b a1=9 a2=0 a3=0 items=0 ppid=900 pid=901 auid=1000 uid=1000 gid=1000 comm="kill" exe="/usr/bin/kill" key="filewatcherd_kill"
+type=PROCTITLE msg=audit(1700000060.300:12): proctitle=6B696C6C
+type=SYSCALL msg=audit(1700000060.300:12): arch=c000003e syscall=62 success=yes exit=0 a0=7b a1=9 a2=0 a3=0 items=0 ppid=900 pid=902 auid=1000 uid=0 gid=0 comm="kill" exe="/usr/bin/kill" key="filewatcherd_kill"
+type=SYSCALL msg=audit(1700000070.000:13): arch=c000003e syscall=62 success=yes exit=0 a0=7c a1=9 a2=0 a3=0 items=0 ppid=900 pid=903 auid=1000 uid=0 gid=0 comm="kill" exe="/usr/bin/kill" key="filewatcherd_kill"
+`
+
+func TestFindKiller(t *testing.T) {
+	// pid 123 = 0x7b；失败的调用与其他进程的记录忽略，取最后一条
+	k := findKiller(strings.NewReader(sampleAudit), 123, time.Unix(1700000000, 0))
+	if k == nil {
+		t.Fatal("findKiller = nil")
+	}
+	if k.PID != 902 || k.UID != 0 || k.AUID != 1000 || k.Comm != "kill" || k.Exe != "/usr/bin/kill" {
+		t.Errorf("findKiller = %+v", k)
+	}
+
+	// 客户端启动前的记录不计入
+	if k := findKiller(strings.NewReader(sampleAudit), 123, time.Unix(1700000061, 0)); k != nil {
+		t.Errorf("findKiller before start = %+v, want nil", k)
+	}
+}
+
+func TestFindKillerUnsetAUID(t *testing.T) {
+	k := findKiller(strings.NewReader(strings.SplitAfter(sampleAudit, "\n")[0]), 123, time.Time{})
+	if k == nil || k.AUID != -1 || k.PID != 500 {
+		t.Errorf("findKiller = %+v", k)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	dir := t.TempDir()
+	if evs, err := TakeEvents(dir); evs != nil || err != nil {
+		t.Fatalf("TakeEvents empty = %v, %v", evs, err)
+	}
+
+	AppendEvent(dir, Event{PID: 1, Kind: ExitKilled, Signal: "killed"})
+	AppendEvent(dir, Event{PID: 2, Kind: ExitStopped, Killer: &Killer{PID: 9}})
+
+	evs, err := TakeEvents(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || evs[0].Kind != ExitKilled || evs[1].Killer == nil || evs[1].Killer.PID != 9 {
+		t.Errorf("TakeEvents = %+v", evs)
+	}
+	if evs, _ := TakeEvents(dir); len(evs) != 0 {
+		t.Errorf("事件应只取出一次: %+v", evs)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	s := New(Config{MinBackoff: time.Second, MaxBackoff: 4 * time.Second, StableAfter: time.Minute})
+	b := time.Duration(0)
+	var got []time.Duration
+	for i := 0; i < 4; i++ {
+		b = s.nextBackoff(b, time.Second)
+		got = append(got, b)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("backoff = %v, want %v", got, want)
+		}
+	}
+	// 稳定运行后重置
+	if b := s.nextBackoff(4*time.Second, 2*time.Minute); b != time.Second {
+		t.Errorf("stable backoff = %v", b)
+	}
+}
@@ -0,0 +1,29 @@
+package systemd
+
+import (
+	"net"
+	"sync"
+)
+
+var (
+	listenOnce sync.Once
+	listenMu   sync.Mutex
+	listeners  map[string][]net.Listener
+)
+
+// Listener 取出 socket 激活传入的指定名称 (socket 单元的 FileDescriptorName) 的监听 socket
+// 未由 socket 激活启动或没有该名称时返回 nil；每个 socket 只能取出一次
+func Listener(name string) net.Listener {
+	listenOnce.Do(func() {
+		listeners = activatedListeners()
+	})
+
+	listenMu.Lock()
+	defer listenMu.Unlock()
+	lns := listeners[name]
+	if len(lns) == 0 {
+		return nil
+	}
+	listeners[name] = lns[1:]
+	return lns[0]
+}
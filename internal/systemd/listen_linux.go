@@ -0,0 +1,53 @@
+//go:build linux
+
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFdsStart socket 激活传入的首个文件描述符 (SD_LISTEN_FDS_START)
+const listenFdsStart = 3
+
+// activatedListeners 读取 socket 激活传入的监听 socket，按 FileDescriptorName 分组
+// 读取后清除 LISTEN_* 环境变量，避免子进程 (外部插件等) 误用
+func activatedListeners() map[string][]net.Listener {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	if os.Getenv("LISTEN_PID") != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make(map[string][]net.Listener)
+	for i := 0; i < n; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := "unknown"
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		// FileListener 复制了描述符，原描述符关闭；非流式 socket (ListenDatagram 等) 不支持，忽略
+		f.Close()
+		if err != nil {
+			continue
+		}
+		listeners[name] = append(listeners[name], ln)
+	}
+	return listeners
+}
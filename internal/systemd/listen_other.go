@@ -0,0 +1,10 @@
+//go:build !linux
+
+package systemd
+
+import "net"
+
+// activatedListeners 非 Linux 平台不支持 socket 激活
+func activatedListeners() map[string][]net.Listener {
+	return nil
+}
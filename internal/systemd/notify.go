@@ -0,0 +1,49 @@
+// Package systemd systemd 集成
+// 提供 sd_notify 就绪与状态通知 (Type=notify)、与内部健康检查绑定的看门狗 (WatchdogSec)
+// 以及 socket 激活 (LISTEN_FDS)。未由 systemd 启动时各函数为空操作
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// sd_notify 状态
+const (
+	StateReady     = "READY=1"
+	StateReloading = "RELOADING=1"
+	StateStopping  = "STOPPING=1"
+	StateWatchdog  = "WATCHDOG=1"
+)
+
+// Notify 向 systemd 发送状态，多个状态在同一消息中发送
+// 未设置 NOTIFY_SOCKET (非 Type=notify 启动) 时返回 false, nil
+func Notify(states ...string) (bool, error) {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return false, nil
+	}
+	// 以 @ 开头为抽象命名空间
+	if strings.HasPrefix(addr, "@") {
+		addr = "\x00" + addr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("systemd: dial notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf("systemd: notify: %w", err)
+	}
+	return true, nil
+}
+
+// Status 状态描述，显示在 systemctl status 中
+func Status(msg string) string {
+	// 状态值为单行
+	return "STATUS=" + strings.ReplaceAll(msg, "\n", " ")
+}
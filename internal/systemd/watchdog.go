@@ -0,0 +1,127 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// WatchdogTimeout systemd 配置的看门狗超时 (WatchdogSec)，未开启或不是发给本进程时返回 0
+func WatchdogTimeout() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// Check 健康检查，返回错误表示不健康
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// ErrCheckTimeout 健康检查未在期限内返回 (可能死锁)
+var ErrCheckTimeout = errors.New("health check timed out")
+
+// Watchdog 周期执行健康检查，全部通过时发送 WATCHDOG=1
+// 任一检查失败或超时时停止喂狗并更新 STATUS，由 systemd 在 WatchdogSec 后按 Restart= 重启进程
+type Watchdog struct {
+	timeout time.Duration
+
+	mu     sync.Mutex
+	checks []Check
+	failed string // 上一轮失败的检查，用于只在状态变化时记录
+}
+
+// NewWatchdog 创建看门狗，timeout 为 systemd 的看门狗超时
+// 每 timeout/2 检查并喂狗一次，单轮检查期限为 timeout/4
+func NewWatchdog(timeout time.Duration) *Watchdog {
+	return &Watchdog{timeout: timeout}
+}
+
+// Add 注册健康检查
+func (w *Watchdog) Add(name string, fn func(ctx context.Context) error) {
+	w.mu.Lock()
+	w.checks = append(w.checks, Check{Name: name, Fn: fn})
+	w.mu.Unlock()
+}
+
+// Run 运行直到 ctx 取消
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		w.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick 执行一轮检查，通过时喂狗
+func (w *Watchdog) tick(ctx context.Context) {
+	name, err := w.check(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+
+	w.mu.Lock()
+	prev := w.failed
+	w.failed = name
+	w.mu.Unlock()
+
+	if err != nil {
+		if prev != name {
+			logger.Error("健康检查失败，停止看门狗通知", "check", name, "error", err)
+		}
+		Notify(Status(fmt.Sprintf("unhealthy: %s: %v", name, err)))
+		return
+	}
+	if prev != "" {
+		logger.Info("健康检查恢复", "check", prev)
+		Notify(StateWatchdog, Status("running"))
+		return
+	}
+	Notify(StateWatchdog)
+}
+
+// check 依次执行健康检查，返回首个失败的检查名与错误
+// 检查在独立 goroutine 中执行，超过期限视为失败 (卡死的检查不阻塞看门狗本身)
+func (w *Watchdog) check(ctx context.Context) (string, error) {
+	w.mu.Lock()
+	checks := append([]Check(nil), w.checks...)
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout/4)
+	defer cancel()
+
+	for _, c := range checks {
+		done := make(chan error, 1)
+		go func(fn func(context.Context) error) {
+			done <- fn(ctx)
+		}(c.Fn)
+
+		select {
+		case err := <-done:
+			if err != nil {
+				return c.Name, err
+			}
+		case <-ctx.Done():
+			return c.Name, ErrCheckTimeout
+		}
+	}
+	return "", nil
+}
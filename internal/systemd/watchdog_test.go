@@ -0,0 +1,74 @@
+package systemd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdogTimeout(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "60000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := WatchdogTimeout(); got != time.Minute {
+		t.Errorf("WatchdogTimeout = %v, want 1m", got)
+	}
+
+	// 发给其他进程的看门狗设置
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	if got := WatchdogTimeout(); got != 0 {
+		t.Errorf("WatchdogTimeout for other pid = %v, want 0", got)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "")
+	if got := WatchdogTimeout(); got != 0 {
+		t.Errorf("WatchdogTimeout unset = %v, want 0", got)
+	}
+}
+
+func TestWatchdogTick(t *testing.T) {
+	conn := listenNotify(t)
+
+	var healthErr error
+	w := NewWatchdog(400 * time.Millisecond)
+	w.Add("storage", func(ctx context.Context) error { return healthErr })
+
+	w.tick(context.Background())
+	if got := readNotify(t, conn); got != StateWatchdog {
+		t.Errorf("healthy tick = %q, want %q", got, StateWatchdog)
+	}
+
+	// 检查失败时不喂狗
+	healthErr = errors.New("db closed")
+	w.tick(context.Background())
+	if got := readNotify(t, conn); strings.Contains(got, StateWatchdog) || !strings.Contains(got, "storage: db closed") {
+		t.Errorf("unhealthy tick = %q", got)
+	}
+
+	// 恢复后重新喂狗并恢复状态
+	healthErr = nil
+	w.tick(context.Background())
+	if got := readNotify(t, conn); got != StateWatchdog+"\nSTATUS=running" {
+		t.Errorf("recovered tick = %q", got)
+	}
+}
+
+func TestWatchdogCheckTimeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	w := NewWatchdog(200 * time.Millisecond)
+	w.Add("ok", func(ctx context.Context) error { return nil })
+	w.Add("stuck", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	name, err := w.check(context.Background())
+	if name != "stuck" || !errors.Is(err, ErrCheckTimeout) {
+		t.Errorf("check = %q, %v, want stuck, ErrCheckTimeout", name, err)
+	}
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// transport 记录 HTTP 请求的 span 与请求指标
+type transport struct {
+	rt http.RoundTripper
+}
+
+// Transport 包装 HTTP RoundTripper，每个请求记录为 SpanHTTP 时间线 (耗时至收到响应头)
+// 追踪未开启时直接转发；URL 不记录查询参数，避免泄露令牌等敏感信息
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{rt: rt}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr := Default()
+	if tr == nil {
+		return t.rt.RoundTrip(req)
+	}
+
+	u := *req.URL
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+	tl := tr.start(SpanHTTP, u.String())
+	tl.Annotate("http.method", req.Method)
+
+	resp, err := t.rt.RoundTrip(req)
+	if err == nil {
+		tl.Annotate("http.status_code", strconv.Itoa(resp.StatusCode))
+	}
+	tl.finish(err)
+	return resp, err
+}
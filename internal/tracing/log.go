@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// logExporter 以结构化日志输出时间线
+type logExporter struct{}
+
+// NewLogExporter 创建日志导出器，不导出指标
+// 每个文件一条日志，stages 为 "阶段@开始偏移+耗时" 列表，失败的阶段附带错误；上报请求每次一条日志
+func NewLogExporter() Exporter {
+	return logExporter{}
+}
+
+func (logExporter) Export(t *Timeline) {
+	if t.Name == SpanHTTP {
+		args := []any{"url", t.Path, "duration", t.Duration().Round(time.Microsecond).String()}
+		if t.Err != "" {
+			args = append(args, "error", t.Err)
+		}
+		logger.Info("上报请求耗时", appendAttrs(args, t)...)
+		return
+	}
+
+	args := []any{
+		"path", t.Path,
+		"duration", t.Duration().Round(time.Microsecond).String(),
+		"found", t.Found,
+		"stages", formatStages(t),
+	}
+	if t.Err != "" {
+		args = append(args, "error", t.Err)
+	}
+	logger.Info("检测时间线", appendAttrs(args, t)...)
+}
+
+// appendAttrs 按键排序追加附加属性
+func appendAttrs(args []any, t *Timeline) []any {
+	attrs := t.Attrs()
+	for _, k := range sortedKeys(attrs) {
+		args = append(args, k, attrs[k])
+	}
+	return args
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (logExporter) Close() error { return nil }
+
+// formatStages 按开始时间排列阶段
+func formatStages(t *Timeline) string {
+	stages := t.Stages()
+	sort.SliceStable(stages, func(i, j int) bool {
+		return stages[i].Start.Before(stages[j].Start)
+	})
+
+	var b strings.Builder
+	for i, s := range stages {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s@%s+%s", s.Name,
+			s.Start.Sub(t.Start).Round(time.Microsecond),
+			s.Duration().Round(time.Microsecond))
+		if s.Err != "" {
+			fmt.Fprintf(&b, "(%s)", s.Err)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 指标名称
+const (
+	// 检测文件数，属性 result (hit/clean/error)、file.type
+	MetricDetectFiles = "detect.files"
+	// 单文件检测耗时 (毫秒)，属性 file.type
+	MetricDetectDuration = "detect.duration"
+	// 检测阶段耗时 (毫秒)，属性 stage
+	MetricDetectStageDuration = "detect.stage.duration"
+	// 上报请求数，属性 http.method、http.status_code (网络错误为 error)
+	MetricHTTPRequests = "http.client.requests"
+	// 上报请求耗时 (毫秒)，属性 http.method
+	MetricHTTPDuration = "http.client.duration"
+)
+
+// durationBounds 耗时直方图的桶上界 (毫秒)
+var durationBounds = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000}
+
+// Metrics 累计指标，由时间线结束时自动记录，并发安全
+type Metrics struct {
+	start time.Time
+
+	mu         sync.Mutex
+	counters   map[string]*CounterPoint
+	histograms map[string]*HistogramPoint
+}
+
+// CounterPoint 单调递增计数
+type CounterPoint struct {
+	Name  string
+	Attrs []string // 键值交替，按键排序
+	Value int64
+}
+
+// HistogramPoint 直方图，Counts[i] 为落入 (Bounds[i-1], Bounds[i]] 的次数，最后一个桶无上界
+type HistogramPoint struct {
+	Name   string
+	Attrs  []string
+	Count  int64
+	Sum    float64
+	Bounds []float64
+	Counts []int64
+}
+
+// NewMetrics 创建指标集合
+func NewMetrics() *Metrics {
+	return &Metrics{
+		start:      time.Now(),
+		counters:   make(map[string]*CounterPoint),
+		histograms: make(map[string]*HistogramPoint),
+	}
+}
+
+// Start 开始累计的时间
+func (m *Metrics) Start() time.Time {
+	return m.start
+}
+
+// seriesKey 指标名与属性组成的序列键
+func seriesKey(name string, attrs []string) string {
+	return name + "\x00" + strings.Join(attrs, "\x00")
+}
+
+// sortAttrs 按键排序键值对
+func sortAttrs(kv []string) []string {
+	type pair struct{ k, v string }
+	pairs := make([]pair, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		pairs = append(pairs, pair{kv[i], kv[i+1]})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].k < pairs[j].k })
+	out := make([]string, 0, len(pairs)*2)
+	for _, p := range pairs {
+		out = append(out, p.k, p.v)
+	}
+	return out
+}
+
+// Add 计数加 n，attrs 为键值交替的属性
+func (m *Metrics) Add(name string, n int64, attrs ...string) {
+	attrs = sortAttrs(attrs)
+	key := seriesKey(name, attrs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.counters[key]
+	if c == nil {
+		c = &CounterPoint{Name: name, Attrs: attrs}
+		m.counters[key] = c
+	}
+	c.Value += n
+}
+
+// ObserveDuration 记录一次耗时 (按毫秒分桶)
+func (m *Metrics) ObserveDuration(name string, d time.Duration, attrs ...string) {
+	ms := float64(d) / float64(time.Millisecond)
+	attrs = sortAttrs(attrs)
+	key := seriesKey(name, attrs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h := m.histograms[key]
+	if h == nil {
+		h = &HistogramPoint{
+			Name:   name,
+			Attrs:  attrs,
+			Bounds: durationBounds,
+			Counts: make([]int64, len(durationBounds)+1),
+		}
+		m.histograms[key] = h
+	}
+	h.Count++
+	h.Sum += ms
+	h.Counts[sort.SearchFloat64s(h.Bounds, ms)]++
+}
+
+// Snapshot 当前累计值副本，按名称与属性排序
+func (m *Metrics) Snapshot() ([]CounterPoint, []HistogramPoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counters := make([]CounterPoint, 0, len(m.counters))
+	for _, c := range m.counters {
+		counters = append(counters, *c)
+	}
+	histograms := make([]HistogramPoint, 0, len(m.histograms))
+	for _, h := range m.histograms {
+		cp := *h
+		cp.Counts = append([]int64(nil), h.Counts...)
+		histograms = append(histograms, cp)
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		return seriesKey(counters[i].Name, counters[i].Attrs) < seriesKey(counters[j].Name, counters[j].Attrs)
+	})
+	sort.Slice(histograms, func(i, j int) bool {
+		return seriesKey(histograms[i].Name, histograms[i].Attrs) < seriesKey(histograms[j].Name, histograms[j].Attrs)
+	})
+	return counters, histograms
+}
+
+// record 将结束的时间线计入指标
+func (m *Metrics) record(t *Timeline) {
+	switch t.Name {
+	case SpanDetect:
+		result := "clean"
+		switch {
+		case t.Err != "":
+			result = "error"
+		case t.Found:
+			result = "hit"
+		}
+		fileType := t.attr("file.type")
+		m.Add(MetricDetectFiles, 1, "result", result, "file.type", fileType)
+		m.ObserveDuration(MetricDetectDuration, t.Duration(), "file.type", fileType)
+		for _, s := range t.Stages() {
+			m.ObserveDuration(MetricDetectStageDuration, s.Duration(), "stage", s.Name)
+		}
+
+	case SpanHTTP:
+		method, status := t.attr("http.method"), t.attr("http.status_code")
+		if t.Err != "" {
+			status = "error"
+		}
+		m.Add(MetricHTTPRequests, 1, "http.method", method, "http.status_code", status)
+		m.ObserveDuration(MetricHTTPDuration, t.Duration(), "http.method", method)
+	}
+}
@@ -0,0 +1,479 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"linuxFileWatcher/internal/logger"
+)
+
+// OTLP 导出默认参数
+const (
+	DefaultOTLPEndpoint    = "http://127.0.0.1:4318/v1/traces"
+	DefaultServiceName     = "filewatcherd"
+	defaultOTLPTimeout     = 10 * time.Second
+	defaultOTLPBatchSize   = 256
+	defaultOTLPQueueSize   = 4096
+	defaultOTLPFlushPeriod = 5 * time.Second
+	defaultMetricsInterval = time.Minute
+)
+
+// instrumentationScope OTLP span 的 instrumentation scope 名称
+const instrumentationScope = "linuxFileWatcher/detector"
+
+// OTLPConfig OTLP/HTTP 导出参数
+type OTLPConfig struct {
+	// span 接收端地址，如 http://collector:4318/v1/traces
+	Endpoint string
+	// 指标接收端地址，为空时由 Endpoint 的 /v1/traces 替换为 /v1/metrics
+	MetricsEndpoint string
+	// 指标推送间隔
+	MetricsInterval time.Duration
+	// 附加请求头 (鉴权等)
+	Headers map[string]string
+	// 资源属性 service.name
+	ServiceName string
+	// 单次请求超时
+	Timeout time.Duration
+}
+
+// otlpExporter 以 OTLP/HTTP JSON 批量发送时间线：每个文件 (请求) 一个根 span，各阶段为子 span
+// 队列满时丢弃，不阻塞检测；绑定指标后按 MetricsInterval 推送累计指标
+type otlpExporter struct {
+	cfg    OTLPConfig
+	client *http.Client
+
+	mu      sync.RWMutex // 保护 closed 与关闭 queue
+	closed  bool
+	queue   chan *Timeline
+	dropped atomic.Int64
+	done    chan struct{}
+
+	metrics *Metrics
+}
+
+// NewOTLPExporter 创建 OTLP 导出器并启动后台发送
+func NewOTLPExporter(cfg OTLPConfig) (Exporter, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultOTLPEndpoint
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = DefaultServiceName
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultOTLPTimeout
+	}
+	if cfg.MetricsInterval <= 0 {
+		cfg.MetricsInterval = defaultMetricsInterval
+	}
+	if cfg.MetricsEndpoint == "" {
+		cfg.MetricsEndpoint = strings.TrimSuffix(cfg.Endpoint, "/v1/traces") + "/v1/metrics"
+	}
+	for _, ep := range []string{cfg.Endpoint, cfg.MetricsEndpoint} {
+		u, err := url.Parse(ep)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("tracing: invalid OTLP endpoint %q", ep)
+		}
+	}
+
+	e := &otlpExporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan *Timeline, defaultOTLPQueueSize),
+		done:   make(chan struct{}),
+	}
+	go e.loop()
+	return e, nil
+}
+
+func (e *otlpExporter) Export(t *Timeline) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- t:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+func (e *otlpExporter) bindMetrics(m *Metrics) {
+	e.mu.Lock()
+	e.metrics = m
+	e.mu.Unlock()
+}
+
+// Close 停止接收并发送剩余时间线与最终指标
+func (e *otlpExporter) Close() error {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return nil
+	}
+	e.closed = true
+	close(e.queue)
+	e.mu.Unlock()
+
+	<-e.done
+	return nil
+}
+
+// loop 凑满一批或定时发送
+func (e *otlpExporter) loop() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(defaultOTLPFlushPeriod)
+	defer ticker.Stop()
+	metricsTicker := time.NewTicker(e.cfg.MetricsInterval)
+	defer metricsTicker.Stop()
+
+	batch := make([]*Timeline, 0, defaultOTLPBatchSize)
+	flush := func() {
+		if dropped := e.dropped.Swap(0); dropped > 0 {
+			logger.Warn("OTLP 导出队列已满，丢弃检测时间线", "count", dropped)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(e.cfg.Endpoint, e.encode(batch)); err != nil {
+			logger.Warn("OTLP 导出检测时间线失败", "endpoint", e.cfg.Endpoint, "count", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+	pushMetrics := func() {
+		e.mu.RLock()
+		m := e.metrics
+		e.mu.RUnlock()
+		if m == nil {
+			return
+		}
+		req := e.encodeMetrics(m)
+		if len(req.ResourceMetrics[0].ScopeMetrics[0].Metrics) == 0 {
+			return
+		}
+		if err := e.send(e.cfg.MetricsEndpoint, req); err != nil {
+			logger.Warn("OTLP 导出指标失败", "endpoint", e.cfg.MetricsEndpoint, "error", err)
+		}
+	}
+
+	for {
+		select {
+		case t, ok := <-e.queue:
+			if !ok {
+				flush()
+				pushMetrics()
+				return
+			}
+			batch = append(batch, t)
+			if len(batch) >= defaultOTLPBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-metricsTicker.C:
+			pushMetrics()
+		}
+	}
+}
+
+// send 以 JSON 发送 OTLP 请求
+func (e *otlpExporter) send(endpoint string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ==========================================
+// OTLP/JSON 编码 (opentelemetry-proto trace/v1)
+// ==========================================
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// span 类型、状态码与指标聚合方式
+const (
+	spanKindInternal      = 1
+	spanKindClient        = 3
+	statusCodeError       = 2
+	temporalityCumulative = 2
+)
+
+func stringAttr(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func boolAttr(key string, value bool) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func errorStatus(msg string) *otlpStatus {
+	if msg == "" {
+		return nil
+	}
+	return &otlpStatus{Code: statusCodeError, Message: msg}
+}
+
+// randomID 随机 trace/span ID (十六进制)
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// encode 编码一批时间线
+func (e *otlpExporter) encode(batch []*Timeline) otlpRequest {
+	var spans []otlpSpan
+	for _, t := range batch {
+		traceID, rootID := randomID(16), randomID(8)
+
+		kind, status := spanKindInternal, errorStatus(t.Err)
+		var attrs []otlpKeyValue
+		extra := t.Attrs()
+		if t.Name == SpanHTTP {
+			kind = spanKindClient
+			attrs = append(attrs, stringAttr("http.url", t.Path))
+			// 4xx/5xx 响应视为失败
+			if code, _ := strconv.Atoi(extra["http.status_code"]); code >= 400 && status == nil {
+				status = errorStatus("HTTP " + extra["http.status_code"])
+			}
+		} else {
+			attrs = append(attrs, stringAttr("file.path", t.Path), boolAttr("detect.found", t.Found))
+		}
+		for _, k := range sortedKeys(extra) {
+			attrs = append(attrs, stringAttr(k, extra[k]))
+		}
+
+		spans = append(spans, otlpSpan{
+			TraceID:           traceID,
+			SpanID:            rootID,
+			Name:              t.Name,
+			Kind:              kind,
+			StartTimeUnixNano: unixNano(t.Start),
+			EndTimeUnixNano:   unixNano(t.End),
+			Attributes:        attrs,
+			Status:            status,
+		})
+		for _, s := range t.Stages() {
+			spans = append(spans, otlpSpan{
+				TraceID:           traceID,
+				SpanID:            randomID(8),
+				ParentSpanID:      rootID,
+				Name:              s.Name,
+				Kind:              spanKindInternal,
+				StartTimeUnixNano: unixNano(s.Start),
+				EndTimeUnixNano:   unixNano(s.End),
+				Status:            errorStatus(s.Err),
+			})
+		}
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: instrumentationScope}, Spans: spans}},
+	}}}
+}
+
+func (e *otlpExporter) resource() otlpResource {
+	return otlpResource{Attributes: []otlpKeyValue{stringAttr("service.name", e.cfg.ServiceName)}}
+}
+
+// ==========================================
+// OTLP/JSON 指标编码 (opentelemetry-proto metrics/v1)
+// ==========================================
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsInt             string         `json:"asInt"`
+}
+
+type otlpHistogram struct {
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+func kvAttrs(kv []string) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		attrs = append(attrs, stringAttr(kv[i], kv[i+1]))
+	}
+	return attrs
+}
+
+// encodeMetrics 编码累计指标，同名序列合并为一个指标
+func (e *otlpExporter) encodeMetrics(m *Metrics) otlpMetricsRequest {
+	counters, histograms := m.Snapshot()
+	start, now := unixNano(m.Start()), unixNano(time.Now())
+
+	var metrics []otlpMetric
+	for _, c := range counters {
+		if len(metrics) == 0 || metrics[len(metrics)-1].Name != c.Name {
+			metrics = append(metrics, otlpMetric{
+				Name: c.Name,
+				Unit: "1",
+				Sum:  &otlpSum{AggregationTemporality: temporalityCumulative, IsMonotonic: true},
+			})
+		}
+		sum := metrics[len(metrics)-1].Sum
+		sum.DataPoints = append(sum.DataPoints, otlpNumberPoint{
+			Attributes:        kvAttrs(c.Attrs),
+			StartTimeUnixNano: start,
+			TimeUnixNano:      now,
+			AsInt:             strconv.FormatInt(c.Value, 10),
+		})
+	}
+	for _, h := range histograms {
+		if len(metrics) == 0 || metrics[len(metrics)-1].Name != h.Name {
+			metrics = append(metrics, otlpMetric{
+				Name:      h.Name,
+				Unit:      "ms",
+				Histogram: &otlpHistogram{AggregationTemporality: temporalityCumulative},
+			})
+		}
+		counts := make([]string, len(h.Counts))
+		for i, n := range h.Counts {
+			counts[i] = strconv.FormatInt(n, 10)
+		}
+		hist := metrics[len(metrics)-1].Histogram
+		hist.DataPoints = append(hist.DataPoints, otlpHistogramPoint{
+			Attributes:        kvAttrs(h.Attrs),
+			StartTimeUnixNano: start,
+			TimeUnixNano:      now,
+			Count:             strconv.FormatInt(h.Count, 10),
+			Sum:               h.Sum,
+			BucketCounts:      counts,
+			ExplicitBounds:    h.Bounds,
+		})
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     e.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: instrumentationScope}, Metrics: metrics}},
+	}}}
+}
@@ -0,0 +1,244 @@
+// Package tracing 单文件检测时间线与上报请求追踪
+// 开启后记录每个文件在排队、读取、类型识别、文本抽取、各检测模块等阶段的起止时间，
+// 以及上报通道的 HTTP 请求，结束后输出为结构化日志或 OTLP span，同时汇总为检测与请求指标；
+// 未开启时各记录点为空操作
+package tracing
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// 根 span 名称
+const (
+	SpanDetect = "detect.file"
+	SpanHTTP   = "http.client"
+)
+
+// Stage 检测阶段
+type Stage struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+	// 阶段失败原因，成功为空
+	Err string
+}
+
+// Duration 阶段耗时
+func (s Stage) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Timeline 单个文件 (或单次请求) 的时间线，各方法对 nil 为空操作
+type Timeline struct {
+	// 根 span 名称: SpanDetect 或 SpanHTTP
+	Name string
+	// 文件路径；HTTP 请求为不含查询参数的 URL
+	Path  string
+	Start time.Time
+	End   time.Time
+	Found bool
+	Err   string
+
+	tracer  *Tracer
+	sampled bool // 未采样的时间线只计入指标，不导出
+
+	mu     sync.Mutex
+	stages []Stage
+	attrs  map[string]string
+}
+
+// Duration 总耗时
+func (t *Timeline) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// Stages 已记录的阶段，按结束顺序排列
+func (t *Timeline) Stages() []Stage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Stage(nil), t.stages...)
+}
+
+// Attrs 附加属性副本
+func (t *Timeline) Attrs() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	attrs := make(map[string]string, len(t.attrs))
+	for k, v := range t.attrs {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// Annotate 附加属性 (文件大小、类型等)
+func (t *Timeline) Annotate(key, value string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.attrs == nil {
+		t.attrs = make(map[string]string)
+	}
+	t.attrs[key] = value
+	t.mu.Unlock()
+}
+
+func (t *Timeline) attr(key string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.attrs[key]
+}
+
+func (t *Timeline) add(s Stage) {
+	t.mu.Lock()
+	t.stages = append(t.stages, s)
+	t.mu.Unlock()
+}
+
+// Finish 结束检测时间线
+func (t *Timeline) Finish(found bool, err error) {
+	if t == nil {
+		return
+	}
+	t.Found = found
+	t.finish(err)
+}
+
+// finish 计入指标，已采样且总耗时不低于 MinDuration 时导出
+func (t *Timeline) finish(err error) {
+	t.End = time.Now()
+	if err != nil {
+		t.Err = err.Error()
+	}
+	t.tracer.metrics.record(t)
+	if t.sampled && t.Duration() >= t.tracer.opts.MinDuration {
+		t.tracer.opts.Exporter.Export(t)
+	}
+}
+
+// Exporter 时间线导出方式
+type Exporter interface {
+	// Export 在检测 goroutine 中同步调用，不应阻塞
+	Export(t *Timeline)
+	Close() error
+}
+
+// metricsExporter 支持导出指标的导出器，创建追踪器时绑定指标
+type metricsExporter interface {
+	bindMetrics(m *Metrics)
+}
+
+// Options 追踪参数
+type Options struct {
+	// 只导出总耗时不低于该值的时间线，0 导出全部
+	MinDuration time.Duration
+	// 导出比例 (0, 1]，0 导出全部；未采样的时间线仍计入指标
+	SampleRatio float64
+	Exporter    Exporter
+}
+
+// Tracer 检测时间线追踪器
+type Tracer struct {
+	opts    Options
+	metrics *Metrics
+}
+
+// New 创建追踪器，导出器支持指标时同时导出检测与请求指标
+func New(opts Options) *Tracer {
+	if opts.Exporter == nil {
+		opts.Exporter = NewLogExporter()
+	}
+	if opts.SampleRatio <= 0 || opts.SampleRatio > 1 {
+		opts.SampleRatio = 1
+	}
+	tr := &Tracer{opts: opts, metrics: NewMetrics()}
+	if me, ok := opts.Exporter.(metricsExporter); ok {
+		me.bindMetrics(tr.metrics)
+	}
+	return tr
+}
+
+// Metrics 追踪器汇总的指标
+func (tr *Tracer) Metrics() *Metrics {
+	return tr.metrics
+}
+
+// Close 关闭导出器，等待已排队的时间线与指标发送完毕
+func (tr *Tracer) Close() error {
+	return tr.opts.Exporter.Close()
+}
+
+// start 开始根时间线，按 SampleRatio 决定是否导出
+func (tr *Tracer) start(name, path string) *Timeline {
+	return &Timeline{
+		Name:    name,
+		Path:    path,
+		Start:   time.Now(),
+		tracer:  tr,
+		sampled: tr.opts.SampleRatio >= 1 || rand.Float64() < tr.opts.SampleRatio,
+	}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultTracer *Tracer
+)
+
+// Default 返回全局追踪器，未开启时为 nil
+func Default() *Tracer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultTracer
+}
+
+// SetDefault 替换全局追踪器 (配置加载与重载时调用)，nil 关闭追踪
+// 返回旧追踪器，由调用方关闭；进行中的时间线仍导出到开始时的追踪器
+func SetDefault(tr *Tracer) *Tracer {
+	defaultMu.Lock()
+	old := defaultTracer
+	defaultTracer = tr
+	defaultMu.Unlock()
+	return old
+}
+
+// timelineKey 时间线在 context 中的键
+type timelineKey struct{}
+
+// Start 为文件开始检测时间线
+// 追踪未开启，或 ctx 已有时间线 (压缩包内文件等嵌套检测) 时返回 nil，阶段记录到外层时间线
+func Start(ctx context.Context, path string) (context.Context, *Timeline) {
+	tr := Default()
+	if tr == nil || FromContext(ctx) != nil {
+		return ctx, nil
+	}
+	t := tr.start(SpanDetect, path)
+	return context.WithValue(ctx, timelineKey{}, t), t
+}
+
+// FromContext 读取时间线，不存在时返回 nil
+func FromContext(ctx context.Context) *Timeline {
+	t, _ := ctx.Value(timelineKey{}).(*Timeline)
+	return t
+}
+
+func noop(error) {}
+
+// Begin 开始一个阶段，返回结束函数，参数为阶段的错误 (可为 nil)
+// ctx 没有时间线时返回空操作
+func Begin(ctx context.Context, name string) func(error) {
+	t := FromContext(ctx)
+	if t == nil {
+		return noop
+	}
+	start := time.Now()
+	return func(err error) {
+		s := Stage{Name: name, Start: start, End: time.Now()}
+		if err != nil {
+			s.Err = err.Error()
+		}
+		t.add(s)
+	}
+}
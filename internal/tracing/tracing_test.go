@@ -0,0 +1,252 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureExporter 记录导出的时间线
+type captureExporter struct {
+	mu        sync.Mutex
+	timelines []*Timeline
+}
+
+func (e *captureExporter) Export(t *Timeline) {
+	e.mu.Lock()
+	e.timelines = append(e.timelines, t)
+	e.mu.Unlock()
+}
+
+func (e *captureExporter) Close() error { return nil }
+
+func TestTimeline(t *testing.T) {
+	// 未开启时为空操作
+	SetDefault(nil)
+	ctx, tl := Start(context.Background(), "/tmp/a.txt")
+	if tl != nil || FromContext(ctx) != nil {
+		t.Fatal("未开启追踪时不应创建时间线")
+	}
+	Begin(ctx, "read")(nil)
+	tl.Annotate("file.size", "1")
+	tl.Finish(false, nil)
+
+	exp := &captureExporter{}
+	SetDefault(New(Options{Exporter: exp}))
+	defer SetDefault(nil)
+
+	ctx, tl = Start(context.Background(), "/tmp/a.zip")
+	tl.Annotate("file.type", "zip")
+	Begin(ctx, "read")(nil)
+	end := Begin(ctx, "extract")
+	end(errors.New("bad xref"))
+
+	// 嵌套检测记录到外层时间线
+	nested, inner := Start(ctx, "/tmp/a.zip!/b.txt")
+	if inner != nil {
+		t.Fatal("嵌套检测不应创建新时间线")
+	}
+	Begin(nested, "detect.keyword_detect")(nil)
+	tl.Finish(true, nil)
+
+	if len(exp.timelines) != 1 {
+		t.Fatalf("导出 %d 条时间线, want 1", len(exp.timelines))
+	}
+	got := exp.timelines[0]
+	stages := got.Stages()
+	if len(stages) != 3 || stages[0].Name != "read" || stages[1].Err != "bad xref" || stages[2].Name != "detect.keyword_detect" {
+		t.Errorf("阶段 = %+v", stages)
+	}
+	if !got.Found || got.Attrs()["file.type"] != "zip" || got.End.Before(got.Start) {
+		t.Errorf("时间线 = %+v", got)
+	}
+	if s := formatStages(got); !strings.Contains(s, "extract@") || !strings.Contains(s, "(bad xref)") {
+		t.Errorf("formatStages() = %q", s)
+	}
+}
+
+func TestTimeline_MinDuration(t *testing.T) {
+	exp := &captureExporter{}
+	SetDefault(New(Options{MinDuration: time.Hour, Exporter: exp}))
+	defer SetDefault(nil)
+
+	_, tl := Start(context.Background(), "/tmp/fast.txt")
+	tl.Finish(false, nil)
+	if len(exp.timelines) != 0 {
+		t.Error("低于 MinDuration 的时间线不应导出")
+	}
+}
+
+func TestTimeline_Sampling(t *testing.T) {
+	exp := &captureExporter{}
+	tr := New(Options{SampleRatio: 0.000001, Exporter: exp})
+	SetDefault(tr)
+	defer SetDefault(nil)
+
+	for i := 0; i < 100; i++ {
+		_, tl := Start(context.Background(), "/tmp/a.txt")
+		tl.Finish(i%2 == 0, nil)
+	}
+	if len(exp.timelines) > 1 {
+		t.Errorf("采样后导出 %d 条时间线", len(exp.timelines))
+	}
+	// 未采样的时间线仍计入指标
+	counters, histograms := tr.Metrics().Snapshot()
+	var total int64
+	for _, c := range counters {
+		if c.Name == MetricDetectFiles {
+			total += c.Value
+		}
+	}
+	if total != 100 || len(histograms) == 0 {
+		t.Errorf("指标计数 = %d, 直方图 %d 个", total, len(histograms))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.Add(MetricHTTPRequests, 1, "http.status_code", "200", "http.method", "POST")
+	m.Add(MetricHTTPRequests, 2, "http.method", "POST", "http.status_code", "200")
+	m.ObserveDuration(MetricHTTPDuration, 3*time.Millisecond)
+	m.ObserveDuration(MetricHTTPDuration, 2*time.Minute)
+	m.ObserveDuration(MetricHTTPDuration, time.Millisecond)
+
+	counters, histograms := m.Snapshot()
+	if len(counters) != 1 || counters[0].Value != 3 || counters[0].Attrs[0] != "http.method" {
+		t.Errorf("counters = %+v", counters)
+	}
+	h := histograms[0]
+	// 1ms 落入第一个桶 (上界含)，3ms 落入 (1, 5]，2 分钟落入无上界的桶
+	if h.Count != 3 || h.Counts[0] != 1 || h.Counts[1] != 1 || h.Counts[len(h.Counts)-1] != 1 {
+		t.Errorf("histogram = %+v", h)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	// 未开启时直接转发
+	SetDefault(nil)
+	if resp, err := client.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	exp := &captureExporter{}
+	tr := New(Options{Exporter: exp})
+	SetDefault(tr)
+	defer SetDefault(nil)
+
+	resp, err := client.Post(srv.URL+"/api/report?token=secret", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(exp.timelines) != 1 {
+		t.Fatalf("导出 %d 条时间线, want 1", len(exp.timelines))
+	}
+	got := exp.timelines[0]
+	if got.Name != SpanHTTP || got.Path != srv.URL+"/api/report" || got.Attrs()["http.status_code"] != "503" {
+		t.Errorf("时间线 = %+v %v", got, got.Attrs())
+	}
+	counters, _ := tr.Metrics().Snapshot()
+	if len(counters) != 1 || counters[0].Name != MetricHTTPRequests || counters[0].Attrs[3] != "503" {
+		t.Errorf("counters = %+v", counters)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		traces  []otlpRequest
+		metrics []otlpMetricsRequest
+		auth    string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/v1/traces":
+			var req otlpRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("解析 span 请求失败: %v", err)
+			}
+			traces = append(traces, req)
+		case "/v1/metrics":
+			var req otlpMetricsRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("解析指标请求失败: %v", err)
+			}
+			metrics = append(metrics, req)
+		default:
+			t.Errorf("未知路径 %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	if _, err := NewOTLPExporter(OTLPConfig{Endpoint: "collector:4318"}); err == nil {
+		t.Error("无效地址应返回错误")
+	}
+
+	exp, err := NewOTLPExporter(OTLPConfig{Endpoint: srv.URL + "/v1/traces", Headers: map[string]string{"Authorization": "Bearer x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetDefault(New(Options{Exporter: exp}))
+	defer SetDefault(nil)
+
+	ctx, tl := Start(context.Background(), "/tmp/a.pdf")
+	Begin(ctx, "extract")(errors.New("timeout"))
+	tl.Finish(false, nil)
+
+	// Close 发送剩余时间线与指标，之后的导出被忽略
+	exp.Close()
+	exp.Export(tl)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(traces) != 1 || len(metrics) != 1 || auth != "Bearer x" {
+		t.Fatalf("span 请求 %d, 指标请求 %d, Authorization = %q", len(traces), len(metrics), auth)
+	}
+	rs := traces[0].ResourceSpans[0]
+	if v := rs.Resource.Attributes[0].Value.StringValue; v == nil || *v != DefaultServiceName {
+		t.Errorf("service.name = %v", v)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("span 数 = %d, want 2", len(spans))
+	}
+	root, stage := spans[0], spans[1]
+	if root.Name != SpanDetect || len(root.TraceID) != 32 || len(root.SpanID) != 16 || root.Status != nil {
+		t.Errorf("根 span = %+v", root)
+	}
+	if stage.TraceID != root.TraceID || stage.ParentSpanID != root.SpanID || stage.Status == nil || stage.Status.Code != statusCodeError {
+		t.Errorf("阶段 span = %+v", stage)
+	}
+
+	names := map[string]otlpMetric{}
+	for _, m := range metrics[0].ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		names[m.Name] = m
+	}
+	files, ok := names[MetricDetectFiles]
+	if !ok || files.Sum == nil || !files.Sum.IsMonotonic || files.Sum.DataPoints[0].AsInt != "1" {
+		t.Errorf("%s = %+v", MetricDetectFiles, files)
+	}
+	dur, ok := names[MetricDetectDuration]
+	if !ok || dur.Histogram == nil || len(dur.Histogram.DataPoints[0].BucketCounts) != len(durationBounds)+1 {
+		t.Errorf("%s = %+v", MetricDetectDuration, dur)
+	}
+}
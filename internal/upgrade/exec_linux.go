@@ -0,0 +1,86 @@
+//go:build linux
+
+package upgrade
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// 交接监听 socket 的环境变量
+// 格式与 systemd socket 激活类似，但描述符编号不固定 (不能覆盖 3 号起的描述符，运行时可能正在使用)
+const (
+	envPID = "FILEWATCHERD_UPGRADE_PID"
+	envFDs = "FILEWATCHERD_UPGRADE_FDS" // name=fd,name=fd
+)
+
+// Exec 以新版本二进制替换当前进程，files 在新进程中通过 Listener 取出
+// 成功时不返回；失败时已复制的描述符被关闭，当前进程状态不变
+func Exec(binary string, args []string, files []File) error {
+	var fds []int
+	closeFds := func() {
+		for _, fd := range fds {
+			syscall.Close(fd)
+		}
+	}
+
+	pairs := make([]string, 0, len(files))
+	for _, f := range files {
+		// dup 得到的描述符不带 CLOEXEC，exec 后保留
+		fd, err := syscall.Dup(int(f.File.Fd()))
+		if err != nil {
+			closeFds()
+			return fmt.Errorf("upgrade: dup %s: %w", f.Name, err)
+		}
+		fds = append(fds, fd)
+		pairs = append(pairs, f.Name+"="+strconv.Itoa(fd))
+	}
+
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envPID+"=") && !strings.HasPrefix(kv, envFDs+"=") {
+			env = append(env, kv)
+		}
+	}
+	if len(pairs) > 0 {
+		env = append(env, envPID+"="+strconv.Itoa(os.Getpid()), envFDs+"="+strings.Join(pairs, ","))
+	}
+
+	err := syscall.Exec(binary, args, env)
+	closeFds()
+	return fmt.Errorf("upgrade: exec %s: %w", binary, err)
+}
+
+// inheritedListeners 读取旧进程交接的监听 socket，读取后清除环境变量避免子进程误用
+func inheritedListeners() map[string]net.Listener {
+	defer func() {
+		os.Unsetenv(envPID)
+		os.Unsetenv(envFDs)
+	}()
+
+	if os.Getenv(envPID) != strconv.Itoa(os.Getpid()) {
+		return nil
+	}
+	listeners := make(map[string]net.Listener)
+	for _, pair := range strings.Split(os.Getenv(envFDs), ",") {
+		name, fdStr, ok := strings.Cut(pair, "=")
+		fd, err := strconv.Atoi(fdStr)
+		if !ok || err != nil || fd < 3 {
+			continue
+		}
+		syscall.CloseOnExec(fd)
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		listeners[name] = ln
+	}
+	return listeners
+}
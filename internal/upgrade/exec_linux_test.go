@@ -0,0 +1,62 @@
+//go:build linux
+
+package upgrade
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestInheritedListeners(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "detect.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	f, err := ln.(*net.UnixListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	// 模拟 Exec 交接: 复制出的描述符编号通过环境变量传递
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envPID, strconv.Itoa(os.Getpid()))
+	t.Setenv(envFDs, "detectapi="+strconv.Itoa(fd)+",bad=x")
+
+	got := inheritedListeners()
+	if len(got) != 1 || got["detectapi"] == nil {
+		t.Fatalf("inheritedListeners = %v", got)
+	}
+	defer got["detectapi"].Close()
+	if os.Getenv(envFDs) != "" {
+		t.Error("environment not cleared")
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	accepted, err := got["detectapi"].Accept()
+	if err != nil {
+		t.Fatalf("Accept on inherited listener: %v", err)
+	}
+	accepted.Close()
+}
+
+func TestInheritedListenersOtherPID(t *testing.T) {
+	t.Setenv(envPID, strconv.Itoa(os.Getpid()+1))
+	t.Setenv(envFDs, "detectapi=3")
+	if got := inheritedListeners(); got != nil {
+		t.Errorf("inheritedListeners = %v, want nil", got)
+	}
+}
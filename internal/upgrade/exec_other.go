@@ -0,0 +1,15 @@
+//go:build !linux
+
+package upgrade
+
+import "net"
+
+// Exec 非 Linux 平台不支持原地升级
+func Exec(binary string, args []string, files []File) error {
+	return ErrNotSupported
+}
+
+// inheritedListeners 非 Linux 平台没有交接的监听 socket
+func inheritedListeners() map[string]net.Listener {
+	return nil
+}
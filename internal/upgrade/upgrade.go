@@ -0,0 +1,113 @@
+// Package upgrade 原地升级
+// 旧进程在限定时间内等待处理中的扫描完成、将状态落盘后 exec 新版本二进制 (PID 不变，systemd 视为重载)，
+// 并把本机检测服务的监听 socket 交给新进程：升级期间提交的检测请求在 socket 队列中等待。
+// 扫描任务在完成前一直保留在任务日志中，排队或超时被中断的任务由新进程恢复 (中断的任务会重新扫描)
+package upgrade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// StateFileName 数据目录下的升级交接状态文件
+const StateFileName = "upgrade-state.json"
+
+// ErrNotSupported 当前平台不支持原地升级
+var ErrNotSupported = errors.New("upgrade: in-place upgrade not supported on this platform")
+
+// State 升级交接状态，由旧进程写入数据目录，新进程启动时读取并删除
+type State struct {
+	// 旧进程版本
+	FromVersion string `json:"from_version"`
+	// 进程号 (exec 前后不变)
+	PID int `json:"pid"`
+	// 开始升级时间 (Unix 毫秒)
+	StartedAt int64 `json:"started_at"`
+	// 已持久化、待新进程恢复的扫描任务数
+	PendingJobs int `json:"pending_jobs"`
+	// 交接的监听 socket 名称
+	Listeners []string `json:"listeners,omitempty"`
+}
+
+// File 交接给新进程的文件 (监听 socket)
+type File struct {
+	Name string
+	File *os.File
+}
+
+// SaveState 写入交接状态
+// 先写临时文件再重命名，避免新进程读到不完整的内容
+func SaveState(dir string, st *State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("upgrade: marshal state: %w", err)
+	}
+	path := filepath.Join(dir, StateFileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("upgrade: write state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("upgrade: write state: %w", err)
+	}
+	return nil
+}
+
+// LoadState 读取并删除交接状态，非升级启动时返回 nil, nil
+// 状态只读取一次，之后的普通重启不会被误认为升级
+func LoadState(dir string) (*State, error) {
+	path := filepath.Join(dir, StateFileName)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("upgrade: read state: %w", err)
+	}
+	os.Remove(path)
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("upgrade: parse state: %w", err)
+	}
+	return &st, nil
+}
+
+// CheckBinary 检查新版本二进制是否为可执行的普通文件
+// 在停止任何服务之前调用，目标不可用时放弃升级、继续运行
+func CheckBinary(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("upgrade: %w", err)
+	}
+	if !fi.Mode().IsRegular() || fi.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("upgrade: %s is not an executable file", path)
+	}
+	return nil
+}
+
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inherited   map[string]net.Listener
+)
+
+// Listener 取出旧进程交接的指定名称的监听 socket
+// 非升级启动或没有该名称时返回 nil；每个 socket 只能取出一次
+func Listener(name string) net.Listener {
+	inheritOnce.Do(func() {
+		inherited = inheritedListeners()
+	})
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+	ln := inherited[name]
+	delete(inherited, name)
+	return ln
+}
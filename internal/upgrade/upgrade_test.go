@@ -0,0 +1,50 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	want := &State{FromVersion: "1.2.0", PID: 42, StartedAt: 1700000000000, PendingJobs: 3, Listeners: []string{"detectapi"}}
+	if err := SaveState(dir, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadState = %+v, want %+v", got, want)
+	}
+
+	// 只读取一次
+	if got, err := LoadState(dir); got != nil || err != nil {
+		t.Errorf("second LoadState = %+v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestCheckBinary(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "filewatcherd")
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	plain := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(plain, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CheckBinary(exe); err != nil {
+		t.Errorf("CheckBinary(exe) = %v", err)
+	}
+	for _, path := range []string{plain, dir, filepath.Join(dir, "missing")} {
+		if err := CheckBinary(path); err == nil {
+			t.Errorf("CheckBinary(%s) = nil, want error", path)
+		}
+	}
+}
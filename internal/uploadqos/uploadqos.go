@@ -0,0 +1,381 @@
+// Package uploadqos 上报请求限流与上传带宽控制
+// 每个上报接口按令牌桶限制请求速率与突发，请求体按总带宽限速，工作时间可使用更低的带宽上限，
+// 避免一次大范围扫描产生的大量告警占满分支机构的出口带宽
+package uploadqos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limit 请求速率限制
+type Limit struct {
+	// 每秒请求数，<=0 不限
+	Rate float64
+	// 允许的突发请求数，<=0 时取 Rate 向上取整
+	Burst int
+}
+
+// BusinessHours 工作时间带宽上限
+type BusinessHours struct {
+	// 工作时间内的上传带宽 (KB/s)，<=0 不单独限制
+	BandwidthKBps float64
+	// 开始与结束时间 (HH:MM，本地时间)，结束早于开始表示跨午夜
+	Start string
+	End   string
+	// 生效的星期 (0 为周日)，为空表示每天
+	Weekdays []time.Weekday
+}
+
+// Config 限流配置
+type Config struct {
+	// 每个上报接口的默认限制，各接口独立计数
+	Default Limit
+	// 按接口路径前缀覆盖默认限制，最长前缀优先
+	Endpoints map[string]Limit
+	// 上传总带宽 (KB/s)，<=0 不限
+	BandwidthKBps float64
+	BusinessHours BusinessHours
+}
+
+// Limiter 上报限流器
+type Limiter struct {
+	mu sync.Mutex
+
+	cfg      Config
+	prefixes []string // Endpoints 的键，按长度降序
+	start    int      // 工作时间开始 (当日分钟数)
+	end      int
+	days     map[time.Weekday]bool
+
+	endpoints map[string]*bucket
+	bandwidth *bucket
+
+	now func() time.Time
+}
+
+// New 创建限流器
+func New(cfg Config) (*Limiter, error) {
+	l := &Limiter{now: time.Now}
+	if err := l.Update(cfg); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Update 更新限流配置 (配置重载时调用)，已有的令牌桶重新计数
+func (l *Limiter) Update(cfg Config) error {
+	var start, end int
+	bh := cfg.BusinessHours
+	if bh.BandwidthKBps > 0 {
+		var err error
+		if start, err = parseClock(bh.Start); err != nil {
+			return fmt.Errorf("uploadqos: business hours start: %w", err)
+		}
+		if end, err = parseClock(bh.End); err != nil {
+			return fmt.Errorf("uploadqos: business hours end: %w", err)
+		}
+	}
+	days := make(map[time.Weekday]bool, len(bh.Weekdays))
+	for _, d := range bh.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return fmt.Errorf("uploadqos: invalid weekday %d", d)
+		}
+		days[d] = true
+	}
+
+	endpoints := make(map[string]Limit, len(cfg.Endpoints))
+	prefixes := make([]string, 0, len(cfg.Endpoints))
+	for p, lim := range cfg.Endpoints {
+		p = strings.ToLower(p)
+		endpoints[p] = lim
+		prefixes = append(prefixes, p)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	cfg.Endpoints = endpoints
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+	l.prefixes = prefixes
+	l.start, l.end, l.days = start, end, days
+	l.endpoints = make(map[string]*bucket)
+	l.bandwidth = nil
+	return nil
+}
+
+// Wait 等待接口 endpoint (URL 路径) 的请求配额
+func (l *Limiter) Wait(ctx context.Context, endpoint string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	b := l.endpointBucket(endpoint)
+	l.mu.Unlock()
+	return l.take(ctx, b, 1)
+}
+
+// WaitBytes 等待上传 n 个字节的带宽配额
+func (l *Limiter) WaitBytes(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	b := l.bandwidthBucket()
+	l.mu.Unlock()
+	return l.take(ctx, b, float64(n))
+}
+
+// Bandwidth 当前生效的上传带宽上限 (KB/s)，0 表示不限
+func (l *Limiter) Bandwidth() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bandwidthAt(l.now())
+}
+
+// endpointBucket 返回接口的令牌桶，不限速时为 nil (调用方持有 mu)
+func (l *Limiter) endpointBucket(endpoint string) *bucket {
+	endpoint = strings.ToLower(endpoint)
+	key, lim := "", l.cfg.Default
+	for _, p := range l.prefixes {
+		if strings.HasPrefix(endpoint, p) {
+			key, lim = p, l.cfg.Endpoints[p]
+			break
+		}
+	}
+	if lim.Rate <= 0 {
+		return nil
+	}
+	if key == "" {
+		// 默认限制按接口分别计数
+		key = "=" + endpoint
+	}
+	b, ok := l.endpoints[key]
+	if !ok {
+		burst := float64(lim.Burst)
+		if burst <= 0 {
+			burst = math.Ceil(lim.Rate)
+		}
+		b = newBucket(lim.Rate, burst, l.now())
+		l.endpoints[key] = b
+	}
+	return b
+}
+
+// bandwidthBucket 返回总带宽令牌桶，进出工作时间时调整速率 (调用方持有 mu)
+func (l *Limiter) bandwidthBucket() *bucket {
+	now := l.now()
+	kbps := l.bandwidthAt(now)
+	if kbps <= 0 {
+		l.bandwidth = nil
+		return nil
+	}
+	rate := kbps * 1024
+	if l.bandwidth == nil {
+		// 桶容量为 1 秒的配额，允许短时突发
+		l.bandwidth = newBucket(rate, rate, now)
+	} else if l.bandwidth.rate != rate {
+		l.bandwidth.setRate(rate, rate, now)
+	}
+	return l.bandwidth
+}
+
+// bandwidthAt 指定时刻的带宽上限 (KB/s)，工作时间取两者中较低的限制
+func (l *Limiter) bandwidthAt(t time.Time) float64 {
+	limit := l.cfg.BandwidthKBps
+	bh := l.cfg.BusinessHours.BandwidthKBps
+	if bh <= 0 || !l.inBusinessHours(t) {
+		return math.Max(limit, 0)
+	}
+	if limit <= 0 || bh < limit {
+		return bh
+	}
+	return limit
+}
+
+func (l *Limiter) inBusinessHours(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	var in bool
+	switch {
+	case l.start == l.end:
+		in = true
+	case l.start < l.end:
+		in = minute >= l.start && minute < l.end
+	default:
+		// 跨午夜: 凌晨部分属于前一天的工作时间
+		if minute < l.end {
+			day = (day + 6) % 7
+			in = true
+		} else {
+			in = minute >= l.start
+		}
+	}
+	return in && (len(l.days) == 0 || l.days[day])
+}
+
+// take 从令牌桶取 n 个令牌，不足时等待
+func (l *Limiter) take(ctx context.Context, b *bucket, n float64) error {
+	if b == nil {
+		return nil
+	}
+	l.mu.Lock()
+	wait := b.reserve(l.now(), n)
+	l.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.cancel(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// parseClock 解析 HH:MM 为当日分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ==========================================
+// 令牌桶
+// ==========================================
+
+// bucket 令牌桶，令牌可以预支为负数，后来的请求排在其后等待
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate, burst float64, now time.Time) *bucket {
+	return &bucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+func (b *bucket) fill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// reserve 扣减 n 个令牌并返回需要等待的时间；单次超过桶容量时按桶容量扣减，避免永远等不到
+func (b *bucket) reserve(now time.Time, n float64) time.Duration {
+	b.fill(now)
+	b.tokens -= math.Min(n, b.burst)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel 等待被取消时归还令牌
+func (b *bucket) cancel(n float64) {
+	b.tokens = math.Min(b.burst, b.tokens+math.Min(n, b.burst))
+}
+
+func (b *bucket) setRate(rate, burst float64, now time.Time) {
+	b.fill(now)
+	b.rate, b.burst = rate, burst
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+}
+
+// ==========================================
+// HTTP 传输层
+// ==========================================
+
+// Transport 为上报 HTTP 客户端加上限流：请求前等待接口配额，请求体按带宽限速发送
+// 每个请求使用发送时的全局限流器 (Default)，未启用时直接发送
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{next: rt}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := Default()
+	if l == nil {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	if err := l.Wait(ctx, req.URL.Path); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(ctx)
+		req.Body = &throttledBody{ctx: ctx, body: req.Body, l: l}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// throttledBody 每次读取后按实际字节数扣减带宽令牌
+type throttledBody struct {
+	ctx  context.Context
+	body io.ReadCloser
+	l    *Limiter
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		if werr := b.l.WaitBytes(b.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (b *throttledBody) Close() error {
+	return b.body.Close()
+}
+
+// ==========================================
+// 全局实例
+// ==========================================
+
+var defaultLimiter atomic.Pointer[Limiter]
+
+// SetDefault 设置全局限流器 (由主程序按配置设置)，nil 表示不限流
+func SetDefault(l *Limiter) {
+	defaultLimiter.Store(l)
+}
+
+// Default 返回全局限流器，未启用时为 nil
+func Default() *Limiter {
+	return defaultLimiter.Load()
+}
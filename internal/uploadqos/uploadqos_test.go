@@ -0,0 +1,178 @@
+package uploadqos
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Endpoints(t *testing.T) {
+	l, err := New(Config{
+		Default:   Limit{Rate: 1, Burst: 2},
+		Endpoints: map[string]Limit{"/api/alert": {Rate: 10, Burst: 1}, "/api/heartbeat": {}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	l.now = func() time.Time { return now }
+
+	reserve := func(endpoint string) time.Duration {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		b := l.endpointBucket(endpoint)
+		if b == nil {
+			return 0
+		}
+		return b.reserve(now, 1)
+	}
+
+	// 默认限制: 突发 2 个后按每秒 1 个排队
+	if reserve("/api/audit") != 0 || reserve("/api/audit") != 0 {
+		t.Error("burst requests delayed")
+	}
+	if d := reserve("/api/audit"); d != time.Second {
+		t.Errorf("third request wait = %v, want 1s", d)
+	}
+	// 默认限制按接口分别计数
+	if d := reserve("/api/status"); d != 0 {
+		t.Errorf("other endpoint wait = %v", d)
+	}
+	// 前缀覆盖，大小写不敏感
+	reserve("/API/alert/batch")
+	if d := reserve("/api/alert/batch"); d != 100*time.Millisecond {
+		t.Errorf("alert wait = %v, want 100ms", d)
+	}
+	// 速率为 0 的接口不限
+	for i := 0; i < 10; i++ {
+		if d := reserve("/api/heartbeat"); d != 0 {
+			t.Fatalf("heartbeat wait = %v", d)
+		}
+	}
+	// 时间推移后补充令牌
+	now = now.Add(2 * time.Second)
+	if d := reserve("/api/audit"); d != 0 {
+		t.Errorf("wait after refill = %v", d)
+	}
+}
+
+func TestLimiter_BusinessHours(t *testing.T) {
+	l, err := New(Config{
+		BandwidthKBps: 1024,
+		BusinessHours: BusinessHours{
+			BandwidthKBps: 128,
+			Start:         "08:00",
+			End:           "18:00",
+			Weekdays:      []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	friday := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		at   time.Time
+		want float64
+	}{
+		{friday.Add(9 * time.Hour), 128},
+		{friday.Add(7*time.Hour + 59*time.Minute), 1024},
+		{friday.Add(18 * time.Hour), 1024},
+		{friday.Add(-14 * time.Hour), 128}, // 周四 10:00
+		{friday.Add(34 * time.Hour), 1024}, // 周六
+	}
+	for _, tt := range tests {
+		l.now = func() time.Time { return tt.at }
+		if got := l.Bandwidth(); got != tt.want {
+			t.Errorf("Bandwidth() at %v = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+
+	// 跨午夜的时段，凌晨部分按前一天的星期判断
+	l.Update(Config{BusinessHours: BusinessHours{BandwidthKBps: 64, Start: "22:00", End: "06:00", Weekdays: []time.Weekday{time.Friday}}})
+	for _, tt := range []struct {
+		at   time.Time
+		want float64
+	}{
+		{friday.Add(23 * time.Hour), 64},
+		{friday.Add(29 * time.Hour), 64}, // 周六 05:00
+		{friday.Add(5 * time.Hour), 0},   // 周五 05:00 属于周四
+	} {
+		l.now = func() time.Time { return tt.at }
+		if got := l.Bandwidth(); got != tt.want {
+			t.Errorf("overnight Bandwidth() at %v = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := New(Config{BusinessHours: BusinessHours{BandwidthKBps: 1, Start: "8am", End: "18:00"}}); err == nil {
+		t.Error("New() accepted invalid start time")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = len(data)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(nil)}
+	post := func(ctx context.Context, size int) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/api/alert", bytes.NewReader(make([]byte, size)))
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// 未设置全局限流器时直接发送
+	if err := post(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	l, _ := New(Config{Default: Limit{Rate: 20, Burst: 1}, BandwidthKBps: 64})
+	SetDefault(l)
+	defer SetDefault(nil)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := post(context.Background(), 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 requests at 20/s took %v", elapsed)
+	}
+
+	// 请求体按带宽限速: 64KB 桶容量之外的 32KB 需要约 0.5 秒
+	start = time.Now()
+	if err := post(context.Background(), 96*1024); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("96KB at 64KB/s took %v", elapsed)
+	}
+	mu.Lock()
+	if received != 96*1024 {
+		t.Errorf("received %d bytes", received)
+	}
+	mu.Unlock()
+
+	// 等待配额时取消请求
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := post(ctx, 96*1024); err == nil {
+		t.Error("request not cancelled while throttled")
+	}
+}
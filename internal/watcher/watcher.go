@@ -0,0 +1,325 @@
+// Package watcher 基于 fsnotify 的跨平台文件写入监控
+// fsnotify 在 Linux 上使用 inotify，在 macOS 上使用 kqueue，在 Windows 上使用 ReadDirectoryChangesW。
+// 与 fanotify 相比，事件不携带发起进程，且需逐个目录添加监听；
+// 用于不支持 fanotify 的平台，或 Linux 上缺少 CAP_SYS_ADMIN 时的回退
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"linuxFileWatcher/internal/logger"
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+// DefaultSettle 默认的写入静默时间
+const DefaultSettle = 500 * time.Millisecond
+
+// renamePair 改名的 Rename (原路径) 与 Create (新路径) 事件的最大间隔
+// inotify 的 IN_MOVED_FROM 与 IN_MOVED_TO 相邻到达，超过该间隔视为移出监控范围
+const renamePair = time.Second
+
+// Op 文件变更类型，可组合
+type Op uint8
+
+const (
+	OpCreate Op = 1 << iota // 新建文件
+	OpWrite                 // 写入文件
+	OpRename                // 由监控范围内的其他路径改名或移动而来，同时带 OpCreate
+)
+
+// Has 是否包含指定变更类型
+func (o Op) Has(op Op) bool { return o&op != 0 }
+
+// Event 文件事件
+// 同一文件在静默时间内的多次变更合并为一个事件，语义上接近 fanotify 的 FAN_CLOSE_WRITE
+type Event struct {
+	Path string // 文件路径
+	Op   Op     // 静默时间内发生过的变更
+	From string // Op 含 OpRename 时为改名前的路径
+}
+
+// Options 监控选项
+type Options struct {
+	// 路径过滤器，为 nil 时使用 pathfilter.Default()
+	Filter *pathfilter.Filter
+	// 文件最后一次变更后等待多久再上报，0 使用 DefaultSettle
+	Settle time.Duration
+}
+
+// Watcher 递归目录监控
+// 新建的子目录会自动加入监听，被过滤规则排除的目录不监听
+// 注意: macOS 的 kqueue 为每个文件占用一个文件描述符，监控大目录前需调高 ulimit -n
+type Watcher struct {
+	opts Options
+	fw   *fsnotify.Watcher
+
+	mu      sync.Mutex
+	roots   []string
+	pending map[string]*pendingEvent
+	renamed renamedFile
+}
+
+type pendingEvent struct {
+	op   Op
+	from string
+	last time.Time
+}
+
+// renamedFile 最近一次 Rename 事件，等待与新路径的 Create 事件配对
+type renamedFile struct {
+	path string
+	time time.Time
+}
+
+// New 创建监控器
+func New(opts Options) (*Watcher, error) {
+	if opts.Settle <= 0 {
+		opts.Settle = DefaultSettle
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create fsnotify watcher failed: %w", err)
+	}
+	return &Watcher{
+		opts:    opts,
+		fw:      fw,
+		pending: make(map[string]*pendingEvent),
+	}, nil
+}
+
+// Add 递归监听目录
+func (w *Watcher) Add(root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", root)
+	}
+
+	w.mu.Lock()
+	w.roots = append(w.roots, root)
+	w.mu.Unlock()
+	return w.addTree(root, root, false)
+}
+
+// addTree 监听 dir 及其子目录
+// existing 为 true 时 (运行中新建的目录) 目录中已有的文件也作为新建文件上报，
+// 避免在添加监听前写入的文件被遗漏
+func (w *Watcher) addTree(root, dir string, existing bool) error {
+	f := w.filter()
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != dir {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		depth := pathfilter.Depth(root, path)
+		if !d.IsDir() {
+			if existing && d.Type().IsRegular() && !f.SkipFile(path, depth) {
+				w.mark(path, OpCreate, time.Now())
+			}
+			return nil
+		}
+		if path != root && f.SkipDir(path, depth) {
+			return fs.SkipDir
+		}
+		if err := w.fw.Add(path); err != nil {
+			if path == dir {
+				return fmt.Errorf("watch %s failed: %w", path, err)
+			}
+			logger.Warn("添加目录监听失败", "path", path, "error", err)
+			return fs.SkipDir
+		}
+		return nil
+	})
+}
+
+// Run 读取事件，文件静默 Settle 时间后回调，直到 ctx 取消或监控器关闭
+func (w *Watcher) Run(ctx context.Context, handler func(Event)) error {
+	ticker := time.NewTicker(w.opts.Settle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.fw.Events:
+			if !ok {
+				return nil
+			}
+			w.handle(ev)
+		case err, ok := <-w.fw.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				logger.Warn("文件事件队列溢出，部分变更可能未被监控到")
+				continue
+			}
+			logger.Warn("文件监控出错", "error", err)
+		case now := <-ticker.C:
+			for _, ev := range w.settled(now) {
+				handler(ev)
+			}
+		}
+	}
+}
+
+// handle 处理单个 fsnotify 事件
+func (w *Watcher) handle(ev fsnotify.Event) {
+	path := filepath.Clean(ev.Name)
+	now := time.Now()
+
+	var op Op
+	switch {
+	case ev.Has(fsnotify.Create):
+		op = OpCreate
+	case ev.Has(fsnotify.Write):
+		op = OpWrite
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		// 文件已不在原路径，丢弃未上报的变更；移入的目标路径会另有 Create 事件
+		w.mu.Lock()
+		delete(w.pending, path)
+		if ev.Has(fsnotify.Rename) {
+			w.renamed = renamedFile{path: path, time: now}
+		}
+		w.mu.Unlock()
+		return
+	default:
+		return
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+	root := w.rootOf(path)
+	if root == "" {
+		return
+	}
+	depth := pathfilter.Depth(root, path)
+
+	if info.IsDir() {
+		if op == OpCreate && !w.filter().SkipDir(path, depth) {
+			if err := w.addTree(root, path, true); err != nil {
+				logger.Warn("添加目录监听失败", "path", path, "error", err)
+			}
+		}
+		return
+	}
+	if !info.Mode().IsRegular() || w.filter().SkipFile(path, depth) {
+		return
+	}
+	if op == OpCreate {
+		if from := w.renamedFrom(path, now); from != "" {
+			w.markRename(path, from, now)
+			return
+		}
+	}
+	w.mark(path, op, now)
+}
+
+// renamedFrom 取出与新建文件配对的改名前路径，没有时返回空串
+func (w *Watcher) renamedFrom(path string, now time.Time) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r := w.renamed
+	w.renamed = renamedFile{}
+	if r.path == "" || r.path == path || now.Sub(r.time) > renamePair {
+		return ""
+	}
+	return r.path
+}
+
+// markRename 记录改名得到的文件
+func (w *Watcher) markRename(path, from string, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p, ok := w.pending[path]
+	if !ok {
+		p = &pendingEvent{}
+		w.pending[path] = p
+	}
+	p.op |= OpCreate | OpRename
+	p.from = from
+	p.last = now
+}
+
+// mark 记录待上报的变更
+func (w *Watcher) mark(path string, op Op, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p, ok := w.pending[path]
+	if !ok {
+		p = &pendingEvent{}
+		w.pending[path] = p
+	}
+	p.op |= op
+	p.last = now
+}
+
+// settled 取出静默时间已满的事件
+func (w *Watcher) settled(now time.Time) []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []Event
+	for path, p := range w.pending {
+		if now.Sub(p.last) < w.opts.Settle {
+			continue
+		}
+		events = append(events, Event{Path: path, Op: p.op, From: p.from})
+		delete(w.pending, path)
+	}
+	return events
+}
+
+// rootOf 返回路径所属的监听根目录 (最长匹配)
+func (w *Watcher) rootOf(path string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	best := ""
+	for _, root := range w.roots {
+		prefix := root
+		if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+			prefix += string(filepath.Separator)
+		}
+		if path != root && !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+func (w *Watcher) filter() *pathfilter.Filter {
+	if w.opts.Filter != nil {
+		return w.opts.Filter
+	}
+	return pathfilter.Default()
+}
+
+// Close 关闭监控器，Run 随之返回
+func (w *Watcher) Close() error {
+	return w.fw.Close()
+}
@@ -0,0 +1,118 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/pathfilter"
+)
+
+func newTestWatcher(t *testing.T, opts pathfilter.Options) (*Watcher, string, <-chan Event) {
+	t.Helper()
+	f, err := pathfilter.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(Options{Filter: f, Settle: 50 * time.Millisecond})
+	if err != nil {
+		t.Skipf("fsnotify 不可用: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+
+	dir := t.TempDir()
+	if err := w.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan Event, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go w.Run(ctx, func(ev Event) { events <- ev })
+	return w, dir, events
+}
+
+func waitEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(3 * time.Second):
+		t.Fatal("未收到文件事件")
+		return Event{}
+	}
+}
+
+func TestWatcher_MergesWrites(t *testing.T) {
+	_, dir, events := newTestWatcher(t, pathfilter.Options{})
+
+	target := filepath.Join(dir, "a.txt")
+	f, err := os.Create(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		f.WriteString("x")
+		f.Sync()
+	}
+	f.Close()
+
+	ev := waitEvent(t, events)
+	if ev.Path != target || !ev.Op.Has(OpCreate) {
+		t.Errorf("事件 = %+v", ev)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("多次写入应合并为一个事件，多余事件 %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcher_NewSubdirAndFilter(t *testing.T) {
+	_, dir, events := newTestWatcher(t, pathfilter.Options{ExcludeGlobs: []string{"*.tmp"}})
+
+	sub := filepath.Join(dir, "sub", "deep")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(sub, "skip.tmp"), []byte("x"), 0644)
+	target := filepath.Join(sub, "b.doc")
+	if err := os.WriteFile(target, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := waitEvent(t, events)
+	if ev.Path != target {
+		t.Errorf("新建子目录中的文件事件 = %+v, want %s", ev, target)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("被排除的文件不应上报: %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcher_Rename(t *testing.T) {
+	_, dir, events := newTestWatcher(t, pathfilter.Options{})
+
+	src := filepath.Join(dir, "a.doc")
+	if err := os.WriteFile(src, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitEvent(t, events)
+
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "sub", "b.doc")
+	time.Sleep(100 * time.Millisecond)
+	if err := os.Rename(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	ev := waitEvent(t, events)
+	if ev.Path != dst || !ev.Op.Has(OpRename) || !ev.Op.Has(OpCreate) || ev.From != src {
+		t.Errorf("改名事件 = %+v", ev)
+	}
+}
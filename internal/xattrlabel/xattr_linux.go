@@ -0,0 +1,43 @@
+//go:build linux
+
+package xattrlabel
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errNoAttr 属性不存在
+var errNoAttr = syscall.ENODATA
+
+// get 读取属性值，属性不存在时返回 errNoAttr
+func get(path, name string) (string, error) {
+	buf := make([]byte, 256)
+	for {
+		n, err := syscall.Getxattr(path, name, buf)
+		if errors.Is(err, syscall.ERANGE) && len(buf) < 64*1024 {
+			buf = make([]byte, len(buf)*4)
+			continue
+		}
+		if err != nil {
+			return "", wrap(err)
+		}
+		return string(buf[:n]), nil
+	}
+}
+
+func set(path, name, value string) error {
+	return wrap(syscall.Setxattr(path, name, []byte(value), 0))
+}
+
+func remove(path, name string) error {
+	return wrap(syscall.Removexattr(path, name))
+}
+
+// wrap 文件系统不支持扩展属性时转换为 ErrUnsupported
+func wrap(err error) error {
+	if errors.Is(err, syscall.ENOTSUP) {
+		return ErrUnsupported
+	}
+	return err
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+
+package xattrlabel
+
+import "errors"
+
+// errNoAttr 属性不存在
+var errNoAttr = errors.New("no such attribute")
+
+// 非 Linux 平台不支持扩展属性
+func get(path, name string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func set(path, name, value string) error {
+	return ErrUnsupported
+}
+
+func remove(path, name string) error {
+	return ErrUnsupported
+}
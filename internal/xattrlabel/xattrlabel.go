@@ -0,0 +1,143 @@
+// Package xattrlabel 文件扩展属性 (xattr) 中的密级分类标签
+// 标签保存在 security.dlp.* 命名空间：外部系统 (人工定密、文档管理系统) 标注的密级可作为检测信号，
+// 处置策略也可将判定结果写回文件，供 samba (vfs_streams_xattr)、备份软件等下游工具按标签处理。
+// security 命名空间只有具备 CAP_SYS_ADMIN 的进程可以写入，普通用户无法篡改或抹去标签
+package xattrlabel
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+// Prefix 标签属性的命名空间
+const Prefix = "security.dlp."
+
+// 标签属性，值均为 UTF-8 文本
+const (
+	AttrLevel  = Prefix + "level"  // 密级名称: 绝密/机密/秘密/内部
+	AttrRule   = Prefix + "rule"   // 判定依据的策略 ID
+	AttrModule = Prefix + "module" // 判定的检测模块
+	AttrSource = Prefix + "source" // 标注来源
+	AttrTime   = Prefix + "time"   // 标注时间 (RFC 3339)
+)
+
+// SourceAgent 本程序写入的标签来源
+const SourceAgent = "linuxFileWatcher"
+
+var (
+	// ErrUnsupported 平台或文件系统不支持扩展属性
+	ErrUnsupported = errors.New("extended attributes not supported")
+	// ErrInvalidLevel 密级名称无法识别
+	ErrInvalidLevel = errors.New("invalid classification level")
+)
+
+// Label 分类标签
+type Label struct {
+	Level  model.SecretLevel
+	RuleID int64  // 0 表示未记录
+	Module string // 为空表示未记录
+	Source string // 为空表示未记录
+	Time   time.Time
+}
+
+// levelNames 密级与标签中的名称
+var levelNames = map[model.SecretLevel]model.SecretLevelStr{
+	model.LevelTopSecret:    model.SecretLevelTopSecret,
+	model.LevelSecret:       model.SecretLevelConfidential, // 机密
+	model.LevelConfidential: model.SecretLevelSecret,       // 秘密
+	model.LevelInternal:     model.SecretLevelInternal,
+}
+
+// LevelName 密级在标签中的名称，未知密级返回空串
+func LevelName(l model.SecretLevel) string {
+	return string(levelNames[l])
+}
+
+// ParseLevel 解析标签中的密级名称
+func ParseLevel(name string) (model.SecretLevel, error) {
+	name = strings.TrimSpace(name)
+	for l, n := range levelNames {
+		if string(n) == name {
+			return l, nil
+		}
+	}
+	return model.LevelUnknown, fmt.Errorf("%w: %q", ErrInvalidLevel, name)
+}
+
+// Read 读取 path 的分类标签，没有标签时返回 nil, nil
+// 文件系统不支持扩展属性时同样视为没有标签
+func Read(path string) (*Label, error) {
+	level, err := get(path, AttrLevel)
+	if err != nil || level == "" {
+		if errors.Is(err, errNoAttr) || errors.Is(err, ErrUnsupported) {
+			err = nil
+		}
+		return nil, err
+	}
+
+	l := &Label{}
+	if l.Level, err = ParseLevel(level); err != nil {
+		return nil, err
+	}
+	// 其余属性可选，读取失败时忽略
+	if v, _ := get(path, AttrRule); v != "" {
+		l.RuleID, _ = strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	}
+	l.Module, _ = get(path, AttrModule)
+	l.Source, _ = get(path, AttrSource)
+	if v, _ := get(path, AttrTime); v != "" {
+		l.Time, _ = time.Parse(time.RFC3339, strings.TrimSpace(v))
+	}
+	return l, nil
+}
+
+// Write 将标签写入 path，未记录的可选属性从文件上删除，避免残留上一次标注的内容
+func Write(path string, l Label) error {
+	level := LevelName(l.Level)
+	if level == "" {
+		return fmt.Errorf("%w: %d", ErrInvalidLevel, l.Level)
+	}
+	if err := set(path, AttrLevel, level); err != nil {
+		return err
+	}
+
+	rule, ts := "", ""
+	if l.RuleID != 0 {
+		rule = strconv.FormatInt(l.RuleID, 10)
+	}
+	if !l.Time.IsZero() {
+		ts = l.Time.Format(time.RFC3339)
+	}
+	for _, a := range [][2]string{{AttrRule, rule}, {AttrModule, l.Module}, {AttrSource, l.Source}, {AttrTime, ts}} {
+		if err := setOrRemove(path, a[0], a[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove 删除 path 上的全部标签属性
+func Remove(path string) error {
+	for _, name := range []string{AttrLevel, AttrRule, AttrModule, AttrSource, AttrTime} {
+		if err := remove(path, name); err != nil && !errors.Is(err, errNoAttr) {
+			return err
+		}
+	}
+	return nil
+}
+
+// setOrRemove value 为空时删除属性
+func setOrRemove(path, name, value string) error {
+	if value != "" {
+		return set(path, name, value)
+	}
+	if err := remove(path, name); err != nil && !errors.Is(err, errNoAttr) {
+		return err
+	}
+	return nil
+}
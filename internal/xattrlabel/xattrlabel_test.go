@@ -0,0 +1,89 @@
+package xattrlabel
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"linuxFileWatcher/internal/model"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []model.SecretLevel{model.LevelTopSecret, model.LevelSecret, model.LevelConfidential, model.LevelInternal} {
+		got, err := ParseLevel(" " + LevelName(l) + "\n")
+		if err != nil || got != l {
+			t.Errorf("ParseLevel(LevelName(%d)) = %d, %v", l, got, err)
+		}
+	}
+	if LevelName(model.LevelSecret) != "机密" {
+		t.Errorf("LevelName(LevelSecret) = %q", LevelName(model.LevelSecret))
+	}
+	if _, err := ParseLevel("top secret"); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("ParseLevel() err = %v", err)
+	}
+}
+
+// writableFile 创建支持 security 命名空间扩展属性的测试文件，不支持或无权限时跳过
+func writableFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "a.docx")
+	if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := set(path, AttrLevel, "内部"); err != nil {
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			t.Skip("无法写入 security 扩展属性:", err)
+		}
+		t.Fatal(err)
+	}
+	if err := Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadWrite(t *testing.T) {
+	path := writableFile(t)
+
+	if l, err := Read(path); l != nil || err != nil {
+		t.Fatalf("无标签时 Read() = %+v, %v", l, err)
+	}
+
+	ts := time.Date(2026, 10, 16, 9, 30, 0, 0, time.Local)
+	want := Label{Level: model.LevelTopSecret, RuleID: 42, Module: model.ModuleKeywordDetect, Source: SourceAgent, Time: ts}
+	if err := Write(path, want); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := Read(path)
+	if err != nil || got == nil || got.Level != want.Level || got.RuleID != 42 || got.Module != want.Module ||
+		got.Source != SourceAgent || !got.Time.Equal(ts) {
+		t.Fatalf("Read() = %+v, %v", got, err)
+	}
+
+	// 重新标注时清除未记录的可选属性
+	if err := Write(path, Label{Level: model.LevelInternal}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = Read(path)
+	if got == nil || got.Level != model.LevelInternal || got.RuleID != 0 || got.Module != "" || got.Source != "" || !got.Time.IsZero() {
+		t.Errorf("重新标注后 Read() = %+v", got)
+	}
+
+	if err := Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if l, _ := Read(path); l != nil {
+		t.Errorf("Remove() 后 Read() = %+v", l)
+	}
+}
+
+func TestRead_InvalidLevel(t *testing.T) {
+	path := writableFile(t)
+	set(path, AttrLevel, "unknown")
+	if _, err := Read(path); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("Read() err = %v", err)
+	}
+}